@@ -0,0 +1,292 @@
+package fault
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// maxAdminBodySize is the largest form body the AdminHandler of a Manager reads.
+const maxAdminBodySize = 64 << 10
+
+var (
+	// ErrNilAdminAuthorizer when a nil AdminAuthorizer is passed.
+	ErrNilAdminAuthorizer = errors.New("admin authorizer cannot be nil")
+	// ErrApprovalRequired when an admin change needs two-person approval that the handler it was
+	// made to cannot give.
+	ErrApprovalRequired = errors.New("change needs two-person approval through the runtime admin handler")
+)
+
+// FaultChange is a change the AdminHandler of a Manager is asked to make to a Fault.
+type FaultChange struct {
+	// Fault is the Fault to change.
+	Fault *Fault
+	// Enabled, if set, is the enabled state to set.
+	Enabled *bool
+	// Participation, if set, is the participation to set.
+	Participation *float32
+}
+
+// participation returns the participation the Fault has once c is made, or 0.0 if it is disabled.
+func (c FaultChange) participation() float32 {
+	enabled := c.Fault.Enabled()
+	if c.Enabled != nil {
+		enabled = *c.Enabled
+	}
+	if !enabled {
+		return 0.0
+	}
+
+	if c.Participation != nil {
+		return *c.Participation
+	}
+
+	return c.Fault.Participation()
+}
+
+// AdminAuthorizer decides who may use the AdminHandler of a Manager. A Runtime is an
+// AdminAuthorizer that applies its AdminTokens and two-person approval.
+type AdminAuthorizer interface {
+	// AuthorizeAdmin returns nil if r may make c, or may only read if c is nil. It returns
+	// ErrUnauthorized if r does not say who it is, and another error if it may not.
+	AuthorizeAdmin(r *http.Request, c *FaultChange) error
+}
+
+// AdminAuthorizerFunc is an AdminAuthorizer made from a function.
+type AdminAuthorizerFunc func(r *http.Request, c *FaultChange) error
+
+// AuthorizeAdmin calls the function.
+func (f AdminAuthorizerFunc) AuthorizeAdmin(r *http.Request, c *FaultChange) error {
+	return f(r, c)
+}
+
+// FaultStatus is the current configuration of a Fault, as served by the AdminHandler of a Manager.
+type FaultStatus struct {
+	// Name is the name of the Fault.
+	Name string `json:"name"`
+	// Injector is InjectorString of the Fault's Injector.
+	Injector string `json:"injector"`
+	// Enabled is true if the Fault is enabled.
+	Enabled bool `json:"enabled"`
+	// Participation is the percent of requests that run the Injector.
+	Participation float32 `json:"participation"`
+	// Labels are the labels of the Fault, set with WithLabels.
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// newFaultStatus returns the FaultStatus of f.
+func newFaultStatus(f *Fault) FaultStatus {
+	return FaultStatus{
+		Name:          f.Name(),
+		Injector:      InjectorString(f.Injector()),
+		Enabled:       f.Enabled(),
+		Participation: f.Participation(),
+		Labels:        f.Labels(),
+	}
+}
+
+// AdminHandler returns an http.Handler that controls the managed Faults at runtime. GET on the root
+// responds with the FaultStatus of every Fault as a JSON array, and GET on "/{name}" with the
+// FaultStatus of one. POST on "/{name}" with "enabled" and "participation" in the query string or
+// form changes the Fault and responds with its new FaultStatus; either may be left out. During a
+// change freeze it responds 409 Conflict to changes other than disabling the Fault.
+//
+// Every request, reads included, must be allowed by auth, such as the Runtime whose AdminTokens
+// and two-person approval also govern its own AdminHandler. It responds 401 Unauthorized if auth
+// returns ErrUnauthorized and 403 Forbidden if it returns another error. A nil auth refuses every
+// request. Mount it with http.StripPrefix, for example at /debug/faults/.
+func (m *Manager) AdminHandler(auth AdminAuthorizer) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := strings.Trim(r.URL.Path, "/")
+		if name == "" {
+			if r.Method != http.MethodGet {
+				w.Header().Set("Allow", http.MethodGet)
+				http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+				return
+			}
+
+			if !authorizeAdmin(w, r, auth, nil) {
+				return
+			}
+
+			faults := m.Faults()
+			statuses := make([]FaultStatus, len(faults))
+			for idx, f := range faults {
+				statuses[idx] = newFaultStatus(f)
+			}
+
+			writeAdminJSON(w, statuses)
+			return
+		}
+
+		// requests are authorized before the Fault is looked up, so that callers who may not read
+		// cannot tell which Faults exist
+		f := m.Fault(name)
+		switch r.Method {
+		case http.MethodGet:
+			if !authorizeAdmin(w, r, auth, nil) {
+				return
+			}
+		case http.MethodPost:
+			if !m.changeFault(w, r, auth, f) {
+				return
+			}
+		default:
+			w.Header().Set("Allow", http.MethodGet+", "+http.MethodPost)
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		if f == nil {
+			http.Error(w, "fault not found", http.StatusNotFound)
+			return
+		}
+
+		writeAdminJSON(w, newFaultStatus(f))
+	})
+}
+
+// changeFault authorizes and makes the change to f in the form of r, and responds with the error if
+// it fails. It returns true if r may go on. A change to a Fault that does not exist is authorized
+// as a read and makes nothing.
+func (m *Manager) changeFault(w http.ResponseWriter, r *http.Request, auth AdminAuthorizer, f *Fault) bool {
+	if f == nil {
+		return authorizeAdmin(w, r, auth, nil)
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxAdminBodySize)
+	// an invalid form changes nothing, but is still authorized as a change
+	c, err := parseFaultChange(f, r)
+	if !authorizeAdmin(w, r, auth, &c) {
+		return false
+	}
+
+	if fr, ok := m.Freeze(r.Context()); ok && (err != nil || !disablesOnly(c)) {
+		http.Error(w, fmt.Sprintf("%v: %s", ErrFrozen, fr.Reason), http.StatusConflict)
+		return false
+	}
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return false
+	}
+
+	code, err := updateFault(c)
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return false
+	}
+
+	return true
+}
+
+// authorizeAdmin asks auth whether r may make c, and responds with the error if not. It returns
+// true if r may go on.
+func authorizeAdmin(w http.ResponseWriter, r *http.Request, auth AdminAuthorizer, c *FaultChange) bool {
+	if auth == nil {
+		http.Error(w, ErrNilAdminAuthorizer.Error(), http.StatusForbidden)
+		return false
+	}
+
+	err := auth.AuthorizeAdmin(r, c)
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, ErrUnauthorized):
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+	default:
+		http.Error(w, err.Error(), http.StatusForbidden)
+	}
+
+	return false
+}
+
+// parseFaultChange returns the change to f in the form of r. If the form is invalid it returns the
+// error and a change that changes nothing, since nothing is changed unless both the enabled state
+// and the participation are valid.
+func parseFaultChange(f *Fault, r *http.Request) (FaultChange, error) {
+	none := FaultChange{Fault: f}
+
+	err := r.ParseForm()
+	if err != nil {
+		return none, err
+	}
+
+	c := FaultChange{Fault: f}
+	if v := r.Form.Get("enabled"); v != "" {
+		e, err := strconv.ParseBool(v)
+		if err != nil {
+			return none, err
+		}
+		c.Enabled = &e
+	}
+
+	if v := r.Form.Get("participation"); v != "" {
+		p, err := strconv.ParseFloat(v, 32)
+		if err != nil {
+			return none, err
+		}
+		if p < 0.0 || p > 1.0 {
+			return none, ErrInvalidPercent
+		}
+		p32 := float32(p)
+		c.Participation = &p32
+	}
+
+	return c, nil
+}
+
+// updateFault makes c. It returns the status code to respond with if it fails.
+func updateFault(c FaultChange) (int, error) {
+	if c.Participation != nil {
+		err := c.Fault.SetParticipation(*c.Participation)
+		if errors.Is(err, ErrParticipationIncrease) {
+			return http.StatusConflict, err
+		}
+		if err != nil {
+			return http.StatusBadRequest, err
+		}
+	}
+
+	if c.Enabled != nil {
+		c.Fault.SetEnabled(*c.Enabled)
+	}
+
+	return http.StatusOK, nil
+}
+
+// disablesOnly returns true if c only disables a Fault, which is allowed during a change freeze.
+func disablesOnly(c FaultChange) bool {
+	return c.Participation == nil && c.Enabled != nil && !*c.Enabled
+}
+
+// writeAdminJSON responds with v as JSON.
+func writeAdminJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// NewAdminHandler returns the AdminHandler of a Manager that holds faults, allowed by auth, for
+// services that do not run their Faults with a Manager. It returns ErrNilAdminAuthorizer if auth is
+// nil, and ErrNilFault or ErrDuplicateFault if faults cannot be managed together.
+func NewAdminHandler(auth AdminAuthorizer, faults ...*Fault) (http.Handler, error) {
+	if auth == nil {
+		return nil, ErrNilAdminAuthorizer
+	}
+
+	m, err := NewManager()
+	if err != nil {
+		return nil, err
+	}
+
+	err = m.Add(faults...)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.AdminHandler(auth), nil
+}
@@ -0,0 +1,429 @@
+package fault
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/github/go-fault/faulttest"
+)
+
+// testAdminAllowAll is an AdminAuthorizer that allows every request.
+var testAdminAllowAll = AdminAuthorizerFunc(func(*http.Request, *FaultChange) error { return nil })
+
+// TestManagerAdminHandler tests Manager.AdminHandler.
+func TestManagerAdminHandler(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		giveMethod    string
+		givePath      string
+		giveForm      url.Values
+		wantCode      int
+		wantAllow     string
+		wantStatuses  []FaultStatus
+		wantStatus    FaultStatus
+		wantUnchanged bool
+	}{
+		{
+			name:       "list",
+			giveMethod: http.MethodGet,
+			givePath:   "/",
+			wantCode:   http.StatusOK,
+			wantStatuses: []FaultStatus{
+				{Name: "a", Injector: "testInjectorNoop", Enabled: true, Participation: 0.5},
+				{
+					Name:          "b",
+					Labels:        map[string]string{"team": "checkout"},
+					Injector:      "testInjectorNoop",
+					Enabled:       false,
+					Participation: 0,
+				},
+			},
+		},
+		{
+			name:       "list without slash",
+			giveMethod: http.MethodGet,
+			givePath:   "",
+			wantCode:   http.StatusOK,
+			wantStatuses: []FaultStatus{
+				{Name: "a", Injector: "testInjectorNoop", Enabled: true, Participation: 0.5},
+				{
+					Name:          "b",
+					Labels:        map[string]string{"team": "checkout"},
+					Injector:      "testInjectorNoop",
+					Enabled:       false,
+					Participation: 0,
+				},
+			},
+		},
+		{
+			name:       "list method not allowed",
+			giveMethod: http.MethodPost,
+			givePath:   "/",
+			wantCode:   http.StatusMethodNotAllowed,
+			wantAllow:  http.MethodGet,
+		},
+		{
+			name:       "get",
+			giveMethod: http.MethodGet,
+			givePath:   "/a",
+			wantCode:   http.StatusOK,
+			wantStatus: FaultStatus{Name: "a", Injector: "testInjectorNoop", Enabled: true, Participation: 0.5},
+		},
+		{
+			name:       "not found",
+			giveMethod: http.MethodGet,
+			givePath:   "/c",
+			wantCode:   http.StatusNotFound,
+		},
+		{
+			name:       "method not allowed",
+			giveMethod: http.MethodDelete,
+			givePath:   "/a",
+			wantCode:   http.StatusMethodNotAllowed,
+			wantAllow:  "GET, POST",
+		},
+		{
+			name:       "enable",
+			giveMethod: http.MethodPost,
+			givePath:   "/b",
+			giveForm:   url.Values{"enabled": {"true"}},
+			wantCode:   http.StatusOK,
+			wantStatus: FaultStatus{
+				Name:          "b",
+				Labels:        map[string]string{"team": "checkout"},
+				Injector:      "testInjectorNoop",
+				Enabled:       true,
+				Participation: 0,
+			},
+		},
+		{
+			name:       "disable and lower",
+			giveMethod: http.MethodPost,
+			givePath:   "/a/",
+			giveForm:   url.Values{"enabled": {"false"}, "participation": {"0.25"}},
+			wantCode:   http.StatusOK,
+			wantStatus: FaultStatus{Name: "a", Injector: "testInjectorNoop", Enabled: false, Participation: 0.25},
+		},
+		{
+			name:       "raise within limit",
+			giveMethod: http.MethodPost,
+			givePath:   "/a",
+			giveForm:   url.Values{"participation": {"0.55"}},
+			wantCode:   http.StatusOK,
+			wantStatus: FaultStatus{Name: "a", Injector: "testInjectorNoop", Enabled: true, Participation: 0.55},
+		},
+		{
+			name:       "empty form",
+			giveMethod: http.MethodPost,
+			givePath:   "/a",
+			wantCode:   http.StatusOK,
+			wantStatus: FaultStatus{Name: "a", Injector: "testInjectorNoop", Enabled: true, Participation: 0.5},
+		},
+		{
+			name:          "invalid enabled",
+			giveMethod:    http.MethodPost,
+			givePath:      "/a",
+			giveForm:      url.Values{"enabled": {"maybe"}},
+			wantCode:      http.StatusBadRequest,
+			wantUnchanged: true,
+		},
+		{
+			name:          "invalid participation",
+			giveMethod:    http.MethodPost,
+			givePath:      "/a",
+			giveForm:      url.Values{"enabled": {"false"}, "participation": {"half"}},
+			wantCode:      http.StatusBadRequest,
+			wantUnchanged: true,
+		},
+		{
+			name:          "participation out of range",
+			giveMethod:    http.MethodPost,
+			givePath:      "/a",
+			giveForm:      url.Values{"enabled": {"false"}, "participation": {"1.5"}},
+			wantCode:      http.StatusBadRequest,
+			wantUnchanged: true,
+		},
+		{
+			name:          "participation beyond limit",
+			giveMethod:    http.MethodPost,
+			givePath:      "/a",
+			giveForm:      url.Values{"enabled": {"false"}, "participation": {"1.0"}},
+			wantCode:      http.StatusConflict,
+			wantUnchanged: true,
+		},
+		{
+			name:          "invalid query",
+			giveMethod:    http.MethodPost,
+			givePath:      "/a?enabled=%zz",
+			wantCode:      http.StatusBadRequest,
+			wantUnchanged: true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			m, err := NewManager(
+				WithClock(faulttest.NewClock(time.Time{})),
+				WithParticipationLimit(0.1, time.Minute),
+			)
+			assert.NoError(t, err)
+
+			a, err := NewFault(newTestInjectorNoop(), WithName("a"), WithEnabled(true))
+			assert.NoError(t, err)
+			b, err := NewFault(newTestInjectorNoop(), WithName("b"), WithLabels(map[string]string{"team": "checkout"}))
+			assert.NoError(t, err)
+			assert.NoError(t, a.SetParticipation(0.5))
+			assert.NoError(t, m.Add(a, b))
+
+			req := httptest.NewRequest(tt.giveMethod, "http://example.com"+tt.givePath, strings.NewReader(tt.giveForm.Encode()))
+			if tt.giveForm != nil {
+				req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			}
+
+			rr := httptest.NewRecorder()
+			m.AdminHandler(testAdminAllowAll).ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.wantCode, rr.Code)
+			assert.Equal(t, tt.wantAllow, rr.Header().Get("Allow"))
+
+			if tt.wantUnchanged {
+				assert.True(t, a.Enabled())
+				assert.Equal(t, float32(0.5), a.Participation())
+			}
+			if tt.wantCode != http.StatusOK {
+				return
+			}
+
+			assert.Equal(t, "application/json; charset=utf-8", rr.Header().Get("Content-Type"))
+
+			if tt.wantStatuses != nil {
+				var got []FaultStatus
+				assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &got))
+				assert.Equal(t, tt.wantStatuses, got)
+				return
+			}
+
+			var got FaultStatus
+			assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &got))
+			assert.Equal(t, tt.wantStatus, got)
+		})
+	}
+}
+
+// TestNewAdminHandler tests NewAdminHandler.
+func TestNewAdminHandler(t *testing.T) {
+	t.Parallel()
+
+	a, err := NewFault(newTestInjectorNoop(), WithName("a"))
+	assert.NoError(t, err)
+	b, err := NewFault(newTestInjectorNoop(), WithName("a"))
+	assert.NoError(t, err)
+
+	h, err := NewAdminHandler(testAdminAllowAll, a)
+	assert.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/a?enabled=true", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.True(t, a.Enabled())
+
+	h, err = NewAdminHandler(nil, a)
+	assert.True(t, errors.Is(err, ErrNilAdminAuthorizer))
+	assert.Nil(t, h)
+
+	h, err = NewAdminHandler(testAdminAllowAll, a, nil)
+	assert.True(t, errors.Is(err, ErrNilFault))
+	assert.Nil(t, h)
+
+	h, err = NewAdminHandler(testAdminAllowAll, a, b)
+	assert.True(t, errors.Is(err, ErrDuplicateFault))
+	assert.Nil(t, h)
+}
+
+// TestManagerAdminHandlerAuthorizer tests that Manager.AdminHandler only serves the requests its
+// AdminAuthorizer allows, with the AdminTokens and two-person approval of a Runtime.
+func TestManagerAdminHandlerAuthorizer(t *testing.T) {
+	t.Parallel()
+
+	newManager := func() (*Manager, *Fault, *Fault) {
+		m, err := NewManager()
+		assert.NoError(t, err)
+		errs, err := NewFault(newTestInjector500s(), WithName("errors"))
+		assert.NoError(t, err)
+		ri, err := NewRejectInjector()
+		assert.NoError(t, err)
+		reject, err := NewFault(ri, WithName("reject"))
+		assert.NoError(t, err)
+		assert.NoError(t, m.Add(errs, reject))
+
+		return m, errs, reject
+	}
+
+	// a Runtime without tokens refuses every request
+	open, err := NewRuntime()
+	assert.NoError(t, err)
+
+	rt, err := NewRuntime(WithApprovalThreshold(0.1))
+	assert.NoError(t, err)
+	writer, err := rt.IssueToken(WithTokenName("alice"), WithTokenFaults("errors", "reject"),
+		WithTokenRange(0.0, 0.5))
+	assert.NoError(t, err)
+	reader, err := rt.IssueToken(WithTokenOperations(AdminRead))
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		giveAuth   AdminAuthorizer
+		giveToken  *AdminToken
+		giveMethod string
+		givePath   string
+		wantCode   int
+		wantBody   string
+	}{
+		{
+			name:       "nil authorizer",
+			giveMethod: http.MethodGet,
+			givePath:   "/",
+			wantCode:   http.StatusForbidden,
+			wantBody:   ErrNilAdminAuthorizer.Error() + "\n",
+		},
+		{
+			name:       "no token",
+			giveAuth:   rt,
+			giveMethod: http.MethodGet,
+			givePath:   "/",
+			wantCode:   http.StatusUnauthorized,
+			wantBody:   ErrUnauthorized.Error() + "\n",
+		},
+		{
+			name:       "no tokens issued",
+			giveAuth:   open,
+			giveMethod: http.MethodPost,
+			givePath:   "/errors?enabled=true&participation=0.05",
+			wantCode:   http.StatusUnauthorized,
+			wantBody:   ErrUnauthorized.Error() + "\n",
+		},
+		{
+			name:       "unknown fault without token",
+			giveAuth:   rt,
+			giveMethod: http.MethodGet,
+			givePath:   "/unknown",
+			wantCode:   http.StatusUnauthorized,
+			wantBody:   ErrUnauthorized.Error() + "\n",
+		},
+		{
+			name:       "change unknown fault without token",
+			giveAuth:   rt,
+			giveMethod: http.MethodPost,
+			givePath:   "/unknown?enabled=true",
+			wantCode:   http.StatusUnauthorized,
+			wantBody:   ErrUnauthorized.Error() + "\n",
+		},
+		{
+			name:       "unknown fault",
+			giveAuth:   rt,
+			giveToken:  reader,
+			giveMethod: http.MethodGet,
+			givePath:   "/unknown",
+			wantCode:   http.StatusNotFound,
+		},
+		{
+			name:       "read",
+			giveAuth:   rt,
+			giveToken:  reader,
+			giveMethod: http.MethodGet,
+			givePath:   "/errors",
+			wantCode:   http.StatusOK,
+		},
+		{
+			name:       "read only token",
+			giveAuth:   rt,
+			giveToken:  reader,
+			giveMethod: http.MethodPost,
+			givePath:   "/errors?enabled=true&participation=0.05",
+			wantCode:   http.StatusForbidden,
+			wantBody:   ErrOutOfScope.Error() + "\n",
+		},
+		{
+			name:       "write",
+			giveAuth:   rt,
+			giveToken:  writer,
+			giveMethod: http.MethodPost,
+			givePath:   "/errors?enabled=true&participation=0.05",
+			wantCode:   http.StatusOK,
+		},
+		{
+			name:       "out of range",
+			giveAuth:   rt,
+			giveToken:  writer,
+			giveMethod: http.MethodPost,
+			givePath:   "/errors?enabled=true&participation=0.75",
+			wantCode:   http.StatusForbidden,
+			wantBody:   ErrOutOfScope.Error() + "\n",
+		},
+		{
+			name:       "above approval threshold",
+			giveAuth:   rt,
+			giveToken:  writer,
+			giveMethod: http.MethodPost,
+			givePath:   "/errors?enabled=true&participation=0.25",
+			wantCode:   http.StatusForbidden,
+			wantBody:   ErrApprovalRequired.Error() + "\n",
+		},
+		{
+			name:       "destructive",
+			giveAuth:   rt,
+			giveToken:  writer,
+			giveMethod: http.MethodPost,
+			givePath:   "/reject?enabled=true&participation=0.05",
+			wantCode:   http.StatusForbidden,
+			wantBody:   ErrApprovalRequired.Error() + "\n",
+		},
+		{
+			name:       "disable destructive",
+			giveAuth:   rt,
+			giveToken:  writer,
+			giveMethod: http.MethodPost,
+			givePath:   "/reject?enabled=false",
+			wantCode:   http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			m, errs, reject := newManager()
+
+			req := httptest.NewRequest(tt.giveMethod, tt.givePath, nil)
+			if tt.giveToken != nil {
+				req.Header.Set("Authorization", "Bearer "+tt.giveToken.Secret())
+			}
+			rr := httptest.NewRecorder()
+			m.AdminHandler(tt.giveAuth).ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.wantCode, rr.Code)
+			if tt.wantBody != "" {
+				assert.Equal(t, tt.wantBody, rr.Body.String())
+			}
+			if tt.wantCode != http.StatusOK {
+				assert.False(t, errs.Enabled())
+				assert.False(t, reject.Enabled())
+			}
+		})
+	}
+}
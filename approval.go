@@ -0,0 +1,354 @@
+package fault
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// OperatorHeader is the request header that names the operator making an admin change, unless
+// WithOperatorFunc is passed. Any client can set it, so it never names the operator of an
+// approval.
+const OperatorHeader = "X-Fault-Operator"
+
+// ApprovalHeader is the request header that carries the token of a PendingChange to approve.
+const ApprovalHeader = "X-Fault-Approve"
+
+// defaultApprovalTTL is how long a PendingChange waits for approval by default.
+const defaultApprovalTTL = 15 * time.Minute
+
+var (
+	// ErrNoOperator when an admin change that needs approval does not name its operator, or an
+	// approval is not made with a named AdminToken or by an operator named by WithOperatorFunc.
+	ErrNoOperator = errors.New("operator is required")
+	// ErrSelfApproval when an operator approves their own change.
+	ErrSelfApproval = errors.New("a change must be approved by a second operator")
+	// ErrUnknownApproval when an approval token is unknown or expired.
+	ErrUnknownApproval = errors.New("approval token is unknown or expired")
+)
+
+// PendingChange is an admin change to a Runtime that waits for a second operator to approve it.
+type PendingChange struct {
+	// Token is the confirmation token the second operator sends to approve the change.
+	Token string `json:"token"`
+	// Operator is the operator that requested the change.
+	Operator string `json:"operator"`
+	// Values are the keys to set and their values.
+	Values map[string]string `json:"values"`
+	// Expires is when the change is dropped if it has not been approved.
+	Expires time.Time `json:"expires"`
+}
+
+// approval is the two-person approval configuration of a Runtime.
+type approval struct {
+	threshold float32
+	ttl       time.Duration
+	// operator, if set, names the operator of an admin request in place of the OperatorHeader.
+	operator func(r *http.Request) string
+	pending  map[string]PendingChange
+}
+
+// approvalConfig returns the approval configuration of r, creating it if it does not exist.
+func (r *Runtime) approvalConfig() *approval {
+	if r.approval == nil {
+		r.approval = &approval{
+			threshold: 1.0,
+			ttl:       defaultApprovalTTL,
+			pending:   map[string]PendingChange{},
+		}
+	}
+
+	return r.approval
+}
+
+// operatorFromHeader returns the OperatorHeader of req.
+func operatorFromHeader(req *http.Request) string {
+	return req.Header.Get(OperatorHeader)
+}
+
+type approvalThresholdOption float32
+
+func (o approvalThresholdOption) applyRuntime(r *Runtime) error {
+	if o < 0.0 || o > 1.0 {
+		return ErrInvalidPercent
+	}
+
+	r.approvalConfig().threshold = float32(o)
+
+	return nil
+}
+
+// WithApprovalThreshold turns on two-person approval for the AdminHandler. A change that raises
+// the participation of a bound Fault with a destructive Injector, such as a RejectInjector, or
+// raises any bound Fault above p, is not applied. It is held as a PendingChange until another
+// operator approves it by sending its token in the ApprovalHeader, with a named AdminToken or as
+// an operator named by WithOperatorFunc. Changes made with Set are not held. 0.0 <= p <= 1.0.
+func WithApprovalThreshold(p float32) RuntimeOption {
+	return approvalThresholdOption(p)
+}
+
+type approvalTTLOption time.Duration
+
+func (o approvalTTLOption) applyRuntime(r *Runtime) error {
+	if o <= 0 {
+		return ErrInvalidInterval
+	}
+
+	r.approvalConfig().ttl = time.Duration(o)
+
+	return nil
+}
+
+// WithApprovalTTL sets how long a PendingChange waits for approval. Default 15m. It also turns on
+// two-person approval, for destructive Injectors only unless WithApprovalThreshold is passed.
+func WithApprovalTTL(d time.Duration) RuntimeOption {
+	return approvalTTLOption(d)
+}
+
+type operatorFuncOption func(r *http.Request) string
+
+func (o operatorFuncOption) applyRuntime(r *Runtime) error {
+	r.approvalConfig().operator = o
+	return nil
+}
+
+// WithOperatorFunc sets the function that names the operator of an admin request, for example from
+// the identity an authenticating proxy or client certificate established. Without it, or a named
+// AdminToken, the OperatorHeader names the operator of a change but cannot approve one. It also
+// turns on two-person approval, for destructive Injectors only unless WithApprovalThreshold is
+// passed.
+func WithOperatorFunc(f func(r *http.Request) string) RuntimeOption {
+	return operatorFuncOption(f)
+}
+
+// needsApproval returns true if setting values would raise the participation of a bound Fault
+// with a destructive Injector, or raise a bound Fault above the approval threshold. The caller must
+// hold r.mtx.
+func (r *Runtime) needsApproval(values map[string]string) bool {
+	admin := r.adminWith(values)
+	for _, b := range r.bindings {
+		if _, ok := values[b.key]; !ok {
+			continue
+		}
+
+		p := fractionIn(admin, r.disk, b.key, b.def).Float32()
+		current := b.fault.participation.Load()
+		if p <= current {
+			continue
+		}
+
+		if IsDestructive(b.fault.Injector()) || p > r.approval.threshold {
+			return true
+		}
+	}
+
+	return false
+}
+
+// hold stores values as a PendingChange requested by operator and returns it.
+func (r *Runtime) hold(operator string, values map[string]string) PendingChange {
+	b := make([]byte, 16)
+	// crypto/rand does not fail on supported platforms
+	_, _ = rand.Read(b)
+
+	c := PendingChange{
+		Token:    hex.EncodeToString(b),
+		Operator: operator,
+		Values:   values,
+		Expires:  r.clock.Now().Add(r.approval.ttl),
+	}
+
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	r.expireApprovals()
+	r.approval.pending[c.Token] = c
+
+	return c
+}
+
+// expireApprovals drops the PendingChanges that have expired. The caller must hold r.mtx.
+func (r *Runtime) expireApprovals() {
+	now := r.clock.Now()
+	for token, c := range r.approval.pending {
+		if !now.Before(c.Expires) {
+			delete(r.approval.pending, token)
+		}
+	}
+}
+
+// Pending returns the admin changes that wait for approval, in order of expiry. It returns nil if
+// two-person approval is off.
+func (r *Runtime) Pending() []PendingChange {
+	if r.approval == nil {
+		return nil
+	}
+
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	r.expireApprovals()
+
+	pending := make([]PendingChange, 0, len(r.approval.pending))
+	for _, c := range r.approval.pending {
+		pending = append(pending, c)
+	}
+	sort.Slice(pending, func(i, j int) bool {
+		return pending[i].Expires.Before(pending[j].Expires)
+	})
+
+	return pending
+}
+
+// Approve applies the PendingChange with token on behalf of operator, who must not be the operator
+// that requested it. The change is dropped once approved, even if setting a value fails.
+func (r *Runtime) Approve(token, operator string) error {
+	return r.approve(token, operator, nil)
+}
+
+// approve is Approve for the holder of t, which must allow the PendingChange. A nil t allows
+// every PendingChange.
+func (r *Runtime) approve(token, operator string, t *AdminToken) error {
+	c, err := r.takePending(token, operator, t)
+	if err != nil {
+		return err
+	}
+
+	return r.setAll(c.Values)
+}
+
+// takePending removes and returns the PendingChange with token if operator, holding t, may approve
+// it.
+func (r *Runtime) takePending(token, operator string, t *AdminToken) (PendingChange, error) {
+	if operator == "" {
+		return PendingChange{}, ErrNoOperator
+	}
+
+	if r.approval == nil {
+		return PendingChange{}, ErrUnknownApproval
+	}
+
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	r.expireApprovals()
+
+	c, ok := r.approval.pending[token]
+	if !ok {
+		return PendingChange{}, ErrUnknownApproval
+	}
+
+	if c.Operator == operator {
+		return PendingChange{}, ErrSelfApproval
+	}
+
+	if !r.inScope(t, c.Values) {
+		return PendingChange{}, ErrOutOfScope
+	}
+
+	delete(r.approval.pending, token)
+
+	return c, nil
+}
+
+// setAll sets every key in values to its value, in order of key, and stops at the first error.
+func (r *Runtime) setAll(values map[string]string) error {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		err := r.Set(k, values[k])
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// serveApproval sets values for the AdminHandler on behalf of the holder of t, or approves or
+// holds them, and returns true if the AdminHandler should respond with the values of r.
+func (r *Runtime) serveApproval(
+	w http.ResponseWriter, req *http.Request, values map[string]string, t *AdminToken,
+) bool {
+	operator := r.operator(req, t)
+
+	var err error
+	if token := req.Header.Get(ApprovalHeader); token != "" {
+		err = permit(t, AdminApprove)
+		if err == nil {
+			err = r.approve(token, r.verifiedOperator(req, t), t)
+		}
+	} else if err = r.permitWrite(t, values); err == nil {
+		if r.heldForApproval(values) {
+			if operator == "" {
+				http.Error(w, ErrNoOperator.Error(), http.StatusForbidden)
+				return false
+			}
+
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			w.WriteHeader(http.StatusAccepted)
+			_ = json.NewEncoder(w).Encode(r.hold(operator, values))
+
+			return false
+		}
+
+		err = r.setAll(values)
+	}
+
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, ErrNoOperator), errors.Is(err, ErrSelfApproval), errors.Is(err, ErrOutOfScope):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, ErrUnknownApproval):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	default:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	}
+
+	return false
+}
+
+// operator returns the operator of req: the verified operator, or else the operator named by the
+// OperatorHeader.
+func (r *Runtime) operator(req *http.Request, t *AdminToken) string {
+	if operator := r.verifiedOperator(req, t); operator != "" {
+		return operator
+	}
+
+	return operatorFromHeader(req)
+}
+
+// verifiedOperator returns the operator of req that the client cannot name itself: the name of t,
+// or else the operator named by the WithOperatorFunc. It returns "" if there is neither.
+func (r *Runtime) verifiedOperator(req *http.Request, t *AdminToken) string {
+	if t != nil && t.name != "" {
+		return t.name
+	}
+
+	if r.approval != nil && r.approval.operator != nil {
+		return r.approval.operator(req)
+	}
+
+	return ""
+}
+
+// heldForApproval returns true if two-person approval is on and values need approval.
+func (r *Runtime) heldForApproval(values map[string]string) bool {
+	if r.approval == nil {
+		return false
+	}
+
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	return r.needsApproval(values)
+}
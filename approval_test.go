@@ -0,0 +1,346 @@
+package fault
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/github/go-fault/faulttest"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestRuntimeApprovalOptions tests the options that turn on two-person approval.
+func TestRuntimeApprovalOptions(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		giveOptions   []RuntimeOption
+		wantApproval  bool
+		wantThreshold float32
+		wantTTL       time.Duration
+		wantErr       error
+	}{
+		{
+			name:         "off",
+			wantApproval: false,
+		},
+		{
+			name:          "threshold",
+			giveOptions:   []RuntimeOption{WithApprovalThreshold(0.1)},
+			wantApproval:  true,
+			wantThreshold: 0.1,
+			wantTTL:       defaultApprovalTTL,
+		},
+		{
+			name:          "ttl",
+			giveOptions:   []RuntimeOption{WithApprovalTTL(time.Minute)},
+			wantApproval:  true,
+			wantThreshold: 1.0,
+			wantTTL:       time.Minute,
+		},
+		{
+			name: "operator func",
+			giveOptions: []RuntimeOption{
+				WithOperatorFunc(func(r *http.Request) string { return "alice" }),
+				WithApprovalThreshold(0.5),
+			},
+			wantApproval:  true,
+			wantThreshold: 0.5,
+			wantTTL:       defaultApprovalTTL,
+		},
+		{
+			name:        "invalid threshold",
+			giveOptions: []RuntimeOption{WithApprovalThreshold(1.1)},
+			wantErr:     ErrInvalidPercent,
+		},
+		{
+			name:        "invalid ttl",
+			giveOptions: []RuntimeOption{WithApprovalTTL(0)},
+			wantErr:     ErrInvalidInterval,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r, err := NewRuntime(tt.giveOptions...)
+
+			assert.Equal(t, tt.wantErr, err)
+			if tt.wantErr != nil {
+				assert.Nil(t, r)
+				return
+			}
+
+			assert.Equal(t, tt.wantApproval, r.approval != nil)
+			assert.Empty(t, r.Pending())
+			if tt.wantApproval {
+				assert.Equal(t, tt.wantThreshold, r.approval.threshold)
+				assert.Equal(t, tt.wantTTL, r.approval.ttl)
+			}
+		})
+	}
+}
+
+// TestRuntimeApprovalAdminHandler tests which admin changes are held for approval.
+func TestRuntimeApprovalAdminHandler(t *testing.T) {
+	t.Parallel()
+
+	ri, err := NewRejectInjector()
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name         string
+		giveInjector Injector
+		giveOptions  []RuntimeOption
+		giveValues   url.Values
+		giveOperator string
+		wantCode     int
+		wantPending  int
+	}{
+		{
+			name:         "approval off",
+			giveInjector: ri,
+			giveValues:   url.Values{RuntimeAbortPercent: {"100"}},
+			giveOperator: "alice",
+			wantCode:     http.StatusOK,
+		},
+		{
+			name:         "destructive raise",
+			giveInjector: ri,
+			giveOptions:  []RuntimeOption{WithApprovalTTL(time.Minute)},
+			giveValues:   url.Values{RuntimeAbortPercent: {"10"}},
+			giveOperator: "alice",
+			wantCode:     http.StatusAccepted,
+			wantPending:  1,
+		},
+		{
+			name:         "destructive lower",
+			giveInjector: ri,
+			giveOptions:  []RuntimeOption{WithApprovalTTL(time.Minute)},
+			giveValues:   url.Values{RuntimeAbortPercent: {"1"}},
+			giveOperator: "alice",
+			wantCode:     http.StatusOK,
+		},
+		{
+			name:         "below threshold",
+			giveInjector: newTestInjector500s(),
+			giveOptions:  []RuntimeOption{WithApprovalThreshold(0.2)},
+			giveValues:   url.Values{RuntimeAbortPercent: {"20"}},
+			giveOperator: "alice",
+			wantCode:     http.StatusOK,
+		},
+		{
+			name:         "above threshold",
+			giveInjector: newTestInjector500s(),
+			giveOptions:  []RuntimeOption{WithApprovalThreshold(0.2)},
+			giveValues:   url.Values{RuntimeAbortPercent: {"21"}},
+			giveOperator: "alice",
+			wantCode:     http.StatusAccepted,
+			wantPending:  1,
+		},
+		{
+			name:         "unbound key",
+			giveInjector: ri,
+			giveOptions:  []RuntimeOption{WithApprovalTTL(time.Minute)},
+			giveValues:   url.Values{"other": {"100"}},
+			giveOperator: "alice",
+			wantCode:     http.StatusOK,
+		},
+		{
+			name:         "no operator",
+			giveInjector: ri,
+			giveOptions:  []RuntimeOption{WithApprovalTTL(time.Minute)},
+			giveValues:   url.Values{RuntimeAbortPercent: {"10"}},
+			wantCode:     http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			f, err := NewFault(tt.giveInjector, WithEnabled(true))
+			assert.NoError(t, err)
+
+			r, err := NewRuntime(tt.giveOptions...)
+			assert.NoError(t, err)
+			assert.NoError(t, r.Set(RuntimeAbortPercent, "5"))
+			assert.NoError(t, r.Bind(f, RuntimeAbortPercent, FractionalPercent{Denominator: PerHundred}))
+
+			req := httptest.NewRequest(http.MethodPost, "/runtime_modify?"+tt.giveValues.Encode(), nil)
+			req.Header.Set(OperatorHeader, tt.giveOperator)
+			rr := httptest.NewRecorder()
+			r.AdminHandler().ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.wantCode, rr.Code, rr.Body.String())
+			assert.Len(t, r.Pending(), tt.wantPending)
+
+			if tt.wantCode == http.StatusAccepted {
+				var c PendingChange
+				assert.NoError(t, json.NewDecoder(rr.Body).Decode(&c))
+				assert.Equal(t, r.Pending()[0].Token, c.Token)
+				assert.Equal(t, "alice", c.Operator)
+				assert.Equal(t, map[string]string{RuntimeAbortPercent: tt.giveValues.Get(RuntimeAbortPercent)}, c.Values)
+
+				// the held change is not applied
+				assert.InDelta(t, 0.05, f.participation.Load(), 1e-6)
+			}
+		})
+	}
+}
+
+// TestRuntimeApprove tests approving a PendingChange through the AdminHandler.
+func TestRuntimeApprove(t *testing.T) {
+	t.Parallel()
+
+	clock := faulttest.NewClock(time.Time{})
+	operator := WithOperatorFunc(func(r *http.Request) string { return r.Header.Get("X-User") })
+	r, err := NewRuntime(WithClock(clock), WithApprovalTTL(time.Minute), operator)
+	assert.NoError(t, err)
+
+	ri, err := NewRejectInjector()
+	assert.NoError(t, err)
+	f, err := NewFault(ri, WithEnabled(true))
+	assert.NoError(t, err)
+	assert.NoError(t, r.Bind(f, RuntimeAbortPercent, FractionalPercent{Denominator: PerHundred}))
+
+	post := func(user, token string, values url.Values) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/runtime_modify?"+values.Encode(), nil)
+		req.Header.Set("X-User", user)
+		if token != "" {
+			req.Header.Set(ApprovalHeader, token)
+		}
+		rr := httptest.NewRecorder()
+		r.AdminHandler().ServeHTTP(rr, req)
+
+		return rr
+	}
+
+	hold := func(values url.Values) PendingChange {
+		rr := post("alice", "", values)
+		assert.Equal(t, http.StatusAccepted, rr.Code)
+
+		var c PendingChange
+		assert.NoError(t, json.NewDecoder(rr.Body).Decode(&c))
+
+		return c
+	}
+
+	first := hold(url.Values{RuntimeAbortPercent: {"10"}})
+	clock.Advance(30 * time.Second)
+	second := hold(url.Values{RuntimeAbortPercent: {"20"}, "other": {"1"}})
+
+	pending := r.Pending()
+	assert.Len(t, pending, 2)
+	assert.Equal(t, first.Token, pending[0].Token)
+	assert.Equal(t, second.Token, pending[1].Token)
+	assert.Equal(t, time.Time{}.Add(time.Minute), first.Expires.UTC())
+
+	// the requester cannot approve their own change
+	rr := post("alice", second.Token, nil)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	assert.Equal(t, ErrSelfApproval.Error()+"\n", rr.Body.String())
+
+	// nor can an anonymous operator
+	rr = post("", second.Token, nil)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	assert.Equal(t, ErrNoOperator.Error()+"\n", rr.Body.String())
+
+	// a second operator can
+	rr = post("bob", second.Token, nil)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"fault.http.abort.abort_percent": "20", "other": "1"}`, rr.Body.String())
+	assert.InDelta(t, 0.2, f.participation.Load(), 1e-6)
+
+	// but only once
+	rr = post("bob", second.Token, nil)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	// and not after it expires
+	clock.Advance(30 * time.Second)
+	assert.Empty(t, r.Pending())
+	rr = post("bob", first.Token, nil)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assert.Equal(t, ErrUnknownApproval.Error()+"\n", rr.Body.String())
+
+	// an approved change that cannot be set returns the error
+	bad := hold(url.Values{"": {"1"}, RuntimeAbortPercent: {"30"}})
+	rr = post("bob", bad.Token, nil)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Equal(t, ErrEmptyRuntimeKey.Error()+"\n", rr.Body.String())
+}
+
+// TestRuntimeApproveOff tests approving a change on a Runtime without two-person approval.
+func TestRuntimeApproveOff(t *testing.T) {
+	t.Parallel()
+
+	r, err := NewRuntime()
+	assert.NoError(t, err)
+
+	assert.Equal(t, ErrNoOperator, r.Approve("token", ""))
+	assert.Equal(t, ErrUnknownApproval, r.Approve("token", "bob"))
+
+	bob, err := r.IssueToken(WithTokenName("bob"))
+	assert.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodPost, "/runtime_modify", nil)
+	req.Header.Set("Authorization", "Bearer "+bob.Secret())
+	req.Header.Set(ApprovalHeader, "token")
+	rr := httptest.NewRecorder()
+	r.AdminHandler().ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+// TestRuntimeApproveHeaderOperator tests that the OperatorHeader names the operator of a change,
+// but that an approval needs an operator the client cannot name itself.
+func TestRuntimeApproveHeaderOperator(t *testing.T) {
+	t.Parallel()
+
+	r, err := NewRuntime(WithApprovalThreshold(0.05))
+	assert.NoError(t, err)
+
+	f, err := NewFault(newTestInjector500s())
+	assert.NoError(t, err)
+	assert.NoError(t, r.Bind(f, RuntimeDelayPercent, FractionalPercent{Denominator: PerHundred}))
+
+	post := func(operator, secret, approve string, values url.Values) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/runtime?"+values.Encode(), nil)
+		req.Header.Set(OperatorHeader, operator)
+		if secret != "" {
+			req.Header.Set("Authorization", "Bearer "+secret)
+		}
+		if approve != "" {
+			req.Header.Set(ApprovalHeader, approve)
+		}
+		rr := httptest.NewRecorder()
+		r.AdminHandler().ServeHTTP(rr, req)
+
+		return rr
+	}
+
+	rr := post("alice", "", "", url.Values{RuntimeDelayPercent: {"50"}})
+	assert.Equal(t, http.StatusAccepted, rr.Code)
+	var c PendingChange
+	assert.NoError(t, json.NewDecoder(rr.Body).Decode(&c))
+	assert.Equal(t, "alice", c.Operator)
+
+	// naming another operator in the header does not approve the change
+	rr = post("bob", "", c.Token, nil)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	assert.Equal(t, ErrNoOperator.Error()+"\n", rr.Body.String())
+	assert.Len(t, r.Pending(), 1)
+
+	// bob can with a named token, which requires every request to send one from now on
+	bob, err := r.IssueToken(WithTokenName("bob"))
+	assert.NoError(t, err)
+	rr = post("", bob.Secret(), c.Token, nil)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.InDelta(t, 0.5, f.participation.Load(), 1e-6)
+}
@@ -0,0 +1,81 @@
+package fault
+
+import (
+	"math"
+	"sync/atomic"
+)
+
+// atomicBool is a bool that is safe for concurrent use.
+type atomicBool struct {
+	v int32
+}
+
+// newAtomicBool returns an atomicBool set to b.
+func newAtomicBool(b bool) atomicBool {
+	var ab atomicBool
+	ab.Store(b)
+
+	return ab
+}
+
+// Load returns the value of the bool.
+func (b *atomicBool) Load() bool {
+	return atomic.LoadInt32(&b.v) == 1
+}
+
+// Store sets the value of the bool.
+func (b *atomicBool) Store(v bool) {
+	var i int32
+	if v {
+		i = 1
+	}
+	atomic.StoreInt32(&b.v, i)
+}
+
+// Swap sets the value of the bool and returns the previous value.
+func (b *atomicBool) Swap(v bool) bool {
+	var i int32
+	if v {
+		i = 1
+	}
+
+	return atomic.SwapInt32(&b.v, i) == 1
+}
+
+// atomicFloat32 is a float32 that is safe for concurrent use.
+type atomicFloat32 struct {
+	v uint32
+}
+
+// newAtomicFloat32 returns an atomicFloat32 set to f.
+func newAtomicFloat32(f float32) atomicFloat32 {
+	var af atomicFloat32
+	af.Store(f)
+
+	return af
+}
+
+// Load returns the value of the float.
+func (f *atomicFloat32) Load() float32 {
+	return math.Float32frombits(atomic.LoadUint32(&f.v))
+}
+
+// Store sets the value of the float.
+func (f *atomicFloat32) Store(v float32) {
+	atomic.StoreUint32(&f.v, math.Float32bits(v))
+}
+
+// atomicUint32 is a uint32 that is safe for concurrent use.
+type atomicUint32 struct {
+	v uint32
+}
+
+// Load returns the value of the uint32.
+func (u *atomicUint32) Load() uint32 {
+	return atomic.LoadUint32(&u.v)
+}
+
+// Store sets the value of the uint32.
+func (u *atomicUint32) Store(v uint32) {
+	atomic.StoreUint32(&u.v, v)
+}
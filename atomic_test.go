@@ -0,0 +1,33 @@
+package fault
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestAtomicBool tests atomicBool.
+func TestAtomicBool(t *testing.T) {
+	t.Parallel()
+
+	b := newAtomicBool(true)
+	assert.True(t, b.Load())
+
+	b.Store(false)
+	assert.False(t, b.Load())
+
+	assert.False(t, b.Swap(true))
+	assert.True(t, b.Swap(true))
+	assert.True(t, b.Load())
+}
+
+// TestAtomicFloat32 tests atomicFloat32.
+func TestAtomicFloat32(t *testing.T) {
+	t.Parallel()
+
+	f := newAtomicFloat32(0.25)
+	assert.Equal(t, float32(0.25), f.Load())
+
+	f.Store(0.75)
+	assert.Equal(t, float32(0.75), f.Load())
+}
@@ -71,6 +71,8 @@ parse_bench() {
     input="${input//-???/}"
     # Remove leading/trailing quotes
     input="${input//\"/}"
+    # Use periods to separate sub-benchmark names
+    input="${input//\//.}"
     # Remove whitespace and units
     input="$(awk '{print $1,$3,$5,$7}' <<<"$input")"
     # Output
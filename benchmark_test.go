@@ -3,6 +3,7 @@ package fault_test
 import (
 	"net/http"
 	"net/http/httptest"
+	"runtime"
 	"testing"
 
 	"github.com/github/go-fault"
@@ -78,3 +79,136 @@ func BenchmarkFaultError100Percent(b *testing.B) {
 
 	runBenchmark(b, f)
 }
+
+// benchmarkConcurrency is how many requests the parallel benchmarks run at once.
+const benchmarkConcurrency = 64
+
+// discardResponseWriter is an http.ResponseWriter that throws away the response, so that parallel
+// benchmarks only measure the Fault.
+type discardResponseWriter struct {
+	header http.Header
+}
+
+func (w *discardResponseWriter) Header() http.Header         { return w.header }
+func (w *discardResponseWriter) Write(p []byte) (int, error) { return len(p), nil }
+func (w *discardResponseWriter) WriteHeader(int)             {}
+
+// BenchmarkFaultParallel benchmarks the decision path of a Fault with benchmarkConcurrency
+// concurrent requests. Every case except "injected" must not allocate.
+func BenchmarkFaultParallel(b *testing.B) {
+	match := fault.RequestMatcherFunc(func(r *http.Request) bool { return r.Method == http.MethodGet })
+
+	benchmarks := []struct {
+		name string
+		opts []fault.Option
+	}{
+		{
+			name: "disabled",
+			opts: []fault.Option{fault.WithEnabled(false)},
+		},
+		{
+			name: "zero percent",
+			opts: []fault.Option{fault.WithEnabled(true), fault.WithParticipation(0.0)},
+		},
+		{
+			name: "one basis point",
+			opts: []fault.Option{fault.WithEnabled(true), fault.WithFractionalParticipation(fault.BasisPoints(1))},
+		},
+		{
+			name: "unmatched",
+			opts: []fault.Option{
+				fault.WithEnabled(true),
+				fault.WithParticipation(1.0),
+				fault.WithPathBlocklist([]string{"/"}),
+			},
+		},
+		{
+			name: "request matcher",
+			opts: []fault.Option{
+				fault.WithEnabled(true),
+				fault.WithParticipation(0.0),
+				fault.WithPathAllowlist([]string{"/"}),
+				fault.WithHeaderBlocklist(map[string]string{"X-Skip": "true"}),
+				fault.WithRequestMatcher(match),
+			},
+		},
+		{
+			name: "injected",
+			opts: []fault.Option{fault.WithEnabled(true), fault.WithParticipation(1.0)},
+		},
+	}
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	for _, bm := range benchmarks {
+		bm := bm
+		b.Run(bm.name, func(b *testing.B) {
+			i, _ := fault.NewErrorInjector(http.StatusInternalServerError)
+			f, _ := fault.NewFault(i, bm.opts...)
+			h := f.Handler(next)
+
+			parallelism := benchmarkConcurrency / runtime.GOMAXPROCS(0)
+			if parallelism < 1 {
+				parallelism = 1
+			}
+
+			b.ReportAllocs()
+			b.SetParallelism(parallelism)
+			b.RunParallel(func(pb *testing.PB) {
+				req, _ := http.NewRequest("GET", "/", nil)
+				w := &discardResponseWriter{header: http.Header{}}
+
+				for pb.Next() {
+					h.ServeHTTP(w, req)
+				}
+			})
+		})
+	}
+}
+
+// BenchmarkBufferedInjectorsParallel benchmarks Injectors that buffer the response with
+// benchmarkConcurrency concurrent requests, all of which are injected.
+func BenchmarkBufferedInjectorsParallel(b *testing.B) {
+	ji, _ := fault.NewJSONTruncateInjector(1)
+	ci, _ := fault.NewCharsetInjector(fault.CharsetInvalidUTF8)
+
+	benchmarks := []struct {
+		name     string
+		injector fault.Injector
+	}{
+		{"json_truncate", ji},
+		{"charset", ci},
+	}
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`[1, 2, 3, 4, 5, 6, 7, 8, 9, 10]`))
+	})
+
+	for _, bm := range benchmarks {
+		bm := bm
+		b.Run(bm.name, func(b *testing.B) {
+			f, _ := fault.NewFault(bm.injector, fault.WithEnabled(true), fault.WithParticipation(1.0))
+			h := f.Handler(next)
+
+			parallelism := benchmarkConcurrency / runtime.GOMAXPROCS(0)
+			if parallelism < 1 {
+				parallelism = 1
+			}
+
+			b.ReportAllocs()
+			b.SetParallelism(parallelism)
+			b.RunParallel(func(pb *testing.PB) {
+				req, _ := http.NewRequest("GET", "/", nil)
+				w := &discardResponseWriter{header: http.Header{}}
+
+				for pb.Next() {
+					for k := range w.header {
+						delete(w.header, k)
+					}
+					h.ServeHTTP(w, req)
+				}
+			})
+		})
+	}
+}
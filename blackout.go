@@ -0,0 +1,243 @@
+package fault
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrInvalidBlackout when a Blackout does not end after it starts.
+	ErrInvalidBlackout = errors.New("blackout must end after it starts")
+	// ErrRecurringBlackout when an iCalendar event repeats. Recurring events are not expanded, so
+	// they are rejected instead of silently covering only their first occurrence.
+	ErrRecurringBlackout = errors.New("recurring blackout events are not supported")
+)
+
+// Blackout is a window of time during which no fault may be injected, such as a deploy freeze.
+type Blackout struct {
+	// Name describes the Blackout.
+	Name string `json:"name"`
+	// Start is when the Blackout starts.
+	Start time.Time `json:"start"`
+	// End is when the Blackout ends. The Blackout does not include End.
+	End time.Time `json:"end"`
+}
+
+// contains returns true if t is within the Blackout.
+func (b Blackout) contains(t time.Time) bool {
+	return !t.Before(b.Start) && t.Before(b.End)
+}
+
+// BlackoutCalendar holds the Blackouts of a Manager. Use WithBlackoutCalendar to make a Manager
+// enforce it.
+type BlackoutCalendar struct {
+	blackouts []Blackout
+}
+
+// NewBlackoutCalendar returns a BlackoutCalendar of blackouts. Each must end after it starts.
+func NewBlackoutCalendar(blackouts ...Blackout) (*BlackoutCalendar, error) {
+	for _, b := range blackouts {
+		if !b.End.After(b.Start) {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidBlackout, b.Name)
+		}
+	}
+
+	bs := append([]Blackout(nil), blackouts...)
+	sort.SliceStable(bs, func(i, j int) bool {
+		return bs[i].Start.Before(bs[j].Start)
+	})
+
+	return &BlackoutCalendar{blackouts: bs}, nil
+}
+
+// ParseBlackoutJSON returns a BlackoutCalendar of the JSON array of Blackouts read from r, such as
+// [{"name": "black friday", "start": "2024-11-29T00:00:00Z", "end": "2024-12-03T00:00:00Z"}]. Times
+// are in RFC 3339 format.
+func ParseBlackoutJSON(r io.Reader) (*BlackoutCalendar, error) {
+	var blackouts []Blackout
+
+	err := json.NewDecoder(r).Decode(&blackouts)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewBlackoutCalendar(blackouts...)
+}
+
+// ParseBlackoutICal returns a BlackoutCalendar of the events of the iCalendar (RFC 5545) read from
+// r, using their SUMMARY, DTSTART, and DTEND. Times may be UTC, floating (read as UTC), in the TZID
+// of the property, or dates, in which case an event without DTEND lasts the day. Recurring events
+// return ErrRecurringBlackout.
+func ParseBlackoutICal(r io.Reader) (*BlackoutCalendar, error) {
+	lines, err := unfoldICal(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var blackouts []Blackout
+	var event *Blackout
+	var allDay bool
+	for _, line := range lines {
+		name, params, value := splitICalLine(line)
+
+		switch {
+		case name == "BEGIN" && value == "VEVENT":
+			event, allDay = &Blackout{}, false
+		case event == nil:
+			continue
+		case name == "END" && value == "VEVENT":
+			if event.End.IsZero() && allDay {
+				event.End = event.Start.AddDate(0, 0, 1)
+			}
+			blackouts = append(blackouts, *event)
+			event = nil
+		case name == "SUMMARY":
+			event.Name = unescapeICal(value)
+		case name == "DTSTART":
+			event.Start, allDay, err = parseICalTime(params, value)
+		case name == "DTEND":
+			event.End, _, err = parseICalTime(params, value)
+		case name == "RRULE", name == "RDATE":
+			return nil, fmt.Errorf("%w: %q", ErrRecurringBlackout, event.Name)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return NewBlackoutCalendar(blackouts...)
+}
+
+// unfoldICal returns the lines of the iCalendar read from r, joining folded lines.
+func unfoldICal(r io.Reader) ([]string, error) {
+	var lines []string
+
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		line := strings.TrimRight(s.Text(), "\r")
+		if len(lines) > 0 && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+
+	return lines, s.Err()
+}
+
+// splitICalLine splits an iCalendar content line, such as
+// "DTSTART;TZID=Europe/Paris:20241129T000000", into its upper-cased name, its parameters, and its
+// value.
+func splitICalLine(line string) (string, map[string]string, string) {
+	head, value, _ := strings.Cut(line, ":")
+
+	parts := strings.Split(head, ";")
+	params := make(map[string]string, len(parts)-1)
+	for _, p := range parts[1:] {
+		k, v, _ := strings.Cut(p, "=")
+		params[strings.ToUpper(k)] = strings.Trim(v, `"`)
+	}
+
+	return strings.ToUpper(parts[0]), params, value
+}
+
+// parseICalTime parses an iCalendar DATE or DATE-TIME value and returns true if it is a DATE.
+func parseICalTime(params map[string]string, value string) (time.Time, bool, error) {
+	loc := time.UTC
+	if tzid, ok := params["TZID"]; ok {
+		l, err := time.LoadLocation(tzid)
+		if err != nil {
+			return time.Time{}, false, err
+		}
+		loc = l
+	}
+
+	if params["VALUE"] == "DATE" || len(value) == len("20060102") {
+		t, err := time.ParseInLocation("20060102", value, loc)
+		return t, true, err
+	}
+
+	if strings.HasSuffix(value, "Z") {
+		t, err := time.Parse("20060102T150405Z", value)
+		return t, false, err
+	}
+
+	t, err := time.ParseInLocation("20060102T150405", value, loc)
+
+	return t, false, err
+}
+
+// unescapeICal unescapes an iCalendar TEXT value.
+func unescapeICal(s string) string {
+	return strings.NewReplacer(`\n`, "\n", `\N`, "\n", `\,`, ",", `\;`, ";", `\\`, `\`).Replace(s)
+}
+
+// Blackouts returns the Blackouts of the BlackoutCalendar in order of their start.
+func (c *BlackoutCalendar) Blackouts() []Blackout {
+	return append([]Blackout(nil), c.blackouts...)
+}
+
+// Active returns the Blackout that t is within and true, or false if t is not within any.
+func (c *BlackoutCalendar) Active(t time.Time) (Blackout, bool) {
+	for _, b := range c.blackouts {
+		if b.contains(t) {
+			return b, true
+		}
+	}
+
+	return Blackout{}, false
+}
+
+type blackoutCalendarOption struct {
+	calendar *BlackoutCalendar
+}
+
+func (o blackoutCalendarOption) applyManager(m *Manager) error {
+	m.blackouts = o.calendar
+	return nil
+}
+
+// WithBlackoutCalendar makes the Manager skip every Fault with SkipBlackout while the time of its
+// Clock is within a Blackout of c, whether or not the Faults are enabled.
+func WithBlackoutCalendar(c *BlackoutCalendar) ManagerOption {
+	return blackoutCalendarOption{c}
+}
+
+// Blackout returns the Blackout the Manager is in and true, or false if it is not in one.
+func (m *Manager) Blackout() (Blackout, bool) {
+	if m.blackouts == nil {
+		return Blackout{}, false
+	}
+
+	return m.blackouts.Active(m.clock.Now())
+}
+
+// skipAll evaluates r against faults and returns their handlers with every Fault that would have
+// run skipped with reason.
+func skipAll(faults []*Fault, r *http.Request, next http.Handler, reason SkipReason) http.Handler {
+	states := make([]*injectorState, len(faults))
+	evs := make([]Evaluation, len(faults))
+	for idx, f := range faults {
+		states[idx] = f.injector.Load()
+		evs[idx] = f.evaluate(r, states[idx])
+		if evs[idx].Injected {
+			evs[idx].Injected = false
+			evs[idx].SkipReason = reason
+		}
+	}
+
+	h := next
+	for idx := len(faults) - 1; idx >= 0; idx-- {
+		h = evaluatedHandler(faults[idx], h, states[idx], evs[idx])
+	}
+
+	return h
+}
@@ -0,0 +1,340 @@
+package fault
+
+import (
+	"bufio"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/github/go-fault/faulttest"
+	"github.com/stretchr/testify/assert"
+)
+
+// testDate returns midnight UTC of the day in November 2024.
+func testDate(day int) time.Time {
+	return time.Date(2024, time.November, day, 0, 0, 0, 0, time.UTC)
+}
+
+// TestNewBlackoutCalendar tests NewBlackoutCalendar.
+func TestNewBlackoutCalendar(t *testing.T) {
+	t.Parallel()
+
+	freeze := Blackout{Name: "freeze", Start: testDate(20), End: testDate(22)}
+	friday := Blackout{Name: "black friday", Start: testDate(29), End: testDate(30)}
+
+	tests := []struct {
+		name    string
+		give    []Blackout
+		want    []Blackout
+		wantErr error
+	}{
+		{
+			name: "none",
+			give: nil,
+			want: nil,
+		},
+		{
+			name: "sorted by start",
+			give: []Blackout{friday, freeze},
+			want: []Blackout{freeze, friday},
+		},
+		{
+			name:    "empty",
+			give:    []Blackout{freeze, {Name: "empty", Start: testDate(1), End: testDate(1)}},
+			wantErr: ErrInvalidBlackout,
+		},
+		{
+			name:    "reversed",
+			give:    []Blackout{{Name: "reversed", Start: testDate(2), End: testDate(1)}},
+			wantErr: ErrInvalidBlackout,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			c, err := NewBlackoutCalendar(tt.give...)
+
+			if tt.wantErr != nil {
+				assert.True(t, errors.Is(err, tt.wantErr))
+				assert.Nil(t, c)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, c.Blackouts())
+		})
+	}
+}
+
+// TestParseBlackoutJSON tests ParseBlackoutJSON.
+func TestParseBlackoutJSON(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		give    string
+		want    []Blackout
+		wantErr string
+	}{
+		{
+			name: "valid",
+			give: `[
+				{"name": "black friday", "start": "2024-11-29T00:00:00Z", "end": "2024-11-30T00:00:00Z"},
+				{"name": "freeze", "start": "2024-11-20T00:00:00Z", "end": "2024-11-22T00:00:00Z"}
+			]`,
+			want: []Blackout{
+				{Name: "freeze", Start: testDate(20), End: testDate(22)},
+				{Name: "black friday", Start: testDate(29), End: testDate(30)},
+			},
+		},
+		{
+			name:    "invalid json",
+			give:    `[{"name": "freeze", "start": "tomorrow"}]`,
+			wantErr: `tomorrow`,
+		},
+		{
+			name:    "invalid blackout",
+			give:    `[{"name": "freeze", "start": "2024-11-20T00:00:00Z"}]`,
+			wantErr: `blackout must end after it starts: "freeze"`,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			c, err := ParseBlackoutJSON(strings.NewReader(tt.give))
+
+			if tt.wantErr != "" {
+				assert.Error(t, err)
+				if err != nil {
+					assert.Contains(t, err.Error(), tt.wantErr)
+				}
+				assert.Nil(t, c)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, c.Blackouts())
+		})
+	}
+}
+
+// TestParseBlackoutICal tests ParseBlackoutICal.
+func TestParseBlackoutICal(t *testing.T) {
+	t.Parallel()
+
+	paris, err := time.LoadLocation("Europe/Paris")
+	assert.NoError(t, err)
+
+	ical := func(events ...string) string {
+		return "BEGIN:VCALENDAR\r\nVERSION:2.0\r\nSUMMARY:not an event\r\n" +
+			strings.Join(events, "") + "END:VCALENDAR\r\n"
+	}
+
+	tests := []struct {
+		name    string
+		give    string
+		want    []Blackout
+		wantErr string
+	}{
+		{
+			name: "utc",
+			give: ical("BEGIN:VEVENT\r\nSUMMARY:freeze\r\nDTSTART:20241120T090000Z\r\n" +
+				"DTEND:20241120T170000Z\r\nEND:VEVENT\r\n"),
+			want: []Blackout{
+				{Name: "freeze", Start: testDate(20).Add(9 * time.Hour), End: testDate(20).Add(17 * time.Hour)},
+			},
+		},
+		{
+			name: "folded and escaped summary",
+			give: ical("BEGIN:VEVENT\r\nSUMMARY:deploy freeze\\, \r\n Q4\\; no\r\n\tchanges\r\n" +
+				"DTSTART:20241120T090000Z\r\nDTEND:20241120T170000Z\r\nEND:VEVENT\r\n"),
+			want: []Blackout{
+				{Name: "deploy freeze, Q4; nochanges", Start: testDate(20).Add(9 * time.Hour), End: testDate(20).Add(17 * time.Hour)},
+			},
+		},
+		{
+			name: "time zone and floating",
+			give: ical("BEGIN:VEVENT\r\nDTSTART;TZID=\"Europe/Paris\":20241120T090000\r\n" +
+				"DTEND:20241120T170000\r\nEND:VEVENT\r\n"),
+			want: []Blackout{
+				{Start: time.Date(2024, time.November, 20, 9, 0, 0, 0, paris), End: testDate(20).Add(17 * time.Hour)},
+			},
+		},
+		{
+			name: "all day",
+			give: ical(
+				"BEGIN:VEVENT\r\nSUMMARY:black friday\r\nDTSTART;VALUE=DATE:20241129\r\nEND:VEVENT\r\n",
+				"BEGIN:VEVENT\r\nSUMMARY:freeze\r\nDTSTART:20241120\r\nDTEND:20241122\r\nEND:VEVENT\r\n",
+			),
+			want: []Blackout{
+				{Name: "freeze", Start: testDate(20), End: testDate(22)},
+				{Name: "black friday", Start: testDate(29), End: testDate(30)},
+			},
+		},
+		{
+			name: "recurring",
+			give: ical("BEGIN:VEVENT\r\nSUMMARY:weekend\r\nDTSTART:20241123\r\n" +
+				"RRULE:FREQ=WEEKLY\r\nEND:VEVENT\r\n"),
+			wantErr: `recurring blackout events are not supported: "weekend"`,
+		},
+		{
+			name:    "unknown time zone",
+			give:    ical("BEGIN:VEVENT\r\nDTSTART;TZID=Nowhere/Else:20241120T090000\r\nEND:VEVENT\r\n"),
+			wantErr: "unknown time zone Nowhere/Else",
+		},
+		{
+			name:    "invalid time",
+			give:    ical("BEGIN:VEVENT\r\nDTSTART:20241120T090000Z\r\nDTEND:tomorrowZ\r\nEND:VEVENT\r\n"),
+			wantErr: `parsing time "tomorrowZ"`,
+		},
+		{
+			name:    "without times",
+			give:    ical("BEGIN:VEVENT\r\nSUMMARY:freeze\r\nEND:VEVENT\r\n"),
+			wantErr: `blackout must end after it starts: "freeze"`,
+		},
+		{
+			name:    "line too long",
+			give:    strings.Repeat("x", bufio.MaxScanTokenSize+1),
+			wantErr: bufio.ErrTooLong.Error(),
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			c, err := ParseBlackoutICal(strings.NewReader(tt.give))
+
+			if tt.wantErr != "" {
+				assert.Error(t, err)
+				if err != nil {
+					assert.Contains(t, err.Error(), tt.wantErr)
+				}
+				assert.Nil(t, c)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, c.Blackouts())
+		})
+	}
+}
+
+// TestBlackoutCalendarActive tests BlackoutCalendar.Active.
+func TestBlackoutCalendarActive(t *testing.T) {
+	t.Parallel()
+
+	freeze := Blackout{Name: "freeze", Start: testDate(20), End: testDate(22)}
+	c, err := NewBlackoutCalendar(freeze)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		give     time.Time
+		want     Blackout
+		wantBool bool
+	}{
+		{
+			name:     "before",
+			give:     testDate(20).Add(-time.Nanosecond),
+			wantBool: false,
+		},
+		{
+			name:     "start",
+			give:     testDate(20),
+			want:     freeze,
+			wantBool: true,
+		},
+		{
+			name:     "within",
+			give:     testDate(21),
+			want:     freeze,
+			wantBool: true,
+		},
+		{
+			name:     "end",
+			give:     testDate(22),
+			wantBool: false,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			b, ok := c.Active(tt.give)
+
+			assert.Equal(t, tt.want, b)
+			assert.Equal(t, tt.wantBool, ok)
+		})
+	}
+}
+
+// TestManagerBlackout tests that a Manager runs no Faults during a Blackout.
+func TestManagerBlackout(t *testing.T) {
+	t.Parallel()
+
+	freeze := Blackout{Name: "freeze", Start: testDate(20), End: testDate(22)}
+	c, err := NewBlackoutCalendar(freeze)
+	assert.NoError(t, err)
+
+	for _, policy := range []ConflictPolicy{ConflictAll, ConflictFirstWins} {
+		policy := policy
+		t.Run(policy.String(), func(t *testing.T) {
+			t.Parallel()
+
+			clock := faulttest.NewClock(testDate(21))
+			m, err := NewManager(WithClock(clock), WithConflictPolicy(policy), WithBlackoutCalendar(c))
+			assert.NoError(t, err)
+
+			ei, err := NewErrorInjector(http.StatusInternalServerError)
+			assert.NoError(t, err)
+			enabled, err := NewFault(ei, WithName("enabled"), WithEnabled(true), WithParticipation(1.0))
+			assert.NoError(t, err)
+			disabled, err := NewFault(ei, WithName("disabled"), WithParticipation(1.0))
+			assert.NoError(t, err)
+			assert.NoError(t, m.Add(enabled, disabled))
+
+			serve := func() int {
+				rr := httptest.NewRecorder()
+				m.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})).
+					ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+				return rr.Code
+			}
+
+			b, ok := m.Blackout()
+			assert.Equal(t, freeze, b)
+			assert.True(t, ok)
+			assert.Equal(t, http.StatusOK, serve())
+			assert.Equal(t, int64(1), enabled.stats.counters()["skipped_blackout"])
+			assert.Equal(t, int64(1), disabled.stats.counters()["skipped_disabled"])
+
+			es := m.Explain(httptest.NewRequest(http.MethodGet, "/", nil))
+			assert.Equal(t, []SkipReason{SkipBlackout, SkipDisabled}, []SkipReason{es[0].SkipReason, es[1].SkipReason})
+			assert.False(t, es[0].Eligible)
+
+			clock.Advance(24 * time.Hour)
+
+			_, ok = m.Blackout()
+			assert.False(t, ok)
+			assert.Equal(t, http.StatusInternalServerError, serve())
+			assert.True(t, m.Explain(httptest.NewRequest(http.MethodGet, "/", nil))[0].Eligible)
+		})
+	}
+
+	m, err := NewManager()
+	assert.NoError(t, err)
+	_, ok := m.Blackout()
+	assert.False(t, ok)
+}
@@ -0,0 +1,207 @@
+package fault
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	// ErrInvalidInjectionRate when an injection rate is not a positive number of injections per
+	// positive duration.
+	ErrInvalidInjectionRate = errors.New("injection rate must be > 0 per duration > 0")
+	// ErrInvalidBudget when an injection budget is not positive.
+	ErrInvalidBudget = errors.New("injection budget must be > 0")
+)
+
+// rateLimit is a token bucket that caps how fast a Fault runs its Injector, so a participation
+// percent does not turn into a flood of injections during a traffic spike.
+type rateLimit struct {
+	// burst is the size of the bucket, and rate the tokens added per second.
+	burst float64
+	rate  float64
+	clock Clock
+
+	mtx    sync.Mutex
+	tokens float64
+	// last is when tokens was last refilled, and used false until the bucket is first used.
+	last time.Time
+	used bool
+}
+
+type injectionRateOption struct {
+	n   int
+	per time.Duration
+}
+
+func (o injectionRateOption) applyFault(f *Fault) error {
+	if o.n <= 0 || o.per <= 0 {
+		return ErrInvalidInjectionRate
+	}
+
+	f.rateLimit = &rateLimit{
+		burst: float64(o.n),
+		rate:  float64(o.n) / o.per.Seconds(),
+	}
+
+	return nil
+}
+
+// WithInjectionRate caps the Injector at n injections per duration, such as 10 per second, however
+// many requests the participation percent selects. Up to n injections may happen at once, after
+// which they are spread evenly over per. Requests over the rate are skipped with SkipRateLimit. The
+// rate is timed with the Clock set by WithClock().
+func WithInjectionRate(n int, per time.Duration) Option {
+	return injectionRateOption{n: n, per: per}
+}
+
+// refill adds the tokens earned since the bucket was last refilled. c.mtx must be held.
+func (c *rateLimit) refill() {
+	now := c.clock.Now()
+	if !c.used {
+		c.tokens = c.burst
+		c.used = true
+	} else if elapsed := now.Sub(c.last); elapsed > 0 {
+		c.tokens += elapsed.Seconds() * c.rate
+		if c.tokens > c.burst {
+			c.tokens = c.burst
+		}
+	}
+	c.last = now
+}
+
+// allowed returns true if there is a token for another injection, without taking it.
+func (c *rateLimit) allowed() bool {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	c.refill()
+
+	return c.tokens >= 1
+}
+
+// take takes a token for an injection and returns true, or returns false if there is none.
+func (c *rateLimit) take() bool {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	c.refill()
+	if c.tokens < 1 {
+		return false
+	}
+	c.tokens--
+
+	return true
+}
+
+func (o maxConcurrentOption) applyFault(f *Fault) error {
+	if o < 0 {
+		return ErrInvalidMaxConcurrent
+	}
+
+	f.maxConcurrent = int64(o)
+
+	return nil
+}
+
+// acquireConcurrent counts another request the Injector runs on and returns true, or returns false
+// if the Injector already runs on f.maxConcurrent requests.
+func (f *Fault) acquireConcurrent() bool {
+	for {
+		n := atomic.LoadInt64(&f.concurrent)
+		if n >= f.maxConcurrent {
+			return false
+		}
+		if atomic.CompareAndSwapInt64(&f.concurrent, n, n+1) {
+			return true
+		}
+	}
+}
+
+// releaseConcurrent stops counting a request acquired with acquireConcurrent.
+func (f *Fault) releaseConcurrent() {
+	atomic.AddInt64(&f.concurrent, -1)
+}
+
+// budget is the total number of injections a Fault may make before it disables itself.
+type budget struct {
+	total int64
+
+	mtx  sync.Mutex
+	used int64
+}
+
+type injectionBudgetOption int64
+
+func (o injectionBudgetOption) applyFault(f *Fault) error {
+	if o <= 0 {
+		return ErrInvalidBudget
+	}
+
+	f.budget = &budget{total: int64(o)}
+
+	return nil
+}
+
+// WithInjectionBudget limits the Fault to n injections in total, so an experiment has a known blast
+// radius. The request that uses up the budget is the last the Injector runs on: the Fault then
+// disables itself and reports an Event of type EventBudgetExhausted if its Reporter is an
+// EventReporter. Requests that would go over the budget are skipped with SkipBudget. Enabling the
+// Fault again with SetEnabled starts a new budget.
+func WithInjectionBudget(n int64) Option {
+	return injectionBudgetOption(n)
+}
+
+// exhausted returns true if the budget is used up.
+func (b *budget) exhausted() bool {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	return b.used >= b.total
+}
+
+// take uses one injection of the budget and returns true, or returns false if it is used up. last
+// is true if the injection used up the budget.
+func (b *budget) take() (ok, last bool) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	if b.used >= b.total {
+		return false, false
+	}
+	b.used++
+
+	return true, b.used == b.total
+}
+
+// reset makes the whole budget available again.
+func (b *budget) reset() {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	b.used = 0
+}
+
+// BudgetRemaining returns how many more times the Fault may run its Injector before the budget set
+// by WithInjectionBudget runs out, and false if it has no budget.
+func (f *Fault) BudgetRemaining() (int64, bool) {
+	if f.budget == nil {
+		return 0, false
+	}
+
+	f.budget.mtx.Lock()
+	defer f.budget.mtx.Unlock()
+
+	return f.budget.total - f.budget.used, true
+}
+
+// exhaustBudget disables the Fault after r used up its budget and reports EventBudgetExhausted.
+func (f *Fault) exhaustBudget(r *http.Request, st *injectorState) {
+	f.DisableNow()
+
+	if er, ok := f.reporter.(EventReporter); ok {
+		er.ReportEvent(f.newEvent(EventBudgetExhausted, r, st))
+	}
+}
@@ -0,0 +1,289 @@
+package fault
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/github/go-fault/faulttest"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestWithInjectionRate tests the options of WithInjectionRate.
+func TestWithInjectionRate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		giveN     int
+		givePer   time.Duration
+		wantBurst float64
+		wantRate  float64
+		wantErr   error
+	}{
+		{
+			name:      "per second",
+			giveN:     10,
+			givePer:   time.Second,
+			wantBurst: 10,
+			wantRate:  10,
+		},
+		{
+			name:      "per minute",
+			giveN:     30,
+			givePer:   time.Minute,
+			wantBurst: 30,
+			wantRate:  0.5,
+		},
+		{
+			name:    "zero n",
+			giveN:   0,
+			givePer: time.Second,
+			wantErr: ErrInvalidInjectionRate,
+		},
+		{
+			name:    "zero duration",
+			giveN:   10,
+			givePer: 0,
+			wantErr: ErrInvalidInjectionRate,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			f, err := NewFault(newTestInjectorNoop(), WithInjectionRate(tt.giveN, tt.givePer))
+
+			assert.Equal(t, tt.wantErr, err)
+			if tt.wantErr != nil {
+				assert.Nil(t, f)
+				return
+			}
+
+			assert.Equal(t, tt.wantBurst, f.rateLimit.burst)
+			assert.Equal(t, tt.wantRate, f.rateLimit.rate)
+			assert.Equal(t, NewRealClock(), f.rateLimit.clock)
+		})
+	}
+}
+
+// TestFaultHandlerInjectionRate tests that a Fault with an injection rate skips the requests over
+// the rate and refills as time passes.
+func TestFaultHandlerInjectionRate(t *testing.T) {
+	t.Parallel()
+
+	clock := faulttest.NewClock(time.Time{})
+
+	f, err := NewFault(newTestInjector500s(),
+		WithEnabled(true),
+		WithParticipation(1.0),
+		WithInjectionRate(2, time.Second),
+		WithClock(clock),
+	)
+	assert.NoError(t, err)
+
+	serve := func() int {
+		return testRequest(t, f).Code
+	}
+
+	assert.Equal(t, http.StatusInternalServerError, serve())
+	assert.Equal(t, http.StatusInternalServerError, serve())
+	assert.Equal(t, testHandlerCode, serve())
+	assert.Equal(t, SkipRateLimit, ExplainRequest(f, httptest.NewRequest(http.MethodGet, "/", nil)).SkipReason)
+
+	// half a second earns one token
+	clock.Advance(500 * time.Millisecond)
+	assert.True(t, ExplainRequest(f, httptest.NewRequest(http.MethodGet, "/", nil)).Eligible)
+	assert.Equal(t, http.StatusInternalServerError, serve())
+	assert.Equal(t, testHandlerCode, serve())
+
+	// the bucket holds no more than the burst
+	clock.Advance(time.Hour)
+	assert.Equal(t, http.StatusInternalServerError, serve())
+	assert.Equal(t, http.StatusInternalServerError, serve())
+	assert.Equal(t, testHandlerCode, serve())
+
+	assert.Equal(t, int64(5), f.stats.counters()["injected"])
+	assert.Equal(t, int64(3), f.stats.counters()["skipped_rate_limit"])
+}
+
+// TestWithInjectionBudget tests the options of WithInjectionBudget.
+func TestWithInjectionBudget(t *testing.T) {
+	t.Parallel()
+
+	f, err := NewFault(newTestInjectorNoop(), WithInjectionBudget(3))
+	assert.NoError(t, err)
+
+	remaining, ok := f.BudgetRemaining()
+	assert.True(t, ok)
+	assert.Equal(t, int64(3), remaining)
+
+	f, err = NewFault(newTestInjectorNoop())
+	assert.NoError(t, err)
+
+	_, ok = f.BudgetRemaining()
+	assert.False(t, ok)
+
+	for _, n := range []int64{0, -1} {
+		f, err = NewFault(newTestInjectorNoop(), WithInjectionBudget(n))
+		assert.Equal(t, ErrInvalidBudget, err)
+		assert.Nil(t, f)
+	}
+}
+
+// TestFaultHandlerInjectionBudget tests that a Fault disables itself and reports an Event once it
+// uses up its budget, and that enabling it again starts a new budget.
+func TestFaultHandlerInjectionBudget(t *testing.T) {
+	t.Parallel()
+
+	reporter := &testEventReporter{events: make(chan Event, 10)}
+
+	f, err := NewFault(newTestInjector500s(),
+		WithName("budget"),
+		WithEnabled(true),
+		WithParticipation(1.0),
+		WithInjectionBudget(2),
+		WithReporter(reporter),
+	)
+	assert.NoError(t, err)
+
+	assert.Equal(t, http.StatusInternalServerError, testRequest(t, f).Code)
+	assert.Equal(t, []EventType{EventStarted, EventFinished}, eventTypes(reporter.receive(2)))
+	assert.True(t, f.Enabled())
+
+	assert.Equal(t, http.StatusInternalServerError, testRequest(t, f).Code)
+	events := reporter.receive(3)
+	assert.Equal(t, []EventType{EventStarted, EventFinished, EventBudgetExhausted}, eventTypes(events))
+	assert.Equal(t, "budget", events[2].Fault)
+	assert.False(t, f.Enabled())
+
+	remaining, _ := f.BudgetRemaining()
+	assert.Equal(t, int64(0), remaining)
+	assert.Equal(t, testHandlerCode, testRequest(t, f).Code)
+
+	f.SetEnabled(true)
+	remaining, _ = f.BudgetRemaining()
+	assert.Equal(t, int64(2), remaining)
+	assert.Equal(t, http.StatusInternalServerError, testRequest(t, f).Code)
+	assert.Equal(t, []EventType{EventStarted, EventFinished}, eventTypes(reporter.receive(2)))
+}
+
+// TestFaultHandlerInjectionBudgetExhausted tests that requests over the budget of a Fault that is
+// still enabled, such as ones evaluated before it disabled itself, are skipped.
+func TestFaultHandlerInjectionBudgetExhausted(t *testing.T) {
+	t.Parallel()
+
+	f, err := NewFault(newTestInjector500s(),
+		WithEnabled(true),
+		WithParticipation(1.0),
+		WithInjectionBudget(1),
+	)
+	assert.NoError(t, err)
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	st := f.injector.Load()
+	ev := f.evaluate(r, st)
+
+	assert.Equal(t, http.StatusInternalServerError, testRequest(t, f).Code)
+	assert.Equal(t, SkipDisabled, ExplainRequest(f, r).SkipReason)
+
+	f.enabled.Store(true)
+	assert.Equal(t, SkipBudget, ExplainRequest(f, r).SkipReason)
+
+	rr := httptest.NewRecorder()
+	f.serve(rr, r, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(testHandlerCode)
+	}), st, ev)
+	assert.Equal(t, testHandlerCode, rr.Code)
+	assert.Equal(t, int64(1), f.stats.counters()["skipped_budget"])
+}
+
+// eventTypes returns the type of each Event in events.
+func eventTypes(events []Event) []EventType {
+	types := make([]EventType, len(events))
+	for idx, e := range events {
+		types[idx] = e.Type
+	}
+
+	return types
+}
+
+// TestFaultWithMaxConcurrent tests WithMaxConcurrent as an Option of a Fault.
+func TestFaultWithMaxConcurrent(t *testing.T) {
+	t.Parallel()
+
+	f, err := NewFault(newTestInjectorNoop(), WithMaxConcurrent(50))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(50), f.maxConcurrent)
+
+	f, err = NewFault(newTestInjectorNoop(), WithMaxConcurrent(-1))
+	assert.Equal(t, ErrInvalidMaxConcurrent, err)
+	assert.Nil(t, f)
+}
+
+// TestFaultHandlerMaxConcurrent tests that a Fault passes requests through while its Injector runs
+// on as many requests as WithMaxConcurrent allows.
+func TestFaultHandlerMaxConcurrent(t *testing.T) {
+	t.Parallel()
+
+	entered, release := make(chan struct{}), make(chan struct{})
+	blocking := InjectorFunc(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			entered <- struct{}{}
+			<-release
+			w.WriteHeader(http.StatusInternalServerError)
+		})
+	})
+
+	f, err := NewFault(blocking,
+		WithEnabled(true),
+		WithParticipation(1.0),
+		WithMaxConcurrent(2),
+		WithInjectionBudget(3),
+	)
+	assert.NoError(t, err)
+
+	codes := make(chan int, 2)
+	for idx := 0; idx < 2; idx++ {
+		go func() { codes <- testRequest(t, f).Code }()
+		<-entered
+	}
+
+	assert.Equal(t, testHandlerCode, testRequest(t, f).Code)
+	assert.Equal(t, SkipConcurrency, ExplainRequest(f, httptest.NewRequest(http.MethodGet, "/", nil)).SkipReason)
+	stats := f.Stats()
+	assert.Equal(t, int64(2), stats.Active)
+	assert.Equal(t, map[SkipReason]int64{SkipConcurrency: 1}, stats.SkippedBy)
+	remaining, _ := f.BudgetRemaining()
+	assert.Equal(t, int64(1), remaining)
+
+	close(release)
+	assert.Equal(t, http.StatusInternalServerError, <-codes)
+	assert.Equal(t, http.StatusInternalServerError, <-codes)
+
+	go func() { <-entered }()
+	assert.Equal(t, http.StatusInternalServerError, testRequest(t, f).Code)
+	assert.Equal(t, int64(0), f.concurrent)
+}
+
+// TestFaultHandlerMaxConcurrentSkipped tests that a request that takes a slot and is then skipped
+// by a later check gives the slot back.
+func TestFaultHandlerMaxConcurrentSkipped(t *testing.T) {
+	t.Parallel()
+
+	f, err := NewFault(newTestInjector500s(),
+		WithEnabled(true),
+		WithParticipation(1.0),
+		WithMaxConcurrent(1),
+		WithInjectionRate(1, time.Hour),
+	)
+	assert.NoError(t, err)
+
+	assert.Equal(t, http.StatusInternalServerError, testRequest(t, f).Code)
+	assert.Equal(t, testHandlerCode, testRequest(t, f).Code)
+	assert.Equal(t, int64(0), f.concurrent)
+	assert.Equal(t, map[SkipReason]int64{SkipRateLimit: 1}, f.Stats().SkippedBy)
+}
@@ -0,0 +1,91 @@
+package fault
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// callKey is the context key of the Call a request describes.
+type callKey struct{}
+
+// Call describes a call of any protocol, such as a gRPC method, a SQL query, a Redis command, or a
+// connection, so that a Fault can decide whether to fault it with the same participation,
+// matchers, schedule, rate limit, and budget as an HTTP request. The Fault sees the Call as the
+// request returned by Request, so RequestMatchers, allowlists, and key functions work on it
+// unchanged.
+type Call struct {
+	// Context is the context of the Call. Default context.Background().
+	Context context.Context
+	// Protocol names the protocol of the Call, such as "grpc", "sql", "redis", or "tcp".
+	Protocol string
+	// Operation is what the Call does, such as "/checkout.Checkout/Pay", "SELECT", or "GET". It is
+	// the path of the request.
+	Operation string
+	// Target is where the Call goes, such as the address of a server or the name of a database. It
+	// is the host of the request.
+	Target string
+	// Metadata are the headers of the request, such as the metadata of a gRPC call.
+	Metadata map[string][]string
+	// RemoteAddr is the network address of the client.
+	RemoteAddr string
+	// ReadOnly is true if the Call does not change any state, such as a SQL SELECT. A ReadOnly Call
+	// is a GET request and any other Call a POST request, so an IdempotencyClassifier keeps
+	// destructive Injectors away from Calls that are not ReadOnly.
+	ReadOnly bool
+}
+
+// Request returns the request a Fault sees for the Call. The Call can be found again in its context
+// with CallFromRequest.
+func (c Call) Request() *http.Request {
+	method := http.MethodPost
+	if c.ReadOnly {
+		method = http.MethodGet
+	}
+
+	ctx := c.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	r := &http.Request{
+		Method:     method,
+		URL:        &url.URL{Path: c.Operation},
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     make(http.Header, len(c.Metadata)),
+		Body:       http.NoBody,
+		Host:       c.Target,
+		RemoteAddr: c.RemoteAddr,
+		RequestURI: c.Operation,
+	}
+
+	for k, vs := range c.Metadata {
+		for _, v := range vs {
+			r.Header.Add(k, v)
+		}
+	}
+
+	return r.WithContext(context.WithValue(ctx, callKey{}, c))
+}
+
+// CallFromRequest returns the Call r was built from by Call.Request, and false if r is a genuine
+// HTTP request.
+func CallFromRequest(r *http.Request) (Call, bool) {
+	c, ok := r.Context().Value(callKey{}).(Call)
+	return c, ok
+}
+
+// Decide decides whether the Fault faults c, with every check an HTTP request gets, and counts and
+// reports the Evaluation like one. It lets injectors of other protocols reuse the decisions of a
+// Fault instead of making their own: if the Evaluation is Injected, the caller applies its own
+// fault to the Call, such as returning an error from a SQL driver, and does not run the Injector
+// of the Fault. Call done once the Call finishes, so the Fault stops counting it as active and a
+// Fault that used up its budget disables itself.
+func (f *Fault) Decide(c Call) (ev Evaluation, done func()) {
+	r := c.Request()
+	st := f.injector.Load()
+
+	return f.settle(r, st, f.evaluate(r, st))
+}
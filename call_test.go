@@ -0,0 +1,117 @@
+package fault
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestCallRequest tests Call.Request and CallFromRequest.
+func TestCallRequest(t *testing.T) {
+	t.Parallel()
+
+	type ctxKey struct{}
+	ctx := context.WithValue(context.Background(), ctxKey{}, "value")
+
+	tests := []struct {
+		name       string
+		give       Call
+		wantMethod string
+		wantCtx    interface{}
+	}{
+		{
+			name: "read only",
+			give: Call{
+				Context:    ctx,
+				Protocol:   "sql",
+				Operation:  "SELECT",
+				Target:     "orders",
+				Metadata:   map[string][]string{"x-user-id": {"alice"}},
+				RemoteAddr: "192.0.2.1:1234",
+				ReadOnly:   true,
+			},
+			wantMethod: http.MethodGet,
+			wantCtx:    "value",
+		},
+		{
+			name: "write without context",
+			give: Call{
+				Protocol:  "redis",
+				Operation: "SET",
+				Target:    "cache:6379",
+			},
+			wantMethod: http.MethodPost,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := tt.give.Request()
+
+			assert.Equal(t, tt.wantMethod, r.Method)
+			assert.Equal(t, tt.give.Operation, r.URL.Path)
+			assert.Equal(t, tt.give.Target, r.Host)
+			assert.Equal(t, tt.give.RemoteAddr, r.RemoteAddr)
+			assert.Equal(t, tt.wantCtx, r.Context().Value(ctxKey{}))
+			for k, vs := range tt.give.Metadata {
+				assert.Equal(t, vs, r.Header.Values(k))
+			}
+
+			c, ok := CallFromRequest(r)
+			assert.True(t, ok)
+			assert.Equal(t, tt.give, c)
+		})
+	}
+
+	_, ok := CallFromRequest(&http.Request{})
+	assert.False(t, ok)
+}
+
+// TestFaultDecide tests that Fault.Decide decides Calls with the checks, stats, and budget of
+// requests.
+func TestFaultDecide(t *testing.T) {
+	t.Parallel()
+
+	f, err := NewFault(newTestInjector500s(),
+		WithName("sql"),
+		WithEnabled(true),
+		WithParticipation(1.0),
+		WithHeaderAllowlist(map[string]string{"X-Tenant": "test"}),
+		WithInjectionBudget(1),
+	)
+	assert.NoError(t, err)
+
+	call := Call{Protocol: "sql", Operation: "SELECT", Metadata: map[string][]string{"x-tenant": {"test"}}}
+
+	ev, done := f.Decide(Call{Protocol: "sql", Operation: "SELECT"})
+	assert.False(t, ev.Injected)
+	assert.Equal(t, SkipUnmatched, ev.SkipReason)
+	done()
+
+	ev, done = f.Decide(call)
+	assert.True(t, ev.Injected)
+	assert.Equal(t, "sql", ev.Fault)
+	assert.Equal(t, int64(1), f.stats.counters()["active"])
+	assert.True(t, f.Enabled())
+
+	done()
+	assert.Equal(t, int64(0), f.stats.counters()["active"])
+	assert.False(t, f.Enabled())
+
+	ev, done = f.Decide(call)
+	assert.Equal(t, SkipDisabled, ev.SkipReason)
+	done()
+
+	assert.Equal(t, testCounters(map[string]int64{
+		"evaluated":         3,
+		"injected":          1,
+		"skipped":           2,
+		"skipped_unmatched": 1,
+		"skipped_disabled":  1,
+	}), f.stats.counters())
+}
@@ -0,0 +1,123 @@
+package fault
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+var (
+	// ErrInvalidCapturePeriod when a LatencyCapture is not given a positive period.
+	ErrInvalidCapturePeriod = errors.New("capture period must be > 0")
+	// ErrInvalidProfile when a LatencyProfile holds a malformed LatencyHistogram.
+	ErrInvalidProfile = errors.New("invalid latency profile")
+)
+
+// LatencyProfile is the latency distribution of each route of real traffic, captured by a
+// LatencyCapture and replayed by a DistributionInjector.
+type LatencyProfile struct {
+	// Routes are the latency distributions of each route.
+	Routes map[string]LatencyHistogram `json:"routes"`
+}
+
+// ReadLatencyProfile reads a LatencyProfile written by LatencyProfile.Write. It returns an error
+// wrapping ErrInvalidProfile naming the first route whose histogram is malformed.
+func ReadLatencyProfile(r io.Reader) (*LatencyProfile, error) {
+	var p LatencyProfile
+	err := json.NewDecoder(r).Decode(&p)
+	if err != nil {
+		return nil, err
+	}
+
+	for route, h := range p.Routes {
+		if !h.valid() {
+			return nil, fmt.Errorf("%w: route %q", ErrInvalidProfile, route)
+		}
+	}
+
+	return &p, nil
+}
+
+// Write writes the LatencyProfile as JSON.
+func (p *LatencyProfile) Write(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(p)
+}
+
+// LatencyCapture records the latency of real requests to each route for a period, without
+// injecting anything, so the resulting LatencyProfile can later be replayed by a
+// DistributionInjector. Capturing starts when the LatencyCapture is created.
+//
+//	lc, _ := fault.NewLatencyCapture(time.Hour)
+//	handler := lc.Handler(mux)
+//	go func() {
+//	    <-lc.Done()
+//	    f, _ := os.Create("latency.json")
+//	    lc.Profile().Write(f)
+//	    f.Close()
+//	}()
+type LatencyCapture struct {
+	sampler *LatencySampler
+	done    chan struct{}
+}
+
+// NewLatencyCapture returns a LatencyCapture that records requests for period. It takes the same
+// options as a LatencySampler, such as WithBuckets() and WithRouteFunc(). It returns
+// ErrInvalidCapturePeriod if period is not positive.
+func NewLatencyCapture(period time.Duration, opts ...LatencySamplerOption) (*LatencyCapture, error) {
+	if period <= 0 {
+		return nil, ErrInvalidCapturePeriod
+	}
+
+	s, err := NewLatencySampler(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	lc := &LatencyCapture{
+		sampler: s,
+		done:    make(chan struct{}),
+	}
+
+	after := s.clock.After(period)
+	go func() {
+		<-after
+		close(lc.done)
+	}()
+
+	return lc, nil
+}
+
+// Handler records the latency of requests to next until the period is over.
+func (c *LatencyCapture) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-c.done:
+		default:
+			defer c.sampler.start(r, false)()
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Done returns a channel that is closed once the period is over.
+func (c *LatencyCapture) Done() <-chan struct{} {
+	return c.done
+}
+
+// Profile returns the LatencyProfile recorded so far.
+func (c *LatencyCapture) Profile() *LatencyProfile {
+	snap := c.sampler.Snapshot()
+
+	p := &LatencyProfile{Routes: make(map[string]LatencyHistogram, len(snap))}
+	for route, rl := range snap {
+		p.Routes[route] = rl.Baseline
+	}
+
+	return p
+}
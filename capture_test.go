@@ -0,0 +1,172 @@
+package fault
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/github/go-fault/faulttest"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestNewLatencyCapture tests NewLatencyCapture.
+func TestNewLatencyCapture(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		givePeriod  time.Duration
+		giveOptions []LatencySamplerOption
+		wantErr     error
+	}{
+		{
+			name:       "valid",
+			givePeriod: time.Hour,
+		},
+		{
+			name:       "zero period",
+			givePeriod: 0,
+			wantErr:    ErrInvalidCapturePeriod,
+		},
+		{
+			name:        "option error",
+			givePeriod:  time.Hour,
+			giveOptions: []LatencySamplerOption{WithBuckets(nil)},
+			wantErr:     ErrInvalidBuckets,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			lc, err := NewLatencyCapture(tt.givePeriod, tt.giveOptions...)
+
+			assert.Equal(t, tt.wantErr, err)
+			if tt.wantErr != nil {
+				assert.Nil(t, lc)
+				return
+			}
+
+			assert.Empty(t, lc.Profile().Routes)
+		})
+	}
+}
+
+// TestLatencyCaptureHandler tests that a LatencyCapture records requests until its period is over.
+func TestLatencyCaptureHandler(t *testing.T) {
+	t.Parallel()
+
+	clock := faulttest.NewClock(time.Time{})
+	lc, err := NewLatencyCapture(time.Hour,
+		WithBuckets([]time.Duration{10 * time.Millisecond, 100 * time.Millisecond}),
+		WithClock(clock),
+	)
+	assert.NoError(t, err)
+
+	h := lc.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		clock.Advance(50 * time.Millisecond)
+		w.WriteHeader(testHandlerCode)
+	}))
+	serve := func(path string) {
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+		assert.Equal(t, testHandlerCode, rr.Code)
+	}
+
+	serve("/a")
+	serve("/a")
+	serve("/b")
+
+	clock.Advance(time.Hour)
+	<-lc.Done()
+
+	serve("/a")
+	serve("/c")
+
+	p := lc.Profile()
+	assert.Len(t, p.Routes, 2)
+	assert.Equal(t, []int64{0, 2, 0}, p.Routes["/a"].Counts)
+	assert.Equal(t, []int64{0, 1, 0}, p.Routes["/b"].Counts)
+}
+
+// TestLatencyProfileWrite tests that LatencyProfile.Write output is read back by
+// ReadLatencyProfile.
+func TestLatencyProfileWrite(t *testing.T) {
+	t.Parallel()
+
+	want := &LatencyProfile{Routes: map[string]LatencyHistogram{
+		"/a": {
+			Buckets: []time.Duration{10 * time.Millisecond, 100 * time.Millisecond},
+			Counts:  []int64{3, 2, 1},
+			Count:   6,
+			Sum:     time.Second,
+		},
+	}}
+
+	var buf bytes.Buffer
+	assert.NoError(t, want.Write(&buf))
+
+	got, err := ReadLatencyProfile(&buf)
+	assert.NoError(t, err)
+	assert.Equal(t, want, got)
+}
+
+// TestReadLatencyProfile tests ReadLatencyProfile.
+func TestReadLatencyProfile(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		give    string
+		want    *LatencyProfile
+		wantErr string
+	}{
+		{
+			name: "valid",
+			give: `{"routes": {"/a": {"buckets": [1000], "counts": [1, 1], "count": 2, "sum": 3000}}}`,
+			want: &LatencyProfile{Routes: map[string]LatencyHistogram{
+				"/a": {Buckets: []time.Duration{1000}, Counts: []int64{1, 1}, Count: 2, Sum: 3000},
+			}},
+		},
+		{
+			name:    "invalid json",
+			give:    `{"routes": [`,
+			wantErr: "unexpected EOF",
+		},
+		{
+			name:    "invalid histogram",
+			give:    `{"routes": {"/a": {"buckets": [1000], "counts": [1], "count": 1}}}`,
+			wantErr: `invalid latency profile: route "/a"`,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			p, err := ReadLatencyProfile(strings.NewReader(tt.give))
+
+			if tt.wantErr != "" {
+				assert.Error(t, err)
+				if err != nil {
+					assert.Equal(t, tt.wantErr, err.Error())
+				}
+				assert.Nil(t, p)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, p)
+		})
+	}
+
+	_, err := ReadLatencyProfile(strings.NewReader(`{"routes": {"/a": {}}}`))
+	assert.True(t, errors.Is(err, ErrInvalidProfile))
+}
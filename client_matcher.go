@@ -0,0 +1,149 @@
+package fault
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/netip"
+	"regexp"
+	"strings"
+)
+
+// MatchClientIP returns a RequestMatcher that matches requests whose client IP, taken from
+// RemoteAddr, is in any of prefixes, such as the ranges of an office network or of the synthetic
+// monitors of a provider. Use netip.MustParsePrefix() to build them, with a /32 or /128 prefix for
+// a single address. IPv4 clients connected over IPv6 are matched as IPv4. Behind a proxy RemoteAddr
+// is the address of the proxy, so set it from the forwarded headers you trust before the Fault runs.
+func MatchClientIP(prefixes ...netip.Prefix) RequestMatcher {
+	return RequestMatcherFunc(func(r *http.Request) bool {
+		ip, err := netip.ParseAddr(clientIP(r))
+		if err != nil {
+			return false
+		}
+		ip = ip.Unmap()
+
+		for _, p := range prefixes {
+			if p.Contains(ip) {
+				return true
+			}
+		}
+
+		return false
+	})
+}
+
+// MatchUserAgent returns a RequestMatcher that matches requests whose User-Agent matches re, such as
+// the agents of synthetic monitors.
+func MatchUserAgent(re *regexp.Regexp) RequestMatcher {
+	return RequestMatcherFunc(func(r *http.Request) bool {
+		return re.MatchString(r.UserAgent())
+	})
+}
+
+// MatchCookie returns a RequestMatcher that matches requests with cookie name set to any of values,
+// compared case-sensitively. Without values, any non-empty value matches.
+func MatchCookie(name string, values ...string) RequestMatcher {
+	return RequestMatcherFunc(func(r *http.Request) bool {
+		c, err := r.Cookie(name)
+		if err != nil {
+			return false
+		}
+		if len(values) == 0 {
+			return c.Value != ""
+		}
+
+		for _, want := range values {
+			if c.Value == want {
+				return true
+			}
+		}
+
+		return false
+	})
+}
+
+// MatchJWTClaim returns a RequestMatcher that matches requests that send a JWT as a Bearer token in
+// the Authorization header with claim set to any of values, such as the "sub" of test accounts. A
+// claim that is a list, like "aud" or "groups", matches if any of its elements does, and numbers
+// and booleans are compared in their JSON form. Without values, any claim that is set matches.
+//
+// The signature of the token is not verified, so any client can send a token that matches. Use it
+// to choose who is faulted, never to decide what a client may do.
+func MatchJWTClaim(claim string, values ...string) RequestMatcher {
+	return RequestMatcherFunc(func(r *http.Request) bool {
+		claims, ok := jwtClaims(r)
+		if !ok {
+			return false
+		}
+
+		raw, ok := claims[claim]
+		if !ok {
+			return false
+		}
+		if len(values) == 0 {
+			return true
+		}
+
+		for _, v := range claimValues(raw) {
+			for _, want := range values {
+				if v == want {
+					return true
+				}
+			}
+		}
+
+		return false
+	})
+}
+
+// jwtClaims returns the claims of the JWT r sends as a Bearer token, without verifying it, and
+// false if r does not send one.
+func jwtClaims(r *http.Request) (map[string]json.RawMessage, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return nil, false
+	}
+
+	parts := strings.Split(strings.TrimSpace(token), ".")
+	if len(parts) != 3 {
+		return nil, false
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return nil, false
+	}
+
+	var claims map[string]json.RawMessage
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, false
+	}
+
+	return claims, true
+}
+
+// claimValues returns the values of a JWT claim as strings: the claim itself, or its elements if it
+// is a list.
+func claimValues(raw json.RawMessage) []string {
+	var list []json.RawMessage
+	if err := json.Unmarshal(raw, &list); err != nil {
+		return []string{claimValue(raw)}
+	}
+
+	out := make([]string, 0, len(list))
+	for _, elem := range list {
+		out = append(out, claimValue(elem))
+	}
+
+	return out
+}
+
+// claimValue returns a string claim unquoted and any other claim in its JSON form.
+func claimValue(raw json.RawMessage) string {
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s
+	}
+
+	return strings.TrimSpace(string(raw))
+}
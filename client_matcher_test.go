@@ -0,0 +1,220 @@
+package fault
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testJWT returns an unsigned JWT with payload as its claims.
+func testJWT(payload string) string {
+	enc := base64.RawURLEncoding
+	return enc.EncodeToString([]byte(`{"alg":"none"}`)) + "." + enc.EncodeToString([]byte(payload)) + "."
+}
+
+// TestMatchClient tests MatchClientIP, MatchUserAgent, MatchCookie, MatchJWTClaim, and MatchAll.
+func TestMatchClient(t *testing.T) {
+	t.Parallel()
+
+	office := netip.MustParsePrefix("10.0.0.0/8")
+	monitor := netip.MustParsePrefix("2001:db8::/32")
+	testAccount := MatchJWTClaim("sub", "test-1", "test-2")
+
+	tests := []struct {
+		name        string
+		giveMatcher RequestMatcher
+		giveAddr    string
+		giveHeader  http.Header
+		want        bool
+	}{
+		{
+			name:        "ip",
+			giveMatcher: MatchClientIP(office, monitor),
+			giveAddr:    "10.1.2.3:1234",
+			want:        true,
+		},
+		{
+			name:        "ipv6",
+			giveMatcher: MatchClientIP(office, monitor),
+			giveAddr:    "[2001:db8::1]:1234",
+			want:        true,
+		},
+		{
+			name:        "ipv4 mapped",
+			giveMatcher: MatchClientIP(office),
+			giveAddr:    "[::ffff:10.1.2.3]:1234",
+			want:        true,
+		},
+		{
+			name:        "ip without port",
+			giveMatcher: MatchClientIP(netip.MustParsePrefix("192.0.2.1/32")),
+			giveAddr:    "192.0.2.1",
+			want:        true,
+		},
+		{
+			name:        "ip no match",
+			giveMatcher: MatchClientIP(office, monitor),
+			giveAddr:    "192.0.2.1:1234",
+			want:        false,
+		},
+		{
+			name:        "ip invalid",
+			giveMatcher: MatchClientIP(office),
+			giveAddr:    "pipe",
+			want:        false,
+		},
+		{
+			name:        "user agent",
+			giveMatcher: MatchUserAgent(regexp.MustCompile(`(?i)pingdom|datadog`)),
+			giveHeader:  http.Header{"User-Agent": {"Datadog/Synthetics"}},
+			want:        true,
+		},
+		{
+			name:        "user agent no match",
+			giveMatcher: MatchUserAgent(regexp.MustCompile(`(?i)pingdom|datadog`)),
+			giveHeader:  http.Header{"User-Agent": {"Mozilla/5.0"}},
+			want:        false,
+		},
+		{
+			name:        "cookie",
+			giveMatcher: MatchCookie("account", "test-1", "test-2"),
+			giveHeader:  http.Header{"Cookie": {"session=abc; account=test-2"}},
+			want:        true,
+		},
+		{
+			name:        "cookie case",
+			giveMatcher: MatchCookie("account", "test-1"),
+			giveHeader:  http.Header{"Cookie": {"account=TEST-1"}},
+			want:        false,
+		},
+		{
+			name:        "cookie any value",
+			giveMatcher: MatchCookie("fault"),
+			giveHeader:  http.Header{"Cookie": {"fault=1"}},
+			want:        true,
+		},
+		{
+			name:        "cookie empty",
+			giveMatcher: MatchCookie("fault"),
+			giveHeader:  http.Header{"Cookie": {"fault="}},
+			want:        false,
+		},
+		{
+			name:        "cookie missing",
+			giveMatcher: MatchCookie("fault"),
+			want:        false,
+		},
+		{
+			name:        "jwt",
+			giveMatcher: testAccount,
+			giveHeader:  http.Header{"Authorization": {"Bearer " + testJWT(`{"sub":"test-2"}`)}},
+			want:        true,
+		},
+		{
+			name:        "jwt scheme case",
+			giveMatcher: testAccount,
+			giveHeader:  http.Header{"Authorization": {"bearer " + testJWT(`{"sub":"test-1"}`)}},
+			want:        true,
+		},
+		{
+			name:        "jwt no match",
+			giveMatcher: testAccount,
+			giveHeader:  http.Header{"Authorization": {"Bearer " + testJWT(`{"sub":"user-1"}`)}},
+			want:        false,
+		},
+		{
+			name:        "jwt list",
+			giveMatcher: MatchJWTClaim("groups", "chaos"),
+			giveHeader:  http.Header{"Authorization": {"Bearer " + testJWT(`{"groups":["staff","chaos"]}`)}},
+			want:        true,
+		},
+		{
+			name:        "jwt bool",
+			giveMatcher: MatchJWTClaim("test", "true"),
+			giveHeader:  http.Header{"Authorization": {"Bearer " + testJWT(`{"test":true}`)}},
+			want:        true,
+		},
+		{
+			name:        "jwt number",
+			giveMatcher: MatchJWTClaim("tier", "3"),
+			giveHeader:  http.Header{"Authorization": {"Bearer " + testJWT(`{"tier":3}`)}},
+			want:        true,
+		},
+		{
+			name:        "jwt any value",
+			giveMatcher: MatchJWTClaim("test"),
+			giveHeader:  http.Header{"Authorization": {"Bearer " + testJWT(`{"test":false}`)}},
+			want:        true,
+		},
+		{
+			name:        "jwt claim missing",
+			giveMatcher: MatchJWTClaim("test"),
+			giveHeader:  http.Header{"Authorization": {"Bearer " + testJWT(`{"sub":"test-1"}`)}},
+			want:        false,
+		},
+		{
+			name:        "jwt basic auth",
+			giveMatcher: MatchJWTClaim("sub"),
+			giveHeader:  http.Header{"Authorization": {"Basic dXNlcjpwYXNz"}},
+			want:        false,
+		},
+		{
+			name:        "jwt malformed",
+			giveMatcher: MatchJWTClaim("sub"),
+			giveHeader:  http.Header{"Authorization": {"Bearer abc.!!!.def"}},
+			want:        false,
+		},
+		{
+			name:        "jwt not json",
+			giveMatcher: MatchJWTClaim("sub"),
+			giveHeader:  http.Header{"Authorization": {"Bearer " + testJWT(`sub`)}},
+			want:        false,
+		},
+		{
+			name:        "all",
+			giveMatcher: MatchAll(MatchClientIP(office), testAccount),
+			giveAddr:    "10.1.2.3:1234",
+			giveHeader:  http.Header{"Authorization": {"Bearer " + testJWT(`{"sub":"test-1"}`)}},
+			want:        true,
+		},
+		{
+			name:        "all no match",
+			giveMatcher: MatchAll(MatchClientIP(office), testAccount),
+			giveAddr:    "192.0.2.1:1234",
+			giveHeader:  http.Header{"Authorization": {"Bearer " + testJWT(`{"sub":"test-1"}`)}},
+			want:        false,
+		},
+		{
+			name: "any of all",
+			giveMatcher: MatchAny(
+				MatchAll(MatchClientIP(office), testAccount),
+				MatchUserAgent(regexp.MustCompile(`Synthetics`)),
+			),
+			giveAddr:   "192.0.2.1:1234",
+			giveHeader: http.Header{"User-Agent": {"Datadog/Synthetics"}},
+			want:       true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.giveAddr != "" {
+				req.RemoteAddr = tt.giveAddr
+			}
+			for k, vs := range tt.giveHeader {
+				req.Header[k] = vs
+			}
+
+			assert.Equal(t, tt.want, tt.giveMatcher.MatchRequest(req))
+		})
+	}
+}
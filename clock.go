@@ -0,0 +1,82 @@
+package fault
+
+import "time"
+
+// Clock tells the time and waits for time to pass. Time-based features use a Clock so that they
+// can be tested without real sleeps. The faulttest package provides a controllable fake.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+	// Sleep pauses the current goroutine for at least the duration d.
+	Sleep(d time.Duration)
+	// After waits for the duration to elapse and then sends the current time on the returned
+	// channel.
+	After(d time.Duration) <-chan time.Time
+}
+
+// RealClock is a Clock that uses the time package.
+type RealClock struct{}
+
+// NewRealClock returns a new RealClock.
+func NewRealClock() *RealClock {
+	return &RealClock{}
+}
+
+// Now returns time.Now().
+func (c *RealClock) Now() time.Time {
+	return time.Now()
+}
+
+// Sleep runs time.Sleep(d).
+func (c *RealClock) Sleep(d time.Duration) {
+	time.Sleep(d)
+}
+
+// After returns time.After(d).
+func (c *RealClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+// ClockOption configures things that can use a Clock.
+type ClockOption interface {
+	Option
+	SlowInjectorOption
+	LatencySamplerOption
+	ManagerOption
+	WatchdogOption
+	SandboxInjectorOption
+	RuntimeOption
+	ErrorGuardOption
+	OutageInjectorOption
+	PresetOption
+	BrownoutInjectorOption
+	DistributionInjectorOption
+	OutcomeRecorderOption
+	WeightedLatencyInjectorOption
+	RampInjectorOption
+	InterimInjectorOption
+	SlowBodyInjectorOption
+	ChainInjectorOption
+	DynamicFaultOption
+	ThrottleInjectorOption
+	ClientDelayInjectorOption
+	BandwidthLimitInjectorOption
+	ResourcePressureInjectorOption
+	ConcluderOption
+	SLOGuardOption
+	ListenerOption
+	ExperimentOption
+	DuplicateRequestInjectorOption
+	StreamInjectorOption
+	StubInjectorOption
+}
+
+// clockOption holds our passed in Clock.
+type clockOption struct {
+	clock Clock
+}
+
+// WithClock sets the Clock used to tell time and wait. Default RealClock.
+func WithClock(c Clock) ClockOption {
+	return clockOption{c}
+}
@@ -0,0 +1,28 @@
+package fault
+
+import (
+	"testing"
+	"time"
+
+	"github.com/github/go-fault/faulttest"
+	"github.com/stretchr/testify/assert"
+)
+
+// Both clocks must satisfy the Clock interface.
+var (
+	_ Clock = NewRealClock()
+	_ Clock = faulttest.NewClock(time.Time{})
+)
+
+// TestRealClock tests RealClock.
+func TestRealClock(t *testing.T) {
+	t.Parallel()
+
+	c := NewRealClock()
+
+	start := c.Now()
+	c.Sleep(time.Millisecond)
+	<-c.After(time.Millisecond)
+
+	assert.GreaterOrEqual(t, int64(c.Now().Sub(start)), int64(2*time.Millisecond))
+}
@@ -0,0 +1,26 @@
+/*
+Command faultctl checks and upgrades the fault configs read by fault.NewFaultsFromConfig, so configs
+kept in a repository can be checked in CI and keep loading across upgrades of the fault package, and
+turns them into Go code once they are ready to be compiled in.
+
+Usage:
+
+    faultctl validate [file ...]
+    faultctl migrate [-w] [file]
+    faultctl gen [-package name] [-func name] [file]
+
+Validate builds the Faults of each config, or of standard input if no file is given, and reports
+the first error of each config that does not load. It exits 1 if any config is invalid. Configs
+without a version are reported so their version can be added with migrate. Validate only knows the
+built-in injector types; configs that use custom types are checked by calling
+fault.NewFaultsFromConfig from a program that registers them.
+
+Migrate rewrites a config in the format of fault.ConfigVersion and prints it, or with -w writes it
+back to the file. Comments are not kept.
+
+Gen prints Go code that builds the Faults of a config with the constructors of the fault package,
+as a function named by -func (default newFaults) in the package named by -package (default faults).
+Like validate, it only knows the built-in injector types. To go the other way, pass Faults built in
+Go to fault.FaultsToConfig.
+*/
+package main
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/github/go-fault"
+	"gopkg.in/yaml.v2"
+)
+
+// stdinName is the file name of standard input.
+const stdinName = "-"
+
+// errUsage when faultctl is run with invalid arguments.
+var errUsage = errors.New("usage: faultctl validate [file ...] | faultctl migrate [-w] [file] | " +
+	"faultctl gen [-package name] [-func name] [file]")
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run runs faultctl with args and returns its exit code.
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprintln(stderr, errUsage)
+		return 2
+	}
+
+	switch args[0] {
+	case "validate":
+		return validate(args[1:], stdin, stdout)
+	case "migrate":
+		return migrate(args[1:], stdin, stdout, stderr)
+	case "gen":
+		return gen(args[1:], stdin, stdout, stderr)
+	default:
+		fmt.Fprintln(stderr, errUsage)
+		return 2
+	}
+}
+
+// validate reports if each config in files loads.
+func validate(files []string, stdin io.Reader, stdout io.Writer) int {
+	if len(files) == 0 {
+		files = []string{stdinName}
+	}
+
+	code := 0
+	for _, name := range files {
+		msg, err := validateFile(name, stdin)
+		if err != nil {
+			fmt.Fprintf(stdout, "%s: %v\n", name, err)
+			code = 1
+			continue
+		}
+
+		fmt.Fprintf(stdout, "%s: %s\n", name, msg)
+	}
+
+	return code
+}
+
+// validateFile loads the config in the file name and describes it.
+func validateFile(name string, stdin io.Reader) (string, error) {
+	b, err := readFile(name, stdin)
+	if err != nil {
+		return "", err
+	}
+
+	faults, err := fault.NewFaultsFromConfig(bytes.NewReader(b))
+	if err != nil {
+		return "", err
+	}
+
+	var v struct {
+		Version int `yaml:"version"`
+	}
+	_ = yaml.Unmarshal(b, &v)
+	if v.Version == 0 {
+		return fmt.Sprintf("ok, %d faults, no version (run faultctl migrate to add one)", len(faults)), nil
+	}
+
+	return fmt.Sprintf("ok, %d faults, version %d", len(faults), v.Version), nil
+}
+
+// migrate rewrites the config in the file of args in the format of fault.ConfigVersion.
+func migrate(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	write := fs.Bool("w", false, "write the migrated config back to the file")
+
+	err := fs.Parse(args)
+	if err != nil {
+		return 2
+	}
+
+	name := stdinName
+	switch {
+	case fs.NArg() == 1:
+		name = fs.Arg(0)
+	case fs.NArg() > 1, *write && fs.NArg() == 0:
+		fmt.Fprintln(stderr, errUsage)
+		return 2
+	}
+
+	b, err := readFile(name, stdin)
+	if err != nil {
+		fmt.Fprintf(stderr, "%s: %v\n", name, err)
+		return 1
+	}
+
+	b, err = fault.MigrateConfig(bytes.NewReader(b))
+	if err != nil {
+		fmt.Fprintf(stderr, "%s: %v\n", name, err)
+		return 1
+	}
+
+	if !*write {
+		_, _ = stdout.Write(b)
+		return 0
+	}
+
+	err = writeFile(name, b)
+	if err != nil {
+		fmt.Fprintf(stderr, "%s: %v\n", name, err)
+		return 1
+	}
+
+	return 0
+}
+
+// gen prints Go code that builds the Faults of the config in the file of args.
+func gen(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("gen", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	pkg := fs.String("package", "faults", "the package of the generated code")
+	funcName := fs.String("func", "newFaults", "the name of the generated function")
+
+	err := fs.Parse(args)
+	if err != nil {
+		return 2
+	}
+
+	name := stdinName
+	switch fs.NArg() {
+	case 0:
+	case 1:
+		name = fs.Arg(0)
+	default:
+		fmt.Fprintln(stderr, errUsage)
+		return 2
+	}
+
+	b, err := readFile(name, stdin)
+	if err != nil {
+		fmt.Fprintf(stderr, "%s: %v\n", name, err)
+		return 1
+	}
+
+	b, err = fault.ConfigToGo(bytes.NewReader(b), fault.WithGoPackage(*pkg), fault.WithGoFunc(*funcName))
+	if err != nil {
+		fmt.Fprintf(stderr, "%s: %v\n", name, err)
+		return 1
+	}
+
+	_, _ = stdout.Write(b)
+	return 0
+}
+
+// writeFile replaces the contents of the file name with b, keeping its permissions.
+func writeFile(name string, b []byte) error {
+	info, err := os.Stat(name)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(name, b, info.Mode().Perm())
+}
+
+// readFile reads the file name, or stdin if name is stdinName.
+func readFile(name string, stdin io.Reader) ([]byte, error) {
+	if name == stdinName {
+		return io.ReadAll(stdin)
+	}
+
+	return os.ReadFile(name)
+}
@@ -0,0 +1,309 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testConfig is a config without a version.
+const testConfig = `
+faults:
+  - name: checkout-latency
+    participation: 0.05
+    injector:
+      type: slow
+      params: {duration: 750ms}
+`
+
+// testMigratedConfig is testConfig migrated to the current version.
+const testMigratedConfig = `version: 1
+faults:
+- name: checkout-latency
+  participation: 0.05
+  injector:
+    type: slow
+    params:
+      duration: 750ms
+`
+
+// writeTestFile writes content to the file name in dir and returns its path.
+func writeTestFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(dir, name)
+	assert.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	return path
+}
+
+// TestValidate tests faultctl validate.
+func TestValidate(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	unversioned := writeTestFile(t, dir, "unversioned.yaml", testConfig)
+	versioned := writeTestFile(t, dir, "versioned.yaml", testMigratedConfig)
+	newer := writeTestFile(t, dir, "newer.yaml", "version: 2\nfaults: []\n")
+	unknown := writeTestFile(t, dir, "unknown.yaml", "faults: [{injector: {type: blackhole}}]\n")
+	missing := filepath.Join(dir, "missing.yaml")
+
+	tests := []struct {
+		name      string
+		giveArgs  []string
+		giveStdin string
+		wantCode  int
+		wantOut   []string
+	}{
+		{
+			name:     "valid",
+			giveArgs: []string{"validate", unversioned, versioned},
+			wantOut: []string{
+				unversioned + ": ok, 1 faults, no version (run faultctl migrate to add one)",
+				versioned + ": ok, 1 faults, version 1",
+			},
+		},
+		{
+			name:     "invalid",
+			giveArgs: []string{"validate", newer, versioned, unknown, missing},
+			wantCode: 1,
+			wantOut: []string{
+				newer + ": unsupported config version: 2, must be 1 to 1",
+				versioned + ": ok, 1 faults, version 1",
+				unknown + `: faults[0].injector.type: unknown injector type, got "blackhole"`,
+				missing + ": open " + missing + ": no such file or directory",
+			},
+		},
+		{
+			name:      "stdin",
+			giveArgs:  []string{"validate"},
+			giveStdin: testMigratedConfig,
+			wantOut:   []string{"-: ok, 1 faults, version 1"},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var stdout, stderr bytes.Buffer
+			code := run(tt.giveArgs, strings.NewReader(tt.giveStdin), &stdout, &stderr)
+
+			assert.Equal(t, tt.wantCode, code)
+			assert.Equal(t, strings.Join(tt.wantOut, "\n")+"\n", stdout.String())
+			assert.Equal(t, "", stderr.String())
+		})
+	}
+}
+
+// TestMigrate tests faultctl migrate.
+func TestMigrate(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	newer := writeTestFile(t, dir, "newer.yaml", "version: 2\nfaults: []\n")
+	missing := filepath.Join(dir, "missing.yaml")
+
+	tests := []struct {
+		name       string
+		giveArgs   []string
+		giveStdin  string
+		wantCode   int
+		wantOut    string
+		wantErrOut string
+	}{
+		{
+			name:      "stdin",
+			giveArgs:  []string{"migrate"},
+			giveStdin: testConfig,
+			wantOut:   testMigratedConfig,
+		},
+		{
+			name:       "unsupported version",
+			giveArgs:   []string{"migrate", newer},
+			wantCode:   1,
+			wantErrOut: newer + ": unsupported config version: 2, must be 1 to 1\n",
+		},
+		{
+			name:       "missing file",
+			giveArgs:   []string{"migrate", missing},
+			wantCode:   1,
+			wantErrOut: missing + ": open " + missing + ": no such file or directory\n",
+		},
+		{
+			name:       "write without file",
+			giveArgs:   []string{"migrate", "-w"},
+			wantCode:   2,
+			wantErrOut: errUsage.Error() + "\n",
+		},
+		{
+			name:       "too many files",
+			giveArgs:   []string{"migrate", newer, missing},
+			wantCode:   2,
+			wantErrOut: errUsage.Error() + "\n",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var stdout, stderr bytes.Buffer
+			code := run(tt.giveArgs, strings.NewReader(tt.giveStdin), &stdout, &stderr)
+
+			assert.Equal(t, tt.wantCode, code)
+			assert.Equal(t, tt.wantOut, stdout.String())
+			assert.Equal(t, tt.wantErrOut, stderr.String())
+		})
+	}
+}
+
+// TestMigrateWrite tests that faultctl migrate -w rewrites the file, keeping its permissions.
+func TestMigrateWrite(t *testing.T) {
+	t.Parallel()
+
+	path := writeTestFile(t, t.TempDir(), "faults.yaml", testConfig)
+
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"migrate", "-w", path}, nil, &stdout, &stderr)
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "", stdout.String())
+	assert.Equal(t, "", stderr.String())
+
+	b, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, testMigratedConfig, string(b))
+
+	info, err := os.Stat(path)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	assert.Error(t, writeFile(filepath.Join(t.TempDir(), "missing.yaml"), b))
+}
+
+// TestGen tests faultctl gen.
+func TestGen(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := writeTestFile(t, dir, "faults.yaml", testConfig)
+	custom := writeTestFile(t, dir, "custom.yaml", "faults: [{injector: {type: custom}}]\n")
+	missing := filepath.Join(dir, "missing.yaml")
+
+	tests := []struct {
+		name       string
+		giveArgs   []string
+		giveStdin  string
+		wantCode   int
+		wantOut    []string
+		wantErrOut string
+	}{
+		{
+			name:      "stdin",
+			giveArgs:  []string{"gen"},
+			giveStdin: testConfig,
+			wantOut: []string{
+				"package faults\n",
+				"func newFaults() ([]*fault.Fault, error) {\n",
+				"i1, err := fault.NewSlowInjector(750 * time.Millisecond)\n",
+			},
+		},
+		{
+			name:     "file",
+			giveArgs: []string{"gen", "-package", "chaos", "-func", "Faults", path},
+			wantOut: []string{
+				"package chaos\n",
+				"func Faults() ([]*fault.Fault, error) {\n",
+			},
+		},
+		{
+			name:       "custom injector type",
+			giveArgs:   []string{"gen", custom},
+			wantCode:   1,
+			wantErrOut: custom + ": faults[0].injector.type: unknown injector type, got \"custom\"\n",
+		},
+		{
+			name:       "invalid package",
+			giveArgs:   []string{"gen", "-package", "go-fault", path},
+			wantCode:   1,
+			wantErrOut: path + ": not a valid Go identifier: \"go-fault\"\n",
+		},
+		{
+			name:       "missing file",
+			giveArgs:   []string{"gen", missing},
+			wantCode:   1,
+			wantErrOut: missing + ": open " + missing + ": no such file or directory\n",
+		},
+		{
+			name:       "too many files",
+			giveArgs:   []string{"gen", path, missing},
+			wantCode:   2,
+			wantErrOut: errUsage.Error() + "\n",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var stdout, stderr bytes.Buffer
+			code := run(tt.giveArgs, strings.NewReader(tt.giveStdin), &stdout, &stderr)
+
+			assert.Equal(t, tt.wantCode, code)
+			for _, want := range tt.wantOut {
+				assert.Contains(t, stdout.String(), want)
+			}
+			if tt.wantOut == nil {
+				assert.Equal(t, "", stdout.String())
+			}
+			assert.Equal(t, tt.wantErrOut, stderr.String())
+		})
+	}
+}
+
+// TestRunUsage tests that faultctl prints its usage for unknown commands and flags.
+func TestRunUsage(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		giveArgs []string
+	}{
+		{
+			name: "no command",
+		},
+		{
+			name:     "unknown command",
+			giveArgs: []string{"apply"},
+		},
+		{
+			name:     "unknown flag",
+			giveArgs: []string{"migrate", "-x"},
+		},
+		{
+			name:     "unknown gen flag",
+			giveArgs: []string{"gen", "-x"},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var stdout, stderr bytes.Buffer
+			code := run(tt.giveArgs, nil, &stdout, &stderr)
+
+			assert.Equal(t, 2, code)
+			assert.Equal(t, "", stdout.String())
+			assert.NotEqual(t, "", stderr.String())
+		})
+	}
+}
@@ -0,0 +1,21 @@
+/*
+Command faultproxy serves a reverse proxy that runs the Faults of a config on the requests it
+forwards, so integration tests can point their real clients at it as a misbehaving upstream. It is
+built to run as a container; see package faultproxy to run the same proxy in-process.
+
+Usage:
+
+    faultproxy [-listen addr] [-upstream url] [-config file]
+
+Each flag falls back to an environment variable, FAULTPROXY_LISTEN (default :8080),
+FAULTPROXY_UPSTREAM, and FAULTPROXY_CONFIG. The upstream is required and must be an absolute URL.
+The config is a YAML config in the format of fault.NewFaultsFromConfig; without one, faultproxy
+forwards every request untouched.
+
+Faultproxy stops on SIGINT or SIGTERM, after waiting up to 10 seconds for the requests in flight.
+
+Build the container image from the root of the repository:
+
+    docker build -t faultproxy -f cmd/faultproxy/Dockerfile .
+*/
+package main
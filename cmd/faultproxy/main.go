@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/github/go-fault"
+	"github.com/github/go-fault/faultproxy"
+)
+
+// shutdownTimeout is how long faultproxy waits for requests in flight when it is stopped.
+const shutdownTimeout = 10 * time.Second
+
+// errNoUpstream when faultproxy is run without an upstream.
+var errNoUpstream = errors.New("faultproxy: -upstream or FAULTPROXY_UPSTREAM is required")
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	os.Exit(run(ctx, os.Args[1:], os.Getenv, os.Stderr, nil))
+}
+
+// run runs faultproxy with args until ctx is done and returns its exit code. It sends the address
+// it listens on to listening, if not nil.
+func run(ctx context.Context, args []string, getenv func(string) string, stderr io.Writer,
+	listening chan<- net.Addr) int {
+	fs := flag.NewFlagSet("faultproxy", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	listen := fs.String("listen", envOr(getenv, "FAULTPROXY_LISTEN", ":8080"), "address to listen on")
+	upstream := fs.String("upstream", getenv("FAULTPROXY_UPSTREAM"), "absolute URL to forward requests to")
+	config := fs.String("config", getenv("FAULTPROXY_CONFIG"), "path of the fault config")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *upstream == "" {
+		fmt.Fprintln(stderr, errNoUpstream)
+		return 2
+	}
+
+	p, err := newProxy(*upstream, *config)
+	if err != nil {
+		fmt.Fprintf(stderr, "faultproxy: %v\n", err)
+		return 1
+	}
+
+	l, err := net.Listen("tcp", *listen)
+	if err != nil {
+		fmt.Fprintf(stderr, "faultproxy: %v\n", err)
+		return 1
+	}
+	if listening != nil {
+		listening <- l.Addr()
+	}
+
+	srv := &http.Server{Handler: p, ConnContext: fault.NewConnContext()}
+	errs := make(chan error, 1)
+	go func() {
+		errs <- srv.Serve(l)
+	}()
+
+	select {
+	case err = <-errs:
+	case <-ctx.Done():
+		sctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		err = srv.Shutdown(sctx)
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "faultproxy: %v\n", err)
+		return 1
+	}
+
+	return 0
+}
+
+// newProxy returns a Proxy in front of upstream that runs the Faults of the config file at path, or
+// none if path is empty.
+func newProxy(upstream, path string) (*faultproxy.Proxy, error) {
+	u, err := url.Parse(upstream)
+	if err != nil {
+		return nil, err
+	}
+
+	if path == "" {
+		return faultproxy.New(u, nil)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return faultproxy.New(u, f)
+}
+
+// envOr returns the environment variable key, or def if it is empty.
+func envOr(getenv func(string) string, key, def string) string {
+	if v := getenv(key); v != "" {
+		return v
+	}
+
+	return def
+}
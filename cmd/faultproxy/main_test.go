@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testConfig has a Fault that answers every request with a 418.
+const testConfig = `
+version: 1
+faults:
+  - name: teapot
+    enabled: true
+    participation: 1.0
+    injector:
+      type: error
+      params: {code: 418}
+`
+
+// testEnv returns a getenv function that reads env.
+func testEnv(env map[string]string) func(string) string {
+	return func(key string) string {
+		return env[key]
+	}
+}
+
+// TestRun tests that faultproxy serves a proxy until it is stopped.
+func TestRun(t *testing.T) {
+	t.Parallel()
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	t.Cleanup(upstream.Close)
+
+	path := filepath.Join(t.TempDir(), "faults.yaml")
+	assert.NoError(t, os.WriteFile(path, []byte(testConfig), 0o600))
+
+	tests := []struct {
+		name     string
+		giveArgs []string
+		giveEnv  map[string]string
+		wantCode int
+	}{
+		{
+			name:     "flags",
+			giveArgs: []string{"-listen", "127.0.0.1:0", "-upstream", upstream.URL},
+			wantCode: http.StatusAccepted,
+		},
+		{
+			name: "env",
+			giveEnv: map[string]string{
+				"FAULTPROXY_LISTEN":   "127.0.0.1:0",
+				"FAULTPROXY_UPSTREAM": upstream.URL,
+				"FAULTPROXY_CONFIG":   path,
+			},
+			wantCode: http.StatusTeapot,
+		},
+		{
+			name:     "flags override env",
+			giveArgs: []string{"-listen", "127.0.0.1:0", "-config", ""},
+			giveEnv: map[string]string{
+				"FAULTPROXY_UPSTREAM": upstream.URL,
+				"FAULTPROXY_CONFIG":   path,
+			},
+			wantCode: http.StatusAccepted,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			listening := make(chan net.Addr, 1)
+			exit := make(chan int, 1)
+			go func() {
+				exit <- run(ctx, tt.giveArgs, testEnv(tt.giveEnv), io.Discard, listening)
+			}()
+
+			addr := <-listening
+			resp, err := http.Get("http://" + addr.String())
+			assert.NoError(t, err)
+			resp.Body.Close()
+			assert.Equal(t, tt.wantCode, resp.StatusCode)
+
+			cancel()
+			assert.Equal(t, 0, <-exit)
+		})
+	}
+}
+
+// TestRunErrors tests that faultproxy exits with an error when it cannot start.
+func TestRunErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		giveArgs   []string
+		wantCode   int
+		wantStderr string
+	}{
+		{
+			name:       "unknown flag",
+			giveArgs:   []string{"-unknown"},
+			wantCode:   2,
+			wantStderr: "flag provided but not defined",
+		},
+		{
+			name:       "no upstream",
+			wantCode:   2,
+			wantStderr: errNoUpstream.Error(),
+		},
+		{
+			name:       "invalid upstream",
+			giveArgs:   []string{"-upstream", "http://[::1"},
+			wantCode:   1,
+			wantStderr: "missing ']' in host",
+		},
+		{
+			name:       "relative upstream",
+			giveArgs:   []string{"-upstream", "/api"},
+			wantCode:   1,
+			wantStderr: "upstream url must be absolute",
+		},
+		{
+			name:       "missing config",
+			giveArgs:   []string{"-upstream", "http://example.com", "-config", "missing.yaml"},
+			wantCode:   1,
+			wantStderr: "missing.yaml",
+		},
+		{
+			name:       "listen error",
+			giveArgs:   []string{"-upstream", "http://example.com", "-listen", "localhost"},
+			wantCode:   1,
+			wantStderr: "missing port in address",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var stderr bytes.Buffer
+			code := run(context.Background(), tt.giveArgs, testEnv(nil), &stderr, nil)
+
+			assert.Equal(t, tt.wantCode, code)
+			assert.Contains(t, stderr.String(), tt.wantStderr)
+		})
+	}
+}
@@ -0,0 +1,466 @@
+package fault
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/format"
+	"go/token"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+var (
+	// ErrInvalidGoIdentifier when generated Go code is given a package or function name that is not a
+	// Go identifier.
+	ErrInvalidGoIdentifier = errors.New("not a valid Go identifier")
+	// ErrUnexportableInjector when an Injector has no injector type in a config.
+	ErrUnexportableInjector = errors.New("injector cannot be written to a config")
+)
+
+// goCloseTypes are the names of the CloseType constants.
+var goCloseTypes = map[CloseType]string{ //nolint:gochecknoglobals
+	CloseAbort:       "CloseAbort",
+	CloseReset:       "CloseReset",
+	CloseMidHeaders:  "CloseMidHeaders",
+	ClosePartialBody: "ClosePartialBody",
+}
+
+// goLatencyPhases are the names of the LatencyPhase constants.
+var goLatencyPhases = map[LatencyPhase]string{ //nolint:gochecknoglobals
+	LatencyBeforeHeaders: "LatencyBeforeHeaders",
+	LatencyBeforeBody:    "LatencyBeforeBody",
+	LatencySpreadBody:    "LatencySpreadBody",
+	LatencyAfterHandler:  "LatencyAfterHandler",
+}
+
+// GoCodeOption configures ConfigToGo.
+type GoCodeOption interface {
+	applyGoCode(o *goCodeOptions) error
+}
+
+// goCodeOptions holds the options of ConfigToGo.
+type goCodeOptions struct {
+	pkg      string
+	funcName string
+}
+
+type goPackageOption string
+
+func (o goPackageOption) applyGoCode(c *goCodeOptions) error {
+	if !token.IsIdentifier(string(o)) {
+		return fmt.Errorf("%w: %q", ErrInvalidGoIdentifier, string(o))
+	}
+
+	c.pkg = string(o)
+	return nil
+}
+
+// WithGoPackage sets the package of the generated Go code. Default "faults".
+func WithGoPackage(name string) GoCodeOption {
+	return goPackageOption(name)
+}
+
+type goFuncOption string
+
+func (o goFuncOption) applyGoCode(c *goCodeOptions) error {
+	if !token.IsIdentifier(string(o)) {
+		return fmt.Errorf("%w: %q", ErrInvalidGoIdentifier, string(o))
+	}
+
+	c.funcName = string(o)
+	return nil
+}
+
+// WithGoFunc sets the name of the function the generated Go code declares. Default "newFaults".
+func WithGoFunc(name string) GoCodeOption {
+	return goFuncOption(name)
+}
+
+// ConfigToGo reads a config from r and returns Go code that builds the same Faults with the
+// constructors of this package, so Faults tried out in a config can be compiled in and reviewed
+// like the rest of a program. The code declares a function that returns the Faults in order:
+//
+//	func newFaults() ([]*fault.Fault, error)
+//
+// The config must load with the built-in injector types; custom types are an error wrapping
+// ErrUnknownInjectorType, since there is no constructor to call for them.
+func ConfigToGo(r io.Reader, opts ...GoCodeOption) ([]byte, error) {
+	// set defaults
+	o := &goCodeOptions{
+		pkg:      "faults",
+		funcName: "newFaults",
+	}
+
+	// apply options
+	for _, opt := range opts {
+		err := opt.applyGoCode(o)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// building the Faults checks the config, so the code below can trust it
+	_, err = NewFaultsFromConfig(bytes.NewReader(b), WithInjectorRegistry(NewInjectorRegistry()))
+	if err != nil {
+		return nil, err
+	}
+	c, _ := decodeConfig(b)
+
+	g := &goGen{imports: map[string]bool{}, vars: map[string]int{}}
+	g.printf("faults := make([]*fault.Fault, 0, %d)\n\n", len(c.Faults))
+	for _, fc := range c.Faults {
+		g.fault(fc)
+	}
+	g.printf("return faults, nil\n")
+
+	var src bytes.Buffer
+	fmt.Fprintf(&src, "package %s\n\nimport (\n", o.pkg)
+	for _, path := range sortedKeys(g.imports) {
+		fmt.Fprintf(&src, "%q\n", path)
+	}
+	fmt.Fprintf(&src, "\n%q\n)\n\n", "github.com/github/go-fault")
+	fmt.Fprintf(&src, "// %s returns the Faults of the config this function was generated from.\n", o.funcName)
+	fmt.Fprintf(&src, "func %s() ([]*fault.Fault, error) {\n%s}\n", o.funcName, g.body.String())
+
+	return format.Source(src.Bytes())
+}
+
+// goGen writes the body of the function ConfigToGo generates.
+type goGen struct {
+	body bytes.Buffer
+
+	// imports are the standard packages the body uses.
+	imports map[string]bool
+	// vars counts the variables of each prefix.
+	vars map[string]int
+}
+
+// printf writes to the body.
+func (g *goGen) printf(format string, args ...interface{}) {
+	fmt.Fprintf(&g.body, format, args...)
+}
+
+// call writes a call of the constructor fn of this package with args to a new variable, returning
+// the error of the function if it fails, and returns the name of the variable. Calls with options
+// get a line per argument.
+func (g *goGen) call(prefix, fn string, args []string) string {
+	g.vars[prefix]++
+	v := fmt.Sprintf("%s%d", prefix, g.vars[prefix])
+
+	if len(args) > 2 {
+		g.printf("%s, err := fault.%s(\n%s,\n)\n", v, fn, strings.Join(args, ",\n"))
+	} else {
+		g.printf("%s, err := fault.%s(%s)\n", v, fn, strings.Join(args, ", "))
+	}
+	g.printf("if err != nil {\nreturn nil, err\n}\n")
+
+	return v
+}
+
+// fault writes the code that builds the Fault c.
+func (g *goGen) fault(c faultConfig) {
+	i := g.injector(c.Injector)
+
+	args := []string{i}
+	if c.Name != "" {
+		args = append(args, fmt.Sprintf("fault.WithName(%q)", c.Name))
+	}
+	if c.Enabled {
+		args = append(args, "fault.WithEnabled(true)")
+	}
+	if c.Participation != 0 {
+		args = append(args, fmt.Sprintf("fault.WithParticipation(%#v)", c.Participation))
+	}
+	if c.Seed != nil {
+		args = append(args, fmt.Sprintf("fault.WithRandSeed(%d)", *c.Seed))
+	}
+	if len(c.PathAllowlist) > 0 {
+		args = append(args, fmt.Sprintf("fault.WithPathAllowlist(%#v)", c.PathAllowlist))
+	}
+	if len(c.PathBlocklist) > 0 {
+		args = append(args, fmt.Sprintf("fault.WithPathBlocklist(%#v)", c.PathBlocklist))
+	}
+	if len(c.HeaderAllowlist) > 0 {
+		args = append(args, fmt.Sprintf("fault.WithHeaderAllowlist(%#v)", c.HeaderAllowlist))
+	}
+	if len(c.HeaderBlocklist) > 0 {
+		args = append(args, fmt.Sprintf("fault.WithHeaderBlocklist(%#v)", c.HeaderBlocklist))
+	}
+	if len(c.Labels) > 0 {
+		args = append(args, fmt.Sprintf("fault.WithLabels(%#v)", c.Labels))
+	}
+
+	f := g.call("f", "NewFault", args)
+	g.printf("faults = append(faults, %s)\n\n", f)
+}
+
+// injector writes the code that builds the injector c and returns the name of its variable. The
+// config was checked by building it, so parameters are not checked again.
+func (g *goGen) injector(c injectorConfig) string {
+	p := ConfigParams{values: c.Params}
+
+	switch c.Type {
+	case "reject":
+		var args []string
+		name, _ := p.String("close_type", CloseAbort.String())
+		for ct := CloseReset; ct <= ClosePartialBody; ct++ {
+			if ct.String() == name {
+				args = append(args, fmt.Sprintf("fault.WithCloseType(fault.%s)", goCloseTypes[ct]))
+			}
+		}
+
+		return g.call("i", "NewRejectInjector", args)
+	case "error":
+		code, _ := p.Int("code", 0)
+		args := []string{strconv.Itoa(code)}
+		if text, _ := p.String("status_text", ""); text != "" {
+			args = append(args, fmt.Sprintf("fault.WithStatusText(%q)", text))
+		}
+		if headers, _ := p.StringMap("headers"); headers != nil {
+			g.imports["net/http"] = true
+
+			h := make(http.Header, len(headers))
+			for k, v := range headers {
+				h.Set(k, v)
+			}
+			values := make([]string, 0, len(h))
+			for _, k := range sortedKeys(h) {
+				values = append(values, fmt.Sprintf("%q: {%q}", k, h.Get(k)))
+			}
+			args = append(args, fmt.Sprintf("fault.WithHeaders(http.Header{%s})", strings.Join(values, ", ")))
+		}
+
+		return g.call("i", "NewErrorInjector", args)
+	case "stub":
+		code, _ := p.Int("code", http.StatusOK)
+		body, _ := p.String("body", "")
+		args := []string{strconv.Itoa(code), fmt.Sprintf("%q", body)}
+		if status, _ := p.String("status", ""); status != "" {
+			args = append(args, fmt.Sprintf("fault.WithStatusTemplate(%q)", status))
+		}
+		headers, _ := p.StringMap("headers")
+		for _, k := range sortedKeys(headers) {
+			args = append(args, fmt.Sprintf("fault.WithHeaderTemplate(%q, %q)", k, headers[k]))
+		}
+
+		return g.call("i", "NewStubInjector", args)
+	case "slow":
+		g.imports["time"] = true
+
+		d, _ := p.Duration("duration", 0)
+		args := []string{goDuration(d)}
+		if n, _ := p.Int("max_concurrent", 0); n != 0 {
+			args = append(args, fmt.Sprintf("fault.WithMaxConcurrent(%d)", n))
+		}
+		name, _ := p.String("phase", LatencyBeforeHeaders.String())
+		for phase := LatencyBeforeBody; phase <= LatencyAfterHandler; phase++ {
+			if phase.String() == name {
+				args = append(args, fmt.Sprintf("fault.WithLatencyPhase(fault.%s)", goLatencyPhases[phase]))
+			}
+		}
+
+		return g.call("i", "NewSlowInjector", args)
+	case "chain":
+		return g.call("i", "NewChainInjector", []string{g.injectors(p, "injectors")})
+	default: // random, the only other built-in type
+		args := []string{g.injectors(p, "injectors")}
+		if seed, _ := p.Int("seed", defaultRandSeed); seed != defaultRandSeed {
+			args = append(args, fmt.Sprintf("fault.WithRandSeed(%d)", seed))
+		}
+
+		return g.call("i", "NewRandomInjector", args)
+	}
+}
+
+// injectors writes the code that builds the list of injectors in the parameter key of p and
+// returns a slice of their variables.
+func (g *goGen) injectors(p ConfigParams, key string) string {
+	cs, _, _ := p.injectorConfigs(key)
+	vars := make([]string, 0, len(cs))
+	for _, c := range cs {
+		vars = append(vars, g.injector(c))
+	}
+
+	return fmt.Sprintf("[]fault.Injector{%s}", strings.Join(vars, ", "))
+}
+
+// goDuration returns the Go expression of d in the largest unit it is a whole number of, such as
+// "750 * time.Millisecond".
+func goDuration(d time.Duration) string {
+	units := []struct {
+		d    time.Duration
+		name string
+	}{
+		{time.Hour, "time.Hour"},
+		{time.Minute, "time.Minute"},
+		{time.Second, "time.Second"},
+		{time.Millisecond, "time.Millisecond"},
+		{time.Microsecond, "time.Microsecond"},
+	}
+	for _, u := range units {
+		if d != 0 && d%u.d == 0 {
+			return fmt.Sprintf("%d * %s", d/u.d, u.name)
+		}
+	}
+
+	return fmt.Sprintf("time.Duration(%d)", int64(d))
+}
+
+// FaultsToConfig returns a config, as YAML in the format of ConfigVersion, that NewFaultsFromConfig
+// builds faults like from, so Faults written in Go can be moved to a config. Only what a config can
+// express is written: the name, enabled state, participation, seed, path and header lists, and labels
+// of each Fault, and the injector type and parameters of its Injector. Other options, such as
+// RequestMatchers or the latency distribution of a SlowInjector, are left out. Injectors without a
+// built-in injector type are an error wrapping ErrUnexportableInjector.
+func FaultsToConfig(faults []*Fault) ([]byte, error) {
+	c := config{Version: ConfigVersion, Faults: make([]faultConfig, 0, len(faults))}
+	for idx, f := range faults {
+		path := fmt.Sprintf("faults[%d]", idx)
+
+		i := f.Injector()
+		ic, err := injectorToConfig(path+".injector", i)
+		if err != nil {
+			return nil, err
+		}
+
+		fc := faultConfig{
+			Enabled:         f.Enabled(),
+			Participation:   f.Participation(),
+			PathAllowlist:   sortedKeys(f.pathAllowlist),
+			PathBlocklist:   sortedKeys(f.pathBlocklist),
+			HeaderAllowlist: f.headerAllowlist,
+			HeaderBlocklist: f.headerBlocklist,
+			Labels:          f.labels,
+			Injector:        ic,
+		}
+		// a Fault without a name is named after its Injector
+		if f.name != InjectorString(i) {
+			fc.Name = f.name
+		}
+		if f.randSeed != defaultRandSeed {
+			seed := f.randSeed
+			fc.Seed = &seed
+		}
+		c.Faults = append(c.Faults, fc)
+	}
+
+	return yaml.Marshal(c)
+}
+
+// injectorToConfig returns the injectorConfig of i, found at path in the config.
+func injectorToConfig(path string, i Injector) (injectorConfig, error) {
+	params := map[string]interface{}{}
+
+	var c injectorConfig
+	switch i := i.(type) {
+	case *RejectInjector:
+		c.Type = "reject"
+		if i.closeType != CloseAbort {
+			params["close_type"] = i.closeType.String()
+		}
+	case *ErrorInjector:
+		c.Type = "error"
+		params["code"] = i.statusCode
+		if i.statusText != http.StatusText(i.statusCode) {
+			params["status_text"] = i.statusText
+		}
+		if len(i.header) > 0 {
+			headers := make(map[string]string, len(i.header))
+			for k := range i.header {
+				headers[k] = i.header.Get(k)
+			}
+			params["headers"] = headers
+		}
+	case *StubInjector:
+		c.Type = "stub"
+		if i.status != "" {
+			params["status"] = i.status
+		} else {
+			params["code"] = i.code
+		}
+		if len(i.headers) > 0 {
+			headers := make(map[string]string, len(i.headers))
+			for k := range i.headers {
+				headers[k] = i.headers.Get(k)
+			}
+			params["headers"] = headers
+		}
+		params["body"] = i.body
+	case *SlowInjector:
+		c.Type = "slow"
+		params["duration"] = i.duration.String()
+		if i.maxConcurrent != 0 {
+			params["max_concurrent"] = i.maxConcurrent
+		}
+		if i.phase != LatencyBeforeHeaders {
+			params["phase"] = i.phase.String()
+		}
+	case *ChainInjector:
+		c.Type = "chain"
+		cs, err := injectorsToConfig(path, i.injectors)
+		if err != nil {
+			return c, err
+		}
+		params["injectors"] = cs
+	case *RandomInjector:
+		c.Type = "random"
+		cs, err := injectorsToConfig(path, i.injectors)
+		if err != nil {
+			return c, err
+		}
+		params["injectors"] = cs
+		if i.randSeed != defaultRandSeed {
+			params["seed"] = i.randSeed
+		}
+	default:
+		return c, fmt.Errorf("%w: %s: %s", ErrUnexportableInjector, path, InjectorString(i))
+	}
+
+	if len(params) > 0 {
+		c.Params = params
+	}
+
+	return c, nil
+}
+
+// injectorsToConfig returns the injectorConfigs of is, the injectors parameter of the injector at
+// path in the config.
+func injectorsToConfig(path string, is []Injector) ([]injectorConfig, error) {
+	cs := make([]injectorConfig, 0, len(is))
+	for idx, i := range is {
+		c, err := injectorToConfig(fmt.Sprintf("%s.params.injectors[%d]", path, idx), i)
+		if err != nil {
+			return nil, err
+		}
+		cs = append(cs, c)
+	}
+
+	return cs, nil
+}
+
+// sortedKeys returns the keys of m, sorted, or nil if m is empty.
+func sortedKeys[V any](m map[string]V) []string {
+	if len(m) == 0 {
+		return nil
+	}
+
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,332 @@
+package fault
+
+import (
+	"errors"
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestConfigToGo tests ConfigToGo.
+func TestConfigToGo(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		giveConfig  string
+		giveOptions []GoCodeOption
+		want        string
+		wantErr     error
+	}{
+		{
+			name: "valid",
+			giveConfig: `
+version: 1
+faults:
+  - name: hangup
+    enabled: true
+    injector:
+      type: reject
+      params: {close_type: mid_headers}
+  - participation: 0.25
+    injector:
+      type: slow
+      params: {duration: 1m30s}
+`,
+			giveOptions: []GoCodeOption{WithGoPackage("chaos"), WithGoFunc("Faults")},
+			want: `package chaos
+
+import (
+	"time"
+
+	"github.com/github/go-fault"
+)
+
+// Faults returns the Faults of the config this function was generated from.
+func Faults() ([]*fault.Fault, error) {
+	faults := make([]*fault.Fault, 0, 2)
+
+	i1, err := fault.NewRejectInjector(fault.WithCloseType(fault.CloseMidHeaders))
+	if err != nil {
+		return nil, err
+	}
+	f1, err := fault.NewFault(
+		i1,
+		fault.WithName("hangup"),
+		fault.WithEnabled(true),
+	)
+	if err != nil {
+		return nil, err
+	}
+	faults = append(faults, f1)
+
+	i2, err := fault.NewSlowInjector(90 * time.Second)
+	if err != nil {
+		return nil, err
+	}
+	f2, err := fault.NewFault(i2, fault.WithParticipation(0.25))
+	if err != nil {
+		return nil, err
+	}
+	faults = append(faults, f2)
+
+	return faults, nil
+}
+`,
+		},
+		{
+			name:        "empty",
+			giveConfig:  "faults: []",
+			giveOptions: []GoCodeOption{},
+			want: `package faults
+
+import (
+	"github.com/github/go-fault"
+)
+
+// newFaults returns the Faults of the config this function was generated from.
+func newFaults() ([]*fault.Fault, error) {
+	faults := make([]*fault.Fault, 0, 0)
+
+	return faults, nil
+}
+`,
+		},
+		{
+			name:       "custom injector type",
+			giveConfig: "faults: [{injector: {type: custom}}]",
+			wantErr:    ErrUnknownInjectorType,
+		},
+		{
+			name:       "invalid config",
+			giveConfig: "faults: [{injector: {type: slow, params: {duration: soon}}}]",
+			wantErr:    ErrInvalidConfig,
+		},
+		{
+			name:        "invalid package",
+			giveConfig:  "faults: []",
+			giveOptions: []GoCodeOption{WithGoPackage("go-fault")},
+			wantErr:     ErrInvalidGoIdentifier,
+		},
+		{
+			name:        "invalid func",
+			giveConfig:  "faults: []",
+			giveOptions: []GoCodeOption{WithGoFunc("new faults")},
+			wantErr:     ErrInvalidGoIdentifier,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			b, err := ConfigToGo(strings.NewReader(tt.giveConfig), tt.giveOptions...)
+
+			assert.True(t, errors.Is(err, tt.wantErr), err)
+			assert.Equal(t, tt.want, string(b))
+		})
+	}
+}
+
+// TestConfigToGoAllTypes tests that ConfigToGo generates valid Go code for every built-in injector
+// type.
+func TestConfigToGoAllTypes(t *testing.T) {
+	t.Parallel()
+
+	b, err := ConfigToGo(strings.NewReader(testConfig))
+	assert.NoError(t, err)
+
+	_, err = parser.ParseFile(token.NewFileSet(), "faults.go", b, parser.AllErrors)
+	assert.NoError(t, err)
+
+	for _, want := range []string{
+		`i1, err := fault.NewSlowInjector(750*time.Millisecond, fault.WithMaxConcurrent(10))`,
+		`fault.WithHeaderAllowlist(map[string]string{"X-Chaos": "true"}),`,
+		`fault.WithHeaders(http.Header{"Retry-After": {"15"}}),`,
+		`i3, err := fault.NewRejectInjector(fault.WithCloseType(fault.CloseReset))`,
+		`i4, err := fault.NewSlowInjector(1*time.Second, fault.WithLatencyPhase(fault.LatencyBeforeBody))`,
+		`i6, err := fault.NewChainInjector([]fault.Injector{i4, i5})`,
+		`i9, err := fault.NewRandomInjector([]fault.Injector{i7, i8}, fault.WithRandSeed(7))`,
+		`f5, err := fault.NewFault(i9, fault.WithName("either"))`,
+		`fault.WithStatusTemplate("{{if .Query.Get \"id\"}}200{{else}}404{{end}}"),`,
+		`fault.WithHeaderTemplate("Content-Type", "application/json"),`,
+	} {
+		assert.Contains(t, string(b), want)
+	}
+}
+
+// TestConfigToGoReadError tests that ConfigToGo returns the error of its reader.
+func TestConfigToGoReadError(t *testing.T) {
+	t.Parallel()
+
+	errRead := errors.New("read failed")
+
+	b, err := ConfigToGo(iotest.ErrReader(errRead))
+	assert.Equal(t, errRead, err)
+	assert.Nil(t, b)
+}
+
+// TestGoDuration tests goDuration.
+func TestGoDuration(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		give time.Duration
+		want string
+	}{
+		{give: 0, want: "time.Duration(0)"},
+		{give: 2 * time.Hour, want: "2 * time.Hour"},
+		{give: 90 * time.Minute, want: "90 * time.Minute"},
+		{give: 1500 * time.Millisecond, want: "1500 * time.Millisecond"},
+		{give: 5 * time.Microsecond, want: "5 * time.Microsecond"},
+		{give: 1001, want: "time.Duration(1001)"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.want, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, goDuration(tt.give))
+		})
+	}
+}
+
+// TestFaultsToConfig tests that FaultsToConfig writes a config that builds the same Faults.
+func TestFaultsToConfig(t *testing.T) {
+	t.Parallel()
+
+	faults, err := NewFaultsFromConfig(strings.NewReader(testConfig))
+	assert.NoError(t, err)
+
+	b, err := FaultsToConfig(faults)
+	assert.NoError(t, err)
+	assert.Equal(t, `version: 1
+faults:
+- name: checkout-latency
+  enabled: true
+  participation: 0.05
+  seed: 42
+  path_allowlist:
+  - /checkout
+  path_blocklist:
+  - /checkout/health
+  header_allowlist:
+    X-Chaos: "true"
+  header_blocklist:
+    X-Canary: "false"
+  labels:
+    team: checkout
+  injector:
+    type: slow
+    params:
+      duration: 750ms
+      max_concurrent: 10
+- name: unavailable
+  participation: 1
+  injector:
+    type: error
+    params:
+      code: 503
+      headers:
+        Retry-After: "15"
+      status_text: try again
+- injector:
+    type: reject
+    params:
+      close_type: reset
+- name: compound
+  injector:
+    type: chain
+    params:
+      injectors:
+      - type: slow
+        params:
+          duration: 1s
+          phase: before_body
+      - type: error
+        params:
+          code: 500
+- name: either
+  injector:
+    type: random
+    params:
+      injectors:
+      - type: reject
+      - type: error
+        params:
+          code: 502
+      seed: 7
+- name: stubbed
+  injector:
+    type: stub
+    params:
+      body: '{"id": {{json (.Query.Get "id")}}}'
+      headers:
+        Content-Type: application/json
+      status: '{{if .Query.Get "id"}}200{{else}}404{{end}}'
+`, string(b))
+
+	// the config builds Faults that write the same config
+	again, err := NewFaultsFromConfig(strings.NewReader(string(b)))
+	assert.NoError(t, err)
+	b2, err := FaultsToConfig(again)
+	assert.NoError(t, err)
+	assert.Equal(t, string(b), string(b2))
+}
+
+// TestFaultsToConfigUnexportable tests that FaultsToConfig rejects Injectors without an injector
+// type.
+func TestFaultsToConfigUnexportable(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		giveWrap func(i Injector) Injector
+		wantErr  string
+	}{
+		{
+			name:     "fault",
+			giveWrap: func(i Injector) Injector { return i },
+			wantErr:  "faults[0].injector: ",
+		},
+		{
+			name: "chain",
+			giveWrap: func(i Injector) Injector {
+				ri, _ := NewRejectInjector()
+				ci, _ := NewChainInjector([]Injector{ri, i})
+				return ci
+			},
+			wantErr: "faults[0].injector.params.injectors[1]: ",
+		},
+		{
+			name: "random",
+			giveWrap: func(i Injector) Injector {
+				ri, _ := NewRandomInjector([]Injector{i})
+				return ri
+			},
+			wantErr: "faults[0].injector.params.injectors[0]: ",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			f, err := NewFault(tt.giveWrap(newTestInjector500s()))
+			assert.NoError(t, err)
+
+			b, err := FaultsToConfig([]*Fault{f})
+			assert.True(t, errors.Is(err, ErrUnexportableInjector))
+			assert.Contains(t, err.Error(), tt.wantErr)
+			assert.Nil(t, b)
+		})
+	}
+}
@@ -0,0 +1,152 @@
+package fault
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"math"
+	"net"
+	"net/http"
+)
+
+// Cohort is the group of an experiment a request belongs to.
+type Cohort string
+
+const (
+	// CohortControl requests are never faulted.
+	CohortControl Cohort = "control"
+	// CohortTreatment requests may be faulted.
+	CohortTreatment Cohort = "treatment"
+)
+
+// Exposure records that a request was assigned to a Cohort of an experiment.
+type Exposure struct {
+	// Request is the request that was assigned.
+	Request *http.Request
+	// Fault is the name of the Fault running the experiment.
+	Fault string
+	// Experiment is the name of the experiment.
+	Experiment string
+	// Unit identifies who was assigned, such as a user or session id.
+	Unit string
+	// Cohort is the group Unit was assigned to.
+	Cohort Cohort
+}
+
+// ExposureReporter is a Reporter that also receives an Exposure every time a Fault with a
+// CohortAssigner assigns a request to a Cohort. Log Exposures to compare cohorts in experiment
+// analysis.
+type ExposureReporter interface {
+	Reporter
+	ReportExposure(e Exposure)
+}
+
+// CohortAssigner deterministically splits requests into control and treatment cohorts. The same
+// unit is always assigned to the same Cohort of an experiment, and different experiments split
+// units independently.
+type CohortAssigner struct {
+	experiment string
+	treatment  float32
+	unitF      func(r *http.Request) string
+}
+
+// CohortAssignerOption configures a CohortAssigner.
+type CohortAssignerOption interface {
+	applyCohortAssigner(a *CohortAssigner) error
+}
+
+type unitFuncOption func(r *http.Request) string
+
+func (o unitFuncOption) applyCohortAssigner(a *CohortAssigner) error {
+	a.unitF = o
+	return nil
+}
+
+// WithUnitFunc sets the function that returns the unit a request belongs to, such as a user id.
+// Requests with an empty unit are not part of the experiment. Default the IP of the client.
+func WithUnitFunc(f func(r *http.Request) string) CohortAssignerOption {
+	return unitFuncOption(f)
+}
+
+// WithUnitHeader sets the unit of a request to the value of header h.
+func WithUnitHeader(h string) CohortAssignerOption {
+	return unitFuncOption(func(r *http.Request) string {
+		return r.Header.Get(h)
+	})
+}
+
+// NewCohortAssigner returns a CohortAssigner for experiment that assigns a percent of units to
+// the treatment cohort. 0.0 <= treatment <= 1.0.
+func NewCohortAssigner(experiment string, treatment float32, opts ...CohortAssignerOption) (*CohortAssigner, error) {
+	if treatment < 0.0 || treatment > 1.0 {
+		return nil, ErrInvalidPercent
+	}
+
+	// set defaults
+	a := &CohortAssigner{
+		experiment: experiment,
+		treatment:  treatment,
+		unitF:      clientIP,
+	}
+
+	// apply options
+	for _, opt := range opts {
+		err := opt.applyCohortAssigner(a)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return a, nil
+}
+
+// Assign returns the unit of r and the Cohort it belongs to. ok is false if r has no unit.
+func (a *CohortAssigner) Assign(r *http.Request) (unit string, cohort Cohort, ok bool) {
+	unit = a.unitF(r)
+	if unit == "" {
+		return "", "", false
+	}
+
+	if hashFraction(a.experiment, unit) < float64(a.treatment) {
+		return unit, CohortTreatment, true
+	}
+
+	return unit, CohortControl, true
+}
+
+// hashFraction deterministically maps unit to a float64 [0.0,1.0], independently for each
+// experiment.
+func hashFraction(experiment, unit string) float64 {
+	sum := sha256.Sum256([]byte(experiment + "\x00" + unit))
+	return float64(binary.BigEndian.Uint64(sum[:8])) / math.MaxUint64
+}
+
+// Experiment returns the name of the experiment.
+func (a *CohortAssigner) Experiment() string {
+	return a.experiment
+}
+
+// clientIP returns the IP of the client that sent r.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
+
+type cohortAssignerOption struct {
+	assigner *CohortAssigner
+}
+
+func (o cohortAssignerOption) applyFault(f *Fault) error {
+	f.cohorts = o.assigner
+	return nil
+}
+
+// WithCohortAssigner makes the Fault run an experiment. Only requests in the treatment cohort are
+// faulted, at the Fault's participation, and every assignment is reported as an Exposure if the
+// Fault's Reporter is an ExposureReporter.
+func WithCohortAssigner(a *CohortAssigner) Option {
+	return cohortAssignerOption{a}
+}
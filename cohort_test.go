@@ -0,0 +1,257 @@
+package fault
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestNewCohortAssigner tests NewCohortAssigner.
+func TestNewCohortAssigner(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		giveTreatment float32
+		giveOptions   []CohortAssignerOption
+		wantErr       error
+	}{
+		{
+			name:          "valid",
+			giveTreatment: 0.5,
+			giveOptions: []CohortAssignerOption{
+				WithUnitHeader("X-User"),
+			},
+		},
+		{
+			name:          "treatment too low",
+			giveTreatment: -0.1,
+			wantErr:       ErrInvalidPercent,
+		},
+		{
+			name:          "treatment too high",
+			giveTreatment: 1.1,
+			wantErr:       ErrInvalidPercent,
+		},
+		{
+			name:          "option error",
+			giveTreatment: 0.5,
+			giveOptions: []CohortAssignerOption{
+				withError(),
+			},
+			wantErr: errErrorOption,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			a, err := NewCohortAssigner("exp", tt.giveTreatment, tt.giveOptions...)
+
+			assert.Equal(t, tt.wantErr, err)
+			if tt.wantErr == nil {
+				assert.Equal(t, "exp", a.Experiment())
+				assert.Equal(t, tt.giveTreatment, a.treatment)
+			}
+		})
+	}
+}
+
+// TestCohortAssignerAssign tests CohortAssigner.Assign.
+func TestCohortAssignerAssign(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		giveTreatment float32
+		giveOptions   []CohortAssignerOption
+		giveRemote    string
+		giveHeader    string
+		wantUnit      string
+		wantCohort    Cohort
+		wantOK        bool
+	}{
+		{
+			name:          "all control",
+			giveTreatment: 0.0,
+			giveRemote:    "10.0.0.1:1234",
+			wantUnit:      "10.0.0.1",
+			wantCohort:    CohortControl,
+			wantOK:        true,
+		},
+		{
+			name:          "all treatment",
+			giveTreatment: 1.0,
+			giveRemote:    "10.0.0.1:1234",
+			wantUnit:      "10.0.0.1",
+			wantCohort:    CohortTreatment,
+			wantOK:        true,
+		},
+		{
+			name:          "remote addr without port",
+			giveTreatment: 1.0,
+			giveRemote:    "pipe",
+			wantUnit:      "pipe",
+			wantCohort:    CohortTreatment,
+			wantOK:        true,
+		},
+		{
+			name:          "header unit",
+			giveTreatment: 1.0,
+			giveOptions:   []CohortAssignerOption{WithUnitHeader("X-User")},
+			giveHeader:    "user-1",
+			wantUnit:      "user-1",
+			wantCohort:    CohortTreatment,
+			wantOK:        true,
+		},
+		{
+			name:          "no unit",
+			giveTreatment: 1.0,
+			giveOptions:   []CohortAssignerOption{WithUnitHeader("X-User")},
+			wantOK:        false,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			a, err := NewCohortAssigner("exp", tt.giveTreatment, tt.giveOptions...)
+			assert.NoError(t, err)
+
+			req := httptest.NewRequest("GET", "/", nil)
+			req.RemoteAddr = tt.giveRemote
+			if tt.giveHeader != "" {
+				req.Header.Set("X-User", tt.giveHeader)
+			}
+
+			unit, cohort, ok := a.Assign(req)
+			assert.Equal(t, tt.wantUnit, unit)
+			assert.Equal(t, tt.wantCohort, cohort)
+			assert.Equal(t, tt.wantOK, ok)
+		})
+	}
+}
+
+// TestCohortAssignerSplit tests that units are split deterministically and in proportion.
+func TestCohortAssignerSplit(t *testing.T) {
+	t.Parallel()
+
+	unitF := WithUnitFunc(func(r *http.Request) string { return r.URL.Query().Get("u") })
+	a, err := NewCohortAssigner("exp-a", 0.25, unitF)
+	assert.NoError(t, err)
+	b, err := NewCohortAssigner("exp-b", 0.25, unitF)
+	assert.NoError(t, err)
+
+	var treatment, differ int
+	for n := 0; n < 2000; n++ {
+		req := httptest.NewRequest("GET", "/?u="+strconv.Itoa(n), nil)
+
+		_, ca, _ := a.Assign(req)
+		_, again, _ := a.Assign(req)
+		_, cb, _ := b.Assign(req)
+
+		assert.Equal(t, ca, again)
+		if ca == CohortTreatment {
+			treatment++
+		}
+		if ca != cb {
+			differ++
+		}
+	}
+
+	assert.InDelta(t, 500, treatment, 75)
+	assert.Greater(t, differ, 0)
+}
+
+// TestFaultCohorts tests that a Fault with a CohortAssigner only faults the treatment cohort and
+// reports Exposures.
+func TestFaultCohorts(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		giveTreatment float32
+		giveUser      string
+		wantCode      int
+		wantExposure  *Exposure
+	}{
+		{
+			name:          "treatment",
+			giveTreatment: 1.0,
+			giveUser:      "user-1",
+			wantCode:      http.StatusInternalServerError,
+			wantExposure: &Exposure{
+				Fault:      "exp-fault",
+				Experiment: "exp",
+				Unit:       "user-1",
+				Cohort:     CohortTreatment,
+			},
+		},
+		{
+			name:          "control",
+			giveTreatment: 0.0,
+			giveUser:      "user-1",
+			wantCode:      testHandlerCode,
+			wantExposure: &Exposure{
+				Fault:      "exp-fault",
+				Experiment: "exp",
+				Unit:       "user-1",
+				Cohort:     CohortControl,
+			},
+		},
+		{
+			name:          "no unit",
+			giveTreatment: 1.0,
+			wantCode:      testHandlerCode,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			a, err := NewCohortAssigner("exp", tt.giveTreatment, WithUnitHeader("X-User"))
+			assert.NoError(t, err)
+
+			reporter := newTestExposureReporter()
+			f, err := NewFault(newTestInjector500s(),
+				WithName("exp-fault"),
+				WithEnabled(true),
+				WithParticipation(1.0),
+				WithCohortAssigner(a),
+				WithReporter(reporter),
+			)
+			assert.NoError(t, err)
+
+			req := httptest.NewRequest("GET", "/", nil)
+			if tt.giveUser != "" {
+				req.Header.Set("X-User", tt.giveUser)
+			}
+
+			rr := httptest.NewRecorder()
+			f.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(testHandlerCode)
+			})).ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.wantCode, rr.Code)
+
+			if tt.wantExposure == nil {
+				assert.Len(t, reporter.exposures, 0)
+				return
+			}
+
+			got := <-reporter.exposures
+			assert.Equal(t, req, got.Request)
+			got.Request = nil
+			assert.Equal(t, *tt.wantExposure, got)
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package fault
+
+import "errors"
+
+const (
+	// DefaultMaxInjectors is the default number of Injectors a ChainInjector or RandomInjector may
+	// combine.
+	DefaultMaxInjectors = 64
+	// DefaultMaxDepth is the default number of ChainInjectors and RandomInjectors that may be nested
+	// inside each other, counting the outermost one.
+	DefaultMaxDepth = 8
+)
+
+var (
+	// ErrInvalidLimit when a limit on composite Injectors is not positive.
+	ErrInvalidLimit = errors.New("limit must be > 0")
+	// ErrTooManyInjectors when a ChainInjector or RandomInjector is given more Injectors than its
+	// limit.
+	ErrTooManyInjectors = errors.New("too many injectors")
+	// ErrTooDeep when ChainInjectors and RandomInjectors are nested deeper than the limit.
+	ErrTooDeep = errors.New("injectors are nested too deeply")
+	// ErrInjectorCycle when a ChainInjector or RandomInjector contains itself.
+	ErrInjectorCycle = errors.New("injector contains itself")
+)
+
+// CompositeOption configures Injectors that combine other Injectors.
+type CompositeOption interface {
+	ChainInjectorOption
+	RandomInjectorOption
+	OutageInjectorOption
+	ConditionalInjectorOption
+}
+
+// compositeLimits limit the per-request work of a composite Injector.
+type compositeLimits struct {
+	maxInjectors int
+	maxDepth     int
+}
+
+// defaultCompositeLimits returns the default compositeLimits.
+func defaultCompositeLimits() compositeLimits {
+	return compositeLimits{
+		maxInjectors: DefaultMaxInjectors,
+		maxDepth:     DefaultMaxDepth,
+	}
+}
+
+type maxInjectorsOption int
+
+func (o maxInjectorsOption) applyChainInjector(i *ChainInjector) error {
+	return o.apply(&i.limits)
+}
+
+func (o maxInjectorsOption) applyRandomInjector(i *RandomInjector) error {
+	return o.apply(&i.limits)
+}
+
+func (o maxInjectorsOption) apply(l *compositeLimits) error {
+	if o < 1 {
+		return ErrInvalidLimit
+	}
+
+	l.maxInjectors = int(o)
+
+	return nil
+}
+
+// WithMaxInjectors sets the number of Injectors a ChainInjector, RandomInjector, OutageInjector, or
+// ConditionalInjector may combine. Default DefaultMaxInjectors.
+func WithMaxInjectors(n int) CompositeOption {
+	return maxInjectorsOption(n)
+}
+
+type maxDepthOption int
+
+func (o maxDepthOption) applyChainInjector(i *ChainInjector) error {
+	return o.apply(&i.limits)
+}
+
+func (o maxDepthOption) applyRandomInjector(i *RandomInjector) error {
+	return o.apply(&i.limits)
+}
+
+func (o maxDepthOption) apply(l *compositeLimits) error {
+	if o < 1 {
+		return ErrInvalidLimit
+	}
+
+	l.maxDepth = int(o)
+
+	return nil
+}
+
+// WithMaxDepth sets how many ChainInjectors and RandomInjectors may be nested inside each other,
+// counting the one being created. Default DefaultMaxDepth.
+func WithMaxDepth(n int) CompositeOption {
+	return maxDepthOption(n)
+}
+
+// composite is implemented by Injectors that combine other Injectors.
+type composite interface {
+	children() []Injector
+}
+
+// check returns an error if is has more Injectors or deeper nesting than l allows, or if an
+// Injector contains itself.
+func (l compositeLimits) check(is []Injector) error {
+	if len(is) > l.maxInjectors {
+		return ErrTooManyInjectors
+	}
+
+	depth, err := compositeDepth(is, make(map[composite]bool))
+	if err != nil {
+		return err
+	}
+
+	// the composite being created adds one level
+	if depth+1 > l.maxDepth {
+		return ErrTooDeep
+	}
+
+	return nil
+}
+
+// compositeDepth returns how deeply composites are nested in is. path holds the composites that
+// contain is, to detect cycles.
+func compositeDepth(is []Injector, path map[composite]bool) (int, error) {
+	depth := 0
+
+	for _, i := range is {
+		c, ok := i.(composite)
+		if !ok {
+			continue
+		}
+
+		if path[c] {
+			return 0, ErrInjectorCycle
+		}
+
+		path[c] = true
+		d, err := compositeDepth(c.children(), path)
+		delete(path, c)
+
+		if err != nil {
+			return 0, err
+		}
+
+		if d+1 > depth {
+			depth = d + 1
+		}
+	}
+
+	return depth, nil
+}
@@ -0,0 +1,147 @@
+package fault
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// nestChains returns n ChainInjectors nested inside each other.
+func nestChains(t *testing.T, n int) Injector {
+	t.Helper()
+
+	var i Injector = newTestInjectorNoop()
+	for idx := 0; idx < n; idx++ {
+		ci, err := NewChainInjector([]Injector{i})
+		assert.NoError(t, err)
+		i = ci
+	}
+
+	return i
+}
+
+// TestCompositeLimits tests the limits on ChainInjectors and RandomInjectors.
+func TestCompositeLimits(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		give        func(t *testing.T) []Injector
+		giveOptions []CompositeOption
+		wantErr     error
+	}{
+		{
+			name: "at max injectors",
+			give: func(t *testing.T) []Injector {
+				return []Injector{newTestInjectorNoop(), newTestInjectorNoop()}
+			},
+			giveOptions: []CompositeOption{WithMaxInjectors(2)},
+			wantErr:     nil,
+		},
+		{
+			name: "too many injectors",
+			give: func(t *testing.T) []Injector {
+				return []Injector{newTestInjectorNoop(), newTestInjectorNoop(), newTestInjectorNoop()}
+			},
+			giveOptions: []CompositeOption{WithMaxInjectors(2)},
+			wantErr:     ErrTooManyInjectors,
+		},
+		{
+			name: "too many injectors by default",
+			give: func(t *testing.T) []Injector {
+				is := make([]Injector, DefaultMaxInjectors+1)
+				for idx := range is {
+					is[idx] = newTestInjectorNoop()
+				}
+				return is
+			},
+			giveOptions: nil,
+			wantErr:     ErrTooManyInjectors,
+		},
+		{
+			name: "at max depth",
+			give: func(t *testing.T) []Injector {
+				return []Injector{nestChains(t, 2)}
+			},
+			giveOptions: []CompositeOption{WithMaxDepth(3)},
+			wantErr:     nil,
+		},
+		{
+			name: "too deep",
+			give: func(t *testing.T) []Injector {
+				return []Injector{newTestInjectorNoop(), nestChains(t, 3)}
+			},
+			giveOptions: []CompositeOption{WithMaxDepth(3)},
+			wantErr:     ErrTooDeep,
+		},
+		{
+			name: "too deep by default",
+			give: func(t *testing.T) []Injector {
+				return []Injector{nestChains(t, DefaultMaxDepth)}
+			},
+			giveOptions: nil,
+			wantErr:     ErrTooDeep,
+		},
+		{
+			name: "invalid max injectors",
+			give: func(t *testing.T) []Injector {
+				return []Injector{newTestInjectorNoop()}
+			},
+			giveOptions: []CompositeOption{WithMaxInjectors(0)},
+			wantErr:     ErrInvalidLimit,
+		},
+		{
+			name: "invalid max depth",
+			give: func(t *testing.T) []Injector {
+				return []Injector{newTestInjectorNoop()}
+			},
+			giveOptions: []CompositeOption{WithMaxDepth(0)},
+			wantErr:     ErrInvalidLimit,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var (
+				chainOpts  []ChainInjectorOption
+				randomOpts []RandomInjectorOption
+			)
+			for _, opt := range tt.giveOptions {
+				chainOpts = append(chainOpts, opt)
+				randomOpts = append(randomOpts, opt)
+			}
+
+			ci, err := NewChainInjector(tt.give(t), chainOpts...)
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.wantErr == nil, ci != nil)
+
+			ri, err := NewRandomInjector(tt.give(t), randomOpts...)
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.wantErr == nil, ri != nil)
+		})
+	}
+}
+
+// TestCompositeCycle tests that composites that contain themselves are rejected.
+func TestCompositeCycle(t *testing.T) {
+	t.Parallel()
+
+	ci, err := NewChainInjector([]Injector{newTestInjectorNoop()})
+	assert.NoError(t, err)
+
+	ri, err := NewRandomInjector([]Injector{ci})
+	assert.NoError(t, err)
+
+	// only possible by changing the Injectors after creation, as a config loader resolving
+	// references might
+	ci.injectors = []Injector{ri}
+
+	_, err = NewChainInjector([]Injector{ri})
+	assert.Equal(t, ErrInjectorCycle, err)
+
+	_, err = NewChainInjector([]Injector{newTestInjectorNoop(), ci})
+	assert.Equal(t, ErrInjectorCycle, err)
+}
@@ -0,0 +1,360 @@
+package fault
+
+import (
+	"errors"
+	"math"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultConcludeInterval is how often a Concluder checks the impact of its Fault by default.
+	defaultConcludeInterval = 30 * time.Second
+	// defaultMinSamples is the fewest injected requests a Concluder concludes on by default.
+	defaultMinSamples = 100
+	// defaultStableChecks is how many checks the impact must hold steady for by default.
+	defaultStableChecks = 3
+	// defaultImpactTolerance is how far the impact may move and still be stable by default.
+	defaultImpactTolerance = 0.01
+
+	// confidenceZ is the z-score of a two-sided 95% confidence interval.
+	confidenceZ = 1.96
+)
+
+var (
+	// ErrNoOutcomeRecorder when a Concluder is given a Fault without an OutcomeRecorder.
+	ErrNoOutcomeRecorder = errors.New("fault has no outcome recorder")
+	// ErrInvalidMinSamples when a Concluder minimum sample size is not positive.
+	ErrInvalidMinSamples = errors.New("min samples must be > 0")
+	// ErrInvalidStableChecks when a Concluder is not given at least two checks to compare.
+	ErrInvalidStableChecks = errors.New("stable checks must be >= 2")
+	// ErrInvalidTolerance when a Concluder impact tolerance is not 0.0 < tolerance <= 1.0.
+	ErrInvalidTolerance = errors.New("tolerance must be 0.0 < tolerance <= 1.0")
+)
+
+// Conclusion describes a Concluder ending the experiment of its Fault.
+type Conclusion struct {
+	// Fault is the name of the Fault that was disabled.
+	Fault string
+	// Injected is the number of requests the Injector ran on.
+	Injected int64
+	// Baseline is the number of requests the Injector did not run on.
+	Baseline int64
+	// SuccessImpact is the success rate of the baseline requests minus that of the injected
+	// requests, so 0.05 means the Injector failed 5% more requests.
+	SuccessImpact float64
+	// Margin is the half-width of the 95% confidence interval of SuccessImpact.
+	Margin float64
+	// LatencyImpact is the mean latency of the injected requests minus that of the baseline
+	// requests.
+	LatencyImpact time.Duration
+	// ConcludedAt is when the experiment was concluded.
+	ConcludedAt time.Time
+}
+
+// impact is the impact of a Fault measured by one check.
+type impact struct {
+	success  float64
+	latency  time.Duration
+	baseline time.Duration
+}
+
+// Concluder ends an experiment as soon as its result is known, to expose no more customers to it
+// than necessary. It periodically compares the injected and baseline requests the OutcomeRecorder
+// of its Fault recorded, and disables the Fault once enough requests were injected, the success
+// rate impact is known to within the tolerance at 95% confidence, and both the success rate and
+// latency impacts have held steady over the last few checks.
+type Concluder struct {
+	fault    *Fault
+	recorder *OutcomeRecorder
+
+	minSamples   int64
+	stableChecks int
+	tolerance    float64
+	interval     time.Duration
+	clock        Clock
+	conclusion   func(c Conclusion)
+
+	// checkMtx serializes checks and protects recent and concluded.
+	checkMtx sync.Mutex
+	// recent are the impacts of the last checks with enough samples, oldest first.
+	recent []impact
+	// concluded is the Conclusion, or nil if the experiment has not been concluded.
+	concluded *Conclusion
+
+	mtx  sync.Mutex
+	stop chan struct{}
+	done chan struct{}
+}
+
+// ConcluderOption configures a Concluder.
+type ConcluderOption interface {
+	applyConcluder(c *Concluder) error
+}
+
+func (o clockOption) applyConcluder(c *Concluder) error {
+	c.clock = o.clock
+	return nil
+}
+
+func (o checkIntervalOption) applyConcluder(c *Concluder) error {
+	if o <= 0 {
+		return ErrInvalidInterval
+	}
+
+	c.interval = time.Duration(o)
+
+	return nil
+}
+
+type minSamplesOption int64
+
+func (o minSamplesOption) applyConcluder(c *Concluder) error {
+	if o <= 0 {
+		return ErrInvalidMinSamples
+	}
+
+	c.minSamples = int64(o)
+
+	return nil
+}
+
+// WithMinSamples sets the fewest injected requests a Concluder concludes on, however confident and
+// steady the impact looks. Default 100.
+func WithMinSamples(n int64) ConcluderOption {
+	return minSamplesOption(n)
+}
+
+type stableChecksOption int
+
+func (o stableChecksOption) applyConcluder(c *Concluder) error {
+	if o < 2 {
+		return ErrInvalidStableChecks
+	}
+
+	c.stableChecks = int(o)
+
+	return nil
+}
+
+// WithStableChecks sets how many consecutive checks, with enough samples, the impact must hold
+// steady over before a Concluder concludes. Default 3.
+func WithStableChecks(n int) ConcluderOption {
+	return stableChecksOption(n)
+}
+
+type impactToleranceOption float64
+
+func (o impactToleranceOption) applyConcluder(c *Concluder) error {
+	if o <= 0 || o > 1 {
+		return ErrInvalidTolerance
+	}
+
+	c.tolerance = float64(o)
+
+	return nil
+}
+
+// WithImpactTolerance sets how precisely a Concluder must know the impact, as a fraction. The 95%
+// confidence interval of the success rate impact must be no wider than tolerance either side, the
+// success rate impact must move no more than tolerance over the stable checks, and the latency
+// impact no more than tolerance of the mean baseline latency. Default 0.01.
+func WithImpactTolerance(t float64) ConcluderOption {
+	return impactToleranceOption(t)
+}
+
+type conclusionFuncOption func(c Conclusion)
+
+func (o conclusionFuncOption) applyConcluder(c *Concluder) error {
+	c.conclusion = o
+	return nil
+}
+
+// WithConclusionFunc sets a function that is called with the Conclusion, for example to log or
+// publish the result of the experiment.
+func WithConclusionFunc(f func(c Conclusion)) ConcluderOption {
+	return conclusionFuncOption(f)
+}
+
+// NewConcluder returns a Concluder that ends the experiment of f early. f must have an
+// OutcomeRecorder, added with WithOutcomeRecorder(); NewConcluder returns ErrNoOutcomeRecorder
+// otherwise. Call Start to begin checking.
+func NewConcluder(f *Fault, opts ...ConcluderOption) (*Concluder, error) {
+	if f == nil {
+		return nil, ErrNilFault
+	}
+
+	if f.outcomes == nil {
+		return nil, ErrNoOutcomeRecorder
+	}
+
+	// set defaults
+	c := &Concluder{
+		fault:        f,
+		recorder:     f.outcomes,
+		minSamples:   defaultMinSamples,
+		stableChecks: defaultStableChecks,
+		tolerance:    defaultImpactTolerance,
+		interval:     defaultConcludeInterval,
+		clock:        NewRealClock(),
+		conclusion:   func(Conclusion) {},
+	}
+
+	// apply options
+	for _, opt := range opts {
+		err := opt.applyConcluder(c)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return c, nil
+}
+
+// Start begins checking the impact every interval in a new goroutine. It does nothing if the
+// Concluder is already started.
+func (c *Concluder) Start() {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if c.stop != nil {
+		return
+	}
+
+	c.stop = make(chan struct{})
+	c.done = make(chan struct{})
+
+	go c.run(c.stop, c.done)
+}
+
+// Stop stops checking and waits for a check in progress to finish. It does nothing if the
+// Concluder is not started.
+func (c *Concluder) Stop() {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if c.stop == nil {
+		return
+	}
+
+	close(c.stop)
+	<-c.done
+
+	c.stop = nil
+	c.done = nil
+}
+
+// run calls Check every interval until stop is closed.
+func (c *Concluder) run(stop <-chan struct{}, done chan<- struct{}) {
+	defer close(done)
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-c.clock.After(c.interval):
+			c.Check()
+		}
+	}
+}
+
+// Check measures the impact of the Fault once, over every request recorded so far, and concludes
+// the experiment by disabling the Fault if the impact is confident and steady. A check without
+// enough injected requests, or without baseline requests to compare them to, is not counted
+// towards the stable checks. It returns the Conclusion, or nil if the experiment goes on or was
+// already concluded.
+func (c *Concluder) Check() *Conclusion {
+	c.checkMtx.Lock()
+	defer c.checkMtx.Unlock()
+
+	if c.concluded != nil {
+		return nil
+	}
+
+	baseline, faulted := newOutcome(c.recorder.buckets), newOutcome(c.recorder.buckets)
+	for _, ro := range c.recorder.Snapshot()[c.fault.Name()] {
+		baseline.add(ro.Baseline)
+		faulted.add(ro.Faulted)
+	}
+
+	if faulted.Requests() < c.minSamples || baseline.Requests() == 0 {
+		return nil
+	}
+
+	now := impact{
+		success:  baseline.SuccessRate() - faulted.SuccessRate(),
+		latency:  faulted.Latency.Mean() - baseline.Latency.Mean(),
+		baseline: baseline.Latency.Mean(),
+	}
+	c.recent = append(c.recent, now)
+	if len(c.recent) > c.stableChecks {
+		c.recent = c.recent[1:]
+	}
+
+	margin := confidenceZ * math.Sqrt(variance(baseline)+variance(faulted))
+	if margin > c.tolerance || !c.steady() {
+		return nil
+	}
+
+	c.fault.DisableNow()
+	c.concluded = &Conclusion{
+		Fault:         c.fault.Name(),
+		Injected:      faulted.Requests(),
+		Baseline:      baseline.Requests(),
+		SuccessImpact: now.success,
+		Margin:        margin,
+		LatencyImpact: now.latency,
+		ConcludedAt:   c.clock.Now(),
+	}
+
+	c.conclusion(*c.concluded)
+
+	concluded := *c.concluded
+	return &concluded
+}
+
+// steady returns true if there are impacts for all the stable checks and they move no more than
+// the tolerance.
+func (c *Concluder) steady() bool {
+	if len(c.recent) < c.stableChecks {
+		return false
+	}
+
+	last := c.recent[len(c.recent)-1]
+	maxLatency := time.Duration(c.tolerance * float64(last.baseline))
+
+	for _, i := range c.recent[:len(c.recent)-1] {
+		if math.Abs(i.success-last.success) > c.tolerance {
+			return false
+		}
+
+		d := i.latency - last.latency
+		if d < 0 {
+			d = -d
+		}
+		if d > maxLatency {
+			return false
+		}
+	}
+
+	return true
+}
+
+// variance returns the variance of the success rate of o as an estimate of its true rate.
+func variance(o Outcome) float64 {
+	p := o.SuccessRate()
+	return p * (1 - p) / float64(o.Requests())
+}
+
+// Conclusion returns the Conclusion of the experiment and true, or false if it has not been
+// concluded.
+func (c *Concluder) Conclusion() (Conclusion, bool) {
+	c.checkMtx.Lock()
+	defer c.checkMtx.Unlock()
+
+	if c.concluded == nil {
+		return Conclusion{}, false
+	}
+
+	return *c.concluded, true
+}
@@ -0,0 +1,291 @@
+package fault
+
+import (
+	"testing"
+	"time"
+
+	"github.com/github/go-fault/faulttest"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestConcluderFault returns an enabled Fault named "exp" with a new OutcomeRecorder.
+func newTestConcluderFault(t *testing.T) (*Fault, *OutcomeRecorder) {
+	t.Helper()
+
+	rec, err := NewOutcomeRecorder()
+	assert.NoError(t, err)
+
+	f, err := NewFault(newTestInjectorNoop(), WithName("exp"), WithEnabled(true), WithOutcomeRecorder(rec))
+	assert.NoError(t, err)
+
+	return f, rec
+}
+
+// observeRequests records n requests of the Fault named "exp" that took d, of which failed ended
+// with a 500.
+func observeRequests(rec *OutcomeRecorder, route string, injected bool, n, failed int, d time.Duration) {
+	for i := 0; i < n; i++ {
+		code := 200
+		if i < failed {
+			code = 500
+		}
+		rec.observe("exp", route, injected, code, d)
+	}
+}
+
+// TestNewConcluder tests NewConcluder.
+func TestNewConcluder(t *testing.T) {
+	t.Parallel()
+
+	clock := faulttest.NewClock(time.Time{})
+	f, _ := newTestConcluderFault(t)
+	unrecorded, err := NewFault(newTestInjectorNoop())
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name             string
+		giveFault        *Fault
+		giveOptions      []ConcluderOption
+		wantMinSamples   int64
+		wantStableChecks int
+		wantTolerance    float64
+		wantInterval     time.Duration
+		wantClock        Clock
+		wantErr          error
+	}{
+		{
+			name:             "defaults",
+			giveFault:        f,
+			wantMinSamples:   defaultMinSamples,
+			wantStableChecks: defaultStableChecks,
+			wantTolerance:    defaultImpactTolerance,
+			wantInterval:     defaultConcludeInterval,
+			wantClock:        NewRealClock(),
+		},
+		{
+			name:      "options",
+			giveFault: f,
+			giveOptions: []ConcluderOption{
+				WithMinSamples(10),
+				WithStableChecks(2),
+				WithImpactTolerance(1),
+				WithCheckInterval(time.Minute),
+				WithClock(clock),
+				WithConclusionFunc(func(Conclusion) {}),
+			},
+			wantMinSamples:   10,
+			wantStableChecks: 2,
+			wantTolerance:    1,
+			wantInterval:     time.Minute,
+			wantClock:        clock,
+		},
+		{
+			name:    "nil fault",
+			wantErr: ErrNilFault,
+		},
+		{
+			name:      "no outcome recorder",
+			giveFault: unrecorded,
+			wantErr:   ErrNoOutcomeRecorder,
+		},
+		{
+			name:        "invalid min samples",
+			giveFault:   f,
+			giveOptions: []ConcluderOption{WithMinSamples(0)},
+			wantErr:     ErrInvalidMinSamples,
+		},
+		{
+			name:        "invalid stable checks",
+			giveFault:   f,
+			giveOptions: []ConcluderOption{WithStableChecks(1)},
+			wantErr:     ErrInvalidStableChecks,
+		},
+		{
+			name:        "zero tolerance",
+			giveFault:   f,
+			giveOptions: []ConcluderOption{WithImpactTolerance(0)},
+			wantErr:     ErrInvalidTolerance,
+		},
+		{
+			name:        "tolerance over one",
+			giveFault:   f,
+			giveOptions: []ConcluderOption{WithImpactTolerance(1.5)},
+			wantErr:     ErrInvalidTolerance,
+		},
+		{
+			name:        "invalid interval",
+			giveFault:   f,
+			giveOptions: []ConcluderOption{WithCheckInterval(0)},
+			wantErr:     ErrInvalidInterval,
+		},
+		{
+			name:        "option error",
+			giveFault:   f,
+			giveOptions: []ConcluderOption{withError()},
+			wantErr:     errErrorOption,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			c, err := NewConcluder(tt.giveFault, tt.giveOptions...)
+
+			assert.Equal(t, tt.wantErr, err)
+			if tt.wantErr != nil {
+				assert.Nil(t, c)
+				return
+			}
+
+			assert.Equal(t, tt.giveFault, c.fault)
+			assert.Equal(t, tt.wantMinSamples, c.minSamples)
+			assert.Equal(t, tt.wantStableChecks, c.stableChecks)
+			assert.Equal(t, tt.wantTolerance, c.tolerance)
+			assert.Equal(t, tt.wantInterval, c.interval)
+			assert.Equal(t, tt.wantClock, c.clock)
+			assert.NotNil(t, c.conclusion)
+		})
+	}
+}
+
+// TestConcluderCheck tests that a Concluder only concludes once the impact is confident and steady.
+func TestConcluderCheck(t *testing.T) {
+	t.Parallel()
+
+	clock := faulttest.NewClock(time.Unix(1700000000, 0))
+	f, rec := newTestConcluderFault(t)
+
+	var conclusions []Conclusion
+	c, err := NewConcluder(f,
+		WithMinSamples(1000),
+		WithStableChecks(2),
+		WithImpactTolerance(0.05),
+		WithClock(clock),
+		WithConclusionFunc(func(c Conclusion) {
+			conclusions = append(conclusions, c)
+		}),
+	)
+	assert.NoError(t, err)
+
+	// too few injected requests
+	observeRequests(rec, "/a", true, 999, 100, 300*time.Millisecond)
+	observeRequests(rec, "/a", false, 1000, 0, 100*time.Millisecond)
+	assert.Nil(t, c.Check())
+
+	// enough requests, but the first check with them cannot be steady
+	observeRequests(rec, "/b", true, 1, 0, 300*time.Millisecond)
+	assert.Nil(t, c.Check())
+
+	// the latency impact moved
+	observeRequests(rec, "/b", true, 1000, 100, time.Second)
+	assert.Nil(t, c.Check())
+
+	// both impacts held steady
+	observeRequests(rec, "/b", true, 2000, 200, 650*time.Millisecond)
+	observeRequests(rec, "/b", false, 1000, 0, 100*time.Millisecond)
+	got := c.Check()
+
+	assert.NotNil(t, got)
+	assert.Equal(t, "exp", got.Fault)
+	assert.Equal(t, int64(4000), got.Injected)
+	assert.Equal(t, int64(2000), got.Baseline)
+	assert.InDelta(t, 0.1, got.SuccessImpact, 0.001)
+	assert.InDelta(t, 0.0093, got.Margin, 0.0001)
+	assert.Equal(t, 550*time.Millisecond, got.LatencyImpact)
+	assert.Equal(t, clock.Now(), got.ConcludedAt)
+	assert.False(t, f.enabled.Load())
+	assert.Equal(t, []Conclusion{*got}, conclusions)
+
+	conclusion, ok := c.Conclusion()
+	assert.True(t, ok)
+	assert.Equal(t, *got, conclusion)
+
+	// a concluded experiment is not concluded again
+	assert.Nil(t, c.Check())
+	assert.Len(t, conclusions, 1)
+}
+
+// TestConcluderCheckUnconfident tests that a Concluder does not conclude while the confidence
+// interval of the success rate impact is wider than the tolerance.
+func TestConcluderCheckUnconfident(t *testing.T) {
+	t.Parallel()
+
+	f, rec := newTestConcluderFault(t)
+	c, err := NewConcluder(f, WithMinSamples(10), WithStableChecks(2), WithImpactTolerance(0.05))
+	assert.NoError(t, err)
+
+	// no baseline to compare to
+	observeRequests(rec, "/", true, 10, 5, time.Second)
+	assert.Nil(t, c.Check())
+
+	observeRequests(rec, "/", false, 10, 0, time.Second)
+	assert.Nil(t, c.Check())
+	assert.Nil(t, c.Check())
+
+	_, ok := c.Conclusion()
+	assert.False(t, ok)
+	assert.True(t, f.enabled.Load())
+}
+
+// TestConcluderCheckSuccessMoved tests that a Concluder does not conclude while the success rate
+// impact moves more than the tolerance.
+func TestConcluderCheckSuccessMoved(t *testing.T) {
+	t.Parallel()
+
+	f, rec := newTestConcluderFault(t)
+	c, err := NewConcluder(f, WithMinSamples(10), WithStableChecks(2), WithImpactTolerance(0.05))
+	assert.NoError(t, err)
+
+	observeRequests(rec, "/", true, 1000, 1000, time.Second)
+	observeRequests(rec, "/", false, 1000, 0, time.Second)
+	assert.Nil(t, c.Check())
+
+	// the impact is confident, but fell from 1.0 to 0.1
+	observeRequests(rec, "/", true, 9000, 0, time.Second)
+	assert.Nil(t, c.Check())
+	assert.True(t, f.enabled.Load())
+}
+
+// TestConcluderStartStop tests Concluder.Start and Concluder.Stop.
+func TestConcluderStartStop(t *testing.T) {
+	t.Parallel()
+
+	clock := faulttest.NewClock(time.Time{})
+	f, rec := newTestConcluderFault(t)
+
+	conclusions := make(chan Conclusion, 1)
+	c, err := NewConcluder(f,
+		WithMinSamples(1),
+		WithStableChecks(2),
+		WithCheckInterval(time.Second),
+		WithClock(clock),
+		WithConclusionFunc(func(c Conclusion) {
+			conclusions <- c
+		}),
+	)
+	assert.NoError(t, err)
+
+	observeRequests(rec, "/", true, 10, 10, time.Second)
+	observeRequests(rec, "/", false, 10, 0, time.Second)
+
+	// stopping before starting does nothing
+	c.Stop()
+
+	c.Start()
+	c.Start()
+
+	clock.BlockUntil(1)
+	clock.Advance(time.Second)
+	clock.BlockUntil(1)
+	assert.True(t, f.enabled.Load())
+	clock.Advance(time.Second)
+
+	got := <-conclusions
+	assert.Equal(t, 1.0, got.SuccessImpact)
+	assert.False(t, f.enabled.Load())
+
+	c.Stop()
+	c.Stop()
+}
@@ -0,0 +1,729 @@
+package fault
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+var (
+	// ErrInvalidConfig when a config cannot be parsed or a parameter has the wrong type.
+	ErrInvalidConfig = errors.New("invalid config")
+	// ErrUnknownInjectorType when a config names an injector type that is not registered.
+	ErrUnknownInjectorType = errors.New("unknown injector type")
+	// ErrDuplicateInjectorType when an injector type is registered twice.
+	ErrDuplicateInjectorType = errors.New("injector type is already registered")
+	// ErrNilInjectorFactory when a nil InjectorFactory is registered.
+	ErrNilInjectorFactory = errors.New("injector factory cannot be nil")
+	// ErrUnsupportedConfigVersion when a config has a version this package cannot read, such as one
+	// written for a newer version of the package.
+	ErrUnsupportedConfigVersion = errors.New("unsupported config version")
+)
+
+// ConfigVersion is the version of the config format this package writes. NewFaultsFromConfig reads
+// configs of every version up to ConfigVersion, so configs kept in a repository keep loading as the
+// package is upgraded. Configs without a version are read as version 1, the format used before
+// versions were added.
+const ConfigVersion = 1
+
+// config is a declarative list of Faults.
+type config struct {
+	Version int           `yaml:"version"`
+	Faults  []faultConfig `yaml:"faults"`
+}
+
+// faultConfig configures a Fault.
+type faultConfig struct {
+	Name            string            `yaml:"name,omitempty"`
+	Enabled         bool              `yaml:"enabled,omitempty"`
+	Participation   float32           `yaml:"participation,omitempty"`
+	Seed            *int64            `yaml:"seed,omitempty"`
+	PathAllowlist   []string          `yaml:"path_allowlist,omitempty"`
+	PathBlocklist   []string          `yaml:"path_blocklist,omitempty"`
+	HeaderAllowlist map[string]string `yaml:"header_allowlist,omitempty"`
+	HeaderBlocklist map[string]string `yaml:"header_blocklist,omitempty"`
+	Labels          map[string]string `yaml:"labels,omitempty"`
+	Injector        injectorConfig    `yaml:"injector"`
+}
+
+// injectorConfig configures an Injector.
+type injectorConfig struct {
+	Type   string                 `yaml:"type"`
+	Params map[string]interface{} `yaml:"params,omitempty"`
+}
+
+// InjectorFactory builds an Injector from the parameters of an injector in a config.
+type InjectorFactory func(p ConfigParams) (Injector, error)
+
+// InjectorRegistry maps the injector types of a config to the InjectorFactory that builds them.
+type InjectorRegistry struct {
+	mtx       sync.RWMutex
+	factories map[string]InjectorFactory
+}
+
+// NewInjectorRegistry returns an InjectorRegistry with the Injectors of this package registered:
+//
+//	reject  close_type: abort, reset, mid_headers, or partial_body
+//	error   code, status_text, headers: a map of header names to values
+//	slow    duration, max_concurrent, phase: before_headers, before_body, spread_body, or after_handler
+//	chain   injectors: a list of injectors
+//	random  injectors: a list of injectors, seed
+//	stub    code, status, headers: a map of header names to values, body; all but code are templates
+func NewInjectorRegistry() *InjectorRegistry {
+	return &InjectorRegistry{
+		factories: map[string]InjectorFactory{
+			"reject": newRejectInjectorFromConfig,
+			"error":  newErrorInjectorFromConfig,
+			"slow":   newSlowInjectorFromConfig,
+			"chain":  newChainInjectorFromConfig,
+			"random": newRandomInjectorFromConfig,
+			"stub":   newStubInjectorFromConfig,
+		},
+	}
+}
+
+// Register registers f to build the injectors of type name. It returns ErrDuplicateInjectorType if
+// name is already registered.
+func (r *InjectorRegistry) Register(name string, f InjectorFactory) error {
+	if f == nil {
+		return ErrNilInjectorFactory
+	}
+
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	if _, ok := r.factories[name]; ok {
+		return fmt.Errorf("%w: %q", ErrDuplicateInjectorType, name)
+	}
+	r.factories[name] = f
+
+	return nil
+}
+
+// Types returns the registered injector types, sorted.
+func (r *InjectorRegistry) Types() []string {
+	r.mtx.RLock()
+	defer r.mtx.RUnlock()
+
+	types := make([]string, 0, len(r.factories))
+	for name := range r.factories {
+		types = append(types, name)
+	}
+	sort.Strings(types)
+
+	return types
+}
+
+// newInjector builds the Injector of c, found at path in the config.
+func (r *InjectorRegistry) newInjector(path string, c injectorConfig) (Injector, error) {
+	r.mtx.RLock()
+	f, ok := r.factories[c.Type]
+	r.mtx.RUnlock()
+	if !ok {
+		return nil, &ConfigError{Field: path + ".type", Value: c.Type, Err: ErrUnknownInjectorType}
+	}
+
+	i, err := f(ConfigParams{path: path + ".params", values: c.Params, registry: r})
+	var ce *ConfigError
+	if errors.As(err, &ce) {
+		// the error already names its field
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return i, nil
+}
+
+// ConfigOption configures NewFaultsFromConfig.
+type ConfigOption interface {
+	applyConfig(c *configOptions) error
+}
+
+// configOptions holds the options of NewFaultsFromConfig.
+type configOptions struct {
+	registry *InjectorRegistry
+}
+
+// InjectorRegistryOption configures things that build Injectors from a config.
+type InjectorRegistryOption interface {
+	ConfigOption
+	DynamicFaultOption
+}
+
+type injectorRegistryOption struct {
+	registry *InjectorRegistry
+}
+
+func (o injectorRegistryOption) applyConfig(c *configOptions) error {
+	c.registry = o.registry
+	return nil
+}
+
+// WithInjectorRegistry sets the InjectorRegistry that builds the injectors of a config, with the
+// custom injector types registered on it. Default a new registry of the Injectors of this package,
+// so that no injector types are shared across a process.
+func WithInjectorRegistry(r *InjectorRegistry) InjectorRegistryOption {
+	return injectorRegistryOption{registry: r}
+}
+
+// NewFaultsFromConfig returns the Faults declared in a YAML or JSON config read from r, in order.
+// The config sets the version of its format, and each Fault sets its name, if it is enabled, its
+// participation, its seed, its path and header allowlists and blocklists, and the type and
+// parameters of its injector:
+//
+//	version: 1
+//	faults:
+//	  - name: checkout-latency
+//	    enabled: true
+//	    participation: 0.05
+//	    path_allowlist: [/checkout]
+//	    injector:
+//	      type: slow
+//	      params:
+//	        duration: 750ms
+//
+// Unknown fields are an error wrapping ErrInvalidConfig, unknown injector types are an error
+// wrapping ErrUnknownInjectorType, and versions newer than ConfigVersion are an error wrapping
+// ErrUnsupportedConfigVersion. Errors name the field they were found at, and invalid values of a
+// field are a *ConfigError.
+func NewFaultsFromConfig(r io.Reader, opts ...ConfigOption) ([]*Fault, error) {
+	// set defaults
+	o := &configOptions{
+		registry: NewInjectorRegistry(),
+	}
+
+	// apply options
+	for _, opt := range opts {
+		err := opt.applyConfig(o)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := decodeConfig(b)
+	if err != nil {
+		return nil, err
+	}
+
+	faults := make([]*Fault, 0, len(c.Faults))
+	for idx, fc := range c.Faults {
+		path := fmt.Sprintf("faults[%d]", idx)
+
+		f, err := fc.newFault(path, o.registry)
+		if err != nil {
+			return nil, err
+		}
+		faults = append(faults, f)
+	}
+
+	return faults, nil
+}
+
+// MigrateConfig reads a config of any version NewFaultsFromConfig supports from r and returns it as
+// YAML in the format of ConfigVersion, with its version set. Comments and formatting are not kept.
+// The injectors are not built, so a config can be migrated without registering its custom injector
+// types.
+func MigrateConfig(r io.Reader) ([]byte, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := decodeConfig(b)
+	if err != nil {
+		return nil, err
+	}
+
+	return yaml.Marshal(c)
+}
+
+// decodeConfig decodes a config of any supported version as a config of ConfigVersion. The version
+// is read first, so a config written for a newer version of this package is reported as such
+// instead of as having unknown fields.
+func decodeConfig(b []byte) (config, error) {
+	var v struct {
+		Version int `yaml:"version"`
+	}
+	err := yaml.Unmarshal(b, &v)
+	if err != nil {
+		return config{}, fmt.Errorf("%w: %v", ErrInvalidConfig, err)
+	}
+	if v.Version < 0 || v.Version > ConfigVersion {
+		return config{}, fmt.Errorf("%w: %d, must be 1 to %d", ErrUnsupportedConfigVersion, v.Version, ConfigVersion)
+	}
+
+	var c config
+	err = yaml.UnmarshalStrict(b, &c)
+	if err != nil {
+		return config{}, fmt.Errorf("%w: %v", ErrInvalidConfig, err)
+	}
+
+	// When the format changes, configs of older versions are migrated here, one version at a time.
+	c.Version = ConfigVersion
+
+	return c, nil
+}
+
+// newFault builds the Fault of c, found at path in the config.
+func (c faultConfig) newFault(path string, registry *InjectorRegistry) (*Fault, error) {
+	i, err := registry.newInjector(path+".injector", c.Injector)
+	if err != nil {
+		return nil, err
+	}
+
+	// the fields that can be invalid are checked on their own, so the error can name the field
+	for _, fo := range []struct {
+		field string
+		value interface{}
+		opt   Option
+	}{
+		{field: "participation", value: c.Participation, opt: WithParticipation(c.Participation)},
+		{field: "labels", value: c.Labels, opt: WithLabels(c.Labels)},
+	} {
+		if err := fo.opt.applyFault(&Fault{}); err != nil {
+			return nil, &ConfigError{Field: path + "." + fo.field, Value: fo.value, Err: err}
+		}
+	}
+
+	opts := []Option{
+		WithEnabled(c.Enabled),
+		WithParticipation(c.Participation),
+		WithPathAllowlist(c.PathAllowlist),
+		WithPathBlocklist(c.PathBlocklist),
+		WithHeaderAllowlist(c.HeaderAllowlist),
+		WithHeaderBlocklist(c.HeaderBlocklist),
+		WithLabels(c.Labels),
+	}
+	if c.Name != "" {
+		opts = append(opts, WithName(c.Name))
+	}
+	if c.Seed != nil {
+		opts = append(opts, WithRandSeed(*c.Seed))
+	}
+
+	f, err := NewFault(i, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return f, nil
+}
+
+// ConfigError describes an invalid field of a config, so that tools can point the people editing it
+// at the value to fix.
+type ConfigError struct {
+	// Field is the path of the field in the config, such as "faults[0].injector.params.code".
+	Field string
+	// Value is the value of the field in the config.
+	Value interface{}
+	// Err is why the value is invalid. It wraps ErrInvalidConfig if the value has the wrong type or
+	// format, and is the error of the constructor or Option the value is passed to otherwise, such
+	// as ErrInvalidPercent.
+	Err error
+}
+
+// Error returns the field, the reason, and the value if the field is set, such as
+// "faults[0].participation: percent must be 0.0 <= percent <= 1.0, got 2".
+func (e *ConfigError) Error() string {
+	if e.Value == nil {
+		return fmt.Sprintf("%s: %s", e.Field, e.Err)
+	}
+	if s, ok := e.Value.(string); ok {
+		return fmt.Sprintf("%s: %s, got %q", e.Field, e.Err, s)
+	}
+
+	return fmt.Sprintf("%s: %s, got %v", e.Field, e.Err, e.Value)
+}
+
+// Unwrap returns e.Err.
+func (e *ConfigError) Unwrap() error {
+	return e.Err
+}
+
+// ConfigParams are the parameters of an injector in a config, passed to its InjectorFactory. Each
+// getter returns def if the parameter is not set, and a *ConfigError wrapping ErrInvalidConfig if
+// it has the wrong type.
+type ConfigParams struct {
+	path     string
+	values   map[string]interface{}
+	registry *InjectorRegistry
+}
+
+// Invalid returns a *ConfigError for the parameter key, which err says is invalid. An
+// InjectorFactory can return it when the constructor of its Injector rejects a parameter, so the
+// error names the parameter:
+//
+//	code, err := p.Int("code", 0)
+//	...
+//	ei, err := fault.NewErrorInjector(code)
+//	if errors.Is(err, fault.ErrInvalidHTTPCode) {
+//		return nil, p.Invalid("code", err)
+//	}
+func (p ConfigParams) Invalid(key string, err error) error {
+	return &ConfigError{Field: p.path + "." + key, Value: p.values[key], Err: err}
+}
+
+// invalid returns a *ConfigError for the parameter key that is not a want.
+func (p ConfigParams) invalid(key, want string) error {
+	return p.Invalid(key, fmt.Errorf("%w: must be %s", ErrInvalidConfig, want))
+}
+
+// String returns the string parameter key.
+func (p ConfigParams) String(key, def string) (string, error) {
+	v, ok := p.values[key]
+	if !ok {
+		return def, nil
+	}
+
+	s, ok := v.(string)
+	if !ok {
+		return "", p.invalid(key, "a string")
+	}
+
+	return s, nil
+}
+
+// Int returns the integer parameter key.
+func (p ConfigParams) Int(key string, def int) (int, error) {
+	v, ok := p.values[key]
+	if !ok {
+		return def, nil
+	}
+
+	n, ok := v.(int)
+	if !ok {
+		return 0, p.invalid(key, "an integer")
+	}
+
+	return n, nil
+}
+
+// Float returns the number parameter key.
+func (p ConfigParams) Float(key string, def float64) (float64, error) {
+	v, ok := p.values[key]
+	if !ok {
+		return def, nil
+	}
+
+	switch n := v.(type) {
+	case int:
+		return float64(n), nil
+	case float64:
+		return n, nil
+	}
+
+	return 0, p.invalid(key, "a number")
+}
+
+// Bool returns the boolean parameter key.
+func (p ConfigParams) Bool(key string, def bool) (bool, error) {
+	v, ok := p.values[key]
+	if !ok {
+		return def, nil
+	}
+
+	b, ok := v.(bool)
+	if !ok {
+		return false, p.invalid(key, "a boolean")
+	}
+
+	return b, nil
+}
+
+// Duration returns the duration parameter key, written like "750ms" as parsed by
+// time.ParseDuration.
+func (p ConfigParams) Duration(key string, def time.Duration) (time.Duration, error) {
+	s, err := p.String(key, def.String())
+	if err != nil {
+		return 0, p.invalid(key, "a duration")
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, p.invalid(key, "a duration")
+	}
+
+	return d, nil
+}
+
+// StringMap returns the parameter key, a map of strings to strings, or nil if it is not set.
+func (p ConfigParams) StringMap(key string) (map[string]string, error) {
+	v, ok := p.values[key]
+	if !ok {
+		return nil, nil
+	}
+
+	m, ok := v.(map[interface{}]interface{})
+	if !ok {
+		return nil, p.invalid(key, "a map of strings")
+	}
+
+	sm := make(map[string]string, len(m))
+	for k, v := range m {
+		ks, kok := k.(string)
+		vs, vok := v.(string)
+		if !kok || !vok {
+			return nil, p.invalid(key, "a map of strings")
+		}
+		sm[ks] = vs
+	}
+
+	return sm, nil
+}
+
+// Injectors builds the parameter key, a list of injectors each with a type and params like the
+// injector of a Fault, with the InjectorRegistry of the config. It returns nil if it is not set.
+func (p ConfigParams) Injectors(key string) ([]Injector, error) {
+	cs, paths, err := p.injectorConfigs(key)
+	if cs == nil || err != nil {
+		return nil, err
+	}
+
+	is := make([]Injector, 0, len(cs))
+	for idx, c := range cs {
+		i, err := p.registry.newInjector(paths[idx], c)
+		if err != nil {
+			return nil, err
+		}
+		is = append(is, i)
+	}
+
+	return is, nil
+}
+
+// injectorConfigs returns the parameter key, a list of injectors, and the path of each in the
+// config. It returns nil if it is not set.
+func (p ConfigParams) injectorConfigs(key string) ([]injectorConfig, []string, error) {
+	v, ok := p.values[key]
+	if !ok {
+		return nil, nil, nil
+	}
+
+	list, ok := v.([]interface{})
+	if !ok {
+		return nil, nil, p.invalid(key, "a list of injectors")
+	}
+
+	cs := make([]injectorConfig, 0, len(list))
+	paths := make([]string, 0, len(list))
+	for idx, item := range list {
+		path := fmt.Sprintf("%s.%s[%d]", p.path, key, idx)
+
+		c, ok := parseInjectorConfig(item)
+		if !ok {
+			return nil, nil, &ConfigError{
+				Field: path,
+				Value: item,
+				Err:   fmt.Errorf("%w: must be an injector with a type and params", ErrInvalidConfig),
+			}
+		}
+		cs = append(cs, c)
+		paths = append(paths, path)
+	}
+
+	return cs, paths, nil
+}
+
+// parseInjectorConfig returns the injectorConfig in v, a map with a type and params. It returns
+// false if v has any other field or a field of the wrong type.
+func parseInjectorConfig(v interface{}) (injectorConfig, bool) {
+	var c injectorConfig
+
+	m, ok := v.(map[interface{}]interface{})
+	if !ok {
+		return c, false
+	}
+
+	for k, v := range m {
+		switch k {
+		case "type":
+			c.Type, ok = v.(string)
+		case "params":
+			var params map[interface{}]interface{}
+			params, ok = v.(map[interface{}]interface{})
+			c.Params = make(map[string]interface{}, len(params))
+			for pk, pv := range params {
+				var name string
+				name, ok = pk.(string)
+				c.Params[name] = pv
+			}
+		default:
+			ok = false
+		}
+
+		if !ok {
+			return c, false
+		}
+	}
+
+	return c, true
+}
+
+// newRejectInjectorFromConfig builds a RejectInjector.
+func newRejectInjectorFromConfig(p ConfigParams) (Injector, error) {
+	name, err := p.String("close_type", CloseAbort.String())
+	if err != nil {
+		return nil, err
+	}
+
+	for ct := CloseAbort; ct <= ClosePartialBody; ct++ {
+		if ct.String() == name {
+			return NewRejectInjector(WithCloseType(ct))
+		}
+	}
+
+	return nil, p.invalid("close_type", "abort, reset, mid_headers, or partial_body")
+}
+
+// newErrorInjectorFromConfig builds an ErrorInjector.
+func newErrorInjectorFromConfig(p ConfigParams) (Injector, error) {
+	code, err := p.Int("code", 0)
+	if err != nil {
+		return nil, err
+	}
+
+	var opts []ErrorInjectorOption
+
+	text, err := p.String("status_text", "")
+	if err != nil {
+		return nil, err
+	}
+	if text != "" {
+		opts = append(opts, WithStatusText(text))
+	}
+
+	headers, err := p.StringMap("headers")
+	if err != nil {
+		return nil, err
+	}
+	if headers != nil {
+		h := make(http.Header, len(headers))
+		for k, v := range headers {
+			h.Set(k, v)
+		}
+		opts = append(opts, WithHeaders(h))
+	}
+
+	ei, err := NewErrorInjector(code, opts...)
+	if errors.Is(err, ErrInvalidHTTPCode) {
+		return nil, p.Invalid("code", err)
+	}
+
+	return ei, err
+}
+
+// newStubInjectorFromConfig builds a StubInjector.
+func newStubInjectorFromConfig(p ConfigParams) (Injector, error) {
+	code, err := p.Int("code", http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := p.String("body", "")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := parseStubTemplate("body", body); err != nil {
+		return nil, p.Invalid("body", err)
+	}
+
+	var opts []StubInjectorOption
+
+	status, err := p.String("status", "")
+	if err != nil {
+		return nil, err
+	}
+	if status != "" {
+		if _, err := parseStubTemplate("status", status); err != nil {
+			return nil, p.Invalid("status", err)
+		}
+		opts = append(opts, WithStatusTemplate(status))
+	}
+
+	headers, err := p.StringMap("headers")
+	if err != nil {
+		return nil, err
+	}
+	for _, k := range sortedKeys(headers) {
+		if _, err := parseStubTemplate("header "+k, headers[k]); err != nil {
+			return nil, p.Invalid("headers", err)
+		}
+		opts = append(opts, WithHeaderTemplate(k, headers[k]))
+	}
+
+	si, err := NewStubInjector(code, body, opts...)
+	if errors.Is(err, ErrInvalidHTTPCode) {
+		return nil, p.Invalid("code", err)
+	}
+
+	return si, err
+}
+
+// newSlowInjectorFromConfig builds a SlowInjector.
+func newSlowInjectorFromConfig(p ConfigParams) (Injector, error) {
+	d, err := p.Duration("duration", 0)
+	if err != nil {
+		return nil, err
+	}
+
+	n, err := p.Int("max_concurrent", 0)
+	if err != nil {
+		return nil, err
+	}
+
+	name, err := p.String("phase", LatencyBeforeHeaders.String())
+	if err != nil {
+		return nil, err
+	}
+
+	for phase := LatencyBeforeHeaders; phase <= LatencyAfterHandler; phase++ {
+		if phase.String() == name {
+			si, err := NewSlowInjector(d, WithMaxConcurrent(n), WithLatencyPhase(phase))
+			if errors.Is(err, ErrInvalidMaxConcurrent) {
+				return nil, p.Invalid("max_concurrent", err)
+			}
+
+			return si, err
+		}
+	}
+
+	return nil, p.invalid("phase", "before_headers, before_body, spread_body, or after_handler")
+}
+
+// newChainInjectorFromConfig builds a ChainInjector.
+func newChainInjectorFromConfig(p ConfigParams) (Injector, error) {
+	is, err := p.Injectors("injectors")
+	if err != nil {
+		return nil, err
+	}
+
+	return NewChainInjector(is)
+}
+
+// newRandomInjectorFromConfig builds a RandomInjector.
+func newRandomInjectorFromConfig(p ConfigParams) (Injector, error) {
+	is, err := p.Injectors("injectors")
+	if err != nil {
+		return nil, err
+	}
+
+	seed, err := p.Int("seed", defaultRandSeed)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewRandomInjector(is, WithRandSeed(int64(seed)))
+}
@@ -0,0 +1,620 @@
+package fault
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testConfig declares a Fault of every built-in injector type.
+const testConfig = `
+faults:
+  - name: checkout-latency
+    enabled: true
+    participation: 0.05
+    seed: 42
+    labels: {team: checkout}
+    path_allowlist: [/checkout]
+    path_blocklist: [/checkout/health]
+    header_allowlist: {X-Chaos: "true"}
+    header_blocklist: {X-Canary: "false"}
+    injector:
+      type: slow
+      params:
+        duration: 750ms
+        max_concurrent: 10
+  - name: unavailable
+    participation: 1
+    injector:
+      type: error
+      params:
+        code: 503
+        status_text: try again
+        headers: {Retry-After: "15"}
+  - injector:
+      type: reject
+      params:
+        close_type: reset
+  - name: compound
+    injector:
+      type: chain
+      params:
+        injectors:
+          - type: slow
+            params: {duration: 1s, phase: before_body}
+          - type: error
+            params: {code: 500}
+  - name: either
+    injector:
+      type: random
+      params:
+        seed: 7
+        injectors:
+          - type: reject
+          - type: error
+            params: {code: 502}
+  - name: stubbed
+    injector:
+      type: stub
+      params:
+        status: '{{if .Query.Get "id"}}200{{else}}404{{end}}'
+        headers: {Content-Type: application/json}
+        body: '{"id": {{json (.Query.Get "id")}}}'
+`
+
+// TestNewFaultsFromConfig tests that NewFaultsFromConfig builds the Faults of a config.
+func TestNewFaultsFromConfig(t *testing.T) {
+	t.Parallel()
+
+	faults, err := NewFaultsFromConfig(strings.NewReader(testConfig))
+	assert.NoError(t, err)
+	assert.Len(t, faults, 6)
+
+	type fault struct {
+		name          string
+		enabled       bool
+		participation float32
+		seed          int64
+		injector      string
+	}
+
+	got := make([]fault, 0, len(faults))
+	for _, f := range faults {
+		got = append(got, fault{
+			name:          f.Name(),
+			enabled:       f.Enabled(),
+			participation: f.Participation(),
+			seed:          f.Seed(),
+			injector:      InjectorString(f.Injector()),
+		})
+	}
+
+	assert.Equal(t, []fault{
+		{name: "checkout-latency", enabled: true, participation: 0.05, seed: 42, injector: "slow(750ms)"},
+		{name: "unavailable", participation: 1.0, seed: 1, injector: "error(503)"},
+		{name: "reject(reset)", seed: 1, injector: "reject(reset)"},
+		{name: "compound", seed: 1, injector: "chain(slow(1s), error(500))"},
+		{name: "either", seed: 1, injector: "random(reject, error(502))"},
+		{name: "stubbed", seed: 1, injector: "stub(template)"},
+	}, got)
+
+	slow := faults[0]
+	assert.Equal(t, map[string]bool{"/checkout": true}, slow.pathAllowlist)
+	assert.Equal(t, map[string]bool{"/checkout/health": true}, slow.pathBlocklist)
+	assert.Equal(t, map[string]string{"X-Chaos": "true"}, slow.headerAllowlist)
+	assert.Equal(t, map[string]string{"X-Canary": "false"}, slow.headerBlocklist)
+	assert.Equal(t, 10, slow.Injector().(*SlowInjector).maxConcurrent)
+	assert.Equal(t, map[string]string{"team": "checkout"}, slow.Labels())
+
+	assert.Equal(t, int64(7), faults[4].Injector().(*RandomInjector).Seed())
+
+	rr := httptest.NewRecorder()
+	faults[1].SetEnabled(true)
+	faults[1].Handler(http.NotFoundHandler()).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+	assert.Equal(t, "15", rr.Header().Get("Retry-After"))
+	assert.Equal(t, "try again\n", rr.Body.String())
+}
+
+// TestNewFaultsFromConfigJSON tests that NewFaultsFromConfig reads JSON configs.
+func TestNewFaultsFromConfigJSON(t *testing.T) {
+	t.Parallel()
+
+	faults, err := NewFaultsFromConfig(strings.NewReader(`{
+		"version": 1,
+		"faults": [
+			{
+				"name": "latency",
+				"enabled": true,
+				"participation": 0.5,
+				"injector": {"type": "slow", "params": {"duration": "250ms"}}
+			}
+		]
+	}`))
+	assert.NoError(t, err)
+	assert.Len(t, faults, 1)
+	assert.Equal(t, "latency", faults[0].Name())
+	assert.Equal(t, float32(0.5), faults[0].Participation())
+	assert.Equal(t, "slow(250ms)", InjectorString(faults[0].Injector()))
+}
+
+// testErrReader is an io.Reader that always fails.
+type testErrReader struct{}
+
+var errTestRead = errors.New("read failed")
+
+func (testErrReader) Read(p []byte) (int, error) {
+	return 0, errTestRead
+}
+
+// TestConfigError tests that the errors of NewFaultsFromConfig name the invalid field and its value,
+// including for custom injector types.
+func TestConfigError(t *testing.T) {
+	t.Parallel()
+
+	r := NewInjectorRegistry()
+	err := r.Register("custom", func(p ConfigParams) (Injector, error) {
+		n, err := p.Int("count", 0)
+		if err != nil {
+			return nil, err
+		}
+		if n < 1 {
+			return nil, p.Invalid("count", ErrInvalidCount)
+		}
+
+		return newTestInjector500s(), nil
+	})
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		giveConfig string
+		want       *ConfigError
+	}{
+		{
+			name:       "wrong type",
+			giveConfig: "faults: [{injector: {type: error, params: {code: '500'}}}]",
+			want: &ConfigError{
+				Field: "faults[0].injector.params.code",
+				Value: "500",
+				Err:   ErrInvalidConfig,
+			},
+		},
+		{
+			name:       "option",
+			giveConfig: "faults: [{injector: {type: reject}}, {participation: 1.5, injector: {type: reject}}]",
+			want: &ConfigError{
+				Field: "faults[1].participation",
+				Value: float32(1.5),
+				Err:   ErrInvalidPercent,
+			},
+		},
+		{
+			name:       "custom",
+			giveConfig: "faults: [{injector: {type: chain, params: {injectors: [{type: custom, params: {count: 0}}]}}}]",
+			want: &ConfigError{
+				Field: "faults[0].injector.params.injectors[0].params.count",
+				Value: 0,
+				Err:   ErrInvalidCount,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := NewFaultsFromConfig(strings.NewReader(tt.giveConfig), WithInjectorRegistry(r))
+
+			var ce *ConfigError
+			if !assert.True(t, errors.As(err, &ce), err) {
+				return
+			}
+			assert.Equal(t, tt.want.Field, ce.Field)
+			assert.Equal(t, tt.want.Value, ce.Value)
+			assert.True(t, errors.Is(err, tt.want.Err), err)
+		})
+	}
+}
+
+// TestNewFaultsFromConfigErrors tests the errors of NewFaultsFromConfig.
+func TestNewFaultsFromConfigErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		giveConfig string
+		wantErr    error
+		wantMsg    string
+	}{
+		{
+			name:       "empty",
+			giveConfig: "",
+		},
+		{
+			name:       "invalid yaml",
+			giveConfig: "faults: [",
+			wantErr:    ErrInvalidConfig,
+		},
+		{
+			name:       "unknown field",
+			giveConfig: "faults: [{percent: 0.5, injector: {type: reject}}]",
+			wantErr:    ErrInvalidConfig,
+		},
+		{
+			// the version is reported instead of the fields this version does not know
+			name:       "newer version",
+			giveConfig: "version: 2\nfaults: [{percent: 0.5, injector: {type: reject}}]",
+			wantErr:    ErrUnsupportedConfigVersion,
+			wantMsg:    "unsupported config version: 2, must be 1 to 1",
+		},
+		{
+			name:       "negative version",
+			giveConfig: "version: -1\nfaults: []",
+			wantErr:    ErrUnsupportedConfigVersion,
+		},
+		{
+			name:       "version not integer",
+			giveConfig: "version: one\nfaults: []",
+			wantErr:    ErrInvalidConfig,
+		},
+		{
+			name:       "unknown injector type",
+			giveConfig: "faults: [{injector: {type: blackhole}}]",
+			wantErr:    ErrUnknownInjectorType,
+			wantMsg:    `faults[0].injector.type: unknown injector type, got "blackhole"`,
+		},
+		{
+			name:       "missing injector",
+			giveConfig: "faults: [{name: nothing}]",
+			wantErr:    ErrUnknownInjectorType,
+		},
+		{
+			name:       "invalid participation",
+			giveConfig: "faults: [{participation: 2, injector: {type: reject}}]",
+			wantErr:    ErrInvalidPercent,
+			wantMsg:    "faults[0].participation: percent must be 0.0 <= percent <= 1.0, got 2",
+		},
+		{
+			name:       "injector error",
+			giveConfig: "faults: [{injector: {type: error}}]",
+			wantErr:    ErrInvalidHTTPCode,
+			wantMsg:    "faults[0].injector.params.code: not a valid http status code",
+		},
+		{
+			name:       "string not integer",
+			giveConfig: "faults: [{injector: {type: error, params: {code: '500'}}}]",
+			wantErr:    ErrInvalidConfig,
+			wantMsg:    `faults[0].injector.params.code: invalid config: must be an integer, got "500"`,
+		},
+		{
+			name:       "integer not string",
+			giveConfig: "faults: [{injector: {type: error, params: {code: 500, status_text: 1}}}]",
+			wantErr:    ErrInvalidConfig,
+		},
+		{
+			name:       "invalid headers",
+			giveConfig: "faults: [{injector: {type: error, params: {code: 500, headers: [a]}}}]",
+			wantErr:    ErrInvalidConfig,
+		},
+		{
+			name:       "invalid header value",
+			giveConfig: "faults: [{injector: {type: error, params: {code: 500, headers: {A: 1}}}}]",
+			wantErr:    ErrInvalidConfig,
+		},
+		{
+			name:       "invalid duration",
+			giveConfig: "faults: [{injector: {type: slow, params: {duration: fast}}}]",
+			wantErr:    ErrInvalidConfig,
+		},
+		{
+			name:       "number not duration",
+			giveConfig: "faults: [{injector: {type: slow, params: {duration: 5}}}]",
+			wantErr:    ErrInvalidConfig,
+		},
+		{
+			name:       "empty label",
+			giveConfig: "faults: [{labels: {'': checkout}, injector: {type: reject}}]",
+			wantErr:    ErrInvalidLabel,
+			wantMsg:    "faults[0].labels: label keys cannot be empty, got map[:checkout]",
+		},
+		{
+			name:       "negative max concurrent",
+			giveConfig: "faults: [{injector: {type: slow, params: {duration: 1s, max_concurrent: -1}}}]",
+			wantErr:    ErrInvalidMaxConcurrent,
+			wantMsg:    "faults[0].injector.params.max_concurrent: max concurrent must be >= 0, got -1",
+		},
+		{
+			name:       "invalid max concurrent",
+			giveConfig: "faults: [{injector: {type: slow, params: {duration: 1s, max_concurrent: many}}}]",
+			wantErr:    ErrInvalidConfig,
+		},
+		{
+			name:       "invalid phase",
+			giveConfig: "faults: [{injector: {type: slow, params: {duration: 1s, phase: after_body}}}]",
+			wantErr:    ErrInvalidConfig,
+		},
+		{
+			name:       "phase not string",
+			giveConfig: "faults: [{injector: {type: slow, params: {duration: 1s, phase: 1}}}]",
+			wantErr:    ErrInvalidConfig,
+		},
+		{
+			name:       "invalid close type",
+			giveConfig: "faults: [{injector: {type: reject, params: {close_type: slam}}}]",
+			wantErr:    ErrInvalidConfig,
+		},
+		{
+			name:       "close type not string",
+			giveConfig: "faults: [{injector: {type: reject, params: {close_type: 1}}}]",
+			wantErr:    ErrInvalidConfig,
+		},
+		{
+			name:       "chain without injectors",
+			giveConfig: "faults: [{injector: {type: chain}}]",
+			wantErr:    ErrNoInjectors,
+		},
+		{
+			name:       "injectors not list",
+			giveConfig: "faults: [{injector: {type: chain, params: {injectors: reject}}}]",
+			wantErr:    ErrInvalidConfig,
+		},
+		{
+			name:       "injector not map",
+			giveConfig: "faults: [{injector: {type: chain, params: {injectors: [reject]}}}]",
+			wantErr:    ErrInvalidConfig,
+			wantMsg: `faults[0].injector.params.injectors[0]: invalid config: ` +
+				`must be an injector with a type and params, got "reject"`,
+		},
+		{
+			name:       "injector unknown field",
+			giveConfig: "faults: [{injector: {type: chain, params: {injectors: [{type: reject, seed: 1}]}}}]",
+			wantErr:    ErrInvalidConfig,
+		},
+		{
+			name:       "injector type not string",
+			giveConfig: "faults: [{injector: {type: chain, params: {injectors: [{type: 1}]}}}]",
+			wantErr:    ErrInvalidConfig,
+		},
+		{
+			name:       "injector params not map",
+			giveConfig: "faults: [{injector: {type: chain, params: {injectors: [{type: slow, params: 1s}]}}}]",
+			wantErr:    ErrInvalidConfig,
+		},
+		{
+			name:       "injector params key not string",
+			giveConfig: "faults: [{injector: {type: chain, params: {injectors: [{type: slow, params: {1: 1s}}]}}}]",
+			wantErr:    ErrInvalidConfig,
+		},
+		{
+			name:       "stub invalid code",
+			giveConfig: "faults: [{injector: {type: stub, params: {code: 999}}}]",
+			wantErr:    ErrInvalidHTTPCode,
+			wantMsg:    `faults[0].injector.params.code: not a valid http status code, got 999`,
+		},
+		{
+			name:       "stub invalid body",
+			giveConfig: "faults: [{injector: {type: stub, params: {body: '{{.Path'}}}]",
+			wantErr:    ErrInvalidTemplate,
+		},
+		{
+			name:       "stub invalid status",
+			giveConfig: "faults: [{injector: {type: stub, params: {status: '{{end}}'}}}]",
+			wantErr:    ErrInvalidTemplate,
+		},
+		{
+			name:       "stub invalid header",
+			giveConfig: "faults: [{injector: {type: stub, params: {headers: {X-Id: '{{'}}}}]",
+			wantErr:    ErrInvalidTemplate,
+		},
+		{
+			name:       "stub body not string",
+			giveConfig: "faults: [{injector: {type: stub, params: {body: [1]}}}]",
+			wantErr:    ErrInvalidConfig,
+		},
+		{
+			name:       "nested unknown injector type",
+			giveConfig: "faults: [{injector: {type: random, params: {injectors: [{type: reject}, {type: blackhole}]}}}]",
+			wantErr:    ErrUnknownInjectorType,
+			wantMsg:    `faults[0].injector.params.injectors[1].type: unknown injector type, got "blackhole"`,
+		},
+		{
+			name:       "random without injectors",
+			giveConfig: "faults: [{injector: {type: random, params: {injectors: []}}}]",
+			wantErr:    ErrNoInjectors,
+		},
+		{
+			name:       "invalid random seed",
+			giveConfig: "faults: [{injector: {type: random, params: {seed: x, injectors: [{type: reject}]}}}]",
+			wantErr:    ErrInvalidConfig,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			faults, err := NewFaultsFromConfig(strings.NewReader(tt.giveConfig))
+
+			assert.True(t, errors.Is(err, tt.wantErr), err)
+			if tt.wantErr == nil {
+				assert.Empty(t, faults)
+				return
+			}
+
+			assert.Nil(t, faults)
+			if tt.wantMsg != "" {
+				assert.Equal(t, tt.wantMsg, err.Error())
+			}
+		})
+	}
+
+	_, err := NewFaultsFromConfig(testErrReader{})
+	assert.Equal(t, errTestRead, err)
+
+	_, err = NewFaultsFromConfig(strings.NewReader(testConfig), withError())
+	assert.Equal(t, errErrorOption, err)
+}
+
+// TestMigrateConfig tests that MigrateConfig writes a config that builds the same Faults in the
+// format of ConfigVersion.
+func TestMigrateConfig(t *testing.T) {
+	t.Parallel()
+
+	b, err := MigrateConfig(strings.NewReader(testConfig))
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(b), "version: 1\nfaults:\n- name: checkout-latency\n"), string(b))
+
+	want, err := decodeConfig([]byte(testConfig))
+	assert.NoError(t, err)
+	got, err := decodeConfig(b)
+	assert.NoError(t, err)
+	assert.Equal(t, want, got)
+
+	faults, err := NewFaultsFromConfig(strings.NewReader(string(b)))
+	assert.NoError(t, err)
+	assert.Len(t, faults, 6)
+
+	// migrating again changes nothing
+	again, err := MigrateConfig(strings.NewReader(string(b)))
+	assert.NoError(t, err)
+	assert.Equal(t, string(b), string(again))
+
+	// custom injector types need not be registered
+	b, err = MigrateConfig(strings.NewReader("faults: [{injector: {type: blackhole}}]"))
+	assert.NoError(t, err)
+	assert.Equal(t, "version: 1\nfaults:\n- injector:\n    type: blackhole\n", string(b))
+
+	_, err = MigrateConfig(strings.NewReader("version: 2"))
+	assert.True(t, errors.Is(err, ErrUnsupportedConfigVersion), err)
+
+	_, err = MigrateConfig(strings.NewReader("faults: [{percent: 0.5}]"))
+	assert.True(t, errors.Is(err, ErrInvalidConfig), err)
+
+	_, err = MigrateConfig(testErrReader{})
+	assert.Equal(t, errTestRead, err)
+}
+
+// TestInjectorRegistry tests registering custom injectors in an InjectorRegistry.
+func TestInjectorRegistry(t *testing.T) {
+	t.Parallel()
+
+	r := NewInjectorRegistry()
+	assert.Equal(t, []string{"chain", "error", "random", "reject", "slow", "stub"}, r.Types())
+
+	// a custom injector reads every type of parameter
+	err := r.Register("jitter", func(p ConfigParams) (Injector, error) {
+		ratio, err := p.Float("ratio", 0.1)
+		if err != nil {
+			return nil, err
+		}
+		enabled, err := p.Bool("enabled", true)
+		if err != nil {
+			return nil, err
+		}
+		d, err := p.Duration("base", time.Second)
+		if err != nil {
+			return nil, err
+		}
+		if !enabled {
+			return newTestInjectorNoop(), nil
+		}
+
+		return NewSlowInjector(d + time.Duration(ratio*float64(d)))
+	})
+	assert.NoError(t, err)
+
+	err = r.Register("jitter", func(p ConfigParams) (Injector, error) { return nil, nil })
+	assert.True(t, errors.Is(err, ErrDuplicateInjectorType), err)
+	assert.Equal(t, ErrNilInjectorFactory, r.Register("nil", nil))
+	assert.Equal(t, []string{"chain", "error", "jitter", "random", "reject", "slow", "stub"}, r.Types())
+
+	tests := []struct {
+		name         string
+		giveParams   string
+		wantInjector string
+		wantErr      error
+	}{
+		{
+			name:         "defaults",
+			giveParams:   "{}",
+			wantInjector: "slow(1.1s)",
+		},
+		{
+			name:         "integer ratio",
+			giveParams:   "{ratio: 1, base: 1s}",
+			wantInjector: "slow(2s)",
+		},
+		{
+			name:         "float ratio",
+			giveParams:   "{ratio: 0.5, base: 2s}",
+			wantInjector: "slow(3s)",
+		},
+		{
+			name:         "disabled",
+			giveParams:   "{enabled: false}",
+			wantInjector: "testInjectorNoop",
+		},
+		{
+			name:       "invalid ratio",
+			giveParams: "{ratio: high}",
+			wantErr:    ErrInvalidConfig,
+		},
+		{
+			name:       "invalid enabled",
+			giveParams: "{enabled: 1}",
+			wantErr:    ErrInvalidConfig,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			faults, err := NewFaultsFromConfig(
+				strings.NewReader("faults: [{injector: {type: jitter, params: "+tt.giveParams+"}}]"),
+				WithInjectorRegistry(r),
+			)
+
+			assert.True(t, errors.Is(err, tt.wantErr), err)
+			if tt.wantErr == nil {
+				assert.Equal(t, tt.wantInjector, InjectorString(faults[0].Injector()))
+			}
+		})
+	}
+
+	// the default registry does not know the custom injector
+	_, err = NewFaultsFromConfig(strings.NewReader("faults: [{injector: {type: jitter}}]"))
+	assert.True(t, errors.Is(err, ErrUnknownInjectorType), err)
+}
+
+// TestInjectorRegistryRegister tests that InjectorRegistry.Register adds injector types to the
+// configs given the registry only.
+func TestInjectorRegistryRegister(t *testing.T) {
+	t.Parallel()
+
+	r := NewInjectorRegistry()
+	err := r.Register("test-register-injector", func(p ConfigParams) (Injector, error) {
+		return newTestInjectorNoop(), nil
+	})
+	assert.NoError(t, err)
+
+	config := "faults: [{injector: {type: test-register-injector}}]"
+	faults, err := NewFaultsFromConfig(strings.NewReader(config), WithInjectorRegistry(r))
+	assert.NoError(t, err)
+	assert.Equal(t, "testInjectorNoop", InjectorString(faults[0].Injector()))
+
+	_, err = NewFaultsFromConfig(strings.NewReader(config))
+	assert.True(t, errors.Is(err, ErrUnknownInjectorType), err)
+
+	err = r.Register("slow", func(p ConfigParams) (Injector, error) { return nil, nil })
+	assert.True(t, errors.Is(err, ErrDuplicateInjectorType), err)
+}
@@ -0,0 +1,63 @@
+package fault
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"sync/atomic"
+)
+
+// connIDKey is the context key of the ID of the connection a request arrived on.
+type connIDKey struct{}
+
+// connIDs numbers the connections passed to a function returned by NewConnContext.
+type connIDs struct {
+	// n is the number of connections numbered so far.
+	n uint64
+}
+
+// NewConnContext returns a function that returns ctx with a new ID for the connection c. Set it as
+// the ConnContext of an http.Server so that ConnKey tells its connections apart, even when a client
+// reuses the port of a closed connection. The IDs of each function returned are prefixed with its
+// own, so servers sharing a Fault do not share keys. Instrument sets it for you.
+//
+//	srv := &http.Server{Handler: f.Handler(mux), ConnContext: fault.NewConnContext()}
+func NewConnContext() func(ctx context.Context, c net.Conn) context.Context {
+	ids := &connIDs{}
+
+	return func(ctx context.Context, c net.Conn) context.Context {
+		return context.WithValue(ctx, connIDKey{}, fmt.Sprintf("%p-%d", ids, atomic.AddUint64(&ids.n, 1)))
+	}
+}
+
+// ConnKey returns the connection r arrived on as a key for WithParticipationKey: the ID set by
+// NewConnContext, or else the local and remote addresses of the connection, or "" if r has neither.
+// Every request multiplexed over the same connection, such as the streams of an HTTP/2 connection,
+// has the same key.
+func ConnKey(r *http.Request) string {
+	if id, ok := r.Context().Value(connIDKey{}).(string); ok {
+		return "conn-" + id
+	}
+
+	addr, ok := r.Context().Value(http.LocalAddrContextKey).(net.Addr)
+	if !ok && r.RemoteAddr == "" {
+		return ""
+	}
+
+	local := ""
+	if ok {
+		local = addr.String()
+	}
+
+	return local + "-" + r.RemoteAddr
+}
+
+// WithConnectionDecision makes the participation decision of the Fault once per client connection
+// instead of once per request, so a connection either gets the Injector on every request or never
+// does. It simulates a bad backend connection that consistently misbehaves for all the requests
+// sent over it, while other connections of the same client are fine. It is WithParticipationKey()
+// with ConnKey, so set ConnContext on the http.Server to tell apart connections that reuse a port.
+func WithConnectionDecision() Option {
+	return participationKeyOption(ConnKey)
+}
@@ -0,0 +1,109 @@
+package fault
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestConnKey tests ConnKey.
+func TestConnKey(t *testing.T) {
+	t.Parallel()
+
+	local := &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 8080}
+
+	tests := []struct {
+		name       string
+		giveCtx    context.Context
+		giveRemote string
+		want       string
+	}{
+		{
+			name:       "addresses",
+			giveCtx:    context.WithValue(context.Background(), http.LocalAddrContextKey, local),
+			giveRemote: "192.0.2.1:1234",
+			want:       "127.0.0.1:8080-192.0.2.1:1234",
+		},
+		{
+			name:       "remote address",
+			giveCtx:    context.Background(),
+			giveRemote: "192.0.2.1:1234",
+			want:       "-192.0.2.1:1234",
+		},
+		{
+			name:    "none",
+			giveCtx: context.Background(),
+			want:    "",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(tt.giveCtx)
+			r.RemoteAddr = tt.giveRemote
+
+			assert.Equal(t, tt.want, ConnKey(r))
+		})
+	}
+}
+
+// TestNewConnContext tests that NewConnContext gives each connection its own ConnKey, across
+// servers too.
+func TestNewConnContext(t *testing.T) {
+	t.Parallel()
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	connContext, otherConnContext := NewConnContext(), NewConnContext()
+	first := ConnKey(r.WithContext(connContext(context.Background(), nil)))
+	second := ConnKey(r.WithContext(connContext(context.Background(), nil)))
+	other := ConnKey(r.WithContext(otherConnContext(context.Background(), nil)))
+
+	assert.True(t, strings.HasPrefix(first, "conn-"))
+	assert.True(t, strings.HasPrefix(second, "conn-"))
+	assert.NotEqual(t, first, second)
+	assert.NotEqual(t, first, other)
+}
+
+// TestWithConnectionDecision tests that every request on a connection gets the same decision.
+func TestWithConnectionDecision(t *testing.T) {
+	t.Parallel()
+
+	f, err := NewFault(newTestInjector500s(), WithEnabled(true), WithParticipation(0.5), WithConnectionDecision())
+	assert.NoError(t, err)
+
+	s := httptest.NewUnstartedServer(f.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(testHandlerCode)
+	})))
+	s.Config.ConnContext = NewConnContext()
+	s.Start()
+	defer s.Close()
+
+	codes := map[int]int{}
+	for conn := 0; conn < 20; conn++ {
+		client := &http.Client{Transport: &http.Transport{MaxConnsPerHost: 1}}
+
+		var connCodes []int
+		for n := 0; n < 5; n++ {
+			resp, err := client.Get(s.URL)
+			assert.NoError(t, err)
+			resp.Body.Close()
+			connCodes = append(connCodes, resp.StatusCode)
+		}
+		client.CloseIdleConnections()
+
+		for _, code := range connCodes {
+			assert.Equal(t, connCodes[0], code)
+		}
+		codes[connCodes[0]]++
+	}
+
+	assert.Len(t, codes, 2)
+}
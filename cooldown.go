@@ -0,0 +1,110 @@
+package fault
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrInvalidCooldown when a cooldown period is not positive.
+	ErrInvalidCooldown = errors.New("cooldown must be > 0")
+)
+
+// cooldown exempts the clients a Fault ran its Injector on from it for a period, so a low
+// participation experiment spreads over many clients instead of hitting one unlucky client
+// repeatedly.
+type cooldown struct {
+	period time.Duration
+	keyF   func(r *http.Request) string
+	clock  Clock
+
+	mtx sync.Mutex
+	// until holds when the cooldown of each client ends.
+	until map[string]time.Time
+	// sweepAt is the number of clients at which clients whose cooldown ended are next removed.
+	sweepAt int
+}
+
+type cooldownOption struct {
+	period time.Duration
+	keyF   func(r *http.Request) string
+}
+
+func (o cooldownOption) applyFault(f *Fault) error {
+	if o.period <= 0 {
+		return ErrInvalidCooldown
+	}
+
+	keyF := o.keyF
+	if keyF == nil {
+		keyF = clientIP
+	}
+
+	f.cooldown = &cooldown{
+		period: o.period,
+		keyF:   keyF,
+	}
+
+	return nil
+}
+
+// WithCooldown exempts a client from the Injector for d after the Injector runs on one of its
+// requests. key returns the client a request belongs to, such as its session; requests with an
+// empty key have no cooldown. A nil key uses the IP of the client. The cooldown is timed with the
+// Clock set by WithClock().
+func WithCooldown(d time.Duration, key func(r *http.Request) string) Option {
+	return cooldownOption{period: d, keyF: key}
+}
+
+func (o clockOption) applyFault(f *Fault) error {
+	f.clock = o.clock
+	return nil
+}
+
+// active returns true if the client with key is cooling down.
+func (c *cooldown) active(key string) bool {
+	if key == "" {
+		return false
+	}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	return c.clock.Now().Before(c.until[key])
+}
+
+// start starts the cooldown of the client with key and returns true, or returns false if it is
+// already cooling down, such as when another of its requests started it first.
+func (c *cooldown) start(key string) bool {
+	if key == "" {
+		return true
+	}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	now := c.clock.Now()
+	if now.Before(c.until[key]) {
+		return false
+	}
+
+	if c.until == nil {
+		c.until = make(map[string]time.Time)
+	}
+	c.until[key] = now.Add(c.period)
+
+	// remove clients whose cooldown ended whenever the number of clients doubles, so the map does
+	// not grow with every client ever seen
+	if len(c.until) >= c.sweepAt {
+		for k, t := range c.until {
+			if !now.Before(t) {
+				delete(c.until, k)
+			}
+		}
+		c.sweepAt = 2 * len(c.until)
+	}
+
+	return true
+}
@@ -0,0 +1,214 @@
+package fault
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/github/go-fault/faulttest"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestWithCooldown tests the options of WithCooldown.
+func TestWithCooldown(t *testing.T) {
+	t.Parallel()
+
+	clock := faulttest.NewClock(time.Time{})
+
+	tests := []struct {
+		name        string
+		giveOptions []Option
+		wantPeriod  time.Duration
+		wantKey     string
+		wantClock   Clock
+		wantErr     error
+	}{
+		{
+			name:        "client ip",
+			giveOptions: []Option{WithCooldown(time.Minute, nil)},
+			wantPeriod:  time.Minute,
+			wantKey:     "192.0.2.1",
+			wantClock:   NewRealClock(),
+		},
+		{
+			name: "key func and clock",
+			giveOptions: []Option{
+				WithCooldown(time.Hour, func(r *http.Request) string { return r.Header.Get("X-Session") }),
+				WithClock(clock),
+			},
+			wantPeriod: time.Hour,
+			wantKey:    "abc",
+			wantClock:  clock,
+		},
+		{
+			name:        "zero period",
+			giveOptions: []Option{WithCooldown(0, nil)},
+			wantErr:     ErrInvalidCooldown,
+		},
+		{
+			name:        "negative period",
+			giveOptions: []Option{WithCooldown(-time.Minute, nil)},
+			wantErr:     ErrInvalidCooldown,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			f, err := NewFault(newTestInjectorNoop(), tt.giveOptions...)
+
+			assert.Equal(t, tt.wantErr, err)
+			if tt.wantErr != nil {
+				assert.Nil(t, f)
+				return
+			}
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header.Set("X-Session", "abc")
+
+			assert.Equal(t, tt.wantPeriod, f.cooldown.period)
+			assert.Equal(t, tt.wantKey, f.cooldown.keyF(r))
+			assert.Equal(t, tt.wantClock, f.cooldown.clock)
+		})
+	}
+}
+
+// TestFaultHandlerCooldown tests that a Fault with a cooldown does not run its Injector on a client
+// again until the cooldown of the client ends.
+func TestFaultHandlerCooldown(t *testing.T) {
+	t.Parallel()
+
+	clock := faulttest.NewClock(time.Time{})
+	f, err := NewFault(newTestInjector500s(),
+		WithEnabled(true),
+		WithParticipation(1.0),
+		WithCooldown(time.Minute, func(r *http.Request) string { return r.Header.Get("X-Client") }),
+		WithClock(clock),
+	)
+	assert.NoError(t, err)
+
+	h := f.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(testHandlerCode)
+	}))
+	serve := func(client string) int {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("X-Client", client)
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, r)
+		return rr.Code
+	}
+
+	assert.Equal(t, http.StatusInternalServerError, serve("a"))
+	assert.Equal(t, testHandlerCode, serve("a"))
+	assert.Equal(t, http.StatusInternalServerError, serve("b"))
+
+	// requests without a client have no cooldown
+	assert.Equal(t, http.StatusInternalServerError, serve(""))
+	assert.Equal(t, http.StatusInternalServerError, serve(""))
+
+	clock.Advance(59 * time.Second)
+	assert.Equal(t, testHandlerCode, serve("a"))
+
+	clock.Advance(time.Second)
+	assert.Equal(t, http.StatusInternalServerError, serve("a"))
+	assert.Equal(t, testHandlerCode, serve("a"))
+
+	assert.Equal(t, int64(3), f.stats.counters()["skipped_cooldown"])
+}
+
+// TestFaultServeCooldownStarted tests that a request evaluated before another request of its client
+// started the cooldown is skipped.
+func TestFaultServeCooldownStarted(t *testing.T) {
+	t.Parallel()
+
+	reporter := &testStateReporter{states: make(chan InjectorState, 2)}
+	f, err := NewFault(newTestInjector500s(),
+		WithEnabled(true),
+		WithParticipation(1.0),
+		WithCooldown(time.Minute, nil),
+		WithClock(faulttest.NewClock(time.Time{})),
+		WithReporter(reporter),
+	)
+	assert.NoError(t, err)
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(testHandlerCode)
+	})
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	st := f.injector.Load()
+
+	ev1, ev2 := f.evaluate(r, st), f.evaluate(r, st)
+	assert.True(t, ev1.Injected)
+	assert.True(t, ev2.Injected)
+
+	rr := httptest.NewRecorder()
+	f.serve(rr, r, next, st, ev1)
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+
+	rr = httptest.NewRecorder()
+	f.serve(rr, r, next, st, ev2)
+	assert.Equal(t, testHandlerCode, rr.Code)
+
+	states := map[InjectorState]int{}
+	for n := 0; n < 2; n++ {
+		states[<-reporter.states]++
+	}
+	assert.Equal(t, map[InjectorState]int{StateSelected: 1, StateSkipped: 1}, states)
+	assert.Equal(t, int64(1), f.stats.counters()["skipped_cooldown"])
+}
+
+// TestExplainRequestCooldown tests that ExplainRequest explains requests of clients in their
+// cooldown.
+func TestExplainRequestCooldown(t *testing.T) {
+	t.Parallel()
+
+	f, err := NewFault(newTestInjectorNoop(),
+		WithName("f"),
+		WithEnabled(true),
+		WithParticipation(1.0),
+		WithCooldown(time.Minute, nil),
+		WithClock(faulttest.NewClock(time.Time{})),
+	)
+	assert.NoError(t, err)
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	assert.True(t, ExplainRequest(f, r).Eligible)
+
+	f.Handler(http.NotFoundHandler()).ServeHTTP(httptest.NewRecorder(), r)
+
+	assert.Equal(t, Explanation{
+		Fault:         "f",
+		Injector:      "testInjectorNoop",
+		Enabled:       true,
+		Matched:       true,
+		Participation: 1.0,
+		SkipReason:    SkipCooldown,
+	}, ExplainRequest(f, r))
+}
+
+// TestCooldownSweep tests that clients whose cooldown ended are removed as clients are added.
+func TestCooldownSweep(t *testing.T) {
+	t.Parallel()
+
+	clock := faulttest.NewClock(time.Time{})
+	c := &cooldown{period: time.Minute, clock: clock}
+
+	assert.True(t, c.start("a"))
+	assert.True(t, c.start("b"))
+	assert.False(t, c.start("a"))
+	assert.Len(t, c.until, 2)
+
+	clock.Advance(time.Minute)
+
+	// "a" and "b" are removed once the number of clients doubles
+	assert.True(t, c.start("c"))
+	assert.Len(t, c.until, 3)
+	assert.True(t, c.start("d"))
+	assert.Len(t, c.until, 2)
+	assert.False(t, c.active("a"))
+	assert.True(t, c.active("c"))
+	assert.True(t, c.active("d"))
+}
@@ -0,0 +1,133 @@
+package fault
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// DecisionInjected is the Decision.Decision of a Fault that ran its Injector.
+const DecisionInjected = "injected"
+
+// decisionsKey is the context key of a request's Decisions.
+type decisionsKey struct{}
+
+// Decision is a compact record of what a Fault decided for a request, meant to be embedded in
+// access-log lines so that log-based analysis can join faults with request outcomes.
+type Decision struct {
+	// Fault is the name of the Fault.
+	Fault string `json:"fault"`
+	// Injector is the name of the Injector of the Fault.
+	Injector string `json:"injector"`
+	// Decision is DecisionInjected if the Injector ran, or else the SkipReason it did not.
+	Decision string `json:"decision"`
+	// Latency is how long the Injector held the request before passing it on, or how long it ran
+	// if it never did. Zero if the Injector did not run.
+	Latency time.Duration `json:"latency"`
+}
+
+// String returns the Decision in logfmt, such as
+// "fault=checkout injector=slow decision=injected latency=2s".
+func (d Decision) String() string {
+	return "fault=" + strconv.Quote(d.Fault) + " injector=" + strconv.Quote(d.Injector) +
+		" decision=" + d.Decision + " latency=" + d.Latency.String()
+}
+
+// LogValue returns the Decision as a group for log/slog.
+func (d Decision) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("fault", d.Fault),
+		slog.String("injector", d.Injector),
+		slog.String("decision", d.Decision),
+		slog.Duration("latency", d.Latency),
+	)
+}
+
+// decisions is the append-only list of Decisions made for a request.
+type decisions struct {
+	mtx  sync.Mutex
+	list []Decision
+}
+
+// add appends d.
+func (ds *decisions) add(d Decision) {
+	ds.mtx.Lock()
+	ds.list = append(ds.list, d)
+	ds.mtx.Unlock()
+}
+
+// ContextWithDecisions returns a copy of ctx that collects the Decision of every Fault that
+// evaluates a request with it. Call it in your access-log middleware, before the Faults, and read
+// the Decisions back with DecisionsFromContext once the request is served.
+//
+//	r = r.WithContext(fault.ContextWithDecisions(r.Context()))
+//	next.ServeHTTP(w, r)
+//	logger.Info("request", "path", r.URL.Path, "faults", fault.DecisionsFromContext(r.Context()))
+func ContextWithDecisions(ctx context.Context) context.Context {
+	return context.WithValue(ctx, decisionsKey{}, &decisions{})
+}
+
+// DecisionsFromContext returns the Decisions of the Faults that evaluated the request with context
+// ctx, in the order they were made. It returns nil if ctx was not made by ContextWithDecisions or
+// no Fault has evaluated the request.
+func DecisionsFromContext(ctx context.Context) []Decision {
+	ds, ok := ctx.Value(decisionsKey{}).(*decisions)
+	if !ok {
+		return nil
+	}
+
+	ds.mtx.Lock()
+	defer ds.mtx.Unlock()
+
+	if len(ds.list) == 0 {
+		return nil
+	}
+
+	return append([]Decision(nil), ds.list...)
+}
+
+// requestDecisions returns the Decisions collected for r, or nil if r does not collect them.
+func requestDecisions(r *http.Request) *decisions {
+	ds, _ := r.Context().Value(decisionsKey{}).(*decisions)
+	return ds
+}
+
+// newDecision returns the Decision of ev, without its latency.
+func newDecision(ev Evaluation) Decision {
+	d := Decision{
+		Fault:    ev.Fault,
+		Injector: ev.Injector,
+		Decision: string(ev.SkipReason),
+	}
+	if ev.Injected {
+		d.Decision = DecisionInjected
+	}
+
+	return d
+}
+
+// inject returns a handler that runs i on next like recordInjector and adds d to ds, with the
+// latency i added, once i passes the request on to next, or once i returns or panics if it never
+// does.
+func (ds *decisions) inject(d Decision, i Injector, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		var once sync.Once
+		add := func() {
+			once.Do(func() {
+				d.Latency = time.Since(start)
+				ds.add(d)
+			})
+		}
+		defer add()
+
+		recordInjector(i, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			add()
+			next.ServeHTTP(w, r)
+		})).ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,35 @@
+package fault
+
+import (
+	"errors"
+	"net/http"
+)
+
+var (
+	// ErrNilDecisionHook when a nil decision hook is passed.
+	ErrNilDecisionHook = errors.New("decision hook cannot be nil")
+)
+
+type decisionHookOption func(r *http.Request, willInject bool) bool
+
+func (o decisionHookOption) applyFault(f *Fault) error {
+	if o == nil {
+		return ErrNilDecisionHook
+	}
+
+	f.decisionHook = o
+
+	return nil
+}
+
+// WithDecisionHook calls hook once the Fault has decided whether to run its Injector on a request
+// by participation, with willInject true if it would. Returning false vetoes the injection, and the
+// request is skipped with SkipVeto; returning true keeps the decision, so the hook cannot inject a
+// request the Fault would skip. Use it for policies the RequestMatchers cannot express, such as
+// skipping requests while a dependency is already degraded. Requests the Fault skips before the
+// participation roll, and requests an Override forces or suppresses, do not call hook, and
+// ExplainRequest does not call it. hook runs on the goroutine handling the request, so it must be
+// fast and safe for concurrent use.
+func WithDecisionHook(hook func(r *http.Request, willInject bool) bool) Option {
+	return decisionHookOption(hook)
+}
@@ -0,0 +1,103 @@
+package fault
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestWithDecisionHook tests WithDecisionHook.
+func TestWithDecisionHook(t *testing.T) {
+	t.Parallel()
+
+	f, err := NewFault(newTestInjectorNoop(), WithDecisionHook(nil))
+	assert.Equal(t, ErrNilDecisionHook, err)
+	assert.Nil(t, f)
+}
+
+// TestFaultHandlerDecisionHook tests that the decision hook of a Fault sees the participation
+// decision and can veto injections, but not force them.
+func TestFaultHandlerDecisionHook(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name              string
+		giveEnabled       bool
+		giveParticipation float32
+		giveHook          func(r *http.Request, willInject bool) bool
+		wantCalls         []bool
+		wantCode          int
+		wantSkipReason    SkipReason
+	}{
+		{
+			name:              "keep injection",
+			giveEnabled:       true,
+			giveParticipation: 1.0,
+			giveHook:          func(r *http.Request, willInject bool) bool { return willInject },
+			wantCalls:         []bool{true},
+			wantCode:          http.StatusInternalServerError,
+		},
+		{
+			name:              "veto",
+			giveEnabled:       true,
+			giveParticipation: 1.0,
+			giveHook: func(r *http.Request, willInject bool) bool {
+				return !strings.HasPrefix(r.Header.Get("User-Agent"), "ELB-HealthChecker")
+			},
+			wantCalls:      []bool{true},
+			wantCode:       testHandlerCode,
+			wantSkipReason: SkipVeto,
+		},
+		{
+			name:              "cannot force",
+			giveEnabled:       true,
+			giveParticipation: 0.0,
+			giveHook:          func(r *http.Request, willInject bool) bool { return true },
+			wantCalls:         []bool{false},
+			wantCode:          testHandlerCode,
+			wantSkipReason:    SkipParticipation,
+		},
+		{
+			name:              "not called when disabled",
+			giveEnabled:       false,
+			giveParticipation: 1.0,
+			giveHook:          func(r *http.Request, willInject bool) bool { return false },
+			wantCode:          testHandlerCode,
+			wantSkipReason:    SkipDisabled,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var calls []bool
+			f, err := NewFault(newTestInjector500s(),
+				WithEnabled(tt.giveEnabled),
+				WithParticipation(tt.giveParticipation),
+				WithDecisionHook(func(r *http.Request, willInject bool) bool {
+					calls = append(calls, willInject)
+					return tt.giveHook(r, willInject)
+				}),
+			)
+			assert.NoError(t, err)
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("User-Agent", "ELB-HealthChecker/2.0")
+			rr := httptest.NewRecorder()
+			f.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				http.Error(w, testHandlerBody, testHandlerCode)
+			})).ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.wantCode, rr.Code)
+			assert.Equal(t, tt.wantCalls, calls)
+			if tt.wantSkipReason != "" {
+				assert.Equal(t, map[SkipReason]int64{tt.wantSkipReason: 1}, f.Stats().SkippedBy)
+			}
+		})
+	}
+}
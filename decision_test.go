@@ -0,0 +1,140 @@
+package fault
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestDecisionsFromContext tests that Faults record their Decisions in a context made by
+// ContextWithDecisions.
+func TestDecisionsFromContext(t *testing.T) {
+	t.Parallel()
+
+	slow, err := NewSlowInjector(10 * time.Millisecond)
+	assert.NoError(t, err)
+	ri, err := NewRejectInjector()
+	assert.NoError(t, err)
+
+	newFault := func(i Injector, opts ...Option) *Fault {
+		f, err := NewFault(i, opts...)
+		assert.NoError(t, err)
+		return f
+	}
+
+	tests := []struct {
+		name        string
+		giveFaults  []*Fault
+		giveCollect bool
+		want        []Decision
+		wantPanic   bool
+	}{
+		{
+			name: "not collected",
+			giveFaults: []*Fault{
+				newFault(slow, WithName("slow"), WithEnabled(true), WithParticipation(1.0)),
+			},
+			giveCollect: false,
+			want:        nil,
+		},
+		{
+			name:        "no faults",
+			giveFaults:  nil,
+			giveCollect: true,
+			want:        nil,
+		},
+		{
+			name: "skipped and injected",
+			giveFaults: []*Fault{
+				newFault(ri, WithName("disabled")),
+				newFault(slow, WithName("slow"), WithEnabled(true), WithParticipation(1.0)),
+				newFault(ri, WithName("unmatched"), WithEnabled(true), WithPathBlocklist([]string{"/"})),
+			},
+			giveCollect: true,
+			want: []Decision{
+				{Fault: "disabled", Injector: "reject", Decision: "disabled"},
+				{Fault: "slow", Injector: "slow(10ms)", Decision: DecisionInjected, Latency: 10 * time.Millisecond},
+				{Fault: "unmatched", Injector: "reject", Decision: "unmatched"},
+			},
+		},
+		{
+			name: "never passed on",
+			giveFaults: []*Fault{
+				newFault(ri, WithName("reject"), WithEnabled(true), WithParticipation(1.0)),
+			},
+			giveCollect: true,
+			want: []Decision{
+				{Fault: "reject", Injector: "reject", Decision: DecisionInjected},
+			},
+			wantPanic: true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var h http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(testHandlerCode)
+			})
+			for idx := len(tt.giveFaults) - 1; idx >= 0; idx-- {
+				h = tt.giveFaults[idx].Handler(h)
+			}
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.giveCollect {
+				r = r.WithContext(ContextWithDecisions(r.Context()))
+			}
+
+			serve := func() { h.ServeHTTP(httptest.NewRecorder(), r) }
+			if tt.wantPanic {
+				assert.Panics(t, serve)
+			} else {
+				serve()
+			}
+
+			got := DecisionsFromContext(r.Context())
+			assert.Len(t, got, len(tt.want))
+			for idx := range got {
+				assert.Equal(t, tt.want[idx].Fault, got[idx].Fault)
+				assert.Equal(t, tt.want[idx].Injector, got[idx].Injector)
+				assert.Equal(t, tt.want[idx].Decision, got[idx].Decision)
+				assert.GreaterOrEqual(t, int64(got[idx].Latency), int64(tt.want[idx].Latency))
+			}
+		})
+	}
+}
+
+// TestDecisionEncoding tests the encodings of a Decision.
+func TestDecisionEncoding(t *testing.T) {
+	t.Parallel()
+
+	d := Decision{Fault: "checkout", Injector: "slow", Decision: DecisionInjected, Latency: 2 * time.Second}
+
+	assert.Equal(t, `fault="checkout" injector="slow" decision=injected latency=2s`, d.String())
+
+	b, err := json.Marshal(d)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"fault": "checkout", "injector": "slow", "decision": "injected", "latency": 2000000000}`, string(b))
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey && len(groups) == 0 {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+	logger.InfoContext(context.Background(), "request", "decision", d)
+	assert.Equal(t, "level=INFO msg=request decision.fault=checkout decision.injector=slow "+
+		"decision.decision=injected decision.latency=2s\n", buf.String())
+}
@@ -0,0 +1,174 @@
+package fault
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+const (
+	// EnvRegion is the environment variable DeploymentFromEnv reads the region from.
+	EnvRegion = "FAULT_REGION"
+	// EnvZone is the environment variable DeploymentFromEnv reads the zone from.
+	EnvZone = "FAULT_ZONE"
+
+	// ec2MetadataURL is the base URL of the EC2 instance metadata service.
+	ec2MetadataURL = "http://169.254.169.254"
+	// gceMetadataURL is the base URL of the Compute Engine metadata server.
+	gceMetadataURL = "http://metadata.google.internal"
+
+	// maxMetadataSize is how much of a metadata response is read.
+	maxMetadataSize = 4 << 10
+)
+
+var (
+	// ErrMetadata when a metadata service does not return the deployment of the instance.
+	ErrMetadata = errors.New("metadata service request failed")
+)
+
+// Deployment is where the instance serving requests runs. Faults limited to a zone or region with
+// MatchZone() and MatchRegion() emulate a zonal or regional outage from inside the application.
+type Deployment struct {
+	// Region is the region of the instance, such as "us-east-1".
+	Region string `json:"region"`
+	// Zone is the availability zone of the instance, such as "us-east-1a".
+	Zone string `json:"zone"`
+}
+
+// DeploymentFromEnv returns the Deployment set in the EnvRegion and EnvZone environment variables.
+// The region falls back to AWS_REGION, which AWS sets in Lambda and ECS, when EnvRegion is empty.
+// In Kubernetes, set them from the topology labels of the node with the downward API.
+func DeploymentFromEnv() Deployment {
+	return deploymentFromEnv(os.Getenv)
+}
+
+// deploymentFromEnv is DeploymentFromEnv with the environment read by getenv.
+func deploymentFromEnv(getenv func(string) string) Deployment {
+	d := Deployment{
+		Region: getenv(EnvRegion),
+		Zone:   getenv(EnvZone),
+	}
+	if d.Region == "" {
+		d.Region = getenv("AWS_REGION")
+	}
+
+	return d
+}
+
+// DeploymentFromEC2 returns the Deployment of the EC2 instance it runs on from the instance metadata
+// service, using IMDSv2. A nil client uses http.DefaultClient. Off EC2 the metadata service cannot
+// be reached, so pass a ctx with a short timeout.
+func DeploymentFromEC2(ctx context.Context, client *http.Client) (Deployment, error) {
+	return deploymentFromEC2(ctx, client, ec2MetadataURL)
+}
+
+// deploymentFromEC2 is DeploymentFromEC2 with the metadata service at baseURL.
+func deploymentFromEC2(ctx context.Context, client *http.Client, baseURL string) (Deployment, error) {
+	token, err := getMetadata(ctx, client, http.MethodPut, baseURL+"/latest/api/token",
+		http.Header{"X-Aws-Ec2-Metadata-Token-Ttl-Seconds": {"60"}})
+	if err != nil {
+		return Deployment{}, err
+	}
+
+	header := http.Header{"X-Aws-Ec2-Metadata-Token": {token}}
+
+	region, err := getMetadata(ctx, client, http.MethodGet, baseURL+"/latest/meta-data/placement/region", header)
+	if err != nil {
+		return Deployment{}, err
+	}
+
+	zone, err := getMetadata(ctx, client, http.MethodGet, baseURL+"/latest/meta-data/placement/availability-zone", header)
+	if err != nil {
+		return Deployment{}, err
+	}
+
+	return Deployment{Region: region, Zone: zone}, nil
+}
+
+// DeploymentFromGCE returns the Deployment of the Compute Engine instance or GKE node it runs on
+// from the metadata server. The region is the zone without its last part, like "us-central1" for
+// "us-central1-a". A nil client uses http.DefaultClient. Off Google Cloud the metadata server cannot
+// be reached, so pass a ctx with a short timeout.
+func DeploymentFromGCE(ctx context.Context, client *http.Client) (Deployment, error) {
+	return deploymentFromGCE(ctx, client, gceMetadataURL)
+}
+
+// deploymentFromGCE is DeploymentFromGCE with the metadata server at baseURL.
+func deploymentFromGCE(ctx context.Context, client *http.Client, baseURL string) (Deployment, error) {
+	// the zone is returned as "projects/<number>/zones/<zone>"
+	zone, err := getMetadata(ctx, client, http.MethodGet, baseURL+"/computeMetadata/v1/instance/zone",
+		http.Header{"Metadata-Flavor": {"Google"}})
+	if err != nil {
+		return Deployment{}, err
+	}
+	zone = zone[strings.LastIndex(zone, "/")+1:]
+
+	var region string
+	if idx := strings.LastIndex(zone, "-"); idx > 0 {
+		region = zone[:idx]
+	}
+
+	return Deployment{Region: region, Zone: zone}, nil
+}
+
+// getMetadata returns the trimmed body of a metadata request.
+func getMetadata(ctx context.Context, client *http.Client, method, url string, header http.Header) (string, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header = header
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%w: %s %s: %s", ErrMetadata, method, req.URL.Path, resp.Status)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxMetadataSize))
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(body)), nil
+}
+
+// MatchZone returns a RequestMatcher that matches every request if d is in any of zones, compared
+// case-insensitively, and no request otherwise. Use it to limit a Fault to the instances in a
+// single zone. A Deployment without a zone never matches.
+func MatchZone(d Deployment, zones ...string) RequestMatcher {
+	return matchDeployment(d.Zone, zones)
+}
+
+// MatchRegion returns a RequestMatcher that matches every request if d is in any of regions,
+// compared case-insensitively, and no request otherwise. A Deployment without a region never
+// matches.
+func MatchRegion(d Deployment, regions ...string) RequestMatcher {
+	return matchDeployment(d.Region, regions)
+}
+
+// matchDeployment returns a RequestMatcher that matches every request if have is one of want.
+func matchDeployment(have string, want []string) RequestMatcher {
+	var match bool
+	for _, w := range want {
+		if have != "" && strings.EqualFold(have, w) {
+			match = true
+		}
+	}
+
+	return RequestMatcherFunc(func(r *http.Request) bool {
+		return match
+	})
+}
@@ -0,0 +1,271 @@
+package fault
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestDeploymentFromEnv tests DeploymentFromEnv.
+func TestDeploymentFromEnv(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		giveEnv map[string]string
+		want    Deployment
+	}{
+		{
+			name:    "empty",
+			giveEnv: map[string]string{},
+			want:    Deployment{},
+		},
+		{
+			name:    "region and zone",
+			giveEnv: map[string]string{EnvRegion: "us-east-1", EnvZone: "us-east-1a", "AWS_REGION": "eu-west-1"},
+			want:    Deployment{Region: "us-east-1", Zone: "us-east-1a"},
+		},
+		{
+			name:    "aws region",
+			giveEnv: map[string]string{EnvZone: "eu-west-1b", "AWS_REGION": "eu-west-1"},
+			want:    Deployment{Region: "eu-west-1", Zone: "eu-west-1b"},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := deploymentFromEnv(func(key string) string { return tt.giveEnv[key] })
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+// TestDeploymentFromEnvOS tests that DeploymentFromEnv reads the environment of the process.
+func TestDeploymentFromEnvOS(t *testing.T) {
+	t.Setenv(EnvRegion, "us-east-1")
+	t.Setenv(EnvZone, "us-east-1a")
+
+	assert.Equal(t, Deployment{Region: "us-east-1", Zone: "us-east-1a"}, DeploymentFromEnv())
+}
+
+// testEC2Metadata is an EC2 instance metadata service that requires an IMDSv2 token.
+func testEC2Metadata(t *testing.T, failPath string) *httptest.Server {
+	t.Helper()
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == failPath {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+
+		if r.URL.Path == "/latest/api/token" {
+			assert.Equal(t, http.MethodPut, r.Method)
+			assert.Equal(t, "60", r.Header.Get("X-aws-ec2-metadata-token-ttl-seconds"))
+			_, _ = w.Write([]byte("token"))
+			return
+		}
+
+		if r.Header.Get("X-aws-ec2-metadata-token") != "token" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		switch r.URL.Path {
+		case "/latest/meta-data/placement/region":
+			_, _ = w.Write([]byte("us-east-1"))
+		case "/latest/meta-data/placement/availability-zone":
+			_, _ = w.Write([]byte("us-east-1a\n"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+// TestDeploymentFromEC2 tests that DeploymentFromEC2 reads the Deployment from the EC2 instance
+// metadata service.
+func TestDeploymentFromEC2(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		giveFailPath string
+		want         Deployment
+		wantErr      bool
+	}{
+		{
+			name: "valid",
+			want: Deployment{Region: "us-east-1", Zone: "us-east-1a"},
+		},
+		{
+			name:         "token error",
+			giveFailPath: "/latest/api/token",
+			wantErr:      true,
+		},
+		{
+			name:         "region error",
+			giveFailPath: "/latest/meta-data/placement/region",
+			wantErr:      true,
+		},
+		{
+			name:         "zone error",
+			giveFailPath: "/latest/meta-data/placement/availability-zone",
+			wantErr:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			srv := testEC2Metadata(t, tt.giveFailPath)
+			defer srv.Close()
+
+			got, err := deploymentFromEC2(context.Background(), nil, srv.URL)
+
+			assert.Equal(t, tt.wantErr, errors.Is(err, ErrMetadata), err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+// TestDeploymentFromGCE tests that DeploymentFromGCE reads the Deployment from the Compute Engine
+// metadata server.
+func TestDeploymentFromGCE(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		giveZone string
+		giveCode int
+		want     Deployment
+		wantErr  bool
+	}{
+		{
+			name:     "valid",
+			giveZone: "projects/123456789/zones/us-central1-a",
+			giveCode: http.StatusOK,
+			want:     Deployment{Region: "us-central1", Zone: "us-central1-a"},
+		},
+		{
+			name:     "zone without region",
+			giveZone: "zone",
+			giveCode: http.StatusOK,
+			want:     Deployment{Zone: "zone"},
+		},
+		{
+			name:     "error",
+			giveCode: http.StatusForbidden,
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "/computeMetadata/v1/instance/zone", r.URL.Path)
+				assert.Equal(t, "Google", r.Header.Get("Metadata-Flavor"))
+				w.WriteHeader(tt.giveCode)
+				_, _ = w.Write([]byte(tt.giveZone))
+			}))
+			defer srv.Close()
+
+			got, err := deploymentFromGCE(context.Background(), srv.Client(), srv.URL)
+
+			assert.Equal(t, tt.wantErr, errors.Is(err, ErrMetadata), err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+// TestGetMetadataError tests that getMetadata returns errors of the request.
+func TestGetMetadataError(t *testing.T) {
+	t.Parallel()
+
+	_, err := getMetadata(context.Background(), nil, http.MethodGet, "://", nil)
+	assert.Error(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = getMetadata(ctx, nil, http.MethodGet, "http://127.0.0.1:1", nil)
+	assert.True(t, errors.Is(err, context.Canceled), err)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// a body shorter than its Content-Length fails to read
+		w.Header().Set("Content-Length", "10")
+		_, _ = w.Write([]byte("short"))
+	}))
+	defer srv.Close()
+
+	_, err = getMetadata(context.Background(), nil, http.MethodGet, srv.URL, nil)
+	assert.Error(t, err)
+
+	// the real metadata services are not reached
+	_, err = DeploymentFromEC2(ctx, nil)
+	assert.True(t, errors.Is(err, context.Canceled), err)
+	_, err = DeploymentFromGCE(ctx, nil)
+	assert.True(t, errors.Is(err, context.Canceled), err)
+}
+
+// TestMatchDeployment tests MatchZone and MatchRegion.
+func TestMatchDeployment(t *testing.T) {
+	t.Parallel()
+
+	d := Deployment{Region: "us-east-1", Zone: "us-east-1a"}
+
+	tests := []struct {
+		name string
+		give RequestMatcher
+		want bool
+	}{
+		{
+			name: "zone",
+			give: MatchZone(d, "us-east-1b", "US-EAST-1A"),
+			want: true,
+		},
+		{
+			name: "other zone",
+			give: MatchZone(d, "us-east-1b"),
+			want: false,
+		},
+		{
+			name: "no zones",
+			give: MatchZone(d),
+			want: false,
+		},
+		{
+			name: "unknown zone",
+			give: MatchZone(Deployment{}, ""),
+			want: false,
+		},
+		{
+			name: "region",
+			give: MatchRegion(d, "us-east-1"),
+			want: true,
+		},
+		{
+			name: "other region",
+			give: MatchRegion(d, "eu-west-1"),
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, tt.give.MatchRequest(httptest.NewRequest(http.MethodGet, "/", nil)))
+		})
+	}
+}
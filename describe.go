@@ -0,0 +1,63 @@
+package fault
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Describer is implemented by Injectors that can describe themselves to operators. Every Injector
+// in this package implements Describer and fmt.Stringer, with String() returning a short summary
+// like "slow(750ms)".
+type Describer interface {
+	// Name returns a short name for the kind of Injector, such as "slow".
+	Name() string
+	// Describe returns the parameters of the Injector as key/value pairs.
+	Describe() map[string]string
+}
+
+// InjectorName returns i.Name() if i is a Describer and the type name of i otherwise.
+func InjectorName(i Injector) string {
+	if d, ok := i.(Describer); ok {
+		return d.Name()
+	}
+
+	return reflect.Indirect(reflect.ValueOf(i)).Type().Name()
+}
+
+// InjectorString returns i.String() if i is a fmt.Stringer and InjectorName(i) otherwise.
+func InjectorString(i Injector) string {
+	if s, ok := i.(fmt.Stringer); ok {
+		return s.String()
+	}
+
+	return InjectorName(i)
+}
+
+// joinInjectorStrings returns the InjectorString of each Injector joined by commas.
+func joinInjectorStrings(is []Injector) string {
+	strs := make([]string, len(is))
+	for idx, i := range is {
+		strs[idx] = InjectorString(i)
+	}
+
+	return strings.Join(strs, ", ")
+}
+
+// joinHeader returns the headers of h sorted by key, such as "Retry-After: 30; Vary: A, B".
+func joinHeader(h http.Header) string {
+	keys := make([]string, 0, len(h))
+	for k := range h {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	strs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		strs = append(strs, k+": "+strings.Join(h[k], ", "))
+	}
+
+	return strings.Join(strs, "; ")
+}
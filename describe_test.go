@@ -0,0 +1,608 @@
+package fault
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestDescribe tests InjectorName, InjectorString, and Describer on each Injector.
+func TestDescribe(t *testing.T) {
+	t.Parallel()
+
+	si, _ := NewSlowInjector(750 * time.Millisecond)
+	sc, _ := NewSlowInjector(750*time.Millisecond, WithMaxConcurrent(100))
+	sx, _ := NewSlowInjector(750*time.Millisecond, WithCancelPolicy(CancelAbort), WithCapToDeadline())
+	ei, _ := NewErrorInjector(http.StatusInternalServerError)
+	eh, _ := NewErrorInjector(http.StatusTooManyRequests, WithErrorHeader("Retry-After", "30"),
+		WithErrorHeader("Vary", "A"), WithErrorHeader("Vary", "B"))
+	eb, _ := NewErrorInjector(http.StatusBadGateway, WithResponseBody([]byte(`{"error":"bad gateway"}`)),
+		WithContentType("application/json"))
+	sk, _ := NewStubInjector(http.StatusOK, `{"id":1}`)
+	sh, _ := NewStubInjector(0, "", WithStatusTemplate("{{.Query.Get `code`}}"),
+		WithHeaderTemplate("Content-Type", "application/json"))
+	rj, _ := NewRejectInjector()
+	rjReset, _ := NewRejectInjector(WithCloseType(CloseReset))
+	pn, _ := NewPanicInjector()
+	pw, _ := NewPanicInjector(WithPanicValue("boom"), WithPanicAfterWrite(http.StatusOK, []byte("partial")))
+	rc, _ := NewResponseCorruptionInjector(CorruptInvalidJSON)
+	rf, _ := NewResponseCorruptionInjector(CorruptFlipBytes, WithCorruptedBytes(3), WithRandSeed(5))
+	tb, _ := NewRequestTamperInjector(TamperTruncateBody)
+	du, _ := NewDuplicateRequestInjector()
+	st, _ := NewStreamInjector(WithStallAfterFrames(3))
+	sa, _ := NewStreamInjector(WithStallAfterFrames(0), WithDropAfter(30*time.Second), WithFrameDelay(time.Second))
+	dl, _ := NewDuplicateRequestInjector(WithDuplicates(2), WithReplyFromLast(), WithDuplicateInterval(time.Second))
+	dc, _ := NewDuplicateRequestInjector(WithConcurrentDuplicates(), WithDuplicateInterval(time.Second))
+	tm, _ := NewRequestTamperInjector(TamperMangleHeaders, WithTamperedHeaders("content-type", "Accept"),
+		WithTamperedBytes(3), WithClaimedContentLength(10), WithRandSeed(5))
+	hr, _ := NewHeaderInjector(map[string]HeaderOp{"content-type": RemoveHeader(), "Retry-After": SetHeader("soon")})
+	hc, _ := NewHeaderInjector(nil, WithoutCORS())
+	thr, _ := NewThrottleInjector()
+	thra, _ := NewThrottleInjector(WithThrottleStatus(http.StatusServiceUnavailable), WithRetryAfter(30*time.Second),
+		WithRetryAfterHTTPDate(), WithThrottleAfter(5, time.Minute, nil))
+	ho, _ := NewHeadersOnlyInjector()
+	hb, _ := NewHopByHopInjector()
+	ht, _ := NewHopByHopInjector(WithHopByHopHeader("Upgrade", "h2c"), WithLeakTransferEncoding())
+	ih, _ := NewInterimInjector(InterimEarlyHints)
+	ic, _ := NewInterimInjector(InterimContinue, WithInterimCount(6))
+	iw, _ := NewInterimInjector(InterimWithholdContinue)
+	ir, _ := NewInterimInjector(InterimRefuseContinue)
+	ci, _ := NewChainInjector([]Injector{si, ei})
+	cc, _ := NewChainInjector([]Injector{si, ei},
+		WithLinkCondition(1, LinkCondition{MinDelay: 500 * time.Millisecond, Probability: 0.5, Terminal: true}))
+	ri, _ := NewRandomInjector([]Injector{si, rj, newTestInjectorNoop()})
+	pb, _ := NewPartialResponseInjector(1024)
+	pp, _ := NewPartialResponseInjector(0, WithAbortAfterPercent(0.5))
+	pf, _ := NewPartialResponseInjector(0, WithAbortAfterFlush())
+	jt, _ := NewJSONTruncateInjector(10)
+	jf, _ := NewJSONTruncateInjector(10, WithJSONField("data.items"))
+	cs, _ := NewCharsetInjector(CharsetMislabel)
+	ct, _ := NewContentTypeInjector(ContentTypeErrorPage)
+	cm, _ := NewContentTypeInjector(ContentTypeMislabel, WithServedContentType("text/plain"))
+	te, _ := NewTrailerInjector()
+	ss, _ := NewSessionInjector([]string{"session_id", "remember_me"})
+	cf, _ := NewCSRFInjector(WithCSRFHeaders("x-csrf-token"), WithCSRFCookies("csrf"))
+	rr, _ := NewRerouteInjector(&url.URL{Scheme: "http", Host: "replica:8080"})
+	fz, _ := NewFuzzInjector([]byte{0, 0, 13, 1, 0, 10, 2, 3, 9, 3, 1, 44, 4, 0, 0, 9})
+	ta, _ := NewTrailerInjector(WithGRPCStatus(14, ""), WithDropTrailers(), WithCorruptTrailers("b", "a"))
+	oi, _ := NewOutageInjector([]Phase{
+		{Duration: time.Minute},
+		{Name: "errors", Duration: 5 * time.Minute, Injector: ei, Participation: 1.0},
+		{Duration: 5 * time.Minute, Injector: rj, Participation: 0.25},
+	})
+	di, _ := NewDistributionInjector(&LatencyProfile{Routes: map[string]LatencyHistogram{
+		"/users":  {Buckets: []time.Duration{time.Millisecond}, Counts: []int64{1, 0}, Count: 1},
+		"/orders": {Buckets: []time.Duration{time.Millisecond}, Counts: []int64{0, 1}, Count: 1},
+		"/empty":  {Buckets: []time.Duration{time.Millisecond}, Counts: []int64{0, 0}},
+	}})
+	wl, _ := NewWeightedLatencyInjector([]WeightedLatency{
+		{Latency: 50 * time.Millisecond, Weight: 80},
+		{Latency: 500 * time.Millisecond, Weight: 15},
+		{Latency: 5 * time.Second, Weight: 5},
+	})
+	sj, _ := NewSlowInjector(0, WithJitter(50*time.Millisecond, 150*time.Millisecond))
+	sn, _ := NewSlowInjector(0, WithNormalLatency(100*time.Millisecond, 20*time.Millisecond), WithRandSeed(7))
+	sp, _ := NewSlowInjector(0, WithParetoLatency(50*time.Millisecond, 5*time.Second, 1.16))
+	rp, _ := NewRampInjector(10*time.Millisecond, 5*time.Millisecond)
+	rm, _ := NewRampInjector(0, time.Millisecond, WithMaxWriteDelay(time.Second))
+	sw, _ := NewSlowBodyInjector(64, 100*time.Millisecond)
+	bo, _ := NewBrownoutInjector(WithOptionalFields("recommendations", "items.reviews"),
+		WithDegradedDelay(250*time.Millisecond))
+	sb, _ := NewSandboxInjector(si, WithPanicPolicy(PanicInternalServerError), WithDeadline(time.Second),
+		WithDeadlineCode(http.StatusServiceUnavailable))
+
+	tests := []struct {
+		name         string
+		give         Injector
+		wantName     string
+		wantString   string
+		wantDescribe map[string]string
+	}{
+		{
+			name:         "slow",
+			give:         si,
+			wantName:     "slow",
+			wantString:   "slow(750ms)",
+			wantDescribe: map[string]string{"duration": "750ms"},
+		},
+		{
+			name:         "slow max concurrent",
+			give:         sc,
+			wantName:     "slow",
+			wantString:   "slow(750ms)",
+			wantDescribe: map[string]string{"duration": "750ms", "max_concurrent": "100"},
+		},
+		{
+			name:         "slow cancel policy",
+			give:         sx,
+			wantName:     "slow",
+			wantString:   "slow(750ms)",
+			wantDescribe: map[string]string{"duration": "750ms", "cancel_policy": "abort", "cap_to_deadline": "true"},
+		},
+		{
+			name:         "slow jitter",
+			give:         sj,
+			wantName:     "slow",
+			wantString:   "slow(uniform 50ms..150ms)",
+			wantDescribe: map[string]string{"duration": "uniform 50ms..150ms", "seed": "1"},
+		},
+		{
+			name:         "slow normal",
+			give:         sn,
+			wantName:     "slow",
+			wantString:   "slow(normal 100ms stddev 20ms)",
+			wantDescribe: map[string]string{"duration": "normal 100ms stddev 20ms", "seed": "7"},
+		},
+		{
+			name:         "slow pareto",
+			give:         sp,
+			wantName:     "slow",
+			wantString:   "slow(pareto 50ms..5s shape 1.16)",
+			wantDescribe: map[string]string{"duration": "pareto 50ms..5s shape 1.16", "seed": "1"},
+		},
+		{
+			name:         "outage",
+			give:         oi,
+			wantName:     "outage",
+			wantString:   "outage(healthy 1m0s, errors: error(500) 5m0s, reject 5m0s 25%)",
+			wantDescribe: map[string]string{"phases": "healthy 1m0s, errors: error(500) 5m0s, reject 5m0s 25%", "repeat": "false", "seed": "1"},
+		},
+		{
+			name:         "distribution",
+			give:         di,
+			wantName:     "distribution",
+			wantString:   "distribution(/orders, /users)",
+			wantDescribe: map[string]string{"routes": "/orders, /users", "seed": "1"},
+		},
+		{
+			name:         "weighted latency",
+			give:         wl,
+			wantName:     "weighted_latency",
+			wantString:   "weighted_latency(50ms:80, 500ms:15, 5s:5)",
+			wantDescribe: map[string]string{"latencies": "50ms:80, 500ms:15, 5s:5", "seed": "1"},
+		},
+		{
+			name:         "ramp",
+			give:         rp,
+			wantName:     "ramp",
+			wantString:   "ramp(10ms+5ms)",
+			wantDescribe: map[string]string{"initial": "10ms", "step": "5ms"},
+		},
+		{
+			name:         "slow body",
+			give:         sw,
+			wantName:     "slow_body",
+			wantString:   "slow_body(64B/100ms)",
+			wantDescribe: map[string]string{"chunk_size": "64", "delay": "100ms"},
+		},
+		{
+			name:         "ramp max",
+			give:         rm,
+			wantName:     "ramp",
+			wantString:   "ramp(0s+1ms)",
+			wantDescribe: map[string]string{"initial": "0s", "step": "1ms", "max": "1s"},
+		},
+		{
+			name:       "brownout",
+			give:       bo,
+			wantName:   "brownout",
+			wantString: "brownout(250ms, recommendations, items.reviews)",
+			wantDescribe: map[string]string{
+				"fields": "recommendations, items.reviews",
+				"header": "X-Degraded: true",
+				"delay":  "250ms",
+			},
+		},
+		{
+			name:         "error",
+			give:         ei,
+			wantName:     "error",
+			wantString:   "error(500)",
+			wantDescribe: map[string]string{"code": "500", "text": "Internal Server Error"},
+		},
+		{
+			name:       "error headers",
+			give:       eh,
+			wantName:   "error",
+			wantString: "error(429)",
+			wantDescribe: map[string]string{
+				"code":    "429",
+				"text":    "Too Many Requests",
+				"headers": "Retry-After: 30; Vary: A, B",
+			},
+		},
+		{
+			name:       "error body",
+			give:       eb,
+			wantName:   "error",
+			wantString: "error(502)",
+			wantDescribe: map[string]string{
+				"code":         "502",
+				"text":         "Bad Gateway",
+				"content_type": "application/json",
+				"body":         `{"error":"bad gateway"}`,
+			},
+		},
+		{
+			name:         "stub",
+			give:         sk,
+			wantName:     "stub",
+			wantString:   "stub(200)",
+			wantDescribe: map[string]string{"code": "200", "body": `{"id":1}`},
+		},
+		{
+			name:       "stub template",
+			give:       sh,
+			wantName:   "stub",
+			wantString: "stub(template)",
+			wantDescribe: map[string]string{
+				"status":  "{{.Query.Get `code`}}",
+				"headers": "Content-Type: application/json",
+				"body":    "",
+			},
+		},
+		{
+			name:         "reject",
+			give:         rj,
+			wantName:     "reject",
+			wantString:   "reject",
+			wantDescribe: map[string]string{"close_type": "abort"},
+		},
+		{
+			name:         "reject reset",
+			give:         rjReset,
+			wantName:     "reject",
+			wantString:   "reject(reset)",
+			wantDescribe: map[string]string{"close_type": "reset"},
+		},
+		{
+			name:         "panic",
+			give:         pn,
+			wantName:     "panic",
+			wantString:   "panic",
+			wantDescribe: map[string]string{"value": "go-fault: injected panic"},
+		},
+		{
+			name:         "panic after write",
+			give:         pw,
+			wantName:     "panic",
+			wantString:   "panic(after 200)",
+			wantDescribe: map[string]string{"value": "boom", "code": "200", "body": "partial"},
+		},
+		{
+			name:         "corrupt",
+			give:         rc,
+			wantName:     "corrupt",
+			wantString:   "corrupt(invalid_json)",
+			wantDescribe: map[string]string{"corruption": "invalid_json", "seed": "1"},
+		},
+		{
+			name:         "corrupt bytes",
+			give:         rf,
+			wantName:     "corrupt",
+			wantString:   "corrupt(flip_bytes)",
+			wantDescribe: map[string]string{"corruption": "flip_bytes", "bytes": "3", "seed": "5"},
+		},
+		{
+			name:         "stream",
+			give:         st,
+			wantName:     "stream",
+			wantString:   "stream(stall after 3)",
+			wantDescribe: map[string]string{"stall_after": "3"},
+		},
+		{
+			name:         "stream all",
+			give:         sa,
+			wantName:     "stream",
+			wantString:   "stream(stall after 0, drop after 30s, delay 1s)",
+			wantDescribe: map[string]string{"stall_after": "0", "drop_after": "30s", "frame_delay": "1s"},
+		},
+		{
+			name:         "duplicate",
+			give:         du,
+			wantName:     "duplicate",
+			wantString:   "duplicate(1)",
+			wantDescribe: map[string]string{"duplicates": "1", "reply": "first"},
+		},
+		{
+			name:         "duplicate reply last",
+			give:         dl,
+			wantName:     "duplicate",
+			wantString:   "duplicate(2, reply last, every 1s)",
+			wantDescribe: map[string]string{"duplicates": "2", "reply": "last", "interval": "1s"},
+		},
+		{
+			name:         "duplicate concurrent",
+			give:         dc,
+			wantName:     "duplicate",
+			wantString:   "duplicate(1, concurrent)",
+			wantDescribe: map[string]string{"duplicates": "1", "reply": "first", "concurrent": "true"},
+		},
+		{
+			name:         "tamper",
+			give:         tb,
+			wantName:     "tamper",
+			wantString:   "tamper(truncate_body)",
+			wantDescribe: map[string]string{"tampering": "truncate_body", "seed": "1"},
+		},
+		{
+			name:       "tamper options",
+			give:       tm,
+			wantName:   "tamper",
+			wantString: "tamper(mangle_headers)",
+			wantDescribe: map[string]string{
+				"tampering":      "mangle_headers",
+				"bytes":          "3",
+				"headers":        "Accept, Content-Type",
+				"content_length": "10",
+				"seed":           "5",
+			},
+		},
+		{
+			name:       "header",
+			give:       hr,
+			wantName:   "header",
+			wantString: "header(Content-Type, Retry-After)",
+			wantDescribe: map[string]string{
+				"ops":   "Content-Type: remove; Retry-After: set(soon)",
+				"count": "2",
+			},
+		},
+		{
+			name:     "header without cors",
+			give:     hc,
+			wantName: "header",
+			wantString: "header(Access-Control-Allow-Credentials, Access-Control-Allow-Headers, " +
+				"Access-Control-Allow-Methods, Access-Control-Allow-Origin, Access-Control-Expose-Headers, " +
+				"Access-Control-Max-Age)",
+			wantDescribe: map[string]string{
+				"ops": "Access-Control-Allow-Credentials: remove; Access-Control-Allow-Headers: remove; " +
+					"Access-Control-Allow-Methods: remove; Access-Control-Allow-Origin: remove; " +
+					"Access-Control-Expose-Headers: remove; Access-Control-Max-Age: remove",
+				"count": "6",
+			},
+		},
+		{
+			name:         "throttle",
+			give:         thr,
+			wantName:     "throttle",
+			wantString:   "throttle(429)",
+			wantDescribe: map[string]string{"code": "429", "retry_after": "1s"},
+		},
+		{
+			name:       "throttle after",
+			give:       thra,
+			wantName:   "throttle",
+			wantString: "throttle(503 after 5/1m0s)",
+			wantDescribe: map[string]string{
+				"code":               "503",
+				"retry_after":        "30s",
+				"retry_after_format": "http-date",
+				"allowance":          "5/1m0s",
+			},
+		},
+		{
+			name:         "headers only",
+			give:         ho,
+			wantName:     "headers_only",
+			wantString:   "headers_only",
+			wantDescribe: map[string]string{},
+		},
+		{
+			name:       "hop by hop",
+			give:       hb,
+			wantName:   "hop_by_hop",
+			wantString: "hop_by_hop(Connection, Keep-Alive, Proxy-Connection, Upgrade, X-Go-Fault-Hop)",
+			wantDescribe: map[string]string{
+				"headers": "Connection: Keep-Alive, X-Go-Fault-Hop; Keep-Alive: timeout=5, max=1000; " +
+					"Proxy-Connection: keep-alive; Upgrade: h2c; X-Go-Fault-Hop: leaked",
+				"transfer_encoding": "false",
+			},
+		},
+		{
+			name:         "hop by hop transfer encoding",
+			give:         ht,
+			wantName:     "hop_by_hop",
+			wantString:   "hop_by_hop(Transfer-Encoding, Upgrade)",
+			wantDescribe: map[string]string{"headers": "Upgrade: h2c", "transfer_encoding": "true"},
+		},
+		{
+			name:       "interim early hints",
+			give:       ih,
+			wantName:   "interim",
+			wantString: "interim(early_hints x1)",
+			wantDescribe: map[string]string{
+				"fault": "early_hints",
+				"count": "1",
+				"links": "</go-fault-early-hint.css>; rel=preload; as=style",
+			},
+		},
+		{
+			name:         "interim continue",
+			give:         ic,
+			wantName:     "interim",
+			wantString:   "interim(continue x6)",
+			wantDescribe: map[string]string{"fault": "continue", "count": "6"},
+		},
+		{
+			name:         "interim withhold continue",
+			give:         iw,
+			wantName:     "interim",
+			wantString:   "interim(withhold_continue 5s)",
+			wantDescribe: map[string]string{"fault": "withhold_continue", "delay": "5s"},
+		},
+		{
+			name:         "interim refuse continue",
+			give:         ir,
+			wantName:     "interim",
+			wantString:   "interim(refuse_continue 417)",
+			wantDescribe: map[string]string{"fault": "refuse_continue", "code": "417"},
+		},
+		{
+			name:         "chain",
+			give:         ci,
+			wantName:     "chain",
+			wantString:   "chain(slow(750ms), error(500))",
+			wantDescribe: map[string]string{"injectors": "slow(750ms), error(500)"},
+		},
+		{
+			name:       "chain link conditions",
+			give:       cc,
+			wantName:   "chain",
+			wantString: "chain(slow(750ms), error(500))",
+			wantDescribe: map[string]string{
+				"injectors":  "slow(750ms), error(500)",
+				"conditions": "1:delay>=500ms,p=0.5,terminal",
+			},
+		},
+		{
+			name:         "random",
+			give:         ri,
+			wantName:     "random",
+			wantString:   "random(slow(750ms), reject, testInjectorNoop)",
+			wantDescribe: map[string]string{"injectors": "slow(750ms), reject, testInjectorNoop", "seed": "1"},
+		},
+		{
+			name:         "partial bytes",
+			give:         pb,
+			wantName:     "partial",
+			wantString:   "partial(1024B)",
+			wantDescribe: map[string]string{"bytes": "1024"},
+		},
+		{
+			name:         "partial percent",
+			give:         pp,
+			wantName:     "partial",
+			wantString:   "partial(50%)",
+			wantDescribe: map[string]string{"percent": "0.5"},
+		},
+		{
+			name:         "partial flush",
+			give:         pf,
+			wantName:     "partial",
+			wantString:   "partial(flush)",
+			wantDescribe: map[string]string{"flush": "first"},
+		},
+		{
+			name:         "json truncate",
+			give:         jt,
+			wantName:     "json_truncate",
+			wantString:   "json_truncate(10)",
+			wantDescribe: map[string]string{"elements": "10", "field": ""},
+		},
+		{
+			name:         "json truncate field",
+			give:         jf,
+			wantName:     "json_truncate",
+			wantString:   "json_truncate(data.items, 10)",
+			wantDescribe: map[string]string{"elements": "10", "field": "data.items"},
+		},
+		{
+			name:         "charset",
+			give:         cs,
+			wantName:     "charset",
+			wantString:   "charset(mislabel)",
+			wantDescribe: map[string]string{"fault": "mislabel", "charset": "iso-8859-1"},
+		},
+		{
+			name:       "content type error page",
+			give:       ct,
+			wantName:   "content_type",
+			wantString: "content_type(error_page 502)",
+			wantDescribe: map[string]string{
+				"fault":        "error_page",
+				"content_type": "text/html; charset=utf-8",
+				"code":         "502",
+			},
+		},
+		{
+			name:         "content type mislabel",
+			give:         cm,
+			wantName:     "content_type",
+			wantString:   "content_type(mislabel)",
+			wantDescribe: map[string]string{"fault": "mislabel", "content_type": "text/plain"},
+		},
+		{
+			name:         "trailer empty",
+			give:         te,
+			wantName:     "trailer",
+			wantString:   "trailer()",
+			wantDescribe: map[string]string{},
+		},
+		{
+			name:         "trailer",
+			give:         ta,
+			wantName:     "trailer",
+			wantString:   "trailer(add: Grpc-Message, Grpc-Status; drop: *; corrupt: A, B)",
+			wantDescribe: map[string]string{"add": "Grpc-Message, Grpc-Status", "drop": "*", "corrupt": "A, B"},
+		},
+		{
+			name:         "session",
+			give:         ss,
+			wantName:     "session",
+			wantString:   "session(session_id, remember_me)",
+			wantDescribe: map[string]string{"cookies": "session_id, remember_me"},
+		},
+		{
+			name:         "csrf",
+			give:         cf,
+			wantName:     "csrf",
+			wantString:   "csrf(X-Csrf-Token, csrf)",
+			wantDescribe: map[string]string{"headers": "X-Csrf-Token", "cookies": "csrf"},
+		},
+		{
+			name:       "fuzz",
+			give:       fz,
+			wantName:   "fuzz",
+			wantString: "fuzz(status(504), truncate(@10), flip(@3, bit 1), insert(@1, 0x2c), drop_header(#0))",
+			wantDescribe: map[string]string{
+				"data": "00000d01000a02030903012c04000009",
+				"ops":  "status(504), truncate(@10), flip(@3, bit 1), insert(@1, 0x2c), drop_header(#0)",
+			},
+		},
+		{
+			name:         "reroute",
+			give:         rr,
+			wantName:     "reroute",
+			wantString:   "reroute(http://replica:8080)",
+			wantDescribe: map[string]string{"upstream": "http://replica:8080"},
+		},
+		{
+			name:       "sandbox",
+			give:       sb,
+			wantName:   "sandbox",
+			wantString: "sandbox(slow(750ms))",
+			wantDescribe: map[string]string{
+				"injector":      "slow(750ms)",
+				"policy":        "internal-server-error",
+				"deadline":      "1s",
+				"deadline_code": "503",
+			},
+		},
+		{
+			name:       "custom",
+			give:       newTestInjectorNoop(),
+			wantName:   "testInjectorNoop",
+			wantString: "testInjectorNoop",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.wantName, InjectorName(tt.give))
+			assert.Equal(t, tt.wantString, InjectorString(tt.give))
+
+			if d, ok := tt.give.(Describer); ok {
+				assert.Equal(t, tt.wantDescribe, d.Describe())
+			} else {
+				assert.Nil(t, tt.wantDescribe)
+			}
+		})
+	}
+}
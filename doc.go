@@ -16,6 +16,28 @@ applies generic Fault logic (such as what % of requests to run the Injector on)
 Make sure you use the NewFault() and NewTypeInjector() constructors to create valid Faults and
 Injectors.
 
+WithParticipation() takes the percent of requests as a float32 between 0.0 and 1.0. To set very
+small rates exactly, or to match Envoy's FractionalPercent, pass WithFractionalParticipation() with
+BasisPoints(), PartsPerMillion(), or a FractionalPercent instead. The Fault then decides using
+integer arithmetic, so 1 basis point is exactly 1 in 10000 requests.
+
+A Fault can be changed while it handles requests. Call Fault.SetEnabled() and
+Fault.SetParticipation() to ramp an experiment up or down without restarting your service, and
+Fault.Enabled() and Fault.Participation() to read the current values.
+
+Switching a Fault off at once can release a thundering herd of recovered requests on the systems
+behind it. Pass WithRampDown() to NewFault() to decay the participation to zero over a duration when
+the Fault is disabled, and WithMinDwell() to keep it in each state for a minimum time, so changes
+that come too soon are delayed. Fault.DisableNow() skips both, and emergency stops such as
+Manager.DisableAll() use it.
+
+    f, err := fault.NewFault(ei,
+        fault.WithEnabled(true),
+        fault.WithParticipation(0.2),
+        fault.WithRampDown(30*time.Second),
+        fault.WithMinDwell(5*time.Minute),
+    )
+
 Injectors
 
 There are three main Injectors provided by the fault package:
@@ -32,17 +54,326 @@ response will produce:
     $ curl https://github.com
     curl: (52) Empty reply from server
 
+Pass WithCloseType() to drop the connection in another way, each of which produces a different
+error in the client: CloseReset resets the connection, CloseMidHeaders closes it in the middle of
+the response headers, and ClosePartialBody runs the request and closes the connection after half
+of the response body.
+
+    ri, err := fault.NewRejectInjector(fault.WithCloseType(fault.CloseReset))
+
 ErrorInjector
 
 Use fault.ErrorInjector to immediately return a valid http status code of your choosing along with
 the standard HTTP response body for that code. For example, you can return a 200, 301, 418, 500, or
 any other valid status code to test how your clients respond to different statuses. Pass the
-WithStatusText() option to customize the response text.
+WithStatusText() option to customize the response text, and WithErrorHeader() or WithHeaders() to
+add headers such as Retry-After. To simulate the real error payload of an upstream, such as a JSON
+error envelope or an HTML error page, pass WithResponseBody() and WithContentType().
+
+    ei, err := fault.NewErrorInjector(http.StatusServiceUnavailable,
+        fault.WithResponseBody([]byte(`{"error":{"code":"unavailable","retryable":true}}`)),
+        fault.WithContentType("application/json"),
+        fault.WithHeaders(http.Header{"Retry-After": {"30"}}))
+
+StubInjector
+
+Use fault.StubInjector to answer requests with a fake response instead of the handler, such as to
+stand in for a dependency that is down or not yet built. The body, status code, and headers are
+text/template templates executed with a StubData of the request, so the response can echo its
+path, query, and headers. The json function quotes a value as JSON and pathValue returns a path
+wildcard of the request. Pass WithStatusTemplate() to choose the status code per request and
+WithHeaderTemplate() to add headers. A template that fails to render responds 500.
+
+    si, err := fault.NewStubInjector(http.StatusOK, `{"id": {{json (pathValue .Request "id")}}}`,
+        fault.WithHeaderTemplate("Content-Type", "application/json"))
 
 SlowInjector
 
 Use fault.SlowInjector to wait a configured time.Duration before proceeding with the request. For
-example, you can use the SlowInjector to add a 10ms delay to your requests.
+example, you can use the SlowInjector to add a 10ms delay to your requests. Pass
+WithMaxConcurrent() to bound how many requests it holds at once on each route; once a route is
+full, further requests to it continue without delay so a hot endpoint cannot exhaust the server's
+connections.
+
+A fixed delay rarely looks like real latency. Pass WithJitter() to wait a uniformly random duration
+between a minimum and maximum, WithNormalLatency() to wait a normally distributed duration, or
+WithParetoLatency() for a long tail where most requests wait close to the minimum and a few wait up
+to the maximum. Distributions are seeded with WithRandSeed(), and WithRandFloat64Func() replaces the
+random source entirely for deterministic tests.
+
+    si, err := fault.NewSlowInjector(0, fault.WithParetoLatency(20*time.Millisecond, 2*time.Second, 1.16))
+
+A request stops waiting as soon as its context is done, such as when the client disconnects, so
+long delays do not hold goroutines for clients that have gone. Pass WithCancelPolicy() to return or
+abort instead of continuing the request, and WithCapToDeadline() to never wait past the deadline of
+the request's context.
+
+    si, err := fault.NewSlowInjector(time.Minute,
+        fault.WithCancelPolicy(fault.CancelAbort),
+        fault.WithCapToDeadline(),
+    )
+
+By default the wait comes before the handler runs, delaying the first byte of the response. Clients
+often time out on the first byte and on the body separately, so pass WithLatencyPhase() to the
+SlowInjector, WeightedLatencyInjector, or DistributionInjector to send the headers at once and wait
+before the body instead (LatencyBeforeBody), to spread the wait across the writes of the body
+(LatencySpreadBody), or to run the handler at once and hold its response until the wait ends
+(LatencyAfterHandler).
+
+    si, err := fault.NewSlowInjector(2*time.Second, fault.WithLatencyPhase(fault.LatencyBeforeBody))
+
+To slow the first byte and the transfer of the body separately, pass WithTransferLatency() to a
+SlowInjector. Its latency is spread across the writes of the body after the wait of the phase.
+
+    si, err := fault.NewSlowInjector(500*time.Millisecond, fault.WithTransferLatency(5*time.Second))
+
+WeightedLatencyInjector
+
+Use fault.WeightedLatencyInjector to model multi-modal latency, where most requests get a little
+slower and a few get much slower. Pass it a list of WeightedLatency and each request waits one of
+the latencies, chosen at random by its relative weight.
+
+    wi, _ := fault.NewWeightedLatencyInjector([]fault.WeightedLatency{
+        {Latency: 50 * time.Millisecond, Weight: 80},
+        {Latency: 500 * time.Millisecond, Weight: 15},
+        {Latency: 5 * time.Second, Weight: 5},
+    })
+
+RampInjector
+
+Use fault.RampInjector to simulate a connection that degrades mid-transfer. It runs the request and
+waits before each write of the response body, longer for every write, so streaming and large
+responses slow down as they are sent. Pass WithMaxWriteDelay() to cap the delay before each write.
+
+SlowBodyInjector
+
+Use fault.SlowBodyInjector to simulate a slow network. It runs the request and sends the response
+body in chunks of a few bytes, flushing each chunk and waiting between them, so the headers arrive
+promptly but the body trickles in. Unlike a SlowInjector, this exercises the body read timeouts of
+clients.
+
+    sb, err := fault.NewSlowBodyInjector(64, 100*time.Millisecond)
+
+StreamInjector
+
+Use fault.StreamInjector to fault long-lived streams while the handler streams them. It works on
+frames: the events of a Server-Sent Events response and the writes to a connection the handler
+takes over, such as a WebSocket. WithStallAfterFrames() stops the stream after some frames while
+keeping the connection open, WithDropAfter() cuts it off after a while, and WithFrameDelay() waits
+before each frame.
+
+    si, err := fault.NewStreamInjector(fault.WithStallAfterFrames(3), fault.WithDropAfter(30*time.Second))
+
+BandwidthLimitInjector
+
+Use fault.BandwidthLimitInjector to simulate a constrained network such as 3G. It runs the request
+and caps the throughput of the response body to a number of bytes per second, so a large response
+takes as long as it would over a slow link while a small one is barely delayed. Pass WithBurst() to
+set how many bytes are written at once.
+
+    bl, err := fault.NewBandwidthLimitInjector(50_000)
+
+ResourcePressureInjector
+
+Use fault.ResourcePressureInjector to test how a service behaves when memory or CPU runs short,
+and if its limits and autoscaling react. While it handles a request it holds memory passed to
+WithMemoryPressure() and keeps the goroutines of WithCPUPressure() busy, and it releases both once
+the request is done. Only one request applies pressure at a time unless WithMaxConcurrent() allows
+more.
+
+    rp, err := fault.NewResourcePressureInjector(
+        fault.WithMemoryPressure(256<<20),
+        fault.WithCPUPressure(2, 5*time.Second),
+    )
+
+PartialResponseInjector
+
+Use fault.PartialResponseInjector to run the request, send the response headers and the start of
+the body, and then abort the response before the body is complete. By default the response is
+aborted after a fixed number of bytes. Pass WithAbortAfterPercent() to abort after a percent of the
+Content-Length so the abort point adapts to the size of the response, or WithAbortAfterFlush() to
+abort after the handler first flushes.
+
+HeaderInjector
+
+Use fault.HeaderInjector to run the request and change the headers of its response, to test how
+browsers and SDKs handle a missing Content-Type, a bogus Retry-After, or a header too large for a
+proxy. Pass a map of header names to RemoveHeader(), SetHeader(), AddHeader(), or OversizeHeader().
+Pass WithoutCORS() to also remove the CORS headers, so browsers block cross-origin reads. The body
+is not buffered, so handlers can still stream.
+
+    hi, err := fault.NewHeaderInjector(map[string]fault.HeaderOp{
+        "Content-Type": fault.RemoveHeader(),
+        "Retry-After":  fault.SetHeader("soon"),
+    })
+
+HeadersOnlyInjector
+
+Use fault.HeadersOnlyInjector to run the request, send its complete and correct headers with a
+Content-Length for the whole body, and then abort before any byte of the body. Clients often handle
+this differently from a RejectInjector or a PartialResponseInjector. The HeadersOnlyInjector
+buffers the whole response to learn its length, so handlers can no longer stream while it runs.
+
+HopByHopInjector
+
+Use fault.HopByHopInjector to run the request and add hop-by-hop headers to its response, such as
+Keep-Alive, Upgrade, and a header nominated by Connection, to probe intermediaries and clients that
+forward or act on headers meant for a single connection. Pass WithHopByHopHeader() to choose the
+headers, and WithLeakTransferEncoding() to also send "Transfer-Encoding: chunked" alongside a
+Content-Length and on responses without a body. net/http will not send such a response, so the
+HopByHopInjector writes it to the HTTP/1.1 connection itself and then closes the connection.
+
+InterimInjector
+
+Use fault.InterimInjector to test how clients handle 1xx interim responses. InterimEarlyHints sends
+103 Early Hints with bogus Link headers, InterimContinue sends unsolicited 100 Continue responses,
+and InterimWithholdContinue delays the 100 Continue that a request sent with "Expect: 100-continue"
+waits for before sending its body. InterimRefuseContinue refuses such a request with 417 Expectation
+Failed before its body is sent, to validate how uploading clients handle the handshake. Pass
+WithInterimCount() to send more interim responses than a client accepts, WithEarlyHints() to choose
+the Link headers, WithContinueDelay() to choose how long 100 Continue is withheld, and
+WithRefuseStatus() to refuse with another status such as 413.
+
+JSONTruncateInjector
+
+Use fault.JSONTruncateInjector to run the request and then truncate a JSON array in the response
+after a number of elements, simulating paginated or partial results. The response stays valid JSON.
+By default the response body must be the array. Pass WithJSONField() to truncate an array nested in
+objects, such as "data.items". The JSONTruncateInjector buffers the whole response, so handlers can
+no longer stream while it runs.
+
+BrownoutInjector
+
+Use fault.BrownoutInjector to degrade responses instead of failing them, the way a service sheds
+optional work under load, so product teams can test reduced-functionality UX. By default it marks
+responses with an "X-Degraded: true" header. Pass WithOptionalFields() to strip JSON fields such as
+"recommendations" from the response, WithDegradedHeader() to change the header, and
+WithDegradedDelay() to add moderate latency.
+
+ResponseCorruptionInjector
+
+Use fault.ResponseCorruptionInjector to run the request and then corrupt its response, to test how
+clients parse malformed payloads. CorruptTruncate cuts the body short, CorruptFlipBytes changes
+bytes at random positions, CorruptInvalidJSON breaks the end of a JSON body, and
+CorruptStripContentLength and CorruptGarbleContentLength send the body without a Content-Length or
+with one that is not a number. Pass WithCorruptedBytes() to choose how many bytes are kept or
+changed, and WithRandSeed() to reproduce the same flips.
+
+    ci, err := fault.NewResponseCorruptionInjector(fault.CorruptTruncate, fault.WithCorruptedBytes(512))
+
+RequestTamperInjector
+
+Use fault.RequestTamperInjector to tamper with the request before the handler reads it, to test your
+own input validation against malformed clients. TamperTruncateBody cuts the body short so reading it
+ends in io.ErrUnexpectedEOF, TamperFlipBytes changes bytes at random positions, TamperDropHeaders
+and TamperMangleHeaders remove or garble the headers passed to WithTamperedHeaders, and
+TamperContentLength declares a Content-Length that does not match the body. The request of the
+caller is not changed, and bodies larger than 10MiB are passed through untampered rather than
+buffered.
+
+    ti, err := fault.NewRequestTamperInjector(fault.TamperMangleHeaders, fault.WithTamperedHeaders("Content-Type"))
+
+DuplicateRequestInjector
+
+Use fault.DuplicateRequestInjector to run each request more than once, like a client that retries or
+a queue that delivers twice, to find handlers that are not idempotent. The client gets the response
+of the first run, or of the last with WithReplyFromLast(). Pass WithDuplicates() to set how many
+extra runs there are, WithDuplicateInterval() to wait between them, and WithConcurrentDuplicates()
+to race them against the original instead. Requests with bodies larger than 10MiB run once, and a
+request whose client goes away during the interval runs no more duplicates. It is destructive, so
+WithIdempotencySafety() keeps it off non-idempotent requests.
+
+    di, err := fault.NewDuplicateRequestInjector(fault.WithDuplicates(2), fault.WithReplyFromLast())
+
+CharsetInjector
+
+Use fault.CharsetInjector to run the request and then corrupt the charset of the response to test
+how clients handle text they cannot decode. CharsetLatin1 transcodes the body to Latin-1 while still
+declaring UTF-8, CharsetInvalidUTF8 inserts an invalid UTF-8 sequence into the body, and
+CharsetMislabel leaves the body alone and declares a different charset. Pass WithDeclaredCharset()
+to choose the charset written to the Content-Type header.
+
+ContentTypeInjector
+
+Use fault.ContentTypeInjector to ignore the Accept header of a request and respond with a
+Content-Type the client did not ask for, the way a misconfigured proxy does during an incident.
+ContentTypeErrorPage responds with an HTML error page instead of running the request, and
+ContentTypeMislabel runs the request and declares a different Content-Type for its body. Pass
+WithServedContentType() to choose the Content-Type and WithErrorPageStatus() to choose the status of
+the error page, such as 200 for a captive portal. Use the CharsetInjector for charset mismatches.
+
+TrailerInjector
+
+Use fault.TrailerInjector to run the request and then change its HTTP trailers. Pass WithTrailer()
+to add a trailer, WithDropTrailers() to drop trailers the handler set, and WithCorruptTrailers() to
+replace their values with garbage. WithGRPCStatus() adds the Grpc-Status and Grpc-Message trailers
+gRPC clients read, which works on HTTP/2 and h2c servers alike. Trailers are only sent on responses
+without a Content-Length.
+
+ThrottleInjector
+
+Use fault.ThrottleInjector to simulate rate limiting and validate the back-off of clients end to
+end. It responds 429 Too Many Requests with a Retry-After header. Pass WithThrottleStatus() to
+respond 503 Service Unavailable instead, WithRetryAfter() to choose the Retry-After, and
+WithRetryAfterHTTPDate() to send it as a date. WithThrottleAfter() lets the first requests of each
+client through in every window before throttling the rest, like a real rate limiter, and tells
+throttled clients when their window ends.
+
+    // 10 requests per API key per minute
+    ti, err := fault.NewThrottleInjector(fault.WithThrottleAfter(10, time.Minute, fault.HeaderKey("X-Api-Key")))
+
+SessionInjector
+
+Use fault.SessionInjector to simulate losing a backend session store mid-session. Requests that
+carry one of the configured session cookies get those cookies cleared and a 401 Unauthorized
+response, so you can test how clients re-authenticate. Requests without a session cookie continue
+unchanged. Pass WithCookiePath() and WithCookieDomain() if the cookies were not set on "/" for the
+request host.
+
+CSRFInjector
+
+Use fault.CSRFInjector to run the request and mangle the anti-CSRF tokens it sets in response
+headers or cookies. Mangled tokens keep their length and alphabet so they still look valid, letting
+you verify that clients and frontends fail safely when the token is later rejected. Pass
+WithCSRFHeaders() and WithCSRFCookies() to choose where tokens are found.
+
+FuzzInjector
+
+Use fault.FuzzInjector to turn a fuzz test into a resilience harness for your http clients. The
+FuzzInjector reads its input as a list of corruptions (changing the status code, truncating the
+body, flipping a bit, inserting a byte, or dropping a header) and applies them to the response.
+Seed a testing.F with FuzzCorpus(), or use FuzzData() to generate input from a seed in tests that
+do not use testing.F. Each corruption is independent, so the inputs the fuzzer minimizes are short
+reproducers, and FuzzInjector.String() lists the corruptions they apply.
+
+    func FuzzClient(f *testing.F) {
+        for _, data := range fault.FuzzCorpus() {
+            f.Add(data)
+        }
+        f.Fuzz(func(t *testing.T, data []byte) {
+            fi, _ := fault.NewFuzzInjector(data)
+            srv := httptest.NewServer(fi.Handler(upstream))
+            defer srv.Close()
+            // run your client against srv.URL and check it fails safely
+        })
+    }
+
+RerouteInjector
+
+Use fault.RerouteInjector to send the request to a different upstream instead of your handler,
+such as a stale read replica or an old version of your service. This simulates split-brain and
+version-skew, where some responses come from a backend that disagrees with the rest.
+
+PanicInjector
+
+Use fault.PanicInjector to panic in the handler as a bug would, to check that your recovery
+middleware turns the panic into a 500 and that the crash reaches your logs and alerts. It panics
+with ErrInjectedPanic, or the value passed to WithPanicValue(), never with http.ErrAbortHandler,
+which the server would drop quietly. Pass WithPanicAfterWrite() to write and flush part of a
+response first, as when a handler panics while streaming: the client has already received the
+status, so recovery middleware can no longer send a 500.
+
+    pi, err := fault.NewPanicInjector(fault.WithPanicAfterWrite(http.StatusOK, []byte(`{"items":[`)))
 
 RandomInjector
 
@@ -50,6 +381,127 @@ Use fault.RandomInjector to randomly choose one of the above faults to inject. P
 Injector to fault.NewRandomInjector and when RandomInjector is evaluated it will randomly run one of
 the injectors that you passed.
 
+ConditionalInjector
+
+Use fault.ConditionalInjector to choose between two Injectors by any property of the request,
+without writing a custom Injector. The predicate is a plain func, so the MatchRequest method of a
+RequestMatcher works too. Pass a nil elseInjector to leave the other requests alone.
+
+    mobile := func(r *http.Request) bool {
+        return strings.Contains(r.UserAgent(), "Mobile")
+    }
+    ci, err := fault.NewConditionalInjector(mobile, slowInjector, nil)
+
+    ci, err = fault.NewConditionalInjector(fault.MatchHeader("X-Tenant", "acme").MatchRequest,
+        errorInjector, slowInjector)
+
+OutageInjector
+
+Use fault.OutageInjector to rehearse a whole incident instead of a single failure. It advances
+through Phases on a timeline that starts when its Fault is enabled, running the Injector of the
+current Phase on a share of requests, so dashboards and runbooks can be exercised against a
+realistic arc of degradation and recovery.
+
+    oi, err := fault.NewOutageInjector([]fault.Phase{
+        {Name: "healthy", Duration: time.Minute},
+        {Name: "elevated latency", Duration: 5 * time.Minute, Injector: slow, Participation: 0.5},
+        {Name: "errors", Duration: 5 * time.Minute, Injector: errors503, Participation: 0.5},
+        {Name: "down", Duration: 5 * time.Minute, Injector: reject, Participation: 1.0},
+        {Name: "recovering", Duration: 5 * time.Minute, Injector: errors503, Participation: 0.1},
+    })
+
+Pass WithRepeat(true) to start the timeline over after the last Phase, modeling failures that recur
+such as flapping. Use fault.NewPreset to build the OutageInjector of a common third-party dependency
+failure by name: PresetS3SlowDown, PresetDNSFlap, PresetDatabaseFailover, or PresetRateLimitedAPI.
+Presets() lists them all.
+
+    oi, err := fault.NewPreset(fault.PresetRateLimitedAPI)
+
+Give a Phase Expectations of how clients should behave during it to turn the rehearsal into a
+resilience test. OutageInjector.RunExpectations follows the timeline once and checks each Phase
+against what an OutcomeRecorder recorded for the Fault, such as retries that must less than double
+the request rate of the healthy Phase before it.
+
+    {Name: "errors", Duration: 5 * time.Minute, Injector: errors503, Participation: 0.5,
+        Expect: []fault.Expectation{{MaxRateIncrease: 2, MinSuccessRate: 0.5}}},
+
+    results, err := oi.RunExpectations(ctx, rec, "checkout-outage")
+
+For soak tests that run for hours, use fault.GenerateSoakScenario to mix healthy, slow, error,
+and reject steps of random durations and participations from a seed. A SoakScenario marshals to
+JSON, so keep it with the results of the test and load it again to replay the exact schedule that
+found an issue.
+
+    s, err := fault.GenerateSoakScenario(12*time.Hour, fault.WithRandSeed(seed),
+        fault.WithMaxParticipation(0.05))
+    oi, err := s.OutageInjector()
+
+Protocol Faults
+
+Some faults happen before a request reaches any http.Handler. Use fault.ProtocolListener to wrap the
+net.Listener passed to http.Server.Serve and apply a ProtocolFault to a percent of connections.
+ProtocolHTTP10 downgrades the connection to HTTP/1.0. ProtocolNoHTTP2 stops HTTP/2 from being
+negotiated and ProtocolRejectALPN fails the TLS handshake, both of which need WithTLSConfig() so the
+listener can terminate TLS itself.
+
+    ln, _ := net.Listen("tcp", ":443")
+    pl, _ := fault.NewProtocolListener(ln, fault.ProtocolNoHTTP2,
+        fault.WithTLSConfig(cfg),
+        fault.WithParticipation(0.1),
+    )
+    srv.Serve(pl)
+
+Pass WithFault() instead of WithParticipation() to fault the connections a Fault decides to fault,
+with its participation, matchers, schedule, rate limit, and budget.
+
+To make the connections themselves flaky, wrap the net.Listener with fault.Listener. Each of its
+faults has its own rate: WithAcceptDelay() waits before handing a connection to the server,
+WithDropConnections() resets connections before the server sees them, WithConnectionThrottle()
+limits the bytes a connection reads and writes per second, and WithMidStreamClose() resets a
+connection once the server has written a number of bytes to it. Wrap the Listener with
+tls.NewListener to stall and slow TLS handshakes too.
+
+    ln, _ := net.Listen("tcp", ":443")
+    fl, _ := fault.NewListener(ln,
+        fault.WithAcceptDelay(5*time.Second, 0.01),
+        fault.WithConnectionThrottle(20_000, 0.1),
+        fault.WithMidStreamClose(4096, 0.01),
+    )
+    srv.Serve(tls.NewListener(fl, cfg))
+
+Faulting Other Protocols
+
+The decisions of a Fault are not tied to HTTP. Describe a call of any protocol, such as a SQL query
+or a Redis command, as a fault.Call and pass it to Fault.Decide to get the Evaluation an HTTP request
+would get. The Fault sees the Call as an HTTP request whose path is the Operation, whose host is the
+Target, and whose headers are the Metadata, so matchers and key functions work unchanged; use
+fault.CallFromRequest in a RequestMatcher to see the Call itself. Apply the fault of your protocol
+if the Evaluation is Injected, and call done once the call finishes.
+
+    ev, done := f.Decide(fault.Call{
+        Context:   ctx,
+        Protocol:  "sql",
+        Operation: "SELECT",
+        Target:    "orders",
+        ReadOnly:  true,
+    })
+    defer done()
+    if ev.Injected {
+        return nil, driver.ErrBadConn
+    }
+
+To write injectors for a protocol once and have the compiler check where they are used, implement
+fault.TypedInjector for its request and response types. A TypedInjector wraps a TypedHandler the way
+an Injector wraps an http.Handler. TypedError, TypedDelay, and TypedChain provide the basics, and
+NewTypedFault runs a TypedInjector on the calls a Fault decides to fault:
+
+    tf, err := fault.NewTypedFault(f, fault.TypedError[*Query, *Rows](driver.ErrBadConn),
+        func(ctx context.Context, q *Query) fault.Call {
+            return fault.Call{Protocol: "sql", Operation: q.Verb, Target: q.Table}
+        })
+
+    query := tf.Wrap(db.Query)
+
 Combining Faults
 
 It is easy to combine any of the Injectors into a chained action. There are two ways you might want
@@ -59,12 +511,339 @@ First, you can create separate Faults for each Injector that are sequential but
 other. For example, you can chain Faults such that 1% of requests will return a 500 error and
 another 1% of requests will be rejected.
 
+Independent Faults can compound, such as slowing a request down and then failing it too. To run at
+most one of them on each request, put them in a FaultGroup. It selects one of the Faults that match
+the request by weight, which then decides whether to inject by its own participation, and skips the
+others with SkipConflict:
+
+    g, _ := fault.NewFaultGroup([]fault.WeightedFault{
+        {Fault: slowFault, Weight: 3},
+        {Fault: errorFault, Weight: 1},
+    })
+    handler := g.Handler(mux)
+
 Second, you might want to combine Faults such that 1% of requests will be slowed for 10ms and then
 rejected. You want these Faults to depend on each other. For this use the special ChainInjector,
 which consolidates any number of Injectors into a single Injector that runs each of the provided
 Injectors sequentially. When you add the ChainInjector to a Fault the entire chain will always
 execute together.
 
+Pass WithLinkCondition() to make a link of the chain depend on the outcome of the others. A
+LinkCondition with a MinDelay only runs its link if the links before it held the request that
+long, such as only returning an error once a SlowInjector really delayed the request. A
+LinkCondition with a Response func runs the rest of the chain and the handler first, and only runs
+its link in place of their response if the status code passes, such as only failing requests the
+handler served successfully:
+
+    ci, _ := fault.NewChainInjector([]fault.Injector{si, ei},
+        fault.WithLinkCondition(1, fault.LinkCondition{
+            MinDelay: 100 * time.Millisecond,
+            Response: func(code int) bool { return code < 300 },
+        }))
+
+A LinkCondition with a Probability only runs its link for that fraction of requests, rolled with
+the seed set by WithRandSeed(), so a chain can slow down every request but only fail some of them.
+A Terminal link ends the chain once it has written a response: the rest of the chain and the
+handler are skipped even if the link continues anyway:
+
+    ci, _ := fault.NewChainInjector([]fault.Injector{si, hi, ei},
+        fault.WithLinkCondition(2, fault.LinkCondition{Probability: 0.1, Terminal: true}))
+
+NewChainInjector() and NewRandomInjector() need at least one Injector. Each Injector must be non-nil,
+and the same Injector instance must not appear twice. Any invalid Injectors are returned together
+as InjectorErrors, which name the index of each one.
+
+Every request runs every Injector in a chain, so the composites also limit how much work they can
+be configured to do. By default a ChainInjector or RandomInjector may combine DefaultMaxInjectors
+Injectors and be nested DefaultMaxDepth levels deep. Change the limits with WithMaxInjectors() and
+WithMaxDepth(). A composite that contains itself is rejected with ErrInjectorCycle.
+
+Binding Faults to Servers
+
+A service that serves several ports, like a public API and an internal admin port, can bind Faults
+to one server with WrapServer() so the others are never affected.
+
+    public := &http.Server{Addr: ":8080", Handler: apiMux}
+    admin := &http.Server{Addr: ":9090", Handler: adminMux}
+    err := fault.WrapServer(public, errorFault, slowFault)
+
+When a single Handler serves several listeners, add MatchLocalPort() with WithRequestMatcher() to
+run a Fault only on requests received on the given ports.
+
+The limits of a server can silently cut an injected fault short: a SlowInjector that waits past the
+WriteTimeout means the response is never written, and one that waits past the ReadTimeout means the
+handler can no longer read the request body. CheckServer() returns a LimitWarning for each Injector
+of the Faults, including those inside a ChainInjector or RandomInjector, that conflicts with the
+ReadTimeout or WriteTimeout of a server, and Instrumentation.LimitWarnings() checks the Faults of an
+instrumented Manager:
+
+    for _, w := range fault.CheckServer(public, errorFault, slowFault) {
+        log.Println(w)
+    }
+
+Instrument() installs a Manager on an http.Server in one call. It wraps the Handler in the Manager
+middleware, adds the Manager to each request context for ManagerFromContext(), and tracks the
+state of connections, keeping any BaseContext and ConnState already set. Serve the server with
+Instrumentation.ListenAndServe() so its listeners are wrapped with each WithProtocolFault(), and
+mount Instrumentation.Admin() on an internal port for the explain, vars, connections, and runtime
+endpoints.
+
+    inst, _ := fault.Instrument(public, m, fault.WithAdminRuntime(rt))
+    go http.ListenAndServe("localhost:9090", inst.Admin())
+    err := inst.ListenAndServe()
+
+Frameworks
+
+Faults are net/http middleware, and the adapter packages run them as the middleware of other
+frameworks without losing the request context or the reporting of the Fault: faultchi for chi,
+faultgin for gin, faultecho for echo, and faultfasthttp for fasthttp. Each has a Middleware()
+that takes a Fault, a Manager, or any Injector:
+
+    r := gin.Default()
+    r.Use(faultgin.Middleware(f))
+
+The handlers after the middleware see the context the Injector passed on, so HistoryFromContext()
+and AddedLatencyFromContext() work in them; fasthttp handlers get it from faultfasthttp.Context().
+faultgin, faultecho, and faultfasthttp are separate modules, so services that do not use those
+frameworks do not depend on them.
+
+Faulting Outbound Requests
+
+A Transport is an http.RoundTripper that runs a Fault, a Manager, or any Injector on the requests a
+service sends, to simulate a flaky dependency without controlling the remote server. Requests the
+Injector continues are sent with the base RoundTripper; the responses Injectors write instead, such
+as a 503 from an ErrorInjector, are returned to the caller. A RejectInjector fails the request with
+ErrTransportAborted. Pass WithTransport() to choose the base RoundTripper.
+
+    t, _ := fault.NewTransport(slowFault)
+    client := &http.Client{Transport: t}
+
+Some failures only happen to clients. A ClientDelayInjector slows down resolving the host
+(ClientPhaseDNS) or the first byte of the response (ClientPhaseTTFB) from inside the
+httptrace.ClientTrace of the request, and a ConnectFailureInjector fails the request as if the
+host refused the connection. They call the same httptrace callbacks, in the same order, as a real
+slow or unreachable dependency, so client instrumentation built on httptrace, such as tracing and
+connection metrics, observes them like real failures.
+
+    ci, _ := fault.NewClientDelayInjector(fault.ClientPhaseTTFB, 2*time.Second)
+    f, _ := fault.NewFault(ci, fault.WithEnabled(true), fault.WithParticipation(0.05))
+    t, _ := fault.NewTransport(f)
+
+Swapping Injectors
+
+Call Fault.SetInjector() to replace the Injector of a running Fault, for example to move an
+experiment from added latency to errors without rebuilding your middleware. The swap is atomic.
+Requests that already started finish with the Injector they started with, and new requests use
+the new Injector.
+
+Managing Faults
+
+Use fault.Manager to hold many named Faults and run them as a single middleware. Faults run in the
+order they are added and can be added or removed while the Manager is handling requests.
+
+    m, _ := fault.NewManager()
+    m.Add(errorFault, slowFault)
+    handler := m.Handler(mux)
+
+Give Faults a priority with WithPriority() to run them in a fixed order, highest first, however they
+are added. When more than one Fault matches a request, the ConflictPolicy of the Manager decides
+which run: ConflictAll runs every Fault, ConflictFirstWins runs only the first Fault that decides to
+inject, and ConflictHighestPriority only considers the matching Faults with the highest priority.
+Faults that lose are skipped with SkipConflict.
+
+    m, _ := fault.NewManager(fault.WithConflictPolicy(fault.ConflictFirstWins))
+
+Manager.DisableAll() is an emergency stop that disables every managed Fault, and
+Manager.RestoreEnabled() re-enables the ones that were enabled before it. Call
+Manager.NotifyUserSignals() to stop Faults out-of-band with SIGUSR1 and restore them with SIGUSR2,
+which works even if the admin port is overwhelmed.
+
+Pass WithBlackoutCalendar() to NewManager() to forbid injection during deploy freezes or peak
+events. While the Manager's Clock is within a Blackout, every Fault that would have run is skipped
+with SkipBlackout, however it is configured. Load the calendar from JSON ranges with
+ParseBlackoutJSON() or from an iCalendar export with ParseBlackoutICal().
+
+    cal, err := fault.ParseBlackoutJSON(strings.NewReader(`[
+        {"name": "black friday", "start": "2024-11-29T00:00:00Z", "end": "2024-12-03T00:00:00Z"}
+    ]`))
+    m, err := fault.NewManager(fault.WithBlackoutCalendar(cal))
+
+To follow change freezes declared elsewhere, pass WithFreezeChecker() with a FreezeChecker and how
+often to poll it. HTTPFreezeChecker() asks a freeze calendar endpoint that responds with JSON such
+as {"frozen": true, "reason": "holiday freeze"}. During a freeze every Fault that would have run is
+skipped with SkipFreeze, and the Manager's AdminHandler refuses changes other than disabling
+Faults. The calendar is polled in the background, so a slow calendar never holds up requests, and
+a check that fails counts as a freeze, so chaos pauses when the calendar cannot be reached.
+
+    checker := fault.HTTPFreezeChecker("https://freeze.internal/api/v1/status", nil)
+    m, err := fault.NewManager(fault.WithFreezeChecker(checker, time.Minute))
+
+Pass WithParticipationLimit() to NewManager() to limit how fast the participation of a managed
+Fault can be raised at runtime, so a fat-fingered change from 1% to 100% through the admin or
+remote config is rejected. Each Fault has a budget of increase that refills over the period;
+Fault.SetParticipation() returns ErrParticipationIncrease for a change beyond it, and a Runtime keeps
+the previous value. Lowering participation is never limited.
+
+    // at most 5 percentage points per minute
+    m, err := fault.NewManager(fault.WithParticipationLimit(0.05, time.Minute))
+
+Pass WithMaxAddedLatency() to NewManager() to cap the latency the managed Faults add to a single
+request between them, however their Injectors are nested in ChainInjectors and RandomInjectors.
+Injectors that delay a request, such as the SlowInjector, stop delaying it once the budget is
+spent. Use ContextWithMaxAddedLatency() to set a budget without a Manager, and
+AddedLatencyFromContext() to read how much latency a request was given.
+
+    // no request is slowed by more than 3 seconds of injected latency
+    m, err := fault.NewManager(fault.WithMaxAddedLatency(3 * time.Second))
+
+Manager.AdminHandler() serves a small REST API to list, toggle, and ramp the managed Faults without a
+redeploy. GET on its root lists every Fault's FaultStatus as JSON, and POST on "/{name}" with the
+form fields "enabled" and "participation" changes one. Use NewAdminHandler() for Faults that are not
+run by a Manager. Every request must be allowed by an AdminAuthorizer. Pass a Runtime to apply its
+scoped AdminTokens, and to refuse the changes its two-person approval would hold, which are then
+made through Runtime.AdminHandler() instead. A Runtime refuses every request until it issues a
+token, and a nil AdminAuthorizer refuses every request.
+
+    rt, _ := fault.NewRuntime(fault.WithApprovalThreshold(0.1))
+    tok, _ := rt.IssueToken(fault.WithTokenName("oncall"), fault.WithTokenRange(0.0, 0.1))
+    admin.Handle("/debug/faults/", http.StripPrefix("/debug/faults", m.AdminHandler(rt)))
+
+    curl -H "Authorization: Bearer $TOKEN" -d enabled=true -d participation=0.05 \
+        localhost:6060/debug/faults/errors
+
+Manager.StartSynthetic() plugs a TrafficGenerator, such as a load test or a replay of recorded
+traffic, into the managed Faults so an experiment can run against synthetic load before it is
+promoted to real traffic. Requests from the generator are marked as synthetic in their context and
+carry the labels passed WithSyntheticLabels(), which SyntheticLabels() returns. A Fault with a
+DarkLaunch matcher runs only on synthetic requests until DarkLaunch.Promote() is called.
+
+    var dark fault.DarkLaunch
+    f, err := fault.NewFault(i, fault.WithEnabled(true), fault.WithRequestMatcher(&dark))
+    m.Add(f)
+
+    stop, err := m.StartSynthetic(gen, handler, fault.WithSyntheticLabels(map[string]string{"experiment": "slow-db"}))
+    // ... check the results, then
+    stop(ctx)
+    dark.Promote()
+
+Manager.Verify() smoke-tests that a fault configuration is live, such as from a deployment pipeline
+before a game day. It sends probe requests through the managed Faults, without reaching the
+handler, until the target Fault runs its Injector, and returns ErrNotVerified with the reason it
+was skipped if it never does. Give the Fault WithOptInHeader() and set the header on the probe to
+verify it without faulting real traffic.
+
+    probe, _ := http.NewRequest(http.MethodGet, "/checkout", nil)
+    probe.Header.Set(fault.OptInHeader, "true")
+    v, err := m.Verify(ctx, fault.ProbeTarget{Fault: "checkout-latency", Request: probe})
+
+Watchdog
+
+Injectors that hold requests open, like the SlowInjector, also hold their goroutines, memory, and
+connections. A Watchdog protects the service from its own experiments: while any of its Faults are
+enabled it periodically checks the goroutine count, heap size, and open file descriptors of the
+process, and disables every one of its Faults once a limit is exceeded.
+
+    wd, err := fault.NewWatchdog([]*fault.Fault{slowFault},
+        fault.WithMaxGoroutines(10000),
+        fault.WithMaxHeapBytes(1<<30),
+        fault.WithTripFunc(func(t fault.Trip) {
+            log.Printf("watchdog disabled %v: %s %d > %d", t.Faults, t.Signal, t.Value, t.Limit)
+        }),
+    )
+    wd.Start()
+    defer wd.Stop()
+
+Error Guard
+
+An ErrorGuard keeps an experiment from compounding a real incident. Wrap the handler inside the
+Faults with its Handler so it only counts genuine responses. Every check interval it compares the
+share of them that were 5xx errors to a limit, pauses its enabled Faults while the limit is exceeded,
+and resumes them once an interval is back under it.
+
+    g, err := fault.NewErrorGuard([]*fault.Fault{errorFault}, 0.05,
+        fault.WithChangeFunc(func(c fault.GuardChange) {
+            log.Printf("error guard paused=%t %v: %d/%d errors", c.Paused, c.Faults, c.Errors, c.Requests)
+        }),
+    )
+    g.Start()
+    defer g.Stop()
+    http.ListenAndServe(":8080", errorFault.Handler(g.Handler(mux)))
+
+SLO Guard
+
+An SLOGuard is a circuit breaker that makes it safe to leave Faults enabled in production. Faults
+given it with WithSLOGuard() report the requests that no Injector ran on, and every check interval
+it compares those organic requests to its objectives: WithMaxErrorRatio() for the share that may
+fail with a 5xx status code or a panic, and WithLatencyObjective() for the share that may be slower
+than a threshold. When the service breaches an objective on its own the SLOGuard trips and disables
+its enabled Faults, and after WithRecoveryChecks() checks in a row that meet the objectives it
+enables them again.
+
+    g, err := fault.NewSLOGuard(
+        fault.WithMaxErrorRatio(0.01),
+        fault.WithLatencyObjective(300*time.Millisecond, 0.01),
+        fault.WithSLOChangeFunc(func(c fault.SLOChange) {
+            log.Printf("slo guard tripped=%t %v", c.Tripped, c.Faults)
+        }),
+    )
+    f, err := fault.NewFault(ei, fault.WithEnabled(true), fault.WithParticipation(0.01),
+        fault.WithSLOGuard(g))
+    g.Start()
+    defer g.Stop()
+
+Envoy Runtime Values
+
+A Runtime reads runtime values laid out like Envoy's runtime, so the playbooks that tune the
+fault.http.abort.abort_percent and fault.http.delay.fixed_delay_percent keys of Envoy's fault filter
+also tune Faults. Each file below a disk layer directory is a key named by its path, and values set
+through Runtime.Set() or Runtime.AdminHandler() override the disk layers until they are set to "".
+Bind a Fault to a key to set its participation whenever the value changes. An integer value is a
+numerator over the denominator of the default, like in Envoy.
+
+    rt, err := fault.NewRuntime(fault.WithDiskLayers("/srv/runtime/current"))
+    rt.Bind(errorFault, fault.RuntimeAbortPercent, fault.FractionalPercent{Denominator: fault.PerHundred})
+    rt.Bind(slowFault, fault.RuntimeDelayPercent, fault.FractionalPercent{Denominator: fault.PerHundred})
+    rt.Start()
+    defer rt.Stop()
+    adminMux.Handle("/runtime", rt.AdminHandler())
+
+Pass WithApprovalThreshold() to NewRuntime() to require a second operator for risky admin changes.
+A POST to the AdminHandler that raises a Fault with a destructive Injector, such as a
+RejectInjector, or raises any Fault above the threshold is not applied. It responds 202 Accepted
+with a PendingChange, and the change takes effect once a different operator POSTs its token in the
+X-Fault-Approve header. Operators are named by a named AdminToken, by WithOperatorFunc(), or else
+by the X-Fault-Operator header. Any client can set the header, so an approval is only accepted from
+an operator named by a token or WithOperatorFunc(). Pending changes expire after WithApprovalTTL().
+
+    rt, err := fault.NewRuntime(fault.WithApprovalThreshold(0.1))
+
+To delegate limited control, issue scoped AdminTokens with Runtime.IssueToken(). Once a token is
+issued, the AdminHandler requires every request to send one as a bearer token. Each token allows
+some AdminOperations, and WithTokenFaults() and WithTokenRange() limit which Faults it may change
+and how far. A named token is also the operator of its changes for two-person approval.
+
+    // the search team may only adjust search-latency between 0% and 10%
+    tok, err := rt.IssueToken(
+        fault.WithTokenName("search-team"),
+        fault.WithTokenFaults("search-latency"),
+        fault.WithTokenRange(0.0, 0.1),
+    )
+    sendToSearchTeam(tok.Secret())
+
+Previewing Faults
+
+Before enabling a Fault you can estimate its blast radius. PreviewRequests() counts how many
+requests from a sample the Fault would match and how many it is expected to inject on, scaled to
+requests per minute. Pass WithRequestSampling() to NewManager to keep the metadata of recent
+requests and call Manager.Preview() to preview a Fault against live traffic.
+
+To debug targeting rules, ExplainRequest() and Manager.Explain() report whether each Fault would
+apply to a request and, if not, the SkipReason, without rolling participation or reporting.
+Manager.ExplainHandler() serves the same dry evaluation on an admin port: POST it a JSON
+RequestDescription of the method, path, host, and headers of a request.
+
+    mux.Handle("/debug/faults/explain", m.ExplainHandler())
+
 Allowing & Blocking Paths
 
 The NewFault() constructor has WithPathBlocklist() and WithPathAllowlist() options. Any path you
@@ -84,19 +863,505 @@ Specifying very large lists of paths or headers may cause memory or performance
 running into these problems you should instead consider using your http router to enable the
 middleware on only a subset of your routes.
 
+Matching Requests
+
+Pass WithRequestMatcher() to NewFault() to target requests with your own logic. A request must
+pass every RequestMatcher, as well as the allowlists and blocklists, before the Fault considers it.
+The faultopenapi package provides RequestMatchers that target operations in an OpenAPI document
+by operationId or tag:
+
+    spec, _ := faultopenapi.LoadFile("openapi.yaml")
+    m, _ := spec.MatchOperations("getUser", "listUsers")
+    f, _ := fault.NewFault(ei, fault.WithEnabled(true), fault.WithRequestMatcher(m))
+
+To fault one virtual host of a server that serves several, match the request Host with MatchHost(),
+MatchHostSuffix(), or MatchHostRegexp():
+
+    f, _ := fault.NewFault(ei, fault.WithRequestMatcher(fault.MatchHostSuffix(".staging.example.com")))
+
+To scope a Fault to endpoints, match the request path with MatchPath(), MatchPathPrefix(), or
+MatchPathRegexp(), and the method with MatchMethod(). Combine alternatives with MatchAny(), and pass
+WithRequestExcluder() to leave out the requests a RequestMatcher matches:
+
+    f, _ := fault.NewFault(ei,
+        fault.WithRequestMatcher(fault.MatchMethod(http.MethodPost)),
+        fault.WithRequestMatcher(fault.MatchPathPrefix("/api/v1/payments")),
+        fault.WithRequestExcluder(fault.MatchPathRegexp(regexp.MustCompile(`/refunds$`))),
+    )
+
+To run experiments in production on synthetic or canary traffic only, pass WithOptInHeader(). The
+Fault then only runs on requests that send the opt-in header, by default X-Fault-Inject, optionally
+with one of a list of values such as the name of the experiment:
+
+    f, _ := fault.NewFault(ei,
+        fault.WithEnabled(true),
+        fault.WithParticipation(1.0),
+        fault.WithOptInHeader("", "checkout-errors"),
+    )
+
+MatchLanguage() limits visible faults to a pseudo-locale used by internal testers, and
+MatchHeaderToken() matches any header holding a comma-separated list of tokens.
+
+To direct Faults at internal test accounts and synthetic monitors only, match the client:
+MatchClientIP() matches the client IP against CIDR prefixes, MatchUserAgent() the User-Agent against
+a regexp, MatchCookie() the value of a cookie, and MatchJWTClaim() a claim of the Bearer token,
+which is read without verifying it. Combine them with MatchAll() and MatchAny():
+
+    f, _ := fault.NewFault(ei,
+        fault.WithEnabled(true),
+        fault.WithParticipation(1.0),
+        fault.WithRequestMatcher(fault.MatchAny(
+            fault.MatchAll(
+                fault.MatchClientIP(netip.MustParsePrefix("10.0.0.0/8")),
+                fault.MatchJWTClaim("sub", "test-account-1", "test-account-2"),
+            ),
+            fault.MatchUserAgent(regexp.MustCompile(`^Datadog/Synthetics`)),
+        )),
+    )
+
+A PatternMatcher matches requests with a net/http ServeMux pattern, using the same rules as the
+ServeMux your routes are registered on, even when the Fault wraps the whole ServeMux. Use Where() to
+target requests by the values of the pattern's wildcards, and PathValues() to read them yourself:
+
+    m, _ := fault.NewPatternMatcher("DELETE /users/{id}")
+    m = m.Where(func(v map[string]string) bool { return strings.HasPrefix(v["id"], "test-") })
+    f, _ := fault.NewFault(ei, fault.WithRequestMatcher(m))
+
+To run different Faults on different routes, register them on a Router with the patterns of your
+ServeMux and wrap the ServeMux once. Each request runs the Faults of the most specific pattern it
+matches, so registering a narrower pattern without Faults exempts its requests:
+
+    rt := fault.NewRouter()
+    rt.Register("GET /users/{id}", slowFault)
+    rt.Register("/api/", errorFault, resetFault)
+    rt.Register("GET /api/health")
+    http.ListenAndServe(":8080", rt.Handler(mux))
+
+To emulate a zonal outage from inside the application, limit a Fault to the instances in a zone
+with MatchZone(), or in a region with MatchRegion(). They match every request on instances in the
+zone or region and none elsewhere. Read the Deployment of the instance from the FAULT_ZONE and
+FAULT_REGION environment variables with DeploymentFromEnv(), or from the metadata service with
+DeploymentFromEC2() or DeploymentFromGCE():
+
+    ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+    d, err := fault.DeploymentFromEC2(ctx, nil)
+    cancel()
+    if err != nil {
+        d = fault.DeploymentFromEnv()
+    }
+    f, _ := fault.NewFault(ri, fault.WithRequestMatcher(fault.MatchZone(d, "us-east-1a")))
+
+For policies that depend on more than the request, pass WithDecisionHook(). The Fault calls the hook
+once participation is decided, with whether it would inject, and the hook can veto the injection.
+Vetoed requests are skipped with SkipVeto:
+
+    f, _ := fault.NewFault(si,
+        fault.WithEnabled(true),
+        fault.WithParticipation(0.05),
+        fault.WithDecisionHook(func(r *http.Request, willInject bool) bool {
+            return !breaker.Open()
+        }),
+    )
+
+Idempotency Safety
+
+RejectInjector, PanicInjector, and PartialResponseInjector are destructive: the client cannot tell
+if its request was applied, and retrying a non-idempotent request such as a POST can duplicate
+writes. Pass WithIdempotencySafety() to NewFault() to stop destructive Injectors from running on
+requests that are not idempotent. By default requests are classified by http method, or pass your
+own IdempotencyClassifier to classify by operation and to allow destructive faults on an operation
+anyway. ChainInjector and RandomInjector are destructive if any of their Injectors is, and custom
+Injectors can implement DestructiveInjector.
+
+Overriding Faults per Request
+
+To reproduce a failure on demand, or to rule a Fault out while debugging, pass WithOverrideSecret()
+to NewFault() and send a signed Override in the X-Fault-Override header. OverrideForce runs the
+Injector even if the Fault is disabled, does not match the request, or would not select it for
+participation; OverrideSuppress skips it with SkipOverride. Cooldowns, fairness, rate limits,
+budgets, and Blackouts still apply. Overrides are signed with HMAC-SHA256 and expire, so clients
+without the secret cannot forge one and a leaked header cannot be replayed for long. Requests with
+an invalid Override are evaluated as usual.
+
+    f, err := fault.NewFault(ei, fault.WithName("checkout-errors"), fault.WithOverrideSecret(secret))
+
+    o := fault.Override{
+        Action:  fault.OverrideForce,
+        Faults:  []string{"checkout-errors"},
+        Expires: time.Now().Add(5 * time.Minute),
+    }
+    v, err := o.Sign(secret)
+    req.Header.Set(fault.OverrideHeader, v)
+
+Splitting Reads and Writes
+
+Reads can usually take far more punishment than writes. NewSplitFault() builds a SplitFault, a pair
+of Faults from one definition: the read Fault runs an aggressive Injector on requests for safe
+methods, and the write Fault runs a conservative Injector on the rest at WithWriteScale() of the
+read participation, 10% by default. Pass WithReadMatcher() to decide what is a read by route
+instead. Change the participation, schedule, and state of the pair through the SplitFault to keep
+them in sync:
+
+    sf, _ := fault.NewSplitFault(ri, si,
+        fault.WithName("checkout"),
+        fault.WithParticipation(0.2),
+        fault.WithFaultOptions(fault.WithEnabled(true)),
+    )
+    m.Add(sf.Faults()...)
+
+    sf.SetParticipation(0.5) // checkout-read at 50%, checkout-write at 5%
+
+Schedules
+
+Pass WithSchedule() to NewFault() to run a Fault only during game day hours instead of toggling it
+by hand. A WindowSchedule is active between fixed start and end times, and a CronSchedule is active
+for a duration each time a cron expression matches in a timezone. Outside of its Schedule an
+enabled Fault skips requests with SkipSchedule. Any type with an Active(time.Time) bool method is a
+Schedule.
+
+    loc, _ := time.LoadLocation("America/New_York")
+    // every Tuesday and Thursday from 14:00 to 16:00 in New York
+    s, err := fault.NewCronSchedule("0 14 * * 2,4", 2*time.Hour, loc)
+
+    f, err := fault.NewFault(ei,
+        fault.WithEnabled(true),
+        fault.WithParticipation(0.25),
+        fault.WithSchedule(s),
+    )
+
+Wrap a Schedule with NewJitteredSchedule() to delay its start on each instance of a fleet by up to
+a maximum, so thousands of instances do not begin injecting in the same second and create a cliff
+in dashboards. Like FleetMember(), each instance picks its delay by hashing its id, and StartJitter()
+returns it. The Schedule still stops at the same time on every instance.
+
+    js, err := fault.NewJitteredSchedule(s, "game-day", os.Getenv("POD_NAME"), 30*time.Second)
+
+Cooldowns
+
+With a low participation, the same unlucky client can still be hit again and again. Pass
+WithCooldown() to NewFault() to exempt a client from the Injector for a while after it runs on one
+of its requests. Clients are told apart by IP by default, or pass a function that returns the
+client of a request, such as its session. Requests skipped during a cooldown are skipped with
+SkipCooldown.
+
+    f, err := fault.NewFault(ei,
+        fault.WithEnabled(true),
+        fault.WithParticipation(0.01),
+        fault.WithCooldown(10*time.Minute, func(r *http.Request) string {
+            return r.Header.Get("X-Session-Id")
+        }),
+    )
+
+A few busy clients can also take most of the injections and skew the results of an experiment. Pass
+WithClientFairness() to NewFault() so no client receives more than a share of the injections in a
+sliding window. Requests of clients that received their share are skipped with SkipFairness.
+
+    f, err := fault.NewFault(ei,
+        fault.WithEnabled(true),
+        fault.WithParticipation(0.05),
+        fault.WithClientFairness(0.01, time.Hour, nil),
+    )
+
+Real partial outages are consistent: an endpoint that starts failing fails every request for a
+while. Pass WithStickyTTL() to NewFault() to run the Injector on every request to the same route
+for a TTL after it runs on one, instead of rolling participation for each request. Routes are told
+apart by path by default, or pass a function that returns the route of a request. Forced requests
+report an Evaluation with Sticky set.
+
+    f, err := fault.NewFault(ei,
+        fault.WithEnabled(true),
+        fault.WithParticipation(0.001),
+        fault.WithStickyTTL(5*time.Minute, nil),
+    )
+
+Rate Limits and Budgets
+
+A participation percent injects more faults the more traffic there is, which can hammer a service
+during a spike. Pass WithInjectionRate() to NewFault() to cap the injections per second or minute
+with a token bucket, and WithInjectionBudget() to cap them for the whole experiment. Requests over
+the rate are skipped with SkipRateLimit. Once the budget is used up the Fault disables itself and
+reports an Event of type EventBudgetExhausted to an EventReporter; enabling it again starts a new
+budget.
+
+    f, err := fault.NewFault(ei,
+        fault.WithEnabled(true),
+        fault.WithParticipation(0.1),
+        fault.WithInjectionRate(10, time.Second),
+        fault.WithInjectionBudget(1000),
+    )
+
+A rate does not bound how many injected requests are in flight: at 5% of a busy service, a
+SlowInjector that waits 30 seconds can hold every connection of the server. Pass WithMaxConcurrent()
+to NewFault() to limit the requests the Injector runs on at once. Requests the Fault selects beyond
+the limit pass through to the handler and are skipped with SkipConcurrency:
+
+    f, err := fault.NewFault(si,
+        fault.WithEnabled(true),
+        fault.WithParticipation(0.05),
+        fault.WithMaxConcurrent(50),
+    )
+
 Custom Injectors
 
 The fault package provides an Injector interface and you can satisfy that interface to provide your
 own Injector. Use custom injectors to add additional logic to the package-provided injectors or to
 create your own completely new Injector that can still be managed by a Fault.
 
+For a one-off Injector, convert a function with fault.InjectorFunc, the way http.HandlerFunc
+converts a function to an http.Handler:
+
+    f, err := fault.NewFault(fault.InjectorFunc(func(next http.Handler) http.Handler {
+        return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+            w.Header().Set("Cache-Control", "no-store")
+            next.ServeHTTP(w, r)
+        })
+    }), fault.WithName("no-store"))
+
+Implement the optional Validator interface to check the configuration of a custom Injector up front.
+NewFault, Fault.SetInjector(), NewSandboxInjector(), NewTransport(), and the composite Injectors
+call Validate() and return its error instead of accepting an invalid Injector.
+
+Embed a fault.InjectorReporter in a custom Injector to get the Reporter wiring of the package
+Injectors: it implements ReporterSetter, so composite Injectors propagate their Reporter to it, and
+its ReportHandler() reports StateStarted and StateFinished around the handler of the Injector.
+Custom Injectors that combine other Injectors can call PropagateReporter() to pass their Reporter
+on in turn.
+
+Wrap custom and third party Injectors in a fault.SandboxInjector so that a panic in the Injector
+cannot take down your service. The panic is recovered, reported with StatePanicked, and the request
+continues to your handler. Use WithPanicPolicy() to respond with a 500 or re-panic instead. Panics
+in your handler and http.ErrAbortHandler are never recovered.
+
+    si, err := fault.NewSandboxInjector(customInjector)
+
+Pass WithDeadline() to also bound how long the Injector may run before it continues the request.
+Past the deadline the request continues without the Injector, or fails with the code passed to
+WithDeadlineCode(), and the Injector's request context is canceled.
+
+    si, err := fault.NewSandboxInjector(customInjector,
+        fault.WithDeadline(5*time.Second),
+        fault.WithDeadlineCode(http.StatusServiceUnavailable),
+    )
+
+Lifecycle Hooks
+
+Stateful Injectors can implement the optional EnableHook, DisableHook, and ConfigChangeHook
+interfaces to reset or clean up when their Fault changes. OnEnable runs when a Fault is created
+enabled or enabled with Fault.SetEnabled(), OnDisable runs when it is disabled, and OnConfigChange
+runs after Fault.SetParticipation(). ChainInjector and RandomInjector pass each hook on to the
+Injectors they hold.
+
+Describing Injectors
+
+Every Injector in the package implements Describer, returning a short Name() like "slow" and its
+parameters from Describe(), and fmt.Stringer, returning a summary like "slow(750ms)". Reporters,
+evaluations, and stats use InjectorString() to identify Injectors, so implement these interfaces on
+your custom Injectors for readable reports. Injectors that do not implement them are identified by
+their type name.
+
 Reporter
 
 The package provides a Reporter interface that can be added to Faults and Injectors using the
-WithReporter option. A Reporter will receive events when the state of the Injector changes. For
-example, Reporter.Report(InjectorName, StateStarted) is run at the beginning of all Injectors. The
-Reporter is meant to be provided by the consumer of the package and integrate with services like
-stats and logging. The default Reporter throws away all events.
+WithReporter option. Every Fault and Injector in the package also implements ReporterSetter, so the
+Reporter can be read and replaced after construction with Reporter() and SetReporter(). A Reporter
+will receive events when the state of the Injector changes. For example,
+Reporter.Report(InjectorName, StateStarted) is run at the beginning of all Injectors. Pass
+WithReporter() to NewFault to also receive the decisions of an enabled Fault, named by the
+WithName() option: StateUnmatched when a request does not pass the allowlists and blocklists,
+StateSkipped when a request is not selected by the participation roll, and StateSelected when the
+Injector will run. This makes a Fault that is enabled but never injecting visible. The Reporter is
+meant to be provided by the consumer of the package and integrate with services like stats and
+logging. The default Reporter throws away all events.
+
+Pass WithReporterPropagation(true) to NewChainInjector() or NewRandomInjector() to give their
+Reporter to each of their Injectors that has no Reporter of its own, both at construction and on
+every SetReporter(), instead of setting the same Reporter on every layer by hand.
+
+A Reporter passed to NewFault that also implements EventReporter receives a structured Event for
+each request the Fault handles: EventStarted and EventFinished around every injection, and
+EventSkipped with the SkipReason when an enabled Fault does not inject. Each Event carries the time,
+the Fault and Injector names, the method, host, path, and remote address of the request, and the
+finished Event adds the status code written and the duration of the injection. The status code is 0
+when the Injector aborted the request or took over its connection. Events marshal to JSON, so an
+EventReporter can write them straight to a log. Events are reported while the request is in flight,
+with the request, so an EventReporter can annotate the span of the request. The faultotel package
+provides one that adds a span event to the OpenTelemetry span of every injected request.
+
+The reporters/prometheus package provides an EventReporter that exports the injections, skips, and
+injection durations of Faults as Prometheus metrics. The reporters package provides a
+MemoryReporter that keeps the last Events in a ring buffer, to query in code or dump as JSON from
+its Handler, so you can check if a Fault fired for a request without a logging pipeline.
+
+Tracing
+
+Pass WithTracing(true) and WithReporter() to NewFault to debug why a Fault did or did not run on a
+request. While tracing, the Fault sends an Evaluation for every request it sees to any Reporter that
+also implements EvaluationReporter. The Evaluation records if the Fault was enabled, if the request
+matched the allowlists and blocklists, the participation roll, the Injector, and the SkipReason.
+Tracing reports on every request and should only be enabled while debugging.
+
+Injection History
+
+Every Injector that runs on a request is recorded in the request's context, including the Injectors
+inside a ChainInjector or the one a RandomInjector picked. Call HistoryFromContext() in your
+handler, or in middleware after the Faults, to get the ordered list of the Faults that ran an
+Injector and the Injector names, parameters, and start and end times, so downstream code can log or
+adapt to injected faults. InjectedFromContext() reports if any Injector ran at all, for example to
+tag logs or skip caching a faulted response.
+
+Access Logs
+
+To join faults with request outcomes in your logs, call ContextWithDecisions() in your access-log
+middleware before the Faults. Every Fault that evaluates the request records a Decision with its
+name, its Injector, whether it injected or why it skipped, and the latency its Injector added.
+Read them back with DecisionsFromContext() once the request is served. A Decision encodes as JSON,
+as logfmt with String(), and as a log/slog group.
+
+    r = r.WithContext(fault.ContextWithDecisions(r.Context()))
+    next.ServeHTTP(w, r)
+    logger.Info("request", "status", status, "faults", fault.DecisionsFromContext(r.Context()))
+
+Experiments
+
+Pass WithCohortAssigner() to run a Fault as an experiment. A CohortAssigner hashes a unit of each
+request (the client IP by default, or a user id with WithUnitHeader() or WithUnitFunc()) to
+deterministically split traffic into control and treatment cohorts. Only the treatment cohort is
+faulted. Every assignment is sent as an Exposure to Reporters that implement ExposureReporter, so
+you can compare the cohorts statistically afterwards.
+
+    a, _ := fault.NewCohortAssigner("checkout-latency", 0.1, fault.WithUnitHeader("X-User-ID"))
+    f, _ := fault.NewFault(si,
+        fault.WithEnabled(true),
+        fault.WithParticipation(1.0),
+        fault.WithCohortAssigner(a),
+        fault.WithReporter(exposureLogger),
+    )
+
+To fault a percent of the instances in a fleet instead of a percent of the requests, pass
+WithFleetPercent(). Each instance hashes its id, such as its pod name, to decide on its own if it
+takes part in the experiment, so no coordination backend is needed. On the other instances every
+request is unmatched.
+
+    f, _ := fault.NewFault(ei,
+        fault.WithEnabled(true),
+        fault.WithParticipation(1.0),
+        fault.WithFleetPercent("checkout-errors", os.Getenv("POD_NAME"), 0.1),
+    )
+
+To ramp a Fault up instead of scripting its participation from outside the service, give it to an
+Experiment with a RampProfile. NewLinearRamp() changes the participation linearly over a duration,
+NewStepRamp() changes it in steps, and NewSpikeRamp() spikes it and then holds it lower. Start()
+enables the Fault and updates its participation every interval (default 10s, set with
+WithCheckInterval()), and the last participation is held once the profile ends. Stop() disables the
+Fault, honoring WithRampDown(), and Abort() disables it at once. Every transition is sent to
+Reporters that implement ExperimentReporter.
+
+    r, _ := fault.NewLinearRamp(0.0, 0.1, 30*time.Minute)
+    e, _ := fault.NewExperiment(f, r, fault.WithReporter(transitionLogger))
+    e.Start()
+    defer e.Stop()
+
+Stopping a Fault does not end the requests it already injected into, which may be held in a long
+sleep or a throttled body. To tear down an experiment aborted mid-game-day, call Fault.Close() or
+Experiment.Close() with a deadline: the Fault stops injecting at once and for good, Close waits for
+the injected requests still running, and once the deadline passes it cancels their contexts with
+ErrFaultClosed as the cause. Requests that reach a closed Fault are skipped with SkipClosed, and
+the final FaultStats are sent in a CloseSummary to Reporters that implement CloseReporter.
+
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+    err := e.Close(ctx)
+
+Expvar
+
+Each Fault counts the requests it has evaluated, injected, and skipped, and the requests currently
+inside its Injector (active). Call PublishExpvar() to publish these counters to expvar under the
+"go-fault" key, named by the WithName() option. NewExpvarHandler() returns an opt-in http.Handler
+that serves only the Fault counters, which you can mount at a path like /debug/faults/vars.
+
+Skipped requests are also counted by SkipReason, under keys like "skipped_disabled" and
+"skipped_participation", so you can tell a misconfigured Fault that never matches from one that is
+only being held back by its participation.
+
+PublishExpvar() shares one set of Faults across the process. To fault a server and its outbound
+clients with separate Managers in one process, such as in a test harness, serve each Manager's own
+counters with Manager.ExpvarHandler(), or publish each set of Faults to its own ExpvarPublisher.
+Nothing else in the package is shared between Managers: every Fault and Injector rolls its own
+seeded random source, and a config only uses the custom injector types of the InjectorRegistry it
+is given with WithInjectorRegistry().
+
+    clientVars := fault.NewExpvarPublisher()
+    clientVars.Publish(clientFaults...)
+    expvar.Publish("go-fault-client", clientVars)
+
+Without expvar, call Fault.Stats() for a FaultStats snapshot of the same counters, which also
+breaks injections down by Injector and reports the ObservedParticipation, the share of requests
+that reached the participation roll and were injected. Compare it with the configured
+Participation to check an experiment. NewStatsHandler() and Manager.StatsHandler() serve the
+FaultStats of several Faults as JSON:
+
+    mux.Handle("/debug/faults/stats", m.StatsHandler())
+
+Labels
+
+Pass WithLabels() to NewFault to tag a Fault with labels, such as the team that owns it and the
+experiment it belongs to, so organizations running many experiments at once can attribute their
+impact. The labels are set on every Event the Fault reports, shown in its FaultStatus and its expvar
+counters, and can be added to the metrics of the reporters/prometheus package and the span events
+of the faultotel package. Manager.StatsByLabel() rolls the counters of the managed Faults up by the
+value of a label. Faults can also be labeled in a config under "labels".
+
+    f, _ := fault.NewFault(si,
+        fault.WithEnabled(true),
+        fault.WithLabels(map[string]string{"team": "checkout", "experiment": "q3-gameday"}),
+    )
+    m.Add(f)
+    byTeam := m.StatsByLabel("team")
+    fmt.Println(byTeam["checkout"]["injected"])
+
+Latency Sampling
+
+Counting injections does not tell you how much an experiment hurts. Pass a LatencySampler to
+NewFault with WithLatencySampler() to record the latency of every request the Fault sees. The
+sampler keeps a histogram of baseline (not injected) and faulted (injected) latencies for each
+route, by default the request path, so you can compare the two with LatencySampler.Snapshot().
+
+To see how an experiment affects your success rate, pass an OutcomeRecorder to NewFault with
+WithOutcomeRecorder(). It records the final status code and duration of every request, faulted or
+not, per Fault and per route, so you do not need a separate metrics middleware. Requests whose
+handler panicked are counted under status 0. Share one OutcomeRecorder between Faults and read it
+with OutcomeRecorder.Snapshot().
+
+    or, _ := fault.NewOutcomeRecorder()
+    f, _ := fault.NewFault(i, fault.WithOutcomeRecorder(or))
+    rate := or.Snapshot()[f.Name()]["/orders"].Faulted.SuccessRate()
+
+An experiment only needs to run until its impact is known. A Concluder compares the faulted and
+baseline requests the OutcomeRecorder of a Fault recorded, and disables the Fault once at least
+WithMinSamples() requests were injected, the success rate impact is known to within
+WithImpactTolerance() at 95% confidence, and the success rate and latency impacts have held steady
+for WithStableChecks() checks. The Conclusion holds the measured impact.
+
+    c, _ := fault.NewConcluder(f, fault.WithMinSamples(500),
+        fault.WithConclusionFunc(func(c fault.Conclusion) {
+            log.Printf("%s: %.1f%% more errors, %s slower", c.Fault, c.SuccessImpact*100, c.LatencyImpact)
+        }))
+    c.Start()
+    defer c.Stop()
+
+To simulate latency that looks like production, capture it first. Wrap your handler with a
+LatencyCapture to record the latency of real requests to each route for a period, then write its
+LatencyProfile to a file. Later, read the file with ReadLatencyProfile() and pass it to
+NewDistributionInjector. The DistributionInjector waits a latency drawn from the captured
+distribution of each request's route.
+
+    lc, _ := fault.NewLatencyCapture(time.Hour)
+    handler := lc.Handler(mux)
+    <-lc.Done()
+    lc.Profile().Write(file)
+
+    p, _ := fault.ReadLatencyProfile(file)
+    di, _ := fault.NewDistributionInjector(p)
 
 Random Seeds
 
@@ -104,6 +1369,59 @@ By default all randomness is seeded with defaultRandSeed(1), the same default as
 helps you reproduce any errors you see when running an Injector. If you prefer, you can also
 customize the seed passing WithRandSeed() to NewFault and NewRandomInjector.
 
+Every Fault and Injector that makes random decisions implements Seeder, so you can find the seed it
+is using. InjectorSeeds() collects the seeds of an Injector and the Injectors it wraps. Seeds are
+also reported in each traced Evaluation, along with how many rolls the Fault has made, and published
+to expvar. Build a Fault with WithRandSeed() and the same seed to replay its decisions in a test.
+
+Each Fault rolls with a generator of its own, so Faults neither share the lock of the global
+math/rand source nor each other's sequence. Pass WithRand() to give a Fault a generator with a custom
+rand.Source, or WithRandFloat32Func() to script its rolls in a test:
+
+    rolls := []float32{0.1, 0.9}
+    f, _ := fault.NewFault(ei, fault.WithEnabled(true), fault.WithParticipation(0.5),
+        fault.WithRandFloat32Func(func() float32 {
+            rn := rolls[0]
+            rolls = rolls[1:]
+            return rn
+        }))
+
+Pass WithRequestIDSeed() with a request ID header, such as X-Request-Id, to seed the participation
+roll of each request from its ID instead. A retried request that keeps its ID gets the same
+decision as the original, on every instance running a Fault with the same name and seed, so an
+experiment can tell how clients retry apart from how fresh requests fail:
+
+    f, _ := fault.NewFault(ei, fault.WithEnabled(true), fault.WithParticipation(0.1),
+        fault.WithRequestIDSeed("X-Request-Id"))
+
+Requests without the header roll randomly, and the request ID is recorded in traced Evaluations.
+
+Pass WithParticipationKey() to seed the roll from a key of the request instead, such as a user ID
+header, a session cookie, or the IP of the client. The same user then consistently gets the fault or
+consistently does not, which makes a chaos test of a user journey reproducible and keeps a page from
+half failing:
+
+    f, _ := fault.NewFault(ei, fault.WithEnabled(true), fault.WithParticipation(0.1),
+        fault.WithParticipationKey(fault.CookieKey("session")))
+
+Pass WithConnectionDecision() to decide once per client connection instead, simulating a bad
+backend connection that misbehaves for every request sent over it, including the streams of an
+HTTP/2 connection, while the other connections of the client are fine. Set fault.NewConnContext()
+as the ConnContext of the http.Server, or use Instrument(), so connections that reuse a port are
+told apart.
+
+    srv := &http.Server{Handler: f.Handler(mux), ConnContext: fault.NewConnContext()}
+
+Pass WithParticipationStrategy() to replace the random roll with a ParticipationStrategy.
+EveryNth() deterministically selects every nth request regardless of the participation, and
+EvenParticipation() selects exactly the participation of requests spread evenly, so 0.1 faults
+every 10th request. Implement ParticipationStrategy, or use ParticipationStrategyFunc, for your own
+rule; it receives the current participation of the Fault. Request IDs and participation keys still
+take precedence:
+
+    s, _ := fault.EveryNth(10)
+    f, _ := fault.NewFault(ei, fault.WithEnabled(true), fault.WithParticipationStrategy(s))
+
 Custom Injector Functions
 
 Some Injectors support customizing the functions they use to run their injections. You can take
@@ -121,12 +1439,103 @@ passing WithRandIntFunc() to NewRandomInjector().
 Customize the function a SlowInjector uses to wait (default: time.Sleep) by passing WithSlowFunc()
 to NewSlowInjector().
 
+Clocks
+
+Time-based features tell time and wait using a Clock (default: RealClock, backed by the time
+package). Pass WithClock() to replace it. The faulttest package provides a fake Clock that only
+moves forward when advanced, so time-based behavior can be tested without real sleeps.
+
 Configuration
 
 All configuration for the fault package is done through options passed to NewFault and NewInjector.
-There is no other way to manage configuration for the package. It is up to the user of the fault
-package to manage how the options are generated. Common options are feature flags, environment
-variables, or code changes in deploys.
+It is up to the user of the fault package to manage how the options are generated. Common options
+are feature flags, environment variables, or code changes in deploys.
+
+To keep chaos scenarios in config files checked into a repository instead, declare the Faults in
+YAML or JSON and build them with NewFaultsFromConfig(). Each Fault sets its name, participation,
+seed, allowlists and blocklists, and the type and parameters of its injector:
+
+    version: 1
+    faults:
+      - name: checkout-latency
+        enabled: true
+        participation: 0.05
+        path_allowlist: [/checkout]
+        injector:
+          type: chain
+          params:
+            injectors:
+              - type: slow
+                params: {duration: 750ms}
+              - type: error
+                params: {code: 503, headers: {Retry-After: "15"}}
+
+The reject, error, slow, chain, random, and stub injector types are built in. Register your own
+Injectors by name with InjectorRegistry.Register() on a registry from NewInjectorRegistry(), and
+pass it to NewFaultsFromConfig() with WithInjectorRegistry(). An InjectorFactory reads its
+parameters from ConfigParams, which reject parameters of the wrong type with ErrInvalidConfig.
+
+Errors about a field of a config are a *ConfigError, with the path of the field, such as
+"faults[0].injector.params.code", its value, and the reason, which wraps ErrInvalidConfig or the
+error of the Option the value sets, such as ErrInvalidPercent. Tools that edit configs can use
+errors.As() to point at the value to fix. An InjectorFactory returns one for a parameter its
+Injector rejects with ConfigParams.Invalid():
+
+    n, err := p.Int("count", 1)
+    if err != nil {
+        return nil, err
+    }
+    if n < 1 {
+        return nil, p.Invalid("count", ErrInvalidCount)
+    }
+
+The version of a config is the version of its format, ConfigVersion when it was written. Configs of
+every version up to ConfigVersion keep loading as the fault package is upgraded, and configs without
+a version are read as version 1. A config written for a newer version fails with
+ErrUnsupportedConfigVersion instead of with the fields it does not know. MigrateConfig() rewrites a
+config in the current format. The faultctl command does both from the command line, so CI can check
+the configs in a repository:
+
+    $ go install github.com/github/go-fault/cmd/faultctl@latest
+    $ faultctl validate faults/*.yaml
+    $ faultctl migrate -w faults/checkout.yaml
+
+Once an experiment in a config has settled, ConfigToGo() turns the config into Go code that builds
+the same Faults with their constructors, so they can be compiled in and reviewed like other code.
+FaultsToConfig() goes the other way, writing Faults built in Go as a config; options a config cannot
+express are left out. Both know only the built-in injector types.
+
+    $ faultctl gen -package chaos faults/checkout.yaml > chaos/faults.go
+
+NewDynamicFault() runs a Fault from a ConfigSource that it reloads after Start(), so changing a
+participation does not take a redeploy. FileSource(), EnvSource(), and URLSource() read the config
+from a file, an environment variable, or a URL. When the config changes a new Fault is built and
+swapped in atomically; requests in flight finish with the old Fault, and a config that cannot be
+loaded or is invalid keeps the current one.
+
+    d, err := fault.NewDynamicFault(fault.FileSource("/etc/faults.yaml"),
+        fault.WithDynamicFaultName("checkout-latency"),
+        fault.WithReloadInterval(30*time.Second),
+    )
+    d.Start()
+    defer d.Stop()
+
+    handler := d.Handler(mux)
+
+The faultproxy package runs the Faults of a config in a reverse proxy, so integration tests can point
+their real clients at a misbehaving upstream. faultproxy.Start() runs the proxy in-process for the
+length of a test, and the faultproxy command runs it as a container configured by environment
+variables:
+
+    proxy := faultproxy.Start(t, upstream.URL, config)
+    client := api.NewClient(proxy.URL)
+
+To run Faults built in Go in front of a service written in another language, pass them to
+NewReverseProxy(), which forwards the requests they let through to the service:
+
+    target, _ := url.Parse("http://app:8080")
+    h, _ := fault.NewReverseProxy(target, []*fault.Fault{slowFault, errorFault})
+    http.ListenAndServe(":8081", h)
 
 */
 package fault
@@ -0,0 +1,257 @@
+package fault
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrFaultClosed is the cause of the context of a request whose injection was cancelled because
+// its Fault was closed. Handlers can tell it apart from a client disconnect with context.Cause.
+var ErrFaultClosed = errors.New("fault closed")
+
+// CloseSummary describes the end of a Fault closed by Fault.Close.
+type CloseSummary struct {
+	// Fault is the name of the Fault that was closed.
+	Fault string
+	// Time is when the Fault finished closing, as told by the Fault's Clock.
+	Time time.Time
+	// InFlight is the number of injected requests that were running when Close was called.
+	InFlight int64
+	// Cancelled is the number of injected requests that were still running when the context of
+	// Close ended, and had their contexts cancelled.
+	Cancelled int64
+	// Stats are the final Stats of the Fault.
+	Stats FaultStats
+}
+
+// CloseReporter is a Reporter that also receives the CloseSummary of a Fault once it is closed.
+// ReportClose is called on the goroutine calling Close.
+type CloseReporter interface {
+	Reporter
+	ReportClose(s CloseSummary)
+}
+
+// drain tracks the injected requests of a Fault so that closing it can wait for them or cancel
+// them. The zero value is ready to use.
+type drain struct {
+	// mtx protects all fields but closed, which is only set with mtx held so that it can also be
+	// read without it.
+	mtx sync.Mutex
+
+	// closed is true once the Fault is closed, after which no requests enter.
+	closed atomicBool
+	// inflight is the number of injected requests running.
+	inflight int64
+	// idle, if set, is closed once inflight drops to 0.
+	idle chan struct{}
+
+	// ctx is cancelled to cancel the injected requests running. It is created by the first request
+	// that enters.
+	ctx    context.Context
+	cancel context.CancelCauseFunc
+}
+
+// enter counts an injected request and returns true, or returns false if the Fault is closed.
+func (d *drain) enter() bool {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	if d.closed.Load() {
+		return false
+	}
+
+	if d.ctx == nil {
+		d.ctx, d.cancel = context.WithCancelCause(context.Background())
+	}
+	d.inflight++
+
+	return true
+}
+
+// leave counts the end of a request that entered.
+func (d *drain) leave() {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	d.inflight--
+	if d.inflight == 0 && d.idle != nil {
+		close(d.idle)
+		d.idle = nil
+	}
+}
+
+// attach returns r with a context that is cancelled with ErrFaultClosed if the requests of d are
+// cancelled, and a function to call once r is done. r must have entered.
+func (d *drain) attach(r *http.Request) (*http.Request, func()) {
+	d.mtx.Lock()
+	dctx := d.ctx
+	d.mtx.Unlock()
+
+	c := &drainContext{Context: r.Context(), drain: dctx}
+
+	return r.WithContext(c), c.detach
+}
+
+// drainContext is the context of an injected request, cancelled when its parent is or when the
+// requests of its drain are. The two are only merged once the request waits on the context, so
+// requests that never do, and parents that cannot be waited on outside of a server, such as a
+// fasthttp.RequestCtx in a test, cost nothing.
+type drainContext struct {
+	context.Context
+	drain context.Context
+
+	// mtx protects all fields below.
+	mtx sync.Mutex
+	// merged, once set, is the parent cancelled with the cause of drain.
+	merged context.Context
+	cancel context.CancelCauseFunc
+	stop   func() bool
+	// detached is true once the request is done.
+	detached bool
+}
+
+// merge returns the merged context, creating it on the first call.
+func (c *drainContext) merge() context.Context {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if c.merged != nil {
+		return c.merged
+	}
+
+	c.merged, c.cancel = context.WithCancelCause(c.Context)
+	if c.detached {
+		c.cancel(nil)
+		return c.merged
+	}
+
+	// AfterFunc runs in its own goroutine, so a drain already cancelled is merged at once
+	if c.drain.Err() != nil {
+		c.cancel(context.Cause(c.drain))
+	}
+	cancel := c.cancel
+	c.stop = context.AfterFunc(c.drain, func() {
+		cancel(context.Cause(c.drain))
+	})
+
+	return c.merged
+}
+
+// Done returns the done channel of the merged context.
+func (c *drainContext) Done() <-chan struct{} {
+	return c.merge().Done()
+}
+
+// Err returns the error of the merged context.
+func (c *drainContext) Err() error {
+	return c.merge().Err()
+}
+
+// Value returns the value of key in the merged context, or in the parent until they are merged, so
+// context.Cause finds the cause of the merged context.
+func (c *drainContext) Value(key any) any {
+	c.mtx.Lock()
+	merged := c.merged
+	c.mtx.Unlock()
+
+	if merged != nil {
+		return merged.Value(key)
+	}
+
+	return c.Context.Value(key)
+}
+
+// detach stops cancelling the context with drain, and cancels it like the context of a request
+// whose handler returned.
+func (c *drainContext) detach() {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	c.detached = true
+	if c.merged != nil {
+		c.stop()
+		c.cancel(nil)
+	}
+}
+
+// close stops further requests from entering. It returns whether d was already closed, the number
+// of requests running, and a channel that is closed once they are done.
+func (d *drain) close() (bool, int64, <-chan struct{}) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	wasClosed := d.closed.Swap(true)
+
+	if d.inflight == 0 {
+		idle := make(chan struct{})
+		close(idle)
+		return wasClosed, 0, idle
+	}
+
+	if d.idle == nil {
+		d.idle = make(chan struct{})
+	}
+
+	return wasClosed, d.inflight, d.idle
+}
+
+// cancelAll cancels the requests running with ErrFaultClosed and returns how many there were.
+func (d *drain) cancelAll() int64 {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	if d.inflight > 0 {
+		d.cancel(ErrFaultClosed)
+	}
+
+	return d.inflight
+}
+
+// Close stops the Fault for good: it disables it at once with DisableNow, skips new requests with
+// SkipClosed whatever else enables it, and waits for the injected requests that are running, such
+// as those held by a SlowInjector or a throttled body, to finish. If ctx ends first, the contexts of
+// the requests still running are cancelled with ErrFaultClosed, so Injectors that watch the context
+// give up on them, and Close returns the error of ctx without waiting for them further. The
+// CloseSummary is then reported if the Reporter of the Fault is a CloseReporter.
+//
+// Requests that are not injected are not affected, and Calls decided with Decide are waited for
+// until their done function is called but cannot be cancelled. Calling Close again waits for the
+// requests that are still running and does not report again.
+func (f *Fault) Close(ctx context.Context) error {
+	f.DisableNow()
+
+	wasClosed, inflight, idle := f.drain.close()
+
+	var err error
+	var cancelled int64
+	select {
+	case <-idle:
+	case <-ctx.Done():
+		cancelled = f.drain.cancelAll()
+		err = ctx.Err()
+	}
+
+	if wasClosed {
+		return err
+	}
+
+	if cr, ok := f.reporter.(CloseReporter); ok {
+		cr.ReportClose(CloseSummary{
+			Fault:     f.name,
+			Time:      f.now(),
+			InFlight:  inflight,
+			Cancelled: cancelled,
+			Stats:     f.Stats(),
+		})
+	}
+
+	return err
+}
+
+// Closed returns true once Close has been called on the Fault.
+func (f *Fault) Closed() bool {
+	return f.drain.closed.Load()
+}
@@ -0,0 +1,206 @@
+package fault
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testCloseReporter is a reporter that records CloseSummaries.
+type testCloseReporter struct {
+	testReporter
+
+	mtx       sync.Mutex
+	summaries []CloseSummary
+}
+
+// ReportClose records s.
+func (r *testCloseReporter) ReportClose(s CloseSummary) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	r.summaries = append(r.summaries, s)
+}
+
+// TestFaultClose tests that Fault.Close waits for the injected requests running, or cancels them
+// once its context ends.
+func TestFaultClose(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		giveInFlight  bool
+		giveTimeout   time.Duration
+		wantErr       error
+		wantCancelled int64
+	}{
+		{
+			name:        "idle",
+			giveTimeout: time.Second,
+		},
+		{
+			name:         "drained",
+			giveInFlight: true,
+			giveTimeout:  time.Minute,
+		},
+		{
+			name:          "cancelled",
+			giveInFlight:  true,
+			giveTimeout:   10 * time.Millisecond,
+			wantErr:       context.DeadlineExceeded,
+			wantCancelled: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			// the injector holds the request until it is released or its context is cancelled
+			entered := make(chan struct{})
+			release := make(chan struct{})
+			cause := make(chan error, 1)
+			i := InjectorFunc(func(next http.Handler) http.Handler {
+				return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					close(entered)
+					select {
+					case <-release:
+						cause <- nil
+					case <-r.Context().Done():
+						cause <- context.Cause(r.Context())
+					}
+					w.WriteHeader(http.StatusTeapot)
+				})
+			})
+
+			rep := &testCloseReporter{}
+			f, err := NewFault(i, WithName("checkout"), WithEnabled(true), WithParticipation(1.0),
+				WithReporter(rep))
+			assert.NoError(t, err)
+
+			var wg sync.WaitGroup
+			if tt.giveInFlight {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					testRequest(t, f)
+				}()
+				<-entered
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), tt.giveTimeout)
+			defer cancel()
+
+			closed := make(chan error, 1)
+			go func() {
+				closed <- f.Close(ctx)
+			}()
+
+			if tt.giveInFlight && tt.wantErr == nil {
+				assert.Eventually(t, f.Closed, time.Second, time.Millisecond)
+				close(release)
+				assert.NoError(t, <-cause)
+			}
+
+			err = <-closed
+			assert.True(t, errors.Is(err, tt.wantErr), err)
+			if tt.wantCancelled > 0 {
+				assert.True(t, errors.Is(<-cause, ErrFaultClosed))
+			}
+			wg.Wait()
+
+			assert.False(t, f.Enabled())
+			assert.True(t, f.Closed())
+
+			inFlight := int64(0)
+			if tt.giveInFlight {
+				inFlight = 1
+			}
+			assert.Len(t, rep.summaries, 1)
+			assert.Equal(t, "checkout", rep.summaries[0].Fault)
+			assert.Equal(t, inFlight, rep.summaries[0].InFlight)
+			assert.Equal(t, tt.wantCancelled, rep.summaries[0].Cancelled)
+			assert.Equal(t, inFlight, rep.summaries[0].Stats.Injected)
+
+			// a closed Fault does not inject again, and closing it again does not report again
+			f.SetEnabled(true)
+			rr := testRequest(t, f)
+			assert.Equal(t, testHandlerCode, rr.Code)
+			assert.Equal(t, int64(1), f.Stats().SkippedBy[SkipClosed])
+			assert.Equal(t, SkipClosed, ExplainRequest(f, httptest.NewRequest(http.MethodGet, "/", nil)).SkipReason)
+
+			assert.NoError(t, f.Close(context.Background()))
+			assert.Len(t, rep.summaries, 1)
+		})
+	}
+}
+
+// TestFaultCloseDecide tests that Fault.Close waits for Calls decided with Decide until they are
+// done.
+func TestFaultCloseDecide(t *testing.T) {
+	t.Parallel()
+
+	f, err := NewFault(newTestInjectorNoop(), WithEnabled(true), WithParticipation(1.0))
+	assert.NoError(t, err)
+
+	ev, done := f.Decide(Call{Protocol: "sql", Operation: "SELECT"})
+	assert.True(t, ev.Injected)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, f.Close(ctx))
+
+	done()
+	assert.NoError(t, f.Close(context.Background()))
+
+	ev, _ = f.Decide(Call{Protocol: "sql", Operation: "SELECT"})
+	assert.False(t, ev.Injected)
+	assert.Equal(t, SkipClosed, ev.SkipReason)
+}
+
+// TestFaultCloseNotInjected tests that requests that are not injected keep their context.
+func TestFaultCloseNotInjected(t *testing.T) {
+	t.Parallel()
+
+	f, err := NewFault(newTestInjectorNoop(), WithEnabled(false))
+	assert.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	var got context.Context
+	f.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Context()
+	})).ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.Equal(t, req.Context(), got)
+	assert.NoError(t, f.Close(context.Background()))
+}
+
+// TestDrainContext tests that the context of an injected request sees the cancellation of its
+// drain and the end of the request even when it is first waited on after them.
+func TestDrainContext(t *testing.T) {
+	t.Parallel()
+
+	var d drain
+	assert.True(t, d.enter())
+	r, detach := d.attach(httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, int64(1), d.cancelAll())
+
+	assert.Equal(t, context.Canceled, r.Context().Err())
+	assert.Equal(t, ErrFaultClosed, context.Cause(r.Context()))
+	detach()
+
+	var other drain
+	assert.True(t, other.enter())
+	r, detach = other.attach(httptest.NewRequest(http.MethodGet, "/", nil))
+	detach()
+
+	assert.Equal(t, context.Canceled, r.Context().Err())
+	assert.Equal(t, context.Canceled, context.Cause(r.Context()))
+}
@@ -0,0 +1,289 @@
+package fault
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// maxConfigSize is the largest config a URLSource reads.
+const maxConfigSize = 1 << 20
+
+var (
+	// ErrNilConfigSource when a nil ConfigSource is passed.
+	ErrNilConfigSource = errors.New("config source cannot be nil")
+	// ErrConfigNotSet when the environment variable of an EnvSource is not set.
+	ErrConfigNotSet = errors.New("config environment variable is not set")
+	// ErrConfigStatus when the URL of a URLSource does not respond 200 OK.
+	ErrConfigStatus = errors.New("config url did not respond 200 OK")
+	// ErrFaultNotFound when a Fault is looked up by a name no Fault has, such as in the config of a
+	// DynamicFault.
+	ErrFaultNotFound = errors.New("fault not found")
+)
+
+// ConfigSource loads a config in the format of NewFaultsFromConfig.
+type ConfigSource interface {
+	// LoadConfig returns the current config.
+	LoadConfig(ctx context.Context) ([]byte, error)
+}
+
+// ConfigSourceFunc is a function that satisfies ConfigSource.
+type ConfigSourceFunc func(ctx context.Context) ([]byte, error)
+
+// LoadConfig returns f(ctx).
+func (f ConfigSourceFunc) LoadConfig(ctx context.Context) ([]byte, error) {
+	return f(ctx)
+}
+
+// FileSource returns a ConfigSource that reads the file at path.
+func FileSource(path string) ConfigSource {
+	return ConfigSourceFunc(func(ctx context.Context) ([]byte, error) {
+		return os.ReadFile(path)
+	})
+}
+
+// EnvSource returns a ConfigSource that reads the environment variable key. It returns
+// ErrConfigNotSet if key is not set.
+func EnvSource(key string) ConfigSource {
+	return ConfigSourceFunc(func(ctx context.Context) ([]byte, error) {
+		v, ok := os.LookupEnv(key)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrConfigNotSet, key)
+		}
+
+		return []byte(v), nil
+	})
+}
+
+// URLSource returns a ConfigSource that GETs url with client, or http.DefaultClient if client is
+// nil. It returns an error wrapping ErrConfigStatus unless the response is 200 OK, and reads at
+// most 1MiB of the body.
+func URLSource(url string, client *http.Client) ConfigSource {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	return ConfigSourceFunc(func(ctx context.Context) ([]byte, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("%w: %s", ErrConfigStatus, resp.Status)
+		}
+
+		return io.ReadAll(io.LimitReader(resp.Body, maxConfigSize))
+	})
+}
+
+// DynamicFault is a Fault declared in a ConfigSource that is reloaded while it handles requests.
+// When the config changes, a new Fault is built from it and swapped in atomically: requests in
+// flight finish with the Fault they started with and no request is dropped. Changes made to the
+// Fault at runtime, such as by an AdminHandler, last until the next change of the config.
+type DynamicFault struct {
+	source   ConfigSource
+	name     string
+	interval time.Duration
+	clock    Clock
+	registry *InjectorRegistry
+
+	fault atomic.Pointer[Fault]
+
+	// mtx serializes reloads and protects last, the config the Fault was built from.
+	mtx  sync.Mutex
+	last []byte
+
+	runMtx sync.Mutex
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// DynamicFaultOption configures a DynamicFault.
+type DynamicFaultOption interface {
+	applyDynamicFault(d *DynamicFault) error
+}
+
+func (o clockOption) applyDynamicFault(d *DynamicFault) error {
+	d.clock = o.clock
+	return nil
+}
+
+func (o reloadIntervalOption) applyDynamicFault(d *DynamicFault) error {
+	if o <= 0 {
+		return ErrInvalidInterval
+	}
+
+	d.interval = time.Duration(o)
+
+	return nil
+}
+
+func (o injectorRegistryOption) applyDynamicFault(d *DynamicFault) error {
+	d.registry = o.registry
+	return nil
+}
+
+type dynamicFaultNameOption string
+
+func (o dynamicFaultNameOption) applyDynamicFault(d *DynamicFault) error {
+	d.name = string(o)
+	return nil
+}
+
+// WithDynamicFaultName sets the name of the Fault in the config that the DynamicFault runs. Default
+// the first Fault of the config.
+func WithDynamicFaultName(name string) DynamicFaultOption {
+	return dynamicFaultNameOption(name)
+}
+
+// NewDynamicFault returns a DynamicFault with its config loaded from source. Call Start to reload
+// it periodically.
+func NewDynamicFault(source ConfigSource, opts ...DynamicFaultOption) (*DynamicFault, error) {
+	if source == nil {
+		return nil, ErrNilConfigSource
+	}
+
+	// set defaults
+	d := &DynamicFault{
+		source:   source,
+		interval: defaultReloadInterval,
+		clock:    NewRealClock(),
+		registry: NewInjectorRegistry(),
+	}
+
+	// apply options
+	for _, opt := range opts {
+		err := opt.applyDynamicFault(d)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err := d.Reload(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
+
+// Reload loads the config again and, if it changed, swaps in a Fault built from it. If the config
+// cannot be loaded or is invalid, the DynamicFault keeps its Fault and returns the error.
+func (d *DynamicFault) Reload(ctx context.Context) error {
+	b, err := d.source.LoadConfig(ctx)
+	if err != nil {
+		return err
+	}
+
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	if d.last != nil && bytes.Equal(b, d.last) {
+		return nil
+	}
+
+	faults, err := NewFaultsFromConfig(bytes.NewReader(b), WithInjectorRegistry(d.registry))
+	if err != nil {
+		return err
+	}
+
+	f, err := d.pick(faults)
+	if err != nil {
+		return err
+	}
+
+	d.fault.Store(f)
+	d.last = b
+
+	return nil
+}
+
+// pick returns the Fault of the DynamicFault among faults.
+func (d *DynamicFault) pick(faults []*Fault) (*Fault, error) {
+	for _, f := range faults {
+		if d.name == "" || f.Name() == d.name {
+			return f, nil
+		}
+	}
+
+	if d.name == "" {
+		return nil, ErrFaultNotFound
+	}
+
+	return nil, fmt.Errorf("%w: %q", ErrFaultNotFound, d.name)
+}
+
+// Fault returns the Fault built from the current config.
+func (d *DynamicFault) Fault() *Fault {
+	return d.fault.Load()
+}
+
+// Handler runs the Fault built from the current config on each request and then next.
+func (d *DynamicFault) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		d.fault.Load().Handler(next).ServeHTTP(w, r)
+	})
+}
+
+// Start begins reloading the config every interval in a new goroutine. The Fault stays unchanged
+// while the config cannot be loaded or is invalid. It does nothing if the DynamicFault is already
+// started.
+func (d *DynamicFault) Start() {
+	d.runMtx.Lock()
+	defer d.runMtx.Unlock()
+
+	if d.cancel != nil {
+		return
+	}
+
+	var ctx context.Context
+	ctx, d.cancel = context.WithCancel(context.Background())
+	d.done = make(chan struct{})
+
+	go d.run(ctx, d.done)
+}
+
+// Stop stops reloading, cancelling a reload in progress, and waits for it to return. It does
+// nothing if the DynamicFault is not started.
+func (d *DynamicFault) Stop() {
+	d.runMtx.Lock()
+	defer d.runMtx.Unlock()
+
+	if d.cancel == nil {
+		return
+	}
+
+	d.cancel()
+	<-d.done
+
+	d.cancel = nil
+	d.done = nil
+}
+
+// run calls Reload every interval until ctx is done.
+func (d *DynamicFault) run(ctx context.Context, done chan<- struct{}) {
+	defer close(done)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-d.clock.After(d.interval):
+			_ = d.Reload(ctx)
+		}
+	}
+}
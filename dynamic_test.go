@@ -0,0 +1,363 @@
+package fault
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/github/go-fault/faulttest"
+	"github.com/stretchr/testify/assert"
+)
+
+// testDynamicConfig declares two enabled Faults that respond with errors.
+const testDynamicConfig = `
+faults:
+  - name: unavailable
+    enabled: true
+    participation: 1
+    injector: {type: error, params: {code: 503}}
+  - name: teapot
+    enabled: true
+    participation: 1
+    injector: {type: error, params: {code: 418}}
+`
+
+// testConfigSource is a ConfigSource whose config can be changed.
+type testConfigSource struct {
+	mtx    sync.Mutex
+	config string
+	err    error
+}
+
+func (s *testConfigSource) LoadConfig(ctx context.Context) ([]byte, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return []byte(s.config), s.err
+}
+
+func (s *testConfigSource) set(config string, err error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	s.config = config
+	s.err = err
+}
+
+// TestNewDynamicFault tests NewDynamicFault.
+func TestNewDynamicFault(t *testing.T) {
+	t.Parallel()
+
+	errLoad := errors.New("load")
+	clock := faulttest.NewClock(time.Time{})
+
+	registry := NewInjectorRegistry()
+	err := registry.Register("teapot", func(p ConfigParams) (Injector, error) {
+		return NewErrorInjector(http.StatusTeapot)
+	})
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name         string
+		giveSource   ConfigSource
+		giveOptions  []DynamicFaultOption
+		wantName     string
+		wantInterval time.Duration
+		wantClock    Clock
+		wantErr      error
+	}{
+		{
+			name:         "defaults",
+			giveSource:   &testConfigSource{config: testDynamicConfig},
+			wantName:     "unavailable",
+			wantInterval: defaultReloadInterval,
+			wantClock:    NewRealClock(),
+		},
+		{
+			name:       "options",
+			giveSource: &testConfigSource{config: testDynamicConfig},
+			giveOptions: []DynamicFaultOption{
+				WithDynamicFaultName("teapot"),
+				WithReloadInterval(time.Second),
+				WithClock(clock),
+			},
+			wantName:     "teapot",
+			wantInterval: time.Second,
+			wantClock:    clock,
+		},
+		{
+			name:       "registry",
+			giveSource: &testConfigSource{config: "faults: [{name: custom, injector: {type: teapot}}]"},
+			giveOptions: []DynamicFaultOption{
+				WithInjectorRegistry(registry),
+			},
+			wantName:     "custom",
+			wantInterval: defaultReloadInterval,
+			wantClock:    NewRealClock(),
+		},
+		{
+			name:    "nil source",
+			wantErr: ErrNilConfigSource,
+		},
+		{
+			name:       "invalid interval",
+			giveSource: &testConfigSource{config: testDynamicConfig},
+			giveOptions: []DynamicFaultOption{
+				WithReloadInterval(0),
+			},
+			wantErr: ErrInvalidInterval,
+		},
+		{
+			name:       "option error",
+			giveSource: &testConfigSource{config: testDynamicConfig},
+			giveOptions: []DynamicFaultOption{
+				withError(),
+			},
+			wantErr: errErrorOption,
+		},
+		{
+			name:       "load error",
+			giveSource: &testConfigSource{err: errLoad},
+			wantErr:    errLoad,
+		},
+		{
+			name:       "invalid config",
+			giveSource: &testConfigSource{config: "faults: [{unknown: true}]"},
+			wantErr:    ErrInvalidConfig,
+		},
+		{
+			name:       "no faults",
+			giveSource: &testConfigSource{config: "faults: []"},
+			wantErr:    ErrFaultNotFound,
+		},
+		{
+			name:       "name not found",
+			giveSource: &testConfigSource{config: testDynamicConfig},
+			giveOptions: []DynamicFaultOption{
+				WithDynamicFaultName("missing"),
+			},
+			wantErr: ErrFaultNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			d, err := NewDynamicFault(tt.giveSource, tt.giveOptions...)
+			if tt.wantErr != nil {
+				assert.True(t, errors.Is(err, tt.wantErr), err)
+				assert.Nil(t, d)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantName, d.Fault().Name())
+			assert.Equal(t, tt.wantInterval, d.interval)
+			assert.Equal(t, tt.wantClock, d.clock)
+		})
+	}
+}
+
+// TestDynamicFaultReload tests that DynamicFault.Reload swaps the Fault only when the config
+// changes and keeps it when the config cannot be loaded or is invalid.
+func TestDynamicFaultReload(t *testing.T) {
+	t.Parallel()
+
+	errLoad := errors.New("load")
+	source := &testConfigSource{config: testDynamicConfig}
+
+	d, err := NewDynamicFault(source)
+	assert.NoError(t, err)
+
+	serve := func() int {
+		rr := httptest.NewRecorder()
+		d.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(testHandlerCode)
+		})).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		return rr.Code
+	}
+
+	first := d.Fault()
+	assert.Equal(t, http.StatusServiceUnavailable, serve())
+
+	// an unchanged config keeps the Fault and its runtime changes
+	first.SetEnabled(false)
+	assert.NoError(t, d.Reload(context.Background()))
+	assert.Same(t, first, d.Fault())
+	assert.Equal(t, testHandlerCode, serve())
+
+	// a changed config swaps in a new Fault
+	source.set(`
+faults:
+  - name: unavailable
+    enabled: true
+    participation: 1
+    injector: {type: error, params: {code: 502}}
+`, nil)
+	assert.NoError(t, d.Reload(context.Background()))
+	assert.NotSame(t, first, d.Fault())
+	assert.Equal(t, http.StatusBadGateway, serve())
+
+	// errors keep the Fault
+	second := d.Fault()
+
+	source.set("", errLoad)
+	assert.Equal(t, errLoad, d.Reload(context.Background()))
+	assert.Same(t, second, d.Fault())
+
+	source.set("faults: [{injector: {type: unknown}}]", nil)
+	assert.True(t, errors.Is(d.Reload(context.Background()), ErrUnknownInjectorType))
+	assert.Same(t, second, d.Fault())
+
+	source.set("faults: []", nil)
+	assert.True(t, errors.Is(d.Reload(context.Background()), ErrFaultNotFound))
+	assert.Same(t, second, d.Fault())
+	assert.Equal(t, http.StatusBadGateway, serve())
+}
+
+// TestDynamicFaultStartStop tests DynamicFault.Start and DynamicFault.Stop.
+func TestDynamicFaultStartStop(t *testing.T) {
+	t.Parallel()
+
+	clock := faulttest.NewClock(time.Time{})
+	source := &testConfigSource{config: testDynamicConfig}
+
+	d, err := NewDynamicFault(source, WithReloadInterval(time.Second), WithClock(clock))
+	assert.NoError(t, err)
+
+	// stopping before starting does nothing
+	d.Stop()
+
+	d.Start()
+	d.Start()
+
+	clock.BlockUntil(1)
+	source.set(`faults: [{name: changed, injector: {type: reject}}]`, nil)
+	clock.Advance(time.Second)
+
+	// the next wait starts after the reload
+	clock.BlockUntil(1)
+	assert.Equal(t, "changed", d.Fault().Name())
+
+	d.Stop()
+	d.Stop()
+
+	// the DynamicFault can be started again after stopping
+	d.Start()
+	clock.BlockUntil(2)
+	d.Stop()
+}
+
+// TestFileSource tests FileSource.
+func TestFileSource(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "faults.yaml")
+	assert.NoError(t, os.WriteFile(path, []byte(testDynamicConfig), 0o600))
+
+	b, err := FileSource(path).LoadConfig(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, testDynamicConfig, string(b))
+
+	_, err = FileSource(filepath.Join(dir, "missing.yaml")).LoadConfig(context.Background())
+	assert.True(t, errors.Is(err, os.ErrNotExist), err)
+}
+
+// TestEnvSource tests EnvSource. It cannot run in parallel because it sets an environment variable.
+func TestEnvSource(t *testing.T) {
+	t.Setenv("GO_FAULT_TEST_CONFIG", testDynamicConfig)
+
+	b, err := EnvSource("GO_FAULT_TEST_CONFIG").LoadConfig(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, testDynamicConfig, string(b))
+
+	_, err = EnvSource("GO_FAULT_TEST_CONFIG_UNSET").LoadConfig(context.Background())
+	assert.True(t, errors.Is(err, ErrConfigNotSet), err)
+}
+
+// TestURLSource tests URLSource.
+func TestURLSource(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		givePath     string
+		giveClient   bool
+		giveCanceled bool
+		wantConfig   string
+		wantErr      error
+	}{
+		{
+			name:       "default client",
+			givePath:   "/faults.yaml",
+			wantConfig: testDynamicConfig,
+		},
+		{
+			name:       "client",
+			givePath:   "/faults.yaml",
+			giveClient: true,
+			wantConfig: testDynamicConfig,
+		},
+		{
+			name:     "not found",
+			givePath: "/missing.yaml",
+			wantErr:  ErrConfigStatus,
+		},
+		{
+			name:         "canceled",
+			givePath:     "/faults.yaml",
+			giveCanceled: true,
+			wantErr:      context.Canceled,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/faults.yaml" {
+					http.NotFound(w, r)
+					return
+				}
+
+				_, _ = w.Write([]byte(testDynamicConfig))
+			}))
+			defer srv.Close()
+
+			var client *http.Client
+			if tt.giveClient {
+				client = srv.Client()
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tt.giveCanceled {
+				cancel()
+			}
+
+			b, err := URLSource(srv.URL+tt.givePath, client).LoadConfig(ctx)
+			if tt.wantErr != nil {
+				assert.True(t, errors.Is(err, tt.wantErr), err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantConfig, string(b))
+		})
+	}
+
+	_, err := URLSource("://invalid", nil).LoadConfig(context.Background())
+	assert.Error(t, err)
+}
@@ -0,0 +1,110 @@
+package fault
+
+import (
+	"net/http"
+)
+
+// SkipReason describes why a Fault did not run its Injector on a request.
+type SkipReason string
+
+const (
+	// SkipDisabled when the Fault is not enabled.
+	SkipDisabled SkipReason = "disabled"
+	// SkipUnmatched when the request did not pass the Fault's allowlists and blocklists.
+	SkipUnmatched SkipReason = "unmatched"
+	// SkipParticipation when the request was not selected by the participation roll.
+	SkipParticipation SkipReason = "participation"
+	// SkipCohort when the request was not in the treatment cohort of the Fault's experiment.
+	SkipCohort SkipReason = "cohort"
+	// SkipUnsafe when the Injector is destructive and the request is not idempotent.
+	SkipUnsafe SkipReason = "unsafe"
+	// SkipConflict when the Manager's ConflictPolicy gave the request to another Fault.
+	SkipConflict SkipReason = "conflict"
+	// SkipBlackout when the Manager was in a Blackout of its BlackoutCalendar.
+	SkipBlackout SkipReason = "blackout"
+	// SkipCooldown when the Injector ran on the client of the request during its cooldown.
+	SkipCooldown SkipReason = "cooldown"
+	// SkipFairness when the client of the request already received its share of the injections.
+	SkipFairness SkipReason = "fairness"
+	// SkipSchedule when the Fault's Schedule was not active.
+	SkipSchedule SkipReason = "schedule"
+	// SkipRateLimit when the Fault already ran its Injector as often as WithInjectionRate allows.
+	SkipRateLimit SkipReason = "rate_limit"
+	// SkipBudget when the Fault used up the budget set by WithInjectionBudget.
+	SkipBudget SkipReason = "budget"
+	// SkipOverride when the request carried an Override that suppressed the Fault.
+	SkipOverride SkipReason = "override"
+	// SkipFreeze when the FreezeChecker of the Manager reported a change freeze.
+	SkipFreeze SkipReason = "freeze"
+	// SkipConcurrency when the Injector was already running on as many requests as
+	// WithMaxConcurrent allows.
+	SkipConcurrency SkipReason = "concurrency"
+	// SkipVeto when the hook set by WithDecisionHook vetoed the injection.
+	SkipVeto SkipReason = "veto"
+	// SkipClosed when the Fault was closed by Fault.Close.
+	SkipClosed SkipReason = "closed"
+)
+
+// Evaluation describes how a Fault decided whether to run its Injector on a single request.
+type Evaluation struct {
+	// Request is the request that was evaluated.
+	Request *http.Request
+	// Fault is the name of the Fault.
+	Fault string
+	// Enabled is true if the Fault was enabled.
+	Enabled bool
+	// Matched is true if the request passed the Fault's allowlists and blocklists.
+	Matched bool
+	// Roll is the random number rolled for participation. Only set if the request matched.
+	Roll float32
+	// RequestID is the request ID that seeded Roll. Only set if the Fault was built with
+	// WithRequestIDSeed and the request had one.
+	RequestID string
+	// Key is the participation key that seeded Roll. Only set if the Fault was built with
+	// WithParticipationKey and the request had a key but no request ID.
+	Key string
+	// Participation is the percent of requests the Fault was configured to run the Injector on.
+	Participation float32
+	// Injected is true if the Injector ran.
+	Injected bool
+	// Sticky is true if the Injector ran without a participation roll because the TTL set by
+	// WithStickyTTL had not ended for the key of the request.
+	Sticky bool
+	// Override is the action of the Override the request carried for the Fault. Only set if the
+	// Fault was built with WithOverrideSecret and the Override was valid.
+	Override OverrideAction
+	// Injector describes the Fault's Injector, as returned by InjectorString.
+	Injector string
+	// Cohort is the Cohort the request was assigned to. Only set if the Fault runs an experiment
+	// and the request matched and had a unit.
+	Cohort Cohort
+	// SkipReason is why the Injector did not run. Empty if Injected is true.
+	SkipReason SkipReason
+	// Seed is the seed of the Fault's random number generator.
+	Seed int64
+	// Rolls is the number of participation rolls the Fault has made, including this one. Only set
+	// if the request matched and RequestID and Key are empty. A Fault built with WithRandSeed(Seed)
+	// makes the same roll on its Rolls-th roll.
+	Rolls uint64
+}
+
+// EvaluationReporter is a Reporter that also receives an Evaluation for every request a Fault sees
+// while tracing is enabled. Use it to answer why a Fault did or did not run on a request.
+type EvaluationReporter interface {
+	Reporter
+	ReportEvaluation(e Evaluation)
+}
+
+type tracingOption bool
+
+func (o tracingOption) applyFault(f *Fault) error {
+	f.tracing = bool(o)
+	return nil
+}
+
+// WithTracing sets if the Fault should send an Evaluation for every request to its Reporter. The
+// Reporter must implement EvaluationReporter to receive them. Tracing is meant for debugging and
+// reports on every request, so leave it disabled during normal operation.
+func WithTracing(t bool) Option {
+	return tracingOption(t)
+}
@@ -0,0 +1,137 @@
+package fault
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestFaultHandlerTracing tests that Fault.Handler reports Evaluations when tracing.
+func TestFaultHandlerTracing(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		giveInjector Injector
+		giveOptions  []Option
+		want         Evaluation
+	}{
+		{
+			name:         "disabled",
+			giveInjector: newTestInjector500s(),
+			giveOptions: []Option{
+				WithEnabled(false),
+				WithParticipation(1.0),
+			},
+			want: Evaluation{
+				Fault:         "testInjector500s",
+				Enabled:       false,
+				Participation: 1.0,
+				Injector:      "testInjector500s",
+				SkipReason:    SkipDisabled,
+				Seed:          defaultRandSeed,
+			},
+		},
+		{
+			name:         "unmatched",
+			giveInjector: newTestInjector500s(),
+			giveOptions: []Option{
+				WithEnabled(true),
+				WithParticipation(1.0),
+				WithPathBlocklist([]string{"/"}),
+			},
+			want: Evaluation{
+				Fault:         "testInjector500s",
+				Enabled:       true,
+				Participation: 1.0,
+				Injector:      "testInjector500s",
+				SkipReason:    SkipUnmatched,
+				Seed:          defaultRandSeed,
+			},
+		},
+		{
+			name:         "not selected",
+			giveInjector: newTestInjector500s(),
+			giveOptions: []Option{
+				WithEnabled(true),
+				WithParticipation(0.5),
+				WithRandFloat32Func(func() float32 { return 0.75 }),
+			},
+			want: Evaluation{
+				Fault:         "testInjector500s",
+				Enabled:       true,
+				Matched:       true,
+				Roll:          0.75,
+				Participation: 0.5,
+				Injector:      "testInjector500s",
+				SkipReason:    SkipParticipation,
+				Seed:          defaultRandSeed,
+				Rolls:         1,
+			},
+		},
+		{
+			name:         "injected",
+			giveInjector: newTestInjector500s(),
+			giveOptions: []Option{
+				WithEnabled(true),
+				WithParticipation(0.5),
+				WithRandFloat32Func(func() float32 { return 0.25 }),
+				WithRandSeed(42),
+			},
+			want: Evaluation{
+				Fault:         "testInjector500s",
+				Enabled:       true,
+				Matched:       true,
+				Roll:          0.25,
+				Participation: 0.5,
+				Injected:      true,
+				Injector:      "testInjector500s",
+				Seed:          42,
+				Rolls:         1,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			reporter := newTestEvaluationReporter()
+			opts := append(tt.giveOptions, WithReporter(reporter), WithTracing(true))
+
+			f, err := NewFault(tt.giveInjector, opts...)
+			assert.NoError(t, err)
+
+			testRequest(t, f)
+
+			ev := <-reporter.evaluations
+			assert.NotNil(t, ev.Request)
+
+			ev.Request = nil
+			assert.Equal(t, tt.want, ev)
+		})
+	}
+}
+
+// TestFaultHandlerNotTracing tests that Fault.Handler does not report Evaluations by default.
+func TestFaultHandlerNotTracing(t *testing.T) {
+	t.Parallel()
+
+	reporter := newTestEvaluationReporter()
+
+	f, err := NewFault(newTestInjector500s(),
+		WithEnabled(true),
+		WithParticipation(1.0),
+		WithReporter(reporter),
+	)
+	assert.NoError(t, err)
+
+	testRequest(t, f)
+
+	select {
+	case ev := <-reporter.evaluations:
+		t.Fatalf("unexpected evaluation: %+v", ev)
+	default:
+	}
+}
@@ -0,0 +1,135 @@
+package fault
+
+import (
+	"net/http"
+	"time"
+)
+
+// EventType is the kind of an Event.
+type EventType string
+
+const (
+	// EventSkipped when a Fault decided not to run its Injector on a request.
+	EventSkipped EventType = "skipped"
+	// EventStarted when a Fault started running its Injector on a request.
+	EventStarted EventType = "started"
+	// EventFinished when the Injector a Fault ran on a request returned.
+	EventFinished EventType = "finished"
+	// EventBudgetExhausted when a request used up the budget set by WithInjectionBudget and the
+	// Fault disabled itself. It is reported after the Injector returns.
+	EventBudgetExhausted EventType = "budget_exhausted"
+)
+
+// Event describes a step of a Fault handling a request, structured for observability pipelines.
+type Event struct {
+	// Type is the kind of Event.
+	Type EventType `json:"type"`
+	// Time is when the Event happened, as told by the Fault's Clock.
+	Time time.Time `json:"time"`
+	// Fault is the name of the Fault.
+	Fault string `json:"fault"`
+	// Injector summarizes the Fault's Injector, as returned by InjectorString, such as
+	// "slow(750ms)".
+	Injector string `json:"injector"`
+	// InjectorName is the kind of the Fault's Injector, as returned by InjectorName, such as "slow".
+	InjectorName string `json:"injector_name"`
+	// Participation is the percent of requests the Fault was configured to run the Injector on.
+	Participation float32 `json:"participation"`
+	// Labels are the labels of the Fault, set with WithLabels. They are shared by every Event of the
+	// Fault and must not be changed.
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// Request is the request the Event is about. Use its context to find the span or logger of the
+	// request.
+	Request *http.Request `json:"-"`
+
+	// Method is the method of the request.
+	Method string `json:"method"`
+	// Host is the Host of the request.
+	Host string `json:"host"`
+	// Path is the path of the request.
+	Path string `json:"path"`
+	// RemoteAddr is the network address of the client that sent the request.
+	RemoteAddr string `json:"remote_addr"`
+
+	// SkipReason is why the Injector did not run. Only set for EventSkipped.
+	SkipReason SkipReason `json:"skip_reason,omitempty"`
+	// StatusCode is the status code of the response. Only set for EventFinished, and 0 if the
+	// Injector panicked or took over the connection, such as a RejectInjector dropping it.
+	StatusCode int `json:"status_code,omitempty"`
+	// Duration is how long the Injector and the handlers it called ran. Only set for EventFinished.
+	Duration time.Duration `json:"duration,omitempty"`
+}
+
+// EventReporter is a Reporter that also receives an Event when a Fault skips a request, and when it
+// starts and finishes running its Injector on a request. Requests to a disabled Fault are not
+// reported. ReportEvent is called on the goroutine handling the request, while the request is
+// still in flight, so it can annotate the span of the request, and it must not block.
+type EventReporter interface {
+	Reporter
+	ReportEvent(e Event)
+}
+
+// newEvent returns an Event of type t for r handled with the Injector in st.
+func (f *Fault) newEvent(t EventType, r *http.Request, st *injectorState) Event {
+	return Event{
+		Type:          t,
+		Time:          f.now(),
+		Fault:         f.name,
+		Injector:      st.name,
+		InjectorName:  InjectorName(st.injector),
+		Participation: f.participation.Load(),
+		Labels:        f.labels,
+		Request:       r,
+		Method:        r.Method,
+		Host:          r.Host,
+		Path:          r.URL.Path,
+		RemoteAddr:    r.RemoteAddr,
+	}
+}
+
+// reportInjection reports EventStarted for r to er and returns a function that reports
+// EventFinished with the status code recorded by sw once the Injector returns. Defer it, so an
+// Injector that panics is reported with StatusCode 0.
+func (f *Fault) reportInjection(er EventReporter, r *http.Request, st *injectorState, sw *statusWriter) func() {
+	started := f.newEvent(EventStarted, r, st)
+	er.ReportEvent(started)
+
+	return func() {
+		code := sw.code
+
+		p := recover()
+		if p != nil {
+			code = 0
+		}
+
+		e := f.newEvent(EventFinished, r, st)
+		e.StatusCode = code
+		e.Duration = e.Time.Sub(started.Time)
+		er.ReportEvent(e)
+
+		if p != nil {
+			panic(p)
+		}
+	}
+}
+
+// reportSkip reports EventSkipped for r to er unless the Fault is disabled.
+func (f *Fault) reportSkip(er EventReporter, r *http.Request, st *injectorState, reason SkipReason) {
+	if reason == SkipDisabled {
+		return
+	}
+
+	e := f.newEvent(EventSkipped, r, st)
+	e.SkipReason = reason
+	er.ReportEvent(e)
+}
+
+// now returns the time from the Fault's Clock, or the real time if it has none.
+func (f *Fault) now() time.Time {
+	if f.clock == nil {
+		return time.Now()
+	}
+
+	return f.clock.Now()
+}
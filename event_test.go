@@ -0,0 +1,187 @@
+package fault
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testEventReporter is an EventReporter that sends every Event to a channel.
+type testEventReporter struct {
+	NoopReporter
+
+	events chan Event
+}
+
+// ReportEvent sends e to the channel.
+func (r *testEventReporter) ReportEvent(e Event) {
+	r.events <- e
+}
+
+// receive returns the next n Events.
+func (r *testEventReporter) receive(n int) []Event {
+	events := make([]Event, n)
+	for idx := range events {
+		events[idx] = <-r.events
+	}
+
+	return events
+}
+
+// testTickClock is a Clock whose time advances by a second every time it is read.
+type testTickClock struct {
+	RealClock
+
+	mtx sync.Mutex
+	now time.Time
+}
+
+// Now returns the time and advances it by a second.
+func (c *testTickClock) Now() time.Time {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	now := c.now
+	c.now = c.now.Add(time.Second)
+
+	return now
+}
+
+// testEventRequest returns the request the Event tests send.
+func testEventRequest() *http.Request {
+	r := httptest.NewRequest(http.MethodPost, "http://example.com/users", nil)
+	r.RemoteAddr = "192.0.2.1:1234"
+	return r
+}
+
+// TestFaultEvents tests that a Fault reports Events for injected requests to an EventReporter.
+func TestFaultEvents(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	reporter := &testEventReporter{events: make(chan Event, 2)}
+
+	f, err := NewFault(newTestInjector500s(),
+		WithName("errors"),
+		WithLabels(map[string]string{"team": "checkout"}),
+		WithEnabled(true),
+		WithParticipation(0.75),
+		WithRandFloat32Func(func() float32 { return 0.5 }),
+		WithReporter(reporter),
+		WithClock(&testTickClock{now: start}),
+	)
+	assert.NoError(t, err)
+
+	req := testEventRequest()
+	rr := httptest.NewRecorder()
+	f.Handler(http.NotFoundHandler()).ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+
+	assert.Equal(t, []Event{
+		{
+			Type:          EventStarted,
+			Time:          start,
+			Fault:         "errors",
+			Labels:        map[string]string{"team": "checkout"},
+			Injector:      "testInjector500s",
+			InjectorName:  "testInjector500s",
+			Participation: 0.75,
+			Request:       req,
+			Method:        http.MethodPost,
+			Host:          "example.com",
+			Path:          "/users",
+			RemoteAddr:    "192.0.2.1:1234",
+		},
+		{
+			Type:          EventFinished,
+			Time:          start.Add(time.Second),
+			Fault:         "errors",
+			Labels:        map[string]string{"team": "checkout"},
+			Injector:      "testInjector500s",
+			InjectorName:  "testInjector500s",
+			Participation: 0.75,
+			Request:       req,
+			Method:        http.MethodPost,
+			Host:          "example.com",
+			Path:          "/users",
+			RemoteAddr:    "192.0.2.1:1234",
+			StatusCode:    http.StatusInternalServerError,
+			Duration:      time.Second,
+		},
+	}, reporter.receive(2))
+}
+
+// TestFaultEventsPanic tests that an Injector that panics is reported as finished with no status
+// code.
+func TestFaultEventsPanic(t *testing.T) {
+	t.Parallel()
+
+	ri, err := NewRejectInjector()
+	assert.NoError(t, err)
+
+	reporter := &testEventReporter{events: make(chan Event, 2)}
+	f, err := NewFault(ri,
+		WithEnabled(true),
+		WithParticipation(1.0),
+		WithReporter(reporter),
+	)
+	assert.NoError(t, err)
+
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		f.Handler(http.NotFoundHandler()).ServeHTTP(httptest.NewRecorder(), testEventRequest())
+	})
+
+	events := map[EventType]Event{}
+	for _, e := range reporter.receive(2) {
+		events[e.Type] = e
+	}
+	assert.Equal(t, "reject", events[EventStarted].Injector)
+	assert.Equal(t, 0, events[EventFinished].StatusCode)
+	assert.True(t, events[EventFinished].Duration >= 0, events[EventFinished].Duration)
+}
+
+// TestFaultEventsSkipped tests that a Fault reports requests it skips, except when it is disabled.
+func TestFaultEventsSkipped(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	reporter := &testEventReporter{events: make(chan Event, 1)}
+
+	f, err := NewFault(newTestInjector500s(),
+		WithName("errors"),
+		WithEnabled(true),
+		WithParticipation(0.0),
+		WithReporter(reporter),
+		WithClock(&testTickClock{now: start}),
+	)
+	assert.NoError(t, err)
+
+	req := testEventRequest()
+	rr := httptest.NewRecorder()
+	f.Handler(http.NotFoundHandler()).ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	assert.Equal(t, []Event{
+		{
+			Type:         EventSkipped,
+			Time:         start,
+			Fault:        "errors",
+			Injector:     "testInjector500s",
+			InjectorName: "testInjector500s",
+			Request:      req,
+			Method:       http.MethodPost,
+			Host:         "example.com",
+			Path:         "/users",
+			RemoteAddr:   "192.0.2.1:1234",
+			SkipReason:   SkipParticipation,
+		},
+	}, reporter.receive(1))
+
+	f.SetEnabled(false)
+	f.Handler(http.NotFoundHandler()).ServeHTTP(httptest.NewRecorder(), testEventRequest())
+	assert.Len(t, reporter.events, 0)
+}
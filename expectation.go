@@ -0,0 +1,181 @@
+package fault
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// ErrExpectationFailed when what clients saw during a Phase does not meet one of its Expectations.
+var ErrExpectationFailed = errors.New("expectation failed")
+
+// Expectation is a declarative assertion of how clients should behave during a Phase of an
+// OutageInjector, such as how much their retries may raise the request rate. Checked by
+// OutageInjector.RunExpectations against what an OutcomeRecorder recorded, it turns an experiment
+// into a pass or fail resilience test. Zero fields are not checked.
+type Expectation struct {
+	// MaxRateIncrease is how many times the request rate of the most recent healthy Phase before
+	// the Phase the request rate of the Phase must stay below, such as 2 for retries to less than
+	// double the traffic.
+	MaxRateIncrease float64 `json:"max_rate_increase,omitempty"`
+	// MaxErrorsPerInjection is how many responses with a 5xx status code, or aborted, there may be
+	// per request the Injector of the Phase ran on, such as 1 for clients to see no more failures
+	// than were injected.
+	MaxErrorsPerInjection float64 `json:"max_errors_per_injection,omitempty"`
+	// MinSuccessRate is the lowest share of requests (0.0 <= MinSuccessRate <= 1.0) that may end
+	// without a 5xx status code or an abort.
+	MinSuccessRate float64 `json:"min_success_rate,omitempty"`
+}
+
+// String returns the checks of the Expectation, such as "rate < 2x, errors <= 1x injected".
+func (e Expectation) String() string {
+	var checks []string
+	if e.MaxRateIncrease > 0 {
+		checks = append(checks, fmt.Sprintf("rate < %gx", e.MaxRateIncrease))
+	}
+	if e.MaxErrorsPerInjection > 0 {
+		checks = append(checks, fmt.Sprintf("errors <= %gx injected", e.MaxErrorsPerInjection))
+	}
+	if e.MinSuccessRate > 0 {
+		checks = append(checks, fmt.Sprintf("success >= %g", e.MinSuccessRate))
+	}
+
+	return strings.Join(checks, ", ")
+}
+
+// Check returns an error wrapping ErrExpectationFailed for each check of the Expectation that stats
+// does not meet. reference is the PhaseStats of the most recent healthy Phase before it, or nil if
+// there is none, in which case MaxRateIncrease fails.
+func (e Expectation) Check(stats PhaseStats, reference *PhaseStats) []error {
+	var errs []error
+
+	if e.MaxRateIncrease > 0 {
+		switch {
+		case reference == nil:
+			errs = append(errs, fmt.Errorf("%w: rate < %gx: no healthy phase before %q", ErrExpectationFailed,
+				e.MaxRateIncrease, stats.Phase))
+		case stats.RequestRate() >= e.MaxRateIncrease*reference.RequestRate():
+			errs = append(errs, fmt.Errorf("%w: rate < %gx: %q rate %.3g/s, %q rate %.3g/s", ErrExpectationFailed,
+				e.MaxRateIncrease, stats.Phase, stats.RequestRate(), reference.Phase, reference.RequestRate()))
+		}
+	}
+
+	if e.MaxErrorsPerInjection > 0 && float64(stats.Errors) > e.MaxErrorsPerInjection*float64(stats.Injected) {
+		errs = append(errs, fmt.Errorf("%w: errors <= %gx injected: %q had %d errors, %d injected",
+			ErrExpectationFailed, e.MaxErrorsPerInjection, stats.Phase, stats.Errors, stats.Injected))
+	}
+
+	if e.MinSuccessRate > 0 && stats.SuccessRate() < e.MinSuccessRate {
+		errs = append(errs, fmt.Errorf("%w: success >= %g: %q success %.3g", ErrExpectationFailed,
+			e.MinSuccessRate, stats.Phase, stats.SuccessRate()))
+	}
+
+	return errs
+}
+
+// PhaseStats is what clients saw during a Phase, as recorded by an OutcomeRecorder.
+type PhaseStats struct {
+	// Phase is the name of the Phase.
+	Phase string `json:"phase"`
+	// Duration is how long the Phase was observed.
+	Duration time.Duration `json:"duration"`
+	// Requests is the number of requests.
+	Requests int64 `json:"requests"`
+	// Injected is the number of requests the Injector of the Phase ran on.
+	Injected int64 `json:"injected"`
+	// Errors is the number of requests that ended with a 5xx status code or were aborted.
+	Errors int64 `json:"errors"`
+}
+
+// RequestRate returns the requests per second.
+func (s PhaseStats) RequestRate() float64 {
+	return float64(s.Requests) / s.Duration.Seconds()
+}
+
+// SuccessRate returns the share of requests without errors, or 1 if there are no requests.
+func (s PhaseStats) SuccessRate() float64 {
+	if s.Requests == 0 {
+		return 1
+	}
+
+	return float64(s.Requests-s.Errors) / float64(s.Requests)
+}
+
+// PhaseResult is the result of the Expectations of a Phase.
+type PhaseResult struct {
+	// Stats is what clients saw during the Phase.
+	Stats PhaseStats `json:"stats"`
+	// Failures are the checks that failed. Empty if the Phase passed.
+	Failures []string `json:"failures,omitempty"`
+}
+
+// RunExpectations restarts the timeline and follows it to the end, checking the Expectations of
+// each Phase against what rec recorded for the Fault named fault, which must run the OutageInjector
+// WithOutcomeRecorder(rec). It returns a PhaseResult for every Phase and, if any check failed, an
+// error wrapping ErrExpectationFailed for each. It returns the error of ctx if ctx is done first.
+// RunExpectations blocks for the duration of the timeline, and a timeline that repeats is followed
+// once.
+func (i *OutageInjector) RunExpectations(ctx context.Context, rec *OutcomeRecorder, fault string) (
+	[]PhaseResult, error,
+) {
+	i.Restart()
+
+	results := make([]PhaseResult, 0, len(i.phases))
+	var reference *PhaseStats
+	var errs []error
+	for _, p := range i.phases {
+		begin := i.clock.Now()
+		requests, failed := outcomeTotals(rec, fault)
+		injected := atomic.LoadInt64(&i.injected)
+
+		select {
+		case <-ctx.Done():
+			return results, ctx.Err()
+		case <-i.clock.After(p.Duration):
+		}
+
+		stats := PhaseStats{
+			Phase:    p.Name,
+			Duration: i.clock.Now().Sub(begin),
+			Injected: atomic.LoadInt64(&i.injected) - injected,
+		}
+		r, f := outcomeTotals(rec, fault)
+		stats.Requests, stats.Errors = r-requests, f-failed
+
+		result := PhaseResult{Stats: stats}
+		for _, e := range p.Expect {
+			for _, err := range e.Check(stats, reference) {
+				result.Failures = append(result.Failures, err.Error())
+				errs = append(errs, err)
+			}
+		}
+		results = append(results, result)
+
+		if p.Injector == nil {
+			reference = &results[len(results)-1].Stats
+		}
+	}
+
+	return results, errors.Join(errs...)
+}
+
+// outcomeTotals returns how many requests rec recorded for the Fault named fault, and how many of
+// them ended with a 5xx status code or were aborted.
+func outcomeTotals(rec *OutcomeRecorder, fault string) (requests, failed int64) {
+	for _, ro := range rec.Snapshot()[fault] {
+		for _, o := range []Outcome{ro.Baseline, ro.Faulted} {
+			for code, n := range o.Statuses {
+				requests += n
+				if code == 0 || code >= http.StatusInternalServerError {
+					failed += n
+				}
+			}
+		}
+	}
+
+	return requests, failed
+}
@@ -0,0 +1,246 @@
+package fault
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/github/go-fault/faulttest"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestExpectationString tests Expectation.String.
+func TestExpectationString(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		give Expectation
+		want string
+	}{
+		{
+			name: "empty",
+		},
+		{
+			name: "all",
+			give: Expectation{MaxRateIncrease: 2, MaxErrorsPerInjection: 1, MinSuccessRate: 0.99},
+			want: "rate < 2x, errors <= 1x injected, success >= 0.99",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, tt.give.String())
+		})
+	}
+}
+
+// TestExpectationCheck tests Expectation.Check.
+func TestExpectationCheck(t *testing.T) {
+	t.Parallel()
+
+	healthy := &PhaseStats{Phase: "healthy", Duration: time.Second, Requests: 10}
+
+	tests := []struct {
+		name          string
+		give          Expectation
+		giveStats     PhaseStats
+		giveReference *PhaseStats
+		want          []string
+	}{
+		{
+			name:      "no checks",
+			giveStats: PhaseStats{Phase: "errors", Duration: time.Second, Requests: 100, Errors: 100},
+		},
+		{
+			name:          "pass",
+			give:          Expectation{MaxRateIncrease: 2, MaxErrorsPerInjection: 1, MinSuccessRate: 0.5},
+			giveStats:     PhaseStats{Phase: "errors", Duration: time.Second, Requests: 19, Injected: 5, Errors: 5},
+			giveReference: healthy,
+		},
+		{
+			name:          "fail",
+			give:          Expectation{MaxRateIncrease: 2, MaxErrorsPerInjection: 1, MinSuccessRate: 0.9},
+			giveStats:     PhaseStats{Phase: "errors", Duration: time.Second, Requests: 20, Injected: 5, Errors: 6},
+			giveReference: healthy,
+			want: []string{
+				`expectation failed: rate < 2x: "errors" rate 20/s, "healthy" rate 10/s`,
+				`expectation failed: errors <= 1x injected: "errors" had 6 errors, 5 injected`,
+				`expectation failed: success >= 0.9: "errors" success 0.7`,
+			},
+		},
+		{
+			name:      "no reference",
+			give:      Expectation{MaxRateIncrease: 2},
+			giveStats: PhaseStats{Phase: "errors", Duration: time.Second},
+			want: []string{
+				`expectation failed: rate < 2x: no healthy phase before "errors"`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var got []string
+			for _, err := range tt.give.Check(tt.giveStats, tt.giveReference) {
+				assert.True(t, errors.Is(err, ErrExpectationFailed), err)
+				got = append(got, err.Error())
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+// TestPhaseStats tests the rates of PhaseStats.
+func TestPhaseStats(t *testing.T) {
+	t.Parallel()
+
+	s := PhaseStats{Duration: 2 * time.Second, Requests: 10, Errors: 4}
+	assert.Equal(t, 5.0, s.RequestRate())
+	assert.Equal(t, 0.6, s.SuccessRate())
+
+	assert.Equal(t, 1.0, PhaseStats{Duration: time.Second}.SuccessRate())
+}
+
+// TestOutageInjectorRunExpectations tests that OutageInjector.RunExpectations checks the
+// Expectations of each Phase against what clients saw.
+func TestOutageInjectorRunExpectations(t *testing.T) {
+	t.Parallel()
+
+	clock := faulttest.NewClock(time.Time{})
+
+	errors500, err := NewErrorInjector(http.StatusInternalServerError)
+	assert.NoError(t, err)
+	reject, err := NewRejectInjector()
+	assert.NoError(t, err)
+
+	oi, err := NewOutageInjector([]Phase{
+		{Name: "healthy", Duration: time.Minute},
+		{
+			Name:          "errors",
+			Duration:      time.Minute,
+			Injector:      errors500,
+			Participation: 1.0,
+			Expect: []Expectation{
+				{MaxRateIncrease: 2, MaxErrorsPerInjection: 1},
+				{MinSuccessRate: 0.5},
+			},
+		},
+		{
+			Name:          "rejects",
+			Duration:      time.Minute,
+			Injector:      reject,
+			Participation: 1.0,
+			Expect:        []Expectation{{MaxRateIncrease: 2}},
+		},
+	}, WithClock(clock))
+	assert.NoError(t, err)
+
+	rec, err := NewOutcomeRecorder(WithClock(clock))
+	assert.NoError(t, err)
+
+	f, err := NewFault(oi,
+		WithName("outage"),
+		WithEnabled(true),
+		WithParticipation(1.0),
+		WithOutcomeRecorder(rec),
+	)
+	assert.NoError(t, err)
+
+	h := f.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	serve := func(n int) {
+		for idx := 0; idx < n; idx++ {
+			func() {
+				defer func() { _ = recover() }()
+				h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+			}()
+		}
+	}
+
+	type result struct {
+		results []PhaseResult
+		err     error
+	}
+	done := make(chan result)
+	go func() {
+		results, err := oi.RunExpectations(context.Background(), rec, "outage")
+		done <- result{results, err}
+	}()
+
+	for _, n := range []int{2, 3, 4} {
+		clock.BlockUntil(1)
+		serve(n)
+		clock.Advance(time.Minute)
+	}
+
+	got := <-done
+	assert.Equal(t, []PhaseResult{
+		{
+			Stats: PhaseStats{Phase: "healthy", Duration: time.Minute, Requests: 2},
+		},
+		{
+			Stats: PhaseStats{Phase: "errors", Duration: time.Minute, Requests: 3, Injected: 3, Errors: 3},
+			Failures: []string{
+				`expectation failed: success >= 0.5: "errors" success 0`,
+			},
+		},
+		{
+			Stats: PhaseStats{Phase: "rejects", Duration: time.Minute, Requests: 4, Injected: 4, Errors: 4},
+			Failures: []string{
+				`expectation failed: rate < 2x: "rejects" rate 0.0667/s, "healthy" rate 0.0333/s`,
+			},
+		},
+	}, got.results)
+	assert.True(t, errors.Is(got.err, ErrExpectationFailed), got.err)
+}
+
+// TestOutageInjectorRunExpectationsCanceled tests that OutageInjector.RunExpectations returns when
+// its context is done.
+func TestOutageInjectorRunExpectationsCanceled(t *testing.T) {
+	t.Parallel()
+
+	clock := faulttest.NewClock(time.Time{})
+
+	oi, err := NewOutageInjector([]Phase{
+		{Name: "healthy", Duration: time.Minute},
+		{Name: "still healthy", Duration: time.Minute},
+	}, WithClock(clock))
+	assert.NoError(t, err)
+
+	rec, err := NewOutcomeRecorder(WithClock(clock))
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	type result struct {
+		results []PhaseResult
+		err     error
+	}
+	done := make(chan result)
+	go func() {
+		results, err := oi.RunExpectations(ctx, rec, "outage")
+		done <- result{results, err}
+	}()
+
+	clock.BlockUntil(1)
+	clock.Advance(time.Minute)
+	clock.BlockUntil(1)
+	cancel()
+
+	got := <-done
+	assert.Equal(t, []PhaseResult{
+		{Stats: PhaseStats{Phase: "healthy", Duration: time.Minute}},
+	}, got.results)
+	assert.Equal(t, context.Canceled, got.err)
+}
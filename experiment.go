@@ -0,0 +1,414 @@
+package fault
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// defaultRampInterval is how often an Experiment updates the participation of its Fault by default.
+const defaultRampInterval = 10 * time.Second
+
+var (
+	// ErrNilRampProfile when a nil RampProfile is passed.
+	ErrNilRampProfile = errors.New("ramp profile cannot be nil")
+	// ErrInvalidRampDuration when a RampProfile is given a duration that is not positive.
+	ErrInvalidRampDuration = errors.New("ramp duration must be > 0")
+	// ErrInvalidRampSteps when a StepRamp has no steps, or steps that do not start at >= 0 in
+	// increasing order.
+	ErrInvalidRampSteps = errors.New("ramp steps must start at >= 0 in increasing order")
+	// ErrExperimentEnded when an Experiment that was stopped or aborted is started again.
+	ErrExperimentEnded = errors.New("experiment has ended")
+)
+
+// RampProfile decides the participation of the Fault of an Experiment over time. LinearRamp and
+// StepRamp are provided, and NewSpikeRamp returns a StepRamp that spikes and holds. Any type with a
+// Participation method is a RampProfile.
+type RampProfile interface {
+	// Participation returns the participation elapsed after the start of the Experiment.
+	// Participations outside of [0.0, 1.0] are clamped.
+	Participation(elapsed time.Duration) float32
+}
+
+// LinearRamp is a RampProfile that changes the participation linearly from one percent to another
+// over a duration, and then holds it.
+type LinearRamp struct {
+	from float32
+	to   float32
+	over time.Duration
+}
+
+// NewLinearRamp returns a LinearRamp from the percent from to the percent to over the duration
+// over, such as from 0.0 to 0.1 over 30 minutes. It returns ErrInvalidPercent if from or to are not
+// a percent and ErrInvalidRampDuration if over is not positive.
+func NewLinearRamp(from, to float32, over time.Duration) (*LinearRamp, error) {
+	if from < 0.0 || from > 1.0 || to < 0.0 || to > 1.0 {
+		return nil, ErrInvalidPercent
+	}
+
+	if over <= 0 {
+		return nil, ErrInvalidRampDuration
+	}
+
+	return &LinearRamp{from: from, to: to, over: over}, nil
+}
+
+// Participation returns the participation on the ramp elapsed after its start.
+func (r *LinearRamp) Participation(elapsed time.Duration) float32 {
+	switch {
+	case elapsed <= 0:
+		return r.from
+	case elapsed >= r.over:
+		return r.to
+	default:
+		return r.from + (r.to-r.from)*float32(float64(elapsed)/float64(r.over))
+	}
+}
+
+// RampStep is a step of a StepRamp.
+type RampStep struct {
+	// After is when the step starts, from the start of the Experiment.
+	After time.Duration
+	// Participation is the participation of the step.
+	Participation float32
+}
+
+// StepRamp is a RampProfile that changes the participation in steps, holding each until the next.
+type StepRamp struct {
+	steps []RampStep
+}
+
+// NewStepRamp returns a StepRamp of steps. The participation is 0.0 before the first step. It
+// returns ErrInvalidRampSteps if there are no steps or they do not start at >= 0 in increasing
+// order, and ErrInvalidPercent if the participation of a step is not a percent.
+func NewStepRamp(steps ...RampStep) (*StepRamp, error) {
+	if len(steps) == 0 {
+		return nil, ErrInvalidRampSteps
+	}
+
+	for idx, s := range steps {
+		if s.After < 0 || (idx > 0 && s.After <= steps[idx-1].After) {
+			return nil, ErrInvalidRampSteps
+		}
+
+		if s.Participation < 0.0 || s.Participation > 1.0 {
+			return nil, ErrInvalidPercent
+		}
+	}
+
+	return &StepRamp{steps: append([]RampStep(nil), steps...)}, nil
+}
+
+// Participation returns the participation of the last step that started elapsed after the start.
+func (r *StepRamp) Participation(elapsed time.Duration) float32 {
+	var p float32
+	for _, s := range r.steps {
+		if s.After > elapsed {
+			break
+		}
+		p = s.Participation
+	}
+
+	return p
+}
+
+// NewSpikeRamp returns a StepRamp that spikes the participation to spike for the duration spikeFor
+// and then holds it at hold, to see how a system recovers from a burst of faults and lives with a
+// steady trickle of them. It returns ErrInvalidPercent if spike or hold are not a percent and
+// ErrInvalidRampDuration if spikeFor is not positive.
+func NewSpikeRamp(spike float32, spikeFor time.Duration, hold float32) (*StepRamp, error) {
+	if spikeFor <= 0 {
+		return nil, ErrInvalidRampDuration
+	}
+
+	return NewStepRamp(RampStep{Participation: spike}, RampStep{After: spikeFor, Participation: hold})
+}
+
+// ExperimentState is the state of an Experiment.
+type ExperimentState string
+
+const (
+	// ExperimentPending until the Experiment is started.
+	ExperimentPending ExperimentState = "pending"
+	// ExperimentRunning while the Experiment drives the participation of its Fault.
+	ExperimentRunning ExperimentState = "running"
+	// ExperimentStopped once the Experiment was stopped and its Fault disabled.
+	ExperimentStopped ExperimentState = "stopped"
+	// ExperimentAborted once the Experiment was aborted and its Fault disabled at once.
+	ExperimentAborted ExperimentState = "aborted"
+)
+
+// ExperimentTransition records that an Experiment changed state or the participation of its Fault.
+type ExperimentTransition struct {
+	// Fault is the name of the Fault of the Experiment.
+	Fault string
+	// Time is when the transition happened, as told by the Experiment's Clock.
+	Time time.Time
+	// Elapsed is how long the Experiment had been running, or 0 if it had not started.
+	Elapsed time.Duration
+	// State is the state of the Experiment after the transition.
+	State ExperimentState
+	// Participation is the participation of the Fault after the transition.
+	Participation float32
+	// Reason is why the Experiment was aborted. Only set for ExperimentAborted.
+	Reason string
+}
+
+// ExperimentReporter is a Reporter that also receives every ExperimentTransition of an Experiment.
+// ReportExperiment is called on the goroutine making the transition and must not block.
+type ExperimentReporter interface {
+	Reporter
+	ReportExperiment(t ExperimentTransition)
+}
+
+// Experiment owns a Fault and drives its participation over time according to a RampProfile, so a
+// ramp-up like 0% to 10% over 30 minutes does not have to be scripted outside of the service.
+// Starting the Experiment enables the Fault, and every interval its participation is set to the
+// participation of the RampProfile. Once the profile ends, the last participation is held until
+// the Experiment is stopped or aborted. Every transition is reported if the Reporter of the
+// Experiment, or else of its Fault, is an ExperimentReporter.
+type Experiment struct {
+	fault    *Fault
+	profile  RampProfile
+	interval time.Duration
+	clock    Clock
+	reporter Reporter
+
+	// mtx serializes starting and ending, and protects stop and done.
+	mtx  sync.Mutex
+	stop chan struct{}
+	done chan struct{}
+
+	// stateMtx serializes transitions and protects state and started.
+	stateMtx sync.Mutex
+	state    ExperimentState
+	started  time.Time
+}
+
+// ExperimentOption configures an Experiment.
+type ExperimentOption interface {
+	applyExperiment(e *Experiment) error
+}
+
+func (o clockOption) applyExperiment(e *Experiment) error {
+	e.clock = o.clock
+	return nil
+}
+
+func (o checkIntervalOption) applyExperiment(e *Experiment) error {
+	if o <= 0 {
+		return ErrInvalidInterval
+	}
+
+	e.interval = time.Duration(o)
+
+	return nil
+}
+
+func (o reporterOption) applyExperiment(e *Experiment) error {
+	e.reporter = o.reporter
+	return nil
+}
+
+// NewExperiment returns an Experiment that drives the participation of f with p. Call Start to
+// begin the Experiment.
+func NewExperiment(f *Fault, p RampProfile, opts ...ExperimentOption) (*Experiment, error) {
+	if f == nil {
+		return nil, ErrNilFault
+	}
+
+	if p == nil {
+		return nil, ErrNilRampProfile
+	}
+
+	// set defaults
+	e := &Experiment{
+		fault:    f,
+		profile:  p,
+		interval: defaultRampInterval,
+		clock:    NewRealClock(),
+		state:    ExperimentPending,
+	}
+
+	// apply options
+	for _, opt := range opts {
+		err := opt.applyExperiment(e)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return e, nil
+}
+
+// Start sets the participation of the Fault to the start of the RampProfile, enables it, and
+// begins updating the participation every interval in a new goroutine. It does nothing if the
+// Experiment is already running, and returns ErrExperimentEnded if it was stopped or aborted.
+func (e *Experiment) Start() error {
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+
+	e.stateMtx.Lock()
+	defer e.stateMtx.Unlock()
+
+	switch e.state {
+	case ExperimentRunning:
+		return nil
+	case ExperimentStopped, ExperimentAborted:
+		return ErrExperimentEnded
+	}
+
+	e.started = e.clock.Now()
+	e.state = ExperimentRunning
+	p := e.setParticipation(0)
+	e.fault.SetEnabled(true)
+	e.report(ExperimentTransition{Time: e.started, State: ExperimentRunning, Participation: p})
+
+	e.stop = make(chan struct{})
+	e.done = make(chan struct{})
+
+	go e.run(e.stop, e.done)
+
+	return nil
+}
+
+// Stop ends the Experiment and disables the Fault with SetEnabled(false), so a ramp-down set with
+// WithRampDown still applies. It does nothing unless the Experiment is running.
+func (e *Experiment) Stop() {
+	e.end(ExperimentStopped, "")
+}
+
+// Close tears the Experiment down when it has to end mid-run: it stops the Experiment and then closes
+// its Fault with Fault.Close, which stops new injections at once, waits for the injected requests
+// that are running until ctx ends, and reports the CloseSummary of the Fault. It returns the error
+// of Fault.Close. The Fault cannot be used again once the Experiment is closed.
+func (e *Experiment) Close(ctx context.Context) error {
+	e.Stop()
+
+	return e.fault.Close(ctx)
+}
+
+// Abort ends the Experiment at once and disables the Fault with DisableNow, for reason. An
+// Experiment that has not started is aborted without enabling its Fault. It does nothing if the
+// Experiment has already ended.
+func (e *Experiment) Abort(reason string) {
+	e.end(ExperimentAborted, reason)
+}
+
+// end stops updating the participation and moves the Experiment to state.
+func (e *Experiment) end(state ExperimentState, reason string) {
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+
+	if e.stop != nil {
+		close(e.stop)
+		<-e.done
+
+		e.stop = nil
+		e.done = nil
+	}
+
+	e.stateMtx.Lock()
+	defer e.stateMtx.Unlock()
+
+	switch {
+	case e.state == ExperimentStopped || e.state == ExperimentAborted:
+		return
+	case e.state == ExperimentPending && state == ExperimentStopped:
+		return
+	}
+
+	t := ExperimentTransition{Time: e.clock.Now(), State: state, Reason: reason}
+	if e.state == ExperimentRunning {
+		t.Elapsed = t.Time.Sub(e.started)
+	}
+	e.state = state
+
+	if state == ExperimentAborted {
+		e.fault.DisableNow()
+	} else {
+		e.fault.SetEnabled(false)
+	}
+	t.Participation = e.fault.Participation()
+
+	e.report(t)
+}
+
+// run calls Update every interval until stop is closed.
+func (e *Experiment) run(stop <-chan struct{}, done chan<- struct{}) {
+	defer close(done)
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-e.clock.After(e.interval):
+			e.Update()
+		}
+	}
+}
+
+// Update sets the participation of the Fault to the participation of the RampProfile now, and
+// reports a transition if it changed. It returns the participation of the Fault, and does nothing
+// unless the Experiment is running.
+func (e *Experiment) Update() float32 {
+	e.stateMtx.Lock()
+	defer e.stateMtx.Unlock()
+
+	if e.state != ExperimentRunning {
+		return e.fault.Participation()
+	}
+
+	now := e.clock.Now()
+	elapsed := now.Sub(e.started)
+	before := e.fault.Participation()
+	p := e.setParticipation(elapsed)
+	if p != before {
+		e.report(ExperimentTransition{Time: now, Elapsed: elapsed, State: ExperimentRunning, Participation: p})
+	}
+
+	return p
+}
+
+// setParticipation sets the participation of the Fault to the participation of the RampProfile
+// elapsed after the start, clamped to a percent, and returns it.
+func (e *Experiment) setParticipation(elapsed time.Duration) float32 {
+	p := min(max(e.profile.Participation(elapsed), 0.0), 1.0)
+	_ = e.fault.SetParticipation(p)
+
+	return p
+}
+
+// report sends t to the ExperimentReporter of the Experiment or its Fault, if there is one.
+func (e *Experiment) report(t ExperimentTransition) {
+	r := e.reporter
+	if r == nil {
+		r = e.fault.Reporter()
+	}
+
+	if er, ok := r.(ExperimentReporter); ok {
+		t.Fault = e.fault.Name()
+		er.ReportExperiment(t)
+	}
+}
+
+// State returns the state of the Experiment.
+func (e *Experiment) State() ExperimentState {
+	e.stateMtx.Lock()
+	defer e.stateMtx.Unlock()
+
+	return e.state
+}
+
+// Elapsed returns how long the Experiment has been running, or 0 unless it is running.
+func (e *Experiment) Elapsed() time.Duration {
+	e.stateMtx.Lock()
+	defer e.stateMtx.Unlock()
+
+	if e.state != ExperimentRunning {
+		return 0
+	}
+
+	return e.clock.Now().Sub(e.started)
+}
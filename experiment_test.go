@@ -0,0 +1,491 @@
+package fault
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testExperimentReporter is a reporter that records ExperimentTransitions.
+type testExperimentReporter struct {
+	testReporter
+
+	mtx         sync.Mutex
+	transitions []ExperimentTransition
+}
+
+// ReportExperiment records t.
+func (r *testExperimentReporter) ReportExperiment(t ExperimentTransition) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	r.transitions = append(r.transitions, t)
+}
+
+// testRampProfile is a RampProfile function.
+type testRampProfile func(elapsed time.Duration) float32
+
+func (p testRampProfile) Participation(elapsed time.Duration) float32 {
+	return p(elapsed)
+}
+
+// TestNewLinearRamp tests NewLinearRamp and LinearRamp.Participation.
+func TestNewLinearRamp(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		giveFrom float32
+		giveTo   float32
+		giveOver time.Duration
+		want     map[time.Duration]float32
+		wantErr  error
+	}{
+		{
+			name:     "ramp up",
+			giveFrom: 0.0,
+			giveTo:   0.1,
+			giveOver: 30 * time.Minute,
+			want: map[time.Duration]float32{
+				-time.Minute:     0.0,
+				0:                0.0,
+				15 * time.Minute: 0.05,
+				30 * time.Minute: 0.1,
+				time.Hour:        0.1,
+			},
+		},
+		{
+			name:     "ramp down",
+			giveFrom: 1.0,
+			giveTo:   0.5,
+			giveOver: 10 * time.Second,
+			want: map[time.Duration]float32{
+				5 * time.Second: 0.75,
+			},
+		},
+		{
+			name:     "invalid from",
+			giveFrom: -0.1,
+			giveTo:   0.1,
+			giveOver: time.Minute,
+			wantErr:  ErrInvalidPercent,
+		},
+		{
+			name:     "invalid to",
+			giveFrom: 0.0,
+			giveTo:   1.1,
+			giveOver: time.Minute,
+			wantErr:  ErrInvalidPercent,
+		},
+		{
+			name:     "invalid duration",
+			giveFrom: 0.0,
+			giveTo:   0.1,
+			giveOver: 0,
+			wantErr:  ErrInvalidRampDuration,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r, err := NewLinearRamp(tt.giveFrom, tt.giveTo, tt.giveOver)
+
+			assert.Equal(t, tt.wantErr, err)
+			if tt.wantErr != nil {
+				assert.Nil(t, r)
+				return
+			}
+
+			for elapsed, want := range tt.want {
+				assert.InDelta(t, want, r.Participation(elapsed), 0.0001, elapsed)
+			}
+		})
+	}
+}
+
+// TestNewStepRamp tests NewStepRamp, NewSpikeRamp, and StepRamp.Participation.
+func TestNewStepRamp(t *testing.T) {
+	t.Parallel()
+
+	steps, err := NewStepRamp(
+		RampStep{After: time.Minute, Participation: 0.01},
+		RampStep{After: 10 * time.Minute, Participation: 0.05},
+		RampStep{After: 20 * time.Minute, Participation: 0.1},
+	)
+	assert.NoError(t, err)
+
+	spike, err := NewSpikeRamp(0.5, 5*time.Minute, 0.02)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name string
+		give RampProfile
+		want map[time.Duration]float32
+	}{
+		{
+			name: "steps",
+			give: steps,
+			want: map[time.Duration]float32{
+				0:                0.0,
+				time.Minute:      0.01,
+				15 * time.Minute: 0.05,
+				time.Hour:        0.1,
+			},
+		},
+		{
+			name: "spike",
+			give: spike,
+			want: map[time.Duration]float32{
+				0:               0.5,
+				time.Minute:     0.5,
+				5 * time.Minute: 0.02,
+				time.Hour:       0.02,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			for elapsed, want := range tt.want {
+				assert.Equal(t, want, tt.give.Participation(elapsed), elapsed)
+			}
+		})
+	}
+}
+
+// TestStepRampErrors tests that NewStepRamp and NewSpikeRamp reject invalid steps.
+func TestStepRampErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		give    func() (*StepRamp, error)
+		wantErr error
+	}{
+		{
+			name:    "no steps",
+			give:    func() (*StepRamp, error) { return NewStepRamp() },
+			wantErr: ErrInvalidRampSteps,
+		},
+		{
+			name: "negative start",
+			give: func() (*StepRamp, error) {
+				return NewStepRamp(RampStep{After: -time.Second, Participation: 0.1})
+			},
+			wantErr: ErrInvalidRampSteps,
+		},
+		{
+			name: "out of order",
+			give: func() (*StepRamp, error) {
+				return NewStepRamp(RampStep{After: time.Minute}, RampStep{After: time.Minute})
+			},
+			wantErr: ErrInvalidRampSteps,
+		},
+		{
+			name: "invalid participation",
+			give: func() (*StepRamp, error) {
+				return NewStepRamp(RampStep{Participation: 1.5})
+			},
+			wantErr: ErrInvalidPercent,
+		},
+		{
+			name:    "invalid spike",
+			give:    func() (*StepRamp, error) { return NewSpikeRamp(1.5, time.Minute, 0.1) },
+			wantErr: ErrInvalidPercent,
+		},
+		{
+			name:    "invalid spike duration",
+			give:    func() (*StepRamp, error) { return NewSpikeRamp(0.5, 0, 0.1) },
+			wantErr: ErrInvalidRampDuration,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r, err := tt.give()
+			assert.Equal(t, tt.wantErr, err)
+			assert.Nil(t, r)
+		})
+	}
+}
+
+// TestNewExperiment tests NewExperiment.
+func TestNewExperiment(t *testing.T) {
+	t.Parallel()
+
+	f, _ := NewFault(newTestInjectorNoop())
+	r, _ := NewLinearRamp(0.0, 0.1, time.Minute)
+
+	tests := []struct {
+		name         string
+		giveFault    *Fault
+		giveProfile  RampProfile
+		giveOpts     []ExperimentOption
+		wantInterval time.Duration
+		wantErr      error
+	}{
+		{
+			name:         "defaults",
+			giveFault:    f,
+			giveProfile:  r,
+			wantInterval: defaultRampInterval,
+		},
+		{
+			name:        "options",
+			giveFault:   f,
+			giveProfile: r,
+			giveOpts: []ExperimentOption{
+				WithCheckInterval(time.Second),
+				WithClock(&testConnClock{}),
+				WithReporter(&testExperimentReporter{}),
+			},
+			wantInterval: time.Second,
+		},
+		{
+			name:        "nil fault",
+			giveProfile: r,
+			wantErr:     ErrNilFault,
+		},
+		{
+			name:      "nil profile",
+			giveFault: f,
+			wantErr:   ErrNilRampProfile,
+		},
+		{
+			name:        "invalid interval",
+			giveFault:   f,
+			giveProfile: r,
+			giveOpts:    []ExperimentOption{WithCheckInterval(0)},
+			wantErr:     ErrInvalidInterval,
+		},
+		{
+			name:        "option error",
+			giveFault:   f,
+			giveProfile: r,
+			giveOpts:    []ExperimentOption{withError()},
+			wantErr:     errErrorOption,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			e, err := NewExperiment(tt.giveFault, tt.giveProfile, tt.giveOpts...)
+
+			assert.Equal(t, tt.wantErr, err)
+			if tt.wantErr != nil {
+				assert.Nil(t, e)
+				return
+			}
+
+			assert.Equal(t, tt.wantInterval, e.interval)
+			assert.Equal(t, ExperimentPending, e.State())
+		})
+	}
+}
+
+// TestExperimentRamp tests that an Experiment drives the participation of its Fault and reports
+// every transition.
+func TestExperimentRamp(t *testing.T) {
+	t.Parallel()
+
+	clock := &testConnClock{}
+	rep := &testExperimentReporter{}
+	f, err := NewFault(newTestInjectorNoop(), WithName("checkout"), WithParticipation(0.5))
+	assert.NoError(t, err)
+	r, err := NewLinearRamp(0.0, 0.1, 30*time.Minute)
+	assert.NoError(t, err)
+
+	e, err := NewExperiment(f, r, WithClock(clock), WithCheckInterval(time.Hour), WithReporter(rep))
+	assert.NoError(t, err)
+
+	// updates do nothing before the start
+	assert.Equal(t, float32(0.5), e.Update())
+	assert.Equal(t, time.Duration(0), e.Elapsed())
+
+	assert.NoError(t, e.Start())
+	assert.NoError(t, e.Start())
+	assert.True(t, f.Enabled())
+	assert.Equal(t, float32(0.0), f.Participation())
+	assert.Equal(t, ExperimentRunning, e.State())
+
+	// the clock moves forward by each sleep
+	clock.Sleep(15 * time.Minute)
+	assert.InDelta(t, 0.05, e.Update(), 0.0001)
+	assert.InDelta(t, 0.05, e.Update(), 0.0001)
+	assert.Equal(t, 15*time.Minute, e.Elapsed())
+
+	clock.Sleep(time.Hour)
+	assert.Equal(t, float32(0.1), e.Update())
+
+	e.Stop()
+	e.Stop()
+	assert.False(t, f.Enabled())
+	assert.Equal(t, ExperimentStopped, e.State())
+	assert.Equal(t, time.Duration(0), e.Elapsed())
+	assert.Equal(t, ErrExperimentEnded, e.Start())
+	e.Abort("too late")
+	assert.Equal(t, ExperimentStopped, e.State())
+
+	start := time.Time{}
+	assert.Len(t, rep.transitions, 4)
+	assert.Equal(t, ExperimentTransition{
+		Fault:         "checkout",
+		Time:          start,
+		State:         ExperimentRunning,
+		Participation: 0.0,
+	}, rep.transitions[0])
+	assert.Equal(t, 15*time.Minute, rep.transitions[1].Elapsed)
+	assert.InDelta(t, 0.05, rep.transitions[1].Participation, 0.0001)
+	assert.Equal(t, ExperimentTransition{
+		Fault:         "checkout",
+		Time:          start.Add(75 * time.Minute),
+		Elapsed:       75 * time.Minute,
+		State:         ExperimentRunning,
+		Participation: 0.1,
+	}, rep.transitions[2])
+	assert.Equal(t, ExperimentTransition{
+		Fault:         "checkout",
+		Time:          start.Add(75 * time.Minute),
+		Elapsed:       75 * time.Minute,
+		State:         ExperimentStopped,
+		Participation: 0.1,
+	}, rep.transitions[3])
+}
+
+// TestExperimentAbort tests that aborting an Experiment disables its Fault at once.
+func TestExperimentAbort(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		giveStart   bool
+		wantElapsed time.Duration
+	}{
+		{
+			name:        "running",
+			giveStart:   true,
+			wantElapsed: time.Minute,
+		},
+		{
+			name: "pending",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			clock := &testConnClock{}
+			rep := &testExperimentReporter{}
+			f, err := NewFault(newTestInjectorNoop(), WithName("checkout"), WithReporter(rep),
+				WithRampDown(time.Hour), WithClock(clock))
+			assert.NoError(t, err)
+			r, err := NewSpikeRamp(0.5, time.Hour, 0.1)
+			assert.NoError(t, err)
+
+			e, err := NewExperiment(f, r, WithClock(clock), WithCheckInterval(time.Hour))
+			assert.NoError(t, err)
+
+			// stopping an Experiment that has not started does nothing
+			e.Stop()
+			assert.Equal(t, ExperimentPending, e.State())
+
+			if tt.giveStart {
+				assert.NoError(t, e.Start())
+				clock.Sleep(time.Minute)
+			}
+			e.Abort("error rate too high")
+
+			assert.False(t, f.Enabled())
+			assert.Equal(t, ExperimentAborted, e.State())
+			assert.Equal(t, ErrExperimentEnded, e.Start())
+
+			last := rep.transitions[len(rep.transitions)-1]
+			assert.Equal(t, ExperimentAborted, last.State)
+			assert.Equal(t, "error rate too high", last.Reason)
+			assert.Equal(t, tt.wantElapsed, last.Elapsed)
+		})
+	}
+}
+
+// TestExperimentClose tests that Experiment.Close stops the Experiment and closes its Fault.
+func TestExperimentClose(t *testing.T) {
+	t.Parallel()
+
+	clock := &testConnClock{}
+	rep := &testExperimentReporter{}
+	f, err := NewFault(newTestInjectorNoop(), WithReporter(rep), WithRampDown(time.Hour), WithClock(clock))
+	assert.NoError(t, err)
+	r, err := NewLinearRamp(0.0, 1.0, time.Hour)
+	assert.NoError(t, err)
+
+	e, err := NewExperiment(f, r, WithClock(clock), WithCheckInterval(time.Hour))
+	assert.NoError(t, err)
+	assert.NoError(t, e.Start())
+
+	assert.NoError(t, e.Close(context.Background()))
+	assert.Equal(t, ExperimentStopped, e.State())
+	assert.False(t, f.Enabled())
+	assert.False(t, f.RampingDown())
+	assert.True(t, f.Closed())
+
+	last := rep.transitions[len(rep.transitions)-1]
+	assert.Equal(t, ExperimentStopped, last.State)
+}
+
+// TestExperimentRun tests that a started Experiment updates the participation every interval.
+func TestExperimentRun(t *testing.T) {
+	t.Parallel()
+
+	f, err := NewFault(newTestInjectorNoop())
+	assert.NoError(t, err)
+	r, err := NewStepRamp(RampStep{Participation: 0.1}, RampStep{After: time.Millisecond, Participation: 0.5})
+	assert.NoError(t, err)
+
+	e, err := NewExperiment(f, r, WithCheckInterval(time.Millisecond))
+	assert.NoError(t, err)
+
+	assert.NoError(t, e.Start())
+	assert.Eventually(t, func() bool {
+		return f.Participation() == 0.5
+	}, time.Second, time.Millisecond)
+	e.Stop()
+}
+
+// TestExperimentClamp tests that participations outside of a percent are clamped.
+func TestExperimentClamp(t *testing.T) {
+	t.Parallel()
+
+	clock := &testConnClock{}
+	f, err := NewFault(newTestInjectorNoop())
+	assert.NoError(t, err)
+	r := testRampProfile(func(elapsed time.Duration) float32 {
+		return float32(elapsed.Minutes()) - 1
+	})
+
+	e, err := NewExperiment(f, r, WithClock(clock), WithCheckInterval(time.Hour))
+	assert.NoError(t, err)
+
+	assert.NoError(t, e.Start())
+	assert.Equal(t, float32(0.0), f.Participation())
+
+	clock.Sleep(5 * time.Minute)
+	assert.Equal(t, float32(1.0), e.Update())
+	e.Stop()
+}
@@ -0,0 +1,223 @@
+package fault
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"sync/atomic"
+)
+
+// maxExplainBodySize is the largest request description ExplainHandler reads.
+const maxExplainBodySize = 1 << 20
+
+// Explanation describes how a Fault would treat a request without running it. Participation is not
+// rolled, so a request that is Eligible runs the Injector Participation of the time.
+type Explanation struct {
+	// Fault is the name of the Fault.
+	Fault string `json:"fault"`
+	// Priority is the priority of the Fault.
+	Priority int `json:"priority"`
+	// Injector describes the Fault's Injector, as returned by InjectorString.
+	Injector string `json:"injector"`
+	// Enabled is true if the Fault is enabled.
+	Enabled bool `json:"enabled"`
+	// Matched is true if the request passes the Fault's allowlists, blocklists, and RequestMatchers.
+	Matched bool `json:"matched"`
+	// Cohort is the Cohort the request would be assigned to, if the Fault runs an experiment.
+	Cohort Cohort `json:"cohort,omitempty"`
+	// Participation is the percent of eligible requests the Fault runs the Injector on.
+	Participation float32 `json:"participation"`
+	// Eligible is true if the Injector would run when the request is selected by participation.
+	Eligible bool `json:"eligible"`
+	// SkipReason is why the Injector would not run. Empty if Eligible is true.
+	SkipReason SkipReason `json:"skip_reason,omitempty"`
+}
+
+// ExplainRequest explains how f would treat r. Nothing is reported, participation is not rolled, and
+// r is only read.
+func ExplainRequest(f *Fault, r *http.Request) Explanation {
+	st := f.injector.Load()
+	e := Explanation{
+		Fault:         f.Name(),
+		Priority:      f.priority,
+		Injector:      st.name,
+		Enabled:       f.enabled.Load(),
+		Participation: f.participation.Load(),
+		Matched:       f.checkAllowBlockLists(true, r),
+	}
+
+	switch {
+	case f.Closed():
+		e.SkipReason = SkipClosed
+		return e
+	case !e.Enabled:
+		e.SkipReason = SkipDisabled
+		return e
+	case f.schedule != nil && !f.schedule.Active(f.now()):
+		e.SkipReason = SkipSchedule
+		return e
+	case !e.Matched:
+		e.SkipReason = SkipUnmatched
+		return e
+	}
+
+	if f.cohorts != nil {
+		_, e.Cohort, _ = f.cohorts.Assign(r)
+		if e.Cohort != CohortTreatment {
+			e.SkipReason = SkipCohort
+			return e
+		}
+	}
+
+	if st.destructive && f.idempotency != nil && !f.idempotency.Idempotent(r) {
+		e.SkipReason = SkipUnsafe
+		return e
+	}
+
+	if f.cooldown != nil && f.cooldown.active(f.cooldown.keyF(r)) {
+		e.SkipReason = SkipCooldown
+		return e
+	}
+
+	if f.fairness != nil && !f.fairness.allowed(f.fairness.keyF(r)) {
+		e.SkipReason = SkipFairness
+		return e
+	}
+
+	if f.rateLimit != nil && !f.rateLimit.allowed() {
+		e.SkipReason = SkipRateLimit
+		return e
+	}
+
+	if f.budget != nil && f.budget.exhausted() {
+		e.SkipReason = SkipBudget
+		return e
+	}
+
+	if f.maxConcurrent > 0 && atomic.LoadInt64(&f.concurrent) >= f.maxConcurrent {
+		e.SkipReason = SkipConcurrency
+		return e
+	}
+
+	e.Eligible = true
+
+	return e
+}
+
+// Explain explains how each managed Fault would treat r, in the order the Faults run. With
+// ConflictHighestPriority, lower priority Faults that would lose to a matching Fault are skipped
+// with SkipConflict. With ConflictFirstWins the eligible Faults run in order until one is
+// selected by participation. During a Blackout or a freeze no Fault is eligible.
+func (m *Manager) Explain(r *http.Request) []Explanation {
+	faults := m.Faults()
+
+	es := make([]Explanation, len(faults))
+	top, found := 0, false
+	for idx, f := range faults {
+		es[idx] = ExplainRequest(f, r)
+		if es[idx].Enabled && es[idx].Matched && (!found || f.priority > top) {
+			top, found = f.priority, true
+		}
+	}
+
+	if _, ok := m.Blackout(); ok {
+		skipEligible(es, SkipBlackout)
+	} else if _, ok := m.Freeze(r.Context()); ok {
+		skipEligible(es, SkipFreeze)
+	}
+
+	if m.conflicts == ConflictHighestPriority {
+		for idx := range es {
+			if es[idx].Eligible && es[idx].Priority < top {
+				es[idx].Eligible = false
+				es[idx].SkipReason = SkipConflict
+			}
+		}
+	}
+
+	return es
+}
+
+// skipEligible marks the eligible Explanations of es as skipped with reason.
+func skipEligible(es []Explanation, reason SkipReason) {
+	for idx := range es {
+		if es[idx].Eligible {
+			es[idx].Eligible = false
+			es[idx].SkipReason = reason
+		}
+	}
+}
+
+// RequestDescription describes a request for ExplainHandler to explain.
+type RequestDescription struct {
+	// Method is the method of the request. Default GET.
+	Method string `json:"method"`
+	// Path is the path of the request, and may include a query. Default /.
+	Path string `json:"path"`
+	// Host is the Host of the request.
+	Host string `json:"host"`
+	// Header is the header of the request.
+	Header http.Header `json:"header"`
+	// RemoteAddr is the address of the client, used to assign Cohorts by default.
+	RemoteAddr string `json:"remote_addr"`
+}
+
+// request returns the request that d describes.
+func (d RequestDescription) request() (*http.Request, error) {
+	if d.Method == "" {
+		d.Method = http.MethodGet
+	}
+	if d.Path == "" {
+		d.Path = "/"
+	}
+
+	u, err := url.ParseRequestURI(d.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	header := http.Header{}
+	for k, vs := range d.Header {
+		for _, v := range vs {
+			header.Add(k, v)
+		}
+	}
+
+	return &http.Request{
+		Method:     d.Method,
+		URL:        u,
+		Host:       d.Host,
+		Header:     header,
+		RemoteAddr: d.RemoteAddr,
+	}, nil
+}
+
+// ExplainHandler returns a read-only admin handler that debugs targeting rules. POST it a JSON
+// RequestDescription and it responds with the JSON Explanations of Explain for that request.
+// Nothing about the Faults is changed. Mount it on an internal admin port, for example at
+// /debug/faults/explain.
+func (m *Manager) ExplainHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		var d RequestDescription
+		err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxExplainBodySize)).Decode(&d)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		req, err := d.request()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		_ = json.NewEncoder(w).Encode(m.Explain(req))
+	})
+}
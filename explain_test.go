@@ -0,0 +1,271 @@
+package fault
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestExplainRequest tests ExplainRequest.
+func TestExplainRequest(t *testing.T) {
+	t.Parallel()
+
+	control, err := NewCohortAssigner("exp", 0.0)
+	assert.NoError(t, err)
+	treatment, err := NewCohortAssigner("exp", 1.0)
+	assert.NoError(t, err)
+	ri, err := NewRejectInjector()
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		giveFault  func() (*Fault, error)
+		giveMethod string
+		want       Explanation
+	}{
+		{
+			name: "disabled",
+			giveFault: func() (*Fault, error) {
+				return NewFault(newTestInjectorNoop(), WithName("f"), WithParticipation(0.5))
+			},
+			want: Explanation{
+				Fault:         "f",
+				Injector:      "testInjectorNoop",
+				Participation: 0.5,
+				Matched:       true,
+				SkipReason:    SkipDisabled,
+			},
+		},
+		{
+			name: "unmatched",
+			giveFault: func() (*Fault, error) {
+				return NewFault(newTestInjectorNoop(), WithName("f"), WithEnabled(true),
+					WithPathBlocklist([]string{"/"}))
+			},
+			want: Explanation{
+				Fault:      "f",
+				Injector:   "testInjectorNoop",
+				Enabled:    true,
+				SkipReason: SkipUnmatched,
+			},
+		},
+		{
+			name: "control cohort",
+			giveFault: func() (*Fault, error) {
+				return NewFault(newTestInjectorNoop(), WithName("f"), WithEnabled(true),
+					WithCohortAssigner(control))
+			},
+			want: Explanation{
+				Fault:      "f",
+				Injector:   "testInjectorNoop",
+				Enabled:    true,
+				Matched:    true,
+				Cohort:     CohortControl,
+				SkipReason: SkipCohort,
+			},
+		},
+		{
+			name: "unsafe",
+			giveFault: func() (*Fault, error) {
+				return NewFault(ri, WithName("f"), WithEnabled(true), WithPriority(2),
+					WithIdempotencySafety(nil))
+			},
+			giveMethod: http.MethodPost,
+			want: Explanation{
+				Fault:      "f",
+				Priority:   2,
+				Injector:   "reject",
+				Enabled:    true,
+				Matched:    true,
+				SkipReason: SkipUnsafe,
+			},
+		},
+		{
+			name: "eligible",
+			giveFault: func() (*Fault, error) {
+				return NewFault(ri, WithName("f"), WithEnabled(true), WithParticipation(0.1),
+					WithCohortAssigner(treatment), WithIdempotencySafety(nil))
+			},
+			giveMethod: http.MethodGet,
+			want: Explanation{
+				Fault:         "f",
+				Injector:      "reject",
+				Enabled:       true,
+				Matched:       true,
+				Cohort:        CohortTreatment,
+				Participation: 0.1,
+				Eligible:      true,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			f, err := tt.giveFault()
+			assert.NoError(t, err)
+
+			req := httptest.NewRequest(tt.giveMethod, "/", nil)
+
+			assert.Equal(t, tt.want, ExplainRequest(f, req))
+			assert.Equal(t, uint64(0), f.rolls)
+		})
+	}
+}
+
+// TestManagerExplain tests Manager.Explain.
+func TestManagerExplain(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		givePolicy   ConflictPolicy
+		wantEligible []bool
+		wantReasons  []SkipReason
+	}{
+		{
+			name:         "all",
+			givePolicy:   ConflictAll,
+			wantEligible: []bool{false, true, false, true},
+			wantReasons:  []SkipReason{SkipDisabled, "", SkipUnmatched, ""},
+		},
+		{
+			name:         "first wins",
+			givePolicy:   ConflictFirstWins,
+			wantEligible: []bool{false, true, false, true},
+			wantReasons:  []SkipReason{SkipDisabled, "", SkipUnmatched, ""},
+		},
+		{
+			name:         "highest priority",
+			givePolicy:   ConflictHighestPriority,
+			wantEligible: []bool{false, true, false, false},
+			wantReasons:  []SkipReason{SkipDisabled, "", SkipUnmatched, SkipConflict},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			fault := func(name string, priority int, opts ...Option) *Fault {
+				opts = append(opts, WithName(name), WithPriority(priority))
+				f, err := NewFault(newTestInjectorNoop(), opts...)
+				assert.NoError(t, err)
+				return f
+			}
+
+			m, err := NewManager(WithConflictPolicy(tt.givePolicy))
+			assert.NoError(t, err)
+			assert.NoError(t, m.Add(
+				fault("low", 1, WithEnabled(true)),
+				fault("disabled", 3),
+				fault("high", 2, WithEnabled(true)),
+				fault("unmatched", 2, WithEnabled(true), WithPathBlocklist([]string{"/"})),
+			))
+
+			es := m.Explain(httptest.NewRequest("GET", "/", nil))
+
+			var names []string
+			var eligible []bool
+			var reasons []SkipReason
+			for _, e := range es {
+				names = append(names, e.Fault)
+				eligible = append(eligible, e.Eligible)
+				reasons = append(reasons, e.SkipReason)
+			}
+			assert.Equal(t, []string{"disabled", "high", "unmatched", "low"}, names)
+			assert.Equal(t, tt.wantEligible, eligible)
+			assert.Equal(t, tt.wantReasons, reasons)
+		})
+	}
+}
+
+// TestManagerExplainHandler tests Manager.ExplainHandler.
+func TestManagerExplainHandler(t *testing.T) {
+	t.Parallel()
+
+	f, err := NewFault(newTestInjectorNoop(),
+		WithName("f"),
+		WithEnabled(true),
+		WithPathAllowlist([]string{"/users"}),
+		WithHeaderAllowlist(map[string]string{"X-Team": "ux"}),
+	)
+	assert.NoError(t, err)
+
+	m, err := NewManager()
+	assert.NoError(t, err)
+	assert.NoError(t, m.Add(f))
+
+	tests := []struct {
+		name         string
+		giveMethod   string
+		giveBody     string
+		wantCode     int
+		wantEligible []bool
+	}{
+		{
+			name:         "eligible",
+			giveMethod:   http.MethodPost,
+			giveBody:     `{"method": "PUT", "path": "/users?id=1", "host": "example.com", "header": {"x-team": ["ux"]}}`,
+			wantCode:     http.StatusOK,
+			wantEligible: []bool{true},
+		},
+		{
+			name:         "defaults",
+			giveMethod:   http.MethodPost,
+			giveBody:     `{}`,
+			wantCode:     http.StatusOK,
+			wantEligible: []bool{false},
+		},
+		{
+			name:       "method not allowed",
+			giveMethod: http.MethodGet,
+			wantCode:   http.StatusMethodNotAllowed,
+		},
+		{
+			name:       "invalid json",
+			giveMethod: http.MethodPost,
+			giveBody:   `{`,
+			wantCode:   http.StatusBadRequest,
+		},
+		{
+			name:       "invalid path",
+			giveMethod: http.MethodPost,
+			giveBody:   `{"path": "users"}`,
+			wantCode:   http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			rr := httptest.NewRecorder()
+			m.ExplainHandler().ServeHTTP(rr, httptest.NewRequest(tt.giveMethod, "/", strings.NewReader(tt.giveBody)))
+
+			assert.Equal(t, tt.wantCode, rr.Code)
+			if tt.wantCode != http.StatusOK {
+				return
+			}
+
+			assert.Equal(t, "application/json; charset=utf-8", rr.Header().Get("Content-Type"))
+
+			var es []Explanation
+			assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &es))
+
+			var eligible []bool
+			for _, e := range es {
+				eligible = append(eligible, e.Eligible)
+			}
+			assert.Equal(t, tt.wantEligible, eligible)
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package fault
+
+import (
+	"expvar"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// ExpvarNamespace is the expvar key that Fault counters are published under.
+const ExpvarNamespace = "go-fault"
+
+// ExpvarPublisher publishes the counters of Faults to its own expvar.Map, so several sets of Faults
+// in one process, such as those of a server and those of its outbound clients, can be published
+// and served separately. An ExpvarPublisher is an expvar.Var, so it can also be published to expvar
+// under a key of your choice with expvar.Publish.
+type ExpvarPublisher struct {
+	vars expvar.Map
+}
+
+// NewExpvarPublisher returns an ExpvarPublisher with no Faults published.
+func NewExpvarPublisher() *ExpvarPublisher {
+	return &ExpvarPublisher{}
+}
+
+// Publish publishes the counters (evaluated, injected, skipped, active, and skipped_ followed by
+// each SkipReason), random seed, and labels, if any, of each Fault, keyed by Fault.Name. Publishing
+// a Fault with the same name as a previously published Fault replaces it.
+func (p *ExpvarPublisher) Publish(faults ...*Fault) {
+	for _, f := range faults {
+		f := f
+		p.vars.Set(f.Name(), expvar.Func(func() interface{} {
+			vars := make(map[string]interface{})
+			for name, n := range f.stats.counters() {
+				vars[name] = n
+			}
+			vars["seed"] = f.Seed()
+			if labels := f.Labels(); labels != nil {
+				vars["labels"] = labels
+			}
+			return vars
+		}))
+	}
+}
+
+// String returns the published counters as JSON, keyed by Fault.Name.
+func (p *ExpvarPublisher) String() string {
+	return p.vars.String()
+}
+
+// Handler returns an http.Handler that responds with the published counters as JSON, under
+// ExpvarNamespace.
+func (p *ExpvarPublisher) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		serveExpvar(w, p)
+	})
+}
+
+// serveExpvar responds with the counters published by p as JSON, under ExpvarNamespace.
+func serveExpvar(w http.ResponseWriter, p *ExpvarPublisher) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	fmt.Fprintf(w, "{%q: %s}\n", ExpvarNamespace, p.String())
+}
+
+//nolint:gochecknoglobals // expvar variables are global so the publisher we publish to must be too.
+var (
+	expvarOnce      sync.Once
+	expvarPublisher *ExpvarPublisher
+)
+
+// defaultExpvarPublisher returns the ExpvarPublisher of PublishExpvar, publishing it to expvar
+// under ExpvarNamespace on first use.
+func defaultExpvarPublisher() *ExpvarPublisher {
+	expvarOnce.Do(func() {
+		expvarPublisher = NewExpvarPublisher()
+		expvar.Publish(ExpvarNamespace, expvarPublisher)
+	})
+
+	return expvarPublisher
+}
+
+// PublishExpvar publishes the counters (evaluated, injected, skipped, active, and skipped_ followed
+// by each SkipReason), random seed, and labels, if any, of each Fault to expvar under
+// ExpvarNamespace, keyed by Fault.Name. Publishing a Fault with the same name as a previously
+// published Fault replaces it. Every caller in the process shares the published Faults; use an
+// ExpvarPublisher or Manager.ExpvarHandler to keep them apart.
+func PublishExpvar(faults ...*Fault) {
+	defaultExpvarPublisher().Publish(faults...)
+}
+
+// NewExpvarHandler returns an http.Handler that responds with the Fault counters published by
+// PublishExpvar as JSON. Unlike expvar.Handler it only includes Fault counters. Mount it wherever
+// you like, for example at /debug/faults/vars.
+func NewExpvarHandler() http.Handler {
+	return defaultExpvarPublisher().Handler()
+}
+
+// ExpvarHandler returns an http.Handler that responds with the counters of the managed Faults as
+// JSON, in the format of NewExpvarHandler. Nothing is published to expvar, so each Manager in a
+// process only serves its own Faults.
+func (m *Manager) ExpvarHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p := NewExpvarPublisher()
+		p.Publish(m.Faults()...)
+		serveExpvar(w, p)
+	})
+}
@@ -0,0 +1,145 @@
+package fault
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestPublishExpvar tests PublishExpvar and NewExpvarHandler.
+func TestPublishExpvar(t *testing.T) {
+	t.Parallel()
+
+	f, err := NewFault(newTestInjector500s(),
+		WithName("TestPublishExpvar"),
+		WithEnabled(true),
+		WithParticipation(1.0),
+		WithRandSeed(42),
+	)
+	assert.NoError(t, err)
+
+	PublishExpvar(f)
+	testRequest(t, f)
+
+	rr := httptest.NewRecorder()
+	NewExpvarHandler().ServeHTTP(rr, httptest.NewRequest("GET", "/debug/faults/vars", nil))
+
+	// other tests publish Faults with labels, so only decode the counters of this one
+	var published map[string]map[string]json.RawMessage
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &published))
+	assert.Equal(t, "application/json; charset=utf-8", rr.Header().Get("Content-Type"))
+
+	var got map[string]int64
+	assert.NoError(t, json.Unmarshal(published[ExpvarNamespace]["TestPublishExpvar"], &got))
+	assert.Equal(t, map[string]int64{
+		"evaluated": 1,
+		"injected":  1,
+		"skipped":   0,
+		"active":    0,
+		"seed":      42,
+
+		"skipped_disabled":      0,
+		"skipped_unmatched":     0,
+		"skipped_participation": 0,
+		"skipped_cohort":        0,
+		"skipped_unsafe":        0,
+		"skipped_conflict":      0,
+		"skipped_blackout":      0,
+		"skipped_cooldown":      0,
+		"skipped_fairness":      0,
+		"skipped_schedule":      0,
+		"skipped_rate_limit":    0,
+		"skipped_budget":        0,
+		"skipped_override":      0,
+		"skipped_freeze":        0,
+		"skipped_concurrency":   0,
+		"skipped_veto":          0,
+		"skipped_closed":        0,
+	}, got)
+}
+
+// TestPublishExpvarLabels tests that PublishExpvar publishes the labels of a Fault.
+func TestPublishExpvarLabels(t *testing.T) {
+	t.Parallel()
+
+	f, err := NewFault(newTestInjector500s(),
+		WithName("TestPublishExpvarLabels"),
+		WithLabels(map[string]string{"team": "checkout"}),
+	)
+	assert.NoError(t, err)
+
+	PublishExpvar(f)
+
+	rr := httptest.NewRecorder()
+	NewExpvarHandler().ServeHTTP(rr, httptest.NewRequest("GET", "/debug/faults/vars", nil))
+
+	var got map[string]map[string]struct {
+		Evaluated int64             `json:"evaluated"`
+		Labels    map[string]string `json:"labels"`
+	}
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &got))
+	assert.Equal(t, map[string]string{"team": "checkout"}, got[ExpvarNamespace]["TestPublishExpvarLabels"].Labels)
+	assert.Equal(t, int64(0), got[ExpvarNamespace]["TestPublishExpvarLabels"].Evaluated)
+}
+
+// TestExpvarPublisher tests that ExpvarPublishers publish their Faults independently.
+func TestExpvarPublisher(t *testing.T) {
+	t.Parallel()
+
+	server, err := NewFault(newTestInjector500s(), WithName("fault"), WithEnabled(true), WithParticipation(1.0))
+	assert.NoError(t, err)
+	client, err := NewFault(newTestInjector500s(), WithName("fault"), WithRandSeed(7))
+	assert.NoError(t, err)
+
+	sp := NewExpvarPublisher()
+	sp.Publish(server)
+	cp := NewExpvarPublisher()
+	cp.Publish(client)
+
+	testRequest(t, server)
+	testRequest(t, client)
+
+	var got map[string]map[string]int64
+	assert.NoError(t, json.Unmarshal([]byte(sp.String()), &got))
+	assert.Equal(t, int64(1), got["fault"]["injected"])
+	assert.Equal(t, int64(defaultRandSeed), got["fault"]["seed"])
+
+	rr := httptest.NewRecorder()
+	cp.Handler().ServeHTTP(rr, httptest.NewRequest("GET", "/debug/faults/vars", nil))
+	assert.Equal(t, "application/json; charset=utf-8", rr.Header().Get("Content-Type"))
+
+	var served map[string]map[string]map[string]int64
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &served))
+	assert.Equal(t, int64(0), served[ExpvarNamespace]["fault"]["injected"])
+	assert.Equal(t, int64(1), served[ExpvarNamespace]["fault"]["skipped_disabled"])
+	assert.Equal(t, int64(7), served[ExpvarNamespace]["fault"]["seed"])
+}
+
+// TestManagerExpvarHandler tests that Manager.ExpvarHandler serves only the managed Faults.
+func TestManagerExpvarHandler(t *testing.T) {
+	t.Parallel()
+
+	a, err := NewFault(newTestInjector500s(), WithName("a"))
+	assert.NoError(t, err)
+	b, err := NewFault(newTestInjector500s(), WithName("b"))
+	assert.NoError(t, err)
+
+	ma, err := NewManager()
+	assert.NoError(t, err)
+	assert.NoError(t, ma.Add(a))
+	mb, err := NewManager()
+	assert.NoError(t, err)
+	assert.NoError(t, mb.Add(b))
+
+	for m, want := range map[*Manager]string{ma: "a", mb: "b"} {
+		rr := httptest.NewRecorder()
+		m.ExpvarHandler().ServeHTTP(rr, httptest.NewRequest("GET", "/debug/faults/vars", nil))
+
+		var got map[string]map[string]json.RawMessage
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &got))
+		assert.Len(t, got[ExpvarNamespace], 1)
+		assert.Contains(t, got[ExpvarNamespace], want)
+	}
+}
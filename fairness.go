@@ -0,0 +1,140 @@
+package fault
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrInvalidFairnessWindow when the window of a fairness constraint is not positive.
+	ErrInvalidFairnessWindow = errors.New("fairness window must be > 0")
+)
+
+// fairness spreads the injections of a Fault across clients, so no client receives more than a
+// share of the injections in a sliding window.
+type fairness struct {
+	share  float32
+	window time.Duration
+	keyF   func(r *http.Request) string
+	clock  Clock
+
+	mtx sync.Mutex
+	// injections are the injections in the window, oldest first.
+	injections []fairInjection
+	// counts holds the number of injections in the window of each client.
+	counts map[string]int
+}
+
+// fairInjection is an injection counted by a fairness constraint.
+type fairInjection struct {
+	at  time.Time
+	key string
+}
+
+type fairnessOption struct {
+	share  float32
+	window time.Duration
+	keyF   func(r *http.Request) string
+}
+
+func (o fairnessOption) applyFault(f *Fault) error {
+	if o.share < 0 || o.share > 1 {
+		return ErrInvalidPercent
+	}
+	if o.window <= 0 {
+		return ErrInvalidFairnessWindow
+	}
+
+	keyF := o.keyF
+	if keyF == nil {
+		keyF = clientIP
+	}
+
+	f.fairness = &fairness{
+		share:  o.share,
+		window: o.window,
+		keyF:   keyF,
+	}
+
+	return nil
+}
+
+// WithClientFairness stops the Injector from running on a client that already received more than
+// share (0.0 <= share <= 1.0) of the injections of the Fault in the last window, so an experiment
+// spreads across the user base instead of concentrating on its busiest clients. Every client may
+// receive at least one injection per window, so the share is only exact once the Fault has injected
+// more than 1/share requests in the window. key returns the client a request belongs to; requests
+// with an empty key are not constrained and not counted. A nil key uses the IP of the client. The
+// window is timed with the Clock set by WithClock().
+func WithClientFairness(share float32, window time.Duration, key func(r *http.Request) string) Option {
+	return fairnessOption{share: share, window: window, keyF: key}
+}
+
+// allowed returns true if the client with key may receive another injection.
+func (c *fairness) allowed(key string) bool {
+	if key == "" {
+		return true
+	}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	c.expire(c.clock.Now())
+
+	return c.fits(key)
+}
+
+// record counts an injection for the client with key and returns true, or returns false if the
+// client may not receive another injection, such as when another of its requests was injected
+// first.
+func (c *fairness) record(key string) bool {
+	if key == "" {
+		return true
+	}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	now := c.clock.Now()
+	c.expire(now)
+	if !c.fits(key) {
+		return false
+	}
+
+	if c.counts == nil {
+		c.counts = make(map[string]int)
+	}
+	c.injections = append(c.injections, fairInjection{at: now, key: key})
+	c.counts[key]++
+
+	return true
+}
+
+// fits returns true if one more injection for key keeps it within its share. c.mtx must be held.
+func (c *fairness) fits(key string) bool {
+	limit := int(c.share * float32(len(c.injections)+1))
+	if limit < 1 {
+		limit = 1
+	}
+
+	return c.counts[key] < limit
+}
+
+// expire forgets injections that left the window at now. c.mtx must be held.
+func (c *fairness) expire(now time.Time) {
+	var n int
+	for n < len(c.injections) && !now.Before(c.injections[n].at.Add(c.window)) {
+		key := c.injections[n].key
+		c.counts[key]--
+		if c.counts[key] == 0 {
+			delete(c.counts, key)
+		}
+		n++
+	}
+
+	if n > 0 {
+		c.injections = append(c.injections[:0], c.injections[n:]...)
+	}
+}
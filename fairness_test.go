@@ -0,0 +1,288 @@
+package fault
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/github/go-fault/faulttest"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestWithClientFairness tests the options of WithClientFairness.
+func TestWithClientFairness(t *testing.T) {
+	t.Parallel()
+
+	clock := faulttest.NewClock(time.Time{})
+
+	tests := []struct {
+		name        string
+		giveOptions []Option
+		wantShare   float32
+		wantWindow  time.Duration
+		wantKey     string
+		wantClock   Clock
+		wantErr     error
+	}{
+		{
+			name:        "client ip",
+			giveOptions: []Option{WithClientFairness(0.1, time.Minute, nil)},
+			wantShare:   0.1,
+			wantWindow:  time.Minute,
+			wantKey:     "192.0.2.1",
+			wantClock:   NewRealClock(),
+		},
+		{
+			name: "key func and clock",
+			giveOptions: []Option{
+				WithClientFairness(1.0, time.Hour, func(r *http.Request) string { return r.Header.Get("X-Session") }),
+				WithClock(clock),
+			},
+			wantShare:  1.0,
+			wantWindow: time.Hour,
+			wantKey:    "abc",
+			wantClock:  clock,
+		},
+		{
+			name:        "negative share",
+			giveOptions: []Option{WithClientFairness(-0.1, time.Minute, nil)},
+			wantErr:     ErrInvalidPercent,
+		},
+		{
+			name:        "share above 1",
+			giveOptions: []Option{WithClientFairness(1.1, time.Minute, nil)},
+			wantErr:     ErrInvalidPercent,
+		},
+		{
+			name:        "zero window",
+			giveOptions: []Option{WithClientFairness(0.1, 0, nil)},
+			wantErr:     ErrInvalidFairnessWindow,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			f, err := NewFault(newTestInjectorNoop(), tt.giveOptions...)
+
+			assert.Equal(t, tt.wantErr, err)
+			if tt.wantErr != nil {
+				assert.Nil(t, f)
+				return
+			}
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header.Set("X-Session", "abc")
+
+			assert.Equal(t, tt.wantShare, f.fairness.share)
+			assert.Equal(t, tt.wantWindow, f.fairness.window)
+			assert.Equal(t, tt.wantKey, f.fairness.keyF(r))
+			assert.Equal(t, tt.wantClock, f.fairness.clock)
+		})
+	}
+}
+
+// TestFairnessRecord tests that fairness limits each client to its share of the injections in the
+// window.
+func TestFairnessRecord(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		giveShare float32
+		giveKeys  []string
+		want      []bool
+	}{
+		{
+			name:      "one client",
+			giveShare: 0.5,
+			giveKeys:  []string{"a", "a", "a"},
+			want:      []bool{true, false, false},
+		},
+		{
+			name:      "three clients",
+			giveShare: 0.5,
+			giveKeys:  []string{"a", "b", "a", "c", "a", "a"},
+			want:      []bool{true, true, false, true, true, false},
+		},
+		{
+			name:      "busy client",
+			giveShare: 0.25,
+			giveKeys:  []string{"a", "a", "b", "c", "d", "a", "e", "f", "g", "h", "a"},
+			want:      []bool{true, false, true, true, true, false, true, true, true, true, true},
+		},
+		{
+			name:      "no share",
+			giveShare: 0,
+			giveKeys:  []string{"a", "a", "b"},
+			want:      []bool{true, false, true},
+		},
+		{
+			name:      "full share",
+			giveShare: 1.0,
+			giveKeys:  []string{"a", "a", "a"},
+			want:      []bool{true, true, true},
+		},
+		{
+			name:      "no client",
+			giveShare: 0.5,
+			giveKeys:  []string{"", "", ""},
+			want:      []bool{true, true, true},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			c := &fairness{share: tt.giveShare, window: time.Minute, clock: faulttest.NewClock(time.Time{})}
+
+			var got []bool
+			for _, key := range tt.giveKeys {
+				allowed := c.allowed(key)
+				assert.Equal(t, allowed, c.record(key))
+				got = append(got, allowed)
+			}
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+// TestFairnessWindow tests that injections are forgotten once they leave the window.
+func TestFairnessWindow(t *testing.T) {
+	t.Parallel()
+
+	clock := faulttest.NewClock(time.Time{})
+	c := &fairness{share: 0.5, window: time.Minute, clock: clock}
+
+	assert.True(t, c.record("a"))
+	clock.Advance(30 * time.Second)
+	assert.True(t, c.record("b"))
+	assert.False(t, c.record("b"))
+
+	// the injection of "a" leaves the window
+	clock.Advance(30 * time.Second)
+	assert.True(t, c.allowed("a"))
+	assert.Len(t, c.injections, 1)
+	assert.Equal(t, map[string]int{"b": 1}, c.counts)
+
+	clock.Advance(30 * time.Second)
+	assert.True(t, c.allowed("b"))
+	assert.Len(t, c.injections, 0)
+	assert.Equal(t, map[string]int{}, c.counts)
+}
+
+// TestFaultHandlerClientFairness tests that a Fault with a fairness constraint does not run its
+// Injector on a client that received its share of the injections.
+func TestFaultHandlerClientFairness(t *testing.T) {
+	t.Parallel()
+
+	clock := faulttest.NewClock(time.Time{})
+	f, err := NewFault(newTestInjector500s(),
+		WithEnabled(true),
+		WithParticipation(1.0),
+		WithClientFairness(0.5, time.Minute, func(r *http.Request) string { return r.Header.Get("X-Client") }),
+		WithClock(clock),
+	)
+	assert.NoError(t, err)
+
+	h := f.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(testHandlerCode)
+	}))
+	serve := func(client string) int {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("X-Client", client)
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, r)
+		return rr.Code
+	}
+
+	assert.Equal(t, http.StatusInternalServerError, serve("a"))
+	assert.Equal(t, testHandlerCode, serve("a"))
+	assert.Equal(t, http.StatusInternalServerError, serve("b"))
+	assert.Equal(t, testHandlerCode, serve("a"))
+	assert.Equal(t, http.StatusInternalServerError, serve("c"))
+	assert.Equal(t, http.StatusInternalServerError, serve("a"))
+
+	// requests without a client are not constrained
+	assert.Equal(t, http.StatusInternalServerError, serve(""))
+
+	clock.Advance(time.Minute)
+	assert.Equal(t, http.StatusInternalServerError, serve("a"))
+
+	assert.Equal(t, int64(2), f.stats.counters()["skipped_fairness"])
+}
+
+// TestFaultServeClientFairnessRecorded tests that a request evaluated before other requests of its
+// client used up its share is skipped.
+func TestFaultServeClientFairnessRecorded(t *testing.T) {
+	t.Parallel()
+
+	reporter := &testStateReporter{states: make(chan InjectorState, 2)}
+	f, err := NewFault(newTestInjector500s(),
+		WithEnabled(true),
+		WithParticipation(1.0),
+		WithClientFairness(0.5, time.Minute, nil),
+		WithReporter(reporter),
+	)
+	assert.NoError(t, err)
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(testHandlerCode)
+	})
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	st := f.injector.Load()
+
+	ev1, ev2 := f.evaluate(r, st), f.evaluate(r, st)
+	assert.True(t, ev1.Injected)
+	assert.True(t, ev2.Injected)
+
+	rr := httptest.NewRecorder()
+	f.serve(rr, r, next, st, ev1)
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+
+	rr = httptest.NewRecorder()
+	f.serve(rr, r, next, st, ev2)
+	assert.Equal(t, testHandlerCode, rr.Code)
+
+	states := map[InjectorState]int{}
+	for n := 0; n < 2; n++ {
+		states[<-reporter.states]++
+	}
+	assert.Equal(t, map[InjectorState]int{StateSelected: 1, StateSkipped: 1}, states)
+	assert.Equal(t, int64(1), f.stats.counters()["skipped_fairness"])
+}
+
+// TestExplainRequestClientFairness tests that ExplainRequest explains requests of clients that
+// received their share of the injections.
+func TestExplainRequestClientFairness(t *testing.T) {
+	t.Parallel()
+
+	f, err := NewFault(newTestInjectorNoop(),
+		WithName("f"),
+		WithEnabled(true),
+		WithParticipation(1.0),
+		WithClientFairness(0.5, time.Minute, nil),
+		WithClock(faulttest.NewClock(time.Time{})),
+	)
+	assert.NoError(t, err)
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	assert.True(t, ExplainRequest(f, r).Eligible)
+
+	f.Handler(http.NotFoundHandler()).ServeHTTP(httptest.NewRecorder(), r)
+
+	assert.Equal(t, Explanation{
+		Fault:         "f",
+		Injector:      "testInjectorNoop",
+		Enabled:       true,
+		Matched:       true,
+		Participation: 1.0,
+		SkipReason:    SkipFairness,
+	}, ExplainRequest(f, r))
+}
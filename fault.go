@@ -5,6 +5,7 @@ import (
 	"math/rand"
 	"net/http"
 	"sync"
+	"sync/atomic"
 )
 
 const (
@@ -17,18 +18,51 @@ var (
 	ErrNilInjector = errors.New("injector cannot be nil")
 	// ErrInvalidPercent when a percent is outside of [0.0,1.0).
 	ErrInvalidPercent = errors.New("percent must be 0.0 <= percent <= 1.0")
+	// ErrNilRand when a nil *rand.Rand is passed.
+	ErrNilRand = errors.New("rand cannot be nil")
 )
 
+// injectorState is an Injector and what a Fault derives from it. It is replaced as a whole so a
+// request always sees a consistent Injector.
+type injectorState struct {
+	injector    Injector
+	name        string
+	destructive bool
+}
+
+// newInjectorState returns the injectorState of i.
+func newInjectorState(i Injector) *injectorState {
+	return &injectorState{
+		injector:    i,
+		name:        InjectorString(i),
+		destructive: IsDestructive(i),
+	}
+}
+
 // Fault combines an Injector with options on when to use that Injector.
 type Fault struct {
+	// stats counts the decisions the Fault makes.
+	stats *faultStats
+
+	// name identifies the Fault in reports and stats.
+	name string
+
+	// labels tag the Fault in reports and stats. They do not change once the Fault is created.
+	labels map[string]string
+
 	// enabled determines if the fault should evaluate.
-	enabled bool
+	enabled atomicBool
 
-	// injector is the Injector that will be injected.
-	injector Injector
+	// injector is the Injector that will be injected. Each request loads it once, so requests
+	// finish with the Injector they started with even if it is swapped by SetInjector.
+	injector atomic.Pointer[injectorState]
 
 	// participation is the percent of requests that run the injector. 0.0 <= p <= 1.0.
-	participation float32
+	participation atomicFloat32
+
+	// perMillion is one more than participation as an exact number of parts per million, or 0 if
+	// participation was not set with a FractionalPercent.
+	perMillion atomicUint32
 
 	// pathBlocklist is a map of paths that the Injector will never run against.
 	pathBlocklist map[string]bool
@@ -42,6 +76,12 @@ type Fault struct {
 	// headerAllowlist, if set, is a map of the only headers the Injector will run against.
 	headerAllowlist map[string]string
 
+	// matchers must all match a request for the Injector to run against it.
+	matchers []RequestMatcher
+
+	// idempotency, if set, stops a destructive Injector from running on non-idempotent requests.
+	idempotency IdempotencyClassifier
+
 	// randSeed is a number to seed rand with.
 	randSeed int64
 
@@ -51,8 +91,89 @@ type Fault struct {
 	// randF is a function that returns a float32 [0.0,1.0).
 	randF func() float32
 
+	// rolls is the number of times randF was called. Protected by randMtx.
+	rolls uint64
+
+	// requestIDHeader, if set, is the header whose value seeds the participation roll.
+	requestIDHeader string
+
+	// participationKeyF, if set, returns the key that seeds the participation roll of requests
+	// without a request ID.
+	participationKeyF func(r *http.Request) string
+
+	// strategy, if set, decides participation in place of the random roll.
+	strategy ParticipationStrategy
+
+	// decisionHook, if set, is called with the participation decision and can veto an injection.
+	decisionHook func(r *http.Request, willInject bool) bool
+
 	// randMtx protects Fault.rand, which is not thread safe.
 	randMtx sync.Mutex
+
+	// reporter receives events from the Fault.
+	reporter Reporter
+
+	// tracing determines if every Evaluation is sent to the reporter.
+	tracing bool
+
+	// sampler, if set, records the latency of requests.
+	sampler *LatencySampler
+
+	// outcomes, if set, records the status code and duration of requests.
+	outcomes *OutcomeRecorder
+
+	// slo, if set, counts the requests that are not injected and disables the Fault when they breach
+	// its objectives.
+	slo *SLOGuard
+
+	// cohorts, if set, limits the Injector to the treatment cohort of an experiment.
+	cohorts *CohortAssigner
+
+	// priority orders the Fault in a Manager, highest first.
+	priority int
+
+	// limit, if set, limits how fast SetParticipation can raise participation.
+	limit atomic.Pointer[participationLimit]
+
+	// cooldown, if set, exempts clients from the Injector for a while after it runs on them.
+	cooldown *cooldown
+
+	// fairness, if set, limits the share of injections any one client receives.
+	fairness *fairness
+
+	// schedule, if set, limits when the Injector may run.
+	schedule Schedule
+
+	// overrideSecret, if set, verifies Overrides that force or suppress the Fault on a request.
+	overrideSecret []byte
+
+	// sticky, if set, runs the Injector on every request with the key of a request it ran on for a
+	// while.
+	sticky *cooldown
+
+	// rateLimit, if set, caps how fast the Injector runs.
+	rateLimit *rateLimit
+
+	// budget, if set, is the total number of injections before the Fault disables itself.
+	budget *budget
+
+	// maxConcurrent, if not 0, limits the requests the Injector runs on at once.
+	maxConcurrent int64
+
+	// drain tracks the injected requests so that Close can wait for them.
+	drain drain
+
+	// concurrent is the number of requests the Injector is running on when maxConcurrent is set.
+	// Must be accessed atomically.
+	concurrent int64
+
+	// transitions, if set, ramp the Fault down when it is disabled and keep it in a state for a
+	// minimum time.
+	transitions *transitions
+
+	// clock times the cooldown, the fairness window, the sticky TTL, the injection rate, and the
+	// transitions. Default RealClock.
+	clock Clock
 }
 
 // Option configures a Fault.
@@ -60,10 +181,29 @@ type Option interface {
 	applyFault(f *Fault) error
 }
 
+type nameOption string
+
+func (o nameOption) applyFault(f *Fault) error {
+	f.name = string(o)
+	return nil
+}
+
+// NameOption configures things that can be named.
+type NameOption interface {
+	Option
+	SplitFaultOption
+}
+
+// WithName sets the name that identifies the Fault in reports and stats. Default the
+// InjectorString of the Injector.
+func WithName(n string) NameOption {
+	return nameOption(n)
+}
+
 type enabledOption bool
 
 func (o enabledOption) applyFault(f *Fault) error {
-	f.enabled = bool(o)
+	f.enabled.Store(bool(o))
 	return nil
 }
 
@@ -78,12 +218,20 @@ func (o participationOption) applyFault(f *Fault) error {
 	if o < 0.0 || o > 1.0 {
 		return ErrInvalidPercent
 	}
-	f.participation = float32(o)
+	f.participation.Store(float32(o))
+	f.perMillion.Store(0)
 	return nil
 }
 
+// ParticipationOption configures things that can set a participation percent.
+type ParticipationOption interface {
+	Option
+	ProtocolListenerOption
+	SplitFaultOption
+}
+
 // WithParticipation sets the percent of requests that run the Injector. 0.0 <= p <= 1.0.
-func WithParticipation(p float32) Option {
+func WithParticipation(p float32) ParticipationOption {
 	return participationOption(p)
 }
 
@@ -156,6 +304,18 @@ func WithHeaderAllowlist(allowlist map[string]string) Option {
 type RandSeedOption interface {
 	Option
 	RandomInjectorOption
+	ProtocolListenerOption
+	OutageInjectorOption
+	PresetOption
+	DistributionInjectorOption
+	WeightedLatencyInjectorOption
+	SoakOption
+	SlowInjectorOption
+	ResponseCorruptionInjectorOption
+	ChainInjectorOption
+	FaultGroupOption
+	ListenerOption
+	RequestTamperInjectorOption
 }
 
 type randSeedOption int64
@@ -165,7 +325,8 @@ func (o randSeedOption) applyFault(f *Fault) error {
 	return nil
 }
 
-// WithRandSeed sets the rand.Rand seed for this struct.
+// WithRandSeed sets the rand.Rand seed for this struct. Use the value of Seed() to reproduce the
+// random decisions of another struct.
 func WithRandSeed(s int64) RandSeedOption {
 	return randSeedOption(s)
 }
@@ -183,18 +344,46 @@ func WithRandFloat32Func(f func() float32) Option {
 	return randFloat32FuncOption(f)
 }
 
+type randOption struct {
+	rand *rand.Rand
+}
+
+func (o randOption) applyFault(f *Fault) error {
+	if o.rand == nil {
+		return ErrNilRand
+	}
+
+	f.rand = o.rand
+	return nil
+}
+
+// WithRand sets the random number generator the Fault rolls participation with, such as one with a
+// custom rand.Source. The Fault takes it over and guards it with its own lock, so it must not be
+// used elsewhere. Seed() returns the seed set by WithRandSeed, which a Fault with its own
+// generator does not use. Default a generator seeded with the seed of the Fault.
+func WithRand(r *rand.Rand) Option {
+	return randOption{r}
+}
+
+func (o reporterOption) applyFault(f *Fault) error {
+	f.reporter = o.reporter
+	return nil
+}
+
 // NewFault sets/validates the Injector and Options and returns a usable Fault.
 func NewFault(i Injector, opts ...Option) (*Fault, error) {
-	if i == nil {
-		return nil, ErrNilInjector
+	if err := validateInjector(i); err != nil {
+		return nil, err
 	}
 
 	// set defaults
 	f := &Fault{
-		injector: i,
+		stats:    &faultStats{},
 		randSeed: defaultRandSeed,
 		randF:    nil,
+		reporter: NewNoopReporter(),
 	}
+	f.injector.Store(newInjectorState(i))
 
 	// apply options
 	for _, opt := range opts {
@@ -205,35 +394,445 @@ func NewFault(i Injector, opts ...Option) (*Fault, error) {
 	}
 
 	// set seeded rand source and function
-	f.rand = rand.New(rand.NewSource(f.randSeed))
+	if f.rand == nil {
+		f.rand = rand.New(rand.NewSource(f.randSeed))
+	}
 	if f.randF == nil {
 		f.randF = f.rand.Float32
 	}
 
+	if f.name == "" {
+		f.name = f.injector.Load().name
+	}
+
+	clock := f.clock
+	if clock == nil {
+		clock = NewRealClock()
+	}
+	if f.cooldown != nil {
+		f.cooldown.clock = clock
+	}
+	if f.fairness != nil {
+		f.fairness.clock = clock
+	}
+	if f.sticky != nil {
+		f.sticky.clock = clock
+	}
+	if f.rateLimit != nil {
+		f.rateLimit.clock = clock
+	}
+	if f.transitions != nil {
+		f.transitions.clock = clock
+	}
+
+	if f.slo != nil {
+		f.slo.add(f)
+	}
+
+	if f.enabled.Load() {
+		runEnableHook(i)
+	}
+
 	return f, nil
 }
 
+// SetEnabled enables or disables the Fault. It is safe to call while handling requests. The
+// Injector's OnEnable or OnDisable hook runs when the Fault changes state, and enabling the Fault
+// starts a new budget if it has one set by WithInjectionBudget. The change is smoothed by
+// WithRampDown and WithMinDwell, if set.
+func (f *Fault) SetEnabled(e bool) {
+	if f.transitions != nil {
+		f.transitions.set(f, e)
+		return
+	}
+
+	f.setEnabled(e)
+}
+
+// setEnabled enables or disables the Fault at once and returns true if it changed state.
+func (f *Fault) setEnabled(e bool) bool {
+	if f.enabled.Swap(e) == e {
+		return false
+	}
+
+	if e && f.budget != nil {
+		f.budget.reset()
+	}
+
+	if e {
+		runEnableHook(f.Injector())
+	} else {
+		runDisableHook(f.Injector())
+	}
+
+	return true
+}
+
+// Enabled returns true if the Fault is enabled.
+func (f *Fault) Enabled() bool {
+	return f.enabled.Load()
+}
+
+// Participation returns the percent of requests that run the Injector.
+func (f *Fault) Participation() float32 {
+	return f.participation.Load()
+}
+
+// SetParticipation sets the percent of requests that run the Injector. 0.0 <= p <= 1.0. It is safe
+// to call while handling requests. The Injector's OnConfigChange hook runs after the change. It
+// returns ErrParticipationIncrease if the Fault is managed by a Manager with WithParticipationLimit
+// and p raises it faster than the limit allows.
+func (f *Fault) SetParticipation(p float32) error {
+	if p < 0.0 || p > 1.0 {
+		return ErrInvalidPercent
+	}
+
+	err := f.setParticipation(p, participationOption(p))
+	if err != nil {
+		return err
+	}
+
+	runConfigChangeHook(f.Injector())
+
+	return nil
+}
+
+// Injector returns the Injector of the Fault.
+func (f *Fault) Injector() Injector {
+	return f.injector.Load().injector
+}
+
+// SetInjector atomically replaces the Injector of the Fault. It is safe to call while handling
+// requests: requests that already started finish with the Injector they started with and new
+// requests use i. If the Fault is enabled the OnDisable hook of the old Injector and the OnEnable
+// hook of i run after the swap, even though requests may still be using the old Injector. It returns
+// the error of i.Validate, and keeps the old Injector, if i implements Validator and is invalid.
+func (f *Fault) SetInjector(i Injector) error {
+	if err := validateInjector(i); err != nil {
+		return err
+	}
+
+	old := f.injector.Swap(newInjectorState(i))
+
+	if f.enabled.Load() {
+		runDisableHook(old.injector)
+		runEnableHook(i)
+	}
+
+	return nil
+}
+
+// Name returns the name that identifies the Fault.
+func (f *Fault) Name() string {
+	return f.name
+}
+
 // Handler determines if the Injector should execute and runs it if so.
 func (f *Fault) Handler(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// By default faults do not evaluate. Here we go through conditions where faults
-		// will evaluate, if everything is configured correctly.
-		var shouldEvaluate bool
+		st := f.injector.Load()
+		f.serve(w, r, next, st, f.evaluate(r, st))
+	})
+}
+
+// serve settles ev, the Evaluation of r against the Injector in st, and then runs the Injector or
+// next as it decided.
+func (f *Fault) serve(w http.ResponseWriter, r *http.Request, next http.Handler, st *injectorState, ev Evaluation) {
+	ev, done := f.settle(r, st, ev)
+	defer done()
 
-		shouldEvaluate = f.enabled
+	if f.sampler != nil {
+		defer f.sampler.start(r, ev.Injected)()
+	}
 
-		shouldEvaluate = shouldEvaluate && f.checkAllowBlockLists(shouldEvaluate, r)
+	if f.outcomes != nil {
+		sw := &statusWriter{ResponseWriter: w, code: http.StatusOK}
+		defer f.outcomes.start(f.name, r, ev.Injected, sw)()
+		w = sw
+	}
 
-		// false if not selected for participation
-		shouldEvaluate = shouldEvaluate && f.participate()
+	er, events := f.reporter.(EventReporter)
 
-		// run the injector or pass
-		if shouldEvaluate {
-			f.injector.Handler(next).ServeHTTP(w, r)
-		} else {
-			next.ServeHTTP(w, r)
+	// run the injector or pass
+	if ev.Injected {
+		if events {
+			sw := &statusWriter{ResponseWriter: w, code: http.StatusOK}
+			defer f.reportInjection(er, r, st, sw)()
+			w = sw
 		}
-	})
+
+		var detach func()
+		r, detach = f.drain.attach(r)
+		defer detach()
+
+		r = withInjectingFault(r, f.name)
+
+		if ds := requestDecisions(r); ds != nil {
+			ds.inject(newDecision(ev), st.injector, next).ServeHTTP(w, r)
+			return
+		}
+
+		recordInjector(st.injector, next).ServeHTTP(w, r)
+	} else {
+		if events {
+			f.reportSkip(er, r, st, ev.SkipReason)
+		}
+
+		if ds := requestDecisions(r); ds != nil {
+			ds.add(newDecision(ev))
+		}
+
+		if f.slo != nil {
+			var end func()
+			r, w, end = f.slo.start(r, w)
+			defer end()
+		}
+
+		next.ServeHTTP(w, r)
+	}
+}
+
+// settle finishes ev, the Evaluation of r against the Injector in st, with the checks that change
+// the state of the Fault, and then reports and counts it. It returns the final Evaluation and a
+// function to call once the Injector, or whatever handles r instead, returns.
+func (f *Fault) settle(r *http.Request, st *injectorState, ev Evaluation) (Evaluation, func()) {
+	// a closed Fault injects into no new requests, even ones it was forced to inject into
+	entered := false
+	if ev.Injected {
+		if entered = f.drain.enter(); !entered {
+			ev.Injected = false
+			ev.SkipReason = SkipClosed
+		}
+	}
+
+	// injections over the concurrency limit pass through whatever else selected the request, and
+	// the slot is taken first so that the checks below are not charged for a skipped injection
+	acquired := false
+	if ev.Injected && f.maxConcurrent > 0 {
+		if acquired = f.acquireConcurrent(); !acquired {
+			ev.Injected = false
+			ev.SkipReason = SkipConcurrency
+		}
+	}
+
+	// another request of the client may have started its cooldown since r was evaluated
+	if ev.Injected && f.cooldown != nil && !f.cooldown.start(f.cooldown.keyF(r)) {
+		ev.Injected = false
+		ev.SkipReason = SkipCooldown
+	}
+
+	// other requests of the client may have used up its share since r was evaluated
+	if ev.Injected && f.fairness != nil && !f.fairness.record(f.fairness.keyF(r)) {
+		ev.Injected = false
+		ev.SkipReason = SkipFairness
+	}
+
+	// injections over the rate or the budget are skipped whatever else selected the request
+	if ev.Injected && f.rateLimit != nil && !f.rateLimit.take() {
+		ev.Injected = false
+		ev.SkipReason = SkipRateLimit
+	}
+
+	exhausted := false
+	if ev.Injected && f.budget != nil {
+		ev.Injected, exhausted = f.budget.take()
+		if !ev.Injected {
+			ev.SkipReason = SkipBudget
+		}
+	}
+
+	// the requests with the key of r are faulted from the first injection until the TTL ends
+	if ev.Injected && f.sticky != nil {
+		f.sticky.start(f.sticky.keyF(r))
+	}
+
+	f.reportEvaluation(ev)
+
+	if f.tracing {
+		if er, ok := f.reporter.(EvaluationReporter); ok {
+			go er.ReportEvaluation(ev)
+		}
+	}
+
+	atomic.AddInt64(&f.stats.evaluated, 1)
+
+	if !ev.Injected {
+		if acquired {
+			f.releaseConcurrent()
+		}
+		if entered {
+			f.drain.leave()
+		}
+		f.stats.skip(ev.SkipReason)
+		return ev, func() {}
+	}
+
+	f.stats.inject(st.name)
+
+	return ev, func() {
+		atomic.AddInt64(&f.stats.active, -1)
+		if acquired {
+			f.releaseConcurrent()
+		}
+
+		// the Fault disables itself once the request that used up its budget is done
+		if exhausted {
+			f.exhaustBudget(r, st)
+		}
+
+		f.drain.leave()
+	}
+}
+
+// evaluate decides if the Injector should run against the request. By default faults do not
+// evaluate. Here we go through conditions where faults will evaluate, if everything is configured
+// correctly.
+func (f *Fault) evaluate(r *http.Request, st *injectorState) Evaluation {
+	ev := Evaluation{
+		Request:       r,
+		Fault:         f.name,
+		Enabled:       f.enabled.Load(),
+		Participation: f.participation.Load(),
+		Injector:      st.name,
+		Seed:          f.randSeed,
+	}
+
+	if f.overrideSecret != nil {
+		if action, ok := f.override(r); ok {
+			ev.Override = action
+			if action == OverrideSuppress {
+				ev.SkipReason = SkipOverride
+				return ev
+			}
+
+			ev.Matched = true
+			ev.Injected = true
+			return ev
+		}
+	}
+
+	if !ev.Enabled {
+		ev.SkipReason = SkipDisabled
+		if f.Closed() {
+			ev.SkipReason = SkipClosed
+		}
+		return ev
+	}
+
+	if f.schedule != nil && !f.schedule.Active(f.now()) {
+		ev.SkipReason = SkipSchedule
+		return ev
+	}
+
+	ev.Matched = f.checkAllowBlockLists(true, r)
+	if !ev.Matched {
+		ev.SkipReason = SkipUnmatched
+		return ev
+	}
+
+	if f.cohorts != nil {
+		ev.Cohort = f.assignCohort(r)
+		if ev.Cohort != CohortTreatment {
+			ev.SkipReason = SkipCohort
+			return ev
+		}
+	}
+
+	if st.destructive && f.idempotency != nil && !f.idempotency.Idempotent(r) {
+		ev.SkipReason = SkipUnsafe
+		return ev
+	}
+
+	if f.cooldown != nil && f.cooldown.active(f.cooldown.keyF(r)) {
+		ev.SkipReason = SkipCooldown
+		return ev
+	}
+
+	if f.fairness != nil && !f.fairness.allowed(f.fairness.keyF(r)) {
+		ev.SkipReason = SkipFairness
+		return ev
+	}
+
+	if f.sticky != nil && f.sticky.active(f.sticky.keyF(r)) {
+		ev.Injected = true
+		ev.Sticky = true
+		return f.applyDecisionHook(r, ev)
+	}
+
+	// false if not selected for participation
+	if id := f.requestID(r); id != "" {
+		ev.RequestID = id
+		ev.Injected, ev.Roll = f.rollKey(id)
+	} else if key := f.participationKey(r); key != "" {
+		ev.Key = key
+		ev.Injected, ev.Roll = f.rollKey(key)
+	} else if f.strategy != nil {
+		ev.Injected = f.strategy.Participate(r, f.currentParticipation())
+	} else {
+		ev.Injected, ev.Roll, ev.Rolls = f.roll()
+	}
+	if !ev.Injected {
+		ev.SkipReason = SkipParticipation
+	}
+
+	return f.applyDecisionHook(r, ev)
+}
+
+// applyDecisionHook calls the decision hook of the Fault, if any, with ev, the Evaluation of r once
+// participation is decided, and skips r with SkipVeto if the hook vetoes the injection.
+func (f *Fault) applyDecisionHook(r *http.Request, ev Evaluation) Evaluation {
+	if f.decisionHook == nil {
+		return ev
+	}
+
+	if !f.decisionHook(r, ev.Injected) && ev.Injected {
+		ev.Injected = false
+		ev.Sticky = false
+		ev.SkipReason = SkipVeto
+	}
+
+	return ev
+}
+
+// assignCohort assigns r to a Cohort and reports the Exposure. Requests without a unit are not
+// assigned.
+func (f *Fault) assignCohort(r *http.Request) Cohort {
+	unit, cohort, ok := f.cohorts.Assign(r)
+	if !ok {
+		return ""
+	}
+
+	if er, ok := f.reporter.(ExposureReporter); ok {
+		go er.ReportExposure(Exposure{
+			Request:    r,
+			Fault:      f.name,
+			Experiment: f.cohorts.Experiment(),
+			Unit:       unit,
+			Cohort:     cohort,
+		})
+	}
+
+	return cohort
+}
+
+// reportEvaluation reports the outcome of an Evaluation of an enabled Fault to f.reporter.
+func (f *Fault) reportEvaluation(ev Evaluation) {
+	// avoid starting a goroutine on every request for nothing
+	if isNoopReporter(f.reporter) {
+		return
+	}
+
+	switch {
+	case ev.Injected:
+		go f.reporter.Report(f.name, StateSelected)
+	case ev.SkipReason == SkipUnmatched, ev.SkipReason == SkipCohort, ev.SkipReason == SkipUnsafe:
+		go f.reporter.Report(f.name, StateUnmatched)
+	case ev.SkipReason == SkipParticipation, ev.SkipReason == SkipConflict, ev.SkipReason == SkipBlackout,
+		ev.SkipReason == SkipCooldown, ev.SkipReason == SkipFairness, ev.SkipReason == SkipRateLimit,
+		ev.SkipReason == SkipBudget, ev.SkipReason == SkipOverride, ev.SkipReason == SkipFreeze,
+		ev.SkipReason == SkipConcurrency, ev.SkipReason == SkipVeto, ev.SkipReason == SkipClosed:
+		go f.reporter.Report(f.name, StateSkipped)
+	}
 }
 
 // checkAllowBlockLists checks the request against the provided allowlists and blocklists, returning
@@ -259,19 +858,64 @@ func (f *Fault) checkAllowBlockLists(shouldEvaluate bool, r *http.Request) bool
 		}
 	}
 
+	// false if any RequestMatcher does not match
+	for _, m := range f.matchers {
+		shouldEvaluate = shouldEvaluate && m.MatchRequest(r)
+	}
+
 	return shouldEvaluate
 }
 
 // participate randomly decides (returns true) if the Injector should run based on f.participation.
 // Numbers outside of [0.0,1.0] will always return false.
 func (f *Fault) participate() bool {
+	p, _, _ := f.roll()
+	return p
+}
+
+// roll is participate but also returns the random number that was rolled and how many rolls have
+// been made.
+func (f *Fault) roll() (bool, float32, uint64) {
 	f.randMtx.Lock()
 	rn := f.randF()
+	f.rolls++
+	rolls := f.rolls
 	f.randMtx.Unlock()
 
-	if rn < f.participation && f.participation <= 1.0 {
-		return true
+	return f.decide(rn), rn, rolls
+}
+
+// decide returns true if rn, a number in [0.0,1.0), selects a request for participation. During a
+// ramp-down the participation is scaled down by dividing rn instead.
+func (f *Fault) decide(rn float32) bool {
+	if f.transitions != nil {
+		s := f.transitions.scale()
+		if s <= 0 {
+			return false
+		}
+		rn /= s
+	}
+
+	if ppm := f.perMillion.Load(); ppm > 0 {
+		return rollPerMillion(rn, ppm-1)
 	}
 
-	return false
+	p := f.participation.Load()
+
+	return rn < p && p <= 1.0
+}
+
+// Seed returns the seed of the Fault's random number generator.
+func (f *Fault) Seed() int64 {
+	return f.randSeed
+}
+
+// Reporter returns the Reporter of the Fault.
+func (f *Fault) Reporter() Reporter {
+	return f.reporter
+}
+
+// SetReporter replaces the Reporter of the Fault.
+func (f *Fault) SetReporter(r Reporter) {
+	f.reporter = r
 }
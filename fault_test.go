@@ -4,7 +4,9 @@ import (
 	"fmt"
 	"math/rand"
 	"net/http"
+	"net/http/httptest"
 	"strings"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -33,11 +35,15 @@ func TestNewFault(t *testing.T) {
 				WithHeaderAllowlist(map[string]string{"allow": "yes"}),
 				WithRandSeed(100),
 				WithRandFloat32Func(func() float32 { return 0.0 }),
+				WithReporter(newTestReporter()),
+				WithTracing(true),
+				WithName("all"),
 			},
 			wantFault: &Fault{
-				enabled:       true,
-				injector:      newTestInjectorNoop(),
-				participation: 1.0,
+				stats:         &faultStats{},
+				name:          "all",
+				enabled:       newAtomicBool(true),
+				participation: newAtomicFloat32(1.0),
 				pathBlocklist: map[string]bool{
 					"/donotinject": true,
 				},
@@ -53,6 +59,8 @@ func TestNewFault(t *testing.T) {
 				randSeed: 100,
 				rand:     rand.New(rand.NewSource(100)),
 				randF:    func() float32 { return 0.0 },
+				reporter: newTestReporter(),
+				tracing:  true,
 			},
 			wantErr: nil,
 		},
@@ -86,14 +94,16 @@ func TestNewFault(t *testing.T) {
 			giveInjector: newTestInjectorNoop(),
 			giveOptions:  []Option{},
 			wantFault: &Fault{
-				enabled:       false,
-				injector:      newTestInjectorNoop(),
-				participation: 0.0,
+				stats:         &faultStats{},
+				name:          "testInjectorNoop",
+				enabled:       newAtomicBool(false),
+				participation: newAtomicFloat32(0.0),
 				pathBlocklist: nil,
 				pathAllowlist: nil,
 				randSeed:      defaultRandSeed,
 				rand:          rand.New(rand.NewSource(defaultRandSeed)),
 				randF:         rand.New(rand.NewSource(defaultRandSeed)).Float32,
+				reporter:      NewNoopReporter(),
 			},
 			wantErr: nil,
 		},
@@ -106,10 +116,15 @@ func TestNewFault(t *testing.T) {
 
 			f, err := NewFault(tt.giveInjector, tt.giveOptions...)
 
-			// Function equality cannot be determined so set to nil before comparing
+			// Function equality cannot be determined so set to nil before comparing. The Injector is
+			// compared separately because atomic pointers are only equal if they point to the same
+			// value.
 			if tt.wantFault != nil {
 				f.randF = nil
 				tt.wantFault.randF = nil
+
+				assert.Equal(t, newInjectorState(tt.giveInjector), f.injector.Load())
+				f.injector.Store(nil)
 			}
 
 			assert.Equal(t, tt.wantErr, err)
@@ -345,3 +360,285 @@ func TestFaultPercentDo(t *testing.T) {
 		})
 	}
 }
+
+// TestFaultHandlerReporter tests that Fault.Handler reports its decisions.
+func TestFaultHandlerReporter(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		giveOptions []Option
+		want        InjectorState
+	}{
+		{
+			name: "unmatched",
+			giveOptions: []Option{
+				WithEnabled(true),
+				WithParticipation(1.0),
+				WithPathBlocklist([]string{"/"}),
+			},
+			want: StateUnmatched,
+		},
+		{
+			name: "skipped",
+			giveOptions: []Option{
+				WithEnabled(true),
+				WithParticipation(0.0),
+			},
+			want: StateSkipped,
+		},
+		{
+			name: "selected",
+			giveOptions: []Option{
+				WithEnabled(true),
+				WithParticipation(1.0),
+			},
+			want: StateSelected,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			reporter := newTestStateReporter()
+
+			f, err := NewFault(newTestInjectorNoop(), append(tt.giveOptions, WithReporter(reporter))...)
+			assert.NoError(t, err)
+
+			testRequest(t, f)
+
+			assert.Equal(t, tt.want, <-reporter.states)
+		})
+	}
+}
+
+// TestFaultSetters tests Fault.SetEnabled and Fault.SetParticipation.
+func TestFaultSetters(t *testing.T) {
+	t.Parallel()
+
+	f, err := NewFault(newTestInjector500s())
+	assert.NoError(t, err)
+
+	rr := testRequest(t, f)
+	assert.Equal(t, testHandlerCode, rr.Code)
+
+	f.SetEnabled(true)
+	assert.NoError(t, f.SetParticipation(1.0))
+
+	rr = testRequest(t, f)
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+
+	assert.Equal(t, ErrInvalidPercent, f.SetParticipation(1.5))
+	assert.Equal(t, float32(1.0), f.participation.Load())
+
+	assert.True(t, f.Enabled())
+	assert.Equal(t, float32(1.0), f.Participation())
+	f.SetEnabled(false)
+	assert.False(t, f.Enabled())
+}
+
+// TestFaultSettersConcurrent tests that a Fault can be ramped up and down while it handles requests.
+func TestFaultSettersConcurrent(t *testing.T) {
+	t.Parallel()
+
+	f, err := NewFault(newTestInjector500s(), WithEnabled(true))
+	assert.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for n := 0; n < 4; n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for idx := 0; idx < 100; idx++ {
+				rr := testRequest(t, f)
+				assert.Contains(t, []int{testHandlerCode, http.StatusInternalServerError}, rr.Code)
+			}
+		}()
+	}
+
+	for idx := 0; idx <= 100; idx++ {
+		assert.NoError(t, f.SetParticipation(float32(idx)/100))
+		f.SetEnabled(idx%10 != 0)
+	}
+	wg.Wait()
+
+	assert.Equal(t, float32(1.0), f.Participation())
+	assert.False(t, f.Enabled())
+}
+
+// TestFaultSetInjector tests Fault.SetInjector.
+func TestFaultSetInjector(t *testing.T) {
+	t.Parallel()
+
+	old := newTestInjectorHooks()
+	f, err := NewFault(old, WithParticipation(1.0))
+	assert.NoError(t, err)
+
+	assert.Equal(t, ErrNilInjector, f.SetInjector(nil))
+	assert.Equal(t, old, f.Injector())
+
+	// hooks only run while enabled
+	i500 := newTestInjectorHooks()
+	assert.NoError(t, f.SetInjector(i500))
+	assert.Equal(t, 0, old.disables)
+	assert.Equal(t, 0, i500.enables)
+
+	f.SetEnabled(true)
+	assert.Equal(t, 1, i500.enables)
+
+	assert.NoError(t, f.SetInjector(newTestInjector500s()))
+	assert.Equal(t, 1, i500.disables)
+
+	rr := testRequest(t, f)
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	assert.Equal(t, "testInjectorHooks", f.Name())
+}
+
+// testInjectorBlock is an Injector that waits for release before returning its code.
+type testInjectorBlock struct {
+	code     int
+	started  chan struct{}
+	released chan struct{}
+}
+
+func (i *testInjectorBlock) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(i.started)
+		<-i.released
+		w.WriteHeader(i.code)
+	})
+}
+
+// TestFaultSetInjectorInFlight tests that requests finish with the Injector they started with.
+func TestFaultSetInjectorInFlight(t *testing.T) {
+	t.Parallel()
+
+	old := &testInjectorBlock{
+		code:     http.StatusTeapot,
+		started:  make(chan struct{}),
+		released: make(chan struct{}),
+	}
+
+	reporter := newTestEvaluationReporter()
+	f, err := NewFault(old,
+		WithEnabled(true),
+		WithParticipation(1.0),
+		WithReporter(reporter),
+		WithTracing(true),
+	)
+	assert.NoError(t, err)
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() {
+		done <- testRequest(t, f)
+	}()
+
+	<-old.started
+	assert.Equal(t, "testInjectorBlock", (<-reporter.evaluations).Injector)
+
+	assert.NoError(t, f.SetInjector(newTestInjector500s()))
+	close(old.released)
+
+	assert.Equal(t, http.StatusTeapot, (<-done).Code)
+
+	rr := testRequest(t, f)
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	assert.Equal(t, "testInjector500s", (<-reporter.evaluations).Injector)
+}
+
+// TestFaultSetInjectorConcurrent swaps the Injector while handling requests. Run with -race.
+func TestFaultSetInjectorConcurrent(t *testing.T) {
+	t.Parallel()
+
+	f, err := NewFault(newTestInjector500s(), WithEnabled(true), WithParticipation(1.0))
+	assert.NoError(t, err)
+
+	injectors := []Injector{newTestInjector500s(), newTestInjectorTwoTeapot(), newTestInjectorNoop()}
+
+	var wg sync.WaitGroup
+	for n := 0; n < 8; n++ {
+		wg.Add(2)
+
+		go func() {
+			defer wg.Done()
+
+			for idx := 0; idx < 100; idx++ {
+				rr := testRequest(t, f)
+				assert.Contains(t, []int{http.StatusInternalServerError, http.StatusTeapot, testHandlerCode}, rr.Code)
+			}
+		}()
+
+		go func(n int) {
+			defer wg.Done()
+
+			for idx := 0; idx < 100; idx++ {
+				assert.NoError(t, f.SetInjector(injectors[(n+idx)%len(injectors)]))
+			}
+		}(n)
+	}
+
+	wg.Wait()
+}
+
+// TestFaultHandlerAllocs tests that Fault.Handler does not allocate on requests it does not inject.
+// BenchmarkFaultParallel measures the same paths under concurrency. AllocsPerRun cannot be used in
+// parallel tests.
+func TestFaultHandlerAllocs(t *testing.T) {
+	match := RequestMatcherFunc(func(r *http.Request) bool { return true })
+
+	tests := []struct {
+		name        string
+		giveOptions []Option
+	}{
+		{
+			name:        "disabled",
+			giveOptions: []Option{WithEnabled(false)},
+		},
+		{
+			name:        "not selected",
+			giveOptions: []Option{WithEnabled(true), WithParticipation(0.0)},
+		},
+		{
+			name:        "not selected fractional",
+			giveOptions: []Option{WithEnabled(true), WithFractionalParticipation(PartsPerMillion(0))},
+		},
+		{
+			name: "unmatched",
+			giveOptions: []Option{
+				WithEnabled(true),
+				WithParticipation(1.0),
+				WithPathAllowlist([]string{"/other"}),
+			},
+		},
+		{
+			name: "matchers",
+			giveOptions: []Option{
+				WithEnabled(true),
+				WithParticipation(0.0),
+				WithPathAllowlist([]string{"/"}),
+				WithHeaderBlocklist(map[string]string{"X-Skip": "true"}),
+				WithRequestMatcher(match),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := NewFault(newTestInjector500s(), tt.giveOptions...)
+			assert.NoError(t, err)
+
+			h := f.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+			req, err := http.NewRequest(http.MethodGet, "/", nil)
+			assert.NoError(t, err)
+
+			allocs := testing.AllocsPerRun(100, func() {
+				h.ServeHTTP(nil, req)
+			})
+			assert.Zero(t, allocs)
+		})
+	}
+}
@@ -0,0 +1,325 @@
+package faultchaosmesh
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/github/go-fault"
+)
+
+const (
+	// TargetRequest is the HTTPChaos target that acts on requests.
+	TargetRequest = "Request"
+	// TargetResponse is the HTTPChaos target that acts on responses.
+	TargetResponse = "Response"
+
+	// ModeAll is the HTTPChaos mode that selects every matching pod.
+	ModeAll = "all"
+
+	// PauseAnnotation pauses an HTTPChaos when it is set to "true".
+	PauseAnnotation = "experiment.chaos-mesh.org/pause"
+	// PhaseStop is the desired phase of an HTTPChaos that is not running, such as after its
+	// duration has passed.
+	PhaseStop = "Stop"
+)
+
+var (
+	// ErrUnsupported when an HTTPChaos sets a field that cannot be applied in-process.
+	ErrUnsupported = errors.New("unsupported HTTPChaos field")
+	// ErrInvalidTarget when an HTTPChaos target is not "Request" or "Response".
+	ErrInvalidTarget = errors.New(`target must be "Request" or "Response"`)
+	// ErrNoAction when an HTTPChaos does not abort, delay, or replace the status code.
+	ErrNoAction = errors.New("HTTPChaos must abort, delay, or replace the status code")
+)
+
+// HTTPChaos is the part of a Chaos Mesh HTTPChaos custom resource that is read.
+type HTTPChaos struct {
+	Metadata ObjectMeta      `json:"metadata"`
+	Spec     HTTPChaosSpec   `json:"spec"`
+	Status   HTTPChaosStatus `json:"status"`
+}
+
+// HTTPChaosList is a list of HTTPChaos as returned by the Kubernetes API.
+type HTTPChaosList struct {
+	Items []HTTPChaos `json:"items"`
+}
+
+// ObjectMeta is the metadata of an HTTPChaos.
+type ObjectMeta struct {
+	Name            string            `json:"name"`
+	Namespace       string            `json:"namespace"`
+	ResourceVersion string            `json:"resourceVersion"`
+	Annotations     map[string]string `json:"annotations,omitempty"`
+}
+
+// HTTPChaosSpec is the spec of an HTTPChaos.
+type HTTPChaosSpec struct {
+	Selector        PodSelector       `json:"selector"`
+	Mode            string            `json:"mode"`
+	Value           string            `json:"value,omitempty"`
+	Target          string            `json:"target"`
+	Port            int32             `json:"port,omitempty"`
+	Path            *string           `json:"path,omitempty"`
+	Method          *string           `json:"method,omitempty"`
+	Code            *int32            `json:"code,omitempty"`
+	RequestHeaders  map[string]string `json:"request_headers,omitempty"`
+	ResponseHeaders map[string]string `json:"response_headers,omitempty"`
+	Abort           *bool             `json:"abort,omitempty"`
+	Delay           *string           `json:"delay,omitempty"`
+	Replace         *ReplaceActions   `json:"replace,omitempty"`
+	Patch           json.RawMessage   `json:"patch,omitempty"`
+	Duration        *string           `json:"duration,omitempty"`
+}
+
+// ReplaceActions are the replace actions of an HTTPChaos.
+type ReplaceActions struct {
+	Path    *string           `json:"path,omitempty"`
+	Method  *string           `json:"method,omitempty"`
+	Code    *int32            `json:"code,omitempty"`
+	Body    []byte            `json:"body,omitempty"`
+	Queries map[string]string `json:"queries,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+// PodSelector selects the pods an HTTPChaos targets.
+type PodSelector struct {
+	Namespaces          []string            `json:"namespaces,omitempty"`
+	LabelSelectors      map[string]string   `json:"labelSelectors,omitempty"`
+	AnnotationSelectors map[string]string   `json:"annotationSelectors,omitempty"`
+	Pods                map[string][]string `json:"pods,omitempty"`
+	ExpressionSelectors json.RawMessage     `json:"expressionSelectors,omitempty"`
+	FieldSelectors      map[string]string   `json:"fieldSelectors,omitempty"`
+	Nodes               []string            `json:"nodes,omitempty"`
+	NodeSelectors       map[string]string   `json:"nodeSelectors,omitempty"`
+	PodPhaseSelectors   []string            `json:"podPhaseSelectors,omitempty"`
+}
+
+// HTTPChaosStatus is the status of an HTTPChaos.
+type HTTPChaosStatus struct {
+	Experiment ExperimentStatus `json:"experiment"`
+}
+
+// ExperimentStatus is the experiment status of an HTTPChaos.
+type ExperimentStatus struct {
+	DesiredPhase string `json:"desiredPhase,omitempty"`
+}
+
+// Pod describes the pod the process runs in. Set it from the Downward API.
+type Pod struct {
+	Name        string
+	Namespace   string
+	Labels      map[string]string
+	Annotations map[string]string
+}
+
+// FaultName returns the name of the Fault for c.
+func FaultName(c HTTPChaos) string {
+	return "chaos-mesh/" + c.Metadata.Namespace + "/" + c.Metadata.Name
+}
+
+// Running returns true unless c is paused or stopped.
+func (c HTTPChaos) Running() bool {
+	return c.Metadata.Annotations[PauseAnnotation] != "true" && c.Status.Experiment.DesiredPhase != PhaseStop
+}
+
+// Targets returns true if the selector of c selects p. Pods listed by name are selected regardless
+// of the other selectors. Otherwise p must be in one of the namespaces, or the namespace of c if
+// none are set, and have every label and annotation of the selector.
+func (c HTTPChaos) Targets(p Pod) bool {
+	s := c.Spec.Selector
+
+	if len(s.Pods) > 0 {
+		for _, name := range s.Pods[p.Namespace] {
+			if name == p.Name {
+				return true
+			}
+		}
+		return false
+	}
+
+	namespaces := s.Namespaces
+	if len(namespaces) == 0 {
+		namespaces = []string{c.Metadata.Namespace}
+	}
+	if !contains(namespaces, p.Namespace) {
+		return false
+	}
+
+	return subset(s.LabelSelectors, p.Labels) && subset(s.AnnotationSelectors, p.Annotations)
+}
+
+// contains returns true if s is in ss.
+func contains(ss []string, s string) bool {
+	for _, v := range ss {
+		if v == s {
+			return true
+		}
+	}
+
+	return false
+}
+
+// subset returns true if every key of want has the same value in have.
+func subset(want, have map[string]string) bool {
+	for k, v := range want {
+		if hv, ok := have[k]; !ok || hv != v {
+			return false
+		}
+	}
+
+	return true
+}
+
+// validate returns an error if c sets a field that cannot be applied in-process.
+func (c HTTPChaos) validate() error {
+	s := c.Spec
+
+	if s.Target != TargetRequest && s.Target != TargetResponse {
+		return ErrInvalidTarget
+	}
+
+	unsupported := []struct {
+		field string
+		set   bool
+	}{
+		{"mode " + s.Mode, s.Mode != ModeAll},
+		{"selector.expressionSelectors", len(s.Selector.ExpressionSelectors) > 0},
+		{"selector.fieldSelectors", len(s.Selector.FieldSelectors) > 0},
+		{"selector.nodes", len(s.Selector.Nodes) > 0},
+		{"selector.nodeSelectors", len(s.Selector.NodeSelectors) > 0},
+		{"selector.podPhaseSelectors", len(s.Selector.PodPhaseSelectors) > 0},
+		{"code", s.Code != nil},
+		{"response_headers", len(s.ResponseHeaders) > 0},
+		{"patch", len(s.Patch) > 0},
+		{"replace on requests", s.Replace != nil && s.Target == TargetRequest},
+		{"replace other than code", s.Replace != nil && (s.Replace.Path != nil || s.Replace.Method != nil ||
+			s.Replace.Body != nil || len(s.Replace.Queries) > 0 || len(s.Replace.Headers) > 0)},
+	}
+	for _, u := range unsupported {
+		if u.set {
+			return fmt.Errorf("%w: %s", ErrUnsupported, u.field)
+		}
+	}
+
+	return nil
+}
+
+// NewFault returns an enabled Fault that applies c to every request it matches. Requests are
+// delayed first, and then aborted with a RejectInjector or answered with the replaced status code
+// by an ErrorInjector. Only the "all" mode is supported, and matching on or changing anything but
+// the status code of responses returns ErrUnsupported.
+func NewFault(c HTTPChaos) (*fault.Fault, error) {
+	err := c.validate()
+	if err != nil {
+		return nil, err
+	}
+
+	s := c.Spec
+
+	// the injectors below cannot fail without options
+	var injectors []fault.Injector
+	if s.Delay != nil {
+		d, err := time.ParseDuration(*s.Delay)
+		if err != nil {
+			return nil, err
+		}
+
+		si, _ := fault.NewSlowInjector(d)
+		injectors = append(injectors, si)
+	}
+
+	switch {
+	case s.Abort != nil && *s.Abort:
+		ri, _ := fault.NewRejectInjector()
+		injectors = append(injectors, ri)
+	case s.Replace != nil && s.Replace.Code != nil:
+		ei, err := fault.NewErrorInjector(int(*s.Replace.Code))
+		if err != nil {
+			return nil, err
+		}
+		injectors = append(injectors, ei)
+	}
+
+	var i fault.Injector
+	switch len(injectors) {
+	case 0:
+		return nil, ErrNoAction
+	case 1:
+		i = injectors[0]
+	default:
+		i, _ = fault.NewChainInjector(injectors)
+	}
+
+	opts := []fault.Option{
+		fault.WithName(FaultName(c)),
+		fault.WithEnabled(true),
+		fault.WithParticipation(1.0),
+	}
+	for _, m := range matchers(s) {
+		opts = append(opts, fault.WithRequestMatcher(m))
+	}
+
+	return fault.NewFault(i, opts...)
+}
+
+// matchers returns the RequestMatchers for the request selectors of s.
+func matchers(s HTTPChaosSpec) []fault.RequestMatcher {
+	var ms []fault.RequestMatcher
+
+	if s.Port != 0 {
+		ms = append(ms, fault.MatchLocalPort(int(s.Port)))
+	}
+
+	if s.Method != nil {
+		method := *s.Method
+		ms = append(ms, fault.RequestMatcherFunc(func(r *http.Request) bool {
+			return strings.EqualFold(r.Method, method)
+		}))
+	}
+
+	if s.Path != nil {
+		re := wildcard(*s.Path)
+		ms = append(ms, fault.RequestMatcherFunc(func(r *http.Request) bool {
+			return re.MatchString(r.URL.Path)
+		}))
+	}
+
+	if len(s.RequestHeaders) > 0 {
+		headers := s.RequestHeaders
+		ms = append(ms, fault.RequestMatcherFunc(func(r *http.Request) bool {
+			for k, v := range headers {
+				if r.Header.Get(k) != v {
+					return false
+				}
+			}
+			return true
+		}))
+	}
+
+	return ms
+}
+
+// wildcard returns a regexp that matches the whole of a string against pattern, where "*" matches
+// any run of characters and "?" matches any single character, as in Chaos Mesh paths.
+func wildcard(pattern string) *regexp.Regexp {
+	var b strings.Builder
+	b.WriteString("^")
+	for _, r := range pattern {
+		switch r {
+		case '*':
+			b.WriteString(".*")
+		case '?':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	b.WriteString("$")
+
+	return regexp.MustCompile(b.String())
+}
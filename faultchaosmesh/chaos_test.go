@@ -0,0 +1,466 @@
+package faultchaosmesh
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/github/go-fault"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestHTTPChaosJSON tests that HTTPChaos decodes the resources returned by the Kubernetes API.
+func TestHTTPChaosJSON(t *testing.T) {
+	t.Parallel()
+
+	data := `{
+		"apiVersion": "chaos-mesh.org/v1alpha1",
+		"kind": "HTTPChaos",
+		"metadata": {
+			"name": "checkout-latency",
+			"namespace": "shop",
+			"resourceVersion": "42",
+			"annotations": {"experiment.chaos-mesh.org/pause": "true"}
+		},
+		"spec": {
+			"mode": "all",
+			"selector": {"labelSelectors": {"app": "checkout"}},
+			"target": "Request",
+			"port": 8080,
+			"method": "GET",
+			"path": "/api/*",
+			"request_headers": {"X-Env": "canary"},
+			"delay": "10s",
+			"duration": "5m"
+		},
+		"status": {"experiment": {"desiredPhase": "Run"}}
+	}`
+
+	var hc HTTPChaos
+	assert.NoError(t, json.Unmarshal([]byte(data), &hc))
+
+	assert.Equal(t, HTTPChaos{
+		Metadata: ObjectMeta{
+			Name:            "checkout-latency",
+			Namespace:       "shop",
+			ResourceVersion: "42",
+			Annotations:     map[string]string{PauseAnnotation: "true"},
+		},
+		Spec: HTTPChaosSpec{
+			Selector:       PodSelector{LabelSelectors: map[string]string{"app": "checkout"}},
+			Mode:           ModeAll,
+			Target:         TargetRequest,
+			Port:           8080,
+			Method:         stringPtr("GET"),
+			Path:           stringPtr("/api/*"),
+			RequestHeaders: map[string]string{"X-Env": "canary"},
+			Delay:          stringPtr("10s"),
+			Duration:       stringPtr("5m"),
+		},
+		Status: HTTPChaosStatus{Experiment: ExperimentStatus{DesiredPhase: "Run"}},
+	}, hc)
+	assert.Equal(t, "chaos-mesh/shop/checkout-latency", FaultName(hc))
+}
+
+// TestHTTPChaosRunning tests HTTPChaos.Running.
+func TestHTTPChaosRunning(t *testing.T) {
+	t.Parallel()
+
+	hc := testChaos("a", "1")
+	assert.True(t, hc.Running())
+
+	hc.Metadata.Annotations = map[string]string{PauseAnnotation: "false"}
+	assert.True(t, hc.Running())
+
+	hc.Metadata.Annotations = map[string]string{PauseAnnotation: "true"}
+	assert.False(t, hc.Running())
+
+	hc = testChaos("a", "1")
+	hc.Status.Experiment.DesiredPhase = PhaseStop
+	assert.False(t, hc.Running())
+}
+
+// TestHTTPChaosTargets tests HTTPChaos.Targets.
+func TestHTTPChaosTargets(t *testing.T) {
+	t.Parallel()
+
+	pod := Pod{
+		Name:        "checkout-1",
+		Namespace:   "shop",
+		Labels:      map[string]string{"app": "checkout", "tier": "web"},
+		Annotations: map[string]string{"team": "payments"},
+	}
+
+	tests := []struct {
+		name          string
+		giveNamespace string
+		giveSelector  PodSelector
+		want          bool
+	}{
+		{
+			name:          "namespace of the chaos",
+			giveNamespace: "shop",
+			want:          true,
+		},
+		{
+			name:          "other namespace of the chaos",
+			giveNamespace: "chaos",
+			want:          false,
+		},
+		{
+			name:          "listed namespace",
+			giveNamespace: "chaos",
+			giveSelector:  PodSelector{Namespaces: []string{"web", "shop"}},
+			want:          true,
+		},
+		{
+			name:          "labels",
+			giveNamespace: "shop",
+			giveSelector:  PodSelector{LabelSelectors: map[string]string{"app": "checkout", "tier": "web"}},
+			want:          true,
+		},
+		{
+			name:          "missing label",
+			giveNamespace: "shop",
+			giveSelector:  PodSelector{LabelSelectors: map[string]string{"track": "canary"}},
+			want:          false,
+		},
+		{
+			name:          "different label",
+			giveNamespace: "shop",
+			giveSelector:  PodSelector{LabelSelectors: map[string]string{"app": "cart"}},
+			want:          false,
+		},
+		{
+			name:          "annotations",
+			giveNamespace: "shop",
+			giveSelector:  PodSelector{AnnotationSelectors: map[string]string{"team": "payments"}},
+			want:          true,
+		},
+		{
+			name:          "different annotation",
+			giveNamespace: "shop",
+			giveSelector:  PodSelector{AnnotationSelectors: map[string]string{"team": "search"}},
+			want:          false,
+		},
+		{
+			name:          "listed pod",
+			giveNamespace: "chaos",
+			giveSelector: PodSelector{
+				Pods:           map[string][]string{"shop": {"checkout-0", "checkout-1"}},
+				LabelSelectors: map[string]string{"app": "cart"},
+			},
+			want: true,
+		},
+		{
+			name:          "unlisted pod",
+			giveNamespace: "shop",
+			giveSelector:  PodSelector{Pods: map[string][]string{"shop": {"checkout-0"}}},
+			want:          false,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			hc := HTTPChaos{
+				Metadata: ObjectMeta{Name: "chaos", Namespace: tt.giveNamespace},
+				Spec:     HTTPChaosSpec{Selector: tt.giveSelector},
+			}
+
+			assert.Equal(t, tt.want, hc.Targets(pod))
+		})
+	}
+}
+
+// TestNewFault tests NewFault.
+func TestNewFault(t *testing.T) {
+	t.Parallel()
+
+	code := int32(503)
+	invalidCode := int32(99)
+	abort := true
+	noAbort := false
+
+	tests := []struct {
+		name         string
+		giveSpec     func(s *HTTPChaosSpec)
+		wantInjector string
+		wantErr      error
+	}{
+		{
+			name:         "delay",
+			giveSpec:     func(s *HTTPChaosSpec) {},
+			wantInjector: "slow(1s)",
+		},
+		{
+			name: "abort",
+			giveSpec: func(s *HTTPChaosSpec) {
+				s.Delay = nil
+				s.Abort = &abort
+			},
+			wantInjector: "reject",
+		},
+		{
+			name: "delay and abort",
+			giveSpec: func(s *HTTPChaosSpec) {
+				s.Abort = &abort
+			},
+			wantInjector: "chain(slow(1s), reject)",
+		},
+		{
+			name: "replace code",
+			giveSpec: func(s *HTTPChaosSpec) {
+				s.Target = TargetResponse
+				s.Delay = nil
+				s.Replace = &ReplaceActions{Code: &code}
+			},
+			wantInjector: "error(503)",
+		},
+		{
+			name: "abort wins over replace",
+			giveSpec: func(s *HTTPChaosSpec) {
+				s.Target = TargetResponse
+				s.Delay = nil
+				s.Abort = &abort
+				s.Replace = &ReplaceActions{Code: &code}
+			},
+			wantInjector: "reject",
+		},
+		{
+			name: "no action",
+			giveSpec: func(s *HTTPChaosSpec) {
+				s.Delay = nil
+				s.Abort = &noAbort
+			},
+			wantErr: ErrNoAction,
+		},
+		{
+			name: "invalid target",
+			giveSpec: func(s *HTTPChaosSpec) {
+				s.Target = "Connection"
+			},
+			wantErr: ErrInvalidTarget,
+		},
+		{
+			name: "invalid delay",
+			giveSpec: func(s *HTTPChaosSpec) {
+				s.Delay = stringPtr("soon")
+			},
+			wantErr: errors.New(`time: invalid duration "soon"`),
+		},
+		{
+			name: "invalid code",
+			giveSpec: func(s *HTTPChaosSpec) {
+				s.Target = TargetResponse
+				s.Replace = &ReplaceActions{Code: &invalidCode}
+			},
+			wantErr: fault.ErrInvalidHTTPCode,
+		},
+		{
+			name: "random mode",
+			giveSpec: func(s *HTTPChaosSpec) {
+				s.Mode = "one"
+			},
+			wantErr: ErrUnsupported,
+		},
+		{
+			name: "expression selectors",
+			giveSpec: func(s *HTTPChaosSpec) {
+				s.Selector.ExpressionSelectors = json.RawMessage(`[]`)
+			},
+			wantErr: ErrUnsupported,
+		},
+		{
+			name: "field selectors",
+			giveSpec: func(s *HTTPChaosSpec) {
+				s.Selector.FieldSelectors = map[string]string{"spec.nodeName": "a"}
+			},
+			wantErr: ErrUnsupported,
+		},
+		{
+			name: "nodes",
+			giveSpec: func(s *HTTPChaosSpec) {
+				s.Selector.Nodes = []string{"a"}
+			},
+			wantErr: ErrUnsupported,
+		},
+		{
+			name: "node selectors",
+			giveSpec: func(s *HTTPChaosSpec) {
+				s.Selector.NodeSelectors = map[string]string{"zone": "a"}
+			},
+			wantErr: ErrUnsupported,
+		},
+		{
+			name: "pod phase selectors",
+			giveSpec: func(s *HTTPChaosSpec) {
+				s.Selector.PodPhaseSelectors = []string{"Running"}
+			},
+			wantErr: ErrUnsupported,
+		},
+		{
+			name: "response code",
+			giveSpec: func(s *HTTPChaosSpec) {
+				s.Code = &code
+			},
+			wantErr: ErrUnsupported,
+		},
+		{
+			name: "response headers",
+			giveSpec: func(s *HTTPChaosSpec) {
+				s.ResponseHeaders = map[string]string{"a": "b"}
+			},
+			wantErr: ErrUnsupported,
+		},
+		{
+			name: "patch",
+			giveSpec: func(s *HTTPChaosSpec) {
+				s.Patch = json.RawMessage(`{}`)
+			},
+			wantErr: ErrUnsupported,
+		},
+		{
+			name: "replace request",
+			giveSpec: func(s *HTTPChaosSpec) {
+				s.Replace = &ReplaceActions{Code: &code}
+			},
+			wantErr: ErrUnsupported,
+		},
+		{
+			name: "replace body",
+			giveSpec: func(s *HTTPChaosSpec) {
+				s.Target = TargetResponse
+				s.Replace = &ReplaceActions{Body: []byte("x")}
+			},
+			wantErr: ErrUnsupported,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			hc := testChaos("latency", "1")
+			tt.giveSpec(&hc.Spec)
+
+			f, err := NewFault(hc)
+
+			if tt.wantErr != nil {
+				assert.Nil(t, f)
+				if !errors.Is(err, tt.wantErr) {
+					assert.EqualError(t, err, tt.wantErr.Error())
+				}
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, "chaos-mesh/shop/latency", f.Name())
+			assert.Equal(t, tt.wantInjector, fmt.Sprint(f.Injector()))
+		})
+	}
+}
+
+// TestNewFaultMatchers tests that the Fault from NewFault only runs on the requests it matches.
+func TestNewFaultMatchers(t *testing.T) {
+	t.Parallel()
+
+	abort := true
+	hc := testChaos("abort", "1")
+	hc.Spec.Delay = nil
+	hc.Spec.Abort = &abort
+	hc.Spec.Port = 8080
+	hc.Spec.Method = stringPtr("post")
+	hc.Spec.Path = stringPtr("/api/*/orders?")
+	hc.Spec.RequestHeaders = map[string]string{"X-Env": "canary"}
+
+	tests := []struct {
+		name       string
+		givePort   int
+		giveMethod string
+		givePath   string
+		giveHeader string
+		wantReject bool
+	}{
+		{
+			name:       "match",
+			givePort:   8080,
+			giveMethod: http.MethodPost,
+			givePath:   "/api/v1/users/orders1",
+			giveHeader: "canary",
+			wantReject: true,
+		},
+		{
+			name:       "other port",
+			givePort:   9090,
+			giveMethod: http.MethodPost,
+			givePath:   "/api/v1/orders1",
+			giveHeader: "canary",
+		},
+		{
+			name:       "other method",
+			givePort:   8080,
+			giveMethod: http.MethodGet,
+			givePath:   "/api/v1/orders1",
+			giveHeader: "canary",
+		},
+		{
+			name:       "other path",
+			givePort:   8080,
+			giveMethod: http.MethodPost,
+			givePath:   "/api/v1/orders",
+			giveHeader: "canary",
+		},
+		{
+			name:       "other header",
+			givePort:   8080,
+			giveMethod: http.MethodPost,
+			givePath:   "/api/v1/orders1",
+			giveHeader: "stable",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			f, err := NewFault(hc)
+			assert.NoError(t, err)
+
+			req := httptest.NewRequest(tt.giveMethod, tt.givePath, nil)
+			req.Header.Set("X-Env", tt.giveHeader)
+			addr := &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: tt.givePort}
+			req = req.WithContext(context.WithValue(req.Context(), http.LocalAddrContextKey, addr))
+
+			called := false
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { called = true })
+
+			func() {
+				defer func() { _ = recover() }()
+				f.Handler(next).ServeHTTP(httptest.NewRecorder(), req)
+			}()
+
+			assert.Equal(t, !tt.wantReject, called)
+		})
+	}
+}
+
+// TestWildcard tests wildcard.
+func TestWildcard(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, wildcard("/a/*").MatchString("/a/b/c"))
+	assert.True(t, wildcard("/a/?").MatchString("/a/b"))
+	assert.False(t, wildcard("/a/?").MatchString("/a/bc"))
+	assert.False(t, wildcard("/a.b").MatchString("/aXb"))
+	assert.False(t, wildcard("/a").MatchString("/a/b"))
+}
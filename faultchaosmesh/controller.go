@@ -0,0 +1,274 @@
+package faultchaosmesh
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/github/go-fault"
+)
+
+// defaultSyncInterval is how often a started Controller syncs by default.
+const defaultSyncInterval = 10 * time.Second
+
+var (
+	// ErrNilManager when a Controller is created without a Manager.
+	ErrNilManager = errors.New("manager cannot be nil")
+	// ErrNilLister when a Controller is created without a Lister.
+	ErrNilLister = errors.New("lister cannot be nil")
+)
+
+// Lister lists HTTPChaos resources. APILister lists them from the Kubernetes API.
+type Lister interface {
+	// ListHTTPChaos returns every HTTPChaos the Controller should consider.
+	ListHTTPChaos(ctx context.Context) ([]HTTPChaos, error)
+}
+
+// Controller keeps a fault.Manager in sync with the HTTPChaos resources that target a Pod. Every
+// running HTTPChaos that targets the Pod becomes a Fault named by FaultName, and the Fault is
+// replaced when the HTTPChaos changes and removed when it is deleted, paused, or stopped.
+type Controller struct {
+	manager   *fault.Manager
+	pod       Pod
+	lister    Lister
+	interval  time.Duration
+	clock     fault.Clock
+	errorFunc func(err error)
+
+	mtx sync.Mutex
+	// applied and failed hold the resource version of each HTTPChaos that has been applied or could
+	// not be applied, by Fault name.
+	applied map[string]string
+	failed  map[string]string
+
+	runMtx sync.Mutex
+	stop   chan struct{}
+	done   chan struct{}
+}
+
+// ControllerOption configures a Controller.
+type ControllerOption interface {
+	applyController(c *Controller) error
+}
+
+type syncIntervalOption time.Duration
+
+func (o syncIntervalOption) applyController(c *Controller) error {
+	if o <= 0 {
+		return fault.ErrInvalidInterval
+	}
+
+	c.interval = time.Duration(o)
+
+	return nil
+}
+
+// WithSyncInterval sets how often a started Controller syncs. Default 10s.
+func WithSyncInterval(d time.Duration) ControllerOption {
+	return syncIntervalOption(d)
+}
+
+type clockOption struct {
+	clock fault.Clock
+}
+
+func (o clockOption) applyController(c *Controller) error {
+	c.clock = o.clock
+	return nil
+}
+
+// WithClock sets the Clock used to wait between syncs. Default fault.RealClock.
+func WithClock(clock fault.Clock) ControllerOption {
+	return clockOption{clock}
+}
+
+type errorFuncOption func(err error)
+
+func (o errorFuncOption) applyController(c *Controller) error {
+	c.errorFunc = o
+	return nil
+}
+
+// WithErrorFunc sets a function that is called with every error while syncing, such as an
+// HTTPChaos that cannot be applied or a failed list in the background.
+func WithErrorFunc(f func(err error)) ControllerOption {
+	return errorFuncOption(f)
+}
+
+// NewController returns a Controller that adds Faults for the HTTPChaos listed by lister that
+// target pod to m. Call Sync or Start to apply them.
+func NewController(m *fault.Manager, pod Pod, lister Lister, opts ...ControllerOption) (*Controller, error) {
+	if m == nil {
+		return nil, ErrNilManager
+	}
+
+	if lister == nil {
+		return nil, ErrNilLister
+	}
+
+	// set defaults
+	c := &Controller{
+		manager:   m,
+		pod:       pod,
+		lister:    lister,
+		interval:  defaultSyncInterval,
+		clock:     fault.NewRealClock(),
+		errorFunc: func(error) {},
+		applied:   map[string]string{},
+		failed:    map[string]string{},
+	}
+
+	// apply options
+	for _, opt := range opts {
+		err := opt.applyController(c)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return c, nil
+}
+
+// Sync lists the HTTPChaos resources once and updates the Manager. It returns an error if they
+// cannot be listed. An HTTPChaos that cannot be applied is passed to the error function once per
+// resource version and otherwise ignored.
+func (c *Controller) Sync(ctx context.Context) error {
+	items, err := c.lister.ListHTTPChaos(ctx)
+	if err != nil {
+		return err
+	}
+
+	want := make(map[string]HTTPChaos, len(items))
+	for _, hc := range items {
+		if hc.Running() && hc.Targets(c.pod) {
+			want[FaultName(hc)] = hc
+		}
+	}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	for name, version := range c.applied {
+		if hc, ok := want[name]; ok && hc.Metadata.ResourceVersion == version {
+			delete(want, name)
+			continue
+		}
+
+		c.manager.Remove(name)
+		delete(c.applied, name)
+	}
+
+	names := make([]string, 0, len(want))
+	for name := range want {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		version := want[name].Metadata.ResourceVersion
+		if v, ok := c.failed[name]; ok && v == version {
+			continue
+		}
+
+		f, err := NewFault(want[name])
+		if err == nil {
+			err = c.manager.Add(f)
+		}
+		if err != nil {
+			c.failed[name] = version
+			c.errorFunc(fmt.Errorf("%s: %w", name, err))
+			continue
+		}
+
+		delete(c.failed, name)
+		c.applied[name] = version
+	}
+
+	for name := range c.failed {
+		if _, ok := want[name]; !ok {
+			delete(c.failed, name)
+		}
+	}
+
+	return nil
+}
+
+// removeAll removes every Fault the Controller added from the Manager.
+func (c *Controller) removeAll() {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	for name := range c.applied {
+		c.manager.Remove(name)
+		delete(c.applied, name)
+	}
+
+	for name := range c.failed {
+		delete(c.failed, name)
+	}
+}
+
+// Start syncs every interval in a new goroutine, starting immediately. It does nothing if the
+// Controller is already started.
+func (c *Controller) Start() {
+	c.runMtx.Lock()
+	defer c.runMtx.Unlock()
+
+	if c.stop != nil {
+		return
+	}
+
+	c.stop = make(chan struct{})
+	c.done = make(chan struct{})
+
+	go c.run(c.stop, c.done)
+}
+
+// Stop stops syncing, waits for a sync in progress to finish, and removes every Fault the
+// Controller added so no experiment outlives it. It does nothing if the Controller is not started.
+func (c *Controller) Stop() {
+	c.runMtx.Lock()
+	defer c.runMtx.Unlock()
+
+	if c.stop == nil {
+		return
+	}
+
+	close(c.stop)
+	<-c.done
+
+	c.stop = nil
+	c.done = nil
+
+	c.removeAll()
+}
+
+// run syncs every interval until stop is closed. A sync in progress is canceled when stop is
+// closed.
+func (c *Controller) run(stop <-chan struct{}, done chan<- struct{}) {
+	defer close(done)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go func() {
+		<-stop
+		cancel()
+	}()
+
+	for {
+		err := c.Sync(ctx)
+		if err != nil && ctx.Err() == nil {
+			c.errorFunc(err)
+		}
+
+		select {
+		case <-stop:
+			return
+		case <-c.clock.After(c.interval):
+		}
+	}
+}
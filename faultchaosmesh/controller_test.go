@@ -0,0 +1,258 @@
+package faultchaosmesh
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/github/go-fault"
+	"github.com/github/go-fault/faulttest"
+	"github.com/stretchr/testify/assert"
+)
+
+// testPod is the Pod targeted by testChaos.
+var testPod = Pod{Name: "checkout-1", Namespace: "shop", Labels: map[string]string{"app": "checkout"}} //nolint:gochecknoglobals
+
+// TestNewController tests NewController.
+func TestNewController(t *testing.T) {
+	t.Parallel()
+
+	m, err := fault.NewManager()
+	assert.NoError(t, err)
+	lister := &testLister{}
+	clock := faulttest.NewClock(time.Time{})
+
+	tests := []struct {
+		name         string
+		giveManager  *fault.Manager
+		giveLister   Lister
+		giveOptions  []ControllerOption
+		wantInterval time.Duration
+		wantClock    fault.Clock
+		wantErr      error
+	}{
+		{
+			name:         "defaults",
+			giveManager:  m,
+			giveLister:   lister,
+			wantInterval: defaultSyncInterval,
+			wantClock:    fault.NewRealClock(),
+		},
+		{
+			name:        "options",
+			giveManager: m,
+			giveLister:  lister,
+			giveOptions: []ControllerOption{
+				WithSyncInterval(time.Minute),
+				WithClock(clock),
+				WithErrorFunc(func(error) {}),
+			},
+			wantInterval: time.Minute,
+			wantClock:    clock,
+		},
+		{
+			name:       "nil manager",
+			giveLister: lister,
+			wantErr:    ErrNilManager,
+		},
+		{
+			name:        "nil lister",
+			giveManager: m,
+			wantErr:     ErrNilLister,
+		},
+		{
+			name:        "invalid interval",
+			giveManager: m,
+			giveLister:  lister,
+			giveOptions: []ControllerOption{WithSyncInterval(0)},
+			wantErr:     fault.ErrInvalidInterval,
+		},
+		{
+			name:        "option error",
+			giveManager: m,
+			giveLister:  lister,
+			giveOptions: []ControllerOption{errorOption{}},
+			wantErr:     errErrorOption,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			c, err := NewController(tt.giveManager, testPod, tt.giveLister, tt.giveOptions...)
+
+			assert.Equal(t, tt.wantErr, err)
+			if tt.wantErr != nil {
+				assert.Nil(t, c)
+				return
+			}
+
+			assert.Equal(t, tt.giveManager, c.manager)
+			assert.Equal(t, testPod, c.pod)
+			assert.Equal(t, tt.giveLister, c.lister)
+			assert.Equal(t, tt.wantInterval, c.interval)
+			assert.Equal(t, tt.wantClock, c.clock)
+			assert.NotNil(t, c.errorFunc)
+		})
+	}
+}
+
+// TestControllerSync tests that Sync keeps the Manager in sync with the listed HTTPChaos.
+func TestControllerSync(t *testing.T) {
+	t.Parallel()
+
+	m, err := fault.NewManager()
+	assert.NoError(t, err)
+
+	var errs []error
+	lister := &testLister{}
+	c, err := NewController(m, testPod, lister, WithErrorFunc(func(err error) {
+		errs = append(errs, err)
+	}))
+	assert.NoError(t, err)
+
+	names := func() []string {
+		var names []string
+		for _, f := range m.Faults() {
+			names = append(names, f.Name())
+		}
+		return names
+	}
+
+	// a failed list changes nothing
+	errList := errors.New("list failed")
+	lister.set(nil, errList)
+	assert.Equal(t, errList, c.Sync(context.Background()))
+
+	// only running HTTPChaos that target the pod are applied
+	other := testChaos("other", "1")
+	other.Spec.Selector.LabelSelectors = map[string]string{"app": "cart"}
+	paused := testChaos("paused", "1")
+	paused.Metadata.Annotations = map[string]string{PauseAnnotation: "true"}
+	invalid := testChaos("invalid", "1")
+	invalid.Spec.Mode = "one"
+	lister.set([]HTTPChaos{testChaos("a", "1"), other, paused, invalid}, nil)
+
+	assert.NoError(t, c.Sync(context.Background()))
+	assert.Equal(t, []string{"chaos-mesh/shop/a"}, names())
+	assert.Len(t, errs, 1)
+	assert.True(t, errors.Is(errs[0], ErrUnsupported))
+	assert.Contains(t, errs[0].Error(), "chaos-mesh/shop/invalid")
+
+	// unchanged HTTPChaos are left alone and failures are reported once
+	a := m.Fault("chaos-mesh/shop/a")
+	assert.NoError(t, c.Sync(context.Background()))
+	assert.Same(t, a, m.Fault("chaos-mesh/shop/a"))
+	assert.Len(t, errs, 1)
+
+	// changed HTTPChaos are replaced
+	fixed := testChaos("invalid", "2")
+	lister.set([]HTTPChaos{testChaos("a", "2"), fixed}, nil)
+	assert.NoError(t, c.Sync(context.Background()))
+	assert.NotSame(t, a, m.Fault("chaos-mesh/shop/a"))
+	assert.Equal(t, []string{"chaos-mesh/shop/a", "chaos-mesh/shop/invalid"}, names())
+	assert.Len(t, errs, 1)
+
+	// Faults that are not the Controller's are never replaced or removed
+	si, err := fault.NewSlowInjector(time.Second)
+	assert.NoError(t, err)
+	user, err := fault.NewFault(si, fault.WithName("chaos-mesh/shop/user"))
+	assert.NoError(t, err)
+	assert.NoError(t, m.Add(user))
+
+	lister.set([]HTTPChaos{testChaos("user", "1")}, nil)
+	assert.NoError(t, c.Sync(context.Background()))
+	assert.Len(t, errs, 2)
+	assert.True(t, errors.Is(errs[1], fault.ErrDuplicateFault))
+	assert.Equal(t, []string{"chaos-mesh/shop/user"}, names())
+	assert.Same(t, user, m.Fault("chaos-mesh/shop/user"))
+
+	// HTTPChaos that are gone are forgotten
+	lister.set(nil, nil)
+	assert.NoError(t, c.Sync(context.Background()))
+	assert.Equal(t, []string{"chaos-mesh/shop/user"}, names())
+	assert.Empty(t, c.applied)
+	assert.Empty(t, c.failed)
+}
+
+// blockingLister is a Lister that blocks until its context is done.
+type blockingLister struct {
+	started chan struct{}
+}
+
+// ListHTTPChaos blocks until ctx is done.
+func (l *blockingLister) ListHTTPChaos(ctx context.Context) ([]HTTPChaos, error) {
+	close(l.started)
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+// TestControllerStartStop tests that a started Controller syncs every interval and removes its
+// Faults when stopped.
+func TestControllerStartStop(t *testing.T) {
+	t.Parallel()
+
+	clock := faulttest.NewClock(time.Time{})
+	m, err := fault.NewManager()
+	assert.NoError(t, err)
+
+	var mtx sync.Mutex
+	var errs []error
+	invalid := testChaos("invalid", "1")
+	invalid.Spec.Mode = "one"
+	lister := &testLister{}
+	lister.set([]HTTPChaos{testChaos("a", "1"), invalid}, nil)
+
+	c, err := NewController(m, testPod, lister,
+		WithSyncInterval(time.Second),
+		WithClock(clock),
+		WithErrorFunc(func(err error) {
+			mtx.Lock()
+			defer mtx.Unlock()
+			errs = append(errs, err)
+		}),
+	)
+	assert.NoError(t, err)
+
+	// stopping before starting does nothing
+	c.Stop()
+
+	// the first sync is immediate
+	c.Start()
+	c.Start()
+	clock.BlockUntil(1)
+	assert.Len(t, m.Faults(), 1)
+
+	// failed lists are reported
+	errList := errors.New("list failed")
+	lister.set(nil, errList)
+	clock.Advance(time.Second)
+	clock.BlockUntil(1)
+	assert.Len(t, m.Faults(), 1)
+
+	mtx.Lock()
+	assert.Len(t, errs, 2)
+	assert.Equal(t, errList, errs[1])
+	mtx.Unlock()
+
+	// stopping removes the Faults
+	c.Stop()
+	c.Stop()
+	assert.Empty(t, m.Faults())
+	assert.Empty(t, c.failed)
+
+	// a sync in progress is canceled by Stop and not reported
+	blocking := &blockingLister{started: make(chan struct{})}
+	c.lister = blocking
+	c.Start()
+	<-blocking.started
+	c.Stop()
+
+	mtx.Lock()
+	assert.Len(t, errs, 2)
+	mtx.Unlock()
+}
@@ -0,0 +1,36 @@
+/*
+Package faultchaosmesh lets Chaos Mesh drive in-process fault injection. It watches the HTTPChaos
+custom resources that target the pod the process runs in and turns each of them into a Fault, so
+Kubernetes-native chaos tooling can run application-level experiments without a sidecar proxy.
+
+Controller
+
+A Controller keeps a fault.Manager in sync with the HTTPChaos resources. Describe the pod with the
+Downward API, list the resources from the Kubernetes API with an APILister, and start the
+Controller. Stopping the Controller removes the Faults it added.
+
+    m, _ := fault.NewManager()
+    l, _ := faultchaosmesh.NewInClusterLister(faultchaosmesh.WithNamespaces(os.Getenv("POD_NAMESPACE")))
+    c, _ := faultchaosmesh.NewController(m, faultchaosmesh.Pod{
+        Name:      os.Getenv("POD_NAME"),
+        Namespace: os.Getenv("POD_NAMESPACE"),
+        Labels:    map[string]string{"app": "checkout"},
+    }, l, faultchaosmesh.WithErrorFunc(func(err error) { log.Print(err) }))
+    c.Start()
+    defer c.Stop()
+    http.ListenAndServe(":8080", m.Handler(mux))
+
+The resources are listed again every sync interval rather than watched, so changes apply within
+one interval. Paused experiments and experiments whose desired phase is Stop, such as after their
+duration has passed, are removed.
+
+Supported Fields
+
+An HTTPChaos is matched on its port, method, path, and request headers, and can delay, abort, or
+replace the status code of responses. Only the "all" mode is supported, because pods cannot agree
+among themselves which of them a random mode selects. An HTTPChaos that sets a field that cannot
+be applied in-process, such as a patch or a response code to match, is not applied and its error is
+passed to the function set by WithErrorFunc().
+
+*/
+package faultchaosmesh
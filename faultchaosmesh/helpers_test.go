@@ -0,0 +1,63 @@
+package faultchaosmesh
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// errErrorOption is returned by errorOption.
+var errErrorOption = errors.New("intentional error for tests")
+
+// errorOption is an Option that always fails.
+type errorOption struct{}
+
+func (errorOption) applyController(c *Controller) error {
+	return errErrorOption
+}
+
+func (errorOption) applyAPILister(l *APILister) error {
+	return errErrorOption
+}
+
+// testLister is a Lister that returns items or err.
+type testLister struct {
+	mtx   sync.Mutex
+	items []HTTPChaos
+	err   error
+}
+
+// set sets the items and error returned by the testLister.
+func (l *testLister) set(items []HTTPChaos, err error) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	l.items, l.err = items, err
+}
+
+// ListHTTPChaos returns l.items and l.err.
+func (l *testLister) ListHTTPChaos(ctx context.Context) ([]HTTPChaos, error) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	return l.items, l.err
+}
+
+// stringPtr returns a pointer to s.
+func stringPtr(s string) *string {
+	return &s
+}
+
+// testChaos returns a running HTTPChaos in the namespace "shop" that delays every request to pods
+// labelled app=checkout.
+func testChaos(name, version string) HTTPChaos {
+	return HTTPChaos{
+		Metadata: ObjectMeta{Name: name, Namespace: "shop", ResourceVersion: version},
+		Spec: HTTPChaosSpec{
+			Selector: PodSelector{LabelSelectors: map[string]string{"app": "checkout"}},
+			Mode:     ModeAll,
+			Target:   TargetRequest,
+			Delay:    stringPtr("1s"),
+		},
+	}
+}
@@ -0,0 +1,197 @@
+package faultchaosmesh
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// serviceAccountDir holds the credentials of the pod's service account.
+	serviceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+	// httpChaosPath is the path of the HTTPChaos resources in the Kubernetes API.
+	httpChaosPath = "/apis/chaos-mesh.org/v1alpha1"
+)
+
+var (
+	// ErrNotInCluster when the in-cluster Kubernetes API cannot be found.
+	ErrNotInCluster = errors.New("KUBERNETES_SERVICE_HOST and KUBERNETES_SERVICE_PORT must be set")
+	// ErrInvalidCA when the certificate authority of the Kubernetes API cannot be parsed.
+	ErrInvalidCA = errors.New("no certificates found in CA file")
+)
+
+// APILister lists HTTPChaos resources from the Kubernetes API. The service account of the pod
+// needs permission to list httpchaos.chaos-mesh.org in the namespaces it lists.
+type APILister struct {
+	baseURL    string
+	client     *http.Client
+	tokenFile  string
+	namespaces []string
+}
+
+// APIListerOption configures an APILister.
+type APIListerOption interface {
+	applyAPILister(l *APILister) error
+}
+
+type httpClientOption struct {
+	client *http.Client
+}
+
+func (o httpClientOption) applyAPILister(l *APILister) error {
+	l.client = o.client
+	return nil
+}
+
+// WithHTTPClient sets the http.Client used to call the Kubernetes API. Default http.DefaultClient,
+// or a client trusting the cluster's certificate authority for NewInClusterLister().
+func WithHTTPClient(c *http.Client) APIListerOption {
+	return httpClientOption{c}
+}
+
+type tokenFileOption string
+
+func (o tokenFileOption) applyAPILister(l *APILister) error {
+	l.tokenFile = string(o)
+	return nil
+}
+
+// WithTokenFile sets a file holding the bearer token sent to the Kubernetes API. The file is read
+// on every list so rotated tokens are used. Default none, or the service account token for
+// NewInClusterLister().
+func WithTokenFile(path string) APIListerOption {
+	return tokenFileOption(path)
+}
+
+type namespacesOption []string
+
+func (o namespacesOption) applyAPILister(l *APILister) error {
+	l.namespaces = append(l.namespaces, o...)
+	return nil
+}
+
+// WithNamespaces lists HTTPChaos only in namespaces, which needs less access than the default of
+// listing them in every namespace.
+func WithNamespaces(namespaces ...string) APIListerOption {
+	return namespacesOption(namespaces)
+}
+
+// NewAPILister returns an APILister for the Kubernetes API at baseURL, such as
+// "https://kubernetes.default.svc".
+func NewAPILister(baseURL string, opts ...APIListerOption) (*APILister, error) {
+	// set defaults
+	l := &APILister{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		client:  http.DefaultClient,
+	}
+
+	// apply options
+	for _, opt := range opts {
+		err := opt.applyAPILister(l)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return l, nil
+}
+
+// NewInClusterLister returns an APILister for the Kubernetes API of the cluster the pod runs in,
+// authenticated as the pod's service account.
+func NewInClusterLister(opts ...APIListerOption) (*APILister, error) {
+	return newInClusterLister(serviceAccountDir, os.Getenv, opts...)
+}
+
+// newInClusterLister is NewInClusterLister with the service account in dir and the environment
+// read by getenv.
+func newInClusterLister(dir string, getenv func(string) string, opts ...APIListerOption) (*APILister, error) {
+	host, port := getenv("KUBERNETES_SERVICE_HOST"), getenv("KUBERNETES_SERVICE_PORT")
+	if host == "" || port == "" {
+		return nil, ErrNotInCluster
+	}
+
+	ca, err := os.ReadFile(filepath.Join(dir, "ca.crt"))
+	if err != nil {
+		return nil, err
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(ca) {
+		return nil, ErrInvalidCA
+	}
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			Proxy:           http.ProxyFromEnvironment,
+			TLSClientConfig: &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12},
+		},
+	}
+
+	defaults := []APIListerOption{
+		WithHTTPClient(client),
+		WithTokenFile(filepath.Join(dir, "token")),
+	}
+
+	return NewAPILister("https://"+net.JoinHostPort(host, port), append(defaults, opts...)...)
+}
+
+// ListHTTPChaos lists the HTTPChaos resources in the namespaces of l, or in every namespace.
+func (l *APILister) ListHTTPChaos(ctx context.Context) ([]HTTPChaos, error) {
+	if len(l.namespaces) == 0 {
+		return l.list(ctx, httpChaosPath+"/httpchaos")
+	}
+
+	var items []HTTPChaos
+	for _, ns := range l.namespaces {
+		nsItems, err := l.list(ctx, httpChaosPath+"/namespaces/"+ns+"/httpchaos")
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, nsItems...)
+	}
+
+	return items, nil
+}
+
+// list returns the HTTPChaos resources at path.
+func (l *APILister) list(ctx context.Context, path string) ([]HTTPChaos, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, l.baseURL+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	if l.tokenFile != "" {
+		token, err := os.ReadFile(l.tokenFile)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(token)))
+	}
+
+	resp, err := l.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("faultchaosmesh: list %s: %s", path, resp.Status)
+	}
+
+	var list HTTPChaosList
+	err = json.NewDecoder(resp.Body).Decode(&list)
+	if err != nil {
+		return nil, err
+	}
+
+	return list.Items, nil
+}
@@ -0,0 +1,232 @@
+package faultchaosmesh
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testAPIServer returns a server that lists items as the HTTPChaos of every namespace it is asked
+// for, if requests carry the bearer token "secret".
+func testAPIServer(t *testing.T, tls bool, items []HTTPChaos) *httptest.Server {
+	t.Helper()
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		var list HTTPChaosList
+		for _, hc := range items {
+			all := r.URL.Path == httpChaosPath+"/httpchaos"
+			if all || r.URL.Path == httpChaosPath+"/namespaces/"+hc.Metadata.Namespace+"/httpchaos" {
+				list.Items = append(list.Items, hc)
+			}
+		}
+
+		if r.URL.Path == httpChaosPath+"/namespaces/broken/httpchaos" {
+			_, _ = w.Write([]byte("{"))
+			return
+		}
+
+		_ = json.NewEncoder(w).Encode(list)
+	})
+
+	var srv *httptest.Server
+	if tls {
+		srv = httptest.NewTLSServer(handler)
+	} else {
+		srv = httptest.NewServer(handler)
+	}
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+// writeFile writes content to name in dir and returns its path.
+func writeFile(t *testing.T, dir, name string, content []byte) string {
+	t.Helper()
+
+	path := filepath.Join(dir, name)
+	assert.NoError(t, os.WriteFile(path, content, 0o600))
+
+	return path
+}
+
+// TestNewAPILister tests NewAPILister.
+func TestNewAPILister(t *testing.T) {
+	t.Parallel()
+
+	client := &http.Client{}
+
+	l, err := NewAPILister("https://kubernetes.default.svc/")
+	assert.NoError(t, err)
+	assert.Equal(t, &APILister{baseURL: "https://kubernetes.default.svc", client: http.DefaultClient}, l)
+
+	l, err = NewAPILister("https://kubernetes.default.svc",
+		WithHTTPClient(client),
+		WithTokenFile("/token"),
+		WithNamespaces("shop"),
+		WithNamespaces("web"),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, &APILister{
+		baseURL:    "https://kubernetes.default.svc",
+		client:     client,
+		tokenFile:  "/token",
+		namespaces: []string{"shop", "web"},
+	}, l)
+
+	l, err = NewAPILister("https://kubernetes.default.svc", errorOption{})
+	assert.Equal(t, errErrorOption, err)
+	assert.Nil(t, l)
+}
+
+// TestAPIListerListHTTPChaos tests APILister.ListHTTPChaos.
+func TestAPIListerListHTTPChaos(t *testing.T) {
+	t.Parallel()
+
+	shop, web := testChaos("a", "1"), testChaos("b", "1")
+	web.Metadata.Namespace = "web"
+	srv := testAPIServer(t, false, []HTTPChaos{shop, web})
+
+	dir := t.TempDir()
+	token := writeFile(t, dir, "token", []byte("secret\n"))
+	wrongToken := writeFile(t, dir, "wrong", []byte("guess"))
+
+	tests := []struct {
+		name        string
+		giveURL     string
+		giveOptions []APIListerOption
+		want        []HTTPChaos
+		wantErr     string
+	}{
+		{
+			name:        "all namespaces",
+			giveURL:     srv.URL,
+			giveOptions: []APIListerOption{WithTokenFile(token)},
+			want:        []HTTPChaos{shop, web},
+		},
+		{
+			name:        "namespaces",
+			giveURL:     srv.URL,
+			giveOptions: []APIListerOption{WithTokenFile(token), WithNamespaces("web", "shop", "empty")},
+			want:        []HTTPChaos{web, shop},
+		},
+		{
+			name:        "missing token file",
+			giveURL:     srv.URL,
+			giveOptions: []APIListerOption{WithTokenFile(filepath.Join(dir, "missing"))},
+			wantErr:     "open " + filepath.Join(dir, "missing") + ": no such file or directory",
+		},
+		{
+			name:        "unauthorized",
+			giveURL:     srv.URL,
+			giveOptions: []APIListerOption{WithTokenFile(wrongToken), WithNamespaces("shop")},
+			wantErr:     "faultchaosmesh: list " + httpChaosPath + "/namespaces/shop/httpchaos: 401 Unauthorized",
+		},
+		{
+			name:        "no token",
+			giveURL:     srv.URL,
+			giveOptions: []APIListerOption{},
+			wantErr:     "faultchaosmesh: list " + httpChaosPath + "/httpchaos: 401 Unauthorized",
+		},
+		{
+			name:        "invalid json",
+			giveURL:     srv.URL,
+			giveOptions: []APIListerOption{WithTokenFile(token), WithNamespaces("broken")},
+			wantErr:     "unexpected EOF",
+		},
+		{
+			name:    "invalid url",
+			giveURL: "http://[::1",
+			wantErr: `parse "http://[::1/apis/chaos-mesh.org/v1alpha1/httpchaos": missing ']' in host`,
+		},
+		{
+			name:    "unreachable",
+			giveURL: "http://127.0.0.1:0",
+			wantErr: "connect",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			l, err := NewAPILister(tt.giveURL, tt.giveOptions...)
+			assert.NoError(t, err)
+
+			items, err := l.ListHTTPChaos(context.Background())
+
+			if tt.wantErr != "" {
+				assert.Error(t, err)
+				if err != nil {
+					assert.Contains(t, err.Error(), tt.wantErr)
+				}
+				assert.Nil(t, items)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, items)
+		})
+	}
+}
+
+// TestNewInClusterLister tests that NewInClusterLister uses the service account of the pod.
+func TestNewInClusterLister(t *testing.T) {
+	t.Parallel()
+
+	srv := testAPIServer(t, true, []HTTPChaos{testChaos("a", "1")})
+	u, err := url.Parse(srv.URL)
+	assert.NoError(t, err)
+
+	dir := t.TempDir()
+	writeFile(t, dir, "token", []byte("secret"))
+	writeFile(t, dir, "ca.crt", pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw}))
+
+	badCADir := t.TempDir()
+	writeFile(t, badCADir, "ca.crt", []byte("not a certificate"))
+
+	env := map[string]string{
+		"KUBERNETES_SERVICE_HOST": u.Hostname(),
+		"KUBERNETES_SERVICE_PORT": u.Port(),
+	}
+
+	l, err := NewInClusterLister()
+	if os.Getenv("KUBERNETES_SERVICE_HOST") == "" {
+		assert.Equal(t, ErrNotInCluster, err)
+		assert.Nil(t, l)
+	}
+
+	l, err = newInClusterLister(dir, func(string) string { return "" })
+	assert.Equal(t, ErrNotInCluster, err)
+	assert.Nil(t, l)
+
+	l, err = newInClusterLister(t.TempDir(), func(k string) string { return env[k] })
+	assert.True(t, os.IsNotExist(err))
+	assert.Nil(t, l)
+
+	l, err = newInClusterLister(badCADir, func(k string) string { return env[k] })
+	assert.Equal(t, ErrInvalidCA, err)
+	assert.Nil(t, l)
+
+	l, err = newInClusterLister(dir, func(k string) string { return env[k] }, WithNamespaces("shop"))
+	assert.NoError(t, err)
+	assert.Equal(t, srv.URL, l.baseURL)
+	assert.Equal(t, []string{"shop"}, l.namespaces)
+
+	items, err := l.ListHTTPChaos(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []HTTPChaos{testChaos("a", "1")}, items)
+}
@@ -0,0 +1,25 @@
+/*
+Package faultchi runs Faults as chi middleware.
+
+Middleware
+
+chi middleware is net/http middleware, so Middleware() only returns the Handler method of a
+fault.Fault, a fault.Manager, or any fault.Injector in the form chi expects. The request and
+ResponseWriter the Injector passes on reach the handlers unchanged, with the context values of the
+Fault and any URL parameters chi has already parsed:
+
+    f, _ := fault.NewFault(ei,
+        fault.WithEnabled(true),
+        fault.WithParticipation(0.05),
+        fault.WithReporter(reporter),
+    )
+    r := chi.NewRouter()
+    r.Use(faultchi.Middleware(f))
+
+Middleware added with Use runs before chi routes the request. To fault only some routes, add it to
+them with With, or to a group of routes with Group or Route:
+
+    r.With(faultchi.Middleware(f)).Get("/orders/{id}", getOrder)
+
+*/
+package faultchi
@@ -0,0 +1,13 @@
+package faultchi
+
+import (
+	"net/http"
+
+	"github.com/github/go-fault"
+)
+
+// Middleware returns chi middleware that runs i on requests. Pass it a fault.Fault, a
+// fault.Manager, or any fault.Injector.
+func Middleware(i fault.Injector) func(next http.Handler) http.Handler {
+	return i.Handler
+}
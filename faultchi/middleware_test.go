@@ -0,0 +1,36 @@
+package faultchi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/github/go-fault"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestMiddleware tests Middleware.
+func TestMiddleware(t *testing.T) {
+	t.Parallel()
+
+	ei, err := fault.NewErrorInjector(http.StatusServiceUnavailable)
+	assert.NoError(t, err)
+	f, err := fault.NewFault(ei,
+		fault.WithEnabled(true),
+		fault.WithParticipation(1.0),
+		fault.WithPathBlocklist([]string{"/health"}),
+	)
+	assert.NoError(t, err)
+
+	h := Middleware(f)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/health", nil))
+	assert.Equal(t, http.StatusCreated, rr.Code)
+}
@@ -0,0 +1,31 @@
+/*
+Package faultecho runs Faults as echo middleware.
+
+Middleware
+
+Middleware() runs a fault.Fault, a fault.Manager, or any fault.Injector on the requests of an echo
+server or group, so the Faults of net/http services work unchanged in echo services:
+
+    f, _ := fault.NewFault(ei,
+        fault.WithEnabled(true),
+        fault.WithParticipation(0.05),
+        fault.WithReporter(reporter),
+    )
+    e := echo.New()
+    e.Use(faultecho.Middleware(f))
+
+The next handler runs with the request the Injector passes on, so handlers see the context of the
+Fault in c.Request().Context() and can call fault.HistoryFromContext() and
+fault.AddedLatencyFromContext(). Injectors that change the response, such as a CharsetInjector or
+a SlowInjector with a LatencyPhase, see what the handlers write. To let them see the response of an
+error the handler returns too, the middleware passes the error to c.Error() before it returns it;
+the default error handler of echo then skips the response it already wrote. When an Injector
+writes its own response, such as an ErrorInjector, the next handler does not run.
+
+A RejectInjector drops the connection as it does in net/http services, since the Recover
+middleware of echo passes http.ErrAbortHandler on. The next handler runs at most once, so an
+Injector that runs the request more than once, such as a DuplicateRequestInjector, only runs it the
+first time.
+
+*/
+package faultecho
@@ -0,0 +1,49 @@
+package faultecho
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/github/go-fault"
+	"github.com/labstack/echo/v4"
+)
+
+// Middleware returns echo middleware that runs i on requests. Pass it a fault.Fault, a
+// fault.Manager, or any fault.Injector. If i continues the request the next handler runs with the
+// request and ResponseWriter i passes on, so handlers see the context values i adds and Injectors
+// that change the response see what the handlers write, including the response of an error the
+// handler returns. If i writes its own response the next handler does not run.
+func Middleware(i fault.Injector) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req, resp := c.Request(), c.Response()
+			defer func() {
+				c.SetRequest(req)
+				c.SetResponse(resp)
+			}()
+
+			var err error
+			var once sync.Once
+			i.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				// an Injector that runs the request more than once only runs the handler the first time
+				once.Do(func() {
+					c.SetRequest(r)
+					if w == http.ResponseWriter(resp) {
+						err = next(c)
+						return
+					}
+
+					c.SetResponse(echo.NewResponse(w, c.Echo()))
+					err = next(c)
+					// the error handler writes to the response once the middleware returns, which is
+					// too late for the Injector to see it
+					if err != nil {
+						c.Error(err)
+					}
+				})
+			})).ServeHTTP(resp, req)
+
+			return err
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package faultecho
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/github/go-fault"
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+// testInjectorFunc is a fault.Injector made from a function.
+type testInjectorFunc func(next http.Handler) http.Handler
+
+// Handler calls the function.
+func (f testInjectorFunc) Handler(next http.Handler) http.Handler {
+	return f(next)
+}
+
+// testKey is the context key of testContextInjector.
+type testKey struct{}
+
+// testContextInjector is a fault.Injector that adds a context value and upper cases the body.
+var testContextInjector = testInjectorFunc(func(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(&testUpperWriter{ResponseWriter: w}, r.WithContext(context.WithValue(r.Context(), testKey{}, "value")))
+	})
+})
+
+// testUpperWriter is an http.ResponseWriter that upper cases the body.
+type testUpperWriter struct {
+	http.ResponseWriter
+}
+
+// Write upper cases b.
+func (w *testUpperWriter) Write(b []byte) (int, error) {
+	return w.ResponseWriter.Write(bytes.ToUpper(b))
+}
+
+// testServer returns an echo server that runs i and then handlers on "/" that write the context
+// value of testContextInjector and on "/error" that return an error, and counts the calls of the
+// handlers in calls.
+func testServer(i fault.Injector, calls *int) *echo.Echo {
+	e := echo.New()
+	e.Use(middleware.Recover(), Middleware(i))
+	e.GET("/", func(c echo.Context) error {
+		*calls++
+		v, _ := c.Request().Context().Value(testKey{}).(string)
+		return c.String(http.StatusCreated, "handler "+v)
+	})
+	e.GET("/error", func(c echo.Context) error {
+		*calls++
+		return echo.NewHTTPError(http.StatusTeapot, "short and stout")
+	})
+
+	return e
+}
+
+// TestMiddleware tests Middleware.
+func TestMiddleware(t *testing.T) {
+	t.Parallel()
+
+	errorInjector, err := fault.NewErrorInjector(http.StatusServiceUnavailable)
+	assert.NoError(t, err)
+	disabled, err := fault.NewFault(errorInjector, fault.WithEnabled(false))
+	assert.NoError(t, err)
+	enabled, err := fault.NewFault(errorInjector, fault.WithEnabled(true), fault.WithParticipation(1.0))
+	assert.NoError(t, err)
+	duplicateInjector, err := fault.NewDuplicateRequestInjector(fault.WithDuplicates(2))
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name      string
+		give      fault.Injector
+		givePath  string
+		wantCode  int
+		wantBody  string
+		wantCalls int
+	}{
+		{
+			name:      "not injected",
+			give:      disabled,
+			givePath:  "/",
+			wantCode:  http.StatusCreated,
+			wantBody:  "handler ",
+			wantCalls: 1,
+		},
+		{
+			name:      "not injected error",
+			give:      disabled,
+			givePath:  "/error",
+			wantCode:  http.StatusTeapot,
+			wantBody:  `{"message":"short and stout"}` + "\n",
+			wantCalls: 1,
+		},
+		{
+			name:      "injected",
+			give:      enabled,
+			givePath:  "/",
+			wantCode:  http.StatusServiceUnavailable,
+			wantBody:  http.StatusText(http.StatusServiceUnavailable) + "\n",
+			wantCalls: 0,
+		},
+		{
+			name:      "context and writer",
+			give:      testContextInjector,
+			givePath:  "/",
+			wantCode:  http.StatusCreated,
+			wantBody:  "HANDLER VALUE",
+			wantCalls: 1,
+		},
+		{
+			name:      "error through writer",
+			give:      testContextInjector,
+			givePath:  "/error",
+			wantCode:  http.StatusTeapot,
+			wantBody:  `{"MESSAGE":"SHORT AND STOUT"}` + "\n",
+			wantCalls: 1,
+		},
+		{
+			name:      "run more than once",
+			give:      duplicateInjector,
+			givePath:  "/",
+			wantCode:  http.StatusCreated,
+			wantBody:  "handler ",
+			wantCalls: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var calls int
+			rr := httptest.NewRecorder()
+			testServer(tt.give, &calls).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, tt.givePath, nil))
+
+			assert.Equal(t, tt.wantCode, rr.Code)
+			assert.Equal(t, tt.wantBody, rr.Body.String())
+			assert.Equal(t, tt.wantCalls, calls)
+		})
+	}
+}
+
+// TestMiddlewareRestores tests that middleware before Middleware sees its own request and response
+// once the handler returns.
+func TestMiddlewareRestores(t *testing.T) {
+	t.Parallel()
+
+	e := echo.New()
+	e.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req, resp := c.Request(), c.Response()
+			err := next(c)
+			assert.Equal(t, req, c.Request())
+			assert.Equal(t, resp, c.Response())
+			assert.True(t, resp.Committed)
+			assert.Equal(t, http.StatusAccepted, resp.Status)
+			return err
+		}
+	}, Middleware(testContextInjector))
+	e.GET("/", func(c echo.Context) error {
+		assert.Equal(t, "value", c.Request().Context().Value(testKey{}))
+		return c.NoContent(http.StatusAccepted)
+	})
+
+	rr := httptest.NewRecorder()
+	e.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusAccepted, rr.Code)
+}
+
+// TestMiddlewareReject tests that a RejectInjector drops the connection instead of being recovered
+// into a 500.
+func TestMiddlewareReject(t *testing.T) {
+	t.Parallel()
+
+	ri, err := fault.NewRejectInjector(fault.WithCloseType(fault.CloseAbort))
+	assert.NoError(t, err)
+
+	var calls int
+	srv := httptest.NewServer(testServer(ri, &calls))
+	defer srv.Close()
+
+	resp, err := srv.Client().Get(srv.URL)
+	if err == nil {
+		resp.Body.Close()
+	}
+	assert.Error(t, err)
+	assert.Equal(t, 0, calls)
+}
@@ -0,0 +1,39 @@
+/*
+Package faultfasthttp runs Faults as fasthttp middleware.
+
+Middleware
+
+Middleware() runs a fault.Fault, a fault.Manager, or any fault.Injector on the requests of a
+fasthttp server, so the Faults of net/http services work unchanged in fasthttp services:
+
+    f, _ := fault.NewFault(ei,
+        fault.WithEnabled(true),
+        fault.WithParticipation(0.05),
+        fault.WithReporter(reporter),
+    )
+    err := fasthttp.ListenAndServe(":8080", faultfasthttp.Middleware(f)(handler))
+
+Injectors see each request as the http.Request that fasthttpadaptor converts it to, so path and
+header allowlists and blocklists work on it the same way. An ErrorInjector sends its response
+instead of running the handler, a SlowInjector delays the request, and a RejectInjector closes the
+connection without a response. Headers an Injector sets before it continues the request, such as
+those of a HeaderInjector, are added to the response.
+
+fasthttp handlers take a *fasthttp.RequestCtx rather than an http.Request, so they cannot see the
+context the Injector passed on in the usual way. Call Context() in the handler to get it, with the
+values of the Fault such as for fault.HistoryFromContext():
+
+    func handler(ctx *fasthttp.RequestCtx) {
+        if fault.InjectedFromContext(faultfasthttp.Context(ctx)) {
+            ctx.Response.Header.Set("Cache-Control", "no-store")
+        }
+    }
+
+The handler writes to the fasthttp response directly, so Injectors that change the response the
+handler writes, such as a CharsetInjector, have no effect on it, and a SlowInjector with a
+LatencyPhase other than LatencyBeforeHeaders waits after the handler returns. The handler runs at
+most once, so an Injector that runs the request more than once, such as a DuplicateRequestInjector,
+only runs it the first time.
+
+*/
+package faultfasthttp
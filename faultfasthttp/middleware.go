@@ -0,0 +1,131 @@
+package faultfasthttp
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sync"
+
+	"github.com/github/go-fault"
+	"github.com/valyala/fasthttp"
+	"github.com/valyala/fasthttp/fasthttpadaptor"
+)
+
+// contextKey is the user value that holds the context of the request an Injector continued.
+const contextKey = "github.com/github/go-fault/faultfasthttp.context"
+
+// Middleware returns fasthttp middleware that runs i on requests. Pass it a fault.Fault, a
+// fault.Manager, or any fault.Injector. i sees each request as the http.Request fasthttpadaptor
+// converts it to. If i writes its own response the response is sent and next does not run; if it
+// continues the request, the headers it set are added to the response and next runs, and Context()
+// returns the context i passed on. If i drops the connection, the way a fault.RejectInjector does,
+// the connection is closed without a response.
+func Middleware(i fault.Injector) func(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+	return func(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+		return func(ctx *fasthttp.RequestCtx) {
+			var r http.Request
+			if err := fasthttpadaptor.ConvertRequest(ctx, &r, true); err != nil {
+				// the request cannot be faulted, but fasthttp may still serve it
+				next(ctx)
+				return
+			}
+
+			w := &responseWriter{ctx: ctx, header: make(http.Header)}
+			var once sync.Once
+			h := i.Handler(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+				// an Injector that runs the request more than once only runs next the first time
+				once.Do(func() {
+					w.handOver()
+					ctx.SetUserValue(contextKey, r.Context())
+					next(ctx)
+				})
+			}))
+
+			serve(h, w, r.WithContext(ctx))
+		}
+	}
+}
+
+// Context returns the context of the request an Injector continued to the handler serving ctx,
+// with the values the Injector added, such as for fault.HistoryFromContext(). It returns ctx if
+// no Injector continued the request.
+func Context(ctx *fasthttp.RequestCtx) context.Context {
+	if c, ok := ctx.UserValue(contextKey).(context.Context); ok {
+		return c
+	}
+
+	return ctx
+}
+
+// serve runs h and, if it aborts with http.ErrAbortHandler, closes the connection without sending
+// a response. Other panics are not recovered.
+func serve(h http.Handler, w *responseWriter, r *http.Request) {
+	defer func() {
+		if v := recover(); v != nil {
+			if v != http.ErrAbortHandler { //nolint:errorlint
+				panic(v)
+			}
+
+			w.ctx.HijackSetNoResponse(true)
+			w.ctx.Hijack(func(net.Conn) {})
+		}
+	}()
+
+	h.ServeHTTP(w, r)
+}
+
+// responseWriter is an http.ResponseWriter that writes the response of an Injector to a fasthttp
+// response. Once the Injector continues the request the handler owns the response and later
+// writes of the Injector are dropped.
+type responseWriter struct {
+	ctx    *fasthttp.RequestCtx
+	header http.Header
+
+	wroteHeader bool
+	handedOver  bool
+}
+
+// Header returns the header map of the response.
+func (w *responseWriter) Header() http.Header {
+	return w.header
+}
+
+// WriteHeader sets the status code of the response and adds its headers.
+func (w *responseWriter) WriteHeader(code int) {
+	if w.wroteHeader || w.handedOver {
+		return
+	}
+	w.wroteHeader = true
+
+	w.addHeaders()
+	w.ctx.SetStatusCode(code)
+}
+
+// Write writes b to the body of the response.
+func (w *responseWriter) Write(b []byte) (int, error) {
+	if w.handedOver {
+		return len(b), nil
+	}
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	return w.ctx.Write(b)
+}
+
+// handOver adds the headers set so far to the response and leaves the rest of it to the handler.
+func (w *responseWriter) handOver() {
+	if !w.wroteHeader {
+		w.addHeaders()
+	}
+	w.handedOver = true
+}
+
+// addHeaders adds the headers of the header map to the response.
+func (w *responseWriter) addHeaders() {
+	for k, vs := range w.header {
+		for _, v := range vs {
+			w.ctx.Response.Header.Add(k, v)
+		}
+	}
+}
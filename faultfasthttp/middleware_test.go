@@ -0,0 +1,193 @@
+package faultfasthttp
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/github/go-fault"
+	"github.com/stretchr/testify/assert"
+	"github.com/valyala/fasthttp"
+	"github.com/valyala/fasthttp/fasthttputil"
+)
+
+// testInjectorFunc is a fault.Injector made from a function.
+type testInjectorFunc func(next http.Handler) http.Handler
+
+// Handler calls the function.
+func (f testInjectorFunc) Handler(next http.Handler) http.Handler {
+	return f(next)
+}
+
+// testKey is the context key of testContextInjector.
+type testKey struct{}
+
+// testContextInjector is a fault.Injector that adds a context value and a header, continues the
+// request, and then tries to write a response of its own.
+var testContextInjector = testInjectorFunc(func(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Fault", "1")
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), testKey{}, "value")))
+		w.WriteHeader(http.StatusTeapot)
+		_, _ = w.Write([]byte("dropped"))
+	})
+})
+
+// testHandler returns a handler that writes the context value of testContextInjector, and counts
+// its calls in calls.
+func testHandler(calls *int) fasthttp.RequestHandler {
+	return func(ctx *fasthttp.RequestCtx) {
+		*calls++
+		v, _ := Context(ctx).Value(testKey{}).(string)
+		ctx.SetStatusCode(http.StatusCreated)
+		ctx.SetBodyString("handler " + v)
+	}
+}
+
+// TestMiddleware tests Middleware.
+func TestMiddleware(t *testing.T) {
+	t.Parallel()
+
+	errorInjector, err := fault.NewErrorInjector(http.StatusServiceUnavailable)
+	assert.NoError(t, err)
+	disabled, err := fault.NewFault(errorInjector, fault.WithEnabled(false))
+	assert.NoError(t, err)
+	enabled, err := fault.NewFault(errorInjector, fault.WithEnabled(true), fault.WithParticipation(1.0))
+	assert.NoError(t, err)
+	blocked, err := fault.NewFault(errorInjector, fault.WithEnabled(true), fault.WithParticipation(1.0),
+		fault.WithPathBlocklist([]string{"/health"}))
+	assert.NoError(t, err)
+	duplicateInjector, err := fault.NewDuplicateRequestInjector(fault.WithDuplicates(2))
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		give       fault.Injector
+		giveURI    string
+		wantCode   int
+		wantBody   string
+		wantHeader string
+		wantCalls  int
+	}{
+		{
+			name:      "not injected",
+			give:      disabled,
+			giveURI:   "/",
+			wantCode:  http.StatusCreated,
+			wantBody:  "handler ",
+			wantCalls: 1,
+		},
+		{
+			name:      "injected",
+			give:      enabled,
+			giveURI:   "/",
+			wantCode:  http.StatusServiceUnavailable,
+			wantBody:  http.StatusText(http.StatusServiceUnavailable) + "\n",
+			wantCalls: 0,
+		},
+		{
+			name:      "blocklisted",
+			give:      blocked,
+			giveURI:   "/health?full=1",
+			wantCode:  http.StatusCreated,
+			wantBody:  "handler ",
+			wantCalls: 1,
+		},
+		{
+			name:       "context and headers",
+			give:       testContextInjector,
+			giveURI:    "/",
+			wantCode:   http.StatusCreated,
+			wantBody:   "handler value",
+			wantHeader: "1",
+			wantCalls:  1,
+		},
+		{
+			name:      "run more than once",
+			give:      duplicateInjector,
+			giveURI:   "/",
+			wantCode:  http.StatusCreated,
+			wantBody:  "handler ",
+			wantCalls: 1,
+		},
+		{
+			name:      "invalid request URI",
+			give:      enabled,
+			giveURI:   "/%zz",
+			wantCode:  http.StatusCreated,
+			wantBody:  "handler ",
+			wantCalls: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var ctx fasthttp.RequestCtx
+			ctx.Request.SetRequestURI(tt.giveURI)
+
+			var calls int
+			Middleware(tt.give)(testHandler(&calls))(&ctx)
+
+			assert.Equal(t, tt.wantCode, ctx.Response.StatusCode())
+			assert.Equal(t, tt.wantBody, string(ctx.Response.Body()))
+			assert.Equal(t, tt.wantHeader, string(ctx.Response.Header.Peek("X-Fault")))
+			assert.Equal(t, tt.wantCalls, calls)
+		})
+	}
+}
+
+// TestContext tests that Context returns the RequestCtx if no Injector continued the request.
+func TestContext(t *testing.T) {
+	t.Parallel()
+
+	var ctx fasthttp.RequestCtx
+	assert.Equal(t, &ctx, Context(&ctx))
+}
+
+// TestMiddlewareReject tests that a RejectInjector closes the connection without a response.
+func TestMiddlewareReject(t *testing.T) {
+	t.Parallel()
+
+	ri, err := fault.NewRejectInjector()
+	assert.NoError(t, err)
+
+	var calls int
+	ln := fasthttputil.NewInmemoryListener()
+	srv := &fasthttp.Server{Handler: Middleware(ri)(testHandler(&calls))}
+	go func() { _ = srv.Serve(ln) }()
+	defer srv.Shutdown() //nolint:errcheck
+
+	client := &fasthttp.Client{
+		Dial: func(addr string) (net.Conn, error) {
+			return ln.Dial()
+		},
+	}
+	statusCode, _, err := client.Get(nil, "http://example.com/")
+	assert.Error(t, err)
+	assert.Equal(t, 0, statusCode)
+	assert.Equal(t, 0, calls)
+}
+
+// TestMiddlewarePanic tests that Middleware passes on panics other than http.ErrAbortHandler.
+func TestMiddlewarePanic(t *testing.T) {
+	t.Parallel()
+
+	i := testInjectorFunc(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			panic("boom")
+		})
+	})
+
+	var ctx fasthttp.RequestCtx
+	ctx.Request.SetRequestURI("/")
+
+	var calls int
+	assert.PanicsWithValue(t, "boom", func() {
+		Middleware(i)(testHandler(&calls))(&ctx)
+	})
+	assert.Equal(t, 0, calls)
+}
@@ -0,0 +1,28 @@
+/*
+Package faultgin runs Faults as gin middleware.
+
+Middleware
+
+Middleware() runs a fault.Fault, a fault.Manager, or any fault.Injector on the requests of a gin
+Engine or group, so the Faults of net/http services work unchanged in gin services:
+
+    f, _ := fault.NewFault(ei,
+        fault.WithEnabled(true),
+        fault.WithParticipation(0.05),
+        fault.WithReporter(reporter),
+    )
+    r := gin.Default()
+    r.Use(faultgin.Middleware(f))
+
+The rest of the chain runs with the request the Injector passes on, so handlers see the context of
+the Fault in c.Request.Context() and can call fault.HistoryFromContext() and
+fault.AddedLatencyFromContext(). Injectors that change the response, such as a CharsetInjector or a
+SlowInjector with a LatencyPhase, see what the handlers write. When an Injector writes its own
+response, such as an ErrorInjector, the rest of the chain is aborted. When a RejectInjector aborts
+a request the connection is dropped, rather than recovered into a 500 by gin.Recovery().
+
+The rest of the chain runs at most once, so an Injector that runs the request more than once, such
+as a DuplicateRequestInjector, only runs it the first time.
+
+*/
+package faultgin
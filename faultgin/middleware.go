@@ -0,0 +1,187 @@
+package faultgin
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/github/go-fault"
+)
+
+// noWritten is the size of a response whose headers are not written yet, as gin counts it.
+const noWritten = -1
+
+// Middleware returns gin middleware that runs i on requests. Pass it a fault.Fault, a fault.Manager,
+// or any fault.Injector. If i continues the request the rest of the chain runs with the request
+// and ResponseWriter i passes on, so handlers see the context values i adds and Injectors that
+// change the response see what the handlers write. If i writes its own response the rest of the
+// chain is aborted, and if i drops the connection it is dropped before gin's recovery middleware
+// can turn the drop into a 500.
+func Middleware(i fault.Injector) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		writer, req := c.Writer, c.Request
+		defer func() { c.Writer, c.Request = writer, req }()
+
+		var called bool
+		var once sync.Once
+		h := i.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// an Injector that runs the request more than once only runs the chain the first time
+			once.Do(func() {
+				called = true
+
+				c.Request = r
+				if w != http.ResponseWriter(writer) {
+					// gin writes the headers of its own ResponseWriter once the chain returns
+					rw := &responseWriter{ResponseWriter: w, status: http.StatusOK, size: noWritten}
+					defer rw.WriteHeaderNow()
+					c.Writer = rw
+				}
+				c.Next()
+			})
+		}))
+
+		serve(h, c)
+		if !called {
+			c.Abort()
+		}
+	}
+}
+
+// serve runs h on the request of c. If h aborts with http.ErrAbortHandler, the way a
+// fault.RejectInjector drops a connection, it drops the connection itself. Other panics, and aborts
+// on connections that cannot be taken over such as HTTP/2, are passed on.
+func serve(h http.Handler, c *gin.Context) {
+	defer func() {
+		if v := recover(); v != nil {
+			if v != http.ErrAbortHandler { //nolint:errorlint
+				panic(v)
+			}
+
+			c.Abort()
+			conn, err := hijack(c.Writer)
+			if errors.Is(err, http.ErrHijacked) {
+				return
+			}
+			if err != nil {
+				panic(v)
+			}
+			_ = conn.Close()
+
+			// mark the ResponseWriter of gin written, so it does not write headers to the connection
+			_, _, _ = c.Writer.Hijack()
+		}
+	}()
+
+	h.ServeHTTP(c.Writer, c.Request)
+}
+
+// hijack takes over the connection of w. The ResponseWriter of gin panics if it cannot, so the
+// connection is taken over from the ResponseWriter it wraps.
+func hijack(w gin.ResponseWriter) (net.Conn, error) {
+	var rw http.ResponseWriter = w
+	if u, ok := w.(interface{ Unwrap() http.ResponseWriter }); ok {
+		rw = u.Unwrap()
+	}
+
+	conn, _, err := http.NewResponseController(rw).Hijack()
+	return conn, err
+}
+
+// responseWriter is a gin.ResponseWriter that writes to the http.ResponseWriter an Injector passed
+// on. Like the ResponseWriter of gin it holds the status code until the body is written.
+type responseWriter struct {
+	http.ResponseWriter
+	status int
+	size   int
+}
+
+// WriteHeader sets the status code if the headers are not written yet.
+func (w *responseWriter) WriteHeader(code int) {
+	if code > 0 && !w.Written() {
+		w.status = code
+	}
+}
+
+// WriteHeaderNow writes the status code and headers if they are not written yet.
+func (w *responseWriter) WriteHeaderNow() {
+	if !w.Written() {
+		w.size = 0
+		w.ResponseWriter.WriteHeader(w.status)
+	}
+}
+
+// Write writes the headers, if they are not written yet, and b.
+func (w *responseWriter) Write(b []byte) (int, error) {
+	w.WriteHeaderNow()
+	n, err := w.ResponseWriter.Write(b)
+	w.size += n
+	return n, err
+}
+
+// WriteString writes the headers, if they are not written yet, and s.
+func (w *responseWriter) WriteString(s string) (int, error) {
+	w.WriteHeaderNow()
+	n, err := io.WriteString(w.ResponseWriter, s)
+	w.size += n
+	return n, err
+}
+
+// Status returns the status code of the response.
+func (w *responseWriter) Status() int {
+	return w.status
+}
+
+// Size returns the number of bytes of the body written, or -1 if the headers are not written yet.
+func (w *responseWriter) Size() int {
+	return w.size
+}
+
+// Written returns true once the headers are written.
+func (w *responseWriter) Written() bool {
+	return w.size != noWritten
+}
+
+// Hijack lets the caller take over the connection if the underlying ResponseWriter can, and returns
+// http.ErrNotSupported otherwise.
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if w.size < 0 {
+		w.size = 0
+	}
+
+	return http.NewResponseController(w.ResponseWriter).Hijack()
+}
+
+// CloseNotify returns the CloseNotify channel of the underlying ResponseWriter, or a channel that
+// never receives if it has none.
+func (w *responseWriter) CloseNotify() <-chan bool {
+	if cn, ok := w.ResponseWriter.(http.CloseNotifier); ok { //nolint:staticcheck
+		return cn.CloseNotify()
+	}
+
+	return make(chan bool)
+}
+
+// Flush writes the headers, if they are not written yet, and flushes the underlying ResponseWriter
+// if it can.
+func (w *responseWriter) Flush() {
+	w.WriteHeaderNow()
+	_ = http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Pusher returns the underlying ResponseWriter if it is an http.Pusher, or nil.
+func (w *responseWriter) Pusher() http.Pusher {
+	if p, ok := w.ResponseWriter.(http.Pusher); ok {
+		return p
+	}
+
+	return nil
+}
+
+// Unwrap returns the underlying ResponseWriter for http.ResponseController.
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,301 @@
+package faultgin
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/github/go-fault"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	os.Exit(m.Run())
+}
+
+// testInjectorFunc is a fault.Injector made from a function.
+type testInjectorFunc func(next http.Handler) http.Handler
+
+// Handler calls the function.
+func (f testInjectorFunc) Handler(next http.Handler) http.Handler {
+	return f(next)
+}
+
+// testKey is the context key of testContextInjector.
+type testKey struct{}
+
+// testContextInjector is a fault.Injector that adds a context value and upper cases the body.
+var testContextInjector = testInjectorFunc(func(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(&testUpperWriter{ResponseWriter: w}, r.WithContext(context.WithValue(r.Context(), testKey{}, "value")))
+	})
+})
+
+// testUpperWriter is an http.ResponseWriter that upper cases the body.
+type testUpperWriter struct {
+	http.ResponseWriter
+}
+
+// Write upper cases b.
+func (w *testUpperWriter) Write(b []byte) (int, error) {
+	return w.ResponseWriter.Write(bytes.ToUpper(b))
+}
+
+// testEngine returns an Engine that runs i and then a handler that writes the context value of
+// testContextInjector, and counts the calls of the handler in calls.
+func testEngine(i fault.Injector, calls *int) *gin.Engine {
+	r := gin.New()
+	r.Use(gin.Recovery(), Middleware(i))
+	r.GET("/", func(c *gin.Context) {
+		*calls++
+		v, _ := c.Request.Context().Value(testKey{}).(string)
+		c.String(http.StatusCreated, "handler %s", v)
+	})
+
+	return r
+}
+
+// TestMiddleware tests Middleware.
+func TestMiddleware(t *testing.T) {
+	t.Parallel()
+
+	errorInjector, err := fault.NewErrorInjector(http.StatusServiceUnavailable)
+	assert.NoError(t, err)
+	disabled, err := fault.NewFault(errorInjector, fault.WithEnabled(false))
+	assert.NoError(t, err)
+	enabled, err := fault.NewFault(errorInjector, fault.WithEnabled(true), fault.WithParticipation(1.0))
+	assert.NoError(t, err)
+	duplicateInjector, err := fault.NewDuplicateRequestInjector(fault.WithDuplicates(2))
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name      string
+		give      fault.Injector
+		wantCode  int
+		wantBody  string
+		wantCalls int
+	}{
+		{
+			name:      "not injected",
+			give:      disabled,
+			wantCode:  http.StatusCreated,
+			wantBody:  "handler ",
+			wantCalls: 1,
+		},
+		{
+			name:      "injected",
+			give:      enabled,
+			wantCode:  http.StatusServiceUnavailable,
+			wantBody:  http.StatusText(http.StatusServiceUnavailable) + "\n",
+			wantCalls: 0,
+		},
+		{
+			name:      "context and writer",
+			give:      testContextInjector,
+			wantCode:  http.StatusCreated,
+			wantBody:  "HANDLER VALUE",
+			wantCalls: 1,
+		},
+		{
+			name:      "run more than once",
+			give:      duplicateInjector,
+			wantCode:  http.StatusCreated,
+			wantBody:  "handler ",
+			wantCalls: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var calls int
+			rr := httptest.NewRecorder()
+			testEngine(tt.give, &calls).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			assert.Equal(t, tt.wantCode, rr.Code)
+			assert.Equal(t, tt.wantBody, rr.Body.String())
+			assert.Equal(t, tt.wantCalls, calls)
+		})
+	}
+}
+
+// TestMiddlewareRestores tests that middleware before Middleware sees its own request and writer
+// once the chain returns.
+func TestMiddlewareRestores(t *testing.T) {
+	t.Parallel()
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		writer, req := c.Writer, c.Request
+		c.Next()
+		assert.Equal(t, writer, c.Writer)
+		assert.Equal(t, req, c.Request)
+		assert.Equal(t, http.StatusAccepted, c.Writer.Status())
+	}, Middleware(testContextInjector))
+	r.GET("/", func(c *gin.Context) {
+		assert.Equal(t, "value", c.Request.Context().Value(testKey{}))
+		assert.IsType(t, &responseWriter{}, c.Writer)
+		c.Status(http.StatusAccepted)
+	})
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusAccepted, rr.Code)
+}
+
+// TestMiddlewareReject tests that a RejectInjector drops the connection instead of being recovered
+// into a 500.
+func TestMiddlewareReject(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		give []fault.RejectInjectorOption
+	}{
+		{
+			name: "abort",
+			give: []fault.RejectInjectorOption{fault.WithCloseType(fault.CloseAbort)},
+		},
+		{
+			name: "reset",
+			give: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ri, err := fault.NewRejectInjector(tt.give...)
+			assert.NoError(t, err)
+
+			var calls int
+			srv := httptest.NewServer(testEngine(ri, &calls))
+			defer srv.Close()
+
+			resp, err := srv.Client().Get(srv.URL)
+			if err == nil {
+				resp.Body.Close()
+			}
+			assert.Error(t, err)
+			assert.Equal(t, 0, calls)
+		})
+	}
+}
+
+// TestMiddlewarePanic tests that Middleware passes on panics other than http.ErrAbortHandler, and
+// aborts that cannot drop the connection.
+func TestMiddlewarePanic(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		give interface{}
+	}{
+		{
+			name: "panic",
+			give: "boom",
+		},
+		{
+			name: "abort without hijacker",
+			give: http.ErrAbortHandler,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			i := testInjectorFunc(func(next http.Handler) http.Handler {
+				return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					panic(tt.give)
+				})
+			})
+
+			var calls int
+			rr := httptest.NewRecorder()
+			testEngine(i, &calls).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			assert.Equal(t, http.StatusInternalServerError, rr.Code)
+			assert.Equal(t, 0, calls)
+		})
+	}
+}
+
+// TestResponseWriter tests the gin.ResponseWriter made for a wrapped http.ResponseWriter.
+func TestResponseWriter(t *testing.T) {
+	t.Parallel()
+
+	rr := httptest.NewRecorder()
+	w := &responseWriter{ResponseWriter: rr, status: http.StatusOK, size: noWritten}
+
+	assert.False(t, w.Written())
+	assert.Equal(t, -1, w.Size())
+	assert.Nil(t, w.Pusher())
+	assert.NotNil(t, w.CloseNotify())
+	assert.Equal(t, rr, w.Unwrap())
+
+	_, _, err := w.Hijack()
+	assert.True(t, errors.Is(err, http.ErrNotSupported), err)
+	assert.True(t, w.Written())
+
+	w = &responseWriter{ResponseWriter: rr, status: http.StatusOK, size: noWritten}
+	w.WriteHeader(http.StatusTeapot)
+	n, err := w.WriteString("short")
+	assert.NoError(t, err)
+	assert.Equal(t, 5, n)
+	w.WriteHeader(http.StatusOK)
+	n, err = w.Write([]byte(" and stout"))
+	assert.NoError(t, err)
+	assert.Equal(t, 10, n)
+	w.Flush()
+
+	assert.Equal(t, http.StatusTeapot, w.Status())
+	assert.Equal(t, 15, w.Size())
+	assert.Equal(t, http.StatusTeapot, rr.Code)
+	assert.Equal(t, "short and stout", rr.Body.String())
+	assert.True(t, rr.Flushed)
+
+	body, err := io.ReadAll(rr.Result().Body)
+	assert.NoError(t, err)
+	assert.Equal(t, "short and stout", string(body))
+}
+
+// testPushWriter is an http.ResponseWriter that is an http.Pusher and an http.CloseNotifier.
+type testPushWriter struct {
+	http.ResponseWriter
+	closed chan bool
+}
+
+// Push does nothing.
+func (w testPushWriter) Push(target string, opts *http.PushOptions) error {
+	return nil
+}
+
+// CloseNotify returns the closed channel.
+func (w testPushWriter) CloseNotify() <-chan bool {
+	return w.closed
+}
+
+// TestResponseWriterPusher tests that the gin.ResponseWriter passes on http.Pusher and
+// http.CloseNotifier.
+func TestResponseWriterPusher(t *testing.T) {
+	t.Parallel()
+
+	pw := testPushWriter{ResponseWriter: httptest.NewRecorder(), closed: make(chan bool)}
+	w := &responseWriter{ResponseWriter: pw, status: http.StatusOK, size: noWritten}
+
+	assert.Equal(t, pw, w.Pusher())
+	assert.Equal(t, (<-chan bool)(pw.closed), w.CloseNotify())
+}
@@ -0,0 +1,53 @@
+/*
+Package faultgrpc injects faults into gRPC servers.
+
+Interceptors
+
+UnaryServerInterceptor and StreamServerInterceptor run a fault.Fault, a fault.Manager, or any
+fault.Injector on gRPC calls, so the same Faults protect HTTP and gRPC services. Each call is seen
+by the Injectors as the HTTP request gRPC sends for it: a POST to the full method name, such as
+"/pkg.Svc/Method", with the metadata of the call as headers. Path and header allowlists and
+blocklists work on calls the same way.
+
+    f, _ := fault.NewFault(si,
+        fault.WithEnabled(true),
+        fault.WithParticipation(0.05),
+        fault.WithPathAllowlist([]string{"/pkg.Orders/Create"}),
+    )
+    srv := grpc.NewServer(
+        grpc.ChainUnaryInterceptor(faultgrpc.UnaryServerInterceptor(f)),
+        grpc.ChainStreamInterceptor(faultgrpc.StreamServerInterceptor(f)),
+    )
+
+Use a StatusInjector to fail calls with a gRPC code such as UNAVAILABLE or DEADLINE_EXCEEDED:
+
+    si, _ := faultgrpc.NewStatusInjector(codes.Unavailable)
+
+Other Injectors work as they do on HTTP requests. A SlowInjector delays the call, and the call
+fails with the status of its context if the client gave up while it waited. A RejectInjector fails
+the call with UNAVAILABLE, like a dropped connection. An ErrorInjector fails it with the code a gRPC
+client gives an HTTP error from a proxy, so a 503 becomes UNAVAILABLE. Injectors that change the
+response after the call runs, such as a CharsetInjector, have no effect on gRPC calls.
+
+Health Checks
+
+Load balancers that health check over gRPC with the grpc.health.v1 protocol decide where to send
+traffic from the serving status of each server. Use a HealthInjector to flip that status, or force
+it, and to delay health check responses, so failover can be exercised without taking a server down.
+Install its interceptors on the server that registers the Health service:
+
+    hi, _ := faultgrpc.NewHealthInjector(
+        faultgrpc.WithEnabled(true),
+        faultgrpc.WithServingStatus(healthpb.HealthCheckResponse_NOT_SERVING),
+    )
+    srv := grpc.NewServer(
+        grpc.ChainUnaryInterceptor(hi.UnaryServerInterceptor()),
+        grpc.ChainStreamInterceptor(hi.StreamServerInterceptor()),
+    )
+    healthpb.RegisterHealthServer(srv, health.NewServer())
+
+Call SetEnabled to start and stop the fault while the server is running. Only the Check and Watch
+methods of the Health service are changed; every other call passes through.
+
+*/
+package faultgrpc
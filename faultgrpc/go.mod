@@ -0,0 +1,23 @@
+module github.com/github/go-fault/faultgrpc
+
+go 1.22
+
+require (
+	github.com/github/go-fault v0.0.0
+	github.com/stretchr/testify v1.5.1
+	google.golang.org/grpc v1.58.3
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.0 // indirect
+	github.com/golang/protobuf v1.5.3 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	golang.org/x/net v0.22.0 // indirect
+	golang.org/x/sys v0.18.0 // indirect
+	golang.org/x/text v0.14.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20240318140521-94a12d6c2237 // indirect
+	google.golang.org/protobuf v1.33.0 // indirect
+	gopkg.in/yaml.v2 v2.2.2 // indirect
+)
+
+replace github.com/github/go-fault => ../
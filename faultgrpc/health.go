@@ -0,0 +1,334 @@
+package faultgrpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/github/go-fault"
+	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// healthCheckMethod is the full name of the Check method of the Health service.
+	healthCheckMethod = "/grpc.health.v1.Health/Check"
+	// healthWatchMethod is the full name of the Watch method of the Health service.
+	healthWatchMethod = "/grpc.health.v1.Health/Watch"
+)
+
+var (
+	// ErrInvalidServingStatus when a serving status that is not defined by grpc.health.v1 is passed.
+	ErrInvalidServingStatus = errors.New("invalid serving status")
+	// ErrInvalidDelay when a negative delay is passed.
+	ErrInvalidDelay = errors.New("delay must be >= 0")
+)
+
+// HealthInjector changes the responses of the grpc.health.v1 Health service. By default it flips
+// SERVING to NOT_SERVING and NOT_SERVING to SERVING, leaving other statuses alone.
+type HealthInjector struct {
+	enabled  atomic.Bool
+	status   healthpb.HealthCheckResponse_ServingStatus
+	force    bool
+	delay    time.Duration
+	services map[string]bool
+	clock    fault.Clock
+	reporter fault.Reporter
+}
+
+// HealthInjectorOption configures a HealthInjector.
+type HealthInjectorOption interface {
+	applyHealthInjector(i *HealthInjector) error
+}
+
+type enabledOption bool
+
+func (o enabledOption) applyHealthInjector(i *HealthInjector) error {
+	i.enabled.Store(bool(o))
+	return nil
+}
+
+// WithEnabled sets if the HealthInjector changes responses. Default false.
+func WithEnabled(e bool) HealthInjectorOption {
+	return enabledOption(e)
+}
+
+type servingStatusOption healthpb.HealthCheckResponse_ServingStatus
+
+func (o servingStatusOption) applyHealthInjector(i *HealthInjector) error {
+	if _, ok := healthpb.HealthCheckResponse_ServingStatus_name[int32(o)]; !ok {
+		return ErrInvalidServingStatus
+	}
+
+	i.status = healthpb.HealthCheckResponse_ServingStatus(o)
+	i.force = true
+
+	return nil
+}
+
+// WithServingStatus sets the status every health check responds with instead of flipping it.
+func WithServingStatus(s healthpb.HealthCheckResponse_ServingStatus) HealthInjectorOption {
+	return servingStatusOption(s)
+}
+
+type delayOption time.Duration
+
+func (o delayOption) applyHealthInjector(i *HealthInjector) error {
+	if o < 0 {
+		return ErrInvalidDelay
+	}
+
+	i.delay = time.Duration(o)
+
+	return nil
+}
+
+// WithDelay sets how long each health check response is delayed, such as a little past the
+// timeout of the health checker. Default 0.
+func WithDelay(d time.Duration) HealthInjectorOption {
+	return delayOption(d)
+}
+
+type servicesOption []string
+
+func (o servicesOption) applyHealthInjector(i *HealthInjector) error {
+	i.services = make(map[string]bool, len(o))
+	for _, s := range o {
+		i.services[s] = true
+	}
+
+	return nil
+}
+
+// WithServices limits the HealthInjector to health checks of the named services. The empty name is
+// the health of the whole server. Default every service.
+func WithServices(services ...string) HealthInjectorOption {
+	return servicesOption(services)
+}
+
+type clockOption struct {
+	clock fault.Clock
+}
+
+func (o clockOption) applyHealthInjector(i *HealthInjector) error {
+	i.clock = o.clock
+	return nil
+}
+
+// WithClock sets the Clock used to delay responses. Default fault.RealClock.
+func WithClock(c fault.Clock) HealthInjectorOption {
+	return clockOption{c}
+}
+
+type reporterOption struct {
+	reporter fault.Reporter
+}
+
+func (o reporterOption) applyHealthInjector(i *HealthInjector) error {
+	i.reporter = o.reporter
+	return nil
+}
+
+// ReporterOption configures Injectors that accept a Reporter.
+type ReporterOption interface {
+	HealthInjectorOption
+	StatusInjectorOption
+}
+
+// WithReporter sets the Reporter. Default fault.NoopReporter.
+func WithReporter(r fault.Reporter) ReporterOption {
+	return reporterOption{r}
+}
+
+// NewHealthInjector returns a HealthInjector.
+func NewHealthInjector(opts ...HealthInjectorOption) (*HealthInjector, error) {
+	// set defaults
+	hi := &HealthInjector{
+		clock:    fault.NewRealClock(),
+		reporter: fault.NewNoopReporter(),
+	}
+
+	// apply options
+	for _, opt := range opts {
+		err := opt.applyHealthInjector(hi)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return hi, nil
+}
+
+// SetEnabled sets if the HealthInjector changes responses. It is safe to call while the server is
+// running.
+func (i *HealthInjector) SetEnabled(e bool) {
+	i.enabled.Store(e)
+}
+
+// UnaryServerInterceptor returns an interceptor that changes the responses of the Check method.
+func (i *HealthInjector) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler) (interface{}, error) {
+		hr, ok := req.(*healthpb.HealthCheckRequest)
+		if info.FullMethod != healthCheckMethod || !ok || !i.applies(hr.GetService()) {
+			return handler(ctx, req)
+		}
+
+		go i.reporter.Report(i.String(), fault.StateStarted)
+		defer func() { go i.reporter.Report(i.String(), fault.StateFinished) }()
+
+		err := i.wait(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := handler(ctx, req)
+		if err != nil {
+			return resp, err
+		}
+
+		return i.response(resp), nil
+	}
+}
+
+// StreamServerInterceptor returns an interceptor that changes the responses of the Watch method.
+func (i *HealthInjector) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if info.FullMethod != healthWatchMethod {
+			return handler(srv, ss)
+		}
+
+		return handler(srv, &healthStream{ServerStream: ss, injector: i})
+	}
+}
+
+// applies returns true if the HealthInjector is enabled and changes the health of service.
+func (i *HealthInjector) applies(service string) bool {
+	return i.enabled.Load() && (i.services == nil || i.services[service])
+}
+
+// wait waits for the delay, returning the status error of ctx if it is done first.
+func (i *HealthInjector) wait(ctx context.Context) error {
+	if i.delay == 0 {
+		return nil
+	}
+
+	select {
+	case <-ctx.Done():
+		return status.FromContextError(ctx.Err()).Err()
+	case <-i.clock.After(i.delay):
+		return nil
+	}
+}
+
+// response returns the changed response for m if m is a HealthCheckResponse, and m otherwise.
+func (i *HealthInjector) response(m interface{}) interface{} {
+	resp, ok := m.(*healthpb.HealthCheckResponse)
+	if !ok {
+		return m
+	}
+
+	s := resp.GetStatus()
+	switch {
+	case i.force:
+		s = i.status
+	case s == healthpb.HealthCheckResponse_SERVING:
+		s = healthpb.HealthCheckResponse_NOT_SERVING
+	case s == healthpb.HealthCheckResponse_NOT_SERVING:
+		s = healthpb.HealthCheckResponse_SERVING
+	}
+
+	return &healthpb.HealthCheckResponse{Status: s}
+}
+
+// Reporter returns the Reporter of the HealthInjector.
+func (i *HealthInjector) Reporter() fault.Reporter {
+	return i.reporter
+}
+
+// SetReporter replaces the Reporter of the HealthInjector.
+func (i *HealthInjector) SetReporter(r fault.Reporter) {
+	i.reporter = r
+}
+
+// Name returns "grpc_health".
+func (i *HealthInjector) Name() string {
+	return "grpc_health"
+}
+
+// Describe returns the status, delay, and services of the HealthInjector.
+func (i *HealthInjector) Describe() map[string]string {
+	return map[string]string{
+		"status":   i.statusString(),
+		"delay":    i.delay.String(),
+		"services": i.servicesString(),
+	}
+}
+
+// String returns a summary of the HealthInjector, such as "grpc_health(NOT_SERVING, 5s)".
+func (i *HealthInjector) String() string {
+	return fmt.Sprintf("%s(%s, %s)", i.Name(), i.statusString(), i.delay)
+}
+
+// statusString returns the forced status or "flip".
+func (i *HealthInjector) statusString() string {
+	if i.force {
+		return i.status.String()
+	}
+
+	return "flip"
+}
+
+// servicesString returns the sorted services, or "*" for every service.
+func (i *HealthInjector) servicesString() string {
+	if i.services == nil {
+		return "*"
+	}
+
+	services := make([]string, 0, len(i.services))
+	for s := range i.services {
+		services = append(services, fmt.Sprintf("%q", s))
+	}
+	sort.Strings(services)
+
+	return strings.Join(services, ", ")
+}
+
+// healthStream changes the responses of a Watch call.
+type healthStream struct {
+	grpc.ServerStream
+	injector *HealthInjector
+	service  string
+}
+
+// RecvMsg receives the request and remembers its service.
+func (s *healthStream) RecvMsg(m interface{}) error {
+	err := s.ServerStream.RecvMsg(m)
+	if hr, ok := m.(*healthpb.HealthCheckRequest); ok && err == nil {
+		s.service = hr.GetService()
+	}
+
+	return err
+}
+
+// SendMsg delays and changes m if the HealthInjector applies to the service being watched.
+func (s *healthStream) SendMsg(m interface{}) error {
+	if !s.injector.applies(s.service) {
+		return s.ServerStream.SendMsg(m)
+	}
+
+	go s.injector.reporter.Report(s.injector.String(), fault.StateStarted)
+	defer func() { go s.injector.reporter.Report(s.injector.String(), fault.StateFinished) }()
+
+	err := s.injector.wait(s.Context())
+	if err != nil {
+		return err
+	}
+
+	return s.ServerStream.SendMsg(s.injector.response(m))
+}
@@ -0,0 +1,380 @@
+package faultgrpc
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/github/go-fault"
+	"github.com/github/go-fault/faulttest"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// testBufSize is the buffer size of the in-memory connection to the test server.
+const testBufSize = 1 << 20
+
+// testReporter is a Reporter that sends states to a channel.
+type testReporter struct {
+	states chan fault.InjectorState
+}
+
+// Report sends state to r.states.
+func (r *testReporter) Report(name string, state fault.InjectorState) {
+	r.states <- state
+}
+
+// testHealthClient starts a server with the Health service and hi's interceptors and returns a
+// client for it. The server reports "" and "svc" as SERVING.
+func testHealthClient(t *testing.T, hi *HealthInjector) (healthpb.HealthClient, *health.Server) {
+	t.Helper()
+
+	return testHealthServer(t,
+		grpc.ChainUnaryInterceptor(hi.UnaryServerInterceptor()),
+		grpc.ChainStreamInterceptor(hi.StreamServerInterceptor()),
+	)
+}
+
+// testHealthServer starts a server with the Health service and opts and returns a client for it.
+// The server reports "" and "svc" as SERVING.
+func testHealthServer(t *testing.T, opts ...grpc.ServerOption) (healthpb.HealthClient, *health.Server) {
+	t.Helper()
+
+	hs := health.NewServer()
+	hs.SetServingStatus("svc", healthpb.HealthCheckResponse_SERVING)
+
+	srv := grpc.NewServer(opts...)
+	healthpb.RegisterHealthServer(srv, hs)
+
+	ln := bufconn.Listen(testBufSize)
+	go func() { _ = srv.Serve(ln) }()
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.DialContext(context.Background(), "bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return ln.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	assert.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	return healthpb.NewHealthClient(conn), hs
+}
+
+// TestNewHealthInjector tests NewHealthInjector.
+func TestNewHealthInjector(t *testing.T) {
+	t.Parallel()
+
+	clock := faulttest.NewClock(time.Time{})
+	reporter := &testReporter{}
+
+	tests := []struct {
+		name         string
+		giveOptions  []HealthInjectorOption
+		wantEnabled  bool
+		wantStatus   healthpb.HealthCheckResponse_ServingStatus
+		wantForce    bool
+		wantDelay    time.Duration
+		wantServices map[string]bool
+		wantClock    fault.Clock
+		wantReporter fault.Reporter
+		wantErr      error
+	}{
+		{
+			name:         "defaults",
+			wantClock:    fault.NewRealClock(),
+			wantReporter: fault.NewNoopReporter(),
+		},
+		{
+			name: "options",
+			giveOptions: []HealthInjectorOption{
+				WithEnabled(true),
+				WithServingStatus(healthpb.HealthCheckResponse_SERVICE_UNKNOWN),
+				WithDelay(time.Second),
+				WithServices("", "svc"),
+				WithClock(clock),
+				WithReporter(reporter),
+			},
+			wantEnabled:  true,
+			wantStatus:   healthpb.HealthCheckResponse_SERVICE_UNKNOWN,
+			wantForce:    true,
+			wantDelay:    time.Second,
+			wantServices: map[string]bool{"": true, "svc": true},
+			wantClock:    clock,
+			wantReporter: reporter,
+		},
+		{
+			name: "invalid status",
+			giveOptions: []HealthInjectorOption{
+				WithServingStatus(healthpb.HealthCheckResponse_ServingStatus(99)),
+			},
+			wantErr: ErrInvalidServingStatus,
+		},
+		{
+			name: "invalid delay",
+			giveOptions: []HealthInjectorOption{
+				WithDelay(-time.Second),
+			},
+			wantErr: ErrInvalidDelay,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			hi, err := NewHealthInjector(tt.giveOptions...)
+
+			assert.Equal(t, tt.wantErr, err)
+			if tt.wantErr != nil {
+				assert.Nil(t, hi)
+				return
+			}
+
+			assert.Equal(t, tt.wantEnabled, hi.enabled.Load())
+			assert.Equal(t, tt.wantStatus, hi.status)
+			assert.Equal(t, tt.wantForce, hi.force)
+			assert.Equal(t, tt.wantDelay, hi.delay)
+			assert.Equal(t, tt.wantServices, hi.services)
+			assert.Equal(t, tt.wantClock, hi.clock)
+			assert.Equal(t, tt.wantReporter, hi.Reporter())
+		})
+	}
+}
+
+// TestHealthInjectorCheck tests that the HealthInjector changes the responses of Check.
+func TestHealthInjectorCheck(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		giveOptions []HealthInjectorOption
+		giveService string
+		giveStatus  healthpb.HealthCheckResponse_ServingStatus
+		wantStatus  healthpb.HealthCheckResponse_ServingStatus
+		wantCode    codes.Code
+	}{
+		{
+			name:        "disabled",
+			giveOptions: []HealthInjectorOption{WithEnabled(false)},
+			giveService: "svc",
+			giveStatus:  healthpb.HealthCheckResponse_SERVING,
+			wantStatus:  healthpb.HealthCheckResponse_SERVING,
+		},
+		{
+			name:        "flip serving",
+			giveOptions: []HealthInjectorOption{WithEnabled(true)},
+			giveService: "svc",
+			giveStatus:  healthpb.HealthCheckResponse_SERVING,
+			wantStatus:  healthpb.HealthCheckResponse_NOT_SERVING,
+		},
+		{
+			name:        "flip not serving",
+			giveOptions: []HealthInjectorOption{WithEnabled(true)},
+			giveService: "svc",
+			giveStatus:  healthpb.HealthCheckResponse_NOT_SERVING,
+			wantStatus:  healthpb.HealthCheckResponse_SERVING,
+		},
+		{
+			name:        "flip unknown",
+			giveOptions: []HealthInjectorOption{WithEnabled(true)},
+			giveService: "svc",
+			giveStatus:  healthpb.HealthCheckResponse_SERVICE_UNKNOWN,
+			wantStatus:  healthpb.HealthCheckResponse_SERVICE_UNKNOWN,
+		},
+		{
+			name: "forced status",
+			giveOptions: []HealthInjectorOption{
+				WithEnabled(true),
+				WithServingStatus(healthpb.HealthCheckResponse_UNKNOWN),
+			},
+			giveService: "svc",
+			giveStatus:  healthpb.HealthCheckResponse_SERVING,
+			wantStatus:  healthpb.HealthCheckResponse_UNKNOWN,
+		},
+		{
+			name: "other service",
+			giveOptions: []HealthInjectorOption{
+				WithEnabled(true),
+				WithServices(""),
+			},
+			giveService: "svc",
+			giveStatus:  healthpb.HealthCheckResponse_SERVING,
+			wantStatus:  healthpb.HealthCheckResponse_SERVING,
+		},
+		{
+			name:        "unregistered service",
+			giveOptions: []HealthInjectorOption{WithEnabled(true)},
+			giveService: "missing",
+			wantCode:    codes.NotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			hi, err := NewHealthInjector(tt.giveOptions...)
+			assert.NoError(t, err)
+
+			client, hs := testHealthClient(t, hi)
+			hs.SetServingStatus("svc", tt.giveStatus)
+
+			resp, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: tt.giveService})
+
+			assert.Equal(t, tt.wantCode, status.Code(err))
+			assert.Equal(t, tt.wantStatus, resp.GetStatus())
+		})
+	}
+}
+
+// TestHealthInjectorCheckDelay tests that the HealthInjector delays Check until the delay passes or
+// the call is canceled.
+func TestHealthInjectorCheckDelay(t *testing.T) {
+	t.Parallel()
+
+	clock := faulttest.NewClock(time.Time{})
+	reporter := &testReporter{states: make(chan fault.InjectorState, 2)}
+	hi, err := NewHealthInjector(
+		WithEnabled(true),
+		WithDelay(time.Minute),
+		WithClock(clock),
+		WithReporter(reporter),
+	)
+	assert.NoError(t, err)
+
+	client, _ := testHealthClient(t, hi)
+
+	done := make(chan *healthpb.HealthCheckResponse)
+	go func() {
+		resp, _ := client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: "svc"})
+		done <- resp
+	}()
+
+	clock.BlockUntil(1)
+	clock.Advance(time.Minute)
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, (<-done).GetStatus())
+	assert.ElementsMatch(t, []fault.InjectorState{fault.StateStarted, fault.StateFinished},
+		[]fault.InjectorState{<-reporter.states, <-reporter.states})
+
+	// a canceled call does not wait
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		clock.BlockUntil(1)
+		cancel()
+	}()
+	_, err = client.Check(ctx, &healthpb.HealthCheckRequest{Service: "svc"})
+	assert.Equal(t, codes.Canceled, status.Code(err))
+}
+
+// TestHealthInjectorWatch tests that the HealthInjector delays and changes the responses of Watch.
+func TestHealthInjectorWatch(t *testing.T) {
+	t.Parallel()
+
+	clock := faulttest.NewClock(time.Time{})
+	reporter := &testReporter{states: make(chan fault.InjectorState, 16)}
+	hi, err := NewHealthInjector(
+		WithDelay(time.Second),
+		WithServices("svc"),
+		WithClock(clock),
+		WithReporter(reporter),
+	)
+	assert.NoError(t, err)
+
+	client, hs := testHealthClient(t, hi)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// disabled, the status passes through
+	stream, err := client.Watch(ctx, &healthpb.HealthCheckRequest{Service: "svc"})
+	assert.NoError(t, err)
+	resp, err := stream.Recv()
+	assert.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.GetStatus())
+
+	// enabled, each update is delayed and flipped
+	hi.SetEnabled(true)
+	hs.SetServingStatus("svc", healthpb.HealthCheckResponse_NOT_SERVING)
+	clock.BlockUntil(1)
+	clock.Advance(time.Second)
+	resp, err = stream.Recv()
+	assert.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.GetStatus())
+
+	// the watch ends if canceled while an update is delayed
+	hs.SetServingStatus("svc", healthpb.HealthCheckResponse_SERVING)
+	clock.BlockUntil(1)
+	cancel()
+	_, err = stream.Recv()
+	assert.Equal(t, codes.Canceled, status.Code(err))
+}
+
+// TestHealthInjectorPassThrough tests that calls other than Check and Watch pass through.
+func TestHealthInjectorPassThrough(t *testing.T) {
+	t.Parallel()
+
+	hi, err := NewHealthInjector(WithEnabled(true))
+	assert.NoError(t, err)
+
+	unary := hi.UnaryServerInterceptor()
+	resp, err := unary(context.Background(), "req", &grpc.UnaryServerInfo{FullMethod: healthCheckMethod},
+		func(ctx context.Context, req interface{}) (interface{}, error) { return req, nil })
+	assert.NoError(t, err)
+	assert.Equal(t, "req", resp)
+
+	resp, err = unary(context.Background(), &healthpb.HealthCheckRequest{}, &grpc.UnaryServerInfo{FullMethod: "/pkg.Svc/Method"},
+		func(ctx context.Context, req interface{}) (interface{}, error) { return "other", nil })
+	assert.NoError(t, err)
+	assert.Equal(t, "other", resp)
+
+	called := false
+	stream := hi.StreamServerInterceptor()
+	err = stream(nil, nil, &grpc.StreamServerInfo{FullMethod: "/pkg.Svc/Stream"},
+		func(srv interface{}, ss grpc.ServerStream) error {
+			called = true
+			return nil
+		})
+	assert.NoError(t, err)
+	assert.True(t, called)
+
+	assert.Equal(t, "resp", hi.response("resp"))
+}
+
+// TestHealthInjectorDescribe tests the Describer and ReporterSetter methods of HealthInjector.
+func TestHealthInjectorDescribe(t *testing.T) {
+	t.Parallel()
+
+	flip, err := NewHealthInjector()
+	assert.NoError(t, err)
+	assert.Equal(t, "grpc_health", flip.Name())
+	assert.Equal(t, "grpc_health(flip, 0s)", flip.String())
+	assert.Equal(t, map[string]string{"status": "flip", "delay": "0s", "services": "*"}, flip.Describe())
+
+	forced, err := NewHealthInjector(
+		WithServingStatus(healthpb.HealthCheckResponse_NOT_SERVING),
+		WithDelay(5*time.Second),
+		WithServices("svc", ""),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, "grpc_health(NOT_SERVING, 5s)", forced.String())
+	assert.Equal(t, map[string]string{
+		"status":   "NOT_SERVING",
+		"delay":    "5s",
+		"services": `"", "svc"`,
+	}, forced.Describe())
+
+	reporter := &testReporter{}
+	forced.SetReporter(reporter)
+	assert.Equal(t, reporter, forced.Reporter())
+}
@@ -0,0 +1,202 @@
+package faultgrpc
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/github/go-fault"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryServerInterceptor returns an interceptor that runs i on unary calls. Pass it a fault.Fault, a
+// fault.Manager, or any fault.Injector.
+func UnaryServerInterceptor(i fault.Injector) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler) (interface{}, error) {
+		var resp interface{}
+		err := run(ctx, i, info.FullMethod, func(ctx context.Context) error {
+			var err error
+			resp, err = handler(ctx, req)
+			return err
+		})
+
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor returns an interceptor that runs i on streaming calls. Pass it a
+// fault.Fault, a fault.Manager, or any fault.Injector.
+func StreamServerInterceptor(i fault.Injector) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return run(ss.Context(), i, info.FullMethod, func(ctx context.Context) error {
+			return handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+		})
+	}
+}
+
+// run runs i on a call to method as if it were an HTTP request, and calls next with the context of
+// the request if i continues it. It returns the error of next, or the error of the response i
+// wrote instead.
+func run(ctx context.Context, i fault.Injector, method string, next func(ctx context.Context) error) error {
+	var called bool
+	var nextErr error
+	h := i.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+
+		// an Injector may have held the call past its deadline
+		if err := r.Context().Err(); err != nil {
+			nextErr = status.FromContextError(err).Err()
+			return
+		}
+
+		nextErr = next(r.Context())
+	}))
+
+	cw := &callWriter{header: make(http.Header)}
+	if serve(h, cw, newCallRequest(ctx, method)) {
+		return status.Error(codes.Unavailable, "connection reset by fault injection")
+	}
+	if called {
+		return nextErr
+	}
+
+	return cw.err()
+}
+
+// serve runs h and returns true if it aborted with http.ErrAbortHandler, the way a RejectInjector
+// drops a connection. Other panics are not recovered.
+func serve(h http.Handler, w http.ResponseWriter, r *http.Request) (aborted bool) {
+	defer func() {
+		if v := recover(); v != nil {
+			if v != http.ErrAbortHandler { //nolint:errorlint
+				panic(v)
+			}
+			aborted = true
+		}
+	}()
+
+	h.ServeHTTP(w, r)
+
+	return false
+}
+
+// newCallRequest returns the HTTP request that gRPC sends for a call to method: a POST to the full
+// method name with the metadata of ctx as headers.
+func newCallRequest(ctx context.Context, method string) *http.Request {
+	r := &http.Request{
+		Method:     http.MethodPost,
+		URL:        &url.URL{Path: method},
+		Proto:      "HTTP/2.0",
+		ProtoMajor: 2,
+		Header:     http.Header{"Content-Type": {"application/grpc"}},
+		Body:       http.NoBody,
+		RequestURI: method,
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	for k, vs := range md {
+		if k == ":authority" && len(vs) > 0 {
+			r.Host = vs[0]
+		}
+		if strings.HasPrefix(k, ":") {
+			continue
+		}
+		for _, v := range vs {
+			r.Header.Add(k, v)
+		}
+	}
+
+	return r.WithContext(ctx)
+}
+
+// callWriter records the response an Injector writes instead of running a call.
+type callWriter struct {
+	header http.Header
+	code   int
+	body   bytes.Buffer
+}
+
+// Header returns the response headers.
+func (w *callWriter) Header() http.Header {
+	return w.header
+}
+
+// WriteHeader records the first status code.
+func (w *callWriter) WriteHeader(code int) {
+	if w.code == 0 {
+		w.code = code
+	}
+}
+
+// Write records the body.
+func (w *callWriter) Write(p []byte) (int, error) {
+	w.WriteHeader(http.StatusOK)
+	return w.body.Write(p)
+}
+
+// err returns the error a gRPC client sees for the response. A Grpc-Status header is used as is,
+// and other responses are mapped from their HTTP status code like a client does for a response
+// from a proxy.
+func (w *callWriter) err() error {
+	if s := w.header.Get("Grpc-Status"); s != "" {
+		c, err := strconv.Atoi(s)
+		if err != nil {
+			c = int(codes.Unknown)
+		}
+
+		msg := w.header.Get("Grpc-Message")
+		if m, err := url.PathUnescape(msg); err == nil {
+			msg = m
+		}
+
+		return status.Error(codes.Code(c), msg)
+	}
+
+	code := w.code
+	if code == 0 {
+		code = http.StatusOK
+	}
+
+	msg := strings.TrimSpace(w.body.String())
+	if msg == "" {
+		msg = http.StatusText(code)
+	}
+
+	return status.Error(httpStatusCode(code), msg)
+}
+
+// httpStatusCode returns the gRPC code of an HTTP status code, as mapped by gRPC clients.
+func httpStatusCode(code int) codes.Code {
+	switch code {
+	case http.StatusBadRequest:
+		return codes.Internal
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.Unimplemented
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return codes.Unavailable
+	default:
+		return codes.Unknown
+	}
+}
+
+// contextStream is a ServerStream with the context of the request an Injector continued.
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+// Context returns the context of the request.
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
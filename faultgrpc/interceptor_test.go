@@ -0,0 +1,296 @@
+package faultgrpc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/github/go-fault"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// testInjectorFunc is a fault.Injector made from a function.
+type testInjectorFunc func(next http.Handler) http.Handler
+
+// Handler calls the function.
+func (f testInjectorFunc) Handler(next http.Handler) http.Handler {
+	return f(next)
+}
+
+// testResponder returns a fault.Injector that calls respond instead of the next handler.
+func testResponder(respond func(w http.ResponseWriter)) fault.Injector {
+	return testInjectorFunc(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			respond(w)
+		})
+	})
+}
+
+// testServerStream is a ServerStream with a context.
+type testServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+// Context returns the context of the stream.
+func (s *testServerStream) Context() context.Context {
+	return s.ctx
+}
+
+// TestUnaryServerInterceptor tests UnaryServerInterceptor.
+func TestUnaryServerInterceptor(t *testing.T) {
+	t.Parallel()
+
+	errorInjector, err := fault.NewErrorInjector(http.StatusServiceUnavailable)
+	assert.NoError(t, err)
+	teapotInjector, err := fault.NewErrorInjector(http.StatusTeapot, fault.WithStatusText("short and stout"))
+	assert.NoError(t, err)
+	rejectInjector, err := fault.NewRejectInjector()
+	assert.NoError(t, err)
+	statusInjector, err := NewStatusInjector(codes.DeadlineExceeded, WithStatusMessage("too slow: 100%"))
+	assert.NoError(t, err)
+	disabled, err := fault.NewFault(errorInjector, fault.WithEnabled(false))
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name        string
+		give        fault.Injector
+		giveContext context.Context
+		wantResp    interface{}
+		wantCode    codes.Code
+		wantMessage string
+	}{
+		{
+			name:     "disabled fault",
+			give:     disabled,
+			wantResp: "resp",
+		},
+		{
+			name:        "error injector",
+			give:        errorInjector,
+			wantCode:    codes.Unavailable,
+			wantMessage: "Service Unavailable",
+		},
+		{
+			name:        "unmapped error",
+			give:        teapotInjector,
+			wantCode:    codes.Unknown,
+			wantMessage: "short and stout",
+		},
+		{
+			name:        "reject injector",
+			give:        rejectInjector,
+			wantCode:    codes.Unavailable,
+			wantMessage: "connection reset by fault injection",
+		},
+		{
+			name:        "status injector",
+			give:        statusInjector,
+			wantCode:    codes.DeadlineExceeded,
+			wantMessage: "too slow: 100%",
+		},
+		{
+			name:        "empty response",
+			give:        testResponder(func(w http.ResponseWriter) {}),
+			wantCode:    codes.Unknown,
+			wantMessage: "OK",
+		},
+		{
+			name: "invalid grpc status",
+			give: testResponder(func(w http.ResponseWriter) {
+				w.Header().Set("Grpc-Status", "unavailable")
+				w.Header().Set("Grpc-Message", "bad %zz")
+			}),
+			wantCode:    codes.Unknown,
+			wantMessage: "bad %zz",
+		},
+		{
+			name:        "done before the call",
+			give:        disabled,
+			giveContext: ctx,
+			wantCode:    codes.Canceled,
+			wantMessage: "context canceled",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := tt.giveContext
+			if ctx == nil {
+				ctx = context.Background()
+			}
+
+			unary := UnaryServerInterceptor(tt.give)
+			resp, err := unary(ctx, "req", &grpc.UnaryServerInfo{FullMethod: "/pkg.Svc/Method"},
+				func(ctx context.Context, req interface{}) (interface{}, error) { return "resp", nil })
+
+			assert.Equal(t, tt.wantResp, resp)
+			assert.Equal(t, tt.wantCode, status.Code(err))
+			assert.Equal(t, tt.wantMessage, status.Convert(err).Message())
+		})
+	}
+}
+
+// TestUnaryServerInterceptorRequest tests the request that Injectors see for a unary call.
+func TestUnaryServerInterceptorRequest(t *testing.T) {
+	t.Parallel()
+
+	var got *http.Request
+	recorder := testInjectorFunc(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got = r
+			next.ServeHTTP(w, r)
+		})
+	})
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(
+		":authority", "svc.example.com",
+		"x-team", "ux",
+		"x-team", "api",
+	))
+
+	unary := UnaryServerInterceptor(recorder)
+	_, err := unary(ctx, "req", &grpc.UnaryServerInfo{FullMethod: "/pkg.Svc/Method"},
+		func(ctx context.Context, req interface{}) (interface{}, error) { return "resp", nil })
+	assert.NoError(t, err)
+
+	assert.Equal(t, http.MethodPost, got.Method)
+	assert.Equal(t, "/pkg.Svc/Method", got.URL.Path)
+	assert.Equal(t, "svc.example.com", got.Host)
+	assert.Equal(t, http.Header{
+		"Content-Type": {"application/grpc"},
+		"X-Team":       {"ux", "api"},
+	}, got.Header)
+	assert.Equal(t, ctx, got.Context())
+}
+
+// TestUnaryServerInterceptorPanic tests that panics other than http.ErrAbortHandler are not
+// recovered.
+func TestUnaryServerInterceptorPanic(t *testing.T) {
+	t.Parallel()
+
+	panicErr := errors.New("boom")
+	unary := UnaryServerInterceptor(testResponder(func(w http.ResponseWriter) { panic(panicErr) }))
+
+	assert.PanicsWithValue(t, panicErr, func() {
+		_, _ = unary(context.Background(), "req", &grpc.UnaryServerInfo{FullMethod: "/pkg.Svc/Method"},
+			func(ctx context.Context, req interface{}) (interface{}, error) { return "resp", nil })
+	})
+}
+
+// TestStreamServerInterceptor tests StreamServerInterceptor.
+func TestStreamServerInterceptor(t *testing.T) {
+	t.Parallel()
+
+	type ctxKey struct{}
+	withValue := testInjectorFunc(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), ctxKey{}, "value")))
+		})
+	})
+
+	// the handler runs with the context of the request
+	var got interface{}
+	stream := StreamServerInterceptor(withValue)
+	err := stream(nil, &testServerStream{ctx: context.Background()}, &grpc.StreamServerInfo{FullMethod: "/pkg.Svc/Stream"},
+		func(srv interface{}, ss grpc.ServerStream) error {
+			got = ss.Context().Value(ctxKey{})
+			return status.Error(codes.NotFound, "missing")
+		})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	assert.Equal(t, "value", got)
+
+	// the handler does not run if the Injector responds
+	si, err := NewStatusInjector(codes.Unavailable)
+	assert.NoError(t, err)
+
+	called := false
+	stream = StreamServerInterceptor(si)
+	err = stream(nil, &testServerStream{ctx: context.Background()}, &grpc.StreamServerInfo{FullMethod: "/pkg.Svc/Stream"},
+		func(srv interface{}, ss grpc.ServerStream) error {
+			called = true
+			return nil
+		})
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+	assert.Equal(t, "Unavailable", status.Convert(err).Message())
+	assert.False(t, called)
+}
+
+// TestServerInterceptorsFault tests a Fault installed on a server with allowlists on the method and
+// metadata of calls.
+func TestServerInterceptorsFault(t *testing.T) {
+	t.Parallel()
+
+	si, err := NewStatusInjector(codes.Unavailable, WithStatusMessage("injected outage"))
+	assert.NoError(t, err)
+
+	f, err := fault.NewFault(si,
+		fault.WithEnabled(true),
+		fault.WithParticipation(1.0),
+		fault.WithPathAllowlist([]string{"/grpc.health.v1.Health/Check"}),
+		fault.WithHeaderAllowlist(map[string]string{"x-team": "ux"}),
+	)
+	assert.NoError(t, err)
+
+	client, _ := testHealthServer(t,
+		grpc.ChainUnaryInterceptor(UnaryServerInterceptor(f)),
+		grpc.ChainStreamInterceptor(StreamServerInterceptor(f)),
+	)
+
+	// calls without the metadata are not faulted
+	resp, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: "svc"})
+	assert.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.GetStatus())
+
+	// calls with the metadata are
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-team", "ux")
+	_, err = client.Check(ctx, &healthpb.HealthCheckRequest{Service: "svc"})
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+	assert.Equal(t, "injected outage", status.Convert(err).Message())
+
+	// other methods are not
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	watch, err := client.Watch(ctx, &healthpb.HealthCheckRequest{Service: "svc"})
+	assert.NoError(t, err)
+	resp, err = watch.Recv()
+	assert.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.GetStatus())
+}
+
+// TestHTTPStatusCode tests httpStatusCode.
+func TestHTTPStatusCode(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		give int
+		want codes.Code
+	}{
+		{give: http.StatusBadRequest, want: codes.Internal},
+		{give: http.StatusUnauthorized, want: codes.Unauthenticated},
+		{give: http.StatusForbidden, want: codes.PermissionDenied},
+		{give: http.StatusNotFound, want: codes.Unimplemented},
+		{give: http.StatusTooManyRequests, want: codes.Unavailable},
+		{give: http.StatusBadGateway, want: codes.Unavailable},
+		{give: http.StatusServiceUnavailable, want: codes.Unavailable},
+		{give: http.StatusGatewayTimeout, want: codes.Unavailable},
+		{give: http.StatusInternalServerError, want: codes.Unknown},
+		{give: http.StatusOK, want: codes.Unknown},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, httpStatusCode(tt.give), tt.give)
+	}
+}
@@ -0,0 +1,115 @@
+package faultgrpc
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/github/go-fault"
+	"google.golang.org/grpc/codes"
+)
+
+var (
+	// ErrInvalidCode when OK or a code that is not defined by gRPC is passed.
+	ErrInvalidCode = errors.New("not a valid grpc error code")
+)
+
+// StatusInjector responds to a gRPC call with a status code, such as UNAVAILABLE or
+// DEADLINE_EXCEEDED, instead of running it. It writes a trailers-only gRPC response, so it works on
+// calls served through the interceptors of this package and on gRPC servers mounted as an
+// http.Handler alike.
+type StatusInjector struct {
+	code     codes.Code
+	message  string
+	reporter fault.Reporter
+}
+
+// StatusInjectorOption configures a StatusInjector.
+type StatusInjectorOption interface {
+	applyStatusInjector(i *StatusInjector) error
+}
+
+type statusMessageOption string
+
+func (o statusMessageOption) applyStatusInjector(i *StatusInjector) error {
+	i.message = string(o)
+	return nil
+}
+
+// WithStatusMessage sets the message of the status. Default the name of the code.
+func WithStatusMessage(msg string) StatusInjectorOption {
+	return statusMessageOption(msg)
+}
+
+func (o reporterOption) applyStatusInjector(i *StatusInjector) error {
+	i.reporter = o.reporter
+	return nil
+}
+
+// NewStatusInjector returns a StatusInjector that responds with code. Pass a code other than OK.
+func NewStatusInjector(code codes.Code, opts ...StatusInjectorOption) (*StatusInjector, error) {
+	if code == codes.OK || code > codes.Unauthenticated {
+		return nil, ErrInvalidCode
+	}
+
+	// set defaults
+	si := &StatusInjector{
+		code:     code,
+		message:  code.String(),
+		reporter: fault.NewNoopReporter(),
+	}
+
+	// apply options
+	for _, opt := range opts {
+		err := opt.applyStatusInjector(si)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return si, nil
+}
+
+// Handler responds with the status in the Grpc-Status and Grpc-Message headers.
+func (i *StatusInjector) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		go i.reporter.Report(i.String(), fault.StateStarted)
+
+		w.Header().Set("Content-Type", "application/grpc")
+		w.Header().Set("Grpc-Status", strconv.Itoa(int(i.code)))
+		w.Header().Set("Grpc-Message", url.PathEscape(i.message))
+		w.WriteHeader(http.StatusOK)
+
+		go i.reporter.Report(i.String(), fault.StateFinished)
+	})
+}
+
+// Reporter returns the Reporter of the StatusInjector.
+func (i *StatusInjector) Reporter() fault.Reporter {
+	return i.reporter
+}
+
+// SetReporter replaces the Reporter of the StatusInjector.
+func (i *StatusInjector) SetReporter(r fault.Reporter) {
+	i.reporter = r
+}
+
+// Name returns "grpc_status".
+func (i *StatusInjector) Name() string {
+	return "grpc_status"
+}
+
+// Describe returns the code and message of the StatusInjector.
+func (i *StatusInjector) Describe() map[string]string {
+	return map[string]string{
+		"code":    i.code.String(),
+		"message": i.message,
+	}
+}
+
+// String returns a summary of the StatusInjector, such as "grpc_status(Unavailable)".
+func (i *StatusInjector) String() string {
+	return fmt.Sprintf("%s(%s)", i.Name(), i.code)
+}
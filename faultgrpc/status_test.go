@@ -0,0 +1,127 @@
+package faultgrpc
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/github/go-fault"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+)
+
+// errTestOption is returned by testErrorOption.
+var errTestOption = errors.New("test option error")
+
+// testErrorOption is a StatusInjectorOption that fails.
+type testErrorOption struct{}
+
+func (o testErrorOption) applyStatusInjector(i *StatusInjector) error {
+	return errTestOption
+}
+
+// TestNewStatusInjector tests NewStatusInjector.
+func TestNewStatusInjector(t *testing.T) {
+	t.Parallel()
+
+	reporter := &testReporter{}
+
+	tests := []struct {
+		name        string
+		giveCode    codes.Code
+		giveOptions []StatusInjectorOption
+		want        *StatusInjector
+		wantErr     error
+	}{
+		{
+			name:     "defaults",
+			giveCode: codes.Unavailable,
+			want: &StatusInjector{
+				code:     codes.Unavailable,
+				message:  "Unavailable",
+				reporter: fault.NewNoopReporter(),
+			},
+		},
+		{
+			name:     "options",
+			giveCode: codes.DeadlineExceeded,
+			giveOptions: []StatusInjectorOption{
+				WithStatusMessage("too slow"),
+				WithReporter(reporter),
+			},
+			want: &StatusInjector{
+				code:     codes.DeadlineExceeded,
+				message:  "too slow",
+				reporter: reporter,
+			},
+		},
+		{
+			name:        "option error",
+			giveCode:    codes.Unavailable,
+			giveOptions: []StatusInjectorOption{testErrorOption{}},
+			wantErr:     errTestOption,
+		},
+		{
+			name:     "ok",
+			giveCode: codes.OK,
+			wantErr:  ErrInvalidCode,
+		},
+		{
+			name:     "unknown code",
+			giveCode: codes.Code(17),
+			wantErr:  ErrInvalidCode,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			si, err := NewStatusInjector(tt.giveCode, tt.giveOptions...)
+
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.want, si)
+		})
+	}
+}
+
+// TestStatusInjectorHandler tests StatusInjector.Handler.
+func TestStatusInjectorHandler(t *testing.T) {
+	t.Parallel()
+
+	reporter := &testReporter{states: make(chan fault.InjectorState, 2)}
+	si, err := NewStatusInjector(codes.ResourceExhausted, WithStatusMessage("quota 100%"), WithReporter(reporter))
+	assert.NoError(t, err)
+
+	called := false
+	h := si.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { called = true }))
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/pkg.Svc/Method", nil))
+
+	assert.False(t, called)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "application/grpc", rr.Header().Get("Content-Type"))
+	assert.Equal(t, "8", rr.Header().Get("Grpc-Status"))
+	assert.Equal(t, "quota%20100%25", rr.Header().Get("Grpc-Message"))
+	assert.Empty(t, rr.Body.String())
+	assert.ElementsMatch(t, []fault.InjectorState{fault.StateStarted, fault.StateFinished},
+		[]fault.InjectorState{<-reporter.states, <-reporter.states})
+}
+
+// TestStatusInjectorDescribe tests the Describer and ReporterSetter methods of StatusInjector.
+func TestStatusInjectorDescribe(t *testing.T) {
+	t.Parallel()
+
+	si, err := NewStatusInjector(codes.Unavailable)
+	assert.NoError(t, err)
+	assert.Equal(t, "grpc_status", si.Name())
+	assert.Equal(t, "grpc_status(Unavailable)", si.String())
+	assert.Equal(t, map[string]string{"code": "Unavailable", "message": "Unavailable"}, si.Describe())
+
+	reporter := &testReporter{}
+	si.SetReporter(reporter)
+	assert.Equal(t, reporter, si.Reporter())
+}
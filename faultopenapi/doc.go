@@ -0,0 +1,34 @@
+/*
+Package faultopenapi targets Faults at the operations of an OpenAPI document, so experiments are
+defined against the API contract rather than raw paths.
+
+Loading
+
+Load an OpenAPI 3 or Swagger 2 document in JSON or YAML with Load() or LoadFile(). Only the paths,
+operations, and server base paths are read; the rest of the document is ignored.
+
+    spec, _ := faultopenapi.LoadFile("openapi.yaml")
+
+Targeting
+
+MatchOperations() and MatchTags() return a fault.RequestMatcher that matches requests for the
+chosen operations. Path templates such as /users/{id} are resolved automatically, and when more
+than one path matches a request the most specific one wins, so /users/me does not match an
+operation on /users/{id}.
+
+    m, _ := spec.MatchTags("billing")
+    f, _ := fault.NewFault(ei, fault.WithEnabled(true), fault.WithRequestMatcher(m))
+Idempotency Safety
+
+Destructive faults such as rejected requests and partial responses leave a client unsure if its
+request was applied, and retrying a non-idempotent operation can duplicate writes. Pass
+Idempotency() to fault.WithIdempotencySafety() to stop destructive faults on those operations:
+
+    f, _ := fault.NewFault(ri, fault.WithEnabled(true), fault.WithIdempotencySafety(spec.Idempotency()))
+
+Operations are idempotent based on their http method unless the document sets the x-idempotent
+extension. Set x-fault-allow-destructive: true on an operation to allow destructive faults on it
+anyway.
+
+*/
+package faultopenapi
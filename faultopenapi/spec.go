@@ -0,0 +1,322 @@
+package faultopenapi
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/github/go-fault"
+	"gopkg.in/yaml.v2"
+)
+
+var (
+	// ErrUnknownOperation when an operationId is not in the Spec.
+	ErrUnknownOperation = errors.New("unknown operation")
+	// ErrUnknownTag when no operation in the Spec has a tag.
+	ErrUnknownTag = errors.New("unknown tag")
+)
+
+// templateParam matches a parameter in a path template, such as {id}.
+var templateParam = regexp.MustCompile(`\{[^/{}]+\}`) //nolint:gochecknoglobals
+
+// Operation is an operation in an OpenAPI document.
+type Operation struct {
+	// ID is the operationId of the Operation. It is empty if the document does not set one.
+	ID string
+	// Method is the http method of the Operation, such as "GET".
+	Method string
+	// Path is the path template of the Operation, such as "/users/{id}".
+	Path string
+	// Tags are the tags of the Operation.
+	Tags []string
+	// Idempotent is true if the Operation is safe to retry. It is set by the x-idempotent extension
+	// or, if that is missing, by the Method as defined by fault.IdempotentMethod().
+	Idempotent bool
+	// AllowDestructive is true if the x-fault-allow-destructive extension allows destructive faults
+	// on the Operation even if it is not Idempotent.
+	AllowDestructive bool
+
+	pattern *regexp.Regexp
+	params  int
+}
+
+// Spec is a loaded OpenAPI document.
+type Spec struct {
+	operations []Operation
+	basePaths  []string
+}
+
+// document is the part of an OpenAPI 3 or Swagger 2 document that Spec reads.
+type document struct {
+	BasePath string              `yaml:"basePath"`
+	Servers  []server            `yaml:"servers"`
+	Paths    map[string]pathItem `yaml:"paths"`
+}
+
+type server struct {
+	URL string `yaml:"url"`
+}
+
+type pathItem struct {
+	Get     *operation `yaml:"get"`
+	Put     *operation `yaml:"put"`
+	Post    *operation `yaml:"post"`
+	Delete  *operation `yaml:"delete"`
+	Options *operation `yaml:"options"`
+	Head    *operation `yaml:"head"`
+	Patch   *operation `yaml:"patch"`
+	Trace   *operation `yaml:"trace"`
+}
+
+type operation struct {
+	OperationID      string   `yaml:"operationId"`
+	Tags             []string `yaml:"tags"`
+	Idempotent       *bool    `yaml:"x-idempotent"`
+	AllowDestructive bool     `yaml:"x-fault-allow-destructive"`
+}
+
+// methods returns the operations of the pathItem by http method.
+func (p pathItem) methods() map[string]*operation {
+	return map[string]*operation{
+		http.MethodGet:     p.Get,
+		http.MethodPut:     p.Put,
+		http.MethodPost:    p.Post,
+		http.MethodDelete:  p.Delete,
+		http.MethodOptions: p.Options,
+		http.MethodHead:    p.Head,
+		http.MethodPatch:   p.Patch,
+		http.MethodTrace:   p.Trace,
+	}
+}
+
+// Load reads an OpenAPI 3 or Swagger 2 document in JSON or YAML from r.
+func Load(r io.Reader) (*Spec, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc document
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	s := &Spec{
+		basePaths: basePaths(doc),
+	}
+
+	for path, item := range doc.Paths {
+		pattern := templatePattern(path)
+		params := len(templateParam.FindAllString(path, -1))
+
+		for method, op := range item.methods() {
+			if op == nil {
+				continue
+			}
+
+			idempotent := fault.IdempotentMethod(method)
+			if op.Idempotent != nil {
+				idempotent = *op.Idempotent
+			}
+
+			s.operations = append(s.operations, Operation{
+				ID:               op.OperationID,
+				Method:           method,
+				Path:             path,
+				Tags:             op.Tags,
+				Idempotent:       idempotent,
+				AllowDestructive: op.AllowDestructive,
+				pattern:          pattern,
+				params:           params,
+			})
+		}
+	}
+
+	sort.Slice(s.operations, func(i, j int) bool {
+		a, b := s.operations[i], s.operations[j]
+		if a.Path != b.Path {
+			return a.Path < b.Path
+		}
+		return a.Method < b.Method
+	})
+
+	return s, nil
+}
+
+// LoadFile reads an OpenAPI 3 or Swagger 2 document in JSON or YAML from the file at path.
+func LoadFile(path string) (*Spec, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return Load(f)
+}
+
+// basePaths returns the path prefixes of the servers in doc, or "" if it has none.
+func basePaths(doc document) []string {
+	var paths []string
+	seen := make(map[string]bool)
+
+	add := func(p string) {
+		p = strings.TrimSuffix(p, "/")
+		if !seen[p] {
+			seen[p] = true
+			paths = append(paths, p)
+		}
+	}
+
+	if doc.BasePath != "" {
+		add(doc.BasePath)
+	}
+
+	for _, s := range doc.Servers {
+		u, err := url.Parse(s.URL)
+		if err != nil || strings.Contains(u.Path, "{") {
+			continue
+		}
+		add(u.Path)
+	}
+
+	if len(paths) == 0 {
+		add("")
+	}
+
+	return paths
+}
+
+// templatePattern compiles a path template into a regexp that matches the paths it describes.
+func templatePattern(path string) *regexp.Regexp {
+	var b strings.Builder
+	b.WriteString("^")
+
+	last := 0
+	for _, loc := range templateParam.FindAllStringIndex(path, -1) {
+		b.WriteString(regexp.QuoteMeta(path[last:loc[0]]))
+		b.WriteString("[^/]+")
+		last = loc[1]
+	}
+	b.WriteString(regexp.QuoteMeta(path[last:]))
+	b.WriteString("$")
+
+	return regexp.MustCompile(b.String())
+}
+
+// Operations returns every Operation in the Spec, sorted by path and method.
+func (s *Spec) Operations() []Operation {
+	ops := make([]Operation, len(s.operations))
+	copy(ops, s.operations)
+	return ops
+}
+
+// Operation returns the Operation with operationId id.
+func (s *Spec) Operation(id string) (Operation, bool) {
+	for _, op := range s.operations {
+		if op.ID != "" && op.ID == id {
+			return op, true
+		}
+	}
+
+	return Operation{}, false
+}
+
+// OperationForRequest returns the Operation that r is for. If more than one path template matches
+// r, the one with the fewest parameters wins.
+func (s *Spec) OperationForRequest(r *http.Request) (Operation, bool) {
+	var (
+		match Operation
+		found bool
+	)
+
+	for _, base := range s.basePaths {
+		if !strings.HasPrefix(r.URL.Path, base) {
+			continue
+		}
+		path := strings.TrimPrefix(r.URL.Path, base)
+
+		for _, op := range s.operations {
+			if op.Method != r.Method || !op.pattern.MatchString(path) {
+				continue
+			}
+			if !found || op.params < match.params {
+				match, found = op, true
+			}
+		}
+	}
+
+	return match, found
+}
+
+// MatchOperations returns a fault.RequestMatcher that matches requests for the operations with the
+// operationIds ids.
+func (s *Spec) MatchOperations(ids ...string) (fault.RequestMatcher, error) {
+	want := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		if _, ok := s.Operation(id); !ok {
+			return nil, ErrUnknownOperation
+		}
+		want[id] = true
+	}
+
+	return s.matcher(func(op Operation) bool {
+		return want[op.ID]
+	}), nil
+}
+
+// MatchTags returns a fault.RequestMatcher that matches requests for operations with any of tags.
+func (s *Spec) MatchTags(tags ...string) (fault.RequestMatcher, error) {
+	want := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		want[tag] = true
+	}
+
+	found := make(map[string]bool, len(tags))
+	for _, op := range s.operations {
+		for _, tag := range op.Tags {
+			if want[tag] {
+				found[tag] = true
+			}
+		}
+	}
+	if len(found) != len(want) {
+		return nil, ErrUnknownTag
+	}
+
+	return s.matcher(func(op Operation) bool {
+		for _, tag := range op.Tags {
+			if want[tag] {
+				return true
+			}
+		}
+		return false
+	}), nil
+}
+
+// matcher returns a fault.RequestMatcher that matches requests whose Operation passes fn.
+func (s *Spec) matcher(fn func(op Operation) bool) fault.RequestMatcher {
+	return fault.RequestMatcherFunc(func(r *http.Request) bool {
+		op, ok := s.OperationForRequest(r)
+		return ok && fn(op)
+	})
+}
+
+// Idempotency returns a fault.IdempotencyClassifier that classifies requests by their Operation.
+// Operations that are Idempotent or AllowDestructive are classified as idempotent. Requests that are
+// not for an Operation in the Spec are classified by http method.
+func (s *Spec) Idempotency() fault.IdempotencyClassifier {
+	return fault.IdempotencyClassifierFunc(func(r *http.Request) bool {
+		op, ok := s.OperationForRequest(r)
+		if !ok {
+			return fault.IdempotentMethod(r.Method)
+		}
+
+		return op.Idempotent || op.AllowDestructive
+	})
+}
@@ -0,0 +1,404 @@
+package faultopenapi
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/github/go-fault"
+	"github.com/stretchr/testify/assert"
+)
+
+const testYAML = `
+openapi: 3.0.0
+servers:
+  - url: https://api.example.com/v1/
+  - url: "{scheme}://example.com/{base}"
+  - url: "%zz"
+paths:
+  /users:
+    parameters: []
+    get:
+      operationId: listUsers
+      tags: [users]
+    post:
+      operationId: createUser
+      tags: [users, writes]
+  /users/me:
+    get:
+      operationId: getMe
+      tags: [users]
+  /users/{id}:
+    get:
+      operationId: getUser
+      tags: [users]
+    delete:
+      operationId: deleteUser
+      tags: [users, writes]
+  /files/{name}.json:
+    get:
+      tags: [files]
+`
+
+const testJSON = `{
+  "swagger": "2.0",
+  "basePath": "/api",
+  "paths": {
+    "/orders/{id}": {
+      "put": {"operationId": "updateOrder", "tags": ["orders"]}
+    }
+  }
+}`
+
+type errReader struct{}
+
+func (errReader) Read([]byte) (int, error) {
+	return 0, errors.New("read error")
+}
+
+func testSpec(t *testing.T) *Spec {
+	t.Helper()
+
+	s, err := Load(strings.NewReader(testYAML))
+	assert.NoError(t, err)
+	return s
+}
+
+// TestLoad tests Load.
+func TestLoad(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		give    func() *strings.Reader
+		wantOps int
+		wantErr bool
+	}{
+		{
+			name:    "yaml",
+			give:    func() *strings.Reader { return strings.NewReader(testYAML) },
+			wantOps: 6,
+		},
+		{
+			name:    "json",
+			give:    func() *strings.Reader { return strings.NewReader(testJSON) },
+			wantOps: 1,
+		},
+		{
+			name:    "invalid",
+			give:    func() *strings.Reader { return strings.NewReader("paths: [") },
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			s, err := Load(tt.give())
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Nil(t, s)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Len(t, s.Operations(), tt.wantOps)
+		})
+	}
+}
+
+// TestLoadReadError tests Load when the reader fails.
+func TestLoadReadError(t *testing.T) {
+	t.Parallel()
+
+	s, err := Load(errReader{})
+	assert.Error(t, err)
+	assert.Nil(t, s)
+}
+
+// TestLoadFile tests LoadFile.
+func TestLoadFile(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "faultopenapi")
+	assert.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	path := filepath.Join(dir, "openapi.yaml")
+	assert.NoError(t, ioutil.WriteFile(path, []byte(testYAML), 0o600))
+
+	s, err := LoadFile(path)
+	assert.NoError(t, err)
+	assert.Len(t, s.Operations(), 6)
+
+	s, err = LoadFile(filepath.Join(dir, "missing.yaml"))
+	assert.Error(t, err)
+	assert.Nil(t, s)
+}
+
+// TestSpecOperations tests that Operations are sorted by path and method.
+func TestSpecOperations(t *testing.T) {
+	t.Parallel()
+
+	var got []string
+	for _, op := range testSpec(t).Operations() {
+		got = append(got, op.Method+" "+op.Path)
+	}
+
+	assert.Equal(t, []string{
+		"GET /files/{name}.json",
+		"GET /users",
+		"POST /users",
+		"GET /users/me",
+		"DELETE /users/{id}",
+		"GET /users/{id}",
+	}, got)
+}
+
+// TestSpecOperation tests looking up Operations by operationId.
+func TestSpecOperation(t *testing.T) {
+	t.Parallel()
+
+	s := testSpec(t)
+
+	op, ok := s.Operation("deleteUser")
+	assert.True(t, ok)
+	assert.Equal(t, http.MethodDelete, op.Method)
+	assert.Equal(t, "/users/{id}", op.Path)
+	assert.Equal(t, []string{"users", "writes"}, op.Tags)
+
+	_, ok = s.Operation("missing")
+	assert.False(t, ok)
+
+	_, ok = s.Operation("")
+	assert.False(t, ok)
+}
+
+// TestSpecOperationForRequest tests resolving requests to Operations.
+func TestSpecOperationForRequest(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		giveSpec   string
+		giveMethod string
+		givePath   string
+		wantPath   string
+		wantID     string
+		wantOK     bool
+	}{
+		{
+			name:       "exact",
+			giveSpec:   testYAML,
+			giveMethod: http.MethodPost,
+			givePath:   "/v1/users",
+			wantPath:   "/users",
+			wantID:     "createUser",
+			wantOK:     true,
+		},
+		{
+			name:       "template",
+			giveSpec:   testYAML,
+			giveMethod: http.MethodGet,
+			givePath:   "/v1/users/42",
+			wantPath:   "/users/{id}",
+			wantID:     "getUser",
+			wantOK:     true,
+		},
+		{
+			name:       "most specific",
+			giveSpec:   testYAML,
+			giveMethod: http.MethodGet,
+			givePath:   "/v1/users/me",
+			wantPath:   "/users/me",
+			wantID:     "getMe",
+			wantOK:     true,
+		},
+		{
+			name:       "partial template",
+			giveSpec:   testYAML,
+			giveMethod: http.MethodGet,
+			givePath:   "/v1/files/report.json",
+			wantPath:   "/files/{name}.json",
+			wantOK:     true,
+		},
+		{
+			name:       "wrong method",
+			giveSpec:   testYAML,
+			giveMethod: http.MethodPut,
+			givePath:   "/v1/users/42",
+		},
+		{
+			name:       "missing base path",
+			giveSpec:   testYAML,
+			giveMethod: http.MethodGet,
+			givePath:   "/users/42",
+		},
+		{
+			name:       "extra segment",
+			giveSpec:   testYAML,
+			giveMethod: http.MethodGet,
+			givePath:   "/v1/users/42/posts",
+		},
+		{
+			name:       "swagger base path",
+			giveSpec:   testJSON,
+			giveMethod: http.MethodPut,
+			givePath:   "/api/orders/7",
+			wantPath:   "/orders/{id}",
+			wantID:     "updateOrder",
+			wantOK:     true,
+		},
+		{
+			name:       "no servers",
+			giveSpec:   "paths: {/ping: {get: {operationId: ping}}}",
+			giveMethod: http.MethodGet,
+			givePath:   "/ping",
+			wantPath:   "/ping",
+			wantID:     "ping",
+			wantOK:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			s, err := Load(strings.NewReader(tt.giveSpec))
+			assert.NoError(t, err)
+
+			op, ok := s.OperationForRequest(httptest.NewRequest(tt.giveMethod, tt.givePath, nil))
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.wantPath, op.Path)
+			assert.Equal(t, tt.wantID, op.ID)
+		})
+	}
+}
+
+// TestSpecMatchOperations tests matching requests by operationId.
+func TestSpecMatchOperations(t *testing.T) {
+	t.Parallel()
+
+	s := testSpec(t)
+
+	_, err := s.MatchOperations("getUser", "missing")
+	assert.Equal(t, ErrUnknownOperation, err)
+
+	m, err := s.MatchOperations("getUser", "deleteUser")
+	assert.NoError(t, err)
+
+	assert.True(t, m.MatchRequest(httptest.NewRequest(http.MethodGet, "/v1/users/42", nil)))
+	assert.True(t, m.MatchRequest(httptest.NewRequest(http.MethodDelete, "/v1/users/42", nil)))
+	assert.False(t, m.MatchRequest(httptest.NewRequest(http.MethodGet, "/v1/users/me", nil)))
+	assert.False(t, m.MatchRequest(httptest.NewRequest(http.MethodGet, "/v1/users", nil)))
+	assert.False(t, m.MatchRequest(httptest.NewRequest(http.MethodGet, "/other", nil)))
+}
+
+// TestSpecMatchTags tests matching requests by tag.
+func TestSpecMatchTags(t *testing.T) {
+	t.Parallel()
+
+	s := testSpec(t)
+
+	_, err := s.MatchTags("writes", "missing")
+	assert.Equal(t, ErrUnknownTag, err)
+
+	m, err := s.MatchTags("writes")
+	assert.NoError(t, err)
+
+	assert.True(t, m.MatchRequest(httptest.NewRequest(http.MethodPost, "/v1/users", nil)))
+	assert.True(t, m.MatchRequest(httptest.NewRequest(http.MethodDelete, "/v1/users/42", nil)))
+	assert.False(t, m.MatchRequest(httptest.NewRequest(http.MethodGet, "/v1/users/42", nil)))
+	assert.False(t, m.MatchRequest(httptest.NewRequest(http.MethodGet, "/other", nil)))
+}
+
+// TestSpecFault tests targeting a Fault at operations.
+func TestSpecFault(t *testing.T) {
+	t.Parallel()
+
+	m, err := testSpec(t).MatchOperations("deleteUser")
+	assert.NoError(t, err)
+
+	ei, err := fault.NewErrorInjector(http.StatusServiceUnavailable)
+	assert.NoError(t, err)
+
+	f, err := fault.NewFault(ei,
+		fault.WithEnabled(true),
+		fault.WithParticipation(1.0),
+		fault.WithRequestMatcher(m),
+	)
+	assert.NoError(t, err)
+
+	h := f.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		method string
+		want   int
+	}{
+		{http.MethodDelete, http.StatusServiceUnavailable},
+		{http.MethodGet, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(tt.method, "/v1/users/42", nil))
+		assert.Equal(t, tt.want, rr.Code, tt.method)
+	}
+}
+
+// TestSpecIdempotency tests classifying requests by Operation.
+func TestSpecIdempotency(t *testing.T) {
+	t.Parallel()
+
+	s, err := Load(strings.NewReader(`
+paths:
+  /orders:
+    post:
+      operationId: createOrder
+  /orders/{id}:
+    put:
+      operationId: updateOrder
+      x-idempotent: false
+    patch:
+      operationId: patchOrder
+      x-idempotent: true
+  /carts:
+    post:
+      operationId: createCart
+      x-fault-allow-destructive: true
+`))
+	assert.NoError(t, err)
+
+	op, ok := s.Operation("updateOrder")
+	assert.True(t, ok)
+	assert.False(t, op.Idempotent)
+
+	tests := []struct {
+		giveMethod string
+		givePath   string
+		want       bool
+	}{
+		{http.MethodPost, "/orders", false},
+		{http.MethodPut, "/orders/1", false},
+		{http.MethodPatch, "/orders/1", true},
+		{http.MethodPost, "/carts", true},
+		{http.MethodGet, "/unknown", true},
+		{http.MethodPost, "/unknown", false},
+	}
+
+	c := s.Idempotency()
+	for _, tt := range tests {
+		r := httptest.NewRequest(tt.giveMethod, tt.givePath, nil)
+		assert.Equal(t, tt.want, c.Idempotent(r), "%s %s", tt.giveMethod, tt.givePath)
+	}
+}
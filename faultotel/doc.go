@@ -0,0 +1,54 @@
+/*
+Package faultotel targets Faults with OpenTelemetry context, so upstream services can nominate
+specific flows for fault injection in the services they call.
+
+Baggage
+
+MatchBaggage() matches requests whose baggage has a member with one of the given values. An upstream
+service opts a flow into an experiment by adding a member to its baggage, which propagates with
+the request to every downstream service:
+
+    m := faultotel.MatchBaggage("experiment", "checkout-latency")
+    f, _ := fault.NewFault(si, fault.WithEnabled(true), fault.WithRequestMatcher(m))
+
+The baggage is read from the request context, where it is put by OpenTelemetry instrumentation
+such as otelhttp, or else from the W3C baggage header of the request, so the Fault may run before
+or after that instrumentation.
+
+Span Attributes
+
+MatchSpanAttribute() matches requests whose active span has an attribute with one of the given
+values. Only spans that expose their attributes, such as those of the OpenTelemetry SDK, can
+match, and the span must be started before the Fault runs:
+
+    m := faultotel.MatchSpanAttribute("tenant.tier", "free")
+
+Events
+
+NewEventReporter() returns a Reporter that turns the Evaluation of every request into an Event of
+high-cardinality attributes, such as the Fault name, the decision, the participation roll, and the
+trace and span IDs of the request, for observability-driven analysis of an experiment. The Fault
+must trace its Evaluations. A SpanExporter adds the Events to the span of the request, to be
+exported over OTLP with the trace, and a JSONExporter writes them as Honeycomb events:
+
+    r, _ := faultotel.NewEventReporter(faultotel.NewSpanExporter())
+    f, _ := fault.NewFault(si, fault.WithEnabled(true), fault.WithReporter(r), fault.WithTracing(true))
+
+Pass WithRequestAttributes() to add attributes of your own, such as a user ID.
+
+Injection Spans
+
+NewSpanReporter() returns a Reporter that adds a "fault.injected" event to the active span of every
+request a Fault injects, with the Fault name, the kind of Injector, the participation, the
+duration and status code of the injection, and the labels of the Fault set with fault.WithLabels.
+Latency added by a Fault is then attributed to it in the trace, instead of looking like the service
+slowed down. Pass WithInjectionSpans() to also start a child span covering each injection:
+
+    r, _ := faultotel.NewSpanReporter(faultotel.WithInjectionSpans(tp))
+    f, _ := fault.NewFault(si, fault.WithEnabled(true), fault.WithReporter(r))
+
+Unlike an EventReporter, a SpanReporter needs no tracing, and the span of the request must be
+started before the Fault runs.
+
+*/
+package faultotel
@@ -0,0 +1,197 @@
+package faultotel
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/github/go-fault"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var (
+	// ErrNilExporter when a nil Exporter is passed.
+	ErrNilExporter = errors.New("exporter cannot be nil")
+)
+
+// EventName is the name of the span events added by a SpanExporter.
+const EventName = "fault.evaluation"
+
+// Event is a single Fault Evaluation as a flat list of high-cardinality attributes.
+type Event struct {
+	// Time is when the Event was made.
+	Time time.Time
+	// Attributes are the fields of the Event, such as "fault.name" and "trace.trace_id".
+	Attributes []attribute.KeyValue
+}
+
+// Exporter sends Events somewhere, such as a span or an event store. ctx is the context of the
+// evaluated request.
+type Exporter interface {
+	ExportEvent(ctx context.Context, e Event)
+}
+
+// ExporterFunc is an Exporter that is a function.
+type ExporterFunc func(ctx context.Context, e Event)
+
+// ExportEvent calls f(ctx, e).
+func (f ExporterFunc) ExportEvent(ctx context.Context, e Event) {
+	f(ctx, e)
+}
+
+// EventReporter is a fault.EvaluationReporter that turns each Evaluation into an Event with the
+// trace and span IDs of the request, and sends it to an Exporter. Pass it to a Fault with
+// fault.WithReporter and fault.WithTracing(true).
+type EventReporter struct {
+	exporter Exporter
+	attrs    func(r *http.Request) []attribute.KeyValue
+}
+
+// EventReporterOption configures an EventReporter.
+type EventReporterOption interface {
+	applyEventReporter(r *EventReporter) error
+}
+
+type requestAttributesOption func(r *http.Request) []attribute.KeyValue
+
+func (o requestAttributesOption) applyEventReporter(r *EventReporter) error {
+	r.attrs = o
+	return nil
+}
+
+// WithRequestAttributes adds the attributes f returns for the evaluated request to every Event,
+// such as a user or tenant ID.
+func WithRequestAttributes(f func(r *http.Request) []attribute.KeyValue) EventReporterOption {
+	return requestAttributesOption(f)
+}
+
+// NewEventReporter returns an EventReporter that sends Events to exp.
+func NewEventReporter(exp Exporter, opts ...EventReporterOption) (*EventReporter, error) {
+	if exp == nil {
+		return nil, ErrNilExporter
+	}
+
+	// set defaults
+	r := &EventReporter{
+		exporter: exp,
+	}
+
+	// apply options
+	for _, opt := range opts {
+		err := opt.applyEventReporter(r)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return r, nil
+}
+
+// Report does nothing. Only Evaluations carry the request an Event needs.
+func (r *EventReporter) Report(name string, state fault.InjectorState) {}
+
+// ReportEvaluation sends an Event for e to the Exporter.
+func (r *EventReporter) ReportEvaluation(e fault.Evaluation) {
+	ctx := context.Background()
+	if e.Request != nil {
+		ctx = e.Request.Context()
+	}
+
+	r.exporter.ExportEvent(ctx, Event{
+		Time:       time.Now(),
+		Attributes: r.attributes(ctx, e),
+	})
+}
+
+// attributes returns the attributes of the Event for e, whose request has context ctx.
+func (r *EventReporter) attributes(ctx context.Context, e fault.Evaluation) []attribute.KeyValue {
+	decision := string(e.SkipReason)
+	if e.Injected {
+		decision = fault.DecisionInjected
+	}
+
+	attrs := []attribute.KeyValue{
+		attribute.String("fault.name", e.Fault),
+		attribute.String("fault.injector", e.Injector),
+		attribute.String("fault.decision", decision),
+		attribute.Bool("fault.enabled", e.Enabled),
+		attribute.Bool("fault.matched", e.Matched),
+		attribute.Float64("fault.roll", float64(e.Roll)),
+		attribute.Float64("fault.participation", float64(e.Participation)),
+		attribute.Int64("fault.seed", e.Seed),
+		attribute.Int64("fault.rolls", int64(e.Rolls)),
+	}
+	if e.Cohort != "" {
+		attrs = append(attrs, attribute.String("fault.cohort", string(e.Cohort)))
+	}
+
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		attrs = append(attrs,
+			attribute.String("trace.trace_id", sc.TraceID().String()),
+			attribute.String("trace.span_id", sc.SpanID().String()),
+		)
+	}
+
+	if e.Request != nil {
+		attrs = append(attrs,
+			attribute.String("http.method", e.Request.Method),
+			attribute.String("http.target", e.Request.URL.Path),
+		)
+		if r.attrs != nil {
+			attrs = append(attrs, r.attrs(e.Request)...)
+		}
+	}
+
+	return attrs
+}
+
+// SpanExporter adds each Event to the span of its request as a span event named EventName, so it
+// is exported over OTLP with the trace. Events are reported asynchronously, and an event for a
+// span that has already ended is dropped, so the span should outlive the handler, as a server span
+// started by otelhttp around the Faults does.
+type SpanExporter struct{}
+
+// NewSpanExporter returns a new SpanExporter.
+func NewSpanExporter() *SpanExporter {
+	return &SpanExporter{}
+}
+
+// ExportEvent adds e to the span in ctx.
+func (x *SpanExporter) ExportEvent(ctx context.Context, e Event) {
+	trace.SpanFromContext(ctx).AddEvent(EventName,
+		trace.WithTimestamp(e.Time),
+		trace.WithAttributes(e.Attributes...),
+	)
+}
+
+// JSONExporter writes each Event as a flat JSON object on its own line, with its time under
+// "timestamp". This is the shape of a Honeycomb event, so the output can be sent to Honeycomb as
+// is, for example with honeytail.
+type JSONExporter struct {
+	mtx sync.Mutex
+	enc *json.Encoder
+}
+
+// NewJSONExporter returns a JSONExporter that writes to w.
+func NewJSONExporter(w io.Writer) *JSONExporter {
+	return &JSONExporter{enc: json.NewEncoder(w)}
+}
+
+// ExportEvent writes e. Write errors are ignored.
+func (x *JSONExporter) ExportEvent(ctx context.Context, e Event) {
+	fields := make(map[string]interface{}, len(e.Attributes)+1)
+	fields["timestamp"] = e.Time.Format(time.RFC3339Nano)
+	for _, kv := range e.Attributes {
+		fields[string(kv.Key)] = kv.Value.AsInterface()
+	}
+
+	x.mtx.Lock()
+	defer x.mtx.Unlock()
+
+	_ = x.enc.Encode(fields)
+}
@@ -0,0 +1,285 @@
+package faultotel
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/github/go-fault"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+var errEventReporterOption = errors.New("event reporter option error")
+
+type errorEventReporterOption struct{}
+
+func (errorEventReporterOption) applyEventReporter(r *EventReporter) error {
+	return errEventReporterOption
+}
+
+// testExporter sends every Event it exports to events.
+type testExporter struct {
+	events chan Event
+}
+
+func newTestExporter() *testExporter {
+	return &testExporter{events: make(chan Event, 10)}
+}
+
+func (x *testExporter) ExportEvent(ctx context.Context, e Event) {
+	x.events <- e
+}
+
+// TestNewEventReporter tests NewEventReporter.
+func TestNewEventReporter(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		giveExporter Exporter
+		giveOptions  []EventReporterOption
+		wantErr      error
+	}{
+		{
+			name:         "valid",
+			giveExporter: NewSpanExporter(),
+		},
+		{
+			name:         "nil exporter",
+			giveExporter: nil,
+			wantErr:      ErrNilExporter,
+		},
+		{
+			name:         "option error",
+			giveExporter: NewSpanExporter(),
+			giveOptions:  []EventReporterOption{errorEventReporterOption{}},
+			wantErr:      errEventReporterOption,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r, err := NewEventReporter(tt.giveExporter, tt.giveOptions...)
+
+			assert.Equal(t, tt.wantErr, err)
+			if tt.wantErr != nil {
+				assert.Nil(t, r)
+				return
+			}
+
+			assert.NotNil(t, r)
+			r.Report("fault", fault.StateStarted)
+		})
+	}
+}
+
+// TestEventReporterReportEvaluation tests the attributes of the Events an EventReporter exports.
+func TestEventReporterReportEvaluation(t *testing.T) {
+	t.Parallel()
+
+	tp := sdktrace.NewTracerProvider()
+	t.Cleanup(func() { _ = tp.Shutdown(context.Background()) })
+
+	ctx, span := tp.Tracer("test").Start(context.Background(), "request")
+	span.End()
+	sc := span.SpanContext()
+
+	userAttrs := WithRequestAttributes(func(r *http.Request) []attribute.KeyValue {
+		return []attribute.KeyValue{attribute.String("user.id", r.Header.Get("X-User-ID"))}
+	})
+
+	tests := []struct {
+		name        string
+		giveOptions []EventReporterOption
+		giveEval    fault.Evaluation
+		giveContext context.Context
+		want        []attribute.KeyValue
+	}{
+		{
+			name:        "injected",
+			giveOptions: []EventReporterOption{userAttrs},
+			giveEval: fault.Evaluation{
+				Fault:         "checkout",
+				Enabled:       true,
+				Matched:       true,
+				Roll:          0.25,
+				Participation: 0.5,
+				Injected:      true,
+				Injector:      "slow(1s)",
+				Cohort:        fault.CohortTreatment,
+				Seed:          42,
+				Rolls:         3,
+			},
+			giveContext: ctx,
+			want: []attribute.KeyValue{
+				attribute.String("fault.name", "checkout"),
+				attribute.String("fault.injector", "slow(1s)"),
+				attribute.String("fault.decision", "injected"),
+				attribute.Bool("fault.enabled", true),
+				attribute.Bool("fault.matched", true),
+				attribute.Float64("fault.roll", 0.25),
+				attribute.Float64("fault.participation", 0.5),
+				attribute.Int64("fault.seed", 42),
+				attribute.Int64("fault.rolls", 3),
+				attribute.String("fault.cohort", "treatment"),
+				attribute.String("trace.trace_id", sc.TraceID().String()),
+				attribute.String("trace.span_id", sc.SpanID().String()),
+				attribute.String("http.method", "GET"),
+				attribute.String("http.target", "/orders"),
+				attribute.String("user.id", "7"),
+			},
+		},
+		{
+			name: "skipped without span",
+			giveEval: fault.Evaluation{
+				Fault:      "checkout",
+				Injector:   "slow(1s)",
+				SkipReason: fault.SkipDisabled,
+			},
+			giveContext: context.Background(),
+			want: []attribute.KeyValue{
+				attribute.String("fault.name", "checkout"),
+				attribute.String("fault.injector", "slow(1s)"),
+				attribute.String("fault.decision", "disabled"),
+				attribute.Bool("fault.enabled", false),
+				attribute.Bool("fault.matched", false),
+				attribute.Float64("fault.roll", 0),
+				attribute.Float64("fault.participation", 0),
+				attribute.Int64("fault.seed", 0),
+				attribute.Int64("fault.rolls", 0),
+				attribute.String("http.method", "GET"),
+				attribute.String("http.target", "/orders"),
+			},
+		},
+		{
+			name:        "no request",
+			giveOptions: []EventReporterOption{userAttrs},
+			giveEval: fault.Evaluation{
+				Fault:      "checkout",
+				Injector:   "slow(1s)",
+				SkipReason: fault.SkipParticipation,
+			},
+			want: []attribute.KeyValue{
+				attribute.String("fault.name", "checkout"),
+				attribute.String("fault.injector", "slow(1s)"),
+				attribute.String("fault.decision", "participation"),
+				attribute.Bool("fault.enabled", false),
+				attribute.Bool("fault.matched", false),
+				attribute.Float64("fault.roll", 0),
+				attribute.Float64("fault.participation", 0),
+				attribute.Int64("fault.seed", 0),
+				attribute.Int64("fault.rolls", 0),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			exp := newTestExporter()
+			r, err := NewEventReporter(exp, tt.giveOptions...)
+			assert.NoError(t, err)
+
+			if tt.giveContext != nil {
+				req := httptest.NewRequest(http.MethodGet, "/orders?id=1", nil).WithContext(tt.giveContext)
+				req.Header.Set("X-User-ID", "7")
+				tt.giveEval.Request = req
+			}
+
+			r.ReportEvaluation(tt.giveEval)
+
+			e := <-exp.events
+			assert.False(t, e.Time.IsZero())
+			assert.Equal(t, tt.want, e.Attributes)
+		})
+	}
+}
+
+// TestSpanExporter tests that a Fault with an EventReporter and a SpanExporter adds span events.
+func TestSpanExporter(t *testing.T) {
+	t.Parallel()
+
+	sr := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+	t.Cleanup(func() { _ = tp.Shutdown(context.Background()) })
+
+	exported := make(chan struct{})
+	spans := NewSpanExporter()
+	r, err := NewEventReporter(ExporterFunc(func(ctx context.Context, e Event) {
+		spans.ExportEvent(ctx, e)
+		close(exported)
+	}))
+	assert.NoError(t, err)
+
+	ei, err := fault.NewErrorInjector(http.StatusInternalServerError)
+	assert.NoError(t, err)
+	f, err := fault.NewFault(ei,
+		fault.WithName("checkout"),
+		fault.WithEnabled(true),
+		fault.WithParticipation(1.0),
+		fault.WithReporter(r),
+		fault.WithTracing(true),
+	)
+	assert.NoError(t, err)
+
+	ctx, span := tp.Tracer("test").Start(context.Background(), "request")
+	rr := httptest.NewRecorder()
+	f.Handler(http.NotFoundHandler()).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx))
+	<-exported
+	span.End()
+
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+
+	ended := sr.Ended()
+	assert.Len(t, ended, 1)
+	events := ended[0].Events()
+	assert.Len(t, events, 1)
+	assert.Equal(t, EventName, events[0].Name)
+	assert.Contains(t, events[0].Attributes, attribute.String("fault.name", "checkout"))
+	assert.Contains(t, events[0].Attributes, attribute.String("fault.decision", "injected"))
+}
+
+// TestJSONExporter tests JSONExporter.
+func TestJSONExporter(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	exp := NewJSONExporter(&buf)
+
+	ts := time.Date(2020, 1, 2, 3, 4, 5, 6, time.UTC)
+	exp.ExportEvent(context.Background(), Event{
+		Time: ts,
+		Attributes: []attribute.KeyValue{
+			attribute.String("fault.name", "checkout"),
+			attribute.Bool("fault.enabled", true),
+			attribute.Int64("fault.rolls", 3),
+		},
+	})
+	exp.ExportEvent(context.Background(), Event{Time: ts})
+
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	assert.Len(t, lines, 2)
+	assert.JSONEq(t, `{
+		"timestamp": "2020-01-02T03:04:05.000000006Z",
+		"fault.name": "checkout",
+		"fault.enabled": true,
+		"fault.rolls": 3
+	}`, string(lines[0]))
+
+	var fields map[string]interface{}
+	assert.NoError(t, json.Unmarshal(lines[1], &fields))
+	assert.Equal(t, map[string]interface{}{"timestamp": "2020-01-02T03:04:05.000000006Z"}, fields)
+}
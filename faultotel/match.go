@@ -0,0 +1,87 @@
+package faultotel
+
+import (
+	"net/http"
+
+	"github.com/github/go-fault"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// baggageHeader is the W3C header that carries baggage.
+const baggageHeader = "baggage"
+
+// attributeSpan is a span that exposes its attributes, such as a span of the OpenTelemetry SDK.
+type attributeSpan interface {
+	Attributes() []attribute.KeyValue
+}
+
+// MatchBaggage returns a RequestMatcher that matches requests whose baggage has a member key with
+// any of values, or with any value if values is empty. The baggage is read from the request
+// context, or from the baggage header if the context has none.
+func MatchBaggage(key string, values ...string) fault.RequestMatcher {
+	set := valueSet(values)
+
+	return fault.RequestMatcherFunc(func(r *http.Request) bool {
+		b := baggage.FromContext(r.Context())
+		if b.Len() == 0 {
+			// an invalid header has no members
+			b, _ = baggage.Parse(r.Header.Get(baggageHeader))
+		}
+
+		m := b.Member(key)
+		if m.Key() == "" {
+			return false
+		}
+
+		return set.match(m.Value())
+	})
+}
+
+// MatchSpanAttribute returns a RequestMatcher that matches requests whose active span has the
+// attribute key with any of values, or with any value if values is empty. Values are compared as
+// strings, so the number 5 matches "5". Requests whose span does not expose its attributes never
+// match.
+func MatchSpanAttribute(key attribute.Key, values ...string) fault.RequestMatcher {
+	set := valueSet(values)
+
+	return fault.RequestMatcherFunc(func(r *http.Request) bool {
+		span, ok := trace.SpanFromContext(r.Context()).(attributeSpan)
+		if !ok {
+			return false
+		}
+
+		for _, kv := range span.Attributes() {
+			if kv.Key == key {
+				return set.match(kv.Value.Emit())
+			}
+		}
+
+		return false
+	})
+}
+
+// stringSet is a set of values, where an empty set matches everything.
+type stringSet map[string]struct{}
+
+// valueSet returns the stringSet of values.
+func valueSet(values []string) stringSet {
+	set := make(stringSet, len(values))
+	for _, v := range values {
+		set[v] = struct{}{}
+	}
+
+	return set
+}
+
+// match returns true if v is in s or s is empty.
+func (s stringSet) match(v string) bool {
+	if len(s) == 0 {
+		return true
+	}
+
+	_, ok := s[v]
+
+	return ok
+}
@@ -0,0 +1,164 @@
+package faultotel
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/baggage"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// TestMatchBaggage tests MatchBaggage.
+func TestMatchBaggage(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		giveValues  []string
+		giveContext string
+		giveHeader  string
+		want        bool
+	}{
+		{
+			name:        "context",
+			giveValues:  []string{"checkout-latency"},
+			giveContext: "experiment=checkout-latency,user=1",
+			want:        true,
+		},
+		{
+			name:        "context other value",
+			giveValues:  []string{"checkout-latency"},
+			giveContext: "experiment=search-errors",
+			want:        false,
+		},
+		{
+			name:        "context missing member",
+			giveValues:  []string{"checkout-latency"},
+			giveContext: "user=1",
+			want:        false,
+		},
+		{
+			name:        "context wins over header",
+			giveValues:  []string{"checkout-latency"},
+			giveContext: "user=1",
+			giveHeader:  "experiment=checkout-latency",
+			want:        false,
+		},
+		{
+			name:       "header",
+			giveValues: []string{"search-errors", "checkout-latency"},
+			giveHeader: "user=1, experiment=checkout-latency;ttl=60",
+			want:       true,
+		},
+		{
+			name:       "invalid header",
+			giveValues: []string{"checkout-latency"},
+			giveHeader: "experiment",
+			want:       false,
+		},
+		{
+			name:       "no baggage",
+			giveValues: []string{"checkout-latency"},
+			want:       false,
+		},
+		{
+			name:       "any value",
+			giveHeader: "experiment=anything",
+			want:       true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest("GET", "/", nil)
+			if tt.giveHeader != "" {
+				req.Header.Set("baggage", tt.giveHeader)
+			}
+			if tt.giveContext != "" {
+				b, err := baggage.Parse(tt.giveContext)
+				assert.NoError(t, err)
+				req = req.WithContext(baggage.ContextWithBaggage(req.Context(), b))
+			}
+
+			assert.Equal(t, tt.want, MatchBaggage("experiment", tt.giveValues...).MatchRequest(req))
+		})
+	}
+}
+
+// TestMatchSpanAttribute tests MatchSpanAttribute.
+func TestMatchSpanAttribute(t *testing.T) {
+	t.Parallel()
+
+	tp := sdktrace.NewTracerProvider()
+	t.Cleanup(func() { _ = tp.Shutdown(context.Background()) })
+
+	tests := []struct {
+		name       string
+		giveValues []string
+		giveSpan   bool
+		giveAttrs  []attribute.KeyValue
+		want       bool
+	}{
+		{
+			name:       "match",
+			giveValues: []string{"free", "trial"},
+			giveSpan:   true,
+			giveAttrs:  []attribute.KeyValue{attribute.String("user", "1"), attribute.String("tenant.tier", "free")},
+			want:       true,
+		},
+		{
+			name:       "other value",
+			giveValues: []string{"free"},
+			giveSpan:   true,
+			giveAttrs:  []attribute.KeyValue{attribute.String("tenant.tier", "paid")},
+			want:       false,
+		},
+		{
+			name:       "number",
+			giveValues: []string{"5"},
+			giveSpan:   true,
+			giveAttrs:  []attribute.KeyValue{attribute.Int("tenant.tier", 5)},
+			want:       true,
+		},
+		{
+			name:      "any value",
+			giveSpan:  true,
+			giveAttrs: []attribute.KeyValue{attribute.Bool("tenant.tier", true)},
+			want:      true,
+		},
+		{
+			name:       "missing attribute",
+			giveValues: []string{"free"},
+			giveSpan:   true,
+			want:       false,
+		},
+		{
+			name:       "no span",
+			giveValues: []string{"free"},
+			want:       false,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest("GET", "/", nil)
+			if tt.giveSpan {
+				ctx, span := tp.Tracer("test").Start(req.Context(), "request")
+				defer span.End()
+				span.SetAttributes(tt.giveAttrs...)
+				req = req.WithContext(ctx)
+			}
+
+			assert.Equal(t, tt.want, MatchSpanAttribute("tenant.tier", tt.giveValues...).MatchRequest(req))
+		})
+	}
+}
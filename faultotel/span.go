@@ -0,0 +1,132 @@
+package faultotel
+
+import (
+	"sort"
+
+	"github.com/github/go-fault"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// InjectionEventName is the name of the span events added by a SpanReporter.
+	InjectionEventName = "fault.injected"
+	// InjectionSpanName is the name of the spans started by a SpanReporter built with
+	// WithInjectionSpans.
+	InjectionSpanName = "fault.inject"
+
+	// tracerName is the name of the Tracer that starts injection spans.
+	tracerName = "github.com/github/go-fault/faultotel"
+)
+
+// SpanReporter is a fault.EventReporter that adds a span event named InjectionEventName to the
+// active span of every request a Fault injects, so latency and errors added by the Fault are
+// attributed to it in the trace instead of looking like the service misbehaving. The event is
+// added once the Injector returns, timestamped when it started, with the Fault name, the kind of
+// Injector, the participation, how long the injection took, the status code written, and the
+// labels of the Fault as "fault.label." followed by their key. Pass it
+// to a Fault with fault.WithReporter.
+type SpanReporter struct {
+	tracer trace.Tracer
+}
+
+// SpanReporterOption configures a SpanReporter.
+type SpanReporterOption interface {
+	applySpanReporter(r *SpanReporter) error
+}
+
+type injectionSpansOption struct {
+	tp trace.TracerProvider
+}
+
+func (o injectionSpansOption) applySpanReporter(r *SpanReporter) error {
+	tp := o.tp
+	if tp == nil {
+		tp = otel.GetTracerProvider()
+	}
+
+	r.tracer = tp.Tracer(tracerName)
+
+	return nil
+}
+
+// WithInjectionSpans also starts a child span named InjectionSpanName for every injection, from
+// when the Injector started until it returned, with the same attributes as the span event. A nil
+// tp uses the global TracerProvider.
+func WithInjectionSpans(tp trace.TracerProvider) SpanReporterOption {
+	return injectionSpansOption{tp: tp}
+}
+
+// NewSpanReporter returns a new SpanReporter.
+func NewSpanReporter(opts ...SpanReporterOption) (*SpanReporter, error) {
+	// set defaults
+	r := &SpanReporter{}
+
+	// apply options
+	for _, opt := range opts {
+		err := opt.applySpanReporter(r)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return r, nil
+}
+
+// Report does nothing. Only Events carry the request a span event needs.
+func (r *SpanReporter) Report(name string, state fault.InjectorState) {}
+
+// ReportEvent annotates the span of the request of e once its injection finished.
+func (r *SpanReporter) ReportEvent(e fault.Event) {
+	if e.Type != fault.EventFinished || e.Request == nil {
+		return
+	}
+
+	ctx := e.Request.Context()
+	start := e.Time.Add(-e.Duration)
+	attrs := injectionAttributes(e)
+
+	trace.SpanFromContext(ctx).AddEvent(InjectionEventName,
+		trace.WithTimestamp(start),
+		trace.WithAttributes(attrs...),
+	)
+
+	if r.tracer != nil {
+		_, span := r.tracer.Start(ctx, InjectionSpanName,
+			trace.WithTimestamp(start),
+			trace.WithAttributes(attrs...),
+		)
+		if e.StatusCode == 0 {
+			span.SetStatus(codes.Error, "aborted")
+		}
+		span.End(trace.WithTimestamp(e.Time))
+	}
+}
+
+// injectionAttributes returns the attributes of the injection e finished.
+func injectionAttributes(e fault.Event) []attribute.KeyValue {
+	attrs := []attribute.KeyValue{
+		attribute.String("fault.name", e.Fault),
+		attribute.String("fault.injector", e.InjectorName),
+		attribute.String("fault.injector.description", e.Injector),
+		attribute.Float64("fault.participation", float64(e.Participation)),
+		attribute.Float64("fault.duration_ms", float64(e.Duration.Microseconds())/1000),
+		attribute.Bool("fault.aborted", e.StatusCode == 0),
+	}
+	if e.StatusCode != 0 {
+		attrs = append(attrs, attribute.Int("http.status_code", e.StatusCode))
+	}
+
+	keys := make([]string, 0, len(e.Labels))
+	for k := range e.Labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		attrs = append(attrs, attribute.String("fault.label."+k, e.Labels[k]))
+	}
+
+	return attrs
+}
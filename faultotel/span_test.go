@@ -0,0 +1,249 @@
+package faultotel
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/github/go-fault"
+	"github.com/github/go-fault/faulttest"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+var errSpanReporterOption = errors.New("span reporter option error")
+
+type errorSpanReporterOption struct{}
+
+func (errorSpanReporterOption) applySpanReporter(r *SpanReporter) error {
+	return errSpanReporterOption
+}
+
+// TestNewSpanReporter tests NewSpanReporter.
+func TestNewSpanReporter(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		giveOptions []SpanReporterOption
+		wantTracer  bool
+		wantErr     error
+	}{
+		{
+			name: "no options",
+		},
+		{
+			name:        "injection spans",
+			giveOptions: []SpanReporterOption{WithInjectionSpans(sdktrace.NewTracerProvider())},
+			wantTracer:  true,
+		},
+		{
+			name:        "global tracer provider",
+			giveOptions: []SpanReporterOption{WithInjectionSpans(nil)},
+			wantTracer:  true,
+		},
+		{
+			name:        "option error",
+			giveOptions: []SpanReporterOption{errorSpanReporterOption{}},
+			wantErr:     errSpanReporterOption,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r, err := NewSpanReporter(tt.giveOptions...)
+
+			assert.Equal(t, tt.wantErr, err)
+			if tt.wantErr != nil {
+				assert.Nil(t, r)
+				return
+			}
+
+			assert.Equal(t, tt.wantTracer, r.tracer != nil)
+			r.Report("fault", fault.StateStarted)
+		})
+	}
+}
+
+// testSlowErrorInjector advances a fake clock by a quarter second and responds with a 500.
+type testSlowErrorInjector struct {
+	clock *faulttest.Clock
+}
+
+func (i *testSlowErrorInjector) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		i.clock.Advance(250 * time.Millisecond)
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+}
+
+// testAbortInjector aborts the request.
+type testAbortInjector struct{}
+
+func (i *testAbortInjector) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	})
+}
+
+// TestSpanReporter tests the span events and spans a SpanReporter adds to the span of injected
+// requests.
+func TestSpanReporter(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		name              string
+		giveSpans         bool
+		giveAbort         bool
+		giveParticipation float32
+		giveLabels        map[string]string
+		wantEvent         []attribute.KeyValue
+		wantSpanError     bool
+	}{
+		{
+			name:              "injected",
+			giveParticipation: 1.0,
+			wantEvent: []attribute.KeyValue{
+				attribute.String("fault.name", "checkout"),
+				attribute.String("fault.injector", "testSlowErrorInjector"),
+				attribute.String("fault.injector.description", "testSlowErrorInjector"),
+				attribute.Float64("fault.participation", 1.0),
+				attribute.Float64("fault.duration_ms", 250),
+				attribute.Bool("fault.aborted", false),
+				attribute.Int("http.status_code", http.StatusInternalServerError),
+			},
+		},
+		{
+			name:              "injection span",
+			giveSpans:         true,
+			giveParticipation: 1.0,
+			giveLabels:        map[string]string{"team": "checkout", "experiment": "q3-gameday"},
+			wantEvent: []attribute.KeyValue{
+				attribute.String("fault.name", "checkout"),
+				attribute.String("fault.injector", "testSlowErrorInjector"),
+				attribute.String("fault.injector.description", "testSlowErrorInjector"),
+				attribute.Float64("fault.participation", 1.0),
+				attribute.Float64("fault.duration_ms", 250),
+				attribute.Bool("fault.aborted", false),
+				attribute.Int("http.status_code", http.StatusInternalServerError),
+				attribute.String("fault.label.experiment", "q3-gameday"),
+				attribute.String("fault.label.team", "checkout"),
+			},
+		},
+		{
+			name:              "aborted",
+			giveSpans:         true,
+			giveAbort:         true,
+			giveParticipation: 1.0,
+			wantSpanError:     true,
+			wantEvent: []attribute.KeyValue{
+				attribute.String("fault.name", "checkout"),
+				attribute.String("fault.injector", "testAbortInjector"),
+				attribute.String("fault.injector.description", "testAbortInjector"),
+				attribute.Float64("fault.participation", 1.0),
+				attribute.Float64("fault.duration_ms", 0),
+				attribute.Bool("fault.aborted", true),
+			},
+		},
+		{
+			name:              "skipped",
+			giveSpans:         true,
+			giveParticipation: 0.0,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			sr := tracetest.NewSpanRecorder()
+			tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+			t.Cleanup(func() { _ = tp.Shutdown(context.Background()) })
+
+			var opts []SpanReporterOption
+			if tt.giveSpans {
+				opts = append(opts, WithInjectionSpans(tp))
+			}
+			r, err := NewSpanReporter(opts...)
+			assert.NoError(t, err)
+
+			clock := faulttest.NewClock(start)
+			var i fault.Injector = &testSlowErrorInjector{clock: clock}
+			if tt.giveAbort {
+				i = &testAbortInjector{}
+			}
+
+			f, err := fault.NewFault(i,
+				fault.WithName("checkout"),
+				fault.WithEnabled(true),
+				fault.WithParticipation(tt.giveParticipation),
+				fault.WithReporter(r),
+				fault.WithClock(clock),
+				fault.WithLabels(tt.giveLabels),
+			)
+			assert.NoError(t, err)
+
+			ctx, span := tp.Tracer("test").Start(context.Background(), "request")
+			func() {
+				defer func() { _ = recover() }()
+				f.Handler(http.NotFoundHandler()).ServeHTTP(httptest.NewRecorder(),
+					httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx))
+			}()
+			span.End()
+
+			ended := sr.Ended()
+			request := ended[len(ended)-1]
+			assert.Equal(t, "request", request.Name())
+
+			if tt.wantEvent == nil {
+				assert.Len(t, ended, 1)
+				assert.Len(t, request.Events(), 0)
+				return
+			}
+
+			events := request.Events()
+			assert.Len(t, events, 1)
+			assert.Equal(t, InjectionEventName, events[0].Name)
+			assert.Equal(t, start, events[0].Time)
+			assert.Equal(t, tt.wantEvent, events[0].Attributes)
+
+			if !tt.giveSpans {
+				assert.Len(t, ended, 1)
+				return
+			}
+
+			assert.Len(t, ended, 2)
+			inject := ended[0]
+			assert.Equal(t, InjectionSpanName, inject.Name())
+			assert.Equal(t, span.SpanContext().SpanID(), inject.Parent().SpanID())
+			assert.Equal(t, start, inject.StartTime())
+			assert.Equal(t, events[0].Time.Add(time.Duration(tt.wantEvent[4].Value.AsFloat64())*time.Millisecond), inject.EndTime())
+			assert.Equal(t, tt.wantEvent, inject.Attributes())
+			assert.Equal(t, tt.wantSpanError, inject.Status().Code == codes.Error)
+		})
+	}
+}
+
+// TestSpanReporterNoRequest tests that a SpanReporter ignores Events without a request.
+func TestSpanReporterNoRequest(t *testing.T) {
+	t.Parallel()
+
+	r, err := NewSpanReporter(WithInjectionSpans(nil))
+	assert.NoError(t, err)
+
+	assert.NotPanics(t, func() {
+		r.ReportEvent(fault.Event{Type: fault.EventFinished})
+	})
+}
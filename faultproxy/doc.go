@@ -0,0 +1,32 @@
+/*
+Package faultproxy runs Faults in a reverse proxy, so integration tests can point their real clients
+at a misbehaving upstream without changing the code of either.
+
+In-process
+
+Start a Proxy in front of the upstream for the length of a test. The Faults of the config, and of
+WithFaults(), run on every request the Proxy forwards:
+
+    upstream := httptest.NewServer(app)
+    defer upstream.Close()
+
+    proxy := faultproxy.Start(t, upstream.URL, config)
+    client := api.NewClient(proxy.URL)
+
+Tests can tune the Faults between requests with Fault():
+
+    proxy.Fault("errors").SetEnabled(false)
+
+Use New to build a Proxy as an http.Handler that serves outside of tests.
+
+Container
+
+Command faultproxy, in cmd/faultproxy, serves a Proxy configured by flags or environment variables,
+and builds into a container image with the Dockerfile next to it:
+
+    docker build -t faultproxy -f cmd/faultproxy/Dockerfile .
+    docker run -p 8080:8080 -v $PWD/faults.yaml:/faults.yaml \
+        -e FAULTPROXY_UPSTREAM=http://app:8080 -e FAULTPROXY_CONFIG=/faults.yaml faultproxy
+
+*/
+package faultproxy
@@ -0,0 +1,80 @@
+package faultproxy
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"testing"
+)
+
+// errErrorOption is returned by errorOption.
+var errErrorOption = errors.New("intentional error for tests")
+
+// errorOption is an Option that always fails.
+type errorOption struct{}
+
+func (errorOption) applyProxy(p *Proxy) error {
+	return errErrorOption
+}
+
+// testUpstream starts an upstream that answers 200 with the host, path, and X-Forwarded-Host of
+// each request, and closes it when the test ends.
+func testUpstream(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprintf(w, "%s %s %s", r.Host, r.URL.Path, r.Header.Get("X-Forwarded-Host"))
+	}))
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+// testGet sends a GET to url and returns the status code and body of the response.
+func testGet(t *testing.T, url string) (int, string) {
+	t.Helper()
+
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return resp.StatusCode, string(body)
+}
+
+// testFatalTB is a testing.TB that records the message of Fatalf instead of failing the test.
+type testFatalTB struct {
+	testing.TB
+	msg string
+}
+
+func (tb *testFatalTB) Helper() {}
+
+func (tb *testFatalTB) Fatalf(format string, args ...interface{}) {
+	tb.msg = fmt.Sprintf(format, args...)
+	runtime.Goexit()
+}
+
+// runFatal runs f with a testFatalTB in its own goroutine and returns the message f failed with.
+func runFatal(t *testing.T, f func(tb testing.TB)) string {
+	tb := &testFatalTB{TB: t}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		f(tb)
+	}()
+	<-done
+
+	return tb.msg
+}
@@ -0,0 +1,136 @@
+package faultproxy
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/github/go-fault"
+)
+
+// Proxy is a reverse proxy that runs Faults on the requests it forwards to its upstream.
+type Proxy struct {
+	upstream   *url.URL
+	faults     []*fault.Fault
+	transport  http.RoundTripper
+	configOpts []fault.ConfigOption
+
+	handler http.Handler
+}
+
+// Option configures a Proxy.
+type Option interface {
+	applyProxy(p *Proxy) error
+}
+
+type transportOption struct {
+	transport http.RoundTripper
+}
+
+func (o transportOption) applyProxy(p *Proxy) error {
+	p.transport = o.transport
+	return nil
+}
+
+// WithTransport sets the http.RoundTripper used to send requests to the upstream. Default
+// http.DefaultTransport.
+func WithTransport(rt http.RoundTripper) Option {
+	return transportOption{rt}
+}
+
+type configOptionsOption []fault.ConfigOption
+
+func (o configOptionsOption) applyProxy(p *Proxy) error {
+	p.configOpts = append(p.configOpts, o...)
+	return nil
+}
+
+// WithConfigOptions sets the options the config is read with, such as fault.WithInjectorRegistry()
+// to build custom injector types.
+func WithConfigOptions(opts ...fault.ConfigOption) Option {
+	return configOptionsOption(opts)
+}
+
+type faultsOption []*fault.Fault
+
+func (o faultsOption) applyProxy(p *Proxy) error {
+	p.faults = append(p.faults, o...)
+	return nil
+}
+
+// WithFaults adds Faults built in Go to the Proxy. They run inside the Faults of the config.
+func WithFaults(faults ...*fault.Fault) Option {
+	return faultsOption(faults)
+}
+
+// New returns a Proxy that forwards requests to upstream, an absolute URL whose path is joined with
+// the path of each request, and runs the Faults of config on them. config is a YAML config in the
+// format of fault.NewFaultsFromConfig, or nil to only run the Faults of WithFaults(). The Host
+// header of forwarded requests is set to the host of upstream, and the X-Forwarded headers are set.
+// Requests the upstream cannot answer get a 502 Bad Gateway.
+func New(upstream *url.URL, config io.Reader, opts ...Option) (*Proxy, error) {
+	if upstream == nil || !upstream.IsAbs() {
+		return nil, fault.ErrInvalidURL
+	}
+
+	// set defaults
+	p := &Proxy{
+		upstream:  upstream,
+		transport: http.DefaultTransport,
+	}
+
+	// apply options
+	for _, opt := range opts {
+		err := opt.applyProxy(p)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// the Faults of the config run first
+	if config != nil {
+		faults, err := fault.NewFaultsFromConfig(config, p.configOpts...)
+		if err != nil {
+			return nil, err
+		}
+		p.faults = append(faults, p.faults...)
+	}
+
+	h, err := fault.NewReverseProxy(upstream, p.faults, fault.WithTransport(p.transport))
+	if err != nil {
+		return nil, err
+	}
+	p.handler = h
+
+	return p, nil
+}
+
+// ServeHTTP runs the Faults on r and forwards it to the upstream.
+func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	p.handler.ServeHTTP(w, r)
+}
+
+// Upstream returns the URL requests are forwarded to.
+func (p *Proxy) Upstream() *url.URL {
+	return p.upstream
+}
+
+// Faults returns the Faults of the Proxy, those of the config first, in the order they run.
+func (p *Proxy) Faults() []*fault.Fault {
+	faults := make([]*fault.Fault, len(p.faults))
+	copy(faults, p.faults)
+
+	return faults
+}
+
+// Fault returns the Fault named name, so a test can enable, disable, or tune it between requests,
+// or nil if the Proxy has none.
+func (p *Proxy) Fault(name string) *fault.Fault {
+	for _, f := range p.faults {
+		if f.Name() == name {
+			return f
+		}
+	}
+
+	return nil
+}
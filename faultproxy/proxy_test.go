@@ -0,0 +1,222 @@
+package faultproxy
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/github/go-fault"
+	"github.com/stretchr/testify/assert"
+)
+
+// testConfig has a Fault that answers every request with a 503.
+const testConfig = `
+version: 1
+faults:
+  - name: unavailable
+    enabled: true
+    participation: 1.0
+    injector:
+      type: error
+      params:
+        code: 503
+`
+
+// TestNew tests New.
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	upstream, _ := url.Parse("http://example.com")
+	relative, _ := url.Parse("/example")
+
+	ei, _ := fault.NewErrorInjector(http.StatusTeapot)
+	teapot, _ := fault.NewFault(ei, fault.WithName("teapot"))
+
+	registry := fault.NewInjectorRegistry()
+	_ = registry.Register("custom", func(p fault.ConfigParams) (fault.Injector, error) {
+		return ei, nil
+	})
+
+	tests := []struct {
+		name       string
+		giveURL    *url.URL
+		giveConfig string
+		giveOpts   []Option
+		wantFaults []string
+		wantErr    error
+	}{
+		{
+			name:    "no options",
+			giveURL: upstream,
+		},
+		{
+			name:       "config",
+			giveURL:    upstream,
+			giveConfig: testConfig,
+			wantFaults: []string{"unavailable"},
+		},
+		{
+			name:       "config and faults",
+			giveURL:    upstream,
+			giveConfig: testConfig,
+			giveOpts:   []Option{WithFaults(teapot)},
+			wantFaults: []string{"unavailable", "teapot"},
+		},
+		{
+			name:    "nil url",
+			giveURL: nil,
+			wantErr: fault.ErrInvalidURL,
+		},
+		{
+			name:    "relative url",
+			giveURL: relative,
+			wantErr: fault.ErrInvalidURL,
+		},
+		{
+			name:     "nil fault",
+			giveURL:  upstream,
+			giveOpts: []Option{WithFaults(nil)},
+			wantErr:  fault.ErrNilFault,
+		},
+		{
+			name:       "invalid config",
+			giveURL:    upstream,
+			giveConfig: "faults: [{injector: {type: unknown}}]",
+			wantErr:    fault.ErrUnknownInjectorType,
+		},
+		{
+			name:       "config options",
+			giveURL:    upstream,
+			giveConfig: "faults: [{name: custom, injector: {type: custom}}]",
+			giveOpts:   []Option{WithConfigOptions(fault.WithInjectorRegistry(registry))},
+			wantFaults: []string{"custom"},
+		},
+		{
+			name:     "option error",
+			giveURL:  upstream,
+			giveOpts: []Option{errorOption{}},
+			wantErr:  errErrorOption,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var config *strings.Reader
+			if tt.giveConfig != "" {
+				config = strings.NewReader(tt.giveConfig)
+			}
+
+			var p *Proxy
+			var err error
+			if config == nil {
+				p, err = New(tt.giveURL, nil, tt.giveOpts...)
+			} else {
+				p, err = New(tt.giveURL, config, tt.giveOpts...)
+			}
+
+			assert.True(t, errors.Is(err, tt.wantErr), err)
+			if tt.wantErr != nil {
+				assert.Nil(t, p)
+				return
+			}
+
+			var names []string
+			for _, f := range p.Faults() {
+				names = append(names, f.Name())
+			}
+			assert.Equal(t, tt.wantFaults, names)
+			assert.Equal(t, tt.giveURL, p.Upstream())
+		})
+	}
+}
+
+// TestProxyServeHTTP tests that the Proxy runs its Faults and forwards the other requests.
+func TestProxyServeHTTP(t *testing.T) {
+	t.Parallel()
+
+	upstream := testUpstream(t)
+	u, _ := url.Parse(upstream.URL + "/api")
+
+	p, err := New(u, strings.NewReader(testConfig))
+	assert.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	p.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "http://proxy.test/users", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+
+	p.Fault("unavailable").SetEnabled(false)
+
+	rr = httptest.NewRecorder()
+	p.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "http://proxy.test/users", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, u.Host+" /api/users proxy.test", rr.Body.String())
+}
+
+// TestProxyBadGateway tests that requests the upstream cannot answer get a 502.
+func TestProxyBadGateway(t *testing.T) {
+	t.Parallel()
+
+	upstream := httptest.NewServer(http.NotFoundHandler())
+	u, _ := url.Parse(upstream.URL)
+	upstream.Close()
+
+	p, err := New(u, nil)
+	assert.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	p.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusBadGateway, rr.Code)
+}
+
+// TestProxyWithTransport tests that the Proxy sends requests with its transport.
+func TestProxyWithTransport(t *testing.T) {
+	t.Parallel()
+
+	var got string
+	rt := testRoundTripper(func(r *http.Request) (*http.Response, error) {
+		got = r.URL.String()
+		return &http.Response{StatusCode: http.StatusTeapot, Body: http.NoBody, Request: r}, nil
+	})
+
+	u, _ := url.Parse("http://example.com")
+	p, err := New(u, nil, WithTransport(rt))
+	assert.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	p.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/users?id=1", nil))
+	assert.Equal(t, http.StatusTeapot, rr.Code)
+	assert.Equal(t, "http://example.com/users?id=1", got)
+}
+
+// TestProxyFault tests Proxy.Fault.
+func TestProxyFault(t *testing.T) {
+	t.Parallel()
+
+	ei, _ := fault.NewErrorInjector(http.StatusTeapot)
+	teapot, _ := fault.NewFault(ei, fault.WithName("teapot"))
+
+	u, _ := url.Parse("http://example.com")
+	p, err := New(u, strings.NewReader(testConfig), WithFaults(teapot))
+	assert.NoError(t, err)
+
+	assert.Equal(t, teapot, p.Fault("teapot"))
+	assert.Equal(t, "unavailable", p.Fault("unavailable").Name())
+	assert.Nil(t, p.Fault("missing"))
+
+	faults := p.Faults()
+	faults[0] = nil
+	assert.NotNil(t, p.Faults()[0])
+}
+
+// testRoundTripper is an http.RoundTripper function.
+type testRoundTripper func(r *http.Request) (*http.Response, error)
+
+func (f testRoundTripper) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
@@ -0,0 +1,74 @@
+package faultproxy
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// Server is a Proxy listening on a local port for the length of a test.
+type Server struct {
+	*Proxy
+
+	// URL is the base URL of the Proxy, of the form http://ipaddr:port with no trailing slash. Point
+	// the clients under test at it instead of the upstream.
+	URL string
+
+	server *httptest.Server
+	once   sync.Once
+}
+
+// Start starts a Proxy in front of upstream for the test t and closes it when the test ends. config
+// is a YAML config in the format of fault.NewFaultsFromConfig, or "" for no config. Start fails the
+// test if the Proxy cannot be built.
+//
+//	func TestCheckoutRetries(t *testing.T) {
+//		proxy := faultproxy.Start(t, upstream.URL, `
+//	version: 1
+//	faults:
+//	  - name: errors
+//	    enabled: true
+//	    participation: 0.5
+//	    injector:
+//	      type: error
+//	      params:
+//	        code: 503
+//	`)
+//		client := checkout.NewClient(proxy.URL)
+//		...
+//	}
+func Start(t testing.TB, upstream, config string, opts ...Option) *Server {
+	t.Helper()
+
+	u, err := url.Parse(upstream)
+	if err != nil {
+		t.Fatalf("faultproxy: %v", err)
+	}
+
+	var p *Proxy
+	if config == "" {
+		p, err = New(u, nil, opts...)
+	} else {
+		p, err = New(u, strings.NewReader(config), opts...)
+	}
+	if err != nil {
+		t.Fatalf("faultproxy: %v", err)
+	}
+
+	s := &Server{
+		Proxy:  p,
+		server: httptest.NewServer(p),
+	}
+	s.URL = s.server.URL
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+// Close shuts the Server down and blocks until all its requests have finished. It is safe to call
+// more than once.
+func (s *Server) Close() {
+	s.once.Do(s.server.Close)
+}
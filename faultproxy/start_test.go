@@ -0,0 +1,94 @@
+package faultproxy
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/github/go-fault"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestStart tests that Start serves a Proxy for the length of the test.
+func TestStart(t *testing.T) {
+	t.Parallel()
+
+	upstream := testUpstream(t)
+
+	var url string
+	t.Run("serve", func(t *testing.T) {
+		s := Start(t, upstream.URL, testConfig)
+		url = s.URL
+
+		code, _ := testGet(t, s.URL+"/users")
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+
+		s.Fault("unavailable").SetEnabled(false)
+
+		code, body := testGet(t, s.URL+"/users")
+		assert.Equal(t, http.StatusOK, code)
+		assert.True(t, strings.HasSuffix(body, " /users "+strings.TrimPrefix(s.URL, "http://")), body)
+	})
+
+	_, err := http.Get(url)
+	assert.Error(t, err)
+}
+
+// TestStartNoConfig tests Start without a config.
+func TestStartNoConfig(t *testing.T) {
+	t.Parallel()
+
+	upstream := testUpstream(t)
+	ei, _ := fault.NewErrorInjector(http.StatusTeapot)
+	teapot, _ := fault.NewFault(ei, fault.WithName("teapot"), fault.WithEnabled(true), fault.WithParticipation(1.0))
+
+	s := Start(t, upstream.URL, "", WithFaults(teapot))
+	code, _ := testGet(t, s.URL)
+	assert.Equal(t, http.StatusTeapot, code)
+
+	s.Close()
+	s.Close()
+}
+
+// TestStartFatal tests that Start fails the test if the Proxy cannot be built.
+func TestStartFatal(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		giveUpstream string
+		giveConfig   string
+		wantMsg      string
+	}{
+		{
+			name:         "invalid url",
+			giveUpstream: "http://[::1",
+			wantMsg:      "missing ']' in host",
+		},
+		{
+			name:         "relative url",
+			giveUpstream: "/api",
+			wantMsg:      fault.ErrInvalidURL.Error(),
+		},
+		{
+			name:         "invalid config",
+			giveUpstream: "http://example.com",
+			giveConfig:   "faults: [{injector: {type: unknown}}]",
+			wantMsg:      fault.ErrUnknownInjectorType.Error(),
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			msg := runFatal(t, func(tb testing.TB) {
+				Start(tb, tt.giveUpstream, tt.giveConfig)
+			})
+
+			assert.True(t, strings.HasPrefix(msg, "faultproxy: "), msg)
+			assert.Contains(t, msg, tt.wantMsg)
+		})
+	}
+}
@@ -0,0 +1,268 @@
+package faultreplay
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var (
+	// ErrNilShadow when a Comparator is not given a shadow handler.
+	ErrNilShadow = errors.New("shadow handler cannot be nil")
+	// ErrInvalidSampleRate when a sample rate is not 0.0 <= r <= 1.0.
+	ErrInvalidSampleRate = errors.New("sample rate must be 0.0 <= r <= 1.0")
+)
+
+// Comparison is the outcome of sending a request through both the faulted and the shadow handler
+// of a Comparator.
+type Comparison struct {
+	// Key identifies the request, as returned by the KeyFunc of the Comparator.
+	Key string
+	// FaultedStatus is the status code of the faulted response, or 0 if it was aborted.
+	FaultedStatus int
+	// ShadowStatus is the status code of the shadow response.
+	ShadowStatus int
+	// Differences are where the responses diverge. It is empty if they match.
+	Differences []Difference
+}
+
+// Diverged returns true if the faulted and shadow responses differ.
+func (c *Comparison) Diverged() bool {
+	return len(c.Differences) > 0
+}
+
+// Difference is a part of the response that diverges between the faulted and shadow handlers.
+type Difference struct {
+	// Field is what differs: "status" or "header " and the canonical name of the header.
+	Field string
+	// Faulted is the value in the faulted response, or "aborted" for the status of an aborted
+	// response.
+	Faulted string
+	// Shadow is the value in the shadow response.
+	Shadow string
+}
+
+// Comparator is middleware that sends sampled requests twice: once through the handler it wraps,
+// which runs the Faults, and once through a clean shadow handler, such as the same application
+// without Faults or a proxy to a shadow upstream. The client gets the faulted response, and each
+// sampled request is reported with where the two responses diverged, to tell whether a handler
+// absorbs a fault or amplifies it.
+type Comparator struct {
+	shadow  http.Handler
+	reportF func(*Comparison)
+	keyF    KeyFunc
+	headers []string
+
+	sampleRate float32
+	rand       *rand.Rand
+	// *rand.Rand is not thread safe. This mutex protects our random source
+	randMtx sync.Mutex
+}
+
+// ComparatorOption configures a Comparator.
+type ComparatorOption interface {
+	applyComparator(c *Comparator) error
+}
+
+func (o keyFuncOption) applyComparator(c *Comparator) error {
+	c.keyF = KeyFunc(o)
+	return nil
+}
+
+type compareHeadersOption []string
+
+func (o compareHeadersOption) applyComparator(c *Comparator) error {
+	c.headers = make([]string, len(o))
+	for idx, h := range o {
+		c.headers[idx] = http.CanonicalHeaderKey(h)
+	}
+	return nil
+}
+
+// WithCompareHeaders sets the headers whose values are compared. Default "Content-Type".
+func WithCompareHeaders(headers ...string) ComparatorOption {
+	return compareHeadersOption(headers)
+}
+
+type sampleRateOption float32
+
+func (o sampleRateOption) applyComparator(c *Comparator) error {
+	if o < 0 || o > 1 {
+		return ErrInvalidSampleRate
+	}
+
+	c.sampleRate = float32(o)
+
+	return nil
+}
+
+// WithSampleRate sets the share of requests (0.0 <= r <= 1.0) that are also sent to the shadow
+// handler and compared. Default 1.0.
+func WithSampleRate(r float32) ComparatorOption {
+	return sampleRateOption(r)
+}
+
+// NewComparator returns a Comparator that compares responses with those of shadow and passes each
+// Comparison to report. report may be called from many goroutines at once.
+func NewComparator(shadow http.Handler, report func(*Comparison), opts ...ComparatorOption) (*Comparator, error) {
+	if shadow == nil {
+		return nil, ErrNilShadow
+	}
+
+	// set defaults
+	c := &Comparator{
+		shadow:     shadow,
+		reportF:    report,
+		keyF:       DefaultKey,
+		headers:    []string{"Content-Type"},
+		sampleRate: 1.0,
+		rand:       rand.New(rand.NewSource(1)),
+	}
+
+	// apply options
+	for _, opt := range opts {
+		err := opt.applyComparator(c)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if c.reportF == nil {
+		c.reportF = func(*Comparison) {}
+	}
+
+	return c, nil
+}
+
+// Handler runs sampled requests through next and then through the shadow handler, and reports the
+// Comparison. The request body is buffered so both handlers read all of it. A faulted handler that
+// panics, such as one aborted by a RejectInjector, is reported as aborted before the panic
+// continues.
+func (c *Comparator) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !c.sampled() {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		var body []byte
+		if r.Body != nil {
+			body, _ = ioutil.ReadAll(r.Body)
+			r.Body.Close()
+			r.Body = ioutil.NopCloser(bytes.NewReader(body))
+		}
+		sr := r.Clone(r.Context())
+
+		rw := &recordingWriter{ResponseWriter: w, code: http.StatusOK}
+		aborted := true
+		defer func() {
+			if !aborted {
+				return
+			}
+
+			// a nil recover is runtime.Goexit, which continues on its own
+			if v := recover(); v != nil {
+				c.compare(sr, body, rw.code, w.Header(), true)
+				panic(v)
+			}
+		}()
+
+		next.ServeHTTP(rw, r)
+		aborted = false
+
+		c.compare(sr, body, rw.code, w.Header(), false)
+	})
+}
+
+// sampled returns true if the request should be compared.
+func (c *Comparator) sampled() bool {
+	if c.sampleRate >= 1 {
+		return true
+	}
+
+	c.randMtx.Lock()
+	defer c.randMtx.Unlock()
+
+	return c.rand.Float32() < c.sampleRate
+}
+
+// compare sends r with body to the shadow handler and reports how its response differs from the
+// faulted response with code and header.
+func (c *Comparator) compare(r *http.Request, body []byte, code int, header http.Header, aborted bool) {
+	if body != nil {
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+
+	sw := &shadowWriter{header: make(http.Header)}
+	c.shadow.ServeHTTP(sw, r)
+	if sw.code == 0 {
+		sw.code = http.StatusOK
+	}
+
+	cmp := &Comparison{
+		Key:           c.keyF(r),
+		FaultedStatus: code,
+		ShadowStatus:  sw.code,
+	}
+
+	// the headers of an aborted response never reach the client
+	if aborted {
+		cmp.FaultedStatus = 0
+		cmp.Differences = append(cmp.Differences, Difference{
+			Field:   "status",
+			Faulted: "aborted",
+			Shadow:  strconv.Itoa(sw.code),
+		})
+		c.reportF(cmp)
+		return
+	}
+
+	if code != sw.code {
+		cmp.Differences = append(cmp.Differences, Difference{
+			Field:   "status",
+			Faulted: strconv.Itoa(code),
+			Shadow:  strconv.Itoa(sw.code),
+		})
+	}
+
+	for _, h := range c.headers {
+		f, s := strings.Join(header.Values(h), ", "), strings.Join(sw.header.Values(h), ", ")
+		if f != s {
+			cmp.Differences = append(cmp.Differences, Difference{Field: "header " + h, Faulted: f, Shadow: s})
+		}
+	}
+
+	c.reportF(cmp)
+}
+
+// shadowWriter records the response of the shadow handler, which is not sent to the client.
+type shadowWriter struct {
+	header http.Header
+	code   int
+}
+
+// Header returns the response headers.
+func (w *shadowWriter) Header() http.Header {
+	return w.header
+}
+
+// WriteHeader records the first status code.
+func (w *shadowWriter) WriteHeader(code int) {
+	if w.code == 0 {
+		w.code = code
+	}
+}
+
+// Write discards b, since bodies are not compared.
+func (w *shadowWriter) Write(b []byte) (int, error) {
+	w.WriteHeader(http.StatusOK)
+	return ioutil.Discard.Write(b)
+}
+
+// Flush does nothing; the shadow response is not sent.
+func (w *shadowWriter) Flush() {}
@@ -0,0 +1,290 @@
+package faultreplay
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestNewComparator tests NewComparator.
+func TestNewComparator(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name           string
+		giveShadow     http.Handler
+		giveOptions    []ComparatorOption
+		wantHeaders    []string
+		wantSampleRate float32
+		wantErr        error
+	}{
+		{
+			name:           "defaults",
+			giveShadow:     http.NotFoundHandler(),
+			wantHeaders:    []string{"Content-Type"},
+			wantSampleRate: 1.0,
+		},
+		{
+			name:       "options",
+			giveShadow: http.NotFoundHandler(),
+			giveOptions: []ComparatorOption{
+				WithCompareHeaders("retry-after", "Cache-Control"),
+				WithSampleRate(0.25),
+				WithKeyFunc(func(r *http.Request) string { return "key" }),
+			},
+			wantHeaders:    []string{"Retry-After", "Cache-Control"},
+			wantSampleRate: 0.25,
+		},
+		{
+			name:       "no headers",
+			giveShadow: http.NotFoundHandler(),
+			giveOptions: []ComparatorOption{
+				WithCompareHeaders(),
+			},
+			wantHeaders:    []string{},
+			wantSampleRate: 1.0,
+		},
+		{
+			name:       "nil shadow",
+			giveShadow: nil,
+			wantErr:    ErrNilShadow,
+		},
+		{
+			name:        "sample rate too high",
+			giveShadow:  http.NotFoundHandler(),
+			giveOptions: []ComparatorOption{WithSampleRate(1.1)},
+			wantErr:     ErrInvalidSampleRate,
+		},
+		{
+			name:        "sample rate too low",
+			giveShadow:  http.NotFoundHandler(),
+			giveOptions: []ComparatorOption{WithSampleRate(-0.1)},
+			wantErr:     ErrInvalidSampleRate,
+		},
+		{
+			name:        "option error",
+			giveShadow:  http.NotFoundHandler(),
+			giveOptions: []ComparatorOption{errorOption{}},
+			wantErr:     errErrorOption,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			c, err := NewComparator(tt.giveShadow, nil, tt.giveOptions...)
+
+			assert.Equal(t, tt.wantErr, err)
+			if tt.wantErr != nil {
+				assert.Nil(t, c)
+				return
+			}
+
+			assert.Equal(t, tt.wantHeaders, c.headers)
+			assert.Equal(t, tt.wantSampleRate, c.sampleRate)
+
+			// a nil report function is a no-op
+			c.reportF(&Comparison{})
+		})
+	}
+}
+
+// TestComparatorHandler tests that Comparator.Handler sends the faulted response to the client and
+// reports how it diverges from the shadow response.
+func TestComparatorHandler(t *testing.T) {
+	t.Parallel()
+
+	healthy := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Retry-After", "0")
+		_, _ = w.Write([]byte(`{"ok":true}`))
+	}
+
+	tests := []struct {
+		name        string
+		giveFaulted http.HandlerFunc
+		giveOptions []ComparatorOption
+		wantCode    int
+		wantBody    string
+		want        *Comparison
+	}{
+		{
+			name:        "same",
+			giveFaulted: healthy,
+			wantCode:    http.StatusOK,
+			wantBody:    `{"ok":true}`,
+			want: &Comparison{
+				Key:           "POST /users",
+				FaultedStatus: http.StatusOK,
+				ShadowStatus:  http.StatusOK,
+			},
+		},
+		{
+			name: "status and header",
+			giveFaulted: func(w http.ResponseWriter, r *http.Request) {
+				http.Error(w, "upstream failed", http.StatusBadGateway)
+			},
+			wantCode: http.StatusBadGateway,
+			wantBody: "upstream failed\n",
+			want: &Comparison{
+				Key:           "POST /users",
+				FaultedStatus: http.StatusBadGateway,
+				ShadowStatus:  http.StatusOK,
+				Differences: []Difference{
+					{Field: "status", Faulted: "502", Shadow: "200"},
+					{Field: "header Content-Type", Faulted: "text/plain; charset=utf-8", Shadow: "application/json"},
+				},
+			},
+		},
+		{
+			name: "compared headers",
+			giveFaulted: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/html")
+				w.Header().Set("Retry-After", "30")
+				_, _ = w.Write([]byte(`{"ok":true}`))
+			},
+			giveOptions: []ComparatorOption{WithCompareHeaders("retry-after")},
+			wantCode:    http.StatusOK,
+			wantBody:    `{"ok":true}`,
+			want: &Comparison{
+				Key:           "POST /users",
+				FaultedStatus: http.StatusOK,
+				ShadowStatus:  http.StatusOK,
+				Differences: []Difference{
+					{Field: "header Retry-After", Faulted: "30", Shadow: "0"},
+				},
+			},
+		},
+		{
+			name:        "key func",
+			giveFaulted: healthy,
+			giveOptions: []ComparatorOption{
+				WithKeyFunc(func(r *http.Request) string { return r.URL.Path }),
+			},
+			wantCode: http.StatusOK,
+			wantBody: `{"ok":true}`,
+			want: &Comparison{
+				Key:           "/users",
+				FaultedStatus: http.StatusOK,
+				ShadowStatus:  http.StatusOK,
+			},
+		},
+		{
+			name:        "not sampled",
+			giveFaulted: healthy,
+			giveOptions: []ComparatorOption{WithSampleRate(0)},
+			wantCode:    http.StatusOK,
+			wantBody:    `{"ok":true}`,
+			want:        nil,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var shadowBody string
+			shadow := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				b, _ := ioutil.ReadAll(r.Body)
+				shadowBody = string(b)
+				healthy(w, r)
+			})
+
+			var got *Comparison
+			c, err := NewComparator(shadow, func(cmp *Comparison) { got = cmp }, tt.giveOptions...)
+			assert.NoError(t, err)
+
+			var faultedBody string
+			h := c.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				b, _ := ioutil.ReadAll(r.Body)
+				faultedBody = string(b)
+				tt.giveFaulted(w, r)
+			}))
+
+			rr := httptest.NewRecorder()
+			h.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/users", strings.NewReader("name=test")))
+
+			assert.Equal(t, tt.wantCode, rr.Code)
+			assert.Equal(t, tt.wantBody, rr.Body.String())
+			assert.Equal(t, "name=test", faultedBody)
+			assert.Equal(t, tt.want, got)
+			if tt.want != nil {
+				assert.Equal(t, "name=test", shadowBody)
+				assert.Equal(t, len(tt.want.Differences) > 0, got.Diverged())
+			}
+		})
+	}
+}
+
+// TestComparatorHandlerAborted tests that a faulted handler that aborts is reported before the
+// panic continues.
+func TestComparatorHandlerAborted(t *testing.T) {
+	t.Parallel()
+
+	var got *Comparison
+	c, err := NewComparator(http.NotFoundHandler(), func(cmp *Comparison) { got = cmp })
+	assert.NoError(t, err)
+
+	h := c.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		panic(http.ErrAbortHandler)
+	}))
+
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users", nil))
+	})
+	assert.Equal(t, &Comparison{
+		Key:           "GET /users",
+		FaultedStatus: 0,
+		ShadowStatus:  http.StatusNotFound,
+		Differences: []Difference{
+			{Field: "status", Faulted: "aborted", Shadow: "404"},
+		},
+	}, got)
+	assert.True(t, got.Diverged())
+}
+
+// TestComparatorHandlerNoBody tests that requests without a body are compared.
+func TestComparatorHandlerNoBody(t *testing.T) {
+	t.Parallel()
+
+	var got *Comparison
+	c, err := NewComparator(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.(http.Flusher).Flush()
+	}), func(cmp *Comparison) { got = cmp })
+	assert.NoError(t, err)
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Body = nil
+	c.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Nil(t, r.Body)
+		w.Header().Set("Content-Type", "text/plain")
+		w.WriteHeader(http.StatusOK)
+	})).ServeHTTP(httptest.NewRecorder(), r)
+
+	assert.Equal(t, &Comparison{Key: "GET /", FaultedStatus: http.StatusOK, ShadowStatus: http.StatusOK}, got)
+}
+
+// TestComparatorSampleRate tests that Comparator compares about the share of requests it samples.
+func TestComparatorSampleRate(t *testing.T) {
+	t.Parallel()
+
+	var compared int
+	c, err := NewComparator(http.NotFoundHandler(), func(*Comparison) { compared++ }, WithSampleRate(0.5))
+	assert.NoError(t, err)
+
+	h := c.Handler(http.NotFoundHandler())
+	for n := 0; n < 1000; n++ {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	}
+
+	assert.InDelta(t, 500, compared, 75)
+}
@@ -0,0 +1,43 @@
+/*
+Package faultreplay records real responses to disk and replays them later, so fault injection runs
+are deterministic and do not depend on a live upstream.
+
+Recording
+
+Place a Recorder in front of the real upstream handler (for example an httputil.ReverseProxy). It
+passes every request through and saves the response it returns:
+
+    rec, _ := faultreplay.NewRecorder("testdata/recordings")
+    http.ListenAndServe(":8080", rec.Handler(proxy))
+
+Replaying
+
+A Replayer is an http.Handler that answers requests from the recordings. Wrap it in Faults to
+replay the recorded responses with latency, truncation, different status codes, or any other
+Injector:
+
+    rep, _ := faultreplay.NewReplayer("testdata/recordings")
+    slow, _ := fault.NewFault(si, fault.WithEnabled(true), fault.WithParticipation(1.0))
+    http.ListenAndServe(":8080", slow.Handler(rep))
+
+Requests are matched to recordings by method, path, and query by default. Pass WithKeyFunc() to
+both the Recorder and Replayer to match differently.
+
+Comparing
+
+A Comparator tells whether a handler absorbs a fault or amplifies it. It wraps the faulted handler
+and sends each sampled request a second time to a clean shadow handler, such as the same
+application without Faults or a Replayer. The client gets the faulted response, and every
+Comparison reports where the status code and chosen headers of the two responses diverged:
+
+    cmp, _ := faultreplay.NewComparator(app, func(c *faultreplay.Comparison) {
+        if c.Diverged() {
+            log.Printf("%s: %+v", c.Key, c.Differences)
+        }
+    }, faultreplay.WithSampleRate(0.1), faultreplay.WithCompareHeaders("Content-Type", "Retry-After"))
+    http.ListenAndServe(":8080", cmp.Handler(f.Handler(app)))
+
+Only run a Comparator in front of handlers that are safe to run twice per request.
+
+*/
+package faultreplay
@@ -0,0 +1,39 @@
+package faultreplay
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+// errErrorOption is returned by errorOption.
+var errErrorOption = errors.New("intentional error for tests")
+
+// errorOption is an Option that always fails.
+type errorOption struct{}
+
+func (errorOption) applyRecorder(r *Recorder) error {
+	return errErrorOption
+}
+
+func (errorOption) applyReplayer(r *Replayer) error {
+	return errErrorOption
+}
+
+func (errorOption) applyComparator(c *Comparator) error {
+	return errErrorOption
+}
+
+// testDir returns a temporary directory that is removed when the test ends.
+func testDir(t *testing.T) string {
+	t.Helper()
+
+	dir, err := ioutil.TempDir("", "faultreplay")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+
+	return dir
+}
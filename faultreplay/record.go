@@ -0,0 +1,114 @@
+package faultreplay
+
+import (
+	"bytes"
+	"net/http"
+	"os"
+)
+
+// Recorder is middleware that saves the responses of the handler it wraps.
+type Recorder struct {
+	dir  string
+	keyF KeyFunc
+
+	// errF receives errors from saving Recordings.
+	errF func(error)
+}
+
+// RecorderOption configures a Recorder.
+type RecorderOption interface {
+	applyRecorder(r *Recorder) error
+}
+
+type errorFuncOption func(error)
+
+func (o errorFuncOption) applyRecorder(r *Recorder) error {
+	r.errF = o
+	return nil
+}
+
+// WithErrorFunc sets the function that receives errors from saving Recordings. Responses are still
+// sent when saving fails. Default ignores errors.
+func WithErrorFunc(f func(error)) RecorderOption {
+	return errorFuncOption(f)
+}
+
+// NewRecorder returns a Recorder that saves to dir, creating it if needed.
+func NewRecorder(dir string, opts ...RecorderOption) (*Recorder, error) {
+	// set defaults
+	rec := &Recorder{
+		dir:  dir,
+		keyF: DefaultKey,
+		errF: func(error) {},
+	}
+
+	// apply options
+	for _, opt := range opts {
+		err := opt.applyRecorder(rec)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	return rec, nil
+}
+
+// Handler runs the request and saves the response after it is sent.
+func (rec *Recorder) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rw := &recordingWriter{ResponseWriter: w, code: http.StatusOK}
+		next.ServeHTTP(rw, r)
+
+		err := save(rec.dir, &Recording{
+			Key:        rec.keyF(r),
+			StatusCode: rw.code,
+			Header:     w.Header().Clone(),
+			Body:       rw.body.Bytes(),
+		})
+		if err != nil {
+			rec.errF(err)
+		}
+	})
+}
+
+// recordingWriter is an http.ResponseWriter that keeps a copy of the response it writes.
+type recordingWriter struct {
+	http.ResponseWriter
+
+	code        int
+	body        bytes.Buffer
+	wroteHeader bool
+}
+
+// WriteHeader records the status code and writes it.
+func (w *recordingWriter) WriteHeader(code int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		w.code = code
+	}
+
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Write records b and writes it.
+func (w *recordingWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	n, err := w.ResponseWriter.Write(b)
+	w.body.Write(b[:n])
+
+	return n, err
+}
+
+// Flush flushes the underlying ResponseWriter if it can.
+func (w *recordingWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
@@ -0,0 +1,125 @@
+package faultreplay
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestNewRecorder tests NewRecorder.
+func TestNewRecorder(t *testing.T) {
+	t.Parallel()
+
+	dir := filepath.Join(testDir(t), "a", "b")
+
+	rec, err := NewRecorder(dir, WithKeyFunc(func(r *http.Request) string { return "key" }))
+	assert.NoError(t, err)
+	assert.Equal(t, dir, rec.dir)
+	assert.Equal(t, "key", rec.keyF(nil))
+	assert.DirExists(t, dir)
+
+	_, err = NewRecorder(dir, errorOption{})
+	assert.Equal(t, errErrorOption, err)
+
+	file := filepath.Join(testDir(t), "file")
+	assert.NoError(t, ioutil.WriteFile(file, nil, 0o600))
+	_, err = NewRecorder(filepath.Join(file, "dir"))
+	assert.Error(t, err)
+}
+
+// TestRecorderHandler tests that Recorder.Handler passes responses through and saves them.
+func TestRecorderHandler(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		giveWrite func(w http.ResponseWriter)
+		want      *Recording
+	}{
+		{
+			name: "status and body",
+			giveWrite: func(w http.ResponseWriter) {
+				w.Header().Set("X-Test", "test")
+				w.WriteHeader(http.StatusCreated)
+				_, _ = w.Write([]byte("created"))
+				w.(http.Flusher).Flush()
+			},
+			want: &Recording{
+				Key:        "GET /users",
+				StatusCode: http.StatusCreated,
+				Header:     http.Header{"X-Test": {"test"}},
+				Body:       []byte("created"),
+			},
+		},
+		{
+			name: "implicit status",
+			giveWrite: func(w http.ResponseWriter) {
+				_, _ = w.Write([]byte("ok"))
+			},
+			want: &Recording{
+				Key:        "GET /users",
+				StatusCode: http.StatusOK,
+				Header:     http.Header{},
+				Body:       []byte("ok"),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			rec, err := NewRecorder(testDir(t))
+			assert.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			rec.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				tt.giveWrite(w)
+			})).ServeHTTP(rr, httptest.NewRequest("GET", "/users", nil))
+
+			assert.Equal(t, tt.want.StatusCode, rr.Code)
+			assert.Equal(t, string(tt.want.Body), rr.Body.String())
+
+			got, err := load(rec.dir, "GET /users")
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+// TestRecorderHandlerError tests that errors saving Recordings go to the error function.
+func TestRecorderHandlerError(t *testing.T) {
+	t.Parallel()
+
+	var got error
+	dir := testDir(t)
+	rec, err := NewRecorder(dir, WithErrorFunc(func(err error) { got = err }))
+	assert.NoError(t, err)
+	assert.NoError(t, os.Remove(dir))
+
+	rr := httptest.NewRecorder()
+	rec.Handler(http.NotFoundHandler()).ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assert.Error(t, got)
+	assert.True(t, errors.Is(got, os.ErrNotExist))
+}
+
+// TestRecordingWriterFlushNotFlusher tests that recordingWriter.Flush works when the underlying
+// ResponseWriter cannot flush.
+func TestRecordingWriterFlushNotFlusher(t *testing.T) {
+	t.Parallel()
+
+	rr := httptest.NewRecorder()
+	w := &recordingWriter{ResponseWriter: struct{ http.ResponseWriter }{rr}}
+	w.Flush()
+
+	assert.False(t, rr.Flushed)
+}
@@ -0,0 +1,101 @@
+package faultreplay
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// Recording is a response saved to disk.
+type Recording struct {
+	// Key identifies the requests the Recording answers.
+	Key string `json:"key"`
+	// StatusCode is the status code of the response.
+	StatusCode int `json:"status_code"`
+	// Header is the header of the response.
+	Header http.Header `json:"header"`
+	// Body is the body of the response.
+	Body []byte `json:"body"`
+}
+
+// KeyFunc returns the key that matches a request to a Recording.
+type KeyFunc func(r *http.Request) string
+
+// DefaultKey returns the method, path, and query of r, such as "GET /users?page=2".
+func DefaultKey(r *http.Request) string {
+	return r.Method + " " + r.URL.RequestURI()
+}
+
+// Option configures a Recorder, Replayer, or Comparator.
+type Option interface {
+	applyRecorder(r *Recorder) error
+	applyReplayer(r *Replayer) error
+	applyComparator(c *Comparator) error
+}
+
+type keyFuncOption KeyFunc
+
+func (o keyFuncOption) applyRecorder(r *Recorder) error {
+	r.keyF = KeyFunc(o)
+	return nil
+}
+
+func (o keyFuncOption) applyReplayer(r *Replayer) error {
+	r.keyF = KeyFunc(o)
+	return nil
+}
+
+// WithKeyFunc sets the function that matches requests to Recordings. Default DefaultKey.
+func WithKeyFunc(f KeyFunc) Option {
+	return keyFuncOption(f)
+}
+
+// path returns the file a Recording with key is stored in under dir.
+func path(dir, key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// load reads the Recording with key from dir.
+func load(dir, key string) (*Recording, error) {
+	b, err := ioutil.ReadFile(path(dir, key))
+	if err != nil {
+		return nil, err
+	}
+
+	var rec Recording
+	if err := json.Unmarshal(b, &rec); err != nil {
+		return nil, err
+	}
+
+	return &rec, nil
+}
+
+// save writes rec to dir, replacing any Recording with the same key. The file is written under a
+// temporary name and renamed so a Replayer never reads a partial Recording.
+func save(dir string, rec *Recording) error {
+	// a Recording only holds types that always marshal
+	b, _ := json.MarshalIndent(rec, "", "  ")
+
+	tmp, err := ioutil.TempFile(dir, ".recording-*")
+	if err != nil {
+		return err
+	}
+
+	_, err = tmp.Write(b)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path(dir, rec.Key))
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+	}
+
+	return err
+}
@@ -0,0 +1,72 @@
+package faultreplay
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestDefaultKey tests DefaultKey.
+func TestDefaultKey(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "GET /users?page=2", DefaultKey(httptest.NewRequest("GET", "/users?page=2", nil)))
+	assert.Equal(t, "POST /", DefaultKey(httptest.NewRequest("POST", "/", nil)))
+}
+
+// TestSaveLoad tests that a saved Recording loads unchanged.
+func TestSaveLoad(t *testing.T) {
+	t.Parallel()
+
+	dir := testDir(t)
+	want := &Recording{
+		Key:        "GET /",
+		StatusCode: http.StatusTeapot,
+		Header:     http.Header{"Content-Type": {"text/plain"}},
+		Body:       []byte("body"),
+	}
+
+	assert.NoError(t, save(dir, want))
+
+	got, err := load(dir, "GET /")
+	assert.NoError(t, err)
+	assert.Equal(t, want, got)
+
+	files, err := ioutil.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Len(t, files, 1)
+}
+
+// TestLoadErrors tests that load fails for missing and corrupt Recordings.
+func TestLoadErrors(t *testing.T) {
+	t.Parallel()
+
+	dir := testDir(t)
+
+	_, err := load(dir, "GET /missing")
+	assert.True(t, os.IsNotExist(err))
+
+	assert.NoError(t, ioutil.WriteFile(path(dir, "GET /corrupt"), []byte("{"), 0o600))
+	_, err = load(dir, "GET /corrupt")
+	assert.Error(t, err)
+}
+
+// TestSaveErrors tests that save fails when the Recording cannot be written.
+func TestSaveErrors(t *testing.T) {
+	t.Parallel()
+
+	dir := testDir(t)
+
+	err := save(filepath.Join(dir, "missing"), &Recording{Key: "GET /"})
+	assert.Error(t, err)
+
+	// a directory in the way of the Recording makes the rename fail
+	assert.NoError(t, os.Mkdir(path(dir, "GET /"), 0o755))
+	err = save(dir, &Recording{Key: "GET /"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,59 @@
+package faultreplay
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+)
+
+// Replayer is an http.Handler that responds with saved Recordings.
+type Replayer struct {
+	dir  string
+	keyF KeyFunc
+}
+
+// ReplayerOption configures a Replayer.
+type ReplayerOption interface {
+	applyReplayer(r *Replayer) error
+}
+
+// NewReplayer returns a Replayer that reads Recordings from dir.
+func NewReplayer(dir string, opts ...ReplayerOption) (*Replayer, error) {
+	// set defaults
+	rep := &Replayer{
+		dir:  dir,
+		keyF: DefaultKey,
+	}
+
+	// apply options
+	for _, opt := range opts {
+		err := opt.applyReplayer(rep)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := os.Stat(dir); err != nil {
+		return nil, err
+	}
+
+	return rep, nil
+}
+
+// ServeHTTP responds with the Recording for r. Requests without a Recording get a 502 Bad Gateway,
+// like a proxy with no upstream.
+func (rep *Replayer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	key := rep.keyF(r)
+
+	rec, err := load(rep.dir, key)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("faultreplay: no recording for %q", key), http.StatusBadGateway)
+		return
+	}
+
+	for k, v := range rec.Header {
+		w.Header()[k] = v
+	}
+	w.WriteHeader(rec.StatusCode)
+	_, _ = w.Write(rec.Body)
+}
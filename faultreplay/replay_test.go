@@ -0,0 +1,96 @@
+package faultreplay
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/github/go-fault"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestNewReplayer tests NewReplayer.
+func TestNewReplayer(t *testing.T) {
+	t.Parallel()
+
+	dir := testDir(t)
+
+	rep, err := NewReplayer(dir, WithKeyFunc(func(r *http.Request) string { return "key" }))
+	assert.NoError(t, err)
+	assert.Equal(t, dir, rep.dir)
+	assert.Equal(t, "key", rep.keyF(nil))
+
+	_, err = NewReplayer(dir, errorOption{})
+	assert.Equal(t, errErrorOption, err)
+
+	_, err = NewReplayer(filepath.Join(dir, "missing"))
+	assert.Error(t, err)
+}
+
+// TestReplayer tests that a Replayer responds with what a Recorder saved, with and without Faults.
+func TestReplayer(t *testing.T) {
+	t.Parallel()
+
+	dir := testDir(t)
+
+	rec, err := NewRecorder(dir)
+	assert.NoError(t, err)
+
+	upstream := rec.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`[1, 2, 3]`))
+	}))
+	upstream.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/items", nil))
+
+	rep, err := NewReplayer(dir)
+	assert.NoError(t, err)
+
+	ji, err := fault.NewJSONTruncateInjector(1)
+	assert.NoError(t, err)
+	f, err := fault.NewFault(ji, fault.WithEnabled(true), fault.WithParticipation(1.0))
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		giveHandler http.Handler
+		givePath    string
+		wantCode    int
+		wantBody    string
+	}{
+		{
+			name:        "replayed",
+			giveHandler: rep,
+			givePath:    "/items",
+			wantCode:    http.StatusOK,
+			wantBody:    `[1, 2, 3]`,
+		},
+		{
+			name:        "replayed with fault",
+			giveHandler: f.Handler(rep),
+			givePath:    "/items",
+			wantCode:    http.StatusOK,
+			wantBody:    `[1]`,
+		},
+		{
+			name:        "no recording",
+			giveHandler: rep,
+			givePath:    "/missing",
+			wantCode:    http.StatusBadGateway,
+			wantBody:    "faultreplay: no recording for \"GET /missing\"\n",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			rr := httptest.NewRecorder()
+			tt.giveHandler.ServeHTTP(rr, httptest.NewRequest("GET", tt.givePath, nil))
+
+			assert.Equal(t, tt.wantCode, rr.Code)
+			assert.Equal(t, tt.wantBody, rr.Body.String())
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package faulttest
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock is a fake clock whose time only changes when it is advanced. It satisfies fault.Clock.
+type Clock struct {
+	mtx     sync.Mutex
+	cond    *sync.Cond
+	now     time.Time
+	waiters []*waiter
+}
+
+// waiter is a pending call to Clock.After or Clock.Sleep.
+type waiter struct {
+	until time.Time
+	ch    chan time.Time
+}
+
+// NewClock returns a new Clock set to now.
+func NewClock(now time.Time) *Clock {
+	c := &Clock{
+		now: now,
+	}
+	c.cond = sync.NewCond(&c.mtx)
+
+	return c
+}
+
+// Now returns the current fake time.
+func (c *Clock) Now() time.Time {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	return c.now
+}
+
+// Sleep blocks until the clock has been advanced by at least d.
+func (c *Clock) Sleep(d time.Duration) {
+	<-c.After(d)
+}
+
+// After returns a channel that receives the fake time once the clock has been advanced by at least
+// d. Durations less than or equal to zero fire immediately.
+func (c *Clock) After(d time.Duration) <-chan time.Time {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- c.now
+		return ch
+	}
+
+	c.waiters = append(c.waiters, &waiter{until: c.now.Add(d), ch: ch})
+	c.cond.Broadcast()
+
+	return ch
+}
+
+// Advance moves the clock forward by d and wakes any waiters that are due.
+func (c *Clock) Advance(d time.Duration) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	c.now = c.now.Add(d)
+
+	pending := c.waiters[:0]
+	for _, w := range c.waiters {
+		if w.until.After(c.now) {
+			pending = append(pending, w)
+			continue
+		}
+		w.ch <- c.now
+	}
+	c.waiters = pending
+	c.cond.Broadcast()
+}
+
+// Waiters returns the number of goroutines currently waiting on the clock.
+func (c *Clock) Waiters() int {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	return len(c.waiters)
+}
+
+// BlockUntil blocks until at least n goroutines are waiting on the clock.
+func (c *Clock) BlockUntil(n int) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	for len(c.waiters) < n {
+		c.cond.Wait()
+	}
+}
@@ -0,0 +1,104 @@
+package faulttest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testTime is an arbitrary fixed time for tests.
+var testTime = time.Date(2020, time.May, 21, 0, 0, 0, 0, time.UTC)
+
+// TestClockNow tests Clock.Now.
+func TestClockNow(t *testing.T) {
+	t.Parallel()
+
+	c := NewClock(testTime)
+	assert.Equal(t, testTime, c.Now())
+
+	c.Advance(time.Minute)
+	assert.Equal(t, testTime.Add(time.Minute), c.Now())
+}
+
+// TestClockAfter tests Clock.After.
+func TestClockAfter(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		giveAfter   time.Duration
+		giveAdvance time.Duration
+		wantFired   bool
+	}{
+		{
+			name:        "zero",
+			giveAfter:   0,
+			giveAdvance: 0,
+			wantFired:   true,
+		},
+		{
+			name:        "negative",
+			giveAfter:   -time.Second,
+			giveAdvance: 0,
+			wantFired:   true,
+		},
+		{
+			name:        "not advanced enough",
+			giveAfter:   time.Minute,
+			giveAdvance: time.Second,
+			wantFired:   false,
+		},
+		{
+			name:        "advanced exactly",
+			giveAfter:   time.Minute,
+			giveAdvance: time.Minute,
+			wantFired:   true,
+		},
+		{
+			name:        "advanced past",
+			giveAfter:   time.Minute,
+			giveAdvance: time.Hour,
+			wantFired:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			c := NewClock(testTime)
+			ch := c.After(tt.giveAfter)
+			c.Advance(tt.giveAdvance)
+
+			select {
+			case <-ch:
+				assert.True(t, tt.wantFired)
+				assert.Equal(t, 0, c.Waiters())
+			default:
+				assert.False(t, tt.wantFired)
+				assert.Equal(t, 1, c.Waiters())
+			}
+		})
+	}
+}
+
+// TestClockSleep tests Clock.Sleep and Clock.BlockUntil.
+func TestClockSleep(t *testing.T) {
+	t.Parallel()
+
+	c := NewClock(testTime)
+	done := make(chan struct{})
+
+	go func() {
+		c.Sleep(time.Hour)
+		close(done)
+	}()
+
+	c.BlockUntil(1)
+	c.Advance(time.Hour)
+	<-done
+
+	assert.Equal(t, 0, c.Waiters())
+}
@@ -0,0 +1,19 @@
+/*
+Package faulttest provides utilities for testing code that uses the fault package.
+
+Clock
+
+Use faulttest.Clock in place of the real clock to control the passage of time in your tests. Time
+only moves forward when you call Clock.Advance, so features like the SlowInjector can be tested
+without real sleeps:
+
+    clock := faulttest.NewClock(time.Now())
+    si, _ := fault.NewSlowInjector(time.Hour, fault.WithClock(clock))
+
+    go handler.ServeHTTP(rr, req)
+
+    clock.BlockUntil(1)       // wait for the SlowInjector to start sleeping
+    clock.Advance(time.Hour)  // wake it up
+
+*/
+package faulttest
@@ -0,0 +1,106 @@
+package fault
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"time"
+)
+
+// ErrInvalidJitter when a negative start jitter is provided.
+var ErrInvalidJitter = errors.New("start jitter must be >= 0")
+
+// FleetMember returns true if instance is one of the percent (0.0 <= percent <= 1.0) of instances
+// in a fleet that take part in experiment. Every instance decides on its own by hashing its id, so
+// a fleet agrees on its members without coordinating, and the same instance always decides the
+// same way. Different experiments pick their members independently.
+func FleetMember(experiment, instance string, percent float32) bool {
+	return hashFraction(experiment, instance) < float64(percent)
+}
+
+type fleetPercentOption struct {
+	experiment string
+	instance   string
+	percent    float32
+}
+
+func (o fleetPercentOption) applyFault(f *Fault) error {
+	if o.percent < 0.0 || o.percent > 1.0 {
+		return ErrInvalidPercent
+	}
+
+	instance := o.instance
+	if instance == "" {
+		var err error
+		instance, err = os.Hostname()
+		if err != nil {
+			return err
+		}
+	}
+
+	member := FleetMember(o.experiment, instance, o.percent)
+	f.matchers = append(f.matchers, RequestMatcherFunc(func(r *http.Request) bool {
+		return member
+	}))
+
+	return nil
+}
+
+// WithFleetPercent limits the Fault to percent (0.0 <= percent <= 1.0) of the instances in a fleet,
+// chosen by FleetMember(), and leaves every request unmatched on the others. Combine it with
+// WithParticipation() to express experiments like "fault 10% of pods at 100% of their requests"
+// without a coordination backend. instance is the id of this instance, such as the name of its
+// pod; empty uses the hostname. Name the experiment so that each one picks its own instances.
+func WithFleetPercent(experiment, instance string, percent float32) Option {
+	return fleetPercentOption{experiment: experiment, instance: instance, percent: percent}
+}
+
+// StartJitter returns how long instance waits, between 0 and max, before it starts injecting when a
+// scheduled experiment activates. Like FleetMember, every instance decides on its own by hashing
+// its id, so the starts of a fleet spread over max without coordinating, and the same instance
+// always waits the same time.
+func StartJitter(experiment, instance string, max time.Duration) time.Duration {
+	return time.Duration(hashFraction(experiment, instance) * float64(max))
+}
+
+// JitteredSchedule is a Schedule that becomes active a per-instance delay after the Schedule it
+// wraps, so that a fleet does not begin injecting in the same second and create an artificial cliff
+// in dashboards. It stops when the wrapped Schedule stops. An active period shorter than the delay
+// is skipped.
+type JitteredSchedule struct {
+	schedule Schedule
+	jitter   time.Duration
+}
+
+// NewJitteredSchedule returns a JitteredSchedule that delays the start of s on this instance by
+// StartJitter(experiment, instance, max). instance is the id of this instance, such as the name of
+// its pod; empty uses the hostname.
+//
+//	s, err := fault.NewCronSchedule("0 14 * * 2", 2*time.Hour, nil)
+//	js, err := fault.NewJitteredSchedule(s, "game-day", "", 30*time.Second)
+func NewJitteredSchedule(s Schedule, experiment, instance string, max time.Duration) (*JitteredSchedule, error) {
+	if max < 0 {
+		return nil, ErrInvalidJitter
+	}
+
+	if instance == "" {
+		var err error
+		instance, err = os.Hostname()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &JitteredSchedule{schedule: s, jitter: StartJitter(experiment, instance, max)}, nil
+}
+
+// Active returns true if the wrapped Schedule is active both at t and the jitter of this instance
+// before t.
+func (s *JitteredSchedule) Active(t time.Time) bool {
+	return s.schedule.Active(t) && s.schedule.Active(t.Add(-s.jitter))
+}
+
+// Jitter returns how long this instance waits after the wrapped Schedule activates.
+func (s *JitteredSchedule) Jitter() time.Duration {
+	return s.jitter
+}
@@ -0,0 +1,227 @@
+package fault
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestFleetMember tests that FleetMember picks about percent of a fleet, the same way every time.
+func TestFleetMember(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		givePercent float32
+		wantMin     int
+		wantMax     int
+	}{
+		{
+			name:        "none",
+			givePercent: 0.0,
+			wantMin:     0,
+			wantMax:     0,
+		},
+		{
+			name:        "tenth",
+			givePercent: 0.1,
+			wantMin:     70,
+			wantMax:     130,
+		},
+		{
+			name:        "all",
+			givePercent: 1.0,
+			wantMin:     1000,
+			wantMax:     1000,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var members int
+			for n := 0; n < 1000; n++ {
+				instance := fmt.Sprintf("pod-%d", n)
+				member := FleetMember("experiment", instance, tt.givePercent)
+				assert.Equal(t, member, FleetMember("experiment", instance, tt.givePercent))
+				if member {
+					members++
+				}
+			}
+
+			assert.True(t, members >= tt.wantMin && members <= tt.wantMax, members)
+		})
+	}
+}
+
+// TestFleetMemberExperiments tests that experiments pick their members independently.
+func TestFleetMemberExperiments(t *testing.T) {
+	t.Parallel()
+
+	var a, b, both int
+	for n := 0; n < 1000; n++ {
+		instance := fmt.Sprintf("pod-%d", n)
+		inA, inB := FleetMember("a", instance, 0.5), FleetMember("b", instance, 0.5)
+		if inA {
+			a++
+		}
+		if inB {
+			b++
+		}
+		if inA && inB {
+			both++
+		}
+	}
+
+	// about a quarter of the fleet is in both, not half
+	assert.True(t, both > 180 && both < 320, both)
+	assert.True(t, a > 430 && a < 570, a)
+	assert.True(t, b > 430 && b < 570, b)
+}
+
+// TestWithFleetPercent tests that WithFleetPercent limits a Fault to the members of the fleet.
+func TestWithFleetPercent(t *testing.T) {
+	t.Parallel()
+
+	hostname, err := os.Hostname()
+	assert.NoError(t, err)
+
+	// find an instance in and out of a tenth of the fleet
+	var member, other string
+	for n := 0; member == "" || other == ""; n++ {
+		instance := fmt.Sprintf("pod-%d", n)
+		if FleetMember("experiment", instance, 0.1) {
+			member = instance
+		} else {
+			other = instance
+		}
+	}
+
+	tests := []struct {
+		name         string
+		giveInstance string
+		givePercent  float32
+		wantCode     int
+		wantErr      error
+	}{
+		{
+			name:         "member",
+			giveInstance: member,
+			givePercent:  0.1,
+			wantCode:     http.StatusInternalServerError,
+		},
+		{
+			name:         "not a member",
+			giveInstance: other,
+			givePercent:  0.1,
+			wantCode:     testHandlerCode,
+		},
+		{
+			name:         "hostname",
+			giveInstance: "",
+			givePercent:  0.5,
+			wantCode: map[bool]int{
+				true:  http.StatusInternalServerError,
+				false: testHandlerCode,
+			}[FleetMember("experiment", hostname, 0.5)],
+		},
+		{
+			name:         "negative percent",
+			giveInstance: member,
+			givePercent:  -0.1,
+			wantErr:      ErrInvalidPercent,
+		},
+		{
+			name:         "percent above 1",
+			giveInstance: member,
+			givePercent:  1.1,
+			wantErr:      ErrInvalidPercent,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			f, err := NewFault(newTestInjector500s(),
+				WithEnabled(true),
+				WithParticipation(1.0),
+				WithFleetPercent("experiment", tt.giveInstance, tt.givePercent),
+			)
+
+			assert.Equal(t, tt.wantErr, err)
+			if tt.wantErr != nil {
+				assert.Nil(t, f)
+				return
+			}
+
+			rr := httptest.NewRecorder()
+			f.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(testHandlerCode)
+			})).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			assert.Equal(t, tt.wantCode, rr.Code)
+		})
+	}
+}
+
+// TestStartJitter tests that StartJitter spreads a fleet over the jitter, the same way every time.
+func TestStartJitter(t *testing.T) {
+	t.Parallel()
+
+	var firstHalf int
+	for n := 0; n < 1000; n++ {
+		instance := fmt.Sprintf("pod-%d", n)
+		d := StartJitter("experiment", instance, time.Minute)
+
+		assert.True(t, d >= 0 && d < time.Minute, d)
+		assert.Equal(t, d, StartJitter("experiment", instance, time.Minute))
+		if d < 30*time.Second {
+			firstHalf++
+		}
+	}
+	assert.True(t, firstHalf > 430 && firstHalf < 570, firstHalf)
+
+	assert.Equal(t, time.Duration(0), StartJitter("experiment", "pod-0", 0))
+}
+
+// TestJitteredSchedule tests that a JitteredSchedule starts the jitter of the instance after the
+// Schedule it wraps and stops with it.
+func TestJitteredSchedule(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2024, time.January, 2, 14, 0, 0, 0, time.UTC)
+	ws, err := NewWindowSchedule(Window{Start: start, End: start.Add(time.Hour)})
+	assert.NoError(t, err)
+
+	s, err := NewJitteredSchedule(ws, "game-day", "pod-0", time.Minute)
+	assert.NoError(t, err)
+
+	jitter := StartJitter("game-day", "pod-0", time.Minute)
+	assert.Equal(t, jitter, s.Jitter())
+	assert.True(t, jitter > 0)
+
+	assert.False(t, s.Active(start))
+	assert.False(t, s.Active(start.Add(jitter-time.Nanosecond)))
+	assert.True(t, s.Active(start.Add(jitter)))
+	assert.True(t, s.Active(start.Add(time.Hour-time.Nanosecond)))
+	assert.False(t, s.Active(start.Add(time.Hour)))
+	assert.False(t, s.Active(start.Add(time.Hour+jitter-time.Nanosecond)))
+
+	hostname, err := os.Hostname()
+	assert.NoError(t, err)
+	s, err = NewJitteredSchedule(ws, "game-day", "", time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, StartJitter("game-day", hostname, time.Minute), s.Jitter())
+
+	_, err = NewJitteredSchedule(ws, "game-day", "pod-0", -time.Second)
+	assert.Equal(t, ErrInvalidJitter, err)
+}
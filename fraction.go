@@ -0,0 +1,120 @@
+package fault
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrInvalidDenominator when a FractionalPercent has a Denominator other than PerHundred,
+// PerTenThousand, or PerMillion.
+var ErrInvalidDenominator = errors.New("denominator must be 100, 10000, or 1000000")
+
+// Denominator is the denominator of a FractionalPercent.
+type Denominator uint32
+
+const (
+	// PerHundred makes the Numerator a percent.
+	PerHundred Denominator = 100
+	// PerTenThousand makes the Numerator a number of basis points.
+	PerTenThousand Denominator = 10000
+	// PerMillion makes the Numerator a number of parts per million.
+	PerMillion Denominator = 1000000
+)
+
+// FractionalPercent is a rate expressed as an integer Numerator over a fixed Denominator, matching
+// Envoy's FractionalPercent. Unlike a float32, small rates such as 1 basis point are represented
+// exactly and translate between tools without rounding.
+type FractionalPercent struct {
+	Numerator   uint32
+	Denominator Denominator
+}
+
+// BasisPoints returns a FractionalPercent of n basis points, where 10000 basis points is 100%.
+func BasisPoints(n uint32) FractionalPercent {
+	return FractionalPercent{Numerator: n, Denominator: PerTenThousand}
+}
+
+// PartsPerMillion returns a FractionalPercent of n parts per million.
+func PartsPerMillion(n uint32) FractionalPercent {
+	return FractionalPercent{Numerator: n, Denominator: PerMillion}
+}
+
+// perMillion returns p as an exact number of parts per million.
+func (p FractionalPercent) perMillion() (uint32, error) {
+	switch p.Denominator {
+	case PerHundred, PerTenThousand, PerMillion:
+	default:
+		return 0, ErrInvalidDenominator
+	}
+
+	if p.Numerator > uint32(p.Denominator) {
+		return 0, ErrInvalidPercent
+	}
+
+	return p.Numerator * (uint32(PerMillion) / uint32(p.Denominator)), nil
+}
+
+// Float32 returns p as a fraction between 0.0 and 1.0, the form used by WithParticipation().
+func (p FractionalPercent) Float32() float32 {
+	return float32(float64(p.Numerator) / float64(p.Denominator))
+}
+
+// String returns p as an exact percent, such as "0.01%" for 1 basis point.
+func (p FractionalPercent) String() string {
+	ppm, err := p.perMillion()
+	if err != nil {
+		return fmt.Sprintf("%d/%d", p.Numerator, p.Denominator)
+	}
+
+	s := fmt.Sprintf("%d.%04d", ppm/10000, ppm%10000)
+	s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+
+	return s + "%"
+}
+
+type fractionalParticipationOption FractionalPercent
+
+func (o fractionalParticipationOption) applyFault(f *Fault) error {
+	ppm, err := FractionalPercent(o).perMillion()
+	if err != nil {
+		return err
+	}
+
+	f.participation.Store(FractionalPercent(o).Float32())
+	f.perMillion.Store(ppm + 1)
+
+	return nil
+}
+
+// WithFractionalParticipation sets the rate of requests that run the Injector as a
+// FractionalPercent. The Fault compares its roll to p using integer arithmetic, so the rate is
+// exact rather than rounded to a float32.
+func WithFractionalParticipation(p FractionalPercent) Option {
+	return fractionalParticipationOption(p)
+}
+
+// SetFractionalParticipation sets the rate of requests that run the Injector as a
+// FractionalPercent. It is safe to call while handling requests. The Injector's OnConfigChange hook
+// runs after the change. Like SetParticipation, it is subject to the participation limit of the
+// Manager of the Fault.
+func (f *Fault) SetFractionalParticipation(p FractionalPercent) error {
+	_, err := p.perMillion()
+	if err != nil {
+		return err
+	}
+
+	err = f.setParticipation(p.Float32(), fractionalParticipationOption(p))
+	if err != nil {
+		return err
+	}
+
+	runConfigChangeHook(f.Injector())
+
+	return nil
+}
+
+// rollPerMillion returns true if rn, a number in [0.0,1.0), falls below ppm parts per million.
+func rollPerMillion(rn float32, ppm uint32) bool {
+	return uint32(float64(rn)*float64(PerMillion)) < ppm
+}
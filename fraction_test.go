@@ -0,0 +1,192 @@
+package fault
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestFractionalPercent tests converting FractionalPercents.
+func TestFractionalPercent(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name           string
+		give           FractionalPercent
+		wantPerMillion uint32
+		wantFloat      float32
+		wantString     string
+		wantErr        error
+	}{
+		{
+			name:           "percent",
+			give:           FractionalPercent{Numerator: 25, Denominator: PerHundred},
+			wantPerMillion: 250000,
+			wantFloat:      0.25,
+			wantString:     "25%",
+		},
+		{
+			name:           "basis points",
+			give:           BasisPoints(1),
+			wantPerMillion: 100,
+			wantFloat:      0.0001,
+			wantString:     "0.01%",
+		},
+		{
+			name:           "parts per million",
+			give:           PartsPerMillion(1),
+			wantPerMillion: 1,
+			wantFloat:      0.000001,
+			wantString:     "0.0001%",
+		},
+		{
+			name:           "all",
+			give:           BasisPoints(10000),
+			wantPerMillion: 1000000,
+			wantFloat:      1.0,
+			wantString:     "100%",
+		},
+		{
+			name:           "none",
+			give:           PartsPerMillion(0),
+			wantPerMillion: 0,
+			wantFloat:      0.0,
+			wantString:     "0%",
+		},
+		{
+			name:       "too large",
+			give:       BasisPoints(10001),
+			wantFloat:  1.0001,
+			wantString: "10001/10000",
+			wantErr:    ErrInvalidPercent,
+		},
+		{
+			name:       "invalid denominator",
+			give:       FractionalPercent{Numerator: 1, Denominator: 1000},
+			wantFloat:  0.001,
+			wantString: "1/1000",
+			wantErr:    ErrInvalidDenominator,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ppm, err := tt.give.perMillion()
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.wantPerMillion, ppm)
+			assert.Equal(t, tt.wantFloat, tt.give.Float32())
+			assert.Equal(t, tt.wantString, tt.give.String())
+		})
+	}
+}
+
+// TestWithFractionalParticipation tests creating Faults with a FractionalPercent.
+func TestWithFractionalParticipation(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		give     FractionalPercent
+		giveRoll float32
+		want     bool
+		wantErr  error
+	}{
+		{
+			name:     "below one basis point",
+			give:     BasisPoints(1),
+			giveRoll: 0.00009,
+			want:     true,
+		},
+		{
+			name:     "above one basis point",
+			give:     BasisPoints(1),
+			giveRoll: 0.00011,
+			want:     false,
+		},
+		{
+			name:     "none",
+			give:     PartsPerMillion(0),
+			giveRoll: 0.0,
+			want:     false,
+		},
+		{
+			name:     "all",
+			give:     PartsPerMillion(1000000),
+			giveRoll: 0.9999999,
+			want:     true,
+		},
+		{
+			name:    "invalid",
+			give:    BasisPoints(10001),
+			wantErr: ErrInvalidPercent,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			f, err := NewFault(newTestInjectorNoop(),
+				WithFractionalParticipation(tt.give),
+				WithRandFloat32Func(func() float32 { return tt.giveRoll }),
+			)
+			assert.Equal(t, tt.wantErr, err)
+			if err != nil {
+				assert.Nil(t, f)
+				return
+			}
+
+			assert.Equal(t, tt.want, f.participate())
+		})
+	}
+}
+
+// TestFaultSetFractionalParticipation tests switching between FractionalPercent and float32
+// participation at runtime.
+func TestFaultSetFractionalParticipation(t *testing.T) {
+	t.Parallel()
+
+	i := newTestInjectorHooks()
+	f, err := NewFault(i,
+		WithParticipation(1.0),
+		WithRandFloat32Func(func() float32 { return 0.5 }),
+	)
+	assert.NoError(t, err)
+	assert.True(t, f.participate())
+
+	assert.Equal(t, ErrInvalidDenominator, f.SetFractionalParticipation(FractionalPercent{Numerator: 1}))
+	assert.Equal(t, 0, i.configChanges)
+
+	assert.NoError(t, f.SetFractionalParticipation(BasisPoints(4999)))
+	assert.Equal(t, 1, i.configChanges)
+	assert.Equal(t, float32(0.4999), f.participation.Load())
+	assert.False(t, f.participate())
+
+	assert.NoError(t, f.SetFractionalParticipation(BasisPoints(5001)))
+	assert.True(t, f.participate())
+
+	assert.NoError(t, f.SetParticipation(0.25))
+	assert.False(t, f.participate())
+}
+
+// TestFaultFractionalParticipationRate tests that the rate of a FractionalPercent is accurate.
+func TestFaultFractionalParticipationRate(t *testing.T) {
+	t.Parallel()
+
+	f, err := NewFault(newTestInjectorNoop(), WithFractionalParticipation(PartsPerMillion(250000)))
+	assert.NoError(t, err)
+
+	var trueC, totalC float32
+	for totalC <= 100000 {
+		if f.participate() {
+			trueC++
+		}
+		totalC++
+	}
+
+	assert.InDelta(t, 0.25, trueC/totalC, 0.01)
+}
@@ -0,0 +1,179 @@
+package fault
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// maxFreezeBodySize is the largest response body HTTPFreezeChecker reads.
+const maxFreezeBodySize = 1 << 20
+
+var (
+	// ErrFrozen when a change is refused because a change freeze is in effect.
+	ErrFrozen = errors.New("a change freeze is in effect")
+	// ErrNilFreezeChecker when a nil FreezeChecker is passed.
+	ErrNilFreezeChecker = errors.New("freeze checker cannot be nil")
+	// ErrInvalidFreezeInterval when a FreezeChecker is polled at an interval that is not positive.
+	ErrInvalidFreezeInterval = errors.New("freeze interval must be > 0")
+	// ErrFreezeStatus when the freeze endpoint of an HTTPFreezeChecker responds with a status other
+	// than 200 OK.
+	ErrFreezeStatus = errors.New("unexpected freeze endpoint status")
+)
+
+// FreezeChecker reports if a change freeze is in effect, such as one declared in a company-wide
+// freeze calendar. No Fault of a Manager with a FreezeChecker runs during a freeze.
+type FreezeChecker interface {
+	// Frozen returns true and the reason for it if a freeze is in effect. An error is treated as a
+	// freeze.
+	Frozen(ctx context.Context) (frozen bool, reason string, err error)
+}
+
+// FreezeCheckerFunc is a function that satisfies FreezeChecker.
+type FreezeCheckerFunc func(ctx context.Context) (bool, string, error)
+
+// Frozen calls f.
+func (f FreezeCheckerFunc) Frozen(ctx context.Context) (bool, string, error) {
+	return f(ctx)
+}
+
+// HTTPFreezeChecker returns a FreezeChecker that GETs url with client, or http.DefaultClient if
+// client is nil. The endpoint responds 200 OK with a JSON object such as
+// {"frozen": true, "reason": "holiday freeze"}. Any other status is an error wrapping
+// ErrFreezeStatus, and at most 1MiB of the body is read.
+func HTTPFreezeChecker(url string, client *http.Client) FreezeChecker {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	return FreezeCheckerFunc(func(ctx context.Context) (bool, string, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return false, "", err
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return false, "", err
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			return false, "", fmt.Errorf("%w: %s", ErrFreezeStatus, resp.Status)
+		}
+
+		var body struct {
+			Frozen bool   `json:"frozen"`
+			Reason string `json:"reason"`
+		}
+		err = json.NewDecoder(io.LimitReader(resp.Body, maxFreezeBodySize)).Decode(&body)
+		if err != nil {
+			return false, "", err
+		}
+
+		return body.Frozen, body.Reason, nil
+	})
+}
+
+// Freeze is a change freeze reported by a FreezeChecker.
+type Freeze struct {
+	// Reason is the reason the FreezeChecker gave, or the error of the check if it failed.
+	Reason string
+	// CheckedAt is when the FreezeChecker reported the freeze.
+	CheckedAt time.Time
+}
+
+// freezeInterlock polls a FreezeChecker and holds its last answer.
+type freezeInterlock struct {
+	checker  FreezeChecker
+	interval time.Duration
+
+	// mtx protects the last answer and checking, which is true while a check is running.
+	mtx       sync.Mutex
+	checking  bool
+	checkedAt time.Time
+	frozen    bool
+	reason    string
+}
+
+// state returns the last answer: the Freeze in effect and true, or false if there is none. It never
+// waits for the FreezeChecker, so a slow freeze endpoint does not hold up requests. If the last
+// answer is older than the interval at now, and no check is running, it starts one in the
+// background for later callers. Until the first check completes a freeze is assumed.
+func (l *freezeInterlock) state(ctx context.Context, now time.Time) (Freeze, bool) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	if !l.checking && (l.checkedAt.IsZero() || now.Sub(l.checkedAt) >= l.interval) {
+		l.checking = true
+		go l.check(context.WithoutCancel(ctx), now)
+	}
+
+	return Freeze{Reason: l.reason, CheckedAt: l.checkedAt}, l.frozen
+}
+
+// check asks the FreezeChecker if a freeze is in effect and saves its answer as checked at now. The
+// check is given the interval to complete and does not end with ctx.
+func (l *freezeInterlock) check(ctx context.Context, now time.Time) {
+	ctx, cancel := context.WithTimeout(ctx, l.interval)
+	defer cancel()
+
+	frozen, reason, err := l.checker.Frozen(ctx)
+	if err != nil {
+		frozen, reason = true, err.Error()
+	}
+
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	l.checking = false
+	l.checkedAt, l.frozen, l.reason = now, frozen, reason
+}
+
+type freezeCheckerOption struct {
+	checker  FreezeChecker
+	interval time.Duration
+}
+
+func (o freezeCheckerOption) applyManager(m *Manager) error {
+	if o.checker == nil {
+		return ErrNilFreezeChecker
+	}
+	if o.interval <= 0 {
+		return ErrInvalidFreezeInterval
+	}
+
+	m.freeze = &freezeInterlock{
+		checker:  o.checker,
+		interval: o.interval,
+		frozen:   true,
+		reason:   "freeze not checked yet",
+	}
+	return nil
+}
+
+// WithFreezeChecker makes the Manager ask c if a change freeze is in effect at most once per
+// interval, as requests arrive. c is asked in the background, and requests use its last answer
+// meanwhile. During a freeze every Fault that would have run is skipped with SkipFreeze, however it
+// is configured, and its AdminHandler refuses changes other than disabling Faults. The Manager fails
+// safe: a check that fails or takes longer than interval counts as a freeze, and so does the time
+// before the first check completes.
+func WithFreezeChecker(c FreezeChecker, interval time.Duration) ManagerOption {
+	return freezeCheckerOption{checker: c, interval: interval}
+}
+
+// Freeze returns the change freeze the Manager is in and true, or false if it is not in one or has
+// no FreezeChecker, as of the last answer of the FreezeChecker. If that answer is older than the
+// poll interval, it asks the FreezeChecker again in the background without waiting for it.
+func (m *Manager) Freeze(ctx context.Context) (Freeze, bool) {
+	if m.freeze == nil {
+		return Freeze{}, false
+	}
+
+	return m.freeze.state(ctx, m.clock.Now())
+}
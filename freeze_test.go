@@ -0,0 +1,368 @@
+package fault
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/github/go-fault/faulttest"
+	"github.com/stretchr/testify/assert"
+)
+
+// testFreezeChecker is a FreezeChecker that answers with its fields and counts its checks.
+type testFreezeChecker struct {
+	mtx    sync.Mutex
+	frozen bool
+	reason string
+	err    error
+	count  int
+}
+
+// Frozen returns the fields of the testFreezeChecker.
+func (c *testFreezeChecker) Frozen(ctx context.Context) (bool, string, error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	c.count++
+	return c.frozen, c.reason, c.err
+}
+
+// set replaces the answer of the testFreezeChecker.
+func (c *testFreezeChecker) set(frozen bool, reason string, err error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	c.frozen, c.reason, c.err = frozen, reason, err
+}
+
+// checks returns the number of checks of the testFreezeChecker.
+func (c *testFreezeChecker) checks() int {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	return c.count
+}
+
+// testFreeze asks the FreezeChecker of m again with Manager.Freeze and waits for the answer, which it
+// returns.
+func testFreeze(t *testing.T, m *Manager) (Freeze, bool) {
+	t.Helper()
+
+	m.Freeze(context.Background())
+	assert.Eventually(t, func() bool {
+		m.freeze.mtx.Lock()
+		defer m.freeze.mtx.Unlock()
+
+		return !m.freeze.checking
+	}, time.Second, time.Millisecond)
+
+	return m.Freeze(context.Background())
+}
+
+// TestHTTPFreezeChecker tests HTTPFreezeChecker.
+func TestHTTPFreezeChecker(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		giveCode   int
+		giveBody   string
+		wantFrozen bool
+		wantReason string
+		wantErr    error
+	}{
+		{
+			name:       "frozen",
+			giveCode:   http.StatusOK,
+			giveBody:   `{"frozen": true, "reason": "holiday freeze"}`,
+			wantFrozen: true,
+			wantReason: "holiday freeze",
+		},
+		{
+			name:     "not frozen",
+			giveCode: http.StatusOK,
+			giveBody: `{"frozen": false}`,
+		},
+		{
+			name:     "error status",
+			giveCode: http.StatusServiceUnavailable,
+			wantErr:  ErrFreezeStatus,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.giveCode)
+				_, _ = w.Write([]byte(tt.giveBody))
+			}))
+			defer srv.Close()
+
+			frozen, reason, err := HTTPFreezeChecker(srv.URL, srv.Client()).Frozen(context.Background())
+
+			assert.True(t, errors.Is(err, tt.wantErr), err)
+			assert.Equal(t, tt.wantFrozen, frozen)
+			assert.Equal(t, tt.wantReason, reason)
+		})
+	}
+}
+
+// TestHTTPFreezeCheckerErrors tests that HTTPFreezeChecker returns the errors of its request.
+func TestHTTPFreezeCheckerErrors(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("frozen"))
+	}))
+	defer srv.Close()
+
+	// the body is not JSON
+	_, _, err := HTTPFreezeChecker(srv.URL, nil).Frozen(context.Background())
+	assert.Error(t, err)
+
+	// the URL is invalid
+	_, _, err = HTTPFreezeChecker("://freeze", nil).Frozen(context.Background())
+	assert.Error(t, err)
+
+	// the request fails
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, _, err = HTTPFreezeChecker(srv.URL, nil).Frozen(ctx)
+	var uerr *url.Error
+	assert.True(t, errors.As(err, &uerr))
+}
+
+// TestWithFreezeChecker tests that WithFreezeChecker checks its arguments.
+func TestWithFreezeChecker(t *testing.T) {
+	t.Parallel()
+
+	m, err := NewManager(WithFreezeChecker(nil, time.Minute))
+	assert.Equal(t, ErrNilFreezeChecker, err)
+	assert.Nil(t, m)
+
+	m, err = NewManager(WithFreezeChecker(&testFreezeChecker{}, 0))
+	assert.Equal(t, ErrInvalidFreezeInterval, err)
+	assert.Nil(t, m)
+}
+
+// TestManagerFreeze tests that a Manager polls its FreezeChecker at most once per interval.
+func TestManagerFreeze(t *testing.T) {
+	t.Parallel()
+
+	clock := faulttest.NewClock(time.Unix(1700000000, 0))
+	checker := &testFreezeChecker{frozen: true, reason: "holiday freeze"}
+
+	m, err := NewManager(WithClock(clock), WithFreezeChecker(checker, time.Minute))
+	assert.NoError(t, err)
+
+	fr, ok := testFreeze(t, m)
+	assert.True(t, ok)
+	assert.Equal(t, Freeze{Reason: "holiday freeze", CheckedAt: clock.Now()}, fr)
+	assert.Equal(t, 1, checker.checks())
+
+	// the freeze ends, but the last answer is used until the interval passes
+	checker.set(false, "", nil)
+	clock.Advance(59 * time.Second)
+	_, ok = testFreeze(t, m)
+	assert.True(t, ok)
+	assert.Equal(t, 1, checker.checks())
+
+	// once it passes, the last answer is still used while the FreezeChecker is asked again
+	clock.Advance(time.Second)
+	_, ok = m.Freeze(context.Background())
+	assert.True(t, ok)
+	_, ok = testFreeze(t, m)
+	assert.False(t, ok)
+	assert.Equal(t, 2, checker.checks())
+
+	// a failed check is a freeze
+	checker.set(false, "", errors.New("calendar unavailable"))
+	clock.Advance(time.Minute)
+	fr, ok = testFreeze(t, m)
+	assert.True(t, ok)
+	assert.Equal(t, "calendar unavailable", fr.Reason)
+
+	// a Manager without a FreezeChecker is never frozen
+	m, err = NewManager()
+	assert.NoError(t, err)
+	_, ok = m.Freeze(context.Background())
+	assert.False(t, ok)
+}
+
+// TestManagerFreezeChecking tests that callers do not wait for a check running in the background,
+// that only one check runs at a time, and that a freeze is assumed before the first check completes.
+func TestManagerFreezeChecking(t *testing.T) {
+	t.Parallel()
+
+	var checks int64
+	started := make(chan struct{})
+	release := make(chan struct{})
+	checker := FreezeCheckerFunc(func(ctx context.Context) (bool, string, error) {
+		atomic.AddInt64(&checks, 1)
+		close(started)
+
+		// the check is not canceled with the context of the caller
+		<-release
+		assert.NoError(t, ctx.Err())
+
+		return false, "", nil
+	})
+
+	m, err := NewManager(WithFreezeChecker(checker, time.Minute))
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	fr, ok := m.Freeze(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, "freeze not checked yet", fr.Reason)
+	<-started
+	cancel()
+
+	fr, ok = m.Freeze(context.Background())
+	assert.True(t, ok)
+	assert.Equal(t, "freeze not checked yet", fr.Reason)
+
+	close(release)
+	_, ok = testFreeze(t, m)
+	assert.False(t, ok)
+	assert.Equal(t, int64(1), atomic.LoadInt64(&checks))
+}
+
+// TestManagerHandlerFreeze tests that no Fault of a Manager runs during a freeze.
+func TestManagerHandlerFreeze(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		giveFrozen  bool
+		wantCode    int
+		wantSkipped int64
+	}{
+		{
+			name:        "frozen",
+			giveFrozen:  true,
+			wantCode:    testHandlerCode,
+			wantSkipped: 1,
+		},
+		{
+			name:       "not frozen",
+			giveFrozen: false,
+			wantCode:   http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			checker := &testFreezeChecker{frozen: tt.giveFrozen, reason: "holiday freeze"}
+			m, err := NewManager(WithFreezeChecker(checker, time.Minute))
+			assert.NoError(t, err)
+			testFreeze(t, m)
+
+			f, err := NewFault(newTestInjector500s(), WithEnabled(true), WithParticipation(1.0))
+			assert.NoError(t, err)
+			assert.NoError(t, m.Add(f))
+
+			rr := httptest.NewRecorder()
+			m.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(testHandlerCode)
+			})).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			assert.Equal(t, tt.wantCode, rr.Code)
+			assert.Equal(t, tt.wantSkipped, f.stats.counters()["skipped_freeze"])
+
+			es := m.Explain(httptest.NewRequest(http.MethodGet, "/", nil))
+			assert.Equal(t, !tt.giveFrozen, es[0].Eligible)
+			if tt.giveFrozen {
+				assert.Equal(t, SkipFreeze, es[0].SkipReason)
+			}
+		})
+	}
+}
+
+// TestAdminHandlerFreeze tests that the AdminHandler of a Manager only disables Faults during a
+// freeze.
+func TestAdminHandlerFreeze(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		giveFrozen bool
+		giveQuery  string
+		wantCode   int
+		wantBody   string
+	}{
+		{
+			name:       "enable",
+			giveFrozen: true,
+			giveQuery:  "enabled=true",
+			wantCode:   http.StatusConflict,
+			wantBody:   "a change freeze is in effect: holiday freeze\n",
+		},
+		{
+			name:       "participation",
+			giveFrozen: true,
+			giveQuery:  "participation=0.5",
+			wantCode:   http.StatusConflict,
+		},
+		{
+			name:       "disable with participation",
+			giveFrozen: true,
+			giveQuery:  "enabled=false&participation=0.5",
+			wantCode:   http.StatusConflict,
+		},
+		{
+			name:       "invalid form",
+			giveFrozen: true,
+			giveQuery:  "enabled=%zz",
+			wantCode:   http.StatusConflict,
+		},
+		{
+			name:       "disable",
+			giveFrozen: true,
+			giveQuery:  "enabled=false",
+			wantCode:   http.StatusOK,
+		},
+		{
+			name:       "not frozen",
+			giveFrozen: false,
+			giveQuery:  "enabled=true&participation=0.5",
+			wantCode:   http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			checker := &testFreezeChecker{frozen: tt.giveFrozen, reason: "holiday freeze"}
+			m, err := NewManager(WithFreezeChecker(checker, time.Minute))
+			assert.NoError(t, err)
+			testFreeze(t, m)
+
+			f, err := NewFault(newTestInjector500s(), WithName("f"), WithEnabled(true))
+			assert.NoError(t, err)
+			assert.NoError(t, m.Add(f))
+
+			rr := httptest.NewRecorder()
+			m.AdminHandler(testAdminAllowAll).ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/f?"+tt.giveQuery, nil))
+
+			assert.Equal(t, tt.wantCode, rr.Code)
+			if tt.wantBody != "" {
+				assert.Equal(t, tt.wantBody, rr.Body.String())
+			}
+		})
+	}
+}
@@ -1,5 +1,13 @@
 module github.com/github/go-fault
 
-go 1.14
+go 1.22
 
-require github.com/stretchr/testify v1.5.1
+require (
+	github.com/stretchr/testify v1.5.1
+	gopkg.in/yaml.v2 v2.2.2
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.0 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+)
@@ -0,0 +1,168 @@
+package fault
+
+import (
+	"errors"
+	"math/rand"
+	"net/http"
+	"sync"
+)
+
+var (
+	// ErrEmptyGroup when a FaultGroup is not given any Faults.
+	ErrEmptyGroup = errors.New("fault group needs at least one fault")
+)
+
+// WeightedFault is a Fault and its relative weight in a FaultGroup.
+type WeightedFault struct {
+	// Fault is the member of the FaultGroup.
+	Fault *Fault
+	// Weight is the share of requests the Fault is selected for, relative to the weights of the
+	// other members the request matches.
+	Weight float64
+}
+
+// FaultGroup runs at most one of its Faults on each request. Stacked Faults decide independently,
+// so a request can be both slowed down and failed. A FaultGroup instead selects one of the members
+// that match the request by weight, which then decides whether to inject by its own participation.
+// Every other member is skipped with SkipConflict.
+type FaultGroup struct {
+	faults  []*Fault
+	weights []float64
+
+	randSeed int64
+	rand     *rand.Rand
+
+	// *rand.Rand is not thread safe. This mutex protects our random source
+	randMtx sync.Mutex
+}
+
+// FaultGroupOption configures a FaultGroup.
+type FaultGroupOption interface {
+	applyFaultGroup(g *FaultGroup) error
+}
+
+func (o randSeedOption) applyFaultGroup(g *FaultGroup) error {
+	g.randSeed = int64(o)
+	return nil
+}
+
+// NewFaultGroup returns a FaultGroup of faults. It returns ErrEmptyGroup if faults is empty,
+// ErrNilFault or ErrDuplicateFault if a Fault is nil or named twice, or ErrInvalidWeight if a
+// weight is negative or all weights are 0.
+func NewFaultGroup(faults []WeightedFault, opts ...FaultGroupOption) (*FaultGroup, error) {
+	if len(faults) == 0 {
+		return nil, ErrEmptyGroup
+	}
+
+	// set defaults
+	g := &FaultGroup{
+		randSeed: defaultRandSeed,
+	}
+
+	// apply options
+	for _, opt := range opts {
+		err := opt.applyFaultGroup(g)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// check faults
+	names := make(map[string]bool, len(faults))
+	var total float64
+	for _, wf := range faults {
+		if wf.Fault == nil {
+			return nil, ErrNilFault
+		}
+		if names[wf.Fault.Name()] {
+			return nil, ErrDuplicateFault
+		}
+		names[wf.Fault.Name()] = true
+
+		if wf.Weight < 0 {
+			return nil, ErrInvalidWeight
+		}
+		total += wf.Weight
+
+		g.faults = append(g.faults, wf.Fault)
+		g.weights = append(g.weights, wf.Weight)
+	}
+	if total <= 0 {
+		return nil, ErrInvalidWeight
+	}
+
+	g.rand = rand.New(rand.NewSource(g.randSeed))
+
+	return g, nil
+}
+
+// Handler runs the Faults of the FaultGroup in order on each request and then next. Each member
+// evaluates the request, one of the members the request matches is selected by weight, and only
+// the selected member may inject.
+func (g *FaultGroup) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		states := make([]*injectorState, len(g.faults))
+		evs := make([]Evaluation, len(g.faults))
+		for idx, f := range g.faults {
+			states[idx] = f.injector.Load()
+			evs[idx] = f.evaluate(r, states[idx])
+		}
+
+		selected := g.selectMember(evs)
+		for idx := range evs {
+			if idx != selected && evs[idx].Injected {
+				skipConflict(&evs[idx])
+			}
+		}
+
+		h := next
+		for idx := len(g.faults) - 1; idx >= 0; idx-- {
+			h = evaluatedHandler(g.faults[idx], h, states[idx], evs[idx])
+		}
+
+		h.ServeHTTP(w, r)
+	})
+}
+
+// selectMember returns the index of a member whose Evaluation in evs matched, selected by weight, or
+// -1 if none matched with a weight > 0.
+func (g *FaultGroup) selectMember(evs []Evaluation) int {
+	var total float64
+	for idx, ev := range evs {
+		if ev.Matched {
+			total += g.weights[idx]
+		}
+	}
+	if total <= 0 {
+		return -1
+	}
+
+	g.randMtx.Lock()
+	pick := g.rand.Float64() * total
+	g.randMtx.Unlock()
+
+	selected := -1
+	for idx, ev := range evs {
+		if !ev.Matched || g.weights[idx] <= 0 {
+			continue
+		}
+
+		selected = idx
+		if pick < g.weights[idx] {
+			break
+		}
+		pick -= g.weights[idx]
+	}
+
+	return selected
+}
+
+// Faults returns the Faults of the FaultGroup in order.
+func (g *FaultGroup) Faults() []*Fault {
+	return append([]*Fault(nil), g.faults...)
+}
+
+// Seed returns the seed of the random source that selects the member for each request.
+func (g *FaultGroup) Seed() int64 {
+	return g.randSeed
+}
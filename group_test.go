@@ -0,0 +1,158 @@
+package fault
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestNewFaultGroup tests NewFaultGroup.
+func TestNewFaultGroup(t *testing.T) {
+	t.Parallel()
+
+	a, _ := NewFault(newTestInjectorNoop(), WithName("a"))
+	b, _ := NewFault(newTestInjectorNoop(), WithName("b"))
+	dupe, _ := NewFault(newTestInjectorNoop(), WithName("a"))
+
+	tests := []struct {
+		name        string
+		giveFaults  []WeightedFault
+		giveOptions []FaultGroupOption
+		wantFaults  []*Fault
+		wantSeed    int64
+		wantErr     error
+	}{
+		{
+			name:        "one",
+			giveFaults:  []WeightedFault{{Fault: a, Weight: 1}},
+			giveOptions: []FaultGroupOption{},
+			wantFaults:  []*Fault{a},
+			wantSeed:    defaultRandSeed,
+		},
+		{
+			name:        "two seeded",
+			giveFaults:  []WeightedFault{{Fault: a, Weight: 1}, {Fault: b, Weight: 0}},
+			giveOptions: []FaultGroupOption{WithRandSeed(5)},
+			wantFaults:  []*Fault{a, b},
+			wantSeed:    5,
+		},
+		{
+			name:       "empty",
+			giveFaults: []WeightedFault{},
+			wantErr:    ErrEmptyGroup,
+		},
+		{
+			name:       "nil fault",
+			giveFaults: []WeightedFault{{Fault: a, Weight: 1}, {Weight: 1}},
+			wantErr:    ErrNilFault,
+		},
+		{
+			name:       "duplicate fault",
+			giveFaults: []WeightedFault{{Fault: a, Weight: 1}, {Fault: dupe, Weight: 1}},
+			wantErr:    ErrDuplicateFault,
+		},
+		{
+			name:       "negative weight",
+			giveFaults: []WeightedFault{{Fault: a, Weight: 1}, {Fault: b, Weight: -1}},
+			wantErr:    ErrInvalidWeight,
+		},
+		{
+			name:       "zero weights",
+			giveFaults: []WeightedFault{{Fault: a, Weight: 0}, {Fault: b, Weight: 0}},
+			wantErr:    ErrInvalidWeight,
+		},
+		{
+			name:        "option error",
+			giveFaults:  []WeightedFault{{Fault: a, Weight: 1}},
+			giveOptions: []FaultGroupOption{withError()},
+			wantErr:     errErrorOption,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			g, err := NewFaultGroup(tt.giveFaults, tt.giveOptions...)
+
+			assert.Equal(t, tt.wantErr, err)
+			if tt.wantErr != nil {
+				assert.Nil(t, g)
+				return
+			}
+
+			assert.Equal(t, tt.wantFaults, g.Faults())
+			assert.Equal(t, tt.wantSeed, g.Seed())
+		})
+	}
+}
+
+// TestFaultGroupHandler tests that a FaultGroup runs at most one of its Faults on each request, by
+// weight among the Faults that match it.
+func TestFaultGroupHandler(t *testing.T) {
+	t.Parallel()
+
+	slow, err := NewFault(newTestInjectorOneOK(), WithName("slow"), WithEnabled(true), WithParticipation(1.0))
+	assert.NoError(t, err)
+	fail, err := NewFault(newTestInjector500s(), WithName("fail"), WithEnabled(true), WithParticipation(1.0))
+	assert.NoError(t, err)
+	off, err := NewFault(newTestInjector500s(), WithName("off"), WithParticipation(1.0))
+	assert.NoError(t, err)
+
+	g, err := NewFaultGroup([]WeightedFault{
+		{Fault: off, Weight: 100},
+		{Fault: slow, Weight: 3},
+		{Fault: fail, Weight: 1},
+	})
+	assert.NoError(t, err)
+
+	h := g.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(testHandlerCode)
+	}))
+
+	codes := map[int]int{}
+	for n := 0; n < 400; n++ {
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+		codes[rr.Code]++
+	}
+
+	// the disabled Fault never takes a request, and no request runs both Faults
+	assert.Len(t, codes, 2)
+	assert.InDelta(t, 300, codes[http.StatusOK], 40)
+	assert.InDelta(t, 100, codes[http.StatusInternalServerError], 40)
+
+	slowStats := slow.stats.counters()
+	failStats := fail.stats.counters()
+	assert.Equal(t, int64(400), slowStats["evaluated"])
+	assert.Equal(t, int64(codes[http.StatusOK]), slowStats["injected"])
+	assert.Equal(t, int64(codes[http.StatusInternalServerError]), slowStats["skipped_conflict"])
+	assert.Equal(t, int64(codes[http.StatusInternalServerError]), failStats["injected"])
+	assert.Equal(t, int64(codes[http.StatusOK]), failStats["skipped_conflict"])
+	assert.Equal(t, int64(400), off.stats.counters()["skipped_disabled"])
+}
+
+// TestFaultGroupHandlerNoneMatched tests that a FaultGroup passes requests that no member with a
+// weight matches on to next.
+func TestFaultGroupHandlerNoneMatched(t *testing.T) {
+	t.Parallel()
+
+	a, err := NewFault(newTestInjector500s(), WithName("a"), WithParticipation(1.0))
+	assert.NoError(t, err)
+	b, err := NewFault(newTestInjector500s(), WithName("b"), WithEnabled(true), WithParticipation(1.0))
+	assert.NoError(t, err)
+
+	g, err := NewFaultGroup([]WeightedFault{{Fault: a, Weight: 1}, {Fault: b, Weight: 0}})
+	assert.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	g.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(testHandlerCode)
+	})).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, testHandlerCode, rr.Code)
+	assert.Equal(t, int64(1), b.stats.counters()["skipped_conflict"])
+}
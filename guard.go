@@ -0,0 +1,346 @@
+package fault
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// defaultGuardInterval is how often an ErrorGuard checks the error ratio by default.
+	defaultGuardInterval = 10 * time.Second
+	// defaultGuardMinRequests is the fewest requests in an interval that can pause Faults by
+	// default.
+	defaultGuardMinRequests = 20
+)
+
+var (
+	// ErrInvalidErrorRatio when an ErrorGuard error ratio is not 0.0 <= ratio < 1.0.
+	ErrInvalidErrorRatio = errors.New("error ratio must be 0.0 <= ratio < 1.0")
+	// ErrInvalidMinRequests when an ErrorGuard minimum number of requests is negative.
+	ErrInvalidMinRequests = errors.New("min requests must be >= 0")
+)
+
+// GuardChange describes an ErrorGuard pausing or resuming its Faults.
+type GuardChange struct {
+	// Paused is true if the Faults were paused and false if they were resumed.
+	Paused bool
+	// Requests is the number of requests the handler served in the checked interval.
+	Requests int64
+	// Errors is the number of those requests that the handler answered with a 5xx status code.
+	Errors int64
+	// Ratio is Errors / Requests, or 0 if there were no requests.
+	Ratio float64
+	// Faults are the names of the Faults that were paused or resumed.
+	Faults []string
+}
+
+// ErrorGuard keeps fault injection from compounding a real incident. It counts the responses of the
+// handler it wraps, which are only the genuine responses when it wraps the handler inside the
+// Faults, and every interval checks the ratio of them that are 5xx errors. When the ratio is over
+// the limit it pauses its Faults by disabling the enabled ones, and once an interval passes at or
+// under the limit it enables them again.
+type ErrorGuard struct {
+	// requests and errors count the responses since the last check. They are first to keep them
+	// 64-bit aligned for atomic access.
+	requests int64
+	errors   int64
+
+	faults []*Fault
+
+	maxRatio    float64
+	minRequests int64
+	interval    time.Duration
+	clock       Clock
+	changeFunc  func(c GuardChange)
+
+	// paused are the Faults the ErrorGuard disabled, or nil if it has not paused them.
+	paused []*Fault
+
+	checkMtx sync.Mutex
+
+	mtx  sync.Mutex
+	stop chan struct{}
+	done chan struct{}
+}
+
+// ErrorGuardOption configures an ErrorGuard.
+type ErrorGuardOption interface {
+	applyErrorGuard(g *ErrorGuard) error
+}
+
+func (o clockOption) applyErrorGuard(g *ErrorGuard) error {
+	g.clock = o.clock
+	return nil
+}
+
+func (o checkIntervalOption) applyErrorGuard(g *ErrorGuard) error {
+	if o <= 0 {
+		return ErrInvalidInterval
+	}
+
+	g.interval = time.Duration(o)
+
+	return nil
+}
+
+type minRequestsOption int64
+
+func (o minRequestsOption) applyErrorGuard(g *ErrorGuard) error {
+	if o < 0 {
+		return ErrInvalidMinRequests
+	}
+
+	g.minRequests = int64(o)
+
+	return nil
+}
+
+// MinRequestsOption configures the checks of an ErrorGuard or SLOGuard.
+type MinRequestsOption interface {
+	ErrorGuardOption
+	SLOGuardOption
+}
+
+// WithMinRequests sets the fewest requests an interval must have for its error ratio to pause the
+// Faults of an ErrorGuard, or for it to breach the objectives of an SLOGuard, so a handful of errors
+// on an idle service do not. Default 20.
+func WithMinRequests(n int64) MinRequestsOption {
+	return minRequestsOption(n)
+}
+
+type changeFuncOption func(c GuardChange)
+
+func (o changeFuncOption) applyErrorGuard(g *ErrorGuard) error {
+	g.changeFunc = o
+	return nil
+}
+
+// WithChangeFunc sets a function that is called with every GuardChange, for example to log or
+// alert when the ErrorGuard pauses or resumes Faults.
+func WithChangeFunc(f func(c GuardChange)) ErrorGuardOption {
+	return changeFuncOption(f)
+}
+
+// NewErrorGuard returns an ErrorGuard that pauses faults while more than maxRatio (0.0 <= maxRatio
+// < 1.0) of the responses of its handler are 5xx errors. Wrap the handler the Faults wrap with
+// Handler and call Start to begin checking.
+func NewErrorGuard(faults []*Fault, maxRatio float64, opts ...ErrorGuardOption) (*ErrorGuard, error) {
+	for _, f := range faults {
+		if f == nil {
+			return nil, ErrNilFault
+		}
+	}
+
+	if maxRatio < 0 || maxRatio >= 1 {
+		return nil, ErrInvalidErrorRatio
+	}
+
+	// set defaults
+	g := &ErrorGuard{
+		faults:      faults,
+		maxRatio:    maxRatio,
+		minRequests: defaultGuardMinRequests,
+		interval:    defaultGuardInterval,
+		clock:       NewRealClock(),
+		changeFunc:  func(GuardChange) {},
+	}
+
+	// apply options
+	for _, opt := range opts {
+		err := opt.applyErrorGuard(g)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return g, nil
+}
+
+// Handler counts the responses of next and whether they are 5xx errors. A panic other than
+// http.ErrAbortHandler is counted as an error. Put it inside the Faults, for example
+// f.Handler(g.Handler(mux)), so responses written by Injectors are not counted.
+func (g *ErrorGuard) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sw := &statusWriter{ResponseWriter: w, code: http.StatusOK}
+
+		defer func() {
+			failed := sw.code >= http.StatusInternalServerError
+
+			p := recover()
+			if p != nil && p != http.ErrAbortHandler {
+				failed = true
+			}
+
+			atomic.AddInt64(&g.requests, 1)
+			if failed {
+				atomic.AddInt64(&g.errors, 1)
+			}
+
+			if p != nil {
+				panic(p)
+			}
+		}()
+
+		next.ServeHTTP(sw, r)
+	})
+}
+
+// Start begins checking the error ratio every interval in a new goroutine. It does nothing if the
+// ErrorGuard is already started.
+func (g *ErrorGuard) Start() {
+	g.mtx.Lock()
+	defer g.mtx.Unlock()
+
+	if g.stop != nil {
+		return
+	}
+
+	g.stop = make(chan struct{})
+	g.done = make(chan struct{})
+
+	go g.run(g.stop, g.done)
+}
+
+// Stop stops checking and waits for a check in progress to finish. Paused Faults stay disabled. It
+// does nothing if the ErrorGuard is not started.
+func (g *ErrorGuard) Stop() {
+	g.mtx.Lock()
+	defer g.mtx.Unlock()
+
+	if g.stop == nil {
+		return
+	}
+
+	close(g.stop)
+	<-g.done
+
+	g.stop = nil
+	g.done = nil
+}
+
+// run calls Check every interval until stop is closed.
+func (g *ErrorGuard) run(stop <-chan struct{}, done chan<- struct{}) {
+	defer close(done)
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-g.clock.After(g.interval):
+			g.Check()
+		}
+	}
+}
+
+// Check checks the error ratio of the requests counted since the last check and resets the counts.
+// It pauses the enabled Faults if the ratio is over the limit and there were at least the minimum
+// number of requests, and resumes paused Faults if the ratio is at or under the limit. It returns
+// the GuardChange, or nil if the Faults were left alone.
+func (g *ErrorGuard) Check() *GuardChange {
+	g.checkMtx.Lock()
+	defer g.checkMtx.Unlock()
+
+	c := GuardChange{
+		Requests: atomic.SwapInt64(&g.requests, 0),
+		Errors:   atomic.SwapInt64(&g.errors, 0),
+	}
+	if c.Requests > 0 {
+		c.Ratio = float64(c.Errors) / float64(c.Requests)
+	}
+
+	switch {
+	case g.paused == nil && c.Ratio > g.maxRatio && c.Requests >= g.minRequests:
+		var paused []*Fault
+		for _, f := range g.faults {
+			if f.enabled.Load() {
+				f.DisableNow()
+				paused = append(paused, f)
+				c.Faults = append(c.Faults, f.Name())
+			}
+		}
+
+		if len(paused) == 0 {
+			return nil
+		}
+
+		g.paused = paused
+		c.Paused = true
+	case g.paused != nil && c.Ratio <= g.maxRatio:
+		for _, f := range g.paused {
+			f.SetEnabled(true)
+			c.Faults = append(c.Faults, f.Name())
+		}
+
+		g.paused = nil
+	default:
+		return nil
+	}
+
+	g.changeFunc(c)
+
+	return &c
+}
+
+// Paused returns true if the ErrorGuard has paused its Faults.
+func (g *ErrorGuard) Paused() bool {
+	g.checkMtx.Lock()
+	defer g.checkMtx.Unlock()
+
+	return g.paused != nil
+}
+
+// statusWriter is an http.ResponseWriter that records the status code of the response.
+type statusWriter struct {
+	http.ResponseWriter
+
+	code        int
+	wroteHeader bool
+}
+
+// WriteHeader records the first status code written and writes it.
+func (w *statusWriter) WriteHeader(code int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		w.code = code
+	}
+
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Write records a 200 status code if none was written and writes b.
+func (w *statusWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap returns the underlying ResponseWriter for http.ResponseController.
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Hijack lets the caller take over the connection of the underlying ResponseWriter if it can, and
+// returns http.ErrNotSupported otherwise. A response written to a connection that was taken over
+// has no status code, so it is recorded as 0.
+func (w *statusWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, brw, err := http.NewResponseController(w.ResponseWriter).Hijack()
+	if err == nil {
+		w.code = 0
+		w.wroteHeader = true
+	}
+
+	return conn, brw, err
+}
+
+// Flush flushes the underlying ResponseWriter if it can.
+func (w *statusWriter) Flush() {
+	w.wroteHeader = true
+
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
@@ -0,0 +1,402 @@
+package fault
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/github/go-fault/faulttest"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestNewErrorGuard tests NewErrorGuard.
+func TestNewErrorGuard(t *testing.T) {
+	t.Parallel()
+
+	clock := faulttest.NewClock(time.Time{})
+
+	tests := []struct {
+		name            string
+		giveFaults      []*Fault
+		giveMaxRatio    float64
+		giveOptions     []ErrorGuardOption
+		wantMinRequests int64
+		wantInterval    time.Duration
+		wantClock       Clock
+		wantErr         error
+	}{
+		{
+			name:            "defaults",
+			giveMaxRatio:    0.1,
+			wantMinRequests: defaultGuardMinRequests,
+			wantInterval:    defaultGuardInterval,
+			wantClock:       NewRealClock(),
+		},
+		{
+			name: "options",
+			giveFaults: []*Fault{
+				{},
+			},
+			giveMaxRatio: 0,
+			giveOptions: []ErrorGuardOption{
+				WithMinRequests(0),
+				WithCheckInterval(time.Minute),
+				WithClock(clock),
+				WithChangeFunc(func(GuardChange) {}),
+			},
+			wantMinRequests: 0,
+			wantInterval:    time.Minute,
+			wantClock:       clock,
+		},
+		{
+			name: "nil fault",
+			giveFaults: []*Fault{
+				nil,
+			},
+			giveMaxRatio: 0.1,
+			wantErr:      ErrNilFault,
+		},
+		{
+			name:         "negative ratio",
+			giveMaxRatio: -0.1,
+			wantErr:      ErrInvalidErrorRatio,
+		},
+		{
+			name:         "ratio of one",
+			giveMaxRatio: 1,
+			wantErr:      ErrInvalidErrorRatio,
+		},
+		{
+			name:         "invalid min requests",
+			giveMaxRatio: 0.1,
+			giveOptions: []ErrorGuardOption{
+				WithMinRequests(-1),
+			},
+			wantErr: ErrInvalidMinRequests,
+		},
+		{
+			name:         "invalid interval",
+			giveMaxRatio: 0.1,
+			giveOptions: []ErrorGuardOption{
+				WithCheckInterval(0),
+			},
+			wantErr: ErrInvalidInterval,
+		},
+		{
+			name:         "option error",
+			giveMaxRatio: 0.1,
+			giveOptions: []ErrorGuardOption{
+				withError(),
+			},
+			wantErr: errErrorOption,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			g, err := NewErrorGuard(tt.giveFaults, tt.giveMaxRatio, tt.giveOptions...)
+
+			assert.Equal(t, tt.wantErr, err)
+			if tt.wantErr != nil {
+				assert.Nil(t, g)
+				return
+			}
+
+			assert.Equal(t, tt.giveFaults, g.faults)
+			assert.Equal(t, tt.giveMaxRatio, g.maxRatio)
+			assert.Equal(t, tt.wantMinRequests, g.minRequests)
+			assert.Equal(t, tt.wantInterval, g.interval)
+			assert.Equal(t, tt.wantClock, g.clock)
+			assert.NotNil(t, g.changeFunc)
+		})
+	}
+}
+
+// TestErrorGuardHandler tests that ErrorGuard.Handler counts the responses of its handler.
+func TestErrorGuardHandler(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		give       http.HandlerFunc
+		wantPanic  interface{}
+		wantCode   int
+		wantErrors int64
+	}{
+		{
+			name:       "implicit ok",
+			give:       func(w http.ResponseWriter, r *http.Request) {},
+			wantCode:   http.StatusOK,
+			wantErrors: 0,
+		},
+		{
+			name: "client error",
+			give: func(w http.ResponseWriter, r *http.Request) {
+				http.Error(w, "missing", http.StatusNotFound)
+			},
+			wantCode:   http.StatusNotFound,
+			wantErrors: 0,
+		},
+		{
+			name: "server error",
+			give: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusBadGateway)
+				w.WriteHeader(http.StatusOK)
+			},
+			wantCode:   http.StatusBadGateway,
+			wantErrors: 1,
+		},
+		{
+			name: "error after write",
+			give: func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte("ok"))
+				w.WriteHeader(http.StatusInternalServerError)
+			},
+			wantCode:   http.StatusOK,
+			wantErrors: 0,
+		},
+		{
+			name: "error after flush",
+			give: func(w http.ResponseWriter, r *http.Request) {
+				w.(http.Flusher).Flush()
+				w.WriteHeader(http.StatusInternalServerError)
+			},
+			wantCode:   http.StatusOK,
+			wantErrors: 0,
+		},
+		{
+			name: "panic",
+			give: func(w http.ResponseWriter, r *http.Request) {
+				panic("boom")
+			},
+			wantPanic:  "boom",
+			wantCode:   http.StatusOK,
+			wantErrors: 1,
+		},
+		{
+			name: "abort",
+			give: func(w http.ResponseWriter, r *http.Request) {
+				panic(http.ErrAbortHandler)
+			},
+			wantPanic:  http.ErrAbortHandler,
+			wantCode:   http.StatusOK,
+			wantErrors: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			g, err := NewErrorGuard(nil, 0.1)
+			assert.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			serve := func() {
+				g.Handler(tt.give).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+			}
+
+			if tt.wantPanic != nil {
+				assert.PanicsWithValue(t, tt.wantPanic, serve)
+			} else {
+				assert.NotPanics(t, serve)
+			}
+
+			assert.Equal(t, tt.wantCode, rr.Code)
+			assert.Equal(t, int64(1), g.requests)
+			assert.Equal(t, tt.wantErrors, g.errors)
+		})
+	}
+}
+
+// TestErrorGuardHandlerInsideFault tests that responses written by Injectors are not counted.
+func TestErrorGuardHandlerInsideFault(t *testing.T) {
+	t.Parallel()
+
+	ei, err := NewErrorInjector(http.StatusInternalServerError)
+	assert.NoError(t, err)
+	f, err := NewFault(ei, WithEnabled(true), WithParticipation(1.0))
+	assert.NoError(t, err)
+
+	g, err := NewErrorGuard([]*Fault{f}, 0.1)
+	assert.NoError(t, err)
+
+	h := f.Handler(g.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})))
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	assert.Equal(t, int64(0), g.requests)
+
+	f.SetEnabled(false)
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, int64(1), g.requests)
+	assert.Equal(t, int64(0), g.errors)
+}
+
+// TestErrorGuardCheck tests that ErrorGuard.Check pauses and resumes its Faults.
+func TestErrorGuardCheck(t *testing.T) {
+	t.Parallel()
+
+	faults := make([]*Fault, 3)
+	for i, e := range []bool{true, true, false} {
+		f, err := NewFault(newTestInjectorNoop(), WithName(fmt.Sprintf("fault%d", i)), WithEnabled(e))
+		assert.NoError(t, err)
+		faults[i] = f
+	}
+
+	var changes []GuardChange
+	g, err := NewErrorGuard(faults, 0.2, WithMinRequests(5), WithChangeFunc(func(c GuardChange) {
+		changes = append(changes, c)
+	}))
+	assert.NoError(t, err)
+
+	h := g.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/error" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	serve := func(ok, failed int) {
+		for n := 0; n < ok+failed; n++ {
+			path := "/"
+			if n >= ok {
+				path = "/error"
+			}
+			h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+		}
+	}
+	enabled := func() []bool {
+		return []bool{faults[0].enabled.Load(), faults[1].enabled.Load(), faults[2].enabled.Load()}
+	}
+
+	// no requests
+	assert.Nil(t, g.Check())
+
+	// at the limit
+	serve(8, 2)
+	assert.Nil(t, g.Check())
+	assert.False(t, g.Paused())
+
+	// over the limit with too few requests
+	serve(0, 4)
+	assert.Nil(t, g.Check())
+	assert.Equal(t, []bool{true, true, false}, enabled())
+
+	// over the limit pauses the enabled Faults
+	serve(2, 3)
+	want := GuardChange{Paused: true, Requests: 5, Errors: 3, Ratio: 0.6, Faults: []string{"fault0", "fault1"}}
+	assert.Equal(t, &want, g.Check())
+	assert.True(t, g.Paused())
+	assert.Equal(t, []bool{false, false, false}, enabled())
+
+	// still over the limit keeps them paused, with any number of requests
+	serve(0, 1)
+	assert.Nil(t, g.Check())
+	assert.True(t, g.Paused())
+
+	// recovering resumes only the paused Faults
+	serve(9, 1)
+	assert.Equal(t, &GuardChange{Requests: 10, Errors: 1, Ratio: 0.1, Faults: []string{"fault0", "fault1"}}, g.Check())
+	assert.False(t, g.Paused())
+	assert.Equal(t, []bool{true, true, false}, enabled())
+	assert.Len(t, changes, 2)
+
+	// nothing is paused if no Faults are enabled
+	faults[0].SetEnabled(false)
+	faults[1].SetEnabled(false)
+	serve(0, 5)
+	assert.Nil(t, g.Check())
+	assert.False(t, g.Paused())
+	assert.Len(t, changes, 2)
+}
+
+// TestErrorGuardStartStop tests ErrorGuard.Start and ErrorGuard.Stop.
+func TestErrorGuardStartStop(t *testing.T) {
+	t.Parallel()
+
+	clock := faulttest.NewClock(time.Time{})
+	f, err := NewFault(newTestInjectorNoop(), WithEnabled(true))
+	assert.NoError(t, err)
+
+	changes := make(chan GuardChange, 1)
+	g, err := NewErrorGuard([]*Fault{f}, 0.5,
+		WithMinRequests(1),
+		WithCheckInterval(time.Second),
+		WithClock(clock),
+		WithChangeFunc(func(c GuardChange) {
+			changes <- c
+		}),
+	)
+	assert.NoError(t, err)
+
+	h := g.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(errors.New("boom"))
+	}))
+	assert.Panics(t, func() {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	})
+
+	// stopping before starting does nothing
+	g.Stop()
+
+	g.Start()
+	g.Start()
+
+	clock.BlockUntil(1)
+	assert.Equal(t, 1, clock.Waiters())
+	assert.True(t, f.enabled.Load())
+
+	clock.Advance(time.Second)
+
+	c := <-changes
+	assert.True(t, c.Paused)
+	assert.False(t, f.enabled.Load())
+
+	// a quiet interval resumes the Fault
+	clock.BlockUntil(1)
+	clock.Advance(time.Second)
+
+	c = <-changes
+	assert.False(t, c.Paused)
+	assert.True(t, f.enabled.Load())
+
+	g.Stop()
+	g.Stop()
+}
+
+// TestStatusWriterHijack tests that statusWriter passes Hijack through to the underlying
+// ResponseWriter.
+func TestStatusWriterHijack(t *testing.T) {
+	t.Parallel()
+
+	sw := &statusWriter{ResponseWriter: httptest.NewRecorder(), code: http.StatusOK}
+	_, _, err := sw.Hijack()
+	assert.True(t, errors.Is(err, http.ErrNotSupported), err)
+	assert.Equal(t, http.StatusOK, sw.code)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sw := &statusWriter{ResponseWriter: w, code: http.StatusOK}
+		conn, brw, err := sw.Hijack()
+		assert.NoError(t, err)
+		defer conn.Close()
+		assert.Equal(t, 0, sw.code)
+
+		_, _ = brw.WriteString("HTTP/1.1 202 Accepted\r\nContent-Length: 0\r\nConnection: close\r\n\r\n")
+		_ = brw.Flush()
+	}))
+	defer srv.Close()
+
+	resp, err := srv.Client().Get(srv.URL)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+}
@@ -144,6 +144,56 @@ type errorOption interface {
 	RejectInjectorOption
 	ErrorInjectorOption
 	SlowInjectorOption
+	PartialResponseInjectorOption
+	JSONTruncateInjectorOption
+	CharsetInjectorOption
+	ProtocolListenerOption
+	TrailerInjectorOption
+	SessionInjectorOption
+	CSRFInjectorOption
+	FuzzInjectorOption
+	RerouteInjectorOption
+	SandboxInjectorOption
+	CohortAssignerOption
+	ManagerOption
+	WatchdogOption
+	RuntimeOption
+	ErrorGuardOption
+	OutageInjectorOption
+	BrownoutInjectorOption
+	PresetOption
+	DistributionInjectorOption
+	InstrumentOption
+	WeightedLatencyInjectorOption
+	RampInjectorOption
+	HeadersOnlyInjectorOption
+	InterimInjectorOption
+	ContentTypeInjectorOption
+	TransportOption
+	HopByHopInjectorOption
+	SoakOption
+	SlowBodyInjectorOption
+	ConfigOption
+	SyntheticOption
+	DynamicFaultOption
+	PanicInjectorOption
+	ResponseCorruptionInjectorOption
+	HeaderInjectorOption
+	ThrottleInjectorOption
+	FaultGroupOption
+	ClientDelayInjectorOption
+	ConnectFailureInjectorOption
+	BandwidthLimitInjectorOption
+	ResourcePressureInjectorOption
+	ConditionalInjectorOption
+	ConcluderOption
+	SLOGuardOption
+	ListenerOption
+	ExperimentOption
+	RequestTamperInjectorOption
+	DuplicateRequestInjectorOption
+	StreamInjectorOption
+	StubInjectorOption
 }
 
 type errorOptionBool bool
@@ -172,10 +222,95 @@ func (o errorOptionBool) applySlowInjector(f *SlowInjector) error {
 	return errErrorOption
 }
 
+func (o errorOptionBool) applyPartialResponseInjector(f *PartialResponseInjector) error {
+	return errErrorOption
+}
+
+func (o errorOptionBool) applyJSONTruncateInjector(f *JSONTruncateInjector) error {
+	return errErrorOption
+}
+
+func (o errorOptionBool) applyCharsetInjector(f *CharsetInjector) error {
+	return errErrorOption
+}
+
+func (o errorOptionBool) applyProtocolListener(l *ProtocolListener) error {
+	return errErrorOption
+}
+
+func (o errorOptionBool) applyTrailerInjector(i *TrailerInjector) error {
+	return errErrorOption
+}
+
+func (o errorOptionBool) applySessionInjector(i *SessionInjector) error {
+	return errErrorOption
+}
+
+func (o errorOptionBool) applyCSRFInjector(i *CSRFInjector) error {
+	return errErrorOption
+}
+
+func (o errorOptionBool) applyFuzzInjector(i *FuzzInjector) error {
+	return errErrorOption
+}
+
+func (o errorOptionBool) applyRerouteInjector(i *RerouteInjector) error {
+	return errErrorOption
+}
+
+func (o errorOptionBool) applyCohortAssigner(a *CohortAssigner) error {
+	return errErrorOption
+}
+
+func (o errorOptionBool) applyManager(m *Manager) error {
+	return errErrorOption
+}
+
+func (o errorOptionBool) applySandboxInjector(i *SandboxInjector) error {
+	return errErrorOption
+}
+
+func (o errorOptionBool) applyWatchdog(w *Watchdog) error {
+	return errErrorOption
+}
+
+func (o errorOptionBool) applyRuntime(r *Runtime) error {
+	return errErrorOption
+}
+
+func (o errorOptionBool) applyErrorGuard(g *ErrorGuard) error {
+	return errErrorOption
+}
+
+func (o errorOptionBool) applyOutageInjector(i *OutageInjector) error {
+	return errErrorOption
+}
+
+func (o errorOptionBool) applyBrownoutInjector(i *BrownoutInjector) error {
+	return errErrorOption
+}
+
 func withError() errorOption {
 	return errorOptionBool(true)
 }
 
+// testStateReporter is a reporter that sends reported states to a channel.
+type testStateReporter struct {
+	states chan InjectorState
+}
+
+// newTestStateReporter returns a new testStateReporter.
+func newTestStateReporter() *testStateReporter {
+	return &testStateReporter{
+		states: make(chan InjectorState, 1),
+	}
+}
+
+// Report sends the state to r.states.
+func (r *testStateReporter) Report(name string, state InjectorState) {
+	r.states <- state
+}
+
 // testReporter is a reporter that does nothing.
 type testReporter struct{}
 
@@ -186,3 +321,212 @@ func newTestReporter() *testReporter {
 
 // Report does nothing.
 func (r *testReporter) Report(name string, state InjectorState) {}
+
+// testEvaluationReporter is a reporter that sends Evaluations to a channel.
+type testEvaluationReporter struct {
+	testReporter
+	evaluations chan Evaluation
+}
+
+// newTestEvaluationReporter returns a new testEvaluationReporter.
+func newTestEvaluationReporter() *testEvaluationReporter {
+	return &testEvaluationReporter{
+		evaluations: make(chan Evaluation, 1),
+	}
+}
+
+// ReportEvaluation sends the Evaluation to r.evaluations.
+func (r *testEvaluationReporter) ReportEvaluation(e Evaluation) {
+	r.evaluations <- e
+}
+
+// testExposureReporter is a reporter that sends Exposures to a channel.
+type testExposureReporter struct {
+	testReporter
+	exposures chan Exposure
+}
+
+// newTestExposureReporter returns a new testExposureReporter.
+func newTestExposureReporter() *testExposureReporter {
+	return &testExposureReporter{
+		exposures: make(chan Exposure, 1),
+	}
+}
+
+// ReportExposure sends the Exposure to r.exposures.
+func (r *testExposureReporter) ReportExposure(e Exposure) {
+	r.exposures <- e
+}
+
+// testInjectorFunc is an injector that runs a function with its Fault and continues.
+type testInjectorFunc struct {
+	fault *Fault
+	fn    func(f *Fault)
+}
+
+// newTestInjectorFunc creates a new testInjectorFunc.
+func newTestInjectorFunc(fn func(f *Fault)) *testInjectorFunc {
+	return &testInjectorFunc{fn: fn}
+}
+
+// Handler runs i.fn and continues.
+func (i *testInjectorFunc) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		i.fn(i.fault)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// testInjectorHooks is an injector that counts calls to its lifecycle hooks.
+type testInjectorHooks struct {
+	testInjectorNoop
+	enables       int
+	disables      int
+	configChanges int
+}
+
+// newTestInjectorHooks creates a new testInjectorHooks.
+func newTestInjectorHooks() *testInjectorHooks {
+	return &testInjectorHooks{}
+}
+
+// OnEnable counts the call.
+func (i *testInjectorHooks) OnEnable() { i.enables++ }
+
+// OnDisable counts the call.
+func (i *testInjectorHooks) OnDisable() { i.disables++ }
+
+// OnConfigChange counts the call.
+func (i *testInjectorHooks) OnConfigChange() { i.configChanges++ }
+
+func (o errorOptionBool) applyPreset(c *presetConfig) error {
+	return errErrorOption
+}
+
+func (o errorOptionBool) applyDistributionInjector(i *DistributionInjector) error {
+	return errErrorOption
+}
+
+func (o errorOptionBool) applyInstrument(i *Instrumentation) error {
+	return errErrorOption
+}
+
+func (o errorOptionBool) applyWeightedLatencyInjector(i *WeightedLatencyInjector) error {
+	return errErrorOption
+}
+
+func (o errorOptionBool) applyRampInjector(i *RampInjector) error {
+	return errErrorOption
+}
+
+func (o errorOptionBool) applyHeadersOnlyInjector(i *HeadersOnlyInjector) error {
+	return errErrorOption
+}
+
+func (o errorOptionBool) applyInterimInjector(i *InterimInjector) error {
+	return errErrorOption
+}
+
+func (o errorOptionBool) applyContentTypeInjector(i *ContentTypeInjector) error {
+	return errErrorOption
+}
+
+func (o errorOptionBool) applyTransport(t *Transport) error {
+	return errErrorOption
+}
+
+func (o errorOptionBool) applyHopByHopInjector(i *HopByHopInjector) error {
+	return errErrorOption
+}
+
+func (o errorOptionBool) applySoak(c *soakConfig) error {
+	return errErrorOption
+}
+
+func (o errorOptionBool) applySlowBodyInjector(i *SlowBodyInjector) error {
+	return errErrorOption
+}
+
+func (o errorOptionBool) applyConfig(c *configOptions) error {
+	return errErrorOption
+}
+
+func (o errorOptionBool) applySynthetic(c *syntheticConfig) error {
+	return errErrorOption
+}
+
+func (o errorOptionBool) applyDynamicFault(d *DynamicFault) error {
+	return errErrorOption
+}
+
+func (o errorOptionBool) applyPanicInjector(i *PanicInjector) error {
+	return errErrorOption
+}
+
+func (o errorOptionBool) applyResponseCorruptionInjector(i *ResponseCorruptionInjector) error {
+	return errErrorOption
+}
+
+func (o errorOptionBool) applyHeaderInjector(i *HeaderInjector) error {
+	return errErrorOption
+}
+
+func (o errorOptionBool) applyThrottleInjector(i *ThrottleInjector) error {
+	return errErrorOption
+}
+
+func (o errorOptionBool) applyFaultGroup(g *FaultGroup) error {
+	return errErrorOption
+}
+
+func (o errorOptionBool) applyClientDelayInjector(i *ClientDelayInjector) error {
+	return errErrorOption
+}
+
+func (o errorOptionBool) applyConnectFailureInjector(i *ConnectFailureInjector) error {
+	return errErrorOption
+}
+
+func (o errorOptionBool) applyBandwidthLimitInjector(i *BandwidthLimitInjector) error {
+	return errErrorOption
+}
+
+func (o errorOptionBool) applyResourcePressureInjector(i *ResourcePressureInjector) error {
+	return errErrorOption
+}
+
+func (o errorOptionBool) applyConditionalInjector(i *ConditionalInjector) error {
+	return errErrorOption
+}
+
+func (o errorOptionBool) applyConcluder(c *Concluder) error {
+	return errErrorOption
+}
+
+func (o errorOptionBool) applySLOGuard(g *SLOGuard) error {
+	return errErrorOption
+}
+
+func (o errorOptionBool) applyListener(l *Listener) error {
+	return errErrorOption
+}
+
+func (o errorOptionBool) applyExperiment(e *Experiment) error {
+	return errErrorOption
+}
+
+func (o errorOptionBool) applyRequestTamperInjector(i *RequestTamperInjector) error {
+	return errErrorOption
+}
+
+func (o errorOptionBool) applyDuplicateRequestInjector(i *DuplicateRequestInjector) error {
+	return errErrorOption
+}
+
+func (o errorOptionBool) applyStreamInjector(i *StreamInjector) error {
+	return errErrorOption
+}
+
+func (o errorOptionBool) applyStubInjector(i *StubInjector) error {
+	return errErrorOption
+}
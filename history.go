@@ -0,0 +1,148 @@
+package fault
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// historyKey is the context key of a request's history.
+type historyKey struct{}
+
+// injectingFaultKey is the context key of the name of the Fault whose Injector runs on a request.
+type injectingFaultKey struct{}
+
+// InjectionRecord describes an Injector that ran on a request.
+type InjectionRecord struct {
+	// Fault is the name of the Fault that ran the Injector, or that ran the Injector it is inside,
+	// such as a ChainInjector. Empty if no Fault ran it, such as an Injector passed to NewTransport.
+	Fault string
+	// Injector is the name of the Injector, as returned by InjectorName.
+	Injector string
+	// Params are the parameters of the Injector, as returned by Describe. Nil if the Injector is not
+	// a Describer.
+	Params map[string]string
+	// Start is when the Injector started.
+	Start time.Time
+	// End is when the Injector's handler returned, including everything it ran after itself. Zero
+	// if the Injector is still running.
+	End time.Time
+}
+
+// history is the append-only list of Injectors that ran on a request.
+type history struct {
+	mtx     sync.Mutex
+	records []InjectionRecord
+}
+
+// start appends a record for i, run by the Fault named fault, and returns its index.
+func (h *history) start(fault string, i Injector) int {
+	var params map[string]string
+	if d, ok := i.(Describer); ok {
+		params = d.Describe()
+	}
+
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+
+	h.records = append(h.records, InjectionRecord{
+		Fault:    fault,
+		Injector: InjectorName(i),
+		Params:   params,
+		Start:    time.Now(),
+	})
+
+	return len(h.records) - 1
+}
+
+// finish sets the end time of the record at idx.
+func (h *history) finish(idx int) {
+	h.mtx.Lock()
+	h.records[idx].End = time.Now()
+	h.mtx.Unlock()
+}
+
+// snapshot returns a copy of the records.
+func (h *history) snapshot() []InjectionRecord {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+
+	records := make([]InjectionRecord, len(h.records))
+	copy(records, h.records)
+
+	return records
+}
+
+// len returns the number of records.
+func (h *history) len() int {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+
+	return len(h.records)
+}
+
+// requestHistory returns the history of r, adding one to the context of r if it has none.
+func requestHistory(r *http.Request) (*http.Request, *history) {
+	if h, ok := r.Context().Value(historyKey{}).(*history); ok {
+		return r, h
+	}
+
+	h := &history{}
+
+	return r.WithContext(context.WithValue(r.Context(), historyKey{}, h)), h
+}
+
+// recordInjector returns i.Handler(next) wrapped to record i in the history of each request.
+func recordInjector(i Injector, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r, h := requestHistory(r)
+
+		fault, _ := r.Context().Value(injectingFaultKey{}).(string)
+		idx := h.start(fault, i)
+		defer h.finish(idx)
+
+		i.Handler(next).ServeHTTP(w, r)
+	})
+}
+
+// withInjectingFault returns r with a context that records the Injectors it runs as run by the
+// Fault named fault.
+func withInjectingFault(r *http.Request, fault string) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), injectingFaultKey{}, fault))
+}
+
+// recordedMiddleware returns a middleware that runs i and records it in the history of each
+// request.
+func recordedMiddleware(i Injector) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return recordInjector(i, next)
+	}
+}
+
+// HistoryFromContext returns every Injector that has run on the request with context ctx, in the
+// order they started. Injectors inside a ChainInjector or RandomInjector are listed after the
+// ChainInjector or RandomInjector itself. Returns nil if no Injector has run. Handlers and
+// middleware after the Faults can use it to log the faults of a request or adapt to them, such as
+// by not caching a faulted response.
+func HistoryFromContext(ctx context.Context) []InjectionRecord {
+	h, ok := ctx.Value(historyKey{}).(*history)
+	if !ok {
+		return nil
+	}
+
+	return h.snapshot()
+}
+
+// InjectedFromContext returns true if any Injector has run on the request with context ctx.
+func InjectedFromContext(ctx context.Context) bool {
+	h, ok := ctx.Value(historyKey{}).(*history)
+	if !ok {
+		return false
+	}
+
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+
+	return len(h.records) > 0
+}
@@ -0,0 +1,126 @@
+package fault
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestHistoryFromContext tests that Faults record the Injectors that ran on a request.
+func TestHistoryFromContext(t *testing.T) {
+	t.Parallel()
+
+	ri, err := NewRandomInjector([]Injector{newTestInjectorTwoTeapot()})
+	assert.NoError(t, err)
+
+	ci, err := NewChainInjector([]Injector{newTestInjectorOneOK(), ri})
+	assert.NoError(t, err)
+
+	f, err := NewFault(ci, WithName("checkout"), WithEnabled(true), WithParticipation(1.0))
+	assert.NoError(t, err)
+
+	var (
+		ctx      context.Context
+		during   []InjectionRecord
+		injected bool
+	)
+	h := f.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx = r.Context()
+		during = HistoryFromContext(ctx)
+		injected = InjectedFromContext(ctx)
+	}))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.True(t, injected)
+
+	after := HistoryFromContext(ctx)
+	assert.Len(t, during, 4)
+	assert.Len(t, after, 4)
+
+	var names []string
+	for idx, rec := range after {
+		names = append(names, rec.Injector)
+
+		// Injectors inside the chain are recorded as run by the Fault of the chain
+		assert.Equal(t, "checkout", rec.Fault)
+
+		assert.False(t, rec.Start.IsZero())
+		assert.True(t, during[idx].End.IsZero())
+		assert.False(t, rec.End.Before(rec.Start))
+	}
+	assert.Equal(t, []string{"chain", "testInjectorOneOK", "random", "testInjectorTwoTeapot"}, names)
+
+	assert.Equal(t, ci.Describe(), after[0].Params)
+	assert.Nil(t, after[1].Params)
+	assert.Equal(t, ri.Describe(), after[2].Params)
+
+	// records finish in reverse order
+	assert.False(t, after[0].End.Before(after[2].End))
+}
+
+// TestHistoryFromContextNotInjected tests the history of requests no Injector ran on.
+func TestHistoryFromContextNotInjected(t *testing.T) {
+	t.Parallel()
+
+	f, err := NewFault(newTestInjectorNoop(), WithEnabled(false))
+	assert.NoError(t, err)
+
+	var (
+		records  []InjectionRecord
+		injected bool
+	)
+	h := f.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		records = HistoryFromContext(r.Context())
+		injected = InjectedFromContext(r.Context())
+	}))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Nil(t, records)
+	assert.False(t, injected)
+
+	// a request with an empty history
+	r, _ := requestHistory(httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.False(t, InjectedFromContext(r.Context()))
+	assert.Empty(t, HistoryFromContext(r.Context()))
+}
+
+// TestHistoryAppends tests that nested Faults append to the same history.
+func TestHistoryAppends(t *testing.T) {
+	t.Parallel()
+
+	outer, err := NewFault(newTestInjectorOneOK(), WithName("outer"), WithEnabled(true), WithParticipation(1.0))
+	assert.NoError(t, err)
+
+	inner, err := NewFault(newTestInjectorTwoTeapot(), WithName("inner"), WithEnabled(true), WithParticipation(1.0))
+	assert.NoError(t, err)
+
+	var records []InjectionRecord
+	h := outer.Handler(inner.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		records = HistoryFromContext(r.Context())
+	})))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Len(t, records, 2)
+	assert.Equal(t, "testInjectorOneOK", records[0].Injector)
+	assert.Equal(t, "outer", records[0].Fault)
+	assert.Equal(t, "testInjectorTwoTeapot", records[1].Injector)
+	assert.Equal(t, "inner", records[1].Fault)
+}
+
+// TestHistoryWithoutFault tests that Injectors run without a Fault are recorded without one.
+func TestHistoryWithoutFault(t *testing.T) {
+	t.Parallel()
+
+	var records []InjectionRecord
+	h := recordInjector(newTestInjectorOneOK(), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		records = HistoryFromContext(r.Context())
+	}))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Len(t, records, 1)
+	assert.Equal(t, "testInjectorOneOK", records[0].Injector)
+	assert.Empty(t, records[0].Fault)
+}
@@ -0,0 +1,69 @@
+package fault
+
+import "net/http"
+
+// DestructiveInjector is implemented by Injectors that can leave a client unsure if its request was
+// applied, such as by dropping the connection or cutting off the response. Clients commonly retry
+// those requests, so injecting them on non-idempotent operations can duplicate writes or corrupt
+// data.
+type DestructiveInjector interface {
+	Destructive() bool
+}
+
+// IsDestructive returns true if i implements DestructiveInjector and reports itself as destructive.
+func IsDestructive(i Injector) bool {
+	if d, ok := i.(DestructiveInjector); ok {
+		return d.Destructive()
+	}
+
+	return false
+}
+
+// IdempotencyClassifier decides if a request is for an idempotent operation, which is safe to
+// retry.
+type IdempotencyClassifier interface {
+	Idempotent(r *http.Request) bool
+}
+
+// IdempotencyClassifierFunc is a function that satisfies IdempotencyClassifier.
+type IdempotencyClassifierFunc func(r *http.Request) bool
+
+// Idempotent returns f(r).
+func (f IdempotencyClassifierFunc) Idempotent(r *http.Request) bool {
+	return f(r)
+}
+
+// IdempotentMethod returns true if method is idempotent as defined by RFC 7231: GET, HEAD, OPTIONS,
+// TRACE, PUT, and DELETE.
+func IdempotentMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut,
+		http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+type idempotencySafetyOption struct {
+	classifier IdempotencyClassifier
+}
+
+func (o idempotencySafetyOption) applyFault(f *Fault) error {
+	f.idempotency = o.classifier
+	if f.idempotency == nil {
+		f.idempotency = IdempotencyClassifierFunc(func(r *http.Request) bool {
+			return IdempotentMethod(r.Method)
+		})
+	}
+
+	return nil
+}
+
+// WithIdempotencySafety stops the Fault from running a destructive Injector on requests that c
+// classifies as non-idempotent. Pass nil to classify requests by http method with
+// IdempotentMethod(). To allow destructive faults on an operation anyway, classify its requests as
+// idempotent.
+func WithIdempotencySafety(c IdempotencyClassifier) Option {
+	return idempotencySafetyOption{c}
+}
@@ -0,0 +1,245 @@
+package fault
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testInjectorDestructive is a destructive Injector that returns 500s.
+type testInjectorDestructive struct {
+	testInjector500s
+}
+
+func (i *testInjectorDestructive) Destructive() bool { return true }
+
+// TestIsDestructive tests IsDestructive.
+func TestIsDestructive(t *testing.T) {
+	t.Parallel()
+
+	ri, err := NewRejectInjector()
+	assert.NoError(t, err)
+
+	pi, err := NewPartialResponseInjector(10)
+	assert.NoError(t, err)
+
+	hi, err := NewHeadersOnlyInjector()
+	assert.NoError(t, err)
+
+	ei, err := NewErrorInjector(http.StatusInternalServerError)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name string
+		give func() Injector
+		want bool
+	}{
+		{
+			name: "reject",
+			give: func() Injector { return ri },
+			want: true,
+		},
+		{
+			name: "partial",
+			give: func() Injector { return pi },
+			want: true,
+		},
+		{
+			name: "headers only",
+			give: func() Injector { return hi },
+			want: true,
+		},
+		{
+			name: "hop by hop",
+			give: func() Injector {
+				hi, err := NewHopByHopInjector()
+				assert.NoError(t, err)
+				return hi
+			},
+			want: false,
+		},
+		{
+			name: "hop by hop transfer encoding",
+			give: func() Injector {
+				hi, err := NewHopByHopInjector(WithLeakTransferEncoding())
+				assert.NoError(t, err)
+				return hi
+			},
+			want: true,
+		},
+		{
+			name: "duplicate",
+			give: func() Injector {
+				di, err := NewDuplicateRequestInjector()
+				assert.NoError(t, err)
+				return di
+			},
+			want: true,
+		},
+		{
+			name: "error",
+			give: func() Injector { return ei },
+			want: false,
+		},
+		{
+			name: "custom",
+			give: func() Injector { return newTestInjectorNoop() },
+			want: false,
+		},
+		{
+			name: "chain with destructive",
+			give: func() Injector {
+				ci, err := NewChainInjector([]Injector{ei, ri})
+				assert.NoError(t, err)
+				return ci
+			},
+			want: true,
+		},
+		{
+			name: "chain without destructive",
+			give: func() Injector {
+				ci, err := NewChainInjector([]Injector{ei})
+				assert.NoError(t, err)
+				return ci
+			},
+			want: false,
+		},
+		{
+			name: "random with destructive",
+			give: func() Injector {
+				ri, err := NewRandomInjector([]Injector{ei, pi})
+				assert.NoError(t, err)
+				return ri
+			},
+			want: true,
+		},
+		{
+			name: "random without destructive",
+			give: func() Injector {
+				ri, err := NewRandomInjector([]Injector{ei})
+				assert.NoError(t, err)
+				return ri
+			},
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, IsDestructive(tt.give()))
+		})
+	}
+}
+
+// TestIdempotentMethod tests IdempotentMethod.
+func TestIdempotentMethod(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		give string
+		want bool
+	}{
+		{http.MethodGet, true},
+		{http.MethodHead, true},
+		{http.MethodOptions, true},
+		{http.MethodTrace, true},
+		{http.MethodPut, true},
+		{http.MethodDelete, true},
+		{http.MethodPost, false},
+		{http.MethodPatch, false},
+		{http.MethodConnect, false},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.give, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, IdempotentMethod(tt.give))
+		})
+	}
+}
+
+// TestFaultIdempotencySafety tests that Faults refuse to run destructive Injectors on
+// non-idempotent requests.
+func TestFaultIdempotencySafety(t *testing.T) {
+	t.Parallel()
+
+	allowAll := IdempotencyClassifierFunc(func(r *http.Request) bool { return true })
+
+	tests := []struct {
+		name         string
+		giveInjector Injector
+		giveMethod   string
+		giveOptions  []Option
+		wantCode     int
+		wantState    InjectorState
+	}{
+		{
+			name:         "no safety",
+			giveInjector: &testInjectorDestructive{},
+			giveMethod:   http.MethodPost,
+			giveOptions:  nil,
+			wantCode:     http.StatusInternalServerError,
+			wantState:    StateSelected,
+		},
+		{
+			name:         "idempotent method",
+			giveInjector: &testInjectorDestructive{},
+			giveMethod:   http.MethodPut,
+			giveOptions:  []Option{WithIdempotencySafety(nil)},
+			wantCode:     http.StatusInternalServerError,
+			wantState:    StateSelected,
+		},
+		{
+			name:         "non-idempotent method",
+			giveInjector: &testInjectorDestructive{},
+			giveMethod:   http.MethodPost,
+			giveOptions:  []Option{WithIdempotencySafety(nil)},
+			wantCode:     testHandlerCode,
+			wantState:    StateUnmatched,
+		},
+		{
+			name:         "overridden",
+			giveInjector: &testInjectorDestructive{},
+			giveMethod:   http.MethodPost,
+			giveOptions:  []Option{WithIdempotencySafety(allowAll)},
+			wantCode:     http.StatusInternalServerError,
+			wantState:    StateSelected,
+		},
+		{
+			name:         "not destructive",
+			giveInjector: newTestInjector500s(),
+			giveMethod:   http.MethodPost,
+			giveOptions:  []Option{WithIdempotencySafety(nil)},
+			wantCode:     http.StatusInternalServerError,
+			wantState:    StateSelected,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			reporter := newTestStateReporter()
+			opts := append(tt.giveOptions, WithEnabled(true), WithParticipation(1.0), WithReporter(reporter))
+
+			f, err := NewFault(tt.giveInjector, opts...)
+			assert.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			f.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(testHandlerCode)
+			})).ServeHTTP(rr, httptest.NewRequest(tt.giveMethod, "/", nil))
+
+			assert.Equal(t, tt.wantCode, rr.Code)
+			assert.Equal(t, tt.wantState, <-reporter.states)
+		})
+	}
+}
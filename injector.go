@@ -1,7 +1,19 @@
 package fault
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"reflect"
+	"strings"
+)
+
+var (
+	// ErrNoInjectors when a ChainInjector or RandomInjector is given no Injectors.
+	ErrNoInjectors = errors.New("at least one injector is required")
+	// ErrDuplicateInjector when the same Injector is given to a ChainInjector or RandomInjector more
+	// than once.
+	ErrDuplicateInjector = errors.New("injector is used more than once")
 )
 
 // InjectorState represents the states an injector can be in.
@@ -12,11 +24,132 @@ const (
 	StateStarted InjectorState = iota + 1
 	// StateFinished when an Injector has finished.
 	StateFinished
-	// StateSkipped when an Injector is skipped.
+	// StateSkipped when an Injector is skipped. Faults report this when a request is not selected by
+	// the participation roll.
 	StateSkipped
+	// StateUnmatched when a Fault does not run its Injector because the request did not pass the
+	// Fault's allowlists and blocklists.
+	StateUnmatched
+	// StateSelected when a Fault selects a request to run its Injector on.
+	StateSelected
+	// StatePanicked when a SandboxInjector recovers from a panic in its Injector.
+	StatePanicked
+	// StateDeadlineExceeded when the Injector of a SandboxInjector runs past its deadline.
+	StateDeadlineExceeded
 )
 
+// String returns the name of the state.
+func (s InjectorState) String() string {
+	switch s {
+	case StateStarted:
+		return "started"
+	case StateFinished:
+		return "finished"
+	case StateSkipped:
+		return "skipped"
+	case StateUnmatched:
+		return "unmatched"
+	case StateSelected:
+		return "selected"
+	case StatePanicked:
+		return "panicked"
+	case StateDeadlineExceeded:
+		return "deadline_exceeded"
+	default:
+		return "unknown"
+	}
+}
+
 // Injector are added to Faults and run as middleware in a request.
 type Injector interface {
 	Handler(next http.Handler) http.Handler
 }
+
+// InjectorError describes an invalid Injector in the list passed to NewChainInjector or
+// NewRandomInjector.
+type InjectorError struct {
+	// Index is the position of the Injector in the list.
+	Index int
+	// Err is why the Injector is invalid, such as ErrNilInjector.
+	Err error
+}
+
+// Error returns the index and reason, such as "injector 2: injector cannot be nil".
+func (e *InjectorError) Error() string {
+	return fmt.Sprintf("injector %d: %s", e.Index, e.Err)
+}
+
+// Unwrap returns e.Err.
+func (e *InjectorError) Unwrap() error {
+	return e.Err
+}
+
+// InjectorErrors is every InjectorError found in a list of Injectors.
+type InjectorErrors []*InjectorError
+
+// Error returns every InjectorError separated by semicolons.
+func (e InjectorErrors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, err := range e {
+		msgs = append(msgs, err.Error())
+	}
+
+	return strings.Join(msgs, "; ")
+}
+
+// Is returns true if any InjectorError is target, so errors.Is(err, ErrNilInjector) works on
+// InjectorErrors.
+func (e InjectorErrors) Is(target error) bool {
+	for _, err := range e {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// validateInjectors checks a list of Injectors for a ChainInjector or RandomInjector. The list must
+// not be empty or contain nil Injectors, the same Injector must not appear twice because its reports
+// could not be told apart, and each Injector that implements Validator must be valid.
+func validateInjectors(is []Injector) error {
+	if len(is) == 0 {
+		return ErrNoInjectors
+	}
+
+	var errs InjectorErrors
+	seen := make(map[Injector]bool, len(is))
+
+	for idx, i := range is {
+		if i == nil {
+			errs = append(errs, &InjectorError{Index: idx, Err: ErrNilInjector})
+			continue
+		}
+
+		// only pointers are compared, so value Injectors may be repeated
+		if v := reflect.ValueOf(i); v.Kind() == reflect.Ptr {
+			switch {
+			case v.IsNil():
+				errs = append(errs, &InjectorError{Index: idx, Err: ErrNilInjector})
+				continue
+			case v.Elem().Type().Size() == 0:
+				// pointers to zero-size values may be equal even when allocated separately
+			case seen[i]:
+				errs = append(errs, &InjectorError{Index: idx, Err: ErrDuplicateInjector})
+				continue
+			default:
+				seen[i] = true
+			}
+		}
+
+		if err := validateInjector(i); err != nil {
+			errs = append(errs, &InjectorError{Index: idx, Err: err})
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
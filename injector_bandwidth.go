@@ -0,0 +1,218 @@
+package fault
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+var (
+	// ErrInvalidBandwidth when a BandwidthLimitInjector is given a bandwidth that is not positive.
+	ErrInvalidBandwidth = errors.New("bandwidth must be > 0 bytes per second")
+	// ErrInvalidBurst when a BandwidthLimitInjector is given a burst that is not positive.
+	ErrInvalidBurst = errors.New("burst must be > 0 bytes")
+)
+
+// BandwidthLimitInjector runs the request and limits the throughput of its response body to a
+// number of bytes per second, to simulate a constrained network such as 3G. Unlike a
+// SlowBodyInjector, which waits a fixed delay between chunks of a fixed size, it paces the body
+// with a token bucket, so the response takes as long as its size calls for. Each response is
+// limited separately, and its headers are not limited.
+type BandwidthLimitInjector struct {
+	bytesPerSecond int
+	burst          int
+	clock          Clock
+	reporter       Reporter
+}
+
+// BandwidthLimitInjectorOption configures a BandwidthLimitInjector.
+type BandwidthLimitInjectorOption interface {
+	applyBandwidthLimitInjector(i *BandwidthLimitInjector) error
+}
+
+type burstOption int
+
+func (o burstOption) applyBandwidthLimitInjector(i *BandwidthLimitInjector) error {
+	if o <= 0 {
+		return ErrInvalidBurst
+	}
+
+	i.burst = int(o)
+	return nil
+}
+
+// WithBurst sets the most bytes a BandwidthLimitInjector writes at once. The first burst of the
+// body is written without waiting, and the bytes of the rest are written as they are earned. Default
+// a tenth of a second of bytes.
+func WithBurst(n int) BandwidthLimitInjectorOption {
+	return burstOption(n)
+}
+
+func (o clockOption) applyBandwidthLimitInjector(i *BandwidthLimitInjector) error {
+	i.clock = o.clock
+	return nil
+}
+
+func (o reporterOption) applyBandwidthLimitInjector(i *BandwidthLimitInjector) error {
+	i.reporter = o.reporter
+	return nil
+}
+
+// NewBandwidthLimitInjector returns a BandwidthLimitInjector that writes response bodies at
+// bytesPerSecond, such as 50_000 for a slow 3G connection. It returns ErrInvalidBandwidth if
+// bytesPerSecond is not positive.
+func NewBandwidthLimitInjector(bytesPerSecond int, opts ...BandwidthLimitInjectorOption) (
+	*BandwidthLimitInjector, error,
+) {
+	if bytesPerSecond <= 0 {
+		return nil, ErrInvalidBandwidth
+	}
+
+	// set defaults
+	bi := &BandwidthLimitInjector{
+		bytesPerSecond: bytesPerSecond,
+		burst:          max(bytesPerSecond/10, 1),
+		clock:          NewRealClock(),
+		reporter:       NewNoopReporter(),
+	}
+
+	// apply options
+	for _, opt := range opts {
+		err := opt.applyBandwidthLimitInjector(bi)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return bi, nil
+}
+
+// Handler runs the request with a ResponseWriter that waits for the bytes of the body to be earned
+// before writing them and flushes them to the client. Writes fail with the error of the request's
+// context once it is done, so a client that gives up does not hold the handler.
+func (i *BandwidthLimitInjector) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		go i.reporter.Report(i.String(), StateStarted)
+		next.ServeHTTP(&bandwidthWriter{ResponseWriter: w, injector: i, ctx: r.Context()}, r)
+		go i.reporter.Report(i.String(), StateFinished)
+	})
+}
+
+// Reporter returns the Reporter of the BandwidthLimitInjector.
+func (i *BandwidthLimitInjector) Reporter() Reporter {
+	return i.reporter
+}
+
+// SetReporter replaces the Reporter of the BandwidthLimitInjector.
+func (i *BandwidthLimitInjector) SetReporter(r Reporter) {
+	i.reporter = r
+}
+
+// Name returns "bandwidth_limit".
+func (i *BandwidthLimitInjector) Name() string {
+	return "bandwidth_limit"
+}
+
+// Describe returns the bandwidth and the burst.
+func (i *BandwidthLimitInjector) Describe() map[string]string {
+	return map[string]string{
+		"bytes_per_second": strconv.Itoa(i.bytesPerSecond),
+		"burst":            strconv.Itoa(i.burst),
+	}
+}
+
+// String returns a summary of the BandwidthLimitInjector, such as "bandwidth_limit(50000B/s)".
+func (i *BandwidthLimitInjector) String() string {
+	return fmt.Sprintf("%s(%dB/s)", i.Name(), i.bytesPerSecond)
+}
+
+// bandwidthWriter is an http.ResponseWriter that paces the body with a token bucket of bytes.
+type bandwidthWriter struct {
+	http.ResponseWriter
+	injector *BandwidthLimitInjector
+	ctx      context.Context
+
+	// tokens are the bytes that may be written now, as of last. started is false until the bucket
+	// is filled for the first write.
+	tokens  float64
+	last    time.Time
+	started bool
+}
+
+// Write writes b in chunks of up to the burst, waiting for the bytes of each chunk to be earned and
+// checking the context before it. It returns the bytes written before the context was done.
+func (w *bandwidthWriter) Write(b []byte) (int, error) {
+	var n int
+	for len(b) > 0 {
+		size := min(w.injector.burst, len(b))
+		if err := w.reserve(float64(size)); err != nil {
+			return n, err
+		}
+		w.tokens -= float64(size)
+
+		written, err := w.ResponseWriter.Write(b[:size])
+		n += written
+		if err != nil {
+			return n, err
+		}
+		w.Flush()
+
+		b = b[size:]
+	}
+
+	return n, nil
+}
+
+// reserve waits until want bytes may be written. It returns the error of the context if it is done
+// first. Once the latency budget of the request is used up it stops waiting.
+func (w *bandwidthWriter) reserve(want float64) error {
+	w.refill()
+	if err := w.ctx.Err(); err != nil {
+		return err
+	}
+	if w.tokens >= want {
+		return nil
+	}
+
+	rate := float64(w.injector.bytesPerSecond)
+	d := time.Duration(math.Ceil((want - w.tokens) / rate * float64(time.Second)))
+	select {
+	case <-w.injector.clock.After(addLatency(w.ctx, d)):
+	case <-w.ctx.Done():
+		return w.ctx.Err()
+	}
+
+	// the wait was long enough to earn want, or was cut short by the latency budget
+	w.refill()
+	w.tokens = math.Max(w.tokens, want)
+
+	return nil
+}
+
+// refill adds the bytes earned since the bucket was last refilled, filling it on first use.
+func (w *bandwidthWriter) refill() {
+	now := w.injector.clock.Now()
+	if !w.started {
+		w.tokens = float64(w.injector.burst)
+		w.started = true
+	} else if elapsed := now.Sub(w.last); elapsed > 0 {
+		w.tokens = math.Min(w.tokens+elapsed.Seconds()*float64(w.injector.bytesPerSecond), float64(w.injector.burst))
+	}
+	w.last = now
+}
+
+// Flush flushes the underlying ResponseWriter if it can.
+func (w *bandwidthWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap returns the underlying ResponseWriter for http.ResponseController.
+func (w *bandwidthWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,281 @@
+package fault
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testAfterClock is a Clock whose After records how long it is asked to wait and advances its time
+// by that much at once, or cancels a context and never fires if cancel is set.
+type testAfterClock struct {
+	RealClock
+
+	cancel context.CancelFunc
+
+	mtx   sync.Mutex
+	now   time.Time
+	waits []time.Duration
+}
+
+// Now returns the time.
+func (c *testAfterClock) Now() time.Time {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	return c.now
+}
+
+// After records d and advances the time by d.
+func (c *testAfterClock) After(d time.Duration) <-chan time.Time {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	c.waits = append(c.waits, d)
+	if c.cancel != nil {
+		c.cancel()
+		return make(chan time.Time)
+	}
+
+	c.now = c.now.Add(d)
+	ch := make(chan time.Time, 1)
+	ch <- c.now
+
+	return ch
+}
+
+// TestNewBandwidthLimitInjector tests NewBandwidthLimitInjector.
+func TestNewBandwidthLimitInjector(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		giveBandwidth int
+		giveOptions   []BandwidthLimitInjectorOption
+		wantString    string
+		wantDescribe  map[string]string
+		wantErr       error
+	}{
+		{
+			name:          "default burst",
+			giveBandwidth: 50_000,
+			giveOptions:   []BandwidthLimitInjectorOption{},
+			wantString:    "bandwidth_limit(50000B/s)",
+			wantDescribe:  map[string]string{"bytes_per_second": "50000", "burst": "5000"},
+		},
+		{
+			name:          "default burst of a slow bandwidth",
+			giveBandwidth: 5,
+			giveOptions:   []BandwidthLimitInjectorOption{},
+			wantString:    "bandwidth_limit(5B/s)",
+			wantDescribe:  map[string]string{"bytes_per_second": "5", "burst": "1"},
+		},
+		{
+			name:          "options",
+			giveBandwidth: 1000,
+			giveOptions: []BandwidthLimitInjectorOption{
+				WithBurst(64),
+				WithClock(&testAfterClock{}),
+				WithReporter(NewNoopReporter()),
+			},
+			wantString:   "bandwidth_limit(1000B/s)",
+			wantDescribe: map[string]string{"bytes_per_second": "1000", "burst": "64"},
+		},
+		{
+			name:          "invalid bandwidth",
+			giveBandwidth: 0,
+			wantErr:       ErrInvalidBandwidth,
+		},
+		{
+			name:          "invalid burst",
+			giveBandwidth: 1000,
+			giveOptions:   []BandwidthLimitInjectorOption{WithBurst(0)},
+			wantErr:       ErrInvalidBurst,
+		},
+		{
+			name:          "option error",
+			giveBandwidth: 1000,
+			giveOptions:   []BandwidthLimitInjectorOption{withError()},
+			wantErr:       errErrorOption,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			bi, err := NewBandwidthLimitInjector(tt.giveBandwidth, tt.giveOptions...)
+
+			assert.Equal(t, tt.wantErr, err)
+			if tt.wantErr != nil {
+				assert.Nil(t, bi)
+				return
+			}
+
+			assert.Equal(t, "bandwidth_limit", bi.Name())
+			assert.Equal(t, tt.wantString, bi.String())
+			assert.Equal(t, tt.wantDescribe, bi.Describe())
+		})
+	}
+}
+
+// TestBandwidthLimitInjectorHandler tests that BandwidthLimitInjector.Handler writes the body in
+// bursts and waits for the bytes of each to be earned.
+func TestBandwidthLimitInjectorHandler(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name           string
+		giveWrites     []string
+		giveMaxLatency time.Duration
+		wantChunks     []string
+		wantWaits      []time.Duration
+	}{
+		{
+			name:       "one write",
+			giveWrites: []string{"Accepted"},
+			wantChunks: []string{"Acce", "pted"},
+			wantWaits:  []time.Duration{400 * time.Millisecond},
+		},
+		{
+			name:       "many writes",
+			giveWrites: []string{"A", "ccep", "", "ted"},
+			wantChunks: []string{"A", "ccep", "ted"},
+			wantWaits:  []time.Duration{100 * time.Millisecond, 300 * time.Millisecond},
+		},
+		{
+			name:       "within burst",
+			giveWrites: []string{"Acc"},
+			wantChunks: []string{"Acc"},
+			wantWaits:  nil,
+		},
+		{
+			name:       "no body",
+			giveWrites: nil,
+			wantChunks: nil,
+			wantWaits:  nil,
+		},
+		{
+			name:           "latency budget",
+			giveWrites:     []string{"Accepted!!!!"},
+			giveMaxLatency: 100 * time.Millisecond,
+			wantChunks:     []string{"Acce", "pted", "!!!!"},
+			wantWaits:      []time.Duration{100 * time.Millisecond, 0},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			clock := &testAfterClock{}
+			reporter := &testStateReporter{states: make(chan InjectorState, 2)}
+
+			bi, err := NewBandwidthLimitInjector(10, WithBurst(4), WithClock(clock), WithReporter(reporter))
+			assert.NoError(t, err)
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.giveMaxLatency > 0 {
+				req = req.WithContext(ContextWithMaxAddedLatency(req.Context(), tt.giveMaxLatency))
+			}
+
+			rr := &testChunkRecorder{ResponseRecorder: httptest.NewRecorder()}
+			bi.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				// the handler can still reach the ResponseWriter it wraps
+				assert.True(t, errors.Is(http.NewResponseController(w).EnableFullDuplex(), http.ErrNotSupported))
+
+				w.WriteHeader(testHandlerCode)
+				for _, write := range tt.giveWrites {
+					n, err := w.Write([]byte(write))
+					assert.NoError(t, err)
+					assert.Equal(t, len(write), n)
+				}
+			})).ServeHTTP(rr, req)
+
+			assert.Equal(t, testHandlerCode, rr.Code)
+			assert.Equal(t, tt.wantChunks, rr.chunks)
+			assert.Equal(t, len(tt.wantChunks), rr.flushes)
+			assert.Equal(t, tt.wantWaits, clock.waits)
+
+			states := map[InjectorState]int{}
+			for n := 0; n < 2; n++ {
+				states[<-reporter.states]++
+			}
+			assert.Equal(t, map[InjectorState]int{StateStarted: 1, StateFinished: 1}, states)
+		})
+	}
+}
+
+// TestBandwidthLimitInjectorHandlerCanceled tests that writes stop once the context of the request
+// is done.
+func TestBandwidthLimitInjectorHandlerCanceled(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	clock := &testAfterClock{cancel: cancel}
+
+	bi, err := NewBandwidthLimitInjector(10, WithBurst(3), WithClock(clock))
+	assert.NoError(t, err)
+
+	rr := &testChunkRecorder{ResponseRecorder: httptest.NewRecorder()}
+	bi.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n, err := w.Write([]byte("Accepted"))
+		assert.Equal(t, 3, n)
+		assert.Equal(t, context.Canceled, err)
+
+		n, err = w.Write([]byte("Accepted"))
+		assert.Equal(t, 0, n)
+		assert.Equal(t, context.Canceled, err)
+	})).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx))
+
+	assert.Equal(t, []string{"Acc"}, rr.chunks)
+}
+
+// TestBandwidthLimitInjectorHandlerWriteError tests that a failed write of a chunk stops the write.
+func TestBandwidthLimitInjectorHandlerWriteError(t *testing.T) {
+	t.Parallel()
+
+	bi, err := NewBandwidthLimitInjector(10, WithClock(&testAfterClock{}))
+	assert.NoError(t, err)
+
+	w := &bandwidthWriter{
+		ResponseWriter: testFailingWriter{httptest.NewRecorder()},
+		injector:       bi,
+		ctx:            context.Background(),
+	}
+	n, err := w.Write([]byte("Accepted"))
+
+	assert.Equal(t, 0, n)
+	assert.Equal(t, errTestWrite, err)
+
+	// a ResponseWriter that cannot flush is not flushed
+	w.Flush()
+}
+
+// TestBandwidthLimitInjectorThroughput tests that a BandwidthLimitInjector paces a response to its
+// bandwidth in real time.
+func TestBandwidthLimitInjectorThroughput(t *testing.T) {
+	t.Parallel()
+
+	bi, err := NewBandwidthLimitInjector(1000)
+	assert.NoError(t, err)
+
+	body := make([]byte, 200)
+	start := time.Now()
+	rr := httptest.NewRecorder()
+	bi.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(body)
+	})).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	// the first 100 bytes are the burst, and the other 100 take a tenth of a second
+	assert.Equal(t, 200, rr.Body.Len())
+	assert.GreaterOrEqual(t, int64(time.Since(start)), int64(100*time.Millisecond))
+}
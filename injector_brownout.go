@@ -0,0 +1,264 @@
+package fault
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultDegradedHeader is the header a BrownoutInjector sets on degraded responses by default.
+	DefaultDegradedHeader = "X-Degraded"
+)
+
+var (
+	// ErrNoBrownoutEffects when a BrownoutInjector would neither strip fields, set a header, nor
+	// delay.
+	ErrNoBrownoutEffects = errors.New("brownout must strip fields, set a header, or delay")
+	// ErrInvalidDelay when a delay is negative.
+	ErrInvalidDelay = errors.New("delay must be >= 0")
+)
+
+// BrownoutInjector degrades responses instead of failing them, simulating the reduced-functionality
+// modes of a service that sheds optional work under load. It can strip optional fields from JSON
+// responses, mark responses degraded with a header, and add a moderate delay.
+type BrownoutInjector struct {
+	fields      [][]string
+	headerName  string
+	headerValue string
+	delay       time.Duration
+	clock       Clock
+	reporter    Reporter
+}
+
+// BrownoutInjectorOption configures a BrownoutInjector.
+type BrownoutInjectorOption interface {
+	applyBrownoutInjector(i *BrownoutInjector) error
+}
+
+type optionalFieldsOption []string
+
+func (o optionalFieldsOption) applyBrownoutInjector(i *BrownoutInjector) error {
+	for _, path := range o {
+		if path != "" {
+			i.fields = append(i.fields, strings.Split(path, "."))
+		}
+	}
+	return nil
+}
+
+// WithOptionalFields sets the dot separated paths of the JSON fields to strip from responses, such
+// as "recommendations" or "items.reviews". Arrays on the way are followed into each of their
+// elements. Default none, the response body is left unchanged.
+func WithOptionalFields(paths ...string) BrownoutInjectorOption {
+	return optionalFieldsOption(paths)
+}
+
+type degradedHeaderOption struct {
+	name  string
+	value string
+}
+
+func (o degradedHeaderOption) applyBrownoutInjector(i *BrownoutInjector) error {
+	i.headerName = o.name
+	i.headerValue = o.value
+	return nil
+}
+
+// WithDegradedHeader sets the header marking responses as degraded. An empty name sets no header.
+// Default "X-Degraded: true".
+func WithDegradedHeader(name, value string) BrownoutInjectorOption {
+	return degradedHeaderOption{name: name, value: value}
+}
+
+type degradedDelayOption time.Duration
+
+func (o degradedDelayOption) applyBrownoutInjector(i *BrownoutInjector) error {
+	if o < 0 {
+		return ErrInvalidDelay
+	}
+
+	i.delay = time.Duration(o)
+
+	return nil
+}
+
+// WithDegradedDelay sets how long the BrownoutInjector waits before running the request. Default 0.
+func WithDegradedDelay(d time.Duration) BrownoutInjectorOption {
+	return degradedDelayOption(d)
+}
+
+func (o clockOption) applyBrownoutInjector(i *BrownoutInjector) error {
+	i.clock = o.clock
+	return nil
+}
+
+func (o reporterOption) applyBrownoutInjector(i *BrownoutInjector) error {
+	i.reporter = o.reporter
+	return nil
+}
+
+// NewBrownoutInjector returns a BrownoutInjector. It returns ErrNoBrownoutEffects if the options
+// leave it nothing to do.
+func NewBrownoutInjector(opts ...BrownoutInjectorOption) (*BrownoutInjector, error) {
+	// set defaults
+	bi := &BrownoutInjector{
+		headerName:  DefaultDegradedHeader,
+		headerValue: "true",
+		clock:       NewRealClock(),
+		reporter:    NewNoopReporter(),
+	}
+
+	// apply options
+	for _, opt := range opts {
+		err := opt.applyBrownoutInjector(bi)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(bi.fields) == 0 && bi.headerName == "" && bi.delay == 0 {
+		return nil, ErrNoBrownoutEffects
+	}
+
+	return bi, nil
+}
+
+// Handler waits the delay, runs the request, and marks its response degraded. With optional fields
+// the response is buffered so they can be stripped; responses that are not JSON are sent unchanged.
+func (i *BrownoutInjector) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		go i.reporter.Report(i.String(), StateStarted)
+		defer func() { go i.reporter.Report(i.String(), StateFinished) }()
+
+		if i.delay > 0 {
+			i.clock.Sleep(addLatency(r.Context(), i.delay))
+		}
+
+		if i.headerName != "" {
+			w.Header().Set(i.headerName, i.headerValue)
+		}
+
+		if len(i.fields) == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		bw := newBufferedWriter(w)
+		next.ServeHTTP(bw, r)
+
+		body := bw.body.Bytes()
+		stripped := false
+		for _, path := range i.fields {
+			if out, ok := removeJSONField(body, path); ok {
+				body, stripped = out, true
+			}
+		}
+		if stripped {
+			bw.setBody(body)
+		}
+
+		// the handler may have replaced the header
+		if i.headerName != "" {
+			bw.Header().Set(i.headerName, i.headerValue)
+		}
+
+		bw.send()
+		bw.release()
+	})
+}
+
+// removeJSONField removes the field found by following path through data, into every element of
+// the arrays on the way. It returns false if no field was removed.
+func removeJSONField(data []byte, path []string) ([]byte, bool) {
+	var arr []json.RawMessage
+	if err := json.Unmarshal(data, &arr); err == nil {
+		removed := false
+		for idx, elem := range arr {
+			if out, ok := removeJSONField(elem, path); ok {
+				arr[idx], removed = out, true
+			}
+		}
+		if !removed {
+			return nil, false
+		}
+
+		out, err := json.Marshal(arr)
+		return out, err == nil
+	}
+
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return nil, false
+	}
+
+	child, ok := obj[path[0]]
+	if !ok {
+		return nil, false
+	}
+
+	if len(path) == 1 {
+		delete(obj, path[0])
+	} else {
+		out, ok := removeJSONField(child, path[1:])
+		if !ok {
+			return nil, false
+		}
+		obj[path[0]] = out
+	}
+
+	out, err := json.Marshal(obj)
+	return out, err == nil
+}
+
+// Name returns "brownout".
+func (i *BrownoutInjector) Name() string {
+	return "brownout"
+}
+
+// Describe returns the stripped fields, the degraded header, and the delay.
+func (i *BrownoutInjector) Describe() map[string]string {
+	header := ""
+	if i.headerName != "" {
+		header = i.headerName + ": " + i.headerValue
+	}
+
+	return map[string]string{
+		"fields": strings.Join(i.fieldStrings(), ", "),
+		"header": header,
+		"delay":  i.delay.String(),
+	}
+}
+
+// String returns a summary of the BrownoutInjector, such as "brownout(250ms, recommendations)".
+func (i *BrownoutInjector) String() string {
+	parts := i.fieldStrings()
+	if i.delay > 0 {
+		parts = append([]string{i.delay.String()}, parts...)
+	}
+
+	return fmt.Sprintf("%s(%s)", i.Name(), strings.Join(parts, ", "))
+}
+
+// fieldStrings returns the dot separated paths of the stripped fields.
+func (i *BrownoutInjector) fieldStrings() []string {
+	strs := make([]string, 0, len(i.fields))
+	for _, f := range i.fields {
+		strs = append(strs, strings.Join(f, "."))
+	}
+
+	return strs
+}
+
+// Reporter returns the Reporter of the BrownoutInjector.
+func (i *BrownoutInjector) Reporter() Reporter {
+	return i.reporter
+}
+
+// SetReporter replaces the Reporter of the BrownoutInjector.
+func (i *BrownoutInjector) SetReporter(r Reporter) {
+	i.reporter = r
+}
@@ -0,0 +1,237 @@
+package fault
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/github/go-fault/faulttest"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestNewBrownoutInjector tests NewBrownoutInjector.
+func TestNewBrownoutInjector(t *testing.T) {
+	t.Parallel()
+
+	clock := faulttest.NewClock(time.Time{})
+
+	tests := []struct {
+		name        string
+		giveOptions []BrownoutInjectorOption
+		want        *BrownoutInjector
+		wantErr     error
+	}{
+		{
+			name: "defaults",
+			want: &BrownoutInjector{
+				headerName:  DefaultDegradedHeader,
+				headerValue: "true",
+				clock:       NewRealClock(),
+				reporter:    NewNoopReporter(),
+			},
+		},
+		{
+			name: "options",
+			giveOptions: []BrownoutInjectorOption{
+				WithOptionalFields("recommendations", "", "items.reviews"),
+				WithDegradedHeader("X-Mode", "lite"),
+				WithDegradedDelay(250 * time.Millisecond),
+				WithClock(clock),
+				WithReporter(newTestReporter()),
+			},
+			want: &BrownoutInjector{
+				fields:      [][]string{{"recommendations"}, {"items", "reviews"}},
+				headerName:  "X-Mode",
+				headerValue: "lite",
+				delay:       250 * time.Millisecond,
+				clock:       clock,
+				reporter:    newTestReporter(),
+			},
+		},
+		{
+			name: "delay only",
+			giveOptions: []BrownoutInjectorOption{
+				WithDegradedHeader("", ""),
+				WithDegradedDelay(time.Second),
+			},
+			want: &BrownoutInjector{
+				delay:    time.Second,
+				clock:    NewRealClock(),
+				reporter: NewNoopReporter(),
+			},
+		},
+		{
+			name: "no effects",
+			giveOptions: []BrownoutInjectorOption{
+				WithDegradedHeader("", ""),
+			},
+			wantErr: ErrNoBrownoutEffects,
+		},
+		{
+			name: "negative delay",
+			giveOptions: []BrownoutInjectorOption{
+				WithDegradedDelay(-time.Second),
+			},
+			wantErr: ErrInvalidDelay,
+		},
+		{
+			name: "option error",
+			giveOptions: []BrownoutInjectorOption{
+				withError(),
+			},
+			wantErr: errErrorOption,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			bi, err := NewBrownoutInjector(tt.giveOptions...)
+
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.want, bi)
+		})
+	}
+}
+
+// TestBrownoutInjectorHandler tests BrownoutInjector.Handler.
+func TestBrownoutInjectorHandler(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		giveOptions []BrownoutInjectorOption
+		giveBody    string
+		wantBody    string
+		wantHeader  http.Header
+	}{
+		{
+			name:       "header only",
+			giveBody:   `{"id": 1, "recommendations": []}`,
+			wantBody:   `{"id": 1, "recommendations": []}`,
+			wantHeader: http.Header{"Content-Type": {"application/json"}, "X-Degraded": {"true"}},
+		},
+		{
+			name:        "top level field",
+			giveOptions: []BrownoutInjectorOption{WithOptionalFields("recommendations")},
+			giveBody:    `{"id": 1, "recommendations": [2, 3]}`,
+			wantBody:    `{"id":1}`,
+			wantHeader:  http.Header{"Content-Type": {"application/json"}, "X-Degraded": {"true"}},
+		},
+		{
+			name: "fields in arrays",
+			giveOptions: []BrownoutInjectorOption{
+				WithOptionalFields("items.reviews", "missing", "items.id.deeper"),
+				WithDegradedHeader("", ""),
+			},
+			giveBody:   `{"items": [{"id": 1, "reviews": []}, 2, {"id": 3}, {"id": 4, "reviews": [5]}]}`,
+			wantBody:   `{"items":[{"id":1},2,{"id":3},{"id":4}]}`,
+			wantHeader: http.Header{"Content-Type": {"application/json"}},
+		},
+		{
+			name:        "missing field unchanged",
+			giveOptions: []BrownoutInjectorOption{WithOptionalFields("data.reviews")},
+			giveBody:    `{"data": {"id": 1}}`,
+			wantBody:    `{"data": {"id": 1}}`,
+			wantHeader:  http.Header{"Content-Type": {"application/json"}, "X-Degraded": {"true"}},
+		},
+		{
+			name:        "array without field unchanged",
+			giveOptions: []BrownoutInjectorOption{WithOptionalFields("reviews")},
+			giveBody:    `[1, {"id": 2}]`,
+			wantBody:    `[1, {"id": 2}]`,
+			wantHeader:  http.Header{"Content-Type": {"application/json"}, "X-Degraded": {"true"}},
+		},
+		{
+			name:        "not json unchanged",
+			giveOptions: []BrownoutInjectorOption{WithOptionalFields("reviews")},
+			giveBody:    `not json`,
+			wantBody:    `not json`,
+			wantHeader:  http.Header{"Content-Type": {"application/json"}, "X-Degraded": {"true"}},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			bi, err := NewBrownoutInjector(tt.giveOptions...)
+			assert.NoError(t, err)
+
+			h := bi.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.Header().Del(DefaultDegradedHeader)
+				_, _ = w.Write([]byte(tt.giveBody))
+			}))
+
+			rr := httptest.NewRecorder()
+			h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			assert.Equal(t, http.StatusOK, rr.Code)
+			assert.Equal(t, tt.wantBody, rr.Body.String())
+
+			// headers set by the handler after the injector are only restored when buffering
+			if len(bi.fields) == 0 {
+				tt.wantHeader.Del(DefaultDegradedHeader)
+			}
+			assert.Equal(t, tt.wantHeader, rr.Header())
+		})
+	}
+}
+
+// TestBrownoutInjectorHandlerDelay tests that BrownoutInjector.Handler waits on its Clock.
+func TestBrownoutInjectorHandlerDelay(t *testing.T) {
+	t.Parallel()
+
+	clock := faulttest.NewClock(time.Time{})
+
+	bi, err := NewBrownoutInjector(WithDegradedDelay(time.Second), WithClock(clock))
+	assert.NoError(t, err)
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() {
+		rr := httptest.NewRecorder()
+		bi.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(testHandlerCode)
+		})).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+		done <- rr
+	}()
+
+	clock.BlockUntil(1)
+
+	select {
+	case <-done:
+		t.Fatal("request finished before the clock advanced")
+	default:
+	}
+
+	clock.Advance(time.Second)
+	rr := <-done
+
+	assert.Equal(t, testHandlerCode, rr.Code)
+	assert.Equal(t, "true", rr.Header().Get(DefaultDegradedHeader))
+}
+
+// TestBrownoutInjectorReporter tests that a BrownoutInjector reports to its Reporter.
+func TestBrownoutInjectorReporter(t *testing.T) {
+	t.Parallel()
+
+	reporter := &testStateReporter{states: make(chan InjectorState, 2)}
+
+	bi, err := NewBrownoutInjector()
+	assert.NoError(t, err)
+	bi.SetReporter(reporter)
+	assert.Equal(t, reporter, bi.Reporter())
+
+	f, err := NewFault(bi, WithEnabled(true), WithParticipation(1.0))
+	assert.NoError(t, err)
+	rr := testRequest(t, f)
+	assert.Equal(t, "true", rr.Header().Get(DefaultDegradedHeader))
+
+	states := []InjectorState{<-reporter.states, <-reporter.states}
+	assert.ElementsMatch(t, []InjectorState{StateStarted, StateFinished}, states)
+}
@@ -1,10 +1,146 @@
 package fault
 
-import "net/http"
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrInvalidLink when a LinkCondition is set on a link that is not in the chain.
+	ErrInvalidLink = errors.New("link is not in the chain")
+)
+
+// LinkCondition makes a link of a ChainInjector run only depending on the outcome of the links
+// before it or of the rest of the chain, or only for some requests, and can make the link end the
+// chain. The zero LinkCondition always runs the link.
+type LinkCondition struct {
+	// MinDelay, if > 0, runs the link only if the links before it held the request for at least
+	// MinDelay, such as only returning an error once a SlowInjector actually delayed the request.
+	MinDelay time.Duration
+
+	// Probability, if > 0, runs the link for only that fraction of requests, such as 0.1 to slow
+	// down every request but only fail one in ten of them. It must be <= 1.0. The ChainInjector
+	// rolls with the seed set by WithRandSeed().
+	Probability float32
+
+	// Terminal skips the rest of the chain and the handler once the link has written a response,
+	// such as an ErrorInjector, even if the link continues to its next handler anyway.
+	Terminal bool
+
+	// Response, if set, runs the rest of the chain and the handler first, and then runs the link in
+	// place of their response only if Response returns true for its status code. The link is passed
+	// the buffered response instead of running the handler again. Use it to only fault requests the
+	// handler served successfully. Buffering means the response can no longer stream.
+	Response func(code int) bool
+}
+
+// String returns a summary of the LinkCondition, such as "delay>=100ms,p=0.1,terminal,response".
+func (c LinkCondition) String() string {
+	var parts []string
+	if c.MinDelay > 0 {
+		parts = append(parts, "delay>="+c.MinDelay.String())
+	}
+	if c.Probability > 0 {
+		parts = append(parts, "p="+strconv.FormatFloat(float64(c.Probability), 'g', -1, 32))
+	}
+	if c.Terminal {
+		parts = append(parts, "terminal")
+	}
+	if c.Response != nil {
+		parts = append(parts, "response")
+	}
+
+	return strings.Join(parts, ",")
+}
+
+// middleware returns link wrapped to run only when c allows it, on a request that reached the
+// chain ci at start.
+func (c LinkCondition) middleware(link func(next http.Handler) http.Handler, ci *ChainInjector,
+	start time.Time) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if c.MinDelay > 0 && ci.clock.Now().Sub(start) < c.MinDelay {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if c.Probability > 0 && !ci.roll(c.Probability) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if c.Response == nil {
+				c.serve(link, next, w, r)
+				return
+			}
+
+			bw := newBufferedWriter(w)
+			defer bw.release()
+			next.ServeHTTP(bw, r)
+
+			if !c.Response(bw.code) {
+				bw.send()
+				return
+			}
+
+			// the link is passed the buffered response in place of the rest of the chain
+			c.serve(link, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				bw.w = w
+				bw.send()
+			}), w, r)
+		})
+	}
+}
+
+// serve runs link in front of next, skipping next once link has written a response if c is
+// Terminal.
+func (c LinkCondition) serve(link func(next http.Handler) http.Handler, next http.Handler,
+	w http.ResponseWriter, r *http.Request) {
+	if !c.Terminal {
+		link(next).ServeHTTP(w, r)
+		return
+	}
+
+	rw := &respondedWriter{ResponseWriter: w}
+	link(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if rw.responded {
+			return
+		}
+		next.ServeHTTP(w, r)
+	})).ServeHTTP(rw, r)
+}
 
 // ChainInjector combines many Injectors into a single Injector that runs them in order.
 type ChainInjector struct {
+	injectors   []Injector
 	middlewares []func(next http.Handler) http.Handler
+	reporter    Reporter
+
+	// conditions are the LinkConditions of the links, by index.
+	conditions map[int]LinkCondition
+
+	// clock times the links for LinkConditions. Default RealClock.
+	clock Clock
+
+	// rand, seeded with randSeed, rolls the Probability of LinkConditions.
+	randSeed int64
+	rand     *rand.Rand
+
+	// *rand.Rand is not thread safe. This mutex protects our random source
+	randMtx sync.Mutex
+
+	// propagate determines if reporter is given to the Injectors.
+	propagate bool
+
+	// limits limit the Injectors.
+	limits compositeLimits
 }
 
 // ChainInjectorOption configures a ChainInjector.
@@ -12,10 +148,59 @@ type ChainInjectorOption interface {
 	applyChainInjector(i *ChainInjector) error
 }
 
-// NewChainInjector combines many Injectors into a single Injector that runs them in order.
+func (o reporterOption) applyChainInjector(i *ChainInjector) error {
+	i.reporter = o.reporter
+	return nil
+}
+
+func (o clockOption) applyChainInjector(i *ChainInjector) error {
+	i.clock = o.clock
+	return nil
+}
+
+func (o randSeedOption) applyChainInjector(i *ChainInjector) error {
+	i.randSeed = int64(o)
+	return nil
+}
+
+type linkConditionOption struct {
+	link      int
+	condition LinkCondition
+}
+
+func (o linkConditionOption) applyChainInjector(i *ChainInjector) error {
+	if i.conditions == nil {
+		i.conditions = make(map[int]LinkCondition)
+	}
+	i.conditions[o.link] = o.condition
+
+	return nil
+}
+
+// WithLinkCondition makes the Injector at index link of the chain run only when c allows it. A link
+// that does not run passes the request on to the rest of the chain.
+func WithLinkCondition(link int, c LinkCondition) ChainInjectorOption {
+	return linkConditionOption{link: link, condition: c}
+}
+
+// NewChainInjector combines many Injectors into a single Injector that runs them in order. It returns
+// ErrNoInjectors if is is empty, InjectorErrors naming every Injector in is that is nil or
+// repeated, ErrTooManyInjectors or ErrTooDeep if is exceeds WithMaxInjectors() or WithMaxDepth(),
+// ErrInvalidLink if WithLinkCondition() names a link that is not in is, or ErrInvalidPercent if a
+// LinkCondition has a Probability outside of [0.0,1.0].
 func NewChainInjector(is []Injector, opts ...ChainInjectorOption) (*ChainInjector, error) {
+	err := validateInjectors(is)
+	if err != nil {
+		return nil, err
+	}
+
 	// set defaults
-	ci := &ChainInjector{}
+	ci := &ChainInjector{
+		reporter: NewNoopReporter(),
+		limits:   defaultCompositeLimits(),
+		clock:    NewRealClock(),
+		randSeed: defaultRandSeed,
+	}
 
 	// apply options
 	for _, opt := range opts {
@@ -25,9 +210,34 @@ func NewChainInjector(is []Injector, opts ...ChainInjectorOption) (*ChainInjecto
 		}
 	}
 
+	// check options
+	err = ci.limits.check(is)
+	if err != nil {
+		return nil, err
+	}
+
+	for link, c := range ci.conditions {
+		if link < 0 || link >= len(is) {
+			return nil, ErrInvalidLink
+		}
+		if c.MinDelay < 0 {
+			return nil, ErrInvalidDelay
+		}
+		if c.Probability < 0 || c.Probability > 1.0 {
+			return nil, ErrInvalidPercent
+		}
+	}
+
+	ci.rand = rand.New(rand.NewSource(ci.randSeed))
+
 	// set middleware
+	ci.injectors = is
 	for _, i := range is {
-		ci.middlewares = append(ci.middlewares, i.Handler)
+		ci.middlewares = append(ci.middlewares, recordedMiddleware(i))
+	}
+
+	if ci.propagate {
+		PropagateReporter(is, ci.reporter, nil)
 	}
 
 	return ci, nil
@@ -36,11 +246,121 @@ func NewChainInjector(is []Injector, opts ...ChainInjectorOption) (*ChainInjecto
 // Handler executes ChainInjector.middlewares in order and then returns.
 func (i *ChainInjector) Handler(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		go i.reporter.Report(i.String(), StateStarted)
+
+		var start time.Time
+		if i.conditions != nil {
+			start = i.clock.Now()
+		}
+
 		// Loop in reverse to preserve handler order
 		for idx := len(i.middlewares) - 1; idx >= 0; idx-- {
-			next = i.middlewares[idx](next)
+			link := i.middlewares[idx]
+			if c, ok := i.conditions[idx]; ok {
+				link = c.middleware(link, i, start)
+			}
+			next = link(next)
 		}
 
 		next.ServeHTTP(w, r)
 	})
 }
+
+// roll returns true with probability p.
+func (i *ChainInjector) roll(p float32) bool {
+	i.randMtx.Lock()
+	defer i.randMtx.Unlock()
+
+	return i.rand.Float32() < p
+}
+
+// Seed returns the seed of the random source that rolls the Probability of LinkConditions.
+func (i *ChainInjector) Seed() int64 {
+	return i.randSeed
+}
+
+// Reporter returns the Reporter of the ChainInjector.
+func (i *ChainInjector) Reporter() Reporter {
+	return i.reporter
+}
+
+// SetReporter replaces the Reporter of the ChainInjector. With WithReporterPropagation(true) it also
+// replaces the Reporter of each Injector that has no Reporter of its own.
+func (i *ChainInjector) SetReporter(r Reporter) {
+	if i.propagate {
+		PropagateReporter(i.injectors, r, i.reporter)
+	}
+
+	i.reporter = r
+}
+
+// Name returns "chain".
+func (i *ChainInjector) Name() string {
+	return "chain"
+}
+
+// Describe returns the chained Injectors in order, and the LinkConditions of the links that have
+// one, such as "1:delay>=100ms" or "2:p=0.1,terminal".
+func (i *ChainInjector) Describe() map[string]string {
+	d := map[string]string{
+		"injectors": joinInjectorStrings(i.injectors),
+	}
+
+	if len(i.conditions) > 0 {
+		links := make([]int, 0, len(i.conditions))
+		for link := range i.conditions {
+			links = append(links, link)
+		}
+		sort.Ints(links)
+
+		conditions := make([]string, 0, len(links))
+		for _, link := range links {
+			conditions = append(conditions, fmt.Sprintf("%d:%s", link, i.conditions[link]))
+		}
+		d["conditions"] = strings.Join(conditions, " ")
+	}
+
+	return d
+}
+
+// String returns a summary of the ChainInjector, such as "chain(slow(750ms), error(500))".
+func (i *ChainInjector) String() string {
+	return fmt.Sprintf("%s(%s)", i.Name(), joinInjectorStrings(i.injectors))
+}
+
+// OnEnable runs the OnEnable hook of each Injector.
+func (i *ChainInjector) OnEnable() {
+	for _, c := range i.injectors {
+		runEnableHook(c)
+	}
+}
+
+// OnDisable runs the OnDisable hook of each Injector.
+func (i *ChainInjector) OnDisable() {
+	for _, c := range i.injectors {
+		runDisableHook(c)
+	}
+}
+
+// OnConfigChange runs the OnConfigChange hook of each Injector.
+func (i *ChainInjector) OnConfigChange() {
+	for _, c := range i.injectors {
+		runConfigChangeHook(c)
+	}
+}
+
+// Destructive returns true if any of the Injectors is destructive.
+func (i *ChainInjector) Destructive() bool {
+	for _, c := range i.injectors {
+		if IsDestructive(c) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// children returns the Injectors.
+func (i *ChainInjector) children() []Injector {
+	return i.injectors
+}
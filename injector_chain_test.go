@@ -2,9 +2,12 @@ package fault
 
 import (
 	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/github/go-fault/faulttest"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -12,6 +15,8 @@ import (
 func TestNewChainInjector(t *testing.T) {
 	t.Parallel()
 
+	dup := newTestInjectorHooks()
+
 	tests := []struct {
 		name         string
 		giveInjector []Injector
@@ -22,13 +27,39 @@ func TestNewChainInjector(t *testing.T) {
 			name:         "nil",
 			giveInjector: nil,
 			giveOptions:  []ChainInjectorOption{},
-			wantErr:      nil,
+			wantErr:      ErrNoInjectors,
 		},
 		{
 			name:         "empty",
 			giveInjector: []Injector{},
 			giveOptions:  []ChainInjectorOption{},
-			wantErr:      nil,
+			wantErr:      ErrNoInjectors,
+		},
+		{
+			name: "nil injectors",
+			giveInjector: []Injector{
+				newTestInjectorNoop(),
+				nil,
+				(*SlowInjector)(nil),
+			},
+			giveOptions: []ChainInjectorOption{},
+			wantErr: InjectorErrors{
+				{Index: 1, Err: ErrNilInjector},
+				{Index: 2, Err: ErrNilInjector},
+			},
+		},
+		{
+			name: "duplicate injector",
+			giveInjector: []Injector{
+				dup,
+				newTestInjectorNoop(),
+				newTestInjectorNoop(),
+				dup,
+			},
+			giveOptions: []ChainInjectorOption{},
+			wantErr: InjectorErrors{
+				{Index: 3, Err: ErrDuplicateInjector},
+			},
 		},
 		{
 			name: "one",
@@ -47,6 +78,79 @@ func TestNewChainInjector(t *testing.T) {
 			giveOptions: []ChainInjectorOption{},
 			wantErr:     nil,
 		},
+		{
+			name: "custom reporter",
+			giveInjector: []Injector{
+				newTestInjectorNoop(),
+			},
+			giveOptions: []ChainInjectorOption{
+				WithReporter(newTestReporter()),
+			},
+			wantErr: nil,
+		},
+		{
+			name: "link conditions",
+			giveInjector: []Injector{
+				newTestInjectorNoop(),
+				newTestInjector500s(),
+			},
+			giveOptions: []ChainInjectorOption{
+				WithLinkCondition(0, LinkCondition{}),
+				WithLinkCondition(1, LinkCondition{MinDelay: time.Second}),
+				WithClock(faulttest.NewClock(time.Time{})),
+			},
+			wantErr: nil,
+		},
+		{
+			name: "link out of range",
+			giveInjector: []Injector{
+				newTestInjectorNoop(),
+			},
+			giveOptions: []ChainInjectorOption{
+				WithLinkCondition(1, LinkCondition{}),
+			},
+			wantErr: ErrInvalidLink,
+		},
+		{
+			name: "negative link",
+			giveInjector: []Injector{
+				newTestInjectorNoop(),
+			},
+			giveOptions: []ChainInjectorOption{
+				WithLinkCondition(-1, LinkCondition{}),
+			},
+			wantErr: ErrInvalidLink,
+		},
+		{
+			name: "negative min delay",
+			giveInjector: []Injector{
+				newTestInjectorNoop(),
+			},
+			giveOptions: []ChainInjectorOption{
+				WithLinkCondition(0, LinkCondition{MinDelay: -time.Second}),
+			},
+			wantErr: ErrInvalidDelay,
+		},
+		{
+			name: "negative probability",
+			giveInjector: []Injector{
+				newTestInjectorNoop(),
+			},
+			giveOptions: []ChainInjectorOption{
+				WithLinkCondition(0, LinkCondition{Probability: -0.1}),
+			},
+			wantErr: ErrInvalidPercent,
+		},
+		{
+			name: "probability over one",
+			giveInjector: []Injector{
+				newTestInjectorNoop(),
+			},
+			giveOptions: []ChainInjectorOption{
+				WithLinkCondition(0, LinkCondition{Probability: 1.5}),
+			},
+			wantErr: ErrInvalidPercent,
+		},
 		{
 			name: "option error",
 			giveInjector: []Injector{
@@ -88,20 +192,6 @@ func TestChainInjectorHandler(t *testing.T) {
 		wantCode     int
 		wantBody     string
 	}{
-		{
-			name:         "nil",
-			giveInjector: nil,
-			giveOptions:  []ChainInjectorOption{},
-			wantCode:     testHandlerCode,
-			wantBody:     testHandlerBody,
-		},
-		{
-			name:         "empty",
-			giveInjector: []Injector{},
-			giveOptions:  []ChainInjectorOption{},
-			wantCode:     testHandlerCode,
-			wantBody:     testHandlerBody,
-		},
 		{
 			name: "one",
 			giveInjector: []Injector{
@@ -175,3 +265,202 @@ func TestChainInjectorHandler(t *testing.T) {
 		})
 	}
 }
+
+// testInjectorAdvance is an injector that advances a fake clock and continues.
+type testInjectorAdvance struct {
+	clock *faulttest.Clock
+	d     time.Duration
+}
+
+// Handler advances the clock by d and continues.
+func (i *testInjectorAdvance) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		i.clock.Advance(i.d)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// TestChainInjectorLinkCondition tests that links with a LinkCondition only run when it allows.
+func TestChainInjectorLinkCondition(t *testing.T) {
+	t.Parallel()
+
+	isSuccess := func(code int) bool { return code >= 200 && code < 300 }
+
+	tests := []struct {
+		name          string
+		giveDelay     time.Duration
+		giveLink      Injector
+		giveCondition LinkCondition
+		giveCode      int
+		wantCode      int
+		wantBody      string
+		wantHeader    string
+	}{
+		{
+			name:          "delayed",
+			giveDelay:     time.Second,
+			giveLink:      newTestInjector500s(),
+			giveCondition: LinkCondition{MinDelay: time.Second},
+			giveCode:      testHandlerCode,
+			wantCode:      http.StatusInternalServerError,
+			wantBody:      http.StatusText(http.StatusInternalServerError),
+		},
+		{
+			name:          "not delayed enough",
+			giveDelay:     time.Second - 1,
+			giveLink:      newTestInjector500s(),
+			giveCondition: LinkCondition{MinDelay: time.Second},
+			giveCode:      testHandlerCode,
+			wantCode:      testHandlerCode,
+			wantBody:      testHandlerBody,
+			wantHeader:    "handler",
+		},
+		{
+			name:          "successful response",
+			giveLink:      newTestInjector500s(),
+			giveCondition: LinkCondition{Response: isSuccess},
+			giveCode:      testHandlerCode,
+			wantCode:      http.StatusInternalServerError,
+			wantBody:      http.StatusText(http.StatusInternalServerError),
+		},
+		{
+			name:          "failed response",
+			giveLink:      newTestInjector500s(),
+			giveCondition: LinkCondition{Response: isSuccess},
+			giveCode:      http.StatusServiceUnavailable,
+			wantCode:      http.StatusServiceUnavailable,
+			wantBody:      testHandlerBody,
+			wantHeader:    "handler",
+		},
+		{
+			name:          "link passed buffered response",
+			giveDelay:     time.Second,
+			giveLink:      newTestInjectorOneOK(),
+			giveCondition: LinkCondition{MinDelay: time.Second, Response: isSuccess},
+			giveCode:      testHandlerCode,
+			wantCode:      http.StatusOK,
+			wantBody:      "one" + testHandlerBody,
+			wantHeader:    "handler",
+		},
+		{
+			name:          "always",
+			giveLink:      newTestInjectorOneOK(),
+			giveCondition: LinkCondition{},
+			giveCode:      testHandlerCode,
+			wantCode:      http.StatusOK,
+			wantBody:      "one" + testHandlerBody,
+			wantHeader:    "handler",
+		},
+		{
+			name:          "certain probability",
+			giveLink:      newTestInjector500s(),
+			giveCondition: LinkCondition{Probability: 1.0},
+			giveCode:      testHandlerCode,
+			wantCode:      http.StatusInternalServerError,
+			wantBody:      http.StatusText(http.StatusInternalServerError),
+		},
+		{
+			name:          "terminal",
+			giveLink:      newTestInjectorOneOK(),
+			giveCondition: LinkCondition{Terminal: true},
+			giveCode:      testHandlerCode,
+			wantCode:      http.StatusOK,
+			wantBody:      "one",
+		},
+		{
+			name:          "terminal without response",
+			giveLink:      newTestInjectorNoop(),
+			giveCondition: LinkCondition{Terminal: true},
+			giveCode:      testHandlerCode,
+			wantCode:      testHandlerCode,
+			wantBody:      testHandlerBody,
+			wantHeader:    "handler",
+		},
+		{
+			name:          "terminal in place of successful response",
+			giveLink:      newTestInjectorOneOK(),
+			giveCondition: LinkCondition{Response: isSuccess, Terminal: true},
+			giveCode:      testHandlerCode,
+			wantCode:      http.StatusOK,
+			wantBody:      "one",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			clock := faulttest.NewClock(time.Time{})
+			ci, err := NewChainInjector(
+				[]Injector{&testInjectorAdvance{clock: clock, d: tt.giveDelay}, tt.giveLink},
+				WithLinkCondition(1, tt.giveCondition),
+				WithClock(clock),
+			)
+			assert.NoError(t, err)
+
+			var calls int
+			rr := httptest.NewRecorder()
+			ci.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				w.Header().Set("X-Served-By", "handler")
+				http.Error(w, testHandlerBody, tt.giveCode)
+			})).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			assert.Equal(t, tt.wantCode, rr.Code)
+			assert.Equal(t, tt.wantBody, strings.TrimSpace(rr.Body.String()))
+			assert.Equal(t, tt.wantHeader, rr.Header().Get("X-Served-By"))
+			assert.True(t, calls <= 1, calls)
+		})
+	}
+}
+
+// TestLinkConditionString tests LinkCondition.String.
+func TestLinkConditionString(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "", LinkCondition{}.String())
+	assert.Equal(t, "delay>=100ms", LinkCondition{MinDelay: 100 * time.Millisecond}.String())
+	assert.Equal(t, "response", LinkCondition{Response: func(int) bool { return true }}.String())
+	assert.Equal(t, "delay>=1s,response",
+		LinkCondition{MinDelay: time.Second, Response: func(int) bool { return true }}.String())
+	assert.Equal(t, "p=0.1,terminal", LinkCondition{Probability: 0.1, Terminal: true}.String())
+}
+
+// TestChainInjectorLinkProbability tests that a link with a Probability runs for that fraction of
+// requests, and that a ChainInjector with the same seed runs it for the same requests.
+func TestChainInjectorLinkProbability(t *testing.T) {
+	t.Parallel()
+
+	serve := func(ci *ChainInjector) []int {
+		var codes []int
+		for n := 0; n < 100; n++ {
+			rr := httptest.NewRecorder()
+			ci.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(testHandlerCode)
+			})).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+			codes = append(codes, rr.Code)
+		}
+
+		return codes
+	}
+
+	ci, err := NewChainInjector([]Injector{newTestInjectorNoop(), newTestInjector500s()},
+		WithLinkCondition(1, LinkCondition{Probability: 0.25}), WithRandSeed(42))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(42), ci.Seed())
+
+	codes := serve(ci)
+	var failed int
+	for _, code := range codes {
+		if code == http.StatusInternalServerError {
+			failed++
+		}
+	}
+	assert.InDelta(t, 25, failed, 15)
+
+	replay, err := NewChainInjector([]Injector{newTestInjectorNoop(), newTestInjector500s()},
+		WithLinkCondition(1, LinkCondition{Probability: 0.25}), WithRandSeed(ci.Seed()))
+	assert.NoError(t, err)
+	assert.Equal(t, codes, serve(replay))
+}
@@ -0,0 +1,201 @@
+package fault
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"unicode/utf8"
+)
+
+var (
+	// ErrInvalidCharsetFault when an unknown CharsetFault is provided.
+	ErrInvalidCharsetFault = errors.New("not a valid charset fault")
+)
+
+// CharsetFault is a way of corrupting the charset of a response.
+type CharsetFault int
+
+const (
+	// CharsetLatin1 transcodes the body to ISO-8859-1 while still declaring UTF-8. Characters that
+	// Latin-1 cannot represent become '?'.
+	CharsetLatin1 CharsetFault = iota
+	// CharsetInvalidUTF8 inserts an invalid UTF-8 sequence into the middle of the body.
+	CharsetInvalidUTF8
+	// CharsetMislabel leaves the body alone and declares a different charset.
+	CharsetMislabel
+)
+
+// String returns the name of the CharsetFault.
+func (c CharsetFault) String() string {
+	switch c {
+	case CharsetLatin1:
+		return "latin1"
+	case CharsetInvalidUTF8:
+		return "invalid_utf8"
+	case CharsetMislabel:
+		return "mislabel"
+	default:
+		return fmt.Sprintf("CharsetFault(%d)", int(c))
+	}
+}
+
+// invalidUTF8 is a lone continuation byte followed by a truncated two byte sequence.
+var invalidUTF8 = []byte{0x80, 0xc3} //nolint:gochecknoglobals
+
+// CharsetInjector runs the request and then mislabels or transcodes the charset of the response to
+// test how clients handle text they cannot decode.
+type CharsetInjector struct {
+	fault    CharsetFault
+	charset  string
+	reporter Reporter
+}
+
+// CharsetInjectorOption configures a CharsetInjector.
+type CharsetInjectorOption interface {
+	applyCharsetInjector(i *CharsetInjector) error
+}
+
+type declaredCharsetOption string
+
+func (o declaredCharsetOption) applyCharsetInjector(i *CharsetInjector) error {
+	i.charset = string(o)
+	return nil
+}
+
+// WithDeclaredCharset sets the charset declared in the Content-Type of the response. Default
+// "iso-8859-1" for CharsetMislabel and "utf-8" otherwise.
+func WithDeclaredCharset(cs string) CharsetInjectorOption {
+	return declaredCharsetOption(cs)
+}
+
+func (o reporterOption) applyCharsetInjector(i *CharsetInjector) error {
+	i.reporter = o.reporter
+	return nil
+}
+
+// NewCharsetInjector returns a CharsetInjector that applies a CharsetFault to responses.
+func NewCharsetInjector(f CharsetFault, opts ...CharsetInjectorOption) (*CharsetInjector, error) {
+	if f < CharsetLatin1 || f > CharsetMislabel {
+		return nil, ErrInvalidCharsetFault
+	}
+
+	// set defaults
+	ci := &CharsetInjector{
+		fault:    f,
+		charset:  "utf-8",
+		reporter: NewNoopReporter(),
+	}
+	if f == CharsetMislabel {
+		ci.charset = "iso-8859-1"
+	}
+
+	// apply options
+	for _, opt := range opts {
+		err := opt.applyCharsetInjector(ci)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return ci, nil
+}
+
+// Handler buffers the response, changes the body according to the CharsetFault, and sets the
+// declared charset of the Content-Type. If the handler did not set a Content-Type it is detected
+// from the original body.
+func (i *CharsetInjector) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		go i.reporter.Report(i.String(), StateStarted)
+
+		bw := newBufferedWriter(w)
+		next.ServeHTTP(bw, r)
+
+		contentType := bw.header.Get("Content-Type")
+		if contentType == "" {
+			contentType = http.DetectContentType(bw.body.Bytes())
+		}
+		bw.header.Set("Content-Type", withCharset(contentType, i.charset))
+
+		switch i.fault {
+		case CharsetLatin1:
+			bw.setBody(toLatin1(bw.body.Bytes()))
+		case CharsetInvalidUTF8:
+			bw.setBody(insertInvalidUTF8(bw.body.Bytes()))
+		}
+		bw.send()
+		bw.release()
+
+		go i.reporter.Report(i.String(), StateFinished)
+	})
+}
+
+// withCharset returns contentType with its charset parameter replaced by charset.
+func withCharset(contentType, charset string) string {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return contentType
+	}
+	params["charset"] = charset
+
+	return mime.FormatMediaType(mediaType, params)
+}
+
+// toLatin1 transcodes UTF-8 text to ISO-8859-1.
+func toLatin1(b []byte) []byte {
+	out := make([]byte, 0, len(b))
+	for len(b) > 0 {
+		r, size := utf8.DecodeRune(b)
+		b = b[size:]
+
+		if r > 0xff {
+			r = '?'
+		}
+		out = append(out, byte(r))
+	}
+
+	return out
+}
+
+// insertInvalidUTF8 inserts an invalid UTF-8 sequence at the rune boundary nearest the middle of b.
+func insertInvalidUTF8(b []byte) []byte {
+	mid := len(b) / 2
+	for mid > 0 && !utf8.RuneStart(b[mid]) {
+		mid--
+	}
+
+	out := make([]byte, 0, len(b)+len(invalidUTF8))
+	out = append(out, b[:mid]...)
+	out = append(out, invalidUTF8...)
+	out = append(out, b[mid:]...)
+
+	return out
+}
+
+// Name returns "charset".
+func (i *CharsetInjector) Name() string {
+	return "charset"
+}
+
+// Describe returns the CharsetFault and the declared charset.
+func (i *CharsetInjector) Describe() map[string]string {
+	return map[string]string{
+		"fault":   i.fault.String(),
+		"charset": i.charset,
+	}
+}
+
+// String returns a summary of the CharsetInjector, such as "charset(latin1)".
+func (i *CharsetInjector) String() string {
+	return fmt.Sprintf("%s(%s)", i.Name(), i.fault)
+}
+
+// Reporter returns the Reporter of the CharsetInjector.
+func (i *CharsetInjector) Reporter() Reporter {
+	return i.reporter
+}
+
+// SetReporter replaces the Reporter of the CharsetInjector.
+func (i *CharsetInjector) SetReporter(r Reporter) {
+	i.reporter = r
+}
@@ -0,0 +1,173 @@
+package fault
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestNewCharsetInjector tests NewCharsetInjector.
+func TestNewCharsetInjector(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		giveFault   CharsetFault
+		giveOptions []CharsetInjectorOption
+		want        *CharsetInjector
+		wantErr     error
+	}{
+		{
+			name:      "latin1",
+			giveFault: CharsetLatin1,
+			want: &CharsetInjector{
+				fault:    CharsetLatin1,
+				charset:  "utf-8",
+				reporter: NewNoopReporter(),
+			},
+		},
+		{
+			name:      "mislabel",
+			giveFault: CharsetMislabel,
+			want: &CharsetInjector{
+				fault:    CharsetMislabel,
+				charset:  "iso-8859-1",
+				reporter: NewNoopReporter(),
+			},
+		},
+		{
+			name:      "options",
+			giveFault: CharsetInvalidUTF8,
+			giveOptions: []CharsetInjectorOption{
+				WithDeclaredCharset("utf-16"),
+				WithReporter(newTestReporter()),
+			},
+			want: &CharsetInjector{
+				fault:    CharsetInvalidUTF8,
+				charset:  "utf-16",
+				reporter: newTestReporter(),
+			},
+		},
+		{
+			name:      "invalid fault",
+			giveFault: CharsetFault(-1),
+			wantErr:   ErrInvalidCharsetFault,
+		},
+		{
+			name:      "option error",
+			giveFault: CharsetLatin1,
+			giveOptions: []CharsetInjectorOption{
+				withError(),
+			},
+			wantErr: errErrorOption,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ci, err := NewCharsetInjector(tt.giveFault, tt.giveOptions...)
+
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.want, ci)
+		})
+	}
+}
+
+// TestCharsetInjectorHandler tests CharsetInjector.Handler.
+func TestCharsetInjectorHandler(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name            string
+		giveFault       CharsetFault
+		giveContentType string
+		giveBody        string
+		wantContentType string
+		wantBody        string
+	}{
+		{
+			name:            "latin1",
+			giveFault:       CharsetLatin1,
+			giveContentType: "text/plain; charset=utf-8",
+			giveBody:        "café €",
+			wantContentType: "text/plain; charset=utf-8",
+			wantBody:        "caf\xe9 ?",
+		},
+		{
+			name:            "invalid utf8",
+			giveFault:       CharsetInvalidUTF8,
+			giveContentType: "application/json",
+			giveBody:        `"ééé"`,
+			wantContentType: "application/json; charset=utf-8",
+			wantBody:        "\"é\x80\xc3éé\"",
+		},
+		{
+			name:            "invalid utf8 empty body",
+			giveFault:       CharsetInvalidUTF8,
+			giveContentType: "text/plain",
+			wantContentType: "text/plain; charset=utf-8",
+			wantBody:        "\x80\xc3",
+		},
+		{
+			name:            "mislabel",
+			giveFault:       CharsetMislabel,
+			giveContentType: "text/html; charset=utf-8",
+			giveBody:        "café",
+			wantContentType: "text/html; charset=iso-8859-1",
+			wantBody:        "café",
+		},
+		{
+			name:            "detected content type",
+			giveFault:       CharsetMislabel,
+			giveBody:        "<html></html>",
+			wantContentType: "text/html; charset=iso-8859-1",
+			wantBody:        "<html></html>",
+		},
+		{
+			name:            "unparseable content type",
+			giveFault:       CharsetMislabel,
+			giveContentType: "text/plain; =",
+			giveBody:        "café",
+			wantContentType: "text/plain; =",
+			wantBody:        "café",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ci, err := NewCharsetInjector(tt.giveFault)
+			assert.NoError(t, err)
+
+			h := ci.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.giveContentType != "" {
+					w.Header().Set("Content-Type", tt.giveContentType)
+				}
+				_, _ = w.Write([]byte(tt.giveBody))
+			}))
+
+			rr := httptest.NewRecorder()
+			h.ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+
+			assert.Equal(t, tt.wantContentType, rr.Header().Get("Content-Type"))
+			assert.Equal(t, tt.wantBody, rr.Body.String())
+		})
+	}
+}
+
+// TestCharsetFaultString tests CharsetFault.String.
+func TestCharsetFaultString(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "latin1", CharsetLatin1.String())
+	assert.Equal(t, "invalid_utf8", CharsetInvalidUTF8.String())
+	assert.Equal(t, "mislabel", CharsetMislabel.String())
+	assert.Equal(t, "CharsetFault(7)", CharsetFault(7).String())
+}
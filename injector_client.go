@@ -0,0 +1,310 @@
+package fault
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"os"
+	"syscall"
+	"time"
+)
+
+var (
+	// ErrInvalidClientPhase when an unknown ClientPhase is provided.
+	ErrInvalidClientPhase = errors.New("not a valid client phase")
+	// ErrNilConnectError when a ConnectFailureInjector is given a nil error.
+	ErrNilConnectError = errors.New("connect error cannot be nil")
+)
+
+// ClientPhase is a phase of an outbound request that a ClientDelayInjector can slow down.
+type ClientPhase int
+
+const (
+	// ClientPhaseDNS delays the end of resolving the host of the request, between the DNSStart and
+	// DNSDone callbacks of its httptrace.ClientTrace. Requests sent on a reused connection, or to an
+	// IP address, do not resolve their host and are not delayed.
+	ClientPhaseDNS ClientPhase = iota
+	// ClientPhaseTTFB delays the first byte of the response, between the WroteRequest and
+	// GotFirstResponseByte callbacks of its httptrace.ClientTrace.
+	ClientPhaseTTFB
+)
+
+// String returns the name of the ClientPhase.
+func (p ClientPhase) String() string {
+	switch p {
+	case ClientPhaseDNS:
+		return "dns"
+	case ClientPhaseTTFB:
+		return "ttfb"
+	default:
+		return fmt.Sprintf("ClientPhase(%d)", int(p))
+	}
+}
+
+// ClientDelayInjector slows down a phase of outbound requests sent with an http.Transport, such as
+// through a Transport. It delays the phase from inside the httptrace.ClientTrace of the request, so
+// client instrumentation built on httptrace sees the same callbacks, in the same order, as for a
+// slow dependency. It has no effect on requests a server receives.
+type ClientDelayInjector struct {
+	phase    ClientPhase
+	duration time.Duration
+	clock    Clock
+	reporter Reporter
+}
+
+// ClientDelayInjectorOption configures a ClientDelayInjector.
+type ClientDelayInjectorOption interface {
+	applyClientDelayInjector(i *ClientDelayInjector) error
+}
+
+func (o clockOption) applyClientDelayInjector(i *ClientDelayInjector) error {
+	i.clock = o.clock
+	return nil
+}
+
+func (o reporterOption) applyClientDelayInjector(i *ClientDelayInjector) error {
+	i.reporter = o.reporter
+	return nil
+}
+
+// NewClientDelayInjector returns a ClientDelayInjector that delays phase by d. It returns
+// ErrInvalidClientPhase if phase is unknown and ErrInvalidDelay if d is negative.
+func NewClientDelayInjector(phase ClientPhase, d time.Duration, opts ...ClientDelayInjectorOption) (
+	*ClientDelayInjector, error,
+) {
+	if phase < ClientPhaseDNS || phase > ClientPhaseTTFB {
+		return nil, ErrInvalidClientPhase
+	}
+	if d < 0 {
+		return nil, ErrInvalidDelay
+	}
+
+	// set defaults
+	ci := &ClientDelayInjector{
+		phase:    phase,
+		duration: d,
+		clock:    NewRealClock(),
+		reporter: NewNoopReporter(),
+	}
+
+	// apply options
+	for _, opt := range opts {
+		err := opt.applyClientDelayInjector(ci)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return ci, nil
+}
+
+// Handler continues the request with an httptrace.ClientTrace that waits once its phase ends,
+// before the callbacks of the ClientTraces already on the request run. The wait ends early if the
+// context of the request is done.
+func (i *ClientDelayInjector) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		delay := func() {
+			go i.reporter.Report(i.String(), StateStarted)
+			i.wait(ctx)
+			go i.reporter.Report(i.String(), StateFinished)
+		}
+
+		trace := &httptrace.ClientTrace{}
+		switch i.phase {
+		case ClientPhaseDNS:
+			trace.DNSDone = func(httptrace.DNSDoneInfo) { delay() }
+		case ClientPhaseTTFB:
+			trace.GotFirstResponseByte = delay
+		}
+
+		next.ServeHTTP(w, r.WithContext(httptrace.WithClientTrace(ctx, trace)))
+	})
+}
+
+// wait waits the duration of the ClientDelayInjector or until ctx is done.
+func (i *ClientDelayInjector) wait(ctx context.Context) {
+	select {
+	case <-i.clock.After(addLatency(ctx, i.duration)):
+	case <-ctx.Done():
+	}
+}
+
+// Reporter returns the Reporter of the ClientDelayInjector.
+func (i *ClientDelayInjector) Reporter() Reporter {
+	return i.reporter
+}
+
+// SetReporter replaces the Reporter of the ClientDelayInjector.
+func (i *ClientDelayInjector) SetReporter(r Reporter) {
+	i.reporter = r
+}
+
+// Name returns "client_delay".
+func (i *ClientDelayInjector) Name() string {
+	return "client_delay"
+}
+
+// Describe returns the phase and the delay.
+func (i *ClientDelayInjector) Describe() map[string]string {
+	return map[string]string{
+		"phase":    i.phase.String(),
+		"duration": i.duration.String(),
+	}
+}
+
+// String returns a summary of the ClientDelayInjector, such as "client_delay(dns/2s)".
+func (i *ClientDelayInjector) String() string {
+	return fmt.Sprintf("%s(%s/%s)", i.Name(), i.phase, i.duration)
+}
+
+// ConnectFailureInjector fails outbound requests sent through a Transport as if the remote host
+// refused the connection. It calls the GetConn, ConnectStart, and ConnectDone callbacks of the
+// httptrace.ClientTrace of the request, as an http.Transport does for a connection that fails, and
+// the request fails with a *net.OpError without being sent. The host is not resolved, so the DNS
+// callbacks are not called. On requests a server receives, it aborts the request with
+// http.ErrAbortHandler like a RejectInjector.
+type ConnectFailureInjector struct {
+	err      error
+	reporter Reporter
+}
+
+// ConnectFailureInjectorOption configures a ConnectFailureInjector.
+type ConnectFailureInjectorOption interface {
+	applyConnectFailureInjector(i *ConnectFailureInjector) error
+}
+
+func (o reporterOption) applyConnectFailureInjector(i *ConnectFailureInjector) error {
+	i.reporter = o.reporter
+	return nil
+}
+
+type connectErrorOption struct {
+	err error
+}
+
+func (o connectErrorOption) applyConnectFailureInjector(i *ConnectFailureInjector) error {
+	if o.err == nil {
+		return ErrNilConnectError
+	}
+
+	i.err = o.err
+	return nil
+}
+
+// WithConnectError sets the error the connection fails with, wrapped in a *net.OpError, such as
+// syscall.ETIMEDOUT or syscall.ENETUNREACH. Default connection refused.
+func WithConnectError(err error) ConnectFailureInjectorOption {
+	return connectErrorOption{err}
+}
+
+// NewConnectFailureInjector returns a ConnectFailureInjector.
+func NewConnectFailureInjector(opts ...ConnectFailureInjectorOption) (*ConnectFailureInjector, error) {
+	// set defaults
+	ci := &ConnectFailureInjector{
+		err:      &os.SyscallError{Syscall: "connect", Err: syscall.ECONNREFUSED},
+		reporter: NewNoopReporter(),
+	}
+
+	// apply options
+	for _, opt := range opts {
+		err := opt.applyConnectFailureInjector(ci)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return ci, nil
+}
+
+// Handler fails the request without continuing it.
+func (i *ConnectFailureInjector) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		go i.reporter.Report(i.String(), StateStarted)
+		defer func() { go i.reporter.Report(i.String(), StateFinished) }()
+
+		addr := canonicalAddr(r.URL)
+		err := &net.OpError{Op: "dial", Net: "tcp", Addr: tcpHostAddr(addr), Err: i.err}
+
+		if trace := httptrace.ContextClientTrace(r.Context()); trace != nil {
+			if trace.GetConn != nil {
+				trace.GetConn(addr)
+			}
+			if trace.ConnectStart != nil {
+				trace.ConnectStart("tcp", addr)
+			}
+			if trace.ConnectDone != nil {
+				trace.ConnectDone("tcp", addr, err)
+			}
+		}
+
+		if !failTransport(r, err) {
+			abort()
+		}
+	})
+}
+
+// Reporter returns the Reporter of the ConnectFailureInjector.
+func (i *ConnectFailureInjector) Reporter() Reporter {
+	return i.reporter
+}
+
+// SetReporter replaces the Reporter of the ConnectFailureInjector.
+func (i *ConnectFailureInjector) SetReporter(r Reporter) {
+	i.reporter = r
+}
+
+// Name returns "connect_failure".
+func (i *ConnectFailureInjector) Name() string {
+	return "connect_failure"
+}
+
+// Describe returns the error the connection fails with.
+func (i *ConnectFailureInjector) Describe() map[string]string {
+	return map[string]string{
+		"error": i.err.Error(),
+	}
+}
+
+// String returns a summary of the ConnectFailureInjector, such as
+// "connect_failure(connect: connection refused)".
+func (i *ConnectFailureInjector) String() string {
+	return fmt.Sprintf("%s(%s)", i.Name(), i.err)
+}
+
+// Destructive returns true. On requests a server receives, the aborted request leaves the client
+// unsure if it was applied.
+func (i *ConnectFailureInjector) Destructive() bool {
+	return true
+}
+
+// canonicalAddr returns the "host:port" an http.Transport connects to for u, with the default port
+// of its scheme if it has none.
+func canonicalAddr(u *url.URL) string {
+	port := u.Port()
+	if port == "" {
+		port = "80"
+		if u.Scheme == "https" {
+			port = "443"
+		}
+	}
+
+	return net.JoinHostPort(u.Hostname(), port)
+}
+
+// tcpHostAddr is a net.Addr of a TCP "host:port" that was not resolved.
+type tcpHostAddr string
+
+// Network returns "tcp".
+func (a tcpHostAddr) Network() string {
+	return "tcp"
+}
+
+// String returns the "host:port".
+func (a tcpHostAddr) String() string {
+	return string(a)
+}
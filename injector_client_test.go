@@ -0,0 +1,430 @@
+package fault
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httptrace"
+	"net/url"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/github/go-fault/faulttest"
+	"github.com/stretchr/testify/assert"
+)
+
+// testTrace records the first time each callback of an httptrace.ClientTrace is called, in order.
+type testTrace struct {
+	mtx   sync.Mutex
+	order []string
+	at    map[string]time.Time
+
+	// connectAddr and connectErr are the arguments of the last ConnectDone callback.
+	connectAddr string
+	connectErr  error
+}
+
+// clientTrace returns an httptrace.ClientTrace that records its callbacks to tr.
+func (tr *testTrace) clientTrace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		GetConn:      func(string) { tr.record("GetConn") },
+		DNSStart:     func(httptrace.DNSStartInfo) { tr.record("DNSStart") },
+		DNSDone:      func(httptrace.DNSDoneInfo) { tr.record("DNSDone") },
+		ConnectStart: func(network, addr string) { tr.record("ConnectStart") },
+		ConnectDone: func(network, addr string, err error) {
+			tr.mtx.Lock()
+			tr.connectAddr, tr.connectErr = network+":"+addr, err
+			tr.mtx.Unlock()
+			tr.record("ConnectDone")
+		},
+		TLSHandshakeStart:    func() { tr.record("TLSHandshakeStart") },
+		TLSHandshakeDone:     func(tls.ConnectionState, error) { tr.record("TLSHandshakeDone") },
+		GotConn:              func(httptrace.GotConnInfo) { tr.record("GotConn") },
+		WroteHeaders:         func() { tr.record("WroteHeaders") },
+		WroteRequest:         func(httptrace.WroteRequestInfo) { tr.record("WroteRequest") },
+		GotFirstResponseByte: func() { tr.record("GotFirstResponseByte") },
+	}
+}
+
+// record records the first call of the callback named name.
+func (tr *testTrace) record(name string) {
+	tr.mtx.Lock()
+	defer tr.mtx.Unlock()
+
+	if tr.at == nil {
+		tr.at = make(map[string]time.Time)
+	}
+	if _, ok := tr.at[name]; ok {
+		return
+	}
+
+	tr.order = append(tr.order, name)
+	tr.at[name] = time.Now()
+}
+
+// between returns the time between the first calls of the callbacks named from and to.
+func (tr *testTrace) between(from, to string) time.Duration {
+	tr.mtx.Lock()
+	defer tr.mtx.Unlock()
+
+	return tr.at[to].Sub(tr.at[from])
+}
+
+// TestNewClientDelayInjector tests NewClientDelayInjector.
+func TestNewClientDelayInjector(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		givePhase    ClientPhase
+		giveDuration time.Duration
+		giveOptions  []ClientDelayInjectorOption
+		wantString   string
+		wantDescribe map[string]string
+		wantErr      error
+	}{
+		{
+			name:         "dns",
+			givePhase:    ClientPhaseDNS,
+			giveDuration: 2 * time.Second,
+			giveOptions:  []ClientDelayInjectorOption{},
+			wantString:   "client_delay(dns/2s)",
+			wantDescribe: map[string]string{"phase": "dns", "duration": "2s"},
+		},
+		{
+			name:         "ttfb with options",
+			givePhase:    ClientPhaseTTFB,
+			giveDuration: time.Millisecond,
+			giveOptions: []ClientDelayInjectorOption{
+				WithClock(faulttest.NewClock(time.Time{})),
+				WithReporter(NewNoopReporter()),
+			},
+			wantString:   "client_delay(ttfb/1ms)",
+			wantDescribe: map[string]string{"phase": "ttfb", "duration": "1ms"},
+		},
+		{
+			name:         "invalid phase",
+			givePhase:    ClientPhase(7),
+			giveDuration: time.Second,
+			wantErr:      ErrInvalidClientPhase,
+		},
+		{
+			name:         "negative duration",
+			givePhase:    ClientPhaseDNS,
+			giveDuration: -time.Second,
+			wantErr:      ErrInvalidDelay,
+		},
+		{
+			name:         "option error",
+			givePhase:    ClientPhaseDNS,
+			giveDuration: time.Second,
+			giveOptions:  []ClientDelayInjectorOption{withError()},
+			wantErr:      errErrorOption,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ci, err := NewClientDelayInjector(tt.givePhase, tt.giveDuration, tt.giveOptions...)
+
+			assert.Equal(t, tt.wantErr, err)
+			if tt.wantErr != nil {
+				assert.Nil(t, ci)
+				return
+			}
+
+			assert.Equal(t, tt.wantString, ci.String())
+			assert.Equal(t, tt.wantDescribe, ci.Describe())
+		})
+	}
+}
+
+// TestClientPhaseString tests ClientPhase.String.
+func TestClientPhaseString(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "dns", ClientPhaseDNS.String())
+	assert.Equal(t, "ttfb", ClientPhaseTTFB.String())
+	assert.Equal(t, "ClientPhase(7)", ClientPhase(7).String())
+}
+
+// TestClientDelayInjectorTrace tests that a ClientDelayInjector delays a phase of an outbound
+// request between the httptrace callbacks of the phase, which run in the order of a real request.
+func TestClientDelayInjectorTrace(t *testing.T) {
+	t.Parallel()
+
+	const delay = 50 * time.Millisecond
+
+	tests := []struct {
+		name      string
+		givePhase ClientPhase
+		wantFrom  string
+		wantTo    string
+	}{
+		{
+			name:      "dns",
+			givePhase: ClientPhaseDNS,
+			wantFrom:  "DNSStart",
+			wantTo:    "DNSDone",
+		},
+		{
+			name:      "ttfb",
+			givePhase: ClientPhaseTTFB,
+			wantFrom:  "WroteRequest",
+			wantTo:    "GotFirstResponseByte",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(testHandlerCode)
+			}))
+			defer upstream.Close()
+
+			// resolve the host of the upstream so the DNS callbacks are called
+			u, err := url.Parse(upstream.URL)
+			assert.NoError(t, err)
+			u.Host = net.JoinHostPort("localhost", u.Port())
+
+			ci, err := NewClientDelayInjector(tt.givePhase, delay)
+			assert.NoError(t, err)
+			base := &http.Transport{}
+			defer base.CloseIdleConnections()
+			tr, err := NewTransport(ci, WithTransport(base))
+			assert.NoError(t, err)
+
+			trace := &testTrace{}
+			req, err := http.NewRequestWithContext(
+				httptrace.WithClientTrace(context.Background(), trace.clientTrace()), http.MethodGet, u.String(), nil)
+			assert.NoError(t, err)
+
+			resp, err := (&http.Client{Transport: tr}).Do(req)
+			assert.NoError(t, err)
+			defer resp.Body.Close()
+			assert.Equal(t, testHandlerCode, resp.StatusCode)
+
+			// a dual stack host may be tried on more than one address, so only first calls are recorded
+			assert.Equal(t, []string{
+				"GetConn",
+				"DNSStart",
+				"DNSDone",
+				"ConnectStart",
+				"ConnectDone",
+				"GotConn",
+				"WroteHeaders",
+				"WroteRequest",
+				"GotFirstResponseByte",
+			}, trace.order)
+			assert.GreaterOrEqual(t, int64(trace.between(tt.wantFrom, tt.wantTo)), int64(delay))
+		})
+	}
+}
+
+// TestClientDelayInjectorCanceled tests that a ClientDelayInjector stops waiting once the context
+// of the request is done.
+func TestClientDelayInjectorCanceled(t *testing.T) {
+	t.Parallel()
+
+	// the upstream sends the first byte of its response and then never finishes the headers, so the
+	// request can only end by the wait being canceled
+	sent := make(chan struct{})
+	hold := make(chan struct{})
+	defer close(hold)
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, buf, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		_, _ = buf.WriteString("HTTP/1.1 2")
+		_ = buf.Flush()
+		close(sent)
+		<-hold
+	}))
+	defer upstream.Close()
+
+	ci, err := NewClientDelayInjector(ClientPhaseTTFB, time.Hour)
+	assert.NoError(t, err)
+	tr, err := NewTransport(ci, WithTransport(&http.Transport{}))
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, upstream.URL, nil)
+	assert.NoError(t, err)
+
+	go func() {
+		<-sent
+		cancel()
+	}()
+
+	start := time.Now()
+	resp, err := (&http.Client{Transport: tr}).Do(req)
+	if err == nil {
+		resp.Body.Close()
+	}
+	assert.True(t, errors.Is(err, context.Canceled), err)
+	assert.Less(t, int64(time.Since(start)), int64(time.Minute))
+}
+
+// TestClientDelayInjectorServer tests that a ClientDelayInjector does not delay requests a server
+// receives.
+func TestClientDelayInjectorServer(t *testing.T) {
+	t.Parallel()
+
+	ci, err := NewClientDelayInjector(ClientPhaseTTFB, time.Hour)
+	assert.NoError(t, err)
+
+	f, err := NewFault(ci, WithEnabled(true), WithParticipation(1.0))
+	assert.NoError(t, err)
+
+	rr := testRequest(t, f)
+	assert.Equal(t, testHandlerCode, rr.Code)
+}
+
+// TestNewConnectFailureInjector tests NewConnectFailureInjector.
+func TestNewConnectFailureInjector(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		giveOptions  []ConnectFailureInjectorOption
+		wantString   string
+		wantDescribe map[string]string
+		wantErr      error
+	}{
+		{
+			name:         "default",
+			giveOptions:  []ConnectFailureInjectorOption{},
+			wantString:   "connect_failure(connect: connection refused)",
+			wantDescribe: map[string]string{"error": "connect: connection refused"},
+		},
+		{
+			name: "connect error",
+			giveOptions: []ConnectFailureInjectorOption{
+				WithConnectError(syscall.ETIMEDOUT),
+				WithReporter(NewNoopReporter()),
+			},
+			wantString:   "connect_failure(connection timed out)",
+			wantDescribe: map[string]string{"error": "connection timed out"},
+		},
+		{
+			name:        "nil connect error",
+			giveOptions: []ConnectFailureInjectorOption{WithConnectError(nil)},
+			wantErr:     ErrNilConnectError,
+		},
+		{
+			name:        "option error",
+			giveOptions: []ConnectFailureInjectorOption{withError()},
+			wantErr:     errErrorOption,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ci, err := NewConnectFailureInjector(tt.giveOptions...)
+
+			assert.Equal(t, tt.wantErr, err)
+			if tt.wantErr != nil {
+				assert.Nil(t, ci)
+				return
+			}
+
+			assert.Equal(t, tt.wantString, ci.String())
+			assert.Equal(t, tt.wantDescribe, ci.Describe())
+			assert.True(t, ci.Destructive())
+		})
+	}
+}
+
+// TestConnectFailureInjectorTrace tests that a ConnectFailureInjector fails an outbound request
+// with the httptrace callbacks and error of a refused connection.
+func TestConnectFailureInjectorTrace(t *testing.T) {
+	t.Parallel()
+
+	ci, err := NewConnectFailureInjector()
+	assert.NoError(t, err)
+	f, err := NewFault(ci, WithEnabled(true), WithParticipation(1.0))
+	assert.NoError(t, err)
+	tr, err := NewTransport(f)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		giveURL  string
+		wantAddr string
+	}{
+		{
+			name:     "http",
+			giveURL:  "http://example.invalid/",
+			wantAddr: "example.invalid:80",
+		},
+		{
+			name:     "https",
+			giveURL:  "https://example.invalid/",
+			wantAddr: "example.invalid:443",
+		},
+		{
+			name:     "port",
+			giveURL:  "http://[::1]:8080/",
+			wantAddr: "[::1]:8080",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			trace := &testTrace{}
+			req, err := http.NewRequestWithContext(
+				httptrace.WithClientTrace(context.Background(), trace.clientTrace()), http.MethodGet, tt.giveURL, nil)
+			assert.NoError(t, err)
+
+			resp, err := (&http.Client{Transport: tr}).Do(req)
+			assert.Nil(t, resp)
+			assert.True(t, errors.Is(err, syscall.ECONNREFUSED))
+
+			var opErr *net.OpError
+			assert.True(t, errors.As(err, &opErr))
+			assert.Equal(t, "dial", opErr.Op)
+			assert.Equal(t, "tcp", opErr.Addr.Network())
+			assert.Equal(t, tt.wantAddr, opErr.Addr.String())
+
+			assert.Equal(t, []string{"GetConn", "ConnectStart", "ConnectDone"}, trace.order)
+			assert.Equal(t, "tcp:"+tt.wantAddr, trace.connectAddr)
+			assert.Equal(t, error(opErr), trace.connectErr)
+		})
+	}
+}
+
+// TestConnectFailureInjectorServer tests that a ConnectFailureInjector aborts requests a server
+// receives.
+func TestConnectFailureInjectorServer(t *testing.T) {
+	t.Parallel()
+
+	ci, err := NewConnectFailureInjector()
+	assert.NoError(t, err)
+
+	f, err := NewFault(ci, WithEnabled(true), WithParticipation(1.0))
+	assert.NoError(t, err)
+
+	assert.Panics(t, func() { testRequest(t, f) })
+	assert.Equal(t, "connect_failure", ci.Name())
+}
@@ -0,0 +1,209 @@
+package fault
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+var (
+	// ErrNilPredicate when a ConditionalInjector is given a nil predicate.
+	ErrNilPredicate = errors.New("predicate cannot be nil")
+)
+
+// ConditionalInjector runs one of two Injectors depending on a predicate of the request, such as
+// slowing down mobile user agents and failing the requests of one tenant, without writing a custom
+// Injector each time.
+type ConditionalInjector struct {
+	predicate    func(*http.Request) bool
+	ifInjector   Injector
+	elseInjector Injector
+
+	// injectors are the Injectors of both branches, without a nil elseInjector.
+	injectors []Injector
+
+	reporter Reporter
+
+	// propagate determines if reporter is given to the Injectors.
+	propagate bool
+
+	// limits limit the Injectors.
+	limits compositeLimits
+}
+
+// ConditionalInjectorOption configures a ConditionalInjector.
+type ConditionalInjectorOption interface {
+	applyConditionalInjector(i *ConditionalInjector) error
+}
+
+func (o reporterOption) applyConditionalInjector(i *ConditionalInjector) error {
+	i.reporter = o.reporter
+	return nil
+}
+
+func (o reporterPropagationOption) applyConditionalInjector(i *ConditionalInjector) error {
+	i.propagate = bool(o)
+	return nil
+}
+
+func (o maxInjectorsOption) applyConditionalInjector(i *ConditionalInjector) error {
+	return o.apply(&i.limits)
+}
+
+func (o maxDepthOption) applyConditionalInjector(i *ConditionalInjector) error {
+	return o.apply(&i.limits)
+}
+
+// NewConditionalInjector returns a ConditionalInjector that runs ifInjector on the requests
+// predicate returns true for and elseInjector on the others. elseInjector may be nil to pass the
+// others through untouched. Pass the MatchRequest method of a RequestMatcher, such as
+// MatchHeader("X-Tenant", "acme").MatchRequest, to reuse the matchers of a Fault. It returns
+// ErrNilPredicate if predicate is nil, InjectorErrors naming ifInjector (index 0) or elseInjector
+// (index 1) if it is nil or both are the same Injector, or ErrTooManyInjectors or ErrTooDeep if
+// they exceed WithMaxInjectors() or WithMaxDepth().
+func NewConditionalInjector(predicate func(*http.Request) bool, ifInjector, elseInjector Injector,
+	opts ...ConditionalInjectorOption) (*ConditionalInjector, error) {
+	if predicate == nil {
+		return nil, ErrNilPredicate
+	}
+
+	is := []Injector{ifInjector}
+	if elseInjector != nil {
+		is = append(is, elseInjector)
+	}
+
+	err := validateInjectors(is)
+	if err != nil {
+		return nil, err
+	}
+
+	// set defaults
+	ci := &ConditionalInjector{
+		predicate:    predicate,
+		ifInjector:   ifInjector,
+		elseInjector: elseInjector,
+		injectors:    is,
+		reporter:     NewNoopReporter(),
+		limits:       defaultCompositeLimits(),
+	}
+
+	// apply options
+	for _, opt := range opts {
+		err := opt.applyConditionalInjector(ci)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// check options
+	err = ci.limits.check(is)
+	if err != nil {
+		return nil, err
+	}
+
+	if ci.propagate {
+		PropagateReporter(is, ci.reporter, nil)
+	}
+
+	return ci, nil
+}
+
+// Handler runs the Injector of the branch the predicate chooses for the request. A request for a
+// nil elseInjector is reported as StateSkipped and continues.
+func (i *ConditionalInjector) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		branch := i.elseInjector
+		if i.predicate(r) {
+			branch = i.ifInjector
+		}
+
+		if branch == nil {
+			go i.reporter.Report(i.String(), StateSkipped)
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		go i.reporter.Report(i.String(), StateStarted)
+		recordInjector(branch, next).ServeHTTP(w, r)
+	})
+}
+
+// Reporter returns the Reporter of the ConditionalInjector.
+func (i *ConditionalInjector) Reporter() Reporter {
+	return i.reporter
+}
+
+// SetReporter replaces the Reporter of the ConditionalInjector. With WithReporterPropagation(true)
+// it also replaces the Reporter of each Injector that has no Reporter of its own.
+func (i *ConditionalInjector) SetReporter(r Reporter) {
+	if i.propagate {
+		PropagateReporter(i.injectors, r, i.reporter)
+	}
+
+	i.reporter = r
+}
+
+// Name returns "conditional".
+func (i *ConditionalInjector) Name() string {
+	return "conditional"
+}
+
+// Describe returns the Injectors of both branches, where a nil elseInjector is "none".
+func (i *ConditionalInjector) Describe() map[string]string {
+	return map[string]string{
+		"if":   InjectorString(i.ifInjector),
+		"else": i.elseString(),
+	}
+}
+
+// String returns a summary of the ConditionalInjector, such as
+// "conditional(slow(750ms) else error(500))".
+func (i *ConditionalInjector) String() string {
+	return fmt.Sprintf("%s(%s else %s)", i.Name(), InjectorString(i.ifInjector), i.elseString())
+}
+
+// elseString returns the summary of elseInjector, or "none" if it is nil.
+func (i *ConditionalInjector) elseString() string {
+	if i.elseInjector == nil {
+		return "none"
+	}
+
+	return InjectorString(i.elseInjector)
+}
+
+// OnEnable runs the OnEnable hook of each Injector.
+func (i *ConditionalInjector) OnEnable() {
+	for _, c := range i.injectors {
+		runEnableHook(c)
+	}
+}
+
+// OnDisable runs the OnDisable hook of each Injector.
+func (i *ConditionalInjector) OnDisable() {
+	for _, c := range i.injectors {
+		runDisableHook(c)
+	}
+}
+
+// OnConfigChange runs the OnConfigChange hook of each Injector.
+func (i *ConditionalInjector) OnConfigChange() {
+	for _, c := range i.injectors {
+		runConfigChangeHook(c)
+	}
+}
+
+// Destructive returns true if either Injector is destructive.
+func (i *ConditionalInjector) Destructive() bool {
+	for _, c := range i.injectors {
+		if IsDestructive(c) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// children returns the Injectors.
+func (i *ConditionalInjector) children() []Injector {
+	return i.injectors
+}
@@ -0,0 +1,219 @@
+package fault
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testPredicate matches requests with the test header.
+var testPredicate = MatchHeader(testHeaderKey, testHeaderVal).MatchRequest
+
+// TestNewConditionalInjector tests NewConditionalInjector.
+func TestNewConditionalInjector(t *testing.T) {
+	t.Parallel()
+
+	dup := newTestInjectorHooks()
+	chain, _ := NewChainInjector([]Injector{newTestInjectorNoop()})
+	nested, _ := NewConditionalInjector(testPredicate, chain, nil)
+
+	tests := []struct {
+		name          string
+		givePredicate func(*http.Request) bool
+		giveIf        Injector
+		giveElse      Injector
+		giveOptions   []ConditionalInjectorOption
+		wantString    string
+		wantDescribe  map[string]string
+		wantErr       error
+	}{
+		{
+			name:          "if and else",
+			givePredicate: testPredicate,
+			giveIf:        newTestInjector500s(),
+			giveElse:      newTestInjectorNoop(),
+			giveOptions:   []ConditionalInjectorOption{},
+			wantString:    "conditional(testInjector500s else testInjectorNoop)",
+			wantDescribe:  map[string]string{"if": "testInjector500s", "else": "testInjectorNoop"},
+		},
+		{
+			name:          "no else",
+			givePredicate: testPredicate,
+			giveIf:        newTestInjector500s(),
+			giveOptions:   []ConditionalInjectorOption{WithReporter(NewNoopReporter()), WithMaxDepth(1)},
+			wantString:    "conditional(testInjector500s else none)",
+			wantDescribe:  map[string]string{"if": "testInjector500s", "else": "none"},
+		},
+		{
+			name:    "nil predicate",
+			giveIf:  newTestInjector500s(),
+			wantErr: ErrNilPredicate,
+		},
+		{
+			name:          "nil if",
+			givePredicate: testPredicate,
+			giveElse:      newTestInjectorNoop(),
+			wantErr:       InjectorErrors{{Index: 0, Err: ErrNilInjector}},
+		},
+		{
+			name:          "typed nil else",
+			givePredicate: testPredicate,
+			giveIf:        newTestInjectorNoop(),
+			giveElse:      (*SlowInjector)(nil),
+			wantErr:       InjectorErrors{{Index: 1, Err: ErrNilInjector}},
+		},
+		{
+			name:          "same injector",
+			givePredicate: testPredicate,
+			giveIf:        dup,
+			giveElse:      dup,
+			wantErr:       InjectorErrors{{Index: 1, Err: ErrDuplicateInjector}},
+		},
+		{
+			name:          "too many",
+			givePredicate: testPredicate,
+			giveIf:        newTestInjector500s(),
+			giveElse:      newTestInjectorNoop(),
+			giveOptions:   []ConditionalInjectorOption{WithMaxInjectors(1)},
+			wantErr:       ErrTooManyInjectors,
+		},
+		{
+			name:          "too deep",
+			givePredicate: testPredicate,
+			giveIf:        nested,
+			giveOptions:   []ConditionalInjectorOption{WithMaxDepth(2)},
+			wantErr:       ErrTooDeep,
+		},
+		{
+			name:          "option error",
+			givePredicate: testPredicate,
+			giveIf:        newTestInjector500s(),
+			giveOptions:   []ConditionalInjectorOption{withError()},
+			wantErr:       errErrorOption,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ci, err := NewConditionalInjector(tt.givePredicate, tt.giveIf, tt.giveElse, tt.giveOptions...)
+
+			assert.Equal(t, tt.wantErr, err)
+			if tt.wantErr != nil {
+				assert.Nil(t, ci)
+				return
+			}
+
+			assert.Equal(t, "conditional", ci.Name())
+			assert.Equal(t, tt.wantString, ci.String())
+			assert.Equal(t, tt.wantDescribe, ci.Describe())
+		})
+	}
+}
+
+// TestConditionalInjectorHandler tests that ConditionalInjector.Handler runs the Injector of the
+// branch its predicate chooses.
+func TestConditionalInjectorHandler(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		giveMatch bool
+		giveElse  Injector
+		wantCode  int
+		wantState InjectorState
+	}{
+		{
+			name:      "if",
+			giveMatch: true,
+			giveElse:  newTestInjectorTwoTeapot(),
+			wantCode:  http.StatusInternalServerError,
+			wantState: StateStarted,
+		},
+		{
+			name:      "else",
+			giveMatch: false,
+			giveElse:  newTestInjectorTwoTeapot(),
+			wantCode:  http.StatusTeapot,
+			wantState: StateStarted,
+		},
+		{
+			name:      "no else",
+			giveMatch: false,
+			wantCode:  testHandlerCode,
+			wantState: StateSkipped,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			reporter := newTestStateReporter()
+			ci, err := NewConditionalInjector(func(r *http.Request) bool {
+				return testPredicate(r) == tt.giveMatch
+			}, newTestInjector500s(), tt.giveElse, WithReporter(reporter))
+			assert.NoError(t, err)
+
+			f, err := NewFault(ci, WithEnabled(true), WithParticipation(1.0))
+			assert.NoError(t, err)
+
+			rr := testRequest(t, f)
+			assert.Equal(t, tt.wantCode, rr.Code)
+			assert.Equal(t, tt.wantState, <-reporter.states)
+		})
+	}
+}
+
+// TestConditionalInjectorHistory tests that the Injector of the chosen branch is recorded in the
+// history of the request.
+func TestConditionalInjectorHistory(t *testing.T) {
+	t.Parallel()
+
+	si, err := NewSlowInjector(0)
+	assert.NoError(t, err)
+	ci, err := NewConditionalInjector(testPredicate, si, nil)
+	assert.NoError(t, err)
+
+	var records []InjectionRecord
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(testHeaderKey, testHeaderVal)
+	ci.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		records = HistoryFromContext(r.Context())
+	})).ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.Len(t, records, 1)
+	assert.Equal(t, "slow", records[0].Injector)
+}
+
+// TestConditionalInjectorHooks tests that ConditionalInjector runs the lifecycle hooks of both
+// branches and is destructive if either branch is.
+func TestConditionalInjectorHooks(t *testing.T) {
+	t.Parallel()
+
+	ifHooks := newTestInjectorHooks()
+	elseHooks := newTestInjectorHooks()
+	ci, err := NewConditionalInjector(testPredicate, ifHooks, elseHooks)
+	assert.NoError(t, err)
+
+	ci.OnEnable()
+	ci.OnDisable()
+	ci.OnConfigChange()
+	for _, h := range []*testInjectorHooks{ifHooks, elseHooks} {
+		assert.Equal(t, 1, h.enables)
+		assert.Equal(t, 1, h.disables)
+		assert.Equal(t, 1, h.configChanges)
+	}
+	assert.False(t, ci.Destructive())
+
+	rj, err := NewRejectInjector()
+	assert.NoError(t, err)
+	ci, err = NewConditionalInjector(testPredicate, newTestInjectorNoop(), rj)
+	assert.NoError(t, err)
+	assert.True(t, ci.Destructive())
+}
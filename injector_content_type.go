@@ -0,0 +1,189 @@
+package fault
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+var (
+	// ErrInvalidContentTypeFault when an unknown ContentTypeFault is provided.
+	ErrInvalidContentTypeFault = errors.New("not a valid content type fault")
+)
+
+// defaultServedContentType is the Content-Type a ContentTypeInjector serves when none is set.
+const defaultServedContentType = "text/html; charset=utf-8"
+
+// errorPageFormat is the HTML error page of a ContentTypeInjector, in the style of the pages that
+// proxies and load balancers serve.
+const errorPageFormat = `<html>
+<head><title>%[1]d %[2]s</title></head>
+<body>
+<center><h1>%[1]d %[2]s</h1></center>
+<hr><center>go-fault</center>
+</body>
+</html>
+`
+
+// ContentTypeFault is a way of ignoring the content negotiation of a request.
+type ContentTypeFault int
+
+const (
+	// ContentTypeErrorPage responds with an HTML error page instead of running the request, the way a
+	// misconfigured proxy answers an API call.
+	ContentTypeErrorPage ContentTypeFault = iota
+	// ContentTypeMislabel runs the request and declares a different Content-Type for the body.
+	ContentTypeMislabel
+)
+
+// String returns the name of the ContentTypeFault.
+func (f ContentTypeFault) String() string {
+	switch f {
+	case ContentTypeErrorPage:
+		return "error_page"
+	case ContentTypeMislabel:
+		return "mislabel"
+	default:
+		return fmt.Sprintf("ContentTypeFault(%d)", int(f))
+	}
+}
+
+// ContentTypeInjector ignores the Accept header of a request and responds with an unexpected
+// Content-Type, to test how clients handle a response they cannot parse. Use a CharsetInjector to
+// mismatch only the charset.
+type ContentTypeInjector struct {
+	fault       ContentTypeFault
+	contentType string
+	code        int
+	reporter    Reporter
+}
+
+// ContentTypeInjectorOption configures a ContentTypeInjector.
+type ContentTypeInjectorOption interface {
+	applyContentTypeInjector(i *ContentTypeInjector) error
+}
+
+type servedContentTypeOption string
+
+func (o servedContentTypeOption) applyContentTypeInjector(i *ContentTypeInjector) error {
+	i.contentType = string(o)
+	return nil
+}
+
+// WithServedContentType sets the Content-Type of the response. Default "text/html; charset=utf-8".
+func WithServedContentType(ct string) ContentTypeInjectorOption {
+	return servedContentTypeOption(ct)
+}
+
+type errorPageStatusOption int
+
+func (o errorPageStatusOption) applyContentTypeInjector(i *ContentTypeInjector) error {
+	if o < http.StatusOK || http.StatusText(int(o)) == "" {
+		return ErrInvalidHTTPCode
+	}
+
+	i.code = int(o)
+
+	return nil
+}
+
+// WithErrorPageStatus sets the status code ContentTypeErrorPage responds with. Pass 200 to serve the
+// error page as a success, like a captive portal. Default 502 Bad Gateway.
+func WithErrorPageStatus(code int) ContentTypeInjectorOption {
+	return errorPageStatusOption(code)
+}
+
+func (o reporterOption) applyContentTypeInjector(i *ContentTypeInjector) error {
+	i.reporter = o.reporter
+	return nil
+}
+
+// NewContentTypeInjector returns a ContentTypeInjector that applies a ContentTypeFault to requests.
+func NewContentTypeInjector(f ContentTypeFault, opts ...ContentTypeInjectorOption) (*ContentTypeInjector, error) {
+	if f < ContentTypeErrorPage || f > ContentTypeMislabel {
+		return nil, ErrInvalidContentTypeFault
+	}
+
+	// set defaults
+	ci := &ContentTypeInjector{
+		fault:       f,
+		contentType: defaultServedContentType,
+		code:        http.StatusBadGateway,
+		reporter:    NewNoopReporter(),
+	}
+
+	// apply options
+	for _, opt := range opts {
+		err := opt.applyContentTypeInjector(ci)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return ci, nil
+}
+
+// Handler responds with the error page for ContentTypeErrorPage. For ContentTypeMislabel it buffers
+// the response and replaces its Content-Type.
+func (i *ContentTypeInjector) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		go i.reporter.Report(i.String(), StateStarted)
+
+		switch i.fault {
+		case ContentTypeErrorPage:
+			w.Header().Del("Content-Length")
+			w.Header().Set("Content-Type", i.contentType)
+			w.WriteHeader(i.code)
+			fmt.Fprintf(w, errorPageFormat, i.code, http.StatusText(i.code))
+		case ContentTypeMislabel:
+			bw := newBufferedWriter(w)
+			next.ServeHTTP(bw, r)
+
+			bw.header.Set("Content-Type", i.contentType)
+			bw.send()
+			bw.release()
+		}
+
+		go i.reporter.Report(i.String(), StateFinished)
+	})
+}
+
+// Reporter returns the Reporter of the ContentTypeInjector.
+func (i *ContentTypeInjector) Reporter() Reporter {
+	return i.reporter
+}
+
+// SetReporter replaces the Reporter of the ContentTypeInjector.
+func (i *ContentTypeInjector) SetReporter(r Reporter) {
+	i.reporter = r
+}
+
+// Name returns "content_type".
+func (i *ContentTypeInjector) Name() string {
+	return "content_type"
+}
+
+// Describe returns the ContentTypeFault, the served Content-Type, and the status code of the error
+// page.
+func (i *ContentTypeInjector) Describe() map[string]string {
+	d := map[string]string{
+		"fault":        i.fault.String(),
+		"content_type": i.contentType,
+	}
+	if i.fault == ContentTypeErrorPage {
+		d["code"] = strconv.Itoa(i.code)
+	}
+
+	return d
+}
+
+// String returns a summary of the ContentTypeInjector, such as "content_type(error_page 502)" or
+// "content_type(mislabel)".
+func (i *ContentTypeInjector) String() string {
+	if i.fault == ContentTypeErrorPage {
+		return fmt.Sprintf("%s(%s %d)", i.Name(), i.fault, i.code)
+	}
+
+	return fmt.Sprintf("%s(%s)", i.Name(), i.fault)
+}
@@ -0,0 +1,192 @@
+package fault
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestNewContentTypeInjector tests NewContentTypeInjector.
+func TestNewContentTypeInjector(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		giveFault   ContentTypeFault
+		giveOptions []ContentTypeInjectorOption
+		want        *ContentTypeInjector
+		wantErr     error
+	}{
+		{
+			name:      "error page",
+			giveFault: ContentTypeErrorPage,
+			want: &ContentTypeInjector{
+				fault:       ContentTypeErrorPage,
+				contentType: "text/html; charset=utf-8",
+				code:        http.StatusBadGateway,
+				reporter:    NewNoopReporter(),
+			},
+		},
+		{
+			name:      "options",
+			giveFault: ContentTypeMislabel,
+			giveOptions: []ContentTypeInjectorOption{
+				WithServedContentType("text/plain"),
+				WithErrorPageStatus(http.StatusOK),
+				WithReporter(newTestReporter()),
+			},
+			want: &ContentTypeInjector{
+				fault:       ContentTypeMislabel,
+				contentType: "text/plain",
+				code:        http.StatusOK,
+				reporter:    newTestReporter(),
+			},
+		},
+		{
+			name:      "invalid fault",
+			giveFault: ContentTypeFault(-1),
+			wantErr:   ErrInvalidContentTypeFault,
+		},
+		{
+			name:        "interim status",
+			giveFault:   ContentTypeErrorPage,
+			giveOptions: []ContentTypeInjectorOption{WithErrorPageStatus(http.StatusContinue)},
+			wantErr:     ErrInvalidHTTPCode,
+		},
+		{
+			name:        "unknown status",
+			giveFault:   ContentTypeErrorPage,
+			giveOptions: []ContentTypeInjectorOption{WithErrorPageStatus(599)},
+			wantErr:     ErrInvalidHTTPCode,
+		},
+		{
+			name:        "option error",
+			giveFault:   ContentTypeErrorPage,
+			giveOptions: []ContentTypeInjectorOption{withError()},
+			wantErr:     errErrorOption,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ci, err := NewContentTypeInjector(tt.giveFault, tt.giveOptions...)
+
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.want, ci)
+		})
+	}
+}
+
+// TestContentTypeInjectorHandler tests ContentTypeInjector.Handler.
+func TestContentTypeInjectorHandler(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name            string
+		giveFault       ContentTypeFault
+		giveOptions     []ContentTypeInjectorOption
+		wantCode        int
+		wantContentType string
+		wantBody        string
+		wantHandler     bool
+	}{
+		{
+			name:            "error page",
+			giveFault:       ContentTypeErrorPage,
+			wantCode:        http.StatusBadGateway,
+			wantContentType: "text/html; charset=utf-8",
+			wantBody: "<html>\n<head><title>502 Bad Gateway</title></head>\n<body>\n" +
+				"<center><h1>502 Bad Gateway</h1></center>\n<hr><center>go-fault</center>\n</body>\n</html>\n",
+		},
+		{
+			name:            "error page as success",
+			giveFault:       ContentTypeErrorPage,
+			giveOptions:     []ContentTypeInjectorOption{WithErrorPageStatus(http.StatusOK)},
+			wantCode:        http.StatusOK,
+			wantContentType: "text/html; charset=utf-8",
+			wantBody: "<html>\n<head><title>200 OK</title></head>\n<body>\n" +
+				"<center><h1>200 OK</h1></center>\n<hr><center>go-fault</center>\n</body>\n</html>\n",
+		},
+		{
+			name:            "mislabel",
+			giveFault:       ContentTypeMislabel,
+			wantCode:        http.StatusCreated,
+			wantContentType: "text/html; charset=utf-8",
+			wantBody:        `{"id": 1}`,
+			wantHandler:     true,
+		},
+		{
+			name:            "mislabel custom type",
+			giveFault:       ContentTypeMislabel,
+			giveOptions:     []ContentTypeInjectorOption{WithServedContentType("application/xml")},
+			wantCode:        http.StatusCreated,
+			wantContentType: "application/xml",
+			wantBody:        `{"id": 1}`,
+			wantHandler:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			reporter := &testStateReporter{states: make(chan InjectorState, 2)}
+			ci, err := NewContentTypeInjector(tt.giveFault, append(tt.giveOptions, WithReporter(reporter))...)
+			assert.NoError(t, err)
+
+			var ran bool
+			h := ci.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				ran = true
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusCreated)
+				_, _ = w.Write([]byte(`{"id": 1}`))
+			}))
+
+			rr := httptest.NewRecorder()
+			rr.Header().Set("Content-Length", "9")
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Accept", "application/json")
+			h.ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.wantHandler, ran)
+			assert.Equal(t, tt.wantCode, rr.Code)
+			assert.Equal(t, tt.wantContentType, rr.Header().Get("Content-Type"))
+			assert.Equal(t, tt.wantBody, rr.Body.String())
+			if !tt.wantHandler {
+				assert.Empty(t, rr.Header().Get("Content-Length"))
+			}
+
+			states := map[InjectorState]int{}
+			states[<-reporter.states]++
+			states[<-reporter.states]++
+			assert.Equal(t, map[InjectorState]int{StateStarted: 1, StateFinished: 1}, states)
+		})
+	}
+}
+
+// TestContentTypeFaultString tests ContentTypeFault.String.
+func TestContentTypeFaultString(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "error_page", ContentTypeErrorPage.String())
+	assert.Equal(t, "mislabel", ContentTypeMislabel.String())
+	assert.Equal(t, "ContentTypeFault(7)", ContentTypeFault(7).String())
+}
+
+// TestContentTypeInjectorReporter tests ContentTypeInjector.Reporter and SetReporter.
+func TestContentTypeInjectorReporter(t *testing.T) {
+	t.Parallel()
+
+	ci, err := NewContentTypeInjector(ContentTypeErrorPage)
+	assert.NoError(t, err)
+
+	reporter := newTestReporter()
+	ci.SetReporter(reporter)
+	assert.Equal(t, reporter, ci.Reporter())
+}
@@ -0,0 +1,316 @@
+package fault
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+)
+
+var (
+	// ErrInvalidCorruption when an unknown Corruption is provided.
+	ErrInvalidCorruption = errors.New("not a valid corruption")
+	// ErrInvalidCorruptedBytes when a negative number of corrupted bytes is provided.
+	ErrInvalidCorruptedBytes = errors.New("corrupted bytes must be >= 0")
+)
+
+// Corruption is a way of corrupting a response after the handler has written it.
+type Corruption int
+
+const (
+	// CorruptTruncate cuts the body short, keeping the number of bytes set by WithCorruptedBytes or
+	// half of the body by default. A Content-Length set by the handler is updated to match, so the
+	// response is well framed and only its payload is incomplete.
+	CorruptTruncate Corruption = iota
+	// CorruptFlipBytes replaces bytes of the body at distinct random positions with different values.
+	// It changes the number of bytes set by WithCorruptedBytes, or 1 by default.
+	CorruptFlipBytes
+	// CorruptStripContentLength sends the body without a Content-Length or chunked encoding, so it
+	// ends only when the connection closes, like an HTTP/1.0 response.
+	CorruptStripContentLength
+	// CorruptGarbleContentLength sends the Content-Length in hex, such as "0x1f", which is not a valid
+	// Content-Length.
+	CorruptGarbleContentLength
+	// CorruptInvalidJSON replaces the last closing brace or bracket of the body with a comma, so a
+	// JSON body ends in the middle of an object or array. A body without one gets a trailing comma.
+	CorruptInvalidJSON
+)
+
+// String returns the name of the Corruption.
+func (c Corruption) String() string {
+	switch c {
+	case CorruptTruncate:
+		return "truncate"
+	case CorruptFlipBytes:
+		return "flip_bytes"
+	case CorruptStripContentLength:
+		return "strip_content_length"
+	case CorruptGarbleContentLength:
+		return "garble_content_length"
+	case CorruptInvalidJSON:
+		return "invalid_json"
+	default:
+		return fmt.Sprintf("Corruption(%d)", int(c))
+	}
+}
+
+// ResponseCorruptionInjector runs the request and then corrupts the response, to test how clients
+// parse payloads that are malformed or framed wrong.
+type ResponseCorruptionInjector struct {
+	corruption Corruption
+	// n is the number of bytes to keep or flip, or -1 for the default.
+	n int
+
+	randSeed int64
+	rand     *rand.Rand
+
+	// *rand.Rand is not thread safe. This mutex protects our random source
+	randMtx sync.Mutex
+
+	reporter Reporter
+}
+
+// ResponseCorruptionInjectorOption configures a ResponseCorruptionInjector.
+type ResponseCorruptionInjectorOption interface {
+	applyResponseCorruptionInjector(i *ResponseCorruptionInjector) error
+}
+
+type corruptedBytesOption int
+
+func (o corruptedBytesOption) applyResponseCorruptionInjector(i *ResponseCorruptionInjector) error {
+	if o < 0 {
+		return ErrInvalidCorruptedBytes
+	}
+
+	i.n = int(o)
+	return nil
+}
+
+// WithCorruptedBytes sets how many bytes of the body CorruptTruncate keeps and CorruptFlipBytes
+// changes. Other Corruptions ignore it.
+func WithCorruptedBytes(n int) ResponseCorruptionInjectorOption {
+	return corruptedBytesOption(n)
+}
+
+func (o randSeedOption) applyResponseCorruptionInjector(i *ResponseCorruptionInjector) error {
+	i.randSeed = int64(o)
+	return nil
+}
+
+func (o reporterOption) applyResponseCorruptionInjector(i *ResponseCorruptionInjector) error {
+	i.reporter = o.reporter
+	return nil
+}
+
+// NewResponseCorruptionInjector returns a ResponseCorruptionInjector that applies a Corruption to
+// responses.
+func NewResponseCorruptionInjector(c Corruption, opts ...ResponseCorruptionInjectorOption) (
+	*ResponseCorruptionInjector, error,
+) {
+	if c < CorruptTruncate || c > CorruptInvalidJSON {
+		return nil, ErrInvalidCorruption
+	}
+
+	// set defaults
+	ci := &ResponseCorruptionInjector{
+		corruption: c,
+		n:          -1,
+		randSeed:   defaultRandSeed,
+		reporter:   NewNoopReporter(),
+	}
+
+	// apply options
+	for _, opt := range opts {
+		err := opt.applyResponseCorruptionInjector(ci)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	ci.rand = rand.New(rand.NewSource(ci.randSeed))
+
+	return ci, nil
+}
+
+// Handler buffers the response and corrupts it before sending it. CorruptStripContentLength and
+// CorruptGarbleContentLength take over the connection to write the response, so on connections
+// that cannot be taken over, such as HTTP/2, they send the changed headers and the server may
+// still frame the body correctly.
+func (i *ResponseCorruptionInjector) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		go i.reporter.Report(i.String(), StateStarted)
+		defer func() { go i.reporter.Report(i.String(), StateFinished) }()
+
+		bw := newBufferedWriter(w)
+		defer bw.release()
+		next.ServeHTTP(bw, r)
+
+		switch i.corruption {
+		case CorruptTruncate:
+			bw.setBody(bw.body.Bytes()[:i.keep(bw.body.Len())])
+		case CorruptFlipBytes:
+			i.flip(bw.body.Bytes())
+		case CorruptInvalidJSON:
+			bw.setBody(invalidateJSON(bw.body.Bytes()))
+		case CorruptStripContentLength, CorruptGarbleContentLength:
+			i.sendMisframed(w, bw)
+			return
+		}
+
+		bw.send()
+	})
+}
+
+// keep returns how many of size bytes CorruptTruncate keeps.
+func (i *ResponseCorruptionInjector) keep(size int) int {
+	if i.n < 0 {
+		return size / 2
+	}
+	if i.n > size {
+		return size
+	}
+
+	return i.n
+}
+
+// flip changes the bytes of body set by WithCorruptedBytes, or 1 by default.
+func (i *ResponseCorruptionInjector) flip(body []byte) {
+	n := i.n
+	if n < 0 {
+		n = 1
+	}
+
+	i.randMtx.Lock()
+	defer i.randMtx.Unlock()
+
+	flipBytes(i.rand, body, n)
+}
+
+// flipBytes changes n bytes of body at distinct random positions to different values, or every
+// byte if body is no longer than n.
+func flipBytes(rnd *rand.Rand, body []byte, n int) {
+	if n >= len(body) {
+		for idx := range body {
+			body[idx] ^= byte(1 + rnd.Intn(255))
+		}
+		return
+	}
+
+	flipped := make(map[int]bool, n)
+	for len(flipped) < n {
+		idx := rnd.Intn(len(body))
+		if !flipped[idx] {
+			flipped[idx] = true
+			body[idx] ^= byte(1 + rnd.Intn(255))
+		}
+	}
+}
+
+// invalidateJSON replaces the last closing brace or bracket of body with a comma, or adds a comma if
+// it has none.
+func invalidateJSON(body []byte) []byte {
+	idx := bytes.LastIndexAny(body, "}]")
+	if idx < 0 {
+		return append(body, ',')
+	}
+
+	body[idx] = ','
+	return body
+}
+
+// sendMisframed sends the buffered response without a Content-Length, or with a garbled one, by
+// taking over the connection. If it cannot, the changed headers are sent through w.
+func (i *ResponseCorruptionInjector) sendMisframed(w http.ResponseWriter, bw *bufferedWriter) {
+	h := bw.header.Clone()
+	h.Del("Content-Length")
+	h.Del("Transfer-Encoding")
+	if i.corruption == CorruptGarbleContentLength {
+		h.Set("Content-Length", fmt.Sprintf("0x%x", bw.body.Len()))
+	}
+
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		sendWithHeader(w, bw, h)
+		return
+	}
+
+	conn, brw, err := hj.Hijack()
+	if err != nil {
+		sendWithHeader(w, bw, h)
+		return
+	}
+	defer conn.Close()
+
+	h.Set("Connection", "close")
+	writeMisframed(brw.Writer, bw, h)
+}
+
+// sendWithHeader writes the buffered response to w with the headers h instead of its own.
+func sendWithHeader(w http.ResponseWriter, bw *bufferedWriter, h http.Header) {
+	dst := w.Header()
+	for k := range dst {
+		delete(dst, k)
+	}
+	for k, v := range h {
+		dst[k] = v
+	}
+
+	w.WriteHeader(bw.code)
+	_, _ = w.Write(bw.body.Bytes())
+}
+
+// writeMisframed writes the buffered response as HTTP/1.1 with the headers h, which do not frame
+// the body, followed by the body.
+func writeMisframed(w *bufio.Writer, bw *bufferedWriter, h http.Header) {
+	fmt.Fprintf(w, "HTTP/1.1 %03d %s\r\n", bw.code, http.StatusText(bw.code))
+	_ = h.Write(w)
+	_, _ = w.WriteString("\r\n")
+
+	if bodyAllowed(bw.code) {
+		_, _ = w.Write(bw.body.Bytes())
+	}
+
+	_ = w.Flush()
+}
+
+// Seed returns the seed of the ResponseCorruptionInjector's random number generator.
+func (i *ResponseCorruptionInjector) Seed() int64 {
+	return i.randSeed
+}
+
+// Reporter returns the Reporter of the ResponseCorruptionInjector.
+func (i *ResponseCorruptionInjector) Reporter() Reporter {
+	return i.reporter
+}
+
+// SetReporter replaces the Reporter of the ResponseCorruptionInjector.
+func (i *ResponseCorruptionInjector) SetReporter(r Reporter) {
+	i.reporter = r
+}
+
+// Name returns "corrupt".
+func (i *ResponseCorruptionInjector) Name() string {
+	return "corrupt"
+}
+
+// Describe returns the Corruption, the number of corrupted bytes if set, and the random seed.
+func (i *ResponseCorruptionInjector) Describe() map[string]string {
+	d := map[string]string{
+		"corruption": i.corruption.String(),
+		"seed":       strconv.FormatInt(i.randSeed, 10),
+	}
+	if i.n >= 0 {
+		d["bytes"] = strconv.Itoa(i.n)
+	}
+
+	return d
+}
+
+// String returns a summary of the ResponseCorruptionInjector, such as "corrupt(truncate)".
+func (i *ResponseCorruptionInjector) String() string {
+	return fmt.Sprintf("%s(%s)", i.Name(), i.corruption)
+}
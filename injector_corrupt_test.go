@@ -0,0 +1,372 @@
+package fault
+
+import (
+	"encoding/json"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestNewResponseCorruptionInjector tests NewResponseCorruptionInjector.
+func TestNewResponseCorruptionInjector(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name           string
+		giveCorruption Corruption
+		giveOptions    []ResponseCorruptionInjectorOption
+		want           *ResponseCorruptionInjector
+		wantErr        error
+	}{
+		{
+			name:           "no options",
+			giveCorruption: CorruptTruncate,
+			giveOptions:    []ResponseCorruptionInjectorOption{},
+			want: &ResponseCorruptionInjector{
+				corruption: CorruptTruncate,
+				n:          -1,
+				randSeed:   defaultRandSeed,
+				rand:       rand.New(rand.NewSource(defaultRandSeed)),
+				reporter:   NewNoopReporter(),
+			},
+			wantErr: nil,
+		},
+		{
+			name:           "options",
+			giveCorruption: CorruptFlipBytes,
+			giveOptions: []ResponseCorruptionInjectorOption{
+				WithCorruptedBytes(0),
+				WithRandSeed(5),
+				WithReporter(newTestReporter()),
+			},
+			want: &ResponseCorruptionInjector{
+				corruption: CorruptFlipBytes,
+				n:          0,
+				randSeed:   5,
+				rand:       rand.New(rand.NewSource(5)),
+				reporter:   newTestReporter(),
+			},
+			wantErr: nil,
+		},
+		{
+			name:           "invalid corruption",
+			giveCorruption: CorruptInvalidJSON + 1,
+			giveOptions:    []ResponseCorruptionInjectorOption{},
+			want:           nil,
+			wantErr:        ErrInvalidCorruption,
+		},
+		{
+			name:           "negative corruption",
+			giveCorruption: -1,
+			giveOptions:    []ResponseCorruptionInjectorOption{},
+			want:           nil,
+			wantErr:        ErrInvalidCorruption,
+		},
+		{
+			name:           "negative bytes",
+			giveCorruption: CorruptTruncate,
+			giveOptions: []ResponseCorruptionInjectorOption{
+				WithCorruptedBytes(-1),
+			},
+			want:    nil,
+			wantErr: ErrInvalidCorruptedBytes,
+		},
+		{
+			name:           "option error",
+			giveCorruption: CorruptTruncate,
+			giveOptions: []ResponseCorruptionInjectorOption{
+				withError(),
+			},
+			want:    nil,
+			wantErr: errErrorOption,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ci, err := NewResponseCorruptionInjector(tt.giveCorruption, tt.giveOptions...)
+
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.want, ci)
+		})
+	}
+}
+
+// TestResponseCorruptionInjectorHandler tests the response a ResponseCorruptionInjector sends to a
+// ResponseWriter that cannot be taken over.
+func TestResponseCorruptionInjectorHandler(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name              string
+		giveCorruption    Corruption
+		giveOptions       []ResponseCorruptionInjectorOption
+		giveBody          string
+		giveHijackFailer  bool
+		wantBody          string
+		wantContentLength string
+	}{
+		{
+			name:              "truncate",
+			giveCorruption:    CorruptTruncate,
+			giveBody:          `{"items":[1,2,3]}`,
+			wantBody:          `{"items"`,
+			wantContentLength: "8",
+		},
+		{
+			name:              "truncate bytes",
+			giveCorruption:    CorruptTruncate,
+			giveOptions:       []ResponseCorruptionInjectorOption{WithCorruptedBytes(3)},
+			giveBody:          `{"items":[1,2,3]}`,
+			wantBody:          `{"i`,
+			wantContentLength: "3",
+		},
+		{
+			name:              "truncate more bytes than the body",
+			giveCorruption:    CorruptTruncate,
+			giveOptions:       []ResponseCorruptionInjectorOption{WithCorruptedBytes(100)},
+			giveBody:          `{}`,
+			wantBody:          `{}`,
+			wantContentLength: "2",
+		},
+		{
+			name:              "invalid json object",
+			giveCorruption:    CorruptInvalidJSON,
+			giveBody:          "{\"items\":[1,2,3]}\n",
+			wantBody:          "{\"items\":[1,2,3],\n",
+			wantContentLength: "18",
+		},
+		{
+			name:              "invalid json array",
+			giveCorruption:    CorruptInvalidJSON,
+			giveBody:          `[1,2,3]`,
+			wantBody:          `[1,2,3,`,
+			wantContentLength: "7",
+		},
+		{
+			name:              "invalid json scalar",
+			giveCorruption:    CorruptInvalidJSON,
+			giveBody:          `"ok"`,
+			wantBody:          `"ok",`,
+			wantContentLength: "5",
+		},
+		{
+			name:           "strip content length",
+			giveCorruption: CorruptStripContentLength,
+			giveBody:       "hello",
+			wantBody:       "hello",
+		},
+		{
+			name:              "garble content length",
+			giveCorruption:    CorruptGarbleContentLength,
+			giveBody:          "hello world, hello",
+			giveHijackFailer:  true,
+			wantBody:          "hello world, hello",
+			wantContentLength: "0x12",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			reporter := newTestStateReporter()
+			ci, err := NewResponseCorruptionInjector(tt.giveCorruption,
+				append(tt.giveOptions, WithReporter(reporter))...)
+			assert.NoError(t, err)
+
+			h := ci.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Length", "0")
+				w.WriteHeader(http.StatusAccepted)
+				_, _ = w.Write([]byte(tt.giveBody))
+			}))
+
+			rr := httptest.NewRecorder()
+			rr.Header().Set("X-Request-Id", "1")
+			var w http.ResponseWriter = rr
+			if tt.giveHijackFailer {
+				w = testHijackFailer{rr}
+			}
+			h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			assert.Equal(t, http.StatusAccepted, rr.Code)
+			assert.Equal(t, "1", rr.Header().Get("X-Request-Id"))
+			assert.Equal(t, tt.wantBody, rr.Body.String())
+			assert.Equal(t, tt.wantContentLength, rr.Header().Get("Content-Length"))
+
+			// the states are reported concurrently, so they may arrive in any order
+			states := map[InjectorState]int{}
+			for n := 0; n < 2; n++ {
+				states[<-reporter.states]++
+			}
+			assert.Equal(t, map[InjectorState]int{StateStarted: 1, StateFinished: 1}, states)
+		})
+	}
+}
+
+// TestResponseCorruptionInjectorFlipBytes tests that CorruptFlipBytes changes as many bytes as set,
+// the same way for the same seed.
+func TestResponseCorruptionInjectorFlipBytes(t *testing.T) {
+	t.Parallel()
+
+	body := strings.Repeat("a", 1000)
+
+	tests := []struct {
+		name        string
+		giveOptions []ResponseCorruptionInjectorOption
+		giveBody    string
+		wantFlipped int
+	}{
+		{
+			name:        "default",
+			giveBody:    body,
+			wantFlipped: 1,
+		},
+		{
+			name:        "bytes",
+			giveOptions: []ResponseCorruptionInjectorOption{WithCorruptedBytes(3)},
+			giveBody:    body,
+			wantFlipped: 3,
+		},
+		{
+			name:        "none",
+			giveOptions: []ResponseCorruptionInjectorOption{WithCorruptedBytes(0)},
+			giveBody:    body,
+		},
+		{
+			name:        "more bytes than the body",
+			giveOptions: []ResponseCorruptionInjectorOption{WithCorruptedBytes(10)},
+			giveBody:    "abc",
+			wantFlipped: 3,
+		},
+		{
+			name: "empty body",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			serve := func() string {
+				ci, err := NewResponseCorruptionInjector(CorruptFlipBytes, tt.giveOptions...)
+				assert.NoError(t, err)
+
+				rr := httptest.NewRecorder()
+				ci.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					_, _ = w.Write([]byte(tt.giveBody))
+				})).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+
+				return rr.Body.String()
+			}
+
+			got := serve()
+			assert.Equal(t, got, serve())
+			assert.Equal(t, len(tt.giveBody), len(got))
+
+			var flipped int
+			for idx := range got {
+				if got[idx] != tt.giveBody[idx] {
+					flipped++
+				}
+			}
+			assert.Equal(t, tt.wantFlipped, flipped)
+		})
+	}
+}
+
+// TestResponseCorruptionInjectorClient tests the errors a client gets from corrupted responses.
+func TestResponseCorruptionInjectorClient(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name              string
+		giveCorruption    Corruption
+		wantErr           string
+		wantBody          string
+		wantContentLength int64
+		wantJSONErr       bool
+	}{
+		{
+			name:              "truncate",
+			giveCorruption:    CorruptTruncate,
+			wantBody:          `{"items"`,
+			wantContentLength: 8,
+			wantJSONErr:       true,
+		},
+		{
+			name:              "strip content length",
+			giveCorruption:    CorruptStripContentLength,
+			wantBody:          `{"items":[1,2,3]}`,
+			wantContentLength: -1,
+		},
+		{
+			name:           "garble content length",
+			giveCorruption: CorruptGarbleContentLength,
+			wantErr:        `bad Content-Length "0x11"`,
+		},
+		{
+			name:              "invalid json",
+			giveCorruption:    CorruptInvalidJSON,
+			wantBody:          `{"items":[1,2,3],`,
+			wantContentLength: 17,
+			wantJSONErr:       true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ci, err := NewResponseCorruptionInjector(tt.giveCorruption)
+			assert.NoError(t, err)
+
+			srv := httptest.NewServer(ci.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(`{"items":[1,2,3]}`))
+			})))
+			defer srv.Close()
+
+			resp, err := srv.Client().Get(srv.URL)
+			if tt.wantErr != "" {
+				assert.Error(t, err)
+				assert.True(t, strings.Contains(err.Error(), tt.wantErr), err)
+				return
+			}
+			assert.NoError(t, err)
+			defer resp.Body.Close()
+
+			body, err := io.ReadAll(resp.Body)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantBody, string(body))
+			assert.Equal(t, tt.wantContentLength, resp.ContentLength)
+			assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+
+			var v interface{}
+			assert.Equal(t, tt.wantJSONErr, json.Unmarshal(body, &v) != nil)
+		})
+	}
+}
+
+// TestCorruptionString tests Corruption.String.
+func TestCorruptionString(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "truncate", CorruptTruncate.String())
+	assert.Equal(t, "flip_bytes", CorruptFlipBytes.String())
+	assert.Equal(t, "strip_content_length", CorruptStripContentLength.String())
+	assert.Equal(t, "garble_content_length", CorruptGarbleContentLength.String())
+	assert.Equal(t, "invalid_json", CorruptInvalidJSON.String())
+	assert.Equal(t, "Corruption(7)", Corruption(7).String())
+}
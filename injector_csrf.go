@@ -0,0 +1,172 @@
+package fault
+
+import (
+	"fmt"
+	"net/http"
+	"net/textproto"
+	"strings"
+)
+
+// CSRFInjector runs the request and mangles the anti-CSRF tokens the response sets in headers or
+// cookies. Mangled tokens keep their length and alphabet so they still look valid, which tests
+// that clients and frontends fail safely when the server later rejects them.
+type CSRFInjector struct {
+	headers  []string
+	cookies  []string
+	reporter Reporter
+}
+
+// CSRFInjectorOption configures a CSRFInjector.
+type CSRFInjectorOption interface {
+	applyCSRFInjector(i *CSRFInjector) error
+}
+
+type csrfHeadersOption []string
+
+func (o csrfHeadersOption) applyCSRFInjector(i *CSRFInjector) error {
+	i.headers = make([]string, 0, len(o))
+	for _, h := range o {
+		i.headers = append(i.headers, textproto.CanonicalMIMEHeaderKey(h))
+	}
+	return nil
+}
+
+// WithCSRFHeaders sets the response headers that carry tokens. Default "X-Csrf-Token" and
+// "X-Xsrf-Token".
+func WithCSRFHeaders(names ...string) CSRFInjectorOption {
+	return csrfHeadersOption(names)
+}
+
+type csrfCookiesOption []string
+
+func (o csrfCookiesOption) applyCSRFInjector(i *CSRFInjector) error {
+	i.cookies = o
+	return nil
+}
+
+// WithCSRFCookies sets the names of the cookies that carry tokens. Default "csrf_token", "_csrf",
+// and "XSRF-TOKEN".
+func WithCSRFCookies(names ...string) CSRFInjectorOption {
+	return csrfCookiesOption(names)
+}
+
+func (o reporterOption) applyCSRFInjector(i *CSRFInjector) error {
+	i.reporter = o.reporter
+	return nil
+}
+
+// NewCSRFInjector returns a CSRFInjector.
+func NewCSRFInjector(opts ...CSRFInjectorOption) (*CSRFInjector, error) {
+	// set defaults
+	ci := &CSRFInjector{
+		headers:  []string{"X-Csrf-Token", "X-Xsrf-Token"},
+		cookies:  []string{"csrf_token", "_csrf", "XSRF-TOKEN"},
+		reporter: NewNoopReporter(),
+	}
+
+	// apply options
+	for _, opt := range opts {
+		err := opt.applyCSRFInjector(ci)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return ci, nil
+}
+
+// Handler mangles the tokens in the response headers just before they are written.
+func (i *CSRFInjector) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		go i.reporter.Report(i.String(), StateStarted)
+
+		hw := &headerWriter{ResponseWriter: w, mutate: i.mangle}
+		next.ServeHTTP(hw, r)
+		hw.finish()
+
+		go i.reporter.Report(i.String(), StateFinished)
+	})
+}
+
+// mangle mangles the tokens in h.
+func (i *CSRFInjector) mangle(h http.Header) {
+	for _, name := range i.headers {
+		for idx, v := range h[name] {
+			h[name][idx] = mangleToken(v)
+		}
+	}
+
+	for idx, v := range h["Set-Cookie"] {
+		h["Set-Cookie"][idx] = i.mangleSetCookie(v)
+	}
+}
+
+// mangleSetCookie mangles the value of a Set-Cookie header if it sets one of the token cookies.
+// Cookie attributes are kept as they are.
+func (i *CSRFInjector) mangleSetCookie(v string) string {
+	pair, attrs := v, ""
+	if idx := strings.IndexByte(v, ';'); idx >= 0 {
+		pair, attrs = v[:idx], v[idx:]
+	}
+
+	idx := strings.IndexByte(pair, '=')
+	if idx < 0 {
+		return v
+	}
+
+	name := strings.TrimSpace(pair[:idx])
+	for _, c := range i.cookies {
+		if c == name {
+			return pair[:idx+1] + mangleToken(pair[idx+1:]) + attrs
+		}
+	}
+
+	return v
+}
+
+// mangleToken shifts every letter and digit of a token by one, wrapping around, so the token keeps
+// its length and alphabet but no longer matches.
+func mangleToken(t string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == 'z':
+			return 'a'
+		case r == 'Z':
+			return 'A'
+		case r == '9':
+			return '0'
+		case r >= 'a' && r < 'z', r >= 'A' && r < 'Z', r >= '0' && r < '9':
+			return r + 1
+		default:
+			return r
+		}
+	}, t)
+}
+
+// Name returns "csrf".
+func (i *CSRFInjector) Name() string {
+	return "csrf"
+}
+
+// Describe returns the headers and cookies the CSRFInjector mangles.
+func (i *CSRFInjector) Describe() map[string]string {
+	return map[string]string{
+		"headers": strings.Join(i.headers, ", "),
+		"cookies": strings.Join(i.cookies, ", "),
+	}
+}
+
+// String returns a summary of the CSRFInjector, such as "csrf(X-Csrf-Token, csrf_token)".
+func (i *CSRFInjector) String() string {
+	return fmt.Sprintf("%s(%s)", i.Name(), strings.Join(append(append([]string{}, i.headers...), i.cookies...), ", "))
+}
+
+// Reporter returns the Reporter of the CSRFInjector.
+func (i *CSRFInjector) Reporter() Reporter {
+	return i.reporter
+}
+
+// SetReporter replaces the Reporter of the CSRFInjector.
+func (i *CSRFInjector) SetReporter(r Reporter) {
+	i.reporter = r
+}
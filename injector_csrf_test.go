@@ -0,0 +1,134 @@
+package fault
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestNewCSRFInjector tests NewCSRFInjector.
+func TestNewCSRFInjector(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		giveOptions []CSRFInjectorOption
+		want        *CSRFInjector
+		wantErr     error
+	}{
+		{
+			name: "defaults",
+			want: &CSRFInjector{
+				headers:  []string{"X-Csrf-Token", "X-Xsrf-Token"},
+				cookies:  []string{"csrf_token", "_csrf", "XSRF-TOKEN"},
+				reporter: NewNoopReporter(),
+			},
+		},
+		{
+			name: "options",
+			giveOptions: []CSRFInjectorOption{
+				WithCSRFHeaders("x-token"),
+				WithCSRFCookies("token"),
+				WithReporter(newTestReporter()),
+			},
+			want: &CSRFInjector{
+				headers:  []string{"X-Token"},
+				cookies:  []string{"token"},
+				reporter: newTestReporter(),
+			},
+		},
+		{
+			name: "option error",
+			giveOptions: []CSRFInjectorOption{
+				withError(),
+			},
+			wantErr: errErrorOption,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ci, err := NewCSRFInjector(tt.giveOptions...)
+
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.want, ci)
+		})
+	}
+}
+
+// TestCSRFInjectorHandler tests CSRFInjector.Handler.
+func TestCSRFInjectorHandler(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		giveWrite func(w http.ResponseWriter)
+		wantCode  int
+	}{
+		{
+			name: "write header",
+			giveWrite: func(w http.ResponseWriter) {
+				w.WriteHeader(http.StatusCreated)
+				w.WriteHeader(http.StatusOK)
+			},
+			wantCode: http.StatusCreated,
+		},
+		{
+			name: "write",
+			giveWrite: func(w http.ResponseWriter) {
+				_, _ = w.Write([]byte("body"))
+			},
+			wantCode: http.StatusOK,
+		},
+		{
+			name: "flush",
+			giveWrite: func(w http.ResponseWriter) {
+				w.(http.Flusher).Flush()
+			},
+			wantCode: http.StatusOK,
+		},
+		{
+			name:      "nothing written",
+			giveWrite: func(w http.ResponseWriter) {},
+			wantCode:  http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ci, err := NewCSRFInjector()
+			assert.NoError(t, err)
+
+			h := ci.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("X-CSRF-Token", "abz-AZ9_0.y")
+				w.Header().Set("X-Other", "abc")
+				http.SetCookie(w, &http.Cookie{Name: "csrf_token", Value: "abc123", Path: "/", HttpOnly: true})
+				http.SetCookie(w, &http.Cookie{Name: "session", Value: "abc123"})
+				w.Header().Add("Set-Cookie", "malformed")
+				w.Header().Add("Set-Cookie", "XSRF-TOKEN=zz")
+				tt.giveWrite(w)
+			}))
+
+			rr := httptest.NewRecorder()
+			h.ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+
+			assert.Equal(t, tt.wantCode, rr.Code)
+			assert.Equal(t, "bca-BA0_1.z", rr.Header().Get("X-Csrf-Token"))
+			assert.Equal(t, "abc", rr.Header().Get("X-Other"))
+			assert.Equal(t, []string{
+				"csrf_token=bcd234; Path=/; HttpOnly",
+				"session=abc123",
+				"malformed",
+				"XSRF-TOKEN=aa",
+			}, rr.Header()["Set-Cookie"])
+		})
+	}
+}
@@ -0,0 +1,200 @@
+package fault
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrEmptyProfile when a DistributionInjector is given a LatencyProfile without any requests.
+	ErrEmptyProfile = errors.New("latency profile has no requests")
+)
+
+// DistributionInjector waits a latency drawn from the distribution of the request's route in a
+// LatencyProfile before continuing, replaying latencies captured from real traffic by a
+// LatencyCapture. Latencies are drawn uniformly within the bucket they fall in, and requests slower
+// than the largest bucket wait for the largest bucket. Requests to routes the LatencyProfile does
+// not hold continue without delay.
+type DistributionInjector struct {
+	profile *LatencyProfile
+	routeF  func(r *http.Request) string
+	clock   Clock
+
+	randSeed int64
+	rand     *rand.Rand
+
+	// *rand.Rand is not thread safe. This mutex protects our random source
+	randMtx sync.Mutex
+
+	reporter Reporter
+
+	// phase is the part of the response the wait delays.
+	phase LatencyPhase
+}
+
+// DistributionInjectorOption configures a DistributionInjector.
+type DistributionInjectorOption interface {
+	applyDistributionInjector(i *DistributionInjector) error
+}
+
+func (o routeFuncOption) applyDistributionInjector(i *DistributionInjector) error {
+	i.routeF = o
+	return nil
+}
+
+func (o clockOption) applyDistributionInjector(i *DistributionInjector) error {
+	i.clock = o.clock
+	return nil
+}
+
+func (o randSeedOption) applyDistributionInjector(i *DistributionInjector) error {
+	i.randSeed = int64(o)
+	return nil
+}
+
+func (o reporterOption) applyDistributionInjector(i *DistributionInjector) error {
+	i.reporter = o.reporter
+	return nil
+}
+
+// NewDistributionInjector returns a DistributionInjector that replays p. Pass the WithRouteFunc()
+// the LatencyCapture used so requests are grouped into the same routes. It returns
+// ErrEmptyProfile if p holds no requests.
+func NewDistributionInjector(p *LatencyProfile, opts ...DistributionInjectorOption) (*DistributionInjector, error) {
+	empty := true
+	if p != nil {
+		for _, h := range p.Routes {
+			if h.Count > 0 {
+				empty = false
+				break
+			}
+		}
+	}
+	if empty {
+		return nil, ErrEmptyProfile
+	}
+
+	// set defaults
+	di := &DistributionInjector{
+		profile:  p,
+		routeF:   func(r *http.Request) string { return r.URL.Path },
+		clock:    NewRealClock(),
+		randSeed: defaultRandSeed,
+		reporter: NewNoopReporter(),
+	}
+
+	// apply options
+	for _, opt := range opts {
+		err := opt.applyDistributionInjector(di)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	di.rand = rand.New(rand.NewSource(di.randSeed))
+
+	return di, nil
+}
+
+// Handler waits a latency drawn from the distribution of the request's route and then continues, or
+// delays the body of the response with WithLatencyPhase().
+func (i *DistributionInjector) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h, ok := i.profile.Routes[i.routeF(r)]
+		if !ok || h.Count == 0 {
+			go i.reporter.Report(i.String(), StateSkipped)
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		go i.reporter.Report(i.String(), StateStarted)
+		if i.phase == LatencyBeforeHeaders {
+			i.clock.Sleep(addLatency(r.Context(), i.sample(h)))
+			next.ServeHTTP(w, r)
+		} else {
+			serveDelayedBody(i.phase, i.sample(h), sleepFunc(r.Context(), i.clock), next, w, r)
+		}
+		go i.reporter.Report(i.String(), StateFinished)
+	})
+}
+
+// sample returns a latency drawn from h, which must hold requests.
+func (i *DistributionInjector) sample(h LatencyHistogram) time.Duration {
+	i.randMtx.Lock()
+	defer i.randMtx.Unlock()
+
+	rank := i.rand.Int63n(h.Count)
+	idx := 0
+	for ; rank >= h.Counts[idx]; idx++ {
+		rank -= h.Counts[idx]
+	}
+
+	if idx == len(h.Buckets) {
+		return h.Buckets[idx-1]
+	}
+
+	var lower time.Duration
+	if idx > 0 {
+		lower = h.Buckets[idx-1]
+	}
+
+	return lower + time.Duration(i.rand.Int63n(int64(h.Buckets[idx]-lower)+1))
+}
+
+// Seed returns the seed of the DistributionInjector's random number generator.
+func (i *DistributionInjector) Seed() int64 {
+	return i.randSeed
+}
+
+// Reporter returns the Reporter of the DistributionInjector.
+func (i *DistributionInjector) Reporter() Reporter {
+	return i.reporter
+}
+
+// SetReporter replaces the Reporter of the DistributionInjector.
+func (i *DistributionInjector) SetReporter(r Reporter) {
+	i.reporter = r
+}
+
+// Name returns "distribution".
+func (i *DistributionInjector) Name() string {
+	return "distribution"
+}
+
+// Describe returns the routes of the LatencyProfile, the random seed, and the LatencyPhase, if set.
+func (i *DistributionInjector) Describe() map[string]string {
+	d := map[string]string{
+		"routes": strings.Join(i.routes(), ", "),
+		"seed":   strconv.FormatInt(i.randSeed, 10),
+	}
+	if i.phase != LatencyBeforeHeaders {
+		d["phase"] = i.phase.String()
+	}
+
+	return d
+}
+
+// String returns a summary of the DistributionInjector, such as "distribution(/orders, /users)".
+func (i *DistributionInjector) String() string {
+	return fmt.Sprintf("%s(%s)", i.Name(), strings.Join(i.routes(), ", "))
+}
+
+// routes returns the routes of the LatencyProfile that hold requests, sorted.
+func (i *DistributionInjector) routes() []string {
+	routes := make([]string, 0, len(i.profile.Routes))
+	for route, h := range i.profile.Routes {
+		if h.Count > 0 {
+			routes = append(routes, route)
+		}
+	}
+	sort.Strings(routes)
+
+	return routes
+}
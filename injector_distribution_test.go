@@ -0,0 +1,213 @@
+package fault
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testSleepClock is a Clock that records how long it is asked to sleep instead of sleeping.
+type testSleepClock struct {
+	RealClock
+
+	mtx    sync.Mutex
+	sleeps []time.Duration
+}
+
+// Sleep records d.
+func (c *testSleepClock) Sleep(d time.Duration) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	c.sleeps = append(c.sleeps, d)
+}
+
+// testLatencyProfile returns a LatencyProfile with a route "/empty" without requests and a route
+// "/a" whose requests are all in the bucket at idx of buckets 10ms, 100ms, and overflow.
+func testLatencyProfile(idx int) *LatencyProfile {
+	h := newLatencyHistogram([]time.Duration{10 * time.Millisecond, 100 * time.Millisecond})
+	h.Counts[idx] = 5
+	h.Count = 5
+
+	return &LatencyProfile{Routes: map[string]LatencyHistogram{
+		"/a":     h,
+		"/empty": newLatencyHistogram(h.Buckets),
+	}}
+}
+
+// TestNewDistributionInjector tests NewDistributionInjector.
+func TestNewDistributionInjector(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		giveProfile *LatencyProfile
+		giveOptions []DistributionInjectorOption
+		wantSeed    int64
+		wantErr     error
+	}{
+		{
+			name:        "defaults",
+			giveProfile: testLatencyProfile(0),
+			wantSeed:    defaultRandSeed,
+		},
+		{
+			name:        "options",
+			giveProfile: testLatencyProfile(0),
+			giveOptions: []DistributionInjectorOption{
+				WithRandSeed(42),
+				WithReporter(newTestReporter()),
+				WithClock(&testSleepClock{}),
+				WithRouteFunc(func(r *http.Request) string { return r.Method }),
+			},
+			wantSeed: 42,
+		},
+		{
+			name:        "nil profile",
+			giveProfile: nil,
+			wantErr:     ErrEmptyProfile,
+		},
+		{
+			name: "empty profile",
+			giveProfile: &LatencyProfile{Routes: map[string]LatencyHistogram{
+				"/empty": newLatencyHistogram(defaultLatencyBuckets),
+			}},
+			wantErr: ErrEmptyProfile,
+		},
+		{
+			name:        "option error",
+			giveProfile: testLatencyProfile(0),
+			giveOptions: []DistributionInjectorOption{withError()},
+			wantErr:     errErrorOption,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			di, err := NewDistributionInjector(tt.giveProfile, tt.giveOptions...)
+
+			assert.Equal(t, tt.wantErr, err)
+			if tt.wantErr != nil {
+				assert.Nil(t, di)
+				return
+			}
+
+			assert.Equal(t, tt.wantSeed, di.Seed())
+			assert.Equal(t, tt.giveProfile, di.profile)
+		})
+	}
+}
+
+// TestDistributionInjectorHandler tests that DistributionInjector.Handler waits latencies drawn from
+// the distribution of the route.
+func TestDistributionInjectorHandler(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		giveProfile *LatencyProfile
+		givePath    string
+		wantMin     time.Duration
+		wantMax     time.Duration
+		wantState   InjectorState
+	}{
+		{
+			name:        "first bucket",
+			giveProfile: testLatencyProfile(0),
+			givePath:    "/a",
+			wantMin:     0,
+			wantMax:     10 * time.Millisecond,
+			wantState:   StateStarted,
+		},
+		{
+			name:        "second bucket",
+			giveProfile: testLatencyProfile(1),
+			givePath:    "/a",
+			wantMin:     10 * time.Millisecond,
+			wantMax:     100 * time.Millisecond,
+			wantState:   StateStarted,
+		},
+		{
+			name:        "overflow bucket",
+			giveProfile: testLatencyProfile(2),
+			givePath:    "/a",
+			wantMin:     100 * time.Millisecond,
+			wantMax:     100 * time.Millisecond,
+			wantState:   StateStarted,
+		},
+		{
+			name:        "empty route",
+			giveProfile: testLatencyProfile(1),
+			givePath:    "/empty",
+			wantState:   StateSkipped,
+		},
+		{
+			name:        "unknown route",
+			giveProfile: testLatencyProfile(1),
+			givePath:    "/b",
+			wantState:   StateSkipped,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			clock := &testSleepClock{}
+			reporter := &testStateReporter{states: make(chan InjectorState, 200)}
+
+			di, err := NewDistributionInjector(tt.giveProfile, WithClock(clock), WithReporter(reporter))
+			assert.NoError(t, err)
+
+			h := di.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(testHandlerCode)
+			}))
+
+			for n := 0; n < 100; n++ {
+				rr := httptest.NewRecorder()
+				h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, tt.givePath, nil))
+				assert.Equal(t, testHandlerCode, rr.Code)
+			}
+
+			if tt.wantState == StateSkipped {
+				for n := 0; n < 100; n++ {
+					assert.Equal(t, StateSkipped, <-reporter.states)
+				}
+				assert.Empty(t, clock.sleeps)
+				return
+			}
+
+			states := map[InjectorState]int{}
+			for n := 0; n < 200; n++ {
+				states[<-reporter.states]++
+			}
+			assert.Equal(t, map[InjectorState]int{StateStarted: 100, StateFinished: 100}, states)
+
+			assert.Len(t, clock.sleeps, 100)
+			for _, d := range clock.sleeps {
+				assert.GreaterOrEqual(t, int64(d), int64(tt.wantMin))
+				assert.LessOrEqual(t, int64(d), int64(tt.wantMax))
+			}
+		})
+	}
+}
+
+// TestDistributionInjectorReporter tests DistributionInjector.Reporter and SetReporter.
+func TestDistributionInjectorReporter(t *testing.T) {
+	t.Parallel()
+
+	di, err := NewDistributionInjector(testLatencyProfile(0))
+	assert.NoError(t, err)
+
+	reporter := newTestReporter()
+	di.SetReporter(reporter)
+	assert.Equal(t, reporter, di.Reporter())
+}
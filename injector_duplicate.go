@@ -0,0 +1,269 @@
+package fault
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrInvalidDuplicates when a DuplicateRequestInjector is given fewer than 1 duplicate.
+var ErrInvalidDuplicates = errors.New("duplicates must be > 0")
+
+// DuplicateRequestInjector runs the request more than once, as if the client had retried it or a
+// queue had delivered it twice, and sends the client the response of only one run. Use it to find
+// handlers that are not idempotent.
+type DuplicateRequestInjector struct {
+	duplicates int
+	interval   time.Duration
+	replyLast  bool
+	concurrent bool
+
+	clock    Clock
+	reporter Reporter
+}
+
+// DuplicateRequestInjectorOption configures a DuplicateRequestInjector.
+type DuplicateRequestInjectorOption interface {
+	applyDuplicateRequestInjector(i *DuplicateRequestInjector) error
+}
+
+type duplicatesOption int
+
+func (o duplicatesOption) applyDuplicateRequestInjector(i *DuplicateRequestInjector) error {
+	if o < 1 {
+		return ErrInvalidDuplicates
+	}
+
+	i.duplicates = int(o)
+	return nil
+}
+
+// WithDuplicates sets how many times the request runs after the original. Default 1.
+func WithDuplicates(n int) DuplicateRequestInjectorOption {
+	return duplicatesOption(n)
+}
+
+type duplicateIntervalOption time.Duration
+
+func (o duplicateIntervalOption) applyDuplicateRequestInjector(i *DuplicateRequestInjector) error {
+	if o < 0 {
+		return ErrInvalidDelay
+	}
+
+	i.interval = time.Duration(o)
+	return nil
+}
+
+// WithDuplicateInterval sets how long to wait after each run before the next, like the backoff of
+// a retrying client. Default 0.
+func WithDuplicateInterval(d time.Duration) DuplicateRequestInjectorOption {
+	return duplicateIntervalOption(d)
+}
+
+type replyFromLastOption struct{}
+
+func (o replyFromLastOption) applyDuplicateRequestInjector(i *DuplicateRequestInjector) error {
+	i.replyLast = true
+	return nil
+}
+
+// WithReplyFromLast sends the client the response of the last run instead of the first, like a
+// client that retried after losing the first response.
+func WithReplyFromLast() DuplicateRequestInjectorOption {
+	return replyFromLastOption{}
+}
+
+type concurrentDuplicatesOption struct{}
+
+func (o concurrentDuplicatesOption) applyDuplicateRequestInjector(i *DuplicateRequestInjector) error {
+	i.concurrent = true
+	return nil
+}
+
+// WithConcurrentDuplicates runs the duplicates at the same time as the original, like a duplicate
+// delivery that races it, to find handlers that check and then write without a lock. The interval
+// set by WithDuplicateInterval is ignored.
+func WithConcurrentDuplicates() DuplicateRequestInjectorOption {
+	return concurrentDuplicatesOption{}
+}
+
+func (o clockOption) applyDuplicateRequestInjector(i *DuplicateRequestInjector) error {
+	i.clock = o.clock
+	return nil
+}
+
+func (o reporterOption) applyDuplicateRequestInjector(i *DuplicateRequestInjector) error {
+	i.reporter = o.reporter
+	return nil
+}
+
+// NewDuplicateRequestInjector returns a DuplicateRequestInjector.
+func NewDuplicateRequestInjector(opts ...DuplicateRequestInjectorOption) (*DuplicateRequestInjector, error) {
+	// set defaults
+	di := &DuplicateRequestInjector{
+		duplicates: 1,
+		clock:      NewRealClock(),
+		reporter:   NewNoopReporter(),
+	}
+
+	// apply options
+	for _, opt := range opts {
+		err := opt.applyDuplicateRequestInjector(di)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return di, nil
+}
+
+// Handler reads the whole request body and then runs the request once and once more for each
+// duplicate, each run with its own copy of the request and body. The run that replies writes to w
+// and the responses of the others are discarded. A body larger than 10MiB is not read, and the
+// request runs once without duplicates. If the context of the request is done while waiting the
+// interval, the duplicates left do not run.
+func (i *DuplicateRequestInjector) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		go i.reporter.Report(i.String(), StateStarted)
+		defer func() { go i.reporter.Report(i.String(), StateFinished) }()
+
+		body, ok, err := readBody(r)
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if r.Body != nil {
+			_ = r.Body.Close()
+		}
+
+		// the writers are made before any run so the duplicates copy the headers as they were
+		runs := i.duplicates + 1
+		writers := make([]http.ResponseWriter, runs)
+		for idx := range writers {
+			writers[idx] = newDiscardWriter(w)
+		}
+		if i.replyLast {
+			writers[runs-1] = w
+		} else {
+			writers[0] = w
+		}
+
+		run := func(idx int) {
+			rc := r.Clone(r.Context())
+			rc.Body = &tamperedBody{Reader: bytes.NewReader(body), err: err}
+			next.ServeHTTP(writers[idx], rc)
+		}
+
+		if i.concurrent {
+			var wg sync.WaitGroup
+			for idx := 0; idx < runs; idx++ {
+				wg.Add(1)
+				go func(idx int) {
+					defer wg.Done()
+					run(idx)
+				}(idx)
+			}
+			wg.Wait()
+			return
+		}
+
+		for idx := 0; idx < runs; idx++ {
+			if idx > 0 && i.interval > 0 {
+				select {
+				case <-i.clock.After(i.interval):
+				case <-r.Context().Done():
+					return
+				}
+			}
+			run(idx)
+		}
+	})
+}
+
+// discardWriter is an http.ResponseWriter that discards the response of a duplicate run.
+type discardWriter struct {
+	header http.Header
+}
+
+// newDiscardWriter returns a discardWriter with a copy of the headers already set on w, so the
+// duplicate sees the same headers as the run that replies.
+func newDiscardWriter(w http.ResponseWriter) *discardWriter {
+	return &discardWriter{header: w.Header().Clone()}
+}
+
+// Header returns a header that is discarded.
+func (w *discardWriter) Header() http.Header {
+	return w.header
+}
+
+// Write discards b.
+func (w *discardWriter) Write(b []byte) (int, error) {
+	return len(b), nil
+}
+
+// WriteHeader discards the status code.
+func (w *discardWriter) WriteHeader(code int) {}
+
+// Flush does nothing.
+func (w *discardWriter) Flush() {}
+
+// Destructive returns true. Duplicating a request applies a non-idempotent operation more than
+// once.
+func (i *DuplicateRequestInjector) Destructive() bool {
+	return true
+}
+
+// Reporter returns the Reporter of the DuplicateRequestInjector.
+func (i *DuplicateRequestInjector) Reporter() Reporter {
+	return i.reporter
+}
+
+// SetReporter replaces the Reporter of the DuplicateRequestInjector.
+func (i *DuplicateRequestInjector) SetReporter(r Reporter) {
+	i.reporter = r
+}
+
+// Name returns "duplicate".
+func (i *DuplicateRequestInjector) Name() string {
+	return "duplicate"
+}
+
+// Describe returns the number of duplicates, which run replies, and the interval between runs or
+// whether they run concurrently.
+func (i *DuplicateRequestInjector) Describe() map[string]string {
+	d := map[string]string{
+		"duplicates": strconv.Itoa(i.duplicates),
+		"reply":      "first",
+	}
+	if i.replyLast {
+		d["reply"] = "last"
+	}
+	if i.concurrent {
+		d["concurrent"] = "true"
+	} else if i.interval > 0 {
+		d["interval"] = i.interval.String()
+	}
+
+	return d
+}
+
+// String returns a summary of the DuplicateRequestInjector, such as "duplicate(2)" or
+// "duplicate(1, reply last, every 1s)".
+func (i *DuplicateRequestInjector) String() string {
+	parts := []string{strconv.Itoa(i.duplicates)}
+	if i.replyLast {
+		parts = append(parts, "reply last")
+	}
+	if i.concurrent {
+		parts = append(parts, "concurrent")
+	} else if i.interval > 0 {
+		parts = append(parts, "every "+i.interval.String())
+	}
+
+	return fmt.Sprintf("%s(%s)", i.Name(), strings.Join(parts, ", "))
+}
@@ -0,0 +1,283 @@
+package fault
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/github/go-fault/faulttest"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestNewDuplicateRequestInjector tests NewDuplicateRequestInjector.
+func TestNewDuplicateRequestInjector(t *testing.T) {
+	t.Parallel()
+
+	clock := faulttest.NewClock(time.Time{})
+
+	tests := []struct {
+		name    string
+		give    []DuplicateRequestInjectorOption
+		want    *DuplicateRequestInjector
+		wantErr error
+	}{
+		{
+			name: "no options",
+			give: []DuplicateRequestInjectorOption{},
+			want: &DuplicateRequestInjector{
+				duplicates: 1,
+				clock:      NewRealClock(),
+				reporter:   NewNoopReporter(),
+			},
+			wantErr: nil,
+		},
+		{
+			name: "options",
+			give: []DuplicateRequestInjectorOption{
+				WithDuplicates(3),
+				WithDuplicateInterval(time.Second),
+				WithReplyFromLast(),
+				WithConcurrentDuplicates(),
+				WithClock(clock),
+				WithReporter(newTestReporter()),
+			},
+			want: &DuplicateRequestInjector{
+				duplicates: 3,
+				interval:   time.Second,
+				replyLast:  true,
+				concurrent: true,
+				clock:      clock,
+				reporter:   newTestReporter(),
+			},
+			wantErr: nil,
+		},
+		{
+			name: "no duplicates",
+			give: []DuplicateRequestInjectorOption{
+				WithDuplicates(0),
+			},
+			want:    nil,
+			wantErr: ErrInvalidDuplicates,
+		},
+		{
+			name: "negative interval",
+			give: []DuplicateRequestInjectorOption{
+				WithDuplicateInterval(-time.Second),
+			},
+			want:    nil,
+			wantErr: ErrInvalidDelay,
+		},
+		{
+			name: "option error",
+			give: []DuplicateRequestInjectorOption{
+				withError(),
+			},
+			want:    nil,
+			wantErr: errErrorOption,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			di, err := NewDuplicateRequestInjector(tt.give...)
+
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.want, di)
+		})
+	}
+}
+
+// TestDuplicateRequestInjectorHandler tests that a DuplicateRequestInjector runs the request once
+// for the original and each duplicate, each with the whole body, and replies with one run.
+func TestDuplicateRequestInjectorHandler(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		give     []DuplicateRequestInjectorOption
+		wantRuns int
+		wantBody string
+	}{
+		{
+			name:     "default",
+			wantRuns: 2,
+			wantBody: "run 1: order",
+		},
+		{
+			name:     "duplicates",
+			give:     []DuplicateRequestInjectorOption{WithDuplicates(3)},
+			wantRuns: 4,
+			wantBody: "run 1: order",
+		},
+		{
+			name:     "reply from last",
+			give:     []DuplicateRequestInjectorOption{WithDuplicates(2), WithReplyFromLast()},
+			wantRuns: 3,
+			wantBody: "run 3: order",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			reporter := newTestStateReporter()
+			di, err := NewDuplicateRequestInjector(append(tt.give, WithReporter(reporter))...)
+			assert.NoError(t, err)
+
+			var runs int
+			h := di.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				runs++
+				b, err := io.ReadAll(r.Body)
+				assert.NoError(t, err)
+				assert.Equal(t, "1", w.Header().Get("X-Request-Id"))
+
+				w.Header().Set("X-Run", strconv.Itoa(runs))
+				w.WriteHeader(http.StatusCreated)
+				_, _ = w.Write([]byte("run " + strconv.Itoa(runs) + ": " + string(b)))
+			}))
+
+			rr := httptest.NewRecorder()
+			rr.Header().Set("X-Request-Id", "1")
+			h.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader("order")))
+
+			assert.Equal(t, tt.wantRuns, runs)
+			assert.Equal(t, http.StatusCreated, rr.Code)
+			assert.Equal(t, tt.wantBody, rr.Body.String())
+			assert.Equal(t, strings.TrimSuffix(strings.TrimPrefix(tt.wantBody, "run "), ": order"),
+				rr.Header().Get("X-Run"))
+
+			// the states are reported concurrently, so they may arrive in any order
+			states := map[InjectorState]int{}
+			for n := 0; n < 2; n++ {
+				states[<-reporter.states]++
+			}
+			assert.Equal(t, map[InjectorState]int{StateStarted: 1, StateFinished: 1}, states)
+		})
+	}
+}
+
+// TestDuplicateRequestInjectorInterval tests that a DuplicateRequestInjector waits the interval
+// between runs on its Clock.
+func TestDuplicateRequestInjectorInterval(t *testing.T) {
+	t.Parallel()
+
+	clock := faulttest.NewClock(time.Time{})
+	di, err := NewDuplicateRequestInjector(WithDuplicates(2), WithDuplicateInterval(time.Second),
+		WithClock(clock))
+	assert.NoError(t, err)
+
+	var runs int32
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		di.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&runs, 1)
+		})).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/", nil))
+	}()
+
+	for want := int32(1); want < 3; want++ {
+		clock.BlockUntil(1)
+		assert.Equal(t, want, atomic.LoadInt32(&runs))
+		clock.Advance(time.Second)
+	}
+	<-done
+
+	assert.Equal(t, int32(3), atomic.LoadInt32(&runs))
+}
+
+// TestDuplicateRequestInjectorIntervalCanceled tests that a DuplicateRequestInjector stops waiting
+// the interval, and runs no more duplicates, once the context of the request is done.
+func TestDuplicateRequestInjectorIntervalCanceled(t *testing.T) {
+	t.Parallel()
+
+	clock := faulttest.NewClock(time.Time{})
+	di, err := NewDuplicateRequestInjector(WithDuplicates(2), WithDuplicateInterval(time.Second),
+		WithClock(clock))
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var runs int32
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		di.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&runs, 1)
+		})).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/", nil).WithContext(ctx))
+	}()
+
+	clock.BlockUntil(1)
+	cancel()
+	<-done
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&runs))
+}
+
+// TestDuplicateRequestInjectorLargeBody tests that a request with a body too large to buffer runs
+// once, with the whole body, whether or not its length is known.
+func TestDuplicateRequestInjectorLargeBody(t *testing.T) {
+	t.Parallel()
+
+	body := strings.Repeat("a", maxBufferedBodySize+1)
+
+	for _, unknownLength := range []bool{false, true} {
+		di, err := NewDuplicateRequestInjector()
+		assert.NoError(t, err)
+
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		if unknownLength {
+			req.ContentLength = -1
+		}
+
+		var runs int
+		var got []byte
+		di.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			runs++
+			got, err = io.ReadAll(r.Body)
+			assert.NoError(t, err)
+		})).ServeHTTP(httptest.NewRecorder(), req)
+
+		assert.Equal(t, 1, runs)
+		assert.Equal(t, len(body), len(got))
+	}
+}
+
+// TestDuplicateRequestInjectorConcurrent tests that WithConcurrentDuplicates runs the duplicates at
+// the same time as the original.
+func TestDuplicateRequestInjectorConcurrent(t *testing.T) {
+	t.Parallel()
+
+	di, err := NewDuplicateRequestInjector(WithDuplicates(2), WithConcurrentDuplicates())
+	assert.NoError(t, err)
+
+	// every run waits for the others, so the runs must be concurrent to finish
+	var started sync.WaitGroup
+	started.Add(3)
+	var runs int32
+	h := di.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&runs, 1)
+		started.Done()
+		started.Wait()
+
+		b, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+		_, _ = w.Write(b)
+	}))
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/", strings.NewReader("order")))
+
+	assert.Equal(t, int32(3), atomic.LoadInt32(&runs))
+	assert.Equal(t, "order", rr.Body.String())
+}
@@ -2,8 +2,9 @@ package fault
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
-	"reflect"
+	"strconv"
 )
 
 var (
@@ -11,11 +12,17 @@ var (
 	ErrInvalidHTTPCode = errors.New("not a valid http status code")
 )
 
+// defaultErrorContentType is the Content-Type of ErrorInjector responses when none is set.
+const defaultErrorContentType = "text/plain; charset=utf-8"
+
 // ErrorInjector responds with an http status code and message.
 type ErrorInjector struct {
-	statusCode int
-	statusText string
-	reporter   Reporter
+	statusCode  int
+	statusText  string
+	header      http.Header
+	body        []byte
+	contentType string
+	reporter    Reporter
 }
 
 // ErrorInjectorOption configures an ErrorInjector.
@@ -30,11 +37,82 @@ func (o statusTextOption) applyErrorInjector(i *ErrorInjector) error {
 	return nil
 }
 
+// StatusTextOption configures Injectors that respond with status text.
+type StatusTextOption interface {
+	ErrorInjectorOption
+	SessionInjectorOption
+}
+
 // WithStatusText sets custom status text to write.
-func WithStatusText(t string) ErrorInjectorOption {
+func WithStatusText(t string) StatusTextOption {
 	return statusTextOption(t)
 }
 
+type errorHeaderOption struct {
+	key   string
+	value string
+}
+
+func (o errorHeaderOption) applyErrorInjector(i *ErrorInjector) error {
+	if i.header == nil {
+		i.header = make(http.Header)
+	}
+	i.header.Add(o.key, o.value)
+	return nil
+}
+
+// WithErrorHeader adds a header to the error response, such as "Retry-After: 30". Pass it more than
+// once to add more headers.
+func WithErrorHeader(key, value string) ErrorInjectorOption {
+	return errorHeaderOption{key: key, value: value}
+}
+
+type errorHeadersOption http.Header
+
+func (o errorHeadersOption) applyErrorInjector(i *ErrorInjector) error {
+	if i.header == nil {
+		i.header = make(http.Header)
+	}
+	for k, v := range o {
+		for _, vv := range v {
+			i.header.Add(k, vv)
+		}
+	}
+	return nil
+}
+
+// WithHeaders adds every header in h to the error response. It can be combined with
+// WithErrorHeader().
+func WithHeaders(h http.Header) ErrorInjectorOption {
+	return errorHeadersOption(h)
+}
+
+type responseBodyOption []byte
+
+func (o responseBodyOption) applyErrorInjector(i *ErrorInjector) error {
+	i.body = append([]byte{}, o...)
+	return nil
+}
+
+// WithResponseBody sets the body of the error response, such as the JSON error envelope of an
+// upstream API, written as is instead of the status text.
+func WithResponseBody(b []byte) ErrorInjectorOption {
+	return responseBodyOption(b)
+}
+
+type contentTypeOption string
+
+func (o contentTypeOption) applyErrorInjector(i *ErrorInjector) error {
+	i.contentType = string(o)
+	return nil
+}
+
+// WithContentType sets the Content-Type of the error response, such as "application/json". Default
+// the Content-Type set by WithHeaders() or WithErrorHeader(), or "text/plain; charset=utf-8".
+func WithContentType(ct string) ErrorInjectorOption {
+	return contentTypeOption(ct)
+}
+
 func (o reporterOption) applyErrorInjector(i *ErrorInjector) error {
 	i.reporter = o.reporter
 	return nil
@@ -70,11 +148,80 @@ func NewErrorInjector(code int, opts ...ErrorInjectorOption) (*ErrorInjector, er
 	return ei, nil
 }
 
-// Handler responds with the configured status code and text.
+// Handler responds with the configured status code, headers, and body, or the status text if no
+// body is set.
 func (i *ErrorInjector) Handler(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		go i.reporter.Report(reflect.ValueOf(*i).Type().Name(), StateStarted)
-		http.Error(w, i.statusText, i.statusCode)
-		go i.reporter.Report(reflect.ValueOf(*i).Type().Name(), StateFinished)
+		go i.reporter.Report(i.String(), StateStarted)
+
+		h := w.Header()
+		for k, v := range i.header {
+			h[k] = append([]string(nil), v...)
+		}
+
+		// like http.Error, which this replaces
+		h.Del("Content-Length")
+		h.Set("Content-Type", i.responseContentType())
+		h.Set("X-Content-Type-Options", "nosniff")
+		w.WriteHeader(i.statusCode)
+		if i.body != nil {
+			_, _ = w.Write(i.body)
+		} else {
+			_, _ = fmt.Fprintln(w, i.statusText)
+		}
+
+		go i.reporter.Report(i.String(), StateFinished)
 	})
 }
+
+// responseContentType returns the Content-Type of the response.
+func (i *ErrorInjector) responseContentType() string {
+	if i.contentType != "" {
+		return i.contentType
+	}
+	if ct := i.header.Get("Content-Type"); ct != "" {
+		return ct
+	}
+
+	return defaultErrorContentType
+}
+
+// Reporter returns the Reporter of the ErrorInjector.
+func (i *ErrorInjector) Reporter() Reporter {
+	return i.reporter
+}
+
+// SetReporter replaces the Reporter of the ErrorInjector.
+func (i *ErrorInjector) SetReporter(r Reporter) {
+	i.reporter = r
+}
+
+// Name returns "error".
+func (i *ErrorInjector) Name() string {
+	return "error"
+}
+
+// Describe returns the status code, text, and any headers, Content-Type, and body the ErrorInjector
+// responds with.
+func (i *ErrorInjector) Describe() map[string]string {
+	d := map[string]string{
+		"code": strconv.Itoa(i.statusCode),
+		"text": i.statusText,
+	}
+	if len(i.header) > 0 {
+		d["headers"] = joinHeader(i.header)
+	}
+	if i.contentType != "" {
+		d["content_type"] = i.contentType
+	}
+	if i.body != nil {
+		d["body"] = string(i.body)
+	}
+
+	return d
+}
+
+// String returns a summary of the ErrorInjector, such as "error(500)".
+func (i *ErrorInjector) String() string {
+	return fmt.Sprintf("%s(%d)", i.Name(), i.statusCode)
+}
@@ -69,6 +69,54 @@ func TestNewErrorInjector(t *testing.T) {
 			},
 			wantErr: nil,
 		},
+		{
+			name:     "headers",
+			giveCode: http.StatusTooManyRequests,
+			giveOptions: []ErrorInjectorOption{
+				WithErrorHeader("retry-after", "30"),
+				WithErrorHeader("Cache-Control", "no-store"),
+			},
+			want: &ErrorInjector{
+				statusCode: http.StatusTooManyRequests,
+				statusText: http.StatusText(http.StatusTooManyRequests),
+				header:     http.Header{"Retry-After": {"30"}, "Cache-Control": {"no-store"}},
+				reporter:   NewNoopReporter(),
+			},
+			wantErr: nil,
+		},
+		{
+			name:     "body",
+			giveCode: http.StatusBadGateway,
+			giveOptions: []ErrorInjectorOption{
+				WithHeaders(http.Header{"retry-after": {"30"}, "Vary": {"Accept", "Origin"}}),
+				WithErrorHeader("Vary", "Accept-Encoding"),
+				WithResponseBody([]byte(`{"error":"bad gateway"}`)),
+				WithContentType("application/json"),
+			},
+			want: &ErrorInjector{
+				statusCode:  http.StatusBadGateway,
+				statusText:  http.StatusText(http.StatusBadGateway),
+				header:      http.Header{"Retry-After": {"30"}, "Vary": {"Accept", "Origin", "Accept-Encoding"}},
+				body:        []byte(`{"error":"bad gateway"}`),
+				contentType: "application/json",
+				reporter:    NewNoopReporter(),
+			},
+			wantErr: nil,
+		},
+		{
+			name:     "empty body",
+			giveCode: http.StatusBadGateway,
+			giveOptions: []ErrorInjectorOption{
+				WithResponseBody(nil),
+			},
+			want: &ErrorInjector{
+				statusCode: http.StatusBadGateway,
+				statusText: http.StatusText(http.StatusBadGateway),
+				body:       []byte{},
+				reporter:   NewNoopReporter(),
+			},
+			wantErr: nil,
+		},
 		{
 			name:     "invalid code",
 			giveCode: 0,
@@ -107,18 +155,21 @@ func TestErrorInjectorHandler(t *testing.T) {
 	t.Parallel()
 
 	tests := []struct {
-		name        string
-		giveCode    int
-		giveOptions []ErrorInjectorOption
-		wantCode    int
-		wantBody    string
+		name            string
+		giveCode        int
+		giveOptions     []ErrorInjectorOption
+		wantCode        int
+		wantBody        string
+		wantHeader      string
+		wantContentType string
 	}{
 		{
-			name:        "only code",
-			giveCode:    http.StatusInternalServerError,
-			giveOptions: nil,
-			wantCode:    http.StatusInternalServerError,
-			wantBody:    http.StatusText(http.StatusInternalServerError),
+			name:            "only code",
+			giveCode:        http.StatusInternalServerError,
+			giveOptions:     nil,
+			wantCode:        http.StatusInternalServerError,
+			wantBody:        http.StatusText(http.StatusInternalServerError),
+			wantContentType: "text/plain; charset=utf-8",
 		},
 		{
 			name:     "custom text",
@@ -126,8 +177,55 @@ func TestErrorInjectorHandler(t *testing.T) {
 			giveOptions: []ErrorInjectorOption{
 				WithStatusText("very custom text"),
 			},
-			wantCode: http.StatusInternalServerError,
-			wantBody: "very custom text",
+			wantCode:        http.StatusInternalServerError,
+			wantBody:        "very custom text",
+			wantContentType: "text/plain; charset=utf-8",
+		},
+		{
+			name:     "header",
+			giveCode: http.StatusTooManyRequests,
+			giveOptions: []ErrorInjectorOption{
+				WithErrorHeader("Retry-After", "30"),
+			},
+			wantCode:        http.StatusTooManyRequests,
+			wantBody:        http.StatusText(http.StatusTooManyRequests),
+			wantHeader:      "30",
+			wantContentType: "text/plain; charset=utf-8",
+		},
+		{
+			name:     "body",
+			giveCode: http.StatusServiceUnavailable,
+			giveOptions: []ErrorInjectorOption{
+				WithHeaders(http.Header{"Retry-After": {"30"}}),
+				WithResponseBody([]byte(`{"error":{"code":"unavailable"}}`)),
+				WithContentType("application/json"),
+			},
+			wantCode:        http.StatusServiceUnavailable,
+			wantBody:        `{"error":{"code":"unavailable"}}`,
+			wantHeader:      "30",
+			wantContentType: "application/json",
+		},
+		{
+			name:     "content type header",
+			giveCode: http.StatusBadGateway,
+			giveOptions: []ErrorInjectorOption{
+				WithErrorHeader("Content-Type", "text/html"),
+				WithErrorHeader("Content-Length", "0"),
+				WithResponseBody([]byte("<html><body>502 Bad Gateway</body></html>")),
+			},
+			wantCode:        http.StatusBadGateway,
+			wantBody:        "<html><body>502 Bad Gateway</body></html>",
+			wantContentType: "text/html",
+		},
+		{
+			name:     "empty body",
+			giveCode: http.StatusBadGateway,
+			giveOptions: []ErrorInjectorOption{
+				WithResponseBody(nil),
+			},
+			wantCode:        http.StatusBadGateway,
+			wantBody:        "",
+			wantContentType: "text/plain; charset=utf-8",
 		},
 	}
 
@@ -149,6 +247,10 @@ func TestErrorInjectorHandler(t *testing.T) {
 
 			assert.Equal(t, tt.wantCode, rr.Code)
 			assert.Equal(t, tt.wantBody, strings.TrimSpace(rr.Body.String()))
+			assert.Equal(t, tt.wantHeader, rr.Header().Get("Retry-After"))
+			assert.Equal(t, tt.wantContentType, rr.Header().Get("Content-Type"))
+			assert.Equal(t, "nosniff", rr.Header().Get("X-Content-Type-Options"))
+			assert.Empty(t, rr.Header().Get("Content-Length"))
 		})
 	}
 }
@@ -0,0 +1,50 @@
+package fault
+
+import "net/http"
+
+// InjectorFunc is a function that satisfies Injector, in the way http.HandlerFunc satisfies
+// http.Handler. Use it to write a one-off Injector inline:
+//
+//	i := fault.InjectorFunc(func(next http.Handler) http.Handler {
+//		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+//			w.Header().Set("Cache-Control", "no-store")
+//			next.ServeHTTP(w, r)
+//		})
+//	})
+type InjectorFunc func(next http.Handler) http.Handler
+
+// Handler returns f(next).
+func (f InjectorFunc) Handler(next http.Handler) http.Handler {
+	return f(next)
+}
+
+// Validate returns ErrNilInjector if f is nil.
+func (f InjectorFunc) Validate() error {
+	if f == nil {
+		return ErrNilInjector
+	}
+
+	return nil
+}
+
+// Validator is implemented by Injectors that can check their own configuration. Custom Injectors
+// that are not built by a constructor returning an error can implement it to fail fast: NewFault,
+// Fault.SetInjector, NewSandboxInjector, NewTransport, and the composite Injectors call Validate and
+// return its error instead of accepting the Injector.
+type Validator interface {
+	Validate() error
+}
+
+// validateInjector returns ErrNilInjector if i is nil, or the error of i.Validate if i implements
+// Validator.
+func validateInjector(i Injector) error {
+	if i == nil {
+		return ErrNilInjector
+	}
+
+	if v, ok := i.(Validator); ok {
+		return v.Validate()
+	}
+
+	return nil
+}
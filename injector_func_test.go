@@ -0,0 +1,124 @@
+package fault
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// errTestInvalid is returned by an invalid testInjectorValidator.
+var errTestInvalid = errors.New("test injector is invalid")
+
+// testInjectorValidator is an Injector that does nothing and validates to err.
+type testInjectorValidator struct {
+	testInjectorNoop
+	err error
+}
+
+// Validate returns err.
+func (i *testInjectorValidator) Validate() error {
+	return i.err
+}
+
+// TestInjectorFunc tests that InjectorFunc runs the function as an Injector.
+func TestInjectorFunc(t *testing.T) {
+	t.Parallel()
+
+	i := InjectorFunc(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusTeapot)
+		})
+	})
+	assert.NoError(t, i.Validate())
+
+	f, err := NewFault(i, WithEnabled(true), WithParticipation(1.0))
+	assert.NoError(t, err)
+
+	rr := testRequest(t, f)
+	assert.Equal(t, http.StatusTeapot, rr.Code)
+	assert.Equal(t, "InjectorFunc", f.Name())
+
+	f, err = NewFault(InjectorFunc(nil))
+	assert.Equal(t, ErrNilInjector, err)
+	assert.Nil(t, f)
+}
+
+// TestValidator tests that the constructors that take an Injector reject an invalid Validator.
+func TestValidator(t *testing.T) {
+	t.Parallel()
+
+	valid := &testInjectorValidator{}
+	invalid := &testInjectorValidator{err: errTestInvalid}
+
+	tests := []struct {
+		name    string
+		give    func(i Injector) error
+		wantErr error
+	}{
+		{
+			name: "fault",
+			give: func(i Injector) error {
+				_, err := NewFault(i)
+				return err
+			},
+			wantErr: errTestInvalid,
+		},
+		{
+			name: "chain",
+			give: func(i Injector) error {
+				_, err := NewChainInjector([]Injector{newTestInjectorNoop(), i})
+				return err
+			},
+			wantErr: InjectorErrors{{Index: 1, Err: errTestInvalid}},
+		},
+		{
+			name: "outage",
+			give: func(i Injector) error {
+				_, err := NewOutageInjector([]Phase{{Duration: 1}, {Duration: 1, Injector: i}})
+				return err
+			},
+			wantErr: &InjectorError{Index: 1, Err: errTestInvalid},
+		},
+		{
+			name: "sandbox",
+			give: func(i Injector) error {
+				_, err := NewSandboxInjector(i)
+				return err
+			},
+			wantErr: errTestInvalid,
+		},
+		{
+			name: "transport",
+			give: func(i Injector) error {
+				_, err := NewTransport(i)
+				return err
+			},
+			wantErr: errTestInvalid,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.NoError(t, tt.give(valid))
+			assert.Equal(t, tt.wantErr, tt.give(invalid))
+		})
+	}
+}
+
+// TestFaultSetInjectorValidator tests that Fault.SetInjector keeps its Injector when given an
+// invalid Validator.
+func TestFaultSetInjectorValidator(t *testing.T) {
+	t.Parallel()
+
+	old := newTestInjectorNoop()
+	f, err := NewFault(old)
+	assert.NoError(t, err)
+
+	assert.Equal(t, errTestInvalid, f.SetInjector(&testInjectorValidator{err: errTestInvalid}))
+	assert.Equal(t, old, f.Injector())
+}
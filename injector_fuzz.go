@@ -0,0 +1,231 @@
+package fault
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strings"
+)
+
+const (
+	// fuzzOpSize is the number of bytes of fuzz data that make up one corruption.
+	fuzzOpSize = 3
+
+	// maxFuzzOps is the most corruptions a FuzzInjector applies. Extra data is ignored.
+	maxFuzzOps = 64
+)
+
+// fuzzStatusCodes are the status codes a FuzzInjector chooses from.
+var fuzzStatusCodes = []int{ //nolint:gochecknoglobals
+	http.StatusOK,
+	http.StatusNoContent,
+	http.StatusMovedPermanently,
+	http.StatusNotModified,
+	http.StatusBadRequest,
+	http.StatusUnauthorized,
+	http.StatusForbidden,
+	http.StatusNotFound,
+	http.StatusRequestTimeout,
+	http.StatusTooManyRequests,
+	http.StatusInternalServerError,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+// fuzzOpKind is a kind of corruption.
+type fuzzOpKind byte
+
+const (
+	fuzzStatus fuzzOpKind = iota
+	fuzzTruncate
+	fuzzFlip
+	fuzzInsert
+	fuzzDropHeader
+
+	numFuzzOpKinds
+)
+
+// fuzzOp is one corruption decoded from fuzz data.
+type fuzzOp struct {
+	kind fuzzOpKind
+	a    byte
+	b    byte
+}
+
+// arg returns the two argument bytes as one number.
+func (o fuzzOp) arg() int {
+	return int(o.a)<<8 | int(o.b)
+}
+
+// String describes the corruption, such as "flip(@772, bit 3)". Positions are taken modulo the
+// length of the body when applied.
+func (o fuzzOp) String() string {
+	switch o.kind {
+	case fuzzStatus:
+		return fmt.Sprintf("status(%d)", fuzzStatusCodes[o.arg()%len(fuzzStatusCodes)])
+	case fuzzTruncate:
+		return fmt.Sprintf("truncate(@%d)", o.arg())
+	case fuzzFlip:
+		return fmt.Sprintf("flip(@%d, bit %d)", o.a, o.b%8)
+	case fuzzInsert:
+		return fmt.Sprintf("insert(@%d, 0x%02x)", o.a, o.b)
+	default:
+		return fmt.Sprintf("drop_header(#%d)", o.arg())
+	}
+}
+
+// FuzzInjector corrupts responses as directed by a byte string, such as the input of a fuzz test.
+// Every 3 bytes of data describe one corruption: changing the status code, truncating the body,
+// flipping a bit, inserting a byte, or dropping a header. Because each corruption is independent,
+// fuzzers that minimize failing inputs produce short reproducers that list only the corruptions
+// that matter.
+type FuzzInjector struct {
+	data     []byte
+	ops      []fuzzOp
+	reporter Reporter
+}
+
+// FuzzInjectorOption configures a FuzzInjector.
+type FuzzInjectorOption interface {
+	applyFuzzInjector(i *FuzzInjector) error
+}
+
+func (o reporterOption) applyFuzzInjector(i *FuzzInjector) error {
+	i.reporter = o.reporter
+	return nil
+}
+
+// NewFuzzInjector returns a FuzzInjector that applies the corruptions described by data. Up to 64
+// corruptions are applied and trailing bytes that do not make a whole corruption are ignored.
+func NewFuzzInjector(data []byte, opts ...FuzzInjectorOption) (*FuzzInjector, error) {
+	// set defaults
+	fi := &FuzzInjector{
+		data:     append([]byte(nil), data...),
+		reporter: NewNoopReporter(),
+	}
+
+	// apply options
+	for _, opt := range opts {
+		err := opt.applyFuzzInjector(fi)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for n := 0; n+fuzzOpSize <= len(data) && len(fi.ops) < maxFuzzOps; n += fuzzOpSize {
+		fi.ops = append(fi.ops, fuzzOp{
+			kind: fuzzOpKind(data[n] % byte(numFuzzOpKinds)),
+			a:    data[n+1],
+			b:    data[n+2],
+		})
+	}
+
+	return fi, nil
+}
+
+// FuzzCorpus returns seed inputs for a FuzzInjector, one for each kind of corruption. Add them to
+// a testing.F so the fuzzer starts from inputs that exercise every corruption.
+func FuzzCorpus() [][]byte {
+	corpus := make([][]byte, 0, numFuzzOpKinds)
+	for k := fuzzOpKind(0); k < numFuzzOpKinds; k++ {
+		corpus = append(corpus, []byte{byte(k), 0, 1})
+	}
+
+	return corpus
+}
+
+// FuzzData returns n random corruptions for a FuzzInjector, generated from seed. Use it for
+// property-based tests that do not use testing.F. The same seed always returns the same data.
+func FuzzData(seed int64, n int) []byte {
+	data := make([]byte, n*fuzzOpSize)
+	_, _ = rand.New(rand.NewSource(seed)).Read(data)
+
+	return data
+}
+
+// Handler buffers the response and applies each corruption in order before sending it.
+func (i *FuzzInjector) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		go i.reporter.Report(i.String(), StateStarted)
+
+		bw := newBufferedWriter(w)
+		next.ServeHTTP(bw, r)
+
+		body := bw.body.Bytes()
+		for _, op := range i.ops {
+			body = applyFuzzOp(op, bw, body)
+		}
+		bw.setBody(body)
+		bw.send()
+		bw.release()
+
+		go i.reporter.Report(i.String(), StateFinished)
+	})
+}
+
+// applyFuzzOp applies op to the buffered response and returns the new body.
+func applyFuzzOp(op fuzzOp, bw *bufferedWriter, body []byte) []byte {
+	switch op.kind {
+	case fuzzStatus:
+		bw.code = fuzzStatusCodes[op.arg()%len(fuzzStatusCodes)]
+	case fuzzTruncate:
+		body = body[:op.arg()%(len(body)+1)]
+	case fuzzFlip:
+		if len(body) > 0 {
+			body[int(op.a)%len(body)] ^= 1 << (op.b % 8)
+		}
+	case fuzzInsert:
+		pos := int(op.a) % (len(body) + 1)
+		out := make([]byte, 0, len(body)+1)
+		out = append(out, body[:pos]...)
+		out = append(out, op.b)
+		body = append(out, body[pos:]...)
+	case fuzzDropHeader:
+		if keys := sortedHeaderKeys(bw.header); len(keys) > 0 {
+			delete(bw.header, keys[op.arg()%len(keys)])
+		}
+	}
+
+	return body
+}
+
+// Name returns "fuzz".
+func (i *FuzzInjector) Name() string {
+	return "fuzz"
+}
+
+// Describe returns the fuzz data in hex and the corruptions it describes.
+func (i *FuzzInjector) Describe() map[string]string {
+	return map[string]string{
+		"data": hex.EncodeToString(i.data),
+		"ops":  i.opsString(),
+	}
+}
+
+// String returns a summary of the FuzzInjector, such as "fuzz(status(503), truncate(@10))". It is
+// a readable form of a reproducer found by a fuzzer.
+func (i *FuzzInjector) String() string {
+	return fmt.Sprintf("%s(%s)", i.Name(), i.opsString())
+}
+
+// opsString joins the corruptions.
+func (i *FuzzInjector) opsString() string {
+	ops := make([]string, 0, len(i.ops))
+	for _, op := range i.ops {
+		ops = append(ops, op.String())
+	}
+
+	return strings.Join(ops, ", ")
+}
+
+// Reporter returns the Reporter of the FuzzInjector.
+func (i *FuzzInjector) Reporter() Reporter {
+	return i.reporter
+}
+
+// SetReporter replaces the Reporter of the FuzzInjector.
+func (i *FuzzInjector) SetReporter(r Reporter) {
+	i.reporter = r
+}
@@ -0,0 +1,37 @@
+//go:build go1.18
+// +build go1.18
+
+package fault
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// FuzzFuzzInjector checks that the FuzzInjector can apply any input. It also shows how to drive a
+// FuzzInjector from testing.F: replace the handler and checks with your own client.
+func FuzzFuzzInjector(f *testing.F) {
+	for _, data := range FuzzCorpus() {
+		f.Add(data)
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		fi, err := NewFuzzInjector(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		h := fi.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"items": [1, 2, 3]}`))
+		}))
+
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+
+		if http.StatusText(rr.Code) == "" {
+			t.Fatalf("%s responded with invalid status %d", fi, rr.Code)
+		}
+	})
+}
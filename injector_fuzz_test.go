@@ -0,0 +1,200 @@
+package fault
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestNewFuzzInjector tests NewFuzzInjector.
+func TestNewFuzzInjector(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		giveData    []byte
+		giveOptions []FuzzInjectorOption
+		wantOps     []fuzzOp
+		wantErr     error
+	}{
+		{
+			name:     "empty",
+			giveData: nil,
+			wantOps:  nil,
+		},
+		{
+			name:     "partial op ignored",
+			giveData: []byte{6, 1, 2, 3, 4},
+			giveOptions: []FuzzInjectorOption{
+				WithReporter(newTestReporter()),
+			},
+			wantOps: []fuzzOp{{kind: fuzzTruncate, a: 1, b: 2}},
+		},
+		{
+			name:     "max ops",
+			giveData: make([]byte, (maxFuzzOps+1)*fuzzOpSize),
+			wantOps:  make([]fuzzOp, maxFuzzOps),
+		},
+		{
+			name: "option error",
+			giveOptions: []FuzzInjectorOption{
+				withError(),
+			},
+			wantErr: errErrorOption,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			fi, err := NewFuzzInjector(tt.giveData, tt.giveOptions...)
+
+			assert.Equal(t, tt.wantErr, err)
+			if tt.wantErr != nil {
+				assert.Nil(t, fi)
+				return
+			}
+
+			assert.Equal(t, tt.wantOps, fi.ops)
+			assert.Equal(t, tt.giveData, fi.data)
+		})
+	}
+}
+
+// TestFuzzInjectorHandler tests FuzzInjector.Handler.
+func TestFuzzInjectorHandler(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		giveData   []byte
+		giveBody   string
+		wantCode   int
+		wantBody   string
+		wantHeader http.Header
+	}{
+		{
+			name:       "nothing",
+			giveBody:   "body",
+			wantCode:   http.StatusOK,
+			wantBody:   "body",
+			wantHeader: http.Header{"A": {"a"}, "B": {"b"}},
+		},
+		{
+			name:       "status",
+			giveData:   []byte{0, 0, 12},
+			giveBody:   "body",
+			wantCode:   http.StatusServiceUnavailable,
+			wantBody:   "body",
+			wantHeader: http.Header{"A": {"a"}, "B": {"b"}},
+		},
+		{
+			name:       "truncate",
+			giveData:   []byte{1, 0, 7},
+			giveBody:   "body",
+			wantCode:   http.StatusOK,
+			wantBody:   "bo",
+			wantHeader: http.Header{"A": {"a"}, "B": {"b"}},
+		},
+		{
+			name:       "flip",
+			giveData:   []byte{2, 5, 8},
+			giveBody:   "body",
+			wantCode:   http.StatusOK,
+			wantBody:   "bndy",
+			wantHeader: http.Header{"A": {"a"}, "B": {"b"}},
+		},
+		{
+			name:       "flip empty body",
+			giveData:   []byte{2, 5, 8},
+			giveBody:   "",
+			wantCode:   http.StatusOK,
+			wantBody:   "",
+			wantHeader: http.Header{"A": {"a"}, "B": {"b"}},
+		},
+		{
+			name:       "insert",
+			giveData:   []byte{3, 6, '!'},
+			giveBody:   "body",
+			wantCode:   http.StatusOK,
+			wantBody:   "b!ody",
+			wantHeader: http.Header{"A": {"a"}, "B": {"b"}},
+		},
+		{
+			name:       "drop header",
+			giveData:   []byte{4, 0, 3},
+			giveBody:   "body",
+			wantCode:   http.StatusOK,
+			wantBody:   "body",
+			wantHeader: http.Header{"A": {"a"}},
+		},
+		{
+			name:       "drop every header",
+			giveData:   []byte{4, 0, 0, 4, 0, 0, 4, 0, 0},
+			giveBody:   "body",
+			wantCode:   http.StatusOK,
+			wantBody:   "body",
+			wantHeader: http.Header{},
+		},
+		{
+			name:       "in order",
+			giveData:   []byte{3, 0, '!', 1, 0, 1},
+			giveBody:   "body",
+			wantCode:   http.StatusOK,
+			wantBody:   "!",
+			wantHeader: http.Header{"A": {"a"}, "B": {"b"}},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			fi, err := NewFuzzInjector(tt.giveData)
+			assert.NoError(t, err)
+
+			h := fi.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("A", "a")
+				w.Header().Set("B", "b")
+				_, _ = w.Write([]byte(tt.giveBody))
+			}))
+
+			rr := httptest.NewRecorder()
+			h.ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+
+			assert.Equal(t, tt.wantCode, rr.Code)
+			assert.Equal(t, tt.wantBody, rr.Body.String())
+			assert.Equal(t, tt.wantHeader, rr.Header())
+		})
+	}
+}
+
+// TestFuzzCorpus tests that FuzzCorpus has one input for each kind of corruption.
+func TestFuzzCorpus(t *testing.T) {
+	t.Parallel()
+
+	seen := make(map[fuzzOpKind]bool)
+	for _, data := range FuzzCorpus() {
+		fi, err := NewFuzzInjector(data)
+		assert.NoError(t, err)
+		assert.Len(t, fi.ops, 1)
+		seen[fi.ops[0].kind] = true
+	}
+
+	assert.Len(t, seen, int(numFuzzOpKinds))
+}
+
+// TestFuzzData tests FuzzData.
+func TestFuzzData(t *testing.T) {
+	t.Parallel()
+
+	data := FuzzData(1, 10)
+	assert.Len(t, data, 10*fuzzOpSize)
+	assert.Equal(t, data, FuzzData(1, 10))
+	assert.NotEqual(t, data, FuzzData(2, 10))
+}
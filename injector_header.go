@@ -0,0 +1,256 @@
+package fault
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/textproto"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrNoHeaderOps when a HeaderInjector has no headers to change.
+	ErrNoHeaderOps = errors.New("no header operations provided")
+	// ErrInvalidHeaderOp when a HeaderOp has an unknown HeaderAction or an oversize that is not
+	// positive.
+	ErrInvalidHeaderOp = errors.New("not a valid header operation")
+)
+
+// corsHeaders are the response headers of CORS.
+var corsHeaders = []string{ //nolint:gochecknoglobals
+	"Access-Control-Allow-Origin",
+	"Access-Control-Allow-Credentials",
+	"Access-Control-Allow-Methods",
+	"Access-Control-Allow-Headers",
+	"Access-Control-Expose-Headers",
+	"Access-Control-Max-Age",
+}
+
+// HeaderAction is what a HeaderOp does to a response header.
+type HeaderAction int
+
+const (
+	// HeaderSet replaces the values of the header with the Value of the HeaderOp.
+	HeaderSet HeaderAction = iota
+	// HeaderAdd adds the Value of the HeaderOp to the values of the header.
+	HeaderAdd
+	// HeaderRemove removes the header.
+	HeaderRemove
+	// HeaderOversize pads the header with 'x' until it is Size bytes long, or sets it to Size 'x'
+	// bytes if it is not set.
+	HeaderOversize
+)
+
+// HeaderOp is an operation on a response header.
+type HeaderOp struct {
+	// Action is what the HeaderOp does.
+	Action HeaderAction
+	// Value is the value HeaderSet and HeaderAdd write.
+	Value string
+	// Size is the length in bytes HeaderOversize pads the header to.
+	Size int
+}
+
+// SetHeader returns a HeaderOp that replaces the values of a header with v, such as a bogus
+// Retry-After.
+func SetHeader(v string) HeaderOp {
+	return HeaderOp{Action: HeaderSet, Value: v}
+}
+
+// AddHeader returns a HeaderOp that adds v to the values of a header, such as a second,
+// conflicting Content-Type.
+func AddHeader(v string) HeaderOp {
+	return HeaderOp{Action: HeaderAdd, Value: v}
+}
+
+// RemoveHeader returns a HeaderOp that removes a header, such as Content-Type.
+func RemoveHeader() HeaderOp {
+	return HeaderOp{Action: HeaderRemove}
+}
+
+// OversizeHeader returns a HeaderOp that pads a header to n bytes, to test the header size limits
+// of clients and proxies.
+func OversizeHeader(n int) HeaderOp {
+	return HeaderOp{Action: HeaderOversize, Size: n}
+}
+
+// String describes the HeaderOp, such as "set(soon)", "remove", or "oversize(65536)".
+func (o HeaderOp) String() string {
+	switch o.Action {
+	case HeaderSet:
+		return fmt.Sprintf("set(%s)", o.Value)
+	case HeaderAdd:
+		return fmt.Sprintf("add(%s)", o.Value)
+	case HeaderRemove:
+		return "remove"
+	case HeaderOversize:
+		return fmt.Sprintf("oversize(%d)", o.Size)
+	default:
+		return fmt.Sprintf("HeaderAction(%d)", int(o.Action))
+	}
+}
+
+// valid returns true if the HeaderOp can be applied.
+func (o HeaderOp) valid() bool {
+	switch o.Action {
+	case HeaderSet, HeaderAdd, HeaderRemove:
+		return true
+	case HeaderOversize:
+		return o.Size > 0
+	default:
+		return false
+	}
+}
+
+// apply applies the HeaderOp to the header key of h.
+func (o HeaderOp) apply(h http.Header, key string) {
+	switch o.Action {
+	case HeaderSet:
+		h[key] = []string{o.Value}
+	case HeaderAdd:
+		h[key] = append(h[key], o.Value)
+	case HeaderRemove:
+		delete(h, key)
+	case HeaderOversize:
+		v := strings.Join(h[key], ", ")
+		if len(v) < o.Size {
+			v += strings.Repeat("x", o.Size-len(v))
+		}
+		h[key] = []string{v}
+	}
+}
+
+// HeaderInjector runs the request and changes the headers of its response as they are written, to
+// test how browsers and SDKs handle servers that send missing, bogus, or oversized headers. The
+// body is not buffered, so responses still stream.
+type HeaderInjector struct {
+	ops      map[string]HeaderOp
+	reporter Reporter
+}
+
+// HeaderInjectorOption configures a HeaderInjector.
+type HeaderInjectorOption interface {
+	applyHeaderInjector(i *HeaderInjector) error
+}
+
+type withoutCORSOption struct{}
+
+func (o withoutCORSOption) applyHeaderInjector(i *HeaderInjector) error {
+	for _, key := range corsHeaders {
+		if _, ok := i.ops[key]; !ok {
+			i.ops[key] = RemoveHeader()
+		}
+	}
+	return nil
+}
+
+// WithoutCORS removes the CORS headers of responses, such as Access-Control-Allow-Origin, so
+// browsers block cross-origin reads of them. Headers that already have a HeaderOp keep it.
+func WithoutCORS() HeaderInjectorOption {
+	return withoutCORSOption{}
+}
+
+func (o reporterOption) applyHeaderInjector(i *HeaderInjector) error {
+	i.reporter = o.reporter
+	return nil
+}
+
+// NewHeaderInjector returns a HeaderInjector that applies ops to the response headers they are
+// keyed by, such as {"Content-Type": RemoveHeader(), "Retry-After": SetHeader("soon")}.
+func NewHeaderInjector(ops map[string]HeaderOp, opts ...HeaderInjectorOption) (*HeaderInjector, error) {
+	// set defaults
+	hi := &HeaderInjector{
+		ops:      make(map[string]HeaderOp, len(ops)),
+		reporter: NewNoopReporter(),
+	}
+	for k, op := range ops {
+		if !op.valid() {
+			return nil, fmt.Errorf("%w: %s: %s", ErrInvalidHeaderOp, k, op)
+		}
+		hi.ops[textproto.CanonicalMIMEHeaderKey(k)] = op
+	}
+
+	// apply options
+	for _, opt := range opts {
+		err := opt.applyHeaderInjector(hi)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// check options
+	if len(hi.ops) == 0 {
+		return nil, ErrNoHeaderOps
+	}
+
+	return hi, nil
+}
+
+// Handler changes the response headers just before they are written.
+func (i *HeaderInjector) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		go i.reporter.Report(i.String(), StateStarted)
+
+		hw := &headerWriter{ResponseWriter: w, mutate: i.mutate}
+		next.ServeHTTP(hw, r)
+		hw.finish()
+
+		go i.reporter.Report(i.String(), StateFinished)
+	})
+}
+
+// mutate applies the HeaderOps to h.
+func (i *HeaderInjector) mutate(h http.Header) {
+	for key, op := range i.ops {
+		op.apply(h, key)
+	}
+}
+
+// keys returns the headers the HeaderInjector changes, sorted.
+func (i *HeaderInjector) keys() []string {
+	keys := make([]string, 0, len(i.ops))
+	for k := range i.ops {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// Name returns "header".
+func (i *HeaderInjector) Name() string {
+	return "header"
+}
+
+// Describe returns each header the HeaderInjector changes and how, such as
+// "Content-Type: remove; Retry-After: set(soon)".
+func (i *HeaderInjector) Describe() map[string]string {
+	keys := i.keys()
+	ops := make([]string, 0, len(keys))
+	for _, k := range keys {
+		ops = append(ops, k+": "+i.ops[k].String())
+	}
+
+	return map[string]string{
+		"ops":   strings.Join(ops, "; "),
+		"count": strconv.Itoa(len(ops)),
+	}
+}
+
+// String returns a summary of the HeaderInjector with the headers it changes, such as
+// "header(Content-Type, Retry-After)".
+func (i *HeaderInjector) String() string {
+	return fmt.Sprintf("%s(%s)", i.Name(), strings.Join(i.keys(), ", "))
+}
+
+// Reporter returns the Reporter of the HeaderInjector.
+func (i *HeaderInjector) Reporter() Reporter {
+	return i.reporter
+}
+
+// SetReporter replaces the Reporter of the HeaderInjector.
+func (i *HeaderInjector) SetReporter(r Reporter) {
+	i.reporter = r
+}
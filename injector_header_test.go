@@ -0,0 +1,228 @@
+package fault
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestNewHeaderInjector tests NewHeaderInjector.
+func TestNewHeaderInjector(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		giveOps     map[string]HeaderOp
+		giveOptions []HeaderInjectorOption
+		want        *HeaderInjector
+		wantErr     error
+	}{
+		{
+			name:        "no options",
+			giveOps:     map[string]HeaderOp{"content-type": RemoveHeader()},
+			giveOptions: []HeaderInjectorOption{},
+			want: &HeaderInjector{
+				ops:      map[string]HeaderOp{"Content-Type": RemoveHeader()},
+				reporter: NewNoopReporter(),
+			},
+			wantErr: nil,
+		},
+		{
+			name: "options",
+			giveOps: map[string]HeaderOp{
+				"Retry-After":                 SetHeader("soon"),
+				"access-control-allow-origin": SetHeader("*"),
+			},
+			giveOptions: []HeaderInjectorOption{
+				WithoutCORS(),
+				WithReporter(newTestReporter()),
+			},
+			want: &HeaderInjector{
+				ops: map[string]HeaderOp{
+					"Retry-After":                      SetHeader("soon"),
+					"Access-Control-Allow-Origin":      SetHeader("*"),
+					"Access-Control-Allow-Credentials": RemoveHeader(),
+					"Access-Control-Allow-Methods":     RemoveHeader(),
+					"Access-Control-Allow-Headers":     RemoveHeader(),
+					"Access-Control-Expose-Headers":    RemoveHeader(),
+					"Access-Control-Max-Age":           RemoveHeader(),
+				},
+				reporter: newTestReporter(),
+			},
+			wantErr: nil,
+		},
+		{
+			name:        "no ops",
+			giveOps:     nil,
+			giveOptions: []HeaderInjectorOption{},
+			want:        nil,
+			wantErr:     ErrNoHeaderOps,
+		},
+		{
+			name:        "invalid action",
+			giveOps:     map[string]HeaderOp{"Content-Type": {Action: HeaderOversize + 1}},
+			giveOptions: []HeaderInjectorOption{},
+			want:        nil,
+			wantErr:     ErrInvalidHeaderOp,
+		},
+		{
+			name:        "invalid size",
+			giveOps:     map[string]HeaderOp{"Content-Type": OversizeHeader(0)},
+			giveOptions: []HeaderInjectorOption{},
+			want:        nil,
+			wantErr:     ErrInvalidHeaderOp,
+		},
+		{
+			name:    "option error",
+			giveOps: map[string]HeaderOp{"Content-Type": RemoveHeader()},
+			giveOptions: []HeaderInjectorOption{
+				withError(),
+			},
+			want:    nil,
+			wantErr: errErrorOption,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			hi, err := NewHeaderInjector(tt.giveOps, tt.giveOptions...)
+
+			assert.True(t, errors.Is(err, tt.wantErr), err)
+			assert.Equal(t, tt.want, hi)
+		})
+	}
+}
+
+// TestHeaderInjectorHandler tests that a HeaderInjector changes the response headers and leaves the
+// rest of the response alone.
+func TestHeaderInjectorHandler(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		giveOps     map[string]HeaderOp
+		giveOptions []HeaderInjectorOption
+		giveWrite   bool
+		wantHeader  http.Header
+	}{
+		{
+			name: "set, add, and remove",
+			giveOps: map[string]HeaderOp{
+				"Content-Type": RemoveHeader(),
+				"Retry-After":  SetHeader("soon"),
+				"Vary":         AddHeader("Cookie"),
+			},
+			giveWrite: true,
+			wantHeader: http.Header{
+				"Retry-After":                 {"soon"},
+				"Vary":                        {"Accept", "Cookie"},
+				"X-Request-Id":                {"1"},
+				"Access-Control-Allow-Origin": {"*"},
+				"Access-Control-Max-Age":      {"600"},
+			},
+		},
+		{
+			name:      "oversize",
+			giveOps:   map[string]HeaderOp{"Vary": OversizeHeader(16), "X-Large": OversizeHeader(4)},
+			giveWrite: true,
+			wantHeader: http.Header{
+				"Content-Type":                {"application/json"},
+				"Vary":                        {"Accept" + strings.Repeat("x", 10)},
+				"X-Large":                     {"xxxx"},
+				"X-Request-Id":                {"1"},
+				"Access-Control-Allow-Origin": {"*"},
+				"Access-Control-Max-Age":      {"600"},
+			},
+		},
+		{
+			name:      "oversize shorter than the value",
+			giveOps:   map[string]HeaderOp{"Content-Type": OversizeHeader(1)},
+			giveWrite: true,
+			wantHeader: http.Header{
+				"Content-Type":                {"application/json"},
+				"Vary":                        {"Accept"},
+				"X-Request-Id":                {"1"},
+				"Access-Control-Allow-Origin": {"*"},
+				"Access-Control-Max-Age":      {"600"},
+			},
+		},
+		{
+			name:        "without cors",
+			giveOptions: []HeaderInjectorOption{WithoutCORS()},
+			giveWrite:   true,
+			wantHeader: http.Header{
+				"Content-Type": {"application/json"},
+				"Vary":         {"Accept"},
+				"X-Request-Id": {"1"},
+			},
+		},
+		{
+			name:    "no write",
+			giveOps: map[string]HeaderOp{"Retry-After": SetHeader("-1")},
+			wantHeader: http.Header{
+				"Content-Type":                {"application/json"},
+				"Retry-After":                 {"-1"},
+				"Vary":                        {"Accept"},
+				"X-Request-Id":                {"1"},
+				"Access-Control-Allow-Origin": {"*"},
+				"Access-Control-Max-Age":      {"600"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			reporter := newTestStateReporter()
+			hi, err := NewHeaderInjector(tt.giveOps, append(tt.giveOptions, WithReporter(reporter))...)
+			assert.NoError(t, err)
+
+			h := hi.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.Header().Set("Vary", "Accept")
+				w.Header().Set("Access-Control-Allow-Origin", "*")
+				w.Header().Set("Access-Control-Max-Age", "600")
+				if tt.giveWrite {
+					_, _ = w.Write([]byte(`{}`))
+				}
+			}))
+
+			rr := httptest.NewRecorder()
+			rr.Header().Set("X-Request-Id", "1")
+			h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			assert.Equal(t, http.StatusOK, rr.Code)
+			assert.Equal(t, tt.wantHeader, rr.Header())
+			if tt.giveWrite {
+				assert.Equal(t, `{}`, rr.Body.String())
+			}
+
+			// the states are reported concurrently, so they may arrive in any order
+			states := map[InjectorState]int{}
+			for n := 0; n < 2; n++ {
+				states[<-reporter.states]++
+			}
+			assert.Equal(t, map[InjectorState]int{StateStarted: 1, StateFinished: 1}, states)
+		})
+	}
+}
+
+// TestHeaderOpString tests HeaderOp.String.
+func TestHeaderOpString(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "set(soon)", SetHeader("soon").String())
+	assert.Equal(t, "add(Cookie)", AddHeader("Cookie").String())
+	assert.Equal(t, "remove", RemoveHeader().String())
+	assert.Equal(t, "oversize(65536)", OversizeHeader(65536).String())
+	assert.Equal(t, "HeaderAction(7)", HeaderOp{Action: 7}.String())
+}
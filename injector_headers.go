@@ -0,0 +1,105 @@
+package fault
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// HeadersOnlyInjector runs the request and sends its complete and correct response headers,
+// including a Content-Length, and then aborts the response before any byte of the body. Clients
+// handle this differently from both a RejectInjector, which sends nothing, and a
+// PartialResponseInjector, which sends part of the body.
+type HeadersOnlyInjector struct {
+	reporter Reporter
+}
+
+// HeadersOnlyInjectorOption configures a HeadersOnlyInjector.
+type HeadersOnlyInjectorOption interface {
+	applyHeadersOnlyInjector(i *HeadersOnlyInjector) error
+}
+
+func (o reporterOption) applyHeadersOnlyInjector(i *HeadersOnlyInjector) error {
+	i.reporter = o.reporter
+	return nil
+}
+
+// NewHeadersOnlyInjector returns a HeadersOnlyInjector.
+func NewHeadersOnlyInjector(opts ...HeadersOnlyInjectorOption) (*HeadersOnlyInjector, error) {
+	// set defaults
+	hi := &HeadersOnlyInjector{
+		reporter: NewNoopReporter(),
+	}
+
+	// apply options
+	for _, opt := range opts {
+		err := opt.applyHeadersOnlyInjector(hi)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return hi, nil
+}
+
+// Handler buffers the response to learn its length, sends the status code and headers with a
+// Content-Length for the whole body, and then aborts the response.
+func (i *HeadersOnlyInjector) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		go i.reporter.Report(i.String(), StateStarted)
+
+		bw := newBufferedWriter(w)
+		next.ServeHTTP(bw, r)
+
+		if bodyAllowed(bw.code) {
+			bw.header.Set("Content-Length", strconv.Itoa(bw.body.Len()))
+		}
+		bw.sendHeader()
+		bw.release()
+
+		if f, ok := w.(http.Flusher); ok {
+			f.Flush()
+		}
+
+		// This is a specialized and documented way of sending an interrupted response to
+		// the client without printing the panic stack trace or erroring.
+		// https://golang.org/pkg/net/http/#Handler
+		panic(http.ErrAbortHandler)
+	})
+}
+
+// bodyAllowed returns true if a response with status code may have a body, and so a
+// Content-Length.
+func bodyAllowed(code int) bool {
+	return code >= http.StatusOK && code != http.StatusNoContent && code != http.StatusNotModified
+}
+
+// Reporter returns the Reporter of the HeadersOnlyInjector.
+func (i *HeadersOnlyInjector) Reporter() Reporter {
+	return i.reporter
+}
+
+// SetReporter replaces the Reporter of the HeadersOnlyInjector.
+func (i *HeadersOnlyInjector) SetReporter(r Reporter) {
+	i.reporter = r
+}
+
+// Name returns "headers_only".
+func (i *HeadersOnlyInjector) Name() string {
+	return "headers_only"
+}
+
+// Describe returns no parameters. The HeadersOnlyInjector is not configurable.
+func (i *HeadersOnlyInjector) Describe() map[string]string {
+	return map[string]string{}
+}
+
+// String returns "headers_only".
+func (i *HeadersOnlyInjector) String() string {
+	return i.Name()
+}
+
+// Destructive returns true. The request runs before its response is cut off, so the client cannot
+// tell that it was applied.
+func (i *HeadersOnlyInjector) Destructive() bool {
+	return true
+}
@@ -0,0 +1,169 @@
+package fault
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestNewHeadersOnlyInjector tests NewHeadersOnlyInjector.
+func TestNewHeadersOnlyInjector(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		giveOptions []HeadersOnlyInjectorOption
+		want        *HeadersOnlyInjector
+		wantErr     error
+	}{
+		{
+			name: "no options",
+			want: &HeadersOnlyInjector{
+				reporter: NewNoopReporter(),
+			},
+		},
+		{
+			name:        "custom reporter",
+			giveOptions: []HeadersOnlyInjectorOption{WithReporter(newTestReporter())},
+			want: &HeadersOnlyInjector{
+				reporter: newTestReporter(),
+			},
+		},
+		{
+			name:        "option error",
+			giveOptions: []HeadersOnlyInjectorOption{withError()},
+			wantErr:     errErrorOption,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			hi, err := NewHeadersOnlyInjector(tt.giveOptions...)
+
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.want, hi)
+		})
+	}
+}
+
+// TestHeadersOnlyInjectorHandler tests HeadersOnlyInjector.Handler.
+func TestHeadersOnlyInjectorHandler(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name              string
+		giveCode          int
+		giveContentLength string
+		wantCode          int
+		wantContentLength string
+	}{
+		{
+			name:              "implicit ok",
+			wantCode:          http.StatusOK,
+			wantContentLength: "8",
+		},
+		{
+			name:              "status",
+			giveCode:          http.StatusCreated,
+			wantCode:          http.StatusCreated,
+			wantContentLength: "8",
+		},
+		{
+			name:              "wrong content length",
+			giveCode:          http.StatusOK,
+			giveContentLength: "100",
+			wantCode:          http.StatusOK,
+			wantContentLength: "8",
+		},
+		{
+			name:     "no content",
+			giveCode: http.StatusNoContent,
+			wantCode: http.StatusNoContent,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			reporter := &testStateReporter{states: make(chan InjectorState, 1)}
+			hi, err := NewHeadersOnlyInjector(WithReporter(reporter))
+			assert.NoError(t, err)
+
+			h := hi.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("X-Handler", "true")
+				if tt.giveContentLength != "" {
+					w.Header().Set("Content-Length", tt.giveContentLength)
+				}
+				if tt.giveCode != 0 {
+					w.WriteHeader(tt.giveCode)
+				}
+				if tt.giveCode != http.StatusNoContent {
+					_, _ = w.Write([]byte("abcdefgh"))
+				}
+			}))
+
+			rr := httptest.NewRecorder()
+			assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+				h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+			})
+
+			assert.Equal(t, tt.wantCode, rr.Code)
+			assert.Equal(t, tt.wantContentLength, rr.Header().Get("Content-Length"))
+			assert.Equal(t, "true", rr.Header().Get("X-Handler"))
+			assert.Empty(t, rr.Body.String())
+			assert.True(t, rr.Flushed)
+			assert.Equal(t, StateStarted, <-reporter.states)
+		})
+	}
+}
+
+// TestHeadersOnlyInjectorServer tests that clients see headers and then an aborted body from a real
+// server.
+func TestHeadersOnlyInjectorServer(t *testing.T) {
+	t.Parallel()
+
+	hi, err := NewHeadersOnlyInjector()
+	assert.NoError(t, err)
+
+	f, err := NewFault(hi,
+		WithEnabled(true),
+		WithParticipation(1.0),
+	)
+	assert.NoError(t, err)
+
+	srv := httptest.NewServer(f.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("abcdefgh"))
+	})))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, int64(8), resp.ContentLength)
+
+	got, err := io.ReadAll(resp.Body)
+	assert.Error(t, err)
+	assert.Empty(t, got)
+}
+
+// TestHeadersOnlyInjectorReporter tests HeadersOnlyInjector.Reporter and SetReporter.
+func TestHeadersOnlyInjectorReporter(t *testing.T) {
+	t.Parallel()
+
+	hi, err := NewHeadersOnlyInjector()
+	assert.NoError(t, err)
+
+	reporter := newTestReporter()
+	hi.SetReporter(reporter)
+	assert.Equal(t, reporter, hi.Reporter())
+}
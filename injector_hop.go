@@ -0,0 +1,206 @@
+package fault
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"net/http/httputil"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// defaultHopByHopHeader returns the headers a HopByHopInjector leaks when none are set: every
+// hop-by-hop header that makes sense on a response, and a custom header that the Connection header
+// nominates as hop-by-hop.
+func defaultHopByHopHeader() http.Header {
+	return http.Header{
+		"Connection":       {"Keep-Alive, X-Go-Fault-Hop"},
+		"Keep-Alive":       {"timeout=5, max=1000"},
+		"Proxy-Connection": {"keep-alive"},
+		"Upgrade":          {"h2c"},
+		"X-Go-Fault-Hop":   {"leaked"},
+	}
+}
+
+// HopByHopInjector runs the request and adds hop-by-hop headers to its response, which a
+// well-behaved intermediary strips before forwarding, to test how proxies and clients handle
+// headers that should never have reached them.
+type HopByHopInjector struct {
+	header   http.Header
+	leakTE   bool
+	reporter Reporter
+}
+
+// HopByHopInjectorOption configures a HopByHopInjector.
+type HopByHopInjectorOption interface {
+	applyHopByHopInjector(i *HopByHopInjector) error
+}
+
+type hopByHopHeaderOption struct {
+	key   string
+	value string
+}
+
+func (o hopByHopHeaderOption) applyHopByHopInjector(i *HopByHopInjector) error {
+	if i.header == nil {
+		i.header = make(http.Header)
+	}
+	i.header.Add(o.key, o.value)
+	return nil
+}
+
+// WithHopByHopHeader adds a header to the response instead of the defaults. Pass it more than once
+// to add more headers. Default "Connection", "Keep-Alive", "Proxy-Connection", "Upgrade", and a
+// header nominated by "Connection".
+func WithHopByHopHeader(key, value string) HopByHopInjectorOption {
+	return hopByHopHeaderOption{key: key, value: value}
+}
+
+type leakTransferEncodingOption bool
+
+func (o leakTransferEncodingOption) applyHopByHopInjector(i *HopByHopInjector) error {
+	i.leakTE = bool(o)
+	return nil
+}
+
+// WithLeakTransferEncoding also sends "Transfer-Encoding: chunked" where it does not belong:
+// alongside a Content-Length, and on responses that have no body. net/http refuses to send such a
+// response, so the HopByHopInjector takes over the connection to write it, and closes the connection
+// afterwards. Responses on connections that cannot be taken over, such as HTTP/2, only get the
+// other headers.
+func WithLeakTransferEncoding() HopByHopInjectorOption {
+	return leakTransferEncodingOption(true)
+}
+
+func (o reporterOption) applyHopByHopInjector(i *HopByHopInjector) error {
+	i.reporter = o.reporter
+	return nil
+}
+
+// NewHopByHopInjector returns a HopByHopInjector.
+func NewHopByHopInjector(opts ...HopByHopInjectorOption) (*HopByHopInjector, error) {
+	// set defaults
+	hi := &HopByHopInjector{
+		reporter: NewNoopReporter(),
+	}
+
+	// apply options
+	for _, opt := range opts {
+		err := opt.applyHopByHopInjector(hi)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// check options
+	if hi.header == nil {
+		hi.header = defaultHopByHopHeader()
+	}
+
+	return hi, nil
+}
+
+// Handler adds the headers to the response before the request runs, so the handler may still
+// replace them. net/http replaces the Connection header when it closes the connection after the
+// response. With WithLeakTransferEncoding the response is buffered and written to the connection
+// directly.
+func (i *HopByHopInjector) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		go i.reporter.Report(i.String(), StateStarted)
+		defer func() { go i.reporter.Report(i.String(), StateFinished) }()
+
+		for k, v := range i.header {
+			w.Header()[k] = append([]string(nil), v...)
+		}
+
+		hj, ok := w.(http.Hijacker)
+		if !i.leakTE || !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		bw := newBufferedWriter(w)
+		defer bw.release()
+		next.ServeHTTP(bw, r)
+
+		conn, brw, err := hj.Hijack()
+		if err != nil {
+			bw.send()
+			return
+		}
+		defer conn.Close()
+
+		writeLeakedTransferEncoding(brw.Writer, bw)
+	})
+}
+
+// writeLeakedTransferEncoding writes the buffered response as HTTP/1.1 with both a Content-Length
+// for the body and "Transfer-Encoding: chunked", encoding the body in chunks. Clients that follow
+// the Transfer-Encoding read the body, and clients that follow the Content-Length read the start of
+// the chunked encoding instead.
+func writeLeakedTransferEncoding(w *bufio.Writer, bw *bufferedWriter) {
+	h := bw.header.Clone()
+	h.Set("Transfer-Encoding", "chunked")
+	h.Set("Connection", "close")
+	if bodyAllowed(bw.code) {
+		h.Set("Content-Length", strconv.Itoa(bw.body.Len()))
+	}
+
+	fmt.Fprintf(w, "HTTP/1.1 %03d %s\r\n", bw.code, http.StatusText(bw.code))
+	_ = h.Write(w)
+	_, _ = w.WriteString("\r\n")
+
+	if bodyAllowed(bw.code) {
+		cw := httputil.NewChunkedWriter(w)
+		_, _ = cw.Write(bw.body.Bytes())
+		_ = cw.Close()
+		_, _ = w.WriteString("\r\n")
+	}
+
+	_ = w.Flush()
+}
+
+// Reporter returns the Reporter of the HopByHopInjector.
+func (i *HopByHopInjector) Reporter() Reporter {
+	return i.reporter
+}
+
+// SetReporter replaces the Reporter of the HopByHopInjector.
+func (i *HopByHopInjector) SetReporter(r Reporter) {
+	i.reporter = r
+}
+
+// Name returns "hop_by_hop".
+func (i *HopByHopInjector) Name() string {
+	return "hop_by_hop"
+}
+
+// Describe returns the headers the HopByHopInjector adds and if it leaks Transfer-Encoding.
+func (i *HopByHopInjector) Describe() map[string]string {
+	return map[string]string{
+		"headers":           joinHeader(i.header),
+		"transfer_encoding": strconv.FormatBool(i.leakTE),
+	}
+}
+
+// String returns a summary of the HopByHopInjector with the names of the headers it adds, such as
+// "hop_by_hop(Keep-Alive, Upgrade)".
+func (i *HopByHopInjector) String() string {
+	keys := make([]string, 0, len(i.header)+1)
+	for k := range i.header {
+		keys = append(keys, k)
+	}
+	if i.leakTE {
+		keys = append(keys, "Transfer-Encoding")
+	}
+	sort.Strings(keys)
+
+	return fmt.Sprintf("%s(%s)", i.Name(), strings.Join(keys, ", "))
+}
+
+// Destructive returns true if the HopByHopInjector leaks Transfer-Encoding. The request runs, and
+// a client that cannot frame the response cannot tell that it was applied.
+func (i *HopByHopInjector) Destructive() bool {
+	return i.leakTE
+}
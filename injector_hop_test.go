@@ -0,0 +1,296 @@
+package fault
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testHijackFailer is a ResponseRecorder whose connection cannot be taken over.
+type testHijackFailer struct {
+	*httptest.ResponseRecorder
+}
+
+// Hijack fails.
+func (h testHijackFailer) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return nil, nil, errors.New("cannot hijack")
+}
+
+// TestNewHopByHopInjector tests NewHopByHopInjector.
+func TestNewHopByHopInjector(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		giveOptions []HopByHopInjectorOption
+		want        *HopByHopInjector
+		wantErr     error
+	}{
+		{
+			name: "no options",
+			want: &HopByHopInjector{
+				header: http.Header{
+					"Connection":       {"Keep-Alive, X-Go-Fault-Hop"},
+					"Keep-Alive":       {"timeout=5, max=1000"},
+					"Proxy-Connection": {"keep-alive"},
+					"Upgrade":          {"h2c"},
+					"X-Go-Fault-Hop":   {"leaked"},
+				},
+				reporter: NewNoopReporter(),
+			},
+		},
+		{
+			name: "options",
+			giveOptions: []HopByHopInjectorOption{
+				WithHopByHopHeader("upgrade", "websocket"),
+				WithHopByHopHeader("Connection", "Upgrade"),
+				WithLeakTransferEncoding(),
+				WithReporter(newTestReporter()),
+			},
+			want: &HopByHopInjector{
+				header: http.Header{
+					"Upgrade":    {"websocket"},
+					"Connection": {"Upgrade"},
+				},
+				leakTE:   true,
+				reporter: newTestReporter(),
+			},
+		},
+		{
+			name:        "option error",
+			giveOptions: []HopByHopInjectorOption{withError()},
+			wantErr:     errErrorOption,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			hi, err := NewHopByHopInjector(tt.giveOptions...)
+
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.want, hi)
+		})
+	}
+}
+
+// TestHopByHopInjectorHandler tests HopByHopInjector.Handler on ResponseWriters that cannot be
+// taken over.
+func TestHopByHopInjectorHandler(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		giveOptions []HopByHopInjectorOption
+		giveHijack  bool
+		wantHeader  http.Header
+	}{
+		{
+			name: "defaults",
+			wantHeader: http.Header{
+				"Connection":       {"Keep-Alive, X-Go-Fault-Hop"},
+				"Content-Type":     {"text/plain"},
+				"Keep-Alive":       {"timeout=5, max=1000"},
+				"Proxy-Connection": {"keep-alive"},
+				"Upgrade":          {"websocket"},
+				"X-Go-Fault-Hop":   {"leaked"},
+			},
+		},
+		{
+			name:        "leak transfer encoding without hijacker",
+			giveOptions: []HopByHopInjectorOption{WithHopByHopHeader("Keep-Alive", "max=1"), WithLeakTransferEncoding()},
+			wantHeader: http.Header{
+				"Content-Type": {"text/plain"},
+				"Keep-Alive":   {"max=1"},
+				"Upgrade":      {"websocket"},
+			},
+		},
+		{
+			name:        "leak transfer encoding hijack fails",
+			giveOptions: []HopByHopInjectorOption{WithHopByHopHeader("Keep-Alive", "max=1"), WithLeakTransferEncoding()},
+			giveHijack:  true,
+			wantHeader: http.Header{
+				"Content-Type": {"text/plain"},
+				"Keep-Alive":   {"max=1"},
+				"Upgrade":      {"websocket"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			reporter := &testStateReporter{states: make(chan InjectorState, 2)}
+			hi, err := NewHopByHopInjector(append(tt.giveOptions, WithReporter(reporter))...)
+			assert.NoError(t, err)
+
+			h := hi.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/plain")
+				w.Header().Set("Upgrade", "websocket")
+				w.WriteHeader(http.StatusCreated)
+				_, _ = w.Write([]byte("hello"))
+			}))
+
+			rr := httptest.NewRecorder()
+			var w http.ResponseWriter = rr
+			if tt.giveHijack {
+				w = testHijackFailer{rr}
+			}
+			h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			assert.Equal(t, http.StatusCreated, rr.Code)
+			assert.Equal(t, tt.wantHeader, rr.Header())
+			assert.Equal(t, "hello", rr.Body.String())
+
+			states := map[InjectorState]int{}
+			states[<-reporter.states]++
+			states[<-reporter.states]++
+			assert.Equal(t, map[InjectorState]int{StateStarted: 1, StateFinished: 1}, states)
+		})
+	}
+}
+
+// TestHopByHopInjectorServer tests the responses a real server sends with the HopByHopInjector.
+func TestHopByHopInjectorServer(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		giveOptions []HopByHopInjectorOption
+		giveCode    int
+		wantHeaders []string
+		wantBody    string
+	}{
+		{
+			name:     "headers",
+			giveCode: http.StatusOK,
+			wantHeaders: []string{
+				"Connection: Keep-Alive, X-Go-Fault-Hop",
+				"Keep-Alive: timeout=5, max=1000",
+				"Proxy-Connection: keep-alive",
+				"Upgrade: h2c",
+				"X-Go-Fault-Hop: leaked",
+				"Content-Length: 5",
+			},
+			wantBody: "hello",
+		},
+		{
+			name:        "leak transfer encoding",
+			giveOptions: []HopByHopInjectorOption{WithLeakTransferEncoding()},
+			giveCode:    http.StatusOK,
+			wantHeaders: []string{
+				"Connection: close",
+				"Content-Length: 5",
+				"Transfer-Encoding: chunked",
+				"Upgrade: h2c",
+			},
+			wantBody: "5\r\nhello\r\n0\r\n\r\n",
+		},
+		{
+			name:        "leak transfer encoding without body",
+			giveOptions: []HopByHopInjectorOption{WithLeakTransferEncoding()},
+			giveCode:    http.StatusNoContent,
+			wantHeaders: []string{
+				"Connection: close",
+				"Transfer-Encoding: chunked",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			hi, err := NewHopByHopInjector(tt.giveOptions...)
+			assert.NoError(t, err)
+
+			srv := httptest.NewServer(hi.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.giveCode)
+				_, _ = w.Write([]byte("hello"))
+			})))
+			defer srv.Close()
+
+			conn, err := net.Dial("tcp", srv.Listener.Addr().String())
+			assert.NoError(t, err)
+			defer conn.Close()
+
+			_, err = fmt.Fprint(conn, "GET / HTTP/1.1\r\nHost: example.com\r\n\r\n")
+			assert.NoError(t, err)
+
+			br := bufio.NewReader(conn)
+			status, err := br.ReadString('\n')
+			assert.NoError(t, err)
+			assert.Equal(t, fmt.Sprintf("HTTP/1.1 %d %s\r\n", tt.giveCode, http.StatusText(tt.giveCode)), status)
+
+			var headers []string
+			for {
+				line, err := br.ReadString('\n')
+				assert.NoError(t, err)
+				if line == "\r\n" {
+					break
+				}
+				headers = append(headers, strings.TrimSuffix(line, "\r\n"))
+			}
+			assert.Subset(t, headers, tt.wantHeaders)
+			if tt.giveCode == http.StatusNoContent {
+				assert.NotContains(t, headers, "Content-Length: 5")
+			}
+
+			if tt.wantBody != "" {
+				body := make([]byte, len(tt.wantBody))
+				_, err = io.ReadFull(br, body)
+				assert.NoError(t, err)
+				assert.Equal(t, tt.wantBody, string(body))
+			}
+		})
+	}
+}
+
+// TestHopByHopInjectorClient tests that a client that follows the Transfer-Encoding reads the body
+// of a response with a leaked Transfer-Encoding.
+func TestHopByHopInjectorClient(t *testing.T) {
+	t.Parallel()
+
+	hi, err := NewHopByHopInjector(WithLeakTransferEncoding())
+	assert.NoError(t, err)
+
+	srv := httptest.NewServer(hi.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("hello"))
+	})))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", string(body))
+	assert.Equal(t, []string{"chunked"}, resp.TransferEncoding)
+	assert.Equal(t, int64(-1), resp.ContentLength)
+}
+
+// TestHopByHopInjectorReporter tests HopByHopInjector.Reporter and SetReporter.
+func TestHopByHopInjectorReporter(t *testing.T) {
+	t.Parallel()
+
+	hi, err := NewHopByHopInjector()
+	assert.NoError(t, err)
+
+	reporter := newTestReporter()
+	hi.SetReporter(reporter)
+	assert.Equal(t, reporter, hi.Reporter())
+}
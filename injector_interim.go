@@ -0,0 +1,302 @@
+package fault
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrInvalidInterimFault when an unknown InterimFault is provided.
+	ErrInvalidInterimFault = errors.New("not a valid interim fault")
+	// ErrInvalidInterimCount when an InterimInjector is asked to send fewer than one interim
+	// response.
+	ErrInvalidInterimCount = errors.New("interim count must be > 0")
+)
+
+// defaultEarlyHint is the Link an InterimInjector sends in 103 Early Hints when none is set. It
+// points at a resource that does not exist.
+const defaultEarlyHint = "</go-fault-early-hint.css>; rel=preload; as=style"
+
+// defaultContinueDelay is how long an InterimInjector withholds 100 Continue when no delay is set.
+// It is longer than the 1s a Go http.Transport waits before sending the body anyway.
+const defaultContinueDelay = 5 * time.Second
+
+// InterimFault is a way of corrupting the 1xx interim responses of a request.
+type InterimFault int
+
+const (
+	// InterimEarlyHints sends 103 Early Hints with bogus Link headers before the response.
+	InterimEarlyHints InterimFault = iota
+	// InterimContinue sends unsolicited 100 Continue responses before the response.
+	InterimContinue
+	// InterimWithholdContinue delays the 100 Continue that a request sent with
+	// "Expect: 100-continue" is waiting for before it sends its body.
+	InterimWithholdContinue
+	// InterimRefuseContinue refuses to continue a request sent with "Expect: 100-continue",
+	// responding with a final status, by default 417 Expectation Failed, before the client sends its
+	// body.
+	InterimRefuseContinue
+)
+
+// String returns the name of the InterimFault.
+func (f InterimFault) String() string {
+	switch f {
+	case InterimEarlyHints:
+		return "early_hints"
+	case InterimContinue:
+		return "continue"
+	case InterimWithholdContinue:
+		return "withhold_continue"
+	case InterimRefuseContinue:
+		return "refuse_continue"
+	default:
+		return fmt.Sprintf("InterimFault(%d)", int(f))
+	}
+}
+
+// InterimInjector sends bogus or excessive 1xx interim responses, or withholds or refuses the 100
+// Continue a client is waiting for, to test how clients handle interim responses and how uploading
+// clients handle the Expect/Continue handshake. Clients often cap the number of interim responses
+// they accept, so pass WithInterimCount() to exceed the cap.
+type InterimInjector struct {
+	fault      InterimFault
+	count      int
+	links      []string
+	delay      time.Duration
+	refuseCode int
+	clock      Clock
+	reporter   Reporter
+}
+
+// InterimInjectorOption configures an InterimInjector.
+type InterimInjectorOption interface {
+	applyInterimInjector(i *InterimInjector) error
+}
+
+type interimCountOption int
+
+func (o interimCountOption) applyInterimInjector(i *InterimInjector) error {
+	if o < 1 {
+		return ErrInvalidInterimCount
+	}
+
+	i.count = int(o)
+
+	return nil
+}
+
+// WithInterimCount sets how many interim responses InterimEarlyHints and InterimContinue send.
+// Default 1.
+func WithInterimCount(n int) InterimInjectorOption {
+	return interimCountOption(n)
+}
+
+type earlyHintsOption []string
+
+func (o earlyHintsOption) applyInterimInjector(i *InterimInjector) error {
+	i.links = append([]string(nil), o...)
+	return nil
+}
+
+// WithEarlyHints sets the Link headers of the 103 Early Hints sent by InterimEarlyHints. Default a
+// preload of a stylesheet that does not exist.
+func WithEarlyHints(links ...string) InterimInjectorOption {
+	return earlyHintsOption(links)
+}
+
+type continueDelayOption time.Duration
+
+func (o continueDelayOption) applyInterimInjector(i *InterimInjector) error {
+	if o < 0 {
+		return ErrInvalidDelay
+	}
+
+	i.delay = time.Duration(o)
+
+	return nil
+}
+
+// WithContinueDelay sets how long InterimWithholdContinue withholds 100 Continue. Default 5s.
+func WithContinueDelay(d time.Duration) InterimInjectorOption {
+	return continueDelayOption(d)
+}
+
+type refuseStatusOption int
+
+func (o refuseStatusOption) applyInterimInjector(i *InterimInjector) error {
+	if o < http.StatusBadRequest || http.StatusText(int(o)) == "" {
+		return ErrInvalidHTTPCode
+	}
+
+	i.refuseCode = int(o)
+
+	return nil
+}
+
+// WithRefuseStatus sets the 4xx or 5xx status code InterimRefuseContinue responds with, such as 413
+// Request Entity Too Large. Default 417 Expectation Failed.
+func WithRefuseStatus(code int) InterimInjectorOption {
+	return refuseStatusOption(code)
+}
+
+func (o clockOption) applyInterimInjector(i *InterimInjector) error {
+	i.clock = o.clock
+	return nil
+}
+
+func (o reporterOption) applyInterimInjector(i *InterimInjector) error {
+	i.reporter = o.reporter
+	return nil
+}
+
+// NewInterimInjector returns an InterimInjector that applies an InterimFault to requests.
+func NewInterimInjector(f InterimFault, opts ...InterimInjectorOption) (*InterimInjector, error) {
+	if f < InterimEarlyHints || f > InterimRefuseContinue {
+		return nil, ErrInvalidInterimFault
+	}
+
+	// set defaults
+	ii := &InterimInjector{
+		fault:      f,
+		count:      1,
+		links:      []string{defaultEarlyHint},
+		delay:      defaultContinueDelay,
+		refuseCode: http.StatusExpectationFailed,
+		clock:      NewRealClock(),
+		reporter:   NewNoopReporter(),
+	}
+
+	// apply options
+	for _, opt := range opts {
+		err := opt.applyInterimInjector(ii)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return ii, nil
+}
+
+// Handler sends the interim responses and then runs the request. For InterimWithholdContinue it
+// delays the first read of the request body instead, which is when the server sends 100 Continue,
+// and for InterimRefuseContinue it responds without running the request or reading its body. Both
+// skip requests without "Expect: 100-continue".
+func (i *InterimInjector) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		expectsContinue := strings.EqualFold(r.Header.Get("Expect"), "100-continue")
+		if (i.fault == InterimWithholdContinue || i.fault == InterimRefuseContinue) && !expectsContinue {
+			go i.reporter.Report(i.String(), StateSkipped)
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		switch i.fault {
+		case InterimEarlyHints:
+			go i.reporter.Report(i.String(), StateStarted)
+			i.sendEarlyHints(w)
+		case InterimContinue:
+			go i.reporter.Report(i.String(), StateStarted)
+			for n := 0; n < i.count; n++ {
+				w.WriteHeader(http.StatusContinue)
+			}
+		case InterimWithholdContinue:
+			go i.reporter.Report(i.String(), StateStarted)
+			r = r.Clone(r.Context())
+			r.Body = &withheldBody{ReadCloser: r.Body, wait: func() { i.clock.Sleep(addLatency(r.Context(), i.delay)) }}
+		case InterimRefuseContinue:
+			go i.reporter.Report(i.String(), StateStarted)
+			http.Error(w, http.StatusText(i.refuseCode), i.refuseCode)
+			go i.reporter.Report(i.String(), StateFinished)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+		go i.reporter.Report(i.String(), StateFinished)
+	})
+}
+
+// sendEarlyHints sends the 103 Early Hints. The Link headers already set on w are kept for the
+// final response, since 1xx responses do not clear the headers.
+func (i *InterimInjector) sendEarlyHints(w http.ResponseWriter) {
+	h := w.Header()
+	prev := h.Values("Link")
+
+	h.Del("Link")
+	for _, l := range i.links {
+		h.Add("Link", l)
+	}
+	for n := 0; n < i.count; n++ {
+		w.WriteHeader(http.StatusEarlyHints)
+	}
+
+	h.Del("Link")
+	for _, l := range prev {
+		h.Add("Link", l)
+	}
+}
+
+// Reporter returns the Reporter of the InterimInjector.
+func (i *InterimInjector) Reporter() Reporter {
+	return i.reporter
+}
+
+// SetReporter replaces the Reporter of the InterimInjector.
+func (i *InterimInjector) SetReporter(r Reporter) {
+	i.reporter = r
+}
+
+// Name returns "interim".
+func (i *InterimInjector) Name() string {
+	return "interim"
+}
+
+// Describe returns the InterimFault and its parameters.
+func (i *InterimInjector) Describe() map[string]string {
+	switch i.fault {
+	case InterimEarlyHints:
+		return map[string]string{
+			"fault": i.fault.String(),
+			"count": strconv.Itoa(i.count),
+			"links": strings.Join(i.links, ", "),
+		}
+	case InterimContinue:
+		return map[string]string{"fault": i.fault.String(), "count": strconv.Itoa(i.count)}
+	case InterimRefuseContinue:
+		return map[string]string{"fault": i.fault.String(), "code": strconv.Itoa(i.refuseCode)}
+	default:
+		return map[string]string{"fault": i.fault.String(), "delay": i.delay.String()}
+	}
+}
+
+// String returns a summary of the InterimInjector, such as "interim(early_hints x1)",
+// "interim(continue x6)", "interim(withhold_continue 5s)", or "interim(refuse_continue 417)".
+func (i *InterimInjector) String() string {
+	switch i.fault {
+	case InterimWithholdContinue:
+		return fmt.Sprintf("%s(%s %s)", i.Name(), i.fault, i.delay)
+	case InterimRefuseContinue:
+		return fmt.Sprintf("%s(%s %d)", i.Name(), i.fault, i.refuseCode)
+	default:
+		return fmt.Sprintf("%s(%s x%d)", i.Name(), i.fault, i.count)
+	}
+}
+
+// withheldBody is a request body that waits before it is first read.
+type withheldBody struct {
+	io.ReadCloser
+	wait func()
+
+	once sync.Once
+}
+
+// Read waits on the first call and then reads from the body.
+func (b *withheldBody) Read(p []byte) (int, error) {
+	b.once.Do(b.wait)
+	return b.ReadCloser.Read(p)
+}
@@ -0,0 +1,421 @@
+package fault
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httptrace"
+	"net/textproto"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestNewInterimInjector tests NewInterimInjector.
+func TestNewInterimInjector(t *testing.T) {
+	t.Parallel()
+
+	clock := &testSleepClock{}
+
+	tests := []struct {
+		name        string
+		giveFault   InterimFault
+		giveOptions []InterimInjectorOption
+		want        *InterimInjector
+		wantErr     error
+	}{
+		{
+			name:      "defaults",
+			giveFault: InterimEarlyHints,
+			want: &InterimInjector{
+				fault:      InterimEarlyHints,
+				count:      1,
+				links:      []string{defaultEarlyHint},
+				delay:      defaultContinueDelay,
+				refuseCode: http.StatusExpectationFailed,
+				clock:      NewRealClock(),
+				reporter:   NewNoopReporter(),
+			},
+		},
+		{
+			name:      "options",
+			giveFault: InterimWithholdContinue,
+			giveOptions: []InterimInjectorOption{
+				WithInterimCount(3),
+				WithEarlyHints("</a.js>; rel=preload; as=script", "</b.css>; rel=preload; as=style"),
+				WithContinueDelay(time.Second),
+				WithRefuseStatus(http.StatusRequestEntityTooLarge),
+				WithClock(clock),
+				WithReporter(newTestReporter()),
+			},
+			want: &InterimInjector{
+				fault:      InterimWithholdContinue,
+				count:      3,
+				links:      []string{"</a.js>; rel=preload; as=script", "</b.css>; rel=preload; as=style"},
+				delay:      time.Second,
+				refuseCode: http.StatusRequestEntityTooLarge,
+				clock:      clock,
+				reporter:   newTestReporter(),
+			},
+		},
+		{
+			name:        "success refuse status",
+			giveFault:   InterimRefuseContinue,
+			giveOptions: []InterimInjectorOption{WithRefuseStatus(http.StatusOK)},
+			wantErr:     ErrInvalidHTTPCode,
+		},
+		{
+			name:        "unknown refuse status",
+			giveFault:   InterimRefuseContinue,
+			giveOptions: []InterimInjectorOption{WithRefuseStatus(499)},
+			wantErr:     ErrInvalidHTTPCode,
+		},
+		{
+			name:      "invalid fault",
+			giveFault: InterimFault(7),
+			wantErr:   ErrInvalidInterimFault,
+		},
+		{
+			name:        "invalid count",
+			giveFault:   InterimContinue,
+			giveOptions: []InterimInjectorOption{WithInterimCount(0)},
+			wantErr:     ErrInvalidInterimCount,
+		},
+		{
+			name:        "invalid delay",
+			giveFault:   InterimWithholdContinue,
+			giveOptions: []InterimInjectorOption{WithContinueDelay(-time.Second)},
+			wantErr:     ErrInvalidDelay,
+		},
+		{
+			name:        "option error",
+			giveFault:   InterimContinue,
+			giveOptions: []InterimInjectorOption{withError()},
+			wantErr:     errErrorOption,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ii, err := NewInterimInjector(tt.giveFault, tt.giveOptions...)
+
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.want, ii)
+		})
+	}
+}
+
+// repeatInts returns a slice of n copies of v.
+func repeatInts(v, n int) []int {
+	s := make([]int, n)
+	for idx := range s {
+		s[idx] = v
+	}
+
+	return s
+}
+
+// repeatLinks returns a slice of n copies of v.
+func repeatLinks(v []string, n int) [][]string {
+	s := make([][]string, n)
+	for idx := range s {
+		s[idx] = v
+	}
+
+	return s
+}
+
+// TestInterimInjectorHandler tests that clients of a real server see the interim responses of
+// InterimEarlyHints and InterimContinue.
+func TestInterimInjectorHandler(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		giveFault   InterimFault
+		giveOptions []InterimInjectorOption
+		wantCodes   []int
+		wantLinks   [][]string
+	}{
+		{
+			name:      "early hints",
+			giveFault: InterimEarlyHints,
+			wantCodes: []int{http.StatusEarlyHints},
+			wantLinks: [][]string{{defaultEarlyHint}},
+		},
+		{
+			name:        "several early hints",
+			giveFault:   InterimEarlyHints,
+			giveOptions: []InterimInjectorOption{WithInterimCount(2), WithEarlyHints("</a.js>", "</b.js>")},
+			wantCodes:   []int{http.StatusEarlyHints, http.StatusEarlyHints},
+			wantLinks:   [][]string{{"</a.js>", "</b.js>"}, {"</a.js>", "</b.js>"}},
+		},
+		{
+			name:      "continue",
+			giveFault: InterimContinue,
+			wantCodes: []int{http.StatusContinue},
+			wantLinks: [][]string{{"</app.css>; rel=preload"}},
+		},
+		{
+			name:        "excessive continue",
+			giveFault:   InterimContinue,
+			giveOptions: []InterimInjectorOption{WithInterimCount(10)},
+			wantCodes:   repeatInts(http.StatusContinue, 10),
+			wantLinks:   repeatLinks([]string{"</app.css>; rel=preload"}, 10),
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			reporter := &testStateReporter{states: make(chan InjectorState, 2)}
+			opts := append([]InterimInjectorOption{WithReporter(reporter)}, tt.giveOptions...)
+			ii, err := NewInterimInjector(tt.giveFault, opts...)
+			assert.NoError(t, err)
+
+			h := ii.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(testHandlerCode)
+				_, _ = w.Write([]byte(testHandlerBody))
+			}))
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				// a Link the application set before the injector runs
+				w.Header().Set("Link", "</app.css>; rel=preload")
+				h.ServeHTTP(w, r)
+			}))
+			defer srv.Close()
+
+			var (
+				mtx   sync.Mutex
+				codes []int
+				links [][]string
+			)
+			trace := &httptrace.ClientTrace{
+				Got1xxResponse: func(code int, header textproto.MIMEHeader) error {
+					mtx.Lock()
+					defer mtx.Unlock()
+
+					codes = append(codes, code)
+					links = append(links, header.Values("Link"))
+					return nil
+				},
+			}
+			ctx := httptrace.WithClientTrace(context.Background(), trace)
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+			assert.NoError(t, err)
+
+			resp, err := http.DefaultClient.Do(req)
+			assert.NoError(t, err)
+			defer resp.Body.Close()
+
+			body, err := io.ReadAll(resp.Body)
+			assert.NoError(t, err)
+
+			assert.Equal(t, testHandlerCode, resp.StatusCode)
+			assert.Equal(t, testHandlerBody, string(body))
+			assert.Equal(t, []string{"</app.css>; rel=preload"}, resp.Header.Values("Link"))
+
+			mtx.Lock()
+			defer mtx.Unlock()
+			assert.Equal(t, tt.wantCodes, codes)
+			assert.Equal(t, tt.wantLinks, links)
+
+			states := map[InjectorState]int{}
+			for n := 0; n < 2; n++ {
+				states[<-reporter.states]++
+			}
+			assert.Equal(t, map[InjectorState]int{StateStarted: 1, StateFinished: 1}, states)
+		})
+	}
+}
+
+// TestInterimInjectorWithholdContinue tests that InterimWithholdContinue delays the 100 Continue of
+// requests that expect it.
+func TestInterimInjectorWithholdContinue(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		giveExpect  string
+		wantSleeps  []time.Duration
+		wantReports int
+		wantStates  map[InjectorState]int
+	}{
+		{
+			name:        "expect continue",
+			giveExpect:  "100-continue",
+			wantSleeps:  []time.Duration{time.Second},
+			wantReports: 2,
+			wantStates:  map[InjectorState]int{StateStarted: 1, StateFinished: 1},
+		},
+		{
+			name:        "no expect",
+			wantReports: 1,
+			wantStates:  map[InjectorState]int{StateSkipped: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			clock := &testSleepClock{}
+			reporter := &testStateReporter{states: make(chan InjectorState, 2)}
+			ii, err := NewInterimInjector(InterimWithholdContinue,
+				WithContinueDelay(time.Second), WithClock(clock), WithReporter(reporter))
+			assert.NoError(t, err)
+
+			srv := httptest.NewServer(ii.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				// reading the body more than once only waits once
+				first := make([]byte, 3)
+				_, err := io.ReadFull(r.Body, first)
+				assert.NoError(t, err)
+				rest, err := io.ReadAll(r.Body)
+				assert.NoError(t, err)
+
+				w.WriteHeader(testHandlerCode)
+				_, _ = w.Write(append(first, rest...))
+			})))
+			defer srv.Close()
+
+			var continues atomic.Int32
+			trace := &httptrace.ClientTrace{
+				Got100Continue: func() { continues.Add(1) },
+			}
+			ctx := httptrace.WithClientTrace(context.Background(), trace)
+			req, err := http.NewRequestWithContext(ctx, http.MethodPost, srv.URL, bytes.NewBufferString(testHandlerBody))
+			assert.NoError(t, err)
+			if tt.giveExpect != "" {
+				req.Header.Set("Expect", tt.giveExpect)
+			}
+
+			client := &http.Client{Transport: &http.Transport{ExpectContinueTimeout: time.Minute}}
+			resp, err := client.Do(req)
+			assert.NoError(t, err)
+			defer resp.Body.Close()
+
+			body, err := io.ReadAll(resp.Body)
+			assert.NoError(t, err)
+
+			assert.Equal(t, testHandlerCode, resp.StatusCode)
+			assert.Equal(t, testHandlerBody, string(body))
+			assert.Equal(t, int32(len(tt.wantSleeps)), continues.Load())
+			assert.Equal(t, tt.wantSleeps, clock.sleeps)
+
+			states := map[InjectorState]int{}
+			for n := 0; n < tt.wantReports; n++ {
+				states[<-reporter.states]++
+			}
+			assert.Equal(t, tt.wantStates, states)
+		})
+	}
+}
+
+// TestInterimInjectorRefuseContinue tests that InterimRefuseContinue refuses requests that expect
+// 100 Continue before they send their body.
+func TestInterimInjectorRefuseContinue(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		giveExpect  string
+		wantCode    int
+		wantHandled bool
+		wantReports int
+		wantStates  map[InjectorState]int
+	}{
+		{
+			name:        "expect continue",
+			giveExpect:  "100-continue",
+			wantCode:    http.StatusRequestEntityTooLarge,
+			wantReports: 2,
+			wantStates:  map[InjectorState]int{StateStarted: 1, StateFinished: 1},
+		},
+		{
+			name:        "no expect",
+			wantCode:    testHandlerCode,
+			wantHandled: true,
+			wantReports: 1,
+			wantStates:  map[InjectorState]int{StateSkipped: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			reporter := &testStateReporter{states: make(chan InjectorState, 2)}
+			ii, err := NewInterimInjector(InterimRefuseContinue,
+				WithRefuseStatus(http.StatusRequestEntityTooLarge), WithReporter(reporter))
+			assert.NoError(t, err)
+
+			var handled atomic.Bool
+			srv := httptest.NewServer(ii.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				handled.Store(true)
+				_, _ = io.Copy(io.Discard, r.Body)
+				w.WriteHeader(testHandlerCode)
+			})))
+			defer srv.Close()
+
+			var continues atomic.Int32
+			trace := &httptrace.ClientTrace{
+				Got100Continue: func() { continues.Add(1) },
+			}
+			ctx := httptrace.WithClientTrace(context.Background(), trace)
+			req, err := http.NewRequestWithContext(ctx, http.MethodPut, srv.URL, bytes.NewBufferString(testHandlerBody))
+			assert.NoError(t, err)
+			if tt.giveExpect != "" {
+				req.Header.Set("Expect", tt.giveExpect)
+			}
+
+			client := &http.Client{Transport: &http.Transport{ExpectContinueTimeout: time.Minute}}
+			resp, err := client.Do(req)
+			assert.NoError(t, err)
+			defer resp.Body.Close()
+
+			assert.Equal(t, tt.wantCode, resp.StatusCode)
+			assert.Equal(t, tt.wantHandled, handled.Load())
+			assert.Equal(t, int32(0), continues.Load())
+
+			states := map[InjectorState]int{}
+			for n := 0; n < tt.wantReports; n++ {
+				states[<-reporter.states]++
+			}
+			assert.Equal(t, tt.wantStates, states)
+		})
+	}
+}
+
+// TestInterimInjectorReporter tests InterimInjector.Reporter and SetReporter.
+func TestInterimInjectorReporter(t *testing.T) {
+	t.Parallel()
+
+	ii, err := NewInterimInjector(InterimContinue)
+	assert.NoError(t, err)
+
+	reporter := newTestReporter()
+	ii.SetReporter(reporter)
+	assert.Equal(t, reporter, ii.Reporter())
+}
+
+// TestInterimFaultString tests InterimFault.String.
+func TestInterimFaultString(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "early_hints", InterimEarlyHints.String())
+	assert.Equal(t, "continue", InterimContinue.String())
+	assert.Equal(t, "withhold_continue", InterimWithholdContinue.String())
+	assert.Equal(t, "refuse_continue", InterimRefuseContinue.String())
+	assert.Equal(t, "InterimFault(7)", InterimFault(7).String())
+}
@@ -0,0 +1,158 @@
+package fault
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrInvalidCount when a negative count is provided.
+	ErrInvalidCount = errors.New("count must be >= 0")
+)
+
+// JSONTruncateInjector runs the request and then truncates a JSON array in the response after a
+// number of elements. The response stays syntactically valid JSON, simulating paginated or partial
+// results.
+type JSONTruncateInjector struct {
+	elements int
+	field    []string
+	reporter Reporter
+}
+
+// JSONTruncateInjectorOption configures a JSONTruncateInjector.
+type JSONTruncateInjectorOption interface {
+	applyJSONTruncateInjector(i *JSONTruncateInjector) error
+}
+
+type jsonFieldOption string
+
+func (o jsonFieldOption) applyJSONTruncateInjector(i *JSONTruncateInjector) error {
+	i.field = nil
+	if o != "" {
+		i.field = strings.Split(string(o), ".")
+	}
+	return nil
+}
+
+// WithJSONField sets the dot separated path of object keys leading to the array to truncate, such
+// as "data.items". Default "", the response body itself is the array.
+func WithJSONField(path string) JSONTruncateInjectorOption {
+	return jsonFieldOption(path)
+}
+
+func (o reporterOption) applyJSONTruncateInjector(i *JSONTruncateInjector) error {
+	i.reporter = o.reporter
+	return nil
+}
+
+// NewJSONTruncateInjector returns a JSONTruncateInjector that keeps the first n elements of the
+// array.
+func NewJSONTruncateInjector(n int, opts ...JSONTruncateInjectorOption) (*JSONTruncateInjector, error) {
+	if n < 0 {
+		return nil, ErrInvalidCount
+	}
+
+	// set defaults
+	ji := &JSONTruncateInjector{
+		elements: n,
+		reporter: NewNoopReporter(),
+	}
+
+	// apply options
+	for _, opt := range opts {
+		err := opt.applyJSONTruncateInjector(ji)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return ji, nil
+}
+
+// Handler buffers the response and truncates the array before sending it. Responses that are not
+// JSON, do not contain the array, or have no more than n elements are sent unchanged.
+func (i *JSONTruncateInjector) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		go i.reporter.Report(i.String(), StateStarted)
+
+		bw := newBufferedWriter(w)
+		next.ServeHTTP(bw, r)
+
+		if body, ok := truncateJSONArray(bw.body.Bytes(), i.field, i.elements); ok {
+			bw.setBody(body)
+		}
+		bw.send()
+		bw.release()
+
+		go i.reporter.Report(i.String(), StateFinished)
+	})
+}
+
+// truncateJSONArray keeps the first n elements of the array found by following path through data.
+// It returns false if the array is not found or was not truncated.
+func truncateJSONArray(data []byte, path []string, n int) ([]byte, bool) {
+	if len(path) == 0 {
+		var arr []json.RawMessage
+		if err := json.Unmarshal(data, &arr); err != nil || len(arr) <= n {
+			return nil, false
+		}
+
+		out, err := json.Marshal(arr[:n])
+		return out, err == nil
+	}
+
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return nil, false
+	}
+
+	child, ok := obj[path[0]]
+	if !ok {
+		return nil, false
+	}
+
+	truncated, ok := truncateJSONArray(child, path[1:], n)
+	if !ok {
+		return nil, false
+	}
+	obj[path[0]] = truncated
+
+	out, err := json.Marshal(obj)
+	return out, err == nil
+}
+
+// Name returns "json_truncate".
+func (i *JSONTruncateInjector) Name() string {
+	return "json_truncate"
+}
+
+// Describe returns the number of elements kept and the field of the array.
+func (i *JSONTruncateInjector) Describe() map[string]string {
+	return map[string]string{
+		"elements": strconv.Itoa(i.elements),
+		"field":    strings.Join(i.field, "."),
+	}
+}
+
+// String returns a summary of the JSONTruncateInjector, such as "json_truncate(data.items, 10)".
+func (i *JSONTruncateInjector) String() string {
+	if len(i.field) == 0 {
+		return fmt.Sprintf("%s(%d)", i.Name(), i.elements)
+	}
+
+	return fmt.Sprintf("%s(%s, %d)", i.Name(), strings.Join(i.field, "."), i.elements)
+}
+
+// Reporter returns the Reporter of the JSONTruncateInjector.
+func (i *JSONTruncateInjector) Reporter() Reporter {
+	return i.reporter
+}
+
+// SetReporter replaces the Reporter of the JSONTruncateInjector.
+func (i *JSONTruncateInjector) SetReporter(r Reporter) {
+	i.reporter = r
+}
@@ -0,0 +1,156 @@
+package fault
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestNewJSONTruncateInjector tests NewJSONTruncateInjector.
+func TestNewJSONTruncateInjector(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		giveElements int
+		giveOptions  []JSONTruncateInjectorOption
+		want         *JSONTruncateInjector
+		wantErr      error
+	}{
+		{
+			name:         "top level",
+			giveElements: 2,
+			want: &JSONTruncateInjector{
+				elements: 2,
+				reporter: NewNoopReporter(),
+			},
+		},
+		{
+			name:         "field",
+			giveElements: 2,
+			giveOptions: []JSONTruncateInjectorOption{
+				WithJSONField("data.items"),
+				WithReporter(newTestReporter()),
+			},
+			want: &JSONTruncateInjector{
+				elements: 2,
+				field:    []string{"data", "items"},
+				reporter: newTestReporter(),
+			},
+		},
+		{
+			name:         "negative",
+			giveElements: -1,
+			wantErr:      ErrInvalidCount,
+		},
+		{
+			name: "option error",
+			giveOptions: []JSONTruncateInjectorOption{
+				withError(),
+			},
+			wantErr: errErrorOption,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ji, err := NewJSONTruncateInjector(tt.giveElements, tt.giveOptions...)
+
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.want, ji)
+		})
+	}
+}
+
+// TestJSONTruncateInjectorHandler tests JSONTruncateInjector.Handler.
+func TestJSONTruncateInjectorHandler(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		giveElements int
+		giveField    string
+		giveBody     string
+		wantBody     string
+	}{
+		{
+			name:         "top level",
+			giveElements: 2,
+			giveBody:     `[1, 2, 3, {"a": [4]}]`,
+			wantBody:     `[1,2]`,
+		},
+		{
+			name:         "zero elements",
+			giveElements: 0,
+			giveBody:     `[1, 2, 3]`,
+			wantBody:     `[]`,
+		},
+		{
+			name:         "short array unchanged",
+			giveElements: 5,
+			giveBody:     `[1, 2, 3]`,
+			wantBody:     `[1, 2, 3]`,
+		},
+		{
+			name:         "nested field",
+			giveElements: 1,
+			giveField:    "data.items",
+			giveBody:     `{"next": "abc", "data": {"items": [{"id": 1}, {"id": 2}]}}`,
+			wantBody:     `{"data":{"items":[{"id":1}]},"next":"abc"}`,
+		},
+		{
+			name:         "missing field unchanged",
+			giveElements: 1,
+			giveField:    "items",
+			giveBody:     `{"other": [1, 2]}`,
+			wantBody:     `{"other": [1, 2]}`,
+		},
+		{
+			name:         "field not an array unchanged",
+			giveElements: 1,
+			giveField:    "data.items",
+			giveBody:     `{"data": {"items": 1}}`,
+			wantBody:     `{"data": {"items": 1}}`,
+		},
+		{
+			name:         "field not an object unchanged",
+			giveElements: 1,
+			giveField:    "items",
+			giveBody:     `[1, 2]`,
+			wantBody:     `[1, 2]`,
+		},
+		{
+			name:         "not json unchanged",
+			giveElements: 1,
+			giveBody:     `not json`,
+			wantBody:     `not json`,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ji, err := NewJSONTruncateInjector(tt.giveElements, WithJSONField(tt.giveField))
+			assert.NoError(t, err)
+
+			h := ji.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(tt.giveBody))
+			}))
+
+			rr := httptest.NewRecorder()
+			h.ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+
+			assert.Equal(t, http.StatusOK, rr.Code)
+			assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+			assert.Equal(t, tt.wantBody, rr.Body.String())
+		})
+	}
+}
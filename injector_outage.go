@@ -0,0 +1,367 @@
+package fault
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	// ErrNoPhases when an OutageInjector is given no Phases.
+	ErrNoPhases = errors.New("at least one phase is required")
+	// ErrInvalidPhaseDuration when a Phase of an OutageInjector does not last a positive duration.
+	ErrInvalidPhaseDuration = errors.New("phase duration must be > 0")
+)
+
+// Phase is one step of the timeline of an OutageInjector.
+type Phase struct {
+	// Name describes the Phase, such as "elevated latency".
+	Name string
+	// Duration is how long the Phase lasts.
+	Duration time.Duration
+	// Injector runs on the requests of the Phase that are selected by Participation. A nil Injector
+	// is a healthy Phase.
+	Injector Injector
+	// Participation is the share of requests (0.0 <= Participation <= 1.0) the Injector runs on.
+	// Lower it over a few Phases to model a gradual recovery.
+	Participation float32
+	// Expect are checked by OutageInjector.RunExpectations against what clients saw during the
+	// Phase.
+	Expect []Expectation
+}
+
+// OutageInjector models the lifecycle of a dependency outage by advancing through Phases on a
+// timeline, such as healthy, elevated latency, errors, total rejects, and a gradual recovery. The
+// timeline starts when its Fault is enabled, or on the first request, and requests pass through
+// once the last Phase is over unless WithRepeat(true) starts it over.
+type OutageInjector struct {
+	phases    []Phase
+	injectors []Injector
+
+	clock    Clock
+	reporter Reporter
+
+	randSeed int64
+	rand     *rand.Rand
+
+	// propagate determines if reporter is given to the Injectors.
+	propagate bool
+
+	// repeat determines if the timeline starts over after the last Phase.
+	repeat bool
+
+	// limits limit the Injectors.
+	limits compositeLimits
+
+	// mtx protects started, start, and rand.
+	mtx     sync.Mutex
+	started bool
+	// start is when the timeline started.
+	start time.Time
+
+	// injected is the number of requests an Injector of a Phase ran on.
+	injected int64
+}
+
+// OutageInjectorOption configures an OutageInjector.
+type OutageInjectorOption interface {
+	applyOutageInjector(i *OutageInjector) error
+}
+
+func (o clockOption) applyOutageInjector(i *OutageInjector) error {
+	i.clock = o.clock
+	return nil
+}
+
+func (o reporterOption) applyOutageInjector(i *OutageInjector) error {
+	i.reporter = o.reporter
+	return nil
+}
+
+func (o randSeedOption) applyOutageInjector(i *OutageInjector) error {
+	i.randSeed = int64(o)
+	return nil
+}
+
+type repeatOption bool
+
+func (o repeatOption) applyOutageInjector(i *OutageInjector) error {
+	i.repeat = bool(o)
+	return nil
+}
+
+// WithRepeat sets whether the timeline of an OutageInjector starts over from the first Phase after
+// the last Phase is over, to model recurring failures such as flapping or bursts. Default false.
+func WithRepeat(r bool) OutageInjectorOption {
+	return repeatOption(r)
+}
+
+func (o reporterPropagationOption) applyOutageInjector(i *OutageInjector) error {
+	i.propagate = bool(o)
+	return nil
+}
+
+func (o maxInjectorsOption) applyOutageInjector(i *OutageInjector) error {
+	return o.apply(&i.limits)
+}
+
+func (o maxDepthOption) applyOutageInjector(i *OutageInjector) error {
+	return o.apply(&i.limits)
+}
+
+// NewOutageInjector returns an OutageInjector that runs phases in order. It returns ErrNoPhases if
+// phases is empty, an InjectorError of ErrInvalidPhaseDuration, ErrInvalidPercent, ErrNilInjector,
+// or the error of an Injector that implements Validator naming the first invalid Phase, or
+// ErrTooManyInjectors or ErrTooDeep if the Injectors of phases exceed WithMaxInjectors() or
+// WithMaxDepth(). The same Injector may be used by more than one Phase.
+func NewOutageInjector(phases []Phase, opts ...OutageInjectorOption) (*OutageInjector, error) {
+	if len(phases) == 0 {
+		return nil, ErrNoPhases
+	}
+
+	var injectors []Injector
+	seen := make(map[Injector]bool, len(phases))
+	for idx, p := range phases {
+		if p.Duration <= 0 {
+			return nil, &InjectorError{Index: idx, Err: ErrInvalidPhaseDuration}
+		}
+		if p.Participation < 0 || p.Participation > 1 {
+			return nil, &InjectorError{Index: idx, Err: ErrInvalidPercent}
+		}
+		if p.Injector == nil || seen[p.Injector] {
+			continue
+		}
+
+		// like in validateInjectors, only pointers to values with a size are compared
+		v := reflect.ValueOf(p.Injector)
+		if v.Kind() == reflect.Ptr && v.IsNil() {
+			return nil, &InjectorError{Index: idx, Err: ErrNilInjector}
+		}
+		if err := validateInjector(p.Injector); err != nil {
+			return nil, &InjectorError{Index: idx, Err: err}
+		}
+		if v.Kind() == reflect.Ptr && v.Elem().Type().Size() > 0 {
+			seen[p.Injector] = true
+		}
+		injectors = append(injectors, p.Injector)
+	}
+
+	// set defaults
+	oi := &OutageInjector{
+		phases:    append([]Phase(nil), phases...),
+		injectors: injectors,
+		clock:     NewRealClock(),
+		reporter:  NewNoopReporter(),
+		randSeed:  defaultRandSeed,
+		limits:    defaultCompositeLimits(),
+	}
+
+	// apply options
+	for _, opt := range opts {
+		err := opt.applyOutageInjector(oi)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err := oi.limits.check(injectors)
+	if err != nil {
+		return nil, err
+	}
+
+	if oi.propagate {
+		PropagateReporter(injectors, oi.reporter, nil)
+	}
+
+	oi.rand = rand.New(rand.NewSource(oi.randSeed))
+
+	return oi, nil
+}
+
+// Handler runs the Injector of the current Phase on the requests it selects, and passes the others
+// through.
+func (i *OutageInjector) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var p Phase
+		if idx := i.current(true); idx >= 0 {
+			p = i.phases[idx]
+		}
+
+		i.mtx.Lock()
+		run := p.Injector != nil && i.rand.Float32() < p.Participation
+		i.mtx.Unlock()
+
+		if !run {
+			go i.reporter.Report(i.String(), StateSkipped)
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		atomic.AddInt64(&i.injected, 1)
+		go i.reporter.Report(i.String(), StateStarted)
+		recordInjector(p.Injector, next).ServeHTTP(w, r)
+	})
+}
+
+// Phase returns the index of the current Phase, or -1 if the timeline has not started or the last
+// Phase is over and does not repeat.
+func (i *OutageInjector) Phase() int {
+	return i.current(false)
+}
+
+// current returns the index of the current Phase, or -1 if the timeline has not started or the
+// last Phase is over. If start is true a timeline that has not started starts now.
+func (i *OutageInjector) current(start bool) int {
+	now := i.clock.Now()
+
+	i.mtx.Lock()
+	if start && !i.started {
+		i.started, i.start = true, now
+	}
+	started, elapsed := i.started, now.Sub(i.start)
+	i.mtx.Unlock()
+
+	if !started {
+		return -1
+	}
+
+	if i.repeat {
+		elapsed %= i.total()
+	}
+
+	for idx, p := range i.phases {
+		if elapsed < p.Duration {
+			return idx
+		}
+		elapsed -= p.Duration
+	}
+
+	return -1
+}
+
+// total returns how long the timeline lasts.
+func (i *OutageInjector) total() time.Duration {
+	var d time.Duration
+	for _, p := range i.phases {
+		d += p.Duration
+	}
+
+	return d
+}
+
+// Restart starts the timeline again from the first Phase.
+func (i *OutageInjector) Restart() {
+	i.mtx.Lock()
+	defer i.mtx.Unlock()
+
+	i.started, i.start = true, i.clock.Now()
+}
+
+// Reporter returns the Reporter of the OutageInjector.
+func (i *OutageInjector) Reporter() Reporter {
+	return i.reporter
+}
+
+// SetReporter replaces the Reporter of the OutageInjector. With WithReporterPropagation(true) it
+// also replaces the Reporter of each Injector that has no Reporter of its own.
+func (i *OutageInjector) SetReporter(r Reporter) {
+	if i.propagate {
+		PropagateReporter(i.injectors, r, i.reporter)
+	}
+
+	i.reporter = r
+}
+
+// Name returns "outage".
+func (i *OutageInjector) Name() string {
+	return "outage"
+}
+
+// Describe returns the Phases, whether they repeat, and the random seed.
+func (i *OutageInjector) Describe() map[string]string {
+	return map[string]string{
+		"phases": i.phaseStrings(),
+		"repeat": strconv.FormatBool(i.repeat),
+		"seed":   strconv.FormatInt(i.randSeed, 10),
+	}
+}
+
+// Seed returns the seed of the OutageInjector's random number generator.
+func (i *OutageInjector) Seed() int64 {
+	return i.randSeed
+}
+
+// String returns a summary of the OutageInjector, such as
+// "outage(healthy 1m0s, slow(2s) 5m0s 50%, reject 5m0s)".
+func (i *OutageInjector) String() string {
+	return fmt.Sprintf("%s(%s)", i.Name(), i.phaseStrings())
+}
+
+// phaseStrings returns a summary of each Phase separated by commas. Phases are summarized by their
+// Name, if any, their Injector, or "healthy", their Duration, and their Participation if it is not
+// 100%.
+func (i *OutageInjector) phaseStrings() string {
+	strs := make([]string, 0, len(i.phases))
+	for _, p := range i.phases {
+		s := "healthy"
+		if p.Injector != nil {
+			s = InjectorString(p.Injector)
+		}
+		if p.Name != "" {
+			s = p.Name + ": " + s
+		}
+		s += " " + p.Duration.String()
+		if p.Injector != nil && p.Participation < 1 {
+			s += " " + strconv.FormatFloat(float64(p.Participation)*100, 'g', -1, 32) + "%"
+		}
+		strs = append(strs, s)
+	}
+
+	return strings.Join(strs, ", ")
+}
+
+// OnEnable starts the timeline from the first Phase and runs the OnEnable hook of each Injector.
+func (i *OutageInjector) OnEnable() {
+	i.Restart()
+
+	for _, c := range i.injectors {
+		runEnableHook(c)
+	}
+}
+
+// OnDisable runs the OnDisable hook of each Injector.
+func (i *OutageInjector) OnDisable() {
+	for _, c := range i.injectors {
+		runDisableHook(c)
+	}
+}
+
+// OnConfigChange runs the OnConfigChange hook of each Injector.
+func (i *OutageInjector) OnConfigChange() {
+	for _, c := range i.injectors {
+		runConfigChangeHook(c)
+	}
+}
+
+// Destructive returns true if any of the Injectors is destructive.
+func (i *OutageInjector) Destructive() bool {
+	for _, c := range i.injectors {
+		if IsDestructive(c) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// children returns the Injectors.
+func (i *OutageInjector) children() []Injector {
+	return i.injectors
+}
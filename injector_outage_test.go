@@ -0,0 +1,260 @@
+package fault
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/github/go-fault/faulttest"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestNewOutageInjector tests NewOutageInjector.
+func TestNewOutageInjector(t *testing.T) {
+	t.Parallel()
+
+	ei, err := NewErrorInjector(http.StatusInternalServerError)
+	assert.NoError(t, err)
+	ri, err := NewRejectInjector()
+	assert.NoError(t, err)
+	noop1, noop2 := newTestInjectorNoop(), newTestInjectorNoop()
+	var nilInjector *ErrorInjector
+
+	tests := []struct {
+		name          string
+		givePhases    []Phase
+		giveOptions   []OutageInjectorOption
+		wantInjectors []Injector
+		wantSeed      int64
+		wantErr       error
+	}{
+		{
+			name:       "no phases",
+			givePhases: nil,
+			wantErr:    ErrNoPhases,
+		},
+		{
+			name: "phases",
+			givePhases: []Phase{
+				{Duration: time.Minute},
+				{Duration: time.Minute, Injector: ei, Participation: 1.0},
+				{Duration: time.Minute, Injector: ri, Participation: 1.0},
+				{Duration: time.Minute, Injector: ei, Participation: 0.5},
+				{Duration: time.Minute, Injector: noop1, Participation: 0.5},
+				{Duration: time.Minute, Injector: noop2, Participation: 0.5},
+			},
+			giveOptions:   []OutageInjectorOption{WithRandSeed(42)},
+			wantInjectors: []Injector{ei, ri, noop1, noop2},
+			wantSeed:      42,
+		},
+		{
+			name: "invalid duration",
+			givePhases: []Phase{
+				{Duration: time.Minute},
+				{Duration: 0, Injector: ei},
+			},
+			wantErr: &InjectorError{Index: 1, Err: ErrInvalidPhaseDuration},
+		},
+		{
+			name: "invalid participation",
+			givePhases: []Phase{
+				{Duration: time.Minute, Injector: ei, Participation: 1.5},
+			},
+			wantErr: &InjectorError{Index: 0, Err: ErrInvalidPercent},
+		},
+		{
+			name: "nil pointer injector",
+			givePhases: []Phase{
+				{Duration: time.Minute, Injector: nilInjector, Participation: 1.0},
+			},
+			wantErr: &InjectorError{Index: 0, Err: ErrNilInjector},
+		},
+		{
+			name: "too many injectors",
+			givePhases: []Phase{
+				{Duration: time.Minute, Injector: ei, Participation: 1.0},
+				{Duration: time.Minute, Injector: ri, Participation: 1.0},
+			},
+			giveOptions: []OutageInjectorOption{WithMaxInjectors(1)},
+			wantErr:     ErrTooManyInjectors,
+		},
+		{
+			name: "invalid depth",
+			givePhases: []Phase{
+				{Duration: time.Minute},
+			},
+			giveOptions: []OutageInjectorOption{WithMaxDepth(0)},
+			wantErr:     ErrInvalidLimit,
+		},
+		{
+			name: "option error",
+			givePhases: []Phase{
+				{Duration: time.Minute},
+			},
+			giveOptions: []OutageInjectorOption{withError()},
+			wantErr:     errErrorOption,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			oi, err := NewOutageInjector(tt.givePhases, tt.giveOptions...)
+
+			assert.Equal(t, tt.wantErr, err)
+			if tt.wantErr != nil {
+				assert.Nil(t, oi)
+				return
+			}
+
+			assert.Equal(t, tt.givePhases, oi.phases)
+			assert.Equal(t, tt.wantInjectors, oi.children())
+			assert.Equal(t, tt.wantSeed, oi.Seed())
+			assert.Equal(t, -1, oi.Phase())
+		})
+	}
+}
+
+// TestOutageInjectorHandler tests that OutageInjector.Handler advances through its Phases.
+func TestOutageInjectorHandler(t *testing.T) {
+	t.Parallel()
+
+	clock := faulttest.NewClock(time.Time{})
+	reporter := &testStateReporter{states: make(chan InjectorState, 1024)}
+
+	slow, err := NewSlowInjector(time.Second, WithSlowFunc(func(time.Duration) {}))
+	assert.NoError(t, err)
+	ei, err := NewErrorInjector(http.StatusServiceUnavailable)
+	assert.NoError(t, err)
+
+	oi, err := NewOutageInjector([]Phase{
+		{Name: "healthy", Duration: time.Minute},
+		{Name: "elevated latency", Duration: time.Minute, Injector: slow, Participation: 1.0},
+		{Name: "errors", Duration: time.Minute, Injector: ei, Participation: 1.0},
+		{Name: "recovery", Duration: time.Minute, Injector: ei, Participation: 0.5},
+	}, WithClock(clock), WithReporter(reporter))
+	assert.NoError(t, err)
+
+	h := oi.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(testHandlerCode)
+	}))
+	serve := func() int {
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+		return rr.Code
+	}
+
+	// the first request starts the timeline
+	assert.Equal(t, -1, oi.Phase())
+	assert.Equal(t, testHandlerCode, serve())
+	assert.Equal(t, 0, oi.Phase())
+	assert.Equal(t, StateSkipped, <-reporter.states)
+
+	clock.Advance(time.Minute)
+	assert.Equal(t, 1, oi.Phase())
+	assert.Equal(t, testHandlerCode, serve())
+	assert.Equal(t, StateStarted, <-reporter.states)
+
+	clock.Advance(time.Minute)
+	assert.Equal(t, 2, oi.Phase())
+	assert.Equal(t, http.StatusServiceUnavailable, serve())
+	assert.Equal(t, StateStarted, <-reporter.states)
+
+	// the recovery fails only some requests
+	clock.Advance(time.Minute)
+	assert.Equal(t, 3, oi.Phase())
+	codes := map[int]int{}
+	for n := 0; n < 100; n++ {
+		codes[serve()]++
+		<-reporter.states
+	}
+	assert.Greater(t, codes[testHandlerCode], 0)
+	assert.Greater(t, codes[http.StatusServiceUnavailable], 0)
+
+	// requests pass through once the outage is over
+	clock.Advance(time.Minute)
+	assert.Equal(t, -1, oi.Phase())
+	assert.Equal(t, testHandlerCode, serve())
+	assert.Equal(t, StateSkipped, <-reporter.states)
+
+	oi.Restart()
+	assert.Equal(t, 0, oi.Phase())
+}
+
+// TestOutageInjectorLifecycle tests that enabling the Fault of an OutageInjector restarts its
+// timeline and that its hooks reach its Injectors.
+func TestOutageInjectorLifecycle(t *testing.T) {
+	t.Parallel()
+
+	clock := faulttest.NewClock(time.Time{})
+	hooks := newTestInjectorHooks()
+	ri, err := NewRejectInjector()
+	assert.NoError(t, err)
+
+	oi, err := NewOutageInjector([]Phase{
+		{Duration: time.Minute, Injector: hooks, Participation: 1.0},
+		{Duration: time.Minute, Injector: ri, Participation: 1.0},
+	}, WithClock(clock))
+	assert.NoError(t, err)
+	assert.True(t, oi.Destructive())
+
+	f, err := NewFault(oi, WithEnabled(true))
+	assert.NoError(t, err)
+	assert.Equal(t, 0, oi.Phase())
+
+	clock.Advance(time.Minute)
+	assert.Equal(t, 1, oi.Phase())
+
+	f.SetEnabled(false)
+	assert.NoError(t, f.SetParticipation(0.5))
+	f.SetEnabled(true)
+	assert.Equal(t, 0, oi.Phase())
+
+	assert.Equal(t, 2, hooks.enables)
+	assert.Equal(t, 1, hooks.disables)
+	assert.Equal(t, 1, hooks.configChanges)
+
+	healthy, err := NewOutageInjector([]Phase{{Duration: time.Minute, Injector: hooks}})
+	assert.NoError(t, err)
+	assert.False(t, healthy.Destructive())
+}
+
+// TestOutageInjectorReporterPropagation tests that an OutageInjector gives its Reporter to its
+// Injectors.
+func TestOutageInjectorReporterPropagation(t *testing.T) {
+	t.Parallel()
+
+	first, second := newTestStateReporter(), newTestStateReporter()
+	ei, err := NewErrorInjector(http.StatusInternalServerError)
+	assert.NoError(t, err)
+
+	oi, err := NewOutageInjector([]Phase{{Duration: time.Minute, Injector: ei, Participation: 1.0}},
+		WithReporter(first), WithReporterPropagation(true))
+	assert.NoError(t, err)
+	assert.Equal(t, first, ei.Reporter())
+
+	oi.SetReporter(second)
+	assert.Equal(t, second, oi.Reporter())
+	assert.Equal(t, second, ei.Reporter())
+}
+
+// TestOutageInjectorRepeat tests that the timeline of an OutageInjector WithRepeat(true) starts over.
+func TestOutageInjectorRepeat(t *testing.T) {
+	t.Parallel()
+
+	clock := faulttest.NewClock(time.Time{})
+	oi, err := NewOutageInjector([]Phase{
+		{Duration: time.Minute},
+		{Duration: 30 * time.Second},
+	}, WithClock(clock), WithRepeat(true))
+	assert.NoError(t, err)
+
+	oi.Restart()
+	for _, want := range []int{0, 0, 1, 0, 0, 1} {
+		assert.Equal(t, want, oi.Phase())
+		clock.Advance(30 * time.Second)
+	}
+}
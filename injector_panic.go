@@ -0,0 +1,158 @@
+package fault
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+var (
+	// ErrInjectedPanic is the default value a PanicInjector panics with.
+	ErrInjectedPanic = errors.New("go-fault: injected panic")
+	// ErrInvalidPanicValue when a PanicInjector is set to panic with nil or http.ErrAbortHandler.
+	ErrInvalidPanicValue = errors.New("panic value must not be nil or http.ErrAbortHandler")
+)
+
+// PanicInjector panics in the handler, like a bug in the handler would. Unlike a RejectInjector,
+// which panics with http.ErrAbortHandler to quietly drop the connection, it panics with a value
+// that recovery middleware, the server's panic logging, and alerting treat as a real crash.
+type PanicInjector struct {
+	value    interface{}
+	code     int
+	body     []byte
+	reporter Reporter
+}
+
+// PanicInjectorOption configures a PanicInjector.
+type PanicInjectorOption interface {
+	applyPanicInjector(i *PanicInjector) error
+}
+
+type panicValueOption struct {
+	value interface{}
+}
+
+func (o panicValueOption) applyPanicInjector(i *PanicInjector) error {
+	if o.value == nil || o.value == http.ErrAbortHandler {
+		return ErrInvalidPanicValue
+	}
+
+	i.value = o.value
+	return nil
+}
+
+// WithPanicValue sets the value the PanicInjector panics with. It cannot be nil or
+// http.ErrAbortHandler. Default ErrInjectedPanic.
+func WithPanicValue(v interface{}) PanicInjectorOption {
+	return panicValueOption{value: v}
+}
+
+type panicAfterWriteOption struct {
+	code int
+	body []byte
+}
+
+func (o panicAfterWriteOption) applyPanicInjector(i *PanicInjector) error {
+	if http.StatusText(o.code) == "" {
+		return ErrInvalidHTTPCode
+	}
+
+	i.code = o.code
+	i.body = o.body
+	return nil
+}
+
+// WithPanicAfterWrite makes the PanicInjector write the status code and body, and flush them to the
+// client, before it panics. Recovery middleware can then no longer send a 500, and the server drops
+// the connection mid-response, as when a handler panics while streaming its response.
+func WithPanicAfterWrite(code int, body []byte) PanicInjectorOption {
+	return panicAfterWriteOption{code: code, body: body}
+}
+
+func (o reporterOption) applyPanicInjector(i *PanicInjector) error {
+	i.reporter = o.reporter
+	return nil
+}
+
+// NewPanicInjector returns a PanicInjector.
+func NewPanicInjector(opts ...PanicInjectorOption) (*PanicInjector, error) {
+	// set defaults
+	pi := &PanicInjector{
+		value:    ErrInjectedPanic,
+		reporter: NewNoopReporter(),
+	}
+
+	// apply options
+	for _, opt := range opts {
+		err := opt.applyPanicInjector(pi)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return pi, nil
+}
+
+// Handler panics without running the request, after writing the partial response set by
+// WithPanicAfterWrite if any.
+func (i *PanicInjector) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		go i.reporter.Report(i.String(), StateStarted)
+
+		if i.code != 0 {
+			w.WriteHeader(i.code)
+			_, _ = w.Write(i.body)
+			if f, ok := w.(http.Flusher); ok {
+				f.Flush()
+			}
+		}
+
+		panic(i.value)
+	})
+}
+
+// Reporter returns the Reporter of the PanicInjector.
+func (i *PanicInjector) Reporter() Reporter {
+	return i.reporter
+}
+
+// SetReporter replaces the Reporter of the PanicInjector.
+func (i *PanicInjector) SetReporter(r Reporter) {
+	i.reporter = r
+}
+
+// Name returns "panic".
+func (i *PanicInjector) Name() string {
+	return "panic"
+}
+
+// Describe returns the value the PanicInjector panics with, and the status code and body it writes
+// first if any.
+func (i *PanicInjector) Describe() map[string]string {
+	d := map[string]string{
+		"value": fmt.Sprint(i.value),
+	}
+	if i.code != 0 {
+		d["code"] = strconv.Itoa(i.code)
+		d["body"] = string(i.body)
+	}
+
+	return d
+}
+
+// String returns "panic", followed by the status code it writes first if any, such as
+// "panic(after 200)".
+func (i *PanicInjector) String() string {
+	if i.code == 0 {
+		return i.Name()
+	}
+
+	return fmt.Sprintf("%s(after %d)", i.Name(), i.code)
+}
+
+// Destructive returns true. A request that panics gets no response, or a broken one, so the client
+// cannot tell if it was applied.
+func (i *PanicInjector) Destructive() bool {
+	return true
+}
@@ -0,0 +1,256 @@
+package fault
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestNewPanicInjector tests NewPanicInjector.
+func TestNewPanicInjector(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		giveOptions []PanicInjectorOption
+		want        *PanicInjector
+		wantErr     error
+	}{
+		{
+			name:        "no options",
+			giveOptions: []PanicInjectorOption{},
+			want: &PanicInjector{
+				value:    ErrInjectedPanic,
+				reporter: NewNoopReporter(),
+			},
+			wantErr: nil,
+		},
+		{
+			name: "custom reporter",
+			giveOptions: []PanicInjectorOption{
+				WithReporter(newTestReporter()),
+			},
+			want: &PanicInjector{
+				value:    ErrInjectedPanic,
+				reporter: newTestReporter(),
+			},
+			wantErr: nil,
+		},
+		{
+			name: "panic value",
+			giveOptions: []PanicInjectorOption{
+				WithPanicValue("boom"),
+			},
+			want: &PanicInjector{
+				value:    "boom",
+				reporter: NewNoopReporter(),
+			},
+			wantErr: nil,
+		},
+		{
+			name: "nil panic value",
+			giveOptions: []PanicInjectorOption{
+				WithPanicValue(nil),
+			},
+			want:    nil,
+			wantErr: ErrInvalidPanicValue,
+		},
+		{
+			name: "abort handler panic value",
+			giveOptions: []PanicInjectorOption{
+				WithPanicValue(http.ErrAbortHandler),
+			},
+			want:    nil,
+			wantErr: ErrInvalidPanicValue,
+		},
+		{
+			name: "panic after write",
+			giveOptions: []PanicInjectorOption{
+				WithPanicAfterWrite(http.StatusOK, []byte("partial")),
+			},
+			want: &PanicInjector{
+				value:    ErrInjectedPanic,
+				code:     http.StatusOK,
+				body:     []byte("partial"),
+				reporter: NewNoopReporter(),
+			},
+			wantErr: nil,
+		},
+		{
+			name: "invalid code",
+			giveOptions: []PanicInjectorOption{
+				WithPanicAfterWrite(0, nil),
+			},
+			want:    nil,
+			wantErr: ErrInvalidHTTPCode,
+		},
+		{
+			name: "option error",
+			giveOptions: []PanicInjectorOption{
+				withError(),
+			},
+			want:    nil,
+			wantErr: errErrorOption,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			pi, err := NewPanicInjector(tt.giveOptions...)
+
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.want, pi)
+		})
+	}
+}
+
+// TestPanicInjectorHandler tests that PanicInjector.Handler panics with its value without running
+// the request, after writing its partial response if set.
+func TestPanicInjectorHandler(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		giveOptions []PanicInjectorOption
+		wantValue   interface{}
+		wantCode    int
+		wantBody    string
+	}{
+		{
+			name:        "default",
+			giveOptions: []PanicInjectorOption{},
+			wantValue:   ErrInjectedPanic,
+			wantCode:    http.StatusOK,
+		},
+		{
+			name:        "panic value",
+			giveOptions: []PanicInjectorOption{WithPanicValue("boom")},
+			wantValue:   "boom",
+			wantCode:    http.StatusOK,
+		},
+		{
+			name:        "panic after write",
+			giveOptions: []PanicInjectorOption{WithPanicAfterWrite(http.StatusAccepted, []byte("partial"))},
+			wantValue:   ErrInjectedPanic,
+			wantCode:    http.StatusAccepted,
+			wantBody:    "partial",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			reporter := newTestStateReporter()
+			pi, err := NewPanicInjector(append(tt.giveOptions, WithReporter(reporter))...)
+			assert.NoError(t, err)
+
+			f, err := NewFault(pi,
+				WithEnabled(true),
+				WithParticipation(1.0),
+			)
+			assert.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			assert.PanicsWithValue(t, tt.wantValue, func() {
+				f.Handler(http.NotFoundHandler()).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+			})
+
+			assert.Equal(t, tt.wantCode, rr.Code)
+			assert.Equal(t, tt.wantBody, rr.Body.String())
+			assert.True(t, rr.Flushed == (tt.wantBody != ""))
+			assert.Equal(t, StateStarted, <-reporter.states)
+		})
+	}
+}
+
+// TestPanicInjectorRecovery tests the responses a client gets when a PanicInjector panics, with and
+// without recovery middleware.
+func TestPanicInjectorRecovery(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		giveOptions []PanicInjectorOption
+		giveRecover bool
+		wantCode    int
+		wantBody    string
+		wantBodyErr error
+	}{
+		{
+			name:        "recovered",
+			giveOptions: []PanicInjectorOption{},
+			giveRecover: true,
+			wantCode:    http.StatusInternalServerError,
+			wantBody:    "recovered: go-fault: injected panic\n",
+		},
+		{
+			// the status was already sent, so the recovery middleware cannot send a 500
+			name: "recovered after write",
+			giveOptions: []PanicInjectorOption{
+				WithPanicAfterWrite(http.StatusOK, []byte("partial")),
+			},
+			giveRecover: true,
+			wantCode:    http.StatusOK,
+			wantBody:    "partialrecovered: go-fault: injected panic\n",
+		},
+		{
+			// the server drops the connection mid-body
+			name: "panic after write",
+			giveOptions: []PanicInjectorOption{
+				WithPanicAfterWrite(http.StatusOK, []byte("partial")),
+			},
+			wantCode:    http.StatusOK,
+			wantBody:    "partial",
+			wantBodyErr: io.ErrUnexpectedEOF,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			pi, err := NewPanicInjector(tt.giveOptions...)
+			assert.NoError(t, err)
+
+			recovered := make(chan interface{}, 1)
+			h := pi.Handler(http.NotFoundHandler())
+			srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				defer func() {
+					v := recover()
+					recovered <- v
+					if !tt.giveRecover {
+						panic(v)
+					}
+					http.Error(w, fmt.Sprint("recovered: ", v), http.StatusInternalServerError)
+				}()
+
+				h.ServeHTTP(w, r)
+			}))
+			srv.Config.ErrorLog = log.New(ioutil.Discard, "", 0)
+			srv.Start()
+			defer srv.Close()
+
+			resp, err := srv.Client().Get(srv.URL)
+			assert.NoError(t, err)
+			defer resp.Body.Close()
+
+			body, err := ioutil.ReadAll(resp.Body)
+			assert.Equal(t, tt.wantCode, resp.StatusCode)
+			assert.Equal(t, tt.wantBody, string(body))
+			assert.Equal(t, tt.wantBodyErr, err)
+			assert.Equal(t, ErrInjectedPanic, <-recovered)
+		})
+	}
+}
@@ -0,0 +1,251 @@
+package fault
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+var (
+	// ErrInvalidBytes when a negative number of bytes is provided.
+	ErrInvalidBytes = errors.New("bytes must be >= 0")
+
+	// errResponseAborted is returned from writes after a response has been aborted.
+	errResponseAborted = errors.New("go-fault: response aborted")
+)
+
+// abortMode is where a PartialResponseInjector aborts the response.
+type abortMode int
+
+const (
+	// abortAfterBytes aborts after a fixed number of bytes.
+	abortAfterBytes abortMode = iota
+	// abortAfterPercent aborts after a percent of Content-Length.
+	abortAfterPercent
+	// abortAfterFlush aborts after the first flush.
+	abortAfterFlush
+)
+
+// PartialResponseInjector runs the request and sends the response headers and the start of the body,
+// then aborts the response before the body is complete.
+type PartialResponseInjector struct {
+	mode     abortMode
+	bytes    int64
+	percent  float32
+	reporter Reporter
+}
+
+// PartialResponseInjectorOption configures a PartialResponseInjector.
+type PartialResponseInjectorOption interface {
+	applyPartialResponseInjector(i *PartialResponseInjector) error
+}
+
+type abortAfterPercentOption float32
+
+func (o abortAfterPercentOption) applyPartialResponseInjector(i *PartialResponseInjector) error {
+	if o < 0.0 || o > 1.0 {
+		return ErrInvalidPercent
+	}
+	i.mode = abortAfterPercent
+	i.percent = float32(o)
+	return nil
+}
+
+// WithAbortAfterPercent aborts the response after a percent (0.0 <= p <= 1.0) of the body's
+// Content-Length has been sent, so the abort point adapts to the size of the response. Responses
+// without a Content-Length are sent in full and then aborted before they complete.
+func WithAbortAfterPercent(p float32) PartialResponseInjectorOption {
+	return abortAfterPercentOption(p)
+}
+
+type abortAfterFlushOption struct{}
+
+func (o abortAfterFlushOption) applyPartialResponseInjector(i *PartialResponseInjector) error {
+	i.mode = abortAfterFlush
+	return nil
+}
+
+// WithAbortAfterFlush aborts the response after the handler first flushes it. Responses that are
+// never flushed are sent in full and then aborted before they complete.
+func WithAbortAfterFlush() PartialResponseInjectorOption {
+	return abortAfterFlushOption{}
+}
+
+func (o reporterOption) applyPartialResponseInjector(i *PartialResponseInjector) error {
+	i.reporter = o.reporter
+	return nil
+}
+
+// NewPartialResponseInjector returns a PartialResponseInjector that aborts the response after n
+// bytes of the body. Pass WithAbortAfterPercent or WithAbortAfterFlush to abort somewhere else.
+func NewPartialResponseInjector(n int64, opts ...PartialResponseInjectorOption) (*PartialResponseInjector, error) {
+	if n < 0 {
+		return nil, ErrInvalidBytes
+	}
+
+	// set defaults
+	pi := &PartialResponseInjector{
+		mode:     abortAfterBytes,
+		bytes:    n,
+		reporter: NewNoopReporter(),
+	}
+
+	// apply options
+	for _, opt := range opts {
+		err := opt.applyPartialResponseInjector(pi)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return pi, nil
+}
+
+// Handler runs the request with a ResponseWriter that stops writing at the abort point. It then
+// flushes what was written and aborts the response.
+func (i *PartialResponseInjector) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		go i.reporter.Report(i.String(), StateStarted)
+
+		pw := &partialWriter{
+			ResponseWriter: w,
+			injector:       i,
+			limit:          -1,
+		}
+		if i.mode == abortAfterBytes {
+			pw.limit = i.bytes
+		}
+
+		next.ServeHTTP(pw, r)
+
+		if f, ok := w.(http.Flusher); ok {
+			f.Flush()
+		}
+
+		// This is a specialized and documented way of sending an interrupted response to
+		// the client without printing the panic stack trace or erroring.
+		// https://golang.org/pkg/net/http/#Handler
+		panic(http.ErrAbortHandler)
+	})
+}
+
+// Name returns "partial".
+func (i *PartialResponseInjector) Name() string {
+	return "partial"
+}
+
+// Describe returns where the PartialResponseInjector aborts the response.
+func (i *PartialResponseInjector) Describe() map[string]string {
+	switch i.mode {
+	case abortAfterPercent:
+		return map[string]string{"percent": strconv.FormatFloat(float64(i.percent), 'g', -1, 32)}
+	case abortAfterFlush:
+		return map[string]string{"flush": "first"}
+	default:
+		return map[string]string{"bytes": strconv.FormatInt(i.bytes, 10)}
+	}
+}
+
+// String returns a summary of the PartialResponseInjector, such as "partial(1024B)", "partial(50%)",
+// or "partial(flush)".
+func (i *PartialResponseInjector) String() string {
+	switch i.mode {
+	case abortAfterPercent:
+		return fmt.Sprintf("%s(%g%%)", i.Name(), i.percent*100)
+	case abortAfterFlush:
+		return fmt.Sprintf("%s(flush)", i.Name())
+	default:
+		return fmt.Sprintf("%s(%dB)", i.Name(), i.bytes)
+	}
+}
+
+// Reporter returns the Reporter of the PartialResponseInjector.
+func (i *PartialResponseInjector) Reporter() Reporter {
+	return i.reporter
+}
+
+// SetReporter replaces the Reporter of the PartialResponseInjector.
+func (i *PartialResponseInjector) SetReporter(r Reporter) {
+	i.reporter = r
+}
+
+// partialWriter is an http.ResponseWriter that stops writing the body at an abort point.
+type partialWriter struct {
+	http.ResponseWriter
+	injector *PartialResponseInjector
+
+	// limit is the number of body bytes to write, or -1 if not yet known.
+	limit       int64
+	written     int64
+	wroteHeader bool
+	aborted     bool
+}
+
+// WriteHeader calculates the abort point for percent mode and writes the header.
+func (w *partialWriter) WriteHeader(code int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+
+	if w.injector.mode == abortAfterPercent {
+		cl, err := strconv.ParseInt(w.Header().Get("Content-Length"), 10, 64)
+		if err == nil {
+			w.limit = int64(float64(cl) * float64(w.injector.percent))
+		}
+	}
+
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Write writes b until the abort point and then fails.
+func (w *partialWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	if w.aborted {
+		return 0, errResponseAborted
+	}
+
+	if w.limit >= 0 && int64(len(b)) > w.limit-w.written {
+		n, err := w.ResponseWriter.Write(b[:w.limit-w.written])
+		w.written += int64(n)
+		w.aborted = true
+		if err != nil {
+			return n, err
+		}
+
+		return n, errResponseAborted
+	}
+
+	n, err := w.ResponseWriter.Write(b)
+	w.written += int64(n)
+	if w.limit >= 0 && w.written >= w.limit {
+		w.aborted = true
+	}
+
+	return n, err
+}
+
+// Flush flushes the underlying ResponseWriter, aborting afterwards in flush mode.
+func (w *partialWriter) Flush() {
+	if w.aborted {
+		return
+	}
+
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+
+	if w.injector.mode == abortAfterFlush {
+		w.aborted = true
+	}
+}
+
+// Destructive returns true. The request runs before its response is cut off, so the client cannot
+// tell that it was applied.
+func (i *PartialResponseInjector) Destructive() bool {
+	return true
+}
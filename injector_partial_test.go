@@ -0,0 +1,230 @@
+package fault
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestNewPartialResponseInjector tests NewPartialResponseInjector.
+func TestNewPartialResponseInjector(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		giveBytes   int64
+		giveOptions []PartialResponseInjectorOption
+		want        *PartialResponseInjector
+		wantErr     error
+	}{
+		{
+			name:      "bytes",
+			giveBytes: 10,
+			want: &PartialResponseInjector{
+				mode:     abortAfterBytes,
+				bytes:    10,
+				reporter: NewNoopReporter(),
+			},
+		},
+		{
+			name:      "negative bytes",
+			giveBytes: -1,
+			wantErr:   ErrInvalidBytes,
+		},
+		{
+			name: "percent",
+			giveOptions: []PartialResponseInjectorOption{
+				WithAbortAfterPercent(0.5),
+			},
+			want: &PartialResponseInjector{
+				mode:     abortAfterPercent,
+				percent:  0.5,
+				reporter: NewNoopReporter(),
+			},
+		},
+		{
+			name: "invalid percent",
+			giveOptions: []PartialResponseInjectorOption{
+				WithAbortAfterPercent(1.5),
+			},
+			wantErr: ErrInvalidPercent,
+		},
+		{
+			name: "flush",
+			giveOptions: []PartialResponseInjectorOption{
+				WithAbortAfterFlush(),
+				WithReporter(newTestReporter()),
+			},
+			want: &PartialResponseInjector{
+				mode:     abortAfterFlush,
+				reporter: newTestReporter(),
+			},
+		},
+		{
+			name: "option error",
+			giveOptions: []PartialResponseInjectorOption{
+				withError(),
+			},
+			wantErr: errErrorOption,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			pi, err := NewPartialResponseInjector(tt.giveBytes, tt.giveOptions...)
+
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.want, pi)
+		})
+	}
+}
+
+// TestPartialResponseInjectorHandler tests PartialResponseInjector.Handler.
+func TestPartialResponseInjectorHandler(t *testing.T) {
+	t.Parallel()
+
+	const body = "0123456789"
+
+	tests := []struct {
+		name              string
+		giveBytes         int64
+		giveOptions       []PartialResponseInjectorOption
+		giveContentLength bool
+		wantBody          string
+	}{
+		{
+			name:      "zero bytes",
+			giveBytes: 0,
+			wantBody:  "",
+		},
+		{
+			name:      "some bytes",
+			giveBytes: 4,
+			wantBody:  "0123",
+		},
+		{
+			name:      "exactly the first write",
+			giveBytes: 10,
+			wantBody:  body,
+		},
+		{
+			name:      "more bytes than body",
+			giveBytes: 100,
+			wantBody:  body + body,
+		},
+		{
+			name: "percent",
+			giveOptions: []PartialResponseInjectorOption{
+				WithAbortAfterPercent(0.25),
+			},
+			giveContentLength: true,
+			wantBody:          "01234",
+		},
+		{
+			name: "percent without content length",
+			giveOptions: []PartialResponseInjectorOption{
+				WithAbortAfterPercent(0.25),
+			},
+			wantBody: body + body,
+		},
+		{
+			name: "after flush",
+			giveOptions: []PartialResponseInjectorOption{
+				WithAbortAfterFlush(),
+			},
+			wantBody: body,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			pi, err := NewPartialResponseInjector(tt.giveBytes, tt.giveOptions...)
+			assert.NoError(t, err)
+
+			h := pi.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.giveContentLength {
+					w.Header().Set("Content-Length", strconv.Itoa(2*len(body)))
+				}
+				w.WriteHeader(http.StatusOK)
+				w.WriteHeader(http.StatusTeapot)
+				_, _ = w.Write([]byte(body))
+				w.(http.Flusher).Flush()
+				_, _ = w.Write([]byte(body))
+			}))
+
+			rr := httptest.NewRecorder()
+			assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+				h.ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+			})
+
+			assert.Equal(t, http.StatusOK, rr.Code)
+			assert.Equal(t, tt.wantBody, rr.Body.String())
+		})
+	}
+}
+
+// TestPartialResponseInjectorServer tests that clients see a truncated body from a real server.
+func TestPartialResponseInjectorServer(t *testing.T) {
+	t.Parallel()
+
+	pi, err := NewPartialResponseInjector(0, WithAbortAfterPercent(0.5))
+	assert.NoError(t, err)
+
+	f, err := NewFault(pi,
+		WithEnabled(true),
+		WithParticipation(1.0),
+	)
+	assert.NoError(t, err)
+
+	srv := httptest.NewServer(f.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", "8")
+		_, _ = w.Write([]byte("abcdefgh"))
+	})))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	got, err := ioutil.ReadAll(resp.Body)
+	assert.Error(t, err)
+	assert.Equal(t, "abcd", string(got))
+}
+
+// errResponseWriter is an http.ResponseWriter whose writes always fail.
+type errResponseWriter struct {
+	*httptest.ResponseRecorder
+}
+
+func (w errResponseWriter) Write(b []byte) (int, error) {
+	return 0, errors.New("write failed")
+}
+
+// TestPartialWriterWriteError tests that partialWriter returns errors from the underlying writer.
+func TestPartialWriterWriteError(t *testing.T) {
+	t.Parallel()
+
+	pi, err := NewPartialResponseInjector(1)
+	assert.NoError(t, err)
+
+	w := &partialWriter{
+		ResponseWriter: errResponseWriter{httptest.NewRecorder()},
+		injector:       pi,
+		limit:          1,
+	}
+
+	n, err := w.Write([]byte("abc"))
+	assert.Equal(t, 0, n)
+	assert.EqualError(t, err, "write failed")
+}
@@ -0,0 +1,284 @@
+package fault
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrNoPressure when a ResourcePressureInjector is given neither memory nor CPU pressure.
+	ErrNoPressure = errors.New("resource pressure injector needs memory or cpu pressure")
+	// ErrInvalidMemoryPressure when memory pressure is not a positive number of bytes.
+	ErrInvalidMemoryPressure = errors.New("memory pressure must be > 0 bytes")
+	// ErrInvalidCPUPressure when CPU pressure does not have a positive number of workers and duration.
+	ErrInvalidCPUPressure = errors.New("cpu pressure must have > 0 workers for > 0 duration")
+)
+
+// ResourcePressureInjector puts the process under memory or CPU pressure while it handles a
+// request, to test how the service behaves when resources run short and if its limits and
+// autoscaling react. The pressure is bounded: memory is released, and CPU workers stop, once the
+// request is done, and WithMaxConcurrent() limits how many requests apply pressure at once.
+type ResourcePressureInjector struct {
+	memory      int
+	cpuWorkers  int
+	cpuDuration time.Duration
+	clock       Clock
+	reporter    Reporter
+
+	// maxConcurrent limits the requests applying pressure at once, where 0 is no limit.
+	maxConcurrent int
+
+	mtx sync.Mutex
+	// active counts the requests applying pressure.
+	active int
+}
+
+// ResourcePressureInjectorOption configures a ResourcePressureInjector.
+type ResourcePressureInjectorOption interface {
+	applyResourcePressureInjector(i *ResourcePressureInjector) error
+}
+
+type memoryPressureOption int
+
+func (o memoryPressureOption) applyResourcePressureInjector(i *ResourcePressureInjector) error {
+	if o <= 0 {
+		return ErrInvalidMemoryPressure
+	}
+
+	i.memory = int(o)
+	return nil
+}
+
+// WithMemoryPressure allocates n bytes for each request and writes to every page of them, so the
+// memory counts against the resident size and limits of the process. The memory is held until the
+// request and its CPU pressure are done, and is then left for the garbage collector.
+func WithMemoryPressure(n int) ResourcePressureInjectorOption {
+	return memoryPressureOption(n)
+}
+
+type cpuPressureOption struct {
+	workers int
+	d       time.Duration
+}
+
+func (o cpuPressureOption) applyResourcePressureInjector(i *ResourcePressureInjector) error {
+	if o.workers <= 0 || o.d <= 0 {
+		return ErrInvalidCPUPressure
+	}
+
+	i.cpuWorkers = o.workers
+	i.cpuDuration = o.d
+	return nil
+}
+
+// WithCPUPressure keeps workers goroutines busy for d from the start of each request, each using
+// up to a core. The request runs alongside them and its response waits for them to stop, so a
+// request takes at least d.
+func WithCPUPressure(workers int, d time.Duration) ResourcePressureInjectorOption {
+	return cpuPressureOption{workers: workers, d: d}
+}
+
+func (o maxConcurrentOption) applyResourcePressureInjector(i *ResourcePressureInjector) error {
+	if o < 0 {
+		return ErrInvalidMaxConcurrent
+	}
+
+	i.maxConcurrent = int(o)
+	return nil
+}
+
+func (o clockOption) applyResourcePressureInjector(i *ResourcePressureInjector) error {
+	i.clock = o.clock
+	return nil
+}
+
+func (o reporterOption) applyResourcePressureInjector(i *ResourcePressureInjector) error {
+	i.reporter = o.reporter
+	return nil
+}
+
+// NewResourcePressureInjector returns a ResourcePressureInjector. Pass WithMemoryPressure(),
+// WithCPUPressure(), or both; it returns ErrNoPressure otherwise. By default one request applies
+// pressure at a time.
+func NewResourcePressureInjector(opts ...ResourcePressureInjectorOption) (*ResourcePressureInjector, error) {
+	// set defaults
+	pi := &ResourcePressureInjector{
+		clock:         NewRealClock(),
+		reporter:      NewNoopReporter(),
+		maxConcurrent: 1,
+	}
+
+	// apply options
+	for _, opt := range opts {
+		err := opt.applyResourcePressureInjector(pi)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// check options
+	if pi.memory == 0 && pi.cpuWorkers == 0 {
+		return nil, ErrNoPressure
+	}
+
+	return pi, nil
+}
+
+// Handler allocates the memory and starts the CPU workers, runs the request, and waits for the
+// workers to stop before releasing the memory. The workers stop early if the context of the
+// request is done. If the concurrency limit is reached it reports StateSkipped and runs the request
+// without pressure.
+func (i *ResourcePressureInjector) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !i.acquire() {
+			go i.reporter.Report(i.String(), StateSkipped)
+			next.ServeHTTP(w, r)
+			return
+		}
+		defer i.release()
+
+		go i.reporter.Report(i.String(), StateStarted)
+
+		mem := i.allocate()
+		done := i.burn(r.Context())
+
+		next.ServeHTTP(w, r)
+
+		<-done
+		runtime.KeepAlive(mem)
+
+		go i.reporter.Report(i.String(), StateFinished)
+	})
+}
+
+// acquire returns true if a request may apply pressure, counting it as active until release is
+// called. It returns false if the concurrency limit is reached.
+func (i *ResourcePressureInjector) acquire() bool {
+	i.mtx.Lock()
+	defer i.mtx.Unlock()
+
+	if i.maxConcurrent > 0 && i.active >= i.maxConcurrent {
+		return false
+	}
+	i.active++
+
+	return true
+}
+
+// release stops counting an acquired request as active.
+func (i *ResourcePressureInjector) release() {
+	i.mtx.Lock()
+	defer i.mtx.Unlock()
+
+	i.active--
+}
+
+// allocate returns the memory pressure of a request, with every page written so it is resident.
+func (i *ResourcePressureInjector) allocate() []byte {
+	if i.memory == 0 {
+		return nil
+	}
+
+	b := make([]byte, i.memory)
+	for n := 0; n < len(b); n += os.Getpagesize() {
+		b[n] = 1
+	}
+
+	return b
+}
+
+// burn starts the CPU workers of a request and returns a channel that is closed once they have
+// stopped, after the CPU pressure duration or when ctx is done.
+func (i *ResourcePressureInjector) burn(ctx context.Context) <-chan struct{} {
+	done := make(chan struct{})
+	if i.cpuWorkers == 0 {
+		close(done)
+		return done
+	}
+
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for n := 0; n < i.cpuWorkers; n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			spin(stop)
+		}()
+	}
+
+	go func() {
+		select {
+		case <-i.clock.After(addLatency(ctx, i.cpuDuration)):
+		case <-ctx.Done():
+		}
+		close(stop)
+		wg.Wait()
+		close(done)
+	}()
+
+	return done
+}
+
+// spin keeps a core busy until stop is closed.
+func spin(stop <-chan struct{}) {
+	for {
+		select {
+		case <-stop:
+			return
+		default:
+		}
+	}
+}
+
+// Reporter returns the Reporter of the ResourcePressureInjector.
+func (i *ResourcePressureInjector) Reporter() Reporter {
+	return i.reporter
+}
+
+// SetReporter replaces the Reporter of the ResourcePressureInjector.
+func (i *ResourcePressureInjector) SetReporter(r Reporter) {
+	i.reporter = r
+}
+
+// Name returns "resource_pressure".
+func (i *ResourcePressureInjector) Name() string {
+	return "resource_pressure"
+}
+
+// Describe returns the memory and CPU pressure and the concurrency limit.
+func (i *ResourcePressureInjector) Describe() map[string]string {
+	d := map[string]string{
+		"max_concurrent": strconv.Itoa(i.maxConcurrent),
+	}
+	if i.memory > 0 {
+		d["memory_bytes"] = strconv.Itoa(i.memory)
+	}
+	if i.cpuWorkers > 0 {
+		d["cpu_workers"] = strconv.Itoa(i.cpuWorkers)
+		d["cpu_duration"] = i.cpuDuration.String()
+	}
+
+	return d
+}
+
+// String returns a summary of the ResourcePressureInjector, such as
+// "resource_pressure(memory=1048576B,cpu=2x1s)".
+func (i *ResourcePressureInjector) String() string {
+	var parts []string
+	if i.memory > 0 {
+		parts = append(parts, fmt.Sprintf("memory=%dB", i.memory))
+	}
+	if i.cpuWorkers > 0 {
+		parts = append(parts, fmt.Sprintf("cpu=%dx%s", i.cpuWorkers, i.cpuDuration))
+	}
+
+	return fmt.Sprintf("%s(%s)", i.Name(), strings.Join(parts, ","))
+}
@@ -0,0 +1,252 @@
+package fault
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/github/go-fault/faulttest"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestNewResourcePressureInjector tests NewResourcePressureInjector.
+func TestNewResourcePressureInjector(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		giveOptions  []ResourcePressureInjectorOption
+		wantString   string
+		wantDescribe map[string]string
+		wantErr      error
+	}{
+		{
+			name:         "memory",
+			giveOptions:  []ResourcePressureInjectorOption{WithMemoryPressure(1 << 20)},
+			wantString:   "resource_pressure(memory=1048576B)",
+			wantDescribe: map[string]string{"memory_bytes": "1048576", "max_concurrent": "1"},
+		},
+		{
+			name:        "cpu",
+			giveOptions: []ResourcePressureInjectorOption{WithCPUPressure(2, time.Second)},
+			wantString:  "resource_pressure(cpu=2x1s)",
+			wantDescribe: map[string]string{
+				"cpu_workers":    "2",
+				"cpu_duration":   "1s",
+				"max_concurrent": "1",
+			},
+		},
+		{
+			name: "options",
+			giveOptions: []ResourcePressureInjectorOption{
+				WithMemoryPressure(4096),
+				WithCPUPressure(1, time.Millisecond),
+				WithMaxConcurrent(0),
+				WithClock(&testAfterClock{}),
+				WithReporter(NewNoopReporter()),
+			},
+			wantString: "resource_pressure(memory=4096B,cpu=1x1ms)",
+			wantDescribe: map[string]string{
+				"memory_bytes":   "4096",
+				"cpu_workers":    "1",
+				"cpu_duration":   "1ms",
+				"max_concurrent": "0",
+			},
+		},
+		{
+			name:        "no pressure",
+			giveOptions: []ResourcePressureInjectorOption{WithMaxConcurrent(2)},
+			wantErr:     ErrNoPressure,
+		},
+		{
+			name:        "invalid memory",
+			giveOptions: []ResourcePressureInjectorOption{WithMemoryPressure(0)},
+			wantErr:     ErrInvalidMemoryPressure,
+		},
+		{
+			name:        "invalid cpu workers",
+			giveOptions: []ResourcePressureInjectorOption{WithCPUPressure(0, time.Second)},
+			wantErr:     ErrInvalidCPUPressure,
+		},
+		{
+			name:        "invalid cpu duration",
+			giveOptions: []ResourcePressureInjectorOption{WithCPUPressure(1, 0)},
+			wantErr:     ErrInvalidCPUPressure,
+		},
+		{
+			name:        "invalid max concurrent",
+			giveOptions: []ResourcePressureInjectorOption{WithMemoryPressure(1), WithMaxConcurrent(-1)},
+			wantErr:     ErrInvalidMaxConcurrent,
+		},
+		{
+			name:        "option error",
+			giveOptions: []ResourcePressureInjectorOption{withError()},
+			wantErr:     errErrorOption,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			pi, err := NewResourcePressureInjector(tt.giveOptions...)
+
+			assert.Equal(t, tt.wantErr, err)
+			if tt.wantErr != nil {
+				assert.Nil(t, pi)
+				return
+			}
+
+			assert.Equal(t, "resource_pressure", pi.Name())
+			assert.Equal(t, tt.wantString, pi.String())
+			assert.Equal(t, tt.wantDescribe, pi.Describe())
+		})
+	}
+}
+
+// TestResourcePressureInjectorHandler tests that ResourcePressureInjector.Handler applies pressure
+// while the request runs and releases it afterward.
+func TestResourcePressureInjectorHandler(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		giveOptions []ResourcePressureInjectorOption
+		wantWaits   []time.Duration
+	}{
+		{
+			name:        "memory",
+			giveOptions: []ResourcePressureInjectorOption{WithMemoryPressure(1 << 20)},
+			wantWaits:   nil,
+		},
+		{
+			name:        "cpu",
+			giveOptions: []ResourcePressureInjectorOption{WithCPUPressure(2, time.Second)},
+			wantWaits:   []time.Duration{time.Second},
+		},
+		{
+			name: "memory and cpu",
+			giveOptions: []ResourcePressureInjectorOption{
+				WithMemoryPressure(1 << 20),
+				WithCPUPressure(1, time.Minute),
+			},
+			wantWaits: []time.Duration{time.Minute},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			clock := &testAfterClock{}
+			reporter := &testStateReporter{states: make(chan InjectorState, 2)}
+
+			opts := append([]ResourcePressureInjectorOption{WithClock(clock), WithReporter(reporter)}, tt.giveOptions...)
+			pi, err := NewResourcePressureInjector(opts...)
+			assert.NoError(t, err)
+
+			f, err := NewFault(pi, WithEnabled(true), WithParticipation(1.0))
+			assert.NoError(t, err)
+
+			rr := testRequest(t, f)
+			assert.Equal(t, testHandlerCode, rr.Code)
+			assert.Equal(t, tt.wantWaits, clock.waits)
+			assert.Equal(t, 0, pi.active)
+
+			states := map[InjectorState]int{}
+			for n := 0; n < 2; n++ {
+				states[<-reporter.states]++
+			}
+			assert.Equal(t, map[InjectorState]int{StateStarted: 1, StateFinished: 1}, states)
+		})
+	}
+}
+
+// TestResourcePressureInjectorAllocate tests that the memory pressure of a request is allocated.
+func TestResourcePressureInjectorAllocate(t *testing.T) {
+	t.Parallel()
+
+	pi, err := NewResourcePressureInjector(WithMemoryPressure(10_000))
+	assert.NoError(t, err)
+
+	b := pi.allocate()
+	assert.Len(t, b, 10_000)
+	assert.Equal(t, byte(1), b[0])
+}
+
+// TestResourcePressureInjectorMaxConcurrent tests that requests past the concurrency limit run
+// without pressure.
+func TestResourcePressureInjectorMaxConcurrent(t *testing.T) {
+	t.Parallel()
+
+	reporter := &testStateReporter{states: make(chan InjectorState, 4)}
+	pi, err := NewResourcePressureInjector(WithMemoryPressure(4096), WithReporter(reporter))
+	assert.NoError(t, err)
+
+	held := make(chan struct{})
+	release := make(chan struct{})
+	h := pi.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/held" {
+			close(held)
+			<-release
+		}
+		w.WriteHeader(testHandlerCode)
+	}))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/held", nil))
+	}()
+	<-held
+	assert.Equal(t, StateStarted, <-reporter.states)
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, testHandlerCode, rr.Code)
+	assert.Equal(t, StateSkipped, <-reporter.states)
+
+	close(release)
+	<-done
+	assert.Equal(t, StateFinished, <-reporter.states)
+}
+
+// TestResourcePressureInjectorCanceled tests that CPU pressure stops once the context of the request
+// is done.
+func TestResourcePressureInjectorCanceled(t *testing.T) {
+	t.Parallel()
+
+	// the clock never fires, so only the context stops the workers
+	pi, err := NewResourcePressureInjector(WithCPUPressure(1, time.Hour), WithClock(faulttest.NewClock(time.Now())))
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	h := pi.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cancel()
+	}))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx))
+
+	assert.Equal(t, 0, pi.active)
+}
+
+// TestResourcePressureInjectorDuration tests that a request under CPU pressure takes as long as the
+// pressure in real time.
+func TestResourcePressureInjectorDuration(t *testing.T) {
+	t.Parallel()
+
+	pi, err := NewResourcePressureInjector(WithCPUPressure(1, 20*time.Millisecond))
+	assert.NoError(t, err)
+
+	f, err := NewFault(pi, WithEnabled(true), WithParticipation(1.0))
+	assert.NoError(t, err)
+
+	start := time.Now()
+	rr := testRequest(t, f)
+
+	assert.Equal(t, testHandlerCode, rr.Code)
+	assert.GreaterOrEqual(t, int64(time.Since(start)), int64(20*time.Millisecond))
+}
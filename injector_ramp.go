@@ -0,0 +1,160 @@
+package fault
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// RampInjector runs the request and waits before each write of the response body, waiting longer
+// for every write, to simulate a connection that degrades mid-transfer. Streaming and large
+// responses, which are written in many pieces, slow down progressively as they are sent.
+type RampInjector struct {
+	initial  time.Duration
+	step     time.Duration
+	max      time.Duration
+	clock    Clock
+	reporter Reporter
+}
+
+// RampInjectorOption configures a RampInjector.
+type RampInjectorOption interface {
+	applyRampInjector(i *RampInjector) error
+}
+
+type maxWriteDelayOption time.Duration
+
+func (o maxWriteDelayOption) applyRampInjector(i *RampInjector) error {
+	if o < 0 {
+		return ErrInvalidDelay
+	}
+
+	i.max = time.Duration(o)
+
+	return nil
+}
+
+// WithMaxWriteDelay caps the delay before each write of a RampInjector at d, so that long streams
+// level off instead of stalling. Default 0, no cap.
+func WithMaxWriteDelay(d time.Duration) RampInjectorOption {
+	return maxWriteDelayOption(d)
+}
+
+func (o clockOption) applyRampInjector(i *RampInjector) error {
+	i.clock = o.clock
+	return nil
+}
+
+func (o reporterOption) applyRampInjector(i *RampInjector) error {
+	i.reporter = o.reporter
+	return nil
+}
+
+// NewRampInjector returns a RampInjector that waits initial before the first write of the body and
+// step longer before each write after it. It returns ErrInvalidDelay if either is negative.
+func NewRampInjector(initial, step time.Duration, opts ...RampInjectorOption) (*RampInjector, error) {
+	if initial < 0 || step < 0 {
+		return nil, ErrInvalidDelay
+	}
+
+	// set defaults
+	ri := &RampInjector{
+		initial:  initial,
+		step:     step,
+		clock:    NewRealClock(),
+		reporter: NewNoopReporter(),
+	}
+
+	// apply options
+	for _, opt := range opts {
+		err := opt.applyRampInjector(ri)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return ri, nil
+}
+
+// Handler runs the request with a ResponseWriter that waits before each write of the body.
+func (i *RampInjector) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		go i.reporter.Report(i.String(), StateStarted)
+		next.ServeHTTP(&rampWriter{ResponseWriter: w, injector: i, ctx: r.Context()}, r)
+		go i.reporter.Report(i.String(), StateFinished)
+	})
+}
+
+// delay returns how long to wait before the write at idx, counting from 0.
+func (i *RampInjector) delay(idx int) time.Duration {
+	d := i.initial + time.Duration(idx)*i.step
+	if i.max > 0 && d > i.max {
+		return i.max
+	}
+
+	return d
+}
+
+// Reporter returns the Reporter of the RampInjector.
+func (i *RampInjector) Reporter() Reporter {
+	return i.reporter
+}
+
+// SetReporter replaces the Reporter of the RampInjector.
+func (i *RampInjector) SetReporter(r Reporter) {
+	i.reporter = r
+}
+
+// Name returns "ramp".
+func (i *RampInjector) Name() string {
+	return "ramp"
+}
+
+// Describe returns the initial delay, the step, and the cap, if any.
+func (i *RampInjector) Describe() map[string]string {
+	d := map[string]string{
+		"initial": i.initial.String(),
+		"step":    i.step.String(),
+	}
+	if i.max > 0 {
+		d["max"] = i.max.String()
+	}
+
+	return d
+}
+
+// String returns a summary of the RampInjector, such as "ramp(10ms+5ms)".
+func (i *RampInjector) String() string {
+	return fmt.Sprintf("%s(%s+%s)", i.Name(), i.initial, i.step)
+}
+
+// rampWriter is an http.ResponseWriter that waits longer before each write of the body.
+type rampWriter struct {
+	http.ResponseWriter
+	injector *RampInjector
+	ctx      context.Context
+
+	writes int
+}
+
+// Write waits the delay of this write and then writes b.
+func (w *rampWriter) Write(b []byte) (int, error) {
+	w.injector.clock.Sleep(addLatency(w.ctx, w.injector.delay(w.writes)))
+	w.writes++
+
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush flushes the underlying ResponseWriter so that streamed writes reach the client as they are
+// delayed.
+func (w *rampWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap returns the underlying ResponseWriter for http.ResponseController.
+func (w *rampWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,166 @@
+package fault
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestNewRampInjector tests NewRampInjector.
+func TestNewRampInjector(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		giveInitial time.Duration
+		giveStep    time.Duration
+		giveOptions []RampInjectorOption
+		wantMax     time.Duration
+		wantErr     error
+	}{
+		{
+			name:        "defaults",
+			giveInitial: time.Millisecond,
+			giveStep:    time.Millisecond,
+		},
+		{
+			name:     "options",
+			giveStep: time.Millisecond,
+			giveOptions: []RampInjectorOption{
+				WithMaxWriteDelay(time.Second),
+				WithReporter(newTestReporter()),
+				WithClock(&testSleepClock{}),
+			},
+			wantMax: time.Second,
+		},
+		{
+			name:        "negative initial",
+			giveInitial: -time.Millisecond,
+			wantErr:     ErrInvalidDelay,
+		},
+		{
+			name:     "negative step",
+			giveStep: -time.Millisecond,
+			wantErr:  ErrInvalidDelay,
+		},
+		{
+			name:        "negative max",
+			giveOptions: []RampInjectorOption{WithMaxWriteDelay(-time.Second)},
+			wantErr:     ErrInvalidDelay,
+		},
+		{
+			name:        "option error",
+			giveOptions: []RampInjectorOption{withError()},
+			wantErr:     errErrorOption,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ri, err := NewRampInjector(tt.giveInitial, tt.giveStep, tt.giveOptions...)
+
+			assert.Equal(t, tt.wantErr, err)
+			if tt.wantErr != nil {
+				assert.Nil(t, ri)
+				return
+			}
+
+			assert.Equal(t, tt.giveInitial, ri.initial)
+			assert.Equal(t, tt.giveStep, ri.step)
+			assert.Equal(t, tt.wantMax, ri.max)
+		})
+	}
+}
+
+// TestRampInjectorHandler tests that RampInjector.Handler waits longer before each write.
+func TestRampInjectorHandler(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		giveInitial time.Duration
+		giveStep    time.Duration
+		giveOptions []RampInjectorOption
+		wantSleeps  []time.Duration
+	}{
+		{
+			name:        "ramp",
+			giveInitial: 10 * time.Millisecond,
+			giveStep:    5 * time.Millisecond,
+			wantSleeps: []time.Duration{
+				10 * time.Millisecond,
+				15 * time.Millisecond,
+				20 * time.Millisecond,
+				25 * time.Millisecond,
+			},
+		},
+		{
+			name:        "max",
+			giveStep:    time.Second,
+			giveOptions: []RampInjectorOption{WithMaxWriteDelay(1500 * time.Millisecond)},
+			wantSleeps: []time.Duration{
+				0,
+				time.Second,
+				1500 * time.Millisecond,
+				1500 * time.Millisecond,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			clock := &testSleepClock{}
+			reporter := &testStateReporter{states: make(chan InjectorState, 2)}
+
+			opts := append([]RampInjectorOption{WithClock(clock), WithReporter(reporter)}, tt.giveOptions...)
+			ri, err := NewRampInjector(tt.giveInitial, tt.giveStep, opts...)
+			assert.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			ri.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				// the handler can still reach the ResponseWriter it wraps
+				assert.True(t, errors.Is(http.NewResponseController(w).EnableFullDuplex(), http.ErrNotSupported))
+
+				w.WriteHeader(testHandlerCode)
+				for _, chunk := range []string{"A", "cc", "ep", "ted"} {
+					_, err := w.Write([]byte(chunk))
+					assert.NoError(t, err)
+					w.(http.Flusher).Flush()
+				}
+			})).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			assert.Equal(t, testHandlerCode, rr.Code)
+			assert.Equal(t, testHandlerBody, rr.Body.String())
+			assert.True(t, rr.Flushed)
+			assert.Equal(t, tt.wantSleeps, clock.sleeps)
+
+			states := map[InjectorState]int{}
+			for n := 0; n < 2; n++ {
+				states[<-reporter.states]++
+			}
+			assert.Equal(t, map[InjectorState]int{StateStarted: 1, StateFinished: 1}, states)
+		})
+	}
+}
+
+// TestRampInjectorReporter tests RampInjector.Reporter and SetReporter.
+func TestRampInjectorReporter(t *testing.T) {
+	t.Parallel()
+
+	ri, err := NewRampInjector(0, time.Millisecond)
+	assert.NoError(t, err)
+
+	reporter := newTestReporter()
+	ri.SetReporter(reporter)
+	assert.Equal(t, reporter, ri.Reporter())
+}
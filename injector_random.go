@@ -1,13 +1,16 @@
 package fault
 
 import (
+	"fmt"
 	"math/rand"
 	"net/http"
+	"strconv"
 	"sync"
 )
 
 // RandomInjector combines many Injectors into a single Injector that runs one randomly.
 type RandomInjector struct {
+	injectors   []Injector
 	middlewares []func(next http.Handler) http.Handler
 
 	randSeed int64
@@ -16,6 +19,14 @@ type RandomInjector struct {
 
 	// *rand.Rand is not thread safe. This mutex protects our random source
 	randMtx sync.Mutex
+
+	reporter Reporter
+
+	// propagate determines if reporter is given to the Injectors.
+	propagate bool
+
+	// limits limit the Injectors.
+	limits compositeLimits
 }
 
 // RandomInjectorOption configures a RandomInjector.
@@ -41,12 +52,26 @@ func WithRandIntFunc(f func(int) int) RandomInjectorOption {
 	return randIntFuncOption(f)
 }
 
-// NewRandomInjector combines many Injectors into a single Injector that runs one randomly.
+func (o reporterOption) applyRandomInjector(i *RandomInjector) error {
+	i.reporter = o.reporter
+	return nil
+}
+
+// NewRandomInjector combines many Injectors into a single Injector that runs one randomly. It returns
+// ErrNoInjectors if is is empty, InjectorErrors naming every Injector in is that is nil or
+// repeated, or ErrTooManyInjectors or ErrTooDeep if is exceeds WithMaxInjectors() or WithMaxDepth().
 func NewRandomInjector(is []Injector, opts ...RandomInjectorOption) (*RandomInjector, error) {
+	err := validateInjectors(is)
+	if err != nil {
+		return nil, err
+	}
+
 	// set defaults
 	ri := &RandomInjector{
 		randSeed: defaultRandSeed,
 		randF:    nil,
+		reporter: NewNoopReporter(),
+		limits:   defaultCompositeLimits(),
 	}
 
 	// apply options
@@ -57,9 +82,19 @@ func NewRandomInjector(is []Injector, opts ...RandomInjectorOption) (*RandomInje
 		}
 	}
 
+	err = ri.limits.check(is)
+	if err != nil {
+		return nil, err
+	}
+
 	// set middleware
+	ri.injectors = is
 	for _, i := range is {
-		ri.middlewares = append(ri.middlewares, i.Handler)
+		ri.middlewares = append(ri.middlewares, recordedMiddleware(i))
+	}
+
+	if ri.propagate {
+		PropagateReporter(is, ri.reporter, nil)
 	}
 
 	// set seeded rand source and function
@@ -74,14 +109,87 @@ func NewRandomInjector(is []Injector, opts ...RandomInjectorOption) (*RandomInje
 // Handler executes a random Injector from RandomInjector.middlewares.
 func (i *RandomInjector) Handler(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if len(i.middlewares) > 0 {
-			i.randMtx.Lock()
-			randIdx := i.randF(len(i.middlewares))
-			i.randMtx.Unlock()
-
-			i.middlewares[randIdx](next).ServeHTTP(w, r)
-		} else {
-			next.ServeHTTP(w, r)
-		}
+		go i.reporter.Report(i.String(), StateStarted)
+
+		i.randMtx.Lock()
+		randIdx := i.randF(len(i.middlewares))
+		i.randMtx.Unlock()
+
+		i.middlewares[randIdx](next).ServeHTTP(w, r)
 	})
 }
+
+// Reporter returns the Reporter of the RandomInjector.
+func (i *RandomInjector) Reporter() Reporter {
+	return i.reporter
+}
+
+// SetReporter replaces the Reporter of the RandomInjector. With WithReporterPropagation(true) it also
+// replaces the Reporter of each Injector that has no Reporter of its own.
+func (i *RandomInjector) SetReporter(r Reporter) {
+	if i.propagate {
+		PropagateReporter(i.injectors, r, i.reporter)
+	}
+
+	i.reporter = r
+}
+
+// Name returns "random".
+func (i *RandomInjector) Name() string {
+	return "random"
+}
+
+// Describe returns the Injectors that are chosen from and the random seed.
+func (i *RandomInjector) Describe() map[string]string {
+	return map[string]string{
+		"injectors": joinInjectorStrings(i.injectors),
+		"seed":      strconv.FormatInt(i.randSeed, 10),
+	}
+}
+
+// Seed returns the seed of the RandomInjector's random number generator.
+func (i *RandomInjector) Seed() int64 {
+	return i.randSeed
+}
+
+// String returns a summary of the RandomInjector, such as "random(slow(750ms), reject)".
+func (i *RandomInjector) String() string {
+	return fmt.Sprintf("%s(%s)", i.Name(), joinInjectorStrings(i.injectors))
+}
+
+// OnEnable runs the OnEnable hook of each Injector.
+func (i *RandomInjector) OnEnable() {
+	for _, c := range i.injectors {
+		runEnableHook(c)
+	}
+}
+
+// OnDisable runs the OnDisable hook of each Injector.
+func (i *RandomInjector) OnDisable() {
+	for _, c := range i.injectors {
+		runDisableHook(c)
+	}
+}
+
+// OnConfigChange runs the OnConfigChange hook of each Injector.
+func (i *RandomInjector) OnConfigChange() {
+	for _, c := range i.injectors {
+		runConfigChangeHook(c)
+	}
+}
+
+// Destructive returns true if any of the Injectors is destructive.
+func (i *RandomInjector) Destructive() bool {
+	for _, c := range i.injectors {
+		if IsDestructive(c) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// children returns the Injectors.
+func (i *RandomInjector) children() []Injector {
+	return i.injectors
+}
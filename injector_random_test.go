@@ -13,6 +13,8 @@ import (
 func TestNewRandomInjector(t *testing.T) {
 	t.Parallel()
 
+	dup := newTestInjectorHooks()
+
 	tests := []struct {
 		name         string
 		giveInjector []Injector
@@ -24,15 +26,26 @@ func TestNewRandomInjector(t *testing.T) {
 			name:         "nil",
 			giveInjector: nil,
 			giveOptions:  nil,
-			wantRand:     rand.New(rand.NewSource(defaultRandSeed)),
-			wantErr:      nil,
+			wantErr:      ErrNoInjectors,
 		},
 		{
 			name:         "empty",
 			giveInjector: []Injector{},
 			giveOptions:  nil,
-			wantRand:     rand.New(rand.NewSource(defaultRandSeed)),
-			wantErr:      nil,
+			wantErr:      ErrNoInjectors,
+		},
+		{
+			name: "nil and duplicate injectors",
+			giveInjector: []Injector{
+				dup,
+				nil,
+				dup,
+			},
+			giveOptions: nil,
+			wantErr: InjectorErrors{
+				{Index: 1, Err: ErrNilInjector},
+				{Index: 2, Err: ErrDuplicateInjector},
+			},
 		},
 		{
 			name: "one",
@@ -77,6 +90,17 @@ func TestNewRandomInjector(t *testing.T) {
 			wantRand: rand.New(rand.NewSource(defaultRandSeed)),
 			wantErr:  nil,
 		},
+		{
+			name: "custom reporter",
+			giveInjector: []Injector{
+				newTestInjectorNoop(),
+			},
+			giveOptions: []RandomInjectorOption{
+				WithReporter(newTestReporter()),
+			},
+			wantRand: rand.New(rand.NewSource(defaultRandSeed)),
+			wantErr:  nil,
+		},
 		{
 			name: "option error",
 			giveInjector: []Injector{
@@ -120,20 +144,6 @@ func TestRandomInjectorHandler(t *testing.T) {
 		wantCode    int
 		wantBody    string
 	}{
-		{
-			name:        "nil",
-			give:        nil,
-			giveOptions: nil,
-			wantCode:    testHandlerCode,
-			wantBody:    testHandlerBody,
-		},
-		{
-			name:        "empty",
-			give:        []Injector{},
-			giveOptions: nil,
-			wantCode:    testHandlerCode,
-			wantBody:    testHandlerBody,
-		},
 		{
 			name: "one",
 			give: []Injector{
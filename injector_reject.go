@@ -1,13 +1,59 @@
 package fault
 
 import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
 	"net/http"
-	"reflect"
+	"strconv"
 )
 
+var (
+	// ErrInvalidCloseType when an unknown CloseType is provided.
+	ErrInvalidCloseType = errors.New("not a valid close type")
+)
+
+// CloseType is a way of dropping the connection of a rejected request. Each produces a different
+// error in the client.
+type CloseType int
+
+const (
+	// CloseAbort aborts the handler with http.ErrAbortHandler, so the server closes the connection
+	// without a response. Clients see an empty reply, often reported as EOF.
+	CloseAbort CloseType = iota
+	// CloseReset takes over the connection and resets it, sending a TCP RST instead of a FIN.
+	// Clients see "connection reset by peer".
+	CloseReset
+	// CloseMidHeaders takes over the connection, writes a status line and part of the headers, and
+	// closes it. Clients fail to read the response headers, often with an unexpected EOF.
+	CloseMidHeaders
+	// ClosePartialBody runs the request, writes its status line and headers with the full
+	// Content-Length, and closes the connection after half of its body. Clients get a response whose
+	// body fails to read with an unexpected EOF.
+	ClosePartialBody
+)
+
+// String returns the name of the CloseType.
+func (c CloseType) String() string {
+	switch c {
+	case CloseAbort:
+		return "abort"
+	case CloseReset:
+		return "reset"
+	case CloseMidHeaders:
+		return "mid_headers"
+	case ClosePartialBody:
+		return "partial_body"
+	default:
+		return fmt.Sprintf("CloseType(%d)", int(c))
+	}
+}
+
 // RejectInjector sends back an empty response.
 type RejectInjector struct {
-	reporter Reporter
+	closeType CloseType
+	reporter  Reporter
 }
 
 // RejectInjectorOption configures a RejectInjector.
@@ -15,6 +61,25 @@ type RejectInjectorOption interface {
 	applyRejectInjector(i *RejectInjector) error
 }
 
+type closeTypeOption CloseType
+
+func (o closeTypeOption) applyRejectInjector(i *RejectInjector) error {
+	if CloseType(o) < CloseAbort || CloseType(o) > ClosePartialBody {
+		return ErrInvalidCloseType
+	}
+
+	i.closeType = CloseType(o)
+
+	return nil
+}
+
+// WithCloseType sets how the RejectInjector drops the connection. CloseReset, CloseMidHeaders, and
+// ClosePartialBody take over the connection, so on connections that cannot be taken over, such as
+// HTTP/2, they fall back to CloseAbort. Default CloseAbort.
+func WithCloseType(c CloseType) RejectInjectorOption {
+	return closeTypeOption(c)
+}
+
 func (o reporterOption) applyRejectInjector(i *RejectInjector) error {
 	i.reporter = o.reporter
 	return nil
@@ -24,7 +89,8 @@ func (o reporterOption) applyRejectInjector(i *RejectInjector) error {
 func NewRejectInjector(opts ...RejectInjectorOption) (*RejectInjector, error) {
 	// set defaults
 	ri := &RejectInjector{
-		reporter: NewNoopReporter(),
+		closeType: CloseAbort,
+		reporter:  NewNoopReporter(),
 	}
 
 	// apply options
@@ -38,14 +104,121 @@ func NewRejectInjector(opts ...RejectInjectorOption) (*RejectInjector, error) {
 	return ri, nil
 }
 
-// Handler rejects the request, returning an empty response.
+// Handler rejects the request, dropping the connection as set by WithCloseType. Only
+// ClosePartialBody runs the request.
 func (i *RejectInjector) Handler(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		go i.reporter.Report(reflect.ValueOf(*i).Type().Name(), StateStarted)
+		go i.reporter.Report(i.String(), StateStarted)
+
+		hj, ok := w.(http.Hijacker)
+		if i.closeType == CloseAbort || !ok {
+			abort()
+		}
+
+		var bw *bufferedWriter
+		if i.closeType == ClosePartialBody {
+			bw = newBufferedWriter(w)
+			defer bw.release()
+			next.ServeHTTP(bw, r)
+		}
 
-		// This is a specialized and documented way of sending an interrupted response to
-		// the client without printing the panic stack trace or erroring.
-		// https://golang.org/pkg/net/http/#Handler
-		panic(http.ErrAbortHandler)
+		conn, brw, err := hj.Hijack()
+		if err != nil {
+			abort()
+		}
+		defer func() { go i.reporter.Report(i.String(), StateFinished) }()
+		defer conn.Close()
+
+		switch i.closeType {
+		case CloseReset:
+			resetOnClose(conn)
+		case CloseMidHeaders:
+			writeMidHeaders(brw.Writer)
+		case ClosePartialBody:
+			writePartialBody(brw.Writer, bw)
+		}
 	})
 }
+
+// abort panics with http.ErrAbortHandler.
+func abort() {
+	// This is a specialized and documented way of sending an interrupted response to
+	// the client without printing the panic stack trace or erroring.
+	// https://golang.org/pkg/net/http/#Handler
+	panic(http.ErrAbortHandler)
+}
+
+// resetOnClose makes closing conn send a TCP RST, unwrapping TLS connections. Connections that are
+// not TCP are closed normally.
+func resetOnClose(conn net.Conn) {
+	if nc, ok := conn.(interface{ NetConn() net.Conn }); ok {
+		conn = nc.NetConn()
+	}
+
+	if tc, ok := conn.(*net.TCPConn); ok {
+		_ = tc.SetLinger(0)
+	}
+}
+
+// writeMidHeaders writes a status line and a header without ending the headers.
+func writeMidHeaders(w *bufio.Writer) {
+	_, _ = w.WriteString("HTTP/1.1 200 OK\r\nContent-Type: text/pla")
+	_ = w.Flush()
+}
+
+// writePartialBody writes the buffered response as HTTP/1.1 with a Content-Length for the whole
+// body, followed by the first half of the body.
+func writePartialBody(w *bufio.Writer, bw *bufferedWriter) {
+	h := bw.header.Clone()
+	h.Del("Transfer-Encoding")
+	if bodyAllowed(bw.code) {
+		h.Set("Content-Length", strconv.Itoa(bw.body.Len()))
+	}
+
+	fmt.Fprintf(w, "HTTP/1.1 %03d %s\r\n", bw.code, http.StatusText(bw.code))
+	_ = h.Write(w)
+	_, _ = w.WriteString("\r\n")
+
+	if bodyAllowed(bw.code) {
+		_, _ = w.Write(bw.body.Bytes()[:bw.body.Len()/2])
+	}
+
+	_ = w.Flush()
+}
+
+// Reporter returns the Reporter of the RejectInjector.
+func (i *RejectInjector) Reporter() Reporter {
+	return i.reporter
+}
+
+// SetReporter replaces the Reporter of the RejectInjector.
+func (i *RejectInjector) SetReporter(r Reporter) {
+	i.reporter = r
+}
+
+// Name returns "reject".
+func (i *RejectInjector) Name() string {
+	return "reject"
+}
+
+// Describe returns how the RejectInjector drops the connection.
+func (i *RejectInjector) Describe() map[string]string {
+	return map[string]string{
+		"close_type": i.closeType.String(),
+	}
+}
+
+// String returns "reject", followed by the CloseType if it is not CloseAbort, such as
+// "reject(reset)".
+func (i *RejectInjector) String() string {
+	if i.closeType == CloseAbort {
+		return i.Name()
+	}
+
+	return fmt.Sprintf("%s(%s)", i.Name(), i.closeType)
+}
+
+// Destructive returns true. A rejected request leaves the client unsure if it was applied.
+func (i *RejectInjector) Destructive() bool {
+	return true
+}
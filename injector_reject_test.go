@@ -1,6 +1,12 @@
 package fault
 
 import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -34,6 +40,33 @@ func TestNewRejectInjector(t *testing.T) {
 			},
 			wantErr: nil,
 		},
+		{
+			name: "close type",
+			giveOptions: []RejectInjectorOption{
+				WithCloseType(ClosePartialBody),
+			},
+			want: &RejectInjector{
+				closeType: ClosePartialBody,
+				reporter:  NewNoopReporter(),
+			},
+			wantErr: nil,
+		},
+		{
+			name: "invalid close type",
+			giveOptions: []RejectInjectorOption{
+				WithCloseType(ClosePartialBody + 1),
+			},
+			want:    nil,
+			wantErr: ErrInvalidCloseType,
+		},
+		{
+			name: "negative close type",
+			giveOptions: []RejectInjectorOption{
+				WithCloseType(-1),
+			},
+			want:    nil,
+			wantErr: ErrInvalidCloseType,
+		},
 		{
 			name: "option error",
 			giveOptions: []RejectInjectorOption{
@@ -69,6 +102,11 @@ func TestRejectInjectorHandler(t *testing.T) {
 			name:        "valid",
 			giveOptions: []RejectInjectorOption{},
 		},
+		{
+			// a ResponseRecorder cannot be taken over
+			name:        "reset without hijacker",
+			giveOptions: []RejectInjectorOption{WithCloseType(CloseReset)},
+		},
 	}
 
 	for _, tt := range tests {
@@ -90,3 +128,139 @@ func TestRejectInjectorHandler(t *testing.T) {
 		})
 	}
 }
+
+// TestRejectInjectorHandlerHijackError tests that a RejectInjector aborts the handler if it cannot
+// take over the connection.
+func TestRejectInjectorHandlerHijackError(t *testing.T) {
+	t.Parallel()
+
+	ri, err := NewRejectInjector(WithCloseType(CloseMidHeaders))
+	assert.NoError(t, err)
+
+	w := testHijackFailer{httptest.NewRecorder()}
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		ri.Handler(http.NotFoundHandler()).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	})
+}
+
+// TestRejectInjectorCloseType tests the error each CloseType produces in a client.
+func TestRejectInjectorCloseType(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		giveCloseType CloseType
+		giveCode      int
+		wantErr       string
+		wantBody      string
+		wantBodyErr   error
+		wantStates    map[InjectorState]int
+	}{
+		{
+			name:          "abort",
+			giveCloseType: CloseAbort,
+			wantErr:       "EOF",
+			wantStates:    map[InjectorState]int{StateStarted: 1},
+		},
+		{
+			name:          "reset",
+			giveCloseType: CloseReset,
+			wantErr:       "connection reset by peer",
+			wantStates:    map[InjectorState]int{StateStarted: 1, StateFinished: 1},
+		},
+		{
+			name:          "mid headers",
+			giveCloseType: CloseMidHeaders,
+			wantErr:       "unexpected EOF",
+			wantStates:    map[InjectorState]int{StateStarted: 1, StateFinished: 1},
+		},
+		{
+			name:          "partial body",
+			giveCloseType: ClosePartialBody,
+			giveCode:      testHandlerCode,
+			wantBody:      "Acce",
+			wantBodyErr:   io.ErrUnexpectedEOF,
+			wantStates:    map[InjectorState]int{StateStarted: 1, StateFinished: 1},
+		},
+		{
+			name:          "partial body without body",
+			giveCloseType: ClosePartialBody,
+			giveCode:      http.StatusNoContent,
+			wantStates:    map[InjectorState]int{StateStarted: 1, StateFinished: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			reporter := &testStateReporter{states: make(chan InjectorState, 2)}
+			ri, err := NewRejectInjector(WithCloseType(tt.giveCloseType), WithReporter(reporter))
+			assert.NoError(t, err)
+
+			srv := httptest.NewServer(ri.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.giveCode)
+				_, _ = w.Write([]byte("Accepted"))
+			})))
+			defer srv.Close()
+
+			resp, err := srv.Client().Get(srv.URL)
+			if tt.wantErr != "" {
+				assert.Error(t, err)
+				assert.True(t, strings.Contains(err.Error(), tt.wantErr), err)
+			} else {
+				assert.NoError(t, err)
+				defer resp.Body.Close()
+
+				body, err := io.ReadAll(resp.Body)
+				assert.Equal(t, tt.giveCode, resp.StatusCode)
+				assert.Equal(t, tt.wantBody, string(body))
+				assert.Equal(t, tt.wantBodyErr, err)
+			}
+
+			// the states are reported concurrently, so they may arrive in any order
+			states := map[InjectorState]int{}
+			for n := 0; n < len(tt.wantStates); n++ {
+				states[<-reporter.states]++
+			}
+			assert.Equal(t, tt.wantStates, states)
+		})
+	}
+}
+
+// TestResetOnClose tests that resetOnClose unwraps connections and ignores connections that are not
+// TCP.
+func TestResetOnClose(t *testing.T) {
+	t.Parallel()
+
+	a, b := net.Pipe()
+	defer b.Close()
+
+	resetOnClose(testNetConn{a})
+	assert.NoError(t, a.Close())
+
+	_, err := bufio.NewReader(b).ReadByte()
+	assert.Equal(t, io.EOF, err)
+}
+
+// testNetConn is a net.Conn that wraps another, like a tls.Conn.
+type testNetConn struct {
+	net.Conn
+}
+
+// NetConn returns the wrapped net.Conn.
+func (c testNetConn) NetConn() net.Conn {
+	return c.Conn
+}
+
+// TestCloseTypeString tests CloseType.String.
+func TestCloseTypeString(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "abort", CloseAbort.String())
+	assert.Equal(t, "reset", CloseReset.String())
+	assert.Equal(t, "mid_headers", CloseMidHeaders.String())
+	assert.Equal(t, "partial_body", ClosePartialBody.String())
+	assert.Equal(t, "CloseType(7)", CloseType(7).String())
+}
@@ -0,0 +1,120 @@
+package fault
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+)
+
+var (
+	// ErrInvalidURL when a nil or relative upstream URL is provided.
+	ErrInvalidURL = errors.New("upstream url must be absolute")
+)
+
+// RerouteInjector sends the request to a different upstream instead of the next handler, such as a
+// stale read replica or an old version of the service, to simulate split-brain and version-skew.
+type RerouteInjector struct {
+	upstream  *url.URL
+	transport http.RoundTripper
+	proxy     *httputil.ReverseProxy
+	reporter  Reporter
+}
+
+// RerouteInjectorOption configures a RerouteInjector.
+type RerouteInjectorOption interface {
+	applyRerouteInjector(i *RerouteInjector) error
+}
+
+type transportOption struct {
+	transport http.RoundTripper
+}
+
+func (o transportOption) applyRerouteInjector(i *RerouteInjector) error {
+	i.transport = o.transport
+	return nil
+}
+
+// RoundTripperOption configures structs that send requests with an http.RoundTripper.
+type RoundTripperOption interface {
+	RerouteInjectorOption
+	TransportOption
+	ReverseProxyOption
+}
+
+// WithTransport sets the http.RoundTripper used to send requests, to the upstream of a
+// RerouteInjector or a reverse proxy, or on from a Transport. Default http.DefaultTransport.
+func WithTransport(rt http.RoundTripper) RoundTripperOption {
+	return transportOption{rt}
+}
+
+func (o reporterOption) applyRerouteInjector(i *RerouteInjector) error {
+	i.reporter = o.reporter
+	return nil
+}
+
+// NewRerouteInjector returns a RerouteInjector that proxies requests to upstream. The path of
+// upstream is joined with the path of each request, like httputil.NewSingleHostReverseProxy.
+func NewRerouteInjector(upstream *url.URL, opts ...RerouteInjectorOption) (*RerouteInjector, error) {
+	if upstream == nil || !upstream.IsAbs() {
+		return nil, ErrInvalidURL
+	}
+
+	// set defaults
+	ri := &RerouteInjector{
+		upstream:  upstream,
+		transport: http.DefaultTransport,
+		reporter:  NewNoopReporter(),
+	}
+
+	// apply options
+	for _, opt := range opts {
+		err := opt.applyRerouteInjector(ri)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	ri.proxy = httputil.NewSingleHostReverseProxy(upstream)
+	ri.proxy.Transport = ri.transport
+
+	return ri, nil
+}
+
+// Handler proxies the request to the upstream. The next handler does not run. Requests the
+// upstream cannot answer get a 502 Bad Gateway.
+func (i *RerouteInjector) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		go i.reporter.Report(i.String(), StateStarted)
+		i.proxy.ServeHTTP(w, r)
+		go i.reporter.Report(i.String(), StateFinished)
+	})
+}
+
+// Name returns "reroute".
+func (i *RerouteInjector) Name() string {
+	return "reroute"
+}
+
+// Describe returns the upstream URL.
+func (i *RerouteInjector) Describe() map[string]string {
+	return map[string]string{
+		"upstream": i.upstream.String(),
+	}
+}
+
+// String returns a summary of the RerouteInjector, such as "reroute(http://replica:8080)".
+func (i *RerouteInjector) String() string {
+	return fmt.Sprintf("%s(%s)", i.Name(), i.upstream)
+}
+
+// Reporter returns the Reporter of the RerouteInjector.
+func (i *RerouteInjector) Reporter() Reporter {
+	return i.reporter
+}
+
+// SetReporter replaces the Reporter of the RerouteInjector.
+func (i *RerouteInjector) SetReporter(r Reporter) {
+	i.reporter = r
+}
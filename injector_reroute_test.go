@@ -0,0 +1,110 @@
+package fault
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestNewRerouteInjector tests NewRerouteInjector.
+func TestNewRerouteInjector(t *testing.T) {
+	t.Parallel()
+
+	upstream := &url.URL{Scheme: "http", Host: "replica"}
+	transport := &http.Transport{}
+
+	tests := []struct {
+		name          string
+		giveUpstream  *url.URL
+		giveOptions   []RerouteInjectorOption
+		wantTransport http.RoundTripper
+		wantReporter  Reporter
+		wantErr       error
+	}{
+		{
+			name:          "defaults",
+			giveUpstream:  upstream,
+			wantTransport: http.DefaultTransport,
+			wantReporter:  NewNoopReporter(),
+		},
+		{
+			name:         "options",
+			giveUpstream: upstream,
+			giveOptions: []RerouteInjectorOption{
+				WithTransport(transport),
+				WithReporter(newTestReporter()),
+			},
+			wantTransport: transport,
+			wantReporter:  newTestReporter(),
+		},
+		{
+			name:         "nil url",
+			giveUpstream: nil,
+			wantErr:      ErrInvalidURL,
+		},
+		{
+			name:         "relative url",
+			giveUpstream: &url.URL{Path: "/replica"},
+			wantErr:      ErrInvalidURL,
+		},
+		{
+			name:         "option error",
+			giveUpstream: upstream,
+			giveOptions: []RerouteInjectorOption{
+				withError(),
+			},
+			wantErr: errErrorOption,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ri, err := NewRerouteInjector(tt.giveUpstream, tt.giveOptions...)
+
+			assert.Equal(t, tt.wantErr, err)
+			if tt.wantErr != nil {
+				assert.Nil(t, ri)
+				return
+			}
+
+			assert.Equal(t, tt.giveUpstream, ri.upstream)
+			assert.Equal(t, tt.wantTransport, ri.transport)
+			assert.Equal(t, tt.wantTransport, ri.proxy.Transport)
+			assert.Equal(t, tt.wantReporter, ri.reporter)
+		})
+	}
+}
+
+// TestRerouteInjectorHandler tests that RerouteInjector.Handler proxies to the upstream.
+func TestRerouteInjectorHandler(t *testing.T) {
+	t.Parallel()
+
+	replica := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Backend", "replica")
+		_, _ = io.WriteString(w, r.URL.Path)
+	}))
+	defer replica.Close()
+
+	upstream, err := url.Parse(replica.URL + "/v1")
+	assert.NoError(t, err)
+
+	ri, err := NewRerouteInjector(upstream)
+	assert.NoError(t, err)
+
+	f, err := NewFault(ri, WithEnabled(true), WithParticipation(1.0))
+	assert.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	f.Handler(http.NotFoundHandler()).ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "replica", rr.Header().Get("X-Backend"))
+	assert.Equal(t, "/v1/", rr.Body.String())
+}
@@ -0,0 +1,460 @@
+package fault
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"strconv"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrInvalidPanicPolicy when an unknown PanicPolicy is passed.
+	ErrInvalidPanicPolicy = errors.New("invalid panic policy")
+	// ErrInvalidDeadline when a negative deadline is passed.
+	ErrInvalidDeadline = errors.New("deadline must be >= 0")
+)
+
+// PanicPolicy decides how a SandboxInjector handles a panic recovered from its Injector.
+type PanicPolicy int
+
+const (
+	// PanicPassThrough continues the request as if the Injector were not there. This is the default.
+	PanicPassThrough PanicPolicy = iota
+	// PanicInternalServerError responds with 500 Internal Server Error.
+	PanicInternalServerError
+	// PanicPropagate reports the panic and then panics again with the same value.
+	PanicPropagate
+)
+
+// String returns the name of the PanicPolicy.
+func (p PanicPolicy) String() string {
+	switch p {
+	case PanicPassThrough:
+		return "pass-through"
+	case PanicInternalServerError:
+		return "internal-server-error"
+	case PanicPropagate:
+		return "propagate"
+	default:
+		return "unknown"
+	}
+}
+
+// PanicReporter is a Reporter that also receives the value and stack of every panic a
+// SandboxInjector recovers.
+type PanicReporter interface {
+	Reporter
+	ReportPanic(name string, recovered interface{}, stack []byte)
+}
+
+// SandboxInjector runs an Injector, such as a custom or third party Injector, and recovers from any
+// panic in it so that a buggy Injector cannot take down the service. Panics in the handlers after
+// the Injector, and http.ErrAbortHandler, which Injectors like the RejectInjector panic with on
+// purpose, are not recovered. With WithDeadline() it also bounds how long the Injector may run
+// before it continues the request, so an Injector that hangs cannot hold requests forever.
+type SandboxInjector struct {
+	injector     Injector
+	policy       PanicPolicy
+	deadline     time.Duration
+	deadlineCode int
+	clock        Clock
+	reporter     Reporter
+}
+
+// SandboxInjectorOption configures a SandboxInjector.
+type SandboxInjectorOption interface {
+	applySandboxInjector(i *SandboxInjector) error
+}
+
+func (o reporterOption) applySandboxInjector(i *SandboxInjector) error {
+	i.reporter = o.reporter
+	return nil
+}
+
+type panicPolicyOption PanicPolicy
+
+func (o panicPolicyOption) applySandboxInjector(i *SandboxInjector) error {
+	if o < panicPolicyOption(PanicPassThrough) || o > panicPolicyOption(PanicPropagate) {
+		return ErrInvalidPanicPolicy
+	}
+
+	i.policy = PanicPolicy(o)
+
+	return nil
+}
+
+// WithPanicPolicy sets how the SandboxInjector handles a panic. Default PanicPassThrough.
+func WithPanicPolicy(p PanicPolicy) SandboxInjectorOption {
+	return panicPolicyOption(p)
+}
+
+type deadlineOption time.Duration
+
+func (o deadlineOption) applySandboxInjector(i *SandboxInjector) error {
+	if o < 0 {
+		return ErrInvalidDeadline
+	}
+
+	i.deadline = time.Duration(o)
+
+	return nil
+}
+
+// WithDeadline sets how long the Injector may run before it must continue the request. Past the
+// deadline the request continues without the Injector, or is failed with WithDeadlineCode(), and
+// the context of the request the Injector holds is canceled. Anything the Injector writes after
+// the deadline is discarded. The Injector runs in its own goroutine, and headers it sets are only
+// sent when it writes, so it cannot set trailers. Default 0, no deadline.
+func WithDeadline(d time.Duration) SandboxInjectorOption {
+	return deadlineOption(d)
+}
+
+type deadlineCodeOption int
+
+func (o deadlineCodeOption) applySandboxInjector(i *SandboxInjector) error {
+	if o != 0 && http.StatusText(int(o)) == "" {
+		return ErrInvalidHTTPCode
+	}
+
+	i.deadlineCode = int(o)
+
+	return nil
+}
+
+// WithDeadlineCode sets the status code the SandboxInjector responds with when the Injector runs
+// past its deadline, such as http.StatusServiceUnavailable. Default 0, continue the request.
+func WithDeadlineCode(code int) SandboxInjectorOption {
+	return deadlineCodeOption(code)
+}
+
+func (o clockOption) applySandboxInjector(i *SandboxInjector) error {
+	i.clock = o.clock
+	return nil
+}
+
+// NewSandboxInjector returns a SandboxInjector that recovers from panics in i.
+func NewSandboxInjector(i Injector, opts ...SandboxInjectorOption) (*SandboxInjector, error) {
+	if err := validateInjector(i); err != nil {
+		return nil, err
+	}
+
+	// set defaults
+	si := &SandboxInjector{
+		injector: i,
+		policy:   PanicPassThrough,
+		clock:    NewRealClock(),
+		reporter: NewNoopReporter(),
+	}
+
+	// apply options
+	for _, opt := range opts {
+		err := opt.applySandboxInjector(si)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return si, nil
+}
+
+// Handler runs the Injector and recovers from a panic in it. The request only continues to next
+// once, even if the Injector panics after calling next.
+func (i *SandboxInjector) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		g := &sandboxGuard{}
+		tracked := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !g.call() {
+				return
+			}
+			next.ServeHTTP(w, r)
+			g.ret()
+		})
+
+		defer func() {
+			v := recover()
+			if v == nil {
+				return
+			}
+
+			// the panic is not the Injector's to recover
+			if v == http.ErrAbortHandler || g.inNext() {
+				panic(v)
+			}
+
+			i.recovered(v)
+
+			switch {
+			case i.policy == PanicPropagate:
+				panic(v)
+			case i.policy == PanicInternalServerError:
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			case !g.nextCalled():
+				next.ServeHTTP(w, r)
+			}
+		}()
+
+		if i.deadline == 0 {
+			i.injector.Handler(tracked).ServeHTTP(w, r)
+			return
+		}
+
+		i.serveDeadline(w, r, next, tracked, g)
+	})
+}
+
+// serveDeadline runs the Injector in a new goroutine and waits until it calls tracked, returns, or
+// runs past the deadline. A panic in the Injector before the deadline is raised again on the
+// calling goroutine.
+func (i *SandboxInjector) serveDeadline(w http.ResponseWriter, r *http.Request, next, tracked http.Handler,
+	g *sandboxGuard) {
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	gw := &guardedWriter{w: w, g: g, header: w.Header().Clone()}
+	g.calledCh = make(chan struct{})
+
+	var v interface{}
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		defer func() { v = recover() }()
+
+		i.injector.Handler(tracked).ServeHTTP(gw, r.WithContext(ctx))
+	}()
+
+	select {
+	case <-g.calledCh:
+	case <-finished:
+	case <-i.clock.After(i.deadline):
+		if g.timeout() {
+			cancel()
+			go i.reporter.Report(i.String(), StateDeadlineExceeded)
+
+			if i.deadlineCode != 0 {
+				http.Error(w, http.StatusText(i.deadlineCode), i.deadlineCode)
+				return
+			}
+			next.ServeHTTP(w, r)
+
+			return
+		}
+	}
+
+	<-finished
+	if v != nil {
+		panic(v)
+	}
+}
+
+// recovered reports a panic with value v.
+func (i *SandboxInjector) recovered(v interface{}) {
+	go i.reporter.Report(i.String(), StatePanicked)
+
+	if pr, ok := i.reporter.(PanicReporter); ok {
+		stack := debug.Stack()
+		go pr.ReportPanic(i.String(), v, stack)
+	}
+}
+
+// Reporter returns the Reporter of the SandboxInjector.
+func (i *SandboxInjector) Reporter() Reporter {
+	return i.reporter
+}
+
+// SetReporter replaces the Reporter of the SandboxInjector.
+func (i *SandboxInjector) SetReporter(r Reporter) {
+	i.reporter = r
+}
+
+// Name returns "sandbox".
+func (i *SandboxInjector) Name() string {
+	return "sandbox"
+}
+
+// Describe returns the sandboxed Injector, the PanicPolicy, and the deadline.
+func (i *SandboxInjector) Describe() map[string]string {
+	return map[string]string{
+		"injector":      InjectorString(i.injector),
+		"policy":        i.policy.String(),
+		"deadline":      i.deadline.String(),
+		"deadline_code": strconv.Itoa(i.deadlineCode),
+	}
+}
+
+// String returns a summary of the SandboxInjector, such as "sandbox(slow(750ms))".
+func (i *SandboxInjector) String() string {
+	return fmt.Sprintf("%s(%s)", i.Name(), InjectorString(i.injector))
+}
+
+// OnEnable runs the OnEnable hook of the Injector.
+func (i *SandboxInjector) OnEnable() {
+	runEnableHook(i.injector)
+}
+
+// OnDisable runs the OnDisable hook of the Injector.
+func (i *SandboxInjector) OnDisable() {
+	runDisableHook(i.injector)
+}
+
+// OnConfigChange runs the OnConfigChange hook of the Injector.
+func (i *SandboxInjector) OnConfigChange() {
+	runConfigChangeHook(i.injector)
+}
+
+// Destructive returns true if the Injector is destructive.
+func (i *SandboxInjector) Destructive() bool {
+	return IsDestructive(i.injector)
+}
+
+// children returns the Injector.
+func (i *SandboxInjector) children() []Injector {
+	return []Injector{i.injector}
+}
+
+// sandboxGuard tracks a request through a SandboxInjector: whether next was called and returned,
+// and whether the Injector ran past its deadline first.
+type sandboxGuard struct {
+	mtx      sync.Mutex
+	called   bool
+	returned bool
+	timedOut bool
+
+	// calledCh, if set, is closed when next is called.
+	calledCh chan struct{}
+}
+
+// call records that next was called and returns true, or returns false if the deadline passed first
+// and next must not run.
+func (g *sandboxGuard) call() bool {
+	g.mtx.Lock()
+	defer g.mtx.Unlock()
+
+	if g.timedOut {
+		return false
+	}
+
+	g.called = true
+	if g.calledCh != nil {
+		close(g.calledCh)
+	}
+
+	return true
+}
+
+// ret records that next returned.
+func (g *sandboxGuard) ret() {
+	g.mtx.Lock()
+	defer g.mtx.Unlock()
+
+	g.returned = true
+}
+
+// nextCalled returns true if next was called.
+func (g *sandboxGuard) nextCalled() bool {
+	g.mtx.Lock()
+	defer g.mtx.Unlock()
+
+	return g.called
+}
+
+// inNext returns true if next was called and has not returned.
+func (g *sandboxGuard) inNext() bool {
+	g.mtx.Lock()
+	defer g.mtx.Unlock()
+
+	return g.called && !g.returned
+}
+
+// timeout records that the deadline passed and returns true, or returns false if next was already
+// called.
+func (g *sandboxGuard) timeout() bool {
+	g.mtx.Lock()
+	defer g.mtx.Unlock()
+
+	if g.called {
+		return false
+	}
+
+	g.timedOut = true
+
+	return true
+}
+
+// guardedWriter is the http.ResponseWriter given to an Injector with a deadline. It holds its own
+// headers so the Injector cannot race the SandboxInjector once the deadline passes, and discards
+// everything written after it.
+type guardedWriter struct {
+	w      http.ResponseWriter
+	g      *sandboxGuard
+	header http.Header
+
+	wroteHeader bool
+}
+
+// Header returns the headers of the Injector. They are sent when it first writes.
+func (gw *guardedWriter) Header() http.Header {
+	return gw.header
+}
+
+// WriteHeader sends the headers and code unless the deadline passed.
+func (gw *guardedWriter) WriteHeader(code int) {
+	gw.g.mtx.Lock()
+	defer gw.g.mtx.Unlock()
+
+	if gw.g.timedOut || gw.wroteHeader {
+		return
+	}
+
+	gw.writeHeader(code)
+}
+
+// Write sends p unless the deadline passed.
+func (gw *guardedWriter) Write(p []byte) (int, error) {
+	gw.g.mtx.Lock()
+	defer gw.g.mtx.Unlock()
+
+	if gw.g.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	if !gw.wroteHeader {
+		gw.writeHeader(http.StatusOK)
+	}
+
+	return gw.w.Write(p)
+}
+
+// Flush flushes the underlying writer unless the deadline passed.
+func (gw *guardedWriter) Flush() {
+	gw.g.mtx.Lock()
+	defer gw.g.mtx.Unlock()
+
+	if gw.g.timedOut {
+		return
+	}
+	if !gw.wroteHeader {
+		gw.writeHeader(http.StatusOK)
+	}
+
+	if f, ok := gw.w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// writeHeader copies the headers of the Injector to the underlying writer and sends code. The lock
+// of the sandboxGuard must be held.
+func (gw *guardedWriter) writeHeader(code int) {
+	dst := gw.w.Header()
+	for k := range dst {
+		delete(dst, k)
+	}
+	for k, v := range gw.header {
+		dst[k] = append([]string(nil), v...)
+	}
+
+	gw.wroteHeader = true
+	gw.w.WriteHeader(code)
+}
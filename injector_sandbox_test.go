@@ -0,0 +1,477 @@
+package fault
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/github/go-fault/faulttest"
+	"github.com/stretchr/testify/assert"
+)
+
+// testInjectorPanic is an Injector that panics with value, before or after calling next.
+type testInjectorPanic struct {
+	value     interface{}
+	afterNext bool
+}
+
+// Handler panics with i.value.
+func (i *testInjectorPanic) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if i.afterNext {
+			next.ServeHTTP(w, r)
+		}
+		panic(i.value)
+	})
+}
+
+// testPanicReporter is a PanicReporter that sends recovered values to a channel.
+type testPanicReporter struct {
+	testReporter
+	panics chan interface{}
+}
+
+// ReportPanic sends recovered to r.panics.
+func (r *testPanicReporter) ReportPanic(name string, recovered interface{}, stack []byte) {
+	r.panics <- recovered
+}
+
+// TestNewSandboxInjector tests NewSandboxInjector.
+func TestNewSandboxInjector(t *testing.T) {
+	t.Parallel()
+
+	clock := faulttest.NewClock(time.Time{})
+
+	tests := []struct {
+		name             string
+		giveInjector     Injector
+		giveOptions      []SandboxInjectorOption
+		wantPolicy       PanicPolicy
+		wantDeadline     time.Duration
+		wantDeadlineCode int
+		wantClock        Clock
+		wantReporter     Reporter
+		wantErr          error
+	}{
+		{
+			name:         "defaults",
+			giveInjector: newTestInjectorNoop(),
+			wantPolicy:   PanicPassThrough,
+			wantClock:    NewRealClock(),
+			wantReporter: NewNoopReporter(),
+		},
+		{
+			name:         "options",
+			giveInjector: newTestInjectorNoop(),
+			giveOptions: []SandboxInjectorOption{
+				WithPanicPolicy(PanicPropagate),
+				WithDeadline(time.Second),
+				WithDeadlineCode(http.StatusServiceUnavailable),
+				WithClock(clock),
+				WithReporter(newTestReporter()),
+			},
+			wantPolicy:       PanicPropagate,
+			wantDeadline:     time.Second,
+			wantDeadlineCode: http.StatusServiceUnavailable,
+			wantClock:        clock,
+			wantReporter:     newTestReporter(),
+		},
+		{
+			name:         "negative deadline",
+			giveInjector: newTestInjectorNoop(),
+			giveOptions: []SandboxInjectorOption{
+				WithDeadline(-time.Second),
+			},
+			wantErr: ErrInvalidDeadline,
+		},
+		{
+			name:         "invalid deadline code",
+			giveInjector: newTestInjectorNoop(),
+			giveOptions: []SandboxInjectorOption{
+				WithDeadlineCode(999),
+			},
+			wantErr: ErrInvalidHTTPCode,
+		},
+		{
+			name:         "nil injector",
+			giveInjector: nil,
+			wantErr:      ErrNilInjector,
+		},
+		{
+			name:         "policy below range",
+			giveInjector: newTestInjectorNoop(),
+			giveOptions: []SandboxInjectorOption{
+				WithPanicPolicy(PanicPolicy(-1)),
+			},
+			wantErr: ErrInvalidPanicPolicy,
+		},
+		{
+			name:         "policy above range",
+			giveInjector: newTestInjectorNoop(),
+			giveOptions: []SandboxInjectorOption{
+				WithPanicPolicy(PanicPropagate + 1),
+			},
+			wantErr: ErrInvalidPanicPolicy,
+		},
+		{
+			name:         "option error",
+			giveInjector: newTestInjectorNoop(),
+			giveOptions: []SandboxInjectorOption{
+				withError(),
+			},
+			wantErr: errErrorOption,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			si, err := NewSandboxInjector(tt.giveInjector, tt.giveOptions...)
+
+			assert.Equal(t, tt.wantErr, err)
+			if tt.wantErr != nil {
+				assert.Nil(t, si)
+				return
+			}
+
+			assert.Equal(t, &SandboxInjector{
+				injector:     tt.giveInjector,
+				policy:       tt.wantPolicy,
+				deadline:     tt.wantDeadline,
+				deadlineCode: tt.wantDeadlineCode,
+				clock:        tt.wantClock,
+				reporter:     tt.wantReporter,
+			}, si)
+		})
+	}
+}
+
+// TestSandboxInjectorHandler tests SandboxInjector.Handler, with and without a deadline that is
+// never reached.
+func TestSandboxInjectorHandler(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		giveInjector  Injector
+		givePolicy    PanicPolicy
+		giveNextPanic bool
+		wantCode      int
+		wantNextCalls int
+		wantPanic     interface{}
+		wantReported  interface{}
+	}{
+		{
+			name:          "no panic",
+			giveInjector:  newTestInjector500s(),
+			givePolicy:    PanicPassThrough,
+			wantCode:      http.StatusInternalServerError,
+			wantNextCalls: 0,
+		},
+		{
+			name:          "pass through",
+			giveInjector:  &testInjectorPanic{value: "boom"},
+			givePolicy:    PanicPassThrough,
+			wantCode:      testHandlerCode,
+			wantNextCalls: 1,
+			wantReported:  "boom",
+		},
+		{
+			name:          "pass through after next",
+			giveInjector:  &testInjectorPanic{value: "boom", afterNext: true},
+			givePolicy:    PanicPassThrough,
+			wantCode:      testHandlerCode,
+			wantNextCalls: 1,
+			wantReported:  "boom",
+		},
+		{
+			name:          "internal server error",
+			giveInjector:  &testInjectorPanic{value: "boom"},
+			givePolicy:    PanicInternalServerError,
+			wantCode:      http.StatusInternalServerError,
+			wantNextCalls: 0,
+			wantReported:  "boom",
+		},
+		{
+			name:          "propagate",
+			giveInjector:  &testInjectorPanic{value: "boom"},
+			givePolicy:    PanicPropagate,
+			wantNextCalls: 0,
+			wantPanic:     "boom",
+			wantReported:  "boom",
+		},
+		{
+			name:          "abort handler",
+			giveInjector:  &testInjectorPanic{value: http.ErrAbortHandler},
+			givePolicy:    PanicPassThrough,
+			wantNextCalls: 0,
+			wantPanic:     http.ErrAbortHandler,
+		},
+		{
+			name:          "panic in next",
+			giveInjector:  newTestInjectorNoop(),
+			givePolicy:    PanicPassThrough,
+			giveNextPanic: true,
+			wantNextCalls: 1,
+			wantPanic:     "next",
+		},
+	}
+
+	for _, tt := range tests {
+		for _, deadline := range []time.Duration{0, time.Hour} {
+			tt, deadline := tt, deadline
+			t.Run(tt.name+"/"+deadline.String(), func(t *testing.T) {
+				t.Parallel()
+
+				reporter := &testPanicReporter{panics: make(chan interface{}, 1)}
+				si, err := NewSandboxInjector(tt.giveInjector,
+					WithPanicPolicy(tt.givePolicy),
+					WithDeadline(deadline),
+					WithReporter(reporter),
+				)
+				assert.NoError(t, err)
+
+				var nextCalls int32
+				h := si.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					atomic.AddInt32(&nextCalls, 1)
+					if tt.giveNextPanic {
+						panic("next")
+					}
+					http.Error(w, testHandlerBody, testHandlerCode)
+				}))
+
+				rr := httptest.NewRecorder()
+				serve := func() { h.ServeHTTP(rr, httptest.NewRequest("GET", "/", nil)) }
+
+				if tt.wantPanic != nil {
+					assert.PanicsWithValue(t, tt.wantPanic, serve)
+				} else {
+					assert.NotPanics(t, serve)
+					assert.Equal(t, tt.wantCode, rr.Code)
+				}
+				assert.Equal(t, tt.wantNextCalls, int(atomic.LoadInt32(&nextCalls)))
+
+				if tt.wantReported != nil {
+					assert.Equal(t, tt.wantReported, <-reporter.panics)
+				} else {
+					assert.Empty(t, reporter.panics)
+				}
+			})
+		}
+	}
+}
+
+// TestSandboxInjectorReporter tests that a SandboxInjector reports StatePanicked.
+func TestSandboxInjectorReporter(t *testing.T) {
+	t.Parallel()
+
+	reporter := newTestStateReporter()
+	si, err := NewSandboxInjector(&testInjectorPanic{value: "boom"}, WithReporter(reporter))
+	assert.NoError(t, err)
+
+	si.Handler(http.NotFoundHandler()).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+
+	assert.Equal(t, StatePanicked, <-reporter.states)
+}
+
+// testInjectorHang is an Injector that sets a header, waits for its request to be canceled, and then
+// writes and continues.
+type testInjectorHang struct {
+	done chan struct{}
+}
+
+// Handler waits for the request to be canceled.
+func (i *testInjectorHang) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer close(i.done)
+
+		w.Header().Set(testHeaderKey, testHeaderVal)
+		<-r.Context().Done()
+
+		w.WriteHeader(http.StatusTeapot)
+		_, err := w.Write([]byte("late"))
+		if err != http.ErrHandlerTimeout {
+			panic(err)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// TestSandboxInjectorDeadline tests that a SandboxInjector continues or fails the request when its
+// Injector runs past the deadline.
+func TestSandboxInjectorDeadline(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		giveCode      int
+		wantCode      int
+		wantBody      string
+		wantNextCalls int32
+	}{
+		{
+			name:          "continue",
+			giveCode:      0,
+			wantCode:      testHandlerCode,
+			wantBody:      testHandlerBody + "\n",
+			wantNextCalls: 1,
+		},
+		{
+			name:          "fail",
+			giveCode:      http.StatusServiceUnavailable,
+			wantCode:      http.StatusServiceUnavailable,
+			wantBody:      http.StatusText(http.StatusServiceUnavailable) + "\n",
+			wantNextCalls: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			clock := faulttest.NewClock(time.Time{})
+			reporter := newTestStateReporter()
+			hang := &testInjectorHang{done: make(chan struct{})}
+
+			si, err := NewSandboxInjector(hang,
+				WithDeadline(time.Second),
+				WithDeadlineCode(tt.giveCode),
+				WithClock(clock),
+				WithReporter(reporter),
+			)
+			assert.NoError(t, err)
+
+			var nextCalls int32
+			h := si.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&nextCalls, 1)
+				http.Error(w, testHandlerBody, testHandlerCode)
+			}))
+
+			rr := httptest.NewRecorder()
+			served := make(chan struct{})
+			go func() {
+				defer close(served)
+				h.ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+			}()
+
+			clock.BlockUntil(1)
+			clock.Advance(time.Second)
+			<-served
+			<-hang.done
+
+			assert.Equal(t, tt.wantCode, rr.Code)
+			assert.Equal(t, tt.wantBody, rr.Body.String())
+			assert.Empty(t, rr.Header().Get(testHeaderKey))
+			assert.Equal(t, tt.wantNextCalls, atomic.LoadInt32(&nextCalls))
+			assert.Equal(t, StateDeadlineExceeded, <-reporter.states)
+		})
+	}
+}
+
+// testResponseWriter is an http.ResponseWriter that does not implement http.Flusher.
+type testResponseWriter struct {
+	http.ResponseWriter
+}
+
+// TestGuardedWriter tests guardedWriter.
+func TestGuardedWriter(t *testing.T) {
+	t.Parallel()
+
+	rr := httptest.NewRecorder()
+	rr.Header().Set("Before", "1")
+	rr.Header().Set("Deleted", "1")
+
+	gw := &guardedWriter{w: rr, g: &sandboxGuard{}, header: rr.Header().Clone()}
+	gw.Header().Set(testHeaderKey, testHeaderVal)
+	gw.Header().Del("Deleted")
+
+	// headers are not sent before the first write
+	assert.Empty(t, rr.Header().Get(testHeaderKey))
+
+	gw.Flush()
+	gw.WriteHeader(http.StatusTeapot)
+	n, err := gw.Write([]byte("body"))
+	assert.NoError(t, err)
+	assert.Equal(t, 4, n)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.True(t, rr.Flushed)
+	assert.Equal(t, "body", rr.Body.String())
+	assert.Equal(t, http.Header{"Before": {"1"}, testHeaderKey: {testHeaderVal}}, rr.Header())
+
+	// nothing is sent once the deadline passed
+	assert.True(t, (&sandboxGuard{}).timeout())
+	gw.g.timedOut = true
+	gw.Flush()
+	gw.WriteHeader(http.StatusTeapot)
+	n, err = gw.Write([]byte("late"))
+	assert.Equal(t, http.ErrHandlerTimeout, err)
+	assert.Equal(t, 0, n)
+	assert.Equal(t, "body", rr.Body.String())
+
+	// WriteHeader sends the code and a writer without Flush is not flushed
+	rr = httptest.NewRecorder()
+	gw = &guardedWriter{w: testResponseWriter{rr}, g: &sandboxGuard{}, header: http.Header{}}
+	gw.WriteHeader(http.StatusTeapot)
+	gw.Flush()
+	assert.Equal(t, http.StatusTeapot, rr.Code)
+	assert.False(t, rr.Flushed)
+
+	// a guard whose next was called cannot time out
+	g := &sandboxGuard{}
+	assert.True(t, g.call())
+	assert.False(t, g.timeout())
+}
+
+// TestSandboxInjectorWraps tests that a SandboxInjector passes hooks and destructiveness through to
+// its Injector.
+func TestSandboxInjectorWraps(t *testing.T) {
+	t.Parallel()
+
+	hooks := newTestInjectorHooks()
+	si, err := NewSandboxInjector(hooks)
+	assert.NoError(t, err)
+
+	si.OnEnable()
+	si.OnDisable()
+	si.OnConfigChange()
+	assert.Equal(t, 1, hooks.enables)
+	assert.Equal(t, 1, hooks.disables)
+	assert.Equal(t, 1, hooks.configChanges)
+
+	assert.False(t, si.Destructive())
+	assert.Equal(t, []Injector{hooks}, si.children())
+
+	di, err := NewSandboxInjector(&testInjectorDestructive{})
+	assert.NoError(t, err)
+	assert.True(t, di.Destructive())
+}
+
+// TestPanicPolicyString tests PanicPolicy.String.
+func TestPanicPolicyString(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		give PanicPolicy
+		want string
+	}{
+		{PanicPassThrough, "pass-through"},
+		{PanicInternalServerError, "internal-server-error"},
+		{PanicPropagate, "propagate"},
+		{PanicPolicy(-1), "unknown"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.want, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, tt.give.String())
+		})
+	}
+}
@@ -0,0 +1,152 @@
+package fault
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+var (
+	// ErrNoCookies when no session cookie names are provided.
+	ErrNoCookies = errors.New("at least one cookie name is required")
+)
+
+// SessionInjector simulates the loss of a backend session store. Requests that carry a session
+// cookie have their cookies cleared and get a 401 Unauthorized response. Requests without a session
+// cookie are not part of a session and continue unchanged.
+type SessionInjector struct {
+	cookies    []string
+	path       string
+	domain     string
+	statusText string
+	reporter   Reporter
+}
+
+// SessionInjectorOption configures a SessionInjector.
+type SessionInjectorOption interface {
+	applySessionInjector(i *SessionInjector) error
+}
+
+func (o statusTextOption) applySessionInjector(i *SessionInjector) error {
+	i.statusText = string(o)
+	return nil
+}
+
+type cookiePathOption string
+
+func (o cookiePathOption) applySessionInjector(i *SessionInjector) error {
+	i.path = string(o)
+	return nil
+}
+
+// WithCookiePath sets the path of the cookies to clear. It must match the path the cookies were set
+// with. Default "/".
+func WithCookiePath(p string) SessionInjectorOption {
+	return cookiePathOption(p)
+}
+
+type cookieDomainOption string
+
+func (o cookieDomainOption) applySessionInjector(i *SessionInjector) error {
+	i.domain = string(o)
+	return nil
+}
+
+// WithCookieDomain sets the domain of the cookies to clear. It must match the domain the cookies
+// were set with. Default "", the host of the request.
+func WithCookieDomain(d string) SessionInjectorOption {
+	return cookieDomainOption(d)
+}
+
+func (o reporterOption) applySessionInjector(i *SessionInjector) error {
+	i.reporter = o.reporter
+	return nil
+}
+
+// NewSessionInjector returns a SessionInjector that clears the cookies with the given names.
+func NewSessionInjector(cookies []string, opts ...SessionInjectorOption) (*SessionInjector, error) {
+	if len(cookies) == 0 {
+		return nil, ErrNoCookies
+	}
+
+	// set defaults
+	si := &SessionInjector{
+		cookies:    cookies,
+		path:       "/",
+		statusText: http.StatusText(http.StatusUnauthorized),
+		reporter:   NewNoopReporter(),
+	}
+
+	// apply options
+	for _, opt := range opts {
+		err := opt.applySessionInjector(si)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return si, nil
+}
+
+// Handler clears the session cookies and responds with 401 Unauthorized if the request has any of
+// them.
+func (i *SessionInjector) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !i.inSession(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		go i.reporter.Report(i.String(), StateStarted)
+		for _, name := range i.cookies {
+			http.SetCookie(w, &http.Cookie{
+				Name:   name,
+				Value:  "",
+				Path:   i.path,
+				Domain: i.domain,
+				MaxAge: -1,
+			})
+		}
+		http.Error(w, i.statusText, http.StatusUnauthorized)
+		go i.reporter.Report(i.String(), StateFinished)
+	})
+}
+
+// inSession returns true if r has any of the session cookies.
+func (i *SessionInjector) inSession(r *http.Request) bool {
+	for _, name := range i.cookies {
+		if _, err := r.Cookie(name); err == nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Name returns "session".
+func (i *SessionInjector) Name() string {
+	return "session"
+}
+
+// Describe returns the cookies the SessionInjector clears.
+func (i *SessionInjector) Describe() map[string]string {
+	return map[string]string{
+		"cookies": strings.Join(i.cookies, ", "),
+	}
+}
+
+// String returns a summary of the SessionInjector, such as "session(session_id)".
+func (i *SessionInjector) String() string {
+	return fmt.Sprintf("%s(%s)", i.Name(), strings.Join(i.cookies, ", "))
+}
+
+// Reporter returns the Reporter of the SessionInjector.
+func (i *SessionInjector) Reporter() Reporter {
+	return i.reporter
+}
+
+// SetReporter replaces the Reporter of the SessionInjector.
+func (i *SessionInjector) SetReporter(r Reporter) {
+	i.reporter = r
+}
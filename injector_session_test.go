@@ -0,0 +1,126 @@
+package fault
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestNewSessionInjector tests NewSessionInjector.
+func TestNewSessionInjector(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		giveCookies []string
+		giveOptions []SessionInjectorOption
+		want        *SessionInjector
+		wantErr     error
+	}{
+		{
+			name:        "defaults",
+			giveCookies: []string{"session"},
+			want: &SessionInjector{
+				cookies:    []string{"session"},
+				path:       "/",
+				statusText: http.StatusText(http.StatusUnauthorized),
+				reporter:   NewNoopReporter(),
+			},
+		},
+		{
+			name:        "options",
+			giveCookies: []string{"session", "remember"},
+			giveOptions: []SessionInjectorOption{
+				WithCookiePath("/app"),
+				WithCookieDomain("example.com"),
+				WithStatusText("session expired"),
+				WithReporter(newTestReporter()),
+			},
+			want: &SessionInjector{
+				cookies:    []string{"session", "remember"},
+				path:       "/app",
+				domain:     "example.com",
+				statusText: "session expired",
+				reporter:   newTestReporter(),
+			},
+		},
+		{
+			name:    "no cookies",
+			wantErr: ErrNoCookies,
+		},
+		{
+			name:        "option error",
+			giveCookies: []string{"session"},
+			giveOptions: []SessionInjectorOption{
+				withError(),
+			},
+			wantErr: errErrorOption,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			si, err := NewSessionInjector(tt.giveCookies, tt.giveOptions...)
+
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.want, si)
+		})
+	}
+}
+
+// TestSessionInjectorHandler tests SessionInjector.Handler.
+func TestSessionInjectorHandler(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		giveCookies   []*http.Cookie
+		wantCode      int
+		wantSetCookie []string
+	}{
+		{
+			name:        "no session",
+			giveCookies: []*http.Cookie{{Name: "other", Value: "1"}},
+			wantCode:    testHandlerCode,
+		},
+		{
+			name:        "in session",
+			giveCookies: []*http.Cookie{{Name: "remember", Value: "1"}},
+			wantCode:    http.StatusUnauthorized,
+			wantSetCookie: []string{
+				"session=; Path=/; Max-Age=0",
+				"remember=; Path=/; Max-Age=0",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			si, err := NewSessionInjector([]string{"session", "remember"})
+			assert.NoError(t, err)
+
+			h := si.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(testHandlerCode)
+			}))
+
+			req := httptest.NewRequest("GET", "/", nil)
+			for _, c := range tt.giveCookies {
+				req.AddCookie(c)
+			}
+
+			rr := httptest.NewRecorder()
+			h.ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.wantCode, rr.Code)
+			assert.Equal(t, tt.wantSetCookie, rr.Header()["Set-Cookie"])
+		})
+	}
+}
@@ -1,16 +1,88 @@
 package fault
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
 	"net/http"
-	"reflect"
+	"strconv"
+	"sync"
 	"time"
 )
 
+var (
+	// ErrInvalidMaxConcurrent when a concurrency limit is negative.
+	ErrInvalidMaxConcurrent = errors.New("max concurrent must be >= 0")
+	// ErrInvalidLatencyRange when the range of a latency distribution is not 0 <= min <= max.
+	ErrInvalidLatencyRange = errors.New("latency range must satisfy 0 <= min <= max")
+	// ErrInvalidParetoShape when the shape of a pareto latency distribution is not positive.
+	ErrInvalidParetoShape = errors.New("pareto shape must be > 0")
+	// ErrInvalidCancelPolicy when an unknown CancelPolicy is passed.
+	ErrInvalidCancelPolicy = errors.New("invalid cancel policy")
+)
+
+// CancelPolicy decides what a SlowInjector does when the context of a request is done while the
+// request waits.
+type CancelPolicy int
+
+const (
+	// CancelContinue stops waiting and continues the request, so the handler sees the done context.
+	CancelContinue CancelPolicy = iota
+	// CancelReturn stops waiting and returns without continuing the request.
+	CancelReturn
+	// CancelAbort stops waiting and aborts the request with http.ErrAbortHandler.
+	CancelAbort
+)
+
+// String returns the name of the CancelPolicy.
+func (p CancelPolicy) String() string {
+	switch p {
+	case CancelContinue:
+		return "continue"
+	case CancelReturn:
+		return "return"
+	case CancelAbort:
+		return "abort"
+	default:
+		return fmt.Sprintf("CancelPolicy(%d)", int(p))
+	}
+}
+
 // SlowInjector waits and then continues the request.
 type SlowInjector struct {
 	duration time.Duration
 	slowF    func(t time.Duration)
+	clock    Clock
 	reporter Reporter
+
+	// dist, if set, picks how long each request waits instead of duration.
+	dist     latencyDistribution
+	randSeed int64
+	rand     *rand.Rand
+	// randF returns a float64 [0.0,1.0) for dist to sample from.
+	randF func() float64
+
+	// *rand.Rand is not thread safe. This mutex protects our random source
+	randMtx sync.Mutex
+
+	// cancelPolicy decides what happens when the context of a waiting request is done.
+	cancelPolicy CancelPolicy
+	// capToDeadline caps the wait to the time left before the deadline of the request's context.
+	capToDeadline bool
+	// phase is the part of the response the wait delays.
+	phase LatencyPhase
+	// transfer is spread across the writes of the body, on top of the wait in phase.
+	transfer time.Duration
+
+	// maxConcurrent limits the requests waiting at once on each route, where 0 is no limit.
+	maxConcurrent int
+	routeF        func(r *http.Request) string
+
+	mtx sync.Mutex
+	// waiting counts the requests waiting on each route.
+	waiting map[string]int
 }
 
 // SlowInjectorOption configures a SlowInjector.
@@ -25,11 +97,269 @@ func (o slowFunctionOption) applySlowInjector(i *SlowInjector) error {
 	return nil
 }
 
-// WithSlowFunc sets the function that will be used to wait the time.Duration.
+// WithSlowFunc sets the function that will be used to wait the time.Duration. The function cannot be
+// interrupted, so a request whose context is done only stops waiting once it returns. Default
+// waiting on the Clock until the duration passes or the context of the request is done.
 func WithSlowFunc(f func(t time.Duration)) SlowInjectorOption {
 	return slowFunctionOption(f)
 }
 
+type maxConcurrentOption int
+
+func (o maxConcurrentOption) applySlowInjector(i *SlowInjector) error {
+	if o < 0 {
+		return ErrInvalidMaxConcurrent
+	}
+
+	i.maxConcurrent = int(o)
+
+	return nil
+}
+
+// MaxConcurrentOption configures Faults and Injectors that limit how many requests they hold at
+// once.
+type MaxConcurrentOption interface {
+	Option
+	SlowInjectorOption
+	ResourcePressureInjectorOption
+}
+
+// WithMaxConcurrent limits how many requests the SlowInjector holds at once on each route. Once n
+// requests are waiting on a route, further requests to it continue without waiting, so an
+// experiment on a hot route cannot tie up every connection of the server. Routes are named by
+// WithRouteFunc(); return the same route for every request to limit them all together. Default 0,
+// no limit.
+//
+// A ResourcePressureInjector limits the requests applying pressure at once across all routes;
+// further requests run without pressure. Default 1; 0 is no limit.
+//
+// A Fault limits the requests its Injector runs on at once, whatever the Injector. Requests the
+// Fault selects beyond the limit pass through to the handler and are skipped with
+// SkipConcurrency, so a slow Injector cannot exhaust the connections of a busy server. Default 0,
+// no limit.
+func WithMaxConcurrent(n int) MaxConcurrentOption {
+	return maxConcurrentOption(n)
+}
+
+type cancelPolicyOption CancelPolicy
+
+func (o cancelPolicyOption) applySlowInjector(i *SlowInjector) error {
+	if o < cancelPolicyOption(CancelContinue) || o > cancelPolicyOption(CancelAbort) {
+		return ErrInvalidCancelPolicy
+	}
+
+	i.cancelPolicy = CancelPolicy(o)
+
+	return nil
+}
+
+// WithCancelPolicy sets what the SlowInjector does when the context of a request is done while it
+// waits, such as when the client disconnects. Default CancelContinue. It only applies to
+// LatencyBeforeHeaders; in the later phases of WithLatencyPhase() the handler has already run, and
+// its writes fail with the error of the context instead.
+func WithCancelPolicy(p CancelPolicy) SlowInjectorOption {
+	return cancelPolicyOption(p)
+}
+
+type capToDeadlineOption bool
+
+func (o capToDeadlineOption) applySlowInjector(i *SlowInjector) error {
+	i.capToDeadline = bool(o)
+	return nil
+}
+
+// WithCapToDeadline caps the wait of each request to the time left before the deadline of its
+// context, as told by the Clock, so a request never waits past its deadline. Requests without a
+// deadline wait the full duration.
+func WithCapToDeadline() SlowInjectorOption {
+	return capToDeadlineOption(true)
+}
+
+type transferLatencyOption time.Duration
+
+func (o transferLatencyOption) applySlowInjector(i *SlowInjector) error {
+	if o < 0 {
+		return ErrInvalidDelay
+	}
+
+	i.transfer = time.Duration(o)
+
+	return nil
+}
+
+// WithTransferLatency spreads d across the writes of the body, as with LatencySpreadBody, on top of
+// the wait of the SlowInjector in its LatencyPhase. Together they slow the time to first byte and
+// the transfer of the body separately:
+//
+//	NewSlowInjector(2*time.Second, WithTransferLatency(5*time.Second))
+//
+// waits 2s before the response starts and then takes 5s more to send the body. Default 0.
+func WithTransferLatency(d time.Duration) SlowInjectorOption {
+	return transferLatencyOption(d)
+}
+
+// latencyDistribution is a distribution of latencies a SlowInjector waits.
+type latencyDistribution interface {
+	// sample returns a latency from randF, which returns a float64 [0.0,1.0).
+	sample(randF func() float64) time.Duration
+	// longest returns the longest latency the distribution practically samples.
+	longest() time.Duration
+	// String returns a summary of the distribution, such as "uniform 50ms..150ms".
+	String() string
+}
+
+// uniformLatency is a latency equally likely to be anything between min and max.
+type uniformLatency struct {
+	min time.Duration
+	max time.Duration
+}
+
+func (d uniformLatency) sample(randF func() float64) time.Duration {
+	return d.min + time.Duration(randF()*float64(d.max-d.min))
+}
+
+func (d uniformLatency) longest() time.Duration {
+	return d.max
+}
+
+func (d uniformLatency) String() string {
+	return fmt.Sprintf("uniform %s..%s", d.min, d.max)
+}
+
+func (d uniformLatency) applySlowInjector(i *SlowInjector) error {
+	if d.min < 0 || d.max < d.min {
+		return ErrInvalidLatencyRange
+	}
+
+	i.dist = d
+
+	return nil
+}
+
+// WithJitter waits a random duration between min and max, all equally likely, instead of the
+// duration of the SlowInjector.
+func WithJitter(min, max time.Duration) SlowInjectorOption {
+	return uniformLatency{min: min, max: max}
+}
+
+// normalLatency is a normally distributed latency. Latencies below 0 are 0.
+type normalLatency struct {
+	mean   time.Duration
+	stddev time.Duration
+}
+
+func (d normalLatency) sample(randF func() float64) time.Duration {
+	// Box-Muller transform, with 1-randF() in (0.0,1.0] so the log is finite
+	z := math.Sqrt(-2*math.Log(1-randF())) * math.Cos(2*math.Pi*randF())
+	l := time.Duration(float64(d.mean) + z*float64(d.stddev))
+	if l < 0 {
+		return 0
+	}
+
+	return l
+}
+
+// longest returns the mean plus three standard deviations, which 99.9% of latencies are below.
+func (d normalLatency) longest() time.Duration {
+	return d.mean + 3*d.stddev
+}
+
+func (d normalLatency) String() string {
+	return fmt.Sprintf("normal %s stddev %s", d.mean, d.stddev)
+}
+
+func (d normalLatency) applySlowInjector(i *SlowInjector) error {
+	if d.mean < 0 || d.stddev < 0 {
+		return ErrInvalidLatency
+	}
+
+	i.dist = d
+
+	return nil
+}
+
+// WithNormalLatency waits a normally distributed duration around mean instead of the duration of
+// the SlowInjector. Durations that would be negative are 0.
+func WithNormalLatency(mean, stddev time.Duration) SlowInjectorOption {
+	return normalLatency{mean: mean, stddev: stddev}
+}
+
+// paretoLatency is a latency from a pareto distribution bounded by min and max.
+type paretoLatency struct {
+	min   time.Duration
+	max   time.Duration
+	shape float64
+}
+
+func (d paretoLatency) sample(randF func() float64) time.Duration {
+	if d.min == d.max {
+		return d.min
+	}
+
+	// inverse of the CDF of the bounded pareto distribution
+	r := math.Pow(float64(d.min)/float64(d.max), d.shape)
+	l := float64(d.min) * math.Pow(1-randF()*(1-r), -1/d.shape)
+
+	return time.Duration(math.Min(l, float64(d.max)))
+}
+
+func (d paretoLatency) longest() time.Duration {
+	return d.max
+}
+
+func (d paretoLatency) String() string {
+	return fmt.Sprintf("pareto %s..%s shape %g", d.min, d.max, d.shape)
+}
+
+func (d paretoLatency) applySlowInjector(i *SlowInjector) error {
+	if d.min <= 0 || d.max < d.min {
+		return ErrInvalidLatencyRange
+	}
+	if !(d.shape > 0) {
+		return ErrInvalidParetoShape
+	}
+
+	i.dist = d
+
+	return nil
+}
+
+// WithParetoLatency waits a duration between min and max from a pareto distribution instead of the
+// duration of the SlowInjector, so most requests wait close to min and a few wait much longer.
+// Lower shapes give longer tails; 1.16 gives the 80/20 rule. min must be > 0.
+func WithParetoLatency(min, max time.Duration, shape float64) SlowInjectorOption {
+	return paretoLatency{min: min, max: max, shape: shape}
+}
+
+func (o randSeedOption) applySlowInjector(i *SlowInjector) error {
+	i.randSeed = int64(o)
+	return nil
+}
+
+type randFloat64FuncOption func() float64
+
+func (o randFloat64FuncOption) applySlowInjector(i *SlowInjector) error {
+	i.randF = o
+	return nil
+}
+
+// WithRandFloat64Func sets the function that the latency distribution of the SlowInjector samples,
+// for deterministic tests. It must return a float64 between [0.0,1.0). Default a rand.Rand seeded
+// with WithRandSeed().
+func WithRandFloat64Func(f func() float64) SlowInjectorOption {
+	return randFloat64FuncOption(f)
+}
+
+func (o routeFuncOption) applySlowInjector(i *SlowInjector) error {
+	i.routeF = o
+	return nil
+}
+
+func (o clockOption) applySlowInjector(i *SlowInjector) error {
+	i.clock = o.clock
+	return nil
+}
+
 func (o reporterOption) applySlowInjector(i *SlowInjector) error {
 	i.reporter = o.reporter
 	return nil
@@ -40,8 +370,10 @@ func NewSlowInjector(d time.Duration, opts ...SlowInjectorOption) (*SlowInjector
 	// set defaults
 	si := &SlowInjector{
 		duration: d,
-		slowF:    time.Sleep,
+		slowF:    nil,
+		clock:    NewRealClock(),
 		reporter: NewNoopReporter(),
+		randSeed: defaultRandSeed,
 	}
 
 	// apply options
@@ -52,16 +384,223 @@ func NewSlowInjector(d time.Duration, opts ...SlowInjectorOption) (*SlowInjector
 		}
 	}
 
+	// sample from our seeded source unless a custom function was set
+	if si.dist != nil && si.randF == nil {
+		si.rand = rand.New(rand.NewSource(si.randSeed))
+		si.randF = si.randFloat64
+	}
+
 	return si, nil
 }
 
-// Handler runs i.slowF to wait the set duration and then continues.
+// Handler waits the set duration and then continues, or delays the body of the response with
+// WithLatencyPhase(), and spreads WithTransferLatency() across the body. If the context of the
+// request is done first it stops waiting and follows the CancelPolicy. If the route of the request
+// is at the concurrency limit it reports StateSkipped and continues without waiting.
 func (i *SlowInjector) Handler(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		go i.reporter.Report(reflect.ValueOf(*i).Type().Name(), StateStarted)
-		i.slowF(i.duration)
-		go i.reporter.Report(reflect.ValueOf(*i).Type().Name(), StateFinished)
+		route, ok := i.acquire(r)
+		if !ok {
+			go i.reporter.Report(i.String(), StateSkipped)
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		go i.reporter.Report(i.String(), StateStarted)
 
-		next.ServeHTTP(w, r)
+		wait := func(d time.Duration) bool {
+			return i.wait(r.Context(), d)
+		}
+
+		if i.phase != LatencyBeforeHeaders {
+			// the slot is held while next runs, and released even if next panics
+			defer i.release(route)
+
+			d := i.latency()
+			i.transferHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				serveDelayedBody(i.phase, d, wait, next, w, r)
+			}), wait).ServeHTTP(w, r)
+			go i.reporter.Report(i.String(), StateFinished)
+			return
+		}
+
+		waited := i.wait(r.Context(), i.latency())
+		go i.reporter.Report(i.String(), StateFinished)
+
+		i.release(route)
+
+		if !waited {
+			switch i.cancelPolicy {
+			case CancelReturn:
+				return
+			case CancelAbort:
+				abort()
+			}
+		}
+
+		i.transferHandler(next, wait).ServeHTTP(w, r)
+	})
+}
+
+// transferHandler returns next with the transfer latency spread across the writes of its body by
+// wait, after any wait of next, or next if there is no transfer latency.
+func (i *SlowInjector) transferHandler(next http.Handler, wait func(d time.Duration) bool) http.Handler {
+	if i.transfer == 0 {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		serveDelayedBody(LatencySpreadBody, i.transfer, wait, next, w, r)
 	})
 }
+
+// wait waits d, capped to the deadline of ctx with WithCapToDeadline, and returns false if ctx was
+// done before the wait ended.
+func (i *SlowInjector) wait(ctx context.Context, d time.Duration) bool {
+	if deadline, ok := ctx.Deadline(); ok && i.capToDeadline {
+		if left := deadline.Sub(i.clock.Now()); left < d {
+			d = left
+		}
+	}
+
+	d = addLatency(ctx, d)
+
+	if i.slowF != nil {
+		i.slowF(d)
+		return ctx.Err() == nil
+	}
+
+	select {
+	case <-i.clock.After(d):
+		return ctx.Err() == nil
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// latency returns how long to wait: a sample of the latency distribution, if set, or the duration.
+func (i *SlowInjector) latency() time.Duration {
+	if i.dist == nil {
+		return i.duration
+	}
+
+	return i.dist.sample(i.randF)
+}
+
+// maxLatency returns the longest the SlowInjector practically waits.
+func (i *SlowInjector) maxLatency() time.Duration {
+	if i.dist == nil {
+		return i.duration
+	}
+
+	return i.dist.longest()
+}
+
+// randFloat64 returns a float64 [0.0,1.0) from the random source of the SlowInjector.
+func (i *SlowInjector) randFloat64() float64 {
+	i.randMtx.Lock()
+	defer i.randMtx.Unlock()
+
+	return i.rand.Float64()
+}
+
+// Seed returns the seed of the SlowInjector's random number generator.
+func (i *SlowInjector) Seed() int64 {
+	return i.randSeed
+}
+
+// acquire returns the route of r and true if r may wait, counting it as waiting until release is
+// called. It returns false if the route is at the concurrency limit.
+func (i *SlowInjector) acquire(r *http.Request) (string, bool) {
+	if i.maxConcurrent == 0 {
+		return "", true
+	}
+
+	route := r.URL.Path
+	if i.routeF != nil {
+		route = i.routeF(r)
+	}
+
+	i.mtx.Lock()
+	defer i.mtx.Unlock()
+
+	if i.waiting[route] >= i.maxConcurrent {
+		return "", false
+	}
+
+	if i.waiting == nil {
+		i.waiting = map[string]int{}
+	}
+	i.waiting[route]++
+
+	return route, true
+}
+
+// release stops counting a request acquired on route as waiting.
+func (i *SlowInjector) release(route string) {
+	if i.maxConcurrent == 0 {
+		return
+	}
+
+	i.mtx.Lock()
+	defer i.mtx.Unlock()
+
+	i.waiting[route]--
+	if i.waiting[route] == 0 {
+		delete(i.waiting, route)
+	}
+}
+
+// Reporter returns the Reporter of the SlowInjector.
+func (i *SlowInjector) Reporter() Reporter {
+	return i.reporter
+}
+
+// SetReporter replaces the Reporter of the SlowInjector.
+func (i *SlowInjector) SetReporter(r Reporter) {
+	i.reporter = r
+}
+
+// Name returns "slow".
+func (i *SlowInjector) Name() string {
+	return "slow"
+}
+
+// Describe returns the duration the SlowInjector waits, or its latency distribution and random seed,
+// and its concurrency limit, CancelPolicy, deadline cap, LatencyPhase, and transfer latency, if set.
+func (i *SlowInjector) Describe() map[string]string {
+	d := map[string]string{
+		"duration": i.duration.String(),
+	}
+	if i.dist != nil {
+		d["duration"] = i.dist.String()
+		d["seed"] = strconv.FormatInt(i.randSeed, 10)
+	}
+	if i.maxConcurrent > 0 {
+		d["max_concurrent"] = strconv.Itoa(i.maxConcurrent)
+	}
+	if i.cancelPolicy != CancelContinue {
+		d["cancel_policy"] = i.cancelPolicy.String()
+	}
+	if i.capToDeadline {
+		d["cap_to_deadline"] = "true"
+	}
+	if i.phase != LatencyBeforeHeaders {
+		d["phase"] = i.phase.String()
+	}
+	if i.transfer > 0 {
+		d["transfer"] = i.transfer.String()
+	}
+
+	return d
+}
+
+// String returns a summary of the SlowInjector, such as "slow(750ms)" or
+// "slow(uniform 50ms..150ms)".
+func (i *SlowInjector) String() string {
+	if i.dist != nil {
+		return fmt.Sprintf("%s(%s)", i.Name(), i.dist)
+	}
+
+	return fmt.Sprintf("%s(%s)", i.Name(), i.duration)
+}
@@ -0,0 +1,166 @@
+package fault
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+var (
+	// ErrInvalidChunkSize when a SlowBodyInjector chunk size is not positive.
+	ErrInvalidChunkSize = errors.New("chunk size must be > 0")
+)
+
+// SlowBodyInjector runs the request and sends the response body in chunks of a few bytes, waiting
+// between chunks, to simulate a slow network. Unlike a SlowInjector, which waits before the request
+// runs, it holds the response after its headers are sent, so clients hit their body read timeouts
+// rather than their response header timeouts.
+type SlowBodyInjector struct {
+	chunkSize int
+	delay     time.Duration
+	clock     Clock
+	reporter  Reporter
+}
+
+// SlowBodyInjectorOption configures a SlowBodyInjector.
+type SlowBodyInjectorOption interface {
+	applySlowBodyInjector(i *SlowBodyInjector) error
+}
+
+func (o clockOption) applySlowBodyInjector(i *SlowBodyInjector) error {
+	i.clock = o.clock
+	return nil
+}
+
+func (o reporterOption) applySlowBodyInjector(i *SlowBodyInjector) error {
+	i.reporter = o.reporter
+	return nil
+}
+
+// NewSlowBodyInjector returns a SlowBodyInjector that sends chunks of chunkSize bytes and waits
+// delay between them. It returns ErrInvalidChunkSize if chunkSize is not positive and
+// ErrInvalidDelay if delay is negative.
+func NewSlowBodyInjector(chunkSize int, delay time.Duration, opts ...SlowBodyInjectorOption) (
+	*SlowBodyInjector, error,
+) {
+	if chunkSize <= 0 {
+		return nil, ErrInvalidChunkSize
+	}
+	if delay < 0 {
+		return nil, ErrInvalidDelay
+	}
+
+	// set defaults
+	si := &SlowBodyInjector{
+		chunkSize: chunkSize,
+		delay:     delay,
+		clock:     NewRealClock(),
+		reporter:  NewNoopReporter(),
+	}
+
+	// apply options
+	for _, opt := range opts {
+		err := opt.applySlowBodyInjector(si)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return si, nil
+}
+
+// Handler runs the request with a ResponseWriter that splits the body into chunks, flushes each
+// chunk to the client, and waits before the next. Writes fail with the error of the request's
+// context once it is done, so a client that gives up does not hold the handler.
+func (i *SlowBodyInjector) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		go i.reporter.Report(i.String(), StateStarted)
+		next.ServeHTTP(&slowBodyWriter{ResponseWriter: w, injector: i, ctx: r.Context()}, r)
+		go i.reporter.Report(i.String(), StateFinished)
+	})
+}
+
+// Reporter returns the Reporter of the SlowBodyInjector.
+func (i *SlowBodyInjector) Reporter() Reporter {
+	return i.reporter
+}
+
+// SetReporter replaces the Reporter of the SlowBodyInjector.
+func (i *SlowBodyInjector) SetReporter(r Reporter) {
+	i.reporter = r
+}
+
+// Name returns "slow_body".
+func (i *SlowBodyInjector) Name() string {
+	return "slow_body"
+}
+
+// Describe returns the chunk size and the delay between chunks.
+func (i *SlowBodyInjector) Describe() map[string]string {
+	return map[string]string{
+		"chunk_size": strconv.Itoa(i.chunkSize),
+		"delay":      i.delay.String(),
+	}
+}
+
+// String returns a summary of the SlowBodyInjector, such as "slow_body(64B/100ms)".
+func (i *SlowBodyInjector) String() string {
+	return fmt.Sprintf("%s(%dB/%s)", i.Name(), i.chunkSize, i.delay)
+}
+
+// slowBodyWriter is an http.ResponseWriter that writes the body in delayed chunks.
+type slowBodyWriter struct {
+	http.ResponseWriter
+	injector *SlowBodyInjector
+	ctx      context.Context
+
+	// started is true once the first chunk is written; there is no wait before it.
+	started bool
+}
+
+// Write writes b in chunks, waiting and checking the context before every chunk but the first of
+// the body. It returns the bytes written before the context was done.
+func (w *slowBodyWriter) Write(b []byte) (int, error) {
+	var n int
+	for len(b) > 0 {
+		if w.started {
+			w.injector.clock.Sleep(addLatency(w.ctx, w.injector.delay))
+		}
+		w.started = true
+
+		if err := w.ctx.Err(); err != nil {
+			return n, err
+		}
+
+		size := w.injector.chunkSize
+		if size > len(b) {
+			size = len(b)
+		}
+
+		written, err := w.ResponseWriter.Write(b[:size])
+		n += written
+		if err != nil {
+			return n, err
+		}
+		w.Flush()
+
+		b = b[size:]
+	}
+
+	return n, nil
+}
+
+// Flush flushes the underlying ResponseWriter if it can.
+func (w *slowBodyWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap returns the underlying ResponseWriter for http.ResponseController.
+func (w *slowBodyWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,251 @@
+package fault
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestNewSlowBodyInjector tests NewSlowBodyInjector.
+func TestNewSlowBodyInjector(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		giveChunkSize int
+		giveDelay     time.Duration
+		giveOptions   []SlowBodyInjectorOption
+		wantErr       error
+	}{
+		{
+			name:          "defaults",
+			giveChunkSize: 1,
+			giveDelay:     time.Millisecond,
+		},
+		{
+			name:          "options",
+			giveChunkSize: 64,
+			giveDelay:     0,
+			giveOptions: []SlowBodyInjectorOption{
+				WithReporter(newTestReporter()),
+				WithClock(&testSleepClock{}),
+			},
+		},
+		{
+			name:          "zero chunk size",
+			giveChunkSize: 0,
+			giveDelay:     time.Millisecond,
+			wantErr:       ErrInvalidChunkSize,
+		},
+		{
+			name:          "negative delay",
+			giveChunkSize: 1,
+			giveDelay:     -time.Millisecond,
+			wantErr:       ErrInvalidDelay,
+		},
+		{
+			name:          "option error",
+			giveChunkSize: 1,
+			giveOptions:   []SlowBodyInjectorOption{withError()},
+			wantErr:       errErrorOption,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			si, err := NewSlowBodyInjector(tt.giveChunkSize, tt.giveDelay, tt.giveOptions...)
+
+			assert.Equal(t, tt.wantErr, err)
+			if tt.wantErr != nil {
+				assert.Nil(t, si)
+				return
+			}
+
+			assert.Equal(t, tt.giveChunkSize, si.chunkSize)
+			assert.Equal(t, tt.giveDelay, si.delay)
+		})
+	}
+}
+
+// testChunkRecorder is an httptest.ResponseRecorder that records the body of each write.
+type testChunkRecorder struct {
+	*httptest.ResponseRecorder
+
+	chunks  []string
+	flushes int
+}
+
+// Write records b.
+func (r *testChunkRecorder) Write(b []byte) (int, error) {
+	r.chunks = append(r.chunks, string(b))
+	return r.ResponseRecorder.Write(b)
+}
+
+// Flush counts the flush.
+func (r *testChunkRecorder) Flush() {
+	r.flushes++
+	r.ResponseRecorder.Flush()
+}
+
+// TestSlowBodyInjectorHandler tests that SlowBodyInjector.Handler writes the body in chunks and
+// waits between them.
+func TestSlowBodyInjectorHandler(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		giveChunkSize int
+		giveWrites    []string
+		wantChunks    []string
+		wantSleeps    []time.Duration
+	}{
+		{
+			name:          "one write",
+			giveChunkSize: 3,
+			giveWrites:    []string{"Accepted"},
+			wantChunks:    []string{"Acc", "ept", "ed"},
+			wantSleeps:    []time.Duration{time.Second, time.Second},
+		},
+		{
+			name:          "many writes",
+			giveChunkSize: 3,
+			giveWrites:    []string{"A", "ccep", "", "ted"},
+			wantChunks:    []string{"A", "cce", "p", "ted"},
+			wantSleeps:    []time.Duration{time.Second, time.Second, time.Second},
+		},
+		{
+			name:          "chunk larger than body",
+			giveChunkSize: 1024,
+			giveWrites:    []string{"Accepted"},
+			wantChunks:    []string{"Accepted"},
+			wantSleeps:    nil,
+		},
+		{
+			name:          "no body",
+			giveChunkSize: 1,
+			giveWrites:    nil,
+			wantChunks:    nil,
+			wantSleeps:    nil,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			clock := &testSleepClock{}
+			reporter := &testStateReporter{states: make(chan InjectorState, 2)}
+
+			si, err := NewSlowBodyInjector(tt.giveChunkSize, time.Second, WithClock(clock), WithReporter(reporter))
+			assert.NoError(t, err)
+
+			rr := &testChunkRecorder{ResponseRecorder: httptest.NewRecorder()}
+			si.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				// the handler can still reach the ResponseWriter it wraps
+				assert.True(t, errors.Is(http.NewResponseController(w).EnableFullDuplex(), http.ErrNotSupported))
+
+				w.WriteHeader(testHandlerCode)
+				for _, write := range tt.giveWrites {
+					n, err := w.Write([]byte(write))
+					assert.NoError(t, err)
+					assert.Equal(t, len(write), n)
+				}
+			})).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			assert.Equal(t, testHandlerCode, rr.Code)
+			assert.Equal(t, tt.wantChunks, rr.chunks)
+			assert.Equal(t, len(tt.wantChunks), rr.flushes)
+			assert.Equal(t, tt.wantSleeps, clock.sleeps)
+
+			states := map[InjectorState]int{}
+			for n := 0; n < 2; n++ {
+				states[<-reporter.states]++
+			}
+			assert.Equal(t, map[InjectorState]int{StateStarted: 1, StateFinished: 1}, states)
+		})
+	}
+}
+
+// TestSlowBodyInjectorHandlerCanceled tests that writes stop once the context of the request is
+// done.
+func TestSlowBodyInjectorHandlerCanceled(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	clock := &testCancelClock{cancel: cancel}
+
+	si, err := NewSlowBodyInjector(3, time.Second, WithClock(clock))
+	assert.NoError(t, err)
+
+	rr := &testChunkRecorder{ResponseRecorder: httptest.NewRecorder()}
+	si.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n, err := w.Write([]byte("Accepted"))
+		assert.Equal(t, 3, n)
+		assert.Equal(t, context.Canceled, err)
+	})).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx))
+
+	assert.Equal(t, []string{"Acc"}, rr.chunks)
+}
+
+// testCancelClock is a Clock that cancels a context instead of sleeping.
+type testCancelClock struct {
+	RealClock
+
+	cancel context.CancelFunc
+}
+
+// Sleep cancels the context.
+func (c *testCancelClock) Sleep(time.Duration) {
+	c.cancel()
+}
+
+// TestSlowBodyInjectorHandlerWriteError tests that a failed write of a chunk stops the write.
+func TestSlowBodyInjectorHandlerWriteError(t *testing.T) {
+	t.Parallel()
+
+	si, err := NewSlowBodyInjector(3, time.Second, WithClock(&testSleepClock{}))
+	assert.NoError(t, err)
+
+	w := &slowBodyWriter{ResponseWriter: testFailingWriter{httptest.NewRecorder()}, injector: si, ctx: context.Background()}
+	n, err := w.Write([]byte("Accepted"))
+
+	assert.Equal(t, 0, n)
+	assert.Equal(t, errTestWrite, err)
+
+	// a ResponseWriter that cannot flush is not flushed
+	w.Flush()
+}
+
+// errTestWrite is returned by testFailingWriter.
+var errTestWrite = errors.New("write failed")
+
+// testFailingWriter is an http.ResponseWriter whose writes fail.
+type testFailingWriter struct {
+	http.ResponseWriter
+}
+
+// Write fails.
+func (w testFailingWriter) Write([]byte) (int, error) {
+	return 0, errTestWrite
+}
+
+// TestSlowBodyInjectorReporter tests SlowBodyInjector.Reporter and SetReporter.
+func TestSlowBodyInjectorReporter(t *testing.T) {
+	t.Parallel()
+
+	si, err := NewSlowBodyInjector(1, time.Millisecond)
+	assert.NoError(t, err)
+
+	reporter := newTestReporter()
+	si.SetReporter(reporter)
+	assert.Equal(t, reporter, si.Reporter())
+}
@@ -1,10 +1,16 @@
 package fault
 
 import (
+	"context"
+	"math"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/github/go-fault/faulttest"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -26,7 +32,9 @@ func TestNewSlowInjector(t *testing.T) {
 			want: &SlowInjector{
 				duration: 0,
 				slowF:    time.Sleep,
+				clock:    NewRealClock(),
 				reporter: NewNoopReporter(),
+				randSeed: defaultRandSeed,
 			},
 			wantErr: nil,
 		},
@@ -37,7 +45,9 @@ func TestNewSlowInjector(t *testing.T) {
 			want: &SlowInjector{
 				duration: 0,
 				slowF:    time.Sleep,
+				clock:    NewRealClock(),
 				reporter: NewNoopReporter(),
+				randSeed: defaultRandSeed,
 			},
 			wantErr: nil,
 		},
@@ -48,7 +58,9 @@ func TestNewSlowInjector(t *testing.T) {
 			want: &SlowInjector{
 				duration: time.Minute,
 				slowF:    time.Sleep,
+				clock:    NewRealClock(),
 				reporter: NewNoopReporter(),
+				randSeed: defaultRandSeed,
 			},
 			wantErr: nil,
 		},
@@ -61,7 +73,9 @@ func TestNewSlowInjector(t *testing.T) {
 			want: &SlowInjector{
 				duration: time.Minute,
 				slowF:    func(time.Duration) {},
+				clock:    NewRealClock(),
 				reporter: NewNoopReporter(),
+				randSeed: defaultRandSeed,
 			},
 			wantErr: nil,
 		},
@@ -74,10 +88,212 @@ func TestNewSlowInjector(t *testing.T) {
 			want: &SlowInjector{
 				duration: time.Minute,
 				slowF:    time.Sleep,
+				clock:    NewRealClock(),
 				reporter: newTestReporter(),
+				randSeed: defaultRandSeed,
 			},
 			wantErr: nil,
 		},
+		{
+			name:         "custom clock",
+			giveDuration: time.Minute,
+			giveOptions: []SlowInjectorOption{
+				WithClock(faulttest.NewClock(time.Time{})),
+			},
+			want: &SlowInjector{
+				duration: time.Minute,
+				slowF:    faulttest.NewClock(time.Time{}).Sleep,
+				clock:    faulttest.NewClock(time.Time{}),
+				reporter: NewNoopReporter(),
+				randSeed: defaultRandSeed,
+			},
+			wantErr: nil,
+		},
+		{
+			name:         "max concurrent",
+			giveDuration: time.Minute,
+			giveOptions: []SlowInjectorOption{
+				WithMaxConcurrent(2),
+				WithRouteFunc(func(*http.Request) string { return "" }),
+			},
+			want: &SlowInjector{
+				duration:      time.Minute,
+				slowF:         time.Sleep,
+				clock:         NewRealClock(),
+				reporter:      NewNoopReporter(),
+				maxConcurrent: 2,
+				randSeed:      defaultRandSeed,
+			},
+			wantErr: nil,
+		},
+		{
+			name:         "jitter",
+			giveDuration: time.Minute,
+			giveOptions: []SlowInjectorOption{
+				WithJitter(50*time.Millisecond, 150*time.Millisecond),
+			},
+			want: &SlowInjector{
+				duration: time.Minute,
+				slowF:    time.Sleep,
+				clock:    NewRealClock(),
+				reporter: NewNoopReporter(),
+				dist:     uniformLatency{min: 50 * time.Millisecond, max: 150 * time.Millisecond},
+				randSeed: defaultRandSeed,
+				rand:     rand.New(rand.NewSource(defaultRandSeed)),
+			},
+			wantErr: nil,
+		},
+		{
+			name:         "normal latency with seed",
+			giveDuration: 0,
+			giveOptions: []SlowInjectorOption{
+				WithNormalLatency(100*time.Millisecond, 20*time.Millisecond),
+				WithRandSeed(42),
+			},
+			want: &SlowInjector{
+				duration: 0,
+				slowF:    time.Sleep,
+				clock:    NewRealClock(),
+				reporter: NewNoopReporter(),
+				dist:     normalLatency{mean: 100 * time.Millisecond, stddev: 20 * time.Millisecond},
+				randSeed: 42,
+				rand:     rand.New(rand.NewSource(42)),
+			},
+			wantErr: nil,
+		},
+		{
+			name:         "pareto latency with rand func",
+			giveDuration: 0,
+			giveOptions: []SlowInjectorOption{
+				WithParetoLatency(50*time.Millisecond, 5*time.Second, 1.16),
+				WithRandFloat64Func(func() float64 { return 0.5 }),
+			},
+			want: &SlowInjector{
+				duration: 0,
+				slowF:    time.Sleep,
+				clock:    NewRealClock(),
+				reporter: NewNoopReporter(),
+				dist:     paretoLatency{min: 50 * time.Millisecond, max: 5 * time.Second, shape: 1.16},
+				randSeed: defaultRandSeed,
+			},
+			wantErr: nil,
+		},
+		{
+			name:         "negative jitter",
+			giveDuration: 0,
+			giveOptions:  []SlowInjectorOption{WithJitter(-time.Millisecond, time.Millisecond)},
+			want:         nil,
+			wantErr:      ErrInvalidLatencyRange,
+		},
+		{
+			name:         "inverted jitter",
+			giveDuration: 0,
+			giveOptions:  []SlowInjectorOption{WithJitter(time.Second, time.Millisecond)},
+			want:         nil,
+			wantErr:      ErrInvalidLatencyRange,
+		},
+		{
+			name:         "negative mean",
+			giveDuration: 0,
+			giveOptions:  []SlowInjectorOption{WithNormalLatency(-time.Millisecond, time.Millisecond)},
+			want:         nil,
+			wantErr:      ErrInvalidLatency,
+		},
+		{
+			name:         "negative stddev",
+			giveDuration: 0,
+			giveOptions:  []SlowInjectorOption{WithNormalLatency(time.Millisecond, -time.Millisecond)},
+			want:         nil,
+			wantErr:      ErrInvalidLatency,
+		},
+		{
+			name:         "zero pareto min",
+			giveDuration: 0,
+			giveOptions:  []SlowInjectorOption{WithParetoLatency(0, time.Second, 1)},
+			want:         nil,
+			wantErr:      ErrInvalidLatencyRange,
+		},
+		{
+			name:         "inverted pareto",
+			giveDuration: 0,
+			giveOptions:  []SlowInjectorOption{WithParetoLatency(time.Second, time.Millisecond, 1)},
+			want:         nil,
+			wantErr:      ErrInvalidLatencyRange,
+		},
+		{
+			name:         "invalid pareto shape",
+			giveDuration: 0,
+			giveOptions:  []SlowInjectorOption{WithParetoLatency(time.Millisecond, time.Second, 0)},
+			want:         nil,
+			wantErr:      ErrInvalidParetoShape,
+		},
+		{
+			name:         "NaN pareto shape",
+			giveDuration: 0,
+			giveOptions:  []SlowInjectorOption{WithParetoLatency(time.Millisecond, time.Second, math.NaN())},
+			want:         nil,
+			wantErr:      ErrInvalidParetoShape,
+		},
+		{
+			name:         "cancel policy and deadline cap",
+			giveDuration: time.Minute,
+			giveOptions: []SlowInjectorOption{
+				WithCancelPolicy(CancelAbort),
+				WithCapToDeadline(),
+			},
+			want: &SlowInjector{
+				duration:      time.Minute,
+				clock:         NewRealClock(),
+				reporter:      NewNoopReporter(),
+				randSeed:      defaultRandSeed,
+				cancelPolicy:  CancelAbort,
+				capToDeadline: true,
+			},
+			wantErr: nil,
+		},
+		{
+			name:         "invalid cancel policy",
+			giveDuration: time.Minute,
+			giveOptions:  []SlowInjectorOption{WithCancelPolicy(CancelAbort + 1)},
+			want:         nil,
+			wantErr:      ErrInvalidCancelPolicy,
+		},
+		{
+			name:         "negative cancel policy",
+			giveDuration: time.Minute,
+			giveOptions:  []SlowInjectorOption{WithCancelPolicy(-1)},
+			want:         nil,
+			wantErr:      ErrInvalidCancelPolicy,
+		},
+		{
+			name:         "transfer latency",
+			giveDuration: time.Second,
+			giveOptions:  []SlowInjectorOption{WithTransferLatency(time.Minute)},
+			want: &SlowInjector{
+				duration: time.Second,
+				clock:    NewRealClock(),
+				reporter: NewNoopReporter(),
+				randSeed: defaultRandSeed,
+				transfer: time.Minute,
+			},
+			wantErr: nil,
+		},
+		{
+			name:         "negative transfer latency",
+			giveDuration: time.Second,
+			giveOptions:  []SlowInjectorOption{WithTransferLatency(-time.Second)},
+			want:         nil,
+			wantErr:      ErrInvalidDelay,
+		},
+		{
+			name:         "invalid max concurrent",
+			giveDuration: time.Minute,
+			giveOptions: []SlowInjectorOption{
+				WithMaxConcurrent(-1),
+			},
+			want:    nil,
+			wantErr: ErrInvalidMaxConcurrent,
+		},
 		{
 			name:         "option error",
 			giveDuration: time.Minute,
@@ -99,6 +315,8 @@ func TestNewSlowInjector(t *testing.T) {
 			// Function equality cannot be determined so set to nil before comparing
 			if tt.want != nil {
 				si.slowF = nil
+				si.routeF = nil
+				si.randF = nil
 				tt.want.slowF = nil
 			}
 
@@ -172,3 +390,463 @@ func TestSlowInjectorHandler(t *testing.T) {
 		})
 	}
 }
+
+// TestSlowInjectorHandlerClock tests that SlowInjector.Handler waits on its Clock.
+func TestSlowInjectorHandlerClock(t *testing.T) {
+	t.Parallel()
+
+	clock := faulttest.NewClock(time.Time{})
+
+	si, err := NewSlowInjector(time.Hour, WithClock(clock))
+	assert.NoError(t, err)
+
+	f, err := NewFault(si,
+		WithEnabled(true),
+		WithParticipation(1.0),
+	)
+	assert.NoError(t, err)
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() {
+		done <- testRequest(t, f)
+	}()
+
+	clock.BlockUntil(1)
+
+	select {
+	case <-done:
+		t.Fatal("request finished before the clock advanced")
+	default:
+	}
+
+	clock.Advance(time.Hour)
+	rr := <-done
+
+	assert.Equal(t, testHandlerCode, rr.Code)
+	assert.Equal(t, testHandlerBody, strings.TrimSpace(rr.Body.String()))
+}
+
+// TestSlowInjectorLatency tests that the latency distributions of SlowInjector sample the expected
+// latencies from their random source.
+func TestSlowInjectorLatency(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		giveOption SlowInjectorOption
+		giveRands  []float64
+		want       time.Duration
+	}{
+		{
+			name:       "uniform min",
+			giveOption: WithJitter(50*time.Millisecond, 150*time.Millisecond),
+			giveRands:  []float64{0},
+			want:       50 * time.Millisecond,
+		},
+		{
+			name:       "uniform middle",
+			giveOption: WithJitter(50*time.Millisecond, 150*time.Millisecond),
+			giveRands:  []float64{0.5},
+			want:       100 * time.Millisecond,
+		},
+		{
+			name:       "normal mean",
+			giveOption: WithNormalLatency(100*time.Millisecond, 20*time.Millisecond),
+			giveRands:  []float64{0, 0},
+			want:       100 * time.Millisecond,
+		},
+		{
+			name:       "normal one stddev above",
+			giveOption: WithNormalLatency(100*time.Millisecond, 20*time.Millisecond),
+			giveRands:  []float64{1 - math.Exp(-0.5), 0},
+			want:       120 * time.Millisecond,
+		},
+		{
+			name:       "normal below 0",
+			giveOption: WithNormalLatency(10*time.Millisecond, 20*time.Millisecond),
+			giveRands:  []float64{1 - math.Exp(-0.5), 0.5},
+			want:       0,
+		},
+		{
+			name:       "pareto min",
+			giveOption: WithParetoLatency(50*time.Millisecond, 5*time.Second, 1),
+			giveRands:  []float64{0},
+			want:       50 * time.Millisecond,
+		},
+		{
+			name:       "pareto median",
+			giveOption: WithParetoLatency(50*time.Millisecond, 5*time.Second, 1),
+			giveRands:  []float64{0.5},
+			want:       99009900, // 50ms / (1 - 0.5 * (1 - 50ms/5s))
+		},
+		{
+			name:       "pareto max",
+			giveOption: WithParetoLatency(50*time.Millisecond, 5*time.Second, 1),
+			giveRands:  []float64{math.Nextafter(1, 0)},
+			want:       5 * time.Second,
+		},
+		{
+			name:       "pareto fixed",
+			giveOption: WithParetoLatency(time.Second, time.Second, 2),
+			giveRands:  nil,
+			want:       time.Second,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var n int
+			var got time.Duration
+			si, err := NewSlowInjector(time.Hour,
+				tt.giveOption,
+				WithRandFloat64Func(func() float64 {
+					n++
+					return tt.giveRands[n-1]
+				}),
+				WithSlowFunc(func(d time.Duration) { got = d }),
+			)
+			assert.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			si.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(testHandlerCode)
+			})).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			assert.Equal(t, testHandlerCode, rr.Code)
+			assert.Equal(t, len(tt.giveRands), n)
+			assert.InDelta(t, float64(tt.want), float64(got), float64(time.Microsecond))
+		})
+	}
+}
+
+// TestSlowInjectorLatencySeed tests that SlowInjectors with the same seed wait the same latencies,
+// and that the latencies stay in range.
+func TestSlowInjectorLatencySeed(t *testing.T) {
+	t.Parallel()
+
+	opts := []SlowInjectorOption{
+		WithJitter(50*time.Millisecond, 150*time.Millisecond),
+		WithNormalLatency(100*time.Millisecond, 20*time.Millisecond),
+		WithParetoLatency(50*time.Millisecond, 5*time.Second, 1.16),
+	}
+
+	for _, opt := range opts {
+		si, err := NewSlowInjector(0, opt, WithRandSeed(42))
+		assert.NoError(t, err)
+		replay, err := NewSlowInjector(0, opt, WithRandSeed(si.Seed()))
+		assert.NoError(t, err)
+
+		for n := 0; n < 1000; n++ {
+			l := si.latency()
+			assert.Equal(t, l, replay.latency())
+			assert.True(t, l >= 0, l)
+
+			switch d := opt.(type) {
+			case uniformLatency:
+				assert.True(t, l >= d.min && l <= d.max, l)
+			case paretoLatency:
+				assert.True(t, l >= d.min && l <= d.max, l)
+			}
+		}
+	}
+}
+
+// TestSlowInjectorHandlerMaxConcurrent tests that SlowInjector.Handler continues without waiting
+// once the route of the request is at the concurrency limit.
+func TestSlowInjectorHandlerMaxConcurrent(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		giveOptions []SlowInjectorOption
+		givePaths   []string
+		wantWait    []bool
+	}{
+		{
+			name:        "routes by path",
+			giveOptions: nil,
+			givePaths:   []string{"/a", "/a", "/b", "/b"},
+			wantWait:    []bool{true, false, true, false},
+		},
+		{
+			name: "custom routes",
+			giveOptions: []SlowInjectorOption{
+				WithRouteFunc(func(*http.Request) string { return "all" }),
+			},
+			givePaths: []string{"/a", "/a", "/b", "/b"},
+			wantWait:  []bool{true, false, false, false},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			clock := faulttest.NewClock(time.Time{})
+			reporter := &testStateReporter{states: make(chan InjectorState, 2*len(tt.givePaths))}
+
+			si, err := NewSlowInjector(time.Hour, append([]SlowInjectorOption{
+				WithMaxConcurrent(1),
+				WithClock(clock),
+				WithReporter(reporter),
+			}, tt.giveOptions...)...)
+			assert.NoError(t, err)
+
+			h := si.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(testHandlerCode)
+			}))
+
+			var waiting []chan *httptest.ResponseRecorder
+			wantStates := map[InjectorState]int{}
+			for n, path := range tt.givePaths {
+				done := make(chan *httptest.ResponseRecorder, 1)
+				go func(path string) {
+					rr := httptest.NewRecorder()
+					h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+					done <- rr
+				}(path)
+
+				if !tt.wantWait[n] {
+					assert.Equal(t, testHandlerCode, (<-done).Code)
+					wantStates[StateSkipped]++
+					continue
+				}
+
+				waiting = append(waiting, done)
+				clock.BlockUntil(len(waiting))
+				wantStates[StateStarted]++
+				wantStates[StateFinished]++
+			}
+
+			clock.Advance(time.Hour)
+			for _, done := range waiting {
+				assert.Equal(t, testHandlerCode, (<-done).Code)
+			}
+
+			si.mtx.Lock()
+			assert.Empty(t, si.waiting)
+			si.mtx.Unlock()
+
+			states := map[InjectorState]int{}
+			for n := 0; n < len(tt.givePaths)+len(waiting); n++ {
+				states[<-reporter.states]++
+			}
+			assert.Equal(t, wantStates, states)
+		})
+	}
+}
+
+// TestSlowInjectorHandlerMaxConcurrentPanic tests that a request releases its place under the
+// concurrency limit even if the handler panics.
+func TestSlowInjectorHandlerMaxConcurrentPanic(t *testing.T) {
+	t.Parallel()
+
+	si, err := NewSlowInjector(time.Millisecond, WithMaxConcurrent(1), WithLatencyPhase(LatencyAfterHandler))
+	assert.NoError(t, err)
+
+	h := si.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	}))
+
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	})
+
+	si.mtx.Lock()
+	assert.Empty(t, si.waiting)
+	si.mtx.Unlock()
+}
+
+// TestSlowInjectorHandlerCanceled tests that a request stops waiting once its context is done and
+// then follows the CancelPolicy.
+func TestSlowInjectorHandlerCanceled(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		giveOptions []SlowInjectorOption
+		wantNext    bool
+		wantPanic   bool
+	}{
+		{
+			name:     "continue",
+			wantNext: true,
+		},
+		{
+			name:        "return",
+			giveOptions: []SlowInjectorOption{WithCancelPolicy(CancelReturn)},
+			wantNext:    false,
+		},
+		{
+			name:        "abort",
+			giveOptions: []SlowInjectorOption{WithCancelPolicy(CancelAbort)},
+			wantPanic:   true,
+		},
+		{
+			name: "slow func",
+			giveOptions: []SlowInjectorOption{
+				WithCancelPolicy(CancelReturn),
+				WithSlowFunc(func(time.Duration) {}),
+			},
+			wantNext: false,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			clock := faulttest.NewClock(time.Time{})
+			si, err := NewSlowInjector(time.Hour, append([]SlowInjectorOption{WithClock(clock)}, tt.giveOptions...)...)
+			assert.NoError(t, err)
+
+			var ran bool
+			h := si.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				ran = true
+				assert.Equal(t, context.Canceled, r.Context().Err())
+			}))
+
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			serve := func() {
+				h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx))
+			}
+
+			if tt.wantPanic {
+				assert.PanicsWithValue(t, http.ErrAbortHandler, serve)
+			} else {
+				serve()
+			}
+			assert.Equal(t, tt.wantNext, ran)
+		})
+	}
+}
+
+// TestSlowInjectorHandlerCapToDeadline tests that WithCapToDeadline caps the wait to the deadline of
+// the context of the request.
+func TestSlowInjectorHandlerCapToDeadline(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		giveDuration time.Duration
+		giveDeadline time.Duration
+		giveCap      bool
+		want         time.Duration
+	}{
+		{
+			name:         "capped",
+			giveDuration: time.Hour,
+			giveDeadline: time.Minute,
+			giveCap:      true,
+			want:         time.Minute,
+		},
+		{
+			name:         "shorter than deadline",
+			giveDuration: time.Second,
+			giveDeadline: time.Minute,
+			giveCap:      true,
+			want:         time.Second,
+		},
+		{
+			name:         "past deadline",
+			giveDuration: time.Second,
+			giveDeadline: -time.Minute,
+			giveCap:      true,
+			want:         -time.Minute,
+		},
+		{
+			name:         "no deadline",
+			giveDuration: time.Hour,
+			giveCap:      true,
+			want:         time.Hour,
+		},
+		{
+			name:         "not capped",
+			giveDuration: time.Hour,
+			giveDeadline: time.Minute,
+			giveCap:      false,
+			want:         time.Hour,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+			var got time.Duration
+			opts := []SlowInjectorOption{
+				WithClock(faulttest.NewClock(now)),
+				WithSlowFunc(func(d time.Duration) { got = d }),
+			}
+			if tt.giveCap {
+				opts = append(opts, WithCapToDeadline())
+			}
+			si, err := NewSlowInjector(tt.giveDuration, opts...)
+			assert.NoError(t, err)
+
+			// the deadline is only compared with the Clock, so the context is never done
+			ctx := context.Background()
+			if tt.giveDeadline != 0 {
+				ctx = testDeadlineContext{Context: ctx, deadline: now.Add(tt.giveDeadline)}
+			}
+
+			si.Handler(http.NotFoundHandler()).ServeHTTP(httptest.NewRecorder(),
+				httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx))
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+// testDeadlineContext is a context.Context that reports a deadline it does not enforce.
+type testDeadlineContext struct {
+	context.Context
+	deadline time.Time
+}
+
+// Deadline returns the deadline.
+func (c testDeadlineContext) Deadline() (time.Time, bool) {
+	return c.deadline, true
+}
+
+// TestSlowInjectorHandlerWaitCanceled tests that a request waiting on the Clock stops waiting once
+// its context is done.
+func TestSlowInjectorHandlerWaitCanceled(t *testing.T) {
+	t.Parallel()
+
+	clock := faulttest.NewClock(time.Time{})
+	si, err := NewSlowInjector(time.Hour, WithClock(clock), WithCancelPolicy(CancelReturn))
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		si.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			t.Error("request continued")
+		})).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx))
+	}()
+
+	clock.BlockUntil(1)
+	cancel()
+	<-done
+}
+
+// TestCancelPolicyString tests CancelPolicy.String.
+func TestCancelPolicyString(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "continue", CancelContinue.String())
+	assert.Equal(t, "return", CancelReturn.String())
+	assert.Equal(t, "abort", CancelAbort.String())
+	assert.Equal(t, "CancelPolicy(7)", CancelPolicy(7).String())
+}
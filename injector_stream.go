@@ -0,0 +1,423 @@
+package fault
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNoStreamFaults when a StreamInjector is created without stalling, dropping, or delaying
+// frames.
+var ErrNoStreamFaults = errors.New("stream must stall, drop, or delay frames")
+
+// StreamInjector faults long-lived streaming responses while the handler streams them, rather than
+// before it runs. It works on frames: the events of a Server-Sent Events response, each ended by a
+// blank line, and the writes to a connection the handler takes over, such as the frames of a
+// WebSocket. It can stall the stream after some frames, drop it after a while, and delay each frame.
+type StreamInjector struct {
+	// stallAfter is the number of frames sent before the stream stalls, or -1 to never stall.
+	stallAfter int
+	// dropAfter is how long the stream runs before it is dropped, or -1 to never drop.
+	dropAfter time.Duration
+	// frameDelay is the wait before each frame after the first.
+	frameDelay time.Duration
+
+	clock    Clock
+	reporter Reporter
+}
+
+// StreamInjectorOption configures a StreamInjector.
+type StreamInjectorOption interface {
+	applyStreamInjector(i *StreamInjector) error
+}
+
+type stallAfterFramesOption int
+
+func (o stallAfterFramesOption) applyStreamInjector(i *StreamInjector) error {
+	if o < 0 {
+		return ErrInvalidCount
+	}
+
+	i.stallAfter = int(o)
+	return nil
+}
+
+// WithStallAfterFrames stalls the stream once n frames are sent. Later writes block until the client
+// gives up or the stream is dropped, so the connection stays open but nothing more arrives.
+func WithStallAfterFrames(n int) StreamInjectorOption {
+	return stallAfterFramesOption(n)
+}
+
+type dropAfterOption time.Duration
+
+func (o dropAfterOption) applyStreamInjector(i *StreamInjector) error {
+	if o < 0 {
+		return ErrInvalidDelay
+	}
+
+	i.dropAfter = time.Duration(o)
+	return nil
+}
+
+// WithDropAfter drops the stream once it has run for d. A connection the handler took over is
+// reset. Otherwise the context of the request is canceled and the response is aborted at the next
+// write or when the handler returns.
+func WithDropAfter(d time.Duration) StreamInjectorOption {
+	return dropAfterOption(d)
+}
+
+type frameDelayOption time.Duration
+
+func (o frameDelayOption) applyStreamInjector(i *StreamInjector) error {
+	if o < 0 {
+		return ErrInvalidDelay
+	}
+
+	i.frameDelay = time.Duration(o)
+	return nil
+}
+
+// WithFrameDelay waits d before sending each frame after the first.
+func WithFrameDelay(d time.Duration) StreamInjectorOption {
+	return frameDelayOption(d)
+}
+
+func (o clockOption) applyStreamInjector(i *StreamInjector) error {
+	i.clock = o.clock
+	return nil
+}
+
+func (o reporterOption) applyStreamInjector(i *StreamInjector) error {
+	i.reporter = o.reporter
+	return nil
+}
+
+// NewStreamInjector returns a StreamInjector. It returns ErrNoStreamFaults unless at least one of
+// WithStallAfterFrames, WithDropAfter, or a positive WithFrameDelay is given.
+func NewStreamInjector(opts ...StreamInjectorOption) (*StreamInjector, error) {
+	// set defaults
+	si := &StreamInjector{
+		stallAfter: -1,
+		dropAfter:  -1,
+		clock:      NewRealClock(),
+		reporter:   NewNoopReporter(),
+	}
+
+	// apply options
+	for _, opt := range opts {
+		err := opt.applyStreamInjector(si)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if si.stallAfter < 0 && si.dropAfter < 0 && si.frameDelay == 0 {
+		return nil, ErrNoStreamFaults
+	}
+
+	return si, nil
+}
+
+// Handler runs the request with a ResponseWriter that faults the frames of the response, and of
+// the connection if the handler takes it over. Events are told apart by the blank line that ends
+// them, with lines ending in "\n" or "\r\n".
+func (i *StreamInjector) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		go i.reporter.Report(i.String(), StateStarted)
+		defer func() { go i.reporter.Report(i.String(), StateFinished) }()
+
+		ctx, cancel := context.WithCancel(r.Context())
+		defer cancel()
+
+		sw := &streamWriter{
+			ResponseWriter: w,
+			injector:       i,
+			ctx:            ctx,
+			dropped:        make(chan struct{}),
+		}
+
+		if i.dropAfter >= 0 {
+			finished := make(chan struct{})
+			defer close(finished)
+
+			go func() {
+				select {
+				case <-i.clock.After(i.dropAfter):
+					sw.drop()
+					cancel()
+				case <-finished:
+				}
+			}()
+		}
+
+		next.ServeHTTP(sw, r.WithContext(ctx))
+
+		if sw.isDropped() && !sw.isHijacked() {
+			// This is a specialized and documented way of sending an interrupted response to
+			// the client without printing the panic stack trace or erroring.
+			// https://golang.org/pkg/net/http/#Handler
+			panic(http.ErrAbortHandler)
+		}
+	})
+}
+
+// streamWriter is an http.ResponseWriter that stalls, drops, and delays the frames of a response.
+type streamWriter struct {
+	http.ResponseWriter
+	injector *StreamInjector
+	ctx      context.Context
+
+	// frames is the number of frames sent.
+	frames int
+	// inFrame is true once a byte of the current frame is sent, and newline is true if the last
+	// byte sent other than '\r' was '\n'.
+	inFrame bool
+	newline bool
+
+	// mtx protects conn, the connection if the handler took it over. dropped is closed once the
+	// stream is dropped.
+	mtx     sync.Mutex
+	conn    *streamConn
+	dropped chan struct{}
+}
+
+// Write sends b, splitting it at the end of each frame to stall or delay the next. After the stream
+// is dropped it aborts the response.
+func (w *streamWriter) Write(b []byte) (int, error) {
+	var n int
+	for len(b) > 0 {
+		if w.isDropped() {
+			panic(http.ErrAbortHandler)
+		}
+
+		if !w.inFrame {
+			if err := w.waitFrame(); err != nil {
+				return n, err
+			}
+		}
+
+		size, ended := w.frameEnd(b)
+		written, err := w.ResponseWriter.Write(b[:size])
+		n += written
+		if err != nil {
+			return n, err
+		}
+		w.inFrame = !ended
+
+		if ended {
+			w.frames++
+			w.Flush()
+		}
+
+		b = b[size:]
+	}
+
+	return n, nil
+}
+
+// frameEnd returns the number of bytes of b up to and including the blank line that ends the
+// current frame and true, or len(b) and false if the frame does not end in b.
+func (w *streamWriter) frameEnd(b []byte) (int, bool) {
+	for idx, c := range b {
+		switch c {
+		case '\r':
+		case '\n':
+			if w.newline {
+				w.newline = false
+				return idx + 1, true
+			}
+			w.newline = true
+		default:
+			w.newline = false
+		}
+	}
+
+	return len(b), false
+}
+
+// waitFrame stalls or delays the start of a frame. It returns the error of the request's context if
+// the stream stalls, once the client gives up or the stream is dropped.
+func (w *streamWriter) waitFrame() error {
+	if stall := w.injector.stallAfter; stall >= 0 && w.frames >= stall {
+		<-w.ctx.Done()
+		if w.isDropped() {
+			panic(http.ErrAbortHandler)
+		}
+		return w.ctx.Err()
+	}
+
+	if w.frames > 0 && w.injector.frameDelay > 0 {
+		w.injector.clock.Sleep(addLatency(w.ctx, w.injector.frameDelay))
+	}
+
+	return w.ctx.Err()
+}
+
+// drop marks the stream dropped and resets the connection if the handler took it over.
+func (w *streamWriter) drop() {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+
+	close(w.dropped)
+	if w.conn != nil {
+		w.conn.reset()
+	}
+}
+
+// isDropped returns true once the stream is dropped.
+func (w *streamWriter) isDropped() bool {
+	select {
+	case <-w.dropped:
+		return true
+	default:
+		return false
+	}
+}
+
+// isHijacked returns true if the handler took over the connection.
+func (w *streamWriter) isHijacked() bool {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+
+	return w.conn != nil
+}
+
+// Flush flushes the underlying ResponseWriter if it can.
+func (w *streamWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap returns the underlying ResponseWriter for http.ResponseController.
+func (w *streamWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Hijack lets the caller take over the connection of the underlying ResponseWriter if it can, and
+// returns http.ErrNotSupported otherwise. Each write to the connection is a frame.
+func (w *streamWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, brw, err := http.NewResponseController(w.ResponseWriter).Hijack()
+	if err != nil {
+		return conn, brw, err
+	}
+
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+
+	sc := &streamConn{Conn: conn, injector: w.injector, closed: make(chan struct{})}
+	if w.isDropped() {
+		sc.reset()
+	}
+	w.conn = sc
+
+	return sc, bufio.NewReadWriter(brw.Reader, bufio.NewWriter(sc)), nil
+}
+
+// streamConn is a net.Conn taken over from a streamWriter whose writes are stalled and delayed as
+// frames.
+type streamConn struct {
+	net.Conn
+	injector *StreamInjector
+
+	// writeMtx protects frames, the number of writes sent.
+	writeMtx sync.Mutex
+	frames   int
+
+	closeOnce sync.Once
+	closed    chan struct{}
+}
+
+// Write sends b as one frame. A stalled write blocks until the connection is closed.
+func (c *streamConn) Write(b []byte) (int, error) {
+	c.writeMtx.Lock()
+	defer c.writeMtx.Unlock()
+
+	if stall := c.injector.stallAfter; stall >= 0 && c.frames >= stall {
+		<-c.closed
+		return 0, net.ErrClosed
+	}
+
+	if c.frames > 0 && c.injector.frameDelay > 0 {
+		select {
+		case <-c.injector.clock.After(c.injector.frameDelay):
+		case <-c.closed:
+			return 0, net.ErrClosed
+		}
+	}
+	c.frames++
+
+	return c.Conn.Write(b)
+}
+
+// reset resets and closes the connection.
+func (c *streamConn) reset() {
+	resetOnClose(c.Conn)
+	_ = c.Close()
+}
+
+// Close closes the connection and releases stalled writes.
+func (c *streamConn) Close() error {
+	c.closeOnce.Do(func() { close(c.closed) })
+	return c.Conn.Close()
+}
+
+// Reporter returns the Reporter of the StreamInjector.
+func (i *StreamInjector) Reporter() Reporter {
+	return i.reporter
+}
+
+// SetReporter replaces the Reporter of the StreamInjector.
+func (i *StreamInjector) SetReporter(r Reporter) {
+	i.reporter = r
+}
+
+// Destructive returns true if the StreamInjector drops streams, which cuts off their responses.
+func (i *StreamInjector) Destructive() bool {
+	return i.dropAfter >= 0
+}
+
+// Name returns "stream".
+func (i *StreamInjector) Name() string {
+	return "stream"
+}
+
+// Describe returns the frames sent before the stream stalls, how long before it is dropped, and the
+// delay before each frame, whichever are set.
+func (i *StreamInjector) Describe() map[string]string {
+	d := make(map[string]string)
+	if i.stallAfter >= 0 {
+		d["stall_after"] = strconv.Itoa(i.stallAfter)
+	}
+	if i.dropAfter >= 0 {
+		d["drop_after"] = i.dropAfter.String()
+	}
+	if i.frameDelay > 0 {
+		d["frame_delay"] = i.frameDelay.String()
+	}
+
+	return d
+}
+
+// String returns a summary of the StreamInjector, such as "stream(stall after 3, drop after 30s)".
+func (i *StreamInjector) String() string {
+	var parts []string
+	if i.stallAfter >= 0 {
+		parts = append(parts, "stall after "+strconv.Itoa(i.stallAfter))
+	}
+	if i.dropAfter >= 0 {
+		parts = append(parts, "drop after "+i.dropAfter.String())
+	}
+	if i.frameDelay > 0 {
+		parts = append(parts, "delay "+i.frameDelay.String())
+	}
+
+	return fmt.Sprintf("%s(%s)", i.Name(), strings.Join(parts, ", "))
+}
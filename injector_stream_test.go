@@ -0,0 +1,256 @@
+package fault
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/github/go-fault/faulttest"
+	"github.com/stretchr/testify/assert"
+)
+
+// testEvents are Server-Sent Events, with both line endings.
+const testEvents = "data: 1\n\nevent: tick\r\ndata: 2\r\n\r\ndata: 3\n\n"
+
+// TestNewStreamInjector tests NewStreamInjector.
+func TestNewStreamInjector(t *testing.T) {
+	t.Parallel()
+
+	clock := faulttest.NewClock(time.Time{})
+
+	tests := []struct {
+		name    string
+		give    []StreamInjectorOption
+		want    *StreamInjector
+		wantErr error
+	}{
+		{
+			name: "stall",
+			give: []StreamInjectorOption{
+				WithStallAfterFrames(0),
+			},
+			want: &StreamInjector{
+				stallAfter: 0,
+				dropAfter:  -1,
+				clock:      NewRealClock(),
+				reporter:   NewNoopReporter(),
+			},
+			wantErr: nil,
+		},
+		{
+			name: "options",
+			give: []StreamInjectorOption{
+				WithStallAfterFrames(3),
+				WithDropAfter(0),
+				WithFrameDelay(time.Second),
+				WithClock(clock),
+				WithReporter(newTestReporter()),
+			},
+			want: &StreamInjector{
+				stallAfter: 3,
+				dropAfter:  0,
+				frameDelay: time.Second,
+				clock:      clock,
+				reporter:   newTestReporter(),
+			},
+			wantErr: nil,
+		},
+		{
+			name:    "no faults",
+			give:    []StreamInjectorOption{WithFrameDelay(0)},
+			want:    nil,
+			wantErr: ErrNoStreamFaults,
+		},
+		{
+			name:    "negative frames",
+			give:    []StreamInjectorOption{WithStallAfterFrames(-1)},
+			want:    nil,
+			wantErr: ErrInvalidCount,
+		},
+		{
+			name:    "negative drop",
+			give:    []StreamInjectorOption{WithDropAfter(-time.Second)},
+			want:    nil,
+			wantErr: ErrInvalidDelay,
+		},
+		{
+			name:    "negative delay",
+			give:    []StreamInjectorOption{WithFrameDelay(-time.Second)},
+			want:    nil,
+			wantErr: ErrInvalidDelay,
+		},
+		{
+			name:    "option error",
+			give:    []StreamInjectorOption{withError()},
+			want:    nil,
+			wantErr: errErrorOption,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			si, err := NewStreamInjector(tt.give...)
+
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.want, si)
+		})
+	}
+}
+
+// TestStreamInjectorFrameDelay tests that a StreamInjector waits before each event after the first,
+// including events split across writes.
+func TestStreamInjectorFrameDelay(t *testing.T) {
+	t.Parallel()
+
+	clock := faulttest.NewClock(time.Time{})
+	si, err := NewStreamInjector(WithFrameDelay(time.Second), WithClock(clock))
+	assert.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		si.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(testEvents[:5]))
+			_, _ = w.Write([]byte(testEvents[5:]))
+		})).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/events", nil))
+	}()
+
+	for n := 0; n < 2; n++ {
+		clock.BlockUntil(1)
+		clock.Advance(time.Second)
+	}
+	<-done
+
+	assert.Equal(t, testEvents, rr.Body.String())
+	assert.True(t, rr.Flushed)
+}
+
+// TestStreamInjectorStall tests that a StreamInjector stalls an event stream after a number of
+// events until the client gives up.
+func TestStreamInjectorStall(t *testing.T) {
+	t.Parallel()
+
+	si, err := NewStreamInjector(WithStallAfterFrames(2))
+	assert.NoError(t, err)
+
+	handlerErr := make(chan error, 1)
+	srv := httptest.NewServer(si.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, err := w.Write([]byte(testEvents))
+		handlerErr <- err
+	})))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	assert.NoError(t, err)
+	resp, err := srv.Client().Do(req)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	want := "data: 1\n\nevent: tick\r\ndata: 2\r\n\r\n"
+	got := make([]byte, len(want))
+	_, err = io.ReadFull(resp.Body, got)
+	assert.NoError(t, err)
+	assert.Equal(t, want, string(got))
+
+	cancel()
+	err = <-handlerErr
+	assert.True(t, errors.Is(err, context.Canceled), err)
+}
+
+// TestStreamInjectorDrop tests that a StreamInjector cancels the request and aborts the response
+// once the stream has run for its drop duration.
+func TestStreamInjectorDrop(t *testing.T) {
+	t.Parallel()
+
+	clock := faulttest.NewClock(time.Time{})
+	si, err := NewStreamInjector(WithDropAfter(time.Minute), WithClock(clock))
+	assert.NoError(t, err)
+
+	srv := httptest.NewServer(si.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("data: 1\n\n"))
+		<-r.Context().Done()
+		_, _ = w.Write([]byte("data: 2\n\n"))
+	})))
+	defer srv.Close()
+
+	resp, err := srv.Client().Get(srv.URL)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	br := bufio.NewReader(resp.Body)
+	line, err := br.ReadString('\n')
+	assert.NoError(t, err)
+	assert.Equal(t, "data: 1\n", line)
+
+	clock.BlockUntil(1)
+	clock.Advance(time.Minute)
+
+	rest, err := io.ReadAll(br)
+	assert.Error(t, err)
+	assert.Equal(t, "\n", string(rest))
+}
+
+// TestStreamInjectorHijack tests that a StreamInjector stalls the writes to a connection the handler
+// takes over, such as a WebSocket, and resets the connection when it drops the stream.
+func TestStreamInjectorHijack(t *testing.T) {
+	t.Parallel()
+
+	clock := faulttest.NewClock(time.Time{})
+	si, err := NewStreamInjector(WithStallAfterFrames(2), WithDropAfter(time.Minute), WithClock(clock))
+	assert.NoError(t, err)
+
+	handlerErr := make(chan error, 1)
+	srv := httptest.NewServer(si.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, brw, err := http.NewResponseController(w).Hijack()
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer conn.Close()
+
+		for _, frame := range []string{"a", "b", "c"} {
+			_, _ = brw.WriteString(frame)
+			if err := brw.Flush(); err != nil {
+				handlerErr <- err
+				return
+			}
+		}
+		handlerErr <- nil
+	})))
+	defer srv.Close()
+
+	conn, err := net.Dial("tcp", srv.Listener.Addr().String())
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	_, err = fmt.Fprintf(conn, "GET / HTTP/1.1\r\nHost: %s\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n\r\n",
+		srv.Listener.Addr())
+	assert.NoError(t, err)
+
+	got := make([]byte, 2)
+	_, err = io.ReadFull(conn, got)
+	assert.NoError(t, err)
+	assert.Equal(t, "ab", string(got))
+
+	clock.BlockUntil(1)
+	clock.Advance(time.Minute)
+
+	err = <-handlerErr
+	assert.True(t, errors.Is(err, net.ErrClosed), err)
+	_, err = conn.Read(got)
+	assert.Error(t, err)
+}
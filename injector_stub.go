@@ -0,0 +1,293 @@
+package fault
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+var (
+	// ErrInvalidTemplate when a template of a StubInjector cannot be parsed.
+	ErrInvalidTemplate = errors.New("invalid template")
+)
+
+// StubData is the data the templates of a StubInjector are executed with, such as
+// {{.Query.Get "id"}} or {{.Header.Get "X-Request-Id"}}.
+type StubData struct {
+	// Request is the request being stubbed. Its body is not read.
+	Request *http.Request
+	// Method is the method of the request.
+	Method string
+	// Host is the Host of the request.
+	Host string
+	// Path is the path of the request.
+	Path string
+	// Query is the parsed query of the request.
+	Query url.Values
+	// Header is the header of the request.
+	Header http.Header
+	// Now is when the response is rendered, as told by the Clock set by WithClock().
+	Now time.Time
+}
+
+// stubFuncs are the functions the templates of a StubInjector can call in addition to the
+// built-in functions of text/template.
+var stubFuncs = template.FuncMap{ //nolint:gochecknoglobals
+	// json returns v encoded as JSON, to embed request values in a JSON body safely.
+	"json": func(v interface{}) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+	// pathValue returns the value of the wildcard name of the ServeMux pattern the request matched.
+	"pathValue": func(r *http.Request, name string) string {
+		return r.PathValue(name)
+	},
+}
+
+// StubInjector responds with a fake response rendered from Go templates instead of calling the
+// next handler, to simulate payload shapes of an upstream, such as partial data or deprecated
+// fields, without a mock server.
+type StubInjector struct {
+	code     int
+	status   string
+	body     string
+	headers  http.Header
+	clock    Clock
+	reporter Reporter
+
+	statusTmpl  *template.Template
+	bodyTmpl    *template.Template
+	headerTmpls map[string][]*template.Template
+}
+
+// StubInjectorOption configures a StubInjector.
+type StubInjectorOption interface {
+	applyStubInjector(i *StubInjector) error
+}
+
+type statusTemplateOption string
+
+func (o statusTemplateOption) applyStubInjector(i *StubInjector) error {
+	i.status = string(o)
+	return nil
+}
+
+// WithStatusTemplate renders the status code of the stubbed response from a template instead of
+// using the code the StubInjector was created with, such as
+// `{{if .Query.Get "id"}}200{{else}}404{{end}}`. A template that does not render a valid status
+// code is a 500 Internal Server Error.
+func WithStatusTemplate(text string) StubInjectorOption {
+	return statusTemplateOption(text)
+}
+
+type headerTemplateOption struct {
+	key  string
+	text string
+}
+
+func (o headerTemplateOption) applyStubInjector(i *StubInjector) error {
+	if i.headers == nil {
+		i.headers = make(http.Header)
+	}
+	i.headers.Add(o.key, o.text)
+	return nil
+}
+
+// WithHeaderTemplate adds a header to the stubbed response whose value is rendered from a template,
+// such as "X-Request-Id: {{.Header.Get "X-Request-Id"}}". Pass it more than once to add more
+// headers. Without a Content-Type header, the Content-Type is detected from the body.
+func WithHeaderTemplate(key, text string) StubInjectorOption {
+	return headerTemplateOption{key: key, text: text}
+}
+
+func (o clockOption) applyStubInjector(i *StubInjector) error {
+	i.clock = o.clock
+	return nil
+}
+
+func (o reporterOption) applyStubInjector(i *StubInjector) error {
+	i.reporter = o.reporter
+	return nil
+}
+
+// NewStubInjector returns a StubInjector that responds with code and a body rendered from the
+// template body. Templates are text/template templates executed with a StubData, and can call
+// json, to encode a value as JSON, and pathValue, to read a wildcard of the ServeMux pattern the
+// request matched. It returns an error wrapping ErrInvalidTemplate naming a template that cannot be
+// parsed, and ErrInvalidHTTPCode if code is not a valid status code.
+func NewStubInjector(code int, body string, opts ...StubInjectorOption) (*StubInjector, error) {
+	// set defaults
+	si := &StubInjector{
+		code:     code,
+		body:     body,
+		clock:    NewRealClock(),
+		reporter: NewNoopReporter(),
+	}
+
+	// apply options
+	for _, opt := range opts {
+		err := opt.applyStubInjector(si)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// check options
+	if si.status == "" && http.StatusText(si.code) == "" {
+		return nil, ErrInvalidHTTPCode
+	}
+
+	var err error
+	if si.status != "" {
+		si.statusTmpl, err = parseStubTemplate("status", si.status)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	si.bodyTmpl, err = parseStubTemplate("body", body)
+	if err != nil {
+		return nil, err
+	}
+
+	si.headerTmpls = make(map[string][]*template.Template, len(si.headers))
+	for k, vs := range si.headers {
+		for _, v := range vs {
+			tmpl, err := parseStubTemplate("header "+k, v)
+			if err != nil {
+				return nil, err
+			}
+			si.headerTmpls[k] = append(si.headerTmpls[k], tmpl)
+		}
+	}
+
+	return si, nil
+}
+
+// parseStubTemplate parses the template text named name.
+func parseStubTemplate(name, text string) (*template.Template, error) {
+	tmpl, err := template.New(name).Funcs(stubFuncs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidTemplate, err)
+	}
+
+	return tmpl, nil
+}
+
+// Handler renders the stubbed response for the request and writes it. The next handler does not
+// run. If a template fails to execute, the response is a 500 Internal Server Error naming it.
+func (i *StubInjector) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		go i.reporter.Report(i.String(), StateStarted)
+
+		code, header, body, err := i.render(r)
+		if err != nil {
+			http.Error(w, "stub: "+err.Error(), http.StatusInternalServerError)
+		} else {
+			h := w.Header()
+			for k, vs := range header {
+				h[k] = vs
+			}
+			h.Del("Content-Length")
+			w.WriteHeader(code)
+			_, _ = w.Write(body)
+		}
+
+		go i.reporter.Report(i.String(), StateFinished)
+	})
+}
+
+// render renders the status code, headers, and body of the stubbed response for r.
+func (i *StubInjector) render(r *http.Request) (int, http.Header, []byte, error) {
+	data := StubData{
+		Request: r,
+		Method:  r.Method,
+		Host:    r.Host,
+		Path:    r.URL.Path,
+		Query:   r.URL.Query(),
+		Header:  r.Header,
+		Now:     i.clock.Now(),
+	}
+
+	var buf bytes.Buffer
+
+	code := i.code
+	if i.statusTmpl != nil {
+		if err := i.statusTmpl.Execute(&buf, data); err != nil {
+			return 0, nil, nil, err
+		}
+		s := strings.TrimSpace(buf.String())
+		n, err := strconv.Atoi(s)
+		if err != nil || http.StatusText(n) == "" {
+			return 0, nil, nil, fmt.Errorf("%w: status %q", ErrInvalidHTTPCode, s)
+		}
+		code = n
+	}
+
+	header := make(http.Header, len(i.headerTmpls))
+	for k, tmpls := range i.headerTmpls {
+		for _, tmpl := range tmpls {
+			buf.Reset()
+			if err := tmpl.Execute(&buf, data); err != nil {
+				return 0, nil, nil, err
+			}
+			header.Add(k, buf.String())
+		}
+	}
+
+	buf.Reset()
+	if err := i.bodyTmpl.Execute(&buf, data); err != nil {
+		return 0, nil, nil, err
+	}
+
+	return code, header, buf.Bytes(), nil
+}
+
+// Reporter returns the Reporter of the StubInjector.
+func (i *StubInjector) Reporter() Reporter {
+	return i.reporter
+}
+
+// SetReporter replaces the Reporter of the StubInjector.
+func (i *StubInjector) SetReporter(r Reporter) {
+	i.reporter = r
+}
+
+// Name returns "stub".
+func (i *StubInjector) Name() string {
+	return "stub"
+}
+
+// Describe returns the status code or its template, and the templates of the headers and body.
+func (i *StubInjector) Describe() map[string]string {
+	d := map[string]string{
+		"body": i.body,
+	}
+	if i.status != "" {
+		d["status"] = i.status
+	} else {
+		d["code"] = strconv.Itoa(i.code)
+	}
+	if len(i.headers) > 0 {
+		d["headers"] = joinHeader(i.headers)
+	}
+
+	return d
+}
+
+// String returns a summary of the StubInjector, such as "stub(200)", or "stub(template)" if its
+// status code is rendered from a template.
+func (i *StubInjector) String() string {
+	if i.status != "" {
+		return i.Name() + "(template)"
+	}
+
+	return fmt.Sprintf("%s(%d)", i.Name(), i.code)
+}
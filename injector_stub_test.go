@@ -0,0 +1,230 @@
+package fault
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/github/go-fault/faulttest"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestNewStubInjector tests NewStubInjector.
+func TestNewStubInjector(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		giveCode    int
+		giveBody    string
+		giveOptions []StubInjectorOption
+		wantHeaders http.Header
+		wantErr     error
+	}{
+		{
+			name:     "only code and body",
+			giveCode: http.StatusOK,
+			giveBody: `{"id": {{json .Path}}}`,
+		},
+		{
+			name:     "options",
+			giveCode: http.StatusOK,
+			giveBody: "",
+			giveOptions: []StubInjectorOption{
+				WithStatusTemplate("{{if .Query.Get `id`}}200{{else}}404{{end}}"),
+				WithHeaderTemplate("content-type", "application/json"),
+				WithHeaderTemplate("X-Request-Id", `{{.Header.Get "X-Request-Id"}}`),
+				WithClock(faulttest.NewClock(time.Time{})),
+				WithReporter(newTestReporter()),
+			},
+			wantHeaders: http.Header{
+				"Content-Type": {"application/json"},
+				"X-Request-Id": {`{{.Header.Get "X-Request-Id"}}`},
+			},
+		},
+		{
+			name:     "status template ignores code",
+			giveCode: 0,
+			giveOptions: []StubInjectorOption{
+				WithStatusTemplate("503"),
+			},
+		},
+		{
+			name:     "invalid code",
+			giveCode: 0,
+			wantErr:  ErrInvalidHTTPCode,
+		},
+		{
+			name:     "invalid body",
+			giveCode: http.StatusOK,
+			giveBody: "{{.Path",
+			wantErr:  ErrInvalidTemplate,
+		},
+		{
+			name:     "invalid status",
+			giveCode: http.StatusOK,
+			giveOptions: []StubInjectorOption{
+				WithStatusTemplate("{{end}}"),
+			},
+			wantErr: ErrInvalidTemplate,
+		},
+		{
+			name:     "invalid header",
+			giveCode: http.StatusOK,
+			giveOptions: []StubInjectorOption{
+				WithHeaderTemplate("X-Id", "{{nope}}"),
+			},
+			wantErr: ErrInvalidTemplate,
+		},
+		{
+			name:     "option error",
+			giveCode: http.StatusOK,
+			giveOptions: []StubInjectorOption{
+				withError(),
+			},
+			wantErr: errErrorOption,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			si, err := NewStubInjector(tt.giveCode, tt.giveBody, tt.giveOptions...)
+
+			assert.True(t, errors.Is(err, tt.wantErr), err)
+			if tt.wantErr != nil {
+				assert.Nil(t, si)
+				return
+			}
+
+			assert.Equal(t, tt.giveBody, si.body)
+			assert.Equal(t, tt.wantHeaders, si.headers)
+		})
+	}
+}
+
+// TestStubInjectorHandler tests that a StubInjector renders its response from the request instead
+// of running the next handler.
+func TestStubInjectorHandler(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 11, 20, 12, 0, 0, 0, time.UTC)
+	clock := WithClock(faulttest.NewClock(now))
+
+	tests := []struct {
+		name        string
+		giveBody    string
+		giveOptions []StubInjectorOption
+		giveTarget  string
+		wantCode    int
+		wantHeader  http.Header
+		wantBody    string
+	}{
+		{
+			name:       "static",
+			giveBody:   "stubbed",
+			giveTarget: "/users/1",
+			wantCode:   http.StatusAccepted,
+			wantHeader: http.Header{},
+			wantBody:   "stubbed",
+		},
+		{
+			name:     "request fields",
+			giveBody: `{"id":{{json (pathValue .Request "id")}},"q":{{json (.Query.Get "q")}},"at":{{json .Now}}}`,
+			giveOptions: []StubInjectorOption{
+				WithHeaderTemplate("Content-Type", "application/json"),
+				WithHeaderTemplate("X-Echo", "{{.Method}} {{.Host}}{{.Path}}"),
+				WithHeaderTemplate("X-Echo", `{{.Header.Get "X-Test"}}`),
+				clock,
+			},
+			giveTarget: `/users/7?q="a"`,
+			wantCode:   http.StatusAccepted,
+			wantHeader: http.Header{
+				"Content-Type": {"application/json"},
+				"X-Echo":       {"GET example.com/users/7", "test"},
+			},
+			wantBody: `{"id":"7","q":"\"a\"","at":"2024-11-20T12:00:00Z"}`,
+		},
+		{
+			name:     "status template",
+			giveBody: "",
+			giveOptions: []StubInjectorOption{
+				WithStatusTemplate(`{{if eq (pathValue .Request "id") "0"}} 404 {{else}}200{{end}}`),
+			},
+			giveTarget: "/users/0",
+			wantCode:   http.StatusNotFound,
+			wantHeader: http.Header{},
+		},
+		{
+			name:     "invalid rendered status",
+			giveBody: "",
+			giveOptions: []StubInjectorOption{
+				WithStatusTemplate("{{.Path}}"),
+			},
+			giveTarget: "/users/1",
+			wantCode:   http.StatusInternalServerError,
+			wantHeader: http.Header{
+				"Content-Type":           {"text/plain; charset=utf-8"},
+				"X-Content-Type-Options": {"nosniff"},
+			},
+			wantBody: "stub: not a valid http status code: status \"/users/1\"\n",
+		},
+		{
+			name:       "execution error",
+			giveBody:   "{{.Missing}}",
+			giveTarget: "/users/1",
+			wantCode:   http.StatusInternalServerError,
+			wantHeader: http.Header{
+				"Content-Type":           {"text/plain; charset=utf-8"},
+				"X-Content-Type-Options": {"nosniff"},
+			},
+			wantBody: "stub: template: body:1:2: executing \"body\" at <.Missing>: " +
+				"can't evaluate field Missing in type fault.StubData\n",
+		},
+		{
+			name:     "header execution error",
+			giveBody: "",
+			giveOptions: []StubInjectorOption{
+				WithHeaderTemplate("X-Id", `{{index .Query.id 1}}`),
+			},
+			giveTarget: "/users/1?id=a",
+			wantCode:   http.StatusInternalServerError,
+			wantHeader: http.Header{
+				"Content-Type":           {"text/plain; charset=utf-8"},
+				"X-Content-Type-Options": {"nosniff"},
+			},
+			wantBody: "stub: template: header X-Id:1:2: executing \"header X-Id\" at <index .Query.id 1>: " +
+				"error calling index: reflect: slice index out of range\n",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			si, err := NewStubInjector(http.StatusAccepted, tt.giveBody, tt.giveOptions...)
+			assert.NoError(t, err)
+
+			var called bool
+			mux := http.NewServeMux()
+			mux.Handle("/users/{id}", si.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				called = true
+			})))
+
+			req := httptest.NewRequest(http.MethodGet, tt.giveTarget, nil)
+			req.Header.Set("X-Test", "test")
+			rr := httptest.NewRecorder()
+			mux.ServeHTTP(rr, req)
+
+			assert.False(t, called)
+			assert.Equal(t, tt.wantCode, rr.Code)
+			assert.Equal(t, tt.wantHeader, rr.Header())
+			assert.Equal(t, tt.wantBody, rr.Body.String())
+		})
+	}
+}
@@ -0,0 +1,371 @@
+package fault
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/textproto"
+	"strconv"
+	"sync"
+)
+
+var (
+	// ErrInvalidTampering when an unknown Tampering is provided.
+	ErrInvalidTampering = errors.New("not a valid tampering")
+	// ErrInvalidTamperedBytes when a negative number of tampered bytes is provided.
+	ErrInvalidTamperedBytes = errors.New("tampered bytes must be >= 0")
+	// ErrInvalidContentLength when a negative Content-Length is provided.
+	ErrInvalidContentLength = errors.New("content length must be >= 0")
+)
+
+// maxBufferedBodySize is the largest request body the Injectors that read the whole body buffer.
+const maxBufferedBodySize = 10 << 20
+
+// tamperedHeaderValue replaces the value of mangled request headers. It is a valid header value
+// that parses as neither a number, a date, a media type, nor a credential.
+const tamperedHeaderValue = "go-fault-tampered"
+
+// Tampering is a way of tampering with a request before the handler reads it.
+type Tampering int
+
+const (
+	// TamperTruncateBody cuts the body short, keeping the number of bytes set by WithTamperedBytes or
+	// half of the body by default. Reading past the kept bytes returns io.ErrUnexpectedEOF, like a
+	// client that disconnects while sending, and the Content-Length still declares the whole body.
+	TamperTruncateBody Tampering = iota
+	// TamperFlipBytes replaces bytes of the body at distinct random positions with different values.
+	// It changes the number of bytes set by WithTamperedBytes, or 1 by default.
+	TamperFlipBytes
+	// TamperDropHeaders removes the headers set by WithTamperedHeaders, or every header by default.
+	TamperDropHeaders
+	// TamperMangleHeaders replaces the values of the headers set by WithTamperedHeaders, or of every
+	// header by default, with "go-fault-tampered".
+	TamperMangleHeaders
+	// TamperContentLength declares the Content-Length set by WithClaimedContentLength, or one byte
+	// more than the body by default, without changing the body.
+	TamperContentLength
+)
+
+// String returns the name of the Tampering.
+func (t Tampering) String() string {
+	switch t {
+	case TamperTruncateBody:
+		return "truncate_body"
+	case TamperFlipBytes:
+		return "flip_bytes"
+	case TamperDropHeaders:
+		return "drop_headers"
+	case TamperMangleHeaders:
+		return "mangle_headers"
+	case TamperContentLength:
+		return "content_length"
+	default:
+		return fmt.Sprintf("Tampering(%d)", int(t))
+	}
+}
+
+// RequestTamperInjector tampers with the request before running it, to test how the handler
+// validates input from malformed clients. The caller's request is not changed.
+type RequestTamperInjector struct {
+	tampering Tampering
+	// n is the number of bytes to keep or flip, or -1 for the default.
+	n int
+	// headers are the canonical keys of the headers to drop or mangle, or empty for every header.
+	headers map[string]bool
+	// contentLength is the Content-Length to declare, or -1 for the default.
+	contentLength int64
+
+	randSeed int64
+	rand     *rand.Rand
+
+	// *rand.Rand is not thread safe. This mutex protects our random source
+	randMtx sync.Mutex
+
+	reporter Reporter
+}
+
+// RequestTamperInjectorOption configures a RequestTamperInjector.
+type RequestTamperInjectorOption interface {
+	applyRequestTamperInjector(i *RequestTamperInjector) error
+}
+
+type tamperedBytesOption int
+
+func (o tamperedBytesOption) applyRequestTamperInjector(i *RequestTamperInjector) error {
+	if o < 0 {
+		return ErrInvalidTamperedBytes
+	}
+
+	i.n = int(o)
+	return nil
+}
+
+// WithTamperedBytes sets how many bytes of the body TamperTruncateBody keeps and TamperFlipBytes
+// changes. Other Tamperings ignore it.
+func WithTamperedBytes(n int) RequestTamperInjectorOption {
+	return tamperedBytesOption(n)
+}
+
+type tamperedHeadersOption []string
+
+func (o tamperedHeadersOption) applyRequestTamperInjector(i *RequestTamperInjector) error {
+	for _, k := range o {
+		i.headers[textproto.CanonicalMIMEHeaderKey(k)] = true
+	}
+
+	return nil
+}
+
+// WithTamperedHeaders sets the headers TamperDropHeaders removes and TamperMangleHeaders replaces.
+// Without it every header is tampered with. Other Tamperings ignore it.
+func WithTamperedHeaders(keys ...string) RequestTamperInjectorOption {
+	return tamperedHeadersOption(keys)
+}
+
+type claimedContentLengthOption int64
+
+func (o claimedContentLengthOption) applyRequestTamperInjector(i *RequestTamperInjector) error {
+	if o < 0 {
+		return ErrInvalidContentLength
+	}
+
+	i.contentLength = int64(o)
+	return nil
+}
+
+// WithClaimedContentLength sets the Content-Length TamperContentLength declares. Other Tamperings
+// ignore it.
+func WithClaimedContentLength(n int64) RequestTamperInjectorOption {
+	return claimedContentLengthOption(n)
+}
+
+func (o randSeedOption) applyRequestTamperInjector(i *RequestTamperInjector) error {
+	i.randSeed = int64(o)
+	return nil
+}
+
+func (o reporterOption) applyRequestTamperInjector(i *RequestTamperInjector) error {
+	i.reporter = o.reporter
+	return nil
+}
+
+// NewRequestTamperInjector returns a RequestTamperInjector that applies a Tampering to requests.
+func NewRequestTamperInjector(t Tampering, opts ...RequestTamperInjectorOption) (
+	*RequestTamperInjector, error,
+) {
+	if t < TamperTruncateBody || t > TamperContentLength {
+		return nil, ErrInvalidTampering
+	}
+
+	// set defaults
+	ti := &RequestTamperInjector{
+		tampering:     t,
+		n:             -1,
+		headers:       make(map[string]bool),
+		contentLength: -1,
+		randSeed:      defaultRandSeed,
+		reporter:      NewNoopReporter(),
+	}
+
+	// apply options
+	for _, opt := range opts {
+		err := opt.applyRequestTamperInjector(ti)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	ti.rand = rand.New(rand.NewSource(ti.randSeed))
+
+	return ti, nil
+}
+
+// Handler runs the request on a copy of r that has been tampered with. Tamperings of the body read
+// the whole body first, and run the request untampered if it is larger than 10MiB.
+func (i *RequestTamperInjector) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		go i.reporter.Report(i.String(), StateStarted)
+		defer func() { go i.reporter.Report(i.String(), StateFinished) }()
+
+		r = r.Clone(r.Context())
+
+		switch i.tampering {
+		case TamperTruncateBody:
+			body, ok, err := readBody(r)
+			if !ok {
+				break
+			}
+			keep := i.keep(len(body))
+			if keep < len(body) {
+				body, err = body[:keep], io.ErrUnexpectedEOF
+			}
+			r.Body = &tamperedBody{Reader: bytes.NewReader(body), err: err, closer: r.Body}
+		case TamperFlipBytes:
+			body, ok, err := readBody(r)
+			if !ok {
+				break
+			}
+			i.flip(body)
+			r.Body = &tamperedBody{Reader: bytes.NewReader(body), err: err, closer: r.Body}
+		case TamperDropHeaders:
+			for k := range r.Header {
+				if i.tampers(k) {
+					r.Header.Del(k)
+				}
+			}
+		case TamperMangleHeaders:
+			for k := range r.Header {
+				if i.tampers(k) {
+					r.Header[k] = []string{tamperedHeaderValue}
+				}
+			}
+		case TamperContentLength:
+			body, ok, err := readBody(r)
+			if !ok {
+				break
+			}
+			n := i.contentLength
+			if n < 0 {
+				n = int64(len(body)) + 1
+			}
+			r.Body = &tamperedBody{Reader: bytes.NewReader(body), err: err, closer: r.Body}
+			r.ContentLength = n
+			r.TransferEncoding = nil
+			r.Header.Del("Transfer-Encoding")
+			r.Header.Set("Content-Length", strconv.FormatInt(n, 10))
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// readBody reads the body of r and returns true, with the bytes read before any error. If the body
+// is larger than maxBufferedBodySize it returns false instead, leaving the whole body to be read
+// from r.
+func readBody(r *http.Request) ([]byte, bool, error) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil, true, nil
+	}
+	if r.ContentLength > maxBufferedBodySize {
+		return nil, false, nil
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxBufferedBodySize+1))
+	if len(body) > maxBufferedBodySize {
+		r.Body = &replayedBody{Reader: io.MultiReader(bytes.NewReader(body), r.Body), Closer: r.Body}
+		return nil, false, nil
+	}
+
+	return body, true, err
+}
+
+// replayedBody is a body that reads the bytes already read from it again before the rest.
+type replayedBody struct {
+	io.Reader
+	io.Closer
+}
+
+// keep returns how many of size bytes TamperTruncateBody keeps.
+func (i *RequestTamperInjector) keep(size int) int {
+	if i.n < 0 {
+		return size / 2
+	}
+	if i.n > size {
+		return size
+	}
+
+	return i.n
+}
+
+// flip changes the bytes of body set by WithTamperedBytes, or 1 by default.
+func (i *RequestTamperInjector) flip(body []byte) {
+	n := i.n
+	if n < 0 {
+		n = 1
+	}
+
+	i.randMtx.Lock()
+	defer i.randMtx.Unlock()
+
+	flipBytes(i.rand, body, n)
+}
+
+// tampers returns true if the header with the canonical key k is dropped or mangled.
+func (i *RequestTamperInjector) tampers(k string) bool {
+	return len(i.headers) == 0 || i.headers[k]
+}
+
+// tamperedBody is a request body that has been read and changed. It returns err, if set, instead
+// of io.EOF at its end, and closes the original body.
+type tamperedBody struct {
+	*bytes.Reader
+	err    error
+	closer io.Closer
+}
+
+// Read reads from the changed body.
+func (b *tamperedBody) Read(p []byte) (int, error) {
+	n, err := b.Reader.Read(p)
+	if errors.Is(err, io.EOF) && b.err != nil {
+		err = b.err
+	}
+
+	return n, err
+}
+
+// Close closes the original body.
+func (b *tamperedBody) Close() error {
+	if b.closer == nil {
+		return nil
+	}
+
+	return b.closer.Close()
+}
+
+// Seed returns the seed of the RequestTamperInjector's random number generator.
+func (i *RequestTamperInjector) Seed() int64 {
+	return i.randSeed
+}
+
+// Reporter returns the Reporter of the RequestTamperInjector.
+func (i *RequestTamperInjector) Reporter() Reporter {
+	return i.reporter
+}
+
+// SetReporter replaces the Reporter of the RequestTamperInjector.
+func (i *RequestTamperInjector) SetReporter(r Reporter) {
+	i.reporter = r
+}
+
+// Name returns "tamper".
+func (i *RequestTamperInjector) Name() string {
+	return "tamper"
+}
+
+// Describe returns the Tampering, the random seed, and whichever of the number of tampered bytes,
+// the tampered headers, and the claimed Content-Length are set.
+func (i *RequestTamperInjector) Describe() map[string]string {
+	d := map[string]string{
+		"tampering": i.tampering.String(),
+		"seed":      strconv.FormatInt(i.randSeed, 10),
+	}
+	if i.n >= 0 {
+		d["bytes"] = strconv.Itoa(i.n)
+	}
+	if len(i.headers) > 0 {
+		d["headers"] = trailerSet(false, i.headers)
+	}
+	if i.contentLength >= 0 {
+		d["content_length"] = strconv.FormatInt(i.contentLength, 10)
+	}
+
+	return d
+}
+
+// String returns a summary of the RequestTamperInjector, such as "tamper(truncate_body)".
+func (i *RequestTamperInjector) String() string {
+	return fmt.Sprintf("%s(%s)", i.Name(), i.tampering)
+}
@@ -0,0 +1,375 @@
+package fault
+
+import (
+	"errors"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestNewRequestTamperInjector tests NewRequestTamperInjector.
+func TestNewRequestTamperInjector(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		giveTampering Tampering
+		giveOptions   []RequestTamperInjectorOption
+		want          *RequestTamperInjector
+		wantErr       error
+	}{
+		{
+			name:          "no options",
+			giveTampering: TamperTruncateBody,
+			giveOptions:   []RequestTamperInjectorOption{},
+			want: &RequestTamperInjector{
+				tampering:     TamperTruncateBody,
+				n:             -1,
+				headers:       map[string]bool{},
+				contentLength: -1,
+				randSeed:      defaultRandSeed,
+				rand:          rand.New(rand.NewSource(defaultRandSeed)),
+				reporter:      NewNoopReporter(),
+			},
+			wantErr: nil,
+		},
+		{
+			name:          "options",
+			giveTampering: TamperDropHeaders,
+			giveOptions: []RequestTamperInjectorOption{
+				WithTamperedBytes(0),
+				WithTamperedHeaders("content-type", "X-Request-Id"),
+				WithClaimedContentLength(0),
+				WithRandSeed(5),
+				WithReporter(newTestReporter()),
+			},
+			want: &RequestTamperInjector{
+				tampering:     TamperDropHeaders,
+				n:             0,
+				headers:       map[string]bool{"Content-Type": true, "X-Request-Id": true},
+				contentLength: 0,
+				randSeed:      5,
+				rand:          rand.New(rand.NewSource(5)),
+				reporter:      newTestReporter(),
+			},
+			wantErr: nil,
+		},
+		{
+			name:          "invalid tampering",
+			giveTampering: TamperContentLength + 1,
+			giveOptions:   []RequestTamperInjectorOption{},
+			want:          nil,
+			wantErr:       ErrInvalidTampering,
+		},
+		{
+			name:          "negative tampering",
+			giveTampering: -1,
+			giveOptions:   []RequestTamperInjectorOption{},
+			want:          nil,
+			wantErr:       ErrInvalidTampering,
+		},
+		{
+			name:          "negative bytes",
+			giveTampering: TamperTruncateBody,
+			giveOptions: []RequestTamperInjectorOption{
+				WithTamperedBytes(-1),
+			},
+			want:    nil,
+			wantErr: ErrInvalidTamperedBytes,
+		},
+		{
+			name:          "negative content length",
+			giveTampering: TamperContentLength,
+			giveOptions: []RequestTamperInjectorOption{
+				WithClaimedContentLength(-1),
+			},
+			want:    nil,
+			wantErr: ErrInvalidContentLength,
+		},
+		{
+			name:          "option error",
+			giveTampering: TamperTruncateBody,
+			giveOptions: []RequestTamperInjectorOption{
+				withError(),
+			},
+			want:    nil,
+			wantErr: errErrorOption,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ti, err := NewRequestTamperInjector(tt.giveTampering, tt.giveOptions...)
+
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.want, ti)
+		})
+	}
+}
+
+// TestRequestTamperInjectorHandler tests the request the handler of a RequestTamperInjector reads.
+func TestRequestTamperInjectorHandler(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name              string
+		giveTampering     Tampering
+		giveOptions       []RequestTamperInjectorOption
+		giveBody          string
+		wantBody          string
+		wantErr           error
+		wantContentLength int64
+		wantHeader        http.Header
+	}{
+		{
+			name:              "truncate body",
+			giveTampering:     TamperTruncateBody,
+			giveBody:          `{"items":[1,2,3]}`,
+			wantBody:          `{"items"`,
+			wantErr:           io.ErrUnexpectedEOF,
+			wantContentLength: 17,
+		},
+		{
+			name:              "truncate bytes",
+			giveTampering:     TamperTruncateBody,
+			giveOptions:       []RequestTamperInjectorOption{WithTamperedBytes(3)},
+			giveBody:          `{"items":[1,2,3]}`,
+			wantBody:          `{"i`,
+			wantErr:           io.ErrUnexpectedEOF,
+			wantContentLength: 17,
+		},
+		{
+			name:              "truncate more bytes than the body",
+			giveTampering:     TamperTruncateBody,
+			giveOptions:       []RequestTamperInjectorOption{WithTamperedBytes(100)},
+			giveBody:          `{}`,
+			wantBody:          `{}`,
+			wantContentLength: 2,
+		},
+		{
+			name:              "truncate empty body",
+			giveTampering:     TamperTruncateBody,
+			wantContentLength: 0,
+		},
+		{
+			name:          "drop headers",
+			giveTampering: TamperDropHeaders,
+			giveOptions:   []RequestTamperInjectorOption{WithTamperedHeaders("content-type")},
+			giveBody:      "hello",
+			wantBody:      "hello",
+			wantHeader: http.Header{
+				"X-Request-Id": {"1"},
+			},
+			wantContentLength: 5,
+		},
+		{
+			name:              "drop every header",
+			giveTampering:     TamperDropHeaders,
+			giveBody:          "hello",
+			wantBody:          "hello",
+			wantHeader:        http.Header{},
+			wantContentLength: 5,
+		},
+		{
+			name:          "mangle headers",
+			giveTampering: TamperMangleHeaders,
+			giveOptions:   []RequestTamperInjectorOption{WithTamperedHeaders("Content-Type")},
+			giveBody:      "hello",
+			wantBody:      "hello",
+			wantHeader: http.Header{
+				"Content-Type": {"go-fault-tampered"},
+				"X-Request-Id": {"1"},
+			},
+			wantContentLength: 5,
+		},
+		{
+			name:          "content length",
+			giveTampering: TamperContentLength,
+			giveBody:      "hello",
+			wantBody:      "hello",
+			wantHeader: http.Header{
+				"Content-Length": {"6"},
+				"Content-Type":   {"application/json"},
+				"X-Request-Id":   {"1"},
+			},
+			wantContentLength: 6,
+		},
+		{
+			name:          "claimed content length",
+			giveTampering: TamperContentLength,
+			giveOptions:   []RequestTamperInjectorOption{WithClaimedContentLength(2)},
+			giveBody:      "hello",
+			wantBody:      "hello",
+			wantHeader: http.Header{
+				"Content-Length": {"2"},
+				"Content-Type":   {"application/json"},
+				"X-Request-Id":   {"1"},
+			},
+			wantContentLength: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			reporter := newTestStateReporter()
+			ti, err := NewRequestTamperInjector(tt.giveTampering,
+				append(tt.giveOptions, WithReporter(reporter))...)
+			assert.NoError(t, err)
+
+			var gotBody string
+			var gotErr error
+			var gotContentLength int64
+			var gotHeader http.Header
+			h := ti.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				b, err := io.ReadAll(r.Body)
+				gotBody, gotErr, gotContentLength, gotHeader = string(b), err, r.ContentLength, r.Header
+			}))
+
+			var body io.Reader
+			if tt.giveBody != "" {
+				body = strings.NewReader(tt.giveBody)
+			}
+			req := httptest.NewRequest(http.MethodPost, "/", body)
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("X-Request-Id", "1")
+			h.ServeHTTP(httptest.NewRecorder(), req)
+
+			assert.Equal(t, tt.wantBody, gotBody)
+			assert.True(t, errors.Is(gotErr, tt.wantErr), gotErr)
+			assert.Equal(t, tt.wantContentLength, gotContentLength)
+			if tt.wantHeader != nil {
+				assert.Equal(t, tt.wantHeader, gotHeader)
+			}
+
+			// the request of the caller is not changed
+			assert.Equal(t, "application/json", req.Header.Get("Content-Type"))
+			assert.Equal(t, int64(len(tt.giveBody)), req.ContentLength)
+
+			// the states are reported concurrently, so they may arrive in any order
+			states := map[InjectorState]int{}
+			for n := 0; n < 2; n++ {
+				states[<-reporter.states]++
+			}
+			assert.Equal(t, map[InjectorState]int{StateStarted: 1, StateFinished: 1}, states)
+		})
+	}
+}
+
+// TestRequestTamperInjectorFlipBytes tests that TamperFlipBytes changes as many bytes as set, the
+// same way for the same seed.
+func TestRequestTamperInjectorFlipBytes(t *testing.T) {
+	t.Parallel()
+
+	body := strings.Repeat("a", 1000)
+
+	tests := []struct {
+		name        string
+		giveOptions []RequestTamperInjectorOption
+		giveBody    string
+		wantFlipped int
+	}{
+		{
+			name:        "default",
+			giveBody:    body,
+			wantFlipped: 1,
+		},
+		{
+			name:        "bytes",
+			giveOptions: []RequestTamperInjectorOption{WithTamperedBytes(3)},
+			giveBody:    body,
+			wantFlipped: 3,
+		},
+		{
+			name:        "more bytes than the body",
+			giveOptions: []RequestTamperInjectorOption{WithTamperedBytes(10)},
+			giveBody:    "abc",
+			wantFlipped: 3,
+		},
+		{
+			name: "empty body",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			serve := func() string {
+				ti, err := NewRequestTamperInjector(TamperFlipBytes, tt.giveOptions...)
+				assert.NoError(t, err)
+
+				var got string
+				ti.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					b, err := io.ReadAll(r.Body)
+					assert.NoError(t, err)
+					got = string(b)
+				})).ServeHTTP(httptest.NewRecorder(),
+					httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.giveBody)))
+
+				return got
+			}
+
+			got := serve()
+			assert.Equal(t, got, serve())
+			assert.Equal(t, len(tt.giveBody), len(got))
+
+			var flipped int
+			for idx := range got {
+				if got[idx] != tt.giveBody[idx] {
+					flipped++
+				}
+			}
+			assert.Equal(t, tt.wantFlipped, flipped)
+		})
+	}
+}
+
+// TestRequestTamperInjectorLargeBody tests that a body too large to buffer is not tampered with,
+// whether or not its length is known.
+func TestRequestTamperInjectorLargeBody(t *testing.T) {
+	t.Parallel()
+
+	body := strings.Repeat("a", maxBufferedBodySize+1)
+
+	for _, unknownLength := range []bool{false, true} {
+		ti, err := NewRequestTamperInjector(TamperTruncateBody)
+		assert.NoError(t, err)
+
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		if unknownLength {
+			req.ContentLength = -1
+		}
+
+		var got []byte
+		ti.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got, err = io.ReadAll(r.Body)
+			assert.NoError(t, err)
+		})).ServeHTTP(httptest.NewRecorder(), req)
+
+		assert.Equal(t, len(body), len(got))
+	}
+}
+
+// TestTamperingString tests Tampering.String.
+func TestTamperingString(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "truncate_body", TamperTruncateBody.String())
+	assert.Equal(t, "flip_bytes", TamperFlipBytes.String())
+	assert.Equal(t, "drop_headers", TamperDropHeaders.String())
+	assert.Equal(t, "mangle_headers", TamperMangleHeaders.String())
+	assert.Equal(t, "content_length", TamperContentLength.String())
+	assert.Equal(t, "Tampering(7)", Tampering(7).String())
+}
@@ -0,0 +1,67 @@
+package fault
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestInjectorStateString tests InjectorState.String.
+func TestInjectorStateString(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		give InjectorState
+		want string
+	}{
+		{StateStarted, "started"},
+		{StateFinished, "finished"},
+		{StateSkipped, "skipped"},
+		{StateUnmatched, "unmatched"},
+		{StateSelected, "selected"},
+		{StatePanicked, "panicked"},
+		{StateDeadlineExceeded, "deadline_exceeded"},
+		{InjectorState(0), "unknown"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.want, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, tt.give.String())
+		})
+	}
+}
+
+// TestInjectorErrors tests that InjectorErrors name each offending Injector and match its errors.
+func TestInjectorErrors(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewChainInjector([]Injector{nil, newTestInjectorNoop(), nil})
+
+	assert.EqualError(t, err, "injector 0: injector cannot be nil; injector 2: injector cannot be nil")
+	assert.True(t, errors.Is(err, ErrNilInjector))
+	assert.False(t, errors.Is(err, ErrDuplicateInjector))
+
+	var ie InjectorErrors
+	assert.True(t, errors.As(err, &ie))
+	assert.Equal(t, 2, ie[1].Index)
+}
+
+// testInjectorValue is an Injector with a value receiver.
+type testInjectorValue int
+
+func (i testInjectorValue) Handler(next http.Handler) http.Handler { return next }
+
+// TestValidateInjectorsValues tests that repeated value and zero-size Injectors are allowed.
+func TestValidateInjectorsValues(t *testing.T) {
+	t.Parallel()
+
+	v := testInjectorValue(500)
+	noop := newTestInjectorNoop()
+
+	assert.NoError(t, validateInjectors([]Injector{v, v, noop, noop}))
+}
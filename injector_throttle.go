@@ -0,0 +1,298 @@
+package fault
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrInvalidRetryAfter when a negative Retry-After is provided.
+	ErrInvalidRetryAfter = errors.New("retry after must be >= 0")
+	// ErrInvalidAllowance when a ThrottleInjector is asked to let a negative number of requests
+	// through per client, or per a window that is not positive.
+	ErrInvalidAllowance = errors.New("allowance must be >= 0 requests per window > 0")
+)
+
+// defaultRetryAfter is the Retry-After of a ThrottleInjector without WithRetryAfter or
+// WithThrottleAfter.
+const defaultRetryAfter = time.Second
+
+// ThrottleInjector simulates rate limiting. It responds 429 Too Many Requests, or another status
+// such as 503 Service Unavailable, with a Retry-After header, to validate the back-off of clients
+// end to end. With WithThrottleAfter it first lets a number of requests of each client through in
+// every window, like a real rate limiter.
+type ThrottleInjector struct {
+	statusCode int
+	// retryAfter is the Retry-After to send, or -1 for the time left in the window of the client.
+	retryAfter time.Duration
+	httpDate   bool
+
+	// allowance is the number of requests of a client let through per window, or -1 to throttle
+	// every request.
+	allowance int
+	window    time.Duration
+	keyF      func(r *http.Request) string
+	clock     Clock
+
+	mtx sync.Mutex
+	// clients holds the current window of each client.
+	clients map[string]*throttleWindow
+	// swept is when clients were last swept of ended windows.
+	swept time.Time
+
+	reporter Reporter
+}
+
+// throttleWindow counts the requests of a client in its current window.
+type throttleWindow struct {
+	start time.Time
+	count int
+}
+
+// ThrottleInjectorOption configures a ThrottleInjector.
+type ThrottleInjectorOption interface {
+	applyThrottleInjector(i *ThrottleInjector) error
+}
+
+type throttleStatusOption int
+
+func (o throttleStatusOption) applyThrottleInjector(i *ThrottleInjector) error {
+	i.statusCode = int(o)
+	return nil
+}
+
+// WithThrottleStatus sets the status of throttled responses, such as 503 Service Unavailable.
+// Default 429 Too Many Requests.
+func WithThrottleStatus(code int) ThrottleInjectorOption {
+	return throttleStatusOption(code)
+}
+
+type retryAfterOption time.Duration
+
+func (o retryAfterOption) applyThrottleInjector(i *ThrottleInjector) error {
+	if o < 0 {
+		return ErrInvalidRetryAfter
+	}
+
+	i.retryAfter = time.Duration(o)
+	return nil
+}
+
+// WithRetryAfter sets how long throttled clients are told to wait. It is sent in seconds, rounded
+// up. Default the time left in the window of the client with WithThrottleAfter, or 1 second.
+func WithRetryAfter(d time.Duration) ThrottleInjectorOption {
+	return retryAfterOption(d)
+}
+
+type retryAfterHTTPDateOption struct{}
+
+func (o retryAfterHTTPDateOption) applyThrottleInjector(i *ThrottleInjector) error {
+	i.httpDate = true
+	return nil
+}
+
+// WithRetryAfterHTTPDate sends Retry-After as the HTTP-date clients may retry at, such as
+// "Tue, 02 Jan 2024 14:00:30 GMT", instead of a number of seconds. Clients must handle both.
+func WithRetryAfterHTTPDate() ThrottleInjectorOption {
+	return retryAfterHTTPDateOption{}
+}
+
+type throttleAfterOption struct {
+	n      int
+	window time.Duration
+	keyF   func(r *http.Request) string
+}
+
+func (o throttleAfterOption) applyThrottleInjector(i *ThrottleInjector) error {
+	if o.n < 0 || o.window <= 0 {
+		return ErrInvalidAllowance
+	}
+
+	i.allowance = o.n
+	i.window = o.window
+	i.keyF = o.keyF
+	if i.keyF == nil {
+		i.keyF = clientIP
+	}
+
+	return nil
+}
+
+// WithThrottleAfter lets the first n requests of each client through in every window and
+// throttles the rest, so clients can be seen to back off and recover. A client's window starts with
+// its first request. key returns the client a request belongs to, such as HeaderKey("X-Api-Key");
+// requests with an empty key share an allowance. A nil key uses the IP of the client. The window
+// is timed with the Clock set by WithClock().
+func WithThrottleAfter(n int, window time.Duration, key func(r *http.Request) string) ThrottleInjectorOption {
+	return throttleAfterOption{n: n, window: window, keyF: key}
+}
+
+func (o clockOption) applyThrottleInjector(i *ThrottleInjector) error {
+	i.clock = o.clock
+	return nil
+}
+
+func (o reporterOption) applyThrottleInjector(i *ThrottleInjector) error {
+	i.reporter = o.reporter
+	return nil
+}
+
+// NewThrottleInjector returns a ThrottleInjector that throttles every request with 429 Too Many
+// Requests and "Retry-After: 1" unless configured otherwise.
+func NewThrottleInjector(opts ...ThrottleInjectorOption) (*ThrottleInjector, error) {
+	// set defaults
+	ti := &ThrottleInjector{
+		statusCode: http.StatusTooManyRequests,
+		retryAfter: -1,
+		allowance:  -1,
+		clock:      NewRealClock(),
+		reporter:   NewNoopReporter(),
+	}
+
+	// apply options
+	for _, opt := range opts {
+		err := opt.applyThrottleInjector(ti)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// check options
+	if http.StatusText(ti.statusCode) == "" {
+		return nil, ErrInvalidHTTPCode
+	}
+	if ti.retryAfter < 0 && ti.allowance < 0 {
+		ti.retryAfter = defaultRetryAfter
+	}
+
+	return ti, nil
+}
+
+// Handler throttles the request, or continues if the client has not used its allowance. Requests
+// let through report StateSkipped.
+func (i *ThrottleInjector) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		now := i.clock.Now()
+
+		left, ok := i.take(r, now)
+		if ok {
+			go i.reporter.Report(i.String(), StateSkipped)
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		go i.reporter.Report(i.String(), StateStarted)
+
+		if i.retryAfter >= 0 {
+			left = i.retryAfter
+		}
+		seconds := (left + time.Second - 1) / time.Second
+
+		h := w.Header()
+		h.Del("Content-Length")
+		if i.httpDate {
+			h.Set("Retry-After", now.Add(seconds*time.Second).UTC().Format(http.TimeFormat))
+		} else {
+			h.Set("Retry-After", strconv.FormatInt(int64(seconds), 10))
+		}
+		h.Set("Content-Type", defaultErrorContentType)
+		h.Set("X-Content-Type-Options", "nosniff")
+		w.WriteHeader(i.statusCode)
+		_, _ = fmt.Fprintln(w, http.StatusText(i.statusCode))
+
+		go i.reporter.Report(i.String(), StateFinished)
+	})
+}
+
+// take counts r against the allowance of its client at now and returns true if it may continue.
+// Otherwise it returns the time left in the window of the client.
+func (i *ThrottleInjector) take(r *http.Request, now time.Time) (time.Duration, bool) {
+	if i.allowance < 0 {
+		return 0, false
+	}
+
+	key := i.keyF(r)
+
+	i.mtx.Lock()
+	defer i.mtx.Unlock()
+
+	i.sweep(now)
+
+	cw, ok := i.clients[key]
+	if !ok || !now.Before(cw.start.Add(i.window)) {
+		if i.clients == nil {
+			i.clients = make(map[string]*throttleWindow)
+		}
+		cw = &throttleWindow{start: now}
+		i.clients[key] = cw
+	}
+
+	if cw.count < i.allowance {
+		cw.count++
+		return 0, true
+	}
+
+	return cw.start.Add(i.window).Sub(now), false
+}
+
+// sweep forgets the clients whose window ended, at most once per window. i.mtx must be held.
+func (i *ThrottleInjector) sweep(now time.Time) {
+	if now.Before(i.swept.Add(i.window)) {
+		return
+	}
+
+	for key, cw := range i.clients {
+		if !now.Before(cw.start.Add(i.window)) {
+			delete(i.clients, key)
+		}
+	}
+	i.swept = now
+}
+
+// Reporter returns the Reporter of the ThrottleInjector.
+func (i *ThrottleInjector) Reporter() Reporter {
+	return i.reporter
+}
+
+// SetReporter replaces the Reporter of the ThrottleInjector.
+func (i *ThrottleInjector) SetReporter(r Reporter) {
+	i.reporter = r
+}
+
+// Name returns "throttle".
+func (i *ThrottleInjector) Name() string {
+	return "throttle"
+}
+
+// Describe returns the status code, the Retry-After if fixed, its format if an HTTP-date, and the
+// allowance per client if set.
+func (i *ThrottleInjector) Describe() map[string]string {
+	d := map[string]string{
+		"code": strconv.Itoa(i.statusCode),
+	}
+	if i.retryAfter >= 0 {
+		d["retry_after"] = i.retryAfter.String()
+	}
+	if i.httpDate {
+		d["retry_after_format"] = "http-date"
+	}
+	if i.allowance >= 0 {
+		d["allowance"] = fmt.Sprintf("%d/%s", i.allowance, i.window)
+	}
+
+	return d
+}
+
+// String returns a summary of the ThrottleInjector, such as "throttle(429)" or
+// "throttle(429 after 5/1m0s)".
+func (i *ThrottleInjector) String() string {
+	if i.allowance < 0 {
+		return fmt.Sprintf("%s(%d)", i.Name(), i.statusCode)
+	}
+
+	return fmt.Sprintf("%s(%d after %d/%s)", i.Name(), i.statusCode, i.allowance, i.window)
+}
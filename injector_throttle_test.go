@@ -0,0 +1,259 @@
+package fault
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/github/go-fault/faulttest"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestNewThrottleInjector tests NewThrottleInjector.
+func TestNewThrottleInjector(t *testing.T) {
+	t.Parallel()
+
+	clock := faulttest.NewClock(time.Time{})
+
+	tests := []struct {
+		name           string
+		giveOptions    []ThrottleInjectorOption
+		wantCode       int
+		wantRetryAfter time.Duration
+		wantHTTPDate   bool
+		wantAllowance  int
+		wantWindow     time.Duration
+		wantClock      Clock
+		wantReporter   Reporter
+		wantErr        error
+	}{
+		{
+			name:           "no options",
+			giveOptions:    []ThrottleInjectorOption{},
+			wantCode:       http.StatusTooManyRequests,
+			wantRetryAfter: time.Second,
+			wantAllowance:  -1,
+			wantClock:      NewRealClock(),
+			wantReporter:   NewNoopReporter(),
+		},
+		{
+			name: "options",
+			giveOptions: []ThrottleInjectorOption{
+				WithThrottleStatus(http.StatusServiceUnavailable),
+				WithRetryAfter(0),
+				WithRetryAfterHTTPDate(),
+				WithThrottleAfter(0, time.Minute, HeaderKey("X-Api-Key")),
+				WithClock(clock),
+				WithReporter(newTestReporter()),
+			},
+			wantCode:       http.StatusServiceUnavailable,
+			wantRetryAfter: 0,
+			wantHTTPDate:   true,
+			wantAllowance:  0,
+			wantWindow:     time.Minute,
+			wantClock:      clock,
+			wantReporter:   newTestReporter(),
+		},
+		{
+			name: "allowance without retry after",
+			giveOptions: []ThrottleInjectorOption{
+				WithThrottleAfter(5, time.Minute, nil),
+			},
+			wantCode:       http.StatusTooManyRequests,
+			wantRetryAfter: -1,
+			wantAllowance:  5,
+			wantWindow:     time.Minute,
+			wantClock:      NewRealClock(),
+			wantReporter:   NewNoopReporter(),
+		},
+		{
+			name: "invalid code",
+			giveOptions: []ThrottleInjectorOption{
+				WithThrottleStatus(0),
+			},
+			wantErr: ErrInvalidHTTPCode,
+		},
+		{
+			name: "negative retry after",
+			giveOptions: []ThrottleInjectorOption{
+				WithRetryAfter(-time.Second),
+			},
+			wantErr: ErrInvalidRetryAfter,
+		},
+		{
+			name: "negative allowance",
+			giveOptions: []ThrottleInjectorOption{
+				WithThrottleAfter(-1, time.Minute, nil),
+			},
+			wantErr: ErrInvalidAllowance,
+		},
+		{
+			name: "invalid window",
+			giveOptions: []ThrottleInjectorOption{
+				WithThrottleAfter(5, 0, nil),
+			},
+			wantErr: ErrInvalidAllowance,
+		},
+		{
+			name: "option error",
+			giveOptions: []ThrottleInjectorOption{
+				withError(),
+			},
+			wantErr: errErrorOption,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ti, err := NewThrottleInjector(tt.giveOptions...)
+
+			assert.Equal(t, tt.wantErr, err)
+			if tt.wantErr != nil {
+				assert.Nil(t, ti)
+				return
+			}
+
+			assert.Equal(t, tt.wantCode, ti.statusCode)
+			assert.Equal(t, tt.wantRetryAfter, ti.retryAfter)
+			assert.Equal(t, tt.wantHTTPDate, ti.httpDate)
+			assert.Equal(t, tt.wantAllowance, ti.allowance)
+			assert.Equal(t, tt.wantWindow, ti.window)
+			assert.Equal(t, tt.wantClock, ti.clock)
+			assert.Equal(t, tt.wantReporter, ti.reporter)
+			assert.Equal(t, tt.wantAllowance >= 0, ti.keyF != nil)
+		})
+	}
+}
+
+// TestThrottleInjectorHandler tests the responses of a ThrottleInjector.
+func TestThrottleInjectorHandler(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2024, time.January, 2, 14, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name           string
+		giveOptions    []ThrottleInjectorOption
+		wantCode       int
+		wantRetryAfter string
+	}{
+		{
+			name:           "default",
+			wantCode:       http.StatusTooManyRequests,
+			wantRetryAfter: "1",
+		},
+		{
+			name: "options",
+			giveOptions: []ThrottleInjectorOption{
+				WithThrottleStatus(http.StatusServiceUnavailable),
+				WithRetryAfter(1500 * time.Millisecond),
+			},
+			wantCode:       http.StatusServiceUnavailable,
+			wantRetryAfter: "2",
+		},
+		{
+			name: "http date",
+			giveOptions: []ThrottleInjectorOption{
+				WithRetryAfter(30 * time.Second),
+				WithRetryAfterHTTPDate(),
+			},
+			wantCode:       http.StatusTooManyRequests,
+			wantRetryAfter: "Tue, 02 Jan 2024 14:00:30 GMT",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			reporter := newTestStateReporter()
+			ti, err := NewThrottleInjector(append(tt.giveOptions,
+				WithClock(faulttest.NewClock(start)), WithReporter(reporter))...)
+			assert.NoError(t, err)
+
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(testHandlerCode)
+			})
+
+			rr := httptest.NewRecorder()
+			rr.Header().Set("Content-Length", "2")
+			ti.Handler(next).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			assert.Equal(t, tt.wantCode, rr.Code)
+			assert.Equal(t, tt.wantRetryAfter, rr.Header().Get("Retry-After"))
+			assert.Equal(t, "", rr.Header().Get("Content-Length"))
+			assert.Equal(t, "nosniff", rr.Header().Get("X-Content-Type-Options"))
+			assert.Equal(t, http.StatusText(tt.wantCode)+"\n", rr.Body.String())
+
+			// the states are reported concurrently, so they may arrive in any order
+			states := map[InjectorState]int{}
+			for n := 0; n < 2; n++ {
+				states[<-reporter.states]++
+			}
+			assert.Equal(t, map[InjectorState]int{StateStarted: 1, StateFinished: 1}, states)
+		})
+	}
+}
+
+// TestThrottleInjectorAllowance tests that WithThrottleAfter lets the first requests of each client
+// through in every window and tells throttled clients when their window ends.
+func TestThrottleInjectorAllowance(t *testing.T) {
+	t.Parallel()
+
+	clock := faulttest.NewClock(time.Date(2024, time.January, 2, 14, 0, 0, 0, time.UTC))
+	reporter := newTestStateReporter()
+	ti, err := NewThrottleInjector(WithThrottleAfter(2, time.Minute, HeaderKey("X-Api-Key")),
+		WithClock(clock), WithReporter(reporter))
+	assert.NoError(t, err)
+
+	h := ti.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(testHandlerCode)
+	}))
+	serve := func(key string) (int, string) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("X-Api-Key", key)
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+
+		return rr.Code, rr.Header().Get("Retry-After")
+	}
+
+	// a is let through twice, then throttled until its window ends
+	for _, want := range []int{testHandlerCode, testHandlerCode, http.StatusTooManyRequests} {
+		code, _ := serve("a")
+		assert.Equal(t, want, code)
+	}
+
+	// the states are reported concurrently, so they may arrive in any order
+	states := map[InjectorState]int{}
+	for n := 0; n < 4; n++ {
+		states[<-reporter.states]++
+	}
+	assert.Equal(t, map[InjectorState]int{StateSkipped: 2, StateStarted: 1, StateFinished: 1}, states)
+	ti.SetReporter(NewNoopReporter())
+
+	clock.Advance(20*time.Second + time.Millisecond)
+	code, retryAfter := serve("a")
+	assert.Equal(t, http.StatusTooManyRequests, code)
+	assert.Equal(t, "40", retryAfter)
+
+	// b has an allowance of its own
+	code, _ = serve("b")
+	assert.Equal(t, testHandlerCode, code)
+
+	// a's window ends, and b's is forgotten once it has ended too
+	clock.Advance(40 * time.Second)
+	code, _ = serve("a")
+	assert.Equal(t, testHandlerCode, code)
+	assert.Len(t, ti.clients, 2)
+
+	clock.Advance(time.Minute)
+	code, _ = serve("a")
+	assert.Equal(t, testHandlerCode, code)
+	assert.Len(t, ti.clients, 1)
+}
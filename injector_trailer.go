@@ -0,0 +1,266 @@
+package fault
+
+import (
+	"fmt"
+	"net/http"
+	"net/textproto"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// corruptTrailerValue replaces the value of corrupted trailers. It is a valid header value
+	// that parses as neither a number nor a checksum.
+	corruptTrailerValue = "go-fault-corrupt"
+
+	// allTrailers stands for every trailer in Describe and String.
+	allTrailers = "*"
+)
+
+// TrailerInjector runs the request and then adds, drops, or corrupts the HTTP trailers of the
+// response. Trailers are only sent on responses without a Content-Length, which the net/http
+// server handles by using chunked encoding on HTTP/1.1.
+type TrailerInjector struct {
+	add     http.Header
+	drop    map[string]bool
+	corrupt map[string]bool
+
+	dropAll    bool
+	corruptAll bool
+
+	reporter Reporter
+}
+
+// TrailerInjectorOption configures a TrailerInjector.
+type TrailerInjectorOption interface {
+	applyTrailerInjector(i *TrailerInjector) error
+}
+
+type trailerOption struct {
+	key   string
+	value string
+}
+
+func (o trailerOption) applyTrailerInjector(i *TrailerInjector) error {
+	i.add.Set(o.key, o.value)
+	return nil
+}
+
+// WithTrailer adds a trailer to the response, replacing any trailer with the same key.
+func WithTrailer(key, value string) TrailerInjectorOption {
+	return trailerOption{key, value}
+}
+
+type grpcStatusOption struct {
+	code    int
+	message string
+}
+
+func (o grpcStatusOption) applyTrailerInjector(i *TrailerInjector) error {
+	i.add.Set("Grpc-Status", strconv.Itoa(o.code))
+	i.add.Set("Grpc-Message", o.message)
+	return nil
+}
+
+// WithGRPCStatus adds the Grpc-Status and Grpc-Message trailers that gRPC servers use to report
+// the status of a call.
+func WithGRPCStatus(code int, message string) TrailerInjectorOption {
+	return grpcStatusOption{code, message}
+}
+
+type dropTrailersOption []string
+
+func (o dropTrailersOption) applyTrailerInjector(i *TrailerInjector) error {
+	i.dropAll = len(o) == 0
+	for _, k := range o {
+		i.drop[textproto.CanonicalMIMEHeaderKey(k)] = true
+	}
+	return nil
+}
+
+// WithDropTrailers drops the trailers with keys from the response. With no keys every trailer
+// the handler set is dropped.
+func WithDropTrailers(keys ...string) TrailerInjectorOption {
+	return dropTrailersOption(keys)
+}
+
+type corruptTrailersOption []string
+
+func (o corruptTrailersOption) applyTrailerInjector(i *TrailerInjector) error {
+	i.corruptAll = len(o) == 0
+	for _, k := range o {
+		i.corrupt[textproto.CanonicalMIMEHeaderKey(k)] = true
+	}
+	return nil
+}
+
+// WithCorruptTrailers replaces the value of the trailers with keys with garbage. With no keys every
+// trailer the handler set is corrupted.
+func WithCorruptTrailers(keys ...string) TrailerInjectorOption {
+	return corruptTrailersOption(keys)
+}
+
+func (o reporterOption) applyTrailerInjector(i *TrailerInjector) error {
+	i.reporter = o.reporter
+	return nil
+}
+
+// NewTrailerInjector returns a TrailerInjector.
+func NewTrailerInjector(opts ...TrailerInjectorOption) (*TrailerInjector, error) {
+	// set defaults
+	ti := &TrailerInjector{
+		add:      make(http.Header),
+		drop:     make(map[string]bool),
+		corrupt:  make(map[string]bool),
+		reporter: NewNoopReporter(),
+	}
+
+	// apply options
+	for _, opt := range opts {
+		err := opt.applyTrailerInjector(ti)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return ti, nil
+}
+
+// Handler runs the request and then changes the trailers the handler set. Trailers are dropped and
+// corrupted before new trailers are added.
+func (i *TrailerInjector) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		go i.reporter.Report(i.String(), StateStarted)
+
+		next.ServeHTTP(w, r)
+
+		h := w.Header()
+		declared := declaredTrailers(h)
+
+		for _, k := range trailerKeys(h, declared) {
+			key := http.TrailerPrefix + k
+			if declared[k] {
+				key = k
+			}
+
+			switch {
+			case i.dropAll || i.drop[k]:
+				delete(h, key)
+			case i.corruptAll || i.corrupt[k]:
+				h[key] = []string{corruptTrailerValue}
+			}
+		}
+
+		for k, v := range i.add {
+			if declared[k] {
+				h[k] = v
+			} else {
+				h[http.TrailerPrefix+k] = v
+			}
+		}
+
+		go i.reporter.Report(i.String(), StateFinished)
+	})
+}
+
+// declaredTrailers returns the trailer keys declared in the Trailer header.
+func declaredTrailers(h http.Header) map[string]bool {
+	declared := make(map[string]bool)
+	for _, v := range h["Trailer"] {
+		for _, k := range strings.Split(v, ",") {
+			k = textproto.CanonicalMIMEHeaderKey(strings.TrimSpace(k))
+			if k != "" {
+				declared[k] = true
+			}
+		}
+	}
+
+	return declared
+}
+
+// trailerKeys returns the keys of the trailers set in h, either declared or with
+// http.TrailerPrefix.
+func trailerKeys(h http.Header, declared map[string]bool) []string {
+	var keys []string
+	for k := range h {
+		if strings.HasPrefix(k, http.TrailerPrefix) {
+			keys = append(keys, textproto.CanonicalMIMEHeaderKey(strings.TrimPrefix(k, http.TrailerPrefix)))
+		} else if declared[k] {
+			keys = append(keys, k)
+		}
+	}
+
+	return keys
+}
+
+// Name returns "trailer".
+func (i *TrailerInjector) Name() string {
+	return "trailer"
+}
+
+// Describe returns the trailers that are added, dropped, and corrupted.
+func (i *TrailerInjector) Describe() map[string]string {
+	d := make(map[string]string)
+	if len(i.add) > 0 {
+		d["add"] = strings.Join(sortedHeaderKeys(i.add), ", ")
+	}
+	if s := trailerSet(i.dropAll, i.drop); s != "" {
+		d["drop"] = s
+	}
+	if s := trailerSet(i.corruptAll, i.corrupt); s != "" {
+		d["corrupt"] = s
+	}
+
+	return d
+}
+
+// String returns a summary of the TrailerInjector, such as "trailer(add: Grpc-Status; drop: *)".
+func (i *TrailerInjector) String() string {
+	d := i.Describe()
+
+	var parts []string
+	for _, k := range []string{"add", "drop", "corrupt"} {
+		if v, ok := d[k]; ok {
+			parts = append(parts, k+": "+v)
+		}
+	}
+
+	return fmt.Sprintf("%s(%s)", i.Name(), strings.Join(parts, "; "))
+}
+
+// sortedHeaderKeys returns the keys of h in order.
+func sortedHeaderKeys(h http.Header) []string {
+	keys := make([]string, 0, len(h))
+	for k := range h {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// trailerSet describes a set of trailer keys, or every trailer if all is true.
+func trailerSet(all bool, keys map[string]bool) string {
+	if all {
+		return allTrailers
+	}
+
+	sorted := make([]string, 0, len(keys))
+	for k := range keys {
+		sorted = append(sorted, k)
+	}
+	sort.Strings(sorted)
+
+	return strings.Join(sorted, ", ")
+}
+
+// Reporter returns the Reporter of the TrailerInjector.
+func (i *TrailerInjector) Reporter() Reporter {
+	return i.reporter
+}
+
+// SetReporter replaces the Reporter of the TrailerInjector.
+func (i *TrailerInjector) SetReporter(r Reporter) {
+	i.reporter = r
+}
@@ -0,0 +1,178 @@
+package fault
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestNewTrailerInjector tests NewTrailerInjector.
+func TestNewTrailerInjector(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		giveOptions []TrailerInjectorOption
+		want        *TrailerInjector
+		wantErr     error
+	}{
+		{
+			name: "defaults",
+			want: &TrailerInjector{
+				add:      http.Header{},
+				drop:     map[string]bool{},
+				corrupt:  map[string]bool{},
+				reporter: NewNoopReporter(),
+			},
+		},
+		{
+			name: "options",
+			giveOptions: []TrailerInjectorOption{
+				WithTrailer("x-checksum", "abc"),
+				WithGRPCStatus(14, "unavailable"),
+				WithDropTrailers("x-a", "X-B"),
+				WithCorruptTrailers(),
+				WithReporter(newTestReporter()),
+			},
+			want: &TrailerInjector{
+				add: http.Header{
+					"X-Checksum":   {"abc"},
+					"Grpc-Status":  {"14"},
+					"Grpc-Message": {"unavailable"},
+				},
+				drop:       map[string]bool{"X-A": true, "X-B": true},
+				corrupt:    map[string]bool{},
+				corruptAll: true,
+				reporter:   newTestReporter(),
+			},
+		},
+		{
+			name: "option error",
+			giveOptions: []TrailerInjectorOption{
+				withError(),
+			},
+			wantErr: errErrorOption,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ti, err := NewTrailerInjector(tt.giveOptions...)
+
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.want, ti)
+		})
+	}
+}
+
+// TestTrailerInjectorHandler tests TrailerInjector.Handler over HTTP/1.1 and HTTP/2.
+func TestTrailerInjectorHandler(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		giveOptions []TrailerInjectorOption
+		wantTrailer http.Header
+	}{
+		{
+			name:        "unchanged",
+			wantTrailer: http.Header{"X-Declared": {"declared"}, "X-Prefixed": {"prefixed"}},
+		},
+		{
+			name: "grpc status",
+			giveOptions: []TrailerInjectorOption{
+				WithGRPCStatus(14, "unavailable"),
+			},
+			wantTrailer: http.Header{
+				"X-Declared":   {"declared"},
+				"X-Prefixed":   {"prefixed"},
+				"Grpc-Status":  {"14"},
+				"Grpc-Message": {"unavailable"},
+			},
+		},
+		{
+			name: "replace declared",
+			giveOptions: []TrailerInjectorOption{
+				WithTrailer("X-Declared", "replaced"),
+			},
+			wantTrailer: http.Header{"X-Declared": {"replaced"}, "X-Prefixed": {"prefixed"}},
+		},
+		{
+			name: "drop some",
+			giveOptions: []TrailerInjectorOption{
+				WithDropTrailers("x-prefixed"),
+			},
+			wantTrailer: http.Header{"X-Declared": {"declared"}},
+		},
+		{
+			name: "drop all",
+			giveOptions: []TrailerInjectorOption{
+				WithDropTrailers(),
+			},
+			wantTrailer: http.Header{},
+		},
+		{
+			name: "corrupt some",
+			giveOptions: []TrailerInjectorOption{
+				WithCorruptTrailers("x-declared"),
+			},
+			wantTrailer: http.Header{"X-Declared": {corruptTrailerValue}, "X-Prefixed": {"prefixed"}},
+		},
+		{
+			name: "corrupt all",
+			giveOptions: []TrailerInjectorOption{
+				WithCorruptTrailers(),
+			},
+			wantTrailer: http.Header{"X-Declared": {corruptTrailerValue}, "X-Prefixed": {corruptTrailerValue}},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ti, err := NewTrailerInjector(tt.giveOptions...)
+			assert.NoError(t, err)
+
+			h := ti.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Trailer", "X-Declared, ,X-Missing")
+				_, _ = w.Write([]byte("body"))
+				w.Header().Set("X-Declared", "declared")
+				w.Header().Set(http.TrailerPrefix+"X-Prefixed", "prefixed")
+			}))
+
+			for _, http2 := range []bool{false, true} {
+				ts := httptest.NewUnstartedServer(h)
+				ts.EnableHTTP2 = http2
+				ts.StartTLS()
+
+				resp, err := ts.Client().Get(ts.URL)
+				assert.NoError(t, err)
+
+				body, err := ioutil.ReadAll(resp.Body)
+				assert.NoError(t, err)
+				assert.NoError(t, resp.Body.Close())
+				ts.Close()
+
+				assert.Equal(t, "body", string(body))
+				assert.Equal(t, http2, resp.ProtoMajor == 2)
+
+				// unsent declared trailers are nil
+				got := http.Header{}
+				for k, v := range resp.Trailer {
+					if v != nil {
+						got[k] = v
+					}
+				}
+				assert.Equal(t, tt.wantTrailer, got, "http2: %v", http2)
+			}
+		})
+	}
+}
@@ -0,0 +1,214 @@
+package fault
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrNoLatencies when a WeightedLatencyInjector is not given any WeightedLatency.
+	ErrNoLatencies = errors.New("at least one weighted latency must be set")
+	// ErrInvalidWeight when a WeightedLatency has a negative weight or all weights are 0.
+	ErrInvalidWeight = errors.New("weights must be >= 0 and at least one must be > 0")
+	// ErrInvalidLatency when a WeightedLatency has a negative latency.
+	ErrInvalidLatency = errors.New("latency must be >= 0")
+)
+
+// WeightedLatency is a latency and the relative weight of requests that wait it.
+type WeightedLatency struct {
+	// Latency is how long the request waits.
+	Latency time.Duration
+	// Weight is the share of requests that wait Latency, relative to the weights of the other
+	// WeightedLatencies.
+	Weight float64
+}
+
+// WeightedLatencyInjector waits one of several latencies, chosen at random by weight, before
+// continuing. Use it to model multi-modal latency, such as most requests getting a little slower
+// and a few getting much slower, without composing a RandomInjector of SlowInjectors.
+type WeightedLatencyInjector struct {
+	latencies []WeightedLatency
+	// cumulative holds the running total of the weights, so cumulative[len-1] is the total weight.
+	cumulative []float64
+	clock      Clock
+
+	randSeed int64
+	rand     *rand.Rand
+
+	// *rand.Rand is not thread safe. This mutex protects our random source
+	randMtx sync.Mutex
+
+	reporter Reporter
+
+	// phase is the part of the response the wait delays.
+	phase LatencyPhase
+}
+
+// WeightedLatencyInjectorOption configures a WeightedLatencyInjector.
+type WeightedLatencyInjectorOption interface {
+	applyWeightedLatencyInjector(i *WeightedLatencyInjector) error
+}
+
+func (o clockOption) applyWeightedLatencyInjector(i *WeightedLatencyInjector) error {
+	i.clock = o.clock
+	return nil
+}
+
+func (o randSeedOption) applyWeightedLatencyInjector(i *WeightedLatencyInjector) error {
+	i.randSeed = int64(o)
+	return nil
+}
+
+func (o reporterOption) applyWeightedLatencyInjector(i *WeightedLatencyInjector) error {
+	i.reporter = o.reporter
+	return nil
+}
+
+// NewWeightedLatencyInjector returns a WeightedLatencyInjector that waits each latency in ls for its
+// share of requests. Weights are relative and need not add up to 1.0, so 80, 15, and 5 are the same
+// as 0.8, 0.15, and 0.05.
+func NewWeightedLatencyInjector(ls []WeightedLatency, opts ...WeightedLatencyInjectorOption) (
+	*WeightedLatencyInjector, error,
+) {
+	if len(ls) == 0 {
+		return nil, ErrNoLatencies
+	}
+
+	cumulative := make([]float64, len(ls))
+	var total float64
+	for idx, l := range ls {
+		if l.Latency < 0 {
+			return nil, ErrInvalidLatency
+		}
+		if l.Weight < 0 {
+			return nil, ErrInvalidWeight
+		}
+
+		total += l.Weight
+		cumulative[idx] = total
+	}
+	if total == 0 {
+		return nil, ErrInvalidWeight
+	}
+
+	// set defaults
+	wi := &WeightedLatencyInjector{
+		latencies:  append([]WeightedLatency(nil), ls...),
+		cumulative: cumulative,
+		clock:      NewRealClock(),
+		randSeed:   defaultRandSeed,
+		reporter:   NewNoopReporter(),
+	}
+
+	// apply options
+	for _, opt := range opts {
+		err := opt.applyWeightedLatencyInjector(wi)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	wi.rand = rand.New(rand.NewSource(wi.randSeed))
+
+	return wi, nil
+}
+
+// Handler waits a latency chosen by weight and then continues, or delays the body of the response
+// with WithLatencyPhase().
+func (i *WeightedLatencyInjector) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		go i.reporter.Report(i.String(), StateStarted)
+		if i.phase != LatencyBeforeHeaders {
+			serveDelayedBody(i.phase, i.sample(), sleepFunc(r.Context(), i.clock), next, w, r)
+			go i.reporter.Report(i.String(), StateFinished)
+			return
+		}
+
+		i.clock.Sleep(addLatency(r.Context(), i.sample()))
+		go i.reporter.Report(i.String(), StateFinished)
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// sample returns a latency chosen by weight.
+func (i *WeightedLatencyInjector) sample() time.Duration {
+	i.randMtx.Lock()
+	roll := i.rand.Float64() * i.cumulative[len(i.cumulative)-1]
+	i.randMtx.Unlock()
+
+	for idx, c := range i.cumulative[:len(i.cumulative)-1] {
+		if roll < c {
+			return i.latencies[idx].Latency
+		}
+	}
+
+	return i.latencies[len(i.latencies)-1].Latency
+}
+
+// maxLatency returns the longest latency that requests wait.
+func (i *WeightedLatencyInjector) maxLatency() time.Duration {
+	var max time.Duration
+	for _, l := range i.latencies {
+		if l.Weight > 0 && l.Latency > max {
+			max = l.Latency
+		}
+	}
+
+	return max
+}
+
+// Seed returns the seed of the WeightedLatencyInjector's random number generator.
+func (i *WeightedLatencyInjector) Seed() int64 {
+	return i.randSeed
+}
+
+// Reporter returns the Reporter of the WeightedLatencyInjector.
+func (i *WeightedLatencyInjector) Reporter() Reporter {
+	return i.reporter
+}
+
+// SetReporter replaces the Reporter of the WeightedLatencyInjector.
+func (i *WeightedLatencyInjector) SetReporter(r Reporter) {
+	i.reporter = r
+}
+
+// Name returns "weighted_latency".
+func (i *WeightedLatencyInjector) Name() string {
+	return "weighted_latency"
+}
+
+// Describe returns the latencies and their weights, the random seed, and the LatencyPhase, if set.
+func (i *WeightedLatencyInjector) Describe() map[string]string {
+	d := map[string]string{
+		"latencies": i.summary(),
+		"seed":      strconv.FormatInt(i.randSeed, 10),
+	}
+	if i.phase != LatencyBeforeHeaders {
+		d["phase"] = i.phase.String()
+	}
+
+	return d
+}
+
+// String returns a summary of the WeightedLatencyInjector, such as
+// "weighted_latency(50ms:80, 500ms:15, 5s:5)".
+func (i *WeightedLatencyInjector) String() string {
+	return fmt.Sprintf("%s(%s)", i.Name(), i.summary())
+}
+
+// summary returns each latency and its weight, such as "50ms:80, 500ms:15, 5s:5".
+func (i *WeightedLatencyInjector) summary() string {
+	parts := make([]string, len(i.latencies))
+	for idx, l := range i.latencies {
+		parts[idx] = fmt.Sprintf("%s:%g", l.Latency, l.Weight)
+	}
+
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,192 @@
+package fault
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestNewWeightedLatencyInjector tests NewWeightedLatencyInjector.
+func TestNewWeightedLatencyInjector(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		giveLatency []WeightedLatency
+		giveOptions []WeightedLatencyInjectorOption
+		wantSeed    int64
+		wantTotal   float64
+		wantErr     error
+	}{
+		{
+			name:        "defaults",
+			giveLatency: []WeightedLatency{{Latency: time.Millisecond, Weight: 0.8}, {Latency: time.Second, Weight: 0.2}},
+			wantSeed:    defaultRandSeed,
+			wantTotal:   1.0,
+		},
+		{
+			name:        "options",
+			giveLatency: []WeightedLatency{{Latency: time.Millisecond, Weight: 80}, {Latency: time.Second, Weight: 20}},
+			giveOptions: []WeightedLatencyInjectorOption{
+				WithRandSeed(42),
+				WithReporter(newTestReporter()),
+				WithClock(&testSleepClock{}),
+			},
+			wantSeed:  42,
+			wantTotal: 100,
+		},
+		{
+			name:        "zero weight",
+			giveLatency: []WeightedLatency{{Latency: time.Millisecond, Weight: 1}, {Latency: time.Second}},
+			wantSeed:    defaultRandSeed,
+			wantTotal:   1,
+		},
+		{
+			name:    "no latencies",
+			wantErr: ErrNoLatencies,
+		},
+		{
+			name:        "negative latency",
+			giveLatency: []WeightedLatency{{Latency: -time.Millisecond, Weight: 1}},
+			wantErr:     ErrInvalidLatency,
+		},
+		{
+			name:        "negative weight",
+			giveLatency: []WeightedLatency{{Latency: time.Millisecond, Weight: 2}, {Latency: time.Second, Weight: -1}},
+			wantErr:     ErrInvalidWeight,
+		},
+		{
+			name:        "all weights zero",
+			giveLatency: []WeightedLatency{{Latency: time.Millisecond}, {Latency: time.Second}},
+			wantErr:     ErrInvalidWeight,
+		},
+		{
+			name:        "option error",
+			giveLatency: []WeightedLatency{{Latency: time.Millisecond, Weight: 1}},
+			giveOptions: []WeightedLatencyInjectorOption{withError()},
+			wantErr:     errErrorOption,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			wi, err := NewWeightedLatencyInjector(tt.giveLatency, tt.giveOptions...)
+
+			assert.Equal(t, tt.wantErr, err)
+			if tt.wantErr != nil {
+				assert.Nil(t, wi)
+				return
+			}
+
+			assert.Equal(t, tt.wantSeed, wi.Seed())
+			assert.Equal(t, tt.giveLatency, wi.latencies)
+			assert.InDelta(t, tt.wantTotal, wi.cumulative[len(wi.cumulative)-1], 1e-9)
+
+			// the injector keeps its own copy
+			tt.giveLatency[0].Latency = time.Hour
+			assert.NotEqual(t, time.Hour, wi.latencies[0].Latency)
+		})
+	}
+}
+
+// TestWeightedLatencyInjectorHandler tests that WeightedLatencyInjector.Handler waits each latency
+// for its share of requests.
+func TestWeightedLatencyInjectorHandler(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		giveLatency []WeightedLatency
+		wantShares  map[time.Duration]float64
+	}{
+		{
+			name:        "single",
+			giveLatency: []WeightedLatency{{Latency: 50 * time.Millisecond, Weight: 1}},
+			wantShares:  map[time.Duration]float64{50 * time.Millisecond: 1.0},
+		},
+		{
+			name: "multi-modal",
+			giveLatency: []WeightedLatency{
+				{Latency: 50 * time.Millisecond, Weight: 80},
+				{Latency: 500 * time.Millisecond, Weight: 15},
+				{Latency: 5 * time.Second, Weight: 5},
+			},
+			wantShares: map[time.Duration]float64{
+				50 * time.Millisecond:  0.80,
+				500 * time.Millisecond: 0.15,
+				5 * time.Second:        0.05,
+			},
+		},
+		{
+			name: "zero weights are never chosen",
+			giveLatency: []WeightedLatency{
+				{Latency: time.Millisecond},
+				{Latency: 10 * time.Millisecond, Weight: 1},
+				{Latency: time.Second},
+				{Latency: 100 * time.Millisecond, Weight: 1},
+			},
+			wantShares: map[time.Duration]float64{
+				10 * time.Millisecond:  0.5,
+				100 * time.Millisecond: 0.5,
+			},
+		},
+	}
+
+	const requests = 2000
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			clock := &testSleepClock{}
+			reporter := &testStateReporter{states: make(chan InjectorState, 2*requests)}
+
+			wi, err := NewWeightedLatencyInjector(tt.giveLatency, WithClock(clock), WithReporter(reporter))
+			assert.NoError(t, err)
+
+			h := wi.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(testHandlerCode)
+			}))
+
+			for n := 0; n < requests; n++ {
+				rr := httptest.NewRecorder()
+				h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+				assert.Equal(t, testHandlerCode, rr.Code)
+			}
+
+			states := map[InjectorState]int{}
+			for n := 0; n < 2*requests; n++ {
+				states[<-reporter.states]++
+			}
+			assert.Equal(t, map[InjectorState]int{StateStarted: requests, StateFinished: requests}, states)
+
+			counts := map[time.Duration]int{}
+			for _, d := range clock.sleeps {
+				counts[d]++
+			}
+			assert.Len(t, counts, len(tt.wantShares))
+			for d, share := range tt.wantShares {
+				assert.InDelta(t, share, float64(counts[d])/requests, 0.03, d.String())
+			}
+		})
+	}
+}
+
+// TestWeightedLatencyInjectorReporter tests WeightedLatencyInjector.Reporter and SetReporter.
+func TestWeightedLatencyInjectorReporter(t *testing.T) {
+	t.Parallel()
+
+	wi, err := NewWeightedLatencyInjector([]WeightedLatency{{Latency: time.Millisecond, Weight: 1}})
+	assert.NoError(t, err)
+
+	reporter := newTestReporter()
+	wi.SetReporter(reporter)
+	assert.Equal(t, reporter, wi.Reporter())
+}
@@ -0,0 +1,273 @@
+package fault
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+)
+
+var (
+	// ErrNilManager when a nil Manager is passed.
+	ErrNilManager = errors.New("manager cannot be nil")
+)
+
+// DefaultAdminPrefix is the path the admin API of an Instrumentation is served under by default.
+const DefaultAdminPrefix = "/debug/faults/"
+
+// managerKey is the context key of the Manager of an instrumented http.Server.
+type managerKey struct{}
+
+// ManagerFromContext returns the Manager of the instrumented http.Server that is serving the
+// request whose context is ctx.
+func ManagerFromContext(ctx context.Context) (*Manager, bool) {
+	m, ok := ctx.Value(managerKey{}).(*Manager)
+	return m, ok
+}
+
+// Instrumentation is the fault injection Instrument installs on an http.Server: the Manager
+// middleware, an admin API to mount on a separate, internal port, and the ProtocolListeners to
+// wrap its listeners with.
+type Instrumentation struct {
+	server  *http.Server
+	manager *Manager
+	runtime *Runtime
+	prefix  string
+
+	protocols []protocolFaultOption
+	admin     *http.ServeMux
+
+	// mtx protects conns.
+	mtx   sync.Mutex
+	conns map[net.Conn]http.ConnState
+}
+
+// InstrumentOption configures an Instrumentation.
+type InstrumentOption interface {
+	applyInstrument(i *Instrumentation) error
+}
+
+type protocolFaultOption struct {
+	fault ProtocolFault
+	opts  []ProtocolListenerOption
+}
+
+func (o protocolFaultOption) applyInstrument(i *Instrumentation) error {
+	i.protocols = append(i.protocols, o)
+	return nil
+}
+
+// WithProtocolFault wraps the listeners of the instrumented http.Server in a ProtocolListener
+// that applies f, configured by opts. Pass it more than once to wrap listeners in several, the
+// first being the outermost.
+func WithProtocolFault(f ProtocolFault, opts ...ProtocolListenerOption) InstrumentOption {
+	return protocolFaultOption{fault: f, opts: opts}
+}
+
+type adminRuntimeOption struct {
+	runtime *Runtime
+}
+
+func (o adminRuntimeOption) applyInstrument(i *Instrumentation) error {
+	i.runtime = o.runtime
+	return nil
+}
+
+// WithAdminRuntime serves the AdminHandler of rt in the admin API, at "runtime" under its prefix.
+func WithAdminRuntime(rt *Runtime) InstrumentOption {
+	return adminRuntimeOption{rt}
+}
+
+type adminPrefixOption string
+
+func (o adminPrefixOption) applyInstrument(i *Instrumentation) error {
+	i.prefix = string(o)
+	return nil
+}
+
+// WithAdminPrefix sets the path the admin API is served under, which should end in a "/". Default
+// DefaultAdminPrefix.
+func WithAdminPrefix(p string) InstrumentOption {
+	return adminPrefixOption(p)
+}
+
+// Instrument installs m on s in one call. The Handler of s is wrapped in the middleware of m, a nil
+// Handler being http.DefaultServeMux, the context of each request holds m for ManagerFromContext
+// and the ID of its connection for ConnKey, and the state of each connection is tracked for the
+// admin API. The BaseContext, ConnContext, and ConnState already set on s still run. Serve s with
+// Instrumentation.Serve or Instrumentation.ListenAndServe so its listeners are wrapped with
+// WithProtocolFault, and mount Instrumentation.Admin on an internal port. Call Instrument before
+// the server starts serving.
+//
+//	inst, _ := fault.Instrument(srv, m,
+//		fault.WithProtocolFault(fault.ProtocolHTTP10, fault.WithParticipation(0.01)))
+//	go http.ListenAndServe("localhost:6060", inst.Admin())
+//	inst.ListenAndServe()
+func Instrument(s *http.Server, m *Manager, opts ...InstrumentOption) (*Instrumentation, error) {
+	if s == nil {
+		return nil, ErrNilServer
+	}
+	if m == nil {
+		return nil, ErrNilManager
+	}
+
+	// set defaults
+	inst := &Instrumentation{
+		server:  s,
+		manager: m,
+		prefix:  DefaultAdminPrefix,
+		conns:   make(map[net.Conn]http.ConnState),
+	}
+
+	// apply options
+	for _, opt := range opts {
+		err := opt.applyInstrument(inst)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	h := s.Handler
+	if h == nil {
+		h = http.DefaultServeMux
+	}
+	s.Handler = m.Handler(h)
+
+	baseContext := s.BaseContext
+	s.BaseContext = func(l net.Listener) context.Context {
+		ctx := context.Background()
+		if baseContext != nil {
+			ctx = baseContext(l)
+		}
+
+		return context.WithValue(ctx, managerKey{}, m)
+	}
+
+	connContext, connIDContext := s.ConnContext, NewConnContext()
+	s.ConnContext = func(ctx context.Context, c net.Conn) context.Context {
+		if connContext != nil {
+			ctx = connContext(ctx, c)
+		}
+
+		return connIDContext(ctx, c)
+	}
+
+	connState := s.ConnState
+	s.ConnState = func(c net.Conn, state http.ConnState) {
+		inst.trackConn(c, state)
+		if connState != nil {
+			connState(c, state)
+		}
+	}
+
+	inst.admin = http.NewServeMux()
+	inst.admin.Handle(inst.prefix+"explain", m.ExplainHandler())
+	inst.admin.Handle(inst.prefix+"vars", m.ExpvarHandler())
+	inst.admin.Handle(inst.prefix+"connections", http.HandlerFunc(inst.serveConnections))
+	if inst.runtime != nil {
+		inst.admin.Handle(inst.prefix+"runtime", inst.runtime.AdminHandler())
+	}
+
+	return inst, nil
+}
+
+// trackConn records the state of c, forgetting it once it is closed or hijacked.
+func (i *Instrumentation) trackConn(c net.Conn, state http.ConnState) {
+	i.mtx.Lock()
+	defer i.mtx.Unlock()
+
+	if state == http.StateClosed || state == http.StateHijacked {
+		delete(i.conns, c)
+		return
+	}
+
+	i.conns[c] = state
+}
+
+// Connections returns the number of open connections of the instrumented http.Server in each
+// state.
+func (i *Instrumentation) Connections() map[http.ConnState]int {
+	i.mtx.Lock()
+	defer i.mtx.Unlock()
+
+	counts := make(map[http.ConnState]int)
+	for _, state := range i.conns {
+		counts[state]++
+	}
+
+	return counts
+}
+
+// serveConnections responds with the Connections, keyed by state name, as JSON.
+func (i *Instrumentation) serveConnections(w http.ResponseWriter, r *http.Request) {
+	counts := make(map[string]int)
+	for state, n := range i.Connections() {
+		counts[state.String()] = n
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	_ = json.NewEncoder(w).Encode(counts)
+}
+
+// Admin returns the admin API of the Instrumentation. It serves, under the admin prefix, the
+// ExplainHandler of the Manager at "explain", the ExpvarHandler of the Manager at "vars", the
+// Connections at "connections", and the AdminHandler of the Runtime passed with WithAdminRuntime at
+// "runtime". Serve it on a separate, internal port rather than with the instrumented http.Server.
+func (i *Instrumentation) Admin() http.Handler {
+	return i.admin
+}
+
+// Manager returns the Manager installed on the http.Server.
+func (i *Instrumentation) Manager() *Manager {
+	return i.manager
+}
+
+// LimitWarnings returns a LimitWarning for each Injector of the managed Faults that conflicts with
+// the ServerLimits of the http.Server, as CheckServer() does. Check it again after changing the
+// Faults.
+func (i *Instrumentation) LimitWarnings() []LimitWarning {
+	return CheckServer(i.server, i.manager.Faults()...)
+}
+
+// Listener wraps l in a ProtocolListener for each WithProtocolFault.
+func (i *Instrumentation) Listener(l net.Listener) (net.Listener, error) {
+	for idx := len(i.protocols) - 1; idx >= 0; idx-- {
+		p := i.protocols[idx]
+
+		pl, err := NewProtocolListener(l, p.fault, p.opts...)
+		if err != nil {
+			return nil, err
+		}
+		l = pl
+	}
+
+	return l, nil
+}
+
+// Serve wraps l with Listener and serves the instrumented http.Server on it.
+func (i *Instrumentation) Serve(l net.Listener) error {
+	wrapped, err := i.Listener(l)
+	if err != nil {
+		return err
+	}
+
+	return i.server.Serve(wrapped)
+}
+
+// ListenAndServe listens on the TCP address of the instrumented http.Server, ":http" if it is
+// empty, and serves it with Serve.
+func (i *Instrumentation) ListenAndServe() error {
+	addr := i.server.Addr
+	if addr == "" {
+		addr = ":http"
+	}
+
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	return i.Serve(l)
+}
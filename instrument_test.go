@@ -0,0 +1,352 @@
+package fault
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestInstrument tests Instrument.
+func TestInstrument(t *testing.T) {
+	t.Parallel()
+
+	m, err := NewManager()
+	assert.NoError(t, err)
+	f, err := NewFault(newTestInjector500s(), WithName("500s"), WithEnabled(true), WithParticipation(1.0))
+	assert.NoError(t, err)
+	assert.NoError(t, m.Add(f))
+	empty, err := NewManager()
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		giveServer  *http.Server
+		giveManager *Manager
+		giveOptions []InstrumentOption
+		wantCode    int
+		wantErr     error
+	}{
+		{
+			name:        "handler",
+			giveServer:  &http.Server{Handler: testServerHandler},
+			giveManager: m,
+			wantCode:    http.StatusInternalServerError,
+		},
+		{
+			name:        "default serve mux",
+			giveServer:  &http.Server{},
+			giveManager: empty,
+			wantCode:    http.StatusNotFound,
+		},
+		{
+			name:        "nil server",
+			giveServer:  nil,
+			giveManager: m,
+			wantErr:     ErrNilServer,
+		},
+		{
+			name:        "nil manager",
+			giveServer:  &http.Server{},
+			giveManager: nil,
+			wantErr:     ErrNilManager,
+		},
+		{
+			name:        "option error",
+			giveServer:  &http.Server{},
+			giveManager: m,
+			giveOptions: []InstrumentOption{withError()},
+			wantErr:     errErrorOption,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			inst, err := Instrument(tt.giveServer, tt.giveManager, tt.giveOptions...)
+
+			assert.Equal(t, tt.wantErr, err)
+			if tt.wantErr != nil {
+				assert.Nil(t, inst)
+				return
+			}
+
+			assert.Equal(t, tt.giveManager, inst.Manager())
+
+			rr := httptest.NewRecorder()
+			tt.giveServer.Handler.ServeHTTP(rr, httptest.NewRequest("GET", "/go-fault-unregistered", nil))
+			assert.Equal(t, tt.wantCode, rr.Code)
+		})
+	}
+}
+
+// TestInstrumentServe tests that an instrumented http.Server runs its Manager, numbers its
+// connections, keeps its own BaseContext, ConnContext, and ConnState, and wraps its listener with
+// ProtocolFaults.
+func TestInstrumentServe(t *testing.T) {
+	t.Parallel()
+
+	m, err := NewManager()
+	assert.NoError(t, err)
+
+	var baseContexts, connContexts, connStates int64
+	var inst *Instrumentation
+	s := &http.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got, ok := ManagerFromContext(r.Context())
+			assert.True(t, ok)
+			assert.Equal(t, m, got)
+			assert.Equal(t, 1, inst.Connections()[http.StateActive])
+			assert.True(t, strings.HasPrefix(ConnKey(r), "conn-"))
+			w.WriteHeader(testHandlerCode)
+		}),
+		BaseContext: func(l net.Listener) context.Context {
+			atomic.AddInt64(&baseContexts, 1)
+			return context.Background()
+		},
+		ConnContext: func(ctx context.Context, c net.Conn) context.Context {
+			atomic.AddInt64(&connContexts, 1)
+			return ctx
+		},
+		ConnState: func(c net.Conn, state http.ConnState) {
+			atomic.AddInt64(&connStates, 1)
+		},
+	}
+
+	inst, err = Instrument(s, m, WithProtocolFault(ProtocolHTTP10, WithParticipation(1.0)))
+	assert.NoError(t, err)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+
+	served := make(chan error)
+	go func() { served <- inst.Serve(l) }()
+
+	client := &http.Client{Transport: &http.Transport{}}
+	resp, err := client.Get("http://" + l.Addr().String() + "/")
+	assert.NoError(t, err)
+	_, _ = ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	assert.Equal(t, testHandlerCode, resp.StatusCode)
+	assert.Equal(t, "HTTP/1.0", resp.Proto)
+	assert.Equal(t, int64(1), atomic.LoadInt64(&baseContexts))
+	assert.Equal(t, int64(1), atomic.LoadInt64(&connContexts))
+	assert.Greater(t, atomic.LoadInt64(&connStates), int64(0))
+
+	assert.NoError(t, s.Close())
+	assert.Equal(t, http.ErrServerClosed, <-served)
+	assert.Empty(t, inst.Connections())
+}
+
+// TestInstrumentationListener tests Instrumentation.Listener.
+func TestInstrumentationListener(t *testing.T) {
+	t.Parallel()
+
+	m, err := NewManager()
+	assert.NoError(t, err)
+
+	inst, err := Instrument(&http.Server{}, m,
+		WithProtocolFault(ProtocolHTTP10, WithRandSeed(2)),
+		WithProtocolFault(ProtocolHTTP10, WithRandSeed(3)),
+	)
+	assert.NoError(t, err)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer l.Close()
+
+	wrapped, err := inst.Listener(l)
+	assert.NoError(t, err)
+
+	outer, ok := wrapped.(*ProtocolListener)
+	assert.True(t, ok)
+	assert.Equal(t, int64(2), outer.Seed())
+	inner, ok := outer.Listener.(*ProtocolListener)
+	assert.True(t, ok)
+	assert.Equal(t, int64(3), inner.Seed())
+	assert.Equal(t, l, inner.Listener)
+
+	invalid, err := Instrument(&http.Server{}, m, WithProtocolFault(ProtocolNoHTTP2))
+	assert.NoError(t, err)
+	assert.Equal(t, ErrProtocolTLSConfig, invalid.Serve(l))
+}
+
+// TestInstrumentationListenAndServe tests Instrumentation.ListenAndServe.
+func TestInstrumentationListenAndServe(t *testing.T) {
+	t.Parallel()
+
+	m, err := NewManager()
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		giveAddr string
+	}{
+		{
+			name:     "closed",
+			giveAddr: "127.0.0.1:0",
+		},
+		{
+			name:     "default address",
+			giveAddr: "",
+		},
+		{
+			name:     "invalid address",
+			giveAddr: "127.0.0.1:-1",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			s := &http.Server{Addr: tt.giveAddr}
+			inst, err := Instrument(s, m)
+			assert.NoError(t, err)
+
+			// a closed server stops serving as soon as it starts
+			assert.NoError(t, s.Close())
+			assert.Error(t, inst.ListenAndServe())
+		})
+	}
+}
+
+// TestInstrumentationAdmin tests the admin API of an Instrumentation.
+func TestInstrumentationAdmin(t *testing.T) {
+	t.Parallel()
+
+	m, err := NewManager()
+	assert.NoError(t, err)
+	rt, err := NewRuntime()
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		giveOptions []InstrumentOption
+		givePath    string
+		wantCode    int
+		wantBody    string
+	}{
+		{
+			name:     "connections",
+			givePath: "/debug/faults/connections",
+			wantCode: http.StatusOK,
+			wantBody: "{}",
+		},
+		{
+			name:     "vars",
+			givePath: "/debug/faults/vars",
+			wantCode: http.StatusOK,
+			wantBody: `{"` + ExpvarNamespace + `": {}}`,
+		},
+		{
+			name:     "explain",
+			givePath: "/debug/faults/explain",
+			wantCode: http.StatusMethodNotAllowed,
+		},
+		{
+			name:     "no runtime",
+			givePath: "/debug/faults/runtime",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:        "runtime",
+			giveOptions: []InstrumentOption{WithAdminRuntime(rt)},
+			givePath:    "/debug/faults/runtime",
+			wantCode:    http.StatusOK,
+			wantBody:    "{}",
+		},
+		{
+			name:        "prefix",
+			giveOptions: []InstrumentOption{WithAdminPrefix("/faults/")},
+			givePath:    "/faults/connections",
+			wantCode:    http.StatusOK,
+			wantBody:    "{}",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			inst, err := Instrument(&http.Server{}, m, tt.giveOptions...)
+			assert.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			inst.Admin().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, tt.givePath, nil))
+
+			assert.Equal(t, tt.wantCode, rr.Code)
+			assert.True(t, strings.HasPrefix(rr.Body.String(), tt.wantBody), rr.Body.String())
+		})
+	}
+}
+
+// TestInstrumentationConnections tests that an Instrumentation tracks the state of open connections.
+func TestInstrumentationConnections(t *testing.T) {
+	t.Parallel()
+
+	m, err := NewManager()
+	assert.NoError(t, err)
+	inst, err := Instrument(&http.Server{}, m)
+	assert.NoError(t, err)
+
+	a, b := net.Pipe()
+	defer a.Close()
+	defer b.Close()
+	c, d := net.Pipe()
+	defer c.Close()
+	defer d.Close()
+
+	inst.server.ConnState(a, http.StateNew)
+	inst.server.ConnState(a, http.StateActive)
+	inst.server.ConnState(b, http.StateNew)
+	inst.server.ConnState(c, http.StateIdle)
+	inst.server.ConnState(d, http.StateNew)
+	inst.server.ConnState(d, http.StateHijacked)
+
+	assert.Equal(t, map[http.ConnState]int{http.StateActive: 1, http.StateNew: 1, http.StateIdle: 1},
+		inst.Connections())
+
+	rr := httptest.NewRecorder()
+	inst.Admin().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/debug/faults/connections", nil))
+	assert.JSONEq(t, `{"active": 1, "new": 1, "idle": 1}`, rr.Body.String())
+
+	inst.server.ConnState(c, http.StateClosed)
+	assert.Equal(t, map[http.ConnState]int{http.StateActive: 1, http.StateNew: 1}, inst.Connections())
+}
+
+// TestInstrumentationLimitWarnings tests that Instrumentation.LimitWarnings checks the managed
+// Faults against the limits of the server.
+func TestInstrumentationLimitWarnings(t *testing.T) {
+	t.Parallel()
+
+	m, err := NewManager()
+	assert.NoError(t, err)
+
+	inst, err := Instrument(&http.Server{WriteTimeout: time.Second}, m)
+	assert.NoError(t, err)
+	assert.Empty(t, inst.LimitWarnings())
+
+	si, err := NewSlowInjector(time.Second)
+	assert.NoError(t, err)
+	f, err := NewFault(si, WithName("slow"))
+	assert.NoError(t, err)
+	assert.NoError(t, m.Add(f))
+
+	warnings := inst.LimitWarnings()
+	assert.Len(t, warnings, 1)
+	assert.Equal(t, "slow", warnings[0].Fault)
+	assert.Equal(t, "WriteTimeout", warnings[0].Limit)
+}
@@ -0,0 +1,68 @@
+package fault
+
+import "errors"
+
+// ErrInvalidLabel when a label of a Fault has an empty key.
+var ErrInvalidLabel = errors.New("label keys cannot be empty")
+
+type labelsOption map[string]string
+
+func (o labelsOption) applyFault(f *Fault) error {
+	for k, v := range o {
+		if k == "" {
+			return ErrInvalidLabel
+		}
+
+		if f.labels == nil {
+			f.labels = make(map[string]string, len(o))
+		}
+		f.labels[k] = v
+	}
+
+	return nil
+}
+
+// WithLabels tags the Fault with labels, such as {"team": "checkout", "experiment": "q3-gameday"},
+// so organizations running many experiments at once can attribute their impact. The labels are set
+// on every Event the Fault reports, shown in its FaultStatus and expvar counters, and roll up its
+// counters in Manager.StatsByLabel(). Pass it more than once to add more labels.
+func WithLabels(labels map[string]string) Option {
+	return labelsOption(labels)
+}
+
+// Labels returns a copy of the labels of the Fault, or nil if it has none.
+func (f *Fault) Labels() map[string]string {
+	if f.labels == nil {
+		return nil
+	}
+
+	labels := make(map[string]string, len(f.labels))
+	for k, v := range f.labels {
+		labels[k] = v
+	}
+
+	return labels
+}
+
+// StatsByLabel sums the counters of the managed Faults (evaluated, injected, skipped, active, and
+// skipped_ followed by each SkipReason) by the value of their label key, such as the counters of
+// each team. Faults without the label are summed under "".
+func (m *Manager) StatsByLabel(key string) map[string]map[string]int64 {
+	rollup := make(map[string]map[string]int64)
+
+	for _, f := range m.Faults() {
+		value := f.labels[key]
+
+		sums, ok := rollup[value]
+		if !ok {
+			sums = make(map[string]int64)
+			rollup[value] = sums
+		}
+
+		for name, n := range f.stats.counters() {
+			sums[name] += n
+		}
+	}
+
+	return rollup
+}
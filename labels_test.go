@@ -0,0 +1,114 @@
+package fault
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestWithLabels tests WithLabels and Fault.Labels.
+func TestWithLabels(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		giveOptions []Option
+		wantLabels  map[string]string
+		wantErr     error
+	}{
+		{
+			name:        "no labels",
+			giveOptions: []Option{},
+			wantLabels:  nil,
+		},
+		{
+			name:        "empty labels",
+			giveOptions: []Option{WithLabels(nil), WithLabels(map[string]string{})},
+			wantLabels:  nil,
+		},
+		{
+			name: "merged labels",
+			giveOptions: []Option{
+				WithLabels(map[string]string{"team": "checkout", "experiment": "q2-gameday"}),
+				WithLabels(map[string]string{"experiment": "q3-gameday"}),
+			},
+			wantLabels: map[string]string{"team": "checkout", "experiment": "q3-gameday"},
+		},
+		{
+			name:        "empty key",
+			giveOptions: []Option{WithLabels(map[string]string{"": "checkout"})},
+			wantErr:     ErrInvalidLabel,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			f, err := NewFault(newTestInjectorNoop(), tt.giveOptions...)
+
+			assert.Equal(t, tt.wantErr, err)
+			if tt.wantErr != nil {
+				assert.Nil(t, f)
+				return
+			}
+
+			assert.Equal(t, tt.wantLabels, f.Labels())
+		})
+	}
+}
+
+// TestFaultLabelsCopy tests that the labels returned by Fault.Labels cannot change the Fault.
+func TestFaultLabelsCopy(t *testing.T) {
+	t.Parallel()
+
+	f, err := NewFault(newTestInjectorNoop(), WithLabels(map[string]string{"team": "checkout"}))
+	assert.NoError(t, err)
+
+	f.Labels()["team"] = "search"
+	assert.Equal(t, map[string]string{"team": "checkout"}, f.Labels())
+}
+
+// TestManagerStatsByLabel tests that Manager.StatsByLabel sums the counters of Faults by label.
+func TestManagerStatsByLabel(t *testing.T) {
+	t.Parallel()
+
+	checkout := map[string]string{"team": "checkout"}
+	a, err := NewFault(newTestInjectorNoop(), WithName("a"), WithEnabled(true), WithParticipation(1.0),
+		WithLabels(checkout))
+	assert.NoError(t, err)
+	b, err := NewFault(newTestInjectorNoop(), WithName("b"), WithLabels(checkout))
+	assert.NoError(t, err)
+	c, err := NewFault(newTestInjectorNoop(), WithName("c"), WithEnabled(true), WithParticipation(1.0),
+		WithLabels(map[string]string{"team": "search"}))
+	assert.NoError(t, err)
+	d, err := NewFault(newTestInjectorNoop(), WithName("d"), WithEnabled(true), WithParticipation(1.0))
+	assert.NoError(t, err)
+
+	m, err := NewManager()
+	assert.NoError(t, err)
+	assert.NoError(t, m.Add(a, b, c, d))
+
+	h := m.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(testHandlerCode)
+	}))
+	for n := 0; n < 2; n++ {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	}
+
+	got := m.StatsByLabel("team")
+	assert.Len(t, got, 3)
+	assert.Equal(t, int64(4), got["checkout"]["evaluated"])
+	assert.Equal(t, int64(2), got["checkout"]["injected"])
+	assert.Equal(t, int64(2), got["checkout"]["skipped_disabled"])
+	assert.Equal(t, int64(2), got["search"]["injected"])
+	assert.Equal(t, int64(2), got[""]["injected"])
+
+	// every Fault is summed under "" when none has the label
+	got = m.StatsByLabel("experiment")
+	assert.Len(t, got, 1)
+	assert.Equal(t, int64(8), got[""]["evaluated"])
+}
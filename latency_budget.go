@@ -0,0 +1,102 @@
+package fault
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrInvalidMaxAddedLatency when a maximum added latency that is not positive is provided.
+var ErrInvalidMaxAddedLatency = errors.New("max added latency must be > 0")
+
+// latencyBudgetKey is the context key of a request's latencyBudget.
+type latencyBudgetKey struct{}
+
+// latencyBudget is how much latency the Injectors may still add to a request.
+type latencyBudget struct {
+	mtx   sync.Mutex
+	max   time.Duration
+	added time.Duration
+}
+
+// take returns how much of d may still be added and counts it as added.
+func (b *latencyBudget) take(d time.Duration) time.Duration {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	if left := b.max - b.added; d > left {
+		d = left
+	}
+	if d < 0 {
+		d = 0
+	}
+
+	b.added += d
+	return d
+}
+
+// ContextWithMaxAddedLatency returns a copy of ctx in which the Injectors that delay a request, such
+// as the SlowInjector, add at most max latency to it between them, however they are nested in
+// ChainInjectors, RandomInjectors, and Faults. Once the budget is spent they stop delaying the
+// request and let it continue. If ctx already has a budget it is kept, so the outermost one applies.
+func ContextWithMaxAddedLatency(ctx context.Context, max time.Duration) context.Context {
+	if _, ok := ctx.Value(latencyBudgetKey{}).(*latencyBudget); ok {
+		return ctx
+	}
+
+	return context.WithValue(ctx, latencyBudgetKey{}, &latencyBudget{max: max})
+}
+
+// AddedLatencyFromContext returns the latency the Injectors have added to the request with context
+// ctx. It returns 0 if ctx was not made by ContextWithMaxAddedLatency.
+func AddedLatencyFromContext(ctx context.Context) time.Duration {
+	b, ok := ctx.Value(latencyBudgetKey{}).(*latencyBudget)
+	if !ok {
+		return 0
+	}
+
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	return b.added
+}
+
+// addLatency returns how much of d an Injector may delay the request with context ctx, counting it
+// against the budget of ctx if it has one.
+func addLatency(ctx context.Context, d time.Duration) time.Duration {
+	b, ok := ctx.Value(latencyBudgetKey{}).(*latencyBudget)
+	if !ok {
+		return d
+	}
+
+	return b.take(d)
+}
+
+type maxAddedLatencyOption time.Duration
+
+func (o maxAddedLatencyOption) applyManager(m *Manager) error {
+	if o <= 0 {
+		return ErrInvalidMaxAddedLatency
+	}
+
+	m.maxLatency = time.Duration(o)
+	return nil
+}
+
+// WithMaxAddedLatency caps the latency the Injectors of all the Faults of the Manager add to a
+// single request at d, such as 3 seconds, however the Injectors are nested. See
+// ContextWithMaxAddedLatency.
+func WithMaxAddedLatency(d time.Duration) ManagerOption {
+	return maxAddedLatencyOption(d)
+}
+
+// withMaxAddedLatency returns r with the latency budget of the Manager, if it has one.
+func (m *Manager) withMaxAddedLatency(r *http.Request) *http.Request {
+	if m.maxLatency <= 0 {
+		return r
+	}
+
+	return r.WithContext(ContextWithMaxAddedLatency(r.Context(), m.maxLatency))
+}
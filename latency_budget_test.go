@@ -0,0 +1,90 @@
+package fault
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestContextWithMaxAddedLatency tests that the latency budget of a context caps the latency added
+// to a request.
+func TestContextWithMaxAddedLatency(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	assert.Equal(t, 2*time.Second, addLatency(ctx, 2*time.Second))
+	assert.Equal(t, time.Duration(0), AddedLatencyFromContext(ctx))
+
+	ctx = ContextWithMaxAddedLatency(ctx, 3*time.Second)
+	assert.Equal(t, 2*time.Second, addLatency(ctx, 2*time.Second))
+	assert.Equal(t, 2*time.Second, AddedLatencyFromContext(ctx))
+
+	// the outermost budget applies
+	inner := ContextWithMaxAddedLatency(ctx, time.Minute)
+	assert.Equal(t, ctx, inner)
+
+	assert.Equal(t, time.Second, addLatency(inner, 2*time.Second))
+	assert.Equal(t, time.Duration(0), addLatency(inner, time.Second))
+	assert.Equal(t, time.Duration(0), addLatency(inner, -time.Second))
+	assert.Equal(t, 3*time.Second, AddedLatencyFromContext(ctx))
+}
+
+// TestManagerMaxAddedLatency tests that the Injectors of the Faults of a Manager with
+// WithMaxAddedLatency add at most that latency to a request between them.
+func TestManagerMaxAddedLatency(t *testing.T) {
+	t.Parallel()
+
+	clock := &testSleepClock{}
+
+	var mtx sync.Mutex
+	var slept []time.Duration
+	si, err := NewSlowInjector(2*time.Second, WithSlowFunc(func(d time.Duration) {
+		mtx.Lock()
+		slept = append(slept, d)
+		mtx.Unlock()
+	}))
+	assert.NoError(t, err)
+	wi, err := NewWeightedLatencyInjector([]WeightedLatency{{Latency: 2 * time.Second, Weight: 1}}, WithClock(clock))
+	assert.NoError(t, err)
+	ci, err := NewChainInjector([]Injector{si, wi})
+	assert.NoError(t, err)
+	bi, err := NewBrownoutInjector(WithDegradedDelay(time.Second), WithClock(clock))
+	assert.NoError(t, err)
+	ri, err := NewRampInjector(time.Second, 0, WithClock(clock))
+	assert.NoError(t, err)
+	sb, err := NewSlowBodyInjector(1, time.Second, WithClock(clock))
+	assert.NoError(t, err)
+
+	m, err := NewManager(WithMaxAddedLatency(6 * time.Second))
+	assert.NoError(t, err)
+	for idx, i := range []Injector{ci, bi, ri, sb} {
+		f, err := NewFault(i, WithName(string(rune('a'+idx))), WithEnabled(true), WithParticipation(1.0))
+		assert.NoError(t, err)
+		assert.NoError(t, m.Add(f))
+	}
+
+	var added time.Duration
+	h := m.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ab"))
+		added = AddedLatencyFromContext(r.Context())
+	}))
+
+	for n := 0; n < 2; n++ {
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+		assert.Equal(t, "ab", rr.Body.String())
+		assert.Equal(t, 6*time.Second, added)
+	}
+
+	// each request gets a budget of its own
+	assert.Equal(t, []time.Duration{2 * time.Second, 2 * time.Second}, slept)
+	assert.Equal(t, []time.Duration{
+		2 * time.Second, time.Second, time.Second, 0, 0,
+		2 * time.Second, time.Second, time.Second, 0, 0,
+	}, clock.sleeps)
+}
@@ -0,0 +1,287 @@
+package fault
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// spreadParts is how many body writes a LatencySpreadBody latency is spread over when the length
+// of the body is unknown.
+const spreadParts = 10
+
+var (
+	// ErrInvalidLatencyPhase when an unknown LatencyPhase is passed.
+	ErrInvalidLatencyPhase = errors.New("invalid latency phase")
+)
+
+// LatencyPhase is the part of a response a latency Injector delays. Clients often time out on each
+// part separately, such as a time to first byte timeout and an idle read timeout, so delaying each
+// one tests each timeout.
+type LatencyPhase int
+
+const (
+	// LatencyBeforeHeaders waits before the handler runs, delaying the status line and headers and
+	// so the first byte of the response.
+	LatencyBeforeHeaders LatencyPhase = iota
+	// LatencyBeforeBody runs the handler and sends its headers at once, then waits before the first
+	// byte of the body. A response without a body waits before it ends.
+	LatencyBeforeBody
+	// LatencySpreadBody sends the headers at once and spreads the wait across the writes of the
+	// body, in proportion to their size if the response has a Content-Length, or in equal parts
+	// over the first 10 writes if not. Whatever is left of the wait when the handler returns is
+	// waited before the response ends.
+	LatencySpreadBody
+	// LatencyAfterHandler runs the handler at once and holds its whole response, then waits and
+	// sends it, like a server that does its work quickly but is slow to reply. Flushes of the
+	// handler are held too.
+	LatencyAfterHandler
+)
+
+// String returns the name of the LatencyPhase.
+func (p LatencyPhase) String() string {
+	switch p {
+	case LatencyBeforeHeaders:
+		return "before_headers"
+	case LatencyBeforeBody:
+		return "before_body"
+	case LatencySpreadBody:
+		return "spread_body"
+	case LatencyAfterHandler:
+		return "after_handler"
+	default:
+		return fmt.Sprintf("LatencyPhase(%d)", int(p))
+	}
+}
+
+// LatencyPhaseOption configures Injectors that add latency to a phase of the response.
+type LatencyPhaseOption interface {
+	SlowInjectorOption
+	WeightedLatencyInjectorOption
+	DistributionInjectorOption
+}
+
+type latencyPhaseOption LatencyPhase
+
+func (o latencyPhaseOption) validate() (LatencyPhase, error) {
+	if o < latencyPhaseOption(LatencyBeforeHeaders) || o > latencyPhaseOption(LatencyAfterHandler) {
+		return 0, ErrInvalidLatencyPhase
+	}
+
+	return LatencyPhase(o), nil
+}
+
+func (o latencyPhaseOption) applySlowInjector(i *SlowInjector) error {
+	p, err := o.validate()
+	i.phase = p
+	return err
+}
+
+func (o latencyPhaseOption) applyWeightedLatencyInjector(i *WeightedLatencyInjector) error {
+	p, err := o.validate()
+	i.phase = p
+	return err
+}
+
+func (o latencyPhaseOption) applyDistributionInjector(i *DistributionInjector) error {
+	p, err := o.validate()
+	i.phase = p
+	return err
+}
+
+// WithLatencyPhase sets the part of the response a SlowInjector, WeightedLatencyInjector, or
+// DistributionInjector delays. Default LatencyBeforeHeaders.
+func WithLatencyPhase(p LatencyPhase) LatencyPhaseOption {
+	return latencyPhaseOption(p)
+}
+
+// serveDelayedBody serves r with next, waiting d in phase, which is LatencyBeforeBody,
+// LatencySpreadBody, or LatencyAfterHandler, with wait. wait returns false if the context of r was
+// done before the wait ended, after which the writes of next fail with the context error.
+func serveDelayedBody(phase LatencyPhase, d time.Duration, wait func(d time.Duration) bool,
+	next http.Handler, w http.ResponseWriter, r *http.Request) {
+	lw := &latencyPhaseWriter{
+		ResponseWriter: w,
+		phase:          phase,
+		ctx:            r.Context(),
+		wait:           wait,
+		total:          d,
+		left:           d,
+		waited:         true,
+	}
+	next.ServeHTTP(lw, r)
+	lw.finish()
+}
+
+// sleepFunc returns a wait for serveDelayedBody that sleeps on clock, within the latency budget of
+// ctx, and cannot be interrupted.
+func sleepFunc(ctx context.Context, clock Clock) func(d time.Duration) bool {
+	return func(d time.Duration) bool {
+		clock.Sleep(addLatency(ctx, d))
+		return ctx.Err() == nil
+	}
+}
+
+// latencyPhaseWriter is an http.ResponseWriter that waits before or during the body of the
+// response.
+type latencyPhaseWriter struct {
+	http.ResponseWriter
+	phase LatencyPhase
+	ctx   context.Context
+	wait  func(d time.Duration) bool
+
+	// total is the whole latency and left the part of it not waited yet.
+	total time.Duration
+	left  time.Duration
+	// waited is false once a wait was cut short.
+	waited bool
+
+	wroteHeader bool
+	// size is the Content-Length of the response, or -1 if it has none. It is read at the first
+	// write.
+	size int64
+
+	// code, header, and body hold the response of the handler for LatencyAfterHandler until the
+	// wait ends and it is released.
+	code     int
+	header   http.Header
+	body     bytes.Buffer
+	released bool
+}
+
+// WriteHeader writes the status code and headers.
+func (w *latencyPhaseWriter) WriteHeader(code int) {
+	if w.phase == LatencyAfterHandler {
+		if !w.wroteHeader {
+			w.wroteHeader = true
+			w.code = code
+			w.header = w.Header().Clone()
+		}
+		return
+	}
+
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		w.size = -1
+		if n, err := strconv.ParseInt(w.Header().Get("Content-Length"), 10, 64); err == nil && n > 0 {
+			w.size = n
+		}
+	}
+
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Write waits the share of the latency due before b and writes b. It returns the context error if
+// the context is done.
+func (w *latencyPhaseWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	if w.phase == LatencyAfterHandler {
+		return w.body.Write(b)
+	}
+
+	var d time.Duration
+	switch {
+	case w.phase == LatencyBeforeBody:
+		d = w.left
+	case w.size > 0:
+		d = time.Duration(float64(w.total) * float64(len(b)) / float64(w.size))
+	default:
+		d = w.total / spreadParts
+	}
+
+	if !w.sleep(d) {
+		return 0, w.ctx.Err()
+	}
+
+	return w.ResponseWriter.Write(b)
+}
+
+// sleep sends what was written so far, then waits d capped to what is left of the latency. It
+// returns false if this or an earlier wait was cut short.
+func (w *latencyPhaseWriter) sleep(d time.Duration) bool {
+	if !w.waited {
+		return false
+	}
+	if d > w.left {
+		d = w.left
+	}
+	if d <= 0 {
+		return true
+	}
+	w.left -= d
+
+	w.Flush()
+	if !w.wait(d) {
+		w.waited = false
+	}
+
+	return w.waited
+}
+
+// finish waits what is left of the latency, after sending the headers. With LatencyAfterHandler it
+// waits the whole latency and then sends the response held, or drops it if the wait was cut short.
+func (w *latencyPhaseWriter) finish() {
+	if w.phase == LatencyAfterHandler {
+		w.release()
+		return
+	}
+
+	if w.left <= 0 || !w.waited {
+		return
+	}
+
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	w.sleep(w.left)
+}
+
+// release waits the latency and then sends the response held for LatencyAfterHandler.
+func (w *latencyPhaseWriter) release() {
+	if !w.sleep(w.left) {
+		return
+	}
+
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	h := w.ResponseWriter.Header()
+	for k := range h {
+		delete(h, k)
+	}
+	for k, v := range w.header {
+		h[k] = v
+	}
+
+	w.released = true
+	w.ResponseWriter.WriteHeader(w.code)
+	if w.body.Len() > 0 {
+		_, _ = w.ResponseWriter.Write(w.body.Bytes())
+	}
+	w.Flush()
+}
+
+// Flush flushes the underlying ResponseWriter if it can. With LatencyAfterHandler it does nothing
+// until the response is released.
+func (w *latencyPhaseWriter) Flush() {
+	if w.phase == LatencyAfterHandler && !w.released {
+		return
+	}
+
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap returns the underlying ResponseWriter for http.ResponseController.
+func (w *latencyPhaseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
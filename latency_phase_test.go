@@ -0,0 +1,378 @@
+package fault
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testEventWriter is an http.ResponseWriter that logs what is written to it and flushed.
+type testEventWriter struct {
+	*httptest.ResponseRecorder
+	events *[]string
+}
+
+// WriteHeader logs the status code.
+func (w testEventWriter) WriteHeader(code int) {
+	*w.events = append(*w.events, fmt.Sprintf("header %d", code))
+	w.ResponseRecorder.WriteHeader(code)
+}
+
+// Write logs b.
+func (w testEventWriter) Write(b []byte) (int, error) {
+	*w.events = append(*w.events, "write "+string(b))
+	return w.ResponseRecorder.Write(b)
+}
+
+// Flush logs the flush.
+func (w testEventWriter) Flush() {
+	*w.events = append(*w.events, "flush")
+	w.ResponseRecorder.Flush()
+}
+
+// TestLatencyPhaseString tests LatencyPhase.String.
+func TestLatencyPhaseString(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		give LatencyPhase
+		want string
+	}{
+		{give: LatencyBeforeHeaders, want: "before_headers"},
+		{give: LatencyBeforeBody, want: "before_body"},
+		{give: LatencySpreadBody, want: "spread_body"},
+		{give: LatencyAfterHandler, want: "after_handler"},
+		{give: LatencyPhase(7), want: "LatencyPhase(7)"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.want, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, tt.give.String())
+		})
+	}
+}
+
+// TestWithLatencyPhase tests that WithLatencyPhase rejects unknown LatencyPhases.
+func TestWithLatencyPhase(t *testing.T) {
+	t.Parallel()
+
+	for _, p := range []LatencyPhase{-1, LatencyAfterHandler + 1} {
+		si, err := NewSlowInjector(time.Second, WithLatencyPhase(p))
+		assert.Equal(t, ErrInvalidLatencyPhase, err)
+		assert.Nil(t, si)
+
+		wi, err := NewWeightedLatencyInjector([]WeightedLatency{{Latency: time.Second, Weight: 1}}, WithLatencyPhase(p))
+		assert.Equal(t, ErrInvalidLatencyPhase, err)
+		assert.Nil(t, wi)
+
+		di, err := NewDistributionInjector(testLatencyProfile(0), WithLatencyPhase(p))
+		assert.Equal(t, ErrInvalidLatencyPhase, err)
+		assert.Nil(t, di)
+	}
+}
+
+// TestServeDelayedBody tests that serveDelayedBody waits in the right places of the response.
+func TestServeDelayedBody(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		givePhase  LatencyPhase
+		giveHeader http.Header
+		giveCode   int
+		giveWrites []string
+		wantEvents []string
+	}{
+		{
+			name:       "before body",
+			givePhase:  LatencyBeforeBody,
+			giveWrites: []string{"ab", "cd"},
+			wantEvents: []string{"header 200", "flush", "wait 1s", "write ab", "write cd"},
+		},
+		{
+			name:       "before body without body",
+			givePhase:  LatencyBeforeBody,
+			giveCode:   http.StatusNoContent,
+			wantEvents: []string{"header 204", "flush", "wait 1s"},
+		},
+		{
+			name:       "before body without response",
+			givePhase:  LatencyBeforeBody,
+			wantEvents: []string{"header 200", "flush", "wait 1s"},
+		},
+		{
+			name:       "spread with content length",
+			givePhase:  LatencySpreadBody,
+			giveHeader: http.Header{"Content-Length": {"4"}},
+			giveWrites: []string{"a", "", "bcd"},
+			wantEvents: []string{
+				"header 200", "flush", "wait 250ms", "write a", "write ",
+				"flush", "wait 750ms", "write bcd",
+			},
+		},
+		{
+			name:       "spread past content length",
+			givePhase:  LatencySpreadBody,
+			giveHeader: http.Header{"Content-Length": {"2"}},
+			giveWrites: []string{"a", "bcd"},
+			wantEvents: []string{
+				"header 200", "flush", "wait 500ms", "write a",
+				"flush", "wait 500ms", "write bcd",
+			},
+		},
+		{
+			name:       "spread without content length",
+			givePhase:  LatencySpreadBody,
+			giveCode:   http.StatusCreated,
+			giveWrites: []string{"a", "b"},
+			wantEvents: []string{
+				"header 201", "flush", "wait 100ms", "write a",
+				"flush", "wait 100ms", "write b",
+				"flush", "wait 800ms",
+			},
+		},
+		{
+			name:       "after handler",
+			givePhase:  LatencyAfterHandler,
+			giveHeader: http.Header{"Content-Length": {"4"}},
+			giveCode:   http.StatusCreated,
+			giveWrites: []string{"ab", "cd"},
+			wantEvents: []string{"wait 1s", "header 201", "write abcd", "flush"},
+		},
+		{
+			name:       "after handler without response",
+			givePhase:  LatencyAfterHandler,
+			wantEvents: []string{"wait 1s", "header 200", "flush"},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var events []string
+			wait := func(d time.Duration) bool {
+				events = append(events, "wait "+d.String())
+				return true
+			}
+
+			w := testEventWriter{ResponseRecorder: httptest.NewRecorder(), events: &events}
+			serveDelayedBody(tt.givePhase, time.Second, wait, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				for k, v := range tt.giveHeader {
+					w.Header()[k] = v
+				}
+				if tt.giveCode != 0 {
+					w.WriteHeader(tt.giveCode)
+				}
+				for _, s := range tt.giveWrites {
+					_, err := w.Write([]byte(s))
+					assert.NoError(t, err)
+				}
+			}), w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			assert.Equal(t, tt.wantEvents, events)
+		})
+	}
+}
+
+// TestLatencyPhaseInjectors tests that the latency Injectors wait in the body of the response with
+// WithLatencyPhase.
+func TestLatencyPhaseInjectors(t *testing.T) {
+	t.Parallel()
+
+	var slept []time.Duration
+	si, err := NewSlowInjector(10*time.Millisecond, WithLatencyPhase(LatencyBeforeBody),
+		WithSlowFunc(func(d time.Duration) {
+			slept = append(slept, d)
+		}))
+	assert.NoError(t, err)
+
+	wclock := &testSleepClock{}
+	wi, err := NewWeightedLatencyInjector([]WeightedLatency{{Latency: 10 * time.Millisecond, Weight: 1}},
+		WithLatencyPhase(LatencySpreadBody), WithClock(wclock))
+	assert.NoError(t, err)
+
+	dclock := &testSleepClock{}
+	di, err := NewDistributionInjector(testLatencyProfile(2), WithLatencyPhase(LatencySpreadBody), WithClock(dclock))
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name      string
+		give      Injector
+		wantPhase string
+		wantWaits func() []time.Duration
+	}{
+		{
+			name:      "slow",
+			give:      si,
+			wantPhase: "before_body",
+			wantWaits: func() []time.Duration { return slept },
+		},
+		{
+			name:      "weighted latency",
+			give:      wi,
+			wantPhase: "spread_body",
+			wantWaits: func() []time.Duration { return wclock.sleeps },
+		},
+		{
+			name:      "distribution",
+			give:      di,
+			wantPhase: "spread_body",
+			wantWaits: func() []time.Duration { return dclock.sleeps },
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			var events []string
+			w := testEventWriter{ResponseRecorder: httptest.NewRecorder(), events: &events}
+			tt.give.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Length", "4")
+				w.WriteHeader(testHandlerCode)
+				_, err := w.Write([]byte("body"))
+				assert.NoError(t, err)
+			})).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/a", nil))
+
+			assert.Equal(t, []string{"header 202", "flush", "write body"}, events)
+			assert.Len(t, tt.wantWaits(), 1)
+			assert.Equal(t, tt.wantPhase, tt.give.(Describer).Describe()["phase"])
+		})
+	}
+}
+
+// TestServeDelayedBodyAfterHandler tests that a response held for LatencyAfterHandler keeps the
+// headers it was written with, and is dropped if the wait is cut short.
+func TestServeDelayedBodyAfterHandler(t *testing.T) {
+	t.Parallel()
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Before", "1")
+		w.WriteHeader(http.StatusTeapot)
+		w.Header().Set("X-After", "1")
+		_, err := w.Write([]byte("body"))
+		assert.NoError(t, err)
+		w.(http.Flusher).Flush()
+	})
+
+	rr := httptest.NewRecorder()
+	serveDelayedBody(LatencyAfterHandler, time.Second, func(d time.Duration) bool {
+		assert.False(t, rr.Flushed)
+		return true
+	}, handler, rr, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, http.StatusTeapot, rr.Code)
+	assert.Equal(t, "1", rr.Header().Get("X-Before"))
+	assert.Empty(t, rr.Header().Get("X-After"))
+	assert.Equal(t, "body", rr.Body.String())
+	assert.True(t, rr.Flushed)
+
+	rr = httptest.NewRecorder()
+	serveDelayedBody(LatencyAfterHandler, time.Second, func(d time.Duration) bool {
+		return false
+	}, handler, rr, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Empty(t, rr.Body.String())
+	assert.False(t, rr.Flushed)
+}
+
+// TestSlowInjectorTransferLatency tests that a SlowInjector waits its latency before the first byte
+// and spreads its transfer latency across the body.
+func TestSlowInjectorTransferLatency(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		givePhase  LatencyPhase
+		wantEvents []string
+	}{
+		{
+			name:      "before headers",
+			givePhase: LatencyBeforeHeaders,
+			wantEvents: []string{
+				"wait 1s", "header 200", "flush", "wait 1m0s", "write body",
+			},
+		},
+		{
+			name:      "before body",
+			givePhase: LatencyBeforeBody,
+			wantEvents: []string{
+				"header 200", "flush", "wait 1s", "flush", "wait 1m0s", "write body",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var events []string
+			si, err := NewSlowInjector(time.Second,
+				WithLatencyPhase(tt.givePhase),
+				WithTransferLatency(time.Minute),
+				WithSlowFunc(func(d time.Duration) {
+					events = append(events, "wait "+d.String())
+				}),
+			)
+			assert.NoError(t, err)
+
+			w := testEventWriter{ResponseRecorder: httptest.NewRecorder(), events: &events}
+			si.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Length", "4")
+				_, err := w.Write([]byte("body"))
+				assert.NoError(t, err)
+			})).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			assert.Equal(t, tt.wantEvents, events)
+			assert.Equal(t, "1m0s", si.Describe()["transfer"])
+		})
+	}
+}
+
+// TestServeDelayedBodyCanceled tests that the writes of the handler fail once a wait is cut short.
+func TestServeDelayedBodyCanceled(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	waits := 0
+	wait := func(d time.Duration) bool {
+		waits++
+		return false
+	}
+
+	rr := httptest.NewRecorder()
+	serveDelayedBody(LatencySpreadBody, time.Second, wait, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for n := 0; n < 2; n++ {
+			written, err := w.Write([]byte("a"))
+			assert.Equal(t, context.Canceled, err)
+			assert.Equal(t, 0, written)
+		}
+	}), rr, httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx))
+
+	assert.Equal(t, 1, waits)
+	assert.Empty(t, rr.Body.String())
+	assert.Equal(t, rr, (&latencyPhaseWriter{ResponseWriter: rr}).Unwrap())
+}
+
+// TestSleepFunc tests that sleepFunc sleeps on the Clock and reports if the context is done.
+func TestSleepFunc(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, sleepFunc(context.Background(), NewRealClock())(time.Millisecond))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.False(t, sleepFunc(ctx, NewRealClock())(time.Millisecond))
+}
@@ -0,0 +1,42 @@
+package fault
+
+// EnableHook is implemented by Injectors that need to know when their Fault is enabled, for example
+// to reset counters or start background work. OnEnable is called by NewFault if the Fault is created
+// enabled and by Fault.SetEnabled when the Fault changes from disabled to enabled.
+type EnableHook interface {
+	OnEnable()
+}
+
+// DisableHook is implemented by Injectors that need to know when their Fault is disabled, for
+// example to release resources they are holding. OnDisable is called by Fault.SetEnabled when the
+// Fault changes from enabled to disabled.
+type DisableHook interface {
+	OnDisable()
+}
+
+// ConfigChangeHook is implemented by Injectors that need to know when the configuration of their
+// Fault changes at runtime. OnConfigChange is called by Fault.SetParticipation.
+type ConfigChangeHook interface {
+	OnConfigChange()
+}
+
+// runEnableHook calls i.OnEnable if i implements EnableHook.
+func runEnableHook(i Injector) {
+	if h, ok := i.(EnableHook); ok {
+		h.OnEnable()
+	}
+}
+
+// runDisableHook calls i.OnDisable if i implements DisableHook.
+func runDisableHook(i Injector) {
+	if h, ok := i.(DisableHook); ok {
+		h.OnDisable()
+	}
+}
+
+// runConfigChangeHook calls i.OnConfigChange if i implements ConfigChangeHook.
+func runConfigChangeHook(i Injector) {
+	if h, ok := i.(ConfigChangeHook); ok {
+		h.OnConfigChange()
+	}
+}
@@ -0,0 +1,98 @@
+package fault
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestFaultLifecycleHooks tests that Faults run the lifecycle hooks of their Injector.
+func TestFaultLifecycleHooks(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name              string
+		giveEnabled       bool
+		giveSetEnabled    []bool
+		giveParticipation []float32
+		wantEnables       int
+		wantDisables      int
+		wantConfigChanges int
+	}{
+		{
+			name:        "created disabled",
+			giveEnabled: false,
+		},
+		{
+			name:        "created enabled",
+			giveEnabled: true,
+			wantEnables: 1,
+		},
+		{
+			name:           "toggled",
+			giveEnabled:    false,
+			giveSetEnabled: []bool{true, false, true},
+			wantEnables:    2,
+			wantDisables:   1,
+		},
+		{
+			name:           "set to same state",
+			giveEnabled:    true,
+			giveSetEnabled: []bool{true, true},
+			wantEnables:    1,
+		},
+		{
+			name:              "participation changed",
+			giveParticipation: []float32{0.5, 2.0, 0.1},
+			wantConfigChanges: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			i := newTestInjectorHooks()
+
+			f, err := NewFault(i, WithEnabled(tt.giveEnabled))
+			assert.NoError(t, err)
+
+			for _, e := range tt.giveSetEnabled {
+				f.SetEnabled(e)
+			}
+			for _, p := range tt.giveParticipation {
+				_ = f.SetParticipation(p)
+			}
+
+			assert.Equal(t, tt.wantEnables, i.enables)
+			assert.Equal(t, tt.wantDisables, i.disables)
+			assert.Equal(t, tt.wantConfigChanges, i.configChanges)
+		})
+	}
+}
+
+// TestCompositeLifecycleHooks tests that composite Injectors pass lifecycle hooks to their children.
+func TestCompositeLifecycleHooks(t *testing.T) {
+	t.Parallel()
+
+	one, two := newTestInjectorHooks(), newTestInjectorHooks()
+
+	ci, err := NewChainInjector([]Injector{one, newTestInjectorNoop()})
+	assert.NoError(t, err)
+
+	ri, err := NewRandomInjector([]Injector{ci, two})
+	assert.NoError(t, err)
+
+	f, err := NewFault(ri, WithEnabled(true))
+	assert.NoError(t, err)
+
+	f.SetEnabled(false)
+	assert.NoError(t, f.SetParticipation(0.5))
+
+	for _, i := range []*testInjectorHooks{one, two} {
+		assert.Equal(t, 1, i.enables)
+		assert.Equal(t, 1, i.disables)
+		assert.Equal(t, 1, i.configChanges)
+	}
+}
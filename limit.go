@@ -0,0 +1,110 @@
+package fault
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrParticipationIncrease when a participation change raises a Fault faster than the
+	// participation limit of its Manager allows.
+	ErrParticipationIncrease = errors.New("participation increase exceeds the limit")
+)
+
+// participationEpsilon absorbs float32 rounding when comparing an increase to the budget, so that
+// raising 1% to 6% is not rejected for being 5.0000001 points.
+const participationEpsilon = 1e-6
+
+// participationLimitOption holds the arguments of WithParticipationLimit.
+type participationLimitOption struct {
+	increase float32
+	per      time.Duration
+}
+
+func (o participationLimitOption) applyManager(m *Manager) error {
+	if o.increase <= 0.0 || o.increase > 1.0 {
+		return ErrInvalidPercent
+	}
+
+	if o.per <= 0 {
+		return ErrInvalidInterval
+	}
+
+	m.limit = &o
+
+	return nil
+}
+
+// WithParticipationLimit limits how fast the participation of each managed Fault can be raised
+// while it runs to increase, as a fraction of requests, per duration. For example,
+// WithParticipationLimit(0.05, time.Minute) allows at most 5 percentage points per minute, so a
+// fat-fingered change from 1% to 100% is rejected. Fault.SetParticipation and
+// Fault.SetFractionalParticipation return ErrParticipationIncrease for a change beyond the limit,
+// and the Runtime keeps the previous value. Decreases are never limited. 0.0 < increase <= 1.0.
+func WithParticipationLimit(increase float32, per time.Duration) ManagerOption {
+	return participationLimitOption{increase: increase, per: per}
+}
+
+// participationLimit is the token bucket that limits how fast a Fault's participation can rise.
+// It holds up to increase of budget, which refills at increase per per.
+type participationLimit struct {
+	increase float32
+	per      time.Duration
+	clock    Clock
+
+	mtx    sync.Mutex
+	budget float32
+	last   time.Time
+}
+
+// newParticipationLimit returns a participationLimit for o with a full budget.
+func newParticipationLimit(o *participationLimitOption, c Clock) *participationLimit {
+	return &participationLimit{
+		increase: o.increase,
+		per:      o.per,
+		clock:    c,
+		budget:   o.increase,
+		last:     c.Now(),
+	}
+}
+
+// set runs opt, which sets the participation of f to p, if raising f to p is within the budget.
+func (l *participationLimit) set(f *Fault, p float32, opt Option) error {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	now := l.clock.Now()
+	l.budget += l.increase * float32(now.Sub(l.last)) / float32(l.per)
+	if l.budget > l.increase {
+		l.budget = l.increase
+	}
+	l.last = now
+
+	delta := p - f.participation.Load()
+	if delta > l.budget+participationEpsilon {
+		return fmt.Errorf("%w: %s can rise by at most %g now, not %g", ErrParticipationIncrease,
+			f.Name(), l.budget, delta)
+	}
+
+	// p is valid
+	_ = opt.applyFault(f)
+
+	if delta > 0 {
+		l.budget -= delta
+	}
+
+	return nil
+}
+
+// setParticipation runs opt, which sets the participation of f to p, subject to the participation
+// limit of its Manager, if any. p must be valid.
+func (f *Fault) setParticipation(p float32, opt Option) error {
+	l := f.limit.Load()
+	if l == nil {
+		return opt.applyFault(f)
+	}
+
+	return l.set(f, p, opt)
+}
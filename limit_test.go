@@ -0,0 +1,172 @@
+package fault
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/github/go-fault/faulttest"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestWithParticipationLimit tests the validation of WithParticipationLimit.
+func TestWithParticipationLimit(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		giveIncrease float32
+		givePer      time.Duration
+		wantErr      error
+	}{
+		{
+			name:         "valid",
+			giveIncrease: 0.05,
+			givePer:      time.Minute,
+		},
+		{
+			name:         "full",
+			giveIncrease: 1.0,
+			givePer:      time.Second,
+		},
+		{
+			name:         "zero increase",
+			giveIncrease: 0.0,
+			givePer:      time.Minute,
+			wantErr:      ErrInvalidPercent,
+		},
+		{
+			name:         "increase above 1",
+			giveIncrease: 1.1,
+			givePer:      time.Minute,
+			wantErr:      ErrInvalidPercent,
+		},
+		{
+			name:         "zero duration",
+			giveIncrease: 0.05,
+			givePer:      0,
+			wantErr:      ErrInvalidInterval,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			m, err := NewManager(WithParticipationLimit(tt.giveIncrease, tt.givePer))
+
+			assert.Equal(t, tt.wantErr, err)
+			if tt.wantErr != nil {
+				assert.Nil(t, m)
+				return
+			}
+
+			assert.Equal(t, &participationLimitOption{increase: tt.giveIncrease, per: tt.givePer}, m.limit)
+		})
+	}
+}
+
+// TestParticipationLimit tests that a Manager limits how fast the participation of its Faults can
+// be raised.
+func TestParticipationLimit(t *testing.T) {
+	t.Parallel()
+
+	clock := faulttest.NewClock(time.Time{})
+	m, err := NewManager(WithClock(clock), WithParticipationLimit(0.05, time.Minute))
+	assert.NoError(t, err)
+
+	hooks := newTestInjectorHooks()
+	f, err := NewFault(hooks, WithName("limited"), WithParticipation(0.01))
+	assert.NoError(t, err)
+	assert.NoError(t, m.Add(f))
+
+	steps := []struct {
+		name    string
+		advance time.Duration
+		give    float32
+		want    float32
+		wantErr bool
+	}{
+		{name: "fat finger", give: 1.0, want: 0.01, wantErr: true},
+		{name: "within budget", give: 0.06, want: 0.06},
+		{name: "budget spent", give: 0.07, want: 0.06, wantErr: true},
+		{name: "decrease", give: 0.02, want: 0.02},
+		{name: "partly refilled", advance: 30 * time.Second, give: 0.045, want: 0.045},
+		{name: "capped refill", advance: time.Hour, give: 0.11, want: 0.045, wantErr: true},
+		{name: "full refill", give: 0.095, want: 0.095},
+		{name: "invalid", give: 1.5, want: 0.095},
+	}
+
+	for _, s := range steps {
+		clock.Advance(s.advance)
+
+		err := f.SetParticipation(s.give)
+
+		if s.give > 1.0 {
+			assert.Equal(t, ErrInvalidPercent, err, s.name)
+		} else {
+			assert.Equal(t, s.wantErr, errors.Is(err, ErrParticipationIncrease), s.name)
+		}
+		assert.InDelta(t, s.want, f.participation.Load(), 1e-6, s.name)
+	}
+
+	// rejected and invalid changes do not run the hook
+	assert.Equal(t, 4, hooks.configChanges)
+
+	// FractionalPercents are limited the same way
+	assert.True(t, errors.Is(f.SetFractionalParticipation(BasisPoints(5000)), ErrParticipationIncrease))
+	assert.Equal(t, ErrInvalidDenominator, f.SetFractionalParticipation(FractionalPercent{}))
+	assert.NoError(t, f.SetFractionalParticipation(BasisPoints(500)))
+	assert.Equal(t, uint32(50000+1), f.perMillion.Load())
+
+	// a removed Fault is no longer limited
+	assert.True(t, m.Remove("limited"))
+	assert.NoError(t, f.SetParticipation(1.0))
+}
+
+// TestRuntimeParticipationLimit tests that a Runtime keeps the previous value when a bound Fault
+// rejects a new one.
+func TestRuntimeParticipationLimit(t *testing.T) {
+	t.Parallel()
+
+	clock := faulttest.NewClock(time.Time{})
+	m, err := NewManager(WithClock(clock), WithParticipationLimit(0.05, time.Minute))
+	assert.NoError(t, err)
+
+	f, err := NewFault(newTestInjectorHooks(), WithName("limited"))
+	assert.NoError(t, err)
+	assert.NoError(t, m.Add(f))
+
+	dir := t.TempDir()
+	writeRuntimeFiles(t, dir, map[string]string{"fault/http/abort/abort_percent": "10"})
+
+	r, err := NewRuntime(WithDiskLayers(dir))
+	assert.NoError(t, err)
+
+	// the disk value is beyond the limit, so binding keeps the participation
+	err = r.Bind(f, RuntimeAbortPercent, FractionalPercent{Denominator: PerHundred})
+	assert.True(t, errors.Is(err, ErrParticipationIncrease))
+	assert.Equal(t, float32(0), f.participation.Load())
+
+	// and so does reloading it
+	assert.True(t, errors.Is(r.Reload(), ErrParticipationIncrease))
+	assert.Equal(t, float32(0), f.participation.Load())
+
+	// an admin value within the limit applies
+	assert.NoError(t, r.Set(RuntimeAbortPercent, "5"))
+	assert.Equal(t, uint32(50000+1), f.perMillion.Load())
+
+	// an admin value beyond the limit is not set
+	clock.Advance(time.Minute)
+	err = r.Set(RuntimeAbortPercent, "100")
+	assert.True(t, errors.Is(err, ErrParticipationIncrease))
+	assert.Equal(t, uint32(50000+1), f.perMillion.Load())
+	v, _ := r.Get(RuntimeAbortPercent)
+	assert.Equal(t, "5", v)
+
+	// removing the override ramps up to the disk value once the budget allows
+	assert.NoError(t, r.Set(RuntimeAbortPercent, ""))
+	assert.Equal(t, uint32(100000+1), f.perMillion.Load())
+	assert.NoError(t, r.Reload())
+}
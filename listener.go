@@ -0,0 +1,398 @@
+package fault
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNoConnectionFaults when a Listener is not given any connection faults.
+var ErrNoConnectionFaults = errors.New("listener must delay, drop, throttle, or close connections")
+
+// Listener is a net.Listener that faults a percent of the connections it accepts: it can delay
+// accepting them, drop them, throttle the bytes they read and write, or close them in the middle of
+// a response. Each fault has its own rate and is decided separately for each connection. Unlike
+// Injectors it works below http.Handler, so it simulates what middleware cannot, such as TLS
+// handshakes that stall on a slow link or connections that are reset by a flaky network. Pass it
+// to http.Server.Serve, or wrap it with tls.NewListener to fault the TLS handshake too.
+type Listener struct {
+	net.Listener
+
+	acceptDelay     time.Duration
+	acceptDelayRate float32
+	dropRate        float32
+	bytesPerSecond  int
+	throttleRate    float32
+	closeAfter      int
+	closeRate       float32
+	faults          []string
+
+	clock    Clock
+	reporter Reporter
+
+	randSeed int64
+	rand     *rand.Rand
+
+	// *rand.Rand is not thread safe. This mutex protects our random source
+	randMtx sync.Mutex
+}
+
+// ListenerOption configures a Listener.
+type ListenerOption interface {
+	applyListener(l *Listener) error
+}
+
+type acceptDelayOption struct {
+	delay time.Duration
+	rate  float32
+}
+
+func (o acceptDelayOption) applyListener(l *Listener) error {
+	if o.delay < 0 {
+		return ErrInvalidDelay
+	}
+	if o.rate < 0.0 || o.rate > 1.0 {
+		return ErrInvalidPercent
+	}
+
+	l.acceptDelay = o.delay
+	l.acceptDelayRate = o.rate
+	l.faults = append(l.faults, "accept_delay")
+	return nil
+}
+
+// WithAcceptDelay makes the Listener wait d before handing rate of the connections it accepts to
+// the server. The client has connected, but nothing answers it, so a TLS handshake stalls. Accept
+// waits in the caller, so like an overloaded server the connections behind a delayed one wait too.
+func WithAcceptDelay(d time.Duration, rate float32) ListenerOption {
+	return acceptDelayOption{delay: d, rate: rate}
+}
+
+type dropOption float32
+
+func (o dropOption) applyListener(l *Listener) error {
+	if o < 0.0 || o > 1.0 {
+		return ErrInvalidPercent
+	}
+
+	l.dropRate = float32(o)
+	l.faults = append(l.faults, "drop")
+	return nil
+}
+
+// WithDropConnections makes the Listener reset rate of the connections it accepts before the server
+// sees them, and accept the next connection instead. Clients get a connection reset before any byte
+// is exchanged.
+func WithDropConnections(rate float32) ListenerOption {
+	return dropOption(rate)
+}
+
+type connectionThrottleOption struct {
+	bytesPerSecond int
+	rate           float32
+}
+
+func (o connectionThrottleOption) applyListener(l *Listener) error {
+	if o.bytesPerSecond <= 0 {
+		return ErrInvalidBandwidth
+	}
+	if o.rate < 0.0 || o.rate > 1.0 {
+		return ErrInvalidPercent
+	}
+
+	l.bytesPerSecond = o.bytesPerSecond
+	l.throttleRate = o.rate
+	l.faults = append(l.faults, "throttle")
+	return nil
+}
+
+// WithConnectionThrottle limits the bytes that rate of the connections the Listener accepts read
+// and write to bytesPerSecond in each direction, so everything sent over them is slow, from the TLS
+// handshake to the last byte of the last response. Bytes are paced in bursts of a tenth of a second.
+func WithConnectionThrottle(bytesPerSecond int, rate float32) ListenerOption {
+	return connectionThrottleOption{bytesPerSecond: bytesPerSecond, rate: rate}
+}
+
+type midStreamCloseOption struct {
+	afterBytes int
+	rate       float32
+}
+
+func (o midStreamCloseOption) applyListener(l *Listener) error {
+	if o.afterBytes < 0 {
+		return ErrInvalidBytes
+	}
+	if o.rate < 0.0 || o.rate > 1.0 {
+		return ErrInvalidPercent
+	}
+
+	l.closeAfter = o.afterBytes
+	l.closeRate = o.rate
+	l.faults = append(l.faults, "close")
+	return nil
+}
+
+// WithMidStreamClose makes the Listener reset rate of the connections it accepts as soon as the
+// server has written afterBytes to them, so clients get part of a response and then a connection
+// reset. A write past afterBytes fails with net.ErrClosed after writing the bytes before it.
+func WithMidStreamClose(afterBytes int, rate float32) ListenerOption {
+	return midStreamCloseOption{afterBytes: afterBytes, rate: rate}
+}
+
+func (o clockOption) applyListener(l *Listener) error {
+	l.clock = o.clock
+	return nil
+}
+
+func (o randSeedOption) applyListener(l *Listener) error {
+	l.randSeed = int64(o)
+	return nil
+}
+
+func (o reporterOption) applyListener(l *Listener) error {
+	l.reporter = o.reporter
+	return nil
+}
+
+// NewListener returns a Listener that accepts connections from l and faults them as configured by
+// WithAcceptDelay, WithDropConnections, WithConnectionThrottle, and WithMidStreamClose. It returns
+// ErrNoConnectionFaults if none of them are passed.
+func NewListener(l net.Listener, opts ...ListenerOption) (*Listener, error) {
+	// set defaults
+	fl := &Listener{
+		Listener: l,
+		clock:    NewRealClock(),
+		reporter: NewNoopReporter(),
+		randSeed: defaultRandSeed,
+	}
+
+	// apply options
+	for _, opt := range opts {
+		err := opt.applyListener(fl)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// check options
+	if len(fl.faults) == 0 {
+		return nil, ErrNoConnectionFaults
+	}
+
+	fl.rand = rand.New(rand.NewSource(fl.randSeed))
+
+	return fl, nil
+}
+
+// Accept waits for the next connection that is not dropped and faults it.
+func (l *Listener) Accept() (net.Conn, error) {
+	for {
+		conn, err := l.Listener.Accept()
+		if err != nil {
+			return nil, err
+		}
+
+		delay, drop := l.participate(l.acceptDelayRate), l.participate(l.dropRate)
+		throttle, cut := l.participate(l.throttleRate), l.participate(l.closeRate)
+		if !delay && !drop && !throttle && !cut {
+			return conn, nil
+		}
+
+		go l.reporter.Report(l.String(), StateStarted)
+
+		if delay {
+			l.clock.Sleep(l.acceptDelay)
+		}
+
+		if drop {
+			resetOnClose(conn)
+			_ = conn.Close()
+			go l.reporter.Report(l.String(), StateFinished)
+			continue
+		}
+
+		if !throttle && !cut {
+			go l.reporter.Report(l.String(), StateFinished)
+			return conn, nil
+		}
+
+		fc := &faultConn{Conn: conn, listener: l, remaining: -1}
+		if throttle {
+			fc.read = newConnBucket(l.bytesPerSecond, l.clock)
+			fc.write = newConnBucket(l.bytesPerSecond, l.clock)
+		}
+		if cut {
+			fc.remaining = l.closeAfter
+		}
+
+		return fc, nil
+	}
+}
+
+// participate randomly decides (returns true) if a connection gets a fault with rate.
+func (l *Listener) participate(rate float32) bool {
+	if rate <= 0 {
+		return false
+	}
+
+	l.randMtx.Lock()
+	rn := l.rand.Float32()
+	l.randMtx.Unlock()
+
+	return rn < rate
+}
+
+// Seed returns the seed of the Listener's random number generator.
+func (l *Listener) Seed() int64 {
+	return l.randSeed
+}
+
+// String returns a summary of the Listener, such as "listener(drop,throttle)".
+func (l *Listener) String() string {
+	return fmt.Sprintf("listener(%s)", strings.Join(l.faults, ","))
+}
+
+// Reporter returns the Reporter of the Listener.
+func (l *Listener) Reporter() Reporter {
+	return l.reporter
+}
+
+// SetReporter replaces the Reporter of the Listener.
+func (l *Listener) SetReporter(r Reporter) {
+	l.reporter = r
+}
+
+// faultConn is a net.Conn that is throttled, closed after a number of bytes written, or both.
+type faultConn struct {
+	net.Conn
+	listener *Listener
+
+	// read and write pace each direction, or are nil if the connection is not throttled.
+	read  *connBucket
+	write *connBucket
+
+	// writeMtx protects remaining, the bytes left to write before closing, or -1 to never close.
+	writeMtx  sync.Mutex
+	remaining int
+
+	closeOnce sync.Once
+}
+
+// Read reads up to a burst of bytes and waits for them to be earned.
+func (c *faultConn) Read(p []byte) (int, error) {
+	if c.read == nil {
+		return c.Conn.Read(p)
+	}
+
+	if len(p) > c.read.burst {
+		p = p[:c.read.burst]
+	}
+
+	n, err := c.Conn.Read(p)
+	c.read.wait(n)
+
+	return n, err
+}
+
+// Write writes b in chunks of up to a burst, waiting for the bytes of each chunk to be earned, and
+// resets the connection as soon as the bytes to write before closing are written.
+func (c *faultConn) Write(b []byte) (int, error) {
+	c.writeMtx.Lock()
+	defer c.writeMtx.Unlock()
+
+	var n int
+	for len(b) > 0 {
+		if c.remaining == 0 {
+			resetOnClose(c.Conn)
+			_ = c.Close()
+			return n, net.ErrClosed
+		}
+
+		size := len(b)
+		if c.write != nil {
+			size = min(size, c.write.burst)
+			c.write.wait(size)
+		}
+		if c.remaining > 0 {
+			size = min(size, c.remaining)
+		}
+
+		written, err := c.Conn.Write(b[:size])
+		n += written
+		if c.remaining > 0 {
+			c.remaining -= written
+		}
+		if err != nil {
+			return n, err
+		}
+
+		b = b[size:]
+	}
+
+	if c.remaining == 0 {
+		resetOnClose(c.Conn)
+		_ = c.Close()
+	}
+
+	return n, nil
+}
+
+// Close closes the connection and reports that its faults finished.
+func (c *faultConn) Close() error {
+	err := c.Conn.Close()
+	c.closeOnce.Do(func() {
+		go c.listener.reporter.Report(c.listener.String(), StateFinished)
+	})
+
+	return err
+}
+
+// connBucket paces one direction of a connection with a token bucket of bytes.
+type connBucket struct {
+	bytesPerSecond int
+	burst          int
+	clock          Clock
+
+	// tokens are the bytes that may be sent now, as of last. started is false until the bucket is
+	// filled for the first wait.
+	tokens  float64
+	last    time.Time
+	started bool
+}
+
+// newConnBucket returns a connBucket of bytesPerSecond with a burst of a tenth of a second.
+func newConnBucket(bytesPerSecond int, clock Clock) *connBucket {
+	return &connBucket{
+		bytesPerSecond: bytesPerSecond,
+		burst:          max(bytesPerSecond/10, 1),
+		clock:          clock,
+	}
+}
+
+// wait waits until n bytes are earned and spends them.
+func (b *connBucket) wait(n int) {
+	b.refill()
+	if want := float64(n); b.tokens < want {
+		b.clock.Sleep(time.Duration(math.Ceil((want - b.tokens) / float64(b.bytesPerSecond) * float64(time.Second))))
+		b.refill()
+		b.tokens = math.Max(b.tokens, want)
+	}
+	b.tokens -= float64(n)
+}
+
+// refill adds the bytes earned since the bucket was last refilled, filling it on first use.
+func (b *connBucket) refill() {
+	now := b.clock.Now()
+	if !b.started {
+		b.tokens = float64(b.burst)
+		b.started = true
+	} else if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens = math.Min(b.tokens+elapsed.Seconds()*float64(b.bytesPerSecond), float64(b.burst))
+	}
+	b.last = now
+}
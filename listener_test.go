@@ -0,0 +1,469 @@
+package fault
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"log"
+	"net"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testConnListener is a net.Listener that accepts the connections sent on conns until it is closed.
+type testConnListener struct {
+	conns chan net.Conn
+}
+
+// Accept returns the next connection, or net.ErrClosed once conns is closed.
+func (l *testConnListener) Accept() (net.Conn, error) {
+	conn, ok := <-l.conns
+	if !ok {
+		return nil, net.ErrClosed
+	}
+
+	return conn, nil
+}
+
+// Close does nothing.
+func (l *testConnListener) Close() error {
+	return nil
+}
+
+// Addr returns a fixed address.
+func (l *testConnListener) Addr() net.Addr {
+	return &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 8080}
+}
+
+// testConnClock is a Clock whose Sleep records d and moves Now forward by d instead of sleeping.
+type testConnClock struct {
+	RealClock
+
+	mtx    sync.Mutex
+	now    time.Time
+	sleeps []time.Duration
+}
+
+// Now returns the time.
+func (c *testConnClock) Now() time.Time {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	return c.now
+}
+
+// Sleep records d and advances the time by d.
+func (c *testConnClock) Sleep(d time.Duration) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	c.sleeps = append(c.sleeps, d)
+	c.now = c.now.Add(d)
+}
+
+// TestNewListener tests NewListener.
+func TestNewListener(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		giveOpts   []ListenerOption
+		wantString string
+		wantSeed   int64
+		wantErr    error
+	}{
+		{
+			name: "all faults",
+			giveOpts: []ListenerOption{
+				WithAcceptDelay(time.Second, 0.1),
+				WithDropConnections(0.05),
+				WithConnectionThrottle(1000, 0.2),
+				WithMidStreamClose(512, 0.01),
+				WithClock(&testConnClock{}),
+				WithRandSeed(100),
+				WithReporter(newTestReporter()),
+			},
+			wantString: "listener(accept_delay,drop,throttle,close)",
+			wantSeed:   100,
+		},
+		{
+			name:       "zero rate",
+			giveOpts:   []ListenerOption{WithDropConnections(0)},
+			wantString: "listener(drop)",
+			wantSeed:   defaultRandSeed,
+		},
+		{
+			name:    "no faults",
+			wantErr: ErrNoConnectionFaults,
+		},
+		{
+			name:     "negative accept delay",
+			giveOpts: []ListenerOption{WithAcceptDelay(-time.Second, 0.1)},
+			wantErr:  ErrInvalidDelay,
+		},
+		{
+			name:     "invalid accept delay rate",
+			giveOpts: []ListenerOption{WithAcceptDelay(time.Second, 1.1)},
+			wantErr:  ErrInvalidPercent,
+		},
+		{
+			name:     "invalid drop rate",
+			giveOpts: []ListenerOption{WithDropConnections(-0.1)},
+			wantErr:  ErrInvalidPercent,
+		},
+		{
+			name:     "invalid bandwidth",
+			giveOpts: []ListenerOption{WithConnectionThrottle(0, 0.1)},
+			wantErr:  ErrInvalidBandwidth,
+		},
+		{
+			name:     "invalid throttle rate",
+			giveOpts: []ListenerOption{WithConnectionThrottle(1000, 1.1)},
+			wantErr:  ErrInvalidPercent,
+		},
+		{
+			name:     "negative close bytes",
+			giveOpts: []ListenerOption{WithMidStreamClose(-1, 0.1)},
+			wantErr:  ErrInvalidBytes,
+		},
+		{
+			name:     "invalid close rate",
+			giveOpts: []ListenerOption{WithMidStreamClose(512, 1.1)},
+			wantErr:  ErrInvalidPercent,
+		},
+		{
+			name:     "option error",
+			giveOpts: []ListenerOption{withError()},
+			wantErr:  errErrorOption,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			l, err := NewListener(nil, tt.giveOpts...)
+
+			assert.Equal(t, tt.wantErr, err)
+			if tt.wantErr != nil {
+				assert.Nil(t, l)
+				return
+			}
+
+			assert.Equal(t, tt.wantString, l.String())
+			assert.Equal(t, tt.wantSeed, l.Seed())
+		})
+	}
+}
+
+// TestListenerAccept tests that Listener.Accept faults the connections it accepts.
+func TestListenerAccept(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		giveOpts      []ListenerOption
+		wantSleeps    []time.Duration
+		wantFaultConn bool
+		wantThrottle  bool
+		wantRemaining int
+		wantStates    []InjectorState
+	}{
+		{
+			name:          "not faulted",
+			giveOpts:      []ListenerOption{WithDropConnections(0)},
+			wantRemaining: -1,
+		},
+		{
+			name:          "accept delay",
+			giveOpts:      []ListenerOption{WithAcceptDelay(time.Second, 1.0)},
+			wantSleeps:    []time.Duration{time.Second},
+			wantRemaining: -1,
+			wantStates:    []InjectorState{StateStarted, StateFinished},
+		},
+		{
+			name:          "throttle",
+			giveOpts:      []ListenerOption{WithConnectionThrottle(1000, 1.0)},
+			wantFaultConn: true,
+			wantThrottle:  true,
+			wantRemaining: -1,
+			wantStates:    []InjectorState{StateStarted, StateFinished},
+		},
+		{
+			name:          "close",
+			giveOpts:      []ListenerOption{WithMidStreamClose(512, 1.0)},
+			wantFaultConn: true,
+			wantRemaining: 512,
+			wantStates:    []InjectorState{StateStarted, StateFinished},
+		},
+		{
+			name: "delay, throttle, and close",
+			giveOpts: []ListenerOption{
+				WithAcceptDelay(time.Second, 1.0),
+				WithConnectionThrottle(1000, 1.0),
+				WithMidStreamClose(512, 1.0),
+			},
+			wantSleeps:    []time.Duration{time.Second},
+			wantFaultConn: true,
+			wantThrottle:  true,
+			wantRemaining: 512,
+			wantStates:    []InjectorState{StateStarted, StateFinished},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			clock := &testConnClock{}
+			rep := newTestStateReporter()
+			tl := &testConnListener{conns: make(chan net.Conn, 1)}
+			l, err := NewListener(tl, append(tt.giveOpts, WithClock(clock), WithReporter(rep))...)
+			assert.NoError(t, err)
+
+			server, client := net.Pipe()
+			defer client.Close()
+			tl.conns <- server
+
+			conn, err := l.Accept()
+			assert.NoError(t, err)
+
+			fc, ok := conn.(*faultConn)
+			assert.Equal(t, tt.wantFaultConn, ok)
+			if ok {
+				assert.Equal(t, tt.wantThrottle, fc.read != nil)
+				assert.Equal(t, tt.wantThrottle, fc.write != nil)
+				assert.Equal(t, tt.wantRemaining, fc.remaining)
+			} else {
+				assert.Equal(t, server, conn)
+			}
+			assert.Equal(t, tt.wantSleeps, clock.sleeps)
+
+			assert.NoError(t, conn.Close())
+			assert.NoError(t, conn.Close())
+
+			var states []InjectorState
+			for range tt.wantStates {
+				states = append(states, <-rep.states)
+			}
+			assert.ElementsMatch(t, tt.wantStates, states)
+		})
+	}
+}
+
+// TestListenerDrop tests that Listener.Accept drops connections and accepts the next one.
+func TestListenerDrop(t *testing.T) {
+	t.Parallel()
+
+	tl := &testConnListener{conns: make(chan net.Conn, 2)}
+	l, err := NewListener(tl, WithDropConnections(1.0))
+	assert.NoError(t, err)
+
+	var clients []net.Conn
+	for n := 0; n < 2; n++ {
+		server, client := net.Pipe()
+		tl.conns <- server
+		clients = append(clients, client)
+	}
+	close(tl.conns)
+
+	conn, err := l.Accept()
+	assert.Nil(t, conn)
+	assert.True(t, errors.Is(err, net.ErrClosed))
+
+	for _, client := range clients {
+		_, err := client.Read(make([]byte, 1))
+		assert.Equal(t, io.EOF, err)
+	}
+}
+
+// TestFaultConnWrite tests that faultConn closes the connection once it has written enough bytes.
+func TestFaultConnWrite(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		giveRemaining int
+		giveWrites    []string
+		wantN         []int
+		wantErr       []error
+		wantRead      string
+	}{
+		{
+			name:          "not closed",
+			giveRemaining: -1,
+			giveWrites:    []string{"hello", " world"},
+			wantN:         []int{5, 6},
+			wantErr:       []error{nil, nil},
+			wantRead:      "hello world",
+		},
+		{
+			name:          "crossing write",
+			giveRemaining: 8,
+			giveWrites:    []string{"hello", " world"},
+			wantN:         []int{5, 3},
+			wantErr:       []error{nil, net.ErrClosed},
+			wantRead:      "hello wo",
+		},
+		{
+			name:          "exact write",
+			giveRemaining: 5,
+			giveWrites:    []string{"hello", " world"},
+			wantN:         []int{5, 0},
+			wantErr:       []error{nil, net.ErrClosed},
+			wantRead:      "hello",
+		},
+		{
+			name:          "zero bytes",
+			giveRemaining: 0,
+			giveWrites:    []string{"hello"},
+			wantN:         []int{0},
+			wantErr:       []error{net.ErrClosed},
+			wantRead:      "",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			l, err := NewListener(nil, WithMidStreamClose(0, 1.0))
+			assert.NoError(t, err)
+
+			server, client := net.Pipe()
+			fc := &faultConn{Conn: server, listener: l, remaining: tt.giveRemaining}
+
+			read := make(chan string, 1)
+			go func() {
+				b, _ := ioutil.ReadAll(client)
+				read <- string(b)
+			}()
+
+			for idx, s := range tt.giveWrites {
+				n, err := fc.Write([]byte(s))
+				assert.Equal(t, tt.wantN[idx], n)
+				assert.True(t, errors.Is(err, tt.wantErr[idx]), err)
+			}
+			_ = fc.Close()
+
+			assert.Equal(t, tt.wantRead, <-read)
+		})
+	}
+}
+
+// TestFaultConnThrottle tests that faultConn paces reads and writes in bursts.
+func TestFaultConnThrottle(t *testing.T) {
+	t.Parallel()
+
+	clock := &testConnClock{}
+	l, err := NewListener(nil, WithConnectionThrottle(100, 1.0), WithClock(clock))
+	assert.NoError(t, err)
+
+	server, client := net.Pipe()
+	defer client.Close()
+	fc := &faultConn{
+		Conn:      server,
+		listener:  l,
+		read:      newConnBucket(100, clock),
+		write:     newConnBucket(100, clock),
+		remaining: -1,
+	}
+
+	// 25 bytes are written in bursts of 10, 10, and 5 bytes, waiting for the last two
+	go func() {
+		_, _ = io.ReadFull(client, make([]byte, 25))
+	}()
+	n, err := fc.Write(make([]byte, 25))
+	assert.Equal(t, 25, n)
+	assert.NoError(t, err)
+	assert.Equal(t, []time.Duration{100 * time.Millisecond, 50 * time.Millisecond}, clock.sleeps)
+
+	// reads are capped at the burst and wait for their bytes once the bucket is empty
+	go func() {
+		_, _ = client.Write(make([]byte, 25))
+	}()
+	var sizes []int
+	for total := 0; total < 25; {
+		n, err := fc.Read(make([]byte, 64))
+		assert.NoError(t, err)
+		sizes = append(sizes, n)
+		total += n
+	}
+	assert.Equal(t, []int{10, 10, 5}, sizes)
+	assert.Equal(t, []time.Duration{
+		100 * time.Millisecond, 50 * time.Millisecond, 100 * time.Millisecond, 50 * time.Millisecond,
+	}, clock.sleeps)
+
+	// writes fail once the other end is closed
+	assert.NoError(t, client.Close())
+	_, err = fc.Write([]byte("hello"))
+	assert.Equal(t, io.ErrClosedPipe, err)
+}
+
+// TestListenerServe tests a Listener in front of an http.Server.
+func TestListenerServe(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		giveOpts []ListenerOption
+		wantErr  bool
+	}{
+		{
+			name:     "not faulted",
+			giveOpts: []ListenerOption{WithDropConnections(0)},
+		},
+		{
+			name:     "dropped",
+			giveOpts: []ListenerOption{WithDropConnections(1.0)},
+			wantErr:  true,
+		},
+		{
+			name:     "closed mid-stream",
+			giveOpts: []ListenerOption{WithMidStreamClose(20, 1.0)},
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ln, err := net.Listen("tcp", "127.0.0.1:0")
+			assert.NoError(t, err)
+
+			l, err := NewListener(ln, tt.giveOpts...)
+			assert.NoError(t, err)
+
+			srv := &http.Server{
+				Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					_, _ = io.WriteString(w, "hello")
+				}),
+				ErrorLog: log.New(ioutil.Discard, "", 0),
+			}
+			go func() { _ = srv.Serve(l) }()
+			defer srv.Close()
+
+			client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+			resp, err := client.Get("http://" + ln.Addr().String())
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			defer resp.Body.Close()
+			body, err := ioutil.ReadAll(resp.Body)
+			assert.NoError(t, err)
+			assert.Equal(t, "hello", string(body))
+		})
+	}
+}
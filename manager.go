@@ -0,0 +1,179 @@
+package fault
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrNilFault when a nil Fault is passed.
+	ErrNilFault = errors.New("fault cannot be nil")
+	// ErrDuplicateFault when a Fault with the same name is already managed.
+	ErrDuplicateFault = errors.New("a fault with this name already exists")
+)
+
+// Manager holds a set of Faults, keyed by name, and runs them as a single middleware. Faults can be
+// added and removed while the Manager handles requests.
+type Manager struct {
+	mtx    sync.RWMutex
+	faults []*Fault
+
+	clock     Clock
+	samples   *requestSamples
+	conflicts ConflictPolicy
+	blackouts *BlackoutCalendar
+	freeze    *freezeInterlock
+	limit     *participationLimitOption
+	// maxLatency, if > 0, is the most latency the Faults may add to a request.
+	maxLatency time.Duration
+
+	// toggleMtx protects saved, the enabled state of the Faults before DisableAll.
+	toggleMtx sync.Mutex
+	saved     map[*Fault]bool
+}
+
+// ManagerOption configures a Manager.
+type ManagerOption interface {
+	applyManager(m *Manager) error
+}
+
+func (o clockOption) applyManager(m *Manager) error {
+	m.clock = o.clock
+	return nil
+}
+
+// NewManager returns a Manager with no Faults.
+func NewManager(opts ...ManagerOption) (*Manager, error) {
+	// set defaults
+	m := &Manager{
+		clock: NewRealClock(),
+	}
+
+	// apply options
+	for _, opt := range opts {
+		err := opt.applyManager(m)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return m, nil
+}
+
+// Add adds Faults to the Manager. Faults run in order of priority, highest first, and then in the
+// order they are added, the first Fault being the outermost middleware. No Faults are added if any
+// is nil or has the name of a Fault that is already managed. Faults added to a Manager with
+// WithParticipationLimit start with a full budget.
+func (m *Manager) Add(faults ...*Fault) error {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	names := make(map[string]bool, len(m.faults)+len(faults))
+	for _, f := range m.faults {
+		names[f.Name()] = true
+	}
+	for _, f := range faults {
+		if f == nil {
+			return ErrNilFault
+		}
+		if names[f.Name()] {
+			return ErrDuplicateFault
+		}
+		names[f.Name()] = true
+	}
+
+	if m.limit != nil {
+		for _, f := range faults {
+			f.limit.Store(newParticipationLimit(m.limit, m.clock))
+		}
+	}
+
+	m.faults = append(m.faults, faults...)
+	sort.SliceStable(m.faults, func(i, j int) bool {
+		return m.faults[i].priority > m.faults[j].priority
+	})
+
+	return nil
+}
+
+// Remove removes the Fault with name and returns true if it was managed. A removed Fault is no
+// longer subject to the participation limit of the Manager.
+func (m *Manager) Remove(name string) bool {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	for idx, f := range m.faults {
+		if f.Name() == name {
+			if m.limit != nil {
+				f.limit.Store(nil)
+			}
+
+			m.faults = append(m.faults[:idx:idx], m.faults[idx+1:]...)
+			return true
+		}
+	}
+
+	return false
+}
+
+// Fault returns the Fault with name, or nil if it is not managed.
+func (m *Manager) Fault(name string) *Fault {
+	m.mtx.RLock()
+	defer m.mtx.RUnlock()
+
+	for _, f := range m.faults {
+		if f.Name() == name {
+			return f
+		}
+	}
+
+	return nil
+}
+
+// Faults returns the managed Faults in the order they run.
+func (m *Manager) Faults() []*Fault {
+	m.mtx.RLock()
+	defer m.mtx.RUnlock()
+
+	return append([]*Fault(nil), m.faults...)
+}
+
+// Handler runs the managed Faults on each request and then next. Unless the ConflictPolicy is
+// ConflictAll, every Fault evaluates the request before any of them run. During a Blackout no
+// Fault runs, and neither does one while its FreezeChecker reports a freeze.
+func (m *Manager) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if m.samples != nil {
+			m.samples.add(r, m.clock.Now())
+		}
+
+		r = m.withMaxAddedLatency(r)
+
+		faults := m.Faults()
+
+		if _, ok := m.Blackout(); ok {
+			skipAll(faults, r, next, SkipBlackout).ServeHTTP(w, r)
+			return
+		}
+
+		if _, ok := m.Freeze(r.Context()); ok {
+			skipAll(faults, r, next, SkipFreeze).ServeHTTP(w, r)
+			return
+		}
+
+		if m.conflicts != ConflictAll {
+			resolveConflicts(m.conflicts, faults, r, next).ServeHTTP(w, r)
+			return
+		}
+
+		h := next
+		for idx := len(faults) - 1; idx >= 0; idx-- {
+			h = faults[idx].Handler(h)
+		}
+
+		h.ServeHTTP(w, r)
+	})
+}
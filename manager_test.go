@@ -0,0 +1,147 @@
+package fault
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/github/go-fault/faulttest"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestNewManager tests NewManager.
+func TestNewManager(t *testing.T) {
+	t.Parallel()
+
+	clock := faulttest.NewClock(time.Time{})
+
+	tests := []struct {
+		name        string
+		giveOptions []ManagerOption
+		wantClock   Clock
+		wantSamples *requestSamples
+		wantErr     error
+	}{
+		{
+			name:      "defaults",
+			wantClock: NewRealClock(),
+		},
+		{
+			name: "options",
+			giveOptions: []ManagerOption{
+				WithClock(clock),
+				WithRequestSampling(2),
+			},
+			wantClock:   clock,
+			wantSamples: newRequestSamples(2),
+		},
+		{
+			name: "invalid sample size",
+			giveOptions: []ManagerOption{
+				WithRequestSampling(0),
+			},
+			wantErr: ErrInvalidSampleSize,
+		},
+		{
+			name: "invalid max added latency",
+			giveOptions: []ManagerOption{
+				WithMaxAddedLatency(0),
+			},
+			wantErr: ErrInvalidMaxAddedLatency,
+		},
+		{
+			name: "option error",
+			giveOptions: []ManagerOption{
+				withError(),
+			},
+			wantErr: errErrorOption,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			m, err := NewManager(tt.giveOptions...)
+
+			assert.Equal(t, tt.wantErr, err)
+			if tt.wantErr != nil {
+				assert.Nil(t, m)
+				return
+			}
+
+			assert.Equal(t, tt.wantClock, m.clock)
+			assert.Equal(t, tt.wantSamples, m.samples)
+			assert.Empty(t, m.Faults())
+		})
+	}
+}
+
+// TestManagerFaults tests adding, getting, and removing Faults.
+func TestManagerFaults(t *testing.T) {
+	t.Parallel()
+
+	a, _ := NewFault(newTestInjectorNoop(), WithName("a"))
+	b, _ := NewFault(newTestInjectorNoop(), WithName("b"))
+	c, _ := NewFault(newTestInjectorNoop(), WithName("c"))
+	dupe, _ := NewFault(newTestInjectorNoop(), WithName("a"))
+
+	m, err := NewManager()
+	assert.NoError(t, err)
+
+	assert.NoError(t, m.Add(a, b))
+	assert.Equal(t, ErrDuplicateFault, m.Add(c, dupe))
+	assert.Equal(t, ErrDuplicateFault, m.Add(c, c))
+	assert.Equal(t, ErrNilFault, m.Add(c, nil))
+	assert.Equal(t, []*Fault{a, b}, m.Faults())
+
+	assert.NoError(t, m.Add(c))
+	assert.Equal(t, []*Fault{a, b, c}, m.Faults())
+
+	assert.Equal(t, b, m.Fault("b"))
+	assert.Nil(t, m.Fault("missing"))
+
+	assert.True(t, m.Remove("b"))
+	assert.False(t, m.Remove("b"))
+	assert.Equal(t, []*Fault{a, c}, m.Faults())
+	assert.Nil(t, m.Fault("b"))
+}
+
+// TestManagerHandler tests that Manager.Handler runs the Faults in order.
+func TestManagerHandler(t *testing.T) {
+	t.Parallel()
+
+	var mtx sync.Mutex
+	var order []string
+	record := func(name string) *Fault {
+		f, _ := NewFault(newTestInjectorFunc(func(*Fault) {
+			mtx.Lock()
+			order = append(order, name)
+			mtx.Unlock()
+		}), WithName(name), WithEnabled(true), WithParticipation(1.0))
+		return f
+	}
+
+	m, err := NewManager()
+	assert.NoError(t, err)
+	assert.NoError(t, m.Add(record("first"), record("second")))
+
+	h := m.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(testHandlerCode)
+	}))
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+
+	assert.Equal(t, testHandlerCode, rr.Code)
+	assert.Equal(t, []string{"first", "second"}, order)
+
+	// Faults added later run on the next request
+	assert.NoError(t, m.Add(record("third")))
+	order = nil
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, []string{"first", "second", "third"}, order)
+}
@@ -0,0 +1,296 @@
+package fault
+
+import (
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// OptInHeader is the request header that opts a request into a Fault passed WithOptInHeader() without
+// a header of its own.
+const OptInHeader = "X-Fault-Inject"
+
+// RequestMatcher decides if a Fault should consider a request. Use it to target requests in ways the
+// allowlists and blocklists cannot, such as by API operation.
+type RequestMatcher interface {
+	// MatchRequest returns true if the Fault may run on r.
+	MatchRequest(r *http.Request) bool
+}
+
+// RequestMatcherFunc is a function that satisfies RequestMatcher.
+type RequestMatcherFunc func(r *http.Request) bool
+
+// MatchRequest returns f(r).
+func (f RequestMatcherFunc) MatchRequest(r *http.Request) bool {
+	return f(r)
+}
+
+type requestMatcherOption struct {
+	matcher RequestMatcher
+}
+
+func (o requestMatcherOption) applyFault(f *Fault) error {
+	f.matchers = append(f.matchers, o.matcher)
+	return nil
+}
+
+// WithRequestMatcher adds a RequestMatcher to the Fault. Requests must pass every RequestMatcher, as
+// well as the allowlists and blocklists, to be matched.
+func WithRequestMatcher(m RequestMatcher) Option {
+	return requestMatcherOption{m}
+}
+
+type requestExcluderOption struct {
+	matcher RequestMatcher
+}
+
+func (o requestExcluderOption) applyFault(f *Fault) error {
+	f.matchers = append(f.matchers, MatchNot(o.matcher))
+	return nil
+}
+
+// WithRequestExcluder excludes the requests m matches from the Fault. It is the opposite of
+// WithRequestMatcher, so that excluding a few endpoints does not mean listing all the others.
+func WithRequestExcluder(m RequestMatcher) Option {
+	return requestExcluderOption{m}
+}
+
+type optInHeaderOption struct {
+	key    string
+	values []string
+}
+
+func (o optInHeaderOption) applyFault(f *Fault) error {
+	key := o.key
+	if key == "" {
+		key = OptInHeader
+	}
+
+	f.matchers = append(f.matchers, MatchHeader(key, o.values...))
+
+	return nil
+}
+
+// WithOptInHeader only lets the Fault run on requests that opt in by sending header key, or
+// OptInHeader if key is empty. If values are set the header must hold one of them, compared
+// case-insensitively, such as the name of the Fault so that each experiment can be opted into
+// separately; otherwise any non-empty value opts in. Use it to run experiments in production on
+// synthetic or canary traffic only, and combine it with WithParticipation(1.0) to fault every request
+// that opts in.
+func WithOptInHeader(key string, values ...string) Option {
+	return optInHeaderOption{key: key, values: values}
+}
+
+// MatchHeader returns a RequestMatcher that matches requests with header key set to any of values,
+// compared case-insensitively. Without values, any non-empty value matches.
+func MatchHeader(key string, values ...string) RequestMatcher {
+	return RequestMatcherFunc(func(r *http.Request) bool {
+		v := r.Header.Get(key)
+		if len(values) == 0 {
+			return v != ""
+		}
+
+		for _, want := range values {
+			if strings.EqualFold(v, want) {
+				return true
+			}
+		}
+
+		return false
+	})
+}
+
+// MatchAny returns a RequestMatcher that matches requests any of ms matches. Use it to pass a list
+// of alternatives as a single RequestMatcher, since a Fault requires every RequestMatcher to match.
+func MatchAny(ms ...RequestMatcher) RequestMatcher {
+	return RequestMatcherFunc(func(r *http.Request) bool {
+		for _, m := range ms {
+			if m.MatchRequest(r) {
+				return true
+			}
+		}
+
+		return false
+	})
+}
+
+// MatchAll returns a RequestMatcher that matches requests every one of ms matches. Use it to nest a
+// conjunction inside MatchAny(), such as to target test accounts on one endpoint or synthetic
+// monitors on any.
+func MatchAll(ms ...RequestMatcher) RequestMatcher {
+	return RequestMatcherFunc(func(r *http.Request) bool {
+		for _, m := range ms {
+			if !m.MatchRequest(r) {
+				return false
+			}
+		}
+
+		return true
+	})
+}
+
+// MatchNot returns a RequestMatcher that matches requests m does not match.
+func MatchNot(m RequestMatcher) RequestMatcher {
+	return RequestMatcherFunc(func(r *http.Request) bool {
+		return !m.MatchRequest(r)
+	})
+}
+
+// MatchMethod returns a RequestMatcher that matches requests whose method is any of methods.
+// Methods are compared case-sensitively, as http methods are.
+func MatchMethod(methods ...string) RequestMatcher {
+	set := make(map[string]struct{}, len(methods))
+	for _, m := range methods {
+		set[m] = struct{}{}
+	}
+
+	return RequestMatcherFunc(func(r *http.Request) bool {
+		_, ok := set[r.Method]
+		return ok
+	})
+}
+
+// MatchPath returns a RequestMatcher that matches requests whose path is exactly any of paths.
+func MatchPath(paths ...string) RequestMatcher {
+	set := make(map[string]struct{}, len(paths))
+	for _, p := range paths {
+		set[p] = struct{}{}
+	}
+
+	return RequestMatcherFunc(func(r *http.Request) bool {
+		_, ok := set[r.URL.Path]
+		return ok
+	})
+}
+
+// MatchPathPrefix returns a RequestMatcher that matches requests whose path starts with any of
+// prefixes. End a prefix with a slash, like "/api/v1/", to match only the paths below it.
+func MatchPathPrefix(prefixes ...string) RequestMatcher {
+	return RequestMatcherFunc(func(r *http.Request) bool {
+		for _, p := range prefixes {
+			if strings.HasPrefix(r.URL.Path, p) {
+				return true
+			}
+		}
+
+		return false
+	})
+}
+
+// MatchPathRegexp returns a RequestMatcher that matches requests whose path matches re. Anchor re
+// with ^ and $ to match the whole path.
+func MatchPathRegexp(re *regexp.Regexp) RequestMatcher {
+	return RequestMatcherFunc(func(r *http.Request) bool {
+		return re.MatchString(r.URL.Path)
+	})
+}
+
+// requestHostname returns the lowercase Host of r without a port.
+func requestHostname(r *http.Request) string {
+	u := url.URL{Host: r.Host}
+	return strings.ToLower(u.Hostname())
+}
+
+// MatchHost returns a RequestMatcher that matches requests whose Host is any of hosts. The port of
+// the Host is ignored and hosts are compared case-insensitively. Use it to fault a single virtual
+// host of a server that serves several.
+func MatchHost(hosts ...string) RequestMatcher {
+	set := make(map[string]struct{}, len(hosts))
+	for _, h := range hosts {
+		set[strings.ToLower(h)] = struct{}{}
+	}
+
+	return RequestMatcherFunc(func(r *http.Request) bool {
+		_, ok := set[requestHostname(r)]
+		return ok
+	})
+}
+
+// MatchHostSuffix returns a RequestMatcher that matches requests whose Host ends with any of
+// suffixes, ignoring the port and case. Use a leading dot, like ".example.com", to match only the
+// subdomains of a domain.
+func MatchHostSuffix(suffixes ...string) RequestMatcher {
+	lower := make([]string, len(suffixes))
+	for idx, s := range suffixes {
+		lower[idx] = strings.ToLower(s)
+	}
+
+	return RequestMatcherFunc(func(r *http.Request) bool {
+		host := requestHostname(r)
+		for _, s := range lower {
+			if strings.HasSuffix(host, s) {
+				return true
+			}
+		}
+
+		return false
+	})
+}
+
+// MatchHostRegexp returns a RequestMatcher that matches requests whose Host matches re. The port is
+// removed and the Host lowercased before matching.
+func MatchHostRegexp(re *regexp.Regexp) RequestMatcher {
+	return RequestMatcherFunc(func(r *http.Request) bool {
+		return re.MatchString(requestHostname(r))
+	})
+}
+
+// MatchHeaderToken returns a RequestMatcher that matches requests whose header key lists any of
+// tokens. The header is read as a comma-separated list, like Accept-Encoding, over all of its
+// values. Tokens are compared case-insensitively without their parameters, and tokens with a
+// weight of q=0 are refused by the client so never match.
+func MatchHeaderToken(key string, tokens ...string) RequestMatcher {
+	set := make(map[string]struct{}, len(tokens))
+	for _, t := range tokens {
+		set[strings.ToLower(t)] = struct{}{}
+	}
+
+	return RequestMatcherFunc(func(r *http.Request) bool {
+		for _, v := range r.Header.Values(key) {
+			for _, elem := range strings.Split(v, ",") {
+				token, ok := headerToken(elem)
+				if !ok {
+					continue
+				}
+				if _, ok := set[token]; ok {
+					return true
+				}
+			}
+		}
+
+		return false
+	})
+}
+
+// MatchLanguage returns a RequestMatcher that matches requests whose Accept-Language lists any of
+// tags, such as a pseudo-locale like "en-XA" used by internal testers, so that visible faults only
+// reach them.
+func MatchLanguage(tags ...string) RequestMatcher {
+	return MatchHeaderToken("Accept-Language", tags...)
+}
+
+// headerToken returns the lowercase token of an element of a header list and false if the element
+// is empty or has a weight of q=0.
+func headerToken(elem string) (string, bool) {
+	parts := strings.Split(elem, ";")
+
+	token := strings.ToLower(strings.TrimSpace(parts[0]))
+	if token == "" {
+		return "", false
+	}
+
+	for _, param := range parts[1:] {
+		k, v, _ := strings.Cut(strings.TrimSpace(param), "=")
+		if !strings.EqualFold(k, "q") {
+			continue
+		}
+		q, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err == nil && q == 0 {
+			return "", false
+		}
+	}
+
+	return token, true
+}
@@ -0,0 +1,484 @@
+package fault
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestFaultRequestMatcher tests that Faults only run on requests every RequestMatcher matches.
+func TestFaultRequestMatcher(t *testing.T) {
+	t.Parallel()
+
+	match := RequestMatcherFunc(func(r *http.Request) bool { return true })
+	noMatch := RequestMatcherFunc(func(r *http.Request) bool { return false })
+
+	tests := []struct {
+		name         string
+		giveMatchers []RequestMatcher
+		wantCode     int
+	}{
+		{
+			name:         "no matchers",
+			giveMatchers: nil,
+			wantCode:     http.StatusInternalServerError,
+		},
+		{
+			name:         "match",
+			giveMatchers: []RequestMatcher{match},
+			wantCode:     http.StatusInternalServerError,
+		},
+		{
+			name:         "no match",
+			giveMatchers: []RequestMatcher{noMatch},
+			wantCode:     testHandlerCode,
+		},
+		{
+			name:         "all must match",
+			giveMatchers: []RequestMatcher{match, noMatch},
+			wantCode:     testHandlerCode,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			opts := []Option{WithEnabled(true), WithParticipation(1.0)}
+			for _, m := range tt.giveMatchers {
+				opts = append(opts, WithRequestMatcher(m))
+			}
+
+			f, err := NewFault(newTestInjector500s(), opts...)
+			assert.NoError(t, err)
+
+			rr := testRequest(t, f)
+			assert.Equal(t, tt.wantCode, rr.Code)
+		})
+	}
+}
+
+// TestFaultRequestExcluder tests that Faults do not run on requests a RequestExcluder matches.
+func TestFaultRequestExcluder(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		giveMatcher  RequestMatcher
+		giveExcluder RequestMatcher
+		wantCode     int
+	}{
+		{
+			name:         "excluded",
+			giveExcluder: MatchPath("/"),
+			wantCode:     testHandlerCode,
+		},
+		{
+			name:         "not excluded",
+			giveExcluder: MatchPath("/health"),
+			wantCode:     http.StatusInternalServerError,
+		},
+		{
+			name:         "matched and excluded",
+			giveMatcher:  MatchMethod(http.MethodGet),
+			giveExcluder: MatchPathPrefix("/"),
+			wantCode:     testHandlerCode,
+		},
+		{
+			name:         "matched and not excluded",
+			giveMatcher:  MatchMethod(http.MethodGet),
+			giveExcluder: MatchMethod(http.MethodPost),
+			wantCode:     http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			opts := []Option{WithEnabled(true), WithParticipation(1.0), WithRequestExcluder(tt.giveExcluder)}
+			if tt.giveMatcher != nil {
+				opts = append(opts, WithRequestMatcher(tt.giveMatcher))
+			}
+
+			f, err := NewFault(newTestInjector500s(), opts...)
+			assert.NoError(t, err)
+
+			rr := testRequest(t, f)
+			assert.Equal(t, tt.wantCode, rr.Code)
+		})
+	}
+}
+
+// TestFaultOptInHeader tests that Faults passed WithOptInHeader only run on requests that opt in.
+func TestFaultOptInHeader(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		giveKey     string
+		giveValues  []string
+		giveHeaders map[string]string
+		wantCode    int
+	}{
+		{
+			name:        "default header",
+			giveHeaders: map[string]string{OptInHeader: "true"},
+			wantCode:    http.StatusInternalServerError,
+		},
+		{
+			name:        "default header missing",
+			giveHeaders: map[string]string{"X-Canary": "true"},
+			wantCode:    testHandlerCode,
+		},
+		{
+			name:        "default header empty",
+			giveHeaders: map[string]string{OptInHeader: ""},
+			wantCode:    testHandlerCode,
+		},
+		{
+			name:        "custom header",
+			giveKey:     "X-Canary",
+			giveHeaders: map[string]string{"X-Canary": "1"},
+			wantCode:    http.StatusInternalServerError,
+		},
+		{
+			name:        "custom header ignores default",
+			giveKey:     "X-Canary",
+			giveHeaders: map[string]string{OptInHeader: "true"},
+			wantCode:    testHandlerCode,
+		},
+		{
+			name:        "value",
+			giveValues:  []string{"errors", "slow"},
+			giveHeaders: map[string]string{OptInHeader: "Errors"},
+			wantCode:    http.StatusInternalServerError,
+		},
+		{
+			name:        "other value",
+			giveValues:  []string{"errors"},
+			giveHeaders: map[string]string{OptInHeader: "slow"},
+			wantCode:    testHandlerCode,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			f, err := NewFault(newTestInjector500s(),
+				WithEnabled(true),
+				WithParticipation(1.0),
+				WithOptInHeader(tt.giveKey, tt.giveValues...),
+			)
+			assert.NoError(t, err)
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			for k, v := range tt.giveHeaders {
+				req.Header.Set(k, v)
+			}
+
+			rr := httptest.NewRecorder()
+			f.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(testHandlerCode)
+			})).ServeHTTP(rr, req)
+			assert.Equal(t, tt.wantCode, rr.Code)
+		})
+	}
+}
+
+// TestMatchPath tests MatchMethod, MatchPath, MatchPathPrefix, MatchPathRegexp, MatchAny, and
+// MatchNot.
+func TestMatchPath(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		giveMatcher RequestMatcher
+		giveMethod  string
+		giveTarget  string
+		want        bool
+	}{
+		{
+			name:        "method",
+			giveMatcher: MatchMethod(http.MethodPost, http.MethodPut),
+			giveMethod:  http.MethodPost,
+			want:        true,
+		},
+		{
+			name:        "method case",
+			giveMatcher: MatchMethod("post"),
+			giveMethod:  http.MethodPost,
+			want:        false,
+		},
+		{
+			name:        "method no match",
+			giveMatcher: MatchMethod(http.MethodPost),
+			giveMethod:  http.MethodGet,
+			want:        false,
+		},
+		{
+			name:        "path",
+			giveMatcher: MatchPath("/api/v1/payments", "/api/v1/refunds"),
+			giveTarget:  "/api/v1/payments?id=1",
+			want:        true,
+		},
+		{
+			name:        "path no match",
+			giveMatcher: MatchPath("/api/v1/payments"),
+			giveTarget:  "/api/v1/payments/1",
+			want:        false,
+		},
+		{
+			name:        "prefix",
+			giveMatcher: MatchPathPrefix("/api/v2/", "/api/v1/"),
+			giveTarget:  "/api/v1/payments/1",
+			want:        true,
+		},
+		{
+			name:        "prefix no match",
+			giveMatcher: MatchPathPrefix("/api/v1/"),
+			giveTarget:  "/api/v10/payments",
+			want:        false,
+		},
+		{
+			name:        "regexp",
+			giveMatcher: MatchPathRegexp(regexp.MustCompile(`^/api/v\d+/payments/\d+$`)),
+			giveTarget:  "/api/v1/payments/12",
+			want:        true,
+		},
+		{
+			name:        "regexp no match",
+			giveMatcher: MatchPathRegexp(regexp.MustCompile(`^/api/v\d+/payments/\d+$`)),
+			giveTarget:  "/api/v1/payments/abc",
+			want:        false,
+		},
+		{
+			name:        "any",
+			giveMatcher: MatchAny(MatchPath("/a"), MatchMethod(http.MethodDelete)),
+			giveMethod:  http.MethodDelete,
+			giveTarget:  "/b",
+			want:        true,
+		},
+		{
+			name:        "any no match",
+			giveMatcher: MatchAny(MatchPath("/a"), MatchMethod(http.MethodDelete)),
+			giveTarget:  "/b",
+			want:        false,
+		},
+		{
+			name:        "not",
+			giveMatcher: MatchNot(MatchPathPrefix("/internal/")),
+			giveTarget:  "/api/v1/payments",
+			want:        true,
+		},
+		{
+			name:        "not no match",
+			giveMatcher: MatchNot(MatchPathPrefix("/internal/")),
+			giveTarget:  "/internal/health",
+			want:        false,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			method, target := tt.giveMethod, tt.giveTarget
+			if method == "" {
+				method = http.MethodGet
+			}
+			if target == "" {
+				target = "/"
+			}
+
+			assert.Equal(t, tt.want, tt.giveMatcher.MatchRequest(httptest.NewRequest(method, target, nil)))
+		})
+	}
+}
+
+// TestMatchHost tests MatchHost, MatchHostSuffix, and MatchHostRegexp.
+func TestMatchHost(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		giveMatcher RequestMatcher
+		giveHost    string
+		want        bool
+	}{
+		{
+			name:        "exact",
+			giveMatcher: MatchHost("api.example.com", "example.org"),
+			giveHost:    "example.org",
+			want:        true,
+		},
+		{
+			name:        "exact with port",
+			giveMatcher: MatchHost("api.example.com"),
+			giveHost:    "api.example.com:8080",
+			want:        true,
+		},
+		{
+			name:        "exact case",
+			giveMatcher: MatchHost("API.example.com"),
+			giveHost:    "api.EXAMPLE.com",
+			want:        true,
+		},
+		{
+			name:        "exact ipv6",
+			giveMatcher: MatchHost("::1"),
+			giveHost:    "[::1]:8080",
+			want:        true,
+		},
+		{
+			name:        "exact no match",
+			giveMatcher: MatchHost("api.example.com"),
+			giveHost:    "www.example.com",
+			want:        false,
+		},
+		{
+			name:        "suffix",
+			giveMatcher: MatchHostSuffix(".example.org", ".example.com"),
+			giveHost:    "api.example.com:443",
+			want:        true,
+		},
+		{
+			name:        "suffix case",
+			giveMatcher: MatchHostSuffix(".Example.com"),
+			giveHost:    "API.EXAMPLE.COM",
+			want:        true,
+		},
+		{
+			name:        "suffix subdomains only",
+			giveMatcher: MatchHostSuffix(".example.com"),
+			giveHost:    "example.com",
+			want:        false,
+		},
+		{
+			name:        "suffix no match",
+			giveMatcher: MatchHostSuffix(".example.com"),
+			giveHost:    "example.org",
+			want:        false,
+		},
+		{
+			name:        "regexp",
+			giveMatcher: MatchHostRegexp(regexp.MustCompile(`^canary-\d+\.example\.com$`)),
+			giveHost:    "Canary-12.example.com:8080",
+			want:        true,
+		},
+		{
+			name:        "regexp no match",
+			giveMatcher: MatchHostRegexp(regexp.MustCompile(`^canary-\d+\.example\.com$`)),
+			giveHost:    "www.example.com",
+			want:        false,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest("GET", "/", nil)
+			req.Host = tt.giveHost
+
+			assert.Equal(t, tt.want, tt.giveMatcher.MatchRequest(req))
+		})
+	}
+}
+
+// TestMatchHeaderToken tests MatchHeaderToken and MatchLanguage.
+func TestMatchHeaderToken(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		giveMatcher RequestMatcher
+		giveHeader  []string
+		want        bool
+	}{
+		{
+			name:        "language",
+			giveMatcher: MatchLanguage("en-XA"),
+			giveHeader:  []string{"en-XA"},
+			want:        true,
+		},
+		{
+			name:        "language list",
+			giveMatcher: MatchLanguage("en-XA", "ar-XB"),
+			giveHeader:  []string{"fr-CH, ar-xb;q=0.9, en;q=0.8"},
+			want:        true,
+		},
+		{
+			name:        "language values",
+			giveMatcher: MatchLanguage("en-XA"),
+			giveHeader:  []string{"fr-CH", "EN-xa"},
+			want:        true,
+		},
+		{
+			name:        "language refused",
+			giveMatcher: MatchLanguage("en-XA"),
+			giveHeader:  []string{"en-US, en-XA;q=0.0"},
+			want:        false,
+		},
+		{
+			name:        "language invalid weight",
+			giveMatcher: MatchLanguage("en-XA"),
+			giveHeader:  []string{"en-XA;q=high"},
+			want:        true,
+		},
+		{
+			name:        "language no match",
+			giveMatcher: MatchLanguage("en-XA"),
+			giveHeader:  []string{"en-US, en;q=0.9"},
+			want:        false,
+		},
+		{
+			name:        "language prefix",
+			giveMatcher: MatchLanguage("en"),
+			giveHeader:  []string{"en-XA"},
+			want:        false,
+		},
+		{
+			name:        "no header",
+			giveMatcher: MatchLanguage("en-XA"),
+			giveHeader:  nil,
+			want:        false,
+		},
+		{
+			name:        "empty elements",
+			giveMatcher: MatchHeaderToken(testHeaderKey, ""),
+			giveHeader:  []string{" , ;q=1"},
+			want:        false,
+		},
+		{
+			name:        "token with parameters",
+			giveMatcher: MatchHeaderToken(testHeaderKey, "canary"),
+			giveHeader:  []string{"stable; team=web, Canary; team=ux"},
+			want:        true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest("GET", "/", nil)
+			for _, v := range tt.giveHeader {
+				req.Header.Add("Accept-Language", v)
+				req.Header.Add(testHeaderKey, v)
+			}
+
+			assert.Equal(t, tt.want, tt.giveMatcher.MatchRequest(req))
+		})
+	}
+}
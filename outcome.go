@@ -0,0 +1,236 @@
+package fault
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Outcome is what the clients of a group of requests saw: the status codes of the responses and
+// how long the requests took.
+type Outcome struct {
+	// Statuses counts the responses by status code. Requests whose handler panicked, such as
+	// those a RejectInjector aborted, are counted under 0.
+	Statuses map[int]int64 `json:"statuses"`
+	// Latency is the distribution of request durations.
+	Latency LatencyHistogram `json:"latency"`
+}
+
+// newOutcome returns an empty Outcome with the provided latency buckets.
+func newOutcome(buckets []time.Duration) Outcome {
+	return Outcome{
+		Statuses: map[int]int64{},
+		Latency:  newLatencyHistogram(buckets),
+	}
+}
+
+// observe adds a request with status code that took d.
+func (o *Outcome) observe(code int, d time.Duration) {
+	o.Statuses[code]++
+	o.Latency.observe(d)
+}
+
+// add adds the requests of other, which must have the same latency buckets.
+func (o *Outcome) add(other Outcome) {
+	for code, n := range other.Statuses {
+		o.Statuses[code] += n
+	}
+
+	for idx, n := range other.Latency.Counts {
+		o.Latency.Counts[idx] += n
+	}
+	o.Latency.Count += other.Latency.Count
+	o.Latency.Sum += other.Latency.Sum
+}
+
+// copy returns a deep copy of the Outcome.
+func (o Outcome) copy() Outcome {
+	statuses := make(map[int]int64, len(o.Statuses))
+	for code, n := range o.Statuses {
+		statuses[code] = n
+	}
+
+	return Outcome{
+		Statuses: statuses,
+		Latency:  o.Latency.copy(),
+	}
+}
+
+// Requests returns the number of requests.
+func (o Outcome) Requests() int64 {
+	return o.Latency.Count
+}
+
+// SuccessRate returns the share of requests that neither failed with a 5xx status code nor
+// panicked, or 0 if there are no requests.
+func (o Outcome) SuccessRate() float64 {
+	if o.Latency.Count == 0 {
+		return 0
+	}
+
+	var failed int64
+	for code, n := range o.Statuses {
+		if code == 0 || code >= http.StatusInternalServerError {
+			failed += n
+		}
+	}
+
+	return float64(o.Latency.Count-failed) / float64(o.Latency.Count)
+}
+
+// RouteOutcome holds the baseline and faulted Outcomes of a single route.
+type RouteOutcome struct {
+	// Baseline is the Outcome of requests the Injector did not run on.
+	Baseline Outcome `json:"baseline"`
+	// Faulted is the Outcome of requests the Injector ran on.
+	Faulted Outcome `json:"faulted"`
+}
+
+// OutcomeRecorder records the final status code and duration of every request that passes through
+// the Faults it is added to, per Fault and per route, keeping the baseline (not injected) and
+// faulted (injected) requests apart. Compare them to quantify the impact of an experiment on the
+// success rate without wiring a separate metrics middleware. The status and duration include
+// anything the Injector did, such as the error it wrote or the delay it added. Add an
+// OutcomeRecorder to one or more Faults with the WithOutcomeRecorder option.
+type OutcomeRecorder struct {
+	buckets []time.Duration
+	routeF  func(r *http.Request) string
+	clock   Clock
+
+	// mtx protects faults.
+	mtx    sync.Mutex
+	faults map[string]map[string]*RouteOutcome
+}
+
+// OutcomeRecorderOption configures an OutcomeRecorder.
+type OutcomeRecorderOption interface {
+	applyOutcomeRecorder(o *OutcomeRecorder) error
+}
+
+func (o bucketsOption) applyOutcomeRecorder(r *OutcomeRecorder) error {
+	buckets, err := o.validate()
+	if err != nil {
+		return err
+	}
+
+	r.buckets = buckets
+
+	return nil
+}
+
+func (o routeFuncOption) applyOutcomeRecorder(r *OutcomeRecorder) error {
+	r.routeF = o
+	return nil
+}
+
+func (o clockOption) applyOutcomeRecorder(r *OutcomeRecorder) error {
+	r.clock = o.clock
+	return nil
+}
+
+// NewOutcomeRecorder returns an OutcomeRecorder. It takes the same WithBuckets, WithRouteFunc, and
+// WithClock options as a LatencySampler.
+func NewOutcomeRecorder(opts ...OutcomeRecorderOption) (*OutcomeRecorder, error) {
+	// set defaults
+	r := &OutcomeRecorder{
+		buckets: defaultLatencyBuckets,
+		routeF:  func(r *http.Request) string { return r.URL.Path },
+		clock:   NewRealClock(),
+		faults:  make(map[string]map[string]*RouteOutcome),
+	}
+
+	// apply options
+	for _, opt := range opts {
+		err := opt.applyOutcomeRecorder(r)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return r, nil
+}
+
+// start returns a function, to be deferred, that records the Outcome of the request to the Fault
+// named fault once it is served, as written to sw. It recovers a panic to record it and panics
+// again.
+func (o *OutcomeRecorder) start(fault string, r *http.Request, injected bool, sw *statusWriter) func() {
+	begin := o.clock.Now()
+
+	return func() {
+		code := sw.code
+
+		p := recover()
+		if p != nil {
+			code = 0
+		}
+
+		o.observe(fault, o.routeF(r), injected, code, o.clock.Now().Sub(begin))
+
+		if p != nil {
+			panic(p)
+		}
+	}
+}
+
+// observe records a request of fault to route.
+func (o *OutcomeRecorder) observe(fault, route string, injected bool, code int, d time.Duration) {
+	o.mtx.Lock()
+	defer o.mtx.Unlock()
+
+	routes, ok := o.faults[fault]
+	if !ok {
+		routes = make(map[string]*RouteOutcome)
+		o.faults[fault] = routes
+	}
+
+	ro, ok := routes[route]
+	if !ok {
+		ro = &RouteOutcome{
+			Baseline: newOutcome(o.buckets),
+			Faulted:  newOutcome(o.buckets),
+		}
+		routes[route] = ro
+	}
+
+	if injected {
+		ro.Faulted.observe(code, d)
+	} else {
+		ro.Baseline.observe(code, d)
+	}
+}
+
+// Snapshot returns a copy of the Outcomes recorded for each route, keyed by the name of the Fault
+// and then by route.
+func (o *OutcomeRecorder) Snapshot() map[string]map[string]RouteOutcome {
+	o.mtx.Lock()
+	defer o.mtx.Unlock()
+
+	snap := make(map[string]map[string]RouteOutcome, len(o.faults))
+	for fault, routes := range o.faults {
+		snap[fault] = make(map[string]RouteOutcome, len(routes))
+		for route, ro := range routes {
+			snap[fault][route] = RouteOutcome{
+				Baseline: ro.Baseline.copy(),
+				Faulted:  ro.Faulted.copy(),
+			}
+		}
+	}
+
+	return snap
+}
+
+type outcomeRecorderOption struct {
+	recorder *OutcomeRecorder
+}
+
+func (o outcomeRecorderOption) applyFault(f *Fault) error {
+	f.outcomes = o.recorder
+	return nil
+}
+
+// WithOutcomeRecorder records the final status code and duration of every request the Fault sees
+// into the OutcomeRecorder. Share one OutcomeRecorder between the Faults of a Manager to compare
+// them.
+func WithOutcomeRecorder(r *OutcomeRecorder) Option {
+	return outcomeRecorderOption{r}
+}
@@ -0,0 +1,181 @@
+package fault
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/github/go-fault/faulttest"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestNewOutcomeRecorder tests NewOutcomeRecorder.
+func TestNewOutcomeRecorder(t *testing.T) {
+	t.Parallel()
+
+	clock := faulttest.NewClock(time.Time{})
+
+	tests := []struct {
+		name        string
+		giveOptions []OutcomeRecorderOption
+		wantBuckets []time.Duration
+		wantClock   Clock
+		wantErr     error
+	}{
+		{
+			name:        "defaults",
+			wantBuckets: defaultLatencyBuckets,
+			wantClock:   NewRealClock(),
+		},
+		{
+			name: "options",
+			giveOptions: []OutcomeRecorderOption{
+				WithBuckets([]time.Duration{time.Millisecond, time.Second}),
+				WithClock(clock),
+			},
+			wantBuckets: []time.Duration{time.Millisecond, time.Second},
+			wantClock:   clock,
+		},
+		{
+			name:        "invalid buckets",
+			giveOptions: []OutcomeRecorderOption{WithBuckets([]time.Duration{time.Second, time.Millisecond})},
+			wantErr:     ErrInvalidBuckets,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			o, err := NewOutcomeRecorder(tt.giveOptions...)
+
+			assert.Equal(t, tt.wantErr, err)
+			if tt.wantErr != nil {
+				assert.Nil(t, o)
+				return
+			}
+
+			assert.Equal(t, tt.wantBuckets, o.buckets)
+			assert.Equal(t, tt.wantClock, o.clock)
+			assert.Equal(t, "/orders", o.routeF(httptest.NewRequest(http.MethodGet, "/orders?id=1", nil)))
+			assert.Empty(t, o.Snapshot())
+		})
+	}
+}
+
+// TestOutcomeRecorderFault tests that Faults record the Outcomes of their requests.
+func TestOutcomeRecorderFault(t *testing.T) {
+	t.Parallel()
+
+	clock := faulttest.NewClock(time.Time{})
+	o, err := NewOutcomeRecorder(
+		WithClock(clock),
+		WithBuckets([]time.Duration{time.Second}),
+		WithRouteFunc(func(r *http.Request) string { return r.Method + " " + r.URL.Path }),
+	)
+	assert.NoError(t, err)
+
+	// every other request is injected
+	var rolls int
+	alternate := WithRandFloat32Func(func() float32 {
+		rolls++
+		return float32(rolls%2) * 0.9
+	})
+
+	errorsFault, err := NewFault(newTestInjector500s(), WithName("errors"), WithEnabled(true),
+		WithParticipation(0.5), alternate, WithOutcomeRecorder(o))
+	assert.NoError(t, err)
+
+	ri, err := NewRejectInjector()
+	assert.NoError(t, err)
+	rejectFault, err := NewFault(ri, WithName("reject"), WithEnabled(true), WithParticipation(1.0),
+		WithOutcomeRecorder(o))
+	assert.NoError(t, err)
+
+	h := errorsFault.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the handler can still reach the ResponseWriter it wraps
+		assert.True(t, errors.Is(http.NewResponseController(w).EnableFullDuplex(), http.ErrNotSupported))
+
+		clock.Advance(2 * time.Second)
+		w.WriteHeader(testHandlerCode)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+
+	for idx := 0; idx < 4; idx++ {
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/orders", nil))
+	}
+
+	assert.Panics(t, func() {
+		rejectFault.Handler(http.NotFoundHandler()).ServeHTTP(httptest.NewRecorder(),
+			httptest.NewRequest(http.MethodPost, "/orders", nil))
+	})
+
+	snap := o.Snapshot()
+	assert.Len(t, snap, 2)
+
+	errs := snap["errors"]["GET /orders"]
+	assert.Equal(t, map[int]int64{testHandlerCode: 2}, errs.Baseline.Statuses)
+	assert.Equal(t, LatencyHistogram{
+		Buckets: []time.Duration{time.Second},
+		Counts:  []int64{0, 2},
+		Count:   2,
+		Sum:     4 * time.Second,
+	}, errs.Baseline.Latency)
+	assert.Equal(t, map[int]int64{http.StatusInternalServerError: 2}, errs.Faulted.Statuses)
+	assert.Equal(t, int64(2), errs.Faulted.Requests())
+	assert.Equal(t, time.Duration(0), errs.Faulted.Latency.Sum)
+
+	rejects := snap["reject"]["POST /orders"]
+	assert.Equal(t, map[int]int64{0: 1}, rejects.Faulted.Statuses)
+	assert.Equal(t, int64(0), rejects.Baseline.Requests())
+
+	// a snapshot is a copy
+	errs.Baseline.Statuses[testHandlerCode] = 100
+	assert.Equal(t, int64(2), o.Snapshot()["errors"]["GET /orders"].Baseline.Statuses[testHandlerCode])
+}
+
+// TestOutcomeSuccessRate tests Outcome.SuccessRate.
+func TestOutcomeSuccessRate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		give map[int]int
+		want float64
+	}{
+		{
+			name: "no requests",
+			want: 0,
+		},
+		{
+			name: "all succeed",
+			give: map[int]int{http.StatusOK: 3, http.StatusNotFound: 1},
+			want: 1,
+		},
+		{
+			name: "errors and panics",
+			give: map[int]int{http.StatusOK: 2, http.StatusServiceUnavailable: 1, 0: 1},
+			want: 0.5,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			o := newOutcome(defaultLatencyBuckets)
+			for code, n := range tt.give {
+				for idx := 0; idx < n; idx++ {
+					o.observe(code, time.Millisecond)
+				}
+			}
+
+			assert.Equal(t, tt.want, o.SuccessRate())
+		})
+	}
+}
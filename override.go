@@ -0,0 +1,183 @@
+package fault
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// OverrideHeader is the request header that carries a signed Override.
+const OverrideHeader = "X-Fault-Override"
+
+var (
+	// ErrInvalidOverrideSecret when an Override is signed or checked with an empty secret.
+	ErrInvalidOverrideSecret = errors.New("override secret cannot be empty")
+	// ErrInvalidOverride when an Override has an unknown OverrideAction, is malformed, or its
+	// signature does not match.
+	ErrInvalidOverride = errors.New("override is malformed or its signature is invalid")
+	// ErrOverrideExpired when an Override is used after it expires.
+	ErrOverrideExpired = errors.New("override has expired")
+)
+
+// OverrideAction is what an Override does to the Faults it names.
+type OverrideAction string
+
+const (
+	// OverrideForce runs the Injector of the Fault on the request, even if the Fault is disabled,
+	// does not match the request, or does not select it for participation.
+	OverrideForce OverrideAction = "force"
+	// OverrideSuppress skips the Injector of the Fault on the request with SkipOverride.
+	OverrideSuppress OverrideAction = "suppress"
+)
+
+// Override forces or suppresses Faults on a single request, to reproduce a failure on demand or to
+// rule a Fault out while debugging. Send it signed in the OverrideHeader to a Fault built with
+// WithOverrideSecret(). Signing it with a secret keeps external clients from forging one.
+type Override struct {
+	// Action is what the Override does to the Faults.
+	Action OverrideAction
+	// Faults are the names of the Faults the Override applies to. Empty applies it to every Fault
+	// that accepts Overrides signed with the secret.
+	Faults []string
+	// Expires is when the Override stops being accepted, so a leaked header cannot be replayed for
+	// long.
+	Expires time.Time
+}
+
+// Sign returns the value of the OverrideHeader for o, signed with secret using HMAC-SHA256, such as
+// "force;faults=checkout-latency;expires=1700000000;sig=...". It returns ErrInvalidOverrideSecret if
+// secret is empty and ErrInvalidOverride if the OverrideAction is unknown.
+func (o Override) Sign(secret []byte) (string, error) {
+	if len(secret) == 0 {
+		return "", ErrInvalidOverrideSecret
+	}
+	if o.Action != OverrideForce && o.Action != OverrideSuppress {
+		return "", ErrInvalidOverride
+	}
+
+	names := make([]string, 0, len(o.Faults))
+	for _, name := range o.Faults {
+		names = append(names, url.QueryEscape(name))
+	}
+
+	payload := string(o.Action) + ";faults=" + strings.Join(names, ",") +
+		";expires=" + strconv.FormatInt(o.Expires.Unix(), 10)
+
+	return payload + ";sig=" + overrideSignature(payload, secret), nil
+}
+
+// ParseOverride returns the Override in v, the value of an OverrideHeader, if it was signed with
+// secret and has not expired at now. It returns ErrInvalidOverrideSecret if secret is empty,
+// ErrInvalidOverride if v is malformed or not signed with secret, and ErrOverrideExpired if the
+// Override expired.
+func ParseOverride(v string, secret []byte, now time.Time) (Override, error) {
+	if len(secret) == 0 {
+		return Override{}, ErrInvalidOverrideSecret
+	}
+
+	idx := strings.LastIndex(v, ";sig=")
+	if idx < 0 {
+		return Override{}, ErrInvalidOverride
+	}
+
+	payload, sig := v[:idx], v[idx+len(";sig="):]
+	if !hmac.Equal([]byte(sig), []byte(overrideSignature(payload, secret))) {
+		return Override{}, ErrInvalidOverride
+	}
+
+	parts := strings.Split(payload, ";")
+	if len(parts) != 3 || !strings.HasPrefix(parts[1], "faults=") || !strings.HasPrefix(parts[2], "expires=") {
+		return Override{}, ErrInvalidOverride
+	}
+
+	o := Override{Action: OverrideAction(parts[0])}
+	if o.Action != OverrideForce && o.Action != OverrideSuppress {
+		return Override{}, ErrInvalidOverride
+	}
+
+	if names := strings.TrimPrefix(parts[1], "faults="); names != "" {
+		for _, name := range strings.Split(names, ",") {
+			name, err := url.QueryUnescape(name)
+			if err != nil {
+				return Override{}, ErrInvalidOverride
+			}
+			o.Faults = append(o.Faults, name)
+		}
+	}
+
+	expires, err := strconv.ParseInt(strings.TrimPrefix(parts[2], "expires="), 10, 64)
+	if err != nil {
+		return Override{}, ErrInvalidOverride
+	}
+	o.Expires = time.Unix(expires, 0)
+
+	if !now.Before(o.Expires) {
+		return Override{}, ErrOverrideExpired
+	}
+
+	return o, nil
+}
+
+// overrideSignature returns the HMAC-SHA256 of payload with secret.
+func overrideSignature(payload string, secret []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	_, _ = mac.Write([]byte(payload))
+
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// appliesTo returns true if o applies to the Fault named name.
+func (o Override) appliesTo(name string) bool {
+	if len(o.Faults) == 0 {
+		return true
+	}
+
+	for _, n := range o.Faults {
+		if n == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+type overrideSecretOption []byte
+
+func (o overrideSecretOption) applyFault(f *Fault) error {
+	if len(o) == 0 {
+		return ErrInvalidOverrideSecret
+	}
+
+	f.overrideSecret = o
+	return nil
+}
+
+// WithOverrideSecret lets a request force or suppress the Fault with an Override signed with secret
+// in its OverrideHeader. Requests with a missing, forged, or expired Override are evaluated as
+// usual. A forced request is still limited by WithCooldown(), WithClientFairness(), WithInjectionRate(),
+// WithInjectionBudget(), and a Blackout of the Manager. Only enable it where reproducing failures
+// on demand is worth the risk, such as in staging, and keep the secret out of clients.
+func WithOverrideSecret(secret []byte) Option {
+	return overrideSecretOption(secret)
+}
+
+// override returns the OverrideAction of the valid Override r carries for f, if any.
+func (f *Fault) override(r *http.Request) (OverrideAction, bool) {
+	v := r.Header.Get(OverrideHeader)
+	if v == "" {
+		return "", false
+	}
+
+	o, err := ParseOverride(v, f.overrideSecret, f.now())
+	if err != nil || !o.appliesTo(f.name) {
+		return "", false
+	}
+
+	return o.Action, true
+}
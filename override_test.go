@@ -0,0 +1,310 @@
+package fault
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/github/go-fault/faulttest"
+	"github.com/stretchr/testify/assert"
+)
+
+var testOverrideSecret = []byte("secret")
+
+// testOverride returns o signed with testOverrideSecret.
+func testOverride(t *testing.T, o Override) string {
+	t.Helper()
+
+	v, err := o.Sign(testOverrideSecret)
+	assert.NoError(t, err)
+
+	return v
+}
+
+// TestOverrideSign tests Override.Sign.
+func TestOverrideSign(t *testing.T) {
+	t.Parallel()
+
+	expires := time.Unix(1700000000, 0)
+
+	tests := []struct {
+		name       string
+		giveO      Override
+		giveSecret []byte
+		wantPrefix string
+		wantErr    error
+	}{
+		{
+			name:       "force all",
+			giveO:      Override{Action: OverrideForce, Expires: expires},
+			giveSecret: testOverrideSecret,
+			wantPrefix: "force;faults=;expires=1700000000;sig=",
+		},
+		{
+			name:       "suppress named",
+			giveO:      Override{Action: OverrideSuppress, Faults: []string{"a", "b,c"}, Expires: expires},
+			giveSecret: testOverrideSecret,
+			wantPrefix: "suppress;faults=a,b%2Cc;expires=1700000000;sig=",
+		},
+		{
+			name:       "empty secret",
+			giveO:      Override{Action: OverrideForce, Expires: expires},
+			giveSecret: nil,
+			wantErr:    ErrInvalidOverrideSecret,
+		},
+		{
+			name:       "unknown action",
+			giveO:      Override{Action: "maybe", Expires: expires},
+			giveSecret: testOverrideSecret,
+			wantErr:    ErrInvalidOverride,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			v, err := tt.giveO.Sign(tt.giveSecret)
+
+			assert.Equal(t, tt.wantErr, err)
+			if tt.wantErr != nil {
+				assert.Empty(t, v)
+				return
+			}
+
+			assert.True(t, strings.HasPrefix(v, tt.wantPrefix), v)
+		})
+	}
+}
+
+// TestParseOverride tests ParseOverride.
+func TestParseOverride(t *testing.T) {
+	t.Parallel()
+
+	now := time.Unix(1700000000, 0)
+	valid := Override{Action: OverrideSuppress, Faults: []string{"a", "b,c"}, Expires: now.Add(time.Minute)}
+
+	tests := []struct {
+		name       string
+		giveV      string
+		giveSecret []byte
+		want       Override
+		wantErr    error
+	}{
+		{
+			name:       "valid",
+			giveV:      testOverride(t, valid),
+			giveSecret: testOverrideSecret,
+			want:       valid,
+		},
+		{
+			name:       "valid all",
+			giveV:      testOverride(t, Override{Action: OverrideForce, Expires: now.Add(time.Minute)}),
+			giveSecret: testOverrideSecret,
+			want:       Override{Action: OverrideForce, Expires: now.Add(time.Minute)},
+		},
+		{
+			name:       "empty secret",
+			giveV:      testOverride(t, valid),
+			giveSecret: []byte{},
+			wantErr:    ErrInvalidOverrideSecret,
+		},
+		{
+			name:       "wrong secret",
+			giveV:      testOverride(t, valid),
+			giveSecret: []byte("guess"),
+			wantErr:    ErrInvalidOverride,
+		},
+		{
+			name:       "tampered",
+			giveV:      strings.Replace(testOverride(t, valid), "suppress", "force", 1),
+			giveSecret: testOverrideSecret,
+			wantErr:    ErrInvalidOverride,
+		},
+		{
+			name:       "unsigned",
+			giveV:      "force;faults=;expires=1700000060",
+			giveSecret: testOverrideSecret,
+			wantErr:    ErrInvalidOverride,
+		},
+		{
+			name:       "expired",
+			giveV:      testOverride(t, Override{Action: OverrideForce, Expires: now}),
+			giveSecret: testOverrideSecret,
+			wantErr:    ErrOverrideExpired,
+		},
+		{
+			name:       "malformed fields",
+			giveV:      testSignedPayload("force;expires=1700000060"),
+			giveSecret: testOverrideSecret,
+			wantErr:    ErrInvalidOverride,
+		},
+		{
+			name:       "unknown action",
+			giveV:      testSignedPayload("maybe;faults=;expires=1700000060"),
+			giveSecret: testOverrideSecret,
+			wantErr:    ErrInvalidOverride,
+		},
+		{
+			name:       "malformed fault name",
+			giveV:      testSignedPayload("force;faults=%zz;expires=1700000060"),
+			giveSecret: testOverrideSecret,
+			wantErr:    ErrInvalidOverride,
+		},
+		{
+			name:       "malformed expiry",
+			giveV:      testSignedPayload("force;faults=;expires=soon"),
+			giveSecret: testOverrideSecret,
+			wantErr:    ErrInvalidOverride,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			o, err := ParseOverride(tt.giveV, tt.giveSecret, now)
+
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.want, o)
+		})
+	}
+}
+
+// testSignedPayload returns payload signed with testOverrideSecret, whether or not it is a valid
+// Override.
+func testSignedPayload(payload string) string {
+	return payload + ";sig=" + overrideSignature(payload, testOverrideSecret)
+}
+
+// TestWithOverrideSecret tests that a signed Override forces or suppresses a Fault on one request.
+func TestWithOverrideSecret(t *testing.T) {
+	t.Parallel()
+
+	clock := faulttest.NewClock(time.Unix(1700000000, 0))
+	expires := clock.Now().Add(time.Minute)
+
+	tests := []struct {
+		name         string
+		giveOptions  []Option
+		giveOverride string
+		giveRequests int
+		wantCode     int
+		wantSkipped  string
+	}{
+		{
+			name:         "force disabled",
+			giveOptions:  []Option{WithParticipation(0.0)},
+			giveOverride: testOverride(t, Override{Action: OverrideForce, Expires: expires}),
+			wantCode:     http.StatusInternalServerError,
+		},
+		{
+			name:         "force unmatched",
+			giveOptions:  []Option{WithEnabled(true), WithPathBlocklist([]string{"/"})},
+			giveOverride: testOverride(t, Override{Action: OverrideForce, Faults: []string{"f"}, Expires: expires}),
+			wantCode:     http.StatusInternalServerError,
+		},
+		{
+			name:         "force limited by budget",
+			giveOptions:  []Option{WithEnabled(true), WithInjectionBudget(1)},
+			giveOverride: testOverride(t, Override{Action: OverrideForce, Expires: expires}),
+			giveRequests: 2,
+			wantCode:     testHandlerCode,
+			wantSkipped:  "skipped_budget",
+		},
+		{
+			name:         "suppress",
+			giveOptions:  []Option{WithEnabled(true), WithParticipation(1.0)},
+			giveOverride: testOverride(t, Override{Action: OverrideSuppress, Expires: expires}),
+			wantCode:     testHandlerCode,
+			wantSkipped:  "skipped_override",
+		},
+		{
+			name:         "other fault",
+			giveOptions:  []Option{WithEnabled(true), WithParticipation(1.0)},
+			giveOverride: testOverride(t, Override{Action: OverrideSuppress, Faults: []string{"g"}, Expires: expires}),
+			wantCode:     http.StatusInternalServerError,
+		},
+		{
+			name:         "expired",
+			giveOptions:  []Option{WithEnabled(true), WithParticipation(1.0)},
+			giveOverride: testOverride(t, Override{Action: OverrideSuppress, Expires: clock.Now()}),
+			wantCode:     http.StatusInternalServerError,
+		},
+		{
+			name:         "forged",
+			giveOptions:  []Option{WithParticipation(0.0)},
+			giveOverride: "force;faults=;expires=1700000060;sig=forged",
+			wantCode:     testHandlerCode,
+			wantSkipped:  "skipped_disabled",
+		},
+		{
+			name:        "no override",
+			giveOptions: []Option{WithParticipation(0.0)},
+			wantCode:    testHandlerCode,
+			wantSkipped: "skipped_disabled",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			opts := append([]Option{WithName("f"), WithClock(clock), WithOverrideSecret(testOverrideSecret)},
+				tt.giveOptions...)
+			f, err := NewFault(newTestInjector500s(), opts...)
+			assert.NoError(t, err)
+
+			h := f.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(testHandlerCode)
+			}))
+
+			// only the last request is checked
+			var rr *httptest.ResponseRecorder
+			for n := 0; n < tt.giveRequests || n == 0; n++ {
+				req := httptest.NewRequest(http.MethodGet, "/", nil)
+				if tt.giveOverride != "" {
+					req.Header.Set(OverrideHeader, tt.giveOverride)
+				}
+
+				rr = httptest.NewRecorder()
+				h.ServeHTTP(rr, req)
+			}
+
+			assert.Equal(t, tt.wantCode, rr.Code)
+			if tt.wantSkipped != "" {
+				assert.Equal(t, int64(1), f.stats.counters()[tt.wantSkipped])
+			}
+		})
+	}
+}
+
+// TestWithOverrideSecretEmpty tests that WithOverrideSecret rejects an empty secret.
+func TestWithOverrideSecretEmpty(t *testing.T) {
+	t.Parallel()
+
+	f, err := NewFault(newTestInjectorNoop(), WithOverrideSecret(nil))
+	assert.Equal(t, ErrInvalidOverrideSecret, err)
+	assert.Nil(t, f)
+}
+
+// TestOverrideIgnoredWithoutSecret tests that a Fault without WithOverrideSecret ignores Overrides.
+func TestOverrideIgnoredWithoutSecret(t *testing.T) {
+	t.Parallel()
+
+	f, err := NewFault(newTestInjector500s())
+	assert.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(OverrideHeader, testOverride(t, Override{Action: OverrideForce, Expires: time.Now().Add(time.Hour)}))
+
+	ev := f.evaluate(req, f.injector.Load())
+	assert.False(t, ev.Injected)
+	assert.Equal(t, SkipDisabled, ev.SkipReason)
+	assert.Empty(t, ev.Override)
+}
@@ -0,0 +1,58 @@
+package fault
+
+import (
+	"net/http"
+)
+
+type participationKeyOption func(r *http.Request) string
+
+func (o participationKeyOption) applyFault(f *Fault) error {
+	keyF := o
+	if keyF == nil {
+		keyF = clientIP
+	}
+
+	f.participationKeyF = keyF
+
+	return nil
+}
+
+// WithParticipationKey replaces the random participation roll of each request with a hash of its
+// key, the name of the Fault, and its seed, so the same user or session consistently gets the
+// Injector or consistently does not. This makes a chaos test of a user journey reproducible and
+// keeps a page from half failing because some of its calls were faulted and others were not. key
+// returns the key of a request, such as HeaderKey("X-User-Id") or CookieKey("session"); a nil key
+// uses the IP of the client. Requests with an empty key roll randomly. A request ID set by
+// WithRequestIDSeed takes precedence over the key.
+func WithParticipationKey(key func(r *http.Request) string) Option {
+	return participationKeyOption(key)
+}
+
+// HeaderKey returns a key function for WithParticipationKey that returns the value of the header h.
+func HeaderKey(h string) func(r *http.Request) string {
+	return func(r *http.Request) string {
+		return r.Header.Get(h)
+	}
+}
+
+// CookieKey returns a key function for WithParticipationKey that returns the value of the cookie
+// named name.
+func CookieKey(name string) func(r *http.Request) string {
+	return func(r *http.Request) string {
+		c, err := r.Cookie(name)
+		if err != nil {
+			return ""
+		}
+
+		return c.Value
+	}
+}
+
+// participationKey returns the key that seeds the roll of r, or "" if there is none.
+func (f *Fault) participationKey(r *http.Request) string {
+	if f.participationKeyF == nil {
+		return ""
+	}
+
+	return f.participationKeyF(r)
+}
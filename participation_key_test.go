@@ -0,0 +1,152 @@
+package fault
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestParticipationKeyFuncs tests the key functions for WithParticipationKey.
+func TestParticipationKeyFuncs(t *testing.T) {
+	t.Parallel()
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.RemoteAddr = "192.0.2.1:1234"
+	r.Header.Set("X-User-Id", "alice")
+	r.AddCookie(&http.Cookie{Name: "session", Value: "s-1"})
+
+	tests := []struct {
+		name    string
+		giveKey func(r *http.Request) string
+		want    string
+	}{
+		{
+			name:    "header",
+			giveKey: HeaderKey("X-User-Id"),
+			want:    "alice",
+		},
+		{
+			name:    "missing header",
+			giveKey: HeaderKey("X-Tenant-Id"),
+		},
+		{
+			name:    "cookie",
+			giveKey: CookieKey("session"),
+			want:    "s-1",
+		},
+		{
+			name:    "missing cookie",
+			giveKey: CookieKey("theme"),
+		},
+		{
+			name: "client ip",
+			want: "192.0.2.1",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			f, err := NewFault(newTestInjectorNoop(), WithParticipationKey(tt.giveKey))
+			assert.NoError(t, err)
+
+			assert.Equal(t, tt.want, f.participationKey(r))
+		})
+	}
+}
+
+// TestWithParticipationKey tests that requests with the same key get the same decision, and that
+// requests without one roll randomly.
+func TestWithParticipationKey(t *testing.T) {
+	t.Parallel()
+
+	rolls := []float32{0.1, 0.9}
+	f, err := NewFault(newTestInjector500s(),
+		WithEnabled(true),
+		WithParticipation(0.5),
+		WithParticipationKey(HeaderKey("X-User-Id")),
+		WithRandFloat32Func(func() float32 {
+			rn := rolls[0]
+			rolls = rolls[1:]
+			return rn
+		}),
+	)
+	assert.NoError(t, err)
+
+	serve := func(user string) int {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if user != "" {
+			req.Header.Set("X-User-Id", user)
+		}
+
+		rr := httptest.NewRecorder()
+		f.Handler(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			w.WriteHeader(testHandlerCode)
+		})).ServeHTTP(rr, req)
+
+		return rr.Code
+	}
+
+	var injected int
+	for n := 0; n < 1000; n++ {
+		user := fmt.Sprintf("user-%d", n)
+
+		code := serve(user)
+		assert.Equal(t, code, serve(user), user)
+		assert.Equal(t, code, serve(user), user)
+
+		if code == http.StatusInternalServerError {
+			injected++
+		}
+	}
+
+	// the hash spreads keys evenly
+	assert.True(t, injected > 400 && injected < 600, injected)
+
+	assert.Equal(t, http.StatusInternalServerError, serve(""))
+	assert.Equal(t, testHandlerCode, serve(""))
+}
+
+// TestWithParticipationKeyEvaluation tests that the Evaluation of a request records the key that
+// seeded its roll, and that a request ID takes precedence over it.
+func TestWithParticipationKeyEvaluation(t *testing.T) {
+	t.Parallel()
+
+	reporter := newTestEvaluationReporter()
+	f, err := NewFault(newTestInjector500s(),
+		WithEnabled(true),
+		WithParticipation(1.0),
+		WithParticipationKey(nil),
+		WithRequestIDSeed("X-Request-Id"),
+		WithReporter(reporter),
+		WithTracing(true),
+	)
+	assert.NoError(t, err)
+
+	h := f.Handler(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {}))
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.RemoteAddr = "192.0.2.1:1234"
+	h.ServeHTTP(httptest.NewRecorder(), r)
+
+	ev := <-reporter.evaluations
+	assert.True(t, ev.Injected)
+	assert.Equal(t, "192.0.2.1", ev.Key)
+	assert.Equal(t, "", ev.RequestID)
+	assert.Equal(t, uint64(0), ev.Rolls)
+
+	_, rn := f.rollKey("192.0.2.1")
+	assert.Equal(t, rn, ev.Roll)
+
+	r.Header.Set("X-Request-Id", "req-1")
+	h.ServeHTTP(httptest.NewRecorder(), r)
+
+	ev = <-reporter.evaluations
+	assert.Equal(t, "", ev.Key)
+	assert.Equal(t, "req-1", ev.RequestID)
+}
@@ -0,0 +1,124 @@
+package fault
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+)
+
+// ParticipationStrategy decides which requests a Fault runs its Injector on, in place of the random
+// roll against the participation. It is asked only about requests that passed every other check of
+// the Fault, and must be safe for concurrent use.
+type ParticipationStrategy interface {
+	// Participate returns true if the Injector should run against r. p is the participation of the
+	// Fault as it currently applies, including a FractionalPercent and any ramp-down, in [0.0,1.0].
+	Participate(r *http.Request, p float32) bool
+}
+
+// ParticipationStrategyFunc is a function that satisfies ParticipationStrategy.
+type ParticipationStrategyFunc func(r *http.Request, p float32) bool
+
+// Participate returns f(r, p).
+func (f ParticipationStrategyFunc) Participate(r *http.Request, p float32) bool {
+	return f(r, p)
+}
+
+type participationStrategyOption struct {
+	strategy ParticipationStrategy
+}
+
+func (o participationStrategyOption) applyFault(f *Fault) error {
+	f.strategy = o.strategy
+	return nil
+}
+
+// WithParticipationStrategy replaces the random participation roll of each request with s, such as
+// EveryNth(10) to run the Injector on exactly every 10th request. A request ID set by
+// WithRequestIDSeed or a key set by WithParticipationKey takes precedence over s. A nil s keeps the
+// random roll.
+func WithParticipationStrategy(s ParticipationStrategy) Option {
+	return participationStrategyOption{s}
+}
+
+// everyNth is a ParticipationStrategy that selects every nth request.
+type everyNth struct {
+	n     uint64
+	count atomic.Uint64
+}
+
+// EveryNth returns a ParticipationStrategy that selects the nth request it is asked about, then the
+// 2nth, and so on, regardless of the participation. It returns ErrInvalidInterval if n is 0.
+// Share one between Faults to count their requests together.
+func EveryNth(n uint64) (ParticipationStrategy, error) {
+	if n == 0 {
+		return nil, ErrInvalidInterval
+	}
+
+	return &everyNth{n: n}, nil
+}
+
+// Participate returns true for every nth request.
+func (s *everyNth) Participate(r *http.Request, p float32) bool {
+	return s.count.Add(1)%s.n == 0
+}
+
+// String returns a summary of the strategy, such as "every_nth(10)".
+func (s *everyNth) String() string {
+	return "every_nth(" + strconv.FormatUint(s.n, 10) + ")"
+}
+
+// evenParticipation is a ParticipationStrategy that spreads selections evenly.
+type evenParticipation struct {
+	// total is the total participation of every request so far, in parts per million.
+	total atomic.Uint64
+}
+
+// EvenParticipation returns a ParticipationStrategy that selects exactly the participation of
+// requests, spread as evenly as possible instead of at random. It adds up the participation of
+// each request and selects a request whenever the sum passes a whole number, so a participation of
+// 0.1 selects every 10th request and 0.25 every 4th, and a change of participation takes effect
+// from the next request.
+func EvenParticipation() ParticipationStrategy {
+	return &evenParticipation{}
+}
+
+// Participate returns true when the participation of r brings the sum to the next whole number.
+func (s *evenParticipation) Participate(r *http.Request, p float32) bool {
+	if p <= 0 {
+		return false
+	}
+
+	ppm := uint64(math.Round(float64(p) * float64(PerMillion)))
+	total := s.total.Add(ppm)
+
+	return total/uint64(PerMillion) > (total-ppm)/uint64(PerMillion)
+}
+
+// String returns "even".
+func (s *evenParticipation) String() string {
+	return "even"
+}
+
+// currentParticipation returns the participation as the Fault applies it: the FractionalPercent if
+// one was set, scaled down during a ramp-down.
+func (f *Fault) currentParticipation() float32 {
+	p := f.participation.Load()
+	if ppm := f.perMillion.Load(); ppm > 0 {
+		p = float32(ppm-1) / float32(PerMillion)
+	}
+
+	if f.transitions != nil {
+		s := f.transitions.scale()
+		if s <= 0 {
+			return 0
+		}
+		p *= s
+	}
+
+	if p > 1.0 {
+		return 1.0
+	}
+
+	return p
+}
@@ -0,0 +1,145 @@
+package fault
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testParticipations returns the decisions of s for n requests at participation p.
+func testParticipations(s ParticipationStrategy, p float32, n int) []bool {
+	got := make([]bool, 0, n)
+	for idx := 0; idx < n; idx++ {
+		got = append(got, s.Participate(httptest.NewRequest(http.MethodGet, "/", nil), p))
+	}
+
+	return got
+}
+
+// TestEveryNth tests EveryNth.
+func TestEveryNth(t *testing.T) {
+	t.Parallel()
+
+	_, err := EveryNth(0)
+	assert.Equal(t, ErrInvalidInterval, err)
+
+	s, err := EveryNth(3)
+	assert.NoError(t, err)
+	assert.Equal(t, "every_nth(3)", s.(*everyNth).String())
+
+	assert.Equal(t, []bool{false, false, true, false, false, true, false}, testParticipations(s, 0.0, 7))
+}
+
+// TestEvenParticipation tests that EvenParticipation selects exactly the participation of requests,
+// spread evenly.
+func TestEvenParticipation(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		give   float32
+		giveN  int
+		wantAt []int
+	}{
+		{
+			name:   "tenth",
+			give:   0.1,
+			giveN:  30,
+			wantAt: []int{9, 19, 29},
+		},
+		{
+			name:   "quarter",
+			give:   0.25,
+			giveN:  8,
+			wantAt: []int{3, 7},
+		},
+		{
+			name:   "all",
+			give:   1.0,
+			giveN:  3,
+			wantAt: []int{0, 1, 2},
+		},
+		{
+			name:  "none",
+			give:  0.0,
+			giveN: 10,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var got []int
+			for idx, ok := range testParticipations(EvenParticipation(), tt.give, tt.giveN) {
+				if ok {
+					got = append(got, idx)
+				}
+			}
+			assert.Equal(t, tt.wantAt, got)
+		})
+	}
+}
+
+// TestWithParticipationStrategy tests that a Fault asks its ParticipationStrategy instead of
+// rolling, with its current participation, and only about requests that pass its other checks.
+func TestWithParticipationStrategy(t *testing.T) {
+	t.Parallel()
+
+	var gotP []float32
+	f, err := NewFault(newTestInjector500s(),
+		WithEnabled(true),
+		WithFractionalParticipation(PartsPerMillion(250000)),
+		WithPathBlocklist([]string{"/health"}),
+		WithParticipationStrategy(ParticipationStrategyFunc(func(r *http.Request, p float32) bool {
+			gotP = append(gotP, p)
+			return r.URL.Query().Get("fault") == "1"
+		})),
+		WithRandFloat32Func(func() float32 {
+			assert.Fail(t, "the strategy replaces the roll")
+			return 0
+		}),
+	)
+	assert.NoError(t, err)
+
+	h := f.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	serve := func(target string) int {
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, target, nil))
+		return rr.Code
+	}
+
+	assert.Equal(t, http.StatusInternalServerError, serve("/?fault=1"))
+	assert.Equal(t, http.StatusOK, serve("/"))
+	assert.Equal(t, http.StatusOK, serve("/health?fault=1"))
+	assert.Equal(t, []float32{0.25, 0.25}, gotP)
+
+	ev := f.evaluate(httptest.NewRequest(http.MethodGet, "/", nil), f.injector.Load())
+	assert.Equal(t, SkipParticipation, ev.SkipReason)
+}
+
+// TestWithParticipationStrategyKey tests that a participation key takes precedence over a
+// ParticipationStrategy.
+func TestWithParticipationStrategyKey(t *testing.T) {
+	t.Parallel()
+
+	s, err := EveryNth(1)
+	assert.NoError(t, err)
+
+	f, err := NewFault(newTestInjector500s(),
+		WithEnabled(true),
+		WithParticipation(0.0),
+		WithParticipationKey(HeaderKey("X-User-Id")),
+		WithParticipationStrategy(s),
+	)
+	assert.NoError(t, err)
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("X-User-Id", "alice")
+	st := f.injector.Load()
+	assert.False(t, f.evaluate(r, st).Injected)
+	assert.True(t, f.evaluate(httptest.NewRequest(http.MethodGet, "/", nil), st).Injected)
+}
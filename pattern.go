@@ -0,0 +1,130 @@
+package fault
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+)
+
+var (
+	// ErrInvalidPattern when a pattern is not a valid net/http ServeMux pattern.
+	ErrInvalidPattern = errors.New("invalid pattern")
+)
+
+// patternWildcardRegexp finds the named wildcards of a pattern, such as {id} and {path...}. The
+// {$} end anchor has no name.
+var patternWildcardRegexp = regexp.MustCompile(`\{([^}.$]+)(?:\.\.\.)?\}`) //nolint:gochecknoglobals
+
+// PatternMatcher is a RequestMatcher that matches requests against a net/http ServeMux pattern,
+// such as "GET /users/{id}" or "api.example.com/files/{path...}", using the same rules as a
+// ServeMux. It can also extract the values of the wildcards of the pattern, so a Fault in front of
+// your ServeMux can target requests the way your routes see them.
+type PatternMatcher struct {
+	pattern   string
+	mux       *http.ServeMux
+	wildcards []string
+	conds     []func(values map[string]string) bool
+}
+
+// NewPatternMatcher returns a PatternMatcher for pattern. It returns an error wrapping
+// ErrInvalidPattern if a ServeMux would refuse pattern.
+func NewPatternMatcher(pattern string) (m *PatternMatcher, err error) {
+	m = &PatternMatcher{
+		pattern: pattern,
+		mux:     http.NewServeMux(),
+	}
+
+	for _, match := range patternWildcardRegexp.FindAllStringSubmatch(pattern, -1) {
+		m.wildcards = append(m.wildcards, match[1])
+	}
+
+	// a ServeMux panics on invalid patterns
+	defer func() {
+		if rec := recover(); rec != nil {
+			m, err = nil, fmt.Errorf("%w: %v", ErrInvalidPattern, rec)
+		}
+	}()
+	m.mux.Handle(pattern, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*w.(*patternWriter).values = m.pathValues(r)
+	}))
+
+	return m, nil
+}
+
+// Where returns a copy of the PatternMatcher that also requires cond to return true for the values
+// of the wildcards of matched requests. For example, to target only some users:
+//
+//	m, _ := fault.NewPatternMatcher("GET /users/{id}")
+//	m = m.Where(func(v map[string]string) bool { return v["id"] == "42" })
+func (m *PatternMatcher) Where(cond func(values map[string]string) bool) *PatternMatcher {
+	c := *m
+	c.conds = append(append([]func(map[string]string) bool(nil), m.conds...), cond)
+
+	return &c
+}
+
+// MatchRequest returns true if r matches the pattern and every condition added by Where.
+func (m *PatternMatcher) MatchRequest(r *http.Request) bool {
+	values, ok := m.PathValues(r)
+	if !ok {
+		return false
+	}
+
+	for _, cond := range m.conds {
+		if !cond(values) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// PathValues returns the values of the wildcards of the pattern in r, keyed by wildcard name, and
+// false if r does not match the pattern.
+func (m *PatternMatcher) PathValues(r *http.Request) (map[string]string, bool) {
+	var values map[string]string
+
+	// a ServeMux records the matched pattern on the request, so give it a shallow copy
+	m.mux.ServeHTTP(&patternWriter{values: &values}, r.WithContext(r.Context()))
+
+	return values, values != nil
+}
+
+// Pattern returns the pattern of the PatternMatcher.
+func (m *PatternMatcher) Pattern() string {
+	return m.pattern
+}
+
+// pathValues returns the values of the wildcards in r, a request routed by m.mux.
+func (m *PatternMatcher) pathValues(r *http.Request) map[string]string {
+	values := make(map[string]string, len(m.wildcards))
+	for _, name := range m.wildcards {
+		values[name] = r.PathValue(name)
+	}
+
+	return values
+}
+
+// patternWriter discards the response of a ServeMux and receives the values of a matched request.
+type patternWriter struct {
+	values *map[string]string
+	header http.Header
+}
+
+// Header returns a header that is discarded.
+func (w *patternWriter) Header() http.Header {
+	if w.header == nil {
+		w.header = make(http.Header)
+	}
+
+	return w.header
+}
+
+// Write discards b.
+func (w *patternWriter) Write(b []byte) (int, error) {
+	return len(b), nil
+}
+
+// WriteHeader discards the status code.
+func (w *patternWriter) WriteHeader(code int) {}
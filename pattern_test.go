@@ -0,0 +1,248 @@
+package fault
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestNewPatternMatcher tests NewPatternMatcher.
+func TestNewPatternMatcher(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		give          string
+		wantWildcards []string
+		wantErr       error
+	}{
+		{
+			name: "path",
+			give: "/users/",
+		},
+		{
+			name:          "method and wildcards",
+			give:          "GET /users/{id}/files/{path...}",
+			wantWildcards: []string{"id", "path"},
+		},
+		{
+			name:          "end anchor",
+			give:          "example.com/users/{id}/{$}",
+			wantWildcards: []string{"id"},
+		},
+		{
+			name:    "empty",
+			give:    "",
+			wantErr: ErrInvalidPattern,
+		},
+		{
+			name:    "duplicate wildcard",
+			give:    "/users/{id}/{id}",
+			wantErr: ErrInvalidPattern,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			m, err := NewPatternMatcher(tt.give)
+
+			if tt.wantErr != nil {
+				assert.True(t, errors.Is(err, tt.wantErr))
+				assert.Nil(t, m)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.give, m.Pattern())
+			assert.Equal(t, tt.wantWildcards, m.wildcards)
+		})
+	}
+}
+
+// TestPatternMatcherPathValues tests PatternMatcher.PathValues and PatternMatcher.MatchRequest.
+func TestPatternMatcherPathValues(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		givePattern string
+		giveMethod  string
+		giveURL     string
+		want        map[string]string
+		wantOk      bool
+	}{
+		{
+			name:        "wildcards",
+			givePattern: "GET /users/{id}/files/{path...}",
+			giveMethod:  http.MethodGet,
+			giveURL:     "/users/42/files/a/b.txt",
+			want:        map[string]string{"id": "42", "path": "a/b.txt"},
+			wantOk:      true,
+		},
+		{
+			name:        "get matches head",
+			givePattern: "GET /users/{id}",
+			giveMethod:  http.MethodHead,
+			giveURL:     "/users/42",
+			want:        map[string]string{"id": "42"},
+			wantOk:      true,
+		},
+		{
+			name:        "other method",
+			givePattern: "GET /users/{id}",
+			giveMethod:  http.MethodPost,
+			giveURL:     "/users/42",
+			wantOk:      false,
+		},
+		{
+			name:        "other path",
+			givePattern: "GET /users/{id}",
+			giveMethod:  http.MethodGet,
+			giveURL:     "/users/42/files",
+			wantOk:      false,
+		},
+		{
+			name:        "redirect to trailing slash",
+			givePattern: "/users/",
+			giveMethod:  http.MethodGet,
+			giveURL:     "/users",
+			wantOk:      false,
+		},
+		{
+			name:        "subtree without wildcards",
+			givePattern: "/users/",
+			giveMethod:  http.MethodGet,
+			giveURL:     "/users/42",
+			want:        map[string]string{},
+			wantOk:      true,
+		},
+		{
+			name:        "host",
+			givePattern: "api.example.com/",
+			giveMethod:  http.MethodGet,
+			giveURL:     "http://www.example.com/",
+			wantOk:      false,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			m, err := NewPatternMatcher(tt.givePattern)
+			assert.NoError(t, err)
+
+			r := httptest.NewRequest(tt.giveMethod, tt.giveURL, nil)
+			values, ok := m.PathValues(r)
+
+			assert.Equal(t, tt.want, values)
+			assert.Equal(t, tt.wantOk, ok)
+			assert.Equal(t, tt.wantOk, m.MatchRequest(r))
+			assert.Empty(t, r.PathValue("id"))
+		})
+	}
+}
+
+// TestPatternMatcherWhere tests that PatternMatcher.Where adds conditions on the wildcards.
+func TestPatternMatcherWhere(t *testing.T) {
+	t.Parallel()
+
+	m, err := NewPatternMatcher("/orgs/{org}/users/{id}")
+	assert.NoError(t, err)
+
+	org := m.Where(func(v map[string]string) bool { return v["org"] == "github" })
+	user := org.Where(func(v map[string]string) bool { return v["id"] == "42" })
+
+	tests := []struct {
+		name     string
+		giveURL  string
+		wantM    bool
+		wantOrg  bool
+		wantUser bool
+	}{
+		{
+			name:     "both",
+			giveURL:  "/orgs/github/users/42",
+			wantM:    true,
+			wantOrg:  true,
+			wantUser: true,
+		},
+		{
+			name:     "other user",
+			giveURL:  "/orgs/github/users/7",
+			wantM:    true,
+			wantOrg:  true,
+			wantUser: false,
+		},
+		{
+			name:     "other org",
+			giveURL:  "/orgs/gitlab/users/42",
+			wantM:    true,
+			wantOrg:  false,
+			wantUser: false,
+		},
+		{
+			name:     "no match",
+			giveURL:  "/orgs/github",
+			wantM:    false,
+			wantOrg:  false,
+			wantUser: false,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := httptest.NewRequest(http.MethodGet, tt.giveURL, nil)
+
+			assert.Equal(t, tt.wantM, m.MatchRequest(r))
+			assert.Equal(t, tt.wantOrg, org.MatchRequest(r))
+			assert.Equal(t, tt.wantUser, user.MatchRequest(r))
+		})
+	}
+}
+
+// TestPatternMatcherFault tests a PatternMatcher in front of a ServeMux.
+func TestPatternMatcherFault(t *testing.T) {
+	t.Parallel()
+
+	m, err := NewPatternMatcher("DELETE /users/{id}")
+	assert.NoError(t, err)
+
+	ei, err := NewErrorInjector(http.StatusInternalServerError)
+	assert.NoError(t, err)
+	f, err := NewFault(ei, WithEnabled(true), WithParticipation(1.0), WithRequestMatcher(m))
+	assert.NoError(t, err)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-User", r.PathValue("id"))
+		w.WriteHeader(testHandlerCode)
+	})
+	h := f.Handler(mux)
+
+	for _, tt := range []struct {
+		method   string
+		wantCode int
+	}{
+		{http.MethodGet, testHandlerCode},
+		{http.MethodDelete, http.StatusInternalServerError},
+	} {
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(tt.method, "/users/42", nil))
+		assert.Equal(t, tt.wantCode, rr.Code)
+	}
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/users/42", nil))
+	assert.Equal(t, "42", rr.Header().Get("X-User"))
+}
@@ -0,0 +1,158 @@
+package fault
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+)
+
+const (
+	// PresetS3SlowDown models an object store shedding load with bursts of 503 SlowDown errors.
+	PresetS3SlowDown = "s3-slowdown"
+	// PresetDNSFlap models DNS resolution flapping, dropping every connection while it fails.
+	PresetDNSFlap = "dns-flap"
+	// PresetDatabaseFailover models the ~30s pause of a database failing over to a replica.
+	PresetDatabaseFailover = "database-failover"
+	// PresetRateLimitedAPI models a SaaS API whose per-minute quota runs out, answering 429 Too Many
+	// Requests with a Retry-After header until the quota resets.
+	PresetRateLimitedAPI = "rate-limited-api"
+)
+
+var (
+	// ErrUnknownPreset when a preset name is not one of Presets().
+	ErrUnknownPreset = errors.New("unknown preset")
+)
+
+// preset is a named timeline of Phases.
+type preset struct {
+	// repeat determines if the timeline starts over after the last Phase.
+	repeat bool
+	// phases returns the Phases configured by c.
+	phases func(c *presetConfig) ([]Phase, error)
+}
+
+// presets are the presets NewPreset accepts, by name. It is only read.
+var presets = map[string]preset{ //nolint:gochecknoglobals
+	PresetS3SlowDown: {
+		repeat: true,
+		phases: func(c *presetConfig) ([]Phase, error) {
+			ei, err := NewErrorInjector(http.StatusServiceUnavailable,
+				WithStatusText("SlowDown: Please reduce your request rate."))
+			return []Phase{
+				{Duration: 50 * time.Second},
+				{Name: "slowdown", Duration: 10 * time.Second, Injector: ei, Participation: 0.5},
+			}, err
+		},
+	},
+	PresetDNSFlap: {
+		repeat: true,
+		phases: func(c *presetConfig) ([]Phase, error) {
+			ri, err := NewRejectInjector()
+			return []Phase{
+				{Name: "resolving", Duration: 25 * time.Second},
+				{Name: "unresolvable", Duration: 5 * time.Second, Injector: ri, Participation: 1.0},
+			}, err
+		},
+	},
+	PresetDatabaseFailover: {
+		repeat: false,
+		phases: func(c *presetConfig) ([]Phase, error) {
+			si, err := NewSlowInjector(30*time.Second, WithClock(c.clock))
+			return []Phase{
+				{Name: "failover", Duration: 30 * time.Second, Injector: si, Participation: 1.0},
+			}, err
+		},
+	},
+	PresetRateLimitedAPI: {
+		repeat: true,
+		phases: func(c *presetConfig) ([]Phase, error) {
+			ei, err := NewErrorInjector(http.StatusTooManyRequests, WithErrorHeader("Retry-After", "15"))
+			return []Phase{
+				{Name: "within quota", Duration: 45 * time.Second},
+				{Name: "quota exhausted", Duration: 15 * time.Second, Injector: ei, Participation: 1.0},
+			}, err
+		},
+	},
+}
+
+// presetConfig holds the options of a preset.
+type presetConfig struct {
+	clock    Clock
+	reporter Reporter
+	randSeed int64
+}
+
+// PresetOption configures a preset.
+type PresetOption interface {
+	applyPreset(c *presetConfig) error
+}
+
+func (o clockOption) applyPreset(c *presetConfig) error {
+	c.clock = o.clock
+	return nil
+}
+
+func (o reporterOption) applyPreset(c *presetConfig) error {
+	c.reporter = o.reporter
+	return nil
+}
+
+func (o randSeedOption) applyPreset(c *presetConfig) error {
+	c.randSeed = int64(o)
+	return nil
+}
+
+// Presets returns the names of the presets NewPreset accepts, sorted.
+func Presets() []string {
+	names := make([]string, 0, len(presets))
+	for name := range presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// NewPreset returns an OutageInjector modeling a common failure of a third-party dependency, such
+// as PresetS3SlowDown or PresetDatabaseFailover. It returns an error wrapping ErrUnknownPreset if
+// name is not one of Presets().
+func NewPreset(name string, opts ...PresetOption) (*OutageInjector, error) {
+	p, ok := presets[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownPreset, name)
+	}
+
+	return p.build(opts...)
+}
+
+// build returns the OutageInjector of the preset.
+func (p preset) build(opts ...PresetOption) (*OutageInjector, error) {
+	// set defaults
+	c := &presetConfig{
+		clock:    NewRealClock(),
+		reporter: NewNoopReporter(),
+		randSeed: defaultRandSeed,
+	}
+
+	// apply options
+	for _, opt := range opts {
+		err := opt.applyPreset(c)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	phases, err := p.phases(c)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewOutageInjector(phases,
+		WithClock(c.clock),
+		WithReporter(c.reporter),
+		WithRandSeed(c.randSeed),
+		WithRepeat(p.repeat),
+	)
+}
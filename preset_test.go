@@ -0,0 +1,169 @@
+package fault
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/github/go-fault/faulttest"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestPresets tests Presets.
+func TestPresets(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, []string{
+		PresetDatabaseFailover,
+		PresetDNSFlap,
+		PresetRateLimitedAPI,
+		PresetS3SlowDown,
+	}, Presets())
+}
+
+// TestNewPreset tests NewPreset.
+func TestNewPreset(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		give        string
+		giveOptions []PresetOption
+		wantString  string
+		wantRepeat  bool
+		wantSeed    int64
+		wantErr     error
+	}{
+		{
+			name:       "s3 slowdown",
+			give:       PresetS3SlowDown,
+			wantString: "outage(healthy 50s, slowdown: error(503) 10s 50%)",
+			wantRepeat: true,
+			wantSeed:   defaultRandSeed,
+		},
+		{
+			name:        "dns flap",
+			give:        PresetDNSFlap,
+			giveOptions: []PresetOption{WithReporter(newTestReporter())},
+			wantString:  "outage(resolving: healthy 25s, unresolvable: reject 5s)",
+			wantRepeat:  true,
+			wantSeed:    defaultRandSeed,
+		},
+		{
+			name:        "database failover",
+			give:        PresetDatabaseFailover,
+			giveOptions: []PresetOption{WithRandSeed(42)},
+			wantString:  "outage(failover: slow(30s) 30s)",
+			wantRepeat:  false,
+			wantSeed:    42,
+		},
+		{
+			name:       "rate limited api",
+			give:       PresetRateLimitedAPI,
+			wantString: "outage(within quota: healthy 45s, quota exhausted: error(429) 15s)",
+			wantRepeat: true,
+			wantSeed:   defaultRandSeed,
+		},
+		{
+			name:    "unknown",
+			give:    "s4-slowdown",
+			wantErr: ErrUnknownPreset,
+		},
+		{
+			name:        "option error",
+			give:        PresetDNSFlap,
+			giveOptions: []PresetOption{withError()},
+			wantErr:     errErrorOption,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			oi, err := NewPreset(tt.give, tt.giveOptions...)
+
+			if tt.wantErr != nil {
+				assert.True(t, errors.Is(err, tt.wantErr))
+				assert.Nil(t, oi)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantString, oi.String())
+			assert.Equal(t, tt.wantRepeat, oi.repeat)
+			assert.Equal(t, tt.wantSeed, oi.Seed())
+			for _, opt := range tt.giveOptions {
+				if ro, ok := opt.(reporterOption); ok {
+					assert.Equal(t, ro.reporter, oi.Reporter())
+				}
+			}
+		})
+	}
+}
+
+// TestPresetPhasesError tests that building a preset returns the error of its Phases.
+func TestPresetPhasesError(t *testing.T) {
+	t.Parallel()
+
+	errPhases := errors.New("phases")
+	p := preset{phases: func(c *presetConfig) ([]Phase, error) { return nil, errPhases }}
+
+	oi, err := p.build()
+	assert.Equal(t, errPhases, err)
+	assert.Nil(t, oi)
+}
+
+// TestPresetRateLimitedAPI tests that the rate limited API preset runs out of quota every minute.
+func TestPresetRateLimitedAPI(t *testing.T) {
+	t.Parallel()
+
+	clock := faulttest.NewClock(time.Time{})
+	oi, err := NewPreset(PresetRateLimitedAPI, WithClock(clock))
+	assert.NoError(t, err)
+
+	h := oi.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(testHandlerCode)
+	}))
+	serve := func() *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+		return rr
+	}
+
+	for minute := 0; minute < 2; minute++ {
+		assert.Equal(t, testHandlerCode, serve().Code)
+
+		clock.Advance(45 * time.Second)
+		rr := serve()
+		assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+		assert.Equal(t, "15", rr.Header().Get("Retry-After"))
+
+		clock.Advance(15 * time.Second)
+	}
+}
+
+// TestPresetDatabaseFailover tests that the database failover preset pauses requests on its Clock.
+func TestPresetDatabaseFailover(t *testing.T) {
+	t.Parallel()
+
+	clock := faulttest.NewClock(time.Time{})
+	oi, err := NewPreset(PresetDatabaseFailover, WithClock(clock))
+	assert.NoError(t, err)
+
+	done := make(chan struct{})
+	go func() {
+		oi.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})).
+			ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+		close(done)
+	}()
+
+	clock.BlockUntil(1)
+	clock.Advance(30 * time.Second)
+	<-done
+
+	assert.Equal(t, -1, oi.Phase())
+}
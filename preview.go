@@ -0,0 +1,154 @@
+package fault
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrInvalidSampleSize when a sample size below 1 is provided.
+	ErrInvalidSampleSize = errors.New("sample size must be > 0")
+	// ErrNoSample when a preview is requested without any sampled requests.
+	ErrNoSample = errors.New("no requests have been sampled")
+)
+
+// Preview estimates the blast radius of a Fault: how many requests it would match and inject on.
+type Preview struct {
+	// Fault is the name of the Fault.
+	Fault string
+	// Sampled is the number of requests in the sample.
+	Sampled int
+	// Matched is the number of sampled requests the Fault would match. Requests must pass the
+	// allowlists and blocklists and be in the treatment cohort of any experiment.
+	Matched int
+	// Injected is the expected number of sampled requests the Injector would run on, Matched times
+	// the participation.
+	Injected float64
+	// Window is the time the sample covers.
+	Window time.Duration
+	// MatchedPerMinute is Matched scaled to one minute of Window. 0 if Window is 0.
+	MatchedPerMinute float64
+	// InjectedPerMinute is Injected scaled to one minute of Window. 0 if Window is 0.
+	InjectedPerMinute float64
+}
+
+// PreviewRequests estimates how f would act on sample, a set of requests received over window.
+// Whether f is enabled is ignored, so a Fault can be previewed before it is enabled. Requests are
+// only read, nothing is reported, and participation is not rolled.
+func PreviewRequests(f *Fault, sample []*http.Request, window time.Duration) Preview {
+	p := Preview{
+		Fault:   f.Name(),
+		Sampled: len(sample),
+		Window:  window,
+	}
+
+	for _, r := range sample {
+		if !f.checkAllowBlockLists(true, r) {
+			continue
+		}
+		if f.cohorts != nil {
+			if _, c, _ := f.cohorts.Assign(r); c != CohortTreatment {
+				continue
+			}
+		}
+		p.Matched++
+	}
+
+	p.Injected = float64(p.Matched) * float64(f.participation.Load())
+	if window > 0 {
+		p.MatchedPerMinute = float64(p.Matched) * float64(time.Minute) / float64(window)
+		p.InjectedPerMinute = p.Injected * float64(time.Minute) / float64(window)
+	}
+
+	return p
+}
+
+type requestSamplingOption int
+
+func (o requestSamplingOption) applyManager(m *Manager) error {
+	if o < 1 {
+		return ErrInvalidSampleSize
+	}
+	m.samples = newRequestSamples(int(o))
+	return nil
+}
+
+// WithRequestSampling makes the Manager keep the metadata (method, URL, headers, and remote
+// address) of the last n requests it handles so Faults can be previewed against live traffic.
+// Default off.
+func WithRequestSampling(n int) ManagerOption {
+	return requestSamplingOption(n)
+}
+
+// Preview estimates how f would act on the requests the Manager sampled. f does not need to be
+// managed. Returns ErrNoSample if request sampling is off or no requests have been handled.
+func (m *Manager) Preview(f *Fault) (Preview, error) {
+	if m.samples == nil {
+		return Preview{}, ErrNoSample
+	}
+
+	sample, first := m.samples.snapshot()
+	if len(sample) == 0 {
+		return Preview{}, ErrNoSample
+	}
+
+	return PreviewRequests(f, sample, m.clock.Now().Sub(first)), nil
+}
+
+// requestSamples is a ring buffer of recent request metadata.
+type requestSamples struct {
+	mtx   sync.Mutex
+	reqs  []*http.Request
+	times []time.Time
+	next  int
+	full  bool
+}
+
+// newRequestSamples returns requestSamples that hold n requests.
+func newRequestSamples(n int) *requestSamples {
+	return &requestSamples{
+		reqs:  make([]*http.Request, n),
+		times: make([]time.Time, n),
+	}
+}
+
+// add records the metadata of r, received at t, replacing the oldest sample if full.
+func (s *requestSamples) add(r *http.Request, t time.Time) {
+	u := *r.URL
+	sample := &http.Request{
+		Method:     r.Method,
+		URL:        &u,
+		Host:       r.Host,
+		Header:     r.Header.Clone(),
+		RemoteAddr: r.RemoteAddr,
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	s.reqs[s.next] = sample
+	s.times[s.next] = t
+	s.next = (s.next + 1) % len(s.reqs)
+	if s.next == 0 {
+		s.full = true
+	}
+}
+
+// snapshot returns the sampled requests and the time the oldest was received.
+func (s *requestSamples) snapshot() ([]*http.Request, time.Time) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if !s.full {
+		if s.next == 0 {
+			return nil, time.Time{}
+		}
+		return append([]*http.Request(nil), s.reqs[:s.next]...), s.times[0]
+	}
+
+	reqs := append(append([]*http.Request(nil), s.reqs[s.next:]...), s.reqs[:s.next]...)
+
+	return reqs, s.times[s.next]
+}
@@ -0,0 +1,183 @@
+package fault
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/github/go-fault/faulttest"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestPreviewRequests tests PreviewRequests.
+func TestPreviewRequests(t *testing.T) {
+	t.Parallel()
+
+	treatment, _ := NewCohortAssigner("exp", 1.0, WithUnitHeader("X-User"))
+	control, _ := NewCohortAssigner("exp", 0.0, WithUnitHeader("X-User"))
+
+	sample := []*http.Request{
+		httptest.NewRequest("GET", "/a", nil),
+		httptest.NewRequest("GET", "/a", nil),
+		httptest.NewRequest("GET", "/b", nil),
+		httptest.NewRequest("GET", "/c", nil),
+	}
+	sample[0].Header.Set("X-User", "user-1")
+
+	tests := []struct {
+		name        string
+		giveOptions []Option
+		giveWindow  time.Duration
+		want        Preview
+	}{
+		{
+			name: "all match",
+			giveOptions: []Option{
+				WithParticipation(0.5),
+			},
+			giveWindow: 30 * time.Second,
+			want: Preview{
+				Fault:             "p",
+				Sampled:           4,
+				Matched:           4,
+				Injected:          2,
+				Window:            30 * time.Second,
+				MatchedPerMinute:  8,
+				InjectedPerMinute: 4,
+			},
+		},
+		{
+			name: "allowlist",
+			giveOptions: []Option{
+				WithParticipation(1.0),
+				WithPathAllowlist([]string{"/a"}),
+			},
+			want: Preview{
+				Fault:    "p",
+				Sampled:  4,
+				Matched:  2,
+				Injected: 2,
+			},
+		},
+		{
+			name: "treatment cohort",
+			giveOptions: []Option{
+				WithParticipation(1.0),
+				WithCohortAssigner(treatment),
+			},
+			giveWindow: time.Minute,
+			want: Preview{
+				Fault:             "p",
+				Sampled:           4,
+				Matched:           1,
+				Injected:          1,
+				Window:            time.Minute,
+				MatchedPerMinute:  1,
+				InjectedPerMinute: 1,
+			},
+		},
+		{
+			name: "control cohort",
+			giveOptions: []Option{
+				WithParticipation(1.0),
+				WithCohortAssigner(control),
+			},
+			want: Preview{
+				Fault:   "p",
+				Sampled: 4,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			// a disabled Fault can be previewed
+			f, err := NewFault(newTestInjectorNoop(), append(tt.giveOptions, WithName("p"))...)
+			assert.NoError(t, err)
+
+			assert.Equal(t, tt.want, PreviewRequests(f, sample, tt.giveWindow))
+		})
+	}
+}
+
+// TestManagerPreview tests Manager.Preview against sampled requests.
+func TestManagerPreview(t *testing.T) {
+	t.Parallel()
+
+	clock := faulttest.NewClock(time.Time{})
+
+	f, err := NewFault(newTestInjectorNoop(),
+		WithName("p"),
+		WithParticipation(0.5),
+		WithPathAllowlist([]string{"/a"}),
+	)
+	assert.NoError(t, err)
+
+	off, err := NewManager()
+	assert.NoError(t, err)
+	_, err = off.Preview(f)
+	assert.Equal(t, ErrNoSample, err)
+
+	m, err := NewManager(WithClock(clock), WithRequestSampling(3))
+	assert.NoError(t, err)
+	_, err = m.Preview(f)
+	assert.Equal(t, ErrNoSample, err)
+
+	h := m.Handler(http.NotFoundHandler())
+	for _, path := range []string{"/b", "/a", "/a", "/a"} {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+		clock.Advance(10 * time.Second)
+	}
+
+	// only the last 3 requests are kept, received over 30 seconds
+	p, err := m.Preview(f)
+	assert.NoError(t, err)
+	assert.Equal(t, Preview{
+		Fault:             "p",
+		Sampled:           3,
+		Matched:           3,
+		Injected:          1.5,
+		Window:            30 * time.Second,
+		MatchedPerMinute:  6,
+		InjectedPerMinute: 3,
+	}, p)
+}
+
+// TestRequestSamples tests requestSamples.
+func TestRequestSamples(t *testing.T) {
+	t.Parallel()
+
+	start := time.Time{}
+	s := newRequestSamples(2)
+
+	reqs, first := s.snapshot()
+	assert.Empty(t, reqs)
+	assert.Equal(t, time.Time{}, first)
+
+	r := httptest.NewRequest("GET", "/a?x=1", nil)
+	r.Header.Set("X-Test", "test")
+	s.add(r, start)
+
+	reqs, first = s.snapshot()
+	assert.Len(t, reqs, 1)
+	assert.Equal(t, start, first)
+	assert.Equal(t, "/a?x=1", reqs[0].URL.RequestURI())
+	assert.Equal(t, "test", reqs[0].Header.Get("X-Test"))
+
+	// the sample is a copy
+	r.Header.Set("X-Test", "changed")
+	assert.Equal(t, "test", reqs[0].Header.Get("X-Test"))
+
+	s.add(httptest.NewRequest("GET", "/b", nil), start.Add(time.Second))
+	s.add(httptest.NewRequest("GET", "/c", nil), start.Add(2*time.Second))
+
+	reqs, first = s.snapshot()
+	assert.Len(t, reqs, 2)
+	assert.Equal(t, "/b", reqs[0].URL.Path)
+	assert.Equal(t, "/c", reqs[1].URL.Path)
+	assert.Equal(t, start.Add(time.Second), first)
+}
@@ -0,0 +1,127 @@
+package fault
+
+import (
+	"errors"
+	"net/http"
+)
+
+// ErrInvalidConflictPolicy when an unknown ConflictPolicy is passed.
+var ErrInvalidConflictPolicy = errors.New("invalid conflict policy")
+
+// ConflictPolicy decides which Faults of a Manager run when more than one matches a request.
+type ConflictPolicy int
+
+const (
+	// ConflictAll runs every Fault that decides to inject. This is the default.
+	ConflictAll ConflictPolicy = iota
+	// ConflictFirstWins runs only the first Fault, in priority order, that decides to inject.
+	ConflictFirstWins
+	// ConflictHighestPriority only considers the Faults with the highest priority among those that
+	// match the request. Faults with a lower priority are skipped even if the higher priority
+	// Faults are not selected by their participation.
+	ConflictHighestPriority
+)
+
+// String returns the name of the ConflictPolicy.
+func (p ConflictPolicy) String() string {
+	switch p {
+	case ConflictAll:
+		return "all"
+	case ConflictFirstWins:
+		return "first-wins"
+	case ConflictHighestPriority:
+		return "highest-priority"
+	default:
+		return "unknown"
+	}
+}
+
+type priorityOption int
+
+func (o priorityOption) applyFault(f *Fault) error {
+	f.priority = int(o)
+	return nil
+}
+
+// WithPriority sets the priority of the Fault in a Manager. Managed Faults run in order of priority,
+// highest first, and then in the order they were added. Default 0.
+func WithPriority(p int) Option {
+	return priorityOption(p)
+}
+
+// Priority returns the priority of the Fault.
+func (f *Fault) Priority() int {
+	return f.priority
+}
+
+type conflictPolicyOption ConflictPolicy
+
+func (o conflictPolicyOption) applyManager(m *Manager) error {
+	if o < conflictPolicyOption(ConflictAll) || o > conflictPolicyOption(ConflictHighestPriority) {
+		return ErrInvalidConflictPolicy
+	}
+
+	m.conflicts = ConflictPolicy(o)
+
+	return nil
+}
+
+// WithConflictPolicy sets how the Manager resolves more than one Fault matching a request. Default
+// ConflictAll.
+func WithConflictPolicy(p ConflictPolicy) ManagerOption {
+	return conflictPolicyOption(p)
+}
+
+// resolveConflicts evaluates r against faults, which are in priority order, and returns the
+// handlers of faults with the Evaluations p decided on. Faults p skips are marked SkipConflict.
+func resolveConflicts(p ConflictPolicy, faults []*Fault, r *http.Request, next http.Handler) http.Handler {
+	states := make([]*injectorState, len(faults))
+	evs := make([]Evaluation, len(faults))
+	for idx, f := range faults {
+		states[idx] = f.injector.Load()
+		evs[idx] = f.evaluate(r, states[idx])
+	}
+
+	switch p {
+	case ConflictFirstWins:
+		won := false
+		for idx := range evs {
+			if evs[idx].Injected && won {
+				skipConflict(&evs[idx])
+			}
+			won = won || evs[idx].Injected
+		}
+	case ConflictHighestPriority:
+		top, found := 0, false
+		for idx, f := range faults {
+			if evs[idx].Matched && (!found || f.priority > top) {
+				top, found = f.priority, true
+			}
+		}
+		for idx, f := range faults {
+			if evs[idx].Matched && f.priority < top {
+				skipConflict(&evs[idx])
+			}
+		}
+	}
+
+	h := next
+	for idx := len(faults) - 1; idx >= 0; idx-- {
+		h = evaluatedHandler(faults[idx], h, states[idx], evs[idx])
+	}
+
+	return h
+}
+
+// skipConflict marks ev as skipped because of a conflict with another Fault.
+func skipConflict(ev *Evaluation) {
+	ev.Injected = false
+	ev.SkipReason = SkipConflict
+}
+
+// evaluatedHandler returns a handler that serves f with an Evaluation that was already made.
+func evaluatedHandler(f *Fault, next http.Handler, st *injectorState, ev Evaluation) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f.serve(w, r, next, st, ev)
+	})
+}
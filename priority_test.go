@@ -0,0 +1,234 @@
+package fault
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestConflictPolicyString tests ConflictPolicy.String.
+func TestConflictPolicyString(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		give ConflictPolicy
+		want string
+	}{
+		{ConflictAll, "all"},
+		{ConflictFirstWins, "first-wins"},
+		{ConflictHighestPriority, "highest-priority"},
+		{ConflictPolicy(-1), "unknown"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.want, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, tt.give.String())
+		})
+	}
+}
+
+// TestWithConflictPolicy tests WithConflictPolicy.
+func TestWithConflictPolicy(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		give    ConflictPolicy
+		wantErr error
+	}{
+		{
+			name: "all",
+			give: ConflictAll,
+		},
+		{
+			name: "first wins",
+			give: ConflictFirstWins,
+		},
+		{
+			name: "highest priority",
+			give: ConflictHighestPriority,
+		},
+		{
+			name:    "below range",
+			give:    ConflictPolicy(-1),
+			wantErr: ErrInvalidConflictPolicy,
+		},
+		{
+			name:    "above range",
+			give:    ConflictHighestPriority + 1,
+			wantErr: ErrInvalidConflictPolicy,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			m, err := NewManager(WithConflictPolicy(tt.give))
+
+			assert.Equal(t, tt.wantErr, err)
+			if tt.wantErr == nil {
+				assert.Equal(t, tt.give, m.conflicts)
+			}
+		})
+	}
+}
+
+// TestManagerPriority tests that managed Faults are ordered by priority and then the order they
+// were added.
+func TestManagerPriority(t *testing.T) {
+	t.Parallel()
+
+	fault := func(name string, priority int) *Fault {
+		f, err := NewFault(newTestInjectorNoop(), WithName(name), WithPriority(priority))
+		assert.NoError(t, err)
+		return f
+	}
+
+	low := fault("low", -1)
+	first := fault("first", 0)
+	high := fault("high", 10)
+	second := fault("second", 0)
+
+	assert.Equal(t, 10, high.Priority())
+
+	m, err := NewManager()
+	assert.NoError(t, err)
+	assert.NoError(t, m.Add(low, first))
+	assert.NoError(t, m.Add(high, second))
+
+	assert.Equal(t, []*Fault{high, first, second, low}, m.Faults())
+}
+
+// testConflictFault describes a Fault for TestManagerConflictPolicy.
+type testConflictFault struct {
+	name     string
+	priority int
+	matched  bool
+	injected bool
+}
+
+// TestManagerConflictPolicy tests that the Manager runs the Faults its ConflictPolicy selects.
+func TestManagerConflictPolicy(t *testing.T) {
+	t.Parallel()
+
+	faults := []testConflictFault{
+		{name: "skipped", priority: 3, matched: true, injected: false},
+		{name: "high", priority: 2, matched: true, injected: true},
+		{name: "high-unmatched", priority: 2, matched: false, injected: true},
+		{name: "high-too", priority: 2, matched: true, injected: true},
+		{name: "low", priority: 1, matched: true, injected: true},
+	}
+
+	tests := []struct {
+		name        string
+		givePolicy  ConflictPolicy
+		giveFaults  []testConflictFault
+		wantRan     []string
+		wantSkipped []string
+	}{
+		{
+			name:       "all",
+			givePolicy: ConflictAll,
+			giveFaults: faults,
+			wantRan:    []string{"high", "high-too", "low"},
+		},
+		{
+			name:        "first wins",
+			givePolicy:  ConflictFirstWins,
+			giveFaults:  faults,
+			wantRan:     []string{"high"},
+			wantSkipped: []string{"high-too", "low"},
+		},
+		{
+			name:        "highest priority",
+			givePolicy:  ConflictHighestPriority,
+			giveFaults:  faults,
+			wantRan:     nil,
+			wantSkipped: []string{"high", "high-too", "low"},
+		},
+		{
+			name:        "highest priority matched",
+			givePolicy:  ConflictHighestPriority,
+			giveFaults:  faults[1:],
+			wantRan:     []string{"high", "high-too"},
+			wantSkipped: []string{"low"},
+		},
+		{
+			name:       "nothing matched",
+			givePolicy: ConflictHighestPriority,
+			giveFaults: faults[2:3],
+			wantRan:    nil,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var mtx sync.Mutex
+			var ran []string
+
+			m, err := NewManager(WithConflictPolicy(tt.givePolicy))
+			assert.NoError(t, err)
+
+			reporters := make(map[string]*testEvaluationReporter, len(tt.giveFaults))
+			for _, tf := range tt.giveFaults {
+				tf := tf
+				reporters[tf.name] = newTestEvaluationReporter()
+
+				participation := float32(0.0)
+				if tf.injected {
+					participation = 1.0
+				}
+				opts := []Option{
+					WithName(tf.name),
+					WithPriority(tf.priority),
+					WithEnabled(true),
+					WithParticipation(participation),
+					WithTracing(true),
+					WithReporter(reporters[tf.name]),
+				}
+				if !tf.matched {
+					opts = append(opts, WithPathBlocklist([]string{"/"}))
+				}
+
+				f, err := NewFault(newTestInjectorFunc(func(*Fault) {
+					mtx.Lock()
+					ran = append(ran, tf.name)
+					mtx.Unlock()
+				}), opts...)
+				assert.NoError(t, err)
+				assert.NoError(t, m.Add(f))
+			}
+
+			h := m.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(testHandlerCode)
+			}))
+
+			rr := httptest.NewRecorder()
+			h.ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+
+			assert.Equal(t, testHandlerCode, rr.Code)
+			assert.Equal(t, tt.wantRan, ran)
+
+			var skipped []string
+			for _, tf := range tt.giveFaults {
+				ev := <-reporters[tf.name].evaluations
+				if ev.SkipReason == SkipConflict {
+					assert.False(t, ev.Injected)
+					skipped = append(skipped, tf.name)
+				}
+			}
+			assert.Equal(t, tt.wantSkipped, skipped)
+		})
+	}
+}
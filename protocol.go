@@ -0,0 +1,320 @@
+package fault
+
+import (
+	"bytes"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"sync"
+)
+
+const (
+	// rejectALPNProtocol is offered instead of the real protocols when rejecting ALPN. No client
+	// asks for it, so negotiation fails.
+	rejectALPNProtocol = "go-fault-reject"
+
+	// maxRequestLine is how much of a connection ProtocolHTTP10 reads looking for the request line.
+	maxRequestLine = 8 << 10
+)
+
+var (
+	// ErrInvalidProtocolFault when an unknown ProtocolFault is provided.
+	ErrInvalidProtocolFault = errors.New("not a valid protocol fault")
+	// ErrProtocolTLSConfig when a ProtocolFault needs a tls.Config and none was provided, or
+	// cannot be used with one.
+	ErrProtocolTLSConfig = errors.New("tls config is required for ALPN faults and not supported for http/1.0 faults")
+)
+
+// ProtocolFault is a way of breaking the protocol negotiated on a connection.
+type ProtocolFault int
+
+const (
+	// ProtocolHTTP10 downgrades requests on the connection to HTTP/1.0, so the server responds
+	// with HTTP/1.0 and closes the connection after the response. Only plaintext listeners are
+	// supported.
+	ProtocolHTTP10 ProtocolFault = iota
+	// ProtocolNoHTTP2 stops the connection from negotiating HTTP/2 over ALPN. Requires
+	// WithTLSConfig.
+	ProtocolNoHTTP2
+	// ProtocolRejectALPN fails the TLS handshake of clients that use ALPN. Requires WithTLSConfig.
+	ProtocolRejectALPN
+)
+
+// String returns the name of the ProtocolFault.
+func (p ProtocolFault) String() string {
+	switch p {
+	case ProtocolHTTP10:
+		return "http1.0"
+	case ProtocolNoHTTP2:
+		return "no_http2"
+	case ProtocolRejectALPN:
+		return "reject_alpn"
+	default:
+		return fmt.Sprintf("ProtocolFault(%d)", int(p))
+	}
+}
+
+// ProtocolListener is a net.Listener that applies a ProtocolFault to a percent of the connections
+// it accepts. Unlike Injectors it works below http.Handler, so pass it to http.Server.Serve.
+type ProtocolListener struct {
+	net.Listener
+
+	fault         ProtocolFault
+	participation float32
+
+	tlsConfig     *tls.Config
+	faultedConfig *tls.Config
+
+	randSeed int64
+	rand     *rand.Rand
+
+	// *rand.Rand is not thread safe. This mutex protects our random source
+	randMtx sync.Mutex
+
+	reporter Reporter
+
+	// decider, if set, decides which connections are faulted instead of participation.
+	decider *Fault
+}
+
+// ProtocolListenerOption configures a ProtocolListener.
+type ProtocolListenerOption interface {
+	applyProtocolListener(l *ProtocolListener) error
+}
+
+func (o participationOption) applyProtocolListener(l *ProtocolListener) error {
+	if o < 0.0 || o > 1.0 {
+		return ErrInvalidPercent
+	}
+	l.participation = float32(o)
+	return nil
+}
+
+func (o randSeedOption) applyProtocolListener(l *ProtocolListener) error {
+	l.randSeed = int64(o)
+	return nil
+}
+
+type tlsConfigOption struct {
+	config *tls.Config
+}
+
+func (o tlsConfigOption) applyProtocolListener(l *ProtocolListener) error {
+	l.tlsConfig = o.config
+	return nil
+}
+
+// WithTLSConfig makes the ProtocolListener terminate TLS with cfg. If cfg.NextProtos is empty
+// "h2" and "http/1.1" are offered, like http.Server.ServeTLS.
+func WithTLSConfig(cfg *tls.Config) ProtocolListenerOption {
+	return tlsConfigOption{cfg}
+}
+
+func (o reporterOption) applyProtocolListener(l *ProtocolListener) error {
+	l.reporter = o.reporter
+	return nil
+}
+
+type deciderOption struct {
+	fault *Fault
+}
+
+func (o deciderOption) applyProtocolListener(l *ProtocolListener) error {
+	if o.fault == nil {
+		return ErrNilFault
+	}
+
+	l.decider = o.fault
+	return nil
+}
+
+// WithFault makes the ProtocolListener fault the connections f decides to fault with Fault.Decide,
+// instead of a percent of them, so a connection gets the participation, matchers, schedule, rate
+// limit, and budget of f. Each connection is a Call with Protocol "tcp" from the address of the
+// client to the address of the listener. The Injector of f does not run, and Injected connections
+// are not counted as active.
+func WithFault(f *Fault) ProtocolListenerOption {
+	return deciderOption{f}
+}
+
+// NewProtocolListener returns a ProtocolListener that accepts connections from l. By default no
+// connections are faulted, pass WithParticipation to choose the percent of connections.
+func NewProtocolListener(l net.Listener, f ProtocolFault, opts ...ProtocolListenerOption) (*ProtocolListener, error) {
+	if f < ProtocolHTTP10 || f > ProtocolRejectALPN {
+		return nil, ErrInvalidProtocolFault
+	}
+
+	// set defaults
+	pl := &ProtocolListener{
+		Listener: l,
+		fault:    f,
+		randSeed: defaultRandSeed,
+		reporter: NewNoopReporter(),
+	}
+
+	// apply options
+	for _, opt := range opts {
+		err := opt.applyProtocolListener(pl)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if (pl.tlsConfig == nil) != (f == ProtocolHTTP10) {
+		return nil, ErrProtocolTLSConfig
+	}
+
+	if pl.tlsConfig != nil {
+		pl.tlsConfig = pl.tlsConfig.Clone()
+		if len(pl.tlsConfig.NextProtos) == 0 {
+			pl.tlsConfig.NextProtos = []string{"h2", "http/1.1"}
+		}
+
+		pl.faultedConfig = pl.tlsConfig.Clone()
+		switch f {
+		case ProtocolNoHTTP2:
+			pl.faultedConfig.NextProtos = nil
+			for _, p := range pl.tlsConfig.NextProtos {
+				if p != "h2" {
+					pl.faultedConfig.NextProtos = append(pl.faultedConfig.NextProtos, p)
+				}
+			}
+		case ProtocolRejectALPN:
+			pl.faultedConfig.NextProtos = []string{rejectALPNProtocol}
+		}
+	}
+
+	pl.rand = rand.New(rand.NewSource(pl.randSeed))
+
+	return pl, nil
+}
+
+// Accept waits for the next connection and decides if it should be faulted.
+func (l *ProtocolListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+
+	faulted := l.decide(conn)
+	if faulted {
+		go l.reporter.Report(l.String(), StateStarted)
+	}
+
+	switch {
+	case l.tlsConfig == nil && faulted:
+		return &http10Conn{Conn: conn}, nil
+	case l.tlsConfig == nil:
+		return conn, nil
+	case faulted:
+		return tls.Server(conn, l.faultedConfig), nil
+	default:
+		return tls.Server(conn, l.tlsConfig), nil
+	}
+}
+
+// decide decides if conn should be faulted, with the Fault set by WithFault or else randomly.
+func (l *ProtocolListener) decide(conn net.Conn) bool {
+	if l.decider == nil {
+		return l.participate()
+	}
+
+	ev, done := l.decider.Decide(Call{
+		Protocol:   "tcp",
+		Target:     l.Addr().String(),
+		RemoteAddr: conn.RemoteAddr().String(),
+	})
+	done()
+
+	return ev.Injected
+}
+
+// participate randomly decides (returns true) if the connection should be faulted.
+func (l *ProtocolListener) participate() bool {
+	l.randMtx.Lock()
+	rn := l.rand.Float32()
+	l.randMtx.Unlock()
+
+	return rn < l.participation
+}
+
+// Seed returns the seed of the ProtocolListener's random number generator.
+func (l *ProtocolListener) Seed() int64 {
+	return l.randSeed
+}
+
+// String returns a summary of the ProtocolListener, such as "protocol(no_http2)".
+func (l *ProtocolListener) String() string {
+	return fmt.Sprintf("protocol(%s)", l.fault)
+}
+
+// Reporter returns the Reporter of the ProtocolListener.
+func (l *ProtocolListener) Reporter() Reporter {
+	return l.reporter
+}
+
+// SetReporter replaces the Reporter of the ProtocolListener.
+func (l *ProtocolListener) SetReporter(r Reporter) {
+	l.reporter = r
+}
+
+// http10Conn is a net.Conn that rewrites the version of the first request line it reads to
+// HTTP/1.0. net/http servers answer an HTTP/1.0 request with an HTTP/1.0 response and then close the
+// connection, just like an old server or proxy would.
+type http10Conn struct {
+	net.Conn
+
+	pending []byte
+	started bool
+}
+
+// Read returns the rewritten request line and then reads from the connection.
+func (c *http10Conn) Read(p []byte) (int, error) {
+	if !c.started {
+		c.started = true
+
+		line, err := c.readRequestLine()
+		c.pending = downgradeRequestLine(line)
+		if len(c.pending) == 0 {
+			return 0, err
+		}
+	}
+
+	if len(c.pending) > 0 {
+		n := copy(p, c.pending)
+		c.pending = c.pending[n:]
+		return n, nil
+	}
+
+	return c.Conn.Read(p)
+}
+
+// readRequestLine reads until the end of the first line or maxRequestLine bytes.
+func (c *http10Conn) readRequestLine() ([]byte, error) {
+	var buf []byte
+	chunk := make([]byte, 512)
+	for {
+		n, err := c.Conn.Read(chunk)
+		buf = append(buf, chunk[:n]...)
+		if err != nil || bytes.IndexByte(buf, '\n') >= 0 || len(buf) >= maxRequestLine {
+			return buf, err
+		}
+	}
+}
+
+// downgradeRequestLine replaces HTTP/1.1 at the end of the first line of b with HTTP/1.0.
+func downgradeRequestLine(b []byte) []byte {
+	end := bytes.IndexByte(b, '\n')
+	if end < 0 {
+		return b
+	}
+
+	line := bytes.TrimSuffix(b[:end], []byte("\r"))
+	if bytes.HasSuffix(line, []byte("HTTP/1.1")) {
+		line[len(line)-1] = '0'
+	}
+
+	return b
+}
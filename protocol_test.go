@@ -0,0 +1,410 @@
+package fault
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io"
+	"io/ioutil"
+	"log"
+	"math/big"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testTLSConfig returns a tls.Config with a self signed certificate for 127.0.0.1.
+func testTLSConfig(t *testing.T) *tls.Config {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "go-fault"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.NoError(t, err)
+
+	return &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}},
+	}
+}
+
+// testProtocolServer serves a handler that writes the request protocol on a ProtocolListener.
+func testProtocolServer(t *testing.T, f ProtocolFault, opts ...ProtocolListenerOption) string {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+
+	pl, err := NewProtocolListener(ln, f, opts...)
+	assert.NoError(t, err)
+
+	srv := &http.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = io.WriteString(w, r.Proto)
+		}),
+		ErrorLog: log.New(ioutil.Discard, "", 0),
+	}
+	go func() { _ = srv.Serve(pl) }()
+	t.Cleanup(func() { _ = srv.Close() })
+
+	return ln.Addr().String()
+}
+
+// TestNewProtocolListener tests NewProtocolListener.
+func TestNewProtocolListener(t *testing.T) {
+	t.Parallel()
+
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	tests := []struct {
+		name             string
+		giveFault        ProtocolFault
+		giveOptions      []ProtocolListenerOption
+		wantParticipate  float32
+		wantProtos       []string
+		wantFaultedProto []string
+		wantErr          error
+	}{
+		{
+			name:      "http1.0",
+			giveFault: ProtocolHTTP10,
+			giveOptions: []ProtocolListenerOption{
+				WithParticipation(0.5),
+				WithRandSeed(100),
+				WithReporter(newTestReporter()),
+			},
+			wantParticipate: 0.5,
+		},
+		{
+			name:      "no http2 default protos",
+			giveFault: ProtocolNoHTTP2,
+			giveOptions: []ProtocolListenerOption{
+				WithTLSConfig(cfg),
+			},
+			wantProtos:       []string{"h2", "http/1.1"},
+			wantFaultedProto: []string{"http/1.1"},
+		},
+		{
+			name:      "reject alpn",
+			giveFault: ProtocolRejectALPN,
+			giveOptions: []ProtocolListenerOption{
+				WithTLSConfig(&tls.Config{NextProtos: []string{"h2"}}),
+			},
+			wantProtos:       []string{"h2"},
+			wantFaultedProto: []string{rejectALPNProtocol},
+		},
+		{
+			name:      "invalid fault",
+			giveFault: ProtocolFault(10),
+			wantErr:   ErrInvalidProtocolFault,
+		},
+		{
+			name:      "http1.0 with tls",
+			giveFault: ProtocolHTTP10,
+			giveOptions: []ProtocolListenerOption{
+				WithTLSConfig(cfg),
+			},
+			wantErr: ErrProtocolTLSConfig,
+		},
+		{
+			name:      "no http2 without tls",
+			giveFault: ProtocolNoHTTP2,
+			wantErr:   ErrProtocolTLSConfig,
+		},
+		{
+			name:      "invalid participation",
+			giveFault: ProtocolHTTP10,
+			giveOptions: []ProtocolListenerOption{
+				WithParticipation(1.1),
+			},
+			wantErr: ErrInvalidPercent,
+		},
+		{
+			name:      "nil fault",
+			giveFault: ProtocolHTTP10,
+			giveOptions: []ProtocolListenerOption{
+				WithFault(nil),
+			},
+			wantErr: ErrNilFault,
+		},
+		{
+			name:      "option error",
+			giveFault: ProtocolHTTP10,
+			giveOptions: []ProtocolListenerOption{
+				withError(),
+			},
+			wantErr: errErrorOption,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			pl, err := NewProtocolListener(nil, tt.giveFault, tt.giveOptions...)
+
+			assert.Equal(t, tt.wantErr, err)
+			if tt.wantErr != nil {
+				assert.Nil(t, pl)
+				return
+			}
+
+			assert.Equal(t, tt.wantParticipate, pl.participation)
+			if tt.wantProtos != nil {
+				assert.Equal(t, tt.wantProtos, pl.tlsConfig.NextProtos)
+				assert.Equal(t, tt.wantFaultedProto, pl.faultedConfig.NextProtos)
+			}
+		})
+	}
+
+	// the passed config is not changed
+	assert.Nil(t, cfg.NextProtos)
+}
+
+// TestProtocolListenerHTTP10 tests that ProtocolHTTP10 downgrades connections to HTTP/1.0.
+func TestProtocolListenerHTTP10(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name              string
+		giveParticipation float32
+		wantProto         string
+		wantClose         bool
+	}{
+		{
+			name:              "not faulted",
+			giveParticipation: 0.0,
+			wantProto:         "HTTP/1.1",
+			wantClose:         false,
+		},
+		{
+			name:              "faulted",
+			giveParticipation: 1.0,
+			wantProto:         "HTTP/1.0",
+			wantClose:         true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			addr := testProtocolServer(t, ProtocolHTTP10, WithParticipation(tt.giveParticipation))
+
+			client := &http.Client{Transport: &http.Transport{}}
+			resp, err := client.Get("http://" + addr + "/")
+			assert.NoError(t, err)
+			defer resp.Body.Close()
+
+			body, err := ioutil.ReadAll(resp.Body)
+			assert.NoError(t, err)
+
+			assert.Equal(t, tt.wantProto, resp.Proto)
+			assert.Equal(t, tt.wantProto, string(body))
+			assert.Equal(t, tt.wantClose, resp.Close)
+		})
+	}
+}
+
+// TestProtocolListenerWithFault tests that a ProtocolListener with a Fault faults the connections
+// the Fault decides to fault.
+func TestProtocolListenerWithFault(t *testing.T) {
+	t.Parallel()
+
+	calls := make(chan Call, 10)
+	f, err := NewFault(newTestInjectorNoop(),
+		WithEnabled(true),
+		WithParticipation(1.0),
+		WithInjectionBudget(1),
+		WithRequestMatcher(RequestMatcherFunc(func(r *http.Request) bool {
+			c, _ := CallFromRequest(r)
+			calls <- c
+			return true
+		})),
+	)
+	assert.NoError(t, err)
+
+	addr := testProtocolServer(t, ProtocolHTTP10, WithFault(f))
+
+	get := func() string {
+		client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+		resp, err := client.Get("http://" + addr + "/")
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+
+		return resp.Proto
+	}
+
+	// the budget of the Fault allows one faulted connection
+	assert.Equal(t, "HTTP/1.0", get())
+	assert.Equal(t, "HTTP/1.1", get())
+
+	assert.False(t, f.Enabled())
+	assert.Len(t, calls, 1)
+
+	c := <-calls
+	assert.Equal(t, "tcp", c.Protocol)
+	assert.Equal(t, addr, c.Target)
+	assert.Contains(t, c.RemoteAddr, "127.0.0.1:")
+}
+
+// TestProtocolListenerTLS tests the ALPN faults of ProtocolListener.
+func TestProtocolListenerTLS(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name              string
+		giveFault         ProtocolFault
+		giveParticipation float32
+		wantProto         string
+		wantErr           bool
+	}{
+		{
+			name:              "not faulted",
+			giveFault:         ProtocolNoHTTP2,
+			giveParticipation: 0.0,
+			wantProto:         "HTTP/2.0",
+		},
+		{
+			name:              "no http2",
+			giveFault:         ProtocolNoHTTP2,
+			giveParticipation: 1.0,
+			wantProto:         "HTTP/1.1",
+		},
+		{
+			name:              "reject alpn",
+			giveFault:         ProtocolRejectALPN,
+			giveParticipation: 1.0,
+			wantErr:           true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			addr := testProtocolServer(t, tt.giveFault,
+				WithTLSConfig(testTLSConfig(t)),
+				WithParticipation(tt.giveParticipation),
+			)
+
+			client := &http.Client{
+				Transport: &http.Transport{
+					TLSClientConfig:   &tls.Config{InsecureSkipVerify: true}, //nolint:gosec
+					ForceAttemptHTTP2: true,
+				},
+			}
+			resp, err := client.Get("https://" + addr + "/")
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			defer resp.Body.Close()
+
+			assert.Equal(t, tt.wantProto, resp.Proto)
+		})
+	}
+}
+
+// TestProtocolListenerAcceptError tests that ProtocolListener returns errors from Accept.
+func TestProtocolListenerAcceptError(t *testing.T) {
+	t.Parallel()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+
+	pl, err := NewProtocolListener(ln, ProtocolHTTP10)
+	assert.NoError(t, err)
+	assert.NoError(t, pl.Close())
+
+	conn, err := pl.Accept()
+	assert.Nil(t, conn)
+	assert.Error(t, err)
+}
+
+// TestHTTP10ConnRead tests http10Conn.Read.
+func TestHTTP10ConnRead(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		giveReads []string
+		want      string
+	}{
+		{
+			name:      "one read",
+			giveReads: []string{"GET / HTTP/1.1\r\nHost: a\r\n\r\n"},
+			want:      "GET / HTTP/1.0\r\nHost: a\r\n\r\n",
+		},
+		{
+			name:      "split request line",
+			giveReads: []string{"GET / HT", "TP/1.1\n", "Host: a\n\n"},
+			want:      "GET / HTTP/1.0\nHost: a\n\n",
+		},
+		{
+			name:      "already http1.0",
+			giveReads: []string{"GET / HTTP/1.0\r\n\r\n"},
+			want:      "GET / HTTP/1.0\r\n\r\n",
+		},
+		{
+			name:      "no line",
+			giveReads: []string{"GET / HTTP/1.1"},
+			want:      "GET / HTTP/1.1",
+		},
+		{
+			name:      "nothing",
+			giveReads: nil,
+			want:      "",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			client, server := net.Pipe()
+			go func() {
+				for _, r := range tt.giveReads {
+					_, _ = io.WriteString(client, r)
+				}
+				_ = client.Close()
+			}()
+
+			got, err := ioutil.ReadAll(&http10Conn{Conn: server})
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, string(got))
+		})
+	}
+}
+
+// TestProtocolFaultString tests ProtocolFault.String.
+func TestProtocolFaultString(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "http1.0", ProtocolHTTP10.String())
+	assert.Equal(t, "no_http2", ProtocolNoHTTP2.String())
+	assert.Equal(t, "reject_alpn", ProtocolRejectALPN.String())
+	assert.Equal(t, "ProtocolFault(7)", ProtocolFault(7).String())
+
+	pl, err := NewProtocolListener(nil, ProtocolHTTP10)
+	assert.NoError(t, err)
+	assert.Equal(t, "protocol(http1.0)", pl.String())
+}
@@ -1,5 +1,10 @@
 package fault
 
+import (
+	"net/http"
+	"reflect"
+)
+
 // Reporter receives events from faults to use for logging, stats, and other custom reporting.
 type Reporter interface {
 	Report(name string, state InjectorState)
@@ -16,11 +21,93 @@ func NewNoopReporter() *NoopReporter {
 // Report does nothing.
 func (r *NoopReporter) Report(name string, state InjectorState) {}
 
+// ReporterSetter is implemented by every Fault and Injector in this package that accepts a Reporter.
+// Custom Injectors may implement it so that they can be managed the same way, most simply by
+// embedding an InjectorReporter.
+type ReporterSetter interface {
+	// Reporter returns the current Reporter.
+	Reporter() Reporter
+	// SetReporter replaces the current Reporter. It is not safe to call while handling requests.
+	SetReporter(r Reporter)
+}
+
+// InjectorReporter gives a custom Injector the Reporter wiring of the Injectors in this package.
+// Embed it in the Injector to implement ReporterSetter, so that composite Injectors propagate their
+// Reporter to it, and wrap its handler with ReportHandler to report StateStarted and StateFinished.
+// The zero value reports to a NoopReporter.
+type InjectorReporter struct {
+	reporter Reporter
+}
+
+// Reporter returns the current Reporter.
+func (ir *InjectorReporter) Reporter() Reporter {
+	if ir.reporter == nil {
+		return NewNoopReporter()
+	}
+
+	return ir.reporter
+}
+
+// SetReporter replaces the current Reporter. It is not safe to call while handling requests.
+func (ir *InjectorReporter) SetReporter(r Reporter) {
+	ir.reporter = r
+}
+
+// ReportHandler returns a handler that reports StateStarted for name, runs h, and then reports
+// StateFinished. Reports are sent in new goroutines so a slow Reporter does not delay the request.
+func (ir *InjectorReporter) ReportHandler(name string, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reporter := ir.Reporter()
+		go reporter.Report(name, StateStarted)
+		h.ServeHTTP(w, r)
+		go reporter.Report(name, StateFinished)
+	})
+}
+
 // ReporterOption configures structs that accept a Reporter.
 type ReporterOption interface {
+	Option
+	ChainInjectorOption
+	RandomInjectorOption
 	RejectInjectorOption
 	ErrorInjectorOption
 	SlowInjectorOption
+	PartialResponseInjectorOption
+	JSONTruncateInjectorOption
+	CharsetInjectorOption
+	ProtocolListenerOption
+	TrailerInjectorOption
+	SessionInjectorOption
+	CSRFInjectorOption
+	FuzzInjectorOption
+	RerouteInjectorOption
+	SandboxInjectorOption
+	OutageInjectorOption
+	BrownoutInjectorOption
+	PresetOption
+	DistributionInjectorOption
+	WeightedLatencyInjectorOption
+	RampInjectorOption
+	HeadersOnlyInjectorOption
+	InterimInjectorOption
+	ContentTypeInjectorOption
+	HopByHopInjectorOption
+	SlowBodyInjectorOption
+	PanicInjectorOption
+	ResponseCorruptionInjectorOption
+	HeaderInjectorOption
+	ThrottleInjectorOption
+	ClientDelayInjectorOption
+	ConnectFailureInjectorOption
+	BandwidthLimitInjectorOption
+	ResourcePressureInjectorOption
+	ConditionalInjectorOption
+	ListenerOption
+	ExperimentOption
+	RequestTamperInjectorOption
+	DuplicateRequestInjectorOption
+	StreamInjectorOption
+	StubInjectorOption
 }
 
 // reporterOption holds our passed in Reporter.
@@ -32,3 +119,63 @@ type reporterOption struct {
 func WithReporter(r Reporter) ReporterOption {
 	return reporterOption{r}
 }
+
+type reporterPropagationOption bool
+
+func (o reporterPropagationOption) applyChainInjector(i *ChainInjector) error {
+	i.propagate = bool(o)
+	return nil
+}
+
+func (o reporterPropagationOption) applyRandomInjector(i *RandomInjector) error {
+	i.propagate = bool(o)
+	return nil
+}
+
+// WithReporterPropagation sets if a ChainInjector, RandomInjector, OutageInjector, or
+// ConditionalInjector gives its Reporter to each of its Injectors that implement ReporterSetter and
+// have no Reporter of their own. An Injector has no Reporter of its own if it has a NoopReporter or
+// the Reporter it was last given by propagation. Propagation happens when the composite Injector is
+// created and on every SetReporter.
+func WithReporterPropagation(p bool) CompositeOption {
+	return reporterPropagationOption(p)
+}
+
+// PropagateReporter sets r on each Injector in is that implements ReporterSetter and has a
+// NoopReporter or prev, the previous Reporter of their parent. Custom Injectors that combine other
+// Injectors can call it when they are created, with a nil prev, and from SetReporter, with the
+// Reporter being replaced, to propagate their Reporter like the composite Injectors of this package.
+func PropagateReporter(is []Injector, r, prev Reporter) {
+	for _, i := range is {
+		rs, ok := i.(ReporterSetter)
+		if !ok {
+			continue
+		}
+
+		current := rs.Reporter()
+		if isNoopReporter(current) || sameReporter(current, prev) {
+			rs.SetReporter(r)
+		}
+	}
+}
+
+// isNoopReporter returns true if r is nil or a NoopReporter.
+func isNoopReporter(r Reporter) bool {
+	if r == nil {
+		return true
+	}
+
+	_, ok := r.(*NoopReporter)
+
+	return ok
+}
+
+// sameReporter returns true if a and b are the same Reporter. Reporters that cannot be compared are
+// never the same.
+func sameReporter(a, b Reporter) bool {
+	if a == nil || b == nil || !reflect.TypeOf(a).Comparable() || !reflect.TypeOf(b).Comparable() {
+		return false
+	}
+
+	return a == b
+}
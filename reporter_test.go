@@ -0,0 +1,233 @@
+package fault
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestReporterSetter tests that every Fault, Injector, ProtocolListener, and Listener implements
+// ReporterSetter.
+func TestReporterSetter(t *testing.T) {
+	t.Parallel()
+
+	f, _ := NewFault(newTestInjectorNoop())
+	ci, _ := NewChainInjector([]Injector{newTestInjectorNoop()})
+	ri, _ := NewRandomInjector([]Injector{newTestInjectorNoop()})
+	rj, _ := NewRejectInjector()
+	ei, _ := NewErrorInjector(500)
+	sk, _ := NewStubInjector(200, "")
+	si, _ := NewSlowInjector(time.Second)
+	pi, _ := NewPartialResponseInjector(0)
+	ji, _ := NewJSONTruncateInjector(0)
+	cs, _ := NewCharsetInjector(CharsetLatin1)
+	pl, _ := NewProtocolListener(nil, ProtocolHTTP10)
+	fl, _ := NewListener(nil, WithDropConnections(0))
+	ti, _ := NewTrailerInjector()
+	ss, _ := NewSessionInjector([]string{"session"})
+	cf, _ := NewCSRFInjector()
+	fz, _ := NewFuzzInjector(nil)
+	rr, _ := NewRerouteInjector(&url.URL{Scheme: "http", Host: "replica"})
+	sb, _ := NewSandboxInjector(newTestInjectorNoop())
+	pn, _ := NewPanicInjector()
+	rc, _ := NewResponseCorruptionInjector(CorruptTruncate)
+	hi, _ := NewHeaderInjector(map[string]HeaderOp{"Content-Type": RemoveHeader()})
+	th, _ := NewThrottleInjector()
+	cd, _ := NewClientDelayInjector(ClientPhaseDNS, time.Second)
+	cn, _ := NewConnectFailureInjector()
+	bl, _ := NewBandwidthLimitInjector(1000)
+	rp, _ := NewResourcePressureInjector(WithMemoryPressure(1024))
+	tp, _ := NewRequestTamperInjector(TamperFlipBytes)
+	dr, _ := NewDuplicateRequestInjector()
+	sr, _ := NewStreamInjector(WithStallAfterFrames(1))
+	cj, _ := NewConditionalInjector(func(*http.Request) bool { return true }, newTestInjectorNoop(), nil)
+
+	tests := []struct {
+		name string
+		give ReporterSetter
+	}{
+		{"Fault", f},
+		{"ChainInjector", ci},
+		{"RandomInjector", ri},
+		{"RejectInjector", rj},
+		{"ErrorInjector", ei},
+		{"StubInjector", sk},
+		{"SlowInjector", si},
+		{"PartialResponseInjector", pi},
+		{"JSONTruncateInjector", ji},
+		{"CharsetInjector", cs},
+		{"ProtocolListener", pl},
+		{"TrailerInjector", ti},
+		{"SessionInjector", ss},
+		{"CSRFInjector", cf},
+		{"FuzzInjector", fz},
+		{"RerouteInjector", rr},
+		{"SandboxInjector", sb},
+		{"PanicInjector", pn},
+		{"ResponseCorruptionInjector", rc},
+		{"HeaderInjector", hi},
+		{"ThrottleInjector", th},
+		{"ClientDelayInjector", cd},
+		{"ConnectFailureInjector", cn},
+		{"BandwidthLimitInjector", bl},
+		{"ResourcePressureInjector", rp},
+		{"ConditionalInjector", cj},
+		{"RequestTamperInjector", tp},
+		{"DuplicateRequestInjector", dr},
+		{"StreamInjector", sr},
+		{"Listener", fl},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, NewNoopReporter(), tt.give.Reporter())
+
+			tt.give.SetReporter(newTestReporter())
+			assert.Equal(t, newTestReporter(), tt.give.Reporter())
+		})
+	}
+}
+
+// testReporterMap is a Reporter that cannot be compared.
+type testReporterMap map[string]InjectorState
+
+func (r testReporterMap) Report(name string, state InjectorState) {}
+
+// TestReporterPropagation tests that composite Injectors give their Reporter to Injectors without
+// one.
+func TestReporterPropagation(t *testing.T) {
+	t.Parallel()
+
+	first := newTestStateReporter()
+	second := newTestStateReporter()
+	own := newTestStateReporter()
+
+	newChildren := func() (*ErrorInjector, *ErrorInjector, *ErrorInjector) {
+		noop, _ := NewErrorInjector(500)
+		owned, _ := NewErrorInjector(500, WithReporter(own))
+		unset, _ := NewErrorInjector(500)
+		unset.SetReporter(nil)
+		return noop, owned, unset
+	}
+
+	t.Run("chain", func(t *testing.T) {
+		t.Parallel()
+
+		noop, owned, unset := newChildren()
+		ci, err := NewChainInjector([]Injector{noop, owned, unset, newTestInjectorNoop()},
+			WithReporter(first), WithReporterPropagation(true))
+		assert.NoError(t, err)
+
+		assert.Equal(t, first, noop.Reporter())
+		assert.Equal(t, own, owned.Reporter())
+		assert.Equal(t, first, unset.Reporter())
+
+		ci.SetReporter(second)
+		assert.Equal(t, second, ci.Reporter())
+		assert.Equal(t, second, noop.Reporter())
+		assert.Equal(t, own, owned.Reporter())
+		assert.Equal(t, second, unset.Reporter())
+	})
+
+	t.Run("random", func(t *testing.T) {
+		t.Parallel()
+
+		noop, owned, unset := newChildren()
+		ri, err := NewRandomInjector([]Injector{noop, owned, unset}, WithReporterPropagation(true))
+		assert.NoError(t, err)
+
+		ri.SetReporter(first)
+		assert.Equal(t, first, noop.Reporter())
+		assert.Equal(t, own, owned.Reporter())
+		assert.Equal(t, first, unset.Reporter())
+	})
+
+	t.Run("conditional", func(t *testing.T) {
+		t.Parallel()
+
+		noop, owned, _ := newChildren()
+		ci, err := NewConditionalInjector(func(*http.Request) bool { return true }, noop, owned,
+			WithReporterPropagation(true))
+		assert.NoError(t, err)
+
+		ci.SetReporter(first)
+		assert.Equal(t, first, noop.Reporter())
+		assert.Equal(t, own, owned.Reporter())
+	})
+
+	t.Run("nested", func(t *testing.T) {
+		t.Parallel()
+
+		noop, _, _ := newChildren()
+		ri, err := NewRandomInjector([]Injector{noop}, WithReporterPropagation(true))
+		assert.NoError(t, err)
+
+		ci, err := NewChainInjector([]Injector{ri}, WithReporterPropagation(true))
+		assert.NoError(t, err)
+
+		ci.SetReporter(first)
+		assert.Equal(t, first, ri.Reporter())
+		assert.Equal(t, first, noop.Reporter())
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		t.Parallel()
+
+		noop, _, _ := newChildren()
+		ci, err := NewChainInjector([]Injector{noop}, WithReporter(first))
+		assert.NoError(t, err)
+
+		ci.SetReporter(second)
+		assert.Equal(t, NewNoopReporter(), noop.Reporter())
+	})
+
+	t.Run("not comparable", func(t *testing.T) {
+		t.Parallel()
+
+		mapped := testReporterMap{}
+		child, _ := NewErrorInjector(500, WithReporter(testReporterMap{}))
+		ci, err := NewChainInjector([]Injector{child}, WithReporter(mapped), WithReporterPropagation(true))
+		assert.NoError(t, err)
+
+		ci.SetReporter(first)
+		assert.Equal(t, testReporterMap{}, child.Reporter())
+	})
+}
+
+// testInjectorReported is a custom Injector that reports with an embedded InjectorReporter.
+type testInjectorReported struct {
+	InjectorReporter
+}
+
+// Handler reports the request and continues.
+func (i *testInjectorReported) Handler(next http.Handler) http.Handler {
+	return i.ReportHandler("reported", next)
+}
+
+// TestInjectorReporter tests that a custom Injector embedding InjectorReporter is given a Reporter
+// by composite Injectors and reports through it.
+func TestInjectorReporter(t *testing.T) {
+	t.Parallel()
+
+	i := &testInjectorReported{}
+	assert.Equal(t, NewNoopReporter(), i.Reporter())
+
+	reporter := newTestStateReporter()
+	_, err := NewChainInjector([]Injector{i}, WithReporter(reporter), WithReporterPropagation(true))
+	assert.NoError(t, err)
+	assert.Equal(t, reporter, i.Reporter())
+
+	f, err := NewFault(i, WithEnabled(true), WithParticipation(1.0))
+	assert.NoError(t, err)
+
+	rr := testRequest(t, f)
+	assert.Equal(t, testHandlerCode, rr.Code)
+	assert.ElementsMatch(t, []InjectorState{StateStarted, StateFinished},
+		[]InjectorState{<-reporter.states, <-reporter.states})
+}
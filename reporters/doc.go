@@ -0,0 +1,27 @@
+/*
+Package reporters provides Reporters for Faults that need no dependencies beyond the standard
+library. The Prometheus reporter is in its own module, reporters/prometheus.
+
+Memory Reporter
+
+NewMemoryReporter() returns a MemoryReporter that keeps the last Events of the Faults it is passed
+to in a ring buffer, to answer "did the fault actually fire for my request?" without a log or
+metrics pipeline. Pass it to the Faults with fault.WithReporter and mount its Handler on an
+internal port:
+
+    mr, _ := reporters.NewMemoryReporter(1000)
+    f, _ := fault.NewFault(si, fault.WithEnabled(true), fault.WithName("checkout-latency"),
+        fault.WithReporter(mr))
+    admin.Handle("/debug/faults/events", mr.Handler())
+
+The Handler responds with the Events as a JSON array, oldest first, filtered by the query
+parameters fault, type, method, host, path, remote_addr, and skip_reason, which must equal the
+fields of the Event, since, an RFC 3339 time, and limit, which keeps only the latest Events:
+
+    curl 'localhost:9090/debug/faults/events?fault=checkout-latency&type=finished&limit=10'
+
+Query the Events in code with MemoryReporter.Events(), such as in a test that checks which requests
+a Fault injected.
+
+*/
+package reporters
@@ -0,0 +1,169 @@
+package reporters
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/github/go-fault"
+)
+
+var (
+	// ErrInvalidSize when a MemoryReporter is not given a positive size.
+	ErrInvalidSize = errors.New("size must be > 0")
+)
+
+// MemoryReporter is a fault.EventReporter that keeps the last Events of the Faults it is passed to
+// in memory, in a ring buffer of a fixed size. The Request of each Event is dropped, so the
+// requests and their bodies are not kept alive; the method, host, path, and remote address of the
+// request are kept. It is safe for concurrent use.
+type MemoryReporter struct {
+	mtx sync.Mutex
+	// events is the ring buffer, and next the index the next Event is stored at. The buffer is
+	// full once next has wrapped around.
+	events []fault.Event
+	next   int
+	full   bool
+}
+
+// NewMemoryReporter returns a MemoryReporter that keeps the last size Events. It returns
+// ErrInvalidSize if size is not positive.
+func NewMemoryReporter(size int) (*MemoryReporter, error) {
+	if size <= 0 {
+		return nil, ErrInvalidSize
+	}
+
+	return &MemoryReporter{events: make([]fault.Event, size)}, nil
+}
+
+// Report does nothing. The MemoryReporter only keeps Events.
+func (m *MemoryReporter) Report(name string, state fault.InjectorState) {}
+
+// ReportEvent stores e, replacing the oldest Event if the buffer is full.
+func (m *MemoryReporter) ReportEvent(e fault.Event) {
+	e.Request = nil
+
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	m.events[m.next] = e
+	m.next++
+	if m.next == len(m.events) {
+		m.next = 0
+		m.full = true
+	}
+}
+
+// Query filters the Events of a MemoryReporter. Empty fields match any Event.
+type Query struct {
+	// Fault is the name of the Fault.
+	Fault string
+	// Type is the type of the Event.
+	Type fault.EventType
+	// Method is the method of the request.
+	Method string
+	// Host is the Host of the request.
+	Host string
+	// Path is the path of the request.
+	Path string
+	// RemoteAddr is the network address of the client that sent the request.
+	RemoteAddr string
+	// SkipReason is why the Injector did not run.
+	SkipReason fault.SkipReason
+	// Since, if set, leaves out the Events before it.
+	Since time.Time
+	// Limit, if not 0, keeps only the latest Limit Events that match.
+	Limit int
+}
+
+// match returns true if e passes the filters of q.
+func (q Query) match(e fault.Event) bool {
+	return (q.Fault == "" || e.Fault == q.Fault) &&
+		(q.Type == "" || e.Type == q.Type) &&
+		(q.Method == "" || e.Method == q.Method) &&
+		(q.Host == "" || e.Host == q.Host) &&
+		(q.Path == "" || e.Path == q.Path) &&
+		(q.RemoteAddr == "" || e.RemoteAddr == q.RemoteAddr) &&
+		(q.SkipReason == "" || e.SkipReason == q.SkipReason) &&
+		(q.Since.IsZero() || !e.Time.Before(q.Since))
+}
+
+// Events returns the stored Events that match q, oldest first.
+func (m *MemoryReporter) Events(q Query) []fault.Event {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	ordered := make([]fault.Event, 0, len(m.events))
+	if m.full {
+		ordered = append(ordered, m.events[m.next:]...)
+	}
+	ordered = append(ordered, m.events[:m.next]...)
+
+	out := []fault.Event{}
+	for _, e := range ordered {
+		if q.match(e) {
+			out = append(out, e)
+		}
+	}
+
+	if q.Limit > 0 && len(out) > q.Limit {
+		out = out[len(out)-q.Limit:]
+	}
+
+	return out
+}
+
+// Clear removes the stored Events.
+func (m *MemoryReporter) Clear() {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	for idx := range m.events {
+		m.events[idx] = fault.Event{}
+	}
+	m.next = 0
+	m.full = false
+}
+
+// Handler returns an http.Handler that responds with the stored Events as a JSON array, oldest
+// first. The query parameters fault, type, method, host, path, remote_addr, and skip_reason filter
+// the Events by the fields of Query of the same name, since by an RFC 3339 time, and limit keeps
+// only the latest Events. A malformed since or limit is a 400 Bad Request.
+func (m *MemoryReporter) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		params := r.URL.Query()
+		q := Query{
+			Fault:      params.Get("fault"),
+			Type:       fault.EventType(params.Get("type")),
+			Method:     params.Get("method"),
+			Host:       params.Get("host"),
+			Path:       params.Get("path"),
+			RemoteAddr: params.Get("remote_addr"),
+			SkipReason: fault.SkipReason(params.Get("skip_reason")),
+		}
+
+		if v := params.Get("since"); v != "" {
+			since, err := time.Parse(time.RFC3339Nano, v)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			q.Since = since
+		}
+
+		if v := params.Get("limit"); v != "" {
+			limit, err := strconv.Atoi(v)
+			if err != nil || limit < 0 {
+				http.Error(w, "limit must be an integer >= 0", http.StatusBadRequest)
+				return
+			}
+			q.Limit = limit
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		_ = json.NewEncoder(w).Encode(m.Events(q))
+	})
+}
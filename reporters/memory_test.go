@@ -0,0 +1,225 @@
+package reporters
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/github/go-fault"
+	"github.com/stretchr/testify/assert"
+)
+
+// testTime is the time of the first test Event.
+var testTime = time.Date(2024, 11, 20, 12, 0, 0, 0, time.UTC)
+
+// testEvents returns n Events of alternating types one second apart, on paths "/0", "/1", ...
+func testEvents(n int) []fault.Event {
+	events := make([]fault.Event, n)
+	for idx := range events {
+		events[idx] = fault.Event{
+			Type:   fault.EventStarted,
+			Time:   testTime.Add(time.Duration(idx) * time.Second),
+			Fault:  "slow",
+			Method: http.MethodGet,
+			Path:   "/" + string(rune('0'+idx)),
+		}
+		if idx%2 == 1 {
+			events[idx].Type = fault.EventSkipped
+			events[idx].SkipReason = fault.SkipParticipation
+		}
+	}
+
+	return events
+}
+
+// TestNewMemoryReporter tests NewMemoryReporter.
+func TestNewMemoryReporter(t *testing.T) {
+	t.Parallel()
+
+	m, err := NewMemoryReporter(0)
+	assert.Equal(t, ErrInvalidSize, err)
+	assert.Nil(t, m)
+
+	m, err = NewMemoryReporter(3)
+	assert.NoError(t, err)
+	assert.Equal(t, []fault.Event{}, m.Events(Query{}))
+}
+
+// TestMemoryReporterEvents tests that MemoryReporter keeps the last Events in order and filters
+// them.
+func TestMemoryReporterEvents(t *testing.T) {
+	t.Parallel()
+
+	events := testEvents(6)
+
+	tests := []struct {
+		name      string
+		giveSize  int
+		giveQuery Query
+		want      []fault.Event
+	}{
+		{
+			name:     "not full",
+			giveSize: 10,
+			want:     events,
+		},
+		{
+			name:     "exactly full",
+			giveSize: 6,
+			want:     events,
+		},
+		{
+			name:     "wrapped",
+			giveSize: 4,
+			want:     events[2:],
+		},
+		{
+			name:      "type",
+			giveSize:  10,
+			giveQuery: Query{Type: fault.EventSkipped},
+			want:      []fault.Event{events[1], events[3], events[5]},
+		},
+		{
+			name:      "skip reason",
+			giveSize:  10,
+			giveQuery: Query{SkipReason: fault.SkipParticipation, Fault: "slow", Method: http.MethodGet},
+			want:      []fault.Event{events[1], events[3], events[5]},
+		},
+		{
+			name:      "path",
+			giveSize:  10,
+			giveQuery: Query{Path: "/2"},
+			want:      []fault.Event{events[2]},
+		},
+		{
+			name:      "since",
+			giveSize:  10,
+			giveQuery: Query{Since: testTime.Add(4 * time.Second)},
+			want:      events[4:],
+		},
+		{
+			name:      "limit",
+			giveSize:  4,
+			giveQuery: Query{Type: fault.EventStarted, Limit: 1},
+			want:      []fault.Event{events[4]},
+		},
+		{
+			name:      "no match",
+			giveSize:  10,
+			giveQuery: Query{Fault: "error", Host: "example.com", RemoteAddr: "192.0.2.1:1234"},
+			want:      []fault.Event{},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			m, err := NewMemoryReporter(tt.giveSize)
+			assert.NoError(t, err)
+			for _, e := range events {
+				m.ReportEvent(e)
+			}
+
+			assert.Equal(t, tt.want, m.Events(tt.giveQuery))
+		})
+	}
+}
+
+// TestMemoryReporterFault tests that a MemoryReporter passed to a Fault keeps its Events without
+// their requests, and that Clear removes them.
+func TestMemoryReporterFault(t *testing.T) {
+	t.Parallel()
+
+	m, err := NewMemoryReporter(10)
+	assert.NoError(t, err)
+
+	ei, err := fault.NewErrorInjector(http.StatusInternalServerError)
+	assert.NoError(t, err)
+	f, err := fault.NewFault(ei, fault.WithEnabled(true), fault.WithParticipation(1.0),
+		fault.WithName("errors"), fault.WithReporter(m))
+	assert.NoError(t, err)
+
+	f.Handler(http.NotFoundHandler()).ServeHTTP(httptest.NewRecorder(),
+		httptest.NewRequest(http.MethodPost, "/checkout", nil))
+
+	events := m.Events(Query{Fault: "errors"})
+	assert.Len(t, events, 2)
+	assert.Equal(t, fault.EventStarted, events[0].Type)
+	assert.Equal(t, fault.EventFinished, events[1].Type)
+	assert.Equal(t, http.StatusInternalServerError, events[1].StatusCode)
+	assert.Equal(t, http.MethodPost, events[1].Method)
+	assert.Equal(t, "/checkout", events[1].Path)
+	assert.Nil(t, events[1].Request)
+
+	m.Clear()
+	assert.Equal(t, []fault.Event{}, m.Events(Query{}))
+
+	m.ReportEvent(fault.Event{Fault: "errors"})
+	assert.Len(t, m.Events(Query{}), 1)
+}
+
+// TestMemoryReporterHandler tests MemoryReporter.Handler.
+func TestMemoryReporterHandler(t *testing.T) {
+	t.Parallel()
+
+	m, err := NewMemoryReporter(10)
+	assert.NoError(t, err)
+	events := testEvents(6)
+	for _, e := range events {
+		m.ReportEvent(e)
+	}
+
+	tests := []struct {
+		name     string
+		giveURL  string
+		wantCode int
+		want     []fault.Event
+	}{
+		{
+			name:     "all",
+			giveURL:  "/",
+			wantCode: http.StatusOK,
+			want:     events,
+		},
+		{
+			name:     "filters",
+			giveURL:  "/?fault=slow&type=skipped&skip_reason=participation&since=2024-11-20T12:00:02Z&limit=1",
+			wantCode: http.StatusOK,
+			want:     events[5:],
+		},
+		{
+			name:     "invalid since",
+			giveURL:  "/?since=yesterday",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "invalid limit",
+			giveURL:  "/?limit=-1",
+			wantCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			rr := httptest.NewRecorder()
+			m.Handler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, tt.giveURL, nil))
+
+			assert.Equal(t, tt.wantCode, rr.Code)
+			if tt.wantCode != http.StatusOK {
+				return
+			}
+
+			assert.Equal(t, "application/json; charset=utf-8", rr.Header().Get("Content-Type"))
+			var got []fault.Event
+			assert.NoError(t, json.NewDecoder(rr.Body).Decode(&got))
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
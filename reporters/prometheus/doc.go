@@ -0,0 +1,25 @@
+/*
+Package prometheus exports the injections of Faults as Prometheus metrics.
+
+Reporter
+
+NewReporter() returns a Reporter that counts the requests each Fault injects, by the status code
+written, and the requests it skips, by the reason, and observes how long each injection took. Pass
+it to the Faults with fault.WithReporter and register it with Prometheus:
+
+    r, _ := prometheus.NewReporter()
+    promclient.MustRegister(r)
+
+    f, _ := fault.NewFault(si, fault.WithEnabled(true), fault.WithName("checkout-latency"),
+        fault.WithReporter(r))
+
+The metrics are labeled with the Fault name and Injector kind by default. Pass WithLabels() to
+label them with the method, host, or path of the request as well, WithConstLabels() to add fixed
+labels such as the service, and WithNamespace() and WithBuckets() to name the metrics and bucket
+the durations for your dashboards. Pass FaultLabel() to WithLabels() to label them with a label of
+the Fault, set with fault.WithLabels, so impact can be rolled up by team or experiment.
+
+    r, _ := prometheus.NewReporter(prometheus.WithLabels(prometheus.LabelFault, prometheus.FaultLabel("team")))
+
+*/
+package prometheus
@@ -0,0 +1,278 @@
+package prometheus
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/github/go-fault"
+	prom "github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	// ErrInvalidLabel when a Label that is not one of the Label constants or a valid FaultLabel is
+	// passed.
+	ErrInvalidLabel = errors.New("invalid label")
+	// ErrDuplicateLabel when the same Label is passed twice.
+	ErrDuplicateLabel = errors.New("duplicate label")
+)
+
+// Label is a label of the metrics a Reporter exports, taken from each fault.Event.
+type Label string
+
+const (
+	// LabelFault is the name of the Fault, set with fault.WithName.
+	LabelFault Label = "fault"
+	// LabelInjector is the kind of Injector the Fault runs, such as "slow".
+	LabelInjector Label = "injector"
+	// LabelMethod is the method of the request.
+	LabelMethod Label = "method"
+	// LabelHost is the Host of the request.
+	LabelHost Label = "host"
+	// LabelPath is the path of the request. Paths with IDs in them make a time series for every
+	// ID, so only use it on Faults limited to a few routes.
+	LabelPath Label = "path"
+)
+
+// labelPrefix prefixes the Labels returned by FaultLabel.
+const labelPrefix = "label_"
+
+// FaultLabel returns the Label of the label key of the Fault, set with fault.WithLabels, such as
+// FaultLabel("team"), so metrics can be rolled up by team or experiment. It is named "label_"
+// followed by key, and is empty for Faults without the label. key may only contain letters, digits,
+// and underscores.
+func FaultLabel(key string) Label {
+	return Label(labelPrefix + key)
+}
+
+// value returns the value of the Label in e.
+func (l Label) value(e fault.Event) string {
+	switch l {
+	case LabelFault:
+		return e.Fault
+	case LabelInjector:
+		return e.InjectorName
+	case LabelMethod:
+		return e.Method
+	case LabelHost:
+		return e.Host
+	case LabelPath:
+		return e.Path
+	default:
+		return e.Labels[strings.TrimPrefix(string(l), labelPrefix)]
+	}
+}
+
+// valid returns true if l is one of the Label constants or a valid FaultLabel.
+func (l Label) valid() bool {
+	switch l {
+	case LabelFault, LabelInjector, LabelMethod, LabelHost, LabelPath:
+		return true
+	}
+
+	key := strings.TrimPrefix(string(l), labelPrefix)
+	if key == "" || len(key) == len(l) {
+		return false
+	}
+	for _, c := range key {
+		if c != '_' && (c < 'a' || c > 'z') && (c < 'A' || c > 'Z') && (c < '0' || c > '9') {
+			return false
+		}
+	}
+
+	return true
+}
+
+// StatusAborted is the status label of injections that did not write a response, because the
+// Injector panicked or took over the connection.
+const StatusAborted = "aborted"
+
+// Reporter is a fault.EventReporter that counts the injections and skips of the Faults it is passed
+// to and observes how long each injection took. It is a prometheus.Collector, so register it with
+// a prometheus.Registerer to export its metrics, where NAMESPACE is "fault" unless set with
+// WithNamespace:
+//
+//	NAMESPACE_injections_total{<labels>,status}         injections by the status code they wrote
+//	NAMESPACE_skips_total{<labels>,reason}              requests not injected by fault.SkipReason
+//	NAMESPACE_injection_duration_seconds{<labels>}      how long injections took
+//	NAMESPACE_injector_states_total{name,state}         states reported by Injectors
+//
+// The injector states count what Injectors passed the Reporter with fault.WithReporter report
+// themselves, such as the StateStarted of a SlowInjector inside a ChainInjector.
+type Reporter struct {
+	namespace   string
+	labels      []Label
+	constLabels prom.Labels
+	buckets     []float64
+
+	injections *prom.CounterVec
+	skips      *prom.CounterVec
+	durations  *prom.HistogramVec
+	states     *prom.CounterVec
+}
+
+// Option configures a Reporter.
+type Option interface {
+	applyReporter(r *Reporter) error
+}
+
+type namespaceOption string
+
+func (o namespaceOption) applyReporter(r *Reporter) error {
+	r.namespace = string(o)
+	return nil
+}
+
+// WithNamespace sets the namespace that prefixes the names of the metrics. The default is "fault".
+func WithNamespace(ns string) Option {
+	return namespaceOption(ns)
+}
+
+type labelsOption []Label
+
+func (o labelsOption) applyReporter(r *Reporter) error {
+	r.labels = o
+	return nil
+}
+
+// WithLabels sets the labels of the metrics, in order. The default is LabelFault and LabelInjector.
+// The status and reason labels are always added to the injection and skip counters.
+func WithLabels(labels ...Label) Option {
+	return labelsOption(labels)
+}
+
+type constLabelsOption prom.Labels
+
+func (o constLabelsOption) applyReporter(r *Reporter) error {
+	r.constLabels = prom.Labels(o)
+	return nil
+}
+
+// WithConstLabels adds labels with a fixed value to every metric, such as the name of the service.
+func WithConstLabels(labels prom.Labels) Option {
+	return constLabelsOption(labels)
+}
+
+type bucketsOption []float64
+
+func (o bucketsOption) applyReporter(r *Reporter) error {
+	r.buckets = o
+	return nil
+}
+
+// WithBuckets sets the buckets of the injection duration histogram, in seconds. The default is
+// prometheus.DefBuckets.
+func WithBuckets(buckets []float64) Option {
+	return bucketsOption(buckets)
+}
+
+// NewReporter returns a new Reporter.
+func NewReporter(opts ...Option) (*Reporter, error) {
+	// set defaults
+	r := &Reporter{
+		namespace: "fault",
+		labels:    []Label{LabelFault, LabelInjector},
+		buckets:   prom.DefBuckets,
+	}
+
+	// apply options
+	for _, opt := range opts {
+		err := opt.applyReporter(r)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// check options
+	names := make([]string, 0, len(r.labels))
+	seen := make(map[Label]bool, len(r.labels))
+	for _, l := range r.labels {
+		if !l.valid() {
+			return nil, ErrInvalidLabel
+		}
+		if seen[l] {
+			return nil, ErrDuplicateLabel
+		}
+		seen[l] = true
+		names = append(names, string(l))
+	}
+
+	r.injections = prom.NewCounterVec(prom.CounterOpts{
+		Namespace:   r.namespace,
+		Name:        "injections_total",
+		Help:        "Requests a Fault ran its Injector on, by the status code of the response.",
+		ConstLabels: r.constLabels,
+	}, append(names[:len(names):len(names)], "status"))
+
+	r.skips = prom.NewCounterVec(prom.CounterOpts{
+		Namespace:   r.namespace,
+		Name:        "skips_total",
+		Help:        "Requests an enabled Fault did not run its Injector on, by the reason it skipped them.",
+		ConstLabels: r.constLabels,
+	}, append(names[:len(names):len(names)], "reason"))
+
+	r.durations = prom.NewHistogramVec(prom.HistogramOpts{
+		Namespace:   r.namespace,
+		Name:        "injection_duration_seconds",
+		Help:        "How long the Injector of a Fault and the handlers it called ran.",
+		ConstLabels: r.constLabels,
+		Buckets:     r.buckets,
+	}, names)
+
+	r.states = prom.NewCounterVec(prom.CounterOpts{
+		Namespace:   r.namespace,
+		Name:        "injector_states_total",
+		Help:        "States reported by Injectors, by the name they reported.",
+		ConstLabels: r.constLabels,
+	}, []string{"name", "state"})
+
+	return r, nil
+}
+
+// Report counts state for the Injector or Fault called name.
+func (r *Reporter) Report(name string, state fault.InjectorState) {
+	r.states.WithLabelValues(name, state.String()).Inc()
+}
+
+// ReportEvent counts e if it is a skip or a finished injection, and observes the duration of a
+// finished injection.
+func (r *Reporter) ReportEvent(e fault.Event) {
+	switch e.Type {
+	case fault.EventSkipped:
+		r.skips.WithLabelValues(r.values(e, string(e.SkipReason))...).Inc()
+	case fault.EventFinished:
+		status := StatusAborted
+		if e.StatusCode != 0 {
+			status = strconv.Itoa(e.StatusCode)
+		}
+
+		r.injections.WithLabelValues(r.values(e, status)...).Inc()
+		r.durations.WithLabelValues(r.values(e)...).Observe(e.Duration.Seconds())
+	}
+}
+
+// values returns the values of the labels of the Reporter in e, followed by extra.
+func (r *Reporter) values(e fault.Event, extra ...string) []string {
+	values := make([]string, 0, len(r.labels)+len(extra))
+	for _, l := range r.labels {
+		values = append(values, l.value(e))
+	}
+
+	return append(values, extra...)
+}
+
+// Describe implements prometheus.Collector.
+func (r *Reporter) Describe(ch chan<- *prom.Desc) {
+	r.injections.Describe(ch)
+	r.skips.Describe(ch)
+	r.durations.Describe(ch)
+	r.states.Describe(ch)
+}
+
+// Collect implements prometheus.Collector.
+func (r *Reporter) Collect(ch chan<- prom.Metric) {
+	r.injections.Collect(ch)
+	r.skips.Collect(ch)
+	r.durations.Collect(ch)
+	r.states.Collect(ch)
+}
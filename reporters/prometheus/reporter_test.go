@@ -0,0 +1,289 @@
+package prometheus
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/github/go-fault"
+	prom "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+var errOption = errors.New("option error")
+
+type errorOption struct{}
+
+func (errorOption) applyReporter(r *Reporter) error {
+	return errOption
+}
+
+// TestNewReporter tests NewReporter.
+func TestNewReporter(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		giveOptions []Option
+		wantLabels  []Label
+		wantErr     error
+	}{
+		{
+			name:        "no options",
+			giveOptions: []Option{},
+			wantLabels:  []Label{LabelFault, LabelInjector},
+			wantErr:     nil,
+		},
+		{
+			name: "custom options",
+			giveOptions: []Option{
+				WithNamespace("chaos"),
+				WithLabels(LabelPath, LabelMethod, FaultLabel("team_2")),
+				WithConstLabels(prom.Labels{"service": "checkout"}),
+				WithBuckets([]float64{0.1, 1}),
+			},
+			wantLabels: []Label{LabelPath, LabelMethod, "label_team_2"},
+			wantErr:    nil,
+		},
+		{
+			name:        "no labels",
+			giveOptions: []Option{WithLabels()},
+			wantLabels:  nil,
+			wantErr:     nil,
+		},
+		{
+			name:        "invalid label",
+			giveOptions: []Option{WithLabels(LabelFault, "user")},
+			wantErr:     ErrInvalidLabel,
+		},
+		{
+			name:        "empty fault label",
+			giveOptions: []Option{WithLabels(FaultLabel(""))},
+			wantErr:     ErrInvalidLabel,
+		},
+		{
+			name:        "invalid fault label",
+			giveOptions: []Option{WithLabels(FaultLabel("team-name"))},
+			wantErr:     ErrInvalidLabel,
+		},
+		{
+			name:        "duplicate label",
+			giveOptions: []Option{WithLabels(LabelFault, LabelHost, LabelFault)},
+			wantErr:     ErrDuplicateLabel,
+		},
+		{
+			name:        "option error",
+			giveOptions: []Option{errorOption{}},
+			wantErr:     errOption,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r, err := NewReporter(tt.giveOptions...)
+
+			assert.Equal(t, tt.wantErr, err)
+			if tt.wantErr != nil {
+				assert.Nil(t, r)
+				return
+			}
+
+			assert.Equal(t, tt.wantLabels, r.labels)
+			assert.NoError(t, prom.NewRegistry().Register(r))
+		})
+	}
+}
+
+// testEvents are a skip, a finished injection, and an aborted injection of a Fault.
+var testEvents = []fault.Event{
+	{
+		Type:         fault.EventSkipped,
+		Fault:        "checkout",
+		InjectorName: "slow",
+		Method:       http.MethodGet,
+		Host:         "example.com",
+		Path:         "/cart",
+		Labels:       map[string]string{"team": "payments"},
+		SkipReason:   fault.SkipParticipation,
+	},
+	{
+		Type:         fault.EventStarted,
+		Fault:        "checkout",
+		InjectorName: "slow",
+		Method:       http.MethodGet,
+		Host:         "example.com",
+		Path:         "/cart",
+	},
+	{
+		Type:         fault.EventFinished,
+		Fault:        "checkout",
+		InjectorName: "slow",
+		Method:       http.MethodGet,
+		Host:         "example.com",
+		Path:         "/cart",
+		Labels:       map[string]string{"team": "payments"},
+		StatusCode:   http.StatusOK,
+		Duration:     2 * time.Second,
+	},
+	{
+		Type:         fault.EventFinished,
+		Fault:        "checkout",
+		InjectorName: "slow",
+		Method:       http.MethodPost,
+		Host:         "example.com",
+		Path:         "/pay",
+		Duration:     500 * time.Millisecond,
+	},
+}
+
+// TestReporterReportEvent tests that Reporter.ReportEvent exports each Event with the configured
+// labels.
+func TestReporterReportEvent(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		giveOptions []Option
+		want        string
+	}{
+		{
+			name:        "default labels",
+			giveOptions: []Option{WithBuckets([]float64{1})},
+			want: `
+# HELP fault_injection_duration_seconds How long the Injector of a Fault and the handlers it called ran.
+# TYPE fault_injection_duration_seconds histogram
+fault_injection_duration_seconds_bucket{fault="checkout",injector="slow",le="1"} 1
+fault_injection_duration_seconds_bucket{fault="checkout",injector="slow",le="+Inf"} 2
+fault_injection_duration_seconds_sum{fault="checkout",injector="slow"} 2.5
+fault_injection_duration_seconds_count{fault="checkout",injector="slow"} 2
+# HELP fault_injections_total Requests a Fault ran its Injector on, by the status code of the response.
+# TYPE fault_injections_total counter
+fault_injections_total{fault="checkout",injector="slow",status="200"} 1
+fault_injections_total{fault="checkout",injector="slow",status="aborted"} 1
+# HELP fault_skips_total Requests an enabled Fault did not run its Injector on, by the reason it skipped them.
+# TYPE fault_skips_total counter
+fault_skips_total{fault="checkout",injector="slow",reason="participation"} 1
+`,
+		},
+		{
+			name: "request labels",
+			giveOptions: []Option{
+				WithNamespace("chaos"),
+				WithLabels(LabelMethod, LabelHost, LabelPath),
+				WithConstLabels(prom.Labels{"service": "shop"}),
+				WithBuckets([]float64{1}),
+			},
+			want: `
+# HELP chaos_injection_duration_seconds How long the Injector of a Fault and the handlers it called ran.
+# TYPE chaos_injection_duration_seconds histogram
+chaos_injection_duration_seconds_bucket{host="example.com",method="GET",path="/cart",service="shop",le="1"} 0
+chaos_injection_duration_seconds_bucket{host="example.com",method="GET",path="/cart",service="shop",le="+Inf"} 1
+chaos_injection_duration_seconds_sum{host="example.com",method="GET",path="/cart",service="shop"} 2
+chaos_injection_duration_seconds_count{host="example.com",method="GET",path="/cart",service="shop"} 1
+chaos_injection_duration_seconds_bucket{host="example.com",method="POST",path="/pay",service="shop",le="1"} 1
+chaos_injection_duration_seconds_bucket{host="example.com",method="POST",path="/pay",service="shop",le="+Inf"} 1
+chaos_injection_duration_seconds_sum{host="example.com",method="POST",path="/pay",service="shop"} 0.5
+chaos_injection_duration_seconds_count{host="example.com",method="POST",path="/pay",service="shop"} 1
+# HELP chaos_injections_total Requests a Fault ran its Injector on, by the status code of the response.
+# TYPE chaos_injections_total counter
+chaos_injections_total{host="example.com",method="GET",path="/cart",service="shop",status="200"} 1
+chaos_injections_total{host="example.com",method="POST",path="/pay",service="shop",status="aborted"} 1
+# HELP chaos_skips_total Requests an enabled Fault did not run its Injector on, by the reason it skipped them.
+# TYPE chaos_skips_total counter
+chaos_skips_total{host="example.com",method="GET",path="/cart",reason="participation",service="shop"} 1
+`,
+		},
+		{
+			name: "fault labels",
+			giveOptions: []Option{
+				WithLabels(LabelFault, FaultLabel("team")),
+				WithBuckets([]float64{1}),
+			},
+			want: `
+# HELP fault_injection_duration_seconds How long the Injector of a Fault and the handlers it called ran.
+# TYPE fault_injection_duration_seconds histogram
+fault_injection_duration_seconds_bucket{fault="checkout",label_team="",le="1"} 1
+fault_injection_duration_seconds_bucket{fault="checkout",label_team="",le="+Inf"} 1
+fault_injection_duration_seconds_sum{fault="checkout",label_team=""} 0.5
+fault_injection_duration_seconds_count{fault="checkout",label_team=""} 1
+fault_injection_duration_seconds_bucket{fault="checkout",label_team="payments",le="1"} 0
+fault_injection_duration_seconds_bucket{fault="checkout",label_team="payments",le="+Inf"} 1
+fault_injection_duration_seconds_sum{fault="checkout",label_team="payments"} 2
+fault_injection_duration_seconds_count{fault="checkout",label_team="payments"} 1
+# HELP fault_injections_total Requests a Fault ran its Injector on, by the status code of the response.
+# TYPE fault_injections_total counter
+fault_injections_total{fault="checkout",label_team="",status="aborted"} 1
+fault_injections_total{fault="checkout",label_team="payments",status="200"} 1
+# HELP fault_skips_total Requests an enabled Fault did not run its Injector on, by the reason it skipped them.
+# TYPE fault_skips_total counter
+fault_skips_total{fault="checkout",label_team="payments",reason="participation"} 1
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r, err := NewReporter(tt.giveOptions...)
+			assert.NoError(t, err)
+
+			for _, e := range testEvents {
+				r.ReportEvent(e)
+			}
+
+			err = testutil.CollectAndCompare(r, strings.NewReader(tt.want))
+			assert.NoError(t, err)
+		})
+	}
+}
+
+// TestReporterReport tests that Reporter.Report counts the states Injectors report.
+func TestReporterReport(t *testing.T) {
+	t.Parallel()
+
+	r, err := NewReporter()
+	assert.NoError(t, err)
+
+	r.Report("SlowInjector", fault.StateStarted)
+	r.Report("SlowInjector", fault.StateStarted)
+	r.Report("SlowInjector", fault.StateFinished)
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(r.states.WithLabelValues("SlowInjector", "started")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(r.states.WithLabelValues("SlowInjector", "finished")))
+}
+
+// TestReporterFault tests a Reporter passed to a Fault.
+func TestReporterFault(t *testing.T) {
+	t.Parallel()
+
+	r, err := NewReporter()
+	assert.NoError(t, err)
+
+	ei, err := fault.NewErrorInjector(http.StatusInternalServerError)
+	assert.NoError(t, err)
+
+	f, err := fault.NewFault(ei,
+		fault.WithEnabled(true),
+		fault.WithName("checkout"),
+		fault.WithParticipation(1.0),
+		fault.WithPathBlocklist([]string{"/skip"}),
+		fault.WithReporter(r),
+	)
+	assert.NoError(t, err)
+
+	h := f.Handler(http.NotFoundHandler())
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/skip", nil))
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(r.injections.WithLabelValues("checkout", "error", "500")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(r.skips.WithLabelValues("checkout", "error", string(fault.SkipUnmatched))))
+}
@@ -0,0 +1,49 @@
+package fault
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+)
+
+type requestIDSeedOption string
+
+func (o requestIDSeedOption) applyFault(f *Fault) error {
+	f.requestIDHeader = string(o)
+	return nil
+}
+
+// WithRequestIDSeed sets the header, such as "X-Request-Id", whose value seeds the participation
+// roll of a request. The roll becomes a hash of the request ID, the name of the Fault, and its
+// seed, so a retry that reuses the ID of the original request gets the same decision on every
+// instance, and retry behavior can be told apart from that of fresh requests. Requests without
+// the header roll randomly.
+func WithRequestIDSeed(h string) Option {
+	return requestIDSeedOption(h)
+}
+
+// requestID returns the request ID that seeds the roll of r, or "" if there is none.
+func (f *Fault) requestID(r *http.Request) string {
+	if f.requestIDHeader == "" {
+		return ""
+	}
+
+	return r.Header.Get(f.requestIDHeader)
+}
+
+// rollKey is roll with the number seeded by key, such as a request ID, instead of randF.
+func (f *Fault) rollKey(key string) (bool, float32) {
+	rn := unitFloat32(hashFraction(f.name+"\x00"+strconv.FormatInt(f.randSeed, 10), key))
+	return f.decide(rn), rn
+}
+
+// unitFloat32 converts x in [0.0,1.0] to a float32 in [0.0,1.0) like randF returns. Numbers close
+// to 1.0 round up to it as a float32.
+func unitFloat32(x float64) float32 {
+	rn := float32(x)
+	if rn >= 1.0 {
+		return math.Nextafter32(1.0, 0.0)
+	}
+
+	return rn
+}
@@ -0,0 +1,123 @@
+package fault
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testRequestIDFault returns a Fault named name that injects 500s into half of the requests,
+// seeded by their X-Request-Id header.
+func testRequestIDFault(t *testing.T, name string, opts ...Option) *Fault {
+	t.Helper()
+
+	f, err := NewFault(newTestInjector500s(),
+		append([]Option{
+			WithEnabled(true),
+			WithName(name),
+			WithParticipation(0.5),
+			WithRequestIDSeed("X-Request-Id"),
+		}, opts...)...,
+	)
+	assert.NoError(t, err)
+
+	return f
+}
+
+// testRequestIDCode returns the status code f responds to a request with request ID id with.
+func testRequestIDCode(f *Fault, id string) int {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if id != "" {
+		req.Header.Set("X-Request-Id", id)
+	}
+
+	rr := httptest.NewRecorder()
+	f.Handler(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		http.Error(w, testHandlerBody, testHandlerCode)
+	})).ServeHTTP(rr, req)
+
+	return rr.Code
+}
+
+// TestWithRequestIDSeed tests that requests with the same request ID get the same decision from
+// every Fault with the same name and seed.
+func TestWithRequestIDSeed(t *testing.T) {
+	t.Parallel()
+
+	f := testRequestIDFault(t, "checkout")
+	retry := testRequestIDFault(t, "checkout")
+	other := testRequestIDFault(t, "search")
+	reseeded := testRequestIDFault(t, "checkout", WithRandSeed(2))
+
+	var injected, differentName, differentSeed int
+	for n := 0; n < 1000; n++ {
+		id := fmt.Sprintf("req-%d", n)
+
+		code := testRequestIDCode(f, id)
+		assert.Equal(t, code, testRequestIDCode(f, id), id)
+		assert.Equal(t, code, testRequestIDCode(retry, id), id)
+
+		if code == http.StatusInternalServerError {
+			injected++
+		}
+		if code != testRequestIDCode(other, id) {
+			differentName++
+		}
+		if code != testRequestIDCode(reseeded, id) {
+			differentSeed++
+		}
+	}
+
+	// the hash spreads request IDs evenly and independently for each Fault
+	assert.True(t, injected > 400 && injected < 600, injected)
+	assert.True(t, differentName > 400 && differentName < 600, differentName)
+	assert.True(t, differentSeed > 400 && differentSeed < 600, differentSeed)
+}
+
+// TestWithRequestIDSeedMissing tests that requests without a request ID roll randomly.
+func TestWithRequestIDSeedMissing(t *testing.T) {
+	t.Parallel()
+
+	rolls := []float32{0.1, 0.9}
+	f := testRequestIDFault(t, "checkout", WithRandFloat32Func(func() float32 {
+		rn := rolls[0]
+		rolls = rolls[1:]
+		return rn
+	}))
+
+	assert.Equal(t, http.StatusInternalServerError, testRequestIDCode(f, ""))
+	assert.Equal(t, testHandlerCode, testRequestIDCode(f, ""))
+}
+
+// TestWithRequestIDSeedEvaluation tests that the Evaluation of a request records the request ID
+// that seeded its roll.
+func TestWithRequestIDSeedEvaluation(t *testing.T) {
+	t.Parallel()
+
+	reporter := newTestEvaluationReporter()
+	f := testRequestIDFault(t, "checkout", WithReporter(reporter), WithTracing(true),
+		WithParticipation(1.0))
+
+	assert.Equal(t, http.StatusInternalServerError, testRequestIDCode(f, "req-1"))
+
+	ev := <-reporter.evaluations
+	assert.True(t, ev.Injected)
+	assert.Equal(t, "req-1", ev.RequestID)
+	assert.Equal(t, uint64(0), ev.Rolls)
+
+	_, rn := f.rollKey("req-1")
+	assert.Equal(t, rn, ev.Roll)
+}
+
+// TestUnitFloat32 tests unitFloat32.
+func TestUnitFloat32(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, float32(0.0), unitFloat32(0.0))
+	assert.Equal(t, float32(0.5), unitFloat32(0.5))
+	assert.True(t, unitFloat32(1.0) < 1.0)
+	assert.True(t, unitFloat32(0.99999999) < 1.0)
+}
@@ -0,0 +1,200 @@
+package fault
+
+import (
+	"bytes"
+	"net/http"
+	"strconv"
+	"sync"
+)
+
+// maxPooledBufferSize is the largest body a bufferedWriter may have buffered and still be reused.
+// Larger buffers are left for the garbage collector so one big response does not pin its memory.
+const maxPooledBufferSize = 64 << 10
+
+// bufferedWriterPool reuses bufferedWriters and their body buffers between requests.
+var bufferedWriterPool = sync.Pool{ //nolint:gochecknoglobals
+	New: func() interface{} {
+		return &bufferedWriter{header: make(http.Header), code: http.StatusOK}
+	},
+}
+
+// bufferedWriter is an http.ResponseWriter that holds the status code, headers, and body of a
+// response so that an Injector can change them before they are sent. Buffering means the handler
+// can no longer stream, so only Injectors that must see the whole response should use it.
+type bufferedWriter struct {
+	w      http.ResponseWriter
+	header http.Header
+	code   int
+	body   bytes.Buffer
+
+	wroteHeader bool
+}
+
+// newBufferedWriter returns a bufferedWriter that will send to w. Headers already set on w are
+// copied so the handler can read and change them. Call release when the response has been sent.
+func newBufferedWriter(w http.ResponseWriter) *bufferedWriter {
+	b := bufferedWriterPool.Get().(*bufferedWriter)
+	b.w = w
+
+	for k, v := range w.Header() {
+		b.header[k] = append([]string(nil), v...)
+	}
+
+	return b
+}
+
+// release returns the bufferedWriter to the pool unless its body buffer has grown larger than
+// maxPooledBufferSize. It must not be used afterwards.
+func (b *bufferedWriter) release() {
+	if b.body.Cap() > maxPooledBufferSize {
+		return
+	}
+
+	b.reset()
+	bufferedWriterPool.Put(b)
+}
+
+// reset clears the bufferedWriter, keeping its header map and body buffer for reuse.
+func (b *bufferedWriter) reset() {
+	for k := range b.header {
+		delete(b.header, k)
+	}
+	b.body.Reset()
+	b.w = nil
+	b.code = http.StatusOK
+	b.wroteHeader = false
+}
+
+// Header returns the buffered headers.
+func (b *bufferedWriter) Header() http.Header {
+	return b.header
+}
+
+// WriteHeader records the first status code written.
+func (b *bufferedWriter) WriteHeader(code int) {
+	if b.wroteHeader {
+		return
+	}
+	b.wroteHeader = true
+	b.code = code
+}
+
+// Write appends p to the buffered body.
+func (b *bufferedWriter) Write(p []byte) (int, error) {
+	if !b.wroteHeader {
+		b.WriteHeader(http.StatusOK)
+	}
+
+	return b.body.Write(p)
+}
+
+// Flush does nothing. The response is sent by send.
+func (b *bufferedWriter) Flush() {}
+
+// setBody replaces the buffered body with p.
+func (b *bufferedWriter) setBody(p []byte) {
+	b.body.Reset()
+	b.body.Write(p)
+}
+
+// send writes the buffered response to the underlying ResponseWriter. If the handler set a
+// Content-Length it is updated to match the (possibly changed) body.
+func (b *bufferedWriter) send() {
+	b.sendHeader()
+	_, _ = b.w.Write(b.body.Bytes())
+}
+
+// sendHeader writes the buffered status code and headers to the underlying ResponseWriter without
+// the body. If the handler set a Content-Length it is updated to match the body.
+func (b *bufferedWriter) sendHeader() {
+	dst := b.w.Header()
+	for k := range dst {
+		delete(dst, k)
+	}
+	for k, v := range b.header {
+		dst[k] = v
+	}
+
+	if dst.Get("Content-Length") != "" {
+		dst.Set("Content-Length", strconv.Itoa(b.body.Len()))
+	}
+
+	b.w.WriteHeader(b.code)
+}
+
+// headerWriter is an http.ResponseWriter that changes the headers of a response just before they
+// are written, without buffering the body.
+type headerWriter struct {
+	http.ResponseWriter
+	mutate func(h http.Header)
+
+	wroteHeader bool
+}
+
+// WriteHeader changes the headers and writes them.
+func (w *headerWriter) WriteHeader(code int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		w.mutate(w.Header())
+	}
+
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Write writes the header if needed and then b.
+func (w *headerWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush writes the header if needed and flushes the underlying ResponseWriter if it can.
+func (w *headerWriter) Flush() {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// finish changes the headers if the handler did not write them, so they are changed before net/http
+// writes them.
+func (w *headerWriter) finish() {
+	if !w.wroteHeader {
+		w.mutate(w.Header())
+	}
+}
+
+// respondedWriter is an http.ResponseWriter that records whether a response was started. Interim 1xx
+// responses do not count.
+type respondedWriter struct {
+	http.ResponseWriter
+
+	responded bool
+}
+
+// WriteHeader records a final status code and writes it.
+func (w *respondedWriter) WriteHeader(code int) {
+	if code >= http.StatusOK {
+		w.responded = true
+	}
+
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Write records the response and writes b.
+func (w *respondedWriter) Write(b []byte) (int, error) {
+	w.responded = true
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush flushes the underlying ResponseWriter if it can.
+func (w *respondedWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
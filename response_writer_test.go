@@ -0,0 +1,154 @@
+package fault
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestBufferedWriter tests bufferedWriter.
+func TestBufferedWriter(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		giveWrite  func(w http.ResponseWriter)
+		giveChange func(b *bufferedWriter)
+		wantCode   int
+		wantHeader http.Header
+		wantBody   string
+	}{
+		{
+			name:       "nothing written",
+			giveWrite:  func(w http.ResponseWriter) {},
+			wantCode:   http.StatusOK,
+			wantHeader: http.Header{"Existing": {"yes"}},
+			wantBody:   "",
+		},
+		{
+			name: "status and body",
+			giveWrite: func(w http.ResponseWriter) {
+				w.Header().Set("X-Test", "test")
+				w.WriteHeader(http.StatusTeapot)
+				w.WriteHeader(http.StatusOK)
+				_, _ = w.Write([]byte("body"))
+				w.(http.Flusher).Flush()
+			},
+			wantCode:   http.StatusTeapot,
+			wantHeader: http.Header{"Existing": {"yes"}, "X-Test": {"test"}},
+			wantBody:   "body",
+		},
+		{
+			name: "changed body updates content length",
+			giveWrite: func(w http.ResponseWriter) {
+				w.Header().Set("Content-Length", "4")
+				_, _ = w.Write([]byte("body"))
+			},
+			giveChange: func(b *bufferedWriter) {
+				b.setBody([]byte("bo"))
+			},
+			wantCode:   http.StatusOK,
+			wantHeader: http.Header{"Existing": {"yes"}, "Content-Length": {"2"}},
+			wantBody:   "bo",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			rr := httptest.NewRecorder()
+			rr.Header().Set("Existing", "yes")
+
+			b := newBufferedWriter(rr)
+			tt.giveWrite(b)
+			assert.Equal(t, 0, rr.Body.Len())
+
+			if tt.giveChange != nil {
+				tt.giveChange(b)
+			}
+			b.send()
+
+			assert.Equal(t, tt.wantCode, rr.Code)
+			assert.Equal(t, tt.wantHeader, rr.Header())
+			assert.Equal(t, tt.wantBody, rr.Body.String())
+
+			b.release()
+		})
+	}
+}
+
+// TestBufferedWriterReset tests that reset clears a bufferedWriter for reuse.
+func TestBufferedWriterReset(t *testing.T) {
+	t.Parallel()
+
+	b := newBufferedWriter(httptest.NewRecorder())
+	b.Header().Set("X-Test", "test")
+	b.WriteHeader(http.StatusTeapot)
+	_, _ = b.Write([]byte("body"))
+
+	b.reset()
+
+	assert.Nil(t, b.w)
+	assert.Empty(t, b.header)
+	assert.Equal(t, http.StatusOK, b.code)
+	assert.Equal(t, 0, b.body.Len())
+	assert.False(t, b.wroteHeader)
+}
+
+// TestBufferedWriterReleaseLarge tests that bufferedWriters with large buffers are not reused.
+func TestBufferedWriterReleaseLarge(t *testing.T) {
+	t.Parallel()
+
+	b := newBufferedWriter(httptest.NewRecorder())
+	_, _ = b.Write(make([]byte, maxPooledBufferSize+1))
+
+	// a released bufferedWriter would have been reset
+	b.release()
+	assert.Equal(t, maxPooledBufferSize+1, b.body.Len())
+}
+
+// TestHeaderWriterFlushNotFlusher tests that headerWriter.Flush works when the underlying
+// ResponseWriter cannot flush.
+func TestHeaderWriterFlushNotFlusher(t *testing.T) {
+	t.Parallel()
+
+	var mutated int
+	rr := httptest.NewRecorder()
+	w := &headerWriter{
+		ResponseWriter: struct{ http.ResponseWriter }{rr},
+		mutate:         func(h http.Header) { mutated++ },
+	}
+	w.Flush()
+	w.finish()
+
+	assert.True(t, w.wroteHeader)
+	assert.Equal(t, 1, mutated)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.False(t, rr.Flushed)
+}
+
+// TestRespondedWriter tests that respondedWriter records final responses only.
+func TestRespondedWriter(t *testing.T) {
+	t.Parallel()
+
+	rr := httptest.NewRecorder()
+	w := &respondedWriter{ResponseWriter: rr}
+
+	w.WriteHeader(http.StatusEarlyHints)
+	w.Flush()
+	assert.False(t, w.responded)
+	assert.True(t, rr.Flushed)
+
+	w.WriteHeader(http.StatusOK)
+	assert.True(t, w.responded)
+
+	w = &respondedWriter{ResponseWriter: struct{ http.ResponseWriter }{httptest.NewRecorder()}}
+	_, err := w.Write([]byte("ok"))
+	assert.NoError(t, err)
+	w.Flush()
+	assert.True(t, w.responded)
+}
@@ -0,0 +1,60 @@
+package fault
+
+import (
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+)
+
+// ReverseProxyOption configures the reverse proxy of NewReverseProxy.
+type ReverseProxyOption interface {
+	applyReverseProxy(p *httputil.ReverseProxy) error
+}
+
+func (o transportOption) applyReverseProxy(p *httputil.ReverseProxy) error {
+	p.Transport = o.transport
+	return nil
+}
+
+// NewReverseProxy returns an http.Handler that runs faults, in order, on each request and forwards
+// it to target, so the Faults can run in front of a service written in any language. target is an
+// absolute URL whose path is joined with the path of each request. The Host header of forwarded
+// requests is set to the host of target, and the X-Forwarded headers are set. Requests target
+// cannot answer get a 502 Bad Gateway. It returns ErrInvalidURL if target is nil or relative, and
+// ErrNilFault if any of faults is nil.
+//
+// Requests are sent with http.DefaultTransport unless WithTransport() sets another. Use the
+// faultproxy package to build the Faults from a config.
+func NewReverseProxy(target *url.URL, faults []*Fault, opts ...ReverseProxyOption) (http.Handler, error) {
+	if target == nil || !target.IsAbs() {
+		return nil, ErrInvalidURL
+	}
+
+	for _, f := range faults {
+		if f == nil {
+			return nil, ErrNilFault
+		}
+	}
+
+	p := &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			pr.SetURL(target)
+			pr.SetXForwarded()
+		},
+	}
+
+	// apply options
+	for _, opt := range opts {
+		err := opt.applyReverseProxy(p)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var h http.Handler = p
+	for idx := len(faults) - 1; idx >= 0; idx-- {
+		h = faults[idx].Handler(h)
+	}
+
+	return h, nil
+}
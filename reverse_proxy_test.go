@@ -0,0 +1,133 @@
+package fault
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestNewReverseProxy tests the arguments of NewReverseProxy.
+func TestNewReverseProxy(t *testing.T) {
+	t.Parallel()
+
+	f, err := NewFault(newTestInjectorNoop())
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		giveTarget *url.URL
+		giveFaults []*Fault
+		wantErr    error
+	}{
+		{
+			name:       "valid",
+			giveTarget: &url.URL{Scheme: "http", Host: "app:8080"},
+			giveFaults: []*Fault{f},
+		},
+		{
+			name:       "nil url",
+			giveTarget: nil,
+			wantErr:    ErrInvalidURL,
+		},
+		{
+			name:       "relative url",
+			giveTarget: &url.URL{Path: "/app"},
+			wantErr:    ErrInvalidURL,
+		},
+		{
+			name:       "nil fault",
+			giveTarget: &url.URL{Scheme: "http", Host: "app:8080"},
+			giveFaults: []*Fault{f, nil},
+			wantErr:    ErrNilFault,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			h, err := NewReverseProxy(tt.giveTarget, tt.giveFaults)
+			assert.Equal(t, tt.wantErr, err)
+			if tt.wantErr != nil {
+				assert.Nil(t, h)
+				return
+			}
+			assert.NotNil(t, h)
+		})
+	}
+}
+
+// TestReverseProxyHandler tests that a reverse proxy runs its Faults in order and forwards the
+// requests they let through to the target.
+func TestReverseProxyHandler(t *testing.T) {
+	t.Parallel()
+
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Path", r.URL.Path)
+		w.Header().Set("X-Forwarded-Host", r.Header.Get("X-Forwarded-Host"))
+		_, _ = io.WriteString(w, "target")
+	}))
+	defer target.Close()
+
+	u, err := url.Parse(target.URL + "/base")
+	assert.NoError(t, err)
+
+	teapot, err := NewFault(newTestInjectorTwoTeapot(),
+		WithEnabled(true),
+		WithParticipation(1.0),
+		WithPathAllowlist([]string{"/teapot"}),
+	)
+	assert.NoError(t, err)
+	errorFault, err := NewFault(newTestInjector500s(),
+		WithEnabled(true),
+		WithParticipation(1.0),
+		WithPathAllowlist([]string{"/teapot", "/error"}),
+	)
+	assert.NoError(t, err)
+
+	h, err := NewReverseProxy(u, []*Fault{teapot, errorFault})
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		givePath string
+		wantCode int
+		wantBody string
+	}{
+		{
+			name:     "forwarded",
+			givePath: "/users",
+			wantCode: http.StatusOK,
+			wantBody: "target",
+		},
+		{
+			name:     "first fault",
+			givePath: "/teapot",
+			wantCode: http.StatusTeapot,
+		},
+		{
+			name:     "second fault",
+			givePath: "/error",
+			wantCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "http://proxy.example.com"+tt.givePath, nil))
+
+			assert.Equal(t, tt.wantCode, rr.Code)
+			if tt.wantBody != "" {
+				assert.Equal(t, tt.wantBody, rr.Body.String())
+				assert.Equal(t, "/base"+tt.givePath, rr.Header().Get("X-Path"))
+				assert.Equal(t, "proxy.example.com", rr.Header().Get("X-Forwarded-Host"))
+			}
+		})
+	}
+}
@@ -0,0 +1,187 @@
+package fault
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// Router runs different Faults on different routes, registered with net/http ServeMux patterns such
+// as "GET /users/{id}", so a dozen path-scoped Faults wrap a ServeMux as a single middleware. Each
+// request runs the Faults of the one pattern a ServeMux would route it to, the most specific, and
+// requests that match no pattern run none. Routes can be registered and removed while the Router
+// handles requests.
+type Router struct {
+	mtx    sync.RWMutex
+	routes []*faultRoute
+	mux    *http.ServeMux
+}
+
+// faultRoute is a pattern of a Router and the Faults it runs.
+type faultRoute struct {
+	pattern string
+	faults  []*Fault
+}
+
+// NewRouter returns a Router without routes.
+func NewRouter() *Router {
+	return &Router{mux: http.NewServeMux()}
+}
+
+// Register adds faults to the route of pattern, after the Faults already registered on it. Faults
+// run in order, the first Fault being the outermost middleware. Register a more specific pattern
+// without faults to exempt some requests from the Faults of a broader one:
+//
+//	rt.Register("/api/", errorFault)
+//	rt.Register("GET /api/health")
+//
+// It returns ErrNilFault if a Fault is nil, and an error wrapping ErrInvalidPattern if a ServeMux
+// would refuse pattern, such as when it conflicts with a registered pattern.
+func (rt *Router) Register(pattern string, faults ...*Fault) error {
+	for _, f := range faults {
+		if f == nil {
+			return ErrNilFault
+		}
+	}
+
+	rt.mtx.Lock()
+	defer rt.mtx.Unlock()
+
+	// routes are not changed once served, so requests in flight keep the routes they were routed with
+	routes := append([]*faultRoute(nil), rt.routes...)
+	added := false
+	for idx, route := range routes {
+		if route.pattern == pattern {
+			faults = append(append([]*Fault(nil), route.faults...), faults...)
+			routes[idx] = &faultRoute{pattern: pattern, faults: faults}
+			added = true
+		}
+	}
+	if !added {
+		routes = append(routes, &faultRoute{pattern: pattern, faults: faults})
+	}
+
+	mux, err := newRouteMux(routes)
+	if err != nil {
+		return err
+	}
+
+	rt.routes = routes
+	rt.mux = mux
+
+	return nil
+}
+
+// Remove removes the route of pattern and returns true if it was registered.
+func (rt *Router) Remove(pattern string) bool {
+	rt.mtx.Lock()
+	defer rt.mtx.Unlock()
+
+	for idx, route := range rt.routes {
+		if route.pattern == pattern {
+			routes := append(rt.routes[:idx:idx], rt.routes[idx+1:]...)
+			// removing a pattern cannot make the others conflict
+			rt.mux, _ = newRouteMux(routes)
+			rt.routes = routes
+			return true
+		}
+	}
+
+	return false
+}
+
+// Patterns returns the registered patterns in the order they were registered.
+func (rt *Router) Patterns() []string {
+	rt.mtx.RLock()
+	defer rt.mtx.RUnlock()
+
+	patterns := make([]string, 0, len(rt.routes))
+	for _, route := range rt.routes {
+		patterns = append(patterns, route.pattern)
+	}
+
+	return patterns
+}
+
+// Faults returns the Faults registered on pattern in the order they run, or nil if pattern is not
+// registered.
+func (rt *Router) Faults(pattern string) []*Fault {
+	rt.mtx.RLock()
+	defer rt.mtx.RUnlock()
+
+	for _, route := range rt.routes {
+		if route.pattern == pattern {
+			return append([]*Fault(nil), route.faults...)
+		}
+	}
+
+	return nil
+}
+
+// route returns the route of r, or nil if r matches no pattern.
+func (rt *Router) route(r *http.Request) *faultRoute {
+	rt.mtx.RLock()
+	mux := rt.mux
+	rt.mtx.RUnlock()
+
+	// a ServeMux records the matched pattern on the request, so give it a shallow copy
+	w := &routeWriter{}
+	mux.ServeHTTP(w, r.WithContext(r.Context()))
+
+	return w.route
+}
+
+// Pattern returns the pattern r is routed to, or false if r matches no pattern.
+func (rt *Router) Pattern(r *http.Request) (string, bool) {
+	route := rt.route(r)
+	if route == nil {
+		return "", false
+	}
+
+	return route.pattern, true
+}
+
+// Handler runs the Faults of the route of each request and then next.
+func (rt *Router) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := rt.route(r)
+		if route == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		h := next
+		for idx := len(route.faults) - 1; idx >= 0; idx-- {
+			h = route.faults[idx].Handler(h)
+		}
+
+		h.ServeHTTP(w, r)
+	})
+}
+
+// newRouteMux returns a ServeMux that routes requests to routes. It returns an error wrapping
+// ErrInvalidPattern if a pattern is invalid or conflicts with another.
+func newRouteMux(routes []*faultRoute) (mux *http.ServeMux, err error) {
+	mux = http.NewServeMux()
+
+	// a ServeMux panics on invalid patterns
+	defer func() {
+		if rec := recover(); rec != nil {
+			mux, err = nil, fmt.Errorf("%w: %v", ErrInvalidPattern, rec)
+		}
+	}()
+	for _, route := range routes {
+		route := route
+		mux.Handle(route.pattern, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.(*routeWriter).route = route
+		}))
+	}
+
+	return mux, nil
+}
+
+// routeWriter discards the response of a ServeMux and receives the route of a matched request.
+type routeWriter struct {
+	patternWriter
+	route *faultRoute
+}
@@ -0,0 +1,196 @@
+package fault
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testRouterFault returns an enabled Fault that always runs i.
+func testRouterFault(t *testing.T, name string, i Injector) *Fault {
+	t.Helper()
+
+	f, err := NewFault(i, WithName(name), WithEnabled(true), WithParticipation(1.0))
+	assert.NoError(t, err)
+
+	return f
+}
+
+// TestRouterRegister tests Router.Register.
+func TestRouterRegister(t *testing.T) {
+	t.Parallel()
+
+	f := testRouterFault(t, "errors", newTestInjector500s())
+
+	tests := []struct {
+		name         string
+		givePattern  string
+		giveFaults   []*Fault
+		wantPatterns []string
+		wantFaults   int
+		wantErr      error
+	}{
+		{
+			name:         "new pattern",
+			givePattern:  "GET /users/{id}",
+			giveFaults:   []*Fault{f},
+			wantPatterns: []string{"/api/", "GET /api/health", "GET /users/{id}"},
+			wantFaults:   1,
+		},
+		{
+			name:         "registered pattern",
+			givePattern:  "/api/",
+			giveFaults:   []*Fault{f},
+			wantPatterns: []string{"/api/", "GET /api/health"},
+			wantFaults:   2,
+		},
+		{
+			name:         "no faults",
+			givePattern:  "GET /users/{id}",
+			wantPatterns: []string{"/api/", "GET /api/health", "GET /users/{id}"},
+			wantFaults:   0,
+		},
+		{
+			name:         "nil fault",
+			givePattern:  "GET /users/{id}",
+			giveFaults:   []*Fault{nil},
+			wantPatterns: []string{"/api/", "GET /api/health"},
+			wantErr:      ErrNilFault,
+		},
+		{
+			name:         "invalid pattern",
+			givePattern:  "GET",
+			giveFaults:   []*Fault{f},
+			wantPatterns: []string{"/api/", "GET /api/health"},
+			wantErr:      ErrInvalidPattern,
+		},
+		{
+			name:         "conflicting pattern",
+			givePattern:  "/{version}/health",
+			giveFaults:   []*Fault{f},
+			wantPatterns: []string{"/api/", "GET /api/health"},
+			wantErr:      ErrInvalidPattern,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			rt := NewRouter()
+			assert.NoError(t, rt.Register("/api/", f))
+			assert.NoError(t, rt.Register("GET /api/health"))
+
+			err := rt.Register(tt.givePattern, tt.giveFaults...)
+
+			assert.True(t, errors.Is(err, tt.wantErr), err)
+			assert.Equal(t, tt.wantPatterns, rt.Patterns())
+			if tt.wantErr == nil {
+				assert.Len(t, rt.Faults(tt.givePattern), tt.wantFaults)
+			}
+		})
+	}
+}
+
+// TestRouterHandler tests that a Router runs the Faults of the route of each request.
+func TestRouterHandler(t *testing.T) {
+	t.Parallel()
+
+	rt := NewRouter()
+	assert.NoError(t, rt.Register("GET /users/{id}", testRouterFault(t, "errors", newTestInjector500s())))
+	assert.NoError(t, rt.Register("/api/", testRouterFault(t, "one", newTestInjectorOneOK())))
+	assert.NoError(t, rt.Register("/api/", testRouterFault(t, "two", newTestInjectorTwoTeapot())))
+	assert.NoError(t, rt.Register("GET /api/health"))
+
+	h := rt.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(testHandlerCode)
+		_, _ = w.Write([]byte(testHandlerBody))
+	}))
+
+	tests := []struct {
+		name        string
+		giveMethod  string
+		givePath    string
+		wantPattern string
+		wantCode    int
+		wantBody    string
+	}{
+		{
+			name:        "users",
+			giveMethod:  http.MethodGet,
+			givePath:    "/users/1",
+			wantPattern: "GET /users/{id}",
+			wantCode:    http.StatusInternalServerError,
+			wantBody:    http.StatusText(http.StatusInternalServerError) + "\n",
+		},
+		{
+			name:       "users other method",
+			giveMethod: http.MethodDelete,
+			givePath:   "/users/1",
+			wantCode:   testHandlerCode,
+			wantBody:   testHandlerBody,
+		},
+		{
+			name:        "api in order",
+			giveMethod:  http.MethodPost,
+			givePath:    "/api/orders",
+			wantPattern: "/api/",
+			wantCode:    http.StatusOK,
+			wantBody:    "onetwo" + testHandlerBody,
+		},
+		{
+			name:        "exempt route",
+			giveMethod:  http.MethodGet,
+			givePath:    "/api/health",
+			wantPattern: "GET /api/health",
+			wantCode:    testHandlerCode,
+			wantBody:    testHandlerBody,
+		},
+		{
+			name:       "no route",
+			giveMethod: http.MethodGet,
+			givePath:   "/",
+			wantCode:   testHandlerCode,
+			wantBody:   testHandlerBody,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(tt.giveMethod, tt.givePath, nil)
+			rr := httptest.NewRecorder()
+			h.ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.wantCode, rr.Code)
+			assert.Equal(t, tt.wantBody, rr.Body.String())
+
+			pattern, ok := rt.Pattern(req)
+			assert.Equal(t, tt.wantPattern, pattern)
+			assert.Equal(t, tt.wantPattern != "", ok)
+		})
+	}
+}
+
+// TestRouterRemove tests Router.Remove.
+func TestRouterRemove(t *testing.T) {
+	t.Parallel()
+
+	rt := NewRouter()
+	assert.NoError(t, rt.Register("/api/", testRouterFault(t, "errors", newTestInjector500s())))
+	assert.NoError(t, rt.Register("GET /users/{id}"))
+
+	assert.True(t, rt.Remove("/api/"))
+	assert.False(t, rt.Remove("/api/"))
+	assert.Equal(t, []string{"GET /users/{id}"}, rt.Patterns())
+	assert.Nil(t, rt.Faults("/api/"))
+
+	_, ok := rt.Pattern(httptest.NewRequest(http.MethodGet, "/api/orders", nil))
+	assert.False(t, ok)
+}
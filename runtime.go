@@ -0,0 +1,511 @@
+package fault
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Runtime keys read by Envoy's HTTP fault filter. Bind Faults to them to reuse the values an
+// Envoy deployment already sets.
+const (
+	// RuntimeAbortPercent is the rate of requests aborted by Envoy's HTTP fault filter.
+	RuntimeAbortPercent = "fault.http.abort.abort_percent"
+	// RuntimeDelayPercent is the rate of requests delayed by Envoy's HTTP fault filter.
+	RuntimeDelayPercent = "fault.http.delay.fixed_delay_percent"
+)
+
+// defaultReloadInterval is how often a Runtime reloads its disk layers by default.
+const defaultReloadInterval = 10 * time.Second
+
+// maxRuntimeBodySize is the largest form accepted by Runtime.AdminHandler.
+const maxRuntimeBodySize = 1 << 20
+
+// ErrEmptyRuntimeKey when a Runtime key is empty.
+var ErrEmptyRuntimeKey = errors.New("runtime key must not be empty")
+
+// Runtime holds runtime values in the layout of Envoy's runtime: disk layers, where every file
+// below a directory is a key named by its path with "/" replaced by ".", overridden by an admin
+// layer set at runtime. Faults bound to a key have their participation set from its value whenever
+// the value changes.
+type Runtime struct {
+	dirs     []string
+	interval time.Duration
+	clock    Clock
+
+	mtx      sync.Mutex
+	disk     map[string]string
+	admin    map[string]string
+	bindings []*runtimeBinding
+	approval *approval
+	// tokens are the AdminTokens that were issued, keyed by the hash of their secret.
+	tokens map[string]*AdminToken
+
+	runMtx sync.Mutex
+	stop   chan struct{}
+	done   chan struct{}
+}
+
+// runtimeBinding sets the participation of a Fault from a Runtime key.
+type runtimeBinding struct {
+	fault   *Fault
+	key     string
+	def     FractionalPercent
+	applied FractionalPercent
+	// set is false until applied has been set on the Fault.
+	set bool
+}
+
+// RuntimeOption configures a Runtime.
+type RuntimeOption interface {
+	applyRuntime(r *Runtime) error
+}
+
+func (o clockOption) applyRuntime(r *Runtime) error {
+	r.clock = o.clock
+	return nil
+}
+
+type diskLayersOption []string
+
+func (o diskLayersOption) applyRuntime(r *Runtime) error {
+	r.dirs = append(r.dirs, o...)
+	return nil
+}
+
+// WithDiskLayers adds directories to read runtime values from, like Envoy's disk layers. Later
+// directories override earlier ones. A directory may be a symlink that is swapped to update every
+// value at once, and a directory that does not exist is an empty layer. Files and directories
+// whose names start with "." are ignored.
+func WithDiskLayers(dirs ...string) RuntimeOption {
+	return diskLayersOption(dirs)
+}
+
+// ReloadIntervalOption configures things that reload periodically.
+type ReloadIntervalOption interface {
+	RuntimeOption
+	DynamicFaultOption
+}
+
+type reloadIntervalOption time.Duration
+
+func (o reloadIntervalOption) applyRuntime(r *Runtime) error {
+	if o <= 0 {
+		return ErrInvalidInterval
+	}
+
+	r.interval = time.Duration(o)
+
+	return nil
+}
+
+// WithReloadInterval sets how often a started Runtime reloads its disk layers, or a started
+// DynamicFault its config. Default 10s.
+func WithReloadInterval(d time.Duration) ReloadIntervalOption {
+	return reloadIntervalOption(d)
+}
+
+// NewRuntime returns a Runtime with its disk layers loaded. Call Start to reload them periodically.
+func NewRuntime(opts ...RuntimeOption) (*Runtime, error) {
+	// set defaults
+	r := &Runtime{
+		interval: defaultReloadInterval,
+		clock:    NewRealClock(),
+		disk:     map[string]string{},
+		admin:    map[string]string{},
+	}
+
+	// apply options
+	for _, opt := range opts {
+		err := opt.applyRuntime(r)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err := r.Reload()
+	if err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// Reload reads the disk layers again and updates the bound Faults. If a layer cannot be read the
+// Runtime keeps its previous values and returns the error. A bound Fault whose participation limit
+// rejects its new value keeps its participation until a later reload or Set, and Reload returns
+// the first such error.
+func (r *Runtime) Reload() error {
+	disk := map[string]string{}
+	for _, dir := range r.dirs {
+		err := readRuntimeLayer(dir, disk)
+		if err != nil {
+			return err
+		}
+	}
+
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	r.disk = disk
+
+	return r.apply()
+}
+
+// readRuntimeLayer adds the values of the disk layer at dir to values.
+func readRuntimeLayer(dir string, values map[string]string) error {
+	root, err := filepath.EvalSymlinks(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	return readRuntimeDir(root, "", values)
+}
+
+// readRuntimeDir adds the files below dir to values with their keys prefixed by prefix. Symlinks
+// are followed.
+func readRuntimeDir(dir, prefix string, values map[string]string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+
+		path := filepath.Join(dir, e.Name())
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			err = readRuntimeDir(path, prefix+e.Name()+".", values)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		values[prefix+e.Name()] = strings.TrimSpace(string(b))
+	}
+
+	return nil
+}
+
+// Get returns the value of key from the admin layer, or else the last disk layer that has it.
+func (r *Runtime) Get(key string) (string, bool) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	return r.get(key)
+}
+
+// get is Get for callers holding r.mtx.
+func (r *Runtime) get(key string) (string, bool) {
+	return getIn(r.admin, r.disk, key)
+}
+
+// getIn returns the value of key from admin, or else from disk.
+func getIn(admin, disk map[string]string, key string) (string, bool) {
+	if v, ok := admin[key]; ok {
+		return v, true
+	}
+
+	v, ok := disk[key]
+
+	return v, ok
+}
+
+// Set sets key in the admin layer, overriding the disk layers, and updates the bound Faults. As
+// with Envoy, an empty value removes the override. If the participation limit of a bound Fault
+// rejects the new value, the admin layer is left unchanged and Set returns the error.
+func (r *Runtime) Set(key, value string) error {
+	if key == "" {
+		return ErrEmptyRuntimeKey
+	}
+
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	prev, had := r.admin[key]
+	setRuntimeValue(r.admin, key, value, true)
+
+	err := r.apply()
+	if err != nil {
+		setRuntimeValue(r.admin, key, prev, had)
+		// restoring lowers participation, which is never limited, or retries bindings that
+		// already rejected the previous value
+		_ = r.apply()
+
+		return err
+	}
+
+	return nil
+}
+
+// setRuntimeValue sets key to value in layer, or removes it if value is empty or ok is false.
+func setRuntimeValue(layer map[string]string, key, value string, ok bool) {
+	if !ok || value == "" {
+		delete(layer, key)
+		return
+	}
+
+	layer[key] = value
+}
+
+// Values returns every key and its value, with the admin layer applied over the disk layers.
+func (r *Runtime) Values() map[string]string {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	values := make(map[string]string, len(r.disk)+len(r.admin))
+	for k, v := range r.disk {
+		values[k] = v
+	}
+	for k, v := range r.admin {
+		values[k] = v
+	}
+
+	return values
+}
+
+// Fraction returns the value of key as a FractionalPercent, or def if the key is unset or invalid.
+// Like Envoy, an integer value is a Numerator over the Denominator of def, and a JSON object such as
+// {"numerator": 5, "denominator": "TEN_THOUSAND"} sets both. Values above 100% are invalid.
+func (r *Runtime) Fraction(key string, def FractionalPercent) FractionalPercent {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	return r.fraction(key, def)
+}
+
+// fraction is Fraction for callers holding r.mtx.
+func (r *Runtime) fraction(key string, def FractionalPercent) FractionalPercent {
+	return fractionIn(r.admin, r.disk, key, def)
+}
+
+// fractionIn is Fraction with the admin layer admin over the disk layers disk.
+func fractionIn(admin, disk map[string]string, key string, def FractionalPercent) FractionalPercent {
+	v, ok := getIn(admin, disk, key)
+	if !ok {
+		return def
+	}
+
+	p, ok := parseRuntimeFraction(v, def.Denominator)
+	if !ok {
+		return def
+	}
+
+	return p
+}
+
+// runtimeDenominators are the names of Envoy's FractionalPercent denominators.
+var runtimeDenominators = map[string]Denominator{ //nolint:gochecknoglobals
+	"HUNDRED":      PerHundred,
+	"TEN_THOUSAND": PerTenThousand,
+	"MILLION":      PerMillion,
+}
+
+// parseRuntimeFraction parses v as an integer Numerator over d or a JSON FractionalPercent.
+func parseRuntimeFraction(v string, d Denominator) (FractionalPercent, bool) {
+	p := FractionalPercent{Denominator: d}
+
+	n, err := strconv.ParseUint(v, 10, 32)
+	if err == nil {
+		p.Numerator = uint32(n)
+	} else {
+		var obj struct {
+			Numerator   uint32 `json:"numerator"`
+			Denominator string `json:"denominator"`
+		}
+		if json.Unmarshal([]byte(v), &obj) != nil {
+			return FractionalPercent{}, false
+		}
+
+		p.Numerator = obj.Numerator
+		if obj.Denominator != "" {
+			d, ok := runtimeDenominators[obj.Denominator]
+			if !ok {
+				return FractionalPercent{}, false
+			}
+			p.Denominator = d
+		}
+	}
+
+	_, err = p.perMillion()
+
+	return p, err == nil
+}
+
+// Bind sets the participation of f from key now and whenever the value of key changes, using def
+// when the key is unset or invalid. Bind f to RuntimeAbortPercent or RuntimeDelayPercent to follow
+// the values of Envoy's fault filter. If the participation limit of f rejects the value, Bind
+// returns the error and f keeps its participation until the value is applied by a later change.
+func (r *Runtime) Bind(f *Fault, key string, def FractionalPercent) error {
+	if f == nil {
+		return ErrNilFault
+	}
+
+	if key == "" {
+		return ErrEmptyRuntimeKey
+	}
+
+	_, err := def.perMillion()
+	if err != nil {
+		return err
+	}
+
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	b := &runtimeBinding{fault: f, key: key, def: def}
+	r.bindings = append(r.bindings, b)
+
+	// the value is always valid, but may be beyond the participation limit of f
+	return b.apply(r.fraction(key, def))
+}
+
+// apply updates the participation of every bound Fault whose value changed and returns the first
+// error. The caller must hold r.mtx.
+func (r *Runtime) apply() error {
+	var first error
+	for _, b := range r.bindings {
+		p := r.fraction(b.key, b.def)
+		if b.set && p == b.applied {
+			continue
+		}
+
+		err := b.apply(p)
+		if err != nil && first == nil {
+			first = err
+		}
+	}
+
+	return first
+}
+
+// apply sets the participation of the bound Fault to p, remembering p unless it was rejected.
+func (b *runtimeBinding) apply(p FractionalPercent) error {
+	err := b.fault.SetFractionalParticipation(p)
+	if err != nil {
+		return err
+	}
+
+	b.applied = p
+	b.set = true
+
+	return nil
+}
+
+// Start begins reloading the disk layers every interval in a new goroutine. Values stay unchanged
+// while a layer cannot be read. It does nothing if the Runtime is already started.
+func (r *Runtime) Start() {
+	r.runMtx.Lock()
+	defer r.runMtx.Unlock()
+
+	if r.stop != nil {
+		return
+	}
+
+	r.stop = make(chan struct{})
+	r.done = make(chan struct{})
+
+	go r.run(r.stop, r.done)
+}
+
+// Stop stops reloading and waits for a reload in progress to finish. It does nothing if the Runtime
+// is not started.
+func (r *Runtime) Stop() {
+	r.runMtx.Lock()
+	defer r.runMtx.Unlock()
+
+	if r.stop == nil {
+		return
+	}
+
+	close(r.stop)
+	<-r.done
+
+	r.stop = nil
+	r.done = nil
+}
+
+// run calls Reload every interval until stop is closed.
+func (r *Runtime) run(stop <-chan struct{}, done chan<- struct{}) {
+	defer close(done)
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-r.clock.After(r.interval):
+			_ = r.Reload()
+		}
+	}
+}
+
+// AdminHandler returns an http.Handler for the admin layer, like Envoy's /runtime and
+// /runtime_modify endpoints. GET responds with every value as a JSON object. POST sets each key in
+// the query string or form to its value, and an empty value removes the override. With two-person
+// approval, a POST that needs approval responds 202 Accepted with the PendingChange instead, and a
+// POST with the ApprovalHeader approves the PendingChange with that token. Once an AdminToken is
+// issued, every request must send a token that allows it.
+func (r *Runtime) AdminHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		t, err := r.authorize(req)
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		switch req.Method {
+		case http.MethodGet:
+			err = permit(t, AdminRead)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusForbidden)
+				return
+			}
+		case http.MethodPost:
+			req.Body = http.MaxBytesReader(w, req.Body, maxRuntimeBodySize)
+			err = req.ParseForm()
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			values := make(map[string]string, len(req.Form))
+			for k := range req.Form {
+				values[k] = req.Form.Get(k)
+			}
+
+			if !r.serveApproval(w, req, values, t) {
+				return
+			}
+		default:
+			w.Header().Set("Allow", http.MethodGet+", "+http.MethodPost)
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		_ = json.NewEncoder(w).Encode(r.Values())
+	})
+}
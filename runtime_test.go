@@ -0,0 +1,450 @@
+package fault
+
+import (
+	"errors"
+	"io/fs"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/github/go-fault/faulttest"
+	"github.com/stretchr/testify/assert"
+)
+
+// writeRuntimeFiles writes files, keyed by path relative to dir, with their contents.
+func writeRuntimeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		assert.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	}
+}
+
+// TestNewRuntime tests NewRuntime.
+func TestNewRuntime(t *testing.T) {
+	t.Parallel()
+
+	clock := faulttest.NewClock(time.Time{})
+	dir := t.TempDir()
+	writeRuntimeFiles(t, dir, map[string]string{"fault/http/abort/abort_percent": "5\n"})
+
+	tests := []struct {
+		name         string
+		giveOptions  []RuntimeOption
+		wantDirs     []string
+		wantInterval time.Duration
+		wantClock    Clock
+		wantValues   map[string]string
+		wantErr      error
+	}{
+		{
+			name:         "defaults",
+			wantInterval: defaultReloadInterval,
+			wantClock:    NewRealClock(),
+			wantValues:   map[string]string{},
+		},
+		{
+			name: "options",
+			giveOptions: []RuntimeOption{
+				WithDiskLayers(dir),
+				WithDiskLayers(filepath.Join(dir, "missing")),
+				WithReloadInterval(time.Minute),
+				WithClock(clock),
+			},
+			wantDirs:     []string{dir, filepath.Join(dir, "missing")},
+			wantInterval: time.Minute,
+			wantClock:    clock,
+			wantValues:   map[string]string{RuntimeAbortPercent: "5"},
+		},
+		{
+			name:        "invalid interval",
+			giveOptions: []RuntimeOption{WithReloadInterval(0)},
+			wantErr:     ErrInvalidInterval,
+		},
+		{
+			name:        "option error",
+			giveOptions: []RuntimeOption{withError()},
+			wantErr:     errErrorOption,
+		},
+		{
+			name:        "unreadable layer",
+			giveOptions: []RuntimeOption{WithDiskLayers(filepath.Join(dir, "fault", "http", "abort", "abort_percent"))},
+			wantErr:     syscall.ENOTDIR,
+		},
+		{
+			name:        "unresolvable layer",
+			giveOptions: []RuntimeOption{WithDiskLayers(filepath.Join(dir, "fault", "http", "abort", "abort_percent", "x"))},
+			wantErr:     syscall.ENOTDIR,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r, err := NewRuntime(tt.giveOptions...)
+
+			if tt.wantErr != nil {
+				assert.True(t, errors.Is(err, tt.wantErr))
+				assert.Nil(t, r)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantDirs, r.dirs)
+			assert.Equal(t, tt.wantInterval, r.interval)
+			assert.Equal(t, tt.wantClock, r.clock)
+			assert.Equal(t, tt.wantValues, r.Values())
+		})
+	}
+}
+
+// TestRuntimeLayers tests that disk layers are read like Envoy's and overridden in order.
+func TestRuntimeLayers(t *testing.T) {
+	t.Parallel()
+
+	base := t.TempDir()
+	writeRuntimeFiles(t, filepath.Join(base, "v1"), map[string]string{
+		"fault/http/abort/abort_percent":       " 10 ",
+		"fault/http/delay/fixed_delay_percent": "20",
+		".hidden":                              "1",
+		".git/config":                          "1",
+	})
+	writeRuntimeFiles(t, filepath.Join(base, "v2"), map[string]string{
+		"fault/http/abort/abort_percent": "30",
+	})
+	writeRuntimeFiles(t, filepath.Join(base, "override"), map[string]string{
+		"fault/http/delay/fixed_delay_percent": "40",
+	})
+	current := filepath.Join(base, "current")
+	assert.NoError(t, os.Symlink(filepath.Join(base, "v1"), current))
+
+	r, err := NewRuntime(WithDiskLayers(current, filepath.Join(base, "override")))
+	assert.NoError(t, err)
+
+	assert.Equal(t, map[string]string{
+		RuntimeAbortPercent: "10",
+		RuntimeDelayPercent: "40",
+	}, r.Values())
+
+	v, ok := r.Get(RuntimeAbortPercent)
+	assert.True(t, ok)
+	assert.Equal(t, "10", v)
+
+	_, ok = r.Get("missing")
+	assert.False(t, ok)
+
+	// swapping the symlink and reloading updates every value at once
+	next := filepath.Join(base, "next")
+	assert.NoError(t, os.Symlink(filepath.Join(base, "v2"), next))
+	assert.NoError(t, os.Rename(next, current))
+	assert.NoError(t, r.Reload())
+	assert.Equal(t, map[string]string{
+		RuntimeAbortPercent: "30",
+		RuntimeDelayPercent: "40",
+	}, r.Values())
+
+	// a layer that cannot be read keeps the previous values
+	dangling := filepath.Join(base, "v2", "fault", "http", "dangling")
+	assert.NoError(t, os.Symlink(filepath.Join(base, "missing"), dangling))
+	assert.True(t, errors.Is(r.Reload(), fs.ErrNotExist))
+	assert.Equal(t, "30", r.Values()[RuntimeAbortPercent])
+	assert.NoError(t, os.Remove(dangling))
+
+	ln, err := net.Listen("unix", filepath.Join(base, "v2", "sock"))
+	assert.NoError(t, err)
+	defer ln.Close()
+	assert.Error(t, r.Reload())
+	assert.Equal(t, "30", r.Values()[RuntimeAbortPercent])
+}
+
+// TestRuntimeSet tests that the admin layer overrides the disk layers.
+func TestRuntimeSet(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	writeRuntimeFiles(t, dir, map[string]string{"fault/http/abort/abort_percent": "10"})
+
+	r, err := NewRuntime(WithDiskLayers(dir))
+	assert.NoError(t, err)
+
+	assert.Equal(t, ErrEmptyRuntimeKey, r.Set("", "1"))
+
+	assert.NoError(t, r.Set(RuntimeAbortPercent, "50"))
+	assert.NoError(t, r.Set("custom.key", "on"))
+	assert.Equal(t, map[string]string{RuntimeAbortPercent: "50", "custom.key": "on"}, r.Values())
+
+	// reloading keeps the admin layer
+	assert.NoError(t, r.Reload())
+	assert.Equal(t, map[string]string{RuntimeAbortPercent: "50", "custom.key": "on"}, r.Values())
+
+	// an empty value removes the override
+	assert.NoError(t, r.Set(RuntimeAbortPercent, ""))
+	assert.NoError(t, r.Set("custom.key", ""))
+	assert.Equal(t, map[string]string{RuntimeAbortPercent: "10"}, r.Values())
+}
+
+// TestRuntimeFraction tests that runtime values are parsed like Envoy's FractionalPercents.
+func TestRuntimeFraction(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		giveValue string
+		giveDef   FractionalPercent
+		want      FractionalPercent
+	}{
+		{
+			name:    "unset",
+			giveDef: FractionalPercent{Numerator: 1, Denominator: PerHundred},
+			want:    FractionalPercent{Numerator: 1, Denominator: PerHundred},
+		},
+		{
+			name:      "integer percent",
+			giveValue: "25",
+			giveDef:   FractionalPercent{Numerator: 1, Denominator: PerHundred},
+			want:      FractionalPercent{Numerator: 25, Denominator: PerHundred},
+		},
+		{
+			name:      "integer over the default denominator",
+			giveValue: "25",
+			giveDef:   BasisPoints(1),
+			want:      BasisPoints(25),
+		},
+		{
+			name:      "object",
+			giveValue: `{"numerator": 5, "denominator": "MILLION"}`,
+			giveDef:   FractionalPercent{Denominator: PerHundred},
+			want:      PartsPerMillion(5),
+		},
+		{
+			name:      "object without denominator",
+			giveValue: `{"numerator": 5}`,
+			giveDef:   BasisPoints(0),
+			want:      BasisPoints(5),
+		},
+		{
+			name:      "object with unknown denominator",
+			giveValue: `{"numerator": 5, "denominator": "THOUSAND"}`,
+			giveDef:   BasisPoints(1),
+			want:      BasisPoints(1),
+		},
+		{
+			name:      "over 100%",
+			giveValue: "101",
+			giveDef:   FractionalPercent{Numerator: 1, Denominator: PerHundred},
+			want:      FractionalPercent{Numerator: 1, Denominator: PerHundred},
+		},
+		{
+			name:      "not a number",
+			giveValue: "ten",
+			giveDef:   FractionalPercent{Numerator: 1, Denominator: PerHundred},
+			want:      FractionalPercent{Numerator: 1, Denominator: PerHundred},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r, err := NewRuntime()
+			assert.NoError(t, err)
+			assert.NoError(t, r.Set(RuntimeAbortPercent, tt.giveValue))
+
+			assert.Equal(t, tt.want, r.Fraction(RuntimeAbortPercent, tt.giveDef))
+		})
+	}
+}
+
+// TestRuntimeBind tests that bound Faults follow their runtime values.
+func TestRuntimeBind(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	writeRuntimeFiles(t, dir, map[string]string{"fault/http/abort/abort_percent": "10"})
+
+	r, err := NewRuntime(WithDiskLayers(dir))
+	assert.NoError(t, err)
+
+	hooks := newTestInjectorHooks()
+	f, err := NewFault(hooks)
+	assert.NoError(t, err)
+
+	assert.Equal(t, ErrNilFault, r.Bind(nil, RuntimeAbortPercent, BasisPoints(0)))
+	assert.Equal(t, ErrEmptyRuntimeKey, r.Bind(f, "", BasisPoints(0)))
+	assert.Equal(t, ErrInvalidDenominator, r.Bind(f, RuntimeAbortPercent, FractionalPercent{}))
+
+	// binding applies the current value
+	assert.NoError(t, r.Bind(f, RuntimeAbortPercent, FractionalPercent{Denominator: PerHundred}))
+	assert.Equal(t, uint32(100000+1), f.perMillion.Load())
+	assert.Equal(t, 1, hooks.configChanges)
+
+	// an unchanged value is not applied again
+	assert.NoError(t, r.Reload())
+	assert.Equal(t, 1, hooks.configChanges)
+
+	// the admin layer overrides the disk layer
+	assert.NoError(t, r.Set(RuntimeAbortPercent, "50"))
+	assert.Equal(t, uint32(500000+1), f.perMillion.Load())
+	assert.Equal(t, 2, hooks.configChanges)
+
+	// an invalid value falls back to the default
+	assert.NoError(t, r.Set(RuntimeAbortPercent, "invalid"))
+	assert.Equal(t, uint32(0+1), f.perMillion.Load())
+	assert.Equal(t, 3, hooks.configChanges)
+
+	// the disk layer applies again once the override is removed
+	assert.NoError(t, r.Set(RuntimeAbortPercent, ""))
+	writeRuntimeFiles(t, dir, map[string]string{"fault/http/abort/abort_percent": "20"})
+	assert.NoError(t, r.Reload())
+	assert.Equal(t, uint32(200000+1), f.perMillion.Load())
+	assert.Equal(t, 5, hooks.configChanges)
+}
+
+// TestRuntimeStartStop tests that a started Runtime reloads its disk layers.
+func TestRuntimeStartStop(t *testing.T) {
+	t.Parallel()
+
+	clock := faulttest.NewClock(time.Time{})
+	dir := t.TempDir()
+	writeRuntimeFiles(t, dir, map[string]string{"fault/http/abort/abort_percent": "10"})
+
+	r, err := NewRuntime(WithDiskLayers(dir), WithReloadInterval(time.Second), WithClock(clock))
+	assert.NoError(t, err)
+
+	// stopping before starting does nothing
+	r.Stop()
+
+	r.Start()
+	r.Start()
+
+	clock.BlockUntil(1)
+	writeRuntimeFiles(t, dir, map[string]string{"fault/http/abort/abort_percent": "20"})
+	clock.Advance(time.Second)
+
+	// the next wait starts after the reload
+	clock.BlockUntil(1)
+	v, _ := r.Get(RuntimeAbortPercent)
+	assert.Equal(t, "20", v)
+
+	r.Stop()
+	r.Stop()
+
+	// the Runtime can be started again after stopping
+	r.Start()
+	clock.BlockUntil(2)
+	r.Stop()
+}
+
+// TestRuntimeAdminHandler tests Runtime.AdminHandler.
+func TestRuntimeAdminHandler(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		giveMethod  string
+		giveTarget  string
+		giveBody    string
+		wantCode    int
+		wantBody    string
+		wantAllow   string
+		wantAbort   string
+		wantAbortOK bool
+	}{
+		{
+			name:        "get",
+			giveMethod:  http.MethodGet,
+			giveTarget:  "/runtime",
+			wantCode:    http.StatusOK,
+			wantBody:    `{"fault.http.abort.abort_percent":"10"}` + "\n",
+			wantAbort:   "10",
+			wantAbortOK: true,
+		},
+		{
+			name:        "post query",
+			giveMethod:  http.MethodPost,
+			giveTarget:  "/runtime_modify?" + url.Values{RuntimeAbortPercent: {"50"}, "other": {"1"}}.Encode(),
+			wantCode:    http.StatusOK,
+			wantBody:    `{"fault.http.abort.abort_percent":"50","other":"1"}` + "\n",
+			wantAbort:   "50",
+			wantAbortOK: true,
+		},
+		{
+			name:        "post form removes override",
+			giveMethod:  http.MethodPost,
+			giveTarget:  "/runtime_modify",
+			giveBody:    url.Values{RuntimeAbortPercent: {""}}.Encode(),
+			wantCode:    http.StatusOK,
+			wantBody:    `{}` + "\n",
+			wantAbortOK: false,
+		},
+		{
+			name:        "post empty key",
+			giveMethod:  http.MethodPost,
+			giveTarget:  "/runtime_modify?=1",
+			wantCode:    http.StatusBadRequest,
+			wantBody:    ErrEmptyRuntimeKey.Error() + "\n",
+			wantAbort:   "10",
+			wantAbortOK: true,
+		},
+		{
+			name:        "post bad form",
+			giveMethod:  http.MethodPost,
+			giveTarget:  "/runtime_modify",
+			giveBody:    "%zz",
+			wantCode:    http.StatusBadRequest,
+			wantAbort:   "10",
+			wantAbortOK: true,
+		},
+		{
+			name:        "put",
+			giveMethod:  http.MethodPut,
+			giveTarget:  "/runtime",
+			wantCode:    http.StatusMethodNotAllowed,
+			wantBody:    http.StatusText(http.StatusMethodNotAllowed) + "\n",
+			wantAllow:   "GET, POST",
+			wantAbort:   "10",
+			wantAbortOK: true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r, err := NewRuntime()
+			assert.NoError(t, err)
+			assert.NoError(t, r.Set(RuntimeAbortPercent, "10"))
+
+			req := httptest.NewRequest(tt.giveMethod, tt.giveTarget, strings.NewReader(tt.giveBody))
+			if tt.giveBody != "" {
+				req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			}
+			rr := httptest.NewRecorder()
+
+			r.AdminHandler().ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.wantCode, rr.Code)
+			if tt.wantBody != "" {
+				assert.Equal(t, tt.wantBody, rr.Body.String())
+			}
+			assert.Equal(t, tt.wantAllow, rr.Header().Get("Allow"))
+
+			v, ok := r.Get(RuntimeAbortPercent)
+			assert.Equal(t, tt.wantAbortOK, ok)
+			assert.Equal(t, tt.wantAbort, v)
+		})
+	}
+}
@@ -0,0 +1,296 @@
+package fault
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrInvalidBuckets when histogram buckets are empty or not in increasing order.
+	ErrInvalidBuckets = errors.New("buckets must be non-empty and in increasing order")
+)
+
+// defaultLatencyBuckets are the upper bounds of latency histogram buckets when none are set. They
+// are shared by LatencySamplers and never modified.
+var defaultLatencyBuckets = []time.Duration{ //nolint:gochecknoglobals
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	25 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+	10 * time.Second,
+}
+
+// LatencyHistogram is a distribution of request latencies.
+type LatencyHistogram struct {
+	// Buckets are the inclusive upper bounds of each bucket, in increasing order.
+	Buckets []time.Duration `json:"buckets"`
+	// Counts are the number of requests in each bucket. The final count, at index len(Buckets),
+	// holds requests slower than the largest bucket.
+	Counts []int64 `json:"counts"`
+	// Count is the total number of requests.
+	Count int64 `json:"count"`
+	// Sum is the total latency of all requests.
+	Sum time.Duration `json:"sum"`
+}
+
+// newLatencyHistogram returns an empty LatencyHistogram with the provided buckets.
+func newLatencyHistogram(buckets []time.Duration) LatencyHistogram {
+	return LatencyHistogram{
+		Buckets: buckets,
+		Counts:  make([]int64, len(buckets)+1),
+	}
+}
+
+// observe adds a request latency to the histogram.
+func (h *LatencyHistogram) observe(d time.Duration) {
+	idx := sort.Search(len(h.Buckets), func(i int) bool { return d <= h.Buckets[i] })
+	h.Counts[idx]++
+	h.Count++
+	h.Sum += d
+}
+
+// valid returns true if the buckets are increasing and positive and the counts add up to Count.
+func (h LatencyHistogram) valid() bool {
+	if len(h.Buckets) == 0 || len(h.Counts) != len(h.Buckets)+1 || h.Buckets[0] <= 0 {
+		return false
+	}
+	for idx := 1; idx < len(h.Buckets); idx++ {
+		if h.Buckets[idx] <= h.Buckets[idx-1] {
+			return false
+		}
+	}
+
+	var total int64
+	for _, c := range h.Counts {
+		if c < 0 {
+			return false
+		}
+		total += c
+	}
+
+	return total == h.Count
+}
+
+// copy returns a deep copy of the histogram.
+func (h LatencyHistogram) copy() LatencyHistogram {
+	counts := make([]int64, len(h.Counts))
+	copy(counts, h.Counts)
+	h.Counts = counts
+
+	return h
+}
+
+// Mean returns the average latency, or 0 if there are no requests.
+func (h LatencyHistogram) Mean() time.Duration {
+	if h.Count == 0 {
+		return 0
+	}
+
+	return h.Sum / time.Duration(h.Count)
+}
+
+// Quantile returns the upper bound of the bucket that holds quantile q (0.0 <= q <= 1.0) of
+// requests. It returns 0 if there are no requests and the largest bucket if the quantile falls in
+// the overflow bucket.
+func (h LatencyHistogram) Quantile(q float64) time.Duration {
+	if h.Count == 0 || len(h.Buckets) == 0 {
+		return 0
+	}
+
+	rank := int64(math.Ceil(q * float64(h.Count)))
+	if rank < 1 {
+		rank = 1
+	}
+
+	var seen int64
+	for idx, c := range h.Counts[:len(h.Buckets)] {
+		seen += c
+		if seen >= rank {
+			return h.Buckets[idx]
+		}
+	}
+
+	return h.Buckets[len(h.Buckets)-1]
+}
+
+// RouteLatency holds the baseline and faulted latency distributions of a single route.
+type RouteLatency struct {
+	// Baseline is the latency of requests the Injector did not run on.
+	Baseline LatencyHistogram
+	// Faulted is the latency of requests the Injector ran on.
+	Faulted LatencyHistogram
+}
+
+// LatencySampler records the latency of requests passing through a Fault, keeping the baseline (not
+// injected) and faulted (injected) distributions of each route separately. Compare them to quantify
+// the impact of an experiment instead of only counting injections. Add a LatencySampler to a Fault
+// with the WithLatencySampler option.
+type LatencySampler struct {
+	buckets []time.Duration
+	routeF  func(r *http.Request) string
+	clock   Clock
+
+	// mtx protects routes.
+	mtx    sync.Mutex
+	routes map[string]*RouteLatency
+}
+
+// LatencySamplerOption configures a LatencySampler.
+type LatencySamplerOption interface {
+	applyLatencySampler(s *LatencySampler) error
+}
+
+type bucketsOption []time.Duration
+
+func (o bucketsOption) applyLatencySampler(s *LatencySampler) error {
+	buckets, err := o.validate()
+	if err != nil {
+		return err
+	}
+
+	s.buckets = buckets
+	return nil
+}
+
+// validate returns a copy of the buckets if they are non-empty and in increasing order.
+func (o bucketsOption) validate() ([]time.Duration, error) {
+	if len(o) == 0 {
+		return nil, ErrInvalidBuckets
+	}
+	for idx := 1; idx < len(o); idx++ {
+		if o[idx] <= o[idx-1] {
+			return nil, ErrInvalidBuckets
+		}
+	}
+
+	return append([]time.Duration(nil), o...), nil
+}
+
+// BucketsOption configures things that record latency histograms.
+type BucketsOption interface {
+	LatencySamplerOption
+	OutcomeRecorderOption
+}
+
+// WithBuckets sets the inclusive upper bounds of the latency histogram buckets, which must be in
+// increasing order. Default 5ms to 10s.
+func WithBuckets(b []time.Duration) BucketsOption {
+	return bucketsOption(b)
+}
+
+// RouteFuncOption configures things that group requests into routes.
+type RouteFuncOption interface {
+	LatencySamplerOption
+	SlowInjectorOption
+	DistributionInjectorOption
+	OutcomeRecorderOption
+}
+
+type routeFuncOption func(r *http.Request) string
+
+func (o routeFuncOption) applyLatencySampler(s *LatencySampler) error {
+	s.routeF = o
+	return nil
+}
+
+// WithRouteFunc sets the function that groups requests into routes. Default the request path.
+// Return a bounded set of values (such as route patterns instead of paths with IDs) to limit memory.
+func WithRouteFunc(f func(r *http.Request) string) RouteFuncOption {
+	return routeFuncOption(f)
+}
+
+func (o clockOption) applyLatencySampler(s *LatencySampler) error {
+	s.clock = o.clock
+	return nil
+}
+
+// NewLatencySampler returns a LatencySampler.
+func NewLatencySampler(opts ...LatencySamplerOption) (*LatencySampler, error) {
+	// set defaults
+	s := &LatencySampler{
+		buckets: defaultLatencyBuckets,
+		routeF:  func(r *http.Request) string { return r.URL.Path },
+		clock:   NewRealClock(),
+		routes:  make(map[string]*RouteLatency),
+	}
+
+	// apply options
+	for _, opt := range opts {
+		err := opt.applyLatencySampler(s)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
+
+// start returns a function that records the latency of the request since start was called.
+func (s *LatencySampler) start(r *http.Request, injected bool) func() {
+	begin := s.clock.Now()
+
+	return func() {
+		s.observe(s.routeF(r), injected, s.clock.Now().Sub(begin))
+	}
+}
+
+// observe records the latency of a request to route.
+func (s *LatencySampler) observe(route string, injected bool, d time.Duration) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	rl, ok := s.routes[route]
+	if !ok {
+		rl = &RouteLatency{
+			Baseline: newLatencyHistogram(s.buckets),
+			Faulted:  newLatencyHistogram(s.buckets),
+		}
+		s.routes[route] = rl
+	}
+
+	if injected {
+		rl.Faulted.observe(d)
+	} else {
+		rl.Baseline.observe(d)
+	}
+}
+
+// Snapshot returns a copy of the latency distributions recorded for each route.
+func (s *LatencySampler) Snapshot() map[string]RouteLatency {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	snap := make(map[string]RouteLatency, len(s.routes))
+	for route, rl := range s.routes {
+		snap[route] = RouteLatency{
+			Baseline: rl.Baseline.copy(),
+			Faulted:  rl.Faulted.copy(),
+		}
+	}
+
+	return snap
+}
+
+type latencySamplerOption struct {
+	sampler *LatencySampler
+}
+
+func (o latencySamplerOption) applyFault(f *Fault) error {
+	f.sampler = o.sampler
+	return nil
+}
+
+// WithLatencySampler records the latency of every request the Fault sees into the LatencySampler.
+func WithLatencySampler(s *LatencySampler) Option {
+	return latencySamplerOption{s}
+}
@@ -0,0 +1,252 @@
+package fault
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/github/go-fault/faulttest"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestNewLatencySampler tests NewLatencySampler.
+func TestNewLatencySampler(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		giveOptions []LatencySamplerOption
+		wantBuckets []time.Duration
+		wantErr     error
+	}{
+		{
+			name:        "defaults",
+			giveOptions: nil,
+			wantBuckets: defaultLatencyBuckets,
+			wantErr:     nil,
+		},
+		{
+			name: "custom buckets",
+			giveOptions: []LatencySamplerOption{
+				WithBuckets([]time.Duration{time.Millisecond, time.Second}),
+			},
+			wantBuckets: []time.Duration{time.Millisecond, time.Second},
+			wantErr:     nil,
+		},
+		{
+			name: "empty buckets",
+			giveOptions: []LatencySamplerOption{
+				WithBuckets([]time.Duration{}),
+			},
+			wantErr: ErrInvalidBuckets,
+		},
+		{
+			name: "unsorted buckets",
+			giveOptions: []LatencySamplerOption{
+				WithBuckets([]time.Duration{time.Second, time.Millisecond}),
+			},
+			wantErr: ErrInvalidBuckets,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			s, err := NewLatencySampler(tt.giveOptions...)
+
+			assert.Equal(t, tt.wantErr, err)
+			if tt.wantErr == nil {
+				assert.Equal(t, tt.wantBuckets, s.buckets)
+			} else {
+				assert.Nil(t, s)
+			}
+		})
+	}
+}
+
+// TestLatencySamplerFault tests that a Fault records baseline and faulted latency per route.
+func TestLatencySamplerFault(t *testing.T) {
+	t.Parallel()
+
+	clock := faulttest.NewClock(time.Time{})
+
+	s, err := NewLatencySampler(
+		WithBuckets([]time.Duration{time.Millisecond, time.Second}),
+		WithRouteFunc(func(r *http.Request) string { return r.Method }),
+		WithClock(clock),
+	)
+	assert.NoError(t, err)
+
+	si, err := NewSlowInjector(500*time.Millisecond, WithClock(clock))
+	assert.NoError(t, err)
+
+	f, err := NewFault(si,
+		WithEnabled(true),
+		WithParticipation(1.0),
+		WithPathAllowlist([]string{"/slow"}),
+		WithLatencySampler(s),
+	)
+	assert.NoError(t, err)
+
+	h := f.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	done := make(chan struct{})
+	go func() {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/slow", nil))
+		close(done)
+	}()
+	clock.BlockUntil(1)
+	clock.Advance(500 * time.Millisecond)
+	<-done
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/fast", nil))
+
+	snap := s.Snapshot()
+	assert.Len(t, snap, 1)
+	assert.Equal(t, []int64{1, 0, 0}, snap["GET"].Baseline.Counts)
+	assert.Equal(t, []int64{0, 1, 0}, snap["GET"].Faulted.Counts)
+	assert.Equal(t, 500*time.Millisecond, snap["GET"].Faulted.Mean())
+	assert.Equal(t, time.Second, snap["GET"].Faulted.Quantile(0.99))
+}
+
+// TestLatencySamplerDefaultRoute tests that LatencySampler groups by path by default.
+func TestLatencySamplerDefaultRoute(t *testing.T) {
+	t.Parallel()
+
+	s, err := NewLatencySampler()
+	assert.NoError(t, err)
+
+	s.start(httptest.NewRequest("GET", "/a", nil), false)()
+	s.start(httptest.NewRequest("GET", "/b", nil), true)()
+
+	snap := s.Snapshot()
+	assert.Len(t, snap, 2)
+	assert.Equal(t, int64(1), snap["/a"].Baseline.Count)
+	assert.Equal(t, int64(1), snap["/b"].Faulted.Count)
+}
+
+// TestLatencyHistogram tests LatencyHistogram.
+func TestLatencyHistogram(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		giveBuckets  []time.Duration
+		giveObserved []time.Duration
+		wantCounts   []int64
+		wantMean     time.Duration
+		wantP0       time.Duration
+		wantP50      time.Duration
+		wantP99      time.Duration
+	}{
+		{
+			name:        "empty",
+			giveBuckets: []time.Duration{time.Millisecond, time.Second},
+			wantCounts:  []int64{0, 0, 0},
+		},
+		{
+			name:         "one bucket",
+			giveBuckets:  []time.Duration{time.Millisecond, time.Second},
+			giveObserved: []time.Duration{time.Millisecond, time.Millisecond},
+			wantCounts:   []int64{2, 0, 0},
+			wantMean:     time.Millisecond,
+			wantP0:       time.Millisecond,
+			wantP50:      time.Millisecond,
+			wantP99:      time.Millisecond,
+		},
+		{
+			name:         "spread with overflow",
+			giveBuckets:  []time.Duration{time.Millisecond, time.Second},
+			giveObserved: []time.Duration{0, 2 * time.Millisecond, 3 * time.Second, time.Minute},
+			wantCounts:   []int64{1, 1, 2},
+			wantMean:     (2*time.Millisecond + 3*time.Second + time.Minute) / 4,
+			wantP0:       time.Millisecond,
+			wantP50:      time.Second,
+			wantP99:      time.Second,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			h := newLatencyHistogram(tt.giveBuckets)
+			for _, d := range tt.giveObserved {
+				h.observe(d)
+			}
+
+			assert.Equal(t, tt.wantCounts, h.Counts)
+			assert.Equal(t, tt.wantMean, h.Mean())
+			assert.Equal(t, tt.wantP0, h.Quantile(0))
+			assert.Equal(t, tt.wantP50, h.Quantile(0.5))
+			assert.Equal(t, tt.wantP99, h.Quantile(0.99))
+		})
+	}
+}
+
+// TestLatencyHistogramValid tests LatencyHistogram.valid.
+func TestLatencyHistogramValid(t *testing.T) {
+	t.Parallel()
+
+	ms := time.Millisecond
+
+	tests := []struct {
+		name string
+		give LatencyHistogram
+		want bool
+	}{
+		{
+			name: "valid",
+			give: LatencyHistogram{Buckets: []time.Duration{ms, 2 * ms}, Counts: []int64{1, 0, 2}, Count: 3},
+			want: true,
+		},
+		{
+			name: "empty",
+			give: newLatencyHistogram(defaultLatencyBuckets),
+			want: true,
+		},
+		{
+			name: "no buckets",
+			give: LatencyHistogram{Counts: []int64{0}},
+			want: false,
+		},
+		{
+			name: "missing overflow count",
+			give: LatencyHistogram{Buckets: []time.Duration{ms}, Counts: []int64{1}, Count: 1},
+			want: false,
+		},
+		{
+			name: "zero bucket",
+			give: LatencyHistogram{Buckets: []time.Duration{0, ms}, Counts: []int64{0, 0, 0}},
+			want: false,
+		},
+		{
+			name: "unsorted buckets",
+			give: LatencyHistogram{Buckets: []time.Duration{2 * ms, ms}, Counts: []int64{0, 0, 0}},
+			want: false,
+		},
+		{
+			name: "negative count",
+			give: LatencyHistogram{Buckets: []time.Duration{ms}, Counts: []int64{2, -1}, Count: 1},
+			want: false,
+		},
+		{
+			name: "wrong total",
+			give: LatencyHistogram{Buckets: []time.Duration{ms}, Counts: []int64{1, 1}, Count: 1},
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, tt.give.valid())
+		})
+	}
+}
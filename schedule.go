@@ -0,0 +1,261 @@
+package fault
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrInvalidWindow when a Window does not end after it starts.
+	ErrInvalidWindow = errors.New("window must end after it starts")
+	// ErrInvalidCron when a cron expression cannot be parsed.
+	ErrInvalidCron = errors.New("invalid cron expression")
+	// ErrInvalidScheduleDuration when a CronSchedule is asked to stay active for less than a
+	// positive duration.
+	ErrInvalidScheduleDuration = errors.New("schedule duration must be > 0")
+)
+
+// Schedule decides when a Fault may run its Injector, such as during the hours of a game day. Pass
+// it WithSchedule().
+type Schedule interface {
+	// Active returns true if the Fault may run its Injector at t.
+	Active(t time.Time) bool
+}
+
+// ScheduleFunc is a function that satisfies Schedule.
+type ScheduleFunc func(t time.Time) bool
+
+// Active returns f(t).
+func (f ScheduleFunc) Active(t time.Time) bool {
+	return f(t)
+}
+
+type scheduleOption struct {
+	schedule Schedule
+}
+
+func (o scheduleOption) applyFault(f *Fault) error {
+	f.schedule = o.schedule
+	return nil
+}
+
+// ScheduleOption configures things that can be scheduled.
+type ScheduleOption interface {
+	Option
+	SplitFaultOption
+}
+
+// WithSchedule only lets the Fault run its Injector while s is active at the time of the Clock set
+// by WithClock(). Requests outside of the Schedule are skipped with SkipSchedule, even if the Fault
+// is enabled. Default nil, always active.
+func WithSchedule(s Schedule) ScheduleOption {
+	return scheduleOption{s}
+}
+
+// Window is a window of time. Set the location of Start and End to schedule it in a timezone.
+type Window struct {
+	// Start is when the Window starts.
+	Start time.Time `json:"start"`
+	// End is when the Window ends. The Window does not include End.
+	End time.Time `json:"end"`
+}
+
+// WindowSchedule is a Schedule that is active within any of its Windows.
+type WindowSchedule struct {
+	windows []Window
+}
+
+// NewWindowSchedule returns a WindowSchedule of windows. Each must end after it starts.
+func NewWindowSchedule(windows ...Window) (*WindowSchedule, error) {
+	for _, w := range windows {
+		if !w.End.After(w.Start) {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidWindow, w.Start)
+		}
+	}
+
+	return &WindowSchedule{windows: append([]Window(nil), windows...)}, nil
+}
+
+// Active returns true if t is within a Window.
+func (s *WindowSchedule) Active(t time.Time) bool {
+	for _, w := range s.windows {
+		if !t.Before(w.Start) && t.Before(w.End) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// CronSchedule is a Schedule that is active for a duration each time a cron expression matches.
+type CronSchedule struct {
+	expr     string
+	duration time.Duration
+	loc      *time.Location
+
+	minutes [60]bool
+	hours   [24]bool
+	days    [32]bool
+	months  [13]bool
+	weekday [7]bool
+	// anyDay and anyWeekday are true if the day of the month or the day of the week is "*". Like
+	// cron, a day matches either field when both are restricted.
+	anyDay     bool
+	anyWeekday bool
+}
+
+// NewCronSchedule returns a CronSchedule that is active for d from each minute that matches expr in
+// loc, or UTC if loc is nil. expr has the five fields of cron: minute, hour, day of month, month,
+// and day of week, where 0 and 7 are Sunday. Each field is "*", a number, a range such as "9-17",
+// or a list of them such as "1,15", optionally with a step such as "*/15". For example, a game day
+// every Tuesday from 14:00 to 16:00 in Berlin:
+//
+//	loc, _ := time.LoadLocation("Europe/Berlin")
+//	s, err := fault.NewCronSchedule("0 14 * * 2", 2*time.Hour, loc)
+func NewCronSchedule(expr string, d time.Duration, loc *time.Location) (*CronSchedule, error) {
+	if d <= 0 {
+		return nil, ErrInvalidScheduleDuration
+	}
+
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("%w: %q: must have 5 fields", ErrInvalidCron, expr)
+	}
+
+	s := &CronSchedule{
+		expr:       expr,
+		duration:   d,
+		loc:        loc,
+		anyDay:     fields[2] == "*",
+		anyWeekday: fields[4] == "*",
+	}
+
+	var weekday [8]bool
+	for idx, spec := range []struct {
+		set      []bool
+		min, max int
+	}{
+		{s.minutes[:], 0, 59},
+		{s.hours[:], 0, 23},
+		{s.days[:], 1, 31},
+		{s.months[:], 1, 12},
+		{weekday[:], 0, 7},
+	} {
+		err := parseCronField(fields[idx], spec.set, spec.min, spec.max)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q: %v", ErrInvalidCron, expr, err)
+		}
+	}
+
+	copy(s.weekday[:], weekday[:7])
+	s.weekday[0] = s.weekday[0] || weekday[7]
+
+	return s, nil
+}
+
+// parseCronField sets the values of the cron field in set, each between min and max.
+func parseCronField(field string, set []bool, min, max int) error {
+	for _, part := range strings.Split(field, ",") {
+		rng, stepStr, hasStep := strings.Cut(part, "/")
+
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepStr)
+			if err != nil || n < 1 {
+				return fmt.Errorf("invalid step %q", part)
+			}
+			step = n
+		}
+
+		lo, hi := min, max
+		if rng != "*" {
+			loStr, hiStr, isRange := strings.Cut(rng, "-")
+
+			var err error
+			lo, err = strconv.Atoi(loStr)
+			if err != nil {
+				return fmt.Errorf("invalid value %q", part)
+			}
+
+			hi = lo
+			if isRange {
+				hi, err = strconv.Atoi(hiStr)
+				if err != nil {
+					return fmt.Errorf("invalid value %q", part)
+				}
+			} else if hasStep {
+				hi = max
+			}
+		}
+
+		if lo < min || hi > max || lo > hi {
+			return fmt.Errorf("%q out of range %d-%d", part, min, max)
+		}
+
+		for v := lo; v <= hi; v += step {
+			set[v] = true
+		}
+	}
+
+	return nil
+}
+
+// String returns the cron expression, duration, and location of the CronSchedule, such as
+// "0 14 * * 2 for 2h0m0s in Europe/Berlin".
+func (s *CronSchedule) String() string {
+	return fmt.Sprintf("%s for %s in %s", s.expr, s.duration, s.loc)
+}
+
+// Active returns true if t is less than the duration of the CronSchedule after a minute that
+// matches its cron expression.
+func (s *CronSchedule) Active(t time.Time) bool {
+	m, ok := s.last(t, t.Add(-s.duration))
+	return ok && t.Before(m.Add(s.duration))
+}
+
+// last returns the latest minute at or before t that matches the cron expression, and false if
+// there is none at or after min. Days and hours that do not match are skipped whole.
+func (s *CronSchedule) last(t, min time.Time) (time.Time, bool) {
+	t = t.In(s.loc)
+	t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), 0, 0, s.loc)
+
+	for !t.Before(min) {
+		switch {
+		case !s.months[t.Month()]:
+			t = time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, s.loc).Add(-time.Minute)
+		case !s.matchDay(t):
+			t = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, s.loc).Add(-time.Minute)
+		case !s.hours[t.Hour()]:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, s.loc).Add(-time.Minute)
+		case !s.minutes[t.Minute()]:
+			t = t.Add(-time.Minute)
+		default:
+			return t, true
+		}
+	}
+
+	return time.Time{}, false
+}
+
+// matchDay returns true if the day of t matches the day of month and day of week fields.
+func (s *CronSchedule) matchDay(t time.Time) bool {
+	day, weekday := s.days[t.Day()], s.weekday[t.Weekday()]
+
+	switch {
+	case s.anyDay && s.anyWeekday:
+		return true
+	case s.anyDay:
+		return weekday
+	case s.anyWeekday:
+		return day
+	default:
+		return day || weekday
+	}
+}
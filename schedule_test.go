@@ -0,0 +1,389 @@
+package fault
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/github/go-fault/faulttest"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestFaultHandlerSchedule tests that a Fault with a Schedule only runs its Injector while the
+// Schedule is active.
+func TestFaultHandlerSchedule(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2024, time.January, 2, 14, 0, 0, 0, time.UTC)
+	clock := faulttest.NewClock(start.Add(-time.Minute))
+
+	s, err := NewWindowSchedule(Window{Start: start, End: start.Add(time.Hour)})
+	assert.NoError(t, err)
+
+	f, err := NewFault(newTestInjector500s(),
+		WithEnabled(true),
+		WithParticipation(1.0),
+		WithSchedule(s),
+		WithClock(clock),
+	)
+	assert.NoError(t, err)
+
+	h := f.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(testHandlerCode)
+	}))
+	serve := func() int {
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+		return rr.Code
+	}
+
+	assert.Equal(t, testHandlerCode, serve())
+	assert.Equal(t, SkipSchedule, ExplainRequest(f, httptest.NewRequest(http.MethodGet, "/", nil)).SkipReason)
+
+	clock.Advance(time.Minute)
+	assert.Equal(t, http.StatusInternalServerError, serve())
+	assert.True(t, ExplainRequest(f, httptest.NewRequest(http.MethodGet, "/", nil)).Eligible)
+
+	clock.Advance(time.Hour)
+	assert.Equal(t, testHandlerCode, serve())
+
+	assert.Equal(t, int64(2), f.stats.counters()["skipped_schedule"])
+}
+
+// TestScheduleFunc tests ScheduleFunc.
+func TestScheduleFunc(t *testing.T) {
+	t.Parallel()
+
+	s := ScheduleFunc(func(t time.Time) bool { return t.Hour() >= 9 })
+
+	assert.False(t, s.Active(time.Date(2024, time.January, 2, 8, 59, 0, 0, time.UTC)))
+	assert.True(t, s.Active(time.Date(2024, time.January, 2, 9, 0, 0, 0, time.UTC)))
+}
+
+// TestWindowSchedule tests NewWindowSchedule and WindowSchedule.Active.
+func TestWindowSchedule(t *testing.T) {
+	t.Parallel()
+
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	assert.NoError(t, err)
+
+	// 14:00 to 16:00 in Berlin is 13:00 to 15:00 UTC in winter
+	first := Window{
+		Start: time.Date(2024, time.January, 2, 14, 0, 0, 0, berlin),
+		End:   time.Date(2024, time.January, 2, 16, 0, 0, 0, berlin),
+	}
+	second := Window{
+		Start: time.Date(2024, time.January, 9, 14, 0, 0, 0, berlin),
+		End:   time.Date(2024, time.January, 9, 16, 0, 0, 0, berlin),
+	}
+
+	s, err := NewWindowSchedule(first, second)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name string
+		give time.Time
+		want bool
+	}{
+		{
+			name: "before",
+			give: time.Date(2024, time.January, 2, 12, 59, 0, 0, time.UTC),
+		},
+		{
+			name: "start",
+			give: time.Date(2024, time.January, 2, 13, 0, 0, 0, time.UTC),
+			want: true,
+		},
+		{
+			name: "end",
+			give: time.Date(2024, time.January, 2, 15, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "between",
+			give: time.Date(2024, time.January, 5, 14, 0, 0, 0, berlin),
+		},
+		{
+			name: "second",
+			give: time.Date(2024, time.January, 9, 15, 59, 0, 0, berlin),
+			want: true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, s.Active(tt.give))
+		})
+	}
+
+	_, err = NewWindowSchedule(first, Window{Start: first.End, End: first.Start})
+	assert.True(t, errors.Is(err, ErrInvalidWindow), err)
+
+	_, err = NewWindowSchedule(Window{Start: first.Start, End: first.Start})
+	assert.True(t, errors.Is(err, ErrInvalidWindow), err)
+}
+
+// TestNewCronSchedule tests NewCronSchedule.
+func TestNewCronSchedule(t *testing.T) {
+	t.Parallel()
+
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name         string
+		giveExpr     string
+		giveDuration time.Duration
+		giveLoc      *time.Location
+		wantString   string
+		wantErr      error
+	}{
+		{
+			name:         "utc",
+			giveExpr:     "*/15 9-17 * * 1-5",
+			giveDuration: 5 * time.Minute,
+			wantString:   "*/15 9-17 * * 1-5 for 5m0s in UTC",
+		},
+		{
+			name:         "location",
+			giveExpr:     "0 14 * * 2",
+			giveDuration: 2 * time.Hour,
+			giveLoc:      berlin,
+			wantString:   "0 14 * * 2 for 2h0m0s in Europe/Berlin",
+		},
+		{
+			name:         "zero duration",
+			giveExpr:     "0 14 * * 2",
+			giveDuration: 0,
+			wantErr:      ErrInvalidScheduleDuration,
+		},
+		{
+			name:         "too few fields",
+			giveExpr:     "0 14 * *",
+			giveDuration: time.Hour,
+			wantErr:      ErrInvalidCron,
+		},
+		{
+			name:         "invalid value",
+			giveExpr:     "x 14 * * *",
+			giveDuration: time.Hour,
+			wantErr:      ErrInvalidCron,
+		},
+		{
+			name:         "invalid range end",
+			giveExpr:     "0 9-x * * *",
+			giveDuration: time.Hour,
+			wantErr:      ErrInvalidCron,
+		},
+		{
+			name:         "invalid step",
+			giveExpr:     "*/0 * * * *",
+			giveDuration: time.Hour,
+			wantErr:      ErrInvalidCron,
+		},
+		{
+			name:         "out of range",
+			giveExpr:     "0 24 * * *",
+			giveDuration: time.Hour,
+			wantErr:      ErrInvalidCron,
+		},
+		{
+			name:         "reversed range",
+			giveExpr:     "0 17-9 * * *",
+			giveDuration: time.Hour,
+			wantErr:      ErrInvalidCron,
+		},
+		{
+			name:         "day zero",
+			giveExpr:     "0 0 0 * *",
+			giveDuration: time.Hour,
+			wantErr:      ErrInvalidCron,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			s, err := NewCronSchedule(tt.giveExpr, tt.giveDuration, tt.giveLoc)
+			assert.True(t, errors.Is(err, tt.wantErr), err)
+			if tt.wantErr != nil {
+				assert.Nil(t, s)
+				return
+			}
+
+			assert.Equal(t, tt.wantString, s.String())
+		})
+	}
+}
+
+// TestCronScheduleActive tests CronSchedule.Active.
+func TestCronScheduleActive(t *testing.T) {
+	t.Parallel()
+
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	assert.NoError(t, err)
+
+	// 2024-01-01 is a Monday
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2024, time.January, day, hour, minute, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name         string
+		giveExpr     string
+		giveDuration time.Duration
+		giveLoc      *time.Location
+		giveTime     time.Time
+		want         bool
+	}{
+		{
+			name:         "game day start",
+			giveExpr:     "0 14 * * 2",
+			giveDuration: 2 * time.Hour,
+			giveLoc:      berlin,
+			giveTime:     time.Date(2024, time.January, 2, 14, 0, 0, 0, berlin),
+			want:         true,
+		},
+		{
+			name:         "game day in utc",
+			giveExpr:     "0 14 * * 2",
+			giveDuration: 2 * time.Hour,
+			giveLoc:      berlin,
+			giveTime:     at(2, 14, 59),
+			want:         true,
+		},
+		{
+			name:         "game day end",
+			giveExpr:     "0 14 * * 2",
+			giveDuration: 2 * time.Hour,
+			giveLoc:      berlin,
+			giveTime:     at(2, 15, 0),
+		},
+		{
+			name:         "game day before",
+			giveExpr:     "0 14 * * 2",
+			giveDuration: 2 * time.Hour,
+			giveLoc:      berlin,
+			giveTime:     time.Date(2024, time.January, 2, 13, 59, 59, 0, berlin),
+		},
+		{
+			name:         "game day other weekday",
+			giveExpr:     "0 14 * * 2",
+			giveDuration: 2 * time.Hour,
+			giveLoc:      berlin,
+			giveTime:     time.Date(2024, time.January, 3, 14, 30, 0, 0, berlin),
+		},
+		{
+			name:         "step",
+			giveExpr:     "*/15 * * * *",
+			giveDuration: 5 * time.Minute,
+			giveTime:     at(2, 10, 19),
+			want:         true,
+		},
+		{
+			name:         "step gap",
+			giveExpr:     "*/15 * * * *",
+			giveDuration: 5 * time.Minute,
+			giveTime:     at(2, 10, 20),
+		},
+		{
+			name:         "range step",
+			giveExpr:     "0 9-17/4 * * *",
+			giveDuration: time.Minute,
+			giveTime:     at(2, 13, 0),
+			want:         true,
+		},
+		{
+			name:         "range step gap",
+			giveExpr:     "0 9-17/4 * * *",
+			giveDuration: time.Minute,
+			giveTime:     at(2, 11, 0),
+		},
+		{
+			name:         "value step",
+			giveExpr:     "30/10 * * * *",
+			giveDuration: time.Minute,
+			giveTime:     at(2, 11, 50),
+			want:         true,
+		},
+		{
+			name:         "list",
+			giveExpr:     "0 8,20 * * *",
+			giveDuration: time.Hour,
+			giveTime:     at(2, 20, 30),
+			want:         true,
+		},
+		{
+			name:         "across midnight",
+			giveExpr:     "0 23 * * 5",
+			giveDuration: 3 * time.Hour,
+			giveTime:     at(6, 1, 0),
+			want:         true,
+		},
+		{
+			name:         "sunday as 7",
+			giveExpr:     "0 0 * * 7",
+			giveDuration: 24 * time.Hour,
+			giveTime:     at(7, 12, 0),
+			want:         true,
+		},
+		{
+			name:         "day of month",
+			giveExpr:     "0 0 15 * *",
+			giveDuration: 24 * time.Hour,
+			giveTime:     at(15, 12, 0),
+			want:         true,
+		},
+		{
+			name:         "day of month or weekday on day",
+			giveExpr:     "0 0 3 * 1",
+			giveDuration: 24 * time.Hour,
+			giveTime:     at(3, 12, 0),
+			want:         true,
+		},
+		{
+			name:         "day of month or weekday on weekday",
+			giveExpr:     "0 0 3 * 1",
+			giveDuration: 24 * time.Hour,
+			giveTime:     at(8, 12, 0),
+			want:         true,
+		},
+		{
+			name:         "day of month or weekday on neither",
+			giveExpr:     "0 0 3 * 1",
+			giveDuration: 24 * time.Hour,
+			giveTime:     at(4, 12, 0),
+		},
+		{
+			name:         "month",
+			giveExpr:     "0 0 1 1 *",
+			giveDuration: 48 * time.Hour,
+			giveTime:     at(2, 23, 59),
+			want:         true,
+		},
+		{
+			name:         "other month",
+			giveExpr:     "0 0 1 2 *",
+			giveDuration: 48 * time.Hour,
+			giveTime:     at(2, 12, 0),
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			s, err := NewCronSchedule(tt.giveExpr, tt.giveDuration, tt.giveLoc)
+			assert.NoError(t, err)
+
+			assert.Equal(t, tt.want, s.Active(tt.giveTime))
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package fault
+
+// Seeder is implemented by the Faults, Injectors, and listeners in this package that make random
+// decisions. Pass the seed to WithRandSeed to build a copy that makes the same decisions in the same
+// order, so a faulty response seen in production can be reproduced in a test.
+type Seeder interface {
+	// Seed returns the seed of the random number generator.
+	Seed() int64
+}
+
+// InjectorSeeds returns the seeds of i and every Injector it wraps, keyed by InjectorString.
+// Injectors that do not make random decisions are left out.
+func InjectorSeeds(i Injector) map[string]int64 {
+	seeds := make(map[string]int64)
+	collectSeeds(i, seeds)
+
+	return seeds
+}
+
+// collectSeeds adds the seeds of i and its children to seeds.
+func collectSeeds(i Injector, seeds map[string]int64) {
+	if s, ok := i.(Seeder); ok {
+		seeds[InjectorString(i)] = s.Seed()
+	}
+
+	switch c := i.(type) {
+	case *ChainInjector:
+		for _, child := range c.injectors {
+			collectSeeds(child, seeds)
+		}
+	case *RandomInjector:
+		for _, child := range c.injectors {
+			collectSeeds(child, seeds)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package fault
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestSeeder tests that everything that makes random decisions implements Seeder.
+func TestSeeder(t *testing.T) {
+	t.Parallel()
+
+	f, _ := NewFault(newTestInjectorNoop())
+	fs, _ := NewFault(newTestInjectorNoop(), WithRandSeed(5))
+	ri, _ := NewRandomInjector([]Injector{newTestInjectorNoop()})
+	rs, _ := NewRandomInjector([]Injector{newTestInjectorNoop()}, WithRandSeed(5))
+	pl, _ := NewProtocolListener(nil, ProtocolHTTP10)
+	ps, _ := NewProtocolListener(nil, ProtocolHTTP10, WithRandSeed(5))
+	si, _ := NewSlowInjector(0, WithJitter(0, time.Second))
+	ss, _ := NewSlowInjector(0, WithJitter(0, time.Second), WithRandSeed(5))
+	rc, _ := NewResponseCorruptionInjector(CorruptFlipBytes)
+	rcs, _ := NewResponseCorruptionInjector(CorruptFlipBytes, WithRandSeed(5))
+	tp, _ := NewRequestTamperInjector(TamperFlipBytes)
+	tps, _ := NewRequestTamperInjector(TamperFlipBytes, WithRandSeed(5))
+	ci, _ := NewChainInjector([]Injector{newTestInjectorNoop()})
+	cs, _ := NewChainInjector([]Injector{newTestInjectorNoop()}, WithRandSeed(5))
+	fg, _ := NewFaultGroup([]WeightedFault{{Fault: f, Weight: 1}})
+	fgs, _ := NewFaultGroup([]WeightedFault{{Fault: f, Weight: 1}}, WithRandSeed(5))
+
+	tests := []struct {
+		name string
+		give Seeder
+		want int64
+	}{
+		{"Fault", f, defaultRandSeed},
+		{"Fault seeded", fs, 5},
+		{"RandomInjector", ri, defaultRandSeed},
+		{"RandomInjector seeded", rs, 5},
+		{"ProtocolListener", pl, defaultRandSeed},
+		{"ProtocolListener seeded", ps, 5},
+		{"SlowInjector", si, defaultRandSeed},
+		{"SlowInjector seeded", ss, 5},
+		{"ResponseCorruptionInjector", rc, defaultRandSeed},
+		{"ResponseCorruptionInjector seeded", rcs, 5},
+		{"RequestTamperInjector", tp, defaultRandSeed},
+		{"RequestTamperInjector seeded", tps, 5},
+		{"ChainInjector", ci, defaultRandSeed},
+		{"ChainInjector seeded", cs, 5},
+		{"FaultGroup", fg, defaultRandSeed},
+		{"FaultGroup seeded", fgs, 5},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, tt.give.Seed())
+		})
+	}
+}
+
+// TestSeedReproduces tests that a Fault built with the Seed of another Fault makes the same rolls.
+func TestSeedReproduces(t *testing.T) {
+	t.Parallel()
+
+	f, err := NewFault(newTestInjectorNoop(), WithParticipation(0.5), WithRandSeed(1234))
+	assert.NoError(t, err)
+
+	var want []float32
+	for n := 0; n < 10; n++ {
+		_, roll, rolls := f.roll()
+		assert.Equal(t, uint64(n+1), rolls)
+		want = append(want, roll)
+	}
+
+	replay, err := NewFault(newTestInjectorNoop(), WithParticipation(0.5), WithRandSeed(f.Seed()))
+	assert.NoError(t, err)
+
+	var got []float32
+	for n := 0; n < 10; n++ {
+		_, roll, _ := replay.roll()
+		got = append(got, roll)
+	}
+
+	assert.Equal(t, want, got)
+}
+
+// TestWithRand tests that a Fault built WithRand rolls with the given generator.
+func TestWithRand(t *testing.T) {
+	t.Parallel()
+
+	f, err := NewFault(newTestInjectorNoop(), WithParticipation(0.5), WithRand(rand.New(rand.NewSource(42))))
+	assert.NoError(t, err)
+
+	want := rand.New(rand.NewSource(42))
+	for n := 0; n < 10; n++ {
+		_, roll, _ := f.roll()
+		assert.Equal(t, want.Float32(), roll)
+	}
+
+	f, err = NewFault(newTestInjectorNoop(), WithRand(nil))
+	assert.Equal(t, ErrNilRand, err)
+	assert.Nil(t, f)
+}
+
+// TestInjectorSeeds tests InjectorSeeds.
+func TestInjectorSeeds(t *testing.T) {
+	t.Parallel()
+
+	inner, _ := NewRandomInjector([]Injector{newTestInjectorNoop()}, WithRandSeed(3))
+	outer, _ := NewRandomInjector([]Injector{inner}, WithRandSeed(4))
+	chain, _ := NewChainInjector([]Injector{outer, newTestInjectorNoop()})
+
+	assert.Equal(t, map[string]int64{
+		"random(testInjectorNoop)":         3,
+		"random(random(testInjectorNoop))": 4,
+
+		"chain(random(random(testInjectorNoop)), testInjectorNoop)": defaultRandSeed,
+	}, InjectorSeeds(chain))
+	assert.Equal(t, map[string]int64{}, InjectorSeeds(newTestInjectorNoop()))
+}
@@ -0,0 +1,201 @@
+package fault
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// ErrNilServer when a nil http.Server is passed.
+var ErrNilServer = errors.New("server cannot be nil")
+
+// WrapServer binds Faults to a single http.Server by wrapping its Handler, so that a process
+// serving several ports, like a public API and an internal admin port, only runs the Faults on the
+// server they were bound to. Faults run in the order they are passed, the first being the outermost
+// middleware. A nil Handler is wrapped as http.DefaultServeMux, which is what the server would
+// serve. Call WrapServer before the server starts serving.
+func WrapServer(s *http.Server, faults ...*Fault) error {
+	if s == nil {
+		return ErrNilServer
+	}
+	for _, f := range faults {
+		if f == nil {
+			return ErrNilFault
+		}
+	}
+
+	h := s.Handler
+	if h == nil {
+		h = http.DefaultServeMux
+	}
+
+	for idx := len(faults) - 1; idx >= 0; idx-- {
+		h = faults[idx].Handler(h)
+	}
+	s.Handler = h
+
+	return nil
+}
+
+// MatchLocalPort returns a RequestMatcher that matches requests received on any of ports. Use it
+// with WithRequestMatcher() to bind a Fault to listeners when one Handler serves several of them.
+// Requests without a local address, such as those not served by an http.Server, never match.
+func MatchLocalPort(ports ...int) RequestMatcher {
+	set := make(map[int]struct{}, len(ports))
+	for _, p := range ports {
+		set[p] = struct{}{}
+	}
+
+	return RequestMatcherFunc(func(r *http.Request) bool {
+		addr, ok := r.Context().Value(http.LocalAddrContextKey).(net.Addr)
+		if !ok {
+			return false
+		}
+
+		_, port, err := net.SplitHostPort(addr.String())
+		if err != nil {
+			return false
+		}
+
+		p, err := strconv.Atoi(port)
+		if err != nil {
+			return false
+		}
+
+		_, ok = set[p]
+
+		return ok
+	})
+}
+
+// ServerLimits are the limits of an http.Server that can cut an injected fault short. A zero limit
+// is no limit.
+type ServerLimits struct {
+	// ReadTimeout is how long the server reads a request, including its body. A handler that reads
+	// the body after it ends gets an error.
+	ReadTimeout time.Duration
+	// WriteTimeout is how long the server writes a response after reading the request headers.
+	// Writes after it ends fail, and the client sees the connection close.
+	WriteTimeout time.Duration
+}
+
+// ServerLimitsOf returns the ServerLimits of s.
+func ServerLimitsOf(s *http.Server) ServerLimits {
+	return ServerLimits{
+		ReadTimeout:  s.ReadTimeout,
+		WriteTimeout: s.WriteTimeout,
+	}
+}
+
+// LimitWarning describes an Injector of a Fault that conflicts with a limit of the server it runs
+// on, so the server would cut the injected behavior short instead of the client seeing it.
+type LimitWarning struct {
+	// Fault is the name of the Fault.
+	Fault string
+	// Injector describes the conflicting Injector, as returned by InjectorString.
+	Injector string
+	// Limit is the name of the limit in ServerLimits, such as "WriteTimeout".
+	Limit string
+	// Message explains the conflict.
+	Message string
+}
+
+// String returns the LimitWarning as a sentence, such as `fault "checkout": slow(1m0s) waits up to
+// 1m0s, at least the WriteTimeout of 30s, so the response is never written`.
+func (w LimitWarning) String() string {
+	return fmt.Sprintf("fault %q: %s %s", w.Fault, w.Injector, w.Message)
+}
+
+// CheckServer returns a LimitWarning for each Injector of faults that conflicts with the
+// ServerLimits of s. Call it when binding Faults to a server, such as with WrapServer().
+func CheckServer(s *http.Server, faults ...*Fault) []LimitWarning {
+	return CheckServerLimits(ServerLimitsOf(s), faults...)
+}
+
+// CheckServerLimits returns a LimitWarning for each Injector of faults, and each Injector they
+// combine, that conflicts with l: latencies that reach the WriteTimeout, so the response is never
+// written, or the ReadTimeout, so the handler can no longer read the request body, and slow bodies
+// that cannot be sent within the WriteTimeout.
+func CheckServerLimits(l ServerLimits, faults ...*Fault) []LimitWarning {
+	var warnings []LimitWarning
+	for _, f := range faults {
+		if f == nil {
+			continue
+		}
+
+		for _, w := range checkInjectorLimits(f.Injector(), l) {
+			w.Fault = f.Name()
+			warnings = append(warnings, w)
+		}
+	}
+
+	return warnings
+}
+
+// checkInjectorLimits returns the LimitWarnings of i and the Injectors it combines against l.
+func checkInjectorLimits(i Injector, l ServerLimits) []LimitWarning {
+	var warnings []LimitWarning
+
+	switch c := i.(type) {
+	case *SlowInjector:
+		warnings = latencyLimitWarnings(i, c.maxLatency(), l)
+	case *WeightedLatencyInjector:
+		warnings = latencyLimitWarnings(i, c.maxLatency(), l)
+	case *SlowBodyInjector:
+		if l.WriteTimeout > 0 && c.delay > 0 {
+			// the first chunk is written at once and each other chunk after a delay
+			size := c.chunkSize * int(1+(l.WriteTimeout-1)/c.delay)
+			warnings = append(warnings, LimitWarning{
+				Injector: InjectorString(i),
+				Limit:    "WriteTimeout",
+				Message: fmt.Sprintf("cuts off responses longer than %dB, which cannot be sent within "+
+					"the WriteTimeout of %s", size, l.WriteTimeout),
+			})
+		}
+	case *InterimInjector:
+		if c.fault == InterimWithholdContinue && l.ReadTimeout > 0 && c.delay >= l.ReadTimeout {
+			warnings = append(warnings, LimitWarning{
+				Injector: InjectorString(i),
+				Limit:    "ReadTimeout",
+				Message: fmt.Sprintf("withholds 100 Continue for %s, at least the ReadTimeout of %s, so "+
+					"request bodies cannot be read", c.delay, l.ReadTimeout),
+			})
+		}
+	}
+
+	if c, ok := i.(composite); ok {
+		for _, child := range c.children() {
+			warnings = append(warnings, checkInjectorLimits(child, l)...)
+		}
+	}
+
+	return warnings
+}
+
+// latencyLimitWarnings returns the LimitWarnings of i, which waits up to max before the handler
+// runs, against l.
+func latencyLimitWarnings(i Injector, max time.Duration, l ServerLimits) []LimitWarning {
+	var warnings []LimitWarning
+
+	if l.WriteTimeout > 0 && max >= l.WriteTimeout {
+		warnings = append(warnings, LimitWarning{
+			Injector: InjectorString(i),
+			Limit:    "WriteTimeout",
+			Message: fmt.Sprintf("waits up to %s, at least the WriteTimeout of %s, so the response is "+
+				"never written", max, l.WriteTimeout),
+		})
+	}
+	if l.ReadTimeout > 0 && max >= l.ReadTimeout {
+		warnings = append(warnings, LimitWarning{
+			Injector: InjectorString(i),
+			Limit:    "ReadTimeout",
+			Message: fmt.Sprintf("waits up to %s, at least the ReadTimeout of %s, so the handler cannot "+
+				"read the request body", max, l.ReadTimeout),
+		})
+	}
+
+	return warnings
+}
@@ -0,0 +1,334 @@
+package fault
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testServerHandler responds with testHandlerCode.
+var testServerHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { //nolint:gochecknoglobals
+	http.Error(w, testHandlerBody, testHandlerCode)
+})
+
+// testServerGet returns the status code of a GET request to url.
+func testServerGet(t *testing.T, url string) int {
+	t.Helper()
+
+	resp, err := http.Get(url)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	return resp.StatusCode
+}
+
+// TestWrapServer tests WrapServer.
+func TestWrapServer(t *testing.T) {
+	t.Parallel()
+
+	enabled := func(i Injector) *Fault {
+		f, err := NewFault(i, WithEnabled(true), WithParticipation(1.0))
+		assert.NoError(t, err)
+		return f
+	}
+
+	tests := []struct {
+		name       string
+		giveServer *http.Server
+		giveFaults []*Fault
+		wantCode   int
+		wantErr    error
+	}{
+		{
+			name:       "no faults",
+			giveServer: &http.Server{Handler: testServerHandler},
+			giveFaults: nil,
+			wantCode:   testHandlerCode,
+		},
+		{
+			name:       "faults run in order",
+			giveServer: &http.Server{Handler: testServerHandler},
+			giveFaults: []*Fault{
+				enabled(newTestInjector500s()),
+				enabled(newTestInjectorTwoTeapot()),
+			},
+			wantCode: http.StatusInternalServerError,
+		},
+		{
+			name:       "default serve mux",
+			giveServer: &http.Server{},
+			giveFaults: []*Fault{
+				enabled(newTestInjectorNoop()),
+			},
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:       "nil server",
+			giveServer: nil,
+			wantErr:    ErrNilServer,
+		},
+		{
+			name:       "nil fault",
+			giveServer: &http.Server{Handler: testServerHandler},
+			giveFaults: []*Fault{
+				enabled(newTestInjector500s()),
+				nil,
+			},
+			wantCode: testHandlerCode,
+			wantErr:  ErrNilFault,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := WrapServer(tt.giveServer, tt.giveFaults...)
+			assert.Equal(t, tt.wantErr, err)
+			if tt.giveServer == nil {
+				return
+			}
+
+			rr := httptest.NewRecorder()
+			tt.giveServer.Handler.ServeHTTP(rr, httptest.NewRequest("GET", "/go-fault-unregistered", nil))
+			assert.Equal(t, tt.wantCode, rr.Code)
+		})
+	}
+}
+
+// TestWrapServerPorts tests that a Fault bound to one server does not run on another.
+func TestWrapServerPorts(t *testing.T) {
+	t.Parallel()
+
+	f, err := NewFault(newTestInjector500s(), WithEnabled(true), WithParticipation(1.0))
+	assert.NoError(t, err)
+
+	public := httptest.NewUnstartedServer(testServerHandler)
+	admin := httptest.NewUnstartedServer(testServerHandler)
+
+	assert.NoError(t, WrapServer(public.Config, f))
+
+	public.Start()
+	admin.Start()
+	t.Cleanup(public.Close)
+	t.Cleanup(admin.Close)
+
+	assert.Equal(t, http.StatusInternalServerError, testServerGet(t, public.URL))
+	assert.Equal(t, testHandlerCode, testServerGet(t, admin.URL))
+}
+
+// TestMatchLocalPort tests MatchLocalPort.
+func TestMatchLocalPort(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		givePorts []int
+		giveAddr  net.Addr
+		want      bool
+	}{
+		{
+			name:      "match",
+			givePorts: []int{8080, 9090},
+			giveAddr:  &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 9090},
+			want:      true,
+		},
+		{
+			name:      "no match",
+			givePorts: []int{8080},
+			giveAddr:  &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 9090},
+			want:      false,
+		},
+		{
+			name:      "no ports",
+			givePorts: nil,
+			giveAddr:  &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 9090},
+			want:      false,
+		},
+		{
+			name:      "no local addr",
+			givePorts: []int{8080},
+			giveAddr:  nil,
+			want:      false,
+		},
+		{
+			name:      "no port",
+			givePorts: []int{8080},
+			giveAddr:  &net.UnixAddr{Name: "/tmp/go-fault.sock", Net: "unix"},
+			want:      false,
+		},
+		{
+			name:      "named port",
+			givePorts: []int{8080},
+			giveAddr:  &net.UnixAddr{Name: "localhost:http", Net: "unix"},
+			want:      false,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest("GET", "/", nil)
+			if tt.giveAddr != nil {
+				req = req.WithContext(context.WithValue(req.Context(), http.LocalAddrContextKey, tt.giveAddr))
+			}
+
+			assert.Equal(t, tt.want, MatchLocalPort(tt.givePorts...).MatchRequest(req))
+		})
+	}
+}
+
+// TestMatchLocalPortListeners tests that a Fault matching a port only runs on that listener when
+// one server serves several.
+func TestMatchLocalPortListeners(t *testing.T) {
+	t.Parallel()
+
+	publicLn, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	adminLn, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+
+	f, err := NewFault(newTestInjector500s(),
+		WithEnabled(true),
+		WithParticipation(1.0),
+		WithRequestMatcher(MatchLocalPort(publicLn.Addr().(*net.TCPAddr).Port)),
+	)
+	assert.NoError(t, err)
+
+	srv := &http.Server{Handler: f.Handler(testServerHandler)}
+	go func() { _ = srv.Serve(publicLn) }()
+	go func() { _ = srv.Serve(adminLn) }()
+	t.Cleanup(func() { _ = srv.Close() })
+
+	assert.Equal(t, http.StatusInternalServerError, testServerGet(t, "http://"+publicLn.Addr().String()))
+	assert.Equal(t, testHandlerCode, testServerGet(t, "http://"+adminLn.Addr().String()))
+}
+
+// TestCheckServerLimits tests CheckServer and CheckServerLimits.
+func TestCheckServerLimits(t *testing.T) {
+	t.Parallel()
+
+	// named returns a function that builds a Fault called name from the results of an Injector
+	// constructor
+	named := func(name string) func(i Injector, err error) *Fault {
+		return func(i Injector, err error) *Fault {
+			assert.NoError(t, err)
+			f, err := NewFault(i, WithName(name))
+			assert.NoError(t, err)
+			return f
+		}
+	}
+
+	slow := named("slow")(NewSlowInjector(time.Minute))
+	uniform := named("uniform")(NewSlowInjector(0, WithJitter(0, 30*time.Second)))
+	normal := named("normal")(NewSlowInjector(0, WithNormalLatency(10*time.Second, 5*time.Second)))
+	pareto := named("pareto")(NewSlowInjector(0, WithParetoLatency(time.Second, 20*time.Second, 1.16)))
+	weighted := named("weighted")(NewWeightedLatencyInjector([]WeightedLatency{
+		{Latency: time.Second, Weight: 1},
+		{Latency: time.Hour, Weight: 0},
+		{Latency: 40 * time.Second, Weight: 1},
+	}))
+	body := named("body")(NewSlowBodyInjector(64, 100*time.Millisecond))
+	withhold := named("withhold")(NewInterimInjector(InterimWithholdContinue, WithContinueDelay(time.Minute)))
+	hints := named("hints")(NewInterimInjector(InterimEarlyHints))
+	si, err := NewSlowInjector(time.Minute)
+	assert.NoError(t, err)
+	chain := named("chain")(NewChainInjector([]Injector{newTestInjectorNoop(), si}))
+
+	tests := []struct {
+		name       string
+		giveLimits ServerLimits
+		giveFaults []*Fault
+		want       []string
+	}{
+		{
+			name:       "no limits",
+			giveLimits: ServerLimits{},
+			giveFaults: []*Fault{slow, body, withhold},
+			want:       nil,
+		},
+		{
+			name:       "write timeout",
+			giveLimits: ServerLimits{WriteTimeout: 30 * time.Second},
+			giveFaults: []*Fault{slow, uniform, normal, pareto, weighted, hints, nil},
+			want: []string{
+				`fault "slow": slow(1m0s) waits up to 1m0s, at least the WriteTimeout of 30s, so the ` +
+					`response is never written`,
+				`fault "uniform": slow(uniform 0s..30s) waits up to 30s, at least the WriteTimeout of 30s, ` +
+					`so the response is never written`,
+				`fault "weighted": weighted_latency(1s:1, 1h0m0s:0, 40s:1) waits up to 40s, at least the ` +
+					`WriteTimeout of 30s, so the response is never written`,
+			},
+		},
+		{
+			name:       "read timeout",
+			giveLimits: ServerLimits{ReadTimeout: 20 * time.Second},
+			giveFaults: []*Fault{normal, pareto, withhold, hints},
+			want: []string{
+				`fault "normal": slow(normal 10s stddev 5s) waits up to 25s, at least the ReadTimeout of ` +
+					`20s, so the handler cannot read the request body`,
+				`fault "pareto": slow(pareto 1s..20s shape 1.16) waits up to 20s, at least the ReadTimeout ` +
+					`of 20s, so the handler cannot read the request body`,
+				`fault "withhold": interim(withhold_continue 1m0s) withholds 100 Continue for 1m0s, at ` +
+					`least the ReadTimeout of 20s, so request bodies cannot be read`,
+			},
+		},
+		{
+			name:       "slow body",
+			giveLimits: ServerLimits{WriteTimeout: time.Second},
+			giveFaults: []*Fault{body},
+			want: []string{
+				`fault "body": slow_body(64B/100ms) cuts off responses longer than 640B, which cannot be ` +
+					`sent within the WriteTimeout of 1s`,
+			},
+		},
+		{
+			name:       "combined injectors",
+			giveLimits: ServerLimits{WriteTimeout: time.Minute},
+			giveFaults: []*Fault{chain},
+			want: []string{
+				`fault "chain": slow(1m0s) waits up to 1m0s, at least the WriteTimeout of 1m0s, so the ` +
+					`response is never written`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var got []string
+			for _, w := range CheckServerLimits(tt.giveLimits, tt.giveFaults...) {
+				got = append(got, w.String())
+			}
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	s := &http.Server{ReadTimeout: time.Minute, WriteTimeout: time.Minute}
+	assert.Equal(t, ServerLimits{ReadTimeout: time.Minute, WriteTimeout: time.Minute}, ServerLimitsOf(s))
+	assert.Equal(t, []LimitWarning{
+		{
+			Fault:    "slow",
+			Injector: "slow(1m0s)",
+			Limit:    "WriteTimeout",
+			Message:  "waits up to 1m0s, at least the WriteTimeout of 1m0s, so the response is never written",
+		},
+		{
+			Fault:    "slow",
+			Injector: "slow(1m0s)",
+			Limit:    "ReadTimeout",
+			Message:  "waits up to 1m0s, at least the ReadTimeout of 1m0s, so the handler cannot read the request body",
+		},
+	}, CheckServer(s, slow))
+}
@@ -0,0 +1,405 @@
+package fault
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// defaultRecoveryChecks is how many healthy checks in a row re-enable tripped Faults by default.
+	defaultRecoveryChecks = 3
+)
+
+var (
+	// ErrNilSLOGuard when a nil SLOGuard is passed.
+	ErrNilSLOGuard = errors.New("slo guard cannot be nil")
+	// ErrNoObjectives when an SLOGuard is given neither an error nor a latency objective.
+	ErrNoObjectives = errors.New("at least one objective is required")
+	// ErrInvalidLatencyThreshold when an SLOGuard latency threshold is not positive.
+	ErrInvalidLatencyThreshold = errors.New("latency threshold must be > 0")
+	// ErrInvalidSlowRatio when an SLOGuard slow ratio is not 0.0 <= ratio < 1.0.
+	ErrInvalidSlowRatio = errors.New("slow ratio must be 0.0 <= ratio < 1.0")
+	// ErrInvalidRecoveryChecks when an SLOGuard number of recovery checks is not positive.
+	ErrInvalidRecoveryChecks = errors.New("recovery checks must be > 0")
+)
+
+// SLOChange describes an SLOGuard tripping or recovering.
+type SLOChange struct {
+	// Tripped is true if the Faults were disabled and false if they were enabled again.
+	Tripped bool
+	// Requests is the number of organic requests in the checked interval.
+	Requests int64
+	// Errors is the number of those requests answered with a 5xx status code or a panic.
+	Errors int64
+	// Slow is the number of those requests that took longer than the latency threshold.
+	Slow int64
+	// ErrorRatio is Errors / Requests, or 0 if there were no requests.
+	ErrorRatio float64
+	// SlowRatio is Slow / Requests, or 0 if there were no requests.
+	SlowRatio float64
+	// Faults are the names of the Faults that were disabled or enabled.
+	Faults []string
+}
+
+// SLOGuard is a circuit breaker that makes it safe to leave Faults enabled in production. Faults
+// given it with WithSLOGuard() report the requests they do not inject to it, and every interval it
+// checks those organic requests against its objectives: the ratio of them that fail, and the ratio
+// of them slower than a threshold. When the service breaches an objective on its own it trips,
+// disabling its enabled Faults, and once it has met its objectives for enough checks in a row it
+// enables them again.
+//
+// Unlike an ErrorGuard, which counts every response of the handler it wraps, an SLOGuard only counts
+// requests on which no Injector of any Fault ran, so it can be shared by Faults that are nested
+// around the same handler.
+type SLOGuard struct {
+	// requests, errors, and slow count the organic requests since the last check. They are first to
+	// keep them 64-bit aligned for atomic access.
+	requests int64
+	errors   int64
+	slow     int64
+
+	errorObjective bool
+	maxErrorRatio  float64
+
+	latencyObjective bool
+	latencyThreshold time.Duration
+	maxSlowRatio     float64
+
+	minRequests    int64
+	recoveryChecks int
+	interval       time.Duration
+	clock          Clock
+	changeFunc     func(c SLOChange)
+
+	// checkMtx serializes checks and protects faults, tripped, and healthy.
+	checkMtx sync.Mutex
+	faults   []*Fault
+	// tripped are the Faults the SLOGuard disabled, or nil if it has not tripped.
+	tripped []*Fault
+	// healthy counts the checks in a row that met the objectives since the SLOGuard tripped.
+	healthy int
+
+	mtx  sync.Mutex
+	stop chan struct{}
+	done chan struct{}
+}
+
+// SLOGuardOption configures an SLOGuard.
+type SLOGuardOption interface {
+	applySLOGuard(g *SLOGuard) error
+}
+
+func (o clockOption) applySLOGuard(g *SLOGuard) error {
+	g.clock = o.clock
+	return nil
+}
+
+func (o checkIntervalOption) applySLOGuard(g *SLOGuard) error {
+	if o <= 0 {
+		return ErrInvalidInterval
+	}
+
+	g.interval = time.Duration(o)
+
+	return nil
+}
+
+func (o minRequestsOption) applySLOGuard(g *SLOGuard) error {
+	if o < 0 {
+		return ErrInvalidMinRequests
+	}
+
+	g.minRequests = int64(o)
+
+	return nil
+}
+
+type maxErrorRatioOption float64
+
+func (o maxErrorRatioOption) applySLOGuard(g *SLOGuard) error {
+	if o < 0 || o >= 1 {
+		return ErrInvalidErrorRatio
+	}
+
+	g.errorObjective = true
+	g.maxErrorRatio = float64(o)
+
+	return nil
+}
+
+// WithMaxErrorRatio sets the error objective of an SLOGuard: at most maxRatio (0.0 <= maxRatio <
+// 1.0) of the organic requests may be answered with a 5xx status code or a panic.
+func WithMaxErrorRatio(maxRatio float64) SLOGuardOption {
+	return maxErrorRatioOption(maxRatio)
+}
+
+type latencyObjectiveOption struct {
+	threshold time.Duration
+	maxRatio  float64
+}
+
+func (o latencyObjectiveOption) applySLOGuard(g *SLOGuard) error {
+	if o.threshold <= 0 {
+		return ErrInvalidLatencyThreshold
+	}
+
+	if o.maxRatio < 0 || o.maxRatio >= 1 {
+		return ErrInvalidSlowRatio
+	}
+
+	g.latencyObjective = true
+	g.latencyThreshold = o.threshold
+	g.maxSlowRatio = o.maxRatio
+
+	return nil
+}
+
+// WithLatencyObjective sets the latency objective of an SLOGuard: at most maxRatio (0.0 <= maxRatio
+// < 1.0) of the organic requests may take longer than threshold. For example, threshold 300ms and
+// maxRatio 0.01 is the objective that 99% of requests take 300ms or less.
+func WithLatencyObjective(threshold time.Duration, maxRatio float64) SLOGuardOption {
+	return latencyObjectiveOption{threshold: threshold, maxRatio: maxRatio}
+}
+
+type recoveryChecksOption int
+
+func (o recoveryChecksOption) applySLOGuard(g *SLOGuard) error {
+	if o <= 0 {
+		return ErrInvalidRecoveryChecks
+	}
+
+	g.recoveryChecks = int(o)
+
+	return nil
+}
+
+// WithRecoveryChecks sets how many checks in a row must meet the objectives before a tripped
+// SLOGuard enables its Faults again. Default 3.
+func WithRecoveryChecks(n int) SLOGuardOption {
+	return recoveryChecksOption(n)
+}
+
+type sloChangeFuncOption func(c SLOChange)
+
+func (o sloChangeFuncOption) applySLOGuard(g *SLOGuard) error {
+	g.changeFunc = o
+	return nil
+}
+
+// WithSLOChangeFunc sets a function that is called with every SLOChange, for example to log or
+// alert when the SLOGuard trips or recovers.
+func WithSLOChangeFunc(f func(c SLOChange)) SLOGuardOption {
+	return sloChangeFuncOption(f)
+}
+
+type sloGuardOption struct {
+	guard *SLOGuard
+}
+
+func (o sloGuardOption) applyFault(f *Fault) error {
+	if o.guard == nil {
+		return ErrNilSLOGuard
+	}
+
+	f.slo = o.guard
+
+	return nil
+}
+
+// WithSLOGuard reports the requests the Fault does not inject to g, and lets g disable the Fault
+// when the service breaches its objectives on its own.
+func WithSLOGuard(g *SLOGuard) Option {
+	return sloGuardOption{g}
+}
+
+// NewSLOGuard returns an SLOGuard with the objectives set by WithMaxErrorRatio() and
+// WithLatencyObjective(), at least one of which is required. Add it to Faults with WithSLOGuard()
+// and call Start to begin checking.
+func NewSLOGuard(opts ...SLOGuardOption) (*SLOGuard, error) {
+	// set defaults
+	g := &SLOGuard{
+		minRequests:    defaultGuardMinRequests,
+		recoveryChecks: defaultRecoveryChecks,
+		interval:       defaultGuardInterval,
+		clock:          NewRealClock(),
+		changeFunc:     func(SLOChange) {},
+	}
+
+	// apply options
+	for _, opt := range opts {
+		err := opt.applySLOGuard(g)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// check options
+	if !g.errorObjective && !g.latencyObjective {
+		return nil, ErrNoObjectives
+	}
+
+	return g, nil
+}
+
+// add adds f to the Faults the SLOGuard disables when it trips.
+func (g *SLOGuard) add(f *Fault) {
+	g.checkMtx.Lock()
+	defer g.checkMtx.Unlock()
+
+	g.faults = append(g.faults, f)
+}
+
+// start returns r with a history, so that the Injectors of other Faults that run on it are seen, w
+// wrapped to record the status code, and a function to defer until the handler returns. The
+// function counts the request, and a panic other than http.ErrAbortHandler as an error, unless an
+// Injector ran on the request.
+func (g *SLOGuard) start(r *http.Request, w http.ResponseWriter) (*http.Request, http.ResponseWriter, func()) {
+	r, h := requestHistory(r)
+	sw := &statusWriter{ResponseWriter: w, code: http.StatusOK}
+	start := g.clock.Now()
+
+	return r, sw, func() {
+		failed := sw.code >= http.StatusInternalServerError
+
+		p := recover()
+		if p != nil && p != http.ErrAbortHandler {
+			failed = true
+		}
+
+		if h.len() == 0 {
+			atomic.AddInt64(&g.requests, 1)
+			if failed {
+				atomic.AddInt64(&g.errors, 1)
+			}
+			if g.latencyObjective && g.clock.Now().Sub(start) > g.latencyThreshold {
+				atomic.AddInt64(&g.slow, 1)
+			}
+		}
+
+		if p != nil {
+			panic(p)
+		}
+	}
+}
+
+// Start begins checking the objectives every interval in a new goroutine. It does nothing if the
+// SLOGuard is already started.
+func (g *SLOGuard) Start() {
+	g.mtx.Lock()
+	defer g.mtx.Unlock()
+
+	if g.stop != nil {
+		return
+	}
+
+	g.stop = make(chan struct{})
+	g.done = make(chan struct{})
+
+	go g.run(g.stop, g.done)
+}
+
+// Stop stops checking and waits for a check in progress to finish. Tripped Faults stay disabled. It
+// does nothing if the SLOGuard is not started.
+func (g *SLOGuard) Stop() {
+	g.mtx.Lock()
+	defer g.mtx.Unlock()
+
+	if g.stop == nil {
+		return
+	}
+
+	close(g.stop)
+	<-g.done
+
+	g.stop = nil
+	g.done = nil
+}
+
+// run calls Check every interval until stop is closed.
+func (g *SLOGuard) run(stop <-chan struct{}, done chan<- struct{}) {
+	defer close(done)
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-g.clock.After(g.interval):
+			g.Check()
+		}
+	}
+}
+
+// Check checks the organic requests counted since the last check against the objectives and resets
+// the counts. An interval breaches an objective if it has at least the minimum number of requests
+// and more of them failed or were slow than the objective allows. A breach trips the SLOGuard,
+// disabling its enabled Faults, and a tripped SLOGuard enables them again after the set number of
+// checks in a row without a breach. It returns the SLOChange, or nil if the Faults were left alone.
+func (g *SLOGuard) Check() *SLOChange {
+	g.checkMtx.Lock()
+	defer g.checkMtx.Unlock()
+
+	c := SLOChange{
+		Requests: atomic.SwapInt64(&g.requests, 0),
+		Errors:   atomic.SwapInt64(&g.errors, 0),
+		Slow:     atomic.SwapInt64(&g.slow, 0),
+	}
+	if c.Requests > 0 {
+		c.ErrorRatio = float64(c.Errors) / float64(c.Requests)
+		c.SlowRatio = float64(c.Slow) / float64(c.Requests)
+	}
+
+	breached := c.Requests >= g.minRequests &&
+		(g.errorObjective && c.ErrorRatio > g.maxErrorRatio || g.latencyObjective && c.SlowRatio > g.maxSlowRatio)
+
+	switch {
+	case g.tripped == nil && breached:
+		var tripped []*Fault
+		for _, f := range g.faults {
+			if f.enabled.Load() {
+				f.DisableNow()
+				tripped = append(tripped, f)
+				c.Faults = append(c.Faults, f.Name())
+			}
+		}
+
+		if len(tripped) == 0 {
+			return nil
+		}
+
+		g.tripped = tripped
+		g.healthy = 0
+		c.Tripped = true
+	case g.tripped != nil && breached:
+		g.healthy = 0
+		return nil
+	case g.tripped != nil:
+		g.healthy++
+		if g.healthy < g.recoveryChecks {
+			return nil
+		}
+
+		for _, f := range g.tripped {
+			f.SetEnabled(true)
+			c.Faults = append(c.Faults, f.Name())
+		}
+
+		g.tripped = nil
+	default:
+		return nil
+	}
+
+	g.changeFunc(c)
+
+	return &c
+}
+
+// Tripped returns true if the SLOGuard has disabled its Faults.
+func (g *SLOGuard) Tripped() bool {
+	g.checkMtx.Lock()
+	defer g.checkMtx.Unlock()
+
+	return g.tripped != nil
+}
@@ -0,0 +1,379 @@
+package fault
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/github/go-fault/faulttest"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestNewSLOGuard tests NewSLOGuard.
+func TestNewSLOGuard(t *testing.T) {
+	t.Parallel()
+
+	clock := faulttest.NewClock(time.Time{})
+
+	tests := []struct {
+		name               string
+		giveOptions        []SLOGuardOption
+		wantMaxErrorRatio  float64
+		wantThreshold      time.Duration
+		wantMaxSlowRatio   float64
+		wantMinRequests    int64
+		wantRecoveryChecks int
+		wantInterval       time.Duration
+		wantClock          Clock
+		wantErr            error
+	}{
+		{
+			name:               "error objective",
+			giveOptions:        []SLOGuardOption{WithMaxErrorRatio(0.05)},
+			wantMaxErrorRatio:  0.05,
+			wantMinRequests:    defaultGuardMinRequests,
+			wantRecoveryChecks: defaultRecoveryChecks,
+			wantInterval:       defaultGuardInterval,
+			wantClock:          NewRealClock(),
+		},
+		{
+			name: "options",
+			giveOptions: []SLOGuardOption{
+				WithLatencyObjective(300*time.Millisecond, 0.01),
+				WithMinRequests(0),
+				WithRecoveryChecks(1),
+				WithCheckInterval(time.Minute),
+				WithClock(clock),
+				WithSLOChangeFunc(func(SLOChange) {}),
+			},
+			wantThreshold:      300 * time.Millisecond,
+			wantMaxSlowRatio:   0.01,
+			wantMinRequests:    0,
+			wantRecoveryChecks: 1,
+			wantInterval:       time.Minute,
+			wantClock:          clock,
+		},
+		{
+			name:    "no objectives",
+			wantErr: ErrNoObjectives,
+		},
+		{
+			name:        "invalid error ratio",
+			giveOptions: []SLOGuardOption{WithMaxErrorRatio(1)},
+			wantErr:     ErrInvalidErrorRatio,
+		},
+		{
+			name:        "invalid latency threshold",
+			giveOptions: []SLOGuardOption{WithLatencyObjective(0, 0.01)},
+			wantErr:     ErrInvalidLatencyThreshold,
+		},
+		{
+			name:        "invalid slow ratio",
+			giveOptions: []SLOGuardOption{WithLatencyObjective(time.Second, -0.1)},
+			wantErr:     ErrInvalidSlowRatio,
+		},
+		{
+			name:        "invalid min requests",
+			giveOptions: []SLOGuardOption{WithMaxErrorRatio(0.05), WithMinRequests(-1)},
+			wantErr:     ErrInvalidMinRequests,
+		},
+		{
+			name:        "invalid recovery checks",
+			giveOptions: []SLOGuardOption{WithMaxErrorRatio(0.05), WithRecoveryChecks(0)},
+			wantErr:     ErrInvalidRecoveryChecks,
+		},
+		{
+			name:        "invalid interval",
+			giveOptions: []SLOGuardOption{WithMaxErrorRatio(0.05), WithCheckInterval(0)},
+			wantErr:     ErrInvalidInterval,
+		},
+		{
+			name:        "option error",
+			giveOptions: []SLOGuardOption{withError()},
+			wantErr:     errErrorOption,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			g, err := NewSLOGuard(tt.giveOptions...)
+
+			assert.Equal(t, tt.wantErr, err)
+			if tt.wantErr != nil {
+				assert.Nil(t, g)
+				return
+			}
+
+			assert.Equal(t, tt.wantMaxErrorRatio, g.maxErrorRatio)
+			assert.Equal(t, tt.wantThreshold, g.latencyThreshold)
+			assert.Equal(t, tt.wantMaxSlowRatio, g.maxSlowRatio)
+			assert.Equal(t, tt.wantMinRequests, g.minRequests)
+			assert.Equal(t, tt.wantRecoveryChecks, g.recoveryChecks)
+			assert.Equal(t, tt.wantInterval, g.interval)
+			assert.Equal(t, tt.wantClock, g.clock)
+			assert.NotNil(t, g.changeFunc)
+		})
+	}
+}
+
+// TestWithSLOGuard tests that WithSLOGuard rejects a nil SLOGuard and adds the Fault to the
+// SLOGuard.
+func TestWithSLOGuard(t *testing.T) {
+	t.Parallel()
+
+	f, err := NewFault(newTestInjectorNoop(), WithSLOGuard(nil))
+	assert.Equal(t, ErrNilSLOGuard, err)
+	assert.Nil(t, f)
+
+	g, err := NewSLOGuard(WithMaxErrorRatio(0.1))
+	assert.NoError(t, err)
+	f, err = NewFault(newTestInjectorNoop(), WithSLOGuard(g))
+	assert.NoError(t, err)
+	assert.Equal(t, []*Fault{f}, g.faults)
+}
+
+// TestSLOGuardCounts tests that an SLOGuard only counts the requests no Injector ran on, and counts
+// failed and slow requests.
+func TestSLOGuardCounts(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		giveInjected bool
+		giveInner    bool
+		give         http.HandlerFunc
+		wantPanic    interface{}
+		wantRequests int64
+		wantErrors   int64
+		wantSlow     int64
+		wantCode     int
+	}{
+		{
+			name:         "ok",
+			give:         func(w http.ResponseWriter, r *http.Request) {},
+			wantRequests: 1,
+			wantCode:     http.StatusOK,
+		},
+		{
+			name: "server error",
+			give: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			},
+			wantRequests: 1,
+			wantErrors:   1,
+			wantCode:     http.StatusServiceUnavailable,
+		},
+		{
+			name:         "slow",
+			give:         nil,
+			wantRequests: 1,
+			wantSlow:     1,
+			wantCode:     http.StatusOK,
+		},
+		{
+			name: "panic",
+			give: func(w http.ResponseWriter, r *http.Request) {
+				panic("boom")
+			},
+			wantPanic:    "boom",
+			wantRequests: 1,
+			wantErrors:   1,
+			wantCode:     http.StatusOK,
+		},
+		{
+			name: "abort",
+			give: func(w http.ResponseWriter, r *http.Request) {
+				panic(http.ErrAbortHandler)
+			},
+			wantPanic:    http.ErrAbortHandler,
+			wantRequests: 1,
+			wantCode:     http.StatusOK,
+		},
+		{
+			name:         "injected",
+			giveInjected: true,
+			give:         func(w http.ResponseWriter, r *http.Request) {},
+			wantCode:     http.StatusInternalServerError,
+		},
+		{
+			name:      "injected by inner fault",
+			giveInner: true,
+			give:      func(w http.ResponseWriter, r *http.Request) {},
+			wantCode:  http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			clock := faulttest.NewClock(time.Time{})
+			g, err := NewSLOGuard(WithMaxErrorRatio(0.1), WithLatencyObjective(time.Second, 0.1), WithClock(clock))
+			assert.NoError(t, err)
+
+			f, err := NewFault(newTestInjector500s(), WithEnabled(tt.giveInjected), WithParticipation(1.0),
+				WithSLOGuard(g))
+			assert.NoError(t, err)
+
+			h := tt.give
+			if h == nil {
+				h = func(w http.ResponseWriter, r *http.Request) {
+					clock.Advance(2 * time.Second)
+				}
+			}
+
+			var next http.Handler = h
+			if tt.giveInner {
+				inner, err := NewFault(newTestInjector500s(), WithEnabled(true), WithParticipation(1.0))
+				assert.NoError(t, err)
+				next = inner.Handler(next)
+			}
+
+			rr := httptest.NewRecorder()
+			serve := func() {
+				f.Handler(next).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+			}
+			if tt.wantPanic != nil {
+				assert.PanicsWithValue(t, tt.wantPanic, serve)
+			} else {
+				serve()
+			}
+
+			assert.Equal(t, tt.wantCode, rr.Code)
+			assert.Equal(t, tt.wantRequests, g.requests)
+			assert.Equal(t, tt.wantErrors, g.errors)
+			assert.Equal(t, tt.wantSlow, g.slow)
+		})
+	}
+}
+
+// TestSLOGuardCheck tests that SLOGuard.Check trips on a breach of its objectives and recovers
+// after enough healthy checks.
+func TestSLOGuardCheck(t *testing.T) {
+	t.Parallel()
+
+	clock := faulttest.NewClock(time.Time{})
+
+	var changes []SLOChange
+	g, err := NewSLOGuard(
+		WithMaxErrorRatio(0.1),
+		WithLatencyObjective(100*time.Millisecond, 0.1),
+		WithMinRequests(10),
+		WithRecoveryChecks(2),
+		WithClock(clock),
+		WithSLOChangeFunc(func(c SLOChange) {
+			changes = append(changes, c)
+		}),
+	)
+	assert.NoError(t, err)
+
+	enabled, err := NewFault(newTestInjectorNoop(), WithName("enabled"), WithEnabled(true), WithSLOGuard(g))
+	assert.NoError(t, err)
+	disabled, err := NewFault(newTestInjectorNoop(), WithName("disabled"), WithSLOGuard(g))
+	assert.NoError(t, err)
+
+	// the requests are served by the disabled Fault so that they are all organic
+	serve := func(n, failed, slow int) {
+		for i := 0; i < n; i++ {
+			i := i
+			disabled.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if i < failed {
+					w.WriteHeader(http.StatusInternalServerError)
+				}
+				if i < slow {
+					clock.Advance(time.Second)
+				}
+			})).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+		}
+	}
+
+	// healthy
+	serve(10, 1, 1)
+	assert.Nil(t, g.Check())
+
+	// too few requests to breach
+	serve(9, 9, 9)
+	assert.Nil(t, g.Check())
+
+	// errors breach
+	serve(10, 2, 0)
+	got := g.Check()
+	want := &SLOChange{Tripped: true, Requests: 10, Errors: 2, ErrorRatio: 0.2, Faults: []string{"enabled"}}
+	assert.Equal(t, want, got)
+	assert.True(t, g.Tripped())
+	assert.False(t, enabled.enabled.Load())
+
+	// healthy, then latency breaches and restarts the recovery
+	serve(10, 0, 0)
+	assert.Nil(t, g.Check())
+	serve(10, 0, 2)
+	assert.Nil(t, g.Check())
+	serve(10, 0, 0)
+	assert.Nil(t, g.Check())
+	assert.True(t, g.Tripped())
+
+	// an idle check is healthy
+	got = g.Check()
+	want = &SLOChange{Faults: []string{"enabled"}}
+	assert.Equal(t, want, got)
+	assert.False(t, g.Tripped())
+	assert.True(t, enabled.enabled.Load())
+	assert.False(t, disabled.enabled.Load())
+
+	assert.Len(t, changes, 2)
+
+	// a breach without enabled Faults does not trip
+	enabled.SetEnabled(false)
+	serve(10, 10, 0)
+	assert.Nil(t, g.Check())
+	assert.False(t, g.Tripped())
+}
+
+// TestSLOGuardStartStop tests SLOGuard.Start and SLOGuard.Stop.
+func TestSLOGuardStartStop(t *testing.T) {
+	t.Parallel()
+
+	clock := faulttest.NewClock(time.Time{})
+
+	changes := make(chan SLOChange, 1)
+	g, err := NewSLOGuard(
+		WithMaxErrorRatio(0.1),
+		WithMinRequests(1),
+		WithCheckInterval(time.Second),
+		WithClock(clock),
+		WithSLOChangeFunc(func(c SLOChange) {
+			changes <- c
+		}),
+	)
+	assert.NoError(t, err)
+
+	f, err := NewFault(newTestInjectorNoop(), WithEnabled(true), WithSLOGuard(g))
+	assert.NoError(t, err)
+
+	// stopping before starting does nothing
+	g.Stop()
+
+	g.Start()
+	g.Start()
+
+	f.SetEnabled(false)
+	f.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	f.SetEnabled(true)
+
+	clock.BlockUntil(1)
+	clock.Advance(time.Second)
+
+	got := <-changes
+	assert.True(t, got.Tripped)
+	assert.False(t, f.enabled.Load())
+
+	g.Stop()
+	g.Stop()
+}
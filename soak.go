@@ -0,0 +1,290 @@
+package fault
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+var (
+	// ErrInvalidSoakDuration when a SoakScenario is asked to last less than a positive duration.
+	ErrInvalidSoakDuration = errors.New("soak duration must be > 0")
+	// ErrInvalidStepRange when the minimum duration of the steps of a SoakScenario is not positive
+	// or is greater than the maximum.
+	ErrInvalidStepRange = errors.New("step durations must satisfy 0 < min <= max")
+	// ErrUnknownSoakKind when a SoakKind is not one of the SoakKinds of this package.
+	ErrUnknownSoakKind = errors.New("unknown soak kind")
+)
+
+// SoakKind is the kind of Injector a step of a SoakScenario runs.
+type SoakKind string
+
+const (
+	// SoakHealthy steps run no Injector.
+	SoakHealthy SoakKind = "healthy"
+	// SoakSlow steps run a SlowInjector.
+	SoakSlow SoakKind = "slow"
+	// SoakError steps run an ErrorInjector.
+	SoakError SoakKind = "error"
+	// SoakReject steps run a RejectInjector.
+	SoakReject SoakKind = "reject"
+)
+
+// soakKinds are the SoakKinds a SoakScenario mixes by default.
+var soakKinds = []SoakKind{SoakHealthy, SoakSlow, SoakError, SoakReject} //nolint:gochecknoglobals
+
+// soakCodes are the status codes SoakError steps respond with.
+var soakCodes = []int{ //nolint:gochecknoglobals
+	http.StatusInternalServerError,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+	http.StatusTooManyRequests,
+}
+
+const (
+	// minSoakDelay and maxSoakDelay bound the delay of SoakSlow steps.
+	minSoakDelay = 50 * time.Millisecond
+	maxSoakDelay = 5 * time.Second
+)
+
+// SoakStep is one step of a SoakScenario.
+type SoakStep struct {
+	// Kind is the kind of Injector the step runs.
+	Kind SoakKind `json:"kind"`
+	// Start is when the step starts, from the start of the scenario.
+	Start time.Duration `json:"start"`
+	// Duration is how long the step lasts.
+	Duration time.Duration `json:"duration"`
+	// Participation is the share of requests the Injector runs on.
+	Participation float32 `json:"participation"`
+	// Delay is how long SoakSlow steps delay requests.
+	Delay time.Duration `json:"delay,omitempty"`
+	// Code is the status code SoakError steps respond with.
+	Code int `json:"code,omitempty"`
+	// Expect are the Expectations of the Phase of the step.
+	Expect []Expectation `json:"expect,omitempty"`
+}
+
+// String returns a summary of the step, such as "slow 750ms" or "error 503".
+func (s SoakStep) String() string {
+	switch s.Kind {
+	case SoakSlow:
+		return fmt.Sprintf("%s %s", s.Kind, s.Delay)
+	case SoakError:
+		return fmt.Sprintf("%s %d", s.Kind, s.Code)
+	default:
+		return string(s.Kind)
+	}
+}
+
+// injector returns the Injector of the step, or nil for SoakHealthy.
+func (s SoakStep) injector(c Clock) (Injector, error) {
+	switch s.Kind {
+	case SoakHealthy:
+		return nil, nil
+	case SoakSlow:
+		return NewSlowInjector(s.Delay, WithClock(c))
+	case SoakError:
+		return NewErrorInjector(s.Code)
+	case SoakReject:
+		return NewRejectInjector()
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownSoakKind, s.Kind)
+	}
+}
+
+// SoakScenario is a randomized but seeded schedule of Injectors for soak testing, mixing kinds of
+// Injectors and participations over hours. It marshals to JSON, so the exact schedule of a soak
+// test can be kept with its results and replayed when an issue is found.
+type SoakScenario struct {
+	// Seed is the seed the scenario was generated with. The OutageInjector of the scenario also uses
+	// it to select requests.
+	Seed int64 `json:"seed"`
+	// Steps are the steps of the scenario, in order.
+	Steps []SoakStep `json:"steps"`
+}
+
+// soakConfig holds the options of GenerateSoakScenario.
+type soakConfig struct {
+	randSeed         int64
+	kinds            []SoakKind
+	minStep          time.Duration
+	maxStep          time.Duration
+	maxParticipation float32
+}
+
+// SoakOption configures GenerateSoakScenario.
+type SoakOption interface {
+	applySoak(c *soakConfig) error
+}
+
+func (o randSeedOption) applySoak(c *soakConfig) error {
+	c.randSeed = int64(o)
+	return nil
+}
+
+type soakKindsOption []SoakKind
+
+func (o soakKindsOption) applySoak(c *soakConfig) error {
+	if len(o) == 0 {
+		return fmt.Errorf("%w: no kinds", ErrUnknownSoakKind)
+	}
+	for _, k := range o {
+		switch k {
+		case SoakHealthy, SoakSlow, SoakError, SoakReject:
+		default:
+			return fmt.Errorf("%w: %q", ErrUnknownSoakKind, k)
+		}
+	}
+
+	c.kinds = append([]SoakKind(nil), o...)
+
+	return nil
+}
+
+// WithSoakKinds sets the kinds of steps a SoakScenario mixes. Pass a kind more than once to choose
+// it more often. Default SoakHealthy, SoakSlow, SoakError, and SoakReject.
+func WithSoakKinds(kinds ...SoakKind) SoakOption {
+	return soakKindsOption(kinds)
+}
+
+type stepDurationOption struct {
+	min time.Duration
+	max time.Duration
+}
+
+func (o stepDurationOption) applySoak(c *soakConfig) error {
+	if o.min <= 0 || o.max < o.min {
+		return ErrInvalidStepRange
+	}
+
+	c.minStep, c.maxStep = o.min, o.max
+
+	return nil
+}
+
+// WithStepDuration sets the range of durations of the steps of a SoakScenario. The last step is
+// cut short to end with the scenario. Default 1m to 15m.
+func WithStepDuration(min, max time.Duration) SoakOption {
+	return stepDurationOption{min: min, max: max}
+}
+
+type maxParticipationOption float32
+
+func (o maxParticipationOption) applySoak(c *soakConfig) error {
+	if o < 0 || o > 1 {
+		return ErrInvalidPercent
+	}
+
+	c.maxParticipation = float32(o)
+
+	return nil
+}
+
+// WithMaxParticipation sets the highest participation (0.0 <= p <= 1.0) of the steps of a
+// SoakScenario. Default 0.1.
+func WithMaxParticipation(p float32) SoakOption {
+	return maxParticipationOption(p)
+}
+
+// GenerateSoakScenario returns a SoakScenario that lasts d. Steps have a random kind, duration, and
+// participation; SoakSlow steps delay requests by 50ms to 5s, and SoakError steps respond with a
+// 5xx or 429. The same options always generate the same scenario, so pass WithRandSeed() with a new
+// seed for each soak test.
+func GenerateSoakScenario(d time.Duration, opts ...SoakOption) (*SoakScenario, error) {
+	if d <= 0 {
+		return nil, ErrInvalidSoakDuration
+	}
+
+	// set defaults
+	c := &soakConfig{
+		randSeed:         defaultRandSeed,
+		kinds:            soakKinds,
+		minStep:          time.Minute,
+		maxStep:          15 * time.Minute,
+		maxParticipation: 0.1,
+	}
+
+	// apply options
+	for _, opt := range opts {
+		err := opt.applySoak(c)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	r := rand.New(rand.NewSource(c.randSeed))
+	s := &SoakScenario{Seed: c.randSeed}
+	for start := time.Duration(0); start < d; {
+		step := SoakStep{
+			Kind:     c.kinds[r.Intn(len(c.kinds))],
+			Start:    start,
+			Duration: c.minStep + time.Duration(r.Int63n(int64(c.maxStep-c.minStep)+1)),
+		}
+		if step.Duration > d-start {
+			step.Duration = d - start
+		}
+
+		if step.Kind != SoakHealthy {
+			step.Participation = float32(math.Round(r.Float64()*float64(c.maxParticipation)*1000) / 1000)
+		}
+
+		switch step.Kind {
+		case SoakSlow:
+			// log-uniform, so short and long delays are as likely
+			delay := float64(minSoakDelay) * math.Pow(float64(maxSoakDelay)/float64(minSoakDelay), r.Float64())
+			step.Delay = time.Duration(delay).Round(time.Millisecond)
+		case SoakError:
+			step.Code = soakCodes[r.Intn(len(soakCodes))]
+		}
+
+		s.Steps = append(s.Steps, step)
+		start += step.Duration
+	}
+
+	return s, nil
+}
+
+// Duration returns how long the SoakScenario lasts.
+func (s *SoakScenario) Duration() time.Duration {
+	var d time.Duration
+	for _, step := range s.Steps {
+		d += step.Duration
+	}
+
+	return d
+}
+
+// OutageInjector returns an OutageInjector that runs the steps of the SoakScenario as its Phases,
+// selecting requests with the seed of the scenario unless opts set another. The Clock set in opts
+// is also used by the SlowInjectors of SoakSlow steps. It returns an InjectorError naming the
+// first step that cannot be built, such as one of an unknown kind.
+func (s *SoakScenario) OutageInjector(opts ...OutageInjectorOption) (*OutageInjector, error) {
+	// apply the options to a scratch OutageInjector to find the Clock the steps share
+	scratch := &OutageInjector{clock: NewRealClock()}
+	for _, opt := range opts {
+		_ = opt.applyOutageInjector(scratch)
+	}
+
+	phases := make([]Phase, len(s.Steps))
+	for idx, step := range s.Steps {
+		i, err := step.injector(scratch.clock)
+		if err != nil {
+			return nil, &InjectorError{Index: idx, Err: err}
+		}
+
+		phases[idx] = Phase{
+			Name:          step.String(),
+			Duration:      step.Duration,
+			Injector:      i,
+			Participation: step.Participation,
+			Expect:        step.Expect,
+		}
+	}
+
+	return NewOutageInjector(phases, append([]OutageInjectorOption{WithRandSeed(s.Seed)}, opts...)...)
+}
@@ -0,0 +1,410 @@
+package fault
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/github/go-fault/faulttest"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestGenerateSoakScenario tests GenerateSoakScenario.
+func TestGenerateSoakScenario(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name              string
+		giveDuration      time.Duration
+		giveOptions       []SoakOption
+		wantKinds         []SoakKind
+		wantMinStep       time.Duration
+		wantMaxStep       time.Duration
+		wantParticipation float32
+		wantSeed          int64
+		wantErr           error
+	}{
+		{
+			name:              "defaults",
+			giveDuration:      6 * time.Hour,
+			wantKinds:         soakKinds,
+			wantMinStep:       time.Minute,
+			wantMaxStep:       15 * time.Minute,
+			wantParticipation: 0.1,
+			wantSeed:          defaultRandSeed,
+		},
+		{
+			name:         "options",
+			giveDuration: 24 * time.Hour,
+			giveOptions: []SoakOption{
+				WithRandSeed(42),
+				WithSoakKinds(SoakSlow, SoakError),
+				WithStepDuration(time.Minute, 2*time.Minute),
+				WithMaxParticipation(0.5),
+			},
+			wantKinds:         []SoakKind{SoakSlow, SoakError},
+			wantMinStep:       time.Minute,
+			wantMaxStep:       2 * time.Minute,
+			wantParticipation: 0.5,
+			wantSeed:          42,
+		},
+		{
+			name:         "fixed step duration",
+			giveDuration: time.Hour,
+			giveOptions: []SoakOption{
+				WithStepDuration(7*time.Minute, 7*time.Minute),
+			},
+			wantKinds:         soakKinds,
+			wantMinStep:       7 * time.Minute,
+			wantMaxStep:       7 * time.Minute,
+			wantParticipation: 0.1,
+			wantSeed:          defaultRandSeed,
+		},
+		{
+			name:         "shorter than a step",
+			giveDuration: time.Second,
+			wantKinds:    soakKinds,
+			// the only step is cut short
+			wantMinStep:       time.Second,
+			wantMaxStep:       time.Second,
+			wantParticipation: 0.1,
+			wantSeed:          defaultRandSeed,
+		},
+		{
+			name:         "invalid duration",
+			giveDuration: 0,
+			wantErr:      ErrInvalidSoakDuration,
+		},
+		{
+			name:         "no kinds",
+			giveDuration: time.Hour,
+			giveOptions:  []SoakOption{WithSoakKinds()},
+			wantErr:      ErrUnknownSoakKind,
+		},
+		{
+			name:         "unknown kind",
+			giveDuration: time.Hour,
+			giveOptions:  []SoakOption{WithSoakKinds(SoakSlow, "teapot")},
+			wantErr:      ErrUnknownSoakKind,
+		},
+		{
+			name:         "zero step duration",
+			giveDuration: time.Hour,
+			giveOptions:  []SoakOption{WithStepDuration(0, time.Minute)},
+			wantErr:      ErrInvalidStepRange,
+		},
+		{
+			name:         "inverted step duration",
+			giveDuration: time.Hour,
+			giveOptions:  []SoakOption{WithStepDuration(time.Minute, time.Second)},
+			wantErr:      ErrInvalidStepRange,
+		},
+		{
+			name:         "invalid participation",
+			giveDuration: time.Hour,
+			giveOptions:  []SoakOption{WithMaxParticipation(1.1)},
+			wantErr:      ErrInvalidPercent,
+		},
+		{
+			name:         "negative participation",
+			giveDuration: time.Hour,
+			giveOptions:  []SoakOption{WithMaxParticipation(-0.1)},
+			wantErr:      ErrInvalidPercent,
+		},
+		{
+			name:         "option error",
+			giveDuration: time.Hour,
+			giveOptions:  []SoakOption{withError()},
+			wantErr:      errErrorOption,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			s, err := GenerateSoakScenario(tt.giveDuration, tt.giveOptions...)
+
+			assert.True(t, errors.Is(err, tt.wantErr), err)
+			if tt.wantErr != nil {
+				assert.Nil(t, s)
+				return
+			}
+
+			assert.Equal(t, tt.wantSeed, s.Seed)
+			assert.Equal(t, tt.giveDuration, s.Duration())
+
+			var start time.Duration
+			for idx, step := range s.Steps {
+				assert.Equal(t, start, step.Start, "step %d", idx)
+				start += step.Duration
+
+				assert.Contains(t, tt.wantKinds, step.Kind)
+				assert.GreaterOrEqual(t, step.Participation, float32(0))
+				assert.LessOrEqual(t, step.Participation, tt.wantParticipation)
+				assert.True(t, step.Duration <= tt.wantMaxStep, step.Duration)
+				if idx < len(s.Steps)-1 {
+					assert.True(t, step.Duration >= tt.wantMinStep, step.Duration)
+				}
+
+				switch step.Kind {
+				case SoakHealthy:
+					assert.Equal(t, float32(0), step.Participation)
+				case SoakSlow:
+					assert.True(t, step.Delay >= minSoakDelay && step.Delay <= maxSoakDelay, step.Delay)
+					assert.Equal(t, step.Delay.Round(time.Millisecond), step.Delay)
+				case SoakError:
+					assert.Contains(t, soakCodes, step.Code)
+				}
+				if step.Kind != SoakSlow {
+					assert.Zero(t, step.Delay)
+				}
+				if step.Kind != SoakError {
+					assert.Zero(t, step.Code)
+				}
+			}
+
+			// the same options generate the same scenario
+			again, err := GenerateSoakScenario(tt.giveDuration, tt.giveOptions...)
+			assert.NoError(t, err)
+			assert.Equal(t, s, again)
+		})
+	}
+}
+
+// TestGenerateSoakScenarioSeed tests that different seeds generate different scenarios.
+func TestGenerateSoakScenarioSeed(t *testing.T) {
+	t.Parallel()
+
+	s1, err := GenerateSoakScenario(12*time.Hour, WithRandSeed(1))
+	assert.NoError(t, err)
+	s2, err := GenerateSoakScenario(12*time.Hour, WithRandSeed(2))
+	assert.NoError(t, err)
+
+	assert.NotEqual(t, s1.Steps, s2.Steps)
+}
+
+// TestSoakScenarioJSON tests that a SoakScenario survives a round trip through JSON.
+func TestSoakScenarioJSON(t *testing.T) {
+	t.Parallel()
+
+	s, err := GenerateSoakScenario(12*time.Hour, WithRandSeed(7))
+	assert.NoError(t, err)
+
+	b, err := json.Marshal(s)
+	assert.NoError(t, err)
+
+	var got SoakScenario
+	assert.NoError(t, json.Unmarshal(b, &got))
+	assert.Equal(t, s, &got)
+
+	b, err = json.Marshal(SoakStep{Kind: SoakReject, Start: time.Minute, Duration: time.Second, Participation: 0.25})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"kind":"reject","start":60000000000,"duration":1000000000,"participation":0.25}`, string(b))
+}
+
+// TestSoakStepString tests SoakStep.String.
+func TestSoakStepString(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		give SoakStep
+		want string
+	}{
+		{
+			name: "healthy",
+			give: SoakStep{Kind: SoakHealthy},
+			want: "healthy",
+		},
+		{
+			name: "slow",
+			give: SoakStep{Kind: SoakSlow, Delay: 750 * time.Millisecond},
+			want: "slow 750ms",
+		},
+		{
+			name: "error",
+			give: SoakStep{Kind: SoakError, Code: http.StatusServiceUnavailable},
+			want: "error 503",
+		},
+		{
+			name: "reject",
+			give: SoakStep{Kind: SoakReject},
+			want: "reject",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, tt.give.String())
+		})
+	}
+}
+
+// TestSoakScenarioOutageInjector tests SoakScenario.OutageInjector.
+func TestSoakScenarioOutageInjector(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		give        *SoakScenario
+		giveOptions []OutageInjectorOption
+		wantNames   []string
+		wantSeed    int64
+		wantErr     error
+	}{
+		{
+			name: "steps",
+			give: &SoakScenario{
+				Seed: 42,
+				Steps: []SoakStep{
+					{Kind: SoakHealthy, Duration: time.Minute},
+					{Kind: SoakSlow, Start: time.Minute, Duration: time.Minute, Participation: 0.1, Delay: time.Second},
+					{Kind: SoakError, Start: 2 * time.Minute, Duration: time.Minute, Participation: 0.1, Code: 503},
+					{
+						Kind:          SoakReject,
+						Start:         3 * time.Minute,
+						Duration:      time.Minute,
+						Participation: 0.1,
+						Expect:        []Expectation{{MaxRateIncrease: 2}},
+					},
+				},
+			},
+			wantNames: []string{"healthy", "slow 1s", "error 503", "reject"},
+			wantSeed:  42,
+		},
+		{
+			name: "seed option",
+			give: &SoakScenario{
+				Seed:  42,
+				Steps: []SoakStep{{Kind: SoakHealthy, Duration: time.Minute}},
+			},
+			giveOptions: []OutageInjectorOption{WithRandSeed(7)},
+			wantNames:   []string{"healthy"},
+			wantSeed:    7,
+		},
+		{
+			name: "unknown kind",
+			give: &SoakScenario{
+				Steps: []SoakStep{
+					{Kind: SoakHealthy, Duration: time.Minute},
+					{Kind: "teapot", Start: time.Minute, Duration: time.Minute},
+				},
+			},
+			wantErr: ErrUnknownSoakKind,
+		},
+		{
+			name: "invalid code",
+			give: &SoakScenario{
+				Steps: []SoakStep{
+					{Kind: SoakError, Duration: time.Minute, Code: 42},
+				},
+			},
+			wantErr: ErrInvalidHTTPCode,
+		},
+		{
+			name:    "no steps",
+			give:    &SoakScenario{},
+			wantErr: ErrNoPhases,
+		},
+		{
+			name: "option error",
+			give: &SoakScenario{
+				Steps: []SoakStep{{Kind: SoakHealthy, Duration: time.Minute}},
+			},
+			giveOptions: []OutageInjectorOption{withError()},
+			wantErr:     errErrorOption,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			oi, err := tt.give.OutageInjector(tt.giveOptions...)
+
+			assert.True(t, errors.Is(err, tt.wantErr), err)
+			if tt.wantErr != nil {
+				assert.Nil(t, oi)
+				return
+			}
+
+			names := make([]string, len(oi.phases))
+			for idx, p := range oi.phases {
+				names[idx] = p.Name
+				assert.Equal(t, tt.give.Steps[idx].Duration, p.Duration)
+				assert.Equal(t, tt.give.Steps[idx].Participation, p.Participation)
+				assert.Equal(t, tt.give.Steps[idx].Expect, p.Expect)
+			}
+			assert.Equal(t, tt.wantNames, names)
+			assert.Equal(t, tt.wantSeed, oi.Seed())
+		})
+	}
+}
+
+// TestSoakScenarioOutageInjectorError tests that the error of a step that cannot be built names
+// the step.
+func TestSoakScenarioOutageInjectorError(t *testing.T) {
+	t.Parallel()
+
+	s := &SoakScenario{
+		Steps: []SoakStep{
+			{Kind: SoakHealthy, Duration: time.Minute},
+			{Kind: SoakError, Start: time.Minute, Duration: time.Minute, Code: 42},
+		},
+	}
+
+	_, err := s.OutageInjector()
+
+	var ie *InjectorError
+	assert.True(t, errors.As(err, &ie))
+	assert.Equal(t, 1, ie.Index)
+}
+
+// TestSoakScenarioReplay tests that a SoakScenario replays its steps on the Clock it is given.
+func TestSoakScenarioReplay(t *testing.T) {
+	t.Parallel()
+
+	clock := faulttest.NewClock(time.Time{})
+	s := &SoakScenario{
+		Seed: 42,
+		Steps: []SoakStep{
+			{Kind: SoakError, Duration: time.Minute, Participation: 1.0, Code: http.StatusBadGateway},
+			{Kind: SoakSlow, Start: time.Minute, Duration: time.Minute, Participation: 1.0, Delay: time.Second},
+			{Kind: SoakHealthy, Start: 2 * time.Minute, Duration: time.Minute},
+		},
+	}
+
+	oi, err := s.OutageInjector(WithClock(clock))
+	assert.NoError(t, err)
+
+	h := oi.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(testHandlerCode)
+	}))
+	serve := func() int {
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+		return rr.Code
+	}
+
+	assert.Equal(t, http.StatusBadGateway, serve())
+
+	// the SlowInjector waits on the same Clock
+	clock.Advance(time.Minute)
+	done := make(chan int)
+	go func() { done <- serve() }()
+	clock.BlockUntil(1)
+	clock.Advance(time.Second)
+	assert.Equal(t, testHandlerCode, <-done)
+
+	clock.Advance(time.Minute)
+	assert.Equal(t, testHandlerCode, serve())
+}
@@ -0,0 +1,233 @@
+package fault
+
+import (
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// defaultWriteScale is the fraction of the read participation that the write Fault of a SplitFault
+// runs at without WithWriteScale.
+const defaultWriteScale = 0.1
+
+// SplitFault is a pair of Faults built from one definition, splitting requests between an
+// aggressive Injector on safe read routes and a conservative Injector on write routes. The write
+// Fault runs at a fraction of the participation of the read Fault. Change the participation and
+// schedule through the SplitFault to keep the pair in sync. Add both Faults to a Manager with
+// Manager.Add(sf.Faults()...).
+type SplitFault struct {
+	read  *Fault
+	write *Fault
+
+	name        string
+	readMatcher RequestMatcher
+	writeScale  float32
+	faultOpts   []Option
+
+	// participation is the participation of the read Fault when it is built.
+	participation float32
+
+	// schedule is shared by both Faults so SetSchedule changes them together.
+	schedule *splitSchedule
+
+	// mtx serializes SetParticipation so the pair is always changed together.
+	mtx sync.Mutex
+}
+
+// SplitFaultOption configures a SplitFault.
+type SplitFaultOption interface {
+	applySplitFault(sf *SplitFault) error
+}
+
+func (o nameOption) applySplitFault(sf *SplitFault) error {
+	sf.name = string(o)
+	return nil
+}
+
+func (o participationOption) applySplitFault(sf *SplitFault) error {
+	if o < 0.0 || o > 1.0 {
+		return ErrInvalidPercent
+	}
+
+	sf.participation = float32(o)
+	return nil
+}
+
+func (o scheduleOption) applySplitFault(sf *SplitFault) error {
+	sf.schedule.set(o.schedule)
+	return nil
+}
+
+type writeScaleOption float32
+
+func (o writeScaleOption) applySplitFault(sf *SplitFault) error {
+	if o < 0.0 || o > 1.0 {
+		return ErrInvalidPercent
+	}
+
+	sf.writeScale = float32(o)
+	return nil
+}
+
+// WithWriteScale sets the participation of the write Fault of a SplitFault as a fraction of the
+// participation of the read Fault. 0.0 <= scale <= 1.0. Default 0.1.
+func WithWriteScale(scale float32) SplitFaultOption {
+	return writeScaleOption(scale)
+}
+
+type readMatcherOption struct {
+	matcher RequestMatcher
+}
+
+func (o readMatcherOption) applySplitFault(sf *SplitFault) error {
+	sf.readMatcher = o.matcher
+	return nil
+}
+
+// WithReadMatcher sets the RequestMatcher that decides which requests are reads, and run the read
+// Fault of a SplitFault. Every other request runs the write Fault. Default MatchMethod() with the
+// safe methods GET, HEAD, OPTIONS, and TRACE.
+func WithReadMatcher(m RequestMatcher) SplitFaultOption {
+	return readMatcherOption{m}
+}
+
+type faultOptionsOption []Option
+
+func (o faultOptionsOption) applySplitFault(sf *SplitFault) error {
+	sf.faultOpts = append(sf.faultOpts, o...)
+	return nil
+}
+
+// WithFaultOptions configures both Faults of a SplitFault with opts, such as WithEnabled() or
+// WithReporter(). The name, participation, and schedule of the Faults are set by the SplitFault.
+func WithFaultOptions(opts ...Option) SplitFaultOption {
+	return faultOptionsOption(opts)
+}
+
+// NewSplitFault returns a SplitFault that runs read on requests for safe methods and write on the
+// rest. The Faults are named after WithName(), which defaults to "split", followed by "-read" and
+// "-write". WithParticipation() sets the participation of the read Fault, and the write Fault runs
+// at WithWriteScale() of it.
+func NewSplitFault(read, write Injector, opts ...SplitFaultOption) (*SplitFault, error) {
+	// set defaults
+	sf := &SplitFault{
+		name:        "split",
+		readMatcher: MatchMethod(http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace),
+		writeScale:  defaultWriteScale,
+		schedule:    &splitSchedule{},
+	}
+
+	// apply options
+	for _, opt := range opts {
+		err := opt.applySplitFault(sf)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// build faults
+	var err error
+	sf.read, err = NewFault(read, append(sf.faultOpts,
+		WithName(sf.name+"-read"),
+		WithParticipation(sf.participation),
+		WithSchedule(sf.schedule),
+		WithRequestMatcher(sf.readMatcher),
+	)...)
+	if err != nil {
+		return nil, err
+	}
+
+	sf.write, err = NewFault(write, append(sf.faultOpts,
+		WithName(sf.name+"-write"),
+		WithParticipation(sf.participation*sf.writeScale),
+		WithSchedule(sf.schedule),
+		WithRequestExcluder(sf.readMatcher),
+	)...)
+	if err != nil {
+		return nil, err
+	}
+
+	return sf, nil
+}
+
+// Read returns the Fault that runs on read requests.
+func (sf *SplitFault) Read() *Fault {
+	return sf.read
+}
+
+// Write returns the Fault that runs on write requests.
+func (sf *SplitFault) Write() *Fault {
+	return sf.write
+}
+
+// Faults returns the read and write Faults.
+func (sf *SplitFault) Faults() []*Fault {
+	return []*Fault{sf.read, sf.write}
+}
+
+// WriteScale returns the participation of the write Fault as a fraction of the read Fault.
+func (sf *SplitFault) WriteScale() float32 {
+	return sf.writeScale
+}
+
+// SetEnabled enables or disables both Faults.
+func (sf *SplitFault) SetEnabled(e bool) {
+	sf.read.SetEnabled(e)
+	sf.write.SetEnabled(e)
+}
+
+// Participation returns the participation of the read Fault.
+func (sf *SplitFault) Participation() float32 {
+	return sf.read.Participation()
+}
+
+// SetParticipation sets the participation of the read Fault to p and of the write Fault to p scaled
+// by WithWriteScale(). 0.0 <= p <= 1.0. It is safe to call while handling requests. If either Fault
+// refuses the change, such as with ErrParticipationIncrease, neither is changed.
+func (sf *SplitFault) SetParticipation(p float32) error {
+	sf.mtx.Lock()
+	defer sf.mtx.Unlock()
+
+	old := sf.read.Participation()
+	err := sf.read.SetParticipation(p)
+	if err != nil {
+		return err
+	}
+
+	err = sf.write.SetParticipation(p * sf.writeScale)
+	if err != nil {
+		// the read Fault was only just changed from old, so changing it back is allowed
+		_ = sf.read.SetParticipation(old)
+		return err
+	}
+
+	return nil
+}
+
+// SetSchedule replaces the Schedule of both Faults. A nil Schedule is always active. It is safe to
+// call while handling requests.
+func (sf *SplitFault) SetSchedule(s Schedule) {
+	sf.schedule.set(s)
+}
+
+// splitSchedule is a Schedule that can be replaced while it is in use.
+type splitSchedule struct {
+	v atomic.Value
+}
+
+// scheduleHolder holds a Schedule that may be nil, which atomic.Value cannot store.
+type scheduleHolder struct {
+	schedule Schedule
+}
+
+// set replaces the Schedule with schedule.
+func (s *splitSchedule) set(schedule Schedule) {
+	s.v.Store(scheduleHolder{schedule})
+}
+
+// Active returns true if the current Schedule is nil or active at t.
+func (s *splitSchedule) Active(t time.Time) bool {
+	h, _ := s.v.Load().(scheduleHolder)
+	return h.schedule == nil || h.schedule.Active(t)
+}
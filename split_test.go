@@ -0,0 +1,211 @@
+package fault
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/github/go-fault/faulttest"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestNewSplitFault tests NewSplitFault.
+func TestNewSplitFault(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name                   string
+		giveOptions            []SplitFaultOption
+		wantReadName           string
+		wantWriteName          string
+		wantEnabled            bool
+		wantReadParticipation  float32
+		wantWriteParticipation float32
+		wantErr                error
+	}{
+		{
+			name:          "no options",
+			giveOptions:   []SplitFaultOption{},
+			wantReadName:  "split-read",
+			wantWriteName: "split-write",
+		},
+		{
+			name: "options",
+			giveOptions: []SplitFaultOption{
+				WithName("checkout"),
+				WithParticipation(0.5),
+				WithWriteScale(0.2),
+				WithSchedule(ScheduleFunc(func(time.Time) bool { return true })),
+				WithReadMatcher(MatchMethod(http.MethodGet)),
+				WithFaultOptions(WithEnabled(true)),
+			},
+			wantReadName:           "checkout-read",
+			wantWriteName:          "checkout-write",
+			wantEnabled:            true,
+			wantReadParticipation:  0.5,
+			wantWriteParticipation: 0.1,
+		},
+		{
+			name: "invalid participation",
+			giveOptions: []SplitFaultOption{
+				WithParticipation(1.5),
+			},
+			wantErr: ErrInvalidPercent,
+		},
+		{
+			name: "invalid write scale",
+			giveOptions: []SplitFaultOption{
+				WithWriteScale(-0.1),
+			},
+			wantErr: ErrInvalidPercent,
+		},
+		{
+			name: "invalid fault option",
+			giveOptions: []SplitFaultOption{
+				WithFaultOptions(withError()),
+			},
+			wantErr: errErrorOption,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			sf, err := NewSplitFault(newTestInjector500s(), newTestInjectorNoop(), tt.giveOptions...)
+
+			assert.Equal(t, tt.wantErr, err)
+			if tt.wantErr != nil {
+				assert.Nil(t, sf)
+				return
+			}
+
+			assert.Equal(t, []*Fault{sf.Read(), sf.Write()}, sf.Faults())
+			assert.Equal(t, tt.wantReadName, sf.Read().Name())
+			assert.Equal(t, tt.wantWriteName, sf.Write().Name())
+			assert.Equal(t, tt.wantEnabled, sf.Read().Enabled())
+			assert.Equal(t, tt.wantEnabled, sf.Write().Enabled())
+			assert.InDelta(t, tt.wantReadParticipation, sf.Participation(), 1e-6)
+			assert.InDelta(t, tt.wantWriteParticipation, sf.Write().Participation(), 1e-6)
+		})
+	}
+}
+
+// TestNewSplitFaultWriteError tests that NewSplitFault returns the error of building the write
+// Fault.
+func TestNewSplitFaultWriteError(t *testing.T) {
+	t.Parallel()
+
+	sf, err := NewSplitFault(newTestInjectorNoop(), nil)
+	assert.Equal(t, ErrNilInjector, err)
+	assert.Nil(t, sf)
+}
+
+// TestSplitFaultHandler tests that a SplitFault runs the read Fault on safe methods and the write
+// Fault on the rest.
+func TestSplitFaultHandler(t *testing.T) {
+	t.Parallel()
+
+	wi, err := NewErrorInjector(http.StatusServiceUnavailable)
+	assert.NoError(t, err)
+
+	sf, err := NewSplitFault(newTestInjector500s(), wi,
+		WithParticipation(1.0), WithWriteScale(1.0), WithFaultOptions(WithEnabled(true)))
+	assert.NoError(t, err)
+
+	m, err := NewManager()
+	assert.NoError(t, err)
+	assert.NoError(t, m.Add(sf.Faults()...))
+
+	h := m.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(testHandlerCode)
+	}))
+
+	tests := []struct {
+		method   string
+		wantCode int
+	}{
+		{http.MethodGet, http.StatusInternalServerError},
+		{http.MethodHead, http.StatusInternalServerError},
+		{http.MethodPost, http.StatusServiceUnavailable},
+		{http.MethodDelete, http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(tt.method, "/", nil))
+		assert.Equal(t, tt.wantCode, rr.Code, tt.method)
+	}
+
+	sf.SetEnabled(false)
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/", nil))
+	assert.Equal(t, testHandlerCode, rr.Code)
+	assert.False(t, sf.Read().Enabled())
+}
+
+// TestSplitFaultSetParticipation tests that SplitFault.SetParticipation keeps the pair in sync.
+func TestSplitFaultSetParticipation(t *testing.T) {
+	t.Parallel()
+
+	clock := faulttest.NewClock(time.Time{})
+	sf, err := NewSplitFault(newTestInjector500s(), newTestInjectorNoop(), WithWriteScale(0.5))
+	assert.NoError(t, err)
+	assert.Equal(t, float32(0.5), sf.WriteScale())
+
+	assert.NoError(t, sf.SetParticipation(0.04))
+	assert.InDelta(t, 0.04, sf.Read().Participation(), 1e-6)
+	assert.InDelta(t, 0.02, sf.Write().Participation(), 1e-6)
+
+	assert.Equal(t, ErrInvalidPercent, sf.SetParticipation(1.5))
+	assert.InDelta(t, 0.04, sf.Participation(), 1e-6)
+
+	m, err := NewManager(WithClock(clock), WithParticipationLimit(0.05, time.Minute))
+	assert.NoError(t, err)
+	assert.NoError(t, m.Add(sf.Faults()...))
+
+	// the read Fault refuses the change
+	assert.True(t, errors.Is(sf.SetParticipation(0.5), ErrParticipationIncrease))
+	assert.InDelta(t, 0.04, sf.Read().Participation(), 1e-6)
+	assert.InDelta(t, 0.02, sf.Write().Participation(), 1e-6)
+
+	// spend the budget of the write Fault so it refuses the change, and the read Fault is restored
+	assert.NoError(t, sf.Write().SetParticipation(0.06))
+	assert.NoError(t, sf.Write().SetParticipation(0.02))
+	assert.True(t, errors.Is(sf.SetParticipation(0.08), ErrParticipationIncrease))
+	assert.InDelta(t, 0.04, sf.Read().Participation(), 1e-6)
+	assert.InDelta(t, 0.02, sf.Write().Participation(), 1e-6)
+}
+
+// TestSplitFaultSetSchedule tests that SplitFault.SetSchedule changes the Schedule of both Faults.
+func TestSplitFaultSetSchedule(t *testing.T) {
+	t.Parallel()
+
+	inactive := ScheduleFunc(func(time.Time) bool { return false })
+	sf, err := NewSplitFault(newTestInjector500s(), newTestInjector500s(), WithSchedule(inactive),
+		WithParticipation(1.0), WithWriteScale(1.0), WithFaultOptions(WithEnabled(true)))
+	assert.NoError(t, err)
+
+	serve := func(method string) int {
+		rr := httptest.NewRecorder()
+		h := sf.Read().Handler(sf.Write().Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(testHandlerCode)
+		})))
+		h.ServeHTTP(rr, httptest.NewRequest(method, "/", nil))
+
+		return rr.Code
+	}
+
+	assert.Equal(t, testHandlerCode, serve(http.MethodGet))
+	assert.Equal(t, testHandlerCode, serve(http.MethodPost))
+
+	sf.SetSchedule(nil)
+	assert.Equal(t, http.StatusInternalServerError, serve(http.MethodGet))
+	assert.Equal(t, http.StatusInternalServerError, serve(http.MethodPost))
+
+	sf.SetSchedule(inactive)
+	assert.Equal(t, testHandlerCode, serve(http.MethodGet))
+}
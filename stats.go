@@ -0,0 +1,231 @@
+package fault
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// FaultStats is a snapshot of the decisions a Fault has made, as returned by Fault.Stats.
+type FaultStats struct {
+	// Name is the name of the Fault.
+	Name string `json:"name"`
+	// Participation is the participation the Fault is configured with.
+	Participation float32 `json:"participation"`
+	// Evaluated is the number of requests the Fault has seen.
+	Evaluated int64 `json:"evaluated"`
+	// Injected is the number of requests the Injector ran on.
+	Injected int64 `json:"injected"`
+	// Skipped is the number of requests the Injector did not run on.
+	Skipped int64 `json:"skipped"`
+	// Active is the number of requests currently inside the Injector.
+	Active int64 `json:"active"`
+	// SkippedBy breaks Skipped down by SkipReason. Reasons without skips are left out.
+	SkippedBy map[SkipReason]int64 `json:"skipped_by,omitempty"`
+	// Injectors breaks Injected down by the InjectorString of the Injector that ran, which differs
+	// from the current Injector after Fault.SetInjector.
+	Injectors map[string]int64 `json:"injectors,omitempty"`
+	// ObservedParticipation is the share of the requests that reached the participation roll that
+	// were injected, to compare with Participation. It is 0 until a request reaches the roll.
+	ObservedParticipation float64 `json:"observed_participation"`
+}
+
+// Stats returns a snapshot of the decisions the Fault has made.
+func (f *Fault) Stats() FaultStats {
+	counters := f.stats.counters()
+
+	fs := FaultStats{
+		Name:          f.name,
+		Participation: f.Participation(),
+		Evaluated:     counters["evaluated"],
+		Injected:      counters["injected"],
+		Skipped:       counters["skipped"],
+		Active:        counters["active"],
+		Injectors:     f.stats.injectorCounts(),
+	}
+
+	for name, n := range counters {
+		if reason := strings.TrimPrefix(name, "skipped_"); reason != name && n > 0 {
+			if fs.SkippedBy == nil {
+				fs.SkippedBy = make(map[SkipReason]int64)
+			}
+			fs.SkippedBy[SkipReason(reason)] = n
+		}
+	}
+
+	if rolled := fs.Injected + fs.SkippedBy[SkipParticipation]; rolled > 0 {
+		fs.ObservedParticipation = float64(fs.Injected) / float64(rolled)
+	}
+
+	return fs
+}
+
+// NewStatsHandler returns an http.Handler that responds with the FaultStats of each of faults as a
+// JSON array, in order. Mount it on an internal port, for example at /debug/faults/stats.
+func NewStatsHandler(faults ...*Fault) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeFaultStats(w, faults)
+	})
+}
+
+// StatsHandler returns an http.Handler that responds with the FaultStats of each managed Fault as a
+// JSON array, in the format of NewStatsHandler.
+func (m *Manager) StatsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeFaultStats(w, m.Faults())
+	})
+}
+
+// writeFaultStats responds with the FaultStats of faults as JSON.
+func writeFaultStats(w http.ResponseWriter, faults []*Fault) {
+	stats := make([]FaultStats, len(faults))
+	for idx, f := range faults {
+		stats[idx] = f.Stats()
+	}
+
+	writeAdminJSON(w, stats)
+}
+
+// faultStats counts the decisions a Fault makes. All counts except injectors must be accessed
+// atomically.
+type faultStats struct {
+	// evaluated is the number of requests the Fault has seen.
+	evaluated int64
+	// injected is the number of requests the Injector ran on.
+	injected int64
+	// skipped is the number of requests the Injector did not run on.
+	skipped int64
+	// active is the number of requests currently inside the Injector.
+	active int64
+
+	// skippedDisabled, skippedUnmatched, skippedParticipation, skippedCohort, skippedUnsafe,
+	// skippedConflict, skippedBlackout, skippedCooldown, skippedFairness, skippedSchedule,
+	// skippedRateLimit, skippedBudget, skippedOverride, skippedFreeze, skippedConcurrency,
+	// skippedVeto, and skippedClosed break skipped down by SkipReason.
+	skippedDisabled      int64
+	skippedUnmatched     int64
+	skippedParticipation int64
+	skippedCohort        int64
+	skippedUnsafe        int64
+	skippedConflict      int64
+	skippedBlackout      int64
+	skippedCooldown      int64
+	skippedFairness      int64
+	skippedSchedule      int64
+	skippedRateLimit     int64
+	skippedBudget        int64
+	skippedOverride      int64
+	skippedFreeze        int64
+	skippedConcurrency   int64
+	skippedVeto          int64
+	skippedClosed        int64
+
+	// injectors counts the requests each Injector ran on, keyed by InjectorString. Protected by
+	// injectorsMtx.
+	injectors    map[string]int64
+	injectorsMtx sync.Mutex
+}
+
+// inject counts a request the Injector named name ran on.
+func (s *faultStats) inject(name string) {
+	atomic.AddInt64(&s.injected, 1)
+	atomic.AddInt64(&s.active, 1)
+
+	s.injectorsMtx.Lock()
+	defer s.injectorsMtx.Unlock()
+
+	if s.injectors == nil {
+		s.injectors = make(map[string]int64)
+	}
+	s.injectors[name]++
+}
+
+// injectorCounts returns a copy of the number of requests each Injector ran on, or nil if none
+// has run.
+func (s *faultStats) injectorCounts() map[string]int64 {
+	s.injectorsMtx.Lock()
+	defer s.injectorsMtx.Unlock()
+
+	if len(s.injectors) == 0 {
+		return nil
+	}
+
+	counts := make(map[string]int64, len(s.injectors))
+	for name, n := range s.injectors {
+		counts[name] = n
+	}
+
+	return counts
+}
+
+// skip counts a request the Injector did not run on because of reason.
+func (s *faultStats) skip(reason SkipReason) {
+	atomic.AddInt64(&s.skipped, 1)
+
+	switch reason {
+	case SkipDisabled:
+		atomic.AddInt64(&s.skippedDisabled, 1)
+	case SkipUnmatched:
+		atomic.AddInt64(&s.skippedUnmatched, 1)
+	case SkipParticipation:
+		atomic.AddInt64(&s.skippedParticipation, 1)
+	case SkipCohort:
+		atomic.AddInt64(&s.skippedCohort, 1)
+	case SkipUnsafe:
+		atomic.AddInt64(&s.skippedUnsafe, 1)
+	case SkipConflict:
+		atomic.AddInt64(&s.skippedConflict, 1)
+	case SkipBlackout:
+		atomic.AddInt64(&s.skippedBlackout, 1)
+	case SkipCooldown:
+		atomic.AddInt64(&s.skippedCooldown, 1)
+	case SkipFairness:
+		atomic.AddInt64(&s.skippedFairness, 1)
+	case SkipSchedule:
+		atomic.AddInt64(&s.skippedSchedule, 1)
+	case SkipRateLimit:
+		atomic.AddInt64(&s.skippedRateLimit, 1)
+	case SkipBudget:
+		atomic.AddInt64(&s.skippedBudget, 1)
+	case SkipOverride:
+		atomic.AddInt64(&s.skippedOverride, 1)
+	case SkipFreeze:
+		atomic.AddInt64(&s.skippedFreeze, 1)
+	case SkipConcurrency:
+		atomic.AddInt64(&s.skippedConcurrency, 1)
+	case SkipVeto:
+		atomic.AddInt64(&s.skippedVeto, 1)
+	case SkipClosed:
+		atomic.AddInt64(&s.skippedClosed, 1)
+	}
+}
+
+// counters returns a copy of the current counts keyed by name. Skips are also counted by reason,
+// under "skipped_" followed by the SkipReason.
+func (s *faultStats) counters() map[string]int64 {
+	return map[string]int64{
+		"evaluated": atomic.LoadInt64(&s.evaluated),
+		"injected":  atomic.LoadInt64(&s.injected),
+		"skipped":   atomic.LoadInt64(&s.skipped),
+		"active":    atomic.LoadInt64(&s.active),
+
+		"skipped_" + string(SkipDisabled):      atomic.LoadInt64(&s.skippedDisabled),
+		"skipped_" + string(SkipUnmatched):     atomic.LoadInt64(&s.skippedUnmatched),
+		"skipped_" + string(SkipParticipation): atomic.LoadInt64(&s.skippedParticipation),
+		"skipped_" + string(SkipCohort):        atomic.LoadInt64(&s.skippedCohort),
+		"skipped_" + string(SkipUnsafe):        atomic.LoadInt64(&s.skippedUnsafe),
+		"skipped_" + string(SkipConflict):      atomic.LoadInt64(&s.skippedConflict),
+		"skipped_" + string(SkipBlackout):      atomic.LoadInt64(&s.skippedBlackout),
+		"skipped_" + string(SkipCooldown):      atomic.LoadInt64(&s.skippedCooldown),
+		"skipped_" + string(SkipFairness):      atomic.LoadInt64(&s.skippedFairness),
+		"skipped_" + string(SkipSchedule):      atomic.LoadInt64(&s.skippedSchedule),
+		"skipped_" + string(SkipRateLimit):     atomic.LoadInt64(&s.skippedRateLimit),
+		"skipped_" + string(SkipBudget):        atomic.LoadInt64(&s.skippedBudget),
+		"skipped_" + string(SkipOverride):      atomic.LoadInt64(&s.skippedOverride),
+		"skipped_" + string(SkipFreeze):        atomic.LoadInt64(&s.skippedFreeze),
+		"skipped_" + string(SkipConcurrency):   atomic.LoadInt64(&s.skippedConcurrency),
+		"skipped_" + string(SkipVeto):          atomic.LoadInt64(&s.skippedVeto),
+		"skipped_" + string(SkipClosed):        atomic.LoadInt64(&s.skippedClosed),
+	}
+}
@@ -0,0 +1,228 @@
+package fault
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestFaultStats tests that Fault.Handler counts its decisions.
+func TestFaultStats(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		giveOptions []Option
+		giveCount   int
+		want        map[string]int64
+	}{
+		{
+			name:        "disabled",
+			giveOptions: []Option{WithEnabled(false)},
+			giveCount:   3,
+			want: map[string]int64{
+				"evaluated":        3,
+				"skipped":          3,
+				"skipped_disabled": 3,
+			},
+		},
+		{
+			name: "enabled",
+			giveOptions: []Option{
+				WithEnabled(true),
+				WithParticipation(1.0),
+			},
+			giveCount: 3,
+			want: map[string]int64{
+				"evaluated": 3,
+				"injected":  3,
+			},
+		},
+		{
+			name: "unmatched",
+			giveOptions: []Option{
+				WithEnabled(true),
+				WithPathBlocklist([]string{"/"}),
+			},
+			giveCount: 2,
+			want: map[string]int64{
+				"evaluated":         2,
+				"skipped":           2,
+				"skipped_unmatched": 2,
+			},
+		},
+		{
+			name: "participation",
+			giveOptions: []Option{
+				WithEnabled(true),
+				WithParticipation(0.0),
+			},
+			giveCount: 1,
+			want: map[string]int64{
+				"evaluated":             1,
+				"skipped":               1,
+				"skipped_participation": 1,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			f, err := NewFault(newTestInjector500s(), tt.giveOptions...)
+			assert.NoError(t, err)
+
+			for i := 0; i < tt.giveCount; i++ {
+				testRequest(t, f)
+			}
+
+			assert.Equal(t, testCounters(tt.want), f.stats.counters())
+		})
+	}
+}
+
+// testCounters returns the counters of a faultStats with the counts in want and zero for the rest.
+func testCounters(want map[string]int64) map[string]int64 {
+	counters := (&faultStats{}).counters()
+	for k, v := range want {
+		counters[k] = v
+	}
+
+	return counters
+}
+
+// TestFaultStatsSkip tests that faultStats counts skips by SkipReason.
+func TestFaultStatsSkip(t *testing.T) {
+	t.Parallel()
+
+	s := &faultStats{}
+	for _, reason := range []SkipReason{
+		SkipDisabled, SkipUnmatched, SkipParticipation, SkipCohort, SkipUnsafe, SkipConflict, SkipBlackout,
+		SkipCooldown, SkipFairness, SkipSchedule, SkipRateLimit, SkipBudget, SkipOverride, SkipFreeze,
+		SkipConcurrency, SkipVeto, SkipClosed, "unknown",
+	} {
+		s.skip(reason)
+	}
+
+	assert.Equal(t, testCounters(map[string]int64{
+		"skipped":               18,
+		"skipped_disabled":      1,
+		"skipped_unmatched":     1,
+		"skipped_participation": 1,
+		"skipped_cohort":        1,
+		"skipped_unsafe":        1,
+		"skipped_conflict":      1,
+		"skipped_blackout":      1,
+		"skipped_cooldown":      1,
+		"skipped_fairness":      1,
+		"skipped_schedule":      1,
+		"skipped_rate_limit":    1,
+		"skipped_budget":        1,
+		"skipped_override":      1,
+		"skipped_freeze":        1,
+		"skipped_concurrency":   1,
+		"skipped_veto":          1,
+		"skipped_closed":        1,
+	}), s.counters())
+}
+
+// TestFaultStatsActive tests that requests inside the Injector are counted as active.
+func TestFaultStatsActive(t *testing.T) {
+	t.Parallel()
+
+	var active int64
+
+	i := newTestInjectorFunc(func(f *Fault) {
+		active = f.stats.counters()["active"]
+	})
+
+	f, err := NewFault(i,
+		WithEnabled(true),
+		WithParticipation(1.0),
+	)
+	assert.NoError(t, err)
+	i.fault = f
+
+	testRequest(t, f)
+
+	assert.Equal(t, int64(1), active)
+	assert.Equal(t, int64(0), f.stats.counters()["active"])
+}
+
+// TestFaultStatsSnapshot tests Fault.Stats.
+func TestFaultStatsSnapshot(t *testing.T) {
+	t.Parallel()
+
+	rolls := []float32{0.1, 0.9, 0.8, 0.2}
+	f, err := NewFault(newTestInjector500s(),
+		WithName("errors"),
+		WithEnabled(true),
+		WithParticipation(0.5),
+		WithPathBlocklist([]string{"/health"}),
+		WithRandFloat32Func(func() float32 {
+			rn := rolls[0]
+			rolls = rolls[1:]
+			return rn
+		}),
+	)
+	assert.NoError(t, err)
+
+	assert.Equal(t, FaultStats{Name: "errors", Participation: 0.5}, f.Stats())
+
+	h := f.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	for _, target := range []string{"/", "/", "/health", "/"} {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, target, nil))
+	}
+
+	ni := newTestInjectorNoop()
+	assert.NoError(t, f.SetInjector(ni))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, FaultStats{
+		Name:          "errors",
+		Participation: 0.5,
+		Evaluated:     5,
+		Injected:      2,
+		Skipped:       3,
+		SkippedBy:     map[SkipReason]int64{SkipParticipation: 2, SkipUnmatched: 1},
+		Injectors: map[string]int64{
+			InjectorString(newTestInjector500s()): 1,
+			InjectorString(ni):                    1,
+		},
+		ObservedParticipation: 0.5,
+	}, f.Stats())
+}
+
+// TestStatsHandler tests NewStatsHandler and Manager.StatsHandler.
+func TestStatsHandler(t *testing.T) {
+	t.Parallel()
+
+	f1, err := NewFault(newTestInjector500s(), WithName("one"), WithEnabled(true), WithParticipation(1.0))
+	assert.NoError(t, err)
+	f2, err := NewFault(newTestInjector500s(), WithName("two"))
+	assert.NoError(t, err)
+	testRequest(t, f1)
+
+	m, err := NewManager()
+	assert.NoError(t, err)
+	assert.NoError(t, m.Add(f1, f2))
+
+	for name, h := range map[string]http.Handler{
+		"NewStatsHandler":      NewStatsHandler(f1, f2),
+		"Manager.StatsHandler": m.StatsHandler(),
+	} {
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		assert.Equal(t, "application/json; charset=utf-8", rr.Header().Get("Content-Type"), name)
+
+		var got []FaultStats
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &got), name)
+		assert.Equal(t, []FaultStats{f1.Stats(), f2.Stats()}, got, name)
+	}
+}
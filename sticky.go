@@ -0,0 +1,52 @@
+package fault
+
+import (
+	"errors"
+	"net/http"
+	"time"
+)
+
+var (
+	// ErrInvalidStickyTTL when the TTL of sticky injection is not positive.
+	ErrInvalidStickyTTL = errors.New("sticky ttl must be > 0")
+)
+
+type stickyOption struct {
+	ttl  time.Duration
+	keyF func(r *http.Request) string
+}
+
+func (o stickyOption) applyFault(f *Fault) error {
+	if o.ttl <= 0 {
+		return ErrInvalidStickyTTL
+	}
+
+	keyF := o.keyF
+	if keyF == nil {
+		keyF = requestPath
+	}
+
+	// a sticky key is timed like the cooldown of a client, but forces the Injector instead of
+	// exempting from it
+	f.sticky = &cooldown{
+		period: o.ttl,
+		keyF:   keyF,
+	}
+
+	return nil
+}
+
+// WithStickyTTL runs the Injector on every request with the same key for ttl after it runs on one,
+// instead of rolling participation for each request. It mimics a partial outage, where an endpoint
+// that starts failing keeps failing for a while. key returns what a request belongs to, such as
+// its route; requests with an empty key are rolled as usual. A nil key uses the path of the
+// request. The TTL starts with the first injection and is not extended by the requests it forces.
+// Cooldowns and fairness still apply. The TTL is timed with the Clock set by WithClock().
+func WithStickyTTL(ttl time.Duration, key func(r *http.Request) string) Option {
+	return stickyOption{ttl: ttl, keyF: key}
+}
+
+// requestPath returns the path of r.
+func requestPath(r *http.Request) string {
+	return r.URL.Path
+}
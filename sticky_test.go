@@ -0,0 +1,143 @@
+package fault
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/github/go-fault/faulttest"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestWithStickyTTL tests the options of WithStickyTTL.
+func TestWithStickyTTL(t *testing.T) {
+	t.Parallel()
+
+	clock := faulttest.NewClock(time.Time{})
+
+	tests := []struct {
+		name        string
+		giveOptions []Option
+		wantTTL     time.Duration
+		wantKey     string
+		wantClock   Clock
+		wantErr     error
+	}{
+		{
+			name:        "path",
+			giveOptions: []Option{WithStickyTTL(time.Minute, nil)},
+			wantTTL:     time.Minute,
+			wantKey:     "/checkout",
+			wantClock:   NewRealClock(),
+		},
+		{
+			name: "key func and clock",
+			giveOptions: []Option{
+				WithStickyTTL(time.Hour, func(r *http.Request) string { return r.Method + " " + r.URL.Path }),
+				WithClock(clock),
+			},
+			wantTTL:   time.Hour,
+			wantKey:   "GET /checkout",
+			wantClock: clock,
+		},
+		{
+			name:        "zero ttl",
+			giveOptions: []Option{WithStickyTTL(0, nil)},
+			wantErr:     ErrInvalidStickyTTL,
+		},
+		{
+			name:        "negative ttl",
+			giveOptions: []Option{WithStickyTTL(-time.Minute, nil)},
+			wantErr:     ErrInvalidStickyTTL,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			f, err := NewFault(newTestInjectorNoop(), tt.giveOptions...)
+
+			assert.Equal(t, tt.wantErr, err)
+			if tt.wantErr != nil {
+				assert.Nil(t, f)
+				return
+			}
+
+			r := httptest.NewRequest(http.MethodGet, "/checkout", nil)
+
+			assert.Equal(t, tt.wantTTL, f.sticky.period)
+			assert.Equal(t, tt.wantKey, f.sticky.keyF(r))
+			assert.Equal(t, tt.wantClock, f.sticky.clock)
+		})
+	}
+}
+
+// TestFaultHandlerSticky tests that a Fault with a sticky TTL runs its Injector on every request
+// with the key of a faulted request until the TTL ends.
+func TestFaultHandlerSticky(t *testing.T) {
+	t.Parallel()
+
+	clock := faulttest.NewClock(time.Time{})
+	rolls := []float32{0.1}
+	reporter := newTestEvaluationReporter()
+	f, err := NewFault(newTestInjector500s(),
+		WithEnabled(true),
+		WithParticipation(0.5),
+		WithRandFloat32Func(func() float32 {
+			if len(rolls) == 0 {
+				return 0.9
+			}
+			rn := rolls[0]
+			rolls = rolls[1:]
+			return rn
+		}),
+		WithStickyTTL(time.Minute, func(r *http.Request) string { return r.Header.Get("X-Route") }),
+		WithClock(clock),
+		WithReporter(reporter),
+		WithTracing(true),
+	)
+	assert.NoError(t, err)
+
+	h := f.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(testHandlerCode)
+	}))
+	serve := func(route string) (int, bool) {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("X-Route", route)
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, r)
+		ev := <-reporter.evaluations
+		return rr.Code, ev.Sticky
+	}
+
+	// the first request to a is rolled in, and the rest are faulted without a roll
+	code, sticky := serve("a")
+	assert.Equal(t, http.StatusInternalServerError, code)
+	assert.False(t, sticky)
+
+	code, sticky = serve("a")
+	assert.Equal(t, http.StatusInternalServerError, code)
+	assert.True(t, sticky)
+
+	// other routes and requests without a route are rolled
+	code, _ = serve("b")
+	assert.Equal(t, testHandlerCode, code)
+	code, _ = serve("")
+	assert.Equal(t, testHandlerCode, code)
+
+	// forced requests do not extend the TTL
+	clock.Advance(59 * time.Second)
+	code, sticky = serve("a")
+	assert.Equal(t, http.StatusInternalServerError, code)
+	assert.True(t, sticky)
+
+	clock.Advance(time.Second)
+	code, sticky = serve("a")
+	assert.Equal(t, testHandlerCode, code)
+	assert.False(t, sticky)
+
+	assert.Equal(t, int64(3), f.stats.counters()["injected"])
+}
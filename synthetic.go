@@ -0,0 +1,153 @@
+package fault
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+)
+
+var (
+	// ErrNilTrafficGenerator when a nil TrafficGenerator is passed.
+	ErrNilTrafficGenerator = errors.New("traffic generator cannot be nil")
+	// ErrInvalidSyntheticLabel when a synthetic traffic label has an empty key.
+	ErrInvalidSyntheticLabel = errors.New("synthetic label keys cannot be empty")
+)
+
+// TrafficGenerator generates synthetic requests, such as replayed or scripted dark traffic. Plug one
+// into a Manager with Manager.StartSynthetic() to run experiments against synthetic load before
+// they are promoted to real traffic.
+type TrafficGenerator interface {
+	// Start starts sending requests to h and returns without waiting for them. h is safe for
+	// concurrent use.
+	Start(h http.Handler) error
+	// Stop stops sending requests and waits for the requests in flight, or until ctx is done.
+	Stop(ctx context.Context) error
+}
+
+type syntheticKey struct{}
+
+// SyntheticLabels returns the labels of the TrafficGenerator that generated r, and false if r is
+// not synthetic.
+func SyntheticLabels(r *http.Request) (map[string]string, bool) {
+	labels, ok := r.Context().Value(syntheticKey{}).(map[string]string)
+	if !ok {
+		return nil, false
+	}
+
+	out := make(map[string]string, len(labels))
+	for k, v := range labels {
+		out[k] = v
+	}
+
+	return out, true
+}
+
+// MatchSynthetic matches the requests generated by a TrafficGenerator started with
+// Manager.StartSynthetic(). The requests are marked in their context, so real traffic cannot match
+// by setting a header.
+func MatchSynthetic() RequestMatcher {
+	return RequestMatcherFunc(func(r *http.Request) bool {
+		_, ok := r.Context().Value(syntheticKey{}).(map[string]string)
+		return ok
+	})
+}
+
+// DarkLaunch is a RequestMatcher that matches only synthetic requests until it is promoted, and then
+// every request. Pass it WithRequestMatcher() to try a Fault on synthetic load first.
+type DarkLaunch struct {
+	promoted atomicBool
+}
+
+// MatchRequest returns true if the DarkLaunch is promoted or r is synthetic.
+func (d *DarkLaunch) MatchRequest(r *http.Request) bool {
+	return d.promoted.Load() || MatchSynthetic().MatchRequest(r)
+}
+
+// Promote lets the Fault run on real traffic.
+func (d *DarkLaunch) Promote() {
+	d.promoted.Store(true)
+}
+
+// Demote limits the Fault to synthetic traffic again.
+func (d *DarkLaunch) Demote() {
+	d.promoted.Store(false)
+}
+
+// Promoted returns true if the Fault may run on real traffic.
+func (d *DarkLaunch) Promoted() bool {
+	return d.promoted.Load()
+}
+
+type syntheticConfig struct {
+	labels map[string]string
+}
+
+// SyntheticOption configures Manager.StartSynthetic().
+type SyntheticOption interface {
+	applySynthetic(c *syntheticConfig) error
+}
+
+type syntheticLabelsOption map[string]string
+
+func (o syntheticLabelsOption) applySynthetic(c *syntheticConfig) error {
+	for k, v := range o {
+		if k == "" {
+			return ErrInvalidSyntheticLabel
+		}
+		c.labels[k] = v
+	}
+
+	return nil
+}
+
+// WithSyntheticLabels labels the requests of the TrafficGenerator, such as with the name of the
+// experiment. Read them with SyntheticLabels().
+func WithSyntheticLabels(labels map[string]string) SyntheticOption {
+	return syntheticLabelsOption(labels)
+}
+
+// StartSynthetic starts g against the managed Faults and then next. Requests from g are marked as
+// synthetic, so MatchSynthetic() and DarkLaunch match them. Call the returned function to stop g;
+// it returns the error of TrafficGenerator.Stop() and only stops g once.
+func (m *Manager) StartSynthetic(g TrafficGenerator, next http.Handler, opts ...SyntheticOption) (
+	stop func(ctx context.Context) error, err error,
+) {
+	if g == nil {
+		return nil, ErrNilTrafficGenerator
+	}
+
+	// set defaults
+	c := &syntheticConfig{
+		labels: make(map[string]string),
+	}
+
+	// apply options
+	for _, opt := range opts {
+		err := opt.applySynthetic(c)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	h := m.Handler(next)
+	err = g.Start(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), syntheticKey{}, c.labels)))
+	}))
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		once    sync.Once
+		stopErr error
+	)
+
+	return func(ctx context.Context) error {
+		once.Do(func() {
+			stopErr = g.Stop(ctx)
+		})
+
+		return stopErr
+	}, nil
+}
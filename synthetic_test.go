@@ -0,0 +1,235 @@
+package fault
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testTrafficGenerator records the handler it is started with and how often it is stopped.
+type testTrafficGenerator struct {
+	startErr error
+	stopErr  error
+
+	h     http.Handler
+	stops int
+}
+
+func (g *testTrafficGenerator) Start(h http.Handler) error {
+	if g.startErr != nil {
+		return g.startErr
+	}
+
+	g.h = h
+	return nil
+}
+
+func (g *testTrafficGenerator) Stop(ctx context.Context) error {
+	g.stops++
+	return g.stopErr
+}
+
+// TestSyntheticLabels tests SyntheticLabels and MatchSynthetic.
+func TestSyntheticLabels(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		giveLabels map[string]string
+		wantLabels map[string]string
+		wantOk     bool
+	}{
+		{
+			name: "real",
+		},
+		{
+			name:       "no labels",
+			giveLabels: map[string]string{},
+			wantLabels: map[string]string{},
+			wantOk:     true,
+		},
+		{
+			name:       "labels",
+			giveLabels: map[string]string{"experiment": "checkout"},
+			wantLabels: map[string]string{"experiment": "checkout"},
+			wantOk:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.giveLabels != nil {
+				req = req.WithContext(context.WithValue(req.Context(), syntheticKey{}, tt.giveLabels))
+			}
+
+			labels, ok := SyntheticLabels(req)
+			assert.Equal(t, tt.wantLabels, labels)
+			assert.Equal(t, tt.wantOk, ok)
+			assert.Equal(t, tt.wantOk, MatchSynthetic().MatchRequest(req))
+
+			if ok {
+				labels["experiment"] = "changed"
+				again, _ := SyntheticLabels(req)
+				assert.Equal(t, tt.wantLabels, again)
+			}
+		})
+	}
+}
+
+// TestDarkLaunch tests DarkLaunch.
+func TestDarkLaunch(t *testing.T) {
+	t.Parallel()
+
+	realReq := httptest.NewRequest(http.MethodGet, "/", nil)
+	synthetic := realReq.WithContext(context.WithValue(realReq.Context(), syntheticKey{}, map[string]string{}))
+
+	var d DarkLaunch
+	assert.False(t, d.Promoted())
+	assert.False(t, d.MatchRequest(realReq))
+	assert.True(t, d.MatchRequest(synthetic))
+
+	d.Promote()
+	assert.True(t, d.Promoted())
+	assert.True(t, d.MatchRequest(realReq))
+	assert.True(t, d.MatchRequest(synthetic))
+
+	d.Demote()
+	assert.False(t, d.Promoted())
+	assert.False(t, d.MatchRequest(realReq))
+	assert.True(t, d.MatchRequest(synthetic))
+}
+
+// TestManagerStartSynthetic tests Manager.StartSynthetic.
+func TestManagerStartSynthetic(t *testing.T) {
+	t.Parallel()
+
+	errStart := errors.New("start")
+	errStop := errors.New("stop")
+
+	tests := []struct {
+		name          string
+		giveGenerator *testTrafficGenerator
+		giveOptions   []SyntheticOption
+		wantLabels    map[string]string
+		wantErr       error
+		wantStopErr   error
+	}{
+		{
+			name:          "defaults",
+			giveGenerator: &testTrafficGenerator{},
+			wantLabels:    map[string]string{},
+		},
+		{
+			name:          "labels",
+			giveGenerator: &testTrafficGenerator{},
+			giveOptions: []SyntheticOption{
+				WithSyntheticLabels(map[string]string{"experiment": "checkout"}),
+				WithSyntheticLabels(map[string]string{"source": "replay"}),
+			},
+			wantLabels: map[string]string{"experiment": "checkout", "source": "replay"},
+		},
+		{
+			name:          "stop error",
+			giveGenerator: &testTrafficGenerator{stopErr: errStop},
+			wantLabels:    map[string]string{},
+			wantStopErr:   errStop,
+		},
+		{
+			name:    "nil generator",
+			wantErr: ErrNilTrafficGenerator,
+		},
+		{
+			name:          "empty label key",
+			giveGenerator: &testTrafficGenerator{},
+			giveOptions: []SyntheticOption{
+				WithSyntheticLabels(map[string]string{"": "checkout"}),
+			},
+			wantErr: ErrInvalidSyntheticLabel,
+		},
+		{
+			name:          "option error",
+			giveGenerator: &testTrafficGenerator{},
+			giveOptions: []SyntheticOption{
+				withError(),
+			},
+			wantErr: errErrorOption,
+		},
+		{
+			name:          "start error",
+			giveGenerator: &testTrafficGenerator{startErr: errStart},
+			wantErr:       errStart,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var d DarkLaunch
+			f, err := NewFault(newTestInjector500s(),
+				WithEnabled(true),
+				WithParticipation(1.0),
+				WithRequestMatcher(&d),
+			)
+			assert.NoError(t, err)
+
+			m, err := NewManager()
+			assert.NoError(t, err)
+			assert.NoError(t, m.Add(f))
+
+			var gotLabels map[string]string
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotLabels, _ = SyntheticLabels(r)
+				w.WriteHeader(testHandlerCode)
+			})
+
+			var g TrafficGenerator
+			if tt.giveGenerator != nil {
+				g = tt.giveGenerator
+			}
+
+			stop, err := m.StartSynthetic(g, next, tt.giveOptions...)
+			assert.Equal(t, tt.wantErr, err)
+			if tt.wantErr != nil {
+				assert.Nil(t, stop)
+				return
+			}
+
+			// synthetic requests run the Fault
+			rr := httptest.NewRecorder()
+			tt.giveGenerator.h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+			assert.Equal(t, http.StatusInternalServerError, rr.Code)
+
+			// real requests do not until the Fault is promoted
+			rr = httptest.NewRecorder()
+			m.Handler(next).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+			assert.Equal(t, testHandlerCode, rr.Code)
+			assert.Nil(t, gotLabels)
+
+			d.Promote()
+			rr = httptest.NewRecorder()
+			m.Handler(next).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+			assert.Equal(t, http.StatusInternalServerError, rr.Code)
+
+			// next sees the labels of synthetic requests
+			f.SetEnabled(false)
+			rr = httptest.NewRecorder()
+			tt.giveGenerator.h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+			assert.Equal(t, testHandlerCode, rr.Code)
+			assert.Equal(t, tt.wantLabels, gotLabels)
+
+			assert.Equal(t, tt.wantStopErr, stop(context.Background()))
+			assert.Equal(t, tt.wantStopErr, stop(context.Background()))
+			assert.Equal(t, 1, tt.giveGenerator.stops)
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package fault
+
+import (
+	"os"
+	"os/signal"
+)
+
+// DisableAll disables every managed Fault at once with DisableNow and saves which were enabled so
+// RestoreEnabled can re-enable them. Use it as an emergency stop. Calling DisableAll again before
+// RestoreEnabled disables Faults enabled since, but keeps the state saved by the first call.
+func (m *Manager) DisableAll() {
+	m.toggleMtx.Lock()
+	defer m.toggleMtx.Unlock()
+
+	faults := m.Faults()
+
+	if m.saved == nil {
+		m.saved = make(map[*Fault]bool, len(faults))
+		for _, f := range faults {
+			m.saved[f] = f.enabled.Load()
+		}
+	}
+
+	for _, f := range faults {
+		f.DisableNow()
+	}
+}
+
+// RestoreEnabled re-enables the Faults that were enabled when DisableAll was first called and
+// returns true, or returns false if there is no state to restore. Faults that are no longer managed
+// are left alone.
+func (m *Manager) RestoreEnabled() bool {
+	m.toggleMtx.Lock()
+	defer m.toggleMtx.Unlock()
+
+	if m.saved == nil {
+		return false
+	}
+
+	for _, f := range m.Faults() {
+		if m.saved[f] {
+			f.SetEnabled(true)
+		}
+	}
+	m.saved = nil
+
+	return true
+}
+
+// NotifySignals calls DisableAll when the process receives the disable signal and RestoreEnabled
+// when it receives the restore signal, giving operators an emergency stop that works even when
+// the service is too overwhelmed to reach over the network. Call the returned function to stop
+// handling the signals.
+func (m *Manager) NotifySignals(disable, restore os.Signal) (stop func()) {
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, disable, restore)
+
+	done := make(chan struct{})
+	exited := make(chan struct{})
+	go func() {
+		defer close(exited)
+
+		for {
+			select {
+			case <-done:
+				return
+			case sig := <-sigs:
+				if sig == disable {
+					m.DisableAll()
+				} else {
+					m.RestoreEnabled()
+				}
+			}
+		}
+	}()
+
+	return func() {
+		signal.Stop(sigs)
+		close(done)
+		<-exited
+	}
+}
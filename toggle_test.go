@@ -0,0 +1,51 @@
+package fault
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestManagerDisableAll tests Manager.DisableAll and Manager.RestoreEnabled.
+func TestManagerDisableAll(t *testing.T) {
+	t.Parallel()
+
+	fault := func(name string, enabled bool) *Fault {
+		f, err := NewFault(newTestInjectorNoop(), WithName(name), WithEnabled(enabled))
+		assert.NoError(t, err)
+		return f
+	}
+	enabled := func(faults ...*Fault) []bool {
+		var es []bool
+		for _, f := range faults {
+			es = append(es, f.enabled.Load())
+		}
+		return es
+	}
+
+	a, b, c := fault("a", true), fault("b", false), fault("c", true)
+
+	m, err := NewManager()
+	assert.NoError(t, err)
+	assert.NoError(t, m.Add(a, b, c))
+
+	// nothing to restore
+	assert.False(t, m.RestoreEnabled())
+	assert.Equal(t, []bool{true, false, true}, enabled(a, b, c))
+
+	m.DisableAll()
+	assert.Equal(t, []bool{false, false, false}, enabled(a, b, c))
+
+	// a second emergency stop keeps the first saved state
+	b.SetEnabled(true)
+	m.DisableAll()
+	assert.Equal(t, []bool{false, false, false}, enabled(a, b, c))
+
+	// Faults that are no longer managed are left alone
+	assert.True(t, m.Remove("c"))
+
+	assert.True(t, m.RestoreEnabled())
+	assert.Equal(t, []bool{true, false, false}, enabled(a, b, c))
+
+	assert.False(t, m.RestoreEnabled())
+}
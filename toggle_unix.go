@@ -0,0 +1,14 @@
+//go:build !windows && !plan9
+// +build !windows,!plan9
+
+package fault
+
+import "syscall"
+
+// NotifyUserSignals calls NotifySignals with SIGUSR1 to disable every Fault and SIGUSR2 to
+// restore the Faults that were enabled:
+//
+//	kill -USR1 <pid>
+func (m *Manager) NotifyUserSignals() (stop func()) {
+	return m.NotifySignals(syscall.SIGUSR1, syscall.SIGUSR2)
+}
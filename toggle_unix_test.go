@@ -0,0 +1,37 @@
+//go:build !windows && !plan9
+// +build !windows,!plan9
+
+package fault
+
+import (
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestManagerNotifyUserSignals tests that SIGUSR1 disables and SIGUSR2 restores the Faults.
+func TestManagerNotifyUserSignals(t *testing.T) {
+	t.Parallel()
+
+	f, err := NewFault(newTestInjectorNoop(), WithEnabled(true))
+	assert.NoError(t, err)
+
+	m, err := NewManager()
+	assert.NoError(t, err)
+	assert.NoError(t, m.Add(f))
+
+	stop := m.NotifyUserSignals()
+	defer stop()
+
+	p, err := os.FindProcess(os.Getpid())
+	assert.NoError(t, err)
+
+	assert.NoError(t, p.Signal(syscall.SIGUSR1))
+	assert.Eventually(t, func() bool { return !f.enabled.Load() }, time.Second, time.Millisecond)
+
+	assert.NoError(t, p.Signal(syscall.SIGUSR2))
+	assert.Eventually(t, func() bool { return f.enabled.Load() }, time.Second, time.Millisecond)
+}
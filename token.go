@@ -0,0 +1,324 @@
+package fault
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// AdminOperation is an operation of the Runtime AdminHandler that an AdminToken may allow.
+type AdminOperation string
+
+const (
+	// AdminRead allows reading the runtime values.
+	AdminRead AdminOperation = "read"
+	// AdminWrite allows setting runtime values.
+	AdminWrite AdminOperation = "write"
+	// AdminApprove allows approving the PendingChanges of other operators.
+	AdminApprove AdminOperation = "approve"
+)
+
+var (
+	// ErrUnauthorized when an admin request has no valid AdminToken.
+	ErrUnauthorized = errors.New("admin token is missing or invalid")
+	// ErrOutOfScope when an AdminToken does not allow an admin request.
+	ErrOutOfScope = errors.New("admin token does not allow this change")
+	// ErrUnknownAdminOperation when an AdminOperation is not one of the package's.
+	ErrUnknownAdminOperation = errors.New("unknown admin operation")
+)
+
+// AdminToken is a bearer token for the Runtime AdminHandler, scoped to some operations, some
+// Faults, and a range of participation, so that control over a Fault can be delegated safely.
+type AdminToken struct {
+	secret string
+	name   string
+	faults map[string]bool
+	ops    map[AdminOperation]bool
+	min    float32
+	max    float32
+}
+
+// AdminTokenOption configures an AdminToken.
+type AdminTokenOption interface {
+	applyAdminToken(t *AdminToken) error
+}
+
+type tokenNameOption string
+
+func (o tokenNameOption) applyAdminToken(t *AdminToken) error {
+	t.name = string(o)
+	return nil
+}
+
+// WithTokenName names the holder of the AdminToken. A named token is the operator of the changes
+// made with it for two-person approval, instead of the OperatorHeader or WithOperatorFunc.
+func WithTokenName(n string) AdminTokenOption {
+	return tokenNameOption(n)
+}
+
+type tokenFaultsOption []string
+
+func (o tokenFaultsOption) applyAdminToken(t *AdminToken) error {
+	t.faults = make(map[string]bool, len(o))
+	for _, name := range o {
+		t.faults[name] = true
+	}
+
+	return nil
+}
+
+// WithTokenFaults limits the AdminToken to setting the keys that are bound only to the Faults
+// named names. Default any key.
+func WithTokenFaults(names ...string) AdminTokenOption {
+	return tokenFaultsOption(names)
+}
+
+type tokenOperationsOption []AdminOperation
+
+func (o tokenOperationsOption) applyAdminToken(t *AdminToken) error {
+	t.ops = make(map[AdminOperation]bool, len(o))
+	for _, op := range o {
+		switch op {
+		case AdminRead, AdminWrite, AdminApprove:
+		default:
+			return ErrUnknownAdminOperation
+		}
+
+		t.ops[op] = true
+	}
+
+	return nil
+}
+
+// WithTokenOperations limits the AdminToken to ops. Default every AdminOperation.
+func WithTokenOperations(ops ...AdminOperation) AdminTokenOption {
+	return tokenOperationsOption(ops)
+}
+
+type tokenRangeOption struct {
+	min float32
+	max float32
+}
+
+func (o tokenRangeOption) applyAdminToken(t *AdminToken) error {
+	if o.min < 0.0 || o.max > 1.0 || o.min > o.max {
+		return ErrInvalidPercent
+	}
+
+	t.min = o.min
+	t.max = o.max
+
+	return nil
+}
+
+// WithTokenRange limits the AdminToken to changes that leave the participation of every bound
+// Fault they affect between min and max. 0.0 <= min <= max <= 1.0. Default 0.0 to 1.0.
+func WithTokenRange(min, max float32) AdminTokenOption {
+	return tokenRangeOption{min: min, max: max}
+}
+
+// IssueToken returns a new AdminToken with a random secret. Once a token is issued, every request
+// to the AdminHandler must send the secret of a token that allows it, as
+// "Authorization: Bearer <secret>".
+func (r *Runtime) IssueToken(opts ...AdminTokenOption) (*AdminToken, error) {
+	b := make([]byte, 32)
+	// crypto/rand does not fail on supported platforms
+	_, _ = rand.Read(b)
+
+	// set defaults
+	t := &AdminToken{
+		secret: hex.EncodeToString(b),
+		ops:    map[AdminOperation]bool{AdminRead: true, AdminWrite: true, AdminApprove: true},
+		max:    1.0,
+	}
+
+	// apply options
+	for _, opt := range opts {
+		err := opt.applyAdminToken(t)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	if r.tokens == nil {
+		r.tokens = map[string]*AdminToken{}
+	}
+	r.tokens[hashSecret(t.secret)] = t
+
+	return t, nil
+}
+
+// RevokeToken stops t from being accepted by the AdminHandler. The AdminHandler still requires a
+// token once every token is revoked.
+func (r *Runtime) RevokeToken(t *AdminToken) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	delete(r.tokens, hashSecret(t.secret))
+}
+
+// Secret returns the secret to send as the bearer token.
+func (t *AdminToken) Secret() string {
+	return t.secret
+}
+
+// Name returns the name of the holder of the AdminToken.
+func (t *AdminToken) Name() string {
+	return t.name
+}
+
+// Allows returns true if the AdminToken allows op.
+func (t *AdminToken) Allows(op AdminOperation) bool {
+	return t.ops[op]
+}
+
+// hashSecret returns the key a secret is stored under, so that looking it up does not leak it
+// through timing.
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// authorize returns the AdminToken of req, or nil if no token was ever issued.
+func (r *Runtime) authorize(req *http.Request) (*AdminToken, error) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	if r.tokens == nil {
+		return nil, nil
+	}
+
+	secret := strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")
+	t, ok := r.tokens[hashSecret(secret)]
+	if !ok {
+		return nil, ErrUnauthorized
+	}
+
+	return t, nil
+}
+
+// permit returns ErrOutOfScope unless t allows op. A nil t allows everything.
+func permit(t *AdminToken, op AdminOperation) error {
+	if t != nil && !t.Allows(op) {
+		return ErrOutOfScope
+	}
+
+	return nil
+}
+
+// permitWrite returns ErrOutOfScope unless t allows setting values.
+func (r *Runtime) permitWrite(t *AdminToken, values map[string]string) error {
+	err := permit(t, AdminWrite)
+	if err != nil {
+		return err
+	}
+
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	if !r.inScope(t, values) {
+		return ErrOutOfScope
+	}
+
+	return nil
+}
+
+// AuthorizeAdmin makes r an AdminAuthorizer for the AdminHandler of a Manager, with the same rules
+// as its own AdminHandler, except that it returns ErrUnauthorized until an AdminToken is issued
+// instead of allowing everything. Every request must send a token that allows it: reads need
+// AdminRead, and changes need AdminWrite, a Fault allowed by WithTokenFaults, and a participation
+// within WithTokenRange unless they disable the Fault. With two-person approval, a change that
+// raises the participation of a Fault with a destructive Injector, or raises any Fault above the
+// approval threshold, returns ErrApprovalRequired; bind the Fault and make the change through the
+// AdminHandler of r instead.
+func (r *Runtime) AuthorizeAdmin(req *http.Request, c *FaultChange) error {
+	t, err := r.authorize(req)
+	if err != nil {
+		return err
+	}
+	if t == nil {
+		return ErrUnauthorized
+	}
+
+	if c == nil {
+		return permit(t, AdminRead)
+	}
+
+	err = permit(t, AdminWrite)
+	if err != nil {
+		return err
+	}
+
+	if t.faults != nil && !t.faults[c.Fault.Name()] {
+		return ErrOutOfScope
+	}
+
+	p := c.participation()
+	if !disablesOnly(*c) && (p < t.min-participationEpsilon || p > t.max+participationEpsilon) {
+		return ErrOutOfScope
+	}
+
+	current := float32(0.0)
+	if c.Fault.Enabled() {
+		current = c.Fault.Participation()
+	}
+
+	if r.approval != nil && p > current && (IsDestructive(c.Fault.Injector()) || p > r.approval.threshold) {
+		return ErrApprovalRequired
+	}
+
+	return nil
+}
+
+// inScope returns true if t allows setting values. A nil t allows everything. The caller must hold
+// r.mtx.
+func (r *Runtime) inScope(t *AdminToken, values map[string]string) bool {
+	if t == nil {
+		return true
+	}
+
+	admin := r.adminWith(values)
+	for key := range values {
+		bound := false
+		for _, b := range r.bindings {
+			if b.key != key {
+				continue
+			}
+			bound = true
+
+			if t.faults != nil && !t.faults[b.fault.Name()] {
+				return false
+			}
+
+			p := fractionIn(admin, r.disk, key, b.def).Float32()
+			if p < t.min-participationEpsilon || p > t.max+participationEpsilon {
+				return false
+			}
+		}
+
+		if !bound && t.faults != nil {
+			return false
+		}
+	}
+
+	return true
+}
+
+// adminWith returns a copy of the admin layer with values set. The caller must hold r.mtx.
+func (r *Runtime) adminWith(values map[string]string) map[string]string {
+	admin := make(map[string]string, len(r.admin)+len(values))
+	for k, v := range r.admin {
+		admin[k] = v
+	}
+	for k, v := range values {
+		setRuntimeValue(admin, k, v, true)
+	}
+
+	return admin
+}
@@ -0,0 +1,260 @@
+package fault
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestRuntimeIssueToken tests Runtime.IssueToken.
+func TestRuntimeIssueToken(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		giveOptions []AdminTokenOption
+		wantName    string
+		wantOps     []AdminOperation
+		wantErr     error
+	}{
+		{
+			name:    "defaults",
+			wantOps: []AdminOperation{AdminRead, AdminWrite, AdminApprove},
+		},
+		{
+			name: "options",
+			giveOptions: []AdminTokenOption{
+				WithTokenName("search-team"),
+				WithTokenOperations(AdminRead, AdminWrite),
+				WithTokenFaults("search-latency"),
+				WithTokenRange(0.0, 0.1),
+			},
+			wantName: "search-team",
+			wantOps:  []AdminOperation{AdminRead, AdminWrite},
+		},
+		{
+			name:        "unknown operation",
+			giveOptions: []AdminTokenOption{WithTokenOperations("delete")},
+			wantErr:     ErrUnknownAdminOperation,
+		},
+		{
+			name:        "invalid range",
+			giveOptions: []AdminTokenOption{WithTokenRange(0.5, 0.1)},
+			wantErr:     ErrInvalidPercent,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r, err := NewRuntime()
+			assert.NoError(t, err)
+
+			tok, err := r.IssueToken(tt.giveOptions...)
+
+			assert.Equal(t, tt.wantErr, err)
+			if tt.wantErr != nil {
+				assert.Nil(t, tok)
+				assert.Nil(t, r.tokens)
+				return
+			}
+
+			assert.Len(t, tok.Secret(), 64)
+			assert.Equal(t, tt.wantName, tok.Name())
+			for _, op := range []AdminOperation{AdminRead, AdminWrite, AdminApprove} {
+				assert.Equal(t, contains(tt.wantOps, op), tok.Allows(op), op)
+			}
+		})
+	}
+}
+
+// contains returns true if ops has op.
+func contains(ops []AdminOperation, op AdminOperation) bool {
+	for _, o := range ops {
+		if o == op {
+			return true
+		}
+	}
+
+	return false
+}
+
+// TestRuntimeAdminTokens tests that the AdminHandler only allows what the AdminToken of a request
+// allows.
+func TestRuntimeAdminTokens(t *testing.T) {
+	t.Parallel()
+
+	const searchKey = "search.latency_percent"
+
+	r, err := NewRuntime()
+	assert.NoError(t, err)
+
+	search, err := NewFault(newTestInjector500s(), WithName("search-latency"))
+	assert.NoError(t, err)
+	checkout, err := NewFault(newTestInjector500s(), WithName("checkout-errors"))
+	assert.NoError(t, err)
+	assert.NoError(t, r.Bind(search, searchKey, FractionalPercent{Denominator: PerHundred}))
+	assert.NoError(t, r.Bind(checkout, RuntimeAbortPercent, FractionalPercent{Denominator: PerHundred}))
+
+	admin, err := r.IssueToken()
+	assert.NoError(t, err)
+	team, err := r.IssueToken(WithTokenFaults("search-latency"), WithTokenRange(0.0, 0.1))
+	assert.NoError(t, err)
+	reader, err := r.IssueToken(WithTokenOperations(AdminRead))
+	assert.NoError(t, err)
+	writer, err := r.IssueToken(WithTokenOperations(AdminWrite))
+	assert.NoError(t, err)
+	revoked, err := r.IssueToken()
+	assert.NoError(t, err)
+	r.RevokeToken(revoked)
+
+	tests := []struct {
+		name       string
+		giveToken  *AdminToken
+		giveMethod string
+		giveValues url.Values
+		wantCode   int
+	}{
+		{
+			name:       "no token",
+			giveMethod: http.MethodGet,
+			wantCode:   http.StatusUnauthorized,
+		},
+		{
+			name:       "revoked token",
+			giveToken:  revoked,
+			giveMethod: http.MethodGet,
+			wantCode:   http.StatusUnauthorized,
+		},
+		{
+			name:       "reader reads",
+			giveToken:  reader,
+			giveMethod: http.MethodGet,
+			wantCode:   http.StatusOK,
+		},
+		{
+			name:       "reader writes",
+			giveToken:  reader,
+			giveMethod: http.MethodPost,
+			giveValues: url.Values{searchKey: {"5"}},
+			wantCode:   http.StatusForbidden,
+		},
+		{
+			name:       "writer reads",
+			giveToken:  writer,
+			giveMethod: http.MethodGet,
+			wantCode:   http.StatusForbidden,
+		},
+		{
+			name:       "team within range",
+			giveToken:  team,
+			giveMethod: http.MethodPost,
+			giveValues: url.Values{searchKey: {"10"}},
+			wantCode:   http.StatusOK,
+		},
+		{
+			name:       "team above range",
+			giveToken:  team,
+			giveMethod: http.MethodPost,
+			giveValues: url.Values{searchKey: {"11"}},
+			wantCode:   http.StatusForbidden,
+		},
+		{
+			name:       "team other fault",
+			giveToken:  team,
+			giveMethod: http.MethodPost,
+			giveValues: url.Values{RuntimeAbortPercent: {"1"}},
+			wantCode:   http.StatusForbidden,
+		},
+		{
+			name:       "team unbound key",
+			giveToken:  team,
+			giveMethod: http.MethodPost,
+			giveValues: url.Values{"other": {"1"}},
+			wantCode:   http.StatusForbidden,
+		},
+		{
+			name:       "admin anything",
+			giveToken:  admin,
+			giveMethod: http.MethodPost,
+			giveValues: url.Values{RuntimeAbortPercent: {"100"}, "other": {"1"}},
+			wantCode:   http.StatusOK,
+		},
+	}
+
+	// the cases share r, so they run in order
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.giveMethod, "/runtime?"+tt.giveValues.Encode(), nil)
+			if tt.giveToken != nil {
+				req.Header.Set("Authorization", "Bearer "+tt.giveToken.Secret())
+			}
+			rr := httptest.NewRecorder()
+			r.AdminHandler().ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.wantCode, rr.Code, rr.Body.String())
+			if tt.wantCode == http.StatusUnauthorized {
+				assert.Equal(t, "Bearer", rr.Header().Get("WWW-Authenticate"))
+			}
+		})
+	}
+
+	assert.InDelta(t, 0.1, search.participation.Load(), 1e-6)
+	assert.InDelta(t, 1.0, checkout.participation.Load(), 1e-6)
+}
+
+// TestRuntimeAdminTokensApproval tests AdminTokens with two-person approval.
+func TestRuntimeAdminTokensApproval(t *testing.T) {
+	t.Parallel()
+
+	r, err := NewRuntime(WithApprovalThreshold(0.05))
+	assert.NoError(t, err)
+
+	f, err := NewFault(newTestInjector500s(), WithName("search-latency"))
+	assert.NoError(t, err)
+	assert.NoError(t, r.Bind(f, RuntimeDelayPercent, FractionalPercent{Denominator: PerHundred}))
+
+	alice, err := r.IssueToken(WithTokenName("alice"))
+	assert.NoError(t, err)
+	bob, err := r.IssueToken(WithTokenName("bob"), WithTokenRange(0.0, 0.1))
+	assert.NoError(t, err)
+	carol, err := r.IssueToken(WithTokenName("carol"), WithTokenOperations(AdminRead, AdminWrite))
+	assert.NoError(t, err)
+	dave, err := r.IssueToken(WithTokenName("dave"))
+	assert.NoError(t, err)
+
+	post := func(tok *AdminToken, approve string, values url.Values) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/runtime?"+values.Encode(), nil)
+		req.Header.Set("Authorization", "Bearer "+tok.Secret())
+		// the token names the operator, not the header
+		req.Header.Set(OperatorHeader, "mallory")
+		if approve != "" {
+			req.Header.Set(ApprovalHeader, approve)
+		}
+		rr := httptest.NewRecorder()
+		r.AdminHandler().ServeHTTP(rr, req)
+
+		return rr
+	}
+
+	rr := post(alice, "", url.Values{RuntimeDelayPercent: {"50"}})
+	assert.Equal(t, http.StatusAccepted, rr.Code)
+	pending := r.Pending()
+	assert.Len(t, pending, 1)
+	assert.Equal(t, "alice", pending[0].Operator)
+	token := pending[0].Token
+
+	// carol may not approve, and bob may not approve beyond his range
+	assert.Equal(t, http.StatusForbidden, post(carol, token, nil).Code)
+	assert.Equal(t, http.StatusForbidden, post(bob, token, nil).Code)
+	assert.Len(t, r.Pending(), 1)
+
+	assert.Equal(t, http.StatusOK, post(dave, token, nil).Code)
+	assert.InDelta(t, 0.5, f.participation.Load(), 1e-6)
+}
@@ -0,0 +1,204 @@
+package fault
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrInvalidRampDown when a ramp-down duration is not positive.
+	ErrInvalidRampDown = errors.New("ramp down must be > 0")
+	// ErrInvalidDwell when a minimum dwell time is not positive.
+	ErrInvalidDwell = errors.New("dwell must be > 0")
+)
+
+// transitions smooths the changes of a Fault between enabled and disabled, so toggling it does not
+// send a thundering herd of recovered requests to the systems behind it.
+type transitions struct {
+	rampDown time.Duration
+	dwell    time.Duration
+	clock    Clock
+
+	mtx sync.Mutex
+	// changed is when the Fault last changed state, if it has.
+	changed    time.Time
+	hasChanged bool
+	// rampStart is when the current ramp-down started, if ramping.
+	rampStart time.Time
+	ramping   bool
+	// gen is increased by every request to change state, so the waits of earlier requests do
+	// nothing when they end.
+	gen uint64
+}
+
+type rampDownOption time.Duration
+
+func (o rampDownOption) applyFault(f *Fault) error {
+	if o <= 0 {
+		return ErrInvalidRampDown
+	}
+
+	f.transitionsOrNew().rampDown = time.Duration(o)
+	return nil
+}
+
+// WithRampDown makes SetEnabled(false) disable the Fault gracefully: its participation decays
+// linearly from its current value to zero over d, after which the Fault is disabled and the
+// OnDisable hook of its Injector runs. The Fault stays enabled during the ramp-down, and enabling it
+// again cancels the ramp-down. Use DisableNow to disable it at once. The ramp-down is timed with the
+// Clock set by WithClock().
+func WithRampDown(d time.Duration) Option {
+	return rampDownOption(d)
+}
+
+type minDwellOption time.Duration
+
+func (o minDwellOption) applyFault(f *Fault) error {
+	if o <= 0 {
+		return ErrInvalidDwell
+	}
+
+	f.transitionsOrNew().dwell = time.Duration(o)
+	return nil
+}
+
+// WithMinDwell keeps the Fault enabled or disabled for at least d after it changes state. A call to
+// SetEnabled that would change the state sooner takes effect once d has passed, and a later call
+// before then replaces it. DisableNow is never delayed. The dwell time is timed with the Clock set by
+// WithClock().
+func WithMinDwell(d time.Duration) Option {
+	return minDwellOption(d)
+}
+
+// transitionsOrNew returns the transitions of the Fault, adding them if it has none.
+func (f *Fault) transitionsOrNew() *transitions {
+	if f.transitions == nil {
+		f.transitions = &transitions{}
+	}
+
+	return f.transitions
+}
+
+// set requests the Fault to become enabled or disabled, subject to the minimum dwell time and the
+// ramp-down.
+func (t *transitions) set(f *Fault, e bool) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	// the Fault is already on its way to disabled
+	if !e && t.ramping {
+		return
+	}
+
+	t.gen++
+	t.change(f, e, t.gen)
+}
+
+// change changes the state of the Fault to e for the request gen, now or once the dwell time has
+// passed. t.mtx must be held.
+func (t *transitions) change(f *Fault, e bool, gen uint64) {
+	switch {
+	case e && t.ramping:
+		// the Fault never stopped being enabled
+		t.ramping = false
+		return
+	case e == f.enabled.Load():
+		return
+	}
+
+	now := t.clock.Now()
+	if wait := t.changed.Add(t.dwell).Sub(now); t.hasChanged && wait > 0 {
+		t.after(wait, func() { t.change(f, e, gen) }, gen)
+		return
+	}
+
+	if !e && t.rampDown > 0 {
+		t.ramping = true
+		t.rampStart = now
+		t.after(t.rampDown, func() {
+			t.ramping = false
+			t.apply(f, false)
+		}, gen)
+		return
+	}
+
+	t.apply(f, e)
+}
+
+// after runs fn with t.mtx held once d has passed, unless another request to change state was made
+// since the request gen.
+func (t *transitions) after(d time.Duration, fn func(), gen uint64) {
+	ch := t.clock.After(d)
+
+	go func() {
+		<-ch
+
+		t.mtx.Lock()
+		defer t.mtx.Unlock()
+
+		if gen == t.gen {
+			fn()
+		}
+	}()
+}
+
+// apply changes the state of the Fault to e at once. t.mtx must be held.
+func (t *transitions) apply(f *Fault, e bool) {
+	if f.setEnabled(e) {
+		t.changed = t.clock.Now()
+		t.hasChanged = true
+	}
+}
+
+// stop disables the Fault at once, cancelling any ramp-down and delayed change.
+func (t *transitions) stop(f *Fault) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	t.gen++
+	t.ramping = false
+	t.apply(f, false)
+}
+
+// scale returns how much of its participation the Fault has left during a ramp-down, from 1.0 when
+// it starts to 0.0 when it ends, or 1.0 if it is not ramping down.
+func (t *transitions) scale() float32 {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	if !t.ramping {
+		return 1
+	}
+
+	left := 1 - float32(t.clock.Now().Sub(t.rampStart))/float32(t.rampDown)
+	if left < 0 {
+		return 0
+	}
+
+	return left
+}
+
+// DisableNow disables the Fault at once, cancelling any ramp-down set by WithRampDown and ignoring
+// the minimum dwell time set by WithMinDwell. Emergency stops, such as Manager.DisableAll, a Watchdog
+// trip, or an ErrorGuard pause, use it.
+func (f *Fault) DisableNow() {
+	if f.transitions == nil {
+		f.setEnabled(false)
+		return
+	}
+
+	f.transitions.stop(f)
+}
+
+// RampingDown returns true while the Fault is ramping down after SetEnabled(false).
+func (f *Fault) RampingDown() bool {
+	if f.transitions == nil {
+		return false
+	}
+
+	f.transitions.mtx.Lock()
+	defer f.transitions.mtx.Unlock()
+
+	return f.transitions.ramping
+}
@@ -0,0 +1,235 @@
+package fault
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/github/go-fault/faulttest"
+	"github.com/stretchr/testify/assert"
+)
+
+// testHookInjector is an Injector that counts its OnEnable and OnDisable hooks.
+type testHookInjector struct {
+	testInjector500s
+
+	enables  chan struct{}
+	disables chan struct{}
+}
+
+// OnEnable counts an enable.
+func (i *testHookInjector) OnEnable() {
+	i.enables <- struct{}{}
+}
+
+// OnDisable counts a disable.
+func (i *testHookInjector) OnDisable() {
+	i.disables <- struct{}{}
+}
+
+// newTestTransitionFault returns an enabled Fault that injects every request, with opts.
+func newTestTransitionFault(t *testing.T, clock Clock, opts ...Option) (*Fault, *testHookInjector) {
+	t.Helper()
+
+	i := &testHookInjector{enables: make(chan struct{}, 10), disables: make(chan struct{}, 10)}
+	f, err := NewFault(i, append([]Option{
+		WithEnabled(true),
+		WithParticipation(1.0),
+		WithClock(clock),
+	}, opts...)...)
+	assert.NoError(t, err)
+	<-i.enables
+
+	return f, i
+}
+
+// TestTransitionOptions tests the options of WithRampDown and WithMinDwell.
+func TestTransitionOptions(t *testing.T) {
+	t.Parallel()
+
+	clock := faulttest.NewClock(time.Time{})
+
+	tests := []struct {
+		name        string
+		giveOptions []Option
+		want        *transitions
+		wantErr     error
+	}{
+		{
+			name:        "ramp down",
+			giveOptions: []Option{WithRampDown(time.Minute), WithClock(clock)},
+			want:        &transitions{rampDown: time.Minute, clock: clock},
+		},
+		{
+			name:        "dwell",
+			giveOptions: []Option{WithMinDwell(time.Hour)},
+			want:        &transitions{dwell: time.Hour, clock: NewRealClock()},
+		},
+		{
+			name:        "both",
+			giveOptions: []Option{WithRampDown(time.Minute), WithMinDwell(time.Hour)},
+			want:        &transitions{rampDown: time.Minute, dwell: time.Hour, clock: NewRealClock()},
+		},
+		{
+			name:        "none",
+			giveOptions: nil,
+		},
+		{
+			name:        "invalid ramp down",
+			giveOptions: []Option{WithRampDown(0)},
+			wantErr:     ErrInvalidRampDown,
+		},
+		{
+			name:        "invalid dwell",
+			giveOptions: []Option{WithMinDwell(-time.Second)},
+			wantErr:     ErrInvalidDwell,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			f, err := NewFault(newTestInjectorNoop(), tt.giveOptions...)
+
+			assert.Equal(t, tt.wantErr, err)
+			if tt.wantErr != nil {
+				assert.Nil(t, f)
+				return
+			}
+
+			assert.Equal(t, tt.want, f.transitions)
+			assert.False(t, f.RampingDown())
+		})
+	}
+}
+
+// TestFaultRampDown tests that a Fault with a ramp-down decays its participation to zero before it
+// disables itself.
+func TestFaultRampDown(t *testing.T) {
+	t.Parallel()
+
+	clock := faulttest.NewClock(time.Time{})
+	f, i := newTestTransitionFault(t, clock, WithRampDown(10*time.Second), WithRandFloat32Func(func() float32 {
+		return 0.5
+	}))
+
+	f.SetEnabled(false)
+	assert.True(t, f.Enabled())
+	assert.True(t, f.RampingDown())
+
+	// a second disable does not restart the ramp-down
+	clock.Advance(4 * time.Second)
+	f.SetEnabled(false)
+	assert.Equal(t, http.StatusInternalServerError, testRequest(t, f).Code)
+
+	// past half way, the roll of 0.5 is over the decayed participation
+	clock.Advance(2 * time.Second)
+	assert.Equal(t, testHandlerCode, testRequest(t, f).Code)
+	assert.Equal(t, int64(1), f.stats.counters()["skipped_participation"])
+	assert.Len(t, i.disables, 0)
+
+	clock.Advance(4 * time.Second)
+	<-i.disables
+	assert.False(t, f.Enabled())
+	assert.False(t, f.RampingDown())
+	assert.Equal(t, testHandlerCode, testRequest(t, f).Code)
+}
+
+// TestFaultRampDownCanceled tests that enabling a Fault during its ramp-down cancels it.
+func TestFaultRampDownCanceled(t *testing.T) {
+	t.Parallel()
+
+	clock := faulttest.NewClock(time.Time{})
+	f, i := newTestTransitionFault(t, clock, WithRampDown(10*time.Second), WithRandFloat32Func(func() float32 {
+		return 0.9
+	}))
+
+	f.SetEnabled(false)
+	clock.BlockUntil(1)
+	clock.Advance(5 * time.Second)
+	assert.Equal(t, testHandlerCode, testRequest(t, f).Code)
+
+	f.SetEnabled(true)
+	assert.False(t, f.RampingDown())
+	assert.Equal(t, http.StatusInternalServerError, testRequest(t, f).Code)
+
+	clock.Advance(time.Minute)
+	assert.True(t, f.Enabled())
+	assert.Len(t, i.enables, 0)
+	assert.Len(t, i.disables, 0)
+
+	// at the end of a ramp-down the participation is zero until the Fault is disabled
+	f.SetEnabled(false)
+	f.transitions.mtx.Lock()
+	f.transitions.rampStart = f.transitions.rampStart.Add(-time.Hour)
+	f.transitions.mtx.Unlock()
+	assert.Equal(t, testHandlerCode, testRequest(t, f).Code)
+}
+
+// TestFaultMinDwell tests that a Fault with a minimum dwell time delays state changes that come too
+// soon, and that the last requested state wins.
+func TestFaultMinDwell(t *testing.T) {
+	t.Parallel()
+
+	clock := faulttest.NewClock(time.Time{})
+	f, i := newTestTransitionFault(t, clock, WithMinDwell(time.Minute))
+
+	// the first change is not delayed
+	f.SetEnabled(false)
+	<-i.disables
+	assert.False(t, f.Enabled())
+
+	// the enable is delayed until a minute after the disable
+	f.SetEnabled(true)
+	clock.Advance(30 * time.Second)
+	assert.False(t, f.Enabled())
+
+	// a disable before then cancels it, and a later enable replaces it
+	f.SetEnabled(false)
+	f.SetEnabled(true)
+	clock.Advance(29 * time.Second)
+	assert.False(t, f.Enabled())
+
+	clock.Advance(time.Second)
+	<-i.enables
+	assert.True(t, f.Enabled())
+
+	// both waits ended but the Fault was enabled once
+	f.SetEnabled(false)
+	clock.Advance(time.Minute)
+	<-i.disables
+	assert.Len(t, i.enables, 0)
+}
+
+// TestFaultDisableNow tests that DisableNow skips the ramp-down and the minimum dwell time.
+func TestFaultDisableNow(t *testing.T) {
+	t.Parallel()
+
+	clock := faulttest.NewClock(time.Time{})
+	f, i := newTestTransitionFault(t, clock, WithRampDown(time.Minute), WithMinDwell(time.Hour))
+
+	f.SetEnabled(false)
+	assert.True(t, f.RampingDown())
+
+	f.DisableNow()
+	<-i.disables
+	assert.False(t, f.Enabled())
+	assert.False(t, f.RampingDown())
+
+	// the cancelled ramp-down does not disable the Fault again
+	f.SetEnabled(true)
+	clock.Advance(time.Hour)
+	<-i.enables
+	f.DisableNow()
+	<-i.disables
+	assert.False(t, f.Enabled())
+
+	f, err := NewFault(newTestInjectorNoop(), WithEnabled(true))
+	assert.NoError(t, err)
+
+	f.DisableNow()
+	assert.False(t, f.Enabled())
+	assert.False(t, f.RampingDown())
+}
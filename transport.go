@@ -0,0 +1,255 @@
+package fault
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+var (
+	// ErrTransportAborted when an Injector aborts a request sent through a Transport before it
+	// writes a response, the way a RejectInjector drops a connection.
+	ErrTransportAborted = errors.New("connection reset by fault injection")
+)
+
+// Transport is an http.RoundTripper that runs an Injector on outbound requests, to simulate a flaky
+// downstream dependency without controlling the remote server. The Injector sees each request the
+// way a server middleware would: if it continues the request, the request is sent with the base
+// RoundTripper, and if it responds instead, that response is returned to the caller.
+type Transport struct {
+	injector Injector
+	base     http.RoundTripper
+}
+
+// TransportOption configures a Transport.
+type TransportOption interface {
+	applyTransport(t *Transport) error
+}
+
+func (o transportOption) applyTransport(t *Transport) error {
+	t.base = o.transport
+	return nil
+}
+
+// NewTransport returns a Transport that runs i on requests. Pass it a Fault, a Manager, or any
+// Injector.
+func NewTransport(i Injector, opts ...TransportOption) (*Transport, error) {
+	if err := validateInjector(i); err != nil {
+		return nil, err
+	}
+
+	// set defaults
+	t := &Transport{
+		injector: i,
+		base:     http.DefaultTransport,
+	}
+
+	// apply options
+	for _, opt := range opts {
+		err := opt.applyTransport(t)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return t, nil
+}
+
+// RoundTrip runs the Injector on a copy of r. The response of the base RoundTripper streams to the
+// caller unless the Injector changes it, in which case it is read whole and changed first. An
+// Injector that aborts before responding fails the request with ErrTransportAborted, and one that
+// aborts after responding returns a body that fails with io.ErrUnexpectedEOF once the written part
+// is read. An Injector such as a ConnectFailureInjector can fail the request with its own error. A
+// request whose context is done while the Injector holds it is not sent.
+func (t *Transport) RoundTrip(r *http.Request) (*http.Response, error) {
+	tf := &transportFailure{}
+	r = r.Clone(context.WithValue(r.Context(), transportFailureKey{}, tf))
+	if r.Host == "" {
+		r.Host = r.URL.Host
+	}
+
+	tw := &transportWriter{header: make(http.Header)}
+	var resp *http.Response
+	var err error
+	h := t.injector.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		err = r.Context().Err()
+		if err != nil {
+			return
+		}
+
+		resp, err = t.base.RoundTrip(r)
+		if err != nil || w == http.ResponseWriter(tw) {
+			return
+		}
+
+		// the Injector wrapped the writer to change the response
+		defer resp.Body.Close()
+		copyResponse(w, resp)
+		resp = nil
+	}))
+
+	aborted := serve(h, tw, r)
+	if tf.err != nil {
+		if resp != nil {
+			resp.Body.Close()
+		}
+
+		return nil, tf.err
+	}
+
+	if aborted {
+		if resp != nil {
+			resp.Body.Close()
+		}
+		if tw.code == 0 {
+			return nil, ErrTransportAborted
+		}
+
+		return tw.response(r, true), nil
+	}
+
+	if resp != nil {
+		for k, v := range tw.header {
+			if _, ok := resp.Header[k]; !ok {
+				resp.Header[k] = v
+			}
+		}
+
+		return resp, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return tw.response(r, false), nil
+}
+
+// CloseIdleConnections closes the idle connections of the base RoundTripper if it supports it.
+func (t *Transport) CloseIdleConnections() {
+	type closeIdler interface {
+		CloseIdleConnections()
+	}
+
+	if ci, ok := t.base.(closeIdler); ok {
+		ci.CloseIdleConnections()
+	}
+}
+
+// transportFailureKey is the context key of the transportFailure of a request sent through a
+// Transport.
+type transportFailureKey struct{}
+
+// transportFailure holds the error an Injector fails a request sent through a Transport with.
+type transportFailure struct {
+	err error
+}
+
+// failTransport fails r with err if it is being sent through a Transport, and returns false if it
+// is not.
+func failTransport(r *http.Request, err error) bool {
+	tf, ok := r.Context().Value(transportFailureKey{}).(*transportFailure)
+	if ok {
+		tf.err = err
+	}
+
+	return ok
+}
+
+// serve runs h and returns true if it aborted with http.ErrAbortHandler. Other panics are not
+// recovered.
+func serve(h http.Handler, w http.ResponseWriter, r *http.Request) (aborted bool) {
+	defer func() {
+		if v := recover(); v != nil {
+			if v != http.ErrAbortHandler { //nolint:errorlint
+				panic(v)
+			}
+			aborted = true
+		}
+	}()
+
+	h.ServeHTTP(w, r)
+
+	return false
+}
+
+// copyResponse writes resp to w.
+func copyResponse(w http.ResponseWriter, resp *http.Response) {
+	for k, v := range resp.Header {
+		w.Header()[k] = append([]string(nil), v...)
+	}
+	w.WriteHeader(resp.StatusCode)
+	_, _ = io.Copy(w, resp.Body)
+}
+
+// transportWriter records the response an Injector writes for a Transport.
+type transportWriter struct {
+	header http.Header
+	code   int
+	body   bytes.Buffer
+}
+
+// Header returns the response headers.
+func (w *transportWriter) Header() http.Header {
+	return w.header
+}
+
+// WriteHeader records the first final status code. Interim responses are ignored.
+func (w *transportWriter) WriteHeader(code int) {
+	if w.code == 0 && code >= http.StatusOK {
+		w.code = code
+	}
+}
+
+// Write records the body.
+func (w *transportWriter) Write(p []byte) (int, error) {
+	w.WriteHeader(http.StatusOK)
+	return w.body.Write(p)
+}
+
+// Flush does nothing; the body is returned once the Injector is done.
+func (w *transportWriter) Flush() {}
+
+// response returns the recorded response to r. The body of an aborted response fails after the
+// recorded part.
+func (w *transportWriter) response(r *http.Request, aborted bool) *http.Response {
+	code := w.code
+	if code == 0 {
+		code = http.StatusOK
+	}
+
+	resp := &http.Response{
+		Status:        fmt.Sprintf("%d %s", code, http.StatusText(code)),
+		StatusCode:    code,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        w.header,
+		Body:          io.NopCloser(bytes.NewReader(w.body.Bytes())),
+		ContentLength: int64(w.body.Len()),
+		Request:       r,
+	}
+
+	if aborted {
+		resp.ContentLength = -1
+		if n, err := strconv.ParseInt(w.header.Get("Content-Length"), 10, 64); err == nil {
+			resp.ContentLength = n
+		}
+		resp.Body = io.NopCloser(io.MultiReader(&w.body, errReader{io.ErrUnexpectedEOF}))
+	}
+
+	return resp
+}
+
+// errReader is a Reader that always fails.
+type errReader struct {
+	err error
+}
+
+// Read returns the error.
+func (r errReader) Read(p []byte) (int, error) {
+	return 0, r.err
+}
@@ -0,0 +1,351 @@
+package fault
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testInjectorMiddleware is an Injector made from a middleware function.
+type testInjectorMiddleware func(next http.Handler) http.Handler
+
+// Handler calls the middleware function.
+func (m testInjectorMiddleware) Handler(next http.Handler) http.Handler {
+	return m(next)
+}
+
+// testCloseIdler is a RoundTripper that counts calls to CloseIdleConnections.
+type testCloseIdler struct {
+	http.RoundTripper
+	closed int
+}
+
+// CloseIdleConnections counts the call.
+func (c *testCloseIdler) CloseIdleConnections() {
+	c.closed++
+}
+
+// TestNewTransport tests NewTransport.
+func TestNewTransport(t *testing.T) {
+	t.Parallel()
+
+	ni := newTestInjectorNoop()
+	base := &http.Transport{}
+
+	tests := []struct {
+		name         string
+		giveInjector Injector
+		giveOptions  []TransportOption
+		want         *Transport
+		wantErr      error
+	}{
+		{
+			name:         "defaults",
+			giveInjector: ni,
+			want:         &Transport{injector: ni, base: http.DefaultTransport},
+		},
+		{
+			name:         "base transport",
+			giveInjector: ni,
+			giveOptions:  []TransportOption{WithTransport(base)},
+			want:         &Transport{injector: ni, base: base},
+		},
+		{
+			name:    "nil injector",
+			wantErr: ErrNilInjector,
+		},
+		{
+			name:         "option error",
+			giveInjector: ni,
+			giveOptions:  []TransportOption{withError()},
+			wantErr:      errErrorOption,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			tr, err := NewTransport(tt.giveInjector, tt.giveOptions...)
+
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.want, tr)
+		})
+	}
+}
+
+// TestTransportRoundTrip tests Transport.RoundTrip.
+func TestTransportRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	disabled, err := NewFault(newTestInjector500s(), WithEnabled(false))
+	assert.NoError(t, err)
+	ei, err := NewErrorInjector(http.StatusServiceUnavailable, WithErrorHeader("Retry-After", "30"))
+	assert.NoError(t, err)
+	rj, err := NewRejectInjector()
+	assert.NoError(t, err)
+	si, err := NewSlowInjector(0)
+	assert.NoError(t, err)
+	chain, err := NewChainInjector([]Injector{si, ei})
+	assert.NoError(t, err)
+	cs, err := NewCharsetInjector(CharsetMislabel)
+	assert.NoError(t, err)
+	ho, err := NewHeadersOnlyInjector()
+	assert.NoError(t, err)
+	interim, err := NewInterimInjector(InterimEarlyHints)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name            string
+		give            Injector
+		wantErr         error
+		wantCode        int
+		wantHeader      http.Header
+		wantBody        string
+		wantBodyErr     error
+		wantContentLen  int64
+		wantUpstreamHit bool
+	}{
+		{
+			name:            "disabled fault",
+			give:            disabled,
+			wantCode:        http.StatusOK,
+			wantHeader:      http.Header{"Content-Type": {"application/json"}},
+			wantBody:        `{"ok":true}`,
+			wantContentLen:  11,
+			wantUpstreamHit: true,
+		},
+		{
+			name:     "error injector",
+			give:     ei,
+			wantCode: http.StatusServiceUnavailable,
+			wantHeader: http.Header{
+				"Content-Type":           {"text/plain; charset=utf-8"},
+				"Retry-After":            {"30"},
+				"X-Content-Type-Options": {"nosniff"},
+			},
+			wantBody:       "Service Unavailable\n",
+			wantContentLen: 20,
+		},
+		{
+			name:     "chain injector",
+			give:     chain,
+			wantCode: http.StatusServiceUnavailable,
+			wantHeader: http.Header{
+				"Content-Type":           {"text/plain; charset=utf-8"},
+				"Retry-After":            {"30"},
+				"X-Content-Type-Options": {"nosniff"},
+			},
+			wantBody:       "Service Unavailable\n",
+			wantContentLen: 20,
+		},
+		{
+			name:    "reject injector",
+			give:    rj,
+			wantErr: ErrTransportAborted,
+		},
+		{
+			name:           "empty response",
+			give:           newTestInjectorStop(),
+			wantCode:       http.StatusOK,
+			wantHeader:     http.Header{},
+			wantContentLen: 0,
+		},
+		{
+			name:            "changed response",
+			give:            cs,
+			wantCode:        http.StatusOK,
+			wantHeader:      http.Header{"Content-Type": {"application/json; charset=iso-8859-1"}},
+			wantBody:        `{"ok":true}`,
+			wantContentLen:  11,
+			wantUpstreamHit: true,
+		},
+		{
+			name:            "aborted response",
+			give:            ho,
+			wantCode:        http.StatusOK,
+			wantHeader:      http.Header{"Content-Type": {"application/json"}, "Content-Length": {"11"}},
+			wantBodyErr:     io.ErrUnexpectedEOF,
+			wantContentLen:  11,
+			wantUpstreamHit: true,
+		},
+		{
+			name:            "interim responses",
+			give:            interim,
+			wantCode:        http.StatusOK,
+			wantHeader:      http.Header{"Content-Type": {"application/json"}},
+			wantBody:        `{"ok":true}`,
+			wantContentLen:  11,
+			wantUpstreamHit: true,
+		},
+		{
+			name: "header set before the request",
+			give: testInjectorMiddleware(func(next http.Handler) http.Handler {
+				return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.Header().Set("X-Fault", "true")
+					w.Header().Set("Content-Type", "text/plain")
+					next.ServeHTTP(w, r)
+				})
+			}),
+			wantCode:        http.StatusOK,
+			wantHeader:      http.Header{"Content-Type": {"application/json"}, "X-Fault": {"true"}},
+			wantBody:        `{"ok":true}`,
+			wantContentLen:  11,
+			wantUpstreamHit: true,
+		},
+		{
+			name: "aborted after the request",
+			give: testInjectorMiddleware(func(next http.Handler) http.Handler {
+				return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					next.ServeHTTP(w, r)
+					w.(http.Flusher).Flush()
+					panic(http.ErrAbortHandler)
+				})
+			}),
+			wantErr:         ErrTransportAborted,
+			wantUpstreamHit: true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var hits int32
+			upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&hits, 1)
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(`{"ok":true}`))
+			}))
+			defer upstream.Close()
+
+			tr, err := NewTransport(tt.give)
+			assert.NoError(t, err)
+
+			client := &http.Client{Transport: tr}
+			resp, err := client.Get(upstream.URL)
+			assert.Equal(t, tt.wantUpstreamHit, atomic.LoadInt32(&hits) == 1)
+			if tt.wantErr != nil {
+				assert.True(t, errors.Is(err, tt.wantErr))
+				return
+			}
+			assert.NoError(t, err)
+			defer resp.Body.Close()
+
+			body, err := io.ReadAll(resp.Body)
+			assert.Equal(t, tt.wantBodyErr, err)
+
+			assert.Equal(t, tt.wantCode, resp.StatusCode)
+			assert.Equal(t, tt.wantContentLen, resp.ContentLength)
+			assert.Equal(t, tt.wantBody, string(body))
+
+			resp.Header.Del("Date")
+			if tt.wantUpstreamHit && tt.wantBodyErr == nil {
+				resp.Header.Del("Content-Length")
+			}
+			assert.Equal(t, tt.wantHeader, resp.Header)
+		})
+	}
+}
+
+// TestTransportRoundTripRequest tests that Transport.RoundTrip does not change the request it is
+// given or send requests whose context is done.
+func TestTransportRoundTripRequest(t *testing.T) {
+	t.Parallel()
+
+	var got *http.Request
+	tr, err := NewTransport(testInjectorMiddleware(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got = r
+			r.Header.Del("Authorization")
+			next.ServeHTTP(w, r)
+		})
+	}))
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	req := (&http.Request{
+		Method: http.MethodGet,
+		URL:    &url.URL{Scheme: "http", Host: "example.invalid", Path: "/users"},
+		Header: http.Header{"Authorization": {"token"}},
+	}).WithContext(ctx)
+
+	resp, err := tr.RoundTrip(req)
+	assert.Nil(t, resp)
+	assert.Equal(t, context.Canceled, err)
+
+	assert.Equal(t, "token", req.Header.Get("Authorization"))
+	assert.Equal(t, "example.invalid", got.Host)
+	assert.Equal(t, "/users", got.URL.Path)
+}
+
+// TestTransportRoundTripPanic tests that panics other than http.ErrAbortHandler are not recovered.
+func TestTransportRoundTripPanic(t *testing.T) {
+	t.Parallel()
+
+	panicErr := errors.New("boom")
+	tr, err := NewTransport(testInjectorMiddleware(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { panic(panicErr) })
+	}))
+	assert.NoError(t, err)
+
+	assert.PanicsWithValue(t, panicErr, func() {
+		_, _ = tr.RoundTrip(httptest.NewRequest(http.MethodGet, "http://example.invalid/", nil))
+	})
+}
+
+// TestTransportCloseIdleConnections tests Transport.CloseIdleConnections.
+func TestTransportCloseIdleConnections(t *testing.T) {
+	t.Parallel()
+
+	base := &testCloseIdler{}
+	tr, err := NewTransport(newTestInjectorNoop(), WithTransport(base))
+	assert.NoError(t, err)
+
+	tr.CloseIdleConnections()
+	assert.Equal(t, 1, base.closed)
+
+	// a base without idle connections is left alone
+	tr, err = NewTransport(newTestInjectorNoop(), WithTransport(testCloseIdler{}.RoundTripper))
+	assert.NoError(t, err)
+	tr.CloseIdleConnections()
+}
+
+// TestTransportRoundTripFailure tests that an Injector can fail a request sent through a Transport
+// with its own error, even after sending it.
+func TestTransportRoundTripFailure(t *testing.T) {
+	t.Parallel()
+
+	var hits int32
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+	}))
+	defer upstream.Close()
+
+	failErr := errors.New("failed by injector")
+	tr, err := NewTransport(testInjectorMiddleware(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r)
+			assert.True(t, failTransport(r, failErr))
+		})
+	}))
+	assert.NoError(t, err)
+
+	resp, err := tr.RoundTrip(httptest.NewRequest(http.MethodGet, upstream.URL, nil))
+	assert.Nil(t, resp)
+	assert.Equal(t, failErr, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&hits))
+
+	assert.False(t, failTransport(httptest.NewRequest(http.MethodGet, "/", nil), failErr))
+}
@@ -0,0 +1,132 @@
+package fault
+
+import (
+	"context"
+	"time"
+)
+
+// TypedHandler handles a call of a protocol whose requests are Req and whose responses are Resp,
+// such as a SQL query or a Redis command.
+type TypedHandler[Req, Resp any] func(ctx context.Context, req Req) (Resp, error)
+
+// TypedInjector is an Injector for a protocol whose requests are Req and whose responses are Resp.
+// Where an Injector wraps an http.Handler, a TypedInjector wraps a TypedHandler, so the compiler
+// checks that it is only used on calls of the protocol it was written for. Adapt a protocol by
+// describing its calls to a TypedFault, which shares the decisions, stats, and reporting of a
+// Fault.
+type TypedInjector[Req, Resp any] interface {
+	// Wrap returns a TypedHandler that injects the fault into calls and calls next if the call
+	// continues.
+	Wrap(next TypedHandler[Req, Resp]) TypedHandler[Req, Resp]
+}
+
+// TypedInjectorFunc is a function that satisfies TypedInjector. It handles req itself and calls
+// next if the call continues.
+type TypedInjectorFunc[Req, Resp any] func(ctx context.Context, req Req, next TypedHandler[Req, Resp]) (Resp, error)
+
+// Wrap returns a TypedHandler that calls f with next.
+func (f TypedInjectorFunc[Req, Resp]) Wrap(next TypedHandler[Req, Resp]) TypedHandler[Req, Resp] {
+	return func(ctx context.Context, req Req) (Resp, error) {
+		return f(ctx, req, next)
+	}
+}
+
+// TypedChain returns a TypedInjector that runs is in order, each one wrapping the ones after it,
+// like a ChainInjector.
+func TypedChain[Req, Resp any](is ...TypedInjector[Req, Resp]) TypedInjector[Req, Resp] {
+	return TypedInjectorFunc[Req, Resp](func(ctx context.Context, req Req, next TypedHandler[Req, Resp]) (Resp, error) {
+		for idx := len(is) - 1; idx >= 0; idx-- {
+			next = is[idx].Wrap(next)
+		}
+
+		return next(ctx, req)
+	})
+}
+
+// TypedError returns a TypedInjector that fails calls with err instead of running them, like an
+// ErrorInjector.
+func TypedError[Req, Resp any](err error) TypedInjector[Req, Resp] {
+	return TypedInjectorFunc[Req, Resp](func(context.Context, Req, TypedHandler[Req, Resp]) (Resp, error) {
+		var zero Resp
+		return zero, err
+	})
+}
+
+// TypedDelay returns a TypedInjector that holds calls for d before running them, like a
+// SlowInjector. A call whose context is done first fails with the error of its context. A nil
+// clock uses a RealClock.
+func TypedDelay[Req, Resp any](d time.Duration, clock Clock) TypedInjector[Req, Resp] {
+	if clock == nil {
+		clock = NewRealClock()
+	}
+
+	return TypedInjectorFunc[Req, Resp](func(ctx context.Context, req Req, next TypedHandler[Req, Resp]) (Resp, error) {
+		select {
+		case <-ctx.Done():
+			var zero Resp
+			return zero, ctx.Err()
+		case <-clock.After(d):
+		}
+
+		return next(ctx, req)
+	})
+}
+
+// TypedFault runs a TypedInjector on the calls a Fault decides to fault, and lets the rest through.
+// The Fault is only used for its decisions, so its own Injector never runs, but the decisions are
+// counted in its stats and reported to its Reporter like those of HTTP requests. A TypedFault is
+// itself a TypedInjector, so it can be chained with others.
+type TypedFault[Req, Resp any] struct {
+	fault    *Fault
+	injector TypedInjector[Req, Resp]
+	call     func(ctx context.Context, req Req) Call
+}
+
+// NewTypedFault returns a TypedFault that runs i on the calls f decides to fault. call describes a
+// request of the protocol as the Call f decides, such as a query as a Call with Protocol "sql" and
+// Operation "SELECT"; the context of the request is set on Calls without one. A nil call describes
+// every request as a Call with only a context, which suits a Fault that only rolls participation.
+func NewTypedFault[Req, Resp any](f *Fault, i TypedInjector[Req, Resp], call func(ctx context.Context, req Req) Call) (
+	*TypedFault[Req, Resp], error,
+) {
+	if f == nil {
+		return nil, ErrNilFault
+	}
+
+	if i == nil {
+		return nil, ErrNilInjector
+	}
+
+	if call == nil {
+		call = func(context.Context, Req) Call { return Call{} }
+	}
+
+	return &TypedFault[Req, Resp]{fault: f, injector: i, call: call}, nil
+}
+
+// Wrap returns a TypedHandler that runs the TypedInjector on the calls the Fault decides to fault,
+// and next on the rest.
+func (t *TypedFault[Req, Resp]) Wrap(next TypedHandler[Req, Resp]) TypedHandler[Req, Resp] {
+	injected := t.injector.Wrap(next)
+
+	return func(ctx context.Context, req Req) (Resp, error) {
+		c := t.call(ctx, req)
+		if c.Context == nil {
+			c.Context = ctx
+		}
+
+		ev, done := t.fault.Decide(c)
+		defer done()
+
+		if ev.Injected {
+			return injected(ctx, req)
+		}
+
+		return next(ctx, req)
+	}
+}
+
+// Fault returns the Fault that decides which calls are faulted.
+func (t *TypedFault[Req, Resp]) Fault() *Fault {
+	return t.fault
+}
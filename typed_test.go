@@ -0,0 +1,216 @@
+package fault
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/github/go-fault/faulttest"
+	"github.com/stretchr/testify/assert"
+)
+
+// testQuery is a request of a made-up SQL protocol.
+type testQuery struct {
+	table string
+	sql   string
+}
+
+// errTestQuery is returned by queries a TypedInjector fails.
+var errTestQuery = errors.New("connection reset")
+
+// testQueryHandler returns the query as its result.
+func testQueryHandler(ctx context.Context, q testQuery) (string, error) {
+	return "result of " + q.sql, nil
+}
+
+// TestTypedInjectors tests TypedInjectorFunc, TypedChain, TypedError, and TypedDelay.
+func TestTypedInjectors(t *testing.T) {
+	t.Parallel()
+
+	upper := TypedInjectorFunc[testQuery, string](
+		func(ctx context.Context, q testQuery, next TypedHandler[testQuery, string]) (string, error) {
+			resp, err := next(ctx, q)
+			return strings.ToUpper(resp), err
+		})
+	comment := TypedInjectorFunc[testQuery, string](
+		func(ctx context.Context, q testQuery, next TypedHandler[testQuery, string]) (string, error) {
+			q.sql += " -- faulted"
+			return next(ctx, q)
+		})
+
+	tests := []struct {
+		name     string
+		give     TypedInjector[testQuery, string]
+		wantResp string
+		wantErr  error
+	}{
+		{
+			name:     "func",
+			give:     upper,
+			wantResp: "RESULT OF SELECT 1",
+		},
+		{
+			name:     "chain",
+			give:     TypedChain[testQuery, string](upper, comment),
+			wantResp: "RESULT OF SELECT 1 -- FAULTED",
+		},
+		{
+			name:     "empty chain",
+			give:     TypedChain[testQuery, string](),
+			wantResp: "result of SELECT 1",
+		},
+		{
+			name:    "error",
+			give:    TypedChain(comment, TypedError[testQuery, string](errTestQuery)),
+			wantErr: errTestQuery,
+		},
+		{
+			name:     "delay",
+			give:     TypedDelay[testQuery, string](time.Nanosecond, nil),
+			wantResp: "result of SELECT 1",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			resp, err := tt.give.Wrap(testQueryHandler)(context.Background(), testQuery{sql: "SELECT 1"})
+
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.wantResp, resp)
+		})
+	}
+}
+
+// TestTypedDelay tests that TypedDelay holds calls on its Clock and gives up when their context is
+// done.
+func TestTypedDelay(t *testing.T) {
+	t.Parallel()
+
+	clock := faulttest.NewClock(time.Time{})
+	h := TypedDelay[testQuery, string](time.Second, clock).Wrap(testQueryHandler)
+
+	type result struct {
+		resp string
+		err  error
+	}
+	done := make(chan result)
+	go func() {
+		resp, err := h(context.Background(), testQuery{sql: "SELECT 1"})
+		done <- result{resp, err}
+	}()
+
+	clock.BlockUntil(1)
+	clock.Advance(time.Second)
+	assert.Equal(t, result{resp: "result of SELECT 1"}, <-done)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	resp, err := h(ctx, testQuery{sql: "SELECT 1"})
+	assert.Equal(t, context.Canceled, err)
+	assert.Equal(t, "", resp)
+}
+
+// TestNewTypedFault tests NewTypedFault.
+func TestNewTypedFault(t *testing.T) {
+	t.Parallel()
+
+	f, err := NewFault(newTestInjectorNoop())
+	assert.NoError(t, err)
+
+	i := TypedError[testQuery, string](errTestQuery)
+
+	tests := []struct {
+		name         string
+		giveFault    *Fault
+		giveInjector TypedInjector[testQuery, string]
+		wantErr      error
+	}{
+		{
+			name:         "valid",
+			giveFault:    f,
+			giveInjector: i,
+		},
+		{
+			name:         "nil fault",
+			giveInjector: i,
+			wantErr:      ErrNilFault,
+		},
+		{
+			name:      "nil injector",
+			giveFault: f,
+			wantErr:   ErrNilInjector,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			tf, err := NewTypedFault(tt.giveFault, tt.giveInjector, nil)
+
+			assert.Equal(t, tt.wantErr, err)
+			if tt.wantErr != nil {
+				assert.Nil(t, tf)
+				return
+			}
+
+			assert.Equal(t, tt.giveFault, tf.Fault())
+		})
+	}
+}
+
+// TestTypedFaultWrap tests that a TypedFault runs its TypedInjector on the calls its Fault decides
+// to fault.
+func TestTypedFaultWrap(t *testing.T) {
+	t.Parallel()
+
+	f, err := NewFault(newTestInjector500s(),
+		WithEnabled(true),
+		WithParticipation(1.0),
+		WithPathAllowlist([]string{"orders"}),
+	)
+	assert.NoError(t, err)
+
+	type ctxKey struct{}
+	var calls []Call
+	tf, err := NewTypedFault(f, TypedError[testQuery, string](errTestQuery),
+		func(ctx context.Context, q testQuery) Call {
+			c := Call{Protocol: "sql", Operation: q.table}
+			calls = append(calls, c)
+			return c
+		})
+	assert.NoError(t, err)
+
+	h := tf.Wrap(testQueryHandler)
+	ctx := context.WithValue(context.Background(), ctxKey{}, "value")
+
+	resp, err := h(ctx, testQuery{table: "orders", sql: "SELECT 1"})
+	assert.Equal(t, errTestQuery, err)
+	assert.Equal(t, "", resp)
+
+	resp, err = h(ctx, testQuery{table: "users", sql: "SELECT 2"})
+	assert.NoError(t, err)
+	assert.Equal(t, "result of SELECT 2", resp)
+
+	assert.Equal(t, []Call{{Protocol: "sql", Operation: "orders"}, {Protocol: "sql", Operation: "users"}}, calls)
+	assert.Equal(t, testCounters(map[string]int64{
+		"evaluated":         2,
+		"injected":          1,
+		"skipped":           1,
+		"skipped_unmatched": 1,
+	}), f.stats.counters())
+
+	// a TypedFault without a call function decides every call the same
+	tf, err = NewTypedFault(f, TypedError[testQuery, string](errTestQuery), nil)
+	assert.NoError(t, err)
+
+	_, err = TypedChain[testQuery, string](tf).Wrap(testQueryHandler)(ctx, testQuery{table: "orders"})
+	assert.NoError(t, err)
+}
@@ -0,0 +1,95 @@
+package fault
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// ErrNotVerified when no probe sent by Manager.Verify runs the Injector of the expected Fault.
+var ErrNotVerified = errors.New("fault did not fire on the probe")
+
+// ProbeTarget is the probe request Manager.Verify sends and the Fault it expects to fire.
+type ProbeTarget struct {
+	// Fault is the name of the managed Fault that must run its Injector.
+	Fault string
+	// Request is the probe. Each probe is a copy of it, so it should not have a body. Default GET
+	// "/".
+	Request *http.Request
+	// Attempts is how many probes may be sent before Verify gives up, for Faults that run on a
+	// fraction of requests. Default 1.
+	Attempts int
+}
+
+// Verification is the result of Manager.Verify.
+type Verification struct {
+	// Fault is the name of the Fault that was verified.
+	Fault string `json:"fault"`
+	// Attempts is how many probes were sent.
+	Attempts int `json:"attempts"`
+	// Code is the status code the last probe got, or 0 if it was aborted before responding.
+	Code int `json:"code"`
+	// Aborted is true if the last probe was aborted, the way a RejectInjector drops a connection.
+	Aborted bool `json:"aborted"`
+	// Decisions are the Decisions of the Faults that evaluated the last probe.
+	Decisions []Decision `json:"decisions"`
+}
+
+// Verify sends probe requests through the managed Faults until the target Fault runs its Injector,
+// so a deployment pipeline can check that a fault configuration is live before a game day. The
+// probes never reach a handler: a probe that every Injector continues gets an empty 200 OK. It
+// returns ErrFaultNotFound if the Fault is not managed, an error wrapping ErrNotVerified with the
+// last Decision of the Fault if it does not fire within the attempts, and the error of ctx if ctx
+// is done first. Give the Fault WithOptInHeader() and set the header on the probe to fault it
+// without faulting real traffic.
+func (m *Manager) Verify(ctx context.Context, target ProbeTarget) (Verification, error) {
+	v := Verification{Fault: target.Fault}
+
+	if m.Fault(target.Fault) == nil {
+		return v, fmt.Errorf("%w: %q", ErrFaultNotFound, target.Fault)
+	}
+
+	req := target.Request
+	if req == nil {
+		req = &http.Request{Method: http.MethodGet, URL: &url.URL{Path: "/"}, Header: http.Header{}}
+	}
+
+	attempts := target.Attempts
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	h := m.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	reason := "not evaluated"
+	for v.Attempts < attempts {
+		if err := ctx.Err(); err != nil {
+			return v, err
+		}
+
+		r := req.Clone(ContextWithDecisions(ctx))
+		tw := &transportWriter{header: make(http.Header)}
+		v.Aborted = serve(h, tw, r)
+		v.Attempts++
+
+		v.Code = tw.code
+		if v.Code == 0 && !v.Aborted {
+			v.Code = http.StatusOK
+		}
+
+		v.Decisions = DecisionsFromContext(r.Context())
+		for _, d := range v.Decisions {
+			if d.Fault != target.Fault {
+				continue
+			}
+			if d.Decision == DecisionInjected {
+				return v, nil
+			}
+			reason = d.Decision
+		}
+	}
+
+	return v, fmt.Errorf("%w: %q: %s", ErrNotVerified, target.Fault, reason)
+}
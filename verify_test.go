@@ -0,0 +1,192 @@
+package fault
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestManagerVerify tests Manager.Verify.
+func TestManagerVerify(t *testing.T) {
+	t.Parallel()
+
+	optIn := httptest.NewRequest(http.MethodGet, "/checkout", nil)
+	optIn.Header.Set(OptInHeader, "true")
+
+	tests := []struct {
+		name       string
+		giveFaults func(t *testing.T) []*Fault
+		giveTarget ProbeTarget
+		giveCancel bool
+		want       Verification
+		wantErr    error
+	}{
+		{
+			name: "injected",
+			giveFaults: func(t *testing.T) []*Fault {
+				return []*Fault{newVerifyFault(t, "errors", newTestInjector500s(), WithEnabled(true))}
+			},
+			giveTarget: ProbeTarget{Fault: "errors"},
+			want: Verification{
+				Fault:    "errors",
+				Attempts: 1,
+				Code:     http.StatusInternalServerError,
+				Decisions: []Decision{
+					{Fault: "errors", Injector: "testInjector500s", Decision: DecisionInjected},
+				},
+			},
+		},
+		{
+			name: "aborted",
+			giveFaults: func(t *testing.T) []*Fault {
+				i, err := NewRejectInjector()
+				assert.NoError(t, err)
+
+				return []*Fault{newVerifyFault(t, "reject", i, WithEnabled(true))}
+			},
+			giveTarget: ProbeTarget{Fault: "reject"},
+			want: Verification{
+				Fault:    "reject",
+				Attempts: 1,
+				Aborted:  true,
+				Decisions: []Decision{
+					{Fault: "reject", Injector: "reject", Decision: DecisionInjected},
+				},
+			},
+		},
+		{
+			name: "opt in",
+			giveFaults: func(t *testing.T) []*Fault {
+				return []*Fault{newVerifyFault(t, "noop", newTestInjectorNoop(), WithEnabled(true),
+					WithOptInHeader(""))}
+			},
+			giveTarget: ProbeTarget{Fault: "noop", Request: optIn},
+			want: Verification{
+				Fault:    "noop",
+				Attempts: 1,
+				Code:     http.StatusOK,
+				Decisions: []Decision{
+					{Fault: "noop", Injector: "testInjectorNoop", Decision: DecisionInjected},
+				},
+			},
+		},
+		{
+			name: "attempts",
+			giveFaults: func(t *testing.T) []*Fault {
+				rolls := []float32{0.9, 0.9, 0.1}
+				return []*Fault{newVerifyFault(t, "errors", newTestInjector500s(),
+					WithEnabled(true),
+					WithParticipation(0.5),
+					WithRandFloat32Func(func() float32 {
+						rn := rolls[0]
+						rolls = rolls[1:]
+						return rn
+					}),
+				)}
+			},
+			giveTarget: ProbeTarget{Fault: "errors", Attempts: 5},
+			want: Verification{
+				Fault:    "errors",
+				Attempts: 3,
+				Code:     http.StatusInternalServerError,
+				Decisions: []Decision{
+					{Fault: "errors", Injector: "testInjector500s", Decision: DecisionInjected},
+				},
+			},
+		},
+		{
+			name: "not managed",
+			giveFaults: func(t *testing.T) []*Fault {
+				return nil
+			},
+			giveTarget: ProbeTarget{Fault: "errors"},
+			want:       Verification{Fault: "errors"},
+			wantErr:    ErrFaultNotFound,
+		},
+		{
+			name: "disabled",
+			giveFaults: func(t *testing.T) []*Fault {
+				return []*Fault{newVerifyFault(t, "errors", newTestInjector500s())}
+			},
+			giveTarget: ProbeTarget{Fault: "errors", Attempts: 2},
+			want: Verification{
+				Fault:    "errors",
+				Attempts: 2,
+				Code:     http.StatusOK,
+				Decisions: []Decision{
+					{Fault: "errors", Injector: "testInjector500s", Decision: string(SkipDisabled)},
+				},
+			},
+			wantErr: ErrNotVerified,
+		},
+		{
+			name: "not evaluated",
+			giveFaults: func(t *testing.T) []*Fault {
+				return []*Fault{
+					newVerifyFault(t, "first", newTestInjector500s(), WithEnabled(true)),
+					newVerifyFault(t, "second", newTestInjectorNoop(), WithEnabled(true)),
+				}
+			},
+			giveTarget: ProbeTarget{Fault: "second"},
+			want: Verification{
+				Fault:    "second",
+				Attempts: 1,
+				Code:     http.StatusInternalServerError,
+				Decisions: []Decision{
+					{Fault: "first", Injector: "testInjector500s", Decision: DecisionInjected},
+				},
+			},
+			wantErr: ErrNotVerified,
+		},
+		{
+			name: "canceled",
+			giveFaults: func(t *testing.T) []*Fault {
+				return []*Fault{newVerifyFault(t, "errors", newTestInjector500s(), WithEnabled(true))}
+			},
+			giveTarget: ProbeTarget{Fault: "errors"},
+			giveCancel: true,
+			want:       Verification{Fault: "errors"},
+			wantErr:    context.Canceled,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			m, err := NewManager()
+			assert.NoError(t, err)
+			assert.NoError(t, m.Add(tt.giveFaults(t)...))
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tt.giveCancel {
+				cancel()
+			}
+
+			v, err := m.Verify(ctx, tt.giveTarget)
+			assert.True(t, errors.Is(err, tt.wantErr), err)
+
+			// latencies are measured with the real clock
+			for idx := range v.Decisions {
+				v.Decisions[idx].Latency = 0
+			}
+			assert.Equal(t, tt.want, v)
+		})
+	}
+}
+
+// newVerifyFault returns a Fault named name that runs i.
+func newVerifyFault(t *testing.T, name string, i Injector, opts ...Option) *Fault {
+	t.Helper()
+
+	f, err := NewFault(i, append([]Option{WithName(name), WithParticipation(1.0)}, opts...)...)
+	assert.NoError(t, err)
+
+	return f
+}
@@ -0,0 +1,333 @@
+package fault
+
+import (
+	"errors"
+	"os"
+	"runtime"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultCheckInterval is how often a Watchdog checks the health of the process by default.
+	defaultCheckInterval = time.Second
+	// procFDPath lists the open file descriptors of the process on Linux.
+	procFDPath = "/proc/self/fd"
+)
+
+var (
+	// ErrNoWatchdogLimits when a Watchdog is created without any limits.
+	ErrNoWatchdogLimits = errors.New("at least one watchdog limit is required")
+	// ErrInvalidInterval when a Watchdog check interval or the interval of EveryNth is not positive.
+	ErrInvalidInterval = errors.New("interval must be > 0")
+)
+
+// Health is a snapshot of the health signals of the process.
+type Health struct {
+	// Goroutines is the number of goroutines.
+	Goroutines int
+	// HeapBytes is the number of bytes of allocated heap objects.
+	HeapBytes uint64
+	// OpenFiles is the number of open file descriptors, or -1 if it cannot be read on this
+	// platform.
+	OpenFiles int
+}
+
+// ReadHealth returns the current Health of the process. Reading the heap briefly stops the world,
+// so do not call it on every request. Open files are counted from /proc/self/fd.
+func ReadHealth() Health {
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+
+	return Health{
+		Goroutines: runtime.NumGoroutine(),
+		HeapBytes:  ms.HeapAlloc,
+		OpenFiles:  openFiles(procFDPath),
+	}
+}
+
+// openFiles returns the number of open file descriptors listed in path or -1 if they cannot be
+// counted.
+func openFiles(path string) int {
+	dir, err := os.Open(path)
+	if err != nil {
+		return -1
+	}
+	defer dir.Close()
+
+	names, err := dir.Readdirnames(-1)
+	if err != nil {
+		return -1
+	}
+
+	// the directory itself is open while it is read
+	return len(names) - 1
+}
+
+// Trip describes a health limit the Watchdog found exceeded, and the Faults it disabled because of
+// it.
+type Trip struct {
+	// Signal is the health signal over its limit: "goroutines", "heap_bytes", or "open_files".
+	Signal string
+	// Value is the value of the signal.
+	Value uint64
+	// Limit is the limit of the signal.
+	Limit uint64
+	// Health is the Health that was checked.
+	Health Health
+	// Faults are the names of the Faults that were disabled.
+	Faults []string
+}
+
+// Watchdog protects the process from its own fault injection. While any of its Faults are enabled
+// it periodically checks the Health of the process, and if a limit is exceeded it disables every
+// one of its Faults. Watch the Faults whose Injectors hold resources, such as a SlowInjector
+// holding requests and their goroutines and connections open.
+type Watchdog struct {
+	faults []*Fault
+
+	maxGoroutines uint64
+	maxHeapBytes  uint64
+	maxOpenFiles  uint64
+	interval      time.Duration
+	clock         Clock
+	tripFunc      func(t Trip)
+
+	// health reads the Health of the process.
+	health func() Health
+
+	mtx  sync.Mutex
+	stop chan struct{}
+	done chan struct{}
+}
+
+// WatchdogOption configures a Watchdog.
+type WatchdogOption interface {
+	applyWatchdog(w *Watchdog) error
+}
+
+func (o clockOption) applyWatchdog(w *Watchdog) error {
+	w.clock = o.clock
+	return nil
+}
+
+type maxGoroutinesOption uint64
+
+func (o maxGoroutinesOption) applyWatchdog(w *Watchdog) error {
+	w.maxGoroutines = uint64(o)
+	return nil
+}
+
+// WithMaxGoroutines sets the number of goroutines past which the Watchdog disables its Faults.
+// Default 0, no limit.
+func WithMaxGoroutines(n uint64) WatchdogOption {
+	return maxGoroutinesOption(n)
+}
+
+type maxHeapBytesOption uint64
+
+func (o maxHeapBytesOption) applyWatchdog(w *Watchdog) error {
+	w.maxHeapBytes = uint64(o)
+	return nil
+}
+
+// WithMaxHeapBytes sets the number of heap bytes past which the Watchdog disables its Faults.
+// Default 0, no limit.
+func WithMaxHeapBytes(n uint64) WatchdogOption {
+	return maxHeapBytesOption(n)
+}
+
+type maxOpenFilesOption uint64
+
+func (o maxOpenFilesOption) applyWatchdog(w *Watchdog) error {
+	w.maxOpenFiles = uint64(o)
+	return nil
+}
+
+// WithMaxOpenFiles sets the number of open file descriptors past which the Watchdog disables its
+// Faults. The limit is ignored on platforms where open files cannot be counted. Default 0, no
+// limit.
+func WithMaxOpenFiles(n uint64) WatchdogOption {
+	return maxOpenFilesOption(n)
+}
+
+type checkIntervalOption time.Duration
+
+func (o checkIntervalOption) applyWatchdog(w *Watchdog) error {
+	if o <= 0 {
+		return ErrInvalidInterval
+	}
+
+	w.interval = time.Duration(o)
+
+	return nil
+}
+
+// CheckIntervalOption configures things that check periodically.
+type CheckIntervalOption interface {
+	WatchdogOption
+	ErrorGuardOption
+	ConcluderOption
+	SLOGuardOption
+	ExperimentOption
+}
+
+// WithCheckInterval sets how often the Watchdog checks the health of the process (default 1s), the
+// ErrorGuard checks the error ratio (default 10s), the Concluder checks the impact of its Fault
+// (default 30s), the SLOGuard checks its objectives (default 10s), or the Experiment updates the
+// participation of its Fault (default 10s).
+func WithCheckInterval(d time.Duration) CheckIntervalOption {
+	return checkIntervalOption(d)
+}
+
+type tripFuncOption func(t Trip)
+
+func (o tripFuncOption) applyWatchdog(w *Watchdog) error {
+	w.tripFunc = o
+	return nil
+}
+
+// WithTripFunc sets a function that is called with every Trip, for example to log or alert when the
+// Watchdog disables Faults.
+func WithTripFunc(f func(t Trip)) WatchdogOption {
+	return tripFuncOption(f)
+}
+
+// NewWatchdog returns a Watchdog that disables faults when the process is unhealthy. At least one
+// of WithMaxGoroutines(), WithMaxHeapBytes(), or WithMaxOpenFiles() is required. Call Start to
+// begin checking.
+func NewWatchdog(faults []*Fault, opts ...WatchdogOption) (*Watchdog, error) {
+	for _, f := range faults {
+		if f == nil {
+			return nil, ErrNilFault
+		}
+	}
+
+	// set defaults
+	w := &Watchdog{
+		faults:   faults,
+		interval: defaultCheckInterval,
+		clock:    NewRealClock(),
+		tripFunc: func(Trip) {},
+		health:   ReadHealth,
+	}
+
+	// apply options
+	for _, opt := range opts {
+		err := opt.applyWatchdog(w)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if w.maxGoroutines == 0 && w.maxHeapBytes == 0 && w.maxOpenFiles == 0 {
+		return nil, ErrNoWatchdogLimits
+	}
+
+	return w, nil
+}
+
+// Start begins checking the health of the process every interval in a new goroutine. It does
+// nothing if the Watchdog is already started.
+func (w *Watchdog) Start() {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+
+	if w.stop != nil {
+		return
+	}
+
+	w.stop = make(chan struct{})
+	w.done = make(chan struct{})
+
+	go w.run(w.stop, w.done)
+}
+
+// Stop stops checking and waits for a check in progress to finish. It does nothing if the Watchdog
+// is not started.
+func (w *Watchdog) Stop() {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+
+	if w.stop == nil {
+		return
+	}
+
+	close(w.stop)
+	<-w.done
+
+	w.stop = nil
+	w.done = nil
+}
+
+// run calls Check every interval until stop is closed.
+func (w *Watchdog) run(stop <-chan struct{}, done chan<- struct{}) {
+	defer close(done)
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-w.clock.After(w.interval):
+			w.Check()
+		}
+	}
+}
+
+// Check checks the health of the process once and disables the Faults if a limit is exceeded. The
+// health is not read if none of the Faults are enabled. It returns the Trip, or nil if the Faults
+// were left alone.
+func (w *Watchdog) Check() *Trip {
+	enabled := make([]*Fault, 0, len(w.faults))
+	for _, f := range w.faults {
+		if f.enabled.Load() {
+			enabled = append(enabled, f)
+		}
+	}
+
+	if len(enabled) == 0 {
+		return nil
+	}
+
+	h := w.health()
+	trip := w.exceeded(h)
+	if trip == nil {
+		return nil
+	}
+
+	for _, f := range enabled {
+		f.DisableNow()
+		trip.Faults = append(trip.Faults, f.Name())
+	}
+
+	w.tripFunc(*trip)
+
+	return trip
+}
+
+// exceeded returns a Trip for the first signal in h over its limit, or nil if none are.
+func (w *Watchdog) exceeded(h Health) *Trip {
+	signals := []struct {
+		name  string
+		value uint64
+		limit uint64
+		known bool
+	}{
+		{"goroutines", uint64(h.Goroutines), w.maxGoroutines, true},
+		{"heap_bytes", h.HeapBytes, w.maxHeapBytes, true},
+		{"open_files", uint64(h.OpenFiles), w.maxOpenFiles, h.OpenFiles >= 0},
+	}
+
+	for _, s := range signals {
+		if s.limit > 0 && s.known && s.value > s.limit {
+			return &Trip{
+				Signal: s.name,
+				Value:  s.value,
+				Limit:  s.limit,
+				Health: h,
+			}
+		}
+	}
+
+	return nil
+}
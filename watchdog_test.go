@@ -0,0 +1,337 @@
+package fault
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/github/go-fault/faulttest"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestNewWatchdog tests NewWatchdog.
+func TestNewWatchdog(t *testing.T) {
+	t.Parallel()
+
+	clock := faulttest.NewClock(time.Time{})
+
+	tests := []struct {
+		name              string
+		giveFaults        []*Fault
+		giveOptions       []WatchdogOption
+		wantMaxGoroutines uint64
+		wantMaxHeapBytes  uint64
+		wantMaxOpenFiles  uint64
+		wantInterval      time.Duration
+		wantClock         Clock
+		wantErr           error
+	}{
+		{
+			name: "defaults",
+			giveOptions: []WatchdogOption{
+				WithMaxGoroutines(100),
+			},
+			wantMaxGoroutines: 100,
+			wantInterval:      defaultCheckInterval,
+			wantClock:         NewRealClock(),
+		},
+		{
+			name: "options",
+			giveFaults: []*Fault{
+				{},
+			},
+			giveOptions: []WatchdogOption{
+				WithMaxGoroutines(1),
+				WithMaxHeapBytes(2),
+				WithMaxOpenFiles(3),
+				WithCheckInterval(time.Minute),
+				WithClock(clock),
+			},
+			wantMaxGoroutines: 1,
+			wantMaxHeapBytes:  2,
+			wantMaxOpenFiles:  3,
+			wantInterval:      time.Minute,
+			wantClock:         clock,
+		},
+		{
+			name: "nil fault",
+			giveFaults: []*Fault{
+				nil,
+			},
+			giveOptions: []WatchdogOption{
+				WithMaxGoroutines(1),
+			},
+			wantErr: ErrNilFault,
+		},
+		{
+			name:    "no limits",
+			wantErr: ErrNoWatchdogLimits,
+		},
+		{
+			name: "invalid interval",
+			giveOptions: []WatchdogOption{
+				WithMaxGoroutines(1),
+				WithCheckInterval(0),
+			},
+			wantErr: ErrInvalidInterval,
+		},
+		{
+			name: "option error",
+			giveOptions: []WatchdogOption{
+				withError(),
+			},
+			wantErr: errErrorOption,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			w, err := NewWatchdog(tt.giveFaults, tt.giveOptions...)
+
+			assert.Equal(t, tt.wantErr, err)
+			if tt.wantErr != nil {
+				assert.Nil(t, w)
+				return
+			}
+
+			assert.Equal(t, tt.giveFaults, w.faults)
+			assert.Equal(t, tt.wantMaxGoroutines, w.maxGoroutines)
+			assert.Equal(t, tt.wantMaxHeapBytes, w.maxHeapBytes)
+			assert.Equal(t, tt.wantMaxOpenFiles, w.maxOpenFiles)
+			assert.Equal(t, tt.wantInterval, w.interval)
+			assert.Equal(t, tt.wantClock, w.clock)
+			assert.NotNil(t, w.tripFunc)
+			assert.NotNil(t, w.health)
+		})
+	}
+}
+
+// TestReadHealth tests ReadHealth.
+func TestReadHealth(t *testing.T) {
+	t.Parallel()
+
+	h := ReadHealth()
+
+	assert.Greater(t, h.Goroutines, 0)
+	assert.Greater(t, h.HeapBytes, uint64(0))
+	if runtime.GOOS == "linux" {
+		assert.Greater(t, h.OpenFiles, 0)
+	}
+}
+
+// TestOpenFiles tests openFiles.
+func TestOpenFiles(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	file := filepath.Join(dir, "file")
+	assert.NoError(t, os.WriteFile(file, nil, 0o600))
+
+	tests := []struct {
+		name     string
+		givePath string
+		want     int
+	}{
+		{
+			name:     "directory",
+			givePath: dir,
+			want:     0,
+		},
+		{
+			name:     "missing",
+			givePath: filepath.Join(dir, "missing"),
+			want:     -1,
+		},
+		{
+			name:     "not a directory",
+			givePath: file,
+			want:     -1,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, openFiles(tt.givePath))
+		})
+	}
+}
+
+// TestWatchdogCheck tests Watchdog.Check.
+func TestWatchdogCheck(t *testing.T) {
+	t.Parallel()
+
+	healthy := Health{Goroutines: 10, HeapBytes: 10, OpenFiles: 10}
+
+	tests := []struct {
+		name        string
+		giveEnabled []bool
+		giveHealth  Health
+		wantTrip    *Trip
+		wantEnabled []bool
+	}{
+		{
+			name:        "healthy",
+			giveEnabled: []bool{true, false},
+			giveHealth:  healthy,
+			wantTrip:    nil,
+			wantEnabled: []bool{true, false},
+		},
+		{
+			name:        "no enabled faults",
+			giveEnabled: []bool{false, false},
+			giveHealth:  Health{Goroutines: 1000},
+			wantTrip:    nil,
+			wantEnabled: []bool{false, false},
+		},
+		{
+			name:        "goroutines",
+			giveEnabled: []bool{true, false},
+			giveHealth:  Health{Goroutines: 1000, HeapBytes: 10, OpenFiles: 10},
+			wantTrip: &Trip{
+				Signal: "goroutines",
+				Value:  1000,
+				Limit:  100,
+				Health: Health{Goroutines: 1000, HeapBytes: 10, OpenFiles: 10},
+				Faults: []string{"fault0"},
+			},
+			wantEnabled: []bool{false, false},
+		},
+		{
+			name:        "heap bytes",
+			giveEnabled: []bool{true, true},
+			giveHealth:  Health{Goroutines: 10, HeapBytes: 1000, OpenFiles: 10},
+			wantTrip: &Trip{
+				Signal: "heap_bytes",
+				Value:  1000,
+				Limit:  100,
+				Health: Health{Goroutines: 10, HeapBytes: 1000, OpenFiles: 10},
+				Faults: []string{"fault0", "fault1"},
+			},
+			wantEnabled: []bool{false, false},
+		},
+		{
+			name:        "open files",
+			giveEnabled: []bool{false, true},
+			giveHealth:  Health{Goroutines: 10, HeapBytes: 10, OpenFiles: 1000},
+			wantTrip: &Trip{
+				Signal: "open_files",
+				Value:  1000,
+				Limit:  100,
+				Health: Health{Goroutines: 10, HeapBytes: 10, OpenFiles: 1000},
+				Faults: []string{"fault1"},
+			},
+			wantEnabled: []bool{false, false},
+		},
+		{
+			name:        "open files unknown",
+			giveEnabled: []bool{true, true},
+			giveHealth:  Health{Goroutines: 10, HeapBytes: 10, OpenFiles: -1},
+			wantTrip:    nil,
+			wantEnabled: []bool{true, true},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			faults := make([]*Fault, len(tt.giveEnabled))
+			for i, e := range tt.giveEnabled {
+				f, err := NewFault(newTestInjectorNoop(),
+					WithName(fmt.Sprintf("fault%d", i)),
+					WithEnabled(e),
+					WithParticipation(1.0),
+				)
+				assert.NoError(t, err)
+				faults[i] = f
+			}
+
+			var trips []Trip
+			w, err := NewWatchdog(faults,
+				WithMaxGoroutines(100),
+				WithMaxHeapBytes(100),
+				WithMaxOpenFiles(100),
+				WithTripFunc(func(trip Trip) {
+					trips = append(trips, trip)
+				}),
+			)
+			assert.NoError(t, err)
+			w.health = func() Health {
+				return tt.giveHealth
+			}
+
+			trip := w.Check()
+
+			assert.Equal(t, tt.wantTrip, trip)
+			if tt.wantTrip != nil {
+				assert.Equal(t, []Trip{*tt.wantTrip}, trips)
+			} else {
+				assert.Empty(t, trips)
+			}
+			for i, f := range faults {
+				assert.Equal(t, tt.wantEnabled[i], f.enabled.Load())
+			}
+		})
+	}
+}
+
+// TestWatchdogStartStop tests Watchdog.Start and Watchdog.Stop.
+func TestWatchdogStartStop(t *testing.T) {
+	t.Parallel()
+
+	clock := faulttest.NewClock(time.Time{})
+	f, err := NewFault(newTestInjectorNoop(), WithEnabled(true))
+	assert.NoError(t, err)
+
+	trips := make(chan Trip, 1)
+	w, err := NewWatchdog([]*Fault{f},
+		WithMaxGoroutines(100),
+		WithCheckInterval(time.Second),
+		WithClock(clock),
+		WithTripFunc(func(trip Trip) {
+			trips <- trip
+		}),
+	)
+	assert.NoError(t, err)
+	w.health = func() Health {
+		return Health{Goroutines: 1000}
+	}
+
+	// stopping before starting does nothing
+	w.Stop()
+
+	w.Start()
+	w.Start()
+
+	clock.BlockUntil(1)
+	assert.Equal(t, 1, clock.Waiters())
+	assert.True(t, f.enabled.Load())
+
+	clock.Advance(time.Second)
+
+	trip := <-trips
+	assert.Equal(t, "goroutines", trip.Signal)
+	assert.False(t, f.enabled.Load())
+
+	w.Stop()
+	w.Stop()
+
+	// the Watchdog can be started again after stopping
+	f.SetEnabled(true)
+	w.Start()
+	clock.BlockUntil(2)
+	clock.Advance(time.Second)
+	<-trips
+	w.Stop()
+	assert.False(t, f.enabled.Load())
+}